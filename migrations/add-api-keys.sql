@@ -0,0 +1,12 @@
+-- API keys (bearer authentication of the HTTP APIs, optionally scoped to a client)
+CREATE TABLE IF NOT EXISTS api_keys (
+    key_id VARCHAR(255) PRIMARY KEY DEFAULT gen_random_uuid()::text,
+    client_id VARCHAR(255) REFERENCES clients(client_id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    key_hash CHAR(64) NOT NULL UNIQUE,
+    key_prefix VARCHAR(32) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_client ON api_keys(client_id);
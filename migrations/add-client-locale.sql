@@ -0,0 +1,4 @@
+-- Client timezone and locale, used by the sender to render notification timestamps
+ALTER TABLE clients
+    ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS locale VARCHAR(35) NOT NULL DEFAULT '';
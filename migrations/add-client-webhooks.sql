@@ -0,0 +1,110 @@
+-- Client meta-webhooks and their pending deliveries, queued by triggers on rules and endpoints
+CREATE TABLE IF NOT EXISTS client_webhooks (
+    webhook_id VARCHAR(255) PRIMARY KEY DEFAULT gen_random_uuid()::text,
+    client_id VARCHAR(255) NOT NULL REFERENCES clients(client_id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    event_types TEXT[] NOT NULL DEFAULT '{}',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    delivery_id BIGSERIAL PRIMARY KEY,
+    webhook_id VARCHAR(255) NOT NULL REFERENCES client_webhooks(webhook_id) ON DELETE CASCADE,
+    event_type VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMP,
+    failed_at TIMESTAMP,
+    last_error TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_client_webhooks_client ON client_webhooks(client_id);
+-- The dispatcher scans pending deliveries that are due; the API lists a webhook's latest deliveries
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE delivered_at IS NULL AND failed_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries(webhook_id, delivery_id DESC);
+
+-- Queue an event for every enabled webhook of the client subscribed to its type
+-- (an empty event_types subscribes to all). Nothing is queued while the client itself
+-- is being deleted, since its webhooks are deleted with it.
+CREATE OR REPLACE FUNCTION record_platform_event(p_client_id VARCHAR, p_event_type VARCHAR, p_data JSONB) RETURNS VOID AS $$
+BEGIN
+    INSERT INTO webhook_deliveries (webhook_id, event_type, payload)
+    SELECT w.webhook_id, p_event_type, jsonb_build_object(
+        'event_type', p_event_type,
+        'client_id', p_client_id,
+        'occurred_at', to_char(CURRENT_TIMESTAMP AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS.MS"Z"'),
+        'data', p_data
+    )
+    FROM client_webhooks w
+    JOIN clients c ON c.client_id = w.client_id
+    WHERE w.client_id = p_client_id
+      AND w.enabled
+      AND (cardinality(w.event_types) = 0 OR p_event_type = ANY(w.event_types));
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION record_rule_event() RETURNS TRIGGER AS $$
+DECLARE
+    changed rules%ROWTYPE;
+    event_type VARCHAR(100);
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        changed := NEW;
+        event_type := 'rule.created';
+    ELSIF TG_OP = 'DELETE' THEN
+        changed := OLD;
+        event_type := 'rule.deleted';
+    ELSIF NEW.enabled IS DISTINCT FROM OLD.enabled THEN
+        changed := NEW;
+        event_type := CASE WHEN NEW.enabled THEN 'rule.enabled' ELSE 'rule.disabled' END;
+    ELSE
+        changed := NEW;
+        event_type := 'rule.updated';
+    END IF;
+
+    PERFORM record_platform_event(changed.client_id, event_type, jsonb_build_object(
+        'rule_id', changed.rule_id,
+        'severity', changed.severity,
+        'source', changed.source,
+        'name', changed.name,
+        'enabled', TG_OP <> 'DELETE' AND COALESCE(changed.enabled, FALSE),
+        'version', changed.version
+    ));
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION record_endpoint_event() RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.enabled IS NOT DISTINCT FROM OLD.enabled THEN
+        RETURN NULL;
+    END IF;
+
+    PERFORM record_platform_event(
+        (SELECT client_id FROM rules WHERE rule_id = NEW.rule_id),
+        CASE WHEN NEW.enabled THEN 'endpoint.enabled' ELSE 'endpoint.disabled' END,
+        jsonb_build_object(
+            'endpoint_id', NEW.endpoint_id,
+            'rule_id', NEW.rule_id,
+            'endpoint_type', NEW.type,
+            'enabled', COALESCE(NEW.enabled, FALSE)
+        )
+    );
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_rules_platform_events ON rules;
+CREATE TRIGGER trg_rules_platform_events
+    AFTER INSERT OR UPDATE OR DELETE ON rules
+    FOR EACH ROW EXECUTE FUNCTION record_rule_event();
+
+DROP TRIGGER IF EXISTS trg_endpoints_platform_events ON endpoints;
+CREATE TRIGGER trg_endpoints_platform_events
+    AFTER UPDATE OF enabled ON endpoints
+    FOR EACH ROW EXECUTE FUNCTION record_endpoint_event();
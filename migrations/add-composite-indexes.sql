@@ -22,6 +22,27 @@ ON notifications(client_id, created_at DESC);
 CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_notifications_status_created_at
 ON notifications(status, created_at DESC);
 
+-- Rule list filters (GET /api/v1/rules?enabled=&severity=&source=&name=&updated_since=)
+-- Optimizes: SELECT * FROM rules WHERE enabled = $1 ORDER BY created_at DESC (same for severity, source)
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_rules_enabled_created_at
+ON rules(enabled, created_at DESC);
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_rules_severity_created_at
+ON rules(severity, created_at DESC);
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_rules_source_created_at
+ON rules(source, created_at DESC);
+
+-- Optimizes: SELECT * FROM rules WHERE updated_at >= $1
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_rules_updated_at
+ON rules(updated_at);
+
+-- Trigram index for name substring search
+-- Optimizes: SELECT * FROM rules WHERE name ILIKE '%' || $1 || '%'
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_rules_name_trgm
+ON rules USING GIN (name gin_trgm_ops);
+
 -- Analyze tables to update statistics for query planner
 ANALYZE clients;
 ANALYZE rules;
@@ -0,0 +1,3 @@
+-- Endpoint metadata (webhook custom headers; secret header values are encrypted by rule-service)
+ALTER TABLE endpoints
+    ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}';
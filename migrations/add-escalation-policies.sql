@@ -0,0 +1,22 @@
+-- Escalation policies, referenced by rules.escalation_policy_id and run by the sender
+CREATE TABLE IF NOT EXISTS escalation_policies (
+    policy_id VARCHAR(255) PRIMARY KEY DEFAULT gen_random_uuid()::text,
+    client_id VARCHAR(255) NOT NULL REFERENCES clients(client_id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    steps JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(client_id, name)
+);
+
+ALTER TABLE rules
+    ADD COLUMN IF NOT EXISTS escalation_policy_id VARCHAR(255) REFERENCES escalation_policies(policy_id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_rules_escalation_policy ON rules(escalation_policy_id) WHERE escalation_policy_id IS NOT NULL;
+
+-- Escalation state of notifications
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS escalation_step INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS last_escalated_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_notifications_escalations ON notifications USING GIN (rule_ids) WHERE status IN ('SENT', 'PARTIALLY_SENT', 'FAILED');
@@ -0,0 +1,14 @@
+-- Generation schedules (recurring alert generation jobs of the alert-producer API)
+CREATE TABLE IF NOT EXISTS generation_schedules (
+    schedule_id VARCHAR(255) PRIMARY KEY,
+    owner VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    cron VARCHAR(255) NOT NULL,
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    config JSONB NOT NULL,
+    paused BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_run_at TIMESTAMP,
+    last_job_id VARCHAR(255)
+);
@@ -0,0 +1,19 @@
+-- Heartbeat monitors (dead-man's switch)
+-- A client pings a heartbeat periodically; if no ping arrives within interval_seconds,
+-- rule-service injects a synthetic alert into alerts.new.
+CREATE TABLE IF NOT EXISTS heartbeats (
+    heartbeat_id VARCHAR(255) PRIMARY KEY,
+    client_id VARCHAR(255) NOT NULL REFERENCES clients(client_id) ON DELETE CASCADE,
+    interval_seconds INTEGER NOT NULL CHECK (interval_seconds > 0),
+    severity VARCHAR(50) NOT NULL DEFAULT 'HIGH' CHECK (severity IN ('LOW', 'MEDIUM', 'HIGH', 'CRITICAL')),
+    source VARCHAR(255) NOT NULL DEFAULT 'heartbeat',
+    name VARCHAR(255) NOT NULL,
+    last_ping_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    alerted_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_heartbeats_client ON heartbeats(client_id);
+-- Partial index for the missed-ping scan: only heartbeats that have not alerted since their last ping
+CREATE INDEX IF NOT EXISTS idx_heartbeats_pending ON heartbeats(last_ping_at) WHERE alerted_at IS NULL;
@@ -0,0 +1,27 @@
+-- Acknowledgement and resolution times (MTTA/MTTR KPIs), stamped by a trigger on status change
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS acknowledged_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS resolved_at TIMESTAMP;
+
+UPDATE notifications SET acknowledged_at = updated_at WHERE status = 'ACKNOWLEDGED' AND acknowledged_at IS NULL;
+UPDATE notifications SET resolved_at = updated_at WHERE status = 'RESOLVED' AND resolved_at IS NULL;
+
+CREATE OR REPLACE FUNCTION stamp_notification_closed_at() RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.status = 'ACKNOWLEDGED' AND NEW.acknowledged_at IS NULL THEN
+        NEW.acknowledged_at := CURRENT_TIMESTAMP;
+    END IF;
+    IF NEW.status = 'RESOLVED' AND NEW.resolved_at IS NULL THEN
+        NEW.resolved_at := CURRENT_TIMESTAMP;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_notifications_closed_at ON notifications;
+CREATE TRIGGER trg_notifications_closed_at
+    BEFORE INSERT OR UPDATE OF status ON notifications
+    FOR EACH ROW EXECUTE FUNCTION stamp_notification_closed_at();
+
+CREATE INDEX IF NOT EXISTS idx_notifications_acknowledged_at ON notifications(acknowledged_at) WHERE acknowledged_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_notifications_resolved_at ON notifications(resolved_at) WHERE resolved_at IS NOT NULL;
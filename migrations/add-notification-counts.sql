@@ -0,0 +1,45 @@
+-- Per-client notification counts by creation hour, status and severity, kept by a trigger so
+-- summary badges (GET /api/v1/notifications/summary) are served without scanning notifications
+CREATE TABLE IF NOT EXISTS notification_counts (
+    client_id VARCHAR(255) NOT NULL,
+    bucket TIMESTAMP NOT NULL, -- hour the notifications were created in
+    status VARCHAR(50) NOT NULL,
+    severity VARCHAR(50) NOT NULL DEFAULT '',
+    count BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (client_id, bucket, status, severity)
+);
+
+CREATE OR REPLACE FUNCTION update_notification_counts() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND OLD.status = NEW.status AND OLD.severity IS NOT DISTINCT FROM NEW.severity THEN
+        RETURN NULL;
+    END IF;
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE notification_counts SET count = count - 1
+        WHERE client_id = OLD.client_id AND bucket = date_trunc('hour', OLD.created_at)
+            AND status = OLD.status AND severity = COALESCE(OLD.severity, '');
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        INSERT INTO notification_counts (client_id, bucket, status, severity, count)
+        VALUES (NEW.client_id, date_trunc('hour', NEW.created_at), NEW.status, COALESCE(NEW.severity, ''), 1)
+        ON CONFLICT (client_id, bucket, status, severity) DO UPDATE SET count = notification_counts.count + 1;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Rebuild the counts with notification writes blocked, so none is missed or counted twice
+BEGIN;
+LOCK TABLE notifications IN SHARE MODE;
+
+DROP TRIGGER IF EXISTS trg_notification_counts ON notifications;
+CREATE TRIGGER trg_notification_counts
+    AFTER INSERT OR DELETE OR UPDATE OF status, severity ON notifications
+    FOR EACH ROW EXECUTE FUNCTION update_notification_counts();
+
+TRUNCATE TABLE notification_counts;
+INSERT INTO notification_counts (client_id, bucket, status, severity, count)
+SELECT client_id, date_trunc('hour', created_at), status, COALESCE(severity, ''), COUNT(*)
+FROM notifications
+GROUP BY 1, 2, 3, 4;
+COMMIT;
@@ -0,0 +1,15 @@
+-- Notification deliveries: one row per endpoint a notification was delivered to (or failed
+-- to be), after the sender's retries. Written by sender; read by rule-service.
+CREATE TABLE IF NOT EXISTS notification_deliveries (
+    delivery_id BIGSERIAL PRIMARY KEY,
+    notification_id TEXT NOT NULL REFERENCES notifications(notification_id) ON DELETE CASCADE,
+    endpoint_id VARCHAR(255),
+    channel VARCHAR(50) NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('SENT', 'FAILED', 'SIMULATED')),
+    error TEXT,
+    latency_ms INTEGER NOT NULL DEFAULT 0,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_notification ON notification_deliveries(notification_id, delivery_id);
@@ -0,0 +1,4 @@
+-- Actor and note of manual notification transitions (acknowledged, resolved)
+ALTER TABLE notification_events
+    ADD COLUMN IF NOT EXISTS actor VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS note TEXT;
@@ -0,0 +1,3 @@
+-- Notification event time (alert event_ts), rendered by the sender in the client's timezone
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS event_ts TIMESTAMP;
@@ -0,0 +1,14 @@
+-- Notification journal: one row per state transition of a notification
+-- (created, enqueued, send attempt per endpoint, sent/failed, acked).
+-- Written by aggregator and sender; read by rule-service for support investigations.
+CREATE TABLE IF NOT EXISTS notification_events (
+    event_id BIGSERIAL PRIMARY KEY,
+    notification_id UUID NOT NULL REFERENCES notifications(notification_id) ON DELETE CASCADE,
+    service VARCHAR(50) NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    endpoint_type VARCHAR(50),
+    error TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_events_notification ON notification_events(notification_id, event_id);
@@ -0,0 +1,2 @@
+-- Alert history by fingerprint (related alerts in sender notifications)
+CREATE INDEX IF NOT EXISTS idx_notifications_fingerprint_history ON notifications(client_id, fingerprint, created_at DESC);
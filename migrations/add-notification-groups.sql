@@ -0,0 +1,18 @@
+-- Notification groups (digest mode): a grouped client's notifications per time window, and the
+-- digest notification the aggregator created for them. Grouped notifications carry group_id.
+CREATE TABLE IF NOT EXISTS notification_groups (
+    group_id TEXT PRIMARY KEY,
+    client_id VARCHAR(255) NOT NULL,
+    window_start TIMESTAMP NOT NULL,
+    window_end TIMESTAMP NOT NULL,
+    digest_notification_id TEXT REFERENCES notifications(notification_id) ON DELETE SET NULL,
+    published_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_groups_due ON notification_groups(window_end) WHERE published_at IS NULL;
+
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS group_id TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_notifications_group ON notifications(group_id) WHERE group_id IS NOT NULL;
@@ -0,0 +1,12 @@
+-- Delivery priority derived from severity (4 CRITICAL .. 1 LOW, 0 unknown), set by the aggregator
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS priority SMALLINT NOT NULL DEFAULT 0;
+
+UPDATE notifications SET priority = CASE UPPER(severity)
+    WHEN 'CRITICAL' THEN 4
+    WHEN 'HIGH' THEN 3
+    WHEN 'MEDIUM' THEN 2
+    WHEN 'LOW' THEN 1
+    ELSE 0
+END
+WHERE priority = 0;
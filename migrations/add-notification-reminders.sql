@@ -0,0 +1,6 @@
+-- Reminder state for delivered notifications that are not acknowledged (sender reminder scheduler)
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS reminder_count INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS last_reminded_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_notifications_reminders ON notifications(severity, created_at) WHERE status = 'SENT';
@@ -0,0 +1,9 @@
+-- Rule key of notifications (per-rule collapse policy), part of the idempotency key
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS rule_key VARCHAR(255) NOT NULL DEFAULT '';
+
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_client_alert_unique;
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_client_id_alert_id_key;
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_client_alert_rule_unique;
+ALTER TABLE notifications
+    ADD CONSTRAINT notifications_client_alert_rule_unique UNIQUE (client_id, alert_id, rule_key);
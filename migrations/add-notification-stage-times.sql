@@ -0,0 +1,5 @@
+-- Per-stage times of notifications (evaluated, aggregated, ready), for latency attribution
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS evaluated_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS aggregated_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS ready_at TIMESTAMP;
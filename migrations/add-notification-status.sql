@@ -0,0 +1,24 @@
+-- Notification status machine (RECEIVED, SENDING, SENT, PARTIALLY_SENT, FAILED, SIMULATED, SUPPRESSED, THROTTLED,
+-- ACKNOWLEDGED, RESOLVED): migrate existing rows and reject unknown statuses
+UPDATE notifications SET status = 'RECEIVED' WHERE status IS NULL OR status = 'PENDING';
+
+UPDATE notifications n
+SET status = 'PARTIALLY_SENT'
+WHERE n.status = 'SENT'
+    AND EXISTS (
+        SELECT 1 FROM notification_events e
+        WHERE e.notification_id = n.notification_id
+            AND e.event_type = 'send_attempt'
+            AND e.error IS NOT NULL
+    );
+
+UPDATE notifications SET status = 'FAILED'
+WHERE status NOT IN ('RECEIVED', 'SENDING', 'SENT', 'PARTIALLY_SENT', 'FAILED', 'SIMULATED', 'SUPPRESSED', 'THROTTLED', 'ACKNOWLEDGED', 'RESOLVED');
+
+ALTER TABLE notifications ALTER COLUMN status SET NOT NULL;
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_status_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_status_check
+    CHECK (status IN ('RECEIVED', 'SENDING', 'SENT', 'PARTIALLY_SENT', 'FAILED', 'SIMULATED', 'SUPPRESSED', 'THROTTLED', 'ACKNOWLEDGED', 'RESOLVED'));
+
+DROP INDEX IF EXISTS idx_notifications_reminders;
+CREATE INDEX IF NOT EXISTS idx_notifications_reminders ON notifications(severity, created_at) WHERE status IN ('SENT', 'PARTIALLY_SENT');
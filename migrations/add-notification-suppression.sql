@@ -0,0 +1,11 @@
+-- Suppression of repeated alerts into the client's open notification (aggregator)
+-- The fingerprint identifies "the same alert" (severity, source, name); a notification is open
+-- until it is ACKNOWLEDGED or RESOLVED. Repeats increment repeat_count instead of creating rows.
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS fingerprint TEXT GENERATED ALWAYS AS (md5(severity || '|' || source || '|' || name)) STORED,
+    ADD COLUMN IF NOT EXISTS repeat_count INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS last_notified_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_notifications_open_fingerprint
+    ON notifications(client_id, fingerprint, created_at DESC)
+    WHERE status NOT IN ('ACKNOWLEDGED', 'RESOLVED');
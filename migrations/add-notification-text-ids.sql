@@ -0,0 +1,22 @@
+-- Notification IDs as TEXT, so aggregator can supply time-ordered IDs (pkg/ids ULIDs)
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_name = 'notifications' AND column_name = 'notification_id' AND data_type = 'uuid'
+    ) THEN
+        ALTER TABLE notification_events DROP CONSTRAINT IF EXISTS notification_events_notification_id_fkey;
+
+        ALTER TABLE notifications
+            ALTER COLUMN notification_id DROP DEFAULT,
+            ALTER COLUMN notification_id TYPE TEXT USING notification_id::text,
+            ALTER COLUMN notification_id SET DEFAULT gen_random_uuid()::text;
+
+        ALTER TABLE notification_events
+            ALTER COLUMN notification_id TYPE TEXT USING notification_id::text;
+
+        ALTER TABLE notification_events
+            ADD CONSTRAINT notification_events_notification_id_fkey
+            FOREIGN KEY (notification_id) REFERENCES notifications(notification_id) ON DELETE CASCADE;
+    END IF;
+END $$;
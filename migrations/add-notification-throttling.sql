@@ -0,0 +1,10 @@
+-- Notification throttling: notifications whose endpoints were rate limited by the sender are
+-- THROTTLED until throttled_until, then flushed by the sender
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_status_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_status_check
+    CHECK (status IN ('RECEIVED', 'SENDING', 'SENT', 'PARTIALLY_SENT', 'FAILED', 'SIMULATED', 'SUPPRESSED', 'THROTTLED', 'ACKNOWLEDGED', 'RESOLVED'));
+
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS throttled_until TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_notifications_throttled ON notifications(throttled_until) WHERE status = 'THROTTLED';
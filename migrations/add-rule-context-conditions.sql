@@ -0,0 +1,9 @@
+-- Rule context conditions (alert context key/value pairs a rule requires);
+-- rules differing only in their conditions may coexist
+ALTER TABLE rules
+    ADD COLUMN IF NOT EXISTS context_conditions JSONB NOT NULL DEFAULT '[]';
+
+ALTER TABLE rules DROP CONSTRAINT IF EXISTS rules_client_criteria_unique;
+ALTER TABLE rules DROP CONSTRAINT IF EXISTS rules_client_id_severity_source_name_key;
+ALTER TABLE rules
+    ADD CONSTRAINT rules_client_criteria_unique UNIQUE (client_id, severity, source, name, context_conditions);
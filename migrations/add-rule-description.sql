@@ -0,0 +1,3 @@
+-- Rule description (embedded into notifications by the aggregator)
+ALTER TABLE rules
+    ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,4 @@
+-- Rule exclusion lists (sources and names a wildcard rule does not match)
+ALTER TABLE rules
+    ADD COLUMN IF NOT EXISTS exclude_sources TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS exclude_names TEXT[] NOT NULL DEFAULT '{}';
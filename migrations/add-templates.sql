@@ -0,0 +1,16 @@
+-- Notification templates, referenced by endpoints.template_id and rendered by the sender
+CREATE TABLE IF NOT EXISTS templates (
+    template_id VARCHAR(255) PRIMARY KEY DEFAULT gen_random_uuid()::text,
+    client_id VARCHAR(255) NOT NULL REFERENCES clients(client_id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    subject TEXT NOT NULL DEFAULT '',
+    body TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(client_id, name)
+);
+
+ALTER TABLE endpoints
+    ADD COLUMN IF NOT EXISTS template_id VARCHAR(255) REFERENCES templates(template_id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_endpoints_template ON endpoints(template_id) WHERE template_id IS NOT NULL;
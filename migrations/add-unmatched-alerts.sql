@@ -0,0 +1,18 @@
+-- Unmatched alert signatures (daily counts of alerts matching no rule) and the days reported
+CREATE TABLE IF NOT EXISTS unmatched_alert_signatures (
+    day DATE NOT NULL,
+    client_id VARCHAR(255) NOT NULL DEFAULT '',
+    severity VARCHAR(50) NOT NULL,
+    source VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    alert_count BIGINT NOT NULL DEFAULT 0,
+    first_seen_at TIMESTAMP NOT NULL,
+    last_seen_at TIMESTAMP NOT NULL,
+    sample_alert_id VARCHAR(255) NOT NULL DEFAULT '',
+    PRIMARY KEY (day, client_id, severity, source, name)
+);
+
+CREATE TABLE IF NOT EXISTS unmatched_reports (
+    day DATE PRIMARY KEY,
+    sent_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -1,5 +1,9 @@
--- Delete all notifications
-TRUNCATE TABLE notifications;
+-- Delete all notifications (CASCADE also clears their notification_events and notification_deliveries,
+-- and notification_groups through their digest foreign key)
+TRUNCATE TABLE notifications CASCADE;
+
+-- TRUNCATE fires no row triggers, so clear the per-client counts too
+TRUNCATE TABLE notification_counts;
 
 -- Refresh counts cache
 UPDATE table_counts SET row_count = 0, last_updated = NOW() WHERE table_name = 'notifications';
@@ -44,6 +44,122 @@ if [ "$TABLE_COUNT" = "4" ]; then
     echo "Setting up counts cache table..."
     PGPASSWORD="$DB_PASSWORD" psql -h "$DB_HOST" -p "$DB_PORT" -U "$DB_USER" -d "$DB_NAME" < /migrations/add-counts-cache.sql
 
+    # Create heartbeats table if missing (idempotent)
+    echo "Setting up heartbeats table..."
+    PGPASSWORD="$DB_PASSWORD" psql -h "$DB_HOST" -p "$DB_PORT" -U "$DB_USER" -d "$DB_NAME" < /migrations/add-heartbeats.sql
+
+    # Create notification journal table if missing (idempotent)
+    echo "Setting up notification events table..."
+    PGPASSWORD="$DB_PASSWORD" psql -h "$DB_HOST" -p "$DB_PORT" -U "$DB_USER" -d "$DB_NAME" < /migrations/add-notification-events.sql
+
+    # Add notification suppression columns if missing (idempotent)
+    echo "Setting up notification suppression..."
+    PGPASSWORD="$DB_PASSWORD" psql -h "$DB_HOST" -p "$DB_PORT" -U "$DB_USER" -d "$DB_NAME" < /migrations/add-notification-suppression.sql
+
+    # Add notification reminder columns if missing (idempotent)
+    echo "Setting up notification reminders..."
+    PGPASSWORD="$DB_PASSWORD" psql -h "$DB_HOST" -p "$DB_PORT" -U "$DB_USER" -d "$DB_NAME" < /migrations/add-notification-reminders.sql
+
+    # Add endpoint metadata column if missing (idempotent)
+    echo "Setting up endpoint metadata..."
+    PGPASSWORD="$DB_PASSWORD" psql -h "$DB_HOST" -p "$DB_PORT" -U "$DB_USER" -d "$DB_NAME" < /migrations/add-endpoint-metadata.sql
+
+    # Migrate notification statuses and add the status constraint (idempotent)
+    echo "Setting up notification status constraint..."
+    PGPASSWORD="$DB_PASSWORD" psql -h "$DB_HOST" -p "$DB_PORT" -U "$DB_USER" -d "$DB_NAME" < /migrations/add-notification-status.sql
+
+    # Add rule description column if missing (idempotent)
+    echo "Setting up rule descriptions..."
+    PGPASSWORD="$DB_PASSWORD" psql -h "$DB_HOST" -p "$DB_PORT" -U "$DB_USER" -d "$DB_NAME" < /migrations/add-rule-description.sql
+
+    # Add rule exclusion list columns if missing (idempotent)
+    echo "Setting up rule exclusions..."
+    PGPASSWORD="$DB_PASSWORD" psql -h "$DB_HOST" -p "$DB_PORT" -U "$DB_USER" -d "$DB_NAME" < /migrations/add-rule-exclusions.sql
+
+    # Add rule context conditions column if missing (idempotent)
+    echo "Setting up rule context conditions..."
+    PGPASSWORD="$DB_PASSWORD" psql -h "$DB_HOST" -p "$DB_PORT" -U "$DB_USER" -d "$DB_NAME" < /migrations/add-rule-context-conditions.sql
+
+    # Add notification acknowledged_at/resolved_at and their trigger if missing (idempotent)
+    echo "Setting up notification acknowledgement times..."
+    PGPASSWORD="$DB_PASSWORD" psql -h "$DB_HOST" -p "$DB_PORT" -U "$DB_USER" -d "$DB_NAME" < /migrations/add-notification-closed-times.sql
+
+    # Convert notification IDs to TEXT for time-ordered IDs if still UUID (idempotent)
+    echo "Setting up notification text IDs..."
+    PGPASSWORD="$DB_PASSWORD" psql -h "$DB_HOST" -p "$DB_PORT" -U "$DB_USER" -d "$DB_NAME" < /migrations/add-notification-text-ids.sql
+
+    # Create the endpoint change outbox and its trigger if missing (idempotent)
+    echo "Setting up endpoint change outbox..."
+    PGPASSWORD="$DB_PASSWORD" psql -h "$DB_HOST" -p "$DB_PORT" -U "$DB_USER" -d "$DB_NAME" < /migrations/add-endpoint-outbox.sql
+
+    # Add notification delivery priority if missing (idempotent)
+    echo "Setting up notification priority..."
+    PGPASSWORD="$DB_PASSWORD" psql -h "$DB_HOST" -p "$DB_PORT" -U "$DB_USER" -d "$DB_NAME" < /migrations/add-notification-priority.sql
+
+    # Create client meta-webhooks, their deliveries and the event triggers if missing (idempotent)
+    echo "Setting up client meta-webhooks..."
+    PGPASSWORD="$DB_PASSWORD" psql -h "$DB_HOST" -p "$DB_PORT" -U "$DB_USER" -d "$DB_NAME" < /migrations/add-client-webhooks.sql
+
+    # Create notification_deliveries table if missing (idempotent)
+    echo "Setting up notification deliveries..."
+    PGPASSWORD="$DB_PASSWORD" psql -h "$DB_HOST" -p "$DB_PORT" -U "$DB_USER" -d "$DB_NAME" < /migrations/add-notification-deliveries.sql
+
+    # Create notification_groups table and notifications.group_id if missing (idempotent)
+    echo "Setting up notification groups..."
+    PGPASSWORD="$DB_PASSWORD" psql -h "$DB_HOST" -p "$DB_PORT" -U "$DB_USER" -d "$DB_NAME" < /migrations/add-notification-groups.sql
+
+    # Allow the THROTTLED status and add notifications.throttled_until if missing (idempotent)
+    echo "Setting up notification throttling..."
+    PGPASSWORD="$DB_PASSWORD" psql -h "$DB_HOST" -p "$DB_PORT" -U "$DB_USER" -d "$DB_NAME" < /migrations/add-notification-throttling.sql
+
+    # Add notifications.event_ts if missing (idempotent)
+    echo "Setting up notification event time..."
+    PGPASSWORD="$DB_PASSWORD" psql -h "$DB_HOST" -p "$DB_PORT" -U "$DB_USER" -d "$DB_NAME" < /migrations/add-notification-event-ts.sql
+
+    # Add clients.timezone and clients.locale if missing (idempotent)
+    echo "Setting up client timezone and locale..."
+    PGPASSWORD="$DB_PASSWORD" psql -h "$DB_HOST" -p "$DB_PORT" -U "$DB_USER" -d "$DB_NAME" < /migrations/add-client-locale.sql
+
+    # Add the notification fingerprint history index if missing (idempotent)
+    echo "Setting up notification fingerprint history index..."
+    PGPASSWORD="$DB_PASSWORD" psql -h "$DB_HOST" -p "$DB_PORT" -U "$DB_USER" -d "$DB_NAME" < /migrations/add-notification-fingerprint-history-index.sql
+
+    # Add the templates table and endpoints.template_id if missing (idempotent)
+    echo "Setting up notification templates..."
+    PGPASSWORD="$DB_PASSWORD" psql -h "$DB_HOST" -p "$DB_PORT" -U "$DB_USER" -d "$DB_NAME" < /migrations/add-templates.sql
+
+    # Add notification_events.actor and note if missing (idempotent)
+    echo "Setting up notification event actors..."
+    PGPASSWORD="$DB_PASSWORD" psql -h "$DB_HOST" -p "$DB_PORT" -U "$DB_USER" -d "$DB_NAME" < /migrations/add-notification-event-actor.sql
+
+    # Add escalation policies, rules.escalation_policy_id and notification escalation state if missing (idempotent)
+    echo "Setting up escalation policies..."
+    PGPASSWORD="$DB_PASSWORD" psql -h "$DB_HOST" -p "$DB_PORT" -U "$DB_USER" -d "$DB_NAME" < /migrations/add-escalation-policies.sql
+
+    # Add notifications.rule_key and the (client_id, alert_id, rule_key) idempotency key if missing (idempotent)
+    echo "Setting up notification rule keys..."
+    PGPASSWORD="$DB_PASSWORD" psql -h "$DB_HOST" -p "$DB_PORT" -U "$DB_USER" -d "$DB_NAME" < /migrations/add-notification-rule-key.sql
+
+    # Add the api_keys table if missing (idempotent)
+    echo "Setting up API keys..."
+    PGPASSWORD="$DB_PASSWORD" psql -h "$DB_HOST" -p "$DB_PORT" -U "$DB_USER" -d "$DB_NAME" < /migrations/add-api-keys.sql
+
+    # Add notification evaluated_at, aggregated_at and ready_at if missing (idempotent)
+    echo "Setting up notification stage times..."
+    PGPASSWORD="$DB_PASSWORD" psql -h "$DB_HOST" -p "$DB_PORT" -U "$DB_USER" -d "$DB_NAME" < /migrations/add-notification-stage-times.sql
+
+    # Add the unmatched_alert_signatures and unmatched_reports tables if missing (idempotent)
+    echo "Setting up unmatched alert reports..."
+    PGPASSWORD="$DB_PASSWORD" psql -h "$DB_HOST" -p "$DB_PORT" -U "$DB_USER" -d "$DB_NAME" < /migrations/add-unmatched-alerts.sql
+
+    # Add the generation_schedules table if missing (idempotent)
+    echo "Setting up generation schedules..."
+    PGPASSWORD="$DB_PASSWORD" psql -h "$DB_HOST" -p "$DB_PORT" -U "$DB_USER" -d "$DB_NAME" < /migrations/add-generation-schedules.sql
+
+    # Create the notification_counts table and its trigger, and rebuild the counts (idempotent)
+    echo "Setting up notification counts..."
+    PGPASSWORD="$DB_PASSWORD" psql -h "$DB_HOST" -p "$DB_PORT" -U "$DB_USER" -d "$DB_NAME" < /migrations/add-notification-counts.sql
+
     # Cleanup notifications if cleanup script exists
     if [ -f /migrations/cleanup-notifications.sql ]; then
         echo "Cleaning up notifications..."
@@ -57,6 +173,10 @@ else
     PGPASSWORD="$DB_PASSWORD" psql -h "$DB_HOST" -p "$DB_PORT" -U "$DB_USER" -d "$DB_NAME" < /migrations/init-schema.sql
 fi
 
+# Seed the pipeline canary rule (idempotent, safe for new and existing databases)
+echo "Seeding pipeline canary rule..."
+PGPASSWORD="$DB_PASSWORD" psql -h "$DB_HOST" -p "$DB_PORT" -U "$DB_USER" -d "$DB_NAME" < /migrations/seed-canary.sql
+
 echo ""
 echo "================================"
 echo "Verifying schema..."
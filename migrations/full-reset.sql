@@ -1,7 +1,11 @@
 -- Full system reset: Delete notifications, reset counts
 
--- Delete all notifications
-TRUNCATE TABLE notifications;
+-- Delete all notifications (CASCADE also clears their notification_events and notification_deliveries,
+-- and notification_groups through their digest foreign key)
+TRUNCATE TABLE notifications CASCADE;
+
+-- TRUNCATE fires no row triggers, so clear the per-client counts too
+TRUNCATE TABLE notification_counts;
 
 -- Reset notification count in cache
 UPDATE table_counts SET row_count = 0, last_updated = NOW() WHERE table_name = 'notifications';
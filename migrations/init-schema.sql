@@ -2,7 +2,21 @@
 -- Run this once to set up all tables for the alerting platform
 
 -- Drop existing tables to recreate with correct schema
+DROP TABLE IF EXISTS generation_schedules CASCADE;
+DROP TABLE IF EXISTS unmatched_reports CASCADE;
+DROP TABLE IF EXISTS unmatched_alert_signatures CASCADE;
+DROP TABLE IF EXISTS notification_counts CASCADE;
+DROP TABLE IF EXISTS webhook_deliveries CASCADE;
+DROP TABLE IF EXISTS client_webhooks CASCADE;
+DROP TABLE IF EXISTS endpoint_outbox CASCADE;
+DROP TABLE IF EXISTS notification_groups CASCADE;
+DROP TABLE IF EXISTS notification_deliveries CASCADE;
+DROP TABLE IF EXISTS notification_events CASCADE;
+DROP TABLE IF EXISTS heartbeats CASCADE;
 DROP TABLE IF EXISTS endpoints CASCADE;
+DROP TABLE IF EXISTS templates CASCADE;
+DROP TABLE IF EXISTS escalation_policies CASCADE;
+DROP TABLE IF EXISTS api_keys CASCADE;
 DROP TABLE IF EXISTS notifications CASCADE;
 DROP TABLE IF EXISTS rules CASCADE;
 DROP TABLE IF EXISTS clients CASCADE;
@@ -11,10 +25,34 @@ DROP TABLE IF EXISTS clients CASCADE;
 CREATE TABLE clients (
     client_id VARCHAR(255) PRIMARY KEY,
     name VARCHAR(255) NOT NULL,
+    timezone VARCHAR(64) NOT NULL DEFAULT '',
+    locale VARCHAR(35) NOT NULL DEFAULT '',
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
+-- Create api_keys table (bearer API keys; a key with a client_id is scoped to that client)
+CREATE TABLE api_keys (
+    key_id VARCHAR(255) PRIMARY KEY DEFAULT gen_random_uuid()::text,
+    client_id VARCHAR(255) REFERENCES clients(client_id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    key_hash CHAR(64) NOT NULL UNIQUE,
+    key_prefix VARCHAR(32) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+-- Create escalation_policies table (steps run by the sender for unacknowledged notifications)
+CREATE TABLE escalation_policies (
+    policy_id VARCHAR(255) PRIMARY KEY DEFAULT gen_random_uuid()::text,
+    client_id VARCHAR(255) NOT NULL REFERENCES clients(client_id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    steps JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(client_id, name)
+);
+
 -- Create rules table
 CREATE TABLE rules (
     rule_id VARCHAR(255) PRIMARY KEY DEFAULT gen_random_uuid()::text,
@@ -22,11 +60,28 @@ CREATE TABLE rules (
     severity VARCHAR(50),
     source VARCHAR(255),
     name VARCHAR(255),
+    description TEXT NOT NULL DEFAULT '',
+    exclude_sources TEXT[] NOT NULL DEFAULT '{}',
+    exclude_names TEXT[] NOT NULL DEFAULT '{}',
+    context_conditions JSONB NOT NULL DEFAULT '[]',
+    escalation_policy_id VARCHAR(255) REFERENCES escalation_policies(policy_id) ON DELETE SET NULL,
     enabled BOOLEAN DEFAULT TRUE,
     version INTEGER DEFAULT 1,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    UNIQUE(client_id, severity, source, name)
+    CONSTRAINT rules_client_criteria_unique UNIQUE(client_id, severity, source, name, context_conditions)
+);
+
+-- Create templates table (notification templates, rendered by sender)
+CREATE TABLE templates (
+    template_id VARCHAR(255) PRIMARY KEY DEFAULT gen_random_uuid()::text,
+    client_id VARCHAR(255) NOT NULL REFERENCES clients(client_id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    subject TEXT NOT NULL DEFAULT '',
+    body TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(client_id, name)
 );
 
 -- Create endpoints table (linked to rules, not clients)
@@ -36,6 +91,8 @@ CREATE TABLE endpoints (
     type VARCHAR(50) NOT NULL,
     value TEXT NOT NULL,
     enabled BOOLEAN DEFAULT TRUE,
+    metadata JSONB NOT NULL DEFAULT '{}',
+    template_id VARCHAR(255) REFERENCES templates(template_id) ON DELETE SET NULL,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     UNIQUE(rule_id, type, value)
@@ -43,7 +100,7 @@ CREATE TABLE endpoints (
 
 -- Create notifications table
 CREATE TABLE notifications (
-    notification_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    notification_id TEXT PRIMARY KEY DEFAULT gen_random_uuid()::text,
     client_id VARCHAR(255) NOT NULL,
     alert_id VARCHAR(255) NOT NULL,
     severity VARCHAR(50),
@@ -51,10 +108,162 @@ CREATE TABLE notifications (
     name VARCHAR(255),
     context JSONB,
     rule_ids TEXT[],
-    status VARCHAR(50) DEFAULT 'RECEIVED',
+    status VARCHAR(50) NOT NULL DEFAULT 'RECEIVED' CHECK (status IN ('RECEIVED', 'SENDING', 'SENT', 'PARTIALLY_SENT', 'FAILED', 'SIMULATED', 'SUPPRESSED', 'THROTTLED', 'ACKNOWLEDGED', 'RESOLVED')),
+    fingerprint TEXT GENERATED ALWAYS AS (md5(severity || '|' || source || '|' || name)) STORED,
+    repeat_count INTEGER NOT NULL DEFAULT 0,
+    last_notified_at TIMESTAMP,
+    reminder_count INTEGER NOT NULL DEFAULT 0,
+    last_reminded_at TIMESTAMP,
+    escalation_step INTEGER NOT NULL DEFAULT 0,
+    last_escalated_at TIMESTAMP,
+    acknowledged_at TIMESTAMP,
+    resolved_at TIMESTAMP,
+    priority SMALLINT NOT NULL DEFAULT 0,
+    group_id TEXT,
+    throttled_until TIMESTAMP,
+    event_ts TIMESTAMP,
+    rule_key VARCHAR(255) NOT NULL DEFAULT '',
+    evaluated_at TIMESTAMP,
+    aggregated_at TIMESTAMP,
+    ready_at TIMESTAMP,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    UNIQUE(client_id, alert_id)
+    CONSTRAINT notifications_client_alert_rule_unique UNIQUE (client_id, alert_id, rule_key)
+);
+
+-- Create notification_counts table (per-client counts by creation hour, status and severity, kept by trg_notification_counts)
+CREATE TABLE notification_counts (
+    client_id VARCHAR(255) NOT NULL,
+    bucket TIMESTAMP NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    severity VARCHAR(50) NOT NULL DEFAULT '',
+    count BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (client_id, bucket, status, severity)
+);
+
+-- Create heartbeats table (dead-man's switch monitors)
+CREATE TABLE heartbeats (
+    heartbeat_id VARCHAR(255) PRIMARY KEY,
+    client_id VARCHAR(255) NOT NULL REFERENCES clients(client_id) ON DELETE CASCADE,
+    interval_seconds INTEGER NOT NULL CHECK (interval_seconds > 0),
+    severity VARCHAR(50) NOT NULL DEFAULT 'HIGH' CHECK (severity IN ('LOW', 'MEDIUM', 'HIGH', 'CRITICAL')),
+    source VARCHAR(255) NOT NULL DEFAULT 'heartbeat',
+    name VARCHAR(255) NOT NULL,
+    last_ping_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    alerted_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create notification_events table (per-notification journal, written by aggregator and sender)
+CREATE TABLE notification_events (
+    event_id BIGSERIAL PRIMARY KEY,
+    notification_id TEXT NOT NULL REFERENCES notifications(notification_id) ON DELETE CASCADE,
+    service VARCHAR(50) NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    endpoint_type VARCHAR(50),
+    error TEXT,
+    actor VARCHAR(255),
+    note TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create notification_deliveries table (per-endpoint delivery outcomes, written by sender)
+CREATE TABLE notification_deliveries (
+    delivery_id BIGSERIAL PRIMARY KEY,
+    notification_id TEXT NOT NULL REFERENCES notifications(notification_id) ON DELETE CASCADE,
+    endpoint_id VARCHAR(255),
+    channel VARCHAR(50) NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('SENT', 'FAILED', 'SIMULATED')),
+    error TEXT,
+    latency_ms INTEGER NOT NULL DEFAULT 0,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create notification_groups table (digest mode: a grouped client's notifications per time window)
+CREATE TABLE notification_groups (
+    group_id TEXT PRIMARY KEY,
+    client_id VARCHAR(255) NOT NULL,
+    window_start TIMESTAMP NOT NULL,
+    window_end TIMESTAMP NOT NULL,
+    digest_notification_id TEXT REFERENCES notifications(notification_id) ON DELETE SET NULL,
+    published_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create endpoint_outbox table (endpoint changes pending publication to endpoint.changed)
+CREATE TABLE endpoint_outbox (
+    event_id BIGSERIAL PRIMARY KEY,
+    endpoint_id VARCHAR(255) NOT NULL,
+    rule_id VARCHAR(255) NOT NULL,
+    client_id VARCHAR(255),
+    action VARCHAR(20) NOT NULL,
+    endpoint_type VARCHAR(50) NOT NULL,
+    enabled BOOLEAN NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    published_at TIMESTAMP
+);
+
+-- Create client_webhooks table (meta-webhooks receiving platform events about a client's resources)
+CREATE TABLE client_webhooks (
+    webhook_id VARCHAR(255) PRIMARY KEY DEFAULT gen_random_uuid()::text,
+    client_id VARCHAR(255) NOT NULL REFERENCES clients(client_id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    event_types TEXT[] NOT NULL DEFAULT '{}',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create webhook_deliveries table (platform events pending delivery to meta-webhooks)
+CREATE TABLE webhook_deliveries (
+    delivery_id BIGSERIAL PRIMARY KEY,
+    webhook_id VARCHAR(255) NOT NULL REFERENCES client_webhooks(webhook_id) ON DELETE CASCADE,
+    event_type VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMP,
+    failed_at TIMESTAMP,
+    last_error TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create unmatched_alert_signatures table (daily counts of alerts matching no rule, per client hint)
+CREATE TABLE unmatched_alert_signatures (
+    day DATE NOT NULL,
+    client_id VARCHAR(255) NOT NULL DEFAULT '',
+    severity VARCHAR(50) NOT NULL,
+    source VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    alert_count BIGINT NOT NULL DEFAULT 0,
+    first_seen_at TIMESTAMP NOT NULL,
+    last_seen_at TIMESTAMP NOT NULL,
+    sample_alert_id VARCHAR(255) NOT NULL DEFAULT '',
+    PRIMARY KEY (day, client_id, severity, source, name)
+);
+
+-- Create unmatched_reports table (days whose unmatched alert reports were sent)
+CREATE TABLE unmatched_reports (
+    day DATE PRIMARY KEY,
+    sent_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create generation_schedules table (recurring alert generation jobs of the alert-producer API)
+CREATE TABLE generation_schedules (
+    schedule_id VARCHAR(255) PRIMARY KEY,
+    owner VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    cron VARCHAR(255) NOT NULL,
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    config JSONB NOT NULL,
+    paused BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_run_at TIMESTAMP,
+    last_job_id VARCHAR(255)
 );
 
 -- Indexes for primary lookups
@@ -67,12 +276,200 @@ CREATE INDEX idx_notifications_client_status ON notifications(client_id, status)
 CREATE INDEX idx_notifications_status ON notifications(status);
 CREATE INDEX idx_notifications_created_at ON notifications(created_at DESC);
 CREATE INDEX idx_clients_created_at ON clients(created_at DESC);
+CREATE INDEX idx_heartbeats_client ON heartbeats(client_id);
+CREATE INDEX idx_api_keys_client ON api_keys(client_id);
+CREATE INDEX idx_endpoints_template ON endpoints(template_id) WHERE template_id IS NOT NULL;
+CREATE INDEX idx_rules_escalation_policy ON rules(escalation_policy_id) WHERE escalation_policy_id IS NOT NULL;
+CREATE INDEX idx_heartbeats_pending ON heartbeats(last_ping_at) WHERE alerted_at IS NULL;
+CREATE INDEX idx_notification_events_notification ON notification_events(notification_id, event_id);
+CREATE INDEX idx_notification_deliveries_notification ON notification_deliveries(notification_id, delivery_id);
+CREATE INDEX idx_notification_groups_due ON notification_groups(window_end) WHERE published_at IS NULL;
+CREATE INDEX idx_notifications_group ON notifications(group_id) WHERE group_id IS NOT NULL;
+CREATE INDEX idx_notifications_throttled ON notifications(throttled_until) WHERE status = 'THROTTLED';
+CREATE INDEX idx_endpoint_outbox_pending ON endpoint_outbox(event_id) WHERE published_at IS NULL;
+CREATE INDEX idx_endpoint_outbox_published_at ON endpoint_outbox(published_at) WHERE published_at IS NOT NULL;
+CREATE INDEX idx_client_webhooks_client ON client_webhooks(client_id);
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE delivered_at IS NULL AND failed_at IS NULL;
+CREATE INDEX idx_webhook_deliveries_webhook ON webhook_deliveries(webhook_id, delivery_id DESC);
 
 -- Composite indexes for filtering + ordering (pagination performance)
 CREATE INDEX idx_rules_client_created_at ON rules(client_id, created_at DESC);
 CREATE INDEX idx_endpoints_rule_created_at ON endpoints(rule_id, created_at DESC);
 CREATE INDEX idx_notifications_client_created_at ON notifications(client_id, created_at DESC);
 CREATE INDEX idx_notifications_status_created_at ON notifications(status, created_at DESC);
+CREATE INDEX idx_notifications_open_fingerprint ON notifications(client_id, fingerprint, created_at DESC) WHERE status NOT IN ('ACKNOWLEDGED', 'RESOLVED');
+CREATE INDEX idx_notifications_fingerprint_history ON notifications(client_id, fingerprint, created_at DESC);
+CREATE INDEX idx_notifications_reminders ON notifications(severity, created_at) WHERE status IN ('SENT', 'PARTIALLY_SENT');
+CREATE INDEX idx_notifications_escalations ON notifications USING GIN (rule_ids) WHERE status IN ('SENT', 'PARTIALLY_SENT', 'FAILED');
+CREATE INDEX idx_notifications_acknowledged_at ON notifications(acknowledged_at) WHERE acknowledged_at IS NOT NULL;
+CREATE INDEX idx_notifications_resolved_at ON notifications(resolved_at) WHERE resolved_at IS NOT NULL;
+
+-- Rule list filters (GET /api/v1/rules)
+CREATE INDEX idx_rules_enabled_created_at ON rules(enabled, created_at DESC);
+CREATE INDEX idx_rules_severity_created_at ON rules(severity, created_at DESC);
+CREATE INDEX idx_rules_source_created_at ON rules(source, created_at DESC);
+CREATE INDEX idx_rules_updated_at ON rules(updated_at);
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX idx_rules_name_trgm ON rules USING GIN (name gin_trgm_ops);
+
+-- Stamp acknowledged_at/resolved_at when a notification is acknowledged or resolved (MTTA/MTTR KPIs)
+CREATE OR REPLACE FUNCTION stamp_notification_closed_at() RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.status = 'ACKNOWLEDGED' AND NEW.acknowledged_at IS NULL THEN
+        NEW.acknowledged_at := CURRENT_TIMESTAMP;
+    END IF;
+    IF NEW.status = 'RESOLVED' AND NEW.resolved_at IS NULL THEN
+        NEW.resolved_at := CURRENT_TIMESTAMP;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_notifications_closed_at
+    BEFORE INSERT OR UPDATE OF status ON notifications
+    FOR EACH ROW EXECUTE FUNCTION stamp_notification_closed_at();
+
+-- Keep notification_counts in step with notifications (GET /api/v1/notifications/summary)
+CREATE OR REPLACE FUNCTION update_notification_counts() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND OLD.status = NEW.status AND OLD.severity IS NOT DISTINCT FROM NEW.severity THEN
+        RETURN NULL;
+    END IF;
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE notification_counts SET count = count - 1
+        WHERE client_id = OLD.client_id AND bucket = date_trunc('hour', OLD.created_at)
+            AND status = OLD.status AND severity = COALESCE(OLD.severity, '');
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        INSERT INTO notification_counts (client_id, bucket, status, severity, count)
+        VALUES (NEW.client_id, date_trunc('hour', NEW.created_at), NEW.status, COALESCE(NEW.severity, ''), 1)
+        ON CONFLICT (client_id, bucket, status, severity) DO UPDATE SET count = notification_counts.count + 1;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_notification_counts
+    AFTER INSERT OR DELETE OR UPDATE OF status, severity ON notifications
+    FOR EACH ROW EXECUTE FUNCTION update_notification_counts();
+
+-- Record every endpoint change in the outbox (endpoint.changed events)
+CREATE OR REPLACE FUNCTION record_endpoint_change() RETURNS TRIGGER AS $$
+DECLARE
+    changed endpoints%ROWTYPE;
+    change_action VARCHAR(20);
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        changed := NEW;
+        change_action := 'CREATED';
+    ELSIF TG_OP = 'DELETE' THEN
+        changed := OLD;
+        change_action := 'DELETED';
+    ELSIF NEW.type = OLD.type AND NEW.value = OLD.value AND NEW.metadata = OLD.metadata
+        AND NEW.enabled IS DISTINCT FROM OLD.enabled THEN
+        changed := NEW;
+        change_action := CASE WHEN NEW.enabled THEN 'ENABLED' ELSE 'DISABLED' END;
+    ELSE
+        changed := NEW;
+        change_action := 'UPDATED';
+    END IF;
+
+    -- The rule is gone when the endpoint is deleted along with it
+    INSERT INTO endpoint_outbox (endpoint_id, rule_id, client_id, action, endpoint_type, enabled)
+    VALUES (
+        changed.endpoint_id,
+        changed.rule_id,
+        (SELECT client_id FROM rules WHERE rule_id = changed.rule_id),
+        change_action,
+        changed.type,
+        TG_OP <> 'DELETE' AND COALESCE(changed.enabled, FALSE)
+    );
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_endpoints_outbox
+    AFTER INSERT OR UPDATE OR DELETE ON endpoints
+    FOR EACH ROW EXECUTE FUNCTION record_endpoint_change();
+
+-- Queue platform events for client meta-webhooks: one delivery per enabled webhook of the
+-- client subscribed to the event type (an empty event_types subscribes to all). Nothing is
+-- queued while the client itself is being deleted, since its webhooks are deleted with it.
+CREATE OR REPLACE FUNCTION record_platform_event(p_client_id VARCHAR, p_event_type VARCHAR, p_data JSONB) RETURNS VOID AS $$
+BEGIN
+    INSERT INTO webhook_deliveries (webhook_id, event_type, payload)
+    SELECT w.webhook_id, p_event_type, jsonb_build_object(
+        'event_type', p_event_type,
+        'client_id', p_client_id,
+        'occurred_at', to_char(CURRENT_TIMESTAMP AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS.MS"Z"'),
+        'data', p_data
+    )
+    FROM client_webhooks w
+    JOIN clients c ON c.client_id = w.client_id
+    WHERE w.client_id = p_client_id
+      AND w.enabled
+      AND (cardinality(w.event_types) = 0 OR p_event_type = ANY(w.event_types));
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION record_rule_event() RETURNS TRIGGER AS $$
+DECLARE
+    changed rules%ROWTYPE;
+    event_type VARCHAR(100);
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        changed := NEW;
+        event_type := 'rule.created';
+    ELSIF TG_OP = 'DELETE' THEN
+        changed := OLD;
+        event_type := 'rule.deleted';
+    ELSIF NEW.enabled IS DISTINCT FROM OLD.enabled THEN
+        changed := NEW;
+        event_type := CASE WHEN NEW.enabled THEN 'rule.enabled' ELSE 'rule.disabled' END;
+    ELSE
+        changed := NEW;
+        event_type := 'rule.updated';
+    END IF;
+
+    PERFORM record_platform_event(changed.client_id, event_type, jsonb_build_object(
+        'rule_id', changed.rule_id,
+        'severity', changed.severity,
+        'source', changed.source,
+        'name', changed.name,
+        'enabled', TG_OP <> 'DELETE' AND COALESCE(changed.enabled, FALSE),
+        'version', changed.version
+    ));
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION record_endpoint_event() RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.enabled IS NOT DISTINCT FROM OLD.enabled THEN
+        RETURN NULL;
+    END IF;
+
+    PERFORM record_platform_event(
+        (SELECT client_id FROM rules WHERE rule_id = NEW.rule_id),
+        CASE WHEN NEW.enabled THEN 'endpoint.enabled' ELSE 'endpoint.disabled' END,
+        jsonb_build_object(
+            'endpoint_id', NEW.endpoint_id,
+            'rule_id', NEW.rule_id,
+            'endpoint_type', NEW.type,
+            'enabled', COALESCE(NEW.enabled, FALSE)
+        )
+    );
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_rules_platform_events
+    AFTER INSERT OR UPDATE OR DELETE ON rules
+    FOR EACH ROW EXECUTE FUNCTION record_rule_event();
+
+CREATE TRIGGER trg_endpoints_platform_events
+    AFTER UPDATE OF enabled ON endpoints
+    FOR EACH ROW EXECUTE FUNCTION record_endpoint_event();
 
 -- Verify tables were created
 SELECT tablename FROM pg_tables WHERE schemaname = 'public' ORDER BY tablename;
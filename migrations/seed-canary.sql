@@ -0,0 +1,15 @@
+-- Pipeline canary (idempotent)
+-- The canary rule matches the synthetic alerts emitted by alert-producer-api and routes them
+-- to a "null" endpoint, so each canary flows through evaluator, aggregator, and sender without
+-- notifying anyone. Deleting or disabling it makes the canary report the pipeline as failing.
+INSERT INTO clients (client_id, name)
+VALUES ('canary', 'Pipeline Canary')
+ON CONFLICT (client_id) DO NOTHING;
+
+INSERT INTO rules (rule_id, client_id, severity, source, name, enabled, version)
+VALUES ('canary', 'canary', 'LOW', 'canary', 'pipeline-canary', TRUE, 1)
+ON CONFLICT DO NOTHING;
+
+INSERT INTO endpoints (endpoint_id, rule_id, type, value, enabled)
+VALUES ('canary', 'canary', 'null', 'canary', TRUE)
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,3 @@
+module github.com/afikmenashe/alerting-platform/pkg/ids
+
+go 1.23
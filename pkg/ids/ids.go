@@ -0,0 +1,132 @@
+// Package ids generates the platform's IDs (alert_id, notification_id, job IDs) as ULIDs:
+// 26-character, Crockford base32 strings made of a 48-bit millisecond timestamp followed by
+// 80 random bits. IDs sort lexicographically in creation order, so they paginate by ID and
+// tell at a glance when something was created.
+package ids
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Length is the length of an ID.
+const Length = 26
+
+// encoding is Crockford's base32 alphabet (no I, L, O or U).
+const encoding = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// maxTime is the largest timestamp an ID can hold (year 10889).
+const maxTime = 1<<48 - 1
+
+// ErrInvalid is returned by Time for strings that are not IDs.
+var ErrInvalid = errors.New("invalid id")
+
+// generator keeps IDs created in the same millisecond in order, by incrementing the random
+// part of the previous ID instead of drawing a new one.
+var generator struct {
+	mu     sync.Mutex
+	ms     uint64
+	randHi uint16 // top 16 of the 80 random bits
+	randLo uint64 // low 64 of the 80 random bits
+}
+
+// New returns a new ID for the current time. IDs returned by one process are strictly
+// increasing. It panics if the system's secure random source fails, like uuid.New.
+func New() string {
+	return NewAt(time.Now())
+}
+
+// NewAt returns a new ID for t, such as the time of the event the ID is for. It panics if t
+// is before 1970 or after year 10889.
+func NewAt(t time.Time) string {
+	ms := uint64(t.UnixMilli())
+	if ms > maxTime {
+		panic(fmt.Sprintf("ids: time %v does not fit in an id", t))
+	}
+
+	generator.mu.Lock()
+	defer generator.mu.Unlock()
+
+	if ms <= generator.ms {
+		// Same millisecond (or the clock went back): keep the previous time and increment,
+		// moving to the next millisecond once the random bits are exhausted
+		ms = generator.ms
+		generator.randLo++
+		if generator.randLo == 0 {
+			generator.randHi++
+			if generator.randHi == 0 {
+				ms++
+			}
+		}
+	} else {
+		var b [10]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			panic(fmt.Sprintf("ids: failed to read random bytes: %v", err))
+		}
+		generator.randHi = binary.BigEndian.Uint16(b[:2])
+		generator.randLo = binary.BigEndian.Uint64(b[2:])
+	}
+	generator.ms = ms
+
+	return encode(ms<<16|uint64(generator.randHi), generator.randLo)
+}
+
+// Time returns the creation time encoded in id, to millisecond precision.
+func Time(id string) (time.Time, error) {
+	hi, _, err := decode(id)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(int64(hi >> 16)).UTC(), nil
+}
+
+// IsValid reports whether s is a well-formed ID.
+func IsValid(s string) bool {
+	_, _, err := decode(s)
+	return err == nil
+}
+
+// encode writes the 128-bit value hi:lo as 26 base32 characters, most significant first.
+// The first character only holds the top 3 bits.
+func encode(hi, lo uint64) string {
+	var dst [Length]byte
+	for i := Length - 1; i >= 0; i-- {
+		dst[i] = encoding[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(dst[:])
+}
+
+// decode parses an ID into its 128-bit value. Lower case letters are accepted.
+func decode(s string) (hi, lo uint64, err error) {
+	if len(s) != Length {
+		return 0, 0, fmt.Errorf("%w: %q has length %d, want %d", ErrInvalid, s, len(s), Length)
+	}
+	for i := 0; i < Length; i++ {
+		v := decodeChar(s[i])
+		if v < 0 || (i == 0 && v > 7) {
+			return 0, 0, fmt.Errorf("%w: %q has invalid character %q", ErrInvalid, s, s[i])
+		}
+		hi = hi<<5 | lo>>59
+		lo = lo<<5 | uint64(v)
+	}
+	return hi, lo, nil
+}
+
+// decodeChar returns the value of one base32 character, or -1.
+func decodeChar(c byte) int {
+	if c >= 'a' && c <= 'z' {
+		c -= 'a' - 'A'
+	}
+	for v := 0; v < len(encoding); v++ {
+		if encoding[v] == c {
+			return v
+		}
+	}
+	return -1
+}
@@ -0,0 +1,204 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	// DLQSuffix is appended to a topic name to form its dead-letter topic.
+	DLQSuffix = ".dlq"
+	// DefaultMaxFailures is how many times a message may fail before it is dead-lettered.
+	DefaultMaxFailures = 3
+	// maxTrackedFailures bounds the failure counts kept in memory; the oldest are dropped first.
+	maxTrackedFailures = 10000
+)
+
+// Headers added to dead-lettered messages, next to the original key, value and headers.
+const (
+	HeaderDLQError           = "dlq-error"
+	HeaderDLQRetryCount      = "dlq-retry-count"
+	HeaderDLQSourceTopic     = "dlq-source-topic"
+	HeaderDLQSourcePartition = "dlq-source-partition"
+	HeaderDLQSourceOffset    = "dlq-source-offset"
+	HeaderDLQFailedAt        = "dlq-failed-at"
+)
+
+// DLQTopic returns the dead-letter topic for a topic.
+func DLQTopic(topic string) string {
+	return topic + DLQSuffix
+}
+
+// DeadLetterQueue publishes messages that cannot be processed to a dead-letter topic,
+// so consumers can commit past them instead of skipping them silently or retrying forever.
+//
+// Permanent failures (a message that cannot be decoded or is invalid) are dead-lettered
+// at once with Reject. Other failures are counted per partition and offset with Fail, and
+// the message is dead-lettered on its maxFailures-th failure. Counts are kept in memory,
+// so they restart from zero when the consumer restarts.
+//
+// A nil *DeadLetterQueue is valid and dead-letters nothing.
+type DeadLetterQueue struct {
+	writer      *kafka.Writer
+	topic       string
+	maxFailures int
+
+	mu       sync.Mutex
+	failures map[string]int
+	order    []string // failure keys, oldest first, for eviction
+}
+
+// NewDeadLetterQueue creates a dead-letter queue publishing to topic.
+// A maxFailures of 0 or less uses DefaultMaxFailures.
+func NewDeadLetterQueue(brokers, topic string, maxFailures int) (*DeadLetterQueue, error) {
+	if err := ValidateProducerParams(brokers, topic); err != nil {
+		return nil, err
+	}
+	if maxFailures <= 0 {
+		maxFailures = DefaultMaxFailures
+	}
+
+	brokerList := ParseBrokers(brokers)
+	slog.Info("Initializing Kafka dead-letter queue",
+		"brokers", brokerList,
+		"topic", topic,
+		"max_failures", maxFailures,
+	)
+
+	writer := &kafka.Writer{
+		Addr:         kafka.TCP(brokerList...),
+		Transport:    Transport(),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{}, // Keep the original message key's partitioning
+		WriteTimeout: WriteTimeout,
+		RequiredAcks: kafka.RequireOne,
+		Async:        false,
+		BatchSize:    1,
+	}
+
+	return &DeadLetterQueue{
+		writer:      writer,
+		topic:       topic,
+		maxFailures: maxFailures,
+		failures:    make(map[string]int),
+	}, nil
+}
+
+// Topic returns the dead-letter topic, or "" if q is nil.
+func (q *DeadLetterQueue) Topic() string {
+	if q == nil {
+		return ""
+	}
+	return q.topic
+}
+
+// Reject dead-letters a message that will never succeed.
+// Returns true if it was published, in which case the caller should commit it.
+func (q *DeadLetterQueue) Reject(ctx context.Context, msg *kafka.Message, reason error) (bool, error) {
+	if q == nil || msg == nil {
+		return false, nil
+	}
+	key := failureKey(msg)
+	q.mu.Lock()
+	count := q.failures[key] + 1
+	q.mu.Unlock()
+	return q.publish(ctx, msg, reason, count)
+}
+
+// Fail records a failed attempt to process a message and dead-letters it once it has
+// failed maxFailures times. Returns true if it was published, in which case the caller
+// should commit it; otherwise the caller handles the failure as before.
+func (q *DeadLetterQueue) Fail(ctx context.Context, msg *kafka.Message, reason error) (bool, error) {
+	if q == nil || msg == nil {
+		return false, nil
+	}
+	key := failureKey(msg)
+	q.mu.Lock()
+	count := q.failures[key] + 1
+	if count < q.maxFailures {
+		if count == 1 {
+			q.track(key)
+		}
+		q.failures[key] = count
+		q.mu.Unlock()
+		return false, nil
+	}
+	q.mu.Unlock()
+	return q.publish(ctx, msg, reason, count)
+}
+
+// track starts counting failures for key, evicting the oldest counts when full.
+// Must be called with q.mu held.
+func (q *DeadLetterQueue) track(key string) {
+	for len(q.order) >= maxTrackedFailures {
+		delete(q.failures, q.order[0])
+		q.order = q.order[1:]
+	}
+	q.order = append(q.order, key)
+}
+
+// publish writes the message to the dead-letter topic and forgets its failure count.
+func (q *DeadLetterQueue) publish(ctx context.Context, msg *kafka.Message, reason error, count int) (bool, error) {
+	dead := DeadLetterMessage(msg, reason, count, time.Now())
+	if err := q.writer.WriteMessages(ctx, dead); err != nil {
+		return false, fmt.Errorf("failed to publish to dead-letter topic %s: %w", q.topic, err)
+	}
+
+	key := failureKey(msg)
+	q.mu.Lock()
+	delete(q.failures, key)
+	q.mu.Unlock()
+
+	slog.Warn("Message dead-lettered",
+		"source_topic", msg.Topic,
+		"partition", msg.Partition,
+		"offset", msg.Offset,
+		"dlq_topic", q.topic,
+		"retry_count", count,
+		"reason", reason,
+	)
+	return true, nil
+}
+
+// Close closes the dead-letter writer.
+func (q *DeadLetterQueue) Close() error {
+	if q == nil {
+		return nil
+	}
+	return q.writer.Close()
+}
+
+// DeadLetterMessage builds the dead-letter copy of msg: same key, value and headers, plus
+// headers recording the failure reason, the retry count and where the message came from.
+func DeadLetterMessage(msg *kafka.Message, reason error, retryCount int, failedAt time.Time) kafka.Message {
+	errText := "unknown"
+	if reason != nil {
+		errText = reason.Error()
+	}
+	headers := make([]kafka.Header, 0, len(msg.Headers)+6)
+	headers = append(headers, msg.Headers...)
+	headers = append(headers,
+		kafka.Header{Key: HeaderDLQError, Value: []byte(errText)},
+		kafka.Header{Key: HeaderDLQRetryCount, Value: []byte(strconv.Itoa(retryCount))},
+		kafka.Header{Key: HeaderDLQSourceTopic, Value: []byte(msg.Topic)},
+		kafka.Header{Key: HeaderDLQSourcePartition, Value: []byte(strconv.Itoa(msg.Partition))},
+		kafka.Header{Key: HeaderDLQSourceOffset, Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+		kafka.Header{Key: HeaderDLQFailedAt, Value: []byte(failedAt.UTC().Format(time.RFC3339Nano))},
+	)
+	return kafka.Message{
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: headers,
+	}
+}
+
+// failureKey identifies a message by its topic, partition and offset.
+func failureKey(msg *kafka.Message) string {
+	return msg.Topic + "/" + strconv.Itoa(msg.Partition) + "/" + strconv.FormatInt(msg.Offset, 10)
+}
@@ -0,0 +1,39 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Ping checks that the cluster at addr answers a metadata request and that each of topics
+// exists. It is the Kafka check of the services' health and readiness probes.
+func Ping(ctx context.Context, addr net.Addr, topics ...string) error {
+	client := &kafka.Client{
+		Addr:      addr,
+		Timeout:   ReadTimeout,
+		Transport: Transport(),
+	}
+	meta, err := client.Metadata(ctx, &kafka.MetadataRequest{Topics: topics})
+	if err != nil {
+		return fmt.Errorf("failed to read metadata: %w", err)
+	}
+	for _, topic := range topics {
+		found := false
+		for _, t := range meta.Topics {
+			if t.Name != topic {
+				continue
+			}
+			if t.Error != nil {
+				return fmt.Errorf("topic %s: %w", topic, t.Error)
+			}
+			found = true
+		}
+		if !found {
+			return fmt.Errorf("topic %s not found", topic)
+		}
+	}
+	return nil
+}
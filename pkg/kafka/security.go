@@ -0,0 +1,228 @@
+package kafka
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+// SASL mechanisms supported by Security.
+const (
+	SASLPlain       = "PLAIN"
+	SASLScramSHA256 = "SCRAM-SHA-256"
+	SASLScramSHA512 = "SCRAM-SHA-512"
+)
+
+// dialTimeout matches kafka-go's default dialer timeout.
+const dialTimeout = 10 * time.Second
+
+// Security holds the TLS and SASL settings for connecting to secured brokers.
+// The zero value connects in plaintext without authentication.
+type Security struct {
+	TLS                   bool   // Enable TLS (implied by TLSCA or TLSCert)
+	TLSCA                 string // PEM CA bundle used to verify brokers (empty = system roots)
+	TLSCert               string // PEM client certificate for mutual TLS
+	TLSKey                string // PEM client private key for mutual TLS
+	TLSInsecureSkipVerify bool   // Skip broker certificate verification (testing only)
+	SASLMechanism         string // PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512 (empty = no SASL)
+	SASLUsername          string
+	SASLPassword          string
+}
+
+// RegisterSecurityFlags registers the -kafka-tls* and -kafka-sasl-* flags on fs, defaulting
+// to the KAFKA_TLS* and KAFKA_SASL_* environment variables, and returns the Security they fill.
+func RegisterSecurityFlags(fs *flag.FlagSet) *Security {
+	sec := &Security{}
+	fs.BoolVar(&sec.TLS, "kafka-tls", envOrDefault("KAFKA_TLS", "false") == "true", "Connect to Kafka over TLS")
+	fs.StringVar(&sec.TLSCA, "kafka-tls-ca", envOrDefault("KAFKA_TLS_CA", ""), "PEM CA bundle for verifying Kafka brokers (empty = system roots, implies -kafka-tls)")
+	fs.StringVar(&sec.TLSCert, "kafka-tls-cert", envOrDefault("KAFKA_TLS_CERT", ""), "PEM client certificate for Kafka mutual TLS (implies -kafka-tls)")
+	fs.StringVar(&sec.TLSKey, "kafka-tls-key", envOrDefault("KAFKA_TLS_KEY", ""), "PEM client private key for Kafka mutual TLS")
+	fs.BoolVar(&sec.TLSInsecureSkipVerify, "kafka-tls-insecure-skip-verify", envOrDefault("KAFKA_TLS_INSECURE_SKIP_VERIFY", "false") == "true", "Skip Kafka broker certificate verification (testing only)")
+	fs.StringVar(&sec.SASLMechanism, "kafka-sasl-mechanism", envOrDefault("KAFKA_SASL_MECHANISM", ""), "Kafka SASL mechanism: PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512 (empty = no SASL)")
+	fs.StringVar(&sec.SASLUsername, "kafka-sasl-username", envOrDefault("KAFKA_SASL_USERNAME", ""), "Kafka SASL username")
+	fs.StringVar(&sec.SASLPassword, "kafka-sasl-password", envOrDefault("KAFKA_SASL_PASSWORD", ""), "Kafka SASL password")
+	return sec
+}
+
+// TLSEnabled reports whether connections use TLS.
+func (s *Security) TLSEnabled() bool {
+	return s.TLS || s.TLSCA != "" || s.TLSCert != ""
+}
+
+// Validate checks that the settings are complete and consistent.
+func (s *Security) Validate() error {
+	if (s.TLSCert == "") != (s.TLSKey == "") {
+		return fmt.Errorf("kafka-tls-cert and kafka-tls-key must be set together")
+	}
+	switch strings.ToUpper(s.SASLMechanism) {
+	case "":
+		if s.SASLUsername != "" || s.SASLPassword != "" {
+			return fmt.Errorf("kafka-sasl-mechanism is required when SASL credentials are set")
+		}
+		return nil
+	case SASLPlain, SASLScramSHA256, SASLScramSHA512:
+	default:
+		return fmt.Errorf("kafka-sasl-mechanism must be one of %s, %s or %s, got %q", SASLPlain, SASLScramSHA256, SASLScramSHA512, s.SASLMechanism)
+	}
+	if s.SASLUsername == "" || s.SASLPassword == "" {
+		return fmt.Errorf("kafka-sasl-username and kafka-sasl-password are required with kafka-sasl-mechanism")
+	}
+	return nil
+}
+
+// LogFields returns the settings as slog key-value pairs, without the password.
+func (s *Security) LogFields() []any {
+	return []any{
+		"kafka_tls", s.TLSEnabled(),
+		"kafka_tls_ca", s.TLSCA,
+		"kafka_tls_client_cert", s.TLSCert != "",
+		"kafka_sasl_mechanism", s.SASLMechanism,
+		"kafka_sasl_username", s.SASLUsername,
+	}
+}
+
+// tlsConfig builds the TLS configuration, nil when TLS is disabled.
+func (s *Security) tlsConfig() (*tls.Config, error) {
+	if !s.TLSEnabled() {
+		return nil, nil
+	}
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: s.TLSInsecureSkipVerify,
+	}
+	if s.TLSCA != "" {
+		pem, err := os.ReadFile(s.TLSCA)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Kafka CA bundle: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in Kafka CA bundle %s", s.TLSCA)
+		}
+		cfg.RootCAs = pool
+	}
+	if s.TLSCert != "" {
+		cert, err := tls.LoadX509KeyPair(s.TLSCert, s.TLSKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load Kafka client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// mechanism builds the SASL mechanism, nil when SASL is disabled.
+func (s *Security) mechanism() (sasl.Mechanism, error) {
+	switch strings.ToUpper(s.SASLMechanism) {
+	case "":
+		return nil, nil
+	case SASLPlain:
+		return plain.Mechanism{Username: s.SASLUsername, Password: s.SASLPassword}, nil
+	case SASLScramSHA256:
+		return scram.Mechanism(scram.SHA256, s.SASLUsername, s.SASLPassword)
+	case SASLScramSHA512:
+		return scram.Mechanism(scram.SHA512, s.SASLUsername, s.SASLPassword)
+	}
+	return nil, fmt.Errorf("unsupported SASL mechanism %q", s.SASLMechanism)
+}
+
+// connection holds the dialer and transport built by Configure.
+var connection struct {
+	mu        sync.RWMutex
+	dialer    *kafka.Dialer
+	transport *kafka.Transport
+}
+
+// Configure applies sec to every reader, writer and connection created through this package
+// afterwards (NewReaderConfig, Dialer, Transport, Dial). Call it once at startup, before
+// creating consumers and producers. A nil or zero Security keeps kafka-go's plaintext defaults.
+func Configure(sec *Security) error {
+	var tlsCfg *tls.Config
+	var mechanism sasl.Mechanism
+	if sec != nil {
+		if err := sec.Validate(); err != nil {
+			return err
+		}
+		var err error
+		if tlsCfg, err = sec.tlsConfig(); err != nil {
+			return err
+		}
+		if mechanism, err = sec.mechanism(); err != nil {
+			return err
+		}
+	}
+
+	connection.mu.Lock()
+	defer connection.mu.Unlock()
+	if tlsCfg == nil && mechanism == nil {
+		connection.dialer, connection.transport = nil, nil
+		return nil
+	}
+	slog.Info("Kafka connection security enabled", sec.LogFields()...)
+	connection.dialer = &kafka.Dialer{
+		Timeout:       dialTimeout,
+		DualStack:     true,
+		TLS:           tlsCfg,
+		SASLMechanism: mechanism,
+	}
+	connection.transport = &kafka.Transport{
+		DialTimeout: dialTimeout,
+		TLS:         tlsCfg,
+		SASL:        mechanism,
+	}
+	return nil
+}
+
+// Dialer returns the dialer for readers and direct connections, nil (kafka-go's default
+// dialer) unless Configure enabled TLS or SASL.
+func Dialer() *kafka.Dialer {
+	connection.mu.RLock()
+	defer connection.mu.RUnlock()
+	return connection.dialer
+}
+
+// Transport returns the transport for writers and admin clients, nil (kafka-go's default
+// transport) unless Configure enabled TLS or SASL.
+func Transport() kafka.RoundTripper {
+	connection.mu.RLock()
+	defer connection.mu.RUnlock()
+	if connection.transport == nil {
+		return nil // a typed nil would not be replaced by the default transport
+	}
+	return connection.transport
+}
+
+// Dial opens a connection to broker with the configured dialer.
+// It replaces kafka.Dial("tcp", broker) for topic administration.
+func Dial(broker string) (*kafka.Conn, error) {
+	return DialContext(context.Background(), broker)
+}
+
+// DialContext opens a connection to broker with the configured dialer.
+func DialContext(ctx context.Context, broker string) (*kafka.Conn, error) {
+	dialer := Dialer()
+	if dialer == nil {
+		dialer = kafka.DefaultDialer
+	}
+	return dialer.DialContext(ctx, "tcp", broker)
+}
+
+// envOrDefault returns the value of the environment variable key, or defaultValue if unset.
+func envOrDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
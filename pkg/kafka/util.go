@@ -92,5 +92,6 @@ func NewReaderConfig(brokers []string, topic, groupID string) kafka.ReaderConfig
 		MaxWait:        MaxPollWait,
 		CommitInterval: CommitInterval,
 		StartOffset:    kafka.LastOffset, // Start from latest if no committed offset (skip old messages)
+		Dialer:         Dialer(),         // TLS/SASL settings from Configure (nil = plaintext)
 	}
 }
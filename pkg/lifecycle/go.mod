@@ -0,0 +1,3 @@
+module github.com/afikmenashe/alerting-platform/pkg/lifecycle
+
+go 1.23
@@ -0,0 +1,96 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// Closer returns a hook that closes c on stop: a database, a Redis client, or a Kafka
+// consumer or producer.
+func Closer(name string, c io.Closer) Hook {
+	return Hook{
+		Name: name,
+		Stop: func(ctx context.Context) error { return c.Close() },
+	}
+}
+
+// StopFunc returns a hook that calls stop on stop, for components whose Stop returns nothing.
+func StopFunc(name string, stop func()) Hook {
+	return Hook{
+		Name: name,
+		Stop: func(ctx context.Context) error {
+			stop()
+			return nil
+		},
+	}
+}
+
+// Go returns a hook that runs a background loop: Start runs run in a goroutine, and Stop
+// cancels its context and waits for it to return.
+func Go(name string, run func(ctx context.Context)) Hook {
+	var (
+		mu     sync.Mutex
+		cancel context.CancelFunc
+		done   chan struct{}
+	)
+	return Hook{
+		Name: name,
+		Start: func(ctx context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			var runCtx context.Context
+			runCtx, cancel = context.WithCancel(ctx)
+			done = make(chan struct{})
+			go func() {
+				defer close(done)
+				run(runCtx)
+			}()
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	}
+}
+
+// Server returns a hook that serves srv: Start listens on srv.Addr and serves in a goroutine,
+// and Stop shuts the server down gracefully, waiting for in-flight requests. Start returns
+// listen errors, like a port in use; errors once the server is serving are logged.
+func Server(name string, srv *http.Server) Hook {
+	return Hook{
+		Name: name,
+		Start: func(ctx context.Context) error {
+			addr := srv.Addr
+			if addr == "" {
+				addr = ":http"
+			}
+			ln, err := net.Listen("tcp", addr)
+			if err != nil {
+				return err
+			}
+			slog.Info("HTTP server listening", "server", name, "addr", ln.Addr().String())
+			go func() {
+				if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					slog.Error("HTTP server failed", "server", name, "error", err)
+				}
+			}()
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			return srv.Shutdown(ctx)
+		},
+	}
+}
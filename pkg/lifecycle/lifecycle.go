@@ -0,0 +1,143 @@
+// Package lifecycle orders the startup and shutdown of a service's components.
+//
+// A service registers its components with a Manager as it creates them: connections,
+// consumers, producers, background loops and HTTP servers. Start starts them in registration
+// order and Stop stops them in reverse order, so a component is always stopped before the
+// components it was built on: an HTTP server before the producers its handlers publish to,
+// a producer before the Redis client and database it was created after. Every Stop hook is
+// bounded by a timeout, and a failing or hanging component does not keep the others from
+// stopping.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// DefaultStopTimeout bounds each Stop hook that does not set its own timeout.
+const DefaultStopTimeout = 10 * time.Second
+
+// Hook is a component registered with a Manager.
+type Hook struct {
+	// Name identifies the component in logs and errors.
+	Name string
+	// Start starts the component. Nil if the component is running once it is registered,
+	// like an open database connection.
+	Start func(ctx context.Context) error
+	// Stop stops the component. Nil if there is nothing to stop.
+	Stop func(ctx context.Context) error
+	// StopTimeout bounds Stop (0 = the manager's stop timeout).
+	StopTimeout time.Duration
+}
+
+// component is a registered hook and whether it was started.
+type component struct {
+	hook    Hook
+	started bool
+}
+
+// Manager starts and stops a service's components in order.
+type Manager struct {
+	stopTimeout time.Duration
+
+	mu         sync.Mutex
+	components []*component
+	stopped    bool
+}
+
+// New creates a manager with no components.
+func New() *Manager {
+	return &Manager{stopTimeout: DefaultStopTimeout}
+}
+
+// WithStopTimeout sets the timeout of Stop hooks that do not set their own.
+// Defaults to DefaultStopTimeout.
+func (m *Manager) WithStopTimeout(timeout time.Duration) *Manager {
+	m.stopTimeout = timeout
+	return m
+}
+
+// Append registers a component. A component without a Start hook counts as started, so it
+// is stopped even if Start is never called.
+func (m *Manager) Append(hook Hook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.components = append(m.components, &component{hook: hook, started: hook.Start == nil})
+}
+
+// Start starts the components registered since the last call, in registration order.
+// It returns the first error and does not start the components after it; the components
+// started so far are still stopped by Stop.
+func (m *Manager) Start(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stopped {
+		return errors.New("lifecycle: start after stop")
+	}
+	for _, c := range m.components {
+		if c.started {
+			continue
+		}
+		if err := c.hook.Start(ctx); err != nil {
+			return fmt.Errorf("start %s: %w", c.hook.Name, err)
+		}
+		c.started = true
+	}
+	return nil
+}
+
+// Stop stops the started components in reverse registration order. Each Stop hook is given a
+// context bounded by its timeout and by ctx; a hook that does not return in time is left
+// behind and reported. Every component is stopped even if others fail, and the errors are
+// joined. Only the first call stops anything; later calls return nil.
+func (m *Manager) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stopped {
+		return nil
+	}
+	m.stopped = true
+
+	var errs []error
+	for i := len(m.components) - 1; i >= 0; i-- {
+		c := m.components[i]
+		if !c.started || c.hook.Stop == nil {
+			continue
+		}
+		start := time.Now()
+		if err := m.stop(ctx, c.hook); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		slog.Info("Component stopped", "component", c.hook.Name, "duration", time.Since(start))
+	}
+	return errors.Join(errs...)
+}
+
+// stop runs a Stop hook within its timeout.
+func (m *Manager) stop(ctx context.Context, hook Hook) error {
+	timeout := hook.StopTimeout
+	if timeout <= 0 {
+		timeout = m.stopTimeout
+	}
+	stopCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- hook.Stop(stopCtx)
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("stop %s: %w", hook.Name, err)
+		}
+		return nil
+	case <-stopCtx.Done():
+		return fmt.Errorf("stop %s: %w", hook.Name, stopCtx.Err())
+	}
+}
@@ -0,0 +1,158 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recorder records the order hooks run in.
+type recorder struct {
+	calls []string
+}
+
+func (r *recorder) hook(name string, startErr, stopErr error) Hook {
+	return Hook{
+		Name: name,
+		Start: func(ctx context.Context) error {
+			r.calls = append(r.calls, "start "+name)
+			return startErr
+		},
+		Stop: func(ctx context.Context) error {
+			r.calls = append(r.calls, "stop "+name)
+			return stopErr
+		},
+	}
+}
+
+func TestManager_StartStopOrder(t *testing.T) {
+	r := &recorder{}
+	m := New()
+	m.Append(r.hook("db", nil, nil))
+	m.Append(StopFunc("redis", func() { r.calls = append(r.calls, "stop redis") }))
+	m.Append(r.hook("consumer", nil, nil))
+
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if err := m.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	want := []string{"start db", "start consumer", "stop consumer", "stop redis", "stop db"}
+	if !reflect.DeepEqual(r.calls, want) {
+		t.Errorf("calls = %v, want %v", r.calls, want)
+	}
+
+	// Stop only runs once
+	if err := m.Stop(context.Background()); err != nil || len(r.calls) != len(want) {
+		t.Errorf("second Stop() = %v, calls = %v, want nothing stopped", err, r.calls)
+	}
+	if err := m.Start(context.Background()); err == nil {
+		t.Error("Start() after Stop() expected error")
+	}
+}
+
+func TestManager_StartFailure(t *testing.T) {
+	r := &recorder{}
+	m := New()
+	m.Append(r.hook("db", nil, nil))
+	m.Append(r.hook("producer", errors.New("no brokers"), nil))
+	m.Append(r.hook("server", nil, nil))
+
+	err := m.Start(context.Background())
+	if err == nil || err.Error() != "start producer: no brokers" {
+		t.Fatalf("Start() error = %v, want start producer: no brokers", err)
+	}
+	if err := m.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	// Only the started component is stopped
+	want := []string{"start db", "start producer", "stop db"}
+	if !reflect.DeepEqual(r.calls, want) {
+		t.Errorf("calls = %v, want %v", r.calls, want)
+	}
+}
+
+func TestManager_StopErrorsAndTimeouts(t *testing.T) {
+	r := &recorder{}
+	m := New().WithStopTimeout(50 * time.Millisecond)
+	m.Append(r.hook("db", nil, errors.New("db busy")))
+	m.Append(Hook{
+		Name: "producer",
+		Stop: func(ctx context.Context) error {
+			time.Sleep(time.Second) // ignores ctx
+			return nil
+		},
+	})
+	m.Append(r.hook("consumer", nil, errors.New("commit failed")))
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	start := time.Now()
+	err := m.Stop(context.Background())
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Stop() took %v, want the hanging hook abandoned after its timeout", elapsed)
+	}
+	if err == nil {
+		t.Fatal("Stop() expected error")
+	}
+	for _, want := range []string{"stop consumer: commit failed", "stop producer: context deadline exceeded", "stop db: db busy"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Stop() error = %q, want it to contain %q", err, want)
+		}
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Stop() error = %v, want it to wrap context.DeadlineExceeded", err)
+	}
+	// Components after the failing ones are still stopped
+	if want := []string{"start db", "start consumer", "stop consumer", "stop db"}; !reflect.DeepEqual(r.calls, want) {
+		t.Errorf("calls = %v, want %v", r.calls, want)
+	}
+}
+
+func TestGo(t *testing.T) {
+	stopped := make(chan struct{})
+	m := New()
+	m.Append(Go("loop", func(ctx context.Context) {
+		<-ctx.Done()
+		close(stopped)
+	}))
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if err := m.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	select {
+	case <-stopped:
+	default:
+		t.Error("Stop() returned before the loop did")
+	}
+}
+
+func TestServer(t *testing.T) {
+	srv := &http.Server{
+		Addr:    "127.0.0.1:0",
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+	}
+	m := New()
+	m.Append(Server("api", srv))
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if err := m.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+
+	// A listen error fails Start
+	m = New()
+	m.Append(Server("bad", &http.Server{Addr: "127.0.0.1:-1"}))
+	if err := m.Start(context.Background()); err == nil {
+		t.Error("Start() expected listen error")
+	}
+}
@@ -0,0 +1,155 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Canary alert fields. The canary rule seeded by the rule-service migrations matches
+// exactly these values and routes to a "null" endpoint, so canaries flow through every
+// pipeline stage without notifying anyone.
+const (
+	CanaryClientID = "canary"
+	CanarySeverity = "LOW"
+	CanarySource   = "canary"
+	CanaryName     = "pipeline-canary"
+
+	// CanaryContextKey marks an alert as a canary ("true").
+	CanaryContextKey = "canary"
+	// CanarySentAtContextKey holds the emission time in Unix milliseconds.
+	CanarySentAtContextKey = "canary_sent_at"
+)
+
+const (
+	// CanaryStatusKey is the Redis hash holding the canary loop state.
+	CanaryStatusKey = "canary:status"
+	// DefaultCanaryMaxAge is how long after the last delivery the pipeline is still considered healthy.
+	DefaultCanaryMaxAge = 2 * time.Minute
+)
+
+// Canary health states.
+const (
+	CanaryHealthy = "healthy"
+	CanaryFailing = "failing"
+	CanaryUnknown = "unknown"
+)
+
+// CanaryStatus is the end-to-end pipeline health derived from the canary loop.
+type CanaryStatus struct {
+	Status          string     `json:"status"` // healthy, failing, or unknown
+	LastSentAt      *time.Time `json:"last_sent_at,omitempty"`
+	LastDeliveredAt *time.Time `json:"last_delivered_at,omitempty"`
+	LastAlertID     string     `json:"last_alert_id,omitempty"`
+	LastLatencyMs   int64      `json:"last_latency_ms"`
+	Sent            uint64     `json:"sent"`
+	Delivered       uint64     `json:"delivered"`
+	MaxAgeSeconds   float64    `json:"max_age_seconds"`
+}
+
+// IsCanary reports whether an alert or notification context marks a canary.
+func IsCanary(context map[string]string) bool {
+	return context[CanaryContextKey] == "true"
+}
+
+// CanarySentAt returns the emission time stored in a canary context.
+func CanarySentAt(context map[string]string) (time.Time, bool) {
+	ms, err := strconv.ParseInt(context[CanarySentAtContextKey], 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(ms), true
+}
+
+// NewCanaryContext returns the alert context for a canary emitted at sentAt.
+func NewCanaryContext(sentAt time.Time) map[string]string {
+	return map[string]string{
+		CanaryContextKey:       "true",
+		CanarySentAtContextKey: strconv.FormatInt(sentAt.UnixMilli(), 10),
+	}
+}
+
+// RecordCanarySent records that a canary alert entered the pipeline.
+func (c *RedisCollector) RecordCanarySent(ctx context.Context, alertID string, sentAt time.Time) error {
+	if c.redis == nil {
+		return nil
+	}
+	_, err := c.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, CanaryStatusKey,
+			"last_sent_at", sentAt.UnixMilli(),
+			"last_sent_alert_id", alertID,
+		)
+		pipe.HIncrBy(ctx, CanaryStatusKey, "sent", 1)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record canary sent: %w", err)
+	}
+	return nil
+}
+
+// RecordCanaryDelivered records that a canary alert reached the end of the pipeline.
+// Latency is measured from sentAt, the time the canary was emitted.
+func (c *RedisCollector) RecordCanaryDelivered(ctx context.Context, alertID string, sentAt, deliveredAt time.Time) error {
+	if c.redis == nil {
+		return nil
+	}
+	_, err := c.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, CanaryStatusKey,
+			"last_delivered_at", deliveredAt.UnixMilli(),
+			"last_delivered_alert_id", alertID,
+			"last_latency_ms", deliveredAt.Sub(sentAt).Milliseconds(),
+		)
+		pipe.HIncrBy(ctx, CanaryStatusKey, "delivered", 1)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record canary delivery: %w", err)
+	}
+	return nil
+}
+
+// GetCanaryStatus reads the canary loop state. The pipeline is healthy while the last
+// delivery is no older than maxAge, failing once it is, and unknown if no canary was ever sent.
+func (r *RedisReader) GetCanaryStatus(ctx context.Context, maxAge time.Duration) (*CanaryStatus, error) {
+	fields, err := r.redis.HGetAll(ctx, CanaryStatusKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read canary status: %w", err)
+	}
+	return canaryStatusFromFields(fields, maxAge, time.Now()), nil
+}
+
+// canaryStatusFromFields builds a CanaryStatus from the Redis hash fields.
+func canaryStatusFromFields(fields map[string]string, maxAge time.Duration, now time.Time) *CanaryStatus {
+	status := &CanaryStatus{
+		Status:        CanaryUnknown,
+		LastAlertID:   fields["last_delivered_alert_id"],
+		MaxAgeSeconds: maxAge.Seconds(),
+	}
+	status.Sent, _ = strconv.ParseUint(fields["sent"], 10, 64)
+	status.Delivered, _ = strconv.ParseUint(fields["delivered"], 10, 64)
+	status.LastLatencyMs, _ = strconv.ParseInt(fields["last_latency_ms"], 10, 64)
+	status.LastSentAt = parseUnixMilli(fields["last_sent_at"])
+	status.LastDeliveredAt = parseUnixMilli(fields["last_delivered_at"])
+
+	switch {
+	case status.LastDeliveredAt != nil && now.Sub(*status.LastDeliveredAt) <= maxAge:
+		status.Status = CanaryHealthy
+	case status.LastSentAt != nil:
+		status.Status = CanaryFailing
+	}
+	return status
+}
+
+// parseUnixMilli parses a Unix millisecond timestamp, returning nil if empty or invalid.
+func parseUnixMilli(value string) *time.Time {
+	ms, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil
+	}
+	t := time.UnixMilli(ms).UTC()
+	return &t
+}
@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"context"
+	"time"
+)
+
+// Collector records a service's metrics, alert traces, canary, rule propagation and
+// pipeline latency events.
+// Services depend on this interface; RedisCollector is the production implementation,
+// NoOpCollector discards everything and MemoryCollector keeps it in memory for tests.
+type Collector interface {
+	// Start begins periodic reporting; Stop ends it with a final report.
+	Start(ctx context.Context)
+	Stop()
+
+	RecordReceived()
+	RecordProcessed(latency time.Duration)
+	RecordPublished()
+	RecordError()
+	IncrementCustom(name string)
+	AddCustom(name string, value uint64)
+	// GetSnapshot returns the current metrics without reporting them.
+	GetSnapshot() *ServiceMetrics
+
+	RecordTrace(ctx context.Context, alertID string, event TraceEvent)
+	RecordCanarySent(ctx context.Context, alertID string, sentAt time.Time) error
+	RecordCanaryDelivered(ctx context.Context, alertID string, sentAt, deliveredAt time.Time) error
+	RecordRulePropagation(ctx context.Context, stage, ruleID string, publishedAt, appliedAt time.Time) error
+	RecordStageLatency(ctx context.Context, stage string, start, end time.Time) error
+}
+
+// Reader reads what the services' collectors recorded. RedisReader is the production
+// implementation; MemoryCollector also implements it to read back what it recorded.
+type Reader interface {
+	GetServiceMetrics(ctx context.Context, serviceName string) (*ServiceMetrics, error)
+	GetAllServiceMetrics(ctx context.Context) (map[string]*ServiceMetrics, error)
+	GetCanaryStatus(ctx context.Context, maxAge time.Duration) (*CanaryStatus, error)
+	GetRulePropagation(ctx context.Context, threshold time.Duration) (*PropagationStatus, error)
+	GetPipelineLatency(ctx context.Context) (*PipelineLatency, error)
+	GetAlertTrace(ctx context.Context, alertID string) ([]TraceEvent, error)
+}
+
+// Ensure the implementations satisfy the interfaces.
+var (
+	_ Collector = (*RedisCollector)(nil)
+	_ Collector = NoOpCollector{}
+	_ Collector = (*MemoryCollector)(nil)
+	_ Reader    = (*RedisReader)(nil)
+	_ Reader    = NoOpReader{}
+	_ Reader    = (*MemoryCollector)(nil)
+)
@@ -0,0 +1,191 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MemoryCollector is a Collector that keeps everything in memory, for tests. It is also a
+// Reader of what it recorded, so handlers reading metrics can be tested against it too.
+// It is safe for concurrent use.
+type MemoryCollector struct {
+	// counts holds the counters; without a Redis client it never reports anywhere.
+	counts *RedisCollector
+
+	mu          sync.Mutex
+	canary      map[string]string // the canary:status hash
+	propagation map[string]string // the propagation:rules hash
+	latency     map[string]string // the latency:pipeline hash
+	traces      map[string][]TraceEvent
+	services    map[string]*ServiceMetrics // other services' metrics, see SetServiceMetrics
+}
+
+// NewMemoryCollector creates an in-memory collector for a service.
+func NewMemoryCollector(serviceName string) *MemoryCollector {
+	return &MemoryCollector{
+		counts:      NewCollector(serviceName, nil),
+		canary:      make(map[string]string),
+		propagation: make(map[string]string),
+		latency:     make(map[string]string),
+		traces:      make(map[string][]TraceEvent),
+		services:    make(map[string]*ServiceMetrics),
+	}
+}
+
+func (m *MemoryCollector) Start(context.Context)               {}
+func (m *MemoryCollector) Stop()                               {}
+func (m *MemoryCollector) RecordReceived()                     { m.counts.RecordReceived() }
+func (m *MemoryCollector) RecordProcessed(d time.Duration)     { m.counts.RecordProcessed(d) }
+func (m *MemoryCollector) RecordPublished()                    { m.counts.RecordPublished() }
+func (m *MemoryCollector) RecordError()                        { m.counts.RecordError() }
+func (m *MemoryCollector) IncrementCustom(name string)         { m.counts.IncrementCustom(name) }
+func (m *MemoryCollector) AddCustom(name string, value uint64) { m.counts.AddCustom(name, value) }
+func (m *MemoryCollector) GetSnapshot() *ServiceMetrics        { return m.counts.GetSnapshot() }
+
+// Custom returns the value of a custom counter, 0 if it was never incremented.
+func (m *MemoryCollector) Custom(name string) uint64 {
+	return m.GetSnapshot().CustomCounters[name]
+}
+
+// RecordTrace appends an event to the alert's trace, keeping the last MaxTraceEvents.
+func (m *MemoryCollector) RecordTrace(_ context.Context, alertID string, event TraceEvent) {
+	if alertID == "" {
+		return
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	events := append(m.traces[alertID], event)
+	if len(events) > MaxTraceEvents {
+		events = events[len(events)-MaxTraceEvents:]
+	}
+	m.traces[alertID] = events
+}
+
+// RecordCanarySent records that a canary alert entered the pipeline.
+func (m *MemoryCollector) RecordCanarySent(_ context.Context, alertID string, sentAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.canary["last_sent_at"] = strconv.FormatInt(sentAt.UnixMilli(), 10)
+	m.canary["last_sent_alert_id"] = alertID
+	incrField(m.canary, "sent", 1)
+	return nil
+}
+
+// RecordCanaryDelivered records that a canary alert reached the end of the pipeline.
+func (m *MemoryCollector) RecordCanaryDelivered(_ context.Context, alertID string, sentAt, deliveredAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.canary["last_delivered_at"] = strconv.FormatInt(deliveredAt.UnixMilli(), 10)
+	m.canary["last_delivered_alert_id"] = alertID
+	m.canary["last_latency_ms"] = strconv.FormatInt(deliveredAt.Sub(sentAt).Milliseconds(), 10)
+	incrField(m.canary, "delivered", 1)
+	return nil
+}
+
+// RecordRulePropagation records that a rule change published at publishedAt reached stage at appliedAt.
+func (m *MemoryCollector) RecordRulePropagation(_ context.Context, stage, ruleID string, publishedAt, appliedAt time.Time) error {
+	latencyMs := appliedAt.Sub(publishedAt).Milliseconds()
+	if latencyMs < 0 {
+		latencyMs = 0
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.propagation[stage+":last_latency_ms"] = strconv.FormatInt(latencyMs, 10)
+	m.propagation[stage+":last_rule_id"] = ruleID
+	m.propagation[stage+":last_applied_at"] = strconv.FormatInt(appliedAt.UnixMilli(), 10)
+	incrField(m.propagation, stage+":count", 1)
+	incrField(m.propagation, stage+":total_latency_ms", latencyMs)
+	return nil
+}
+
+// RecordStageLatency records that a notification spent from start to end in stage.
+func (m *MemoryCollector) RecordStageLatency(_ context.Context, stage string, start, end time.Time) error {
+	if start.IsZero() {
+		return nil
+	}
+	latencyMs := end.Sub(start).Milliseconds()
+	if latencyMs < 0 {
+		latencyMs = 0
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.latency[stage+":last_latency_ms"] = strconv.FormatInt(latencyMs, 10)
+	m.latency[stage+":last_applied_at"] = strconv.FormatInt(end.UnixMilli(), 10)
+	incrField(m.latency, stage+":count", 1)
+	incrField(m.latency, stage+":total_latency_ms", latencyMs)
+	return nil
+}
+
+// SetServiceMetrics makes the reader return metrics for another service.
+func (m *MemoryCollector) SetServiceMetrics(metrics *ServiceMetrics) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.services[metrics.ServiceName] = metrics
+}
+
+// GetServiceMetrics returns the collector's own snapshot for its service, or the metrics
+// set with SetServiceMetrics.
+func (m *MemoryCollector) GetServiceMetrics(_ context.Context, serviceName string) (*ServiceMetrics, error) {
+	if serviceName == m.counts.serviceName {
+		return m.GetSnapshot(), nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	metrics, ok := m.services[serviceName]
+	if !ok {
+		return nil, fmt.Errorf("no metrics found for service: %s", serviceName)
+	}
+	return metrics, nil
+}
+
+// GetAllServiceMetrics returns the collector's own snapshot and the metrics set with SetServiceMetrics.
+func (m *MemoryCollector) GetAllServiceMetrics(context.Context) (map[string]*ServiceMetrics, error) {
+	m.mu.Lock()
+	result := make(map[string]*ServiceMetrics, len(m.services)+1)
+	for name, metrics := range m.services {
+		result[name] = metrics
+	}
+	m.mu.Unlock()
+	result[m.counts.serviceName] = m.GetSnapshot()
+	return result, nil
+}
+
+// GetCanaryStatus returns the status of the recorded canaries.
+func (m *MemoryCollector) GetCanaryStatus(_ context.Context, maxAge time.Duration) (*CanaryStatus, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return canaryStatusFromFields(m.canary, maxAge, time.Now()), nil
+}
+
+// GetRulePropagation returns the status of the recorded rule propagations.
+func (m *MemoryCollector) GetRulePropagation(_ context.Context, threshold time.Duration) (*PropagationStatus, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return propagationStatusFromFields(m.propagation, threshold), nil
+}
+
+// GetPipelineLatency returns the recorded pipeline stage latencies.
+func (m *MemoryCollector) GetPipelineLatency(context.Context) (*PipelineLatency, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return pipelineLatencyFromFields(m.latency), nil
+}
+
+// GetAlertTrace returns a copy of the recorded trace of an alert.
+func (m *MemoryCollector) GetAlertTrace(_ context.Context, alertID string) ([]TraceEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]TraceEvent{}, m.traces[alertID]...), nil
+}
+
+// incrField adds n to an integer field of an in-memory hash, like Redis HINCRBY.
+func incrField(fields map[string]string, key string, n int64) {
+	current, _ := strconv.ParseInt(fields[key], 10, 64)
+	fields[key] = strconv.FormatInt(current+n, 10)
+}
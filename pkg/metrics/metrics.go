@@ -46,10 +46,11 @@ type ServiceMetrics struct {
 	CustomCounters map[string]uint64 `json:"custom_counters,omitempty"`
 }
 
-// Collector collects and reports metrics for a service.
-type Collector struct {
+// RedisCollector is the Collector services run with: it counts in memory and periodically
+// writes the service's metrics to Redis.
+type RedisCollector struct {
 	serviceName    string
-	redis          *redis.Client
+	redis          redis.UniversalClient
 	startedAt      time.Time
 	reportInterval time.Duration
 
@@ -77,8 +78,8 @@ type Collector struct {
 }
 
 // NewCollector creates a new metrics collector for a service.
-func NewCollector(serviceName string, redisClient *redis.Client) *Collector {
-	return &Collector{
+func NewCollector(serviceName string, redisClient redis.UniversalClient) *RedisCollector {
+	return &RedisCollector{
 		serviceName:    serviceName,
 		redis:          redisClient,
 		startedAt:      time.Now().UTC(),
@@ -90,12 +91,12 @@ func NewCollector(serviceName string, redisClient *redis.Client) *Collector {
 }
 
 // SetReportInterval sets the interval for writing metrics to Redis.
-func (c *Collector) SetReportInterval(interval time.Duration) {
+func (c *RedisCollector) SetReportInterval(interval time.Duration) {
 	c.reportInterval = interval
 }
 
 // Start begins the periodic metrics reporting to Redis.
-func (c *Collector) Start(ctx context.Context) {
+func (c *RedisCollector) Start(ctx context.Context) {
 	c.wg.Add(1)
 	go func() {
 		defer c.wg.Done()
@@ -118,35 +119,35 @@ func (c *Collector) Start(ctx context.Context) {
 }
 
 // Stop stops the metrics reporting.
-func (c *Collector) Stop() {
+func (c *RedisCollector) Stop() {
 	close(c.stopCh)
 	c.wg.Wait()
 }
 
 // RecordReceived increments the messages received counter.
-func (c *Collector) RecordReceived() {
+func (c *RedisCollector) RecordReceived() {
 	c.messagesReceived.Add(1)
 }
 
 // RecordProcessed increments the messages processed counter with latency.
-func (c *Collector) RecordProcessed(latency time.Duration) {
+func (c *RedisCollector) RecordProcessed(latency time.Duration) {
 	c.messagesProcessed.Add(1)
 	c.totalLatencyNs.Add(uint64(latency.Nanoseconds()))
 	c.latencyCount.Add(1)
 }
 
 // RecordPublished increments the messages published counter.
-func (c *Collector) RecordPublished() {
+func (c *RedisCollector) RecordPublished() {
 	c.messagesPublished.Add(1)
 }
 
 // RecordError increments the processing errors counter.
-func (c *Collector) RecordError() {
+func (c *RedisCollector) RecordError() {
 	c.processingErrors.Add(1)
 }
 
 // IncrementCustom increments a custom counter by name.
-func (c *Collector) IncrementCustom(name string) {
+func (c *RedisCollector) IncrementCustom(name string) {
 	c.customMu.RLock()
 	counter, exists := c.customCounters[name]
 	c.customMu.RUnlock()
@@ -164,7 +165,7 @@ func (c *Collector) IncrementCustom(name string) {
 }
 
 // AddCustom adds a value to a custom counter.
-func (c *Collector) AddCustom(name string, value uint64) {
+func (c *RedisCollector) AddCustom(name string, value uint64) {
 	c.customMu.RLock()
 	counter, exists := c.customCounters[name]
 	c.customMu.RUnlock()
@@ -181,7 +182,7 @@ func (c *Collector) AddCustom(name string, value uint64) {
 }
 
 // GetSnapshot returns current metrics without writing to Redis.
-func (c *Collector) GetSnapshot() *ServiceMetrics {
+func (c *RedisCollector) GetSnapshot() *ServiceMetrics {
 	now := time.Now().UTC()
 	processed := c.messagesProcessed.Load()
 
@@ -223,7 +224,7 @@ func (c *Collector) GetSnapshot() *ServiceMetrics {
 }
 
 // writeMetrics writes current metrics to Redis.
-func (c *Collector) writeMetrics(ctx context.Context) {
+func (c *RedisCollector) writeMetrics(ctx context.Context) {
 	if c.redis == nil {
 		return
 	}
@@ -252,18 +253,18 @@ func (c *Collector) writeMetrics(ctx context.Context) {
 	slog.Debug("Metrics written to Redis", "service", c.serviceName, "key", key)
 }
 
-// Reader reads service metrics from Redis.
-type Reader struct {
+// RedisReader is the Reader over the metrics services write to Redis.
+type RedisReader struct {
 	redis *redis.Client
 }
 
 // NewReader creates a new metrics reader.
-func NewReader(redisClient *redis.Client) *Reader {
-	return &Reader{redis: redisClient}
+func NewReader(redisClient *redis.Client) *RedisReader {
+	return &RedisReader{redis: redisClient}
 }
 
 // GetServiceMetrics retrieves metrics for a specific service.
-func (r *Reader) GetServiceMetrics(ctx context.Context, serviceName string) (*ServiceMetrics, error) {
+func (r *RedisReader) GetServiceMetrics(ctx context.Context, serviceName string) (*ServiceMetrics, error) {
 	key := MetricsKeyPrefix + serviceName
 	data, err := r.redis.Get(ctx, key).Bytes()
 	if err == redis.Nil {
@@ -287,7 +288,7 @@ func (r *Reader) GetServiceMetrics(ctx context.Context, serviceName string) (*Se
 }
 
 // GetAllServiceMetrics retrieves metrics for all services.
-func (r *Reader) GetAllServiceMetrics(ctx context.Context) (map[string]*ServiceMetrics, error) {
+func (r *RedisReader) GetAllServiceMetrics(ctx context.Context) (map[string]*ServiceMetrics, error) {
 	pattern := MetricsKeyPrefix + "*"
 	keys, err := r.redis.Keys(ctx, pattern).Result()
 	if err != nil {
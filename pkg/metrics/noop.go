@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// NoOpCollector is a Collector that discards everything. Use it instead of a nil collector
+// when metrics are disabled.
+type NoOpCollector struct{}
+
+func (NoOpCollector) Start(context.Context)                           {}
+func (NoOpCollector) Stop()                                           {}
+func (NoOpCollector) RecordReceived()                                 {}
+func (NoOpCollector) RecordProcessed(time.Duration)                   {}
+func (NoOpCollector) RecordPublished()                                {}
+func (NoOpCollector) RecordError()                                    {}
+func (NoOpCollector) IncrementCustom(string)                          {}
+func (NoOpCollector) AddCustom(string, uint64)                        {}
+func (NoOpCollector) RecordTrace(context.Context, string, TraceEvent) {}
+
+// GetSnapshot returns empty metrics.
+func (NoOpCollector) GetSnapshot() *ServiceMetrics {
+	return &ServiceMetrics{Status: "healthy", CustomCounters: map[string]uint64{}}
+}
+
+func (NoOpCollector) RecordCanarySent(context.Context, string, time.Time) error { return nil }
+
+func (NoOpCollector) RecordCanaryDelivered(context.Context, string, time.Time, time.Time) error {
+	return nil
+}
+
+func (NoOpCollector) RecordRulePropagation(context.Context, string, string, time.Time, time.Time) error {
+	return nil
+}
+
+func (NoOpCollector) RecordStageLatency(context.Context, string, time.Time, time.Time) error {
+	return nil
+}
+
+// NoOpReader is a Reader over no recorded metrics: no services, an unknown canary and
+// rule propagation, no pipeline latency, and empty traces.
+type NoOpReader struct{}
+
+// GetServiceMetrics always reports that the service has no metrics.
+func (NoOpReader) GetServiceMetrics(_ context.Context, serviceName string) (*ServiceMetrics, error) {
+	return nil, fmt.Errorf("no metrics found for service: %s", serviceName)
+}
+
+// GetAllServiceMetrics returns no services.
+func (NoOpReader) GetAllServiceMetrics(context.Context) (map[string]*ServiceMetrics, error) {
+	return map[string]*ServiceMetrics{}, nil
+}
+
+// GetCanaryStatus returns the unknown status.
+func (NoOpReader) GetCanaryStatus(_ context.Context, maxAge time.Duration) (*CanaryStatus, error) {
+	return canaryStatusFromFields(nil, maxAge, time.Now()), nil
+}
+
+// GetRulePropagation returns the unknown status.
+func (NoOpReader) GetRulePropagation(_ context.Context, threshold time.Duration) (*PropagationStatus, error) {
+	return propagationStatusFromFields(nil, threshold), nil
+}
+
+// GetPipelineLatency returns no stages.
+func (NoOpReader) GetPipelineLatency(context.Context) (*PipelineLatency, error) {
+	return pipelineLatencyFromFields(nil), nil
+}
+
+// GetAlertTrace returns an empty trace.
+func (NoOpReader) GetAlertTrace(context.Context, string) ([]TraceEvent, error) {
+	return []TraceEvent{}, nil
+}
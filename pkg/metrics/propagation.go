@@ -0,0 +1,121 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// PropagationStatusKey is the Redis hash holding rule propagation latencies per stage.
+	PropagationStatusKey = "propagation:rules"
+	// DefaultPropagationThreshold is the propagation latency above which rules are reported as slow.
+	DefaultPropagationThreshold = 30 * time.Second
+)
+
+// Rule propagation stages, measured from the rule.changed publication by rule-service.
+const (
+	// PropagationStageSnapshot is the rule-updater applying the change to the Redis snapshot.
+	PropagationStageSnapshot = "snapshot"
+	// PropagationStageEvaluator is the evaluator applying the change to its in-memory indexes.
+	PropagationStageEvaluator = "evaluator"
+)
+
+// Propagation health states.
+const (
+	PropagationOK      = "ok"
+	PropagationSlow    = "slow"
+	PropagationUnknown = "unknown"
+)
+
+// propagationStages lists the stages in pipeline order.
+var propagationStages = []string{PropagationStageSnapshot, PropagationStageEvaluator}
+
+// StageLatency is the propagation latency of rule changes up to one stage.
+type StageLatency struct {
+	LastLatencyMs int64      `json:"last_latency_ms"`
+	AvgLatencyMs  float64    `json:"avg_latency_ms"`
+	Count         uint64     `json:"count"`
+	LastRuleID    string     `json:"last_rule_id,omitempty"`
+	LastAppliedAt *time.Time `json:"last_applied_at,omitempty"`
+}
+
+// PropagationStatus reports how long rule changes take to reach each stage.
+type PropagationStatus struct {
+	Status           string                   `json:"status"` // ok, slow, or unknown
+	ThresholdSeconds float64                  `json:"threshold_seconds"`
+	Stages           map[string]*StageLatency `json:"stages"`
+}
+
+// RecordRulePropagation records that a rule change published at publishedAt reached stage at appliedAt.
+func (c *RedisCollector) RecordRulePropagation(ctx context.Context, stage, ruleID string, publishedAt, appliedAt time.Time) error {
+	if c.redis == nil {
+		return nil
+	}
+	// Clocks differ across hosts; never record a negative latency
+	latencyMs := appliedAt.Sub(publishedAt).Milliseconds()
+	if latencyMs < 0 {
+		latencyMs = 0
+	}
+
+	_, err := c.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, PropagationStatusKey,
+			stage+":last_latency_ms", latencyMs,
+			stage+":last_rule_id", ruleID,
+			stage+":last_applied_at", appliedAt.UnixMilli(),
+		)
+		pipe.HIncrBy(ctx, PropagationStatusKey, stage+":count", 1)
+		pipe.HIncrBy(ctx, PropagationStatusKey, stage+":total_latency_ms", latencyMs)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record rule propagation: %w", err)
+	}
+	return nil
+}
+
+// GetRulePropagation reads the rule propagation latencies. Propagation is slow while the
+// latest change took longer than threshold to reach any stage, and unknown if no change was recorded.
+func (r *RedisReader) GetRulePropagation(ctx context.Context, threshold time.Duration) (*PropagationStatus, error) {
+	fields, err := r.redis.HGetAll(ctx, PropagationStatusKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rule propagation: %w", err)
+	}
+	return propagationStatusFromFields(fields, threshold), nil
+}
+
+// propagationStatusFromFields builds a PropagationStatus from the Redis hash fields.
+func propagationStatusFromFields(fields map[string]string, threshold time.Duration) *PropagationStatus {
+	status := &PropagationStatus{
+		Status:           PropagationUnknown,
+		ThresholdSeconds: threshold.Seconds(),
+		Stages:           make(map[string]*StageLatency, len(propagationStages)),
+	}
+
+	for _, stage := range propagationStages {
+		count, _ := strconv.ParseUint(fields[stage+":count"], 10, 64)
+		if count == 0 {
+			continue
+		}
+		latency := &StageLatency{
+			Count:         count,
+			LastRuleID:    fields[stage+":last_rule_id"],
+			LastAppliedAt: parseUnixMilli(fields[stage+":last_applied_at"]),
+		}
+		latency.LastLatencyMs, _ = strconv.ParseInt(fields[stage+":last_latency_ms"], 10, 64)
+		totalMs, _ := strconv.ParseInt(fields[stage+":total_latency_ms"], 10, 64)
+		latency.AvgLatencyMs = float64(totalMs) / float64(count)
+		status.Stages[stage] = latency
+
+		switch {
+		case time.Duration(latency.LastLatencyMs)*time.Millisecond > threshold:
+			status.Status = PropagationSlow
+		case status.Status == PropagationUnknown:
+			status.Status = PropagationOK
+		}
+	}
+	return status
+}
@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// TraceKeyPrefix is the Redis key prefix for per-alert trace event logs.
+	TraceKeyPrefix = "trace:alert:"
+	// TraceTTL is how long an alert's trace is kept after its last event.
+	TraceTTL = 24 * time.Hour
+	// MaxTraceEvents caps the events kept per alert (oldest are dropped).
+	MaxTraceEvents = 200
+)
+
+// Pipeline stages recorded in alert traces.
+const (
+	StageEvaluator  = "evaluator"
+	StageAggregator = "aggregator"
+	StageSender     = "sender"
+)
+
+// TraceEvent is one step in an alert's journey through the pipeline.
+type TraceEvent struct {
+	Stage          string            `json:"stage"`
+	Event          string            `json:"event"`
+	Timestamp      time.Time         `json:"timestamp"`
+	ClientID       string            `json:"client_id,omitempty"`
+	RuleIDs        []string          `json:"rule_ids,omitempty"`
+	NotificationID string            `json:"notification_id,omitempty"`
+	Error          string            `json:"error,omitempty"`
+	Details        map[string]string `json:"details,omitempty"`
+}
+
+// RecordTrace appends an event to the alert's trace log.
+// Tracing is best effort: failures are logged at debug level and never affect processing.
+func (c *RedisCollector) RecordTrace(ctx context.Context, alertID string, event TraceEvent) {
+	if c.redis == nil || alertID == "" {
+		return
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		slog.Debug("Failed to marshal trace event", "alert_id", alertID, "error", err)
+		return
+	}
+
+	key := TraceKeyPrefix + alertID
+	_, err = c.redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.RPush(ctx, key, data)
+		pipe.LTrim(ctx, key, -MaxTraceEvents, -1)
+		pipe.Expire(ctx, key, TraceTTL)
+		return nil
+	})
+	if err != nil {
+		slog.Debug("Failed to record trace event", "alert_id", alertID, "stage", event.Stage, "error", err)
+	}
+}
+
+// GetAlertTrace returns the recorded trace events for an alert in the order they were written.
+// Returns an empty slice if the alert has no trace (unknown or expired).
+func (r *RedisReader) GetAlertTrace(ctx context.Context, alertID string) ([]TraceEvent, error) {
+	items, err := r.redis.LRange(ctx, TraceKeyPrefix+alertID, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read alert trace: %w", err)
+	}
+
+	events := make([]TraceEvent, 0, len(items))
+	for _, item := range items {
+		var event TraceEvent
+		if err := json.Unmarshal([]byte(item), &event); err != nil {
+			slog.Warn("Skipping malformed trace event", "alert_id", alertID, "error", err)
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
@@ -32,6 +32,7 @@ type AlertNew struct {
 	Source        string                 `protobuf:"bytes,5,opt,name=source,proto3" json:"source,omitempty"`                                                                             // Source system
 	Name          string                 `protobuf:"bytes,6,opt,name=name,proto3" json:"name,omitempty"`                                                                                 // Alert name/type
 	Context       map[string]string      `protobuf:"bytes,7,rep,name=context,proto3" json:"context,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // Optional context metadata
+	ClientHint    string                 `protobuf:"bytes,8,opt,name=client_hint,json=clientHint,proto3" json:"client_hint,omitempty"`                                                   // Restricts matching to this client's rules (shared sources)
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -115,7 +116,15 @@ func (x *AlertNew) GetContext() map[string]string {
 	return nil
 }
 
+func (x *AlertNew) GetClientHint() string {
+	if x != nil {
+		return x.ClientHint
+	}
+	return ""
+}
+
 // AlertMatched represents a matched alert (alerts.matched topic)
+// Per-client fan-out sets client_id and rule_ids; combined fan-out sets matches instead.
 type AlertMatched struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	AlertId       string                 `protobuf:"bytes,1,opt,name=alert_id,json=alertId,proto3" json:"alert_id,omitempty"`
@@ -125,8 +134,10 @@ type AlertMatched struct {
 	Source        string                 `protobuf:"bytes,5,opt,name=source,proto3" json:"source,omitempty"`
 	Name          string                 `protobuf:"bytes,6,opt,name=name,proto3" json:"name,omitempty"`
 	Context       map[string]string      `protobuf:"bytes,7,rep,name=context,proto3" json:"context,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	ClientId      string                 `protobuf:"bytes,8,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"` // Client this alert matched for
-	RuleIds       []string               `protobuf:"bytes,9,rep,name=rule_ids,json=ruleIds,proto3" json:"rule_ids,omitempty"`    // Rule IDs that matched
+	ClientId      string                 `protobuf:"bytes,8,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`                    // Client this alert matched for
+	RuleIds       []string               `protobuf:"bytes,9,rep,name=rule_ids,json=ruleIds,proto3" json:"rule_ids,omitempty"`                       // Rule IDs that matched (the aggregator's collapse policy decides whether they share a notification)
+	Matches       []*ClientMatch         `protobuf:"bytes,10,rep,name=matches,proto3" json:"matches,omitempty"`                                     // Every matching client (combined fan-out only)
+	EvaluatedAtMs int64                  `protobuf:"varint,11,opt,name=evaluated_at_ms,json=evaluatedAtMs,proto3" json:"evaluated_at_ms,omitempty"` // When the evaluator matched the alert (Unix milliseconds)
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -224,11 +235,78 @@ func (x *AlertMatched) GetRuleIds() []string {
 	return nil
 }
 
+func (x *AlertMatched) GetMatches() []*ClientMatch {
+	if x != nil {
+		return x.Matches
+	}
+	return nil
+}
+
+func (x *AlertMatched) GetEvaluatedAtMs() int64 {
+	if x != nil {
+		return x.EvaluatedAtMs
+	}
+	return 0
+}
+
+// ClientMatch is the set of rules that matched an alert for one client
+type ClientMatch struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ClientId      string                 `protobuf:"bytes,1,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	RuleIds       []string               `protobuf:"bytes,2,rep,name=rule_ids,json=ruleIds,proto3" json:"rule_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClientMatch) Reset() {
+	*x = ClientMatch{}
+	mi := &file_alerts_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClientMatch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClientMatch) ProtoMessage() {}
+
+func (x *ClientMatch) ProtoReflect() protoreflect.Message {
+	mi := &file_alerts_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClientMatch.ProtoReflect.Descriptor instead.
+func (*ClientMatch) Descriptor() ([]byte, []int) {
+	return file_alerts_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ClientMatch) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+func (x *ClientMatch) GetRuleIds() []string {
+	if x != nil {
+		return x.RuleIds
+	}
+	return nil
+}
+
 var File_alerts_proto protoreflect.FileDescriptor
 
 const file_alerts_proto_rawDesc = "" +
 	"\n" +
-	"\falerts.proto\x12\x0falerting.alerts\x1a\fcommon.proto\"\xc8\x02\n" +
+	"\falerts.proto\x12\x0falerting.alerts\x1a\fcommon.proto\"\xe9\x02\n" +
 	"\bAlertNew\x12\x19\n" +
 	"\balert_id\x18\x01 \x01(\tR\aalertId\x12%\n" +
 	"\x0eschema_version\x18\x02 \x01(\x05R\rschemaVersion\x12\x19\n" +
@@ -236,10 +314,12 @@ const file_alerts_proto_rawDesc = "" +
 	"\bseverity\x18\x04 \x01(\x0e2\x19.alerting.common.SeverityR\bseverity\x12\x16\n" +
 	"\x06source\x18\x05 \x01(\tR\x06source\x12\x12\n" +
 	"\x04name\x18\x06 \x01(\tR\x04name\x12@\n" +
-	"\acontext\x18\a \x03(\v2&.alerting.alerts.AlertNew.ContextEntryR\acontext\x1a:\n" +
+	"\acontext\x18\a \x03(\v2&.alerting.alerts.AlertNew.ContextEntryR\acontext\x12\x1f\n" +
+	"\vclient_hint\x18\b \x01(\tR\n" +
+	"clientHint\x1a:\n" +
 	"\fContextEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x88\x03\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xe8\x03\n" +
 	"\fAlertMatched\x12\x19\n" +
 	"\balert_id\x18\x01 \x01(\tR\aalertId\x12%\n" +
 	"\x0eschema_version\x18\x02 \x01(\x05R\rschemaVersion\x12\x19\n" +
@@ -249,10 +329,16 @@ const file_alerts_proto_rawDesc = "" +
 	"\x04name\x18\x06 \x01(\tR\x04name\x12D\n" +
 	"\acontext\x18\a \x03(\v2*.alerting.alerts.AlertMatched.ContextEntryR\acontext\x12\x1b\n" +
 	"\tclient_id\x18\b \x01(\tR\bclientId\x12\x19\n" +
-	"\brule_ids\x18\t \x03(\tR\aruleIds\x1a:\n" +
+	"\brule_ids\x18\t \x03(\tR\aruleIds\x126\n" +
+	"\amatches\x18\n" +
+	" \x03(\v2\x1c.alerting.alerts.ClientMatchR\amatches\x12&\n" +
+	"\x0fevaluated_at_ms\x18\v \x01(\x03R\revaluatedAtMs\x1a:\n" +
 	"\fContextEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01B;Z9github.com/afikmenashe/alerting-platform/pkg/proto/alertsb\x06proto3"
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"E\n" +
+	"\vClientMatch\x12\x1b\n" +
+	"\tclient_id\x18\x01 \x01(\tR\bclientId\x12\x19\n" +
+	"\brule_ids\x18\x02 \x03(\tR\aruleIdsB;Z9github.com/afikmenashe/alerting-platform/pkg/proto/alertsb\x06proto3"
 
 var (
 	file_alerts_proto_rawDescOnce sync.Once
@@ -266,24 +352,26 @@ func file_alerts_proto_rawDescGZIP() []byte {
 	return file_alerts_proto_rawDescData
 }
 
-var file_alerts_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_alerts_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
 var file_alerts_proto_goTypes = []any{
 	(*AlertNew)(nil),     // 0: alerting.alerts.AlertNew
 	(*AlertMatched)(nil), // 1: alerting.alerts.AlertMatched
-	nil,                  // 2: alerting.alerts.AlertNew.ContextEntry
-	nil,                  // 3: alerting.alerts.AlertMatched.ContextEntry
-	(common.Severity)(0), // 4: alerting.common.Severity
+	(*ClientMatch)(nil),  // 2: alerting.alerts.ClientMatch
+	nil,                  // 3: alerting.alerts.AlertNew.ContextEntry
+	nil,                  // 4: alerting.alerts.AlertMatched.ContextEntry
+	(common.Severity)(0), // 5: alerting.common.Severity
 }
 var file_alerts_proto_depIdxs = []int32{
-	4, // 0: alerting.alerts.AlertNew.severity:type_name -> alerting.common.Severity
-	2, // 1: alerting.alerts.AlertNew.context:type_name -> alerting.alerts.AlertNew.ContextEntry
-	4, // 2: alerting.alerts.AlertMatched.severity:type_name -> alerting.common.Severity
-	3, // 3: alerting.alerts.AlertMatched.context:type_name -> alerting.alerts.AlertMatched.ContextEntry
-	4, // [4:4] is the sub-list for method output_type
-	4, // [4:4] is the sub-list for method input_type
-	4, // [4:4] is the sub-list for extension type_name
-	4, // [4:4] is the sub-list for extension extendee
-	0, // [0:4] is the sub-list for field type_name
+	5, // 0: alerting.alerts.AlertNew.severity:type_name -> alerting.common.Severity
+	3, // 1: alerting.alerts.AlertNew.context:type_name -> alerting.alerts.AlertNew.ContextEntry
+	5, // 2: alerting.alerts.AlertMatched.severity:type_name -> alerting.common.Severity
+	4, // 3: alerting.alerts.AlertMatched.context:type_name -> alerting.alerts.AlertMatched.ContextEntry
+	2, // 4: alerting.alerts.AlertMatched.matches:type_name -> alerting.alerts.ClientMatch
+	5, // [5:5] is the sub-list for method output_type
+	5, // [5:5] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
 }
 
 func init() { file_alerts_proto_init() }
@@ -297,7 +385,7 @@ func file_alerts_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_alerts_proto_rawDesc), len(file_alerts_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   4,
+			NumMessages:   5,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
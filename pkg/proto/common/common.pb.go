@@ -87,6 +87,7 @@ const (
 	RuleAction_RULE_ACTION_UPDATED     RuleAction = 2
 	RuleAction_RULE_ACTION_DELETED     RuleAction = 3
 	RuleAction_RULE_ACTION_DISABLED    RuleAction = 4
+	RuleAction_RULE_ACTION_REBUILD     RuleAction = 5 // Many rules changed at once (bulk import); rule_id is empty
 )
 
 // Enum value maps for RuleAction.
@@ -97,6 +98,7 @@ var (
 		2: "RULE_ACTION_UPDATED",
 		3: "RULE_ACTION_DELETED",
 		4: "RULE_ACTION_DISABLED",
+		5: "RULE_ACTION_REBUILD",
 	}
 	RuleAction_value = map[string]int32{
 		"RULE_ACTION_UNSPECIFIED": 0,
@@ -104,6 +106,7 @@ var (
 		"RULE_ACTION_UPDATED":     2,
 		"RULE_ACTION_DELETED":     3,
 		"RULE_ACTION_DISABLED":    4,
+		"RULE_ACTION_REBUILD":     5,
 	}
 )
 
@@ -145,14 +148,15 @@ const file_common_proto_rawDesc = "" +
 	"\n" +
 	"\x06MEDIUM\x10\x02\x12\b\n" +
 	"\x04HIGH\x10\x03\x12\f\n" +
-	"\bCRITICAL\x10\x04*\x8e\x01\n" +
+	"\bCRITICAL\x10\x04*\xa7\x01\n" +
 	"\n" +
 	"RuleAction\x12\x1b\n" +
 	"\x17RULE_ACTION_UNSPECIFIED\x10\x00\x12\x17\n" +
 	"\x13RULE_ACTION_CREATED\x10\x01\x12\x17\n" +
 	"\x13RULE_ACTION_UPDATED\x10\x02\x12\x17\n" +
 	"\x13RULE_ACTION_DELETED\x10\x03\x12\x18\n" +
-	"\x14RULE_ACTION_DISABLED\x10\x04B;Z9github.com/afikmenashe/alerting-platform/pkg/proto/commonb\x06proto3"
+	"\x14RULE_ACTION_DISABLED\x10\x04\x12\x17\n" +
+	"\x13RULE_ACTION_REBUILD\x10\x05B;Z9github.com/afikmenashe/alerting-platform/pkg/proto/commonb\x06proto3"
 
 var (
 	file_common_proto_rawDescOnce sync.Once
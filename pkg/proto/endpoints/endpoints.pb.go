@@ -0,0 +1,268 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: endpoints.proto
+
+package endpoints
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Endpoint change actions
+type EndpointAction int32
+
+const (
+	EndpointAction_ENDPOINT_ACTION_UNSPECIFIED EndpointAction = 0
+	EndpointAction_ENDPOINT_ACTION_CREATED     EndpointAction = 1
+	EndpointAction_ENDPOINT_ACTION_UPDATED     EndpointAction = 2
+	EndpointAction_ENDPOINT_ACTION_DELETED     EndpointAction = 3
+	EndpointAction_ENDPOINT_ACTION_ENABLED     EndpointAction = 4
+	EndpointAction_ENDPOINT_ACTION_DISABLED    EndpointAction = 5
+)
+
+// Enum value maps for EndpointAction.
+var (
+	EndpointAction_name = map[int32]string{
+		0: "ENDPOINT_ACTION_UNSPECIFIED",
+		1: "ENDPOINT_ACTION_CREATED",
+		2: "ENDPOINT_ACTION_UPDATED",
+		3: "ENDPOINT_ACTION_DELETED",
+		4: "ENDPOINT_ACTION_ENABLED",
+		5: "ENDPOINT_ACTION_DISABLED",
+	}
+	EndpointAction_value = map[string]int32{
+		"ENDPOINT_ACTION_UNSPECIFIED": 0,
+		"ENDPOINT_ACTION_CREATED":     1,
+		"ENDPOINT_ACTION_UPDATED":     2,
+		"ENDPOINT_ACTION_DELETED":     3,
+		"ENDPOINT_ACTION_ENABLED":     4,
+		"ENDPOINT_ACTION_DISABLED":    5,
+	}
+)
+
+func (x EndpointAction) Enum() *EndpointAction {
+	p := new(EndpointAction)
+	*p = x
+	return p
+}
+
+func (x EndpointAction) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (EndpointAction) Descriptor() protoreflect.EnumDescriptor {
+	return file_endpoints_proto_enumTypes[0].Descriptor()
+}
+
+func (EndpointAction) Type() protoreflect.EnumType {
+	return &file_endpoints_proto_enumTypes[0]
+}
+
+func (x EndpointAction) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use EndpointAction.Descriptor instead.
+func (EndpointAction) EnumDescriptor() ([]byte, []int) {
+	return file_endpoints_proto_rawDescGZIP(), []int{0}
+}
+
+// EndpointChanged represents an endpoint change event (endpoint.changed topic).
+// The endpoint's value and metadata are left out: they hold addresses and secrets.
+type EndpointChanged struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EndpointId    string                 `protobuf:"bytes,1,opt,name=endpoint_id,json=endpointId,proto3" json:"endpoint_id,omitempty"`               // UUID of the endpoint
+	RuleId        string                 `protobuf:"bytes,2,opt,name=rule_id,json=ruleId,proto3" json:"rule_id,omitempty"`                           // UUID of the rule the endpoint belongs to
+	ClientId      string                 `protobuf:"bytes,3,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`                     // Client ID the rule belongs to (empty if deleted with the rule)
+	Action        EndpointAction         `protobuf:"varint,4,opt,name=action,proto3,enum=alerting.endpoints.EndpointAction" json:"action,omitempty"` // CREATED, UPDATED, DELETED, ENABLED, DISABLED
+	Type          string                 `protobuf:"bytes,5,opt,name=type,proto3" json:"type,omitempty"`                                             // email, slack, webhook
+	Enabled       bool                   `protobuf:"varint,6,opt,name=enabled,proto3" json:"enabled,omitempty"`                                      // Whether the endpoint is enabled after the change
+	ChangedAt     int64                  `protobuf:"varint,7,opt,name=changed_at,json=changedAt,proto3" json:"changed_at,omitempty"`                 // Unix timestamp of the change
+	SchemaVersion int32                  `protobuf:"varint,8,opt,name=schema_version,json=schemaVersion,proto3" json:"schema_version,omitempty"`     // Schema version (currently 1)
+	PublishedAtMs int64                  `protobuf:"varint,9,opt,name=published_at_ms,json=publishedAtMs,proto3" json:"published_at_ms,omitempty"`   // Unix milliseconds when rule-service published the event
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EndpointChanged) Reset() {
+	*x = EndpointChanged{}
+	mi := &file_endpoints_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EndpointChanged) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EndpointChanged) ProtoMessage() {}
+
+func (x *EndpointChanged) ProtoReflect() protoreflect.Message {
+	mi := &file_endpoints_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EndpointChanged.ProtoReflect.Descriptor instead.
+func (*EndpointChanged) Descriptor() ([]byte, []int) {
+	return file_endpoints_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *EndpointChanged) GetEndpointId() string {
+	if x != nil {
+		return x.EndpointId
+	}
+	return ""
+}
+
+func (x *EndpointChanged) GetRuleId() string {
+	if x != nil {
+		return x.RuleId
+	}
+	return ""
+}
+
+func (x *EndpointChanged) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+func (x *EndpointChanged) GetAction() EndpointAction {
+	if x != nil {
+		return x.Action
+	}
+	return EndpointAction_ENDPOINT_ACTION_UNSPECIFIED
+}
+
+func (x *EndpointChanged) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *EndpointChanged) GetEnabled() bool {
+	if x != nil {
+		return x.Enabled
+	}
+	return false
+}
+
+func (x *EndpointChanged) GetChangedAt() int64 {
+	if x != nil {
+		return x.ChangedAt
+	}
+	return 0
+}
+
+func (x *EndpointChanged) GetSchemaVersion() int32 {
+	if x != nil {
+		return x.SchemaVersion
+	}
+	return 0
+}
+
+func (x *EndpointChanged) GetPublishedAtMs() int64 {
+	if x != nil {
+		return x.PublishedAtMs
+	}
+	return 0
+}
+
+var File_endpoints_proto protoreflect.FileDescriptor
+
+const file_endpoints_proto_rawDesc = "" +
+	"\n" +
+	"\x0fendpoints.proto\x12\x12alerting.endpoints\"\xc0\x02\n" +
+	"\x0fEndpointChanged\x12\x1f\n" +
+	"\vendpoint_id\x18\x01 \x01(\tR\n" +
+	"endpointId\x12\x17\n" +
+	"\arule_id\x18\x02 \x01(\tR\x06ruleId\x12\x1b\n" +
+	"\tclient_id\x18\x03 \x01(\tR\bclientId\x12:\n" +
+	"\x06action\x18\x04 \x01(\x0e2\".alerting.endpoints.EndpointActionR\x06action\x12\x12\n" +
+	"\x04type\x18\x05 \x01(\tR\x04type\x12\x18\n" +
+	"\aenabled\x18\x06 \x01(\bR\aenabled\x12\x1d\n" +
+	"\n" +
+	"changed_at\x18\a \x01(\x03R\tchangedAt\x12%\n" +
+	"\x0eschema_version\x18\b \x01(\x05R\rschemaVersion\x12&\n" +
+	"\x0fpublished_at_ms\x18\t \x01(\x03R\rpublishedAtMs*\xc3\x01\n" +
+	"\x0eEndpointAction\x12\x1f\n" +
+	"\x1bENDPOINT_ACTION_UNSPECIFIED\x10\x00\x12\x1b\n" +
+	"\x17ENDPOINT_ACTION_CREATED\x10\x01\x12\x1b\n" +
+	"\x17ENDPOINT_ACTION_UPDATED\x10\x02\x12\x1b\n" +
+	"\x17ENDPOINT_ACTION_DELETED\x10\x03\x12\x1b\n" +
+	"\x17ENDPOINT_ACTION_ENABLED\x10\x04\x12\x1c\n" +
+	"\x18ENDPOINT_ACTION_DISABLED\x10\x05B>Z<github.com/afikmenashe/alerting-platform/pkg/proto/endpointsb\x06proto3"
+
+var (
+	file_endpoints_proto_rawDescOnce sync.Once
+	file_endpoints_proto_rawDescData []byte
+)
+
+func file_endpoints_proto_rawDescGZIP() []byte {
+	file_endpoints_proto_rawDescOnce.Do(func() {
+		file_endpoints_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_endpoints_proto_rawDesc), len(file_endpoints_proto_rawDesc)))
+	})
+	return file_endpoints_proto_rawDescData
+}
+
+var file_endpoints_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_endpoints_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_endpoints_proto_goTypes = []any{
+	(EndpointAction)(0),     // 0: alerting.endpoints.EndpointAction
+	(*EndpointChanged)(nil), // 1: alerting.endpoints.EndpointChanged
+}
+var file_endpoints_proto_depIdxs = []int32{
+	0, // 0: alerting.endpoints.EndpointChanged.action:type_name -> alerting.endpoints.EndpointAction
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_endpoints_proto_init() }
+func file_endpoints_proto_init() {
+	if File_endpoints_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_endpoints_proto_rawDesc), len(file_endpoints_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_endpoints_proto_goTypes,
+		DependencyIndexes: file_endpoints_proto_depIdxs,
+		EnumInfos:         file_endpoints_proto_enumTypes,
+		MessageInfos:      file_endpoints_proto_msgTypes,
+	}.Build()
+	File_endpoints_proto = out.File
+	file_endpoints_proto_goTypes = nil
+	file_endpoints_proto_depIdxs = nil
+}
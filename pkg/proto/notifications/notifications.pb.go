@@ -24,10 +24,14 @@ const (
 // NotificationReady represents a notification ready event (notifications.ready topic)
 type NotificationReady struct {
 	state          protoimpl.MessageState `protogen:"open.v1"`
-	NotificationId string                 `protobuf:"bytes,1,opt,name=notification_id,json=notificationId,proto3" json:"notification_id,omitempty"` // UUID of the notification
-	ClientId       string                 `protobuf:"bytes,2,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`                   // Client ID
-	AlertId        string                 `protobuf:"bytes,3,opt,name=alert_id,json=alertId,proto3" json:"alert_id,omitempty"`                      // Alert ID
-	SchemaVersion  int32                  `protobuf:"varint,4,opt,name=schema_version,json=schemaVersion,proto3" json:"schema_version,omitempty"`   // Schema version (currently 1)
+	NotificationId string                 `protobuf:"bytes,1,opt,name=notification_id,json=notificationId,proto3" json:"notification_id,omitempty"`    // UUID of the notification
+	ClientId       string                 `protobuf:"bytes,2,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`                      // Client ID
+	AlertId        string                 `protobuf:"bytes,3,opt,name=alert_id,json=alertId,proto3" json:"alert_id,omitempty"`                         // Alert ID
+	SchemaVersion  int32                  `protobuf:"varint,4,opt,name=schema_version,json=schemaVersion,proto3" json:"schema_version,omitempty"`      // Schema version (currently 1)
+	Priority       int32                  `protobuf:"varint,5,opt,name=priority,proto3" json:"priority,omitempty"`                                     // Delivery priority from severity (4 CRITICAL .. 1 LOW, 0 unknown)
+	EvaluatedAtMs  int64                  `protobuf:"varint,6,opt,name=evaluated_at_ms,json=evaluatedAtMs,proto3" json:"evaluated_at_ms,omitempty"`    // When the evaluator matched the alert (Unix milliseconds)
+	AggregatedAtMs int64                  `protobuf:"varint,7,opt,name=aggregated_at_ms,json=aggregatedAtMs,proto3" json:"aggregated_at_ms,omitempty"` // When the aggregator stored the notification (Unix milliseconds)
+	ReadyAtMs      int64                  `protobuf:"varint,8,opt,name=ready_at_ms,json=readyAtMs,proto3" json:"ready_at_ms,omitempty"`                // When the aggregator published this event (Unix milliseconds)
 	unknownFields  protoimpl.UnknownFields
 	sizeCache      protoimpl.SizeCache
 }
@@ -90,16 +94,159 @@ func (x *NotificationReady) GetSchemaVersion() int32 {
 	return 0
 }
 
+func (x *NotificationReady) GetPriority() int32 {
+	if x != nil {
+		return x.Priority
+	}
+	return 0
+}
+
+func (x *NotificationReady) GetEvaluatedAtMs() int64 {
+	if x != nil {
+		return x.EvaluatedAtMs
+	}
+	return 0
+}
+
+func (x *NotificationReady) GetAggregatedAtMs() int64 {
+	if x != nil {
+		return x.AggregatedAtMs
+	}
+	return 0
+}
+
+func (x *NotificationReady) GetReadyAtMs() int64 {
+	if x != nil {
+		return x.ReadyAtMs
+	}
+	return 0
+}
+
+// NotificationGrouped represents a digest of a client's notifications (notifications.grouped topic)
+type NotificationGrouped struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	NotificationId  string                 `protobuf:"bytes,1,opt,name=notification_id,json=notificationId,proto3" json:"notification_id,omitempty"`    // UUID of the digest notification
+	ClientId        string                 `protobuf:"bytes,2,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`                      // Client ID
+	GroupId         string                 `protobuf:"bytes,3,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`                         // Group ID (one per client and window)
+	NotificationIds []string               `protobuf:"bytes,4,rep,name=notification_ids,json=notificationIds,proto3" json:"notification_ids,omitempty"` // UUIDs of the grouped notifications
+	WindowStart     int64                  `protobuf:"varint,5,opt,name=window_start,json=windowStart,proto3" json:"window_start,omitempty"`            // Window start (Unix seconds)
+	WindowEnd       int64                  `protobuf:"varint,6,opt,name=window_end,json=windowEnd,proto3" json:"window_end,omitempty"`                  // Window end (Unix seconds)
+	SchemaVersion   int32                  `protobuf:"varint,7,opt,name=schema_version,json=schemaVersion,proto3" json:"schema_version,omitempty"`      // Schema version (currently 1)
+	Priority        int32                  `protobuf:"varint,8,opt,name=priority,proto3" json:"priority,omitempty"`                                     // Delivery priority of the most severe grouped alert
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *NotificationGrouped) Reset() {
+	*x = NotificationGrouped{}
+	mi := &file_notifications_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NotificationGrouped) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NotificationGrouped) ProtoMessage() {}
+
+func (x *NotificationGrouped) ProtoReflect() protoreflect.Message {
+	mi := &file_notifications_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NotificationGrouped.ProtoReflect.Descriptor instead.
+func (*NotificationGrouped) Descriptor() ([]byte, []int) {
+	return file_notifications_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *NotificationGrouped) GetNotificationId() string {
+	if x != nil {
+		return x.NotificationId
+	}
+	return ""
+}
+
+func (x *NotificationGrouped) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+func (x *NotificationGrouped) GetGroupId() string {
+	if x != nil {
+		return x.GroupId
+	}
+	return ""
+}
+
+func (x *NotificationGrouped) GetNotificationIds() []string {
+	if x != nil {
+		return x.NotificationIds
+	}
+	return nil
+}
+
+func (x *NotificationGrouped) GetWindowStart() int64 {
+	if x != nil {
+		return x.WindowStart
+	}
+	return 0
+}
+
+func (x *NotificationGrouped) GetWindowEnd() int64 {
+	if x != nil {
+		return x.WindowEnd
+	}
+	return 0
+}
+
+func (x *NotificationGrouped) GetSchemaVersion() int32 {
+	if x != nil {
+		return x.SchemaVersion
+	}
+	return 0
+}
+
+func (x *NotificationGrouped) GetPriority() int32 {
+	if x != nil {
+		return x.Priority
+	}
+	return 0
+}
+
 var File_notifications_proto protoreflect.FileDescriptor
 
 const file_notifications_proto_rawDesc = "" +
 	"\n" +
-	"\x13notifications.proto\x12\x16alerting.notifications\"\x9b\x01\n" +
+	"\x13notifications.proto\x12\x16alerting.notifications\"\xa9\x02\n" +
 	"\x11NotificationReady\x12'\n" +
 	"\x0fnotification_id\x18\x01 \x01(\tR\x0enotificationId\x12\x1b\n" +
 	"\tclient_id\x18\x02 \x01(\tR\bclientId\x12\x19\n" +
 	"\balert_id\x18\x03 \x01(\tR\aalertId\x12%\n" +
-	"\x0eschema_version\x18\x04 \x01(\x05R\rschemaVersionBBZ@github.com/afikmenashe/alerting-platform/pkg/proto/notificationsb\x06proto3"
+	"\x0eschema_version\x18\x04 \x01(\x05R\rschemaVersion\x12\x1a\n" +
+	"\bpriority\x18\x05 \x01(\x05R\bpriority\x12&\n" +
+	"\x0fevaluated_at_ms\x18\x06 \x01(\x03R\revaluatedAtMs\x12(\n" +
+	"\x10aggregated_at_ms\x18\a \x01(\x03R\x0eaggregatedAtMs\x12\x1e\n" +
+	"\vready_at_ms\x18\b \x01(\x03R\treadyAtMs\"\xa6\x02\n" +
+	"\x13NotificationGrouped\x12'\n" +
+	"\x0fnotification_id\x18\x01 \x01(\tR\x0enotificationId\x12\x1b\n" +
+	"\tclient_id\x18\x02 \x01(\tR\bclientId\x12\x19\n" +
+	"\bgroup_id\x18\x03 \x01(\tR\agroupId\x12)\n" +
+	"\x10notification_ids\x18\x04 \x03(\tR\x0fnotificationIds\x12!\n" +
+	"\fwindow_start\x18\x05 \x01(\x03R\vwindowStart\x12\x1d\n" +
+	"\n" +
+	"window_end\x18\x06 \x01(\x03R\twindowEnd\x12%\n" +
+	"\x0eschema_version\x18\a \x01(\x05R\rschemaVersion\x12\x1a\n" +
+	"\bpriority\x18\b \x01(\x05R\bpriorityBBZ@github.com/afikmenashe/alerting-platform/pkg/proto/notificationsb\x06proto3"
 
 var (
 	file_notifications_proto_rawDescOnce sync.Once
@@ -113,9 +260,10 @@ func file_notifications_proto_rawDescGZIP() []byte {
 	return file_notifications_proto_rawDescData
 }
 
-var file_notifications_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_notifications_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
 var file_notifications_proto_goTypes = []any{
-	(*NotificationReady)(nil), // 0: alerting.notifications.NotificationReady
+	(*NotificationReady)(nil),   // 0: alerting.notifications.NotificationReady
+	(*NotificationGrouped)(nil), // 1: alerting.notifications.NotificationGrouped
 }
 var file_notifications_proto_depIdxs = []int32{
 	0, // [0:0] is the sub-list for method output_type
@@ -136,7 +284,7 @@ func file_notifications_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_notifications_proto_rawDesc), len(file_notifications_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   1,
+			NumMessages:   2,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -25,12 +25,14 @@ const (
 // RuleChanged represents a rule change event (rule.changed topic)
 type RuleChanged struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	RuleId        string                 `protobuf:"bytes,1,opt,name=rule_id,json=ruleId,proto3" json:"rule_id,omitempty"`                       // UUID of the rule
-	ClientId      string                 `protobuf:"bytes,2,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`                 // Client ID the rule belongs to
-	Action        common.RuleAction      `protobuf:"varint,3,opt,name=action,proto3,enum=alerting.common.RuleAction" json:"action,omitempty"`    // CREATED, UPDATED, DELETED, DISABLED
-	Version       int32                  `protobuf:"varint,4,opt,name=version,proto3" json:"version,omitempty"`                                  // Rule version (optimistic locking)
-	UpdatedAt     int64                  `protobuf:"varint,5,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`             // Unix timestamp
-	SchemaVersion int32                  `protobuf:"varint,6,opt,name=schema_version,json=schemaVersion,proto3" json:"schema_version,omitempty"` // Schema version (currently 1)
+	RuleId        string                 `protobuf:"bytes,1,opt,name=rule_id,json=ruleId,proto3" json:"rule_id,omitempty"`                         // UUID of the rule
+	ClientId      string                 `protobuf:"bytes,2,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`                   // Client ID the rule belongs to
+	Action        common.RuleAction      `protobuf:"varint,3,opt,name=action,proto3,enum=alerting.common.RuleAction" json:"action,omitempty"`      // CREATED, UPDATED, DELETED, DISABLED
+	Version       int32                  `protobuf:"varint,4,opt,name=version,proto3" json:"version,omitempty"`                                    // Rule version (optimistic locking)
+	UpdatedAt     int64                  `protobuf:"varint,5,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`               // Unix timestamp
+	SchemaVersion int32                  `protobuf:"varint,6,opt,name=schema_version,json=schemaVersion,proto3" json:"schema_version,omitempty"`   // Schema version (currently 1)
+	Rule          *RulePayload           `protobuf:"bytes,7,opt,name=rule,proto3" json:"rule,omitempty"`                                           // Rule fields after the change (unset on DELETED)
+	PublishedAtMs int64                  `protobuf:"varint,8,opt,name=published_at_ms,json=publishedAtMs,proto3" json:"published_at_ms,omitempty"` // Unix milliseconds when rule-service published the event
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -107,11 +109,180 @@ func (x *RuleChanged) GetSchemaVersion() int32 {
 	return 0
 }
 
+func (x *RuleChanged) GetRule() *RulePayload {
+	if x != nil {
+		return x.Rule
+	}
+	return nil
+}
+
+func (x *RuleChanged) GetPublishedAtMs() int64 {
+	if x != nil {
+		return x.PublishedAtMs
+	}
+	return 0
+}
+
+// RulePayload carries the matching fields of a rule so consumers can apply a change
+// without reading the rule back from the database
+type RulePayload struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Severity       string                 `protobuf:"bytes,1,opt,name=severity,proto3" json:"severity,omitempty"`                                   // LOW, MEDIUM, HIGH, CRITICAL, or * (wildcard)
+	Source         string                 `protobuf:"bytes,2,opt,name=source,proto3" json:"source,omitempty"`                                       // Alert source or * (wildcard)
+	Name           string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`                                           // Alert name or * (wildcard)
+	Enabled        bool                   `protobuf:"varint,4,opt,name=enabled,proto3" json:"enabled,omitempty"`                                    // Whether the rule is enabled
+	ExcludeSources []string               `protobuf:"bytes,5,rep,name=exclude_sources,json=excludeSources,proto3" json:"exclude_sources,omitempty"` // Sources the rule never matches (with source *)
+	ExcludeNames   []string               `protobuf:"bytes,6,rep,name=exclude_names,json=excludeNames,proto3" json:"exclude_names,omitempty"`       // Names the rule never matches (with name *)
+	Conditions     []*ContextCondition    `protobuf:"bytes,7,rep,name=conditions,proto3" json:"conditions,omitempty"`                               // Conditions on alert context keys, all of which must hold
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *RulePayload) Reset() {
+	*x = RulePayload{}
+	mi := &file_rules_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RulePayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RulePayload) ProtoMessage() {}
+
+func (x *RulePayload) ProtoReflect() protoreflect.Message {
+	mi := &file_rules_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RulePayload.ProtoReflect.Descriptor instead.
+func (*RulePayload) Descriptor() ([]byte, []int) {
+	return file_rules_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RulePayload) GetSeverity() string {
+	if x != nil {
+		return x.Severity
+	}
+	return ""
+}
+
+func (x *RulePayload) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *RulePayload) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *RulePayload) GetEnabled() bool {
+	if x != nil {
+		return x.Enabled
+	}
+	return false
+}
+
+func (x *RulePayload) GetExcludeSources() []string {
+	if x != nil {
+		return x.ExcludeSources
+	}
+	return nil
+}
+
+func (x *RulePayload) GetExcludeNames() []string {
+	if x != nil {
+		return x.ExcludeNames
+	}
+	return nil
+}
+
+func (x *RulePayload) GetConditions() []*ContextCondition {
+	if x != nil {
+		return x.Conditions
+	}
+	return nil
+}
+
+// ContextCondition matches an alert context value, e.g. region == eu-west-1
+type ContextCondition struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`     // Alert context key
+	Op            string                 `protobuf:"bytes,2,opt,name=op,proto3" json:"op,omitempty"`       // == or !=
+	Value         string                 `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"` // Value compared with the context value
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ContextCondition) Reset() {
+	*x = ContextCondition{}
+	mi := &file_rules_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ContextCondition) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ContextCondition) ProtoMessage() {}
+
+func (x *ContextCondition) ProtoReflect() protoreflect.Message {
+	mi := &file_rules_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ContextCondition.ProtoReflect.Descriptor instead.
+func (*ContextCondition) Descriptor() ([]byte, []int) {
+	return file_rules_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ContextCondition) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *ContextCondition) GetOp() string {
+	if x != nil {
+		return x.Op
+	}
+	return ""
+}
+
+func (x *ContextCondition) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
 var File_rules_proto protoreflect.FileDescriptor
 
 const file_rules_proto_rawDesc = "" +
 	"\n" +
-	"\vrules.proto\x12\x0ealerting.rules\x1a\fcommon.proto\"\xd8\x01\n" +
+	"\vrules.proto\x12\x0ealerting.rules\x1a\fcommon.proto\"\xb1\x02\n" +
 	"\vRuleChanged\x12\x17\n" +
 	"\arule_id\x18\x01 \x01(\tR\x06ruleId\x12\x1b\n" +
 	"\tclient_id\x18\x02 \x01(\tR\bclientId\x123\n" +
@@ -119,7 +290,23 @@ const file_rules_proto_rawDesc = "" +
 	"\aversion\x18\x04 \x01(\x05R\aversion\x12\x1d\n" +
 	"\n" +
 	"updated_at\x18\x05 \x01(\x03R\tupdatedAt\x12%\n" +
-	"\x0eschema_version\x18\x06 \x01(\x05R\rschemaVersionB:Z8github.com/afikmenashe/alerting-platform/pkg/proto/rulesb\x06proto3"
+	"\x0eschema_version\x18\x06 \x01(\x05R\rschemaVersion\x12/\n" +
+	"\x04rule\x18\a \x01(\v2\x1b.alerting.rules.RulePayloadR\x04rule\x12&\n" +
+	"\x0fpublished_at_ms\x18\b \x01(\x03R\rpublishedAtMs\"\xff\x01\n" +
+	"\vRulePayload\x12\x1a\n" +
+	"\bseverity\x18\x01 \x01(\tR\bseverity\x12\x16\n" +
+	"\x06source\x18\x02 \x01(\tR\x06source\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12\x18\n" +
+	"\aenabled\x18\x04 \x01(\bR\aenabled\x12'\n" +
+	"\x0fexclude_sources\x18\x05 \x03(\tR\x0eexcludeSources\x12#\n" +
+	"\rexclude_names\x18\x06 \x03(\tR\fexcludeNames\x12@\n" +
+	"\n" +
+	"conditions\x18\a \x03(\v2 .alerting.rules.ContextConditionR\n" +
+	"conditions\"J\n" +
+	"\x10ContextCondition\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x0e\n" +
+	"\x02op\x18\x02 \x01(\tR\x02op\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\tR\x05valueB:Z8github.com/afikmenashe/alerting-platform/pkg/proto/rulesb\x06proto3"
 
 var (
 	file_rules_proto_rawDescOnce sync.Once
@@ -133,18 +320,22 @@ func file_rules_proto_rawDescGZIP() []byte {
 	return file_rules_proto_rawDescData
 }
 
-var file_rules_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_rules_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
 var file_rules_proto_goTypes = []any{
-	(*RuleChanged)(nil),    // 0: alerting.rules.RuleChanged
-	(common.RuleAction)(0), // 1: alerting.common.RuleAction
+	(*RuleChanged)(nil),      // 0: alerting.rules.RuleChanged
+	(*RulePayload)(nil),      // 1: alerting.rules.RulePayload
+	(*ContextCondition)(nil), // 2: alerting.rules.ContextCondition
+	(common.RuleAction)(0),   // 3: alerting.common.RuleAction
 }
 var file_rules_proto_depIdxs = []int32{
-	1, // 0: alerting.rules.RuleChanged.action:type_name -> alerting.common.RuleAction
-	1, // [1:1] is the sub-list for method output_type
-	1, // [1:1] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	3, // 0: alerting.rules.RuleChanged.action:type_name -> alerting.common.RuleAction
+	1, // 1: alerting.rules.RuleChanged.rule:type_name -> alerting.rules.RulePayload
+	2, // 2: alerting.rules.RulePayload.conditions:type_name -> alerting.rules.ContextCondition
+	3, // [3:3] is the sub-list for method output_type
+	3, // [3:3] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
 }
 
 func init() { file_rules_proto_init() }
@@ -158,7 +349,7 @@ func file_rules_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_rules_proto_rawDesc), len(file_rules_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   1,
+			NumMessages:   3,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -0,0 +1,234 @@
+package shared
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// APIKeyPrefix starts every issued API key, so leaked keys are easy to recognize.
+const APIKeyPrefix = "ak_"
+
+// apiKeyRandomBytes is the entropy of an issued API key.
+const apiKeyRandomBytes = 24
+
+// apiKeyDisplayChars is how many characters after APIKeyPrefix are kept to recognize a key.
+const apiKeyDisplayChars = 8
+
+// DefaultAPIKeyCacheTTL is how long a valid key is trusted before it is looked up again,
+// which bounds how long a revoked key keeps working.
+const DefaultAPIKeyCacheTTL = 30 * time.Second
+
+// APIPrincipal is the caller authenticated by an API key.
+type APIPrincipal struct {
+	KeyID    string
+	Name     string
+	ClientID string // empty for admin keys
+}
+
+// Admin reports whether the key is an admin key, which is not scoped to a client.
+func (p APIPrincipal) Admin() bool {
+	return p.ClientID == ""
+}
+
+// CanAccessClient reports whether p may access the resources of clientID.
+func (p APIPrincipal) CanAccessClient(clientID string) bool {
+	return p.Admin() || p.ClientID == clientID
+}
+
+// GenerateAPIKey returns a new random API key.
+func GenerateAPIKey() (string, error) {
+	b := make([]byte, apiKeyRandomBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	return APIKeyPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashAPIKey returns the hex SHA-256 digest under which a key is stored.
+func HashAPIKey(key string) string {
+	digest := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(digest[:])
+}
+
+// APIKeyDisplayPrefix returns the start of key that is stored in the clear to recognize it.
+func APIKeyDisplayPrefix(key string) string {
+	n := len(APIKeyPrefix) + apiKeyDisplayChars
+	if len(key) < n {
+		return key
+	}
+	return key[:n]
+}
+
+// APIKeyStore looks up issued API keys.
+type APIKeyStore interface {
+	// LookupAPIKey returns the principal of the key with the given digest (see HashAPIKey),
+	// or nil if there is no such key or it was revoked.
+	LookupAPIKey(ctx context.Context, keyHash string) (*APIPrincipal, error)
+}
+
+// SQLAPIKeyStore reads the api_keys table. The caller registers the Postgres driver.
+type SQLAPIKeyStore struct {
+	db *sql.DB
+}
+
+var _ APIKeyStore = (*SQLAPIKeyStore)(nil)
+
+// NewSQLAPIKeyStore creates a store reading api_keys through db.
+func NewSQLAPIKeyStore(db *sql.DB) *SQLAPIKeyStore {
+	return &SQLAPIKeyStore{db: db}
+}
+
+// LookupAPIKey returns the principal of the unrevoked key with the given digest, or nil.
+func (s *SQLAPIKeyStore) LookupAPIKey(ctx context.Context, keyHash string) (*APIPrincipal, error) {
+	var p APIPrincipal
+	err := s.db.QueryRowContext(ctx, `
+		SELECT key_id, name, COALESCE(client_id, '')
+		FROM api_keys
+		WHERE key_hash = $1 AND revoked_at IS NULL
+	`, keyHash).Scan(&p.KeyID, &p.Name, &p.ClientID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up api key: %w", err)
+	}
+	return &p, nil
+}
+
+// cachedPrincipal is a valid key remembered by APIKeyAuthenticator.
+type cachedPrincipal struct {
+	principal APIPrincipal
+	expires   time.Time
+}
+
+// APIKeyAuthenticator validates API keys against a store, caching valid keys for a TTL.
+// Unknown keys are not cached, so a flood of bad keys cannot grow the cache.
+type APIKeyAuthenticator struct {
+	store     APIKeyStore
+	adminHash string
+	ttl       time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedPrincipal
+}
+
+// NewAPIKeyAuthenticator creates an authenticator backed by store. adminKey, if not empty, is
+// a static admin key accepted without a lookup, for bootstrapping the first issued keys.
+// A non-positive cacheTTL uses DefaultAPIKeyCacheTTL.
+func NewAPIKeyAuthenticator(store APIKeyStore, adminKey string, cacheTTL time.Duration) *APIKeyAuthenticator {
+	if cacheTTL <= 0 {
+		cacheTTL = DefaultAPIKeyCacheTTL
+	}
+	a := &APIKeyAuthenticator{
+		store: store,
+		ttl:   cacheTTL,
+		cache: make(map[string]cachedPrincipal),
+	}
+	if adminKey != "" {
+		a.adminHash = HashAPIKey(adminKey)
+	}
+	return a
+}
+
+// Authenticate returns the principal of key, or nil if the key is unknown or revoked.
+func (a *APIKeyAuthenticator) Authenticate(ctx context.Context, key string) (*APIPrincipal, error) {
+	if key == "" {
+		return nil, nil
+	}
+	hash := HashAPIKey(key)
+	if a.adminHash != "" && hash == a.adminHash {
+		return &APIPrincipal{KeyID: "static", Name: "admin"}, nil
+	}
+
+	now := time.Now()
+	a.mu.Lock()
+	cached, ok := a.cache[hash]
+	if ok && now.After(cached.expires) {
+		delete(a.cache, hash)
+		ok = false
+	}
+	a.mu.Unlock()
+	if ok {
+		p := cached.principal
+		return &p, nil
+	}
+
+	if a.store == nil {
+		return nil, nil
+	}
+	p, err := a.store.LookupAPIKey(ctx, hash)
+	if err != nil || p == nil {
+		return nil, err
+	}
+	a.mu.Lock()
+	a.cache[hash] = cachedPrincipal{principal: *p, expires: now.Add(a.ttl)}
+	a.mu.Unlock()
+	return p, nil
+}
+
+// APIKeyFromRequest returns the key of r, read from an "Authorization: Bearer" header
+// or the X-API-Key header.
+func APIKeyFromRequest(r *http.Request) string {
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(bearer)
+	}
+	return strings.TrimSpace(r.Header.Get("X-API-Key"))
+}
+
+type apiPrincipalKey struct{}
+
+// WithAPIPrincipal returns a copy of ctx carrying p.
+func WithAPIPrincipal(ctx context.Context, p APIPrincipal) context.Context {
+	return context.WithValue(ctx, apiPrincipalKey{}, p)
+}
+
+// APIPrincipalFromContext returns the principal stored in ctx by APIKeyMiddleware.
+// ok is false when the request was not authenticated (authentication disabled or a public path).
+func APIPrincipalFromContext(ctx context.Context) (p APIPrincipal, ok bool) {
+	p, ok = ctx.Value(apiPrincipalKey{}).(APIPrincipal)
+	return p, ok
+}
+
+// APIKeyMiddleware rejects requests without a valid API key with 401, and with 503 if the key
+// cannot be checked, and stores the caller's principal in the request context. Paths in public
+// (e.g. /health) and CORS preflights are not authenticated. A nil authenticator disables
+// authentication.
+func APIKeyMiddleware(auth *APIKeyAuthenticator, realm string, public ...string) func(http.Handler) http.Handler {
+	open := make(map[string]bool, len(public))
+	for _, path := range public {
+		open[path] = true
+	}
+	return func(next http.Handler) http.Handler {
+		if auth == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if open[r.URL.Path] || r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+			p, err := auth.Authenticate(r.Context(), APIKeyFromRequest(r))
+			if err != nil {
+				slog.Error("Failed to authenticate API key", "path", r.URL.Path, "error", err)
+				http.Error(w, "Authentication unavailable", http.StatusServiceUnavailable)
+				return
+			}
+			if p == nil {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf("Bearer realm=%q", realm))
+				http.Error(w, "Missing or invalid API key", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(WithAPIPrincipal(r.Context(), *p)))
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package shared
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORS defaults: any origin, with the methods and headers the platform APIs use.
+const (
+	DefaultCORSAllowedOrigins = "*"
+	DefaultCORSAllowedMethods = "GET, POST, PUT, DELETE, OPTIONS"
+	DefaultCORSAllowedHeaders = "Content-Type, Content-Encoding, Authorization, X-API-Key"
+)
+
+// corsMaxAge is how long browsers may cache a preflight response.
+const corsMaxAge = 10 * time.Minute
+
+// CORSPolicy decides which browser origins may call an HTTP API.
+type CORSPolicy struct {
+	anyOrigin   bool
+	origins     map[string]bool
+	methods     string
+	headers     string
+	credentials bool
+}
+
+// DefaultCORSPolicy allows any origin, without credentials.
+func DefaultCORSPolicy() *CORSPolicy {
+	p, _ := ParseCORSPolicy(DefaultCORSAllowedOrigins, DefaultCORSAllowedMethods, DefaultCORSAllowedHeaders, false)
+	return p
+}
+
+// ParseCORSPolicy parses comma-separated allowed origins (scheme://host[:port], or * for any),
+// methods and headers. Credentials (cookies, Authorization from fetch with credentials) need
+// explicit origins, since browsers refuse them for a wildcard origin.
+func ParseCORSPolicy(origins, methods, headers string, credentials bool) (*CORSPolicy, error) {
+	p := &CORSPolicy{origins: make(map[string]bool), credentials: credentials}
+
+	for _, origin := range splitCSV(origins) {
+		if origin == "*" {
+			p.anyOrigin = true
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+			(u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+			return nil, fmt.Errorf("invalid CORS origin %q: want scheme://host[:port] or *", origin)
+		}
+		p.origins[strings.ToLower(u.Scheme+"://"+u.Host)] = true
+	}
+	if !p.anyOrigin && len(p.origins) == 0 {
+		return nil, fmt.Errorf("CORS allowed origins cannot be empty")
+	}
+	if p.anyOrigin && credentials {
+		return nil, fmt.Errorf("CORS credentials cannot be allowed for any origin (*); list the origins")
+	}
+
+	methodList := splitCSV(methods)
+	if len(methodList) == 0 {
+		return nil, fmt.Errorf("CORS allowed methods cannot be empty")
+	}
+	for i, m := range methodList {
+		if !isHTTPToken(m) {
+			return nil, fmt.Errorf("invalid CORS method %q", m)
+		}
+		methodList[i] = strings.ToUpper(m)
+	}
+	p.methods = strings.Join(methodList, ", ")
+
+	headerList := splitCSV(headers)
+	for _, h := range headerList {
+		if !isHTTPToken(h) {
+			return nil, fmt.Errorf("invalid CORS header %q", h)
+		}
+	}
+	p.headers = strings.Join(headerList, ", ")
+	return p, nil
+}
+
+// AnyOrigin reports whether every origin is allowed.
+func (p *CORSPolicy) AnyOrigin() bool {
+	return p.anyOrigin
+}
+
+// allowOrigin returns the Access-Control-Allow-Origin value for a request from origin,
+// or "" if the origin is not allowed.
+func (p *CORSPolicy) allowOrigin(origin string) string {
+	if p.anyOrigin {
+		return "*"
+	}
+	if origin != "" && p.origins[strings.ToLower(origin)] {
+		return origin
+	}
+	return ""
+}
+
+// CORSMiddleware applies p to every request and answers preflight (OPTIONS) requests itself.
+// exposed lists response headers browsers may read, e.g. Retry-After. Requests from origins p
+// does not allow get no CORS headers, so browsers block them; other clients are unaffected.
+func CORSMiddleware(p *CORSPolicy, exposed ...string) func(http.Handler) http.Handler {
+	if p == nil {
+		p = DefaultCORSPolicy()
+	}
+	expose := strings.Join(exposed, ", ")
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			if !p.anyOrigin {
+				h.Add("Vary", "Origin")
+			}
+			if allowed := p.allowOrigin(r.Header.Get("Origin")); allowed != "" {
+				h.Set("Access-Control-Allow-Origin", allowed)
+				h.Set("Access-Control-Allow-Methods", p.methods)
+				if p.headers != "" {
+					h.Set("Access-Control-Allow-Headers", p.headers)
+				}
+				if expose != "" {
+					h.Set("Access-Control-Expose-Headers", expose)
+				}
+				if p.credentials {
+					h.Set("Access-Control-Allow-Credentials", "true")
+				}
+			}
+
+			if r.Method == http.MethodOptions {
+				if h.Get("Access-Control-Allow-Origin") != "" {
+					h.Set("Access-Control-Max-Age", strconv.Itoa(int(corsMaxAge.Seconds())))
+				}
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// CORSFlags are the CORS settings every HTTP service reads from the same flags and environment.
+type CORSFlags struct {
+	AllowedOrigins   string
+	AllowedMethods   string
+	AllowedHeaders   string
+	AllowCredentials bool
+}
+
+// Register registers -cors-allowed-origins, -cors-allowed-methods, -cors-allowed-headers and
+// -cors-allow-credentials on fs, defaulting to CORS_ALLOWED_ORIGINS, CORS_ALLOWED_METHODS,
+// CORS_ALLOWED_HEADERS and CORS_ALLOW_CREDENTIALS.
+func (f *CORSFlags) Register(fs *flag.FlagSet) {
+	fs.StringVar(&f.AllowedOrigins, "cors-allowed-origins", GetEnvOrDefault("CORS_ALLOWED_ORIGINS", DefaultCORSAllowedOrigins), "Comma-separated origins (scheme://host[:port]) browsers may call the API from, or * for any")
+	fs.StringVar(&f.AllowedMethods, "cors-allowed-methods", GetEnvOrDefault("CORS_ALLOWED_METHODS", DefaultCORSAllowedMethods), "Comma-separated HTTP methods allowed for cross-origin requests")
+	fs.StringVar(&f.AllowedHeaders, "cors-allowed-headers", GetEnvOrDefault("CORS_ALLOWED_HEADERS", DefaultCORSAllowedHeaders), "Comma-separated request headers allowed for cross-origin requests")
+	fs.BoolVar(&f.AllowCredentials, "cors-allow-credentials", GetEnvOrDefault("CORS_ALLOW_CREDENTIALS", "false") == "true", "Allow cross-origin requests with credentials (requires explicit origins)")
+}
+
+// Policy parses the flags into a CORSPolicy. Empty origins or methods use the defaults.
+func (f *CORSFlags) Policy() (*CORSPolicy, error) {
+	origins, methods := f.AllowedOrigins, f.AllowedMethods
+	if strings.TrimSpace(origins) == "" {
+		origins = DefaultCORSAllowedOrigins
+	}
+	if strings.TrimSpace(methods) == "" {
+		methods = DefaultCORSAllowedMethods
+	}
+	return ParseCORSPolicy(origins, methods, f.AllowedHeaders, f.AllowCredentials)
+}
+
+// splitCSV splits a comma-separated list, dropping blank entries.
+func splitCSV(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// isHTTPToken reports whether s is a valid HTTP method or header name (RFC 9110 token).
+func isHTTPToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c > 0x7e || c <= ' ' || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, c) {
+			return false
+		}
+	}
+	return true
+}
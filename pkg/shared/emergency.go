@@ -0,0 +1,167 @@
+package shared
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Emergency stop defaults.
+const (
+	// EmergencyStopKey is the Redis hash that holds the platform-wide emergency stop.
+	// The stop is active while the key exists; it never expires and must be cleared explicitly.
+	EmergencyStopKey = "platform:emergency_stop"
+	// DefaultEmergencyStopPollInterval is how often an EmergencyStopWatcher re-reads the flag.
+	DefaultEmergencyStopPollInterval = time.Second
+)
+
+// EmergencyStop is the state of the platform-wide "big red button". While Active, the sender
+// delivers nothing and, if StopJobs is set, the alert-producer halts its generation jobs.
+type EmergencyStop struct {
+	Active    bool       `json:"active"`
+	Reason    string     `json:"reason,omitempty"`
+	Actor     string     `json:"actor,omitempty"`
+	StoppedAt *time.Time `json:"stopped_at,omitempty"`
+	StopJobs  bool       `json:"stop_jobs"`
+}
+
+// EmergencyStopStore reads and writes the emergency stop in Redis.
+type EmergencyStopStore struct {
+	redis *redis.Client
+}
+
+// NewEmergencyStopStore creates a store backed by redisClient.
+func NewEmergencyStopStore(redisClient *redis.Client) *EmergencyStopStore {
+	return &EmergencyStopStore{redis: redisClient}
+}
+
+// GetEmergencyStop returns the current emergency stop. An absent key means no stop is active.
+func (s *EmergencyStopStore) GetEmergencyStop(ctx context.Context) (EmergencyStop, error) {
+	fields, err := s.redis.HGetAll(ctx, EmergencyStopKey).Result()
+	if err != nil {
+		return EmergencyStop{}, fmt.Errorf("failed to read emergency stop: %w", err)
+	}
+	if len(fields) == 0 {
+		return EmergencyStop{}, nil
+	}
+
+	stop := EmergencyStop{
+		Active:   true,
+		Reason:   fields["reason"],
+		Actor:    fields["actor"],
+		StopJobs: fields["stop_jobs"] == "true",
+	}
+	if ms, err := strconv.ParseInt(fields["stopped_at"], 10, 64); err == nil {
+		stoppedAt := time.UnixMilli(ms).UTC()
+		stop.StoppedAt = &stoppedAt
+	}
+	return stop, nil
+}
+
+// SetEmergencyStop activates the emergency stop, replacing any active one.
+// A nil StoppedAt is set to the current time.
+func (s *EmergencyStopStore) SetEmergencyStop(ctx context.Context, stop EmergencyStop) error {
+	stoppedAt := time.Now()
+	if stop.StoppedAt != nil {
+		stoppedAt = *stop.StoppedAt
+	}
+	err := s.redis.HSet(ctx, EmergencyStopKey,
+		"reason", stop.Reason,
+		"actor", stop.Actor,
+		"stopped_at", stoppedAt.UnixMilli(),
+		"stop_jobs", strconv.FormatBool(stop.StopJobs),
+	).Err()
+	if err != nil {
+		return fmt.Errorf("failed to set emergency stop: %w", err)
+	}
+	return nil
+}
+
+// ClearEmergencyStop deactivates the emergency stop.
+func (s *EmergencyStopStore) ClearEmergencyStop(ctx context.Context) error {
+	if err := s.redis.Del(ctx, EmergencyStopKey).Err(); err != nil {
+		return fmt.Errorf("failed to clear emergency stop: %w", err)
+	}
+	return nil
+}
+
+// EmergencyStopWatcher polls the emergency stop so hot paths can check it without a Redis
+// round trip. If Redis is unreachable, the last known state is kept.
+// It is safe for concurrent use.
+type EmergencyStopWatcher struct {
+	store    *EmergencyStopStore
+	interval time.Duration
+
+	mu      sync.RWMutex
+	current EmergencyStop
+}
+
+// NewEmergencyStopWatcher creates a watcher that re-reads store every interval.
+// A non-positive interval uses DefaultEmergencyStopPollInterval.
+func NewEmergencyStopWatcher(store *EmergencyStopStore, interval time.Duration) *EmergencyStopWatcher {
+	if interval <= 0 {
+		interval = DefaultEmergencyStopPollInterval
+	}
+	return &EmergencyStopWatcher{store: store, interval: interval}
+}
+
+// Run polls the emergency stop until ctx is cancelled. Call Refresh first to start
+// from the current state rather than "not stopped".
+func (w *EmergencyStopWatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.Refresh(ctx)
+		}
+	}
+}
+
+// Refresh re-reads the emergency stop and logs when it is activated or cleared.
+func (w *EmergencyStopWatcher) Refresh(ctx context.Context) {
+	stop, err := w.store.GetEmergencyStop(ctx)
+	if err != nil {
+		slog.Warn("Failed to refresh emergency stop, keeping last known state", "error", err)
+		return
+	}
+
+	w.mu.Lock()
+	previous := w.current
+	w.current = stop
+	w.mu.Unlock()
+
+	switch {
+	case stop.Active && !previous.Active:
+		slog.Warn("Emergency stop activated", "reason", stop.Reason, "actor", stop.Actor, "stop_jobs", stop.StopJobs)
+	case !stop.Active && previous.Active:
+		slog.Info("Emergency stop cleared, resuming")
+	}
+}
+
+// Current returns the last known emergency stop.
+func (w *EmergencyStopWatcher) Current() EmergencyStop {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.current
+}
+
+// Wait blocks while the emergency stop is active. It returns nil once the stop is cleared,
+// or ctx.Err() if ctx is cancelled first.
+func (w *EmergencyStopWatcher) Wait(ctx context.Context) error {
+	for w.Current().Active {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(w.interval):
+		}
+	}
+	return nil
+}
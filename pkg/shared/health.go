@@ -0,0 +1,145 @@
+package shared
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Dependency statuses reported by HealthChecker.
+const (
+	HealthStatusUp   = "up"
+	HealthStatusDown = "down"
+)
+
+// Health check defaults applied by NewHealthChecker.
+const (
+	DefaultHealthCacheTTL     = 5 * time.Second
+	DefaultHealthCheckTimeout = 2 * time.Second
+)
+
+// DependencyCheck checks that a dependency, such as Postgres, Redis or a Kafka producer, is reachable.
+type DependencyCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// DependencyStatus is the result of one dependency check.
+type DependencyStatus struct {
+	Status    string `json:"status"` // up or down
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// HealthReport is the result of checking every dependency.
+type HealthReport struct {
+	Status       string                      `json:"status"` // up if every dependency is up, down otherwise
+	Dependencies map[string]DependencyStatus `json:"dependencies"`
+	CheckedAt    time.Time                   `json:"checked_at"`
+}
+
+// Healthy reports whether every dependency is up.
+func (r HealthReport) Healthy() bool {
+	return r.Status == HealthStatusUp
+}
+
+// HealthChecker runs dependency checks for health and readiness probes. The checks run
+// concurrently, each bounded by a timeout, and the report is cached for a TTL: load balancers
+// and orchestrators probing every few seconds, from several places, share one round of checks
+// instead of each hitting the database, Redis and Kafka. Probes arriving while a round is in
+// flight wait for it rather than starting their own.
+type HealthChecker struct {
+	checks  []DependencyCheck
+	ttl     time.Duration
+	timeout time.Duration
+	now     func() time.Time
+
+	mu        sync.Mutex
+	report    HealthReport
+	checkedAt time.Time
+}
+
+// NewHealthChecker creates a checker for the given dependencies, with DefaultHealthCacheTTL
+// and DefaultHealthCheckTimeout.
+func NewHealthChecker(checks ...DependencyCheck) *HealthChecker {
+	return &HealthChecker{
+		checks:  checks,
+		ttl:     DefaultHealthCacheTTL,
+		timeout: DefaultHealthCheckTimeout,
+		now:     time.Now,
+	}
+}
+
+// WithCacheTTL sets how long a report is reused. 0 checks on every call.
+func (c *HealthChecker) WithCacheTTL(ttl time.Duration) *HealthChecker {
+	c.ttl = ttl
+	return c
+}
+
+// WithTimeout sets how long each check may take before its dependency is reported down.
+func (c *HealthChecker) WithTimeout(timeout time.Duration) *HealthChecker {
+	c.timeout = timeout
+	return c
+}
+
+// Check returns the dependencies' health, from the cache if the last report is recent enough.
+// The checks do not use ctx's cancellation, so a probe that gives up does not leave a
+// report of failures behind for the next probes.
+func (c *HealthChecker) Check(ctx context.Context) HealthReport {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.checkedAt.IsZero() && c.now().Sub(c.checkedAt) < c.ttl {
+		return c.report
+	}
+
+	report := HealthReport{
+		Status:       HealthStatusUp,
+		Dependencies: make(map[string]DependencyStatus, len(c.checks)),
+	}
+	statuses := make([]DependencyStatus, len(c.checks))
+	var wg sync.WaitGroup
+	for i, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			statuses[i] = c.run(context.WithoutCancel(ctx), check)
+		}()
+	}
+	wg.Wait()
+
+	for i, check := range c.checks {
+		report.Dependencies[check.Name] = statuses[i]
+		if statuses[i].Status != HealthStatusUp {
+			report.Status = HealthStatusDown
+		}
+	}
+	c.checkedAt = c.now()
+	report.CheckedAt = c.checkedAt.UTC()
+	c.report = report
+	return report
+}
+
+// run runs one check within the timeout.
+func (c *HealthChecker) run(ctx context.Context, check DependencyCheck) DependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- check.Check(ctx)
+	}()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	status := DependencyStatus{Status: HealthStatusUp, LatencyMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		status.Status = HealthStatusDown
+		status.Error = err.Error()
+	}
+	return status
+}
@@ -0,0 +1,71 @@
+package shared
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHealthChecker_Check(t *testing.T) {
+	var postgresCalls atomic.Int32
+	checker := NewHealthChecker(
+		DependencyCheck{Name: "postgres", Check: func(ctx context.Context) error {
+			postgresCalls.Add(1)
+			return nil
+		}},
+		DependencyCheck{Name: "redis", Check: func(ctx context.Context) error {
+			return errors.New("connection refused")
+		}},
+		DependencyCheck{Name: "kafka", Check: func(ctx context.Context) error {
+			<-ctx.Done() // hangs until the timeout
+			return ctx.Err()
+		}},
+	).WithTimeout(20 * time.Millisecond)
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	checker.now = func() time.Time { return now }
+
+	report := checker.Check(context.Background())
+	if report.Healthy() || report.Status != HealthStatusDown {
+		t.Errorf("Check() status = %q, want down", report.Status)
+	}
+	if s := report.Dependencies["postgres"]; s.Status != HealthStatusUp || s.Error != "" {
+		t.Errorf("postgres = %+v, want up", s)
+	}
+	if s := report.Dependencies["redis"]; s.Status != HealthStatusDown || s.Error != "connection refused" {
+		t.Errorf("redis = %+v, want down with its error", s)
+	}
+	if s := report.Dependencies["kafka"]; s.Status != HealthStatusDown || s.Error != context.DeadlineExceeded.Error() {
+		t.Errorf("kafka = %+v, want down after the timeout", s)
+	}
+	if !report.CheckedAt.Equal(now) {
+		t.Errorf("CheckedAt = %v, want %v", report.CheckedAt, now)
+	}
+
+	// Cached within the TTL, even for a cancelled probe
+	now = now.Add(DefaultHealthCacheTTL - time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	checker.Check(ctx)
+	if postgresCalls.Load() != 1 {
+		t.Errorf("postgres checked %d times within the TTL, want 1", postgresCalls.Load())
+	}
+
+	// Checked again once the TTL expires
+	now = now.Add(2 * time.Second)
+	checker.Check(context.Background())
+	if postgresCalls.Load() != 2 {
+		t.Errorf("postgres checked %d times after the TTL, want 2", postgresCalls.Load())
+	}
+}
+
+func TestHealthChecker_Healthy(t *testing.T) {
+	checker := NewHealthChecker(DependencyCheck{Name: "postgres", Check: func(ctx context.Context) error { return nil }})
+	if report := checker.Check(context.Background()); !report.Healthy() || len(report.Dependencies) != 1 {
+		t.Errorf("Check() = %+v, want healthy", report)
+	}
+	if report := NewHealthChecker().Check(context.Background()); !report.Healthy() {
+		t.Errorf("Check() without dependencies = %+v, want healthy", report)
+	}
+}
@@ -0,0 +1,278 @@
+package shared
+
+import (
+	"compress/gzip"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HTTP server defaults applied by NewHTTPServer.
+const (
+	DefaultReadHeaderTimeout = 5 * time.Second
+	DefaultReadTimeout       = 15 * time.Second
+	DefaultWriteTimeout      = 15 * time.Second
+	DefaultIdleTimeout       = 60 * time.Second
+	DefaultMaxHeaderBytes    = 64 << 10
+	DefaultMaxBodyBytes      = 1 << 20
+)
+
+// httpServerConfig holds the settings NewHTTPServer options can change.
+type httpServerConfig struct {
+	maxBodyBytes int64
+	writeTimeout time.Duration
+	quietPaths   map[string]bool
+}
+
+// HTTPServerOption configures a server created by NewHTTPServer.
+type HTTPServerOption func(*httpServerConfig)
+
+// WithMaxBodyBytes limits request bodies to n bytes instead of DefaultMaxBodyBytes.
+// Reading past the limit fails with *http.MaxBytesError. A non-positive n removes the limit.
+func WithMaxBodyBytes(n int64) HTTPServerOption {
+	return func(c *httpServerConfig) {
+		c.maxBodyBytes = n
+	}
+}
+
+// WithWriteTimeout replaces DefaultWriteTimeout. Handlers that stream longer responses can
+// still clear their own deadline with http.ResponseController.
+func WithWriteTimeout(d time.Duration) HTTPServerOption {
+	return func(c *httpServerConfig) {
+		c.writeTimeout = d
+	}
+}
+
+// WithQuietPaths logs requests to paths (such as health checks) at debug level instead of info.
+// "/health" is quiet by default.
+func WithQuietPaths(paths ...string) HTTPServerOption {
+	return func(c *httpServerConfig) {
+		for _, p := range paths {
+			c.quietPaths[p] = true
+		}
+	}
+}
+
+// NewHTTPServer creates an HTTP server for a long-running service. It bounds how long clients
+// may take to send headers and bodies and how large they may be, and wraps handler so every
+// request is access-logged, a panicking handler returns 500 instead of dropping the connection,
+// and responses are gzipped for clients that accept it.
+func NewHTTPServer(addr string, handler http.Handler, opts ...HTTPServerOption) *http.Server {
+	cfg := httpServerConfig{
+		maxBodyBytes: DefaultMaxBodyBytes,
+		writeTimeout: DefaultWriteTimeout,
+		quietPaths:   map[string]bool{"/health": true},
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	// Outermost first: access log, recovery, body limit, gzip
+	h := gzipMiddleware(handler)
+	h = maxBodyMiddleware(cfg.maxBodyBytes)(h)
+	h = recoverMiddleware(h)
+	h = accessLogMiddleware(cfg.quietPaths)(h)
+
+	return &http.Server{
+		Addr:              addr,
+		Handler:           h,
+		ReadHeaderTimeout: DefaultReadHeaderTimeout,
+		ReadTimeout:       DefaultReadTimeout,
+		WriteTimeout:      cfg.writeTimeout,
+		IdleTimeout:       DefaultIdleTimeout,
+		MaxHeaderBytes:    DefaultMaxHeaderBytes,
+		ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+	}
+}
+
+// statusRecorder captures the status code and body size of a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (rw *statusRecorder) WriteHeader(code int) {
+	if rw.status == 0 {
+		rw.status = code
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *statusRecorder) Write(b []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer (flushing, deadlines).
+func (rw *statusRecorder) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// accessLogMiddleware logs one line per request once it has been served.
+func accessLogMiddleware(quietPaths map[string]bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w}
+			defer func() {
+				level := slog.LevelInfo
+				if quietPaths[r.URL.Path] {
+					level = slog.LevelDebug
+				}
+				status := rec.status
+				if status == 0 {
+					// Nothing written: net/http replies 200, or the handler aborted the response
+					status = http.StatusOK
+				}
+				slog.Log(r.Context(), level, "HTTP request",
+					"method", r.Method,
+					"path", r.URL.Path,
+					"status", status,
+					"bytes", rec.bytes,
+					"duration", time.Since(start),
+					"remote_addr", r.RemoteAddr,
+				)
+			}()
+			next.ServeHTTP(rec, r)
+		})
+	}
+}
+
+// recoverMiddleware turns a handler panic into a 500 response. If the response was already
+// started it is aborted instead, so the client sees a truncated transfer rather than a short body.
+func recoverMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w}
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			if p == http.ErrAbortHandler {
+				panic(p)
+			}
+			slog.Error("Panic serving HTTP request",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"panic", p,
+				"stack", string(debug.Stack()),
+			)
+			if rec.status != 0 {
+				panic(http.ErrAbortHandler)
+			}
+			w.Header().Del("Content-Encoding")
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}()
+		next.ServeHTTP(rec, r)
+	})
+}
+
+// maxBodyMiddleware limits request bodies to n bytes; a non-positive n disables the limit.
+func maxBodyMiddleware(n int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if n <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Body != nil {
+				r.Body = http.MaxBytesReader(w, r.Body, n)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+var gzipWriterPool = sync.Pool{
+	New: func() any { return gzip.NewWriter(nil) },
+}
+
+// gzipMiddleware compresses responses for clients that accept gzip.
+func gzipMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if r.Method == http.MethodHead || !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		gw := &gzipResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(gw, r)
+		// Not deferred: after a panic the response is aborted or replaced, not completed
+		if err := gw.close(); err != nil {
+			slog.Debug("Failed to finish gzip response", "path", r.URL.Path, "error", err)
+		}
+	})
+}
+
+// gzipResponseWriter compresses the body once the status is known, unless the response
+// has no body, is already encoded, or is an event stream.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	gz          *gzip.Writer
+	wroteHeader bool
+}
+
+func (w *gzipResponseWriter) WriteHeader(code int) {
+	if w.wroteHeader || code < http.StatusOK {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.wroteHeader = true
+	h := w.Header()
+	if code != http.StatusNoContent && code != http.StatusNotModified &&
+		h.Get("Content-Encoding") == "" && !strings.HasPrefix(h.Get("Content-Type"), "text/event-stream") {
+		h.Set("Content-Encoding", "gzip")
+		h.Del("Content-Length")
+		w.gz = gzipWriterPool.Get().(*gzip.Writer)
+		w.gz.Reset(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *gzipResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		// Sniff the type from the plain body, not the compressed one
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.gz == nil {
+		return w.ResponseWriter.Write(b)
+	}
+	return w.gz.Write(b)
+}
+
+// FlushError flushes the compressed data written so far to the client.
+// http.ResponseController.Flush calls it.
+func (w *gzipResponseWriter) FlushError() error {
+	if w.gz != nil {
+		if err := w.gz.Flush(); err != nil {
+			return err
+		}
+	}
+	return http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer (deadlines).
+func (w *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// close writes the gzip footer and returns the writer to the pool.
+func (w *gzipResponseWriter) close() error {
+	if w.gz == nil {
+		return nil
+	}
+	err := w.gz.Close()
+	gzipWriterPool.Put(w.gz)
+	w.gz = nil
+	return err
+}
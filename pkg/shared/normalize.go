@@ -0,0 +1,76 @@
+package shared
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Normalization options, as written in -normalize flags and rule snapshots.
+const (
+	NormalizeExact = "exact" // no normalization: legacy exact, case-sensitive matching
+	NormalizeTrim  = "trim"  // strip leading and trailing whitespace
+	NormalizeFold  = "fold"  // fold case, so "API" and "api" match
+)
+
+// DefaultNormalization is the normalization rule-updater applies unless configured otherwise.
+const DefaultNormalization = NormalizeTrim + "," + NormalizeFold
+
+// Normalization is how the severity, source and name of rules and alerts are normalized
+// before matching. rule-updater normalizes rules when it writes the snapshot and records
+// the normalization in it; the evaluator normalizes alerts (and rules it applies in place)
+// with the snapshot's normalization, so both sides always agree.
+type Normalization struct {
+	Trim     bool
+	FoldCase bool
+}
+
+// ParseNormalization parses a comma-separated list of normalization options.
+// "exact" and the empty string (snapshots written before normalization) disable normalization.
+func ParseNormalization(s string) (Normalization, error) {
+	var n Normalization
+	for _, part := range strings.Split(s, ",") {
+		switch strings.ToLower(strings.TrimSpace(part)) {
+		case "", NormalizeExact:
+		case NormalizeTrim:
+			n.Trim = true
+		case NormalizeFold:
+			n.FoldCase = true
+		default:
+			return Normalization{}, fmt.Errorf("unknown normalization %q (want %s, %s or %s)", part, NormalizeTrim, NormalizeFold, NormalizeExact)
+		}
+	}
+	return n, nil
+}
+
+// String returns the normalization in the form ParseNormalization accepts.
+func (n Normalization) String() string {
+	var parts []string
+	if n.Trim {
+		parts = append(parts, NormalizeTrim)
+	}
+	if n.FoldCase {
+		parts = append(parts, NormalizeFold)
+	}
+	if len(parts) == 0 {
+		return NormalizeExact
+	}
+	return strings.Join(parts, ",")
+}
+
+// Apply normalizes one field value.
+func (n Normalization) Apply(s string) string {
+	if n.Trim {
+		s = strings.TrimSpace(s)
+	}
+	if n.FoldCase {
+		s = strings.Map(foldRune, s)
+	}
+	return s
+}
+
+// foldRune maps r to lower case by way of upper case, so that runes with several case
+// forms (such as the Kelvin sign, or final sigma) fold to the same value.
+func foldRune(r rune) rune {
+	return unicode.ToLower(unicode.ToUpper(r))
+}
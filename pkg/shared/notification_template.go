@@ -0,0 +1,94 @@
+package shared
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// Notification template bounds.
+const (
+	MaxTemplateSubjectLength = 255
+	MaxTemplateBodyLength    = 16 * 1024
+)
+
+// NotificationTemplateData is what a notification template is executed with: the alert's
+// fields and context. Timestamps are already rendered in the client's timezone and locale.
+type NotificationTemplateData struct {
+	NotificationID string
+	ClientID       string
+	AlertID        string
+	Severity       string
+	Source         string
+	Name           string
+	Context        map[string]string
+	RuleIDs        []string
+	EventTime      string // empty if unknown
+	Created        string
+	// Alerts holds the grouped alerts of a digest; empty otherwise.
+	Alerts []NotificationTemplateData
+}
+
+// notificationTemplateFuncs are the functions available to notification templates.
+var notificationTemplateFuncs = template.FuncMap{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"join":  strings.Join,
+}
+
+// ParseNotificationTemplate parses a notification subject or body template. A missing
+// context key renders as an empty string.
+func ParseNotificationTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(notificationTemplateFuncs).Option("missingkey=zero").Parse(text)
+}
+
+// RenderNotificationTemplate parses and executes a notification template with data.
+func RenderNotificationTemplate(name, text string, data NotificationTemplateData) (string, error) {
+	tmpl, err := ParseNotificationTemplate(name, text)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// sampleTemplateData is the data templates are checked against before they are saved.
+var sampleTemplateData = NotificationTemplateData{
+	NotificationID: "notification-id",
+	ClientID:       "client-id",
+	AlertID:        "alert-id",
+	Severity:       "HIGH",
+	Source:         "source",
+	Name:           "name",
+	Context:        map[string]string{},
+	RuleIDs:        []string{"rule-id"},
+	EventTime:      "2006-01-02 15:04:05 UTC",
+	Created:        "2006-01-02 15:04:05 UTC",
+}
+
+// ValidateNotificationTemplate checks that a template subject and body are within bounds,
+// parse, and only refer to fields of NotificationTemplateData. The subject may be empty.
+func ValidateNotificationTemplate(subject, body string) error {
+	if len(subject) > MaxTemplateSubjectLength {
+		return fmt.Errorf("subject must be at most %d characters", MaxTemplateSubjectLength)
+	}
+	if strings.ContainsAny(subject, "\r\n") {
+		return fmt.Errorf("subject must be a single line")
+	}
+	if strings.TrimSpace(body) == "" {
+		return fmt.Errorf("body is required")
+	}
+	if len(body) > MaxTemplateBodyLength {
+		return fmt.Errorf("body must be at most %d bytes", MaxTemplateBodyLength)
+	}
+	if _, err := RenderNotificationTemplate("subject", subject, sampleTemplateData); err != nil {
+		return fmt.Errorf("invalid subject template: %w", err)
+	}
+	if _, err := RenderNotificationTemplate("body", body, sampleTemplateData); err != nil {
+		return fmt.Errorf("invalid body template: %w", err)
+	}
+	return nil
+}
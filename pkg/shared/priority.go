@@ -0,0 +1,30 @@
+package shared
+
+import "strings"
+
+// Notification delivery priorities, derived from alert severity. Higher is more urgent.
+// The aggregator stores the priority on the notification and carries it on notifications.ready;
+// the sender delivers higher priorities first when it has a backlog.
+const (
+	PriorityUnknown  = 0 // severity missing or not recognized; also events published before priorities
+	PriorityLow      = 1
+	PriorityMedium   = 2
+	PriorityHigh     = 3
+	PriorityCritical = 4
+)
+
+// PriorityForSeverity returns the delivery priority of a severity, case-insensitively.
+func PriorityForSeverity(severity string) int {
+	switch strings.ToUpper(strings.TrimSpace(severity)) {
+	case "CRITICAL":
+		return PriorityCritical
+	case "HIGH":
+		return PriorityHigh
+	case "MEDIUM":
+		return PriorityMedium
+	case "LOW":
+		return PriorityLow
+	default:
+		return PriorityUnknown
+	}
+}
@@ -0,0 +1,232 @@
+package shared
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultRateLimitTier is the tier of callers not assigned to another tier.
+// If the policy has no tier with this name, unassigned callers are not limited.
+const DefaultRateLimitTier = "default"
+
+// rateLimitKeyPrefix prefixes the Redis hash holding each caller's token bucket.
+const rateLimitKeyPrefix = "ratelimit:"
+
+// RateLimitTier is a token bucket: callers get Rate requests per second on average,
+// with bursts of up to Burst requests.
+type RateLimitTier struct {
+	Name  string
+	Rate  float64
+	Burst int
+}
+
+// RateLimitPolicy maps callers to tiers. Buckets live in Redis, so the limits hold across
+// instances as long as every instance of a service uses the same policy.
+type RateLimitPolicy struct {
+	tiers   map[string]RateLimitTier
+	callers map[string]string
+}
+
+// ParseRateLimitPolicy parses a comma-separated list of name:rate:burst tiers and a
+// comma-separated list of caller:tier assignments. Empty tiers return an empty policy,
+// which disables rate limiting.
+func ParseRateLimitPolicy(tiers, callers string) (*RateLimitPolicy, error) {
+	p := &RateLimitPolicy{tiers: make(map[string]RateLimitTier), callers: make(map[string]string)}
+
+	for _, entry := range strings.Split(tiers, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.Split(entry, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid rate limit tier %q: want name:rate:burst", entry)
+		}
+		name := strings.TrimSpace(parts[0])
+		if name == "" {
+			return nil, fmt.Errorf("rate limit tier has an empty name")
+		}
+		rate, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if err != nil || rate <= 0 {
+			return nil, fmt.Errorf("invalid rate for rate limit tier %s: must be a positive number of requests per second", name)
+		}
+		burst, err := strconv.Atoi(strings.TrimSpace(parts[2]))
+		if err != nil || burst < 1 {
+			return nil, fmt.Errorf("invalid burst for rate limit tier %s: must be at least 1", name)
+		}
+		if _, exists := p.tiers[name]; exists {
+			return nil, fmt.Errorf("duplicate rate limit tier %s", name)
+		}
+		p.tiers[name] = RateLimitTier{Name: name, Rate: rate, Burst: burst}
+	}
+
+	for _, entry := range strings.Split(callers, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		caller, tier, ok := strings.Cut(entry, ":")
+		caller, tier = strings.TrimSpace(caller), strings.TrimSpace(tier)
+		if !ok || caller == "" || tier == "" {
+			return nil, fmt.Errorf("invalid rate limit assignment %q: want caller:tier", entry)
+		}
+		if _, exists := p.tiers[tier]; !exists {
+			return nil, fmt.Errorf("rate limit assignment for %s uses unknown tier %s", caller, tier)
+		}
+		if _, exists := p.callers[caller]; exists {
+			return nil, fmt.Errorf("duplicate rate limit assignment for %s", caller)
+		}
+		p.callers[caller] = tier
+	}
+	return p, nil
+}
+
+// Enabled reports whether any tiers are configured.
+func (p *RateLimitPolicy) Enabled() bool {
+	return p != nil && len(p.tiers) > 0
+}
+
+// Len returns the number of configured tiers.
+func (p *RateLimitPolicy) Len() int {
+	if p == nil {
+		return 0
+	}
+	return len(p.tiers)
+}
+
+// TierFor returns the tier of caller: its assigned tier, or the default tier.
+// It returns false if caller is not limited.
+func (p *RateLimitPolicy) TierFor(caller string) (RateLimitTier, bool) {
+	if p == nil {
+		return RateLimitTier{}, false
+	}
+	name, ok := p.callers[caller]
+	if !ok {
+		name = DefaultRateLimitTier
+	}
+	tier, ok := p.tiers[name]
+	return tier, ok
+}
+
+// Tier returns the tier with the given name. It returns false if there is none.
+func (p *RateLimitPolicy) Tier(name string) (RateLimitTier, bool) {
+	if p == nil {
+		return RateLimitTier{}, false
+	}
+	tier, ok := p.tiers[name]
+	return tier, ok
+}
+
+// Tiers returns the names of the configured tiers, sorted.
+func (p *RateLimitPolicy) Tiers() []string {
+	if p == nil {
+		return nil
+	}
+	names := make([]string, 0, len(p.tiers))
+	for name := range p.tiers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RateLimitDecision is the outcome of a rate limit check.
+type RateLimitDecision struct {
+	Allowed bool
+	// Tier is the caller's tier; empty if the caller is not limited.
+	Tier string
+	// RetryAfter is how long until the caller gets a token back; set when not Allowed.
+	RetryAfter time.Duration
+}
+
+// tokenBucketScript refills the caller's bucket for the time since its last request, then takes
+// a token if one is available. It uses the Redis clock so instances with skewed clocks agree.
+// Returns {allowed, milliseconds until the next token}.
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(bucket[1]) or burst
+local ts = tonumber(bucket[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate / 1000)
+
+local allowed = 0
+local wait = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	wait = math.ceil((1 - tokens) * 1000 / rate)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+return {allowed, wait}
+`)
+
+// RateLimiter enforces a RateLimitPolicy with one token bucket per caller in Redis.
+// Buckets are scoped by service, so a caller's rule-service and ingestion traffic are limited separately.
+type RateLimiter struct {
+	redis   *redis.Client
+	service string
+	policy  *RateLimitPolicy
+}
+
+// NewRateLimiter creates a limiter for service backed by redisClient.
+func NewRateLimiter(redisClient *redis.Client, service string, policy *RateLimitPolicy) *RateLimiter {
+	return &RateLimiter{redis: redisClient, service: service, policy: policy}
+}
+
+// Allow takes a token from caller's bucket. Callers without a tier are always allowed.
+func (l *RateLimiter) Allow(ctx context.Context, caller string) (RateLimitDecision, error) {
+	tier, ok := l.policy.TierFor(caller)
+	if !ok {
+		return RateLimitDecision{Allowed: true}, nil
+	}
+	return l.take(ctx, tier, caller)
+}
+
+// AllowIn takes a token from caller's bucket in the named tier, ignoring the caller
+// assignments; use it when the tier follows from the kind of caller (e.g. a sender's
+// channel type). Callers are always allowed if the policy has no such tier.
+func (l *RateLimiter) AllowIn(ctx context.Context, tierName, caller string) (RateLimitDecision, error) {
+	tier, ok := l.policy.Tier(tierName)
+	if !ok {
+		return RateLimitDecision{Allowed: true}, nil
+	}
+	return l.take(ctx, tier, caller)
+}
+
+// take takes a token from caller's bucket in tier.
+func (l *RateLimiter) take(ctx context.Context, tier RateLimitTier, caller string) (RateLimitDecision, error) {
+	res, err := tokenBucketScript.Run(ctx, l.redis, []string{rateLimitKeyPrefix + l.service + ":" + caller}, tier.Rate, tier.Burst).Int64Slice()
+	if err != nil {
+		return RateLimitDecision{}, fmt.Errorf("failed to check rate limit: %w", err)
+	}
+	if len(res) != 2 {
+		return RateLimitDecision{}, fmt.Errorf("failed to check rate limit: unexpected reply %v", res)
+	}
+	return RateLimitDecision{
+		Allowed:    res[0] == 1,
+		Tier:       tier.Name,
+		RetryAfter: time.Duration(res[1]) * time.Millisecond,
+	}, nil
+}
+
+// RetryAfterSeconds formats d as a Retry-After header value: whole seconds, rounded up, at least 1.
+func RetryAfterSeconds(d time.Duration) string {
+	seconds := int64((d + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return strconv.FormatInt(seconds, 10)
+}
@@ -0,0 +1,108 @@
+package shared
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis deployment modes.
+const (
+	RedisModeStandalone = "standalone"
+	RedisModeSentinel   = "sentinel"
+	RedisModeCluster    = "cluster"
+)
+
+// Retries ride out a Sentinel failover or a cluster slot migration: a command that fails on a
+// connection error, a READONLY reply from a demoted master, or a MOVED/ASK redirect is retried
+// with exponential backoff, the failover client asking Sentinel for the new master each time.
+// Lua scripts survive a failover too, since a NOSCRIPT reply from the new master makes
+// redis.Script fall back to EVAL.
+const (
+	redisMaxRetries      = 5
+	redisMinRetryBackoff = 100 * time.Millisecond
+	redisMaxRetryBackoff = 2 * time.Second
+)
+
+// RedisOptions selects the Redis deployment to connect to.
+type RedisOptions struct {
+	// Addrs are the server address (standalone), the Sentinel addresses, or cluster node addresses.
+	Addrs []string
+	// MasterName is the Sentinel master name; set it to connect through Sentinel.
+	MasterName string
+	// Cluster connects to a Redis Cluster; Addrs are seed nodes.
+	Cluster bool
+}
+
+// ParseRedisOptions builds RedisOptions from a comma-separated address list, a Sentinel
+// master name and the cluster flag, as passed on the command line.
+func ParseRedisOptions(addrs, masterName string, cluster bool) (RedisOptions, error) {
+	opts := RedisOptions{MasterName: strings.TrimSpace(masterName), Cluster: cluster}
+	for _, addr := range strings.Split(addrs, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			opts.Addrs = append(opts.Addrs, addr)
+		}
+	}
+	switch {
+	case len(opts.Addrs) == 0:
+		return opts, fmt.Errorf("redis-addr cannot be empty")
+	case opts.MasterName != "" && opts.Cluster:
+		return opts, fmt.Errorf("redis-sentinel-master and redis-cluster cannot be used together")
+	case opts.Mode() == RedisModeStandalone && len(opts.Addrs) > 1:
+		return opts, fmt.Errorf("redis-addr lists %d addresses: set redis-sentinel-master or redis-cluster", len(opts.Addrs))
+	}
+	return opts, nil
+}
+
+// Mode returns the deployment mode: standalone, sentinel or cluster.
+func (o RedisOptions) Mode() string {
+	switch {
+	case o.MasterName != "":
+		return RedisModeSentinel
+	case o.Cluster:
+		return RedisModeCluster
+	}
+	return RedisModeStandalone
+}
+
+// ConnectRedisUniversal creates and validates a connection to a standalone Redis server, a
+// Sentinel-managed master, or a Redis Cluster. Commands are retried across failovers.
+func ConnectRedisUniversal(ctx context.Context, opts RedisOptions) (redis.UniversalClient, error) {
+	if len(opts.Addrs) == 0 {
+		return nil, fmt.Errorf("no Redis address")
+	}
+	var client redis.UniversalClient
+	switch opts.Mode() {
+	case RedisModeSentinel:
+		client = redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:      opts.MasterName,
+			SentinelAddrs:   opts.Addrs,
+			MaxRetries:      redisMaxRetries,
+			MinRetryBackoff: redisMinRetryBackoff,
+			MaxRetryBackoff: redisMaxRetryBackoff,
+		})
+	case RedisModeCluster:
+		client = redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:           opts.Addrs,
+			MaxRetries:      redisMaxRetries,
+			MinRetryBackoff: redisMinRetryBackoff,
+			MaxRetryBackoff: redisMaxRetryBackoff,
+		})
+	default:
+		client = redis.NewClient(&redis.Options{
+			Addr:            opts.Addrs[0],
+			MaxRetries:      redisMaxRetries,
+			MinRetryBackoff: redisMinRetryBackoff,
+			MaxRetryBackoff: redisMaxRetryBackoff,
+		})
+	}
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to Redis (%s) at %s: %w", opts.Mode(), strings.Join(opts.Addrs, ","), err)
+	}
+	return client, nil
+}
@@ -0,0 +1,42 @@
+package shared
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRedisOptions(t *testing.T) {
+	tests := []struct {
+		name       string
+		addrs      string
+		masterName string
+		cluster    bool
+		wantAddrs  []string
+		wantMode   string
+		wantErr    string
+	}{
+		{name: "standalone", addrs: "localhost:6379", wantAddrs: []string{"localhost:6379"}, wantMode: RedisModeStandalone},
+		{name: "sentinel", addrs: "s1:26379, s2:26379,", masterName: "mymaster", wantAddrs: []string{"s1:26379", "s2:26379"}, wantMode: RedisModeSentinel},
+		{name: "cluster", addrs: "n1:6379,n2:6379", cluster: true, wantAddrs: []string{"n1:6379", "n2:6379"}, wantMode: RedisModeCluster},
+		{name: "empty", addrs: " , ", wantErr: "redis-addr cannot be empty"},
+		{name: "sentinel and cluster", addrs: "n1:6379", masterName: "mymaster", cluster: true, wantErr: "redis-sentinel-master and redis-cluster cannot be used together"},
+		{name: "several standalone addresses", addrs: "n1:6379,n2:6379", wantErr: "redis-addr lists 2 addresses: set redis-sentinel-master or redis-cluster"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := ParseRedisOptions(tt.addrs, tt.masterName, tt.cluster)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("ParseRedisOptions() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRedisOptions() error = %v", err)
+			}
+			if !reflect.DeepEqual(opts.Addrs, tt.wantAddrs) || opts.Mode() != tt.wantMode {
+				t.Errorf("ParseRedisOptions() = %v (%s), want %v (%s)", opts.Addrs, opts.Mode(), tt.wantAddrs, tt.wantMode)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package shared
+
+// Context condition operators: the op of a rule's context condition.
+const (
+	ConditionOpEqual    = "==" // the alert context has the key, with the value
+	ConditionOpNotEqual = "!=" // the alert context lacks the key, or has another value
+)
+
+// ContextConditionHolds reports whether an alert context satisfies one context condition of a
+// rule. value is already normalized, like the condition values of a rule snapshot; the context
+// value is normalized with n before it is compared. An unknown op never holds.
+//
+// The evaluator checks rules with it when it matches alerts, and rule-service when it tests a
+// sample alert against a client's rules, so both agree on which rules match.
+func ContextConditionHolds(n Normalization, key, op, value string, context map[string]string) bool {
+	actual, ok := context[key]
+	equal := ok && n.Apply(actual) == value
+	switch op {
+	case ConditionOpEqual:
+		return equal
+	case ConditionOpNotEqual:
+		return !equal
+	default:
+		// rule-service only accepts the operators above; never match on one we do not know
+		return false
+	}
+}
@@ -0,0 +1,33 @@
+package shared
+
+import "testing"
+
+func TestContextConditionHolds(t *testing.T) {
+	fold := Normalization{Trim: true, FoldCase: true}
+	context := map[string]string{"env": " Prod "}
+
+	tests := []struct {
+		name  string
+		n     Normalization
+		key   string
+		op    string
+		value string
+		want  bool
+	}{
+		{"equal after normalization", fold, "env", ConditionOpEqual, "prod", true},
+		{"equal without normalization", Normalization{}, "env", ConditionOpEqual, "prod", false},
+		{"equal to another value", fold, "env", ConditionOpEqual, "staging", false},
+		{"equal on a missing key", fold, "region", ConditionOpEqual, "eu", false},
+		{"not equal to another value", fold, "env", ConditionOpNotEqual, "staging", true},
+		{"not equal to the value", fold, "env", ConditionOpNotEqual, "prod", false},
+		{"not equal on a missing key", fold, "region", ConditionOpNotEqual, "eu", true},
+		{"unknown op", fold, "env", "~=", "prod", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ContextConditionHolds(tt.n, tt.key, tt.op, tt.value, context); got != tt.want {
+				t.Errorf("ContextConditionHolds(%s %s %s) = %v, want %v", tt.key, tt.op, tt.value, got, tt.want)
+			}
+		})
+	}
+}
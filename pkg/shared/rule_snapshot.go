@@ -0,0 +1,274 @@
+package shared
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Rule snapshot keys and defaults. rule-updater writes {rules}:snapshot and, with every write,
+// a copy of it at {rules}:snapshot:v<version>, keeping the last few versions; the evaluator
+// matches against {rules}:snapshot unless it is pinned to one of those copies.
+//
+// The {rules} hash tag puts every snapshot key in the same Redis Cluster slot, so the Lua
+// scripts and MGETs that read and write several of them at once also work on a cluster.
+const (
+	// RuleSnapshotKey is the Redis key of the current rule snapshot.
+	RuleSnapshotKey = "{rules}:snapshot"
+	// RuleVersionKey is the Redis key of the current rule snapshot's version.
+	RuleVersionKey = "{rules}:version"
+	// RuleSnapshotHistoryKey is the Redis sorted set of the versions kept at RuleSnapshotKeyAt,
+	// scored by version.
+	RuleSnapshotHistoryKey = "{rules}:snapshot:history"
+	// RuleSnapshotPinKey is the Redis hash that pins the evaluator to a kept version. The pin is
+	// active while the key exists; it never expires and must be cleared explicitly.
+	RuleSnapshotPinKey = "{rules}:snapshot:pin"
+	// RuleSnapshotReloadKey is the Redis counter bumped to make every evaluator rebuild its
+	// indexes from the snapshot, even if the version did not change.
+	RuleSnapshotReloadKey = "{rules}:snapshot:reload"
+	// RuleSnapshotControlChannel is the Redis pub/sub channel announcing pin, unpin and reload
+	// requests, so evaluators apply them without waiting for their next version poll.
+	RuleSnapshotControlChannel = "rules:snapshot:control"
+	// RuleSnapshotChangedChannel is the Redis pub/sub channel rule-updater publishes the new
+	// version to after every snapshot write, so evaluators reload within moments instead of at
+	// their next version poll.
+	RuleSnapshotChangedChannel = "rules:changed"
+	// LegacyRuleVersionKey is where the version was kept before the keys had the {rules} hash
+	// tag. rule-updater continues numbering from it (see its snapshot.Writer.MigrateVersion).
+	LegacyRuleVersionKey = "rules:version"
+	// DefaultRuleSnapshotHistory is how many snapshot versions rule-updater keeps.
+	DefaultRuleSnapshotHistory = 10
+	// RuleSnapshotDeltasKey is the Redis sorted set of the changes rule-updater made with each
+	// snapshot write, as JSON entries scored by the version written. Evaluators apply the entries
+	// after the version they loaded instead of reloading the whole snapshot.
+	RuleSnapshotDeltasKey = "{rules}:deltas"
+	// DefaultRuleSnapshotDeltas is how many change entries rule-updater keeps.
+	DefaultRuleSnapshotDeltas = 1000
+)
+
+// Rule snapshot delta operations, the op of a RuleSnapshotDeltasKey entry.
+const (
+	// RuleDeltaPut adds a rule or replaces its fields.
+	RuleDeltaPut = "put"
+	// RuleDeltaRemove removes a rule.
+	RuleDeltaRemove = "remove"
+	// RuleDeltaSnapshot marks a rewrite of the whole snapshot, such as a rebuild from Postgres;
+	// readers must reload it.
+	RuleDeltaSnapshot = "snapshot"
+	// RuleDeltaCompact marks a compaction, which rewrites the snapshot without changing its rules.
+	RuleDeltaCompact = "compact"
+)
+
+// ErrRuleSnapshotNotFound is returned for a snapshot version that is not kept (anymore).
+var ErrRuleSnapshotNotFound = errors.New("rule snapshot version not found")
+
+// RuleSnapshotKeyAt returns the Redis key of the copy of the rule snapshot written at version.
+func RuleSnapshotKeyAt(version int64) string {
+	return RuleSnapshotKey + ":v" + strconv.FormatInt(version, 10)
+}
+
+// RuleSnapshotChecksumKey returns the Redis key of the checksum of the snapshot stored at
+// snapshotKey (RuleSnapshotKey or a RuleSnapshotKeyAt copy). rule-updater writes it in the same
+// script as the snapshot, so readers can detect truncated or corrupt snapshots.
+func RuleSnapshotChecksumKey(snapshotKey string) string {
+	return snapshotKey + ":checksum"
+}
+
+// RuleSnapshotChecksum returns the checksum of a snapshot's JSON as stored: its hex SHA-1,
+// which rule-updater's Lua scripts compute with redis.sha1hex. It detects corruption, not
+// tampering.
+func RuleSnapshotChecksum(data []byte) string {
+	sum := sha1.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// RuleSnapshotVersion is a kept rule snapshot version.
+type RuleSnapshotVersion struct {
+	Version int64 `json:"version"`
+	Bytes   int64 `json:"bytes"`
+}
+
+// RuleSnapshotPin pins the evaluator to a kept snapshot version during an incident.
+type RuleSnapshotPin struct {
+	Version  int64      `json:"version"`
+	Reason   string     `json:"reason,omitempty"`
+	Actor    string     `json:"actor,omitempty"`
+	PinnedAt *time.Time `json:"pinned_at,omitempty"`
+}
+
+// RuleSnapshotStore reads the rule snapshot history and reads and writes the pin in Redis.
+type RuleSnapshotStore struct {
+	redis redis.UniversalClient
+}
+
+// NewRuleSnapshotStore creates a store backed by redisClient.
+func NewRuleSnapshotStore(redisClient redis.UniversalClient) *RuleSnapshotStore {
+	return &RuleSnapshotStore{redis: redisClient}
+}
+
+// ListRuleSnapshotVersions returns the kept snapshot versions, newest first.
+func (s *RuleSnapshotStore) ListRuleSnapshotVersions(ctx context.Context) ([]RuleSnapshotVersion, error) {
+	members, err := s.redis.ZRevRange(ctx, RuleSnapshotHistoryKey, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rule snapshot versions: %w", err)
+	}
+
+	versions := make([]RuleSnapshotVersion, 0, len(members))
+	sizes := make([]*redis.IntCmd, 0, len(members))
+	pipe := s.redis.Pipeline()
+	for _, member := range members {
+		version, err := strconv.ParseInt(member, 10, 64)
+		if err != nil {
+			continue
+		}
+		versions = append(versions, RuleSnapshotVersion{Version: version})
+		sizes = append(sizes, pipe.StrLen(ctx, RuleSnapshotKeyAt(version)))
+	}
+	if len(versions) == 0 {
+		return versions, nil
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to read rule snapshot sizes: %w", err)
+	}
+	for i, size := range sizes {
+		versions[i].Bytes = size.Val()
+	}
+	return versions, nil
+}
+
+// GetRuleSnapshot returns the snapshot JSON written at version, or ErrRuleSnapshotNotFound.
+func (s *RuleSnapshotStore) GetRuleSnapshot(ctx context.Context, version int64) ([]byte, error) {
+	data, err := s.redis.Get(ctx, RuleSnapshotKeyAt(version)).Bytes()
+	if err == redis.Nil {
+		return nil, fmt.Errorf("%w: %d", ErrRuleSnapshotNotFound, version)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rule snapshot version %d: %w", version, err)
+	}
+	return data, nil
+}
+
+// GetCurrentRuleSnapshotVersion returns the version of the current snapshot, 0 if there is none.
+func (s *RuleSnapshotStore) GetCurrentRuleSnapshotVersion(ctx context.Context) (int64, error) {
+	version, err := s.redis.Get(ctx, RuleVersionKey).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rule snapshot version: %w", err)
+	}
+	return version, nil
+}
+
+// GetRuleSnapshotPin returns the pin, or nil if the evaluator is not pinned.
+func (s *RuleSnapshotStore) GetRuleSnapshotPin(ctx context.Context) (*RuleSnapshotPin, error) {
+	fields, err := s.redis.HGetAll(ctx, RuleSnapshotPinKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rule snapshot pin: %w", err)
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	version, err := strconv.ParseInt(fields["version"], 10, 64)
+	if err != nil || version <= 0 {
+		return nil, fmt.Errorf("invalid rule snapshot pin version %q", fields["version"])
+	}
+	pin := &RuleSnapshotPin{
+		Version: version,
+		Reason:  fields["reason"],
+		Actor:   fields["actor"],
+	}
+	if ms, err := strconv.ParseInt(fields["pinned_at"], 10, 64); err == nil {
+		pinnedAt := time.UnixMilli(ms).UTC()
+		pin.PinnedAt = &pinnedAt
+	}
+	return pin, nil
+}
+
+// SetRuleSnapshotPin pins the evaluator to pin.Version, replacing any pin.
+// A nil PinnedAt is set to the current time.
+func (s *RuleSnapshotStore) SetRuleSnapshotPin(ctx context.Context, pin RuleSnapshotPin) error {
+	pinnedAt := time.Now()
+	if pin.PinnedAt != nil {
+		pinnedAt = *pin.PinnedAt
+	}
+	err := s.redis.HSet(ctx, RuleSnapshotPinKey,
+		"version", pin.Version,
+		"reason", pin.Reason,
+		"actor", pin.Actor,
+		"pinned_at", pinnedAt.UnixMilli(),
+	).Err()
+	if err != nil {
+		return fmt.Errorf("failed to set rule snapshot pin: %w", err)
+	}
+	s.announce(ctx, "pin")
+	return nil
+}
+
+// ClearRuleSnapshotPin unpins the evaluator, which goes back to the current snapshot.
+func (s *RuleSnapshotStore) ClearRuleSnapshotPin(ctx context.Context) error {
+	if err := s.redis.Del(ctx, RuleSnapshotPinKey).Err(); err != nil {
+		return fmt.Errorf("failed to clear rule snapshot pin: %w", err)
+	}
+	s.announce(ctx, "unpin")
+	return nil
+}
+
+// RequestRuleSnapshotReload makes every evaluator rebuild its indexes from the snapshot
+// (the pinned version, if pinned), and returns the new reload generation.
+func (s *RuleSnapshotStore) RequestRuleSnapshotReload(ctx context.Context) (int64, error) {
+	generation, err := s.redis.Incr(ctx, RuleSnapshotReloadKey).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to request rule snapshot reload: %w", err)
+	}
+	s.announce(ctx, "reload")
+	return generation, nil
+}
+
+// GetRuleSnapshotReloadGeneration returns the number of reloads requested so far.
+func (s *RuleSnapshotStore) GetRuleSnapshotReloadGeneration(ctx context.Context) (int64, error) {
+	generation, err := s.redis.Get(ctx, RuleSnapshotReloadKey).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rule snapshot reload generation: %w", err)
+	}
+	return generation, nil
+}
+
+// SubscribeRuleSnapshotControl subscribes to pin, unpin and reload announcements. Messages
+// only say that something changed; subscribers re-read the pin and reload generation.
+func (s *RuleSnapshotStore) SubscribeRuleSnapshotControl(ctx context.Context) *redis.PubSub {
+	return s.redis.Subscribe(ctx, RuleSnapshotControlChannel)
+}
+
+// AnnounceRuleSnapshotChanged publishes that the snapshot was written at version. Best effort:
+// evaluators that miss it pick the version up at their next poll.
+func (s *RuleSnapshotStore) AnnounceRuleSnapshotChanged(ctx context.Context, version int64) {
+	if err := s.redis.Publish(ctx, RuleSnapshotChangedChannel, version).Err(); err != nil {
+		slog.Warn("Failed to announce rule snapshot change", "version", version, "error", err)
+	}
+}
+
+// SubscribeRuleSnapshotChanged subscribes to snapshot write announcements. Each message is
+// the version written.
+func (s *RuleSnapshotStore) SubscribeRuleSnapshotChanged(ctx context.Context) *redis.PubSub {
+	return s.redis.Subscribe(ctx, RuleSnapshotChangedChannel)
+}
+
+// announce publishes a control change. Best effort: evaluators that miss it pick the change
+// up at their next version poll.
+func (s *RuleSnapshotStore) announce(ctx context.Context, action string) {
+	if err := s.redis.Publish(ctx, RuleSnapshotControlChannel, action).Err(); err != nil {
+		slog.Warn("Failed to announce rule snapshot control change", "action", action, "error", err)
+	}
+}
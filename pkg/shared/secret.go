@@ -0,0 +1,60 @@
+package shared
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+)
+
+// SecretBox encrypts and decrypts short secrets stored in the database, such as
+// webhook header values, with AES-256-GCM. Every service sharing secrets must use the same key.
+type SecretBox struct {
+	aead cipher.AEAD
+}
+
+// NewSecretBox creates a SecretBox from a passphrase. The AES key is the SHA-256 of the passphrase.
+func NewSecretBox(passphrase string) (*SecretBox, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("secret key cannot be empty")
+	}
+	key := sha256.Sum256([]byte(passphrase))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+	return &SecretBox{aead: aead}, nil
+}
+
+// Encrypt returns the base64-encoded nonce and ciphertext of plaintext.
+func (b *SecretBox) Encrypt(plaintext string) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := b.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt reverses Encrypt. It fails if the value was encrypted with a different key or was tampered with.
+func (b *SecretBox) Decrypt(encoded string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode secret: %w", err)
+	}
+	nonceSize := b.aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return "", fmt.Errorf("secret is too short")
+	}
+	plaintext, err := b.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+	return string(plaintext), nil
+}
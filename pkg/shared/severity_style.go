@@ -0,0 +1,124 @@
+package shared
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// SeverityStyle is how a severity is presented: the color, emoji and label used by every
+// notification channel and by the UI, so an alert looks the same wherever it is shown.
+type SeverityStyle struct {
+	Severity string `json:"severity"`
+	Label    string `json:"label"`
+	Color    string `json:"color"` // hex, e.g. #dc2626
+	Emoji    string `json:"emoji"`
+}
+
+// DefaultSeverityStyles are the styles of the alert severities, most urgent first.
+var DefaultSeverityStyles = []SeverityStyle{
+	{Severity: "CRITICAL", Label: "Critical", Color: "#dc2626", Emoji: "🔴"},
+	{Severity: "HIGH", Label: "High", Color: "#ea580c", Emoji: "🟠"},
+	{Severity: "MEDIUM", Label: "Medium", Color: "#ca8a04", Emoji: "🟡"},
+	{Severity: "LOW", Label: "Low", Color: "#16a34a", Emoji: "🟢"},
+}
+
+// unknownSeverityStyle is the style of a missing or unrecognized severity.
+var unknownSeverityStyle = SeverityStyle{Label: "Unknown", Color: "#6b7280", Emoji: "⚪"}
+
+var (
+	severityStylesMu sync.RWMutex
+	severityStyles   = DefaultSeverityStyles
+)
+
+// SeverityStyles returns the configured styles, most urgent first.
+func SeverityStyles() []SeverityStyle {
+	severityStylesMu.RLock()
+	defer severityStylesMu.RUnlock()
+	return append([]SeverityStyle(nil), severityStyles...)
+}
+
+// SeverityStyleFor returns the style of a severity, case-insensitively. An unrecognized
+// severity gets a gray style labelled with the severity itself.
+func SeverityStyleFor(severity string) SeverityStyle {
+	severity = strings.ToUpper(strings.TrimSpace(severity))
+	severityStylesMu.RLock()
+	defer severityStylesMu.RUnlock()
+	for _, style := range severityStyles {
+		if style.Severity == severity {
+			return style
+		}
+	}
+	style := unknownSeverityStyle
+	if severity != "" {
+		style.Severity, style.Label = severity, severity
+	}
+	return style
+}
+
+// ConfigureSeverityStyles overrides the default styles with raw, a JSON object keyed by
+// severity, e.g. {"CRITICAL":{"color":"#b91c1c","emoji":"🚨"}}. Fields left out keep their
+// defaults. An empty raw restores the defaults. Services call it once at startup with their
+// -severity-styles flag, so every service presents severities alike.
+func ConfigureSeverityStyles(raw string) error {
+	styles := append([]SeverityStyle(nil), DefaultSeverityStyles...)
+	if strings.TrimSpace(raw) != "" {
+		var overrides map[string]SeverityStyle
+		if err := json.Unmarshal([]byte(raw), &overrides); err != nil {
+			return fmt.Errorf("severity-styles is not a JSON object of styles: %w", err)
+		}
+		for severity, override := range overrides {
+			i := severityStyleIndex(styles, strings.ToUpper(severity))
+			if i < 0 {
+				return fmt.Errorf("severity-styles: unknown severity %q", severity)
+			}
+			if override.Color != "" && !isHexColor(override.Color) {
+				return fmt.Errorf("severity-styles: %s color %q is not a hex color such as #dc2626", styles[i].Severity, override.Color)
+			}
+			styles[i] = mergeSeverityStyle(styles[i], override)
+		}
+	}
+
+	severityStylesMu.Lock()
+	defer severityStylesMu.Unlock()
+	severityStyles = styles
+	return nil
+}
+
+// severityStyleIndex returns the index of severity's style in styles, or -1.
+func severityStyleIndex(styles []SeverityStyle, severity string) int {
+	for i, style := range styles {
+		if style.Severity == severity {
+			return i
+		}
+	}
+	return -1
+}
+
+// mergeSeverityStyle returns style with the non-empty fields of override.
+func mergeSeverityStyle(style, override SeverityStyle) SeverityStyle {
+	if override.Label != "" {
+		style.Label = override.Label
+	}
+	if override.Color != "" {
+		style.Color = override.Color
+	}
+	if override.Emoji != "" {
+		style.Emoji = override.Emoji
+	}
+	return style
+}
+
+// isHexColor reports whether s is a #rgb or #rrggbb color.
+func isHexColor(s string) bool {
+	if len(s) != 4 && len(s) != 7 || s[0] != '#' {
+		return false
+	}
+	for _, c := range s[1:] {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,50 @@
+package shared
+
+import "testing"
+
+func TestSeverityStyleFor(t *testing.T) {
+	if style := SeverityStyleFor(" critical "); style.Color != "#dc2626" || style.Label != "Critical" || style.Emoji != "🔴" {
+		t.Errorf("SeverityStyleFor(critical) = %+v, want the CRITICAL style", style)
+	}
+	if style := SeverityStyleFor("SEVERE"); style.Severity != "SEVERE" || style.Label != "SEVERE" || style.Color != unknownSeverityStyle.Color {
+		t.Errorf("SeverityStyleFor(SEVERE) = %+v, want a gray style labelled SEVERE", style)
+	}
+	if style := SeverityStyleFor(""); style != unknownSeverityStyle {
+		t.Errorf("SeverityStyleFor(\"\") = %+v, want %+v", style, unknownSeverityStyle)
+	}
+}
+
+func TestConfigureSeverityStyles(t *testing.T) {
+	t.Cleanup(func() { ConfigureSeverityStyles("") })
+
+	if err := ConfigureSeverityStyles(`{"critical":{"color":"#b91c1c","emoji":"🚨"},"LOW":{"label":"Info"}}`); err != nil {
+		t.Fatalf("ConfigureSeverityStyles() error = %v", err)
+	}
+	if style := SeverityStyleFor("CRITICAL"); style != (SeverityStyle{Severity: "CRITICAL", Label: "Critical", Color: "#b91c1c", Emoji: "🚨"}) {
+		t.Errorf("CRITICAL = %+v, want the overridden color and emoji", style)
+	}
+	if style := SeverityStyleFor("LOW"); style.Label != "Info" || style.Color != "#16a34a" {
+		t.Errorf("LOW = %+v, want the overridden label and default color", style)
+	}
+	if styles := SeverityStyles(); len(styles) != len(DefaultSeverityStyles) || styles[0].Severity != "CRITICAL" {
+		t.Errorf("SeverityStyles() = %+v, want the severities most urgent first", styles)
+	}
+	if DefaultSeverityStyles[0].Color != "#dc2626" {
+		t.Error("ConfigureSeverityStyles() changed DefaultSeverityStyles")
+	}
+
+	for _, raw := range []string{`not json`, `{"SEVERE":{"color":"#000"}}`, `{"HIGH":{"color":"orange"}}`} {
+		if err := ConfigureSeverityStyles(raw); err == nil {
+			t.Errorf("ConfigureSeverityStyles(%s) error = nil, want an error", raw)
+		}
+	}
+	// A rejected configuration keeps the previous one
+	if style := SeverityStyleFor("CRITICAL"); style.Color != "#b91c1c" {
+		t.Errorf("CRITICAL color = %q after a rejected configuration, want #b91c1c", style.Color)
+	}
+
+	ConfigureSeverityStyles("")
+	if style := SeverityStyleFor("CRITICAL"); style != DefaultSeverityStyles[0] {
+		t.Errorf("CRITICAL = %+v after reset, want the default", style)
+	}
+}
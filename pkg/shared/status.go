@@ -0,0 +1,116 @@
+package shared
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NotificationStatus is the lifecycle state of a row in the notifications table.
+//
+// The aggregator inserts notifications as RECEIVED. The sender claims them (SENDING) and
+// records the delivery outcome: SENT, PARTIALLY_SENT (some endpoints failed), FAILED (all
+// failed), SIMULATED (dry-run mode) or SUPPRESSED (deliberately not delivered). A
+// notification some of whose endpoints were rate limited is THROTTLED until the sender
+// claims it again (SENDING) to deliver to the rest. Delivered notifications are closed by
+// ACKNOWLEDGED and RESOLVED, or reset to RECEIVED by the aggregator to be re-delivered.
+type NotificationStatus string
+
+// Notification statuses.
+const (
+	NotificationReceived      NotificationStatus = "RECEIVED"
+	NotificationSending       NotificationStatus = "SENDING"
+	NotificationSent          NotificationStatus = "SENT"
+	NotificationPartiallySent NotificationStatus = "PARTIALLY_SENT"
+	NotificationFailed        NotificationStatus = "FAILED"
+	NotificationSimulated     NotificationStatus = "SIMULATED"
+	NotificationSuppressed    NotificationStatus = "SUPPRESSED"
+	NotificationThrottled     NotificationStatus = "THROTTLED"
+	NotificationAcknowledged  NotificationStatus = "ACKNOWLEDGED"
+	NotificationResolved      NotificationStatus = "RESOLVED"
+)
+
+// notificationTransitions lists the statuses each status may move to.
+// SENDING may be re-entered: a sender that crashed mid-send leaves the claim behind and the
+// redelivered message claims it again.
+var notificationTransitions = map[NotificationStatus][]NotificationStatus{
+	NotificationReceived:      {NotificationSending, NotificationSuppressed, NotificationAcknowledged, NotificationResolved},
+	NotificationSending:       {NotificationSending, NotificationSent, NotificationPartiallySent, NotificationFailed, NotificationSimulated, NotificationSuppressed, NotificationThrottled, NotificationAcknowledged, NotificationResolved},
+	NotificationSent:          {NotificationReceived, NotificationAcknowledged, NotificationResolved},
+	NotificationPartiallySent: {NotificationReceived, NotificationAcknowledged, NotificationResolved},
+	NotificationFailed:        {NotificationReceived, NotificationAcknowledged, NotificationResolved},
+	NotificationSimulated:     {NotificationReceived, NotificationAcknowledged, NotificationResolved},
+	NotificationSuppressed:    {NotificationReceived, NotificationAcknowledged, NotificationResolved},
+	NotificationThrottled:     {NotificationSending, NotificationAcknowledged, NotificationResolved},
+	NotificationAcknowledged:  {NotificationResolved},
+	NotificationResolved:      {},
+}
+
+// NotificationStatuses returns all notification statuses in lifecycle order.
+func NotificationStatuses() []NotificationStatus {
+	return []NotificationStatus{
+		NotificationReceived,
+		NotificationSending,
+		NotificationSent,
+		NotificationPartiallySent,
+		NotificationFailed,
+		NotificationSimulated,
+		NotificationSuppressed,
+		NotificationThrottled,
+		NotificationAcknowledged,
+		NotificationResolved,
+	}
+}
+
+// ParseNotificationStatus parses a status name, case-insensitively.
+func ParseNotificationStatus(s string) (NotificationStatus, error) {
+	status := NotificationStatus(strings.ToUpper(strings.TrimSpace(s)))
+	if !status.IsValid() {
+		return "", fmt.Errorf("unknown notification status %q", s)
+	}
+	return status, nil
+}
+
+// String returns the string representation of the status.
+func (s NotificationStatus) String() string {
+	return string(s)
+}
+
+// IsValid reports whether s is a known notification status.
+func (s NotificationStatus) IsValid() bool {
+	_, ok := notificationTransitions[s]
+	return ok
+}
+
+// IsTerminal reports whether delivery of the notification is over, i.e. it is neither
+// RECEIVED nor SENDING. The sender skips such notifications when they are redelivered;
+// THROTTLED ones are picked up from the database instead.
+func (s NotificationStatus) IsTerminal() bool {
+	return s.IsValid() && s != NotificationReceived && s != NotificationSending
+}
+
+// IsClosed reports whether the notification was acknowledged or resolved.
+func (s NotificationStatus) IsClosed() bool {
+	return s == NotificationAcknowledged || s == NotificationResolved
+}
+
+// CanTransitionTo reports whether a notification may move from s to next.
+func (s NotificationStatus) CanTransitionTo(next NotificationStatus) bool {
+	for _, allowed := range notificationTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Predecessors returns the statuses a notification may move to s from, as strings
+// for use in SQL guards (e.g. "WHERE status = ANY($1)").
+func (s NotificationStatus) Predecessors() []string {
+	var from []string
+	for _, status := range NotificationStatuses() {
+		if status.CanTransitionTo(s) {
+			from = append(from, status.String())
+		}
+	}
+	return from
+}
@@ -0,0 +1,90 @@
+package shared
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultTimestampLayout renders notification timestamps for clients without a locale.
+const DefaultTimestampLayout = "2006-01-02 15:04:05 MST"
+
+// timestampLayouts are the client locales notification timestamps can be rendered in.
+// Non-English locales use numeric dates, since Go only formats English month names.
+var timestampLayouts = map[string]string{
+	"en-US": "Jan 2, 2006 3:04:05 PM MST",
+	"en-GB": "2 Jan 2006 15:04:05 MST",
+	"de-DE": "02.01.2006 15:04:05 MST",
+	"fr-FR": "02/01/2006 15:04:05 MST",
+	"es-ES": "02/01/2006 15:04:05 MST",
+	"nl-NL": "02-01-2006 15:04:05 MST",
+	"ja-JP": "2006/01/02 15:04:05 MST",
+}
+
+// SupportedLocales returns the client locales timestamps can be rendered in, sorted.
+func SupportedLocales() []string {
+	locales := make([]string, 0, len(timestampLayouts))
+	for locale := range timestampLayouts {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// ValidateClientTimezone checks that timezone is empty (UTC) or an IANA timezone name.
+func ValidateClientTimezone(timezone string) error {
+	if timezone == "" {
+		return nil
+	}
+	if timezone == "Local" {
+		return fmt.Errorf("timezone must be an IANA timezone name, e.g. Europe/Berlin")
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return fmt.Errorf("unknown timezone %q", timezone)
+	}
+	return nil
+}
+
+// ValidateClientLocale checks that locale is empty (the default layout) or supported.
+func ValidateClientLocale(locale string) error {
+	if locale == "" {
+		return nil
+	}
+	if _, ok := timestampLayouts[locale]; !ok {
+		return fmt.Errorf("unsupported locale %q (supported: %s)", locale, strings.Join(SupportedLocales(), ", "))
+	}
+	return nil
+}
+
+// TimestampFormat renders timestamps in a client's timezone and locale.
+type TimestampFormat struct {
+	Location *time.Location
+	Layout   string
+}
+
+// NewTimestampFormat returns the format for a client's timezone and locale. An empty or
+// unknown timezone falls back to UTC and an empty or unknown locale to DefaultTimestampLayout,
+// so a bad profile never keeps a notification from being rendered.
+func NewTimestampFormat(timezone, locale string) TimestampFormat {
+	format := TimestampFormat{Location: time.UTC, Layout: DefaultTimestampLayout}
+	if timezone != "" && timezone != "Local" {
+		if loc, err := time.LoadLocation(timezone); err == nil {
+			format.Location = loc
+		}
+	}
+	if layout, ok := timestampLayouts[locale]; ok {
+		format.Layout = layout
+	}
+	return format
+}
+
+// Format renders t for display.
+func (f TimestampFormat) Format(t time.Time) string {
+	return t.In(f.Location).Format(f.Layout)
+}
+
+// RFC3339 renders t as RFC 3339 with the client's UTC offset, for machine-readable payloads.
+func (f TimestampFormat) RFC3339(t time.Time) string {
+	return t.In(f.Location).Format(time.RFC3339)
+}
@@ -0,0 +1,118 @@
+package shared
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// FieldError is why one field of a request is invalid. Field is the field's JSON name, with
+// the index of list entries and the name of nested fields (conditions[0].key); it is empty
+// for errors about the request as a whole.
+type FieldError struct {
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every invalid field of a request. WriteValidationError writes it as a
+// 400 response: {"errors":[{"field":"severity","message":"severity is required"}]}.
+type ValidationError struct {
+	Errors []FieldError `json:"errors"`
+}
+
+// Error joins the field messages.
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		messages[i] = fe.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Validator collects the field errors of a request. Every check records an error when it fails
+// and reports whether it passed, so a request is checked in full and the response lists every
+// invalid field, while checks that depend on a field can be skipped when it failed.
+type Validator struct {
+	errors []FieldError
+}
+
+// NewValidator creates a validator with no errors.
+func NewValidator() *Validator {
+	return &Validator{}
+}
+
+// Add records that field is invalid.
+func (v *Validator) Add(field, message string) {
+	v.errors = append(v.errors, FieldError{Field: field, Message: message})
+}
+
+// Addf records that field is invalid, with a formatted message.
+func (v *Validator) Addf(field, format string, args ...any) {
+	v.Add(field, fmt.Sprintf(format, args...))
+}
+
+// Check records message for field unless ok.
+func (v *Validator) Check(ok bool, field, message string) bool {
+	if !ok {
+		v.Add(field, message)
+	}
+	return ok
+}
+
+// Required checks that value is not empty.
+func (v *Validator) Required(field, value string) bool {
+	return v.Check(value != "", field, field+" is required")
+}
+
+// MaxLength checks that value has at most max characters.
+func (v *Validator) MaxLength(field, value string, max int) bool {
+	return v.Check(len([]rune(value)) <= max, field, fmt.Sprintf("%s must be at most %d characters", field, max))
+}
+
+// OneOf checks that value is one of allowed.
+func (v *Validator) OneOf(field, value string, allowed ...string) bool {
+	for _, a := range allowed {
+		if value == a {
+			return true
+		}
+	}
+	v.Addf(field, "%s must be one of: %s", field, strings.Join(allowed, ", "))
+	return false
+}
+
+// Between checks that value is within [min, max].
+func (v *Validator) Between(field string, value, min, max int) bool {
+	return v.Check(value >= min && value <= max, field, fmt.Sprintf("%s must be between %d and %d", field, min, max))
+}
+
+// Valid reports whether no check failed.
+func (v *Validator) Valid() bool {
+	return len(v.errors) == 0
+}
+
+// Errors returns the failed checks, in the order they were recorded.
+func (v *Validator) Errors() []FieldError {
+	return v.errors
+}
+
+// Err returns a *ValidationError listing the failed checks, or nil if none failed.
+func (v *Validator) Err() error {
+	if v.Valid() {
+		return nil
+	}
+	return &ValidationError{Errors: v.errors}
+}
+
+// WriteValidationError writes err as a 400 JSON response. An error other than a
+// *ValidationError is written as a single error without a field.
+func WriteValidationError(w http.ResponseWriter, err error) {
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		verr = &ValidationError{Errors: []FieldError{{Message: err.Error()}}}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(verr)
+}
@@ -0,0 +1,68 @@
+package shared
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestValidator(t *testing.T) {
+	v := NewValidator()
+	if !v.Required("client_id", "client-1") || !v.MaxLength("name", "héllo", 5) || !v.OneOf("severity", "HIGH", "LOW", "HIGH") || !v.Between("days", 7, 1, 90) {
+		t.Fatal("passing checks reported failure")
+	}
+	if !v.Valid() || v.Err() != nil {
+		t.Fatalf("Err() = %v, want nil", v.Err())
+	}
+
+	v.Required("severity", "")
+	v.MaxLength("name", "toolong", 5)
+	v.OneOf("type", "sms", "email", "webhook")
+	v.Between("days", 0, 1, 90)
+	v.Check(false, "", "cannot create rule with all fields as wildcards (*)")
+	v.Addf("conditions[0].key", "conditions[%d]: key is required", 0)
+
+	if got := v.Errors(); len(got) != 6 {
+		t.Errorf("Errors() returned %d errors, want 6", len(got))
+	}
+
+	var verr *ValidationError
+	if !errors.As(v.Err(), &verr) {
+		t.Fatalf("Err() = %v, want *ValidationError", v.Err())
+	}
+	want := []FieldError{
+		{Field: "severity", Message: "severity is required"},
+		{Field: "name", Message: "name must be at most 5 characters"},
+		{Field: "type", Message: "type must be one of: email, webhook"},
+		{Field: "days", Message: "days must be between 1 and 90"},
+		{Message: "cannot create rule with all fields as wildcards (*)"},
+		{Field: "conditions[0].key", Message: "conditions[0]: key is required"},
+	}
+	if !reflect.DeepEqual(verr.Errors, want) {
+		t.Errorf("Errors = %+v, want %+v", verr.Errors, want)
+	}
+}
+
+func TestWriteValidationError(t *testing.T) {
+	v := NewValidator()
+	v.Required("severity", "")
+	v.Required("source", "")
+
+	w := httptest.NewRecorder()
+	WriteValidationError(w, v.Err())
+	if w.Code != http.StatusBadRequest || w.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("status = %d, Content-Type = %q, want 400 JSON", w.Code, w.Header().Get("Content-Type"))
+	}
+	want := `{"errors":[{"field":"severity","message":"severity is required"},{"field":"source","message":"source is required"}]}` + "\n"
+	if w.Body.String() != want {
+		t.Errorf("body = %s, want %s", w.Body.String(), want)
+	}
+
+	w = httptest.NewRecorder()
+	WriteValidationError(w, errors.New("invalid severity-dist"))
+	if want := `{"errors":[{"message":"invalid severity-dist"}]}` + "\n"; w.Body.String() != want {
+		t.Errorf("body = %s, want %s", w.Body.String(), want)
+	}
+}
@@ -2,6 +2,9 @@ import { useState, useEffect, useCallback } from 'react';
 import { clientsAPI } from '../services/api';
 
 const PAGE_SIZE_OPTIONS = [25, 50, 100, 200];
+// Locales notification timestamps can be rendered in (see pkg/shared timestamp.go)
+const LOCALE_OPTIONS = ['en-GB', 'en-US', 'de-DE', 'es-ES', 'fr-FR', 'ja-JP', 'nl-NL'];
+const EMPTY_FORM = { client_id: '', name: '', timezone: '', locale: '' };
 
 export default function Clients() {
   const [clients, setClients] = useState([]);
@@ -9,7 +12,7 @@ export default function Clients() {
   const [error, setError] = useState(null);
   const [success, setSuccess] = useState(null);
   const [showForm, setShowForm] = useState(false);
-  const [formData, setFormData] = useState({ client_id: '', name: '' });
+  const [formData, setFormData] = useState(EMPTY_FORM);
   const [connectionStatus, setConnectionStatus] = useState('checking');
   
   // Pagination state
@@ -127,14 +130,14 @@ export default function Clients() {
       const clientId = formData.client_id;
       const clientName = formData.name;
       
-      const result = await clientsAPI.create(clientId, clientName);
+      const result = await clientsAPI.create(clientId, clientName, formData.timezone.trim(), formData.locale);
       console.log('Client created, response:', result);
       
       // Show success message before clearing form
       setSuccess(`Client "${clientName}" (${clientId}) created successfully!`);
       
       // Clear form and hide it
-      setFormData({ client_id: '', name: '' });
+      setFormData(EMPTY_FORM);
       setShowForm(false);
       
       // Reload clients list immediately
@@ -231,6 +234,29 @@ export default function Clients() {
               placeholder="e.g., Acme Corp"
             />
           </div>
+          <div className="form-group">
+            <label>Timezone</label>
+            <input
+              type="text"
+              value={formData.timezone}
+              onChange={(e) => setFormData({ ...formData, timezone: e.target.value })}
+              placeholder="e.g., Europe/Berlin (default UTC)"
+            />
+          </div>
+          <div className="form-group">
+            <label>Timestamp Locale</label>
+            <select
+              value={formData.locale}
+              onChange={(e) => setFormData({ ...formData, locale: e.target.value })}
+            >
+              <option value="">Default (2006-01-02 15:04:05)</option>
+              {LOCALE_OPTIONS.map((locale) => (
+                <option key={locale} value={locale}>
+                  {locale}
+                </option>
+              ))}
+            </select>
+          </div>
           <div className="button-group">
             <button type="submit" className="btn btn-primary">
               Create Client
@@ -252,6 +278,8 @@ export default function Clients() {
               <tr>
                 <th>Client ID</th>
                 <th>Name</th>
+                <th>Timezone</th>
+                <th>Locale</th>
                 <th>Created At</th>
                 <th>Updated At</th>
               </tr>
@@ -261,6 +289,8 @@ export default function Clients() {
                 <tr key={client.client_id}>
                   <td>{client.client_id}</td>
                   <td>{client.name}</td>
+                  <td>{client.timezone || 'UTC'}</td>
+                  <td>{client.locale || 'Default'}</td>
                   <td>{formatDate(client.created_at)}</td>
                   <td>{formatDate(client.updated_at)}</td>
                 </tr>
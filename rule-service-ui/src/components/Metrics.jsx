@@ -39,6 +39,7 @@ function Metrics() {
     switch (status) {
       case 'SENT': return '#10b981'; // green
       case 'PENDING': return '#f59e0b'; // amber
+      case 'PARTIALLY_SENT': return '#f59e0b'; // amber
       case 'FAILED': return '#ef4444'; // red
       default: return '#6b7280'; // gray
     }
@@ -1,7 +1,8 @@
 import { useState, useEffect, useCallback } from 'react';
 import { notificationsAPI, clientsAPI } from '../services/api';
+import SeverityBadge from './SeverityBadge';
 
-const STATUS_OPTIONS = ['RECEIVED', 'SENT', 'FAILED'];
+const STATUS_OPTIONS = ['RECEIVED', 'SENDING', 'SENT', 'PARTIALLY_SENT', 'FAILED', 'SIMULATED', 'SUPPRESSED', 'THROTTLED', 'ACKNOWLEDGED', 'RESOLVED'];
 const PAGE_SIZE_OPTIONS = [25, 50, 100, 200];
 
 export default function Notifications() {
@@ -226,9 +227,7 @@ export default function Notifications() {
                     <td>{notification.client_id}</td>
                     <td style={{ fontSize: '12px' }}>{notification.alert_id}</td>
                     <td>
-                      <span className={`badge badge-${notification.severity === 'CRITICAL' || notification.severity === 'HIGH' ? 'danger' : 'success'}`}>
-                        {notification.severity}
-                      </span>
+                      <SeverityBadge severity={notification.severity} />
                     </td>
                     <td>{notification.source}</td>
                     <td>{notification.name}</td>
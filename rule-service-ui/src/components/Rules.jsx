@@ -1,9 +1,21 @@
 import { useState, useEffect, useCallback } from 'react';
 import { rulesAPI, clientsAPI } from '../services/api';
+import SeverityBadge from './SeverityBadge';
 
 const SEVERITY_OPTIONS = ['LOW', 'MEDIUM', 'HIGH', 'CRITICAL'];
 const PAGE_SIZE_OPTIONS = [25, 50, 100, 200];
 
+// Exclusion lists are edited as comma-separated text and only apply to wildcard fields
+const parseList = (value) => value.split(',').map((v) => v.trim()).filter(Boolean);
+
+const formExclusions = (formData) => ({
+  sources: formData.source === '*' ? parseList(formData.exclude_sources) : [],
+  names: formData.name === '*' ? parseList(formData.exclude_names) : [],
+});
+
+const withExclusions = (value, exclusions) =>
+  exclusions && exclusions.length > 0 ? `${value} except ${exclusions.join(', ')}` : value;
+
 export default function Rules() {
   const [rules, setRules] = useState([]);
   const [clients, setClients] = useState([]);
@@ -18,6 +30,9 @@ export default function Rules() {
     severity: 'LOW',
     source: '',
     name: '',
+    description: '',
+    exclude_sources: '',
+    exclude_names: '',
   });
 
   // Pagination state
@@ -143,7 +158,9 @@ export default function Rules() {
         formData.client_id,
         formData.severity,
         formData.source,
-        formData.name
+        formData.name,
+        formData.description,
+        formExclusions(formData)
       );
       console.log('Rule created:', result);
       setSuccess('Rule created successfully!');
@@ -166,7 +183,9 @@ export default function Rules() {
         formData.severity,
         formData.source,
         formData.name,
-        editingRule.version
+        formData.description,
+        editingRule.version,
+        formExclusions(formData)
       );
       setSuccess('Rule updated successfully!');
       resetForm();
@@ -213,12 +232,15 @@ export default function Rules() {
       severity: rule.severity,
       source: rule.source,
       name: rule.name,
+      description: rule.description || '',
+      exclude_sources: (rule.exclude_sources || []).join(', '),
+      exclude_names: (rule.exclude_names || []).join(', '),
     });
     setShowForm(true);
   };
 
   const resetForm = () => {
-    setFormData({ client_id: '', severity: 'LOW', source: '', name: '' });
+    setFormData({ client_id: '', severity: 'LOW', source: '', name: '', description: '', exclude_sources: '', exclude_names: '' });
     setEditingRule(null);
     setShowForm(false);
   };
@@ -333,6 +355,38 @@ export default function Rules() {
               placeholder="e.g., timeout, error, latency"
             />
           </div>
+          {formData.source === '*' && (
+            <div className="form-group">
+              <label>Exclude Sources</label>
+              <input
+                type="text"
+                value={formData.exclude_sources}
+                onChange={(e) => setFormData({ ...formData, exclude_sources: e.target.value })}
+                placeholder="Comma-separated, e.g., staging, canary"
+              />
+            </div>
+          )}
+          {formData.name === '*' && (
+            <div className="form-group">
+              <label>Exclude Names</label>
+              <input
+                type="text"
+                value={formData.exclude_names}
+                onChange={(e) => setFormData({ ...formData, exclude_names: e.target.value })}
+                placeholder="Comma-separated, e.g., heartbeat"
+              />
+            </div>
+          )}
+          <div className="form-group">
+            <label>Description</label>
+            <input
+              type="text"
+              value={formData.description}
+              onChange={(e) => setFormData({ ...formData, description: e.target.value })}
+              maxLength={500}
+              placeholder="e.g., API timeouts page the platform on-call"
+            />
+          </div>
           <div className="button-group">
             <button type="submit" className="btn btn-primary">
               {editingRule ? 'Update Rule' : 'Create Rule'}
@@ -363,6 +417,7 @@ export default function Rules() {
                   <th>Severity</th>
                   <th>Source</th>
                   <th>Name</th>
+                  <th>Description</th>
                   <th>Enabled</th>
                   <th>Version</th>
                   <th>Created At</th>
@@ -375,12 +430,11 @@ export default function Rules() {
                     <td style={{ fontSize: '12px' }}>{rule.rule_id}</td>
                     <td>{rule.client_id}</td>
                     <td>
-                      <span className={`badge badge-${rule.severity === 'CRITICAL' ? 'danger' : 'success'}`}>
-                        {rule.severity}
-                      </span>
+                      <SeverityBadge severity={rule.severity} />
                     </td>
-                    <td>{rule.source}</td>
-                    <td>{rule.name}</td>
+                    <td>{withExclusions(rule.source, rule.exclude_sources)}</td>
+                    <td>{withExclusions(rule.name, rule.exclude_names)}</td>
+                    <td>{rule.description || '-'}</td>
                     <td>
                       <span className={rule.enabled ? 'badge badge-success' : 'badge badge-danger'}>
                         {rule.enabled ? 'Enabled' : 'Disabled'}
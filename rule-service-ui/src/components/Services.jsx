@@ -108,6 +108,15 @@ function Services() {
 
       {error && <div className="error-banner">Failed to refresh: {error}</div>}
 
+      {metrics?.emergency_stop?.active && (
+        <div className="error-banner">
+          Emergency stop active: outbound sending is halted
+          {metrics.emergency_stop.stop_jobs && ' and alert generation jobs are stopped'}.
+          Reason: {metrics.emergency_stop.reason}
+          {metrics.emergency_stop.actor && ` (by ${metrics.emergency_stop.actor})`}
+        </div>
+      )}
+
       {/* Service Status Overview */}
       <div className="services-overview">
         {metrics?.known_services?.map((serviceName) => {
@@ -0,0 +1,47 @@
+import { useState, useEffect } from 'react';
+import { severitiesAPI } from '../services/api';
+
+// Severity styles are loaded once and shared by every badge
+let stylesPromise = null;
+
+function loadStyles() {
+  if (!stylesPromise) {
+    stylesPromise = severitiesAPI.list()
+      .then((data) => Object.fromEntries((data.severities || []).map((s) => [s.severity, s])))
+      .catch((err) => {
+        console.error('Failed to load severity styles:', err);
+        stylesPromise = null;
+        return {};
+      });
+  }
+  return stylesPromise;
+}
+
+/**
+ * A severity badge in the severity's color and emoji, as served by GET /api/v1/severities,
+ * so severities look the same as in email and Slack notifications. The wildcard (*) and
+ * severities without a style are shown as a plain badge.
+ */
+export default function SeverityBadge({ severity }) {
+  const [styles, setStyles] = useState({});
+
+  useEffect(() => {
+    let active = true;
+    loadStyles().then((loaded) => {
+      if (active) setStyles(loaded);
+    });
+    return () => {
+      active = false;
+    };
+  }, []);
+
+  const style = styles[severity];
+  if (!style) {
+    return <span className="badge badge-success">{severity}</span>;
+  }
+  return (
+    <span className="badge" title={style.label} style={{ backgroundColor: style.color, color: 'white' }}>
+      {style.emoji} {severity}
+    </span>
+  );
+}
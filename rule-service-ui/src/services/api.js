@@ -40,7 +40,12 @@ async function handleResponse(response) {
       // Try to parse as JSON to extract error message
       try {
         const errorObj = JSON.parse(errorText);
-        errorMessage = errorObj.error || errorText;
+        if (Array.isArray(errorObj.errors)) {
+          // Field errors of an invalid request
+          errorMessage = errorObj.errors.map((e) => e.message).join('; ');
+        } else {
+          errorMessage = errorObj.error || errorText;
+        }
       } catch (parseErr) {
         // Not JSON, use text as-is
         errorMessage = errorText || `HTTP error! status: ${response.status}`;
@@ -78,9 +83,9 @@ async function handleResponse(response) {
 // ============================================================================
 
 export const clientsAPI = {
-  async create(clientId, name) {
+  async create(clientId, name, timezone = '', locale = '') {
     const url = `${API_BASE_URL}/clients`;
-    const body = JSON.stringify({ client_id: clientId, name });
+    const body = JSON.stringify({ client_id: clientId, name, timezone, locale });
     console.log('POST', url, body);
     
     const response = await fetch(url, {
@@ -122,9 +127,17 @@ export const clientsAPI = {
 // ============================================================================
 
 export const rulesAPI = {
-  async create(clientId, severity, source, name) {
+  async create(clientId, severity, source, name, description = '', exclusions = {}) {
     const url = `${API_BASE_URL}/rules`;
-    const body = JSON.stringify({ client_id: clientId, severity, source, name });
+    const body = JSON.stringify({
+      client_id: clientId,
+      severity,
+      source,
+      name,
+      description,
+      exclude_sources: exclusions.sources || [],
+      exclude_names: exclusions.names || [],
+    });
     console.log('POST', url, body);
     
     const response = await fetch(url, {
@@ -165,11 +178,19 @@ export const rulesAPI = {
     return handleResponse(response);
   },
 
-  async update(ruleId, severity, source, name, version) {
+  async update(ruleId, severity, source, name, description, version, exclusions = {}) {
     const response = await fetch(`${API_BASE_URL}/rules/update?rule_id=${ruleId}`, {
       method: 'PUT',
       headers: { 'Content-Type': 'application/json' },
-      body: JSON.stringify({ severity, source, name, version }),
+      body: JSON.stringify({
+        severity,
+        source,
+        name,
+        description,
+        exclude_sources: exclusions.sources || [],
+        exclude_names: exclusions.names || [],
+        version,
+      }),
     });
     return handleResponse(response);
   },
@@ -272,7 +293,7 @@ export const notificationsAPI = {
   /**
    * List notifications with pagination
    * @param {string|null} clientId - Filter by client ID
-   * @param {string|null} status - Filter by status (RECEIVED, SENT, FAILED)
+   * @param {string|null} status - Filter by status, or several comma-separated (e.g. PARTIALLY_SENT,FAILED)
    * @param {number} limit - Number of items per page (default 50, max 200)
    * @param {number} offset - Offset for pagination (default 0)
    * @returns {Promise<{notifications: Array, total: number, limit: number, offset: number}>}
@@ -292,6 +313,24 @@ export const notificationsAPI = {
   },
 };
 
+// ============================================================================
+// Severities API
+// ============================================================================
+
+export const severitiesAPI = {
+  /**
+   * List the color, emoji and label of each severity, as used in notifications
+   * @returns {Promise<{severities: Array<{severity: string, label: string, color: string, emoji: string}>}>}
+   */
+  async list() {
+    const url = `${API_BASE_URL}/severities`;
+    console.log('GET', url);
+    const response = await fetch(url);
+    console.log('Response status:', response.status);
+    return handleResponse(response);
+  },
+};
+
 // ============================================================================
 // System Metrics API
 // ============================================================================
@@ -340,6 +379,11 @@ const ALERT_PRODUCER_API_BASE = API_GATEWAY_URL
   ? (isDirectEC2 ? `${API_GATEWAY_URL}:8082/api/v1/alerts` : `${API_GATEWAY_URL}/alert-producer-api/api/v1/alerts`)
   : '/alert-producer-api/api/v1/alerts';
 
+// Optional alert-producer API key (required when the API server runs with -api-keys)
+const ALERT_PRODUCER_API_KEY = import.meta.env.VITE_ALERT_PRODUCER_API_KEY || '';
+const alertProducerHeaders = (headers = {}) =>
+  ALERT_PRODUCER_API_KEY ? { ...headers, 'X-API-Key': ALERT_PRODUCER_API_KEY } : headers;
+
 export const alertGeneratorAPI = {
   async generate(config) {
     const url = `${ALERT_PRODUCER_API_BASE}/generate`;
@@ -347,7 +391,7 @@ export const alertGeneratorAPI = {
     
     const response = await fetch(url, {
       method: 'POST',
-      headers: { 'Content-Type': 'application/json' },
+      headers: alertProducerHeaders({ 'Content-Type': 'application/json' }),
       body: JSON.stringify(config),
     });
     
@@ -358,7 +402,7 @@ export const alertGeneratorAPI = {
   async getStatus(jobId) {
     const url = `${ALERT_PRODUCER_API_BASE}/generate/status?job_id=${jobId}`;
     console.log('GET', url);
-    const response = await fetch(url);
+    const response = await fetch(url, { headers: alertProducerHeaders() });
     console.log('Response status:', response.status);
     return handleResponse(response);
   },
@@ -369,7 +413,7 @@ export const alertGeneratorAPI = {
       url += `?status=${statusFilter}`;
     }
     console.log('GET', url);
-    const response = await fetch(url);
+    const response = await fetch(url, { headers: alertProducerHeaders() });
     console.log('Response status:', response.status);
     return handleResponse(response);
   },
@@ -379,6 +423,7 @@ export const alertGeneratorAPI = {
     console.log('POST', url);
     const response = await fetch(url, {
       method: 'POST',
+      headers: alertProducerHeaders(),
     });
     console.log('Response status:', response.status);
     return handleResponse(response);
@@ -101,7 +101,7 @@ if [ -n "$REDIS_HOST" ]; then
     echo "✓ Deleted metrics keys: $DELETED"
 
     # Optionally clear rules snapshot to force rebuild
-    # redis-cli -h "$REDIS_HOST" -p "${REDIS_PORT:-6379}" DEL rules:snapshot rules:version
+    # redis-cli -h "$REDIS_HOST" -p "${REDIS_PORT:-6379}" DEL "{rules}:snapshot" "{rules}:version"
 else
     echo "⚠ Redis not configured, skipping Redis cleanup"
 fi
@@ -60,8 +60,19 @@ echo_info "Creating Kafka topics..."
 TOPICS=(
     "alerts.new:9:1"
     "rule.changed:9:1"
+    "endpoint.changed:9:1"
     "alerts.matched:9:1"
     "notifications.ready:9:1"
+    "notifications.grouped:9:1"
+    # Sampled evaluator results for offline QA (written only with -evaluation-sample-rate)
+    "debug.evaluations:3:1"
+    # Alerts matching no rule (written only with the evaluator's -unmatched-policy publish)
+    "alerts.unmatched:3:1"
+    # Dead-letter topics for messages consumers could not process
+    "alerts.new.dlq:3:1"
+    "rule.changed.dlq:3:1"
+    "alerts.matched.dlq:3:1"
+    "notifications.ready.dlq:3:1"
 )
 
 for topic_spec in "${TOPICS[@]}"; do
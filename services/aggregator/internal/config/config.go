@@ -3,6 +3,10 @@ package config
 
 import (
 	"fmt"
+	"time"
+
+	"aggregator/internal/database"
+	"aggregator/internal/events"
 )
 
 // Config holds all configuration parameters for the aggregator service.
@@ -13,6 +17,56 @@ type Config struct {
 	ConsumerGroupID         string
 	PostgresDSN             string
 	RedisAddr               string
+
+	// How the rules of a client that matched an alert become notifications: "client" or "rule"
+	CollapsePolicy string
+
+	// Ownership enrichment (optional). Enabled when CatalogURL or OwnershipFile is set.
+	CatalogURL        string
+	CatalogToken      string
+	OwnershipFile     string
+	OwnershipCacheTTL time.Duration
+
+	// Matched-rule enrichment (optional)
+	RuleEnrichment bool          // Add the matched rules' descriptions to the notification context
+	RuleCacheTTL   time.Duration // How long rule descriptions are cached
+
+	// Open-notification suppression (optional)
+	SuppressOpen     bool          // Fold repeats into the client's open notification with the same fingerprint
+	RenotifyInterval time.Duration // Re-send a suppressed notification after this long (0 = never)
+
+	// Digest grouping (disabled when GroupInterval is 0)
+	GroupInterval             time.Duration // Window over which a client's notifications are grouped into one digest
+	GroupClients              string        // Comma-separated clients to group (empty = all)
+	NotificationsGroupedTopic string
+
+	// Dead-letter queue for matched alerts that cannot be processed (disabled when DLQTopic is empty)
+	DLQTopic       string
+	DLQMaxFailures int // Failed attempts before a message is dead-lettered
+
+	// Batched notification inserts (disabled when InsertBatchSize is below 2)
+	InsertBatchSize     int           // Most notifications written with one INSERT
+	InsertFlushInterval time.Duration // Longest a read message waits for its batch to be written
+}
+
+// BatchInsertsEnabled reports whether new notifications are inserted in batches.
+func (c *Config) BatchInsertsEnabled() bool {
+	return c.InsertBatchSize > 1
+}
+
+// DLQEnabled reports whether unprocessable messages are dead-lettered.
+func (c *Config) DLQEnabled() bool {
+	return c.DLQTopic != ""
+}
+
+// GroupingEnabled reports whether notifications are grouped into digests.
+func (c *Config) GroupingEnabled() bool {
+	return c.GroupInterval > 0
+}
+
+// OwnershipEnabled reports whether ownership enrichment is configured.
+func (c *Config) OwnershipEnabled() bool {
+	return c.CatalogURL != "" || c.OwnershipFile != ""
 }
 
 // Validate checks that all required configuration fields are set and have valid values.
@@ -36,5 +90,32 @@ func (c *Config) Validate() error {
 	if c.RedisAddr == "" {
 		return fmt.Errorf("redis-addr cannot be empty")
 	}
+	if _, err := events.ParseCollapsePolicy(c.CollapsePolicy); err != nil {
+		return fmt.Errorf("collapse-policy: %w", err)
+	}
+	if c.OwnershipCacheTTL < 0 {
+		return fmt.Errorf("ownership-cache-ttl cannot be negative")
+	}
+	if c.RuleCacheTTL < 0 {
+		return fmt.Errorf("rule-cache-ttl cannot be negative")
+	}
+	if c.RenotifyInterval < 0 {
+		return fmt.Errorf("renotify-interval cannot be negative")
+	}
+	if c.GroupInterval < 0 {
+		return fmt.Errorf("group-interval cannot be negative")
+	}
+	if c.GroupingEnabled() && c.NotificationsGroupedTopic == "" {
+		return fmt.Errorf("notifications-grouped-topic cannot be empty when grouping is enabled")
+	}
+	if c.DLQEnabled() && c.DLQMaxFailures <= 0 {
+		return fmt.Errorf("dlq-max-failures must be positive")
+	}
+	if c.InsertBatchSize < 0 || c.InsertBatchSize > database.MaxInsertBatch {
+		return fmt.Errorf("insert-batch-size must be between 0 and %d", database.MaxInsertBatch)
+	}
+	if c.BatchInsertsEnabled() && c.InsertFlushInterval <= 0 {
+		return fmt.Errorf("insert-flush-interval must be positive when batching is enabled")
+	}
 	return nil
 }
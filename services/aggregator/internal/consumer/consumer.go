@@ -6,16 +6,24 @@ import (
 	"fmt"
 	"log/slog"
 
+	"aggregator/internal/events"
+
 	kafkautil "github.com/afikmenashe/alerting-platform/pkg/kafka"
 	pbalerts "github.com/afikmenashe/alerting-platform/pkg/proto/alerts"
-	"aggregator/internal/events"
 	"github.com/segmentio/kafka-go"
 	"google.golang.org/protobuf/proto"
 )
 
+// messageReader is the part of *kafka.Reader the consumer uses.
+type messageReader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
 // Consumer wraps a Kafka reader and provides a simple interface for consuming matched alerts.
 type Consumer struct {
-	reader *kafka.Reader
+	reader messageReader
 	topic  string
 }
 
@@ -50,9 +58,11 @@ func NewConsumer(brokers string, topic string, groupID string) (*Consumer, error
 }
 
 // ReadMessage reads the next message from Kafka and deserializes it as an AlertMatched.
+// Combined events are returned as-is; the processor expands them per client.
+// Messages are fetched without committing them: offsets are only committed by CommitMessage.
 // Returns an error if reading or deserialization fails.
 func (c *Consumer) ReadMessage(ctx context.Context) (*events.AlertMatched, *kafka.Message, error) {
-	msg, err := c.reader.ReadMessage(ctx)
+	msg, err := c.reader.FetchMessage(ctx)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to read message from Kafka: %w", err)
 	}
@@ -72,6 +82,10 @@ func (c *Consumer) ReadMessage(ctx context.Context) (*events.AlertMatched, *kafk
 		Context:       pb.Context,
 		ClientID:      pb.ClientId,
 		RuleIDs:       pb.RuleIds,
+		EvaluatedAtMs: pb.EvaluatedAtMs,
+	}
+	for _, m := range pb.Matches {
+		matched.Matches = append(matched.Matches, events.ClientMatch{ClientID: m.ClientId, RuleIDs: m.RuleIds})
 	}
 
 	return matched, &msg, nil
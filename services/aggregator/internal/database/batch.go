@@ -0,0 +1,211 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/afikmenashe/alerting-platform/pkg/ids"
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+	"github.com/lib/pq"
+)
+
+// MaxInsertBatch is the most notifications InsertNotificationsIdempotent writes in one statement.
+// Each row takes 14 parameters, well within PostgreSQL's limit of 65535 per statement.
+const MaxInsertBatch = 1000
+
+// NewNotification is a notification to insert with InsertNotificationsIdempotent. Its fields are
+// the arguments of InsertNotificationIdempotent.
+type NewNotification struct {
+	ClientID string
+	AlertID  string
+	RuleKey  string
+	Severity string
+	Source   string
+	Name     string
+	Context  map[string]string
+	RuleIDs  []string
+	EventTS  int64
+	Times    StageTimes
+}
+
+// insertNotificationColumns are the columns InsertNotificationsIdempotent writes per row.
+const insertNotificationColumns = 14
+
+// notificationKey is the idempotency key of a notification.
+type notificationKey struct {
+	clientID, alertID, ruleKey string
+}
+
+func (n NewNotification) key() notificationKey {
+	return notificationKey{clientID: n.ClientID, alertID: n.AlertID, ruleKey: n.RuleKey}
+}
+
+// InsertNotificationsIdempotent inserts notifications with a single multi-row INSERT ... ON
+// CONFLICT DO NOTHING, with the same idempotency as InsertNotificationIdempotent. The statement
+// is atomic: either every new notification is stored or none is.
+// Returns, per notification, the new notification or the existing one with the same key. A
+// notification repeating an earlier one of the same batch resolves to it and is never Pending.
+func (db *DB) InsertNotificationsIdempotent(ctx context.Context, notifications []NewNotification) ([]*InsertedNotification, error) {
+	if len(notifications) == 0 {
+		return nil, nil
+	}
+	if len(notifications) > MaxInsertBatch {
+		return nil, fmt.Errorf("batch of %d notifications exceeds the maximum of %d", len(notifications), MaxInsertBatch)
+	}
+
+	notificationIDs := make([]string, len(notifications))
+	for i := range notifications {
+		notificationIDs[i] = ids.New()
+	}
+	query, args, err := buildInsertNotifications(notifications, notificationIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert notifications: %w", err)
+	}
+	defer rows.Close()
+
+	inserted := make(map[string]bool, len(notifications))
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan inserted notification: %w", err)
+		}
+		inserted[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to insert notifications: %w", err)
+	}
+
+	var existing map[notificationKey]InsertedNotification
+	if len(inserted) < len(notifications) {
+		var conflicted []notificationKey
+		for i, n := range notifications {
+			if !inserted[notificationIDs[i]] {
+				conflicted = append(conflicted, n.key())
+			}
+		}
+		if existing, err = db.existingNotifications(ctx, conflicted); err != nil {
+			return nil, err
+		}
+	}
+
+	slog.Info("Inserted notification batch",
+		"count", len(notifications),
+		"inserted", len(inserted),
+	)
+
+	return resolveInserted(notifications, notificationIDs, inserted, existing)
+}
+
+// existingNotifications returns the notifications with the given keys, by key.
+func (db *DB) existingNotifications(ctx context.Context, keys []notificationKey) (map[notificationKey]InsertedNotification, error) {
+	clientIDs := make([]string, len(keys))
+	alertIDs := make([]string, len(keys))
+	ruleKeys := make([]string, len(keys))
+	for i, k := range keys {
+		clientIDs[i], alertIDs[i], ruleKeys[i] = k.clientID, k.alertID, k.ruleKey
+	}
+
+	rows, err := db.conn.QueryContext(ctx, `
+		SELECT notification_id, client_id, alert_id, rule_key, `+pendingNotificationCondition+`
+		FROM notifications
+		WHERE (client_id, alert_id, rule_key) IN (
+			SELECT * FROM unnest($2::text[], $3::text[], $4::text[])
+		)
+	`, shared.NotificationReceived.String(), pq.Array(clientIDs), pq.Array(alertIDs), pq.Array(ruleKeys))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get existing notifications: %w", err)
+	}
+	defer rows.Close()
+
+	existing := make(map[notificationKey]InsertedNotification, len(keys))
+	for rows.Next() {
+		var n InsertedNotification
+		var k notificationKey
+		if err := rows.Scan(&n.NotificationID, &k.clientID, &k.alertID, &k.ruleKey, &n.Pending); err != nil {
+			return nil, fmt.Errorf("failed to scan existing notification: %w", err)
+		}
+		existing[k] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get existing notifications: %w", err)
+	}
+	return existing, nil
+}
+
+// resolveInserted returns the outcome of inserting notifications with notificationIDs, given the
+// IDs the insert returned and the existing notifications the others conflicted with.
+func resolveInserted(notifications []NewNotification, notificationIDs []string, inserted map[string]bool, existing map[notificationKey]InsertedNotification) ([]*InsertedNotification, error) {
+	result := make([]*InsertedNotification, len(notifications))
+	resolved := make(map[notificationKey]bool, len(notifications))
+	for i, n := range notifications {
+		key := n.key()
+		if inserted[notificationIDs[i]] {
+			result[i] = &InsertedNotification{NotificationID: notificationIDs[i], Created: true}
+			resolved[key] = true
+			continue
+		}
+
+		e, ok := existing[key]
+		if !ok {
+			return nil, fmt.Errorf("notification for client %s alert %s conflicted but no longer exists", n.ClientID, n.AlertID)
+		}
+		if resolved[key] {
+			// An earlier notification of this batch already stands for it
+			e.Pending = false
+		}
+		result[i] = &e
+		resolved[key] = true
+	}
+	return result, nil
+}
+
+// buildInsertNotifications returns the multi-row insert of notifications with the given IDs
+// and its arguments.
+func buildInsertNotifications(notifications []NewNotification, notificationIDs []string) (string, []interface{}, error) {
+	values := make([]string, len(notifications))
+	args := make([]interface{}, 0, len(notifications)*insertNotificationColumns)
+	for i, n := range notifications {
+		contextJSON, err := marshalContextToJSONB(n.Context)
+		if err != nil {
+			return "", nil, err
+		}
+
+		placeholders := make([]string, insertNotificationColumns)
+		for j := range placeholders {
+			placeholders[j] = fmt.Sprintf("$%d", i*insertNotificationColumns+j+1)
+		}
+		values[i] = "(" + strings.Join(placeholders, ", ") + ")"
+
+		args = append(args,
+			notificationIDs[i],
+			n.ClientID,
+			n.AlertID,
+			n.Severity,
+			n.Source,
+			n.Name,
+			contextJSON,
+			pq.Array(n.RuleIDs),
+			shared.NotificationReceived.String(),
+			shared.PriorityForSeverity(n.Severity),
+			eventTime(n.EventTS),
+			n.RuleKey,
+			nullTime(n.Times.EvaluatedAt),
+			nullTime(n.Times.AggregatedAt),
+		)
+	}
+
+	query := `
+		INSERT INTO notifications (notification_id, client_id, alert_id, severity, source, name, context, rule_ids, status, priority, event_ts, rule_key, evaluated_at, aggregated_at)
+		VALUES ` + strings.Join(values, ",\n\t\t\t") + `
+		ON CONFLICT (client_id, alert_id, rule_key) DO NOTHING
+		RETURNING notification_id
+	`
+	return query, args, nil
+}
@@ -0,0 +1,82 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildInsertNotifications(t *testing.T) {
+	notifications := []NewNotification{
+		{ClientID: "client-1", AlertID: "alert-1", Severity: "HIGH", RuleIDs: []string{"rule-1"}, Times: StageTimes{AggregatedAt: time.Now()}},
+		{ClientID: "client-2", AlertID: "alert-1", RuleKey: "rule-2", Severity: "LOW", Context: map[string]string{"env": "prod"}},
+	}
+
+	query, args, err := buildInsertNotifications(notifications, []string{"id-1", "id-2"})
+	if err != nil {
+		t.Fatalf("buildInsertNotifications() error = %v", err)
+	}
+	if len(args) != 2*insertNotificationColumns {
+		t.Fatalf("args = %d, want %d", len(args), 2*insertNotificationColumns)
+	}
+	if !strings.Contains(query, "($1, $2,") || !strings.Contains(query, "$15, $16,") || !strings.Contains(query, "$28)") {
+		t.Errorf("query placeholders not numbered per row:\n%s", query)
+	}
+	if !strings.Contains(query, "ON CONFLICT (client_id, alert_id, rule_key) DO NOTHING") {
+		t.Errorf("query is not idempotent:\n%s", query)
+	}
+	if args[0] != "id-1" || args[insertNotificationColumns] != "id-2" {
+		t.Errorf("notification IDs = %v, %v, want id-1, id-2", args[0], args[insertNotificationColumns])
+	}
+	if args[insertNotificationColumns+11] != "rule-2" {
+		t.Errorf("second row rule_key = %v, want rule-2", args[insertNotificationColumns+11])
+	}
+}
+
+func TestDB_InsertNotificationsIdempotent_Limits(t *testing.T) {
+	db := &DB{}
+	ids, err := db.InsertNotificationsIdempotent(context.Background(), nil)
+	if err != nil || ids != nil {
+		t.Errorf("InsertNotificationsIdempotent(nil) = %v, %v, want nil, nil", ids, err)
+	}
+
+	if _, err := db.InsertNotificationsIdempotent(context.Background(), make([]NewNotification, MaxInsertBatch+1)); err == nil {
+		t.Error("InsertNotificationsIdempotent() expected error for an oversized batch")
+	}
+}
+
+func TestResolveInserted(t *testing.T) {
+	notifications := []NewNotification{
+		{ClientID: "client-1", AlertID: "alert-1"},
+		{ClientID: "client-1", AlertID: "alert-2"},
+		{ClientID: "client-1", AlertID: "alert-1"},
+		{ClientID: "client-1", AlertID: "alert-3"},
+		{ClientID: "client-1", AlertID: "alert-3"},
+	}
+	existing := map[notificationKey]InsertedNotification{
+		{clientID: "client-1", alertID: "alert-1"}: {NotificationID: "id-1", Pending: true},
+		{clientID: "client-1", alertID: "alert-3"}: {NotificationID: "old-3", Pending: true},
+	}
+
+	result, err := resolveInserted(notifications, []string{"id-1", "id-2", "id-1b", "id-3", "id-3b"}, map[string]bool{"id-1": true, "id-2": true}, existing)
+	if err != nil {
+		t.Fatalf("resolveInserted() error = %v", err)
+	}
+	want := []InsertedNotification{
+		{NotificationID: "id-1", Created: true},
+		{NotificationID: "id-2", Created: true},
+		{NotificationID: "id-1"},                 // repeats the first row of the batch
+		{NotificationID: "old-3", Pending: true}, // stored by an earlier delivery, never published
+		{NotificationID: "old-3"},                // published once, by the row before it
+	}
+	for i := range want {
+		if *result[i] != want[i] {
+			t.Errorf("result[%d] = %+v, want %+v", i, *result[i], want[i])
+		}
+	}
+
+	if _, err := resolveInserted(notifications[1:2], []string{"id-2"}, nil, nil); err == nil {
+		t.Error("resolveInserted() expected error for a conflicting notification that no longer exists")
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"log/slog"
 	"time"
 
+	"github.com/afikmenashe/alerting-platform/pkg/ids"
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
 	"github.com/lib/pq"
 )
 
@@ -79,10 +81,58 @@ func marshalContextToJSONB(context map[string]string) (sql.NullString, error) {
 	return contextJSON, nil
 }
 
+// eventTime converts an alert's event_ts (Unix seconds) to the notification's event_ts column,
+// NULL if unknown.
+func eventTime(eventTS int64) sql.NullTime {
+	if eventTS <= 0 {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: time.Unix(eventTS, 0).UTC(), Valid: true}
+}
+
+// StageTimes are the times a notification passed the pipeline stages up to its insert. They are
+// stored on the notification so its latency can be attributed to a stage; zero times are NULL.
+type StageTimes struct {
+	EvaluatedAt  time.Time // the evaluator matched the alert
+	AggregatedAt time.Time // the aggregator stored the notification
+}
+
+// nullTime converts a stage time to a nullable column, NULL if unknown.
+func nullTime(t time.Time) sql.NullTime {
+	if t.IsZero() {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
+
+// InsertedNotification is the notification an idempotent insert stored, or the one with the
+// same idempotency key it conflicted with.
+type InsertedNotification struct {
+	NotificationID string
+	// Created is true if the insert stored a new notification.
+	Created bool
+	// Pending is true for an existing notification whose ready event was never published: it is
+	// still RECEIVED, not grouped and has no ready_at, e.g. because the aggregator stopped between
+	// inserting and publishing it. Publishing it again under the same notification_id is safe,
+	// since the sender skips notifications it already claimed.
+	Pending bool
+}
+
+// pendingNotificationCondition selects, with the RECEIVED status as $1, existing notifications
+// whose ready event was never published.
+const pendingNotificationCondition = `status = $1 AND ready_at IS NULL AND group_id IS NULL`
+
 // InsertNotificationIdempotent inserts a notification with idempotency protection.
 // Uses INSERT ... ON CONFLICT DO NOTHING RETURNING to ensure no duplicates.
-// Returns the notification_id if a new row was inserted, or nil if it already existed.
-func (db *DB) InsertNotificationIdempotent(ctx context.Context, clientID, alertID, severity, source, name string, context map[string]string, ruleIDs []string) (*string, error) {
+// The notification_id is a time-ordered ID generated here rather than by the database, so
+// notifications page by ID in creation order. The delivery priority is derived from severity.
+// The idempotency key is (client_id, alert_id, rule_key), enforced by the
+// notifications_client_alert_rule_unique constraint; ruleKey is "" unless the aggregator
+// creates one notification per matched rule.
+// eventTS is the alert's event time in Unix seconds, 0 if unknown; times are stored with it.
+// Returns the new notification, or the existing one with the same key if the insert conflicted,
+// so a redelivered alert resolves to the notification_id of its first delivery.
+func (db *DB) InsertNotificationIdempotent(ctx context.Context, clientID, alertID, ruleKey, severity, source, name string, context map[string]string, ruleIDs []string, eventTS int64, times StageTimes) (*InsertedNotification, error) {
 	// Serialize context map to JSONB
 	contextJSON, err := marshalContextToJSONB(context)
 	if err != nil {
@@ -92,14 +142,15 @@ func (db *DB) InsertNotificationIdempotent(ctx context.Context, clientID, alertI
 	// Use pq.Array to properly handle PostgreSQL array type
 	// This ensures proper escaping and formatting
 	query := `
-		INSERT INTO notifications (client_id, alert_id, severity, source, name, context, rule_ids, status)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, 'RECEIVED')
-		ON CONFLICT (client_id, alert_id) DO NOTHING
+		INSERT INTO notifications (notification_id, client_id, alert_id, severity, source, name, context, rule_ids, status, priority, event_ts, rule_key, evaluated_at, aggregated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		ON CONFLICT (client_id, alert_id, rule_key) DO NOTHING
 		RETURNING notification_id
 	`
 
 	var notificationID string
 	err = db.conn.QueryRowContext(ctx, query,
+		ids.New(),
 		clientID,
 		alertID,
 		severity,
@@ -107,16 +158,18 @@ func (db *DB) InsertNotificationIdempotent(ctx context.Context, clientID, alertI
 		name,
 		contextJSON,
 		pq.Array(ruleIDs),
+		shared.NotificationReceived.String(),
+		shared.PriorityForSeverity(severity),
+		eventTime(eventTS),
+		ruleKey,
+		nullTime(times.EvaluatedAt),
+		nullTime(times.AggregatedAt),
 	).Scan(&notificationID)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
 			// No row was inserted (conflict occurred, row already exists)
-			slog.Debug("Notification already exists, skipping",
-				"client_id", clientID,
-				"alert_id", alertID,
-			)
-			return nil, nil
+			return db.existingNotification(ctx, clientID, alertID, ruleKey)
 		}
 		return nil, fmt.Errorf("failed to insert notification: %w", err)
 	}
@@ -127,5 +180,44 @@ func (db *DB) InsertNotificationIdempotent(ctx context.Context, clientID, alertI
 		"alert_id", alertID,
 	)
 
-	return &notificationID, nil
+	return &InsertedNotification{NotificationID: notificationID, Created: true}, nil
+}
+
+// existingNotification returns the notification an insert with the key (clientID, alertID,
+// ruleKey) conflicted with. ON CONFLICT DO NOTHING waits for a conflicting insert to commit,
+// so the row is visible to this separate statement.
+func (db *DB) existingNotification(ctx context.Context, clientID, alertID, ruleKey string) (*InsertedNotification, error) {
+	existing := &InsertedNotification{}
+	err := db.conn.QueryRowContext(ctx, `
+		SELECT notification_id, `+pendingNotificationCondition+`
+		FROM notifications
+		WHERE client_id = $2 AND alert_id = $3 AND rule_key = $4
+	`, shared.NotificationReceived.String(), clientID, alertID, ruleKey).Scan(&existing.NotificationID, &existing.Pending)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("notification for client %s alert %s conflicted but no longer exists", clientID, alertID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get existing notification: %w", err)
+	}
+
+	slog.Debug("Notification already exists",
+		"notification_id", existing.NotificationID,
+		"client_id", clientID,
+		"alert_id", alertID,
+		"rule_key", ruleKey,
+		"pending", existing.Pending,
+	)
+	return existing, nil
+}
+
+// MarkNotificationReady records when the notification ready event for a notification was
+// published. A re-notified notification keeps the time of its latest ready event.
+func (db *DB) MarkNotificationReady(ctx context.Context, notificationID string, readyAt time.Time) error {
+	_, err := db.conn.ExecContext(ctx, `
+		UPDATE notifications SET ready_at = $2 WHERE notification_id = $1
+	`, notificationID, readyAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to mark notification ready: %w", err)
+	}
+	return nil
 }
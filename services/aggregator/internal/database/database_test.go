@@ -55,7 +55,7 @@ func TestDB_InsertNotificationIdempotent(t *testing.T) {
 	// 1. Successful insert with context
 	// 2. Successful insert with nil context
 	// 3. Successful insert with empty context map
-	// 4. Conflict - notification already exists (returns nil ID, no error)
+	// 4. Conflict - notification already exists (returns the existing notification, Created false)
 	// 5. Database error during insert
 	// 6. Context marshal error (shouldn't happen with valid map, but edge case)
 	// 7. Empty rule IDs array
@@ -0,0 +1,29 @@
+// Package database provides database operations for the notifications table.
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// journalService identifies the aggregator as the writer of notification events.
+const journalService = "aggregator"
+
+// RecordNotificationEvent appends an event to the notification's journal (notification_events).
+// eventErr, if non-nil, is stored as the failure reason.
+func (db *DB) RecordNotificationEvent(ctx context.Context, notificationID, eventType string, eventErr error) error {
+	var errText sql.NullString
+	if eventErr != nil {
+		errText = sql.NullString{String: eventErr.Error(), Valid: true}
+	}
+
+	_, err := db.conn.ExecContext(ctx, `
+		INSERT INTO notification_events (notification_id, service, event_type, error)
+		VALUES ($1, $2, $3, $4)
+	`, notificationID, journalService, eventType, errText)
+	if err != nil {
+		return fmt.Errorf("failed to record notification event: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,269 @@
+// Package database provides database operations for the notifications table.
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/afikmenashe/alerting-platform/pkg/ids"
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+	"github.com/lib/pq"
+)
+
+// DigestSource is the source of digest notifications; their alert ID is DigestAlertPrefix
+// followed by the group ID.
+const (
+	DigestSource      = "digest"
+	DigestAlertPrefix = "digest:"
+)
+
+// Group is a client's notification group for one time window.
+type Group struct {
+	GroupID     string
+	ClientID    string
+	WindowStart time.Time
+	WindowEnd   time.Time
+}
+
+// Digest is the notification created for a group once its window closed.
+type Digest struct {
+	Group
+	NotificationID  string
+	NotificationIDs []string // the grouped notifications, oldest first
+	Severity        string   // of the most severe grouped notification
+	Created         bool     // false if the digest already existed
+}
+
+// InsertGroupedNotificationIdempotent inserts a notification into group, creating the group if
+// needed. It returns nil if the notification already existed.
+// grouped is false if the group's digest was already created, in which case the notification is
+// inserted ungrouped and must be published on its own.
+func (db *DB) InsertGroupedNotificationIdempotent(ctx context.Context, group Group, clientID, alertID, ruleKey, severity, source, name string, context map[string]string, ruleIDs []string, eventTS int64, times StageTimes) (notificationID *string, grouped bool, err error) {
+	contextJSON, err := marshalContextToJSONB(context)
+	if err != nil {
+		return nil, false, err
+	}
+
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO notification_groups (group_id, client_id, window_start, window_end)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (group_id) DO NOTHING
+	`, group.GroupID, group.ClientID, group.WindowStart.UTC(), group.WindowEnd.UTC())
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to insert notification group: %w", err)
+	}
+
+	// The share lock waits for a digest being created, and holds off digest creation
+	// until this notification is committed
+	var flushed bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT digest_notification_id IS NOT NULL OR published_at IS NOT NULL
+		FROM notification_groups WHERE group_id = $1 FOR SHARE
+	`, group.GroupID).Scan(&flushed)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to lock notification group: %w", err)
+	}
+	grouped = !flushed
+	groupID := sql.NullString{String: group.GroupID, Valid: grouped}
+
+	var id string
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO notifications (notification_id, client_id, alert_id, severity, source, name, context, rule_ids, status, priority, group_id, event_ts, rule_key, evaluated_at, aggregated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		ON CONFLICT (client_id, alert_id, rule_key) DO NOTHING
+		RETURNING notification_id
+	`,
+		ids.New(),
+		clientID,
+		alertID,
+		severity,
+		source,
+		name,
+		contextJSON,
+		pq.Array(ruleIDs),
+		shared.NotificationReceived.String(),
+		shared.PriorityForSeverity(severity),
+		groupID,
+		eventTime(eventTS),
+		ruleKey,
+		nullTime(times.EvaluatedAt),
+		nullTime(times.AggregatedAt),
+	).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, grouped, tx.Commit()
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to insert notification: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	slog.Info("Inserted new notification",
+		"notification_id", id,
+		"client_id", clientID,
+		"alert_id", alertID,
+		"group_id", groupID.String,
+	)
+
+	return &id, grouped, nil
+}
+
+// GetDueGroups returns up to limit groups whose window ended before now and whose digest
+// was not published yet, oldest first.
+func (db *DB) GetDueGroups(ctx context.Context, now time.Time, limit int) ([]Group, error) {
+	rows, err := db.conn.QueryContext(ctx, `
+		SELECT group_id, client_id, window_start, window_end
+		FROM notification_groups
+		WHERE published_at IS NULL AND window_end <= $1
+		ORDER BY window_end
+		LIMIT $2
+	`, now.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query due notification groups: %w", err)
+	}
+	defer rows.Close()
+
+	var groups []Group
+	for rows.Next() {
+		var g Group
+		if err := rows.Scan(&g.GroupID, &g.ClientID, &g.WindowStart, &g.WindowEnd); err != nil {
+			return nil, fmt.Errorf("failed to scan notification group: %w", err)
+		}
+		groups = append(groups, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate notification groups: %w", err)
+	}
+	return groups, nil
+}
+
+// CreateDigest creates the digest notification of group: the most severe grouped notification's
+// severity, the union of their rule IDs, and group_id and alert_count in its context. Once the
+// digest exists, later notifications for the group are inserted ungrouped. If the digest was
+// already created (a retried flush), the existing one is returned. A group with no notifications
+// (its only alerts were duplicates) is marked published and nil is returned.
+func (db *DB) CreateDigest(ctx context.Context, group Group) (*Digest, error) {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var digestID sql.NullString
+	err = tx.QueryRowContext(ctx, `
+		SELECT digest_notification_id FROM notification_groups WHERE group_id = $1 FOR UPDATE
+	`, group.GroupID).Scan(&digestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock notification group: %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT notification_id, severity, rule_ids
+		FROM notifications
+		WHERE group_id = $1
+		ORDER BY created_at, notification_id
+	`, group.GroupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query grouped notifications: %w", err)
+	}
+	digest := &Digest{Group: group, NotificationID: digestID.String}
+	var ruleIDs []string
+	seenRules := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		var severity sql.NullString
+		var memberRules []string
+		if err := rows.Scan(&id, &severity, pq.Array(&memberRules)); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan grouped notification: %w", err)
+		}
+		digest.NotificationIDs = append(digest.NotificationIDs, id)
+		if shared.PriorityForSeverity(severity.String) > shared.PriorityForSeverity(digest.Severity) {
+			digest.Severity = severity.String
+		}
+		for _, ruleID := range memberRules {
+			if !seenRules[ruleID] {
+				seenRules[ruleID] = true
+				ruleIDs = append(ruleIDs, ruleID)
+			}
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate grouped notifications: %w", err)
+	}
+
+	if digestID.Valid {
+		return digest, tx.Commit()
+	}
+	if len(digest.NotificationIDs) == 0 {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE notification_groups SET published_at = NOW() WHERE group_id = $1
+		`, group.GroupID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to close empty notification group: %w", err)
+		}
+		return nil, tx.Commit()
+	}
+
+	count := strconv.Itoa(len(digest.NotificationIDs))
+	contextJSON, err := marshalContextToJSONB(map[string]string{"group_id": group.GroupID, "alert_count": count})
+	if err != nil {
+		return nil, err
+	}
+	digest.NotificationID = ids.New()
+	digest.Created = true
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO notifications (notification_id, client_id, alert_id, severity, source, name, context, rule_ids, status, priority)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`,
+		digest.NotificationID,
+		group.ClientID,
+		DigestAlertPrefix+group.GroupID,
+		digest.Severity,
+		DigestSource,
+		count+" alerts",
+		contextJSON,
+		pq.Array(ruleIDs),
+		shared.NotificationReceived.String(),
+		shared.PriorityForSeverity(digest.Severity),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert digest notification: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE notification_groups SET digest_notification_id = $2 WHERE group_id = $1
+	`, group.GroupID, digest.NotificationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set digest notification: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return digest, nil
+}
+
+// MarkGroupPublished records that the group's digest was published.
+func (db *DB) MarkGroupPublished(ctx context.Context, groupID string) error {
+	_, err := db.conn.ExecContext(ctx, `
+		UPDATE notification_groups SET published_at = NOW() WHERE group_id = $1
+	`, groupID)
+	if err != nil {
+		return fmt.Errorf("failed to mark notification group published: %w", err)
+	}
+	return nil
+}
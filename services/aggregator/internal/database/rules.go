@@ -0,0 +1,49 @@
+// Package database provides database operations for the notifications table.
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// Rule holds the rule fields used to describe a matched rule in notification context.
+type Rule struct {
+	RuleID      string
+	Severity    string
+	Source      string
+	Name        string
+	Description string
+}
+
+// GetRules returns the rules with the given IDs. Unknown IDs (e.g. rules deleted
+// since the alert matched) are omitted from the result.
+func (db *DB) GetRules(ctx context.Context, ruleIDs []string) ([]Rule, error) {
+	if len(ruleIDs) == 0 {
+		return nil, nil
+	}
+
+	rows, err := db.conn.QueryContext(ctx, `
+		SELECT rule_id, severity, source, name, description
+		FROM rules
+		WHERE rule_id = ANY($1)
+	`, pq.Array(ruleIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []Rule
+	for rows.Next() {
+		var rule Rule
+		if err := rows.Scan(&rule.RuleID, &rule.Severity, &rule.Source, &rule.Name, &rule.Description); err != nil {
+			return nil, fmt.Errorf("failed to scan rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate rules: %w", err)
+	}
+	return rules, nil
+}
@@ -0,0 +1,82 @@
+// Package database provides database operations for the notifications table.
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+	"github.com/lib/pq"
+)
+
+// ClosedStatuses are the notification statuses that close a notification.
+// A notification in any other status is open and absorbs repeats of its alert.
+var ClosedStatuses = []string{shared.NotificationAcknowledged.String(), shared.NotificationResolved.String()}
+
+// renotifiableStatuses are the statuses that may be reset to RECEIVED for re-delivery:
+// delivery must be over, so a notification still RECEIVED or SENDING is never sent twice at once.
+var renotifiableStatuses = shared.NotificationReceived.Predecessors()
+
+// SuppressedNotification is an open notification that absorbed a repeat of its alert.
+type SuppressedNotification struct {
+	NotificationID string
+	AlertID        string // the alert that opened the notification
+	RepeatCount    int
+	// Renotify is true when the re-notification interval elapsed; the notification
+	// was reset to RECEIVED so the sender delivers it again.
+	Renotify bool
+}
+
+// SuppressOpenNotification folds an alert into the client's newest open notification with the
+// same fingerprint (severity, source, name) and rule key: it increments repeat_count and updated_at, and when
+// renotifyInterval > 0, that long has passed since the notification was last sent and its
+// delivery is over (see renotifiableStatuses), resets it to RECEIVED for re-delivery. Returns nil
+// if the client has no such open notification, or if the open notification is for this very
+// alert (a redelivery, left to the idempotent insert).
+func (db *DB) SuppressOpenNotification(ctx context.Context, clientID, alertID, ruleKey, severity, source, name string, renotifyInterval time.Duration) (*SuppressedNotification, error) {
+	query := `
+		UPDATE notifications n
+		SET repeat_count = n.repeat_count + 1,
+			updated_at = NOW(),
+			last_notified_at = CASE WHEN o.renotify THEN NOW() ELSE n.last_notified_at END,
+			status = CASE WHEN o.renotify THEN 'RECEIVED' ELSE n.status END
+		FROM (
+			SELECT notification_id,
+				$5::float8 > 0 AND COALESCE(last_notified_at, created_at) <= NOW() - make_interval(secs => $5::float8)
+					AND status = ANY($8) AS renotify
+			FROM notifications
+			WHERE client_id = $1
+				AND fingerprint = md5($2 || '|' || $3 || '|' || $4)
+				AND rule_key = $9
+				AND status <> ALL($6)
+			ORDER BY created_at DESC
+			LIMIT 1
+			FOR UPDATE
+		) o
+		WHERE n.notification_id = o.notification_id
+			AND n.alert_id <> $7
+		RETURNING n.notification_id, n.alert_id, n.repeat_count, o.renotify
+	`
+
+	var s SuppressedNotification
+	err := db.conn.QueryRowContext(ctx, query,
+		clientID,
+		severity,
+		source,
+		name,
+		renotifyInterval.Seconds(),
+		pq.Array(ClosedStatuses),
+		alertID,
+		pq.Array(renotifiableStatuses),
+		ruleKey,
+	).Scan(&s.NotificationID, &s.AlertID, &s.RepeatCount, &s.Renotify)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to suppress into open notification: %w", err)
+	}
+	return &s, nil
+}
@@ -1,8 +1,37 @@
-// Package events defines the event structures for alerts.matched and notifications.ready topics.
+// Package events defines the event structures for alerts.matched, notifications.ready and notifications.grouped topics.
 package events
 
+import (
+	"fmt"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// CollapsePolicy decides how the rules of one client that matched an alert become notifications.
+type CollapsePolicy string
+
+const (
+	// CollapseClient creates one notification per client and alert; its rule_ids lists every
+	// rule of the client that matched. This is the default.
+	CollapseClient CollapsePolicy = "client"
+	// CollapseRule creates one notification per matched rule; its rule_ids holds that rule only.
+	CollapseRule CollapsePolicy = "rule"
+)
+
+// ParseCollapsePolicy parses a collapse policy name. An empty name is CollapseClient.
+func ParseCollapsePolicy(s string) (CollapsePolicy, error) {
+	switch CollapsePolicy(s) {
+	case "", CollapseClient:
+		return CollapseClient, nil
+	case CollapseRule:
+		return CollapseRule, nil
+	}
+	return "", fmt.Errorf("unknown collapse policy %q (want %q or %q)", s, CollapseClient, CollapseRule)
+}
+
 // AlertMatched represents a matched alert event from the alerts.matched topic.
-// One message per client_id, containing the alert and the rule_ids that matched for that client.
+// Per-client events carry one client_id and the rule_ids that matched for it;
+// combined events carry every matching client in Matches instead (see PerClient).
 type AlertMatched struct {
 	AlertID       string            `json:"alert_id"`
 	SchemaVersion int               `json:"schema_version"`
@@ -11,17 +40,76 @@ type AlertMatched struct {
 	Source        string            `json:"source"`
 	Name          string            `json:"name"`
 	Context       map[string]string `json:"context,omitempty"`
-	ClientID      string            `json:"client_id"` // The client this message is for
-	RuleIDs       []string          `json:"rule_ids"` // All rule IDs that matched for this client
+	ClientID      string            `json:"client_id"`         // The client this message is for
+	RuleIDs       []string          `json:"rule_ids"`          // All rule IDs that matched for this client
+	Matches       []ClientMatch     `json:"matches,omitempty"` // Every matching client (combined events only)
+	EvaluatedAtMs int64             `json:"evaluated_at_ms"`   // When the evaluator matched the alert (Unix ms, 0 if unknown)
+}
+
+// ClientMatch is the set of rules that matched an alert for one client.
+type ClientMatch struct {
+	ClientID string   `json:"client_id"`
+	RuleIDs  []string `json:"rule_ids"`
+}
+
+// PerClient returns the event as per-client events: the event itself when it is
+// already per-client, or one copy per entry of Matches when it is combined.
+func (m *AlertMatched) PerClient() []*AlertMatched {
+	if len(m.Matches) == 0 {
+		return []*AlertMatched{m}
+	}
+	out := make([]*AlertMatched, 0, len(m.Matches))
+	for _, cm := range m.Matches {
+		perClient := *m
+		perClient.ClientID = cm.ClientID
+		perClient.RuleIDs = cm.RuleIDs
+		perClient.Matches = nil
+		// Enrichment writes to Context, so each client gets its own copy
+		if m.Context != nil {
+			perClient.Context = make(map[string]string, len(m.Context))
+			for k, v := range m.Context {
+				perClient.Context[k] = v
+			}
+		}
+		out = append(out, &perClient)
+	}
+	return out
+}
+
+// PerRule returns a per-client event as one event per matched rule, each with RuleIDs set to
+// that rule only. An event without rule IDs is returned as is.
+func (m *AlertMatched) PerRule() []*AlertMatched {
+	if len(m.RuleIDs) <= 1 {
+		return []*AlertMatched{m}
+	}
+	out := make([]*AlertMatched, 0, len(m.RuleIDs))
+	for _, ruleID := range m.RuleIDs {
+		perRule := *m
+		perRule.RuleIDs = []string{ruleID}
+		if m.Context != nil {
+			perRule.Context = make(map[string]string, len(m.Context))
+			for k, v := range m.Context {
+				perRule.Context[k] = v
+			}
+		}
+		out = append(out, &perRule)
+	}
+	return out
 }
 
 // NotificationReady represents a notification ready event to be published to notifications.ready topic.
 // Emitted only for newly created notifications (after successful idempotent insert).
+// The *AtMs fields are the times (Unix ms, 0 if unknown) the notification passed each stage,
+// so its latency can be attributed to the stage that added it.
 type NotificationReady struct {
 	NotificationID string `json:"notification_id"`
 	ClientID       string `json:"client_id"`
 	AlertID        string `json:"alert_id"`
 	SchemaVersion  int    `json:"schema_version"`
+	Priority       int    `json:"priority"`         // derived from severity, see shared.PriorityForSeverity
+	EvaluatedAtMs  int64  `json:"evaluated_at_ms"`  // the evaluator matched the alert
+	AggregatedAtMs int64  `json:"aggregated_at_ms"` // the aggregator stored the notification
+	ReadyAtMs      int64  `json:"ready_at_ms"`      // the aggregator published this event
 }
 
 // NewNotificationReady creates a new NotificationReady event from an AlertMatched event and notification ID.
@@ -31,5 +119,21 @@ func NewNotificationReady(matched *AlertMatched, notificationID string) *Notific
 		ClientID:       matched.ClientID,
 		AlertID:        matched.AlertID,
 		SchemaVersion:  matched.SchemaVersion,
+		Priority:       shared.PriorityForSeverity(matched.Severity),
+		EvaluatedAtMs:  matched.EvaluatedAtMs,
 	}
 }
+
+// NotificationGrouped represents a digest event to be published to notifications.grouped topic.
+// Emitted once a client's grouping window closes; NotificationID is the digest notification
+// and NotificationIDs are the notifications it groups.
+type NotificationGrouped struct {
+	NotificationID  string   `json:"notification_id"`
+	ClientID        string   `json:"client_id"`
+	GroupID         string   `json:"group_id"`
+	NotificationIDs []string `json:"notification_ids"`
+	WindowStart     int64    `json:"window_start"` // Unix seconds
+	WindowEnd       int64    `json:"window_end"`   // Unix seconds
+	SchemaVersion   int      `json:"schema_version"`
+	Priority        int      `json:"priority"` // of the most severe grouped alert
+}
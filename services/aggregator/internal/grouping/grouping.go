@@ -0,0 +1,223 @@
+// Package grouping implements digest mode: instead of one notification per matched alert,
+// a grouped client's notifications are held per time window (every Interval, aligned to the
+// clock) and, once the window closes, listed in a single digest notification published to
+// notifications.grouped.
+package grouping
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"aggregator/internal/database"
+	"aggregator/internal/events"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// DefaultCheckInterval is how often the flusher scans for groups whose window closed.
+const DefaultCheckInterval = 10 * time.Second
+
+// flushBatchSize bounds how many groups are flushed per scan.
+const flushBatchSize = 100
+
+// digestSchemaVersion is the schema version of notifications.grouped events.
+const digestSchemaVersion = 1
+
+// Notification journal event types written for digests.
+const (
+	journalCreated       = "created"
+	journalEnqueued      = "enqueued"
+	journalEnqueueFailed = "enqueue_failed"
+)
+
+// Policy decides which clients are grouped and over what window.
+type Policy struct {
+	Interval time.Duration
+	Clients  map[string]bool // empty groups every client
+}
+
+// Enabled reports whether any client is grouped.
+func (p Policy) Enabled() bool {
+	return p.Interval > 0
+}
+
+// Window returns the group of clientID's notifications at now, or false if the client
+// is not grouped. Windows are aligned to multiples of Interval since the Unix epoch, so
+// every aggregator instance puts a client's notifications in the same group.
+func (p Policy) Window(clientID string, now time.Time) (database.Group, bool) {
+	if !p.Enabled() || (len(p.Clients) > 0 && !p.Clients[clientID]) {
+		return database.Group{}, false
+	}
+	start := now.UTC().Truncate(p.Interval)
+	return database.Group{
+		GroupID:     fmt.Sprintf("%s:%d", clientID, start.Unix()),
+		ClientID:    clientID,
+		WindowStart: start,
+		WindowEnd:   start.Add(p.Interval),
+	}, true
+}
+
+// ParseClients parses a comma-separated client list, trimming each entry.
+func ParseClients(s string) map[string]bool {
+	clients := make(map[string]bool)
+	for _, part := range strings.Split(s, ",") {
+		if client := strings.TrimSpace(part); client != "" {
+			clients[client] = true
+		}
+	}
+	return clients
+}
+
+// Store provides the group persistence needed by the flusher.
+type Store interface {
+	// GetDueGroups returns groups whose window closed and whose digest was not published.
+	GetDueGroups(ctx context.Context, now time.Time, limit int) ([]database.Group, error)
+	// CreateDigest creates (or returns the existing) digest notification of a group.
+	CreateDigest(ctx context.Context, group database.Group) (*database.Digest, error)
+	// MarkGroupPublished records that the group's digest was published.
+	MarkGroupPublished(ctx context.Context, groupID string) error
+	// RecordNotificationEvent appends an event to the notification journal.
+	RecordNotificationEvent(ctx context.Context, notificationID, eventType string, eventErr error) error
+}
+
+// Publisher publishes digest events to notifications.grouped.
+type Publisher interface {
+	PublishGrouped(ctx context.Context, grouped *events.NotificationGrouped) error
+}
+
+// MetricsRecorder is the subset of metrics used by the flusher.
+type MetricsRecorder interface {
+	RecordPublished()
+	RecordError()
+	IncrementCustom(name string)
+}
+
+// Flusher creates and publishes the digests of groups whose window closed.
+// Several aggregator instances may flush the same group; the digest is created once
+// and a duplicate event is skipped by the sender, which delivers each notification once.
+type Flusher struct {
+	store     Store
+	publisher Publisher
+	metrics   MetricsRecorder
+	interval  time.Duration
+	now       func() time.Time
+}
+
+// NewFlusher creates a flusher that scans every interval.
+// A non-positive interval uses DefaultCheckInterval.
+func NewFlusher(store Store, publisher Publisher, m MetricsRecorder, interval time.Duration) *Flusher {
+	if interval <= 0 {
+		interval = DefaultCheckInterval
+	}
+	return &Flusher{
+		store:     store,
+		publisher: publisher,
+		metrics:   m,
+		interval:  interval,
+		now:       time.Now,
+	}
+}
+
+// Run scans for closed groups until ctx is cancelled.
+func (f *Flusher) Run(ctx context.Context) {
+	slog.Info("Starting digest flusher", "check_interval", f.interval)
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Digest flusher stopped")
+			return
+		case <-ticker.C:
+			f.FlushOnce(ctx)
+		}
+	}
+}
+
+// FlushOnce performs a single scan and returns the number of groups flushed.
+// A group whose digest fails to publish is retried on the next scan.
+func (f *Flusher) FlushOnce(ctx context.Context) int {
+	groups, err := f.store.GetDueGroups(ctx, f.now(), flushBatchSize)
+	if err != nil {
+		slog.Error("Failed to check for due notification groups", "error", err)
+		f.metrics.RecordError()
+		return 0
+	}
+
+	published := 0
+	for _, group := range groups {
+		if err := f.flush(ctx, group); err != nil {
+			slog.Error("Failed to flush notification group",
+				"group_id", group.GroupID,
+				"client_id", group.ClientID,
+				"error", err,
+			)
+			f.metrics.RecordError()
+			continue
+		}
+		published++
+	}
+	return published
+}
+
+// flush creates and publishes one group's digest.
+func (f *Flusher) flush(ctx context.Context, group database.Group) error {
+	digest, err := f.store.CreateDigest(ctx, group)
+	if err != nil {
+		return err
+	}
+	if digest == nil {
+		slog.Debug("Notification group is empty, nothing to publish", "group_id", group.GroupID)
+		return nil
+	}
+	if digest.Created {
+		f.journal(ctx, digest.NotificationID, journalCreated, nil)
+	}
+
+	grouped := &events.NotificationGrouped{
+		NotificationID:  digest.NotificationID,
+		ClientID:        digest.ClientID,
+		GroupID:         digest.GroupID,
+		NotificationIDs: digest.NotificationIDs,
+		WindowStart:     digest.WindowStart.Unix(),
+		WindowEnd:       digest.WindowEnd.Unix(),
+		SchemaVersion:   digestSchemaVersion,
+		Priority:        shared.PriorityForSeverity(digest.Severity),
+	}
+	if err := f.publisher.PublishGrouped(ctx, grouped); err != nil {
+		f.journal(ctx, digest.NotificationID, journalEnqueueFailed, err)
+		return fmt.Errorf("failed to publish digest: %w", err)
+	}
+	f.journal(ctx, digest.NotificationID, journalEnqueued, nil)
+
+	// A failure here republishes the digest on the next scan; the sender skips it
+	if err := f.store.MarkGroupPublished(ctx, group.GroupID); err != nil {
+		return err
+	}
+
+	f.metrics.RecordPublished()
+	f.metrics.IncrementCustom("digests_published")
+	slog.Info("Published digest notification",
+		"notification_id", digest.NotificationID,
+		"group_id", digest.GroupID,
+		"client_id", digest.ClientID,
+		"notifications", len(digest.NotificationIDs),
+	)
+	return nil
+}
+
+// journal records a digest event in the notification journal. Failures are logged and counted.
+func (f *Flusher) journal(ctx context.Context, notificationID, eventType string, eventErr error) {
+	if err := f.store.RecordNotificationEvent(ctx, notificationID, eventType, eventErr); err != nil {
+		slog.Warn("Failed to record notification event",
+			"notification_id", notificationID,
+			"event_type", eventType,
+			"error", err,
+		)
+		f.metrics.IncrementCustom("journal_errors")
+	}
+}
@@ -0,0 +1,189 @@
+package grouping
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"aggregator/internal/database"
+	"aggregator/internal/events"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+func TestPolicy_Window(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 34, 56, 0, time.UTC)
+	policy := Policy{Interval: 10 * time.Minute, Clients: map[string]bool{"client-1": true}}
+
+	group, ok := policy.Window("client-1", now)
+	if !ok {
+		t.Fatal("Window() for a grouped client returned false")
+	}
+	wantStart := time.Date(2026, 10, 15, 12, 30, 0, 0, time.UTC)
+	if !group.WindowStart.Equal(wantStart) || !group.WindowEnd.Equal(wantStart.Add(10*time.Minute)) {
+		t.Errorf("window = %v - %v, want 12:30 - 12:40", group.WindowStart, group.WindowEnd)
+	}
+	if group.ClientID != "client-1" || group.GroupID != "client-1:1792067400" {
+		t.Errorf("group = %+v, want client-1:1792067400", group)
+	}
+
+	// The same window for every alert in it, on any instance
+	if again, _ := policy.Window("client-1", now.Add(4*time.Minute)); again.GroupID != group.GroupID {
+		t.Errorf("group at 12:38 = %s, want %s", again.GroupID, group.GroupID)
+	}
+	if _, ok := policy.Window("client-2", now); ok {
+		t.Error("Window() for a client not in Clients returned true")
+	}
+	if _, ok := (Policy{Interval: time.Minute}).Window("client-2", now); !ok {
+		t.Error("Window() with no Clients should group every client")
+	}
+	if _, ok := (Policy{}).Window("client-1", now); ok {
+		t.Error("Window() with no interval returned true")
+	}
+}
+
+func TestParseClients(t *testing.T) {
+	got := ParseClients(" client-1,,client-2 ")
+	want := map[string]bool{"client-1": true, "client-2": true}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseClients() = %v, want %v", got, want)
+	}
+	if got := ParseClients(""); len(got) != 0 {
+		t.Errorf("ParseClients(\"\") = %v, want empty", got)
+	}
+}
+
+// fakeStore returns fixed due groups and digests, and records journal events.
+type fakeStore struct {
+	groups    []database.Group
+	digests   map[string]*database.Digest
+	dueErr    error
+	digestErr error
+	published []string
+	journal   map[string][]string
+}
+
+func (f *fakeStore) GetDueGroups(ctx context.Context, now time.Time, limit int) ([]database.Group, error) {
+	return f.groups, f.dueErr
+}
+
+func (f *fakeStore) CreateDigest(ctx context.Context, group database.Group) (*database.Digest, error) {
+	if f.digestErr != nil {
+		return nil, f.digestErr
+	}
+	return f.digests[group.GroupID], nil
+}
+
+func (f *fakeStore) MarkGroupPublished(ctx context.Context, groupID string) error {
+	f.published = append(f.published, groupID)
+	return nil
+}
+
+func (f *fakeStore) RecordNotificationEvent(ctx context.Context, notificationID, eventType string, eventErr error) error {
+	if f.journal == nil {
+		f.journal = make(map[string][]string)
+	}
+	f.journal[notificationID] = append(f.journal[notificationID], eventType)
+	return nil
+}
+
+// fakePublisher records published digests and fails for the listed groups.
+type fakePublisher struct {
+	published []*events.NotificationGrouped
+	fail      map[string]bool
+}
+
+func (f *fakePublisher) PublishGrouped(ctx context.Context, grouped *events.NotificationGrouped) error {
+	if f.fail[grouped.GroupID] {
+		return errors.New("kafka down")
+	}
+	f.published = append(f.published, grouped)
+	return nil
+}
+
+// fakeMetrics counts recorded metrics.
+type fakeMetrics struct {
+	published int
+	errors    int
+	custom    map[string]int
+}
+
+func (f *fakeMetrics) RecordPublished() { f.published++ }
+func (f *fakeMetrics) RecordError()     { f.errors++ }
+func (f *fakeMetrics) IncrementCustom(name string) {
+	if f.custom == nil {
+		f.custom = make(map[string]int)
+	}
+	f.custom[name]++
+}
+
+func TestFlusher_FlushOnce(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	group := func(id string) database.Group {
+		return database.Group{GroupID: id, ClientID: "client-1", WindowStart: start, WindowEnd: start.Add(10 * time.Minute)}
+	}
+	store := &fakeStore{
+		groups: []database.Group{group("new"), group("retried"), group("empty"), group("down")},
+		digests: map[string]*database.Digest{
+			"new":     {Group: group("new"), NotificationID: "digest-1", NotificationIDs: []string{"n1", "n2"}, Severity: "CRITICAL", Created: true},
+			"retried": {Group: group("retried"), NotificationID: "digest-2", NotificationIDs: []string{"n3"}, Severity: "LOW"},
+			"down":    {Group: group("down"), NotificationID: "digest-3", NotificationIDs: []string{"n4"}, Created: true},
+		},
+	}
+	publisher := &fakePublisher{fail: map[string]bool{"down": true}}
+	m := &fakeMetrics{}
+	flusher := NewFlusher(store, publisher, m, 0)
+
+	if got := flusher.FlushOnce(context.Background()); got != 3 {
+		t.Errorf("FlushOnce() = %d, want 3", got)
+	}
+	if flusher.interval != DefaultCheckInterval {
+		t.Errorf("interval = %v, want default %v", flusher.interval, DefaultCheckInterval)
+	}
+
+	if len(publisher.published) != 2 {
+		t.Fatalf("published %d digests, want 2", len(publisher.published))
+	}
+	want := &events.NotificationGrouped{
+		NotificationID:  "digest-1",
+		ClientID:        "client-1",
+		GroupID:         "new",
+		NotificationIDs: []string{"n1", "n2"},
+		WindowStart:     1700000000,
+		WindowEnd:       1700000600,
+		SchemaVersion:   1,
+		Priority:        shared.PriorityCritical,
+	}
+	if !reflect.DeepEqual(publisher.published[0], want) {
+		t.Errorf("published = %+v, want %+v", publisher.published[0], want)
+	}
+	if !reflect.DeepEqual(store.published, []string{"new", "retried"}) {
+		t.Errorf("marked published = %v, want new and retried (the failed group is retried)", store.published)
+	}
+	if got := store.journal["digest-1"]; !reflect.DeepEqual(got, []string{"created", "enqueued"}) {
+		t.Errorf("journal of new digest = %v, want created, enqueued", got)
+	}
+	if got := store.journal["digest-2"]; !reflect.DeepEqual(got, []string{"enqueued"}) {
+		t.Errorf("journal of retried digest = %v, want only enqueued", got)
+	}
+	if got := store.journal["digest-3"]; !reflect.DeepEqual(got, []string{"created", "enqueue_failed"}) {
+		t.Errorf("journal of failed digest = %v, want created, enqueue_failed", got)
+	}
+	if m.published != 2 || m.errors != 1 || m.custom["digests_published"] != 2 {
+		t.Errorf("metrics = %+v, want 2 published and 1 error", m)
+	}
+}
+
+func TestFlusher_FlushOnceQueryError(t *testing.T) {
+	m := &fakeMetrics{}
+	flusher := NewFlusher(&fakeStore{dueErr: errors.New("db down")}, &fakePublisher{}, m, time.Second)
+
+	if got := flusher.FlushOnce(context.Background()); got != 0 {
+		t.Errorf("FlushOnce() = %d, want 0", got)
+	}
+	if m.errors != 1 {
+		t.Errorf("errors = %d, want 1", m.errors)
+	}
+}
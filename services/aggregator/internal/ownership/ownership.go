@@ -0,0 +1,175 @@
+// Package ownership resolves the owning team of an alert source and enriches
+// notification context with owner, team, and Slack channel information.
+// Lookups go to a Backstage-compatible service catalog first, are cached in memory,
+// and fall back to a static mapping file when the catalog has no answer.
+package ownership
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"aggregator/internal/events"
+)
+
+// Context keys written into the notification context by the Enricher.
+const (
+	ContextKeyOwner        = "owner"
+	ContextKeyTeam         = "team"
+	ContextKeySlackChannel = "slack_channel"
+)
+
+// DefaultCacheTTL is how long a resolved (or missing) ownership entry is cached.
+const DefaultCacheTTL = 5 * time.Minute
+
+// DefaultFailureTTL is how long the answer for a source is cached after a failed catalog
+// lookup, so an unavailable catalog is not queried (and waited for) on every alert.
+const DefaultFailureTTL = 30 * time.Second
+
+// Owner describes who owns an alert source.
+type Owner struct {
+	Owner        string `json:"owner"`
+	Team         string `json:"team"`
+	SlackChannel string `json:"slack_channel"`
+}
+
+// Lookup resolves the owner of a source.
+// Implementations return (nil, nil) when the source is unknown.
+type Lookup interface {
+	Lookup(ctx context.Context, source string) (*Owner, error)
+}
+
+// cacheEntry holds a cached lookup result. A nil owner caches a miss.
+type cacheEntry struct {
+	owner     *Owner
+	expiresAt time.Time
+}
+
+// Enricher attaches ownership information to matched alerts.
+// It is safe for concurrent use.
+type Enricher struct {
+	catalog    Lookup
+	fallback   Lookup
+	ttl        time.Duration
+	failureTTL time.Duration
+	now        func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+}
+
+// NewEnricher creates an enricher that queries catalog first and fallback second.
+// Either lookup may be nil. A non-positive ttl uses DefaultCacheTTL.
+func NewEnricher(catalog, fallback Lookup, ttl time.Duration) *Enricher {
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	return &Enricher{
+		catalog:    catalog,
+		fallback:   fallback,
+		ttl:        ttl,
+		failureTTL: min(DefaultFailureTTL, ttl),
+		now:        time.Now,
+		cache:      make(map[string]cacheEntry),
+	}
+}
+
+// Enrich resolves the owner of matched.Source and adds owner/team/slack_channel
+// to matched.Context. Keys already present in the context are never overwritten,
+// so producers can set ownership explicitly. Returns true if any key was added.
+func (e *Enricher) Enrich(ctx context.Context, matched *events.AlertMatched) bool {
+	if matched == nil || matched.Source == "" {
+		return false
+	}
+
+	owner := e.resolve(ctx, matched.Source)
+	if owner == nil {
+		return false
+	}
+
+	if matched.Context == nil {
+		matched.Context = make(map[string]string)
+	}
+
+	added := setIfMissing(matched.Context, ContextKeyOwner, owner.Owner)
+	added = setIfMissing(matched.Context, ContextKeyTeam, owner.Team) || added
+	added = setIfMissing(matched.Context, ContextKeySlackChannel, owner.SlackChannel) || added
+	return added
+}
+
+// resolve returns the owner for source using the cache, the catalog, and the fallback in that order.
+func (e *Enricher) resolve(ctx context.Context, source string) *Owner {
+	if owner, ok := e.cached(source); ok {
+		return owner
+	}
+
+	var owner *Owner
+	if e.catalog != nil {
+		found, err := e.catalog.Lookup(ctx, source)
+		if err != nil {
+			// Cache the fallback's answer briefly so the catalog is retried soon, but not per alert
+			slog.Warn("Service catalog lookup failed, using fallback mapping",
+				"source", source,
+				"error", err,
+			)
+			owner = e.lookupFallback(ctx, source)
+			e.store(source, owner, e.failureTTL)
+			return owner
+		}
+		owner = found
+	}
+
+	if owner == nil {
+		owner = e.lookupFallback(ctx, source)
+	}
+
+	e.store(source, owner, e.ttl)
+	return owner
+}
+
+// lookupFallback queries the static fallback mapping, if configured.
+func (e *Enricher) lookupFallback(ctx context.Context, source string) *Owner {
+	if e.fallback == nil {
+		return nil
+	}
+	owner, err := e.fallback.Lookup(ctx, source)
+	if err != nil {
+		slog.Warn("Fallback ownership lookup failed", "source", source, "error", err)
+		return nil
+	}
+	return owner
+}
+
+func (e *Enricher) cached(source string) (*Owner, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	entry, ok := e.cache[source]
+	if !ok {
+		return nil, false
+	}
+	if e.now().After(entry.expiresAt) {
+		delete(e.cache, source)
+		return nil, false
+	}
+	return entry.owner, true
+}
+
+func (e *Enricher) store(source string, owner *Owner, ttl time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.cache[source] = cacheEntry{owner: owner, expiresAt: e.now().Add(ttl)}
+}
+
+// setIfMissing sets key to value when value is non-empty and key is not already set.
+func setIfMissing(m map[string]string, key, value string) bool {
+	if value == "" {
+		return false
+	}
+	if _, exists := m[key]; exists {
+		return false
+	}
+	m[key] = value
+	return true
+}
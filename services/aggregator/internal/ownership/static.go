@@ -0,0 +1,36 @@
+package ownership
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// StaticMapping is an in-memory source -> owner mapping, typically loaded from a JSON file.
+type StaticMapping map[string]Owner
+
+// LoadStaticMapping reads a JSON file of the form:
+//
+//	{"payments": {"owner": "group:default/payments", "team": "payments", "slack_channel": "#payments-alerts"}}
+func LoadStaticMapping(path string) (StaticMapping, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ownership mapping file: %w", err)
+	}
+
+	var mapping StaticMapping
+	if err := json.Unmarshal(data, &mapping); err != nil {
+		return nil, fmt.Errorf("failed to parse ownership mapping file: %w", err)
+	}
+	return mapping, nil
+}
+
+// Lookup returns the mapped owner for source, or nil if the source is not mapped.
+func (m StaticMapping) Lookup(_ context.Context, source string) (*Owner, error) {
+	owner, ok := m[source]
+	if !ok {
+		return nil, nil
+	}
+	return &owner, nil
+}
@@ -0,0 +1,208 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"aggregator/internal/database"
+	"aggregator/internal/events"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// insertBatch buffers the notifications of new alerts to insert them with one multi-row INSERT,
+// and the messages read since the last flush, whose offsets are committed once the buffered
+// notifications are written.
+type insertBatch struct {
+	storage       BatchStorage
+	maxSize       int
+	flushInterval time.Duration
+
+	pending  []pendingInsert
+	messages []*kafka.Message // processed since the last flush, in read order
+	current  *kafka.Message   // the message being processed
+	deadline time.Time        // when the batch is flushed if it does not fill up first
+}
+
+// pendingInsert is a buffered notification and the message it came from.
+type pendingInsert struct {
+	matched   *events.AlertMatched
+	ruleKey   string
+	times     database.StageTimes
+	startTime time.Time
+	msg       *kafka.Message
+}
+
+// WithBatchInserts buffers the inserts of new notifications and writes up to maxSize of them
+// with one statement, at the latest flushInterval after the first message of the batch was read.
+// Offsets are committed only after the batch is written, so a crash redelivers the messages of
+// an unwritten batch. Suppressed and grouped notifications are still written one by one.
+// A nil storage or a maxSize below 2 disables batching.
+func (p *Processor) WithBatchInserts(storage BatchStorage, maxSize int, flushInterval time.Duration) *Processor {
+	if storage == nil || maxSize < 2 {
+		p.batch = nil
+		return p
+	}
+	p.batch = &insertBatch{
+		storage:       storage,
+		maxSize:       maxSize,
+		flushInterval: flushInterval,
+	}
+	return p
+}
+
+// empty reports whether nothing was read since the last flush.
+func (b *insertBatch) empty() bool {
+	return len(b.pending) == 0 && len(b.messages) == 0
+}
+
+// due reports whether the batch should be flushed at now.
+func (b *insertBatch) due(now time.Time) bool {
+	return len(b.pending) >= b.maxSize || (!b.empty() && !now.Before(b.deadline))
+}
+
+// readContext returns the context to read the next message with: when batching, reading stops
+// at the batch's deadline so it is flushed on time.
+func (p *Processor) readContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if p.batch == nil || p.batch.empty() {
+		return ctx, func() {}
+	}
+	return context.WithDeadline(ctx, p.batch.deadline)
+}
+
+// startMessage makes msg the message being processed when batching, starting a new batch's
+// flush interval if it is the first message since the last flush.
+func (p *Processor) startMessage(msg *kafka.Message) {
+	if p.batch == nil {
+		return
+	}
+	if p.batch.empty() {
+		p.batch.deadline = time.Now().Add(p.batch.flushInterval)
+	}
+	p.batch.current = msg
+}
+
+// bufferInsert adds the notification of matched to the batch instead of inserting it.
+func (p *Processor) bufferInsert(matched *events.AlertMatched, times database.StageTimes, startTime time.Time) {
+	p.batch.pending = append(p.batch.pending, pendingInsert{
+		matched:   matched,
+		ruleKey:   p.ruleKey(matched),
+		times:     times,
+		startTime: startTime,
+		msg:       p.batch.current,
+	})
+}
+
+// commit commits msg's offset, or when batching defers it to the next flush.
+func (p *Processor) commit(ctx context.Context, msg *kafka.Message) {
+	if p.batch != nil {
+		p.batch.messages = append(p.batch.messages, msg)
+		return
+	}
+	if err := p.reader.CommitMessage(ctx, msg); err != nil {
+		slog.Error("Failed to commit offset", "offset", msg.Offset, "error", err)
+		// Continue processing - offset will be committed on next interval or retry
+	}
+}
+
+// dropBatch discards the batch without writing it or committing its messages.
+func (p *Processor) dropBatch() {
+	if p.batch == nil || p.batch.empty() {
+		return
+	}
+	slog.Info("Dropping unwritten notification batch",
+		"notifications", len(p.batch.pending),
+		"messages", len(p.batch.messages),
+	)
+	p.batch.pending, p.batch.messages, p.batch.current = nil, nil, nil
+}
+
+// flushBatch inserts the buffered notifications, publishes the new ones, then commits the
+// messages read since the last flush. A message whose notification failed counts as a failed
+// attempt for the dead-letter queue, and its partition is only committed up to the message
+// before it, since committing an offset commits every message before it. If the batch insert
+// fails, its notifications are inserted one by one so a single bad row does not fail the whole
+// batch.
+func (p *Processor) flushBatch(ctx context.Context) {
+	b := p.batch
+	pending, messages := b.pending, b.messages
+	b.pending, b.messages, b.current = nil, nil, nil
+
+	failed := make(map[*kafka.Message]bool)
+	if len(pending) > 0 {
+		rows := make([]database.NewNotification, len(pending))
+		for i, pi := range pending {
+			rows[i] = database.NewNotification{
+				ClientID: pi.matched.ClientID,
+				AlertID:  pi.matched.AlertID,
+				RuleKey:  pi.ruleKey,
+				Severity: pi.matched.Severity,
+				Source:   pi.matched.Source,
+				Name:     pi.matched.Name,
+				Context:  pi.matched.Context,
+				RuleIDs:  pi.matched.RuleIDs,
+				EventTS:  pi.matched.EventTS,
+				Times:    pi.times,
+			}
+		}
+
+		inserted, err := b.storage.InsertNotificationsIdempotent(ctx, rows)
+		if err != nil {
+			slog.Warn("Failed to insert notification batch, inserting one by one",
+				"count", len(pending),
+				"error", err,
+			)
+			p.metrics.IncrementCustom("batch_insert_fallbacks")
+		} else {
+			p.metrics.IncrementCustom("batch_inserts")
+		}
+		for i, pi := range pending {
+			var ok bool
+			if err != nil {
+				ok = p.insertNotification(ctx, pi.matched, pi.times, pi.startTime)
+			} else {
+				ok = p.handleInserted(ctx, pi.matched, inserted[i], pi.times, pi.startTime)
+			}
+			if !ok {
+				failed[pi.msg] = true
+			}
+		}
+	}
+
+	// The last message of each partition before its first failed one commits the ones before it
+	last := make(map[int]*kafka.Message)
+	stopped := make(map[int]bool)
+	var order []int
+	for _, msg := range messages {
+		if failed[msg] {
+			p.failMessage(ctx, msg, fmt.Errorf("failed to insert notification batch at offset %d", msg.Offset))
+			stopped[msg.Partition] = true
+			continue
+		}
+		if stopped[msg.Partition] {
+			continue
+		}
+		if _, ok := last[msg.Partition]; !ok {
+			order = append(order, msg.Partition)
+		}
+		last[msg.Partition] = msg
+	}
+	for _, partition := range order {
+		msg := last[partition]
+		if err := p.reader.CommitMessage(ctx, msg); err != nil {
+			slog.Error("Failed to commit offset", "partition", partition, "offset", msg.Offset, "error", err)
+		}
+	}
+}
+
+// failMessage records a failed attempt at msg with the dead-letter queue, if enabled.
+// A message dead-lettered during a flush is committed with the next batch.
+func (p *Processor) failMessage(ctx context.Context, msg *kafka.Message, reason error) {
+	if p.dlq == nil {
+		return
+	}
+	dead, err := p.dlq.Fail(ctx, msg, reason)
+	p.handleDeadLetter(ctx, msg, dead, err)
+}
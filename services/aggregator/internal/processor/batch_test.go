@@ -0,0 +1,222 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"aggregator/internal/events"
+)
+
+func batchMessages(alertIDs ...string) []*events.AlertMatched {
+	messages := make([]*events.AlertMatched, len(alertIDs))
+	for i, alertID := range alertIDs {
+		messages[i] = &events.AlertMatched{AlertID: alertID, ClientID: "client-1", Severity: "HIGH", RuleIDs: []string{"rule-1"}}
+	}
+	return messages
+}
+
+func TestBatchInserts_FlushWritesOnceThenCommits(t *testing.T) {
+	reader := &FakeReader{Messages: batchMessages("alert-1", "alert-2", "alert-3")}
+	storage := &FakeStorage{}
+	batchStorage := &FakeBatchStorage{Existing: map[string]bool{"alert-2": true}}
+	publisher := &FakePublisher{}
+	metrics := NewFakeMetrics()
+
+	proc := NewProcessorWithMetrics(reader, publisher, storage, metrics).
+		WithBatchInserts(batchStorage, 10, time.Minute)
+	for i := 0; i < 3; i++ {
+		proc.processNext(context.Background())
+	}
+
+	if len(storage.InsertedNotifications) != 0 || len(publisher.Published) != 0 {
+		t.Fatalf("inserted %d and published %d before the flush, want 0", len(storage.InsertedNotifications), len(publisher.Published))
+	}
+	if len(reader.Committed) != 0 {
+		t.Fatalf("Committed = %d before the flush, want 0", len(reader.Committed))
+	}
+
+	proc.flushBatch(context.Background())
+
+	if len(batchStorage.Batches) != 1 || len(batchStorage.Batches[0]) != 3 {
+		t.Fatalf("Batches = %v, want one batch of 3", batchStorage.Batches)
+	}
+	if got := batchStorage.Batches[0][0]; got.AlertID != "alert-1" || got.Severity != "HIGH" || got.Times.AggregatedAt.IsZero() {
+		t.Errorf("first row = %+v, want alert-1 with its stage times", got)
+	}
+	if len(publisher.Published) != 2 {
+		t.Errorf("Published = %d, want 2 (alert-2 already existed)", len(publisher.Published))
+	}
+	if metrics.CustomIncrements["notifications_deduplicated"] != 1 {
+		t.Errorf("notifications_deduplicated = %d, want 1", metrics.CustomIncrements["notifications_deduplicated"])
+	}
+	if len(reader.Committed) != 1 || reader.Committed[0].Offset != 2 {
+		t.Errorf("Committed = %v, want only the last message", reader.Committed)
+	}
+	if !proc.batch.empty() {
+		t.Error("batch not empty after flush")
+	}
+}
+
+func TestBatchInserts_RepublishesPendingNotification(t *testing.T) {
+	reader := &FakeReader{Messages: batchMessages("alert-1", "alert-2")}
+	batchStorage := &FakeBatchStorage{Pending: map[string]bool{"alert-1": true}}
+	publisher := &FakePublisher{}
+	metrics := NewFakeMetrics()
+
+	proc := NewProcessorWithMetrics(reader, publisher, &FakeStorage{}, metrics).
+		WithBatchInserts(batchStorage, 10, time.Minute)
+	for i := 0; i < 2; i++ {
+		proc.processNext(context.Background())
+	}
+	proc.flushBatch(context.Background())
+
+	if len(publisher.Published) != 2 || publisher.Published[0].NotificationID != "notif-alert-1" {
+		t.Fatalf("Published = %v, want both notifications with their existing IDs", publisher.Published)
+	}
+	if metrics.CustomIncrements["notifications_republished"] != 1 || metrics.CustomIncrements["notifications_created"] != 1 {
+		t.Errorf("metrics = %v, want one republished and one created", metrics.CustomIncrements)
+	}
+}
+
+func TestBatchInserts_FallbackInsertsOneByOne(t *testing.T) {
+	reader := &FakeReader{Messages: batchMessages("alert-1", "alert-2", "alert-3")}
+	storage := &FakeStorage{
+		InsertFunc: func(clientID, alertID string) (*string, error) {
+			if alertID == "alert-3" {
+				return nil, errors.New("invalid row")
+			}
+			id := "notif-" + alertID
+			return &id, nil
+		},
+	}
+	batchStorage := &FakeBatchStorage{Err: errors.New("batch rejected")}
+	publisher := &FakePublisher{}
+	dlq := &FakeDLQ{MaxFailures: 3}
+	metrics := NewFakeMetrics()
+
+	proc := NewProcessorWithMetrics(reader, publisher, storage, metrics).
+		WithDeadLetterQueue(dlq).
+		WithBatchInserts(batchStorage, 10, time.Minute)
+	for i := 0; i < 3; i++ {
+		proc.processNext(context.Background())
+	}
+	proc.flushBatch(context.Background())
+
+	if len(storage.InsertedNotifications) != 3 {
+		t.Fatalf("single inserts = %d, want 3", len(storage.InsertedNotifications))
+	}
+	if len(publisher.Published) != 2 {
+		t.Errorf("Published = %d, want 2", len(publisher.Published))
+	}
+	if metrics.CustomIncrements["batch_insert_fallbacks"] != 1 {
+		t.Errorf("batch_insert_fallbacks = %d, want 1", metrics.CustomIncrements["batch_insert_fallbacks"])
+	}
+	if dlq.Failures != 1 {
+		t.Errorf("DLQ failures = %d, want 1 for the failed message", dlq.Failures)
+	}
+	// The failed message is the last one read, so only the messages before it are committed
+	if len(reader.Committed) != 1 || reader.Committed[0].Offset != 1 {
+		t.Errorf("Committed = %v, want the message before the failed one", reader.Committed)
+	}
+}
+
+func TestBatchInserts_CommitsUpToFirstFailure(t *testing.T) {
+	reader := &FakeReader{Messages: batchMessages("alert-1", "alert-2", "alert-3")}
+	storage := &FakeStorage{
+		InsertFunc: func(clientID, alertID string) (*string, error) {
+			if alertID == "alert-2" {
+				return nil, errors.New("invalid row")
+			}
+			id := "notif-" + alertID
+			return &id, nil
+		},
+	}
+	proc := NewProcessor(reader, &FakePublisher{}, storage).
+		WithBatchInserts(&FakeBatchStorage{Err: errors.New("batch rejected")}, 10, time.Minute)
+	for i := 0; i < 3; i++ {
+		proc.processNext(context.Background())
+	}
+	proc.flushBatch(context.Background())
+
+	// Committing alert-3's offset would also commit the failed alert-2
+	if len(reader.Committed) != 1 || reader.Committed[0].Offset != 0 {
+		t.Errorf("Committed = %v, want only the message before the failed one", reader.Committed)
+	}
+}
+
+func TestBatchInserts_DroppedOnShutdown(t *testing.T) {
+	reader := &FakeReader{Messages: batchMessages("alert-1", "alert-2")}
+	batchStorage := &FakeBatchStorage{}
+	proc := NewProcessor(reader, &FakePublisher{}, &FakeStorage{}).
+		WithBatchInserts(batchStorage, 10, time.Minute)
+	for i := 0; i < 2; i++ {
+		proc.processNext(context.Background())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := proc.ProcessNotifications(ctx); err != nil {
+		t.Fatalf("ProcessNotifications() error = %v", err)
+	}
+	if len(batchStorage.Batches) != 0 || len(reader.Committed) != 0 {
+		t.Errorf("wrote %d batches and committed %v at shutdown, want nothing", len(batchStorage.Batches), reader.Committed)
+	}
+	if !proc.batch.empty() {
+		t.Error("batch not empty after shutdown")
+	}
+}
+
+func TestBatchInserts_Due(t *testing.T) {
+	reader := &FakeReader{Messages: batchMessages("alert-1", "alert-2")}
+	proc := NewProcessor(reader, &FakePublisher{}, &FakeStorage{}).
+		WithBatchInserts(&FakeBatchStorage{}, 2, time.Second)
+
+	now := time.Now()
+	if proc.batch.due(now.Add(time.Hour)) {
+		t.Error("empty batch is due")
+	}
+
+	proc.processNext(context.Background())
+	if proc.batch.due(now) {
+		t.Error("batch due before it is full or its flush interval elapsed")
+	}
+	if !proc.batch.due(now.Add(2 * time.Second)) {
+		t.Error("batch not due after its flush interval")
+	}
+
+	proc.processNext(context.Background())
+	if !proc.batch.due(now) {
+		t.Error("full batch not due")
+	}
+}
+
+func TestBatchInserts_ReadStopsAtDeadline(t *testing.T) {
+	proc := NewProcessor(&FakeReader{}, &FakePublisher{}, &FakeStorage{}).
+		WithBatchInserts(&FakeBatchStorage{}, 10, time.Second)
+
+	ctx, cancel := proc.readContext(context.Background())
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("read of an empty batch has a deadline")
+	}
+	cancel()
+
+	proc.startMessage(nil)
+	proc.batch.messages = append(proc.batch.messages, nil)
+	ctx, cancel = proc.readContext(context.Background())
+	defer cancel()
+	if deadline, ok := ctx.Deadline(); !ok || !deadline.Equal(proc.batch.deadline) {
+		t.Errorf("read deadline = %v, want the batch's flush deadline %v", deadline, proc.batch.deadline)
+	}
+}
+
+func TestWithBatchInserts_Disabled(t *testing.T) {
+	proc := NewProcessor(&FakeReader{}, &FakePublisher{}, &FakeStorage{})
+	if proc.WithBatchInserts(&FakeBatchStorage{}, 1, time.Second).batch != nil {
+		t.Error("batch size 1 enables batching")
+	}
+	if proc.WithBatchInserts(nil, 10, time.Second).batch != nil {
+		t.Error("nil storage enables batching")
+	}
+}
@@ -5,8 +5,10 @@ import (
 	"errors"
 	"time"
 
+	"aggregator/internal/database"
 	"aggregator/internal/events"
 
+	"github.com/afikmenashe/alerting-platform/pkg/metrics"
 	"github.com/segmentio/kafka-go"
 )
 
@@ -14,6 +16,7 @@ import (
 type FakeReader struct {
 	Messages   []*events.AlertMatched
 	ReadErr    error
+	DecodeErr  error // returned with a raw message, as for an undecodable message
 	CommitErr  error
 	ReadIndex  int
 	Committed  []kafka.Message
@@ -25,12 +28,16 @@ func (f *FakeReader) ReadMessage(ctx context.Context) (*events.AlertMatched, *ka
 	if f.ReadErr != nil {
 		return nil, nil, f.ReadErr
 	}
+	if f.DecodeErr != nil {
+		return nil, &kafka.Message{Offset: 42}, f.DecodeErr
+	}
 	if f.ReadIndex >= len(f.Messages) {
 		return nil, nil, errors.New("no more messages")
 	}
 	msg := f.Messages[f.ReadIndex]
+	offset := int64(f.ReadIndex)
 	f.ReadIndex++
-	return msg, &kafka.Message{}, nil
+	return msg, &kafka.Message{Offset: offset}, nil
 }
 
 func (f *FakeReader) CommitMessage(ctx context.Context, msg *kafka.Message) error {
@@ -45,6 +52,36 @@ func (f *FakeReader) Close() error {
 	return nil
 }
 
+// FakeDLQ is a test fake for DeadLetterQueue that dead-letters on the MaxFailures-th Fail.
+type FakeDLQ struct {
+	MaxFailures int
+	Failures    int
+	Rejected    []error
+	DeadLetters []kafka.Message
+	Err         error
+}
+
+func (f *FakeDLQ) Reject(ctx context.Context, msg *kafka.Message, reason error) (bool, error) {
+	if f.Err != nil {
+		return false, f.Err
+	}
+	f.Rejected = append(f.Rejected, reason)
+	f.DeadLetters = append(f.DeadLetters, *msg)
+	return true, nil
+}
+
+func (f *FakeDLQ) Fail(ctx context.Context, msg *kafka.Message, reason error) (bool, error) {
+	if f.Err != nil {
+		return false, f.Err
+	}
+	f.Failures++
+	if f.Failures < f.MaxFailures {
+		return false, nil
+	}
+	f.DeadLetters = append(f.DeadLetters, *msg)
+	return true, nil
+}
+
 // FakePublisher is a test fake for MessagePublisher.
 type FakePublisher struct {
 	Published   []*events.NotificationReady
@@ -67,53 +104,110 @@ func (f *FakePublisher) Close() error {
 	return nil
 }
 
-// FakeStorage is a test fake for NotificationStorage.
+// FakeStorage is a test fake for NotificationStorage. An insert returning a nil ID resolves
+// to Existing, or to an existing notification whose ready event was published if Existing is nil.
 type FakeStorage struct {
 	InsertedNotifications []InsertCall
 	InsertResult          *string
 	InsertErr             error
 	InsertFunc            func(clientID, alertID string) (*string, error)
+	Existing              *database.InsertedNotification
+	Ready                 map[string]time.Time
+	MarkReadyErr          error
 }
 
 type InsertCall struct {
 	ClientID string
 	AlertID  string
+	RuleKey  string
 	Severity string
 	Source   string
 	Name     string
 	Context  map[string]string
 	RuleIDs  []string
+	EventTS  int64
+	Times    database.StageTimes
 }
 
 func (f *FakeStorage) InsertNotificationIdempotent(
 	ctx context.Context,
-	clientID, alertID, severity, source, name string,
+	clientID, alertID, ruleKey, severity, source, name string,
 	context map[string]string,
 	ruleIDs []string,
-) (*string, error) {
+	eventTS int64,
+	times database.StageTimes,
+) (*database.InsertedNotification, error) {
 	f.InsertedNotifications = append(f.InsertedNotifications, InsertCall{
 		ClientID: clientID,
 		AlertID:  alertID,
+		RuleKey:  ruleKey,
 		Severity: severity,
 		Source:   source,
 		Name:     name,
 		Context:  context,
 		RuleIDs:  ruleIDs,
+		EventTS:  eventTS,
+		Times:    times,
 	})
 
+	notificationID, err := f.InsertResult, f.InsertErr
 	if f.InsertFunc != nil {
-		return f.InsertFunc(clientID, alertID)
+		notificationID, err = f.InsertFunc(clientID, alertID)
 	}
-	if f.InsertErr != nil {
-		return nil, f.InsertErr
+	if err != nil {
+		return nil, err
 	}
-	return f.InsertResult, nil
+	return f.inserted(notificationID), nil
+}
+
+func (f *FakeStorage) inserted(notificationID *string) *database.InsertedNotification {
+	if notificationID != nil {
+		return &database.InsertedNotification{NotificationID: *notificationID, Created: true}
+	}
+	if f.Existing != nil {
+		return f.Existing
+	}
+	return &database.InsertedNotification{}
+}
+
+func (f *FakeStorage) MarkNotificationReady(ctx context.Context, notificationID string, readyAt time.Time) error {
+	if f.Ready == nil {
+		f.Ready = make(map[string]time.Time)
+	}
+	f.Ready[notificationID] = readyAt
+	return f.MarkReadyErr
 }
 
 func (f *FakeStorage) Close() error {
 	return nil
 }
 
+// FakeBatchStorage is a test fake for BatchStorage that inserts every notification it is given,
+// except those whose alert ID is in Existing or Pending, which already exist (with their ready
+// event never published if Pending).
+type FakeBatchStorage struct {
+	Batches  [][]database.NewNotification
+	Existing map[string]bool
+	Pending  map[string]bool
+	Err      error
+}
+
+func (f *FakeBatchStorage) InsertNotificationsIdempotent(ctx context.Context, notifications []database.NewNotification) ([]*database.InsertedNotification, error) {
+	f.Batches = append(f.Batches, notifications)
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	result := make([]*database.InsertedNotification, len(notifications))
+	for i, n := range notifications {
+		result[i] = &database.InsertedNotification{
+			NotificationID: "notif-" + n.AlertID,
+			Created:        !f.Existing[n.AlertID] && !f.Pending[n.AlertID],
+			Pending:        f.Pending[n.AlertID],
+		}
+	}
+	return result, nil
+}
+
 // FakeMetrics is a test fake for MetricsRecorder that tracks calls.
 type FakeMetrics struct {
 	ReceivedCount      int
@@ -150,3 +244,101 @@ func (f *FakeMetrics) RecordError() {
 func (f *FakeMetrics) IncrementCustom(name string) {
 	f.CustomIncrements[name]++
 }
+
+// FakeEnricher is a test fake for ContextEnricher that sets fixed context keys.
+type FakeEnricher struct {
+	Values map[string]string
+	Calls  int
+}
+
+func (f *FakeEnricher) Enrich(ctx context.Context, matched *events.AlertMatched) bool {
+	f.Calls++
+	if len(f.Values) == 0 {
+		return false
+	}
+	if matched.Context == nil {
+		matched.Context = make(map[string]string)
+	}
+	for k, v := range f.Values {
+		matched.Context[k] = v
+	}
+	return true
+}
+
+// FakeTracer is a test fake for Tracer that records events per alert.
+type FakeTracer struct {
+	Events map[string][]metrics.TraceEvent
+}
+
+func (f *FakeTracer) RecordTrace(ctx context.Context, alertID string, event metrics.TraceEvent) {
+	if f.Events == nil {
+		f.Events = make(map[string][]metrics.TraceEvent)
+	}
+	f.Events[alertID] = append(f.Events[alertID], event)
+}
+
+// FakeLatencyRecorder is a test fake for LatencyRecorder that records latencies per stage.
+type FakeLatencyRecorder struct {
+	Latencies map[string][]time.Duration
+}
+
+func (f *FakeLatencyRecorder) RecordStageLatency(ctx context.Context, stage string, start, end time.Time) error {
+	if f.Latencies == nil {
+		f.Latencies = make(map[string][]time.Duration)
+	}
+	f.Latencies[stage] = append(f.Latencies[stage], end.Sub(start))
+	return nil
+}
+
+// FakeJournal is a test fake for Journal that records event types per notification.
+type FakeJournal struct {
+	Events    map[string][]string
+	RecordErr error
+}
+
+func (f *FakeJournal) RecordNotificationEvent(ctx context.Context, notificationID, eventType string, eventErr error) error {
+	if f.Events == nil {
+		f.Events = make(map[string][]string)
+	}
+	f.Events[notificationID] = append(f.Events[notificationID], eventType)
+	return f.RecordErr
+}
+
+// FakeSuppressor is a test fake for Suppressor that returns a fixed open notification.
+type FakeSuppressor struct {
+	Result    *database.SuppressedNotification
+	Err       error
+	Calls     int
+	Intervals []time.Duration
+	RuleKeys  []string
+}
+
+func (f *FakeSuppressor) SuppressOpenNotification(ctx context.Context, clientID, alertID, ruleKey, severity, source, name string, renotifyInterval time.Duration) (*database.SuppressedNotification, error) {
+	f.Calls++
+	f.RuleKeys = append(f.RuleKeys, ruleKey)
+	f.Intervals = append(f.Intervals, renotifyInterval)
+	return f.Result, f.Err
+}
+
+// FakeGrouper is a test fake for Grouper that returns a fixed insert result.
+type FakeGrouper struct {
+	Result   *string
+	Grouped  bool
+	Err      error
+	Groups   []database.Group
+	RuleKeys []string
+}
+
+func (f *FakeGrouper) InsertGroupedNotificationIdempotent(
+	ctx context.Context,
+	group database.Group,
+	clientID, alertID, ruleKey, severity, source, name string,
+	context map[string]string,
+	ruleIDs []string,
+	eventTS int64,
+	times database.StageTimes,
+) (*string, bool, error) {
+	f.Groups = append(f.Groups, group)
+	f.RuleKeys = append(f.RuleKeys, ruleKey)
+	return f.Result, f.Grouped, f.Err
+}
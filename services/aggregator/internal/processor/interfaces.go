@@ -3,9 +3,12 @@ package processor
 
 import (
 	"context"
+	"time"
 
+	"aggregator/internal/database"
 	"aggregator/internal/events"
 
+	"github.com/afikmenashe/alerting-platform/pkg/metrics"
 	"github.com/segmentio/kafka-go"
 )
 
@@ -34,14 +37,83 @@ type MessagePublisher interface {
 // NotificationStorage stores notification records for deduplication.
 type NotificationStorage interface {
 	// InsertNotificationIdempotent inserts a notification with idempotency protection.
-	// Returns the notification ID if a new row was inserted, or nil if it already existed.
+	// ruleKey is the rule the notification is for under the per-rule collapse policy, "" otherwise.
+	// eventTS is the alert's event time in Unix seconds, 0 if unknown; times are stored with it.
+	// Returns the new notification, or the existing one with the same idempotency key.
 	InsertNotificationIdempotent(
 		ctx context.Context,
-		clientID, alertID, severity, source, name string,
+		clientID, alertID, ruleKey, severity, source, name string,
 		context map[string]string,
 		ruleIDs []string,
-	) (*string, error)
+		eventTS int64,
+		times database.StageTimes,
+	) (*database.InsertedNotification, error)
+
+	// MarkNotificationReady records when the notification's ready event was published.
+	MarkNotificationReady(ctx context.Context, notificationID string, readyAt time.Time) error
 
 	// Close closes the storage connection.
 	Close() error
 }
+
+// BatchStorage inserts many notifications in one database round trip.
+type BatchStorage interface {
+	// InsertNotificationsIdempotent inserts notifications atomically with idempotency protection.
+	// Returns, per notification, the new notification or the existing one with the same key.
+	InsertNotificationsIdempotent(ctx context.Context, notifications []database.NewNotification) ([]*database.InsertedNotification, error)
+}
+
+// ContextEnricher adds derived information (such as service ownership) to a matched alert
+// before it is persisted. Returns true if the alert context was modified.
+type ContextEnricher interface {
+	Enrich(ctx context.Context, matched *events.AlertMatched) bool
+}
+
+// Suppressor folds a repeated alert into the client's open notification with the same fingerprint.
+type Suppressor interface {
+	// SuppressOpenNotification returns the open notification that absorbed the alert,
+	// or nil if a new notification should be created. Only notifications with the same ruleKey match.
+	SuppressOpenNotification(ctx context.Context, clientID, alertID, ruleKey, severity, source, name string, renotifyInterval time.Duration) (*database.SuppressedNotification, error)
+}
+
+// Grouper holds notifications in a client's digest group instead of publishing them one by one.
+type Grouper interface {
+	// InsertGroupedNotificationIdempotent inserts a notification into group. Returns the
+	// notification ID if a new row was inserted, or nil if it already existed; grouped is
+	// false if the group was already flushed and the notification must be published on its own.
+	InsertGroupedNotificationIdempotent(
+		ctx context.Context,
+		group database.Group,
+		clientID, alertID, ruleKey, severity, source, name string,
+		context map[string]string,
+		ruleIDs []string,
+		eventTS int64,
+		times database.StageTimes,
+	) (notificationID *string, grouped bool, err error)
+}
+
+// Journal records state transitions of a notification in its persistent event log.
+type Journal interface {
+	RecordNotificationEvent(ctx context.Context, notificationID, eventType string, eventErr error) error
+}
+
+// DeadLetterQueue moves messages that cannot be processed to a dead-letter topic.
+// It is implemented by pkg/kafka's DeadLetterQueue.
+type DeadLetterQueue interface {
+	// Reject dead-letters a message that will never succeed. Returns true if it was published.
+	Reject(ctx context.Context, msg *kafka.Message, reason error) (bool, error)
+	// Fail records a failed attempt and dead-letters the message once it has failed too often.
+	// Returns true if it was published.
+	Fail(ctx context.Context, msg *kafka.Message, reason error) (bool, error)
+}
+
+// LatencyRecorder records how long notifications spend in each pipeline stage.
+// It is implemented by pkg/metrics collectors.
+type LatencyRecorder interface {
+	RecordStageLatency(ctx context.Context, stage string, start, end time.Time) error
+}
+
+// Tracer records per-alert pipeline events for debugging a single alert's journey.
+type Tracer interface {
+	RecordTrace(ctx context.Context, alertID string, event metrics.TraceEvent)
+}
@@ -4,10 +4,16 @@ package processor
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"time"
 
+	"aggregator/internal/database"
 	"aggregator/internal/events"
+	"aggregator/internal/grouping"
+
+	"github.com/afikmenashe/alerting-platform/pkg/metrics"
+	"github.com/segmentio/kafka-go"
 )
 
 // Processor orchestrates notification aggregation and deduplication.
@@ -16,8 +22,32 @@ type Processor struct {
 	publisher MessagePublisher
 	storage   NotificationStorage
 	metrics   MetricsRecorder
+	enrichers []ContextEnricher
+	tracer    Tracer
+	latency   LatencyRecorder
+	journal   Journal
+	dlq       DeadLetterQueue
+
+	suppressor       Suppressor
+	renotifyInterval time.Duration
+
+	grouper        Grouper
+	groupingPolicy grouping.Policy
+
+	collapsePolicy events.CollapsePolicy
+
+	batch *insertBatch
 }
 
+// Notification journal event types written by the aggregator.
+const (
+	journalCreated       = "created"
+	journalEnqueued      = "enqueued"
+	journalEnqueueFailed = "enqueue_failed"
+	journalRenotified    = "renotified"
+	journalGrouped       = "grouped"
+)
+
 // NewProcessor creates a new notification aggregation processor with no-op metrics.
 func NewProcessor(reader MessageReader, publisher MessagePublisher, storage NotificationStorage) *Processor {
 	return &Processor{
@@ -42,47 +72,182 @@ func NewProcessorWithMetrics(reader MessageReader, publisher MessagePublisher, s
 	}
 }
 
+// WithEnricher adds an enricher that is applied to every matched alert before insert.
+// Enrichers run in the order they were added. Passing nil is a no-op.
+func (p *Processor) WithEnricher(e ContextEnricher) *Processor {
+	if e != nil {
+		p.enrichers = append(p.enrichers, e)
+	}
+	return p
+}
+
+// WithTracer configures a tracer that records each alert's outcome for the debug trace endpoint.
+// Passing nil disables tracing.
+func (p *Processor) WithTracer(t Tracer) *Processor {
+	p.tracer = t
+	return p
+}
+
+// WithLatencyRecorder configures a recorder of the time notifications spend in the aggregation
+// and ready stages, measured from the times stamped on the events. Passing nil disables it.
+func (p *Processor) WithLatencyRecorder(r LatencyRecorder) *Processor {
+	p.latency = r
+	return p
+}
+
+// WithJournal configures the notification journal written on create and enqueue.
+// Passing nil disables the journal.
+func (p *Processor) WithJournal(j Journal) *Processor {
+	p.journal = j
+	return p
+}
+
+// WithSuppression folds repeats of an alert into the client's open notification with the same
+// fingerprint instead of creating a new one. When renotifyInterval > 0, a repeat arriving that
+// long after the notification was last sent re-sends it. Passing nil disables suppression.
+func (p *Processor) WithSuppression(s Suppressor, renotifyInterval time.Duration) *Processor {
+	p.suppressor = s
+	p.renotifyInterval = renotifyInterval
+	return p
+}
+
+// WithGrouping holds the notifications of the clients grouped by policy in digest groups,
+// to be published as one digest per window by a grouping.Flusher, instead of publishing each.
+// Passing nil disables grouping.
+func (p *Processor) WithGrouping(g Grouper, policy grouping.Policy) *Processor {
+	p.grouper = g
+	p.groupingPolicy = policy
+	return p
+}
+
+// WithCollapsePolicy sets how the rules of a client that matched an alert become notifications:
+// one notification per client (events.CollapseClient, the default) or one per matched rule
+// (events.CollapseRule).
+func (p *Processor) WithCollapsePolicy(policy events.CollapsePolicy) *Processor {
+	p.collapsePolicy = policy
+	return p
+}
+
+// WithDeadLetterQueue dead-letters messages that fail to decode, and messages that fail
+// to process too many times, then commits them. Passing nil disables the dead-letter queue:
+// such messages are left uncommitted.
+func (p *Processor) WithDeadLetterQueue(q DeadLetterQueue) *Processor {
+	p.dlq = q
+	return p
+}
+
 // ProcessNotifications continuously reads matched alerts from the message queue, inserts them
 // idempotently into the database, and publishes notification ready events for new notifications.
+// With batch inserts, a batch not yet written when ctx is cancelled is dropped without
+// committing its messages, so they are read again when the aggregator restarts.
 func (p *Processor) ProcessNotifications(ctx context.Context) error {
 	slog.Info("Starting notification processing loop")
 
 	for {
 		select {
 		case <-ctx.Done():
+			p.dropBatch()
 			slog.Info("Notification processing loop stopped")
 			return nil
 		default:
-			// Read matched alert from message queue
-			matched, msg, err := p.reader.ReadMessage(ctx)
-			if err != nil {
-				// Check if context was cancelled
-				if ctx.Err() != nil {
-					return nil
-				}
-				slog.Error("Failed to read matched alert", "error", err)
-				continue
+			p.processNext(ctx)
+			if p.batch != nil && p.batch.due(time.Now()) {
+				p.flushBatch(ctx)
 			}
+		}
+	}
+}
 
-			p.metrics.RecordReceived()
+// processNext reads and handles one matched alert message.
+func (p *Processor) processNext(ctx context.Context) {
+	// Read matched alert from message queue
+	readCtx, cancel := p.readContext(ctx)
+	defer cancel()
+	matched, msg, err := p.reader.ReadMessage(readCtx)
+	if err != nil {
+		// Check if context was cancelled, or the batch is due for a flush
+		if readCtx.Err() != nil {
+			return
+		}
+		slog.Error("Failed to read matched alert", "error", err)
+		// A message that was read but could not be decoded never will be
+		if msg != nil && p.dlq != nil {
+			dead, dlqErr := p.dlq.Reject(ctx, msg, err)
+			p.handleDeadLetter(ctx, msg, dead, dlqErr)
+		}
+		return
+	}
 
-			// Process the message; only commit if processing succeeds
-			if !p.processMessage(ctx, matched) {
-				continue
-			}
+	p.metrics.RecordReceived()
+	p.startMessage(msg)
 
-			// Commit offset only after successful processing
-			// This ensures at-least-once semantics: if we crash before commit, message will be redelivered
-			if err := p.reader.CommitMessage(ctx, msg); err != nil {
-				slog.Error("Failed to commit offset",
-					"alert_id", matched.AlertID,
-					"client_id", matched.ClientID,
-					"error", err,
-				)
-				// Continue processing - offset will be committed on next interval or retry
+	// Process the message; only commit if processing succeeds
+	if !p.processMatched(ctx, matched) {
+		if p.dlq != nil {
+			reason := fmt.Errorf("failed to process matched alert %s for client %s", matched.AlertID, matched.ClientID)
+			dead, dlqErr := p.dlq.Fail(ctx, msg, reason)
+			p.handleDeadLetter(ctx, msg, dead, dlqErr)
+		}
+		return
+	}
+
+	// Commit offset only after successful processing
+	// This ensures at-least-once semantics: if we crash before commit, message will be redelivered
+	p.commit(ctx, msg)
+}
+
+// handleDeadLetter commits a message once it is in the dead-letter topic.
+func (p *Processor) handleDeadLetter(ctx context.Context, msg *kafka.Message, dead bool, err error) {
+	if err != nil {
+		slog.Error("Failed to dead-letter message", "offset", msg.Offset, "error", err)
+		p.metrics.RecordError()
+		return
+	}
+	if !dead {
+		return
+	}
+	p.metrics.IncrementCustom("messages_dead_lettered")
+	p.commit(ctx, msg)
+}
+
+// processMatched handles one alerts.matched message, expanding a combined event into its
+// per-client matches, and each of those into its per-rule matches under the per-rule collapse
+// policy. Returns true only if every notification succeeded; on redelivery, those that already
+// succeeded are deduplicated by the idempotent insert.
+func (p *Processor) processMatched(ctx context.Context, matched *events.AlertMatched) bool {
+	ok := true
+	for _, perClient := range matched.PerClient() {
+		notifications := []*events.AlertMatched{perClient}
+		if p.collapsePolicy == events.CollapseRule {
+			notifications = perClient.PerRule()
+		}
+		for _, n := range notifications {
+			if !p.processMessage(ctx, n) {
+				ok = false
 			}
 		}
 	}
+	return ok
+}
+
+// ruleKey returns the rule key of the notification for matched: its single rule under the
+// per-rule collapse policy, "" otherwise.
+func (p *Processor) ruleKey(matched *events.AlertMatched) string {
+	if p.collapsePolicy != events.CollapseRule || len(matched.RuleIDs) == 0 {
+		return ""
+	}
+	return matched.RuleIDs[0]
+}
+
+// enrich applies all enrichers to matched and reports whether any of them modified its context.
+func (p *Processor) enrich(ctx context.Context, matched *events.AlertMatched) bool {
+	enriched := false
+	for _, e := range p.enrichers {
+		if e.Enrich(ctx, matched) {
+			enriched = true
+		}
+	}
+	return enriched
 }
 
 // processMessage handles a single matched alert: inserts it idempotently
@@ -97,17 +262,58 @@ func (p *Processor) processMessage(ctx context.Context, matched *events.AlertMat
 		"rule_ids", matched.RuleIDs,
 	)
 
+	// Enrich context (e.g. owner/team/slack_channel, matched_rules) before persisting so the sender sees it
+	if p.enrich(ctx, matched) {
+		p.metrics.IncrementCustom("notifications_enriched")
+	}
+
+	// Fold repeats into the client's open notification, if suppression is enabled
+	if p.suppressor != nil {
+		if handled, ok := p.suppress(ctx, matched); handled {
+			if ok {
+				p.metrics.RecordProcessed(time.Since(startTime))
+			}
+			return ok
+		}
+	}
+
+	// Hold the notification for the client's digest, if the client is grouped
+	if p.grouper != nil {
+		if group, ok := p.groupingPolicy.Window(matched.ClientID, startTime); ok {
+			if !p.group(ctx, matched, group) {
+				return false
+			}
+			p.metrics.RecordProcessed(time.Since(startTime))
+			return true
+		}
+	}
+
+	// Buffer the insert for the next batch, if batching
+	times := stageTimes(matched, time.Now())
+	if p.batch != nil {
+		p.bufferInsert(matched, times, startTime)
+		return true
+	}
+	return p.insertNotification(ctx, matched, times, startTime)
+}
+
+// insertNotification inserts the notification of matched with times and publishes it if new.
+// Returns true if processing succeeded.
+func (p *Processor) insertNotification(ctx context.Context, matched *events.AlertMatched, times database.StageTimes, startTime time.Time) bool {
 	// Insert notification idempotently
-	// This is the dedupe boundary: unique constraint on (client_id, alert_id)
-	notificationID, err := p.storage.InsertNotificationIdempotent(
+	// This is the dedupe boundary: unique constraint on (client_id, alert_id, rule_key)
+	inserted, err := p.storage.InsertNotificationIdempotent(
 		ctx,
 		matched.ClientID,
 		matched.AlertID,
+		p.ruleKey(matched),
 		matched.Severity,
 		matched.Source,
 		matched.Name,
 		matched.Context,
 		matched.RuleIDs,
+		matched.EventTS,
+		times,
 	)
 	if err != nil {
 		slog.Error("Failed to insert notification",
@@ -116,17 +322,32 @@ func (p *Processor) processMessage(ctx context.Context, matched *events.AlertMat
 			"error", err,
 		)
 		p.metrics.RecordError()
+		p.trace(ctx, matched, "insert_failed", "", err)
 		return false
 	}
+	return p.handleInserted(ctx, matched, inserted, times, startTime)
+}
 
-	// Only emit notification ready if a new notification was created
-	if notificationID != nil {
-		if !p.publishNotification(ctx, matched, *notificationID) {
+// handleInserted publishes the notification of matched if its insert created it, or if it
+// already existed but its ready event was never published, and records the outcome.
+// Returns true if processing succeeded.
+func (p *Processor) handleInserted(ctx context.Context, matched *events.AlertMatched, inserted *database.InsertedNotification, times database.StageTimes, startTime time.Time) bool {
+	switch {
+	case inserted.Created:
+		if !p.publishNotification(ctx, matched, inserted.NotificationID, times) {
 			return false
 		}
-	} else {
+	case inserted.Pending:
+		// A previous delivery of the alert stored the notification but stopped before
+		// publishing it; publish it under the same notification_id
+		if !p.publishReady(ctx, matched, inserted.NotificationID, times, false) {
+			return false
+		}
+	default:
 		p.metrics.IncrementCustom("notifications_deduplicated")
+		p.trace(ctx, matched, "deduplicated", inserted.NotificationID, nil)
 		slog.Debug("Notification already exists, skipping emit",
+			"notification_id", inserted.NotificationID,
 			"alert_id", matched.AlertID,
 			"client_id", matched.ClientID,
 		)
@@ -136,10 +357,149 @@ func (p *Processor) processMessage(ctx context.Context, matched *events.AlertMat
 	return true
 }
 
+// group inserts the alert's notification into the client's digest group. A notification
+// arriving after its group was flushed is published on its own.
+// Returns true if processing succeeded.
+func (p *Processor) group(ctx context.Context, matched *events.AlertMatched, group database.Group) bool {
+	times := stageTimes(matched, time.Now())
+	notificationID, grouped, err := p.grouper.InsertGroupedNotificationIdempotent(
+		ctx,
+		group,
+		matched.ClientID,
+		matched.AlertID,
+		p.ruleKey(matched),
+		matched.Severity,
+		matched.Source,
+		matched.Name,
+		matched.Context,
+		matched.RuleIDs,
+		matched.EventTS,
+		times,
+	)
+	if err != nil {
+		slog.Error("Failed to insert grouped notification",
+			"alert_id", matched.AlertID,
+			"client_id", matched.ClientID,
+			"group_id", group.GroupID,
+			"error", err,
+		)
+		p.metrics.RecordError()
+		p.trace(ctx, matched, "insert_failed", "", err)
+		return false
+	}
+
+	switch {
+	case notificationID == nil:
+		p.metrics.IncrementCustom("notifications_deduplicated")
+		p.trace(ctx, matched, "deduplicated", "", nil)
+		return true
+	case !grouped:
+		return p.publishNotification(ctx, matched, *notificationID, times)
+	}
+
+	p.recordLatency(ctx, metrics.LatencyStageAggregation, times.EvaluatedAt, times.AggregatedAt)
+	p.metrics.IncrementCustom("notifications_grouped")
+	p.trace(ctx, matched, "grouped", *notificationID, nil)
+	p.recordJournal(ctx, *notificationID, journalCreated, nil)
+	p.recordJournal(ctx, *notificationID, journalGrouped, nil)
+	slog.Debug("Grouped notification for digest",
+		"notification_id", *notificationID,
+		"alert_id", matched.AlertID,
+		"client_id", matched.ClientID,
+		"group_id", group.GroupID,
+	)
+	return true
+}
+
+// suppress folds the alert into the client's open notification with the same fingerprint.
+// handled is false if there is no open notification and a new one should be created;
+// otherwise ok reports whether the message should be committed.
+func (p *Processor) suppress(ctx context.Context, matched *events.AlertMatched) (handled, ok bool) {
+	suppressed, err := p.suppressor.SuppressOpenNotification(ctx,
+		matched.ClientID,
+		matched.AlertID,
+		p.ruleKey(matched),
+		matched.Severity,
+		matched.Source,
+		matched.Name,
+		p.renotifyInterval,
+	)
+	if err != nil {
+		slog.Error("Failed to check open notification",
+			"alert_id", matched.AlertID,
+			"client_id", matched.ClientID,
+			"error", err,
+		)
+		p.metrics.RecordError()
+		p.trace(ctx, matched, "suppress_failed", "", err)
+		return true, false
+	}
+	if suppressed == nil {
+		return false, false
+	}
+
+	p.metrics.IncrementCustom("notifications_suppressed")
+	if !suppressed.Renotify {
+		p.trace(ctx, matched, "suppressed", suppressed.NotificationID, nil)
+		slog.Debug("Suppressed repeat into open notification",
+			"notification_id", suppressed.NotificationID,
+			"alert_id", matched.AlertID,
+			"client_id", matched.ClientID,
+			"repeat_count", suppressed.RepeatCount,
+		)
+		return true, true
+	}
+
+	// The notification was reset to RECEIVED; hand it to the sender again.
+	// A failed publish is not retried: redelivery would count the repeat twice,
+	// and the next repeat after the interval re-notifies.
+	p.recordJournal(ctx, suppressed.NotificationID, journalRenotified, nil)
+	times := stageTimes(matched, time.Now())
+	ready := readyEvent(matched, suppressed.NotificationID, times)
+	ready.AlertID = suppressed.AlertID
+	if err := p.publisher.Publish(ctx, ready); err != nil {
+		slog.Error("Failed to publish re-notification",
+			"notification_id", suppressed.NotificationID,
+			"alert_id", matched.AlertID,
+			"client_id", matched.ClientID,
+			"error", err,
+		)
+		p.metrics.RecordError()
+		p.trace(ctx, matched, "renotify_failed", suppressed.NotificationID, err)
+		p.recordJournal(ctx, suppressed.NotificationID, journalEnqueueFailed, err)
+		return true, true
+	}
+
+	p.markReady(ctx, suppressed.NotificationID, times, ready)
+	p.metrics.RecordPublished()
+	p.metrics.IncrementCustom("notifications_renotified")
+	p.trace(ctx, matched, "renotified", suppressed.NotificationID, nil)
+	p.recordJournal(ctx, suppressed.NotificationID, journalEnqueued, nil)
+	slog.Info("Re-notified open notification",
+		"notification_id", suppressed.NotificationID,
+		"alert_id", matched.AlertID,
+		"client_id", matched.ClientID,
+		"repeat_count", suppressed.RepeatCount,
+	)
+	return true, true
+}
+
 // publishNotification publishes a notification ready event for a newly created notification.
+// times are the stage times the notification was stored with.
 // Returns true if publishing succeeded.
-func (p *Processor) publishNotification(ctx context.Context, matched *events.AlertMatched, notificationID string) bool {
-	ready := events.NewNotificationReady(matched, notificationID)
+func (p *Processor) publishNotification(ctx context.Context, matched *events.AlertMatched, notificationID string, times database.StageTimes) bool {
+	return p.publishReady(ctx, matched, notificationID, times, true)
+}
+
+// publishReady publishes the notification ready event of a notification, created by this
+// delivery of matched or stored by an earlier one that never published it.
+// Returns true if publishing succeeded.
+func (p *Processor) publishReady(ctx context.Context, matched *events.AlertMatched, notificationID string, times database.StageTimes, created bool) bool {
+	if created {
+		p.recordJournal(ctx, notificationID, journalCreated, nil)
+	}
+
+	ready := readyEvent(matched, notificationID, times)
 
 	if err := p.publisher.Publish(ctx, ready); err != nil {
 		slog.Error("Failed to publish notification ready event",
@@ -149,18 +509,107 @@ func (p *Processor) publishNotification(ctx context.Context, matched *events.Ale
 			"error", err,
 		)
 		p.metrics.RecordError()
+		p.trace(ctx, matched, "publish_failed", notificationID, err)
+		p.recordJournal(ctx, notificationID, journalEnqueueFailed, err)
 		return false
 	}
 
+	p.markReady(ctx, notificationID, times, ready)
 	p.metrics.RecordPublished()
-	p.metrics.IncrementCustom("notifications_created")
+	if created {
+		p.metrics.IncrementCustom("notifications_created")
+		p.trace(ctx, matched, "notification_created", notificationID, nil)
+	} else {
+		p.metrics.IncrementCustom("notifications_republished")
+		p.trace(ctx, matched, "notification_republished", notificationID, nil)
+	}
+	p.recordJournal(ctx, notificationID, journalEnqueued, nil)
 
 	slog.Info("Processed new notification",
 		"notification_id", notificationID,
 		"alert_id", matched.AlertID,
 		"client_id", matched.ClientID,
 		"rule_ids", matched.RuleIDs,
+		"created", created,
 	)
 
 	return true
 }
+
+// stageTimes returns the stage times of a notification stored at aggregatedAt for a matched alert.
+func stageTimes(matched *events.AlertMatched, aggregatedAt time.Time) database.StageTimes {
+	times := database.StageTimes{AggregatedAt: aggregatedAt}
+	if matched.EvaluatedAtMs > 0 {
+		times.EvaluatedAt = time.UnixMilli(matched.EvaluatedAtMs)
+	}
+	return times
+}
+
+// readyEvent builds the notification ready event of a notification stored with times,
+// stamped as ready now.
+func readyEvent(matched *events.AlertMatched, notificationID string, times database.StageTimes) *events.NotificationReady {
+	ready := events.NewNotificationReady(matched, notificationID)
+	ready.AggregatedAtMs = times.AggregatedAt.UnixMilli()
+	ready.ReadyAtMs = time.Now().UnixMilli()
+	return ready
+}
+
+// markReady records on the notification when its ready event was published, and the time it
+// spent in the aggregation and ready stages. Both are best effort and never fail processing.
+func (p *Processor) markReady(ctx context.Context, notificationID string, times database.StageTimes, ready *events.NotificationReady) {
+	readyAt := time.UnixMilli(ready.ReadyAtMs)
+	if err := p.storage.MarkNotificationReady(ctx, notificationID, readyAt); err != nil {
+		slog.Warn("Failed to record notification ready time",
+			"notification_id", notificationID,
+			"error", err,
+		)
+		p.metrics.IncrementCustom("ready_time_errors")
+	}
+	p.recordLatency(ctx, metrics.LatencyStageAggregation, times.EvaluatedAt, times.AggregatedAt)
+	p.recordLatency(ctx, metrics.LatencyStageReady, times.AggregatedAt, readyAt)
+}
+
+// recordLatency records the time a notification spent in a stage, if a latency recorder is
+// configured and the stage's start is known.
+func (p *Processor) recordLatency(ctx context.Context, stage string, start, end time.Time) {
+	if p.latency == nil || start.IsZero() {
+		return
+	}
+	if err := p.latency.RecordStageLatency(ctx, stage, start, end); err != nil {
+		slog.Debug("Failed to record stage latency", "stage", stage, "error", err)
+	}
+}
+
+// trace records an aggregator event in the alert's trace log, if tracing is enabled.
+func (p *Processor) trace(ctx context.Context, matched *events.AlertMatched, event, notificationID string, err error) {
+	if p.tracer == nil {
+		return
+	}
+	te := metrics.TraceEvent{
+		Stage:          metrics.StageAggregator,
+		Event:          event,
+		ClientID:       matched.ClientID,
+		RuleIDs:        matched.RuleIDs,
+		NotificationID: notificationID,
+	}
+	if err != nil {
+		te.Error = err.Error()
+	}
+	p.tracer.RecordTrace(ctx, matched.AlertID, te)
+}
+
+// recordJournal appends an event to the notification's journal, if enabled.
+// Journal writes are best effort and never fail processing.
+func (p *Processor) recordJournal(ctx context.Context, notificationID, eventType string, eventErr error) {
+	if p.journal == nil {
+		return
+	}
+	if err := p.journal.RecordNotificationEvent(ctx, notificationID, eventType, eventErr); err != nil {
+		slog.Warn("Failed to record notification event",
+			"notification_id", notificationID,
+			"event_type", eventType,
+			"error", err,
+		)
+		p.metrics.IncrementCustom("journal_errors")
+	}
+}
@@ -3,9 +3,16 @@ package processor
 import (
 	"context"
 	"errors"
+	"reflect"
 	"testing"
+	"time"
 
+	"aggregator/internal/database"
 	"aggregator/internal/events"
+	"aggregator/internal/grouping"
+
+	"github.com/afikmenashe/alerting-platform/pkg/metrics"
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
 )
 
 func TestNewProcessor(t *testing.T) {
@@ -71,6 +78,7 @@ func TestProcessMessage_NewNotification(t *testing.T) {
 	matched := &events.AlertMatched{
 		AlertID:  "alert-1",
 		ClientID: "client-1",
+		EventTS:  1700000000,
 		Severity: "HIGH",
 		Source:   "payments",
 		Name:     "transaction_failed",
@@ -97,6 +105,9 @@ func TestProcessMessage_NewNotification(t *testing.T) {
 	if insert.AlertID != "alert-1" {
 		t.Errorf("Expected AlertID 'alert-1', got '%s'", insert.AlertID)
 	}
+	if insert.EventTS != 1700000000 {
+		t.Errorf("Expected EventTS 1700000000, got %d", insert.EventTS)
+	}
 
 	// Check publisher was called
 	if len(publisher.Published) != 1 {
@@ -106,6 +117,9 @@ func TestProcessMessage_NewNotification(t *testing.T) {
 	if published.NotificationID != notificationID {
 		t.Errorf("Expected NotificationID '%s', got '%s'", notificationID, published.NotificationID)
 	}
+	if published.Priority != shared.PriorityHigh {
+		t.Errorf("Expected Priority %d for a HIGH alert, got %d", shared.PriorityHigh, published.Priority)
+	}
 
 	// Check metrics
 	if metrics.ProcessedCount != 1 {
@@ -229,7 +243,7 @@ func TestPublishNotification(t *testing.T) {
 	publisher := &FakePublisher{}
 	metrics := NewFakeMetrics()
 
-	proc := NewProcessorWithMetrics(nil, publisher, nil, metrics)
+	proc := NewProcessorWithMetrics(nil, publisher, &FakeStorage{}, metrics)
 
 	matched := &events.AlertMatched{
 		AlertID:       "alert-1",
@@ -239,7 +253,7 @@ func TestPublishNotification(t *testing.T) {
 	}
 
 	// Execute
-	result := proc.publishNotification(context.Background(), matched, "notif-123")
+	result := proc.publishNotification(context.Background(), matched, "notif-123", stageTimes(matched, time.Now()))
 
 	// Verify
 	if !result {
@@ -265,3 +279,588 @@ func TestPublishNotification(t *testing.T) {
 		t.Errorf("Expected PublishedCount 1, got %d", metrics.PublishedCount)
 	}
 }
+
+func TestProcessMessage_EnrichesContextBeforeInsert(t *testing.T) {
+	notificationID := "notif-123"
+	storage := &FakeStorage{InsertResult: &notificationID}
+	publisher := &FakePublisher{}
+	metrics := NewFakeMetrics()
+	enricher := &FakeEnricher{Values: map[string]string{"team": "payments"}}
+	rules := &FakeEnricher{Values: map[string]string{"matched_rules": "Payments errors"}}
+
+	proc := NewProcessorWithMetrics(nil, publisher, storage, metrics).WithEnricher(enricher).WithEnricher(rules)
+
+	matched := &events.AlertMatched{
+		AlertID:  "alert-1",
+		ClientID: "client-1",
+		Source:   "payments",
+		RuleIDs:  []string{"rule-1"},
+	}
+
+	if !proc.processMessage(context.Background(), matched) {
+		t.Fatal("processMessage() should return true")
+	}
+	if enricher.Calls != 1 || rules.Calls != 1 {
+		t.Errorf("Enrich() called %d and %d times, want 1 each", enricher.Calls, rules.Calls)
+	}
+	if len(storage.InsertedNotifications) != 1 {
+		t.Fatalf("Expected 1 insert, got %d", len(storage.InsertedNotifications))
+	}
+	if got := storage.InsertedNotifications[0].Context["team"]; got != "payments" {
+		t.Errorf("Inserted context team = %q, want payments", got)
+	}
+	if got := storage.InsertedNotifications[0].Context["matched_rules"]; got != "Payments errors" {
+		t.Errorf("Inserted context matched_rules = %q, want Payments errors", got)
+	}
+	if metrics.CustomIncrements["notifications_enriched"] != 1 {
+		t.Errorf("notifications_enriched = %d, want 1", metrics.CustomIncrements["notifications_enriched"])
+	}
+}
+
+func TestProcessMessage_RecordsTrace(t *testing.T) {
+	notificationID := "notif-123"
+	tests := []struct {
+		name      string
+		storage   *FakeStorage
+		publisher *FakePublisher
+		wantEvent string
+		wantNotif string
+		wantError bool
+	}{
+		{"new notification", &FakeStorage{InsertResult: &notificationID}, &FakePublisher{}, "notification_created", notificationID, false},
+		{"duplicate", &FakeStorage{InsertResult: nil}, &FakePublisher{}, "deduplicated", "", false},
+		{"existing never published", &FakeStorage{Existing: &database.InsertedNotification{NotificationID: notificationID, Pending: true}}, &FakePublisher{}, "notification_republished", notificationID, false},
+		{"insert failure", &FakeStorage{InsertErr: errors.New("db down")}, &FakePublisher{}, "insert_failed", "", true},
+		{"publish failure", &FakeStorage{InsertResult: &notificationID}, &FakePublisher{PublishErr: errors.New("kafka down")}, "publish_failed", notificationID, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracer := &FakeTracer{}
+			proc := NewProcessor(nil, tt.publisher, tt.storage).WithTracer(tracer)
+
+			proc.processMessage(context.Background(), &events.AlertMatched{AlertID: "alert-1", ClientID: "client-1", RuleIDs: []string{"rule-1"}})
+
+			traced := tracer.Events["alert-1"]
+			if len(traced) != 1 {
+				t.Fatalf("recorded %d trace events, want 1", len(traced))
+			}
+			event := traced[0]
+			if event.Stage != "aggregator" || event.Event != tt.wantEvent || event.NotificationID != tt.wantNotif || event.ClientID != "client-1" {
+				t.Errorf("trace event = %+v, want aggregator/%s/%s", event, tt.wantEvent, tt.wantNotif)
+			}
+			if (event.Error != "") != tt.wantError {
+				t.Errorf("trace event error = %q, wantError %v", event.Error, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestProcessMessage_RecordsJournal(t *testing.T) {
+	notificationID := "notif-123"
+	tests := []struct {
+		name       string
+		storage    *FakeStorage
+		publisher  *FakePublisher
+		journalErr error
+		want       []string
+		wantOK     bool
+	}{
+		{"new notification", &FakeStorage{InsertResult: &notificationID}, &FakePublisher{}, nil, []string{"created", "enqueued"}, true},
+		{"duplicate", &FakeStorage{InsertResult: nil}, &FakePublisher{}, nil, nil, true},
+		{"existing never published", &FakeStorage{Existing: &database.InsertedNotification{NotificationID: notificationID, Pending: true}}, &FakePublisher{}, nil, []string{"enqueued"}, true},
+		{"publish failure", &FakeStorage{InsertResult: &notificationID}, &FakePublisher{PublishErr: errors.New("kafka down")}, nil, []string{"created", "enqueue_failed"}, false},
+		{"journal failure does not fail processing", &FakeStorage{InsertResult: &notificationID}, &FakePublisher{}, errors.New("db down"), []string{"created", "enqueued"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			journal := &FakeJournal{RecordErr: tt.journalErr}
+			proc := NewProcessor(nil, tt.publisher, tt.storage).WithJournal(journal)
+
+			ok := proc.processMessage(context.Background(), &events.AlertMatched{AlertID: "alert-1", ClientID: "client-1", RuleIDs: []string{"rule-1"}})
+			if ok != tt.wantOK {
+				t.Errorf("processMessage() = %v, want %v", ok, tt.wantOK)
+			}
+
+			got := journal.Events[notificationID]
+			if len(got) != len(tt.want) {
+				t.Fatalf("journal events = %v, want %v", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("journal events = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessMessage_ExistingNotification(t *testing.T) {
+	tests := []struct {
+		name          string
+		pending       bool
+		wantPublished int
+		wantMetric    string
+	}{
+		{"ready event published", false, 0, "notifications_deduplicated"},
+		{"ready event never published", true, 1, "notifications_republished"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := &FakeStorage{Existing: &database.InsertedNotification{NotificationID: "notif-existing", Pending: tt.pending}}
+			publisher := &FakePublisher{}
+			metrics := NewFakeMetrics()
+			proc := NewProcessorWithMetrics(nil, publisher, storage, metrics)
+
+			if !proc.processMessage(context.Background(), &events.AlertMatched{AlertID: "alert-1", ClientID: "client-1", RuleIDs: []string{"rule-1"}}) {
+				t.Fatal("processMessage() = false, want true")
+			}
+			if len(publisher.Published) != tt.wantPublished {
+				t.Fatalf("Published = %d, want %d", len(publisher.Published), tt.wantPublished)
+			}
+			if tt.wantPublished > 0 && publisher.Published[0].NotificationID != "notif-existing" {
+				t.Errorf("published notification_id = %s, want the existing notif-existing", publisher.Published[0].NotificationID)
+			}
+			if metrics.CustomIncrements[tt.wantMetric] != 1 || metrics.CustomIncrements["notifications_created"] != 0 {
+				t.Errorf("metrics = %v, want one %s and no notifications_created", metrics.CustomIncrements, tt.wantMetric)
+			}
+		})
+	}
+}
+
+func TestProcessMessage_StampsStageTimes(t *testing.T) {
+	notificationID := "notif-123"
+	storage := &FakeStorage{InsertResult: &notificationID}
+	publisher := &FakePublisher{}
+	latency := &FakeLatencyRecorder{}
+	proc := NewProcessor(nil, publisher, storage).WithLatencyRecorder(latency)
+
+	evaluatedAt := time.Now().Add(-time.Second)
+	if !proc.processMessage(context.Background(), &events.AlertMatched{AlertID: "alert-1", ClientID: "client-1", EvaluatedAtMs: evaluatedAt.UnixMilli()}) {
+		t.Fatal("processMessage() = false, want true")
+	}
+
+	times := storage.InsertedNotifications[0].Times
+	if times.EvaluatedAt.UnixMilli() != evaluatedAt.UnixMilli() || times.AggregatedAt.Before(times.EvaluatedAt) {
+		t.Errorf("inserted stage times = %+v, want evaluated at %v then aggregated", times, evaluatedAt)
+	}
+	ready := publisher.Published[0]
+	if ready.EvaluatedAtMs != evaluatedAt.UnixMilli() || ready.AggregatedAtMs != times.AggregatedAt.UnixMilli() || ready.ReadyAtMs < ready.AggregatedAtMs {
+		t.Errorf("ready event stage times = %d/%d/%d, want evaluated, aggregated and ready in order", ready.EvaluatedAtMs, ready.AggregatedAtMs, ready.ReadyAtMs)
+	}
+	if readyAt, ok := storage.Ready[notificationID]; !ok || readyAt.UnixMilli() != ready.ReadyAtMs {
+		t.Errorf("ready_at = %v (recorded %v), want the ready event's time", readyAt, ok)
+	}
+	if got := latency.Latencies[metrics.LatencyStageAggregation]; len(got) != 1 || got[0] < time.Second {
+		t.Errorf("aggregation latencies = %v, want one of at least 1s", got)
+	}
+	if got := latency.Latencies[metrics.LatencyStageReady]; len(got) != 1 {
+		t.Errorf("ready latencies = %v, want one", got)
+	}
+}
+
+func TestProcessMessage_StageTimesUnknown(t *testing.T) {
+	notificationID := "notif-123"
+	storage := &FakeStorage{InsertResult: &notificationID, MarkReadyErr: errors.New("db down")}
+	latency := &FakeLatencyRecorder{}
+	proc := NewProcessor(nil, &FakePublisher{}, storage).WithLatencyRecorder(latency)
+
+	// An event from an evaluator that predates stage times, and a failure to record ready_at
+	if !proc.processMessage(context.Background(), &events.AlertMatched{AlertID: "alert-1", ClientID: "client-1"}) {
+		t.Fatal("processMessage() = false, want true despite the ready_at failure")
+	}
+	if times := storage.InsertedNotifications[0].Times; !times.EvaluatedAt.IsZero() {
+		t.Errorf("EvaluatedAt = %v, want zero", times.EvaluatedAt)
+	}
+	if got := latency.Latencies[metrics.LatencyStageAggregation]; len(got) != 0 {
+		t.Errorf("aggregation latencies = %v, want none without an evaluation time", got)
+	}
+}
+
+func TestProcessMatched_ExpandsCombinedEvent(t *testing.T) {
+	storage := &FakeStorage{InsertFunc: func(clientID, alertID string) (*string, error) {
+		if clientID == "client-2" {
+			return nil, errors.New("db down")
+		}
+		id := "notif-" + clientID
+		return &id, nil
+	}}
+	publisher := &FakePublisher{}
+	proc := NewProcessor(nil, publisher, storage)
+
+	matched := &events.AlertMatched{
+		AlertID:  "alert-1",
+		Severity: "HIGH",
+		Context:  map[string]string{"key": "value"},
+		Matches: []events.ClientMatch{
+			{ClientID: "client-1", RuleIDs: []string{"rule-1"}},
+			{ClientID: "client-2", RuleIDs: []string{"rule-2"}},
+			{ClientID: "client-3", RuleIDs: []string{"rule-3", "rule-4"}},
+		},
+	}
+
+	if proc.processMatched(context.Background(), matched) {
+		t.Error("processMatched() should return false when any client fails")
+	}
+
+	// The failing client does not stop the others
+	if len(storage.InsertedNotifications) != 3 {
+		t.Fatalf("Expected 3 insert calls, got %d", len(storage.InsertedNotifications))
+	}
+	if len(publisher.Published) != 2 || publisher.Published[0].ClientID != "client-1" || publisher.Published[1].ClientID != "client-3" {
+		t.Errorf("Published = %+v, want client-1 and client-3", publisher.Published)
+	}
+	if got := storage.InsertedNotifications[2].RuleIDs; len(got) != 2 {
+		t.Errorf("client-3 RuleIDs = %v, want its own 2 rules", got)
+	}
+}
+
+func TestProcessMatched_CollapsePolicy(t *testing.T) {
+	matched := &events.AlertMatched{
+		AlertID:  "alert-1",
+		Severity: "HIGH",
+		Context:  map[string]string{"key": "value"},
+		ClientID: "client-1",
+		RuleIDs:  []string{"rule-1", "rule-2"},
+	}
+
+	t.Run("client", func(t *testing.T) {
+		id := "notif-1"
+		storage := &FakeStorage{InsertResult: &id}
+		publisher := &FakePublisher{}
+		proc := NewProcessor(nil, publisher, storage).WithCollapsePolicy(events.CollapseClient)
+
+		if !proc.processMatched(context.Background(), matched) {
+			t.Fatal("processMatched() = false, want true")
+		}
+		if len(storage.InsertedNotifications) != 1 {
+			t.Fatalf("Expected 1 insert call, got %d", len(storage.InsertedNotifications))
+		}
+		call := storage.InsertedNotifications[0]
+		if call.RuleKey != "" || len(call.RuleIDs) != 2 {
+			t.Errorf("insert = rule key %q, rule IDs %v, want no rule key and both rules", call.RuleKey, call.RuleIDs)
+		}
+		if len(publisher.Published) != 1 {
+			t.Errorf("Expected 1 published notification, got %d", len(publisher.Published))
+		}
+	})
+
+	t.Run("rule", func(t *testing.T) {
+		storage := &FakeStorage{InsertFunc: func(clientID, alertID string) (*string, error) {
+			id := "notif-" + clientID
+			return &id, nil
+		}}
+		publisher := &FakePublisher{}
+		proc := NewProcessor(nil, publisher, storage).WithCollapsePolicy(events.CollapseRule)
+
+		if !proc.processMatched(context.Background(), matched) {
+			t.Fatal("processMatched() = false, want true")
+		}
+		if len(storage.InsertedNotifications) != 2 {
+			t.Fatalf("Expected 2 insert calls, got %d", len(storage.InsertedNotifications))
+		}
+		for i, want := range []string{"rule-1", "rule-2"} {
+			call := storage.InsertedNotifications[i]
+			if call.RuleKey != want || len(call.RuleIDs) != 1 || call.RuleIDs[0] != want {
+				t.Errorf("insert %d = rule key %q, rule IDs %v, want %s only", i, call.RuleKey, call.RuleIDs, want)
+			}
+		}
+		if len(publisher.Published) != 2 {
+			t.Errorf("Expected 2 published notifications, got %d", len(publisher.Published))
+		}
+		// Each notification gets its own context to enrich
+		storage.InsertedNotifications[0].Context["key"] = "changed"
+		if storage.InsertedNotifications[1].Context["key"] != "value" {
+			t.Error("per-rule notifications share their context map")
+		}
+	})
+}
+
+func TestProcessMessage_SuppressionUsesRuleKey(t *testing.T) {
+	suppressor := &FakeSuppressor{}
+	id := "notif-1"
+	proc := NewProcessor(nil, &FakePublisher{}, &FakeStorage{InsertResult: &id}).
+		WithSuppression(suppressor, 0).
+		WithCollapsePolicy(events.CollapseRule)
+
+	matched := &events.AlertMatched{AlertID: "alert-1", ClientID: "client-1", RuleIDs: []string{"rule-1", "rule-2"}}
+	if !proc.processMatched(context.Background(), matched) {
+		t.Fatal("processMatched() = false, want true")
+	}
+	if len(suppressor.RuleKeys) != 2 || suppressor.RuleKeys[0] != "rule-1" || suppressor.RuleKeys[1] != "rule-2" {
+		t.Errorf("suppression rule keys = %v, want [rule-1 rule-2]", suppressor.RuleKeys)
+	}
+}
+
+func TestProcessMessage_Suppression(t *testing.T) {
+	notificationID := "notif-new"
+	tests := []struct {
+		name          string
+		suppressor    *FakeSuppressor
+		publisher     *FakePublisher
+		wantOK        bool
+		wantInserts   int
+		wantPublished []string // notification IDs
+		wantTrace     string
+		wantJournal   []string // journal events of notif-open
+	}{
+		{
+			name:          "no open notification creates a new one",
+			suppressor:    &FakeSuppressor{},
+			publisher:     &FakePublisher{},
+			wantOK:        true,
+			wantInserts:   1,
+			wantPublished: []string{notificationID},
+			wantTrace:     "notification_created",
+		},
+		{
+			name:       "open notification absorbs the repeat",
+			suppressor: &FakeSuppressor{Result: &database.SuppressedNotification{NotificationID: "notif-open", AlertID: "alert-0", RepeatCount: 3}},
+			publisher:  &FakePublisher{},
+			wantOK:     true,
+			wantTrace:  "suppressed",
+		},
+		{
+			name:          "re-notification after the interval",
+			suppressor:    &FakeSuppressor{Result: &database.SuppressedNotification{NotificationID: "notif-open", AlertID: "alert-0", RepeatCount: 4, Renotify: true}},
+			publisher:     &FakePublisher{},
+			wantOK:        true,
+			wantPublished: []string{"notif-open"},
+			wantTrace:     "renotified",
+			wantJournal:   []string{"renotified", "enqueued"},
+		},
+		{
+			name:        "re-notification publish failure is not retried",
+			suppressor:  &FakeSuppressor{Result: &database.SuppressedNotification{NotificationID: "notif-open", AlertID: "alert-0", Renotify: true}},
+			publisher:   &FakePublisher{PublishErr: errors.New("kafka down")},
+			wantOK:      true,
+			wantTrace:   "renotify_failed",
+			wantJournal: []string{"renotified", "enqueue_failed"},
+		},
+		{
+			name:       "lookup failure is retried",
+			suppressor: &FakeSuppressor{Err: errors.New("db down")},
+			publisher:  &FakePublisher{},
+			wantOK:     false,
+			wantTrace:  "suppress_failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := &FakeStorage{InsertResult: &notificationID}
+			tracer := &FakeTracer{}
+			journal := &FakeJournal{}
+			proc := NewProcessor(nil, tt.publisher, storage).
+				WithTracer(tracer).
+				WithJournal(journal).
+				WithSuppression(tt.suppressor, 30*time.Minute)
+
+			ok := proc.processMessage(context.Background(), &events.AlertMatched{AlertID: "alert-1", ClientID: "client-1", Severity: "HIGH", Source: "api", Name: "timeout", RuleIDs: []string{"rule-1"}})
+			if ok != tt.wantOK {
+				t.Errorf("processMessage() = %v, want %v", ok, tt.wantOK)
+			}
+			if tt.suppressor.Calls != 1 || tt.suppressor.Intervals[0] != 30*time.Minute {
+				t.Errorf("suppressor calls = %d with intervals %v, want 1 with 30m", tt.suppressor.Calls, tt.suppressor.Intervals)
+			}
+			if len(storage.InsertedNotifications) != tt.wantInserts {
+				t.Errorf("inserts = %d, want %d", len(storage.InsertedNotifications), tt.wantInserts)
+			}
+			if len(tt.publisher.Published) != len(tt.wantPublished) {
+				t.Fatalf("published %d events, want %v", len(tt.publisher.Published), tt.wantPublished)
+			}
+			for i, id := range tt.wantPublished {
+				if tt.publisher.Published[i].NotificationID != id {
+					t.Errorf("published[%d] = %s, want %s", i, tt.publisher.Published[i].NotificationID, id)
+				}
+			}
+			if traced := tracer.Events["alert-1"]; len(traced) != 1 || traced[0].Event != tt.wantTrace {
+				t.Errorf("trace = %+v, want one %s event", traced, tt.wantTrace)
+			}
+			if got := journal.Events["notif-open"]; len(got) != len(tt.wantJournal) {
+				t.Errorf("journal events = %v, want %v", got, tt.wantJournal)
+			}
+		})
+	}
+}
+
+func TestProcessMessage_RenotifyKeepsOriginalAlert(t *testing.T) {
+	publisher := &FakePublisher{}
+	suppressor := &FakeSuppressor{Result: &database.SuppressedNotification{NotificationID: "notif-open", AlertID: "alert-0", Renotify: true}}
+	proc := NewProcessor(nil, publisher, &FakeStorage{}).WithSuppression(suppressor, time.Hour)
+
+	proc.processMessage(context.Background(), &events.AlertMatched{AlertID: "alert-1", ClientID: "client-1"})
+
+	if len(publisher.Published) != 1 {
+		t.Fatalf("published %d events, want 1", len(publisher.Published))
+	}
+	if ready := publisher.Published[0]; ready.AlertID != "alert-0" || ready.ClientID != "client-1" {
+		t.Errorf("re-notification = %+v, want the open notification's alert alert-0 for client-1", ready)
+	}
+}
+
+func TestProcessMessage_Grouping(t *testing.T) {
+	notificationID := "notif-new"
+	policy := grouping.Policy{Interval: 10 * time.Minute, Clients: map[string]bool{"client-1": true}}
+	tests := []struct {
+		name          string
+		clientID      string
+		grouper       *FakeGrouper
+		wantOK        bool
+		wantGroups    int
+		wantInserts   int
+		wantPublished int
+		wantTrace     string
+		wantJournal   []string
+	}{
+		{
+			name:        "grouped client is held for the digest",
+			clientID:    "client-1",
+			grouper:     &FakeGrouper{Result: &notificationID, Grouped: true},
+			wantOK:      true,
+			wantGroups:  1,
+			wantTrace:   "grouped",
+			wantJournal: []string{"created", "grouped"},
+		},
+		{
+			name:          "flushed group publishes on its own",
+			clientID:      "client-1",
+			grouper:       &FakeGrouper{Result: &notificationID},
+			wantOK:        true,
+			wantGroups:    1,
+			wantPublished: 1,
+			wantTrace:     "notification_created",
+			wantJournal:   []string{"created", "enqueued"},
+		},
+		{
+			name:       "duplicate is not grouped again",
+			clientID:   "client-1",
+			grouper:    &FakeGrouper{Grouped: true},
+			wantOK:     true,
+			wantGroups: 1,
+			wantTrace:  "deduplicated",
+		},
+		{
+			name:       "insert failure is retried",
+			clientID:   "client-1",
+			grouper:    &FakeGrouper{Err: errors.New("db down")},
+			wantOK:     false,
+			wantGroups: 1,
+			wantTrace:  "insert_failed",
+		},
+		{
+			name:          "other clients are not grouped",
+			clientID:      "client-2",
+			grouper:       &FakeGrouper{},
+			wantOK:        true,
+			wantInserts:   1,
+			wantPublished: 1,
+			wantTrace:     "notification_created",
+			wantJournal:   []string{"created", "enqueued"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := &FakeStorage{InsertResult: &notificationID}
+			publisher := &FakePublisher{}
+			tracer := &FakeTracer{}
+			journal := &FakeJournal{}
+			m := NewFakeMetrics()
+			proc := NewProcessorWithMetrics(nil, publisher, storage, m).
+				WithTracer(tracer).
+				WithJournal(journal).
+				WithGrouping(tt.grouper, policy)
+
+			ok := proc.processMessage(context.Background(), &events.AlertMatched{AlertID: "alert-1", ClientID: tt.clientID, Severity: "HIGH", RuleIDs: []string{"rule-1"}})
+			if ok != tt.wantOK {
+				t.Errorf("processMessage() = %v, want %v", ok, tt.wantOK)
+			}
+			if len(tt.grouper.Groups) != tt.wantGroups {
+				t.Fatalf("grouped inserts = %d, want %d", len(tt.grouper.Groups), tt.wantGroups)
+			}
+			if tt.wantGroups > 0 {
+				group := tt.grouper.Groups[0]
+				if group.ClientID != "client-1" || group.WindowEnd.Sub(group.WindowStart) != 10*time.Minute {
+					t.Errorf("group = %+v, want a 10m window of client-1", group)
+				}
+			}
+			if len(storage.InsertedNotifications) != tt.wantInserts {
+				t.Errorf("inserts = %d, want %d", len(storage.InsertedNotifications), tt.wantInserts)
+			}
+			if len(publisher.Published) != tt.wantPublished {
+				t.Errorf("published %d events, want %d", len(publisher.Published), tt.wantPublished)
+			}
+			if traced := tracer.Events["alert-1"]; len(traced) != 1 || traced[0].Event != tt.wantTrace {
+				t.Errorf("trace = %+v, want one %s event", traced, tt.wantTrace)
+			}
+			if got := journal.Events[notificationID]; !reflect.DeepEqual(got, tt.wantJournal) {
+				t.Errorf("journal events = %v, want %v", got, tt.wantJournal)
+			}
+			if tt.wantTrace == "grouped" && m.CustomIncrements["notifications_grouped"] != 1 {
+				t.Errorf("notifications_grouped = %d, want 1", m.CustomIncrements["notifications_grouped"])
+			}
+		})
+	}
+}
+
+func TestProcessNext_DeadLettersUndecodableMessage(t *testing.T) {
+	decodeErr := errors.New("failed to unmarshal matched alert protobuf")
+	reader := &FakeReader{DecodeErr: decodeErr}
+	dlq := &FakeDLQ{MaxFailures: 3}
+	metrics := NewFakeMetrics()
+
+	proc := NewProcessorWithMetrics(reader, &FakePublisher{}, &FakeStorage{}, metrics).WithDeadLetterQueue(dlq)
+	proc.processNext(context.Background())
+
+	if len(dlq.Rejected) != 1 || dlq.Rejected[0] != decodeErr {
+		t.Fatalf("Rejected = %v, want [%v]", dlq.Rejected, decodeErr)
+	}
+	if len(reader.Committed) != 1 || reader.Committed[0].Offset != 42 {
+		t.Errorf("Committed = %v, want the dead-lettered message", reader.Committed)
+	}
+	if metrics.CustomIncrements["messages_dead_lettered"] != 1 {
+		t.Errorf("messages_dead_lettered = %d, want 1", metrics.CustomIncrements["messages_dead_lettered"])
+	}
+}
+
+func TestProcessNext_DeadLettersAfterMaxFailures(t *testing.T) {
+	matched := &events.AlertMatched{AlertID: "alert-1", ClientID: "client-1", RuleIDs: []string{"rule-1"}}
+	reader := &FakeReader{Messages: []*events.AlertMatched{matched, matched, matched}}
+	storage := &FakeStorage{InsertErr: errors.New("database connection failed")}
+	dlq := &FakeDLQ{MaxFailures: 3}
+
+	proc := NewProcessorWithMetrics(reader, &FakePublisher{}, storage, NewFakeMetrics()).WithDeadLetterQueue(dlq)
+
+	for i := 0; i < 2; i++ {
+		proc.processNext(context.Background())
+		if len(reader.Committed) != 0 {
+			t.Fatalf("failure %d: message committed before reaching max failures", i+1)
+		}
+	}
+	proc.processNext(context.Background())
+	if len(dlq.DeadLetters) != 1 {
+		t.Fatalf("DeadLetters = %d, want 1", len(dlq.DeadLetters))
+	}
+	if len(reader.Committed) != 1 {
+		t.Errorf("Committed = %d, want 1 after dead-lettering", len(reader.Committed))
+	}
+}
+
+func TestProcessNext_DeadLetterFailureLeavesMessageUncommitted(t *testing.T) {
+	reader := &FakeReader{DecodeErr: errors.New("bad payload")}
+	dlq := &FakeDLQ{Err: errors.New("kafka unavailable")}
+	metrics := NewFakeMetrics()
+
+	proc := NewProcessorWithMetrics(reader, &FakePublisher{}, &FakeStorage{}, metrics).WithDeadLetterQueue(dlq)
+	proc.processNext(context.Background())
+
+	if len(reader.Committed) != 0 {
+		t.Errorf("Committed = %d, want 0", len(reader.Committed))
+	}
+	if metrics.ErrorCount != 1 {
+		t.Errorf("ErrorCount = %d, want 1", metrics.ErrorCount)
+	}
+}
@@ -1,4 +1,4 @@
-// Package producer provides Kafka producer functionality for notifications.ready topic.
+// Package producer provides Kafka producer functionality for notifications.ready and notifications.grouped topics.
 package producer
 
 import (
@@ -40,6 +40,7 @@ func NewProducer(brokers string, topic string) (*Producer, error) {
 	// Use Hash balancer to partition by client_id for tenant locality
 	writer := &kafka.Writer{
 		Addr:         kafka.TCP(brokerList...),
+		Transport:    kafkautil.Transport(),
 		Topic:        topic,
 		Balancer:     &kafka.Hash{}, // Key-based partitioning (hashes the message key)
 		WriteTimeout: kafkautil.WriteTimeout,
@@ -70,6 +71,10 @@ func buildMessage(ready *events.NotificationReady) (kafka.Message, error) {
 		ClientId:       ready.ClientID,
 		AlertId:        ready.AlertID,
 		SchemaVersion:  int32(ready.SchemaVersion),
+		Priority:       int32(ready.Priority),
+		EvaluatedAtMs:  ready.EvaluatedAtMs,
+		AggregatedAtMs: ready.AggregatedAtMs,
+		ReadyAtMs:      ready.ReadyAtMs,
 	}
 
 	payload, err := proto.Marshal(pb)
@@ -138,6 +143,65 @@ func (p *Producer) Publish(ctx context.Context, ready *events.NotificationReady)
 	return nil
 }
 
+// buildGroupedMessage creates a Kafka message from a NotificationGrouped event.
+// Like ready events, the message is keyed by client_id.
+func buildGroupedMessage(grouped *events.NotificationGrouped) (kafka.Message, error) {
+	pb := &pbnotifications.NotificationGrouped{
+		NotificationId:  grouped.NotificationID,
+		ClientId:        grouped.ClientID,
+		GroupId:         grouped.GroupID,
+		NotificationIds: grouped.NotificationIDs,
+		WindowStart:     grouped.WindowStart,
+		WindowEnd:       grouped.WindowEnd,
+		SchemaVersion:   int32(grouped.SchemaVersion),
+		Priority:        int32(grouped.Priority),
+	}
+
+	payload, err := proto.Marshal(pb)
+	if err != nil {
+		return kafka.Message{}, fmt.Errorf("failed to marshal notification grouped event: %w", err)
+	}
+
+	return kafka.Message{
+		Key:   []byte(grouped.ClientID),
+		Value: payload,
+		Headers: []kafka.Header{
+			{Key: "content-type", Value: []byte("application/x-protobuf")},
+			{Key: "schema_version", Value: []byte(fmt.Sprintf("%d", grouped.SchemaVersion))},
+			{Key: "notification_id", Value: []byte(grouped.NotificationID)},
+			{Key: "group_id", Value: []byte(grouped.GroupID)},
+		},
+		Time: time.Now(),
+	}, nil
+}
+
+// PublishGrouped publishes a digest event. The producer must be bound to the
+// notifications.grouped topic.
+func (p *Producer) PublishGrouped(ctx context.Context, grouped *events.NotificationGrouped) error {
+	msg, err := buildGroupedMessage(grouped)
+	if err != nil {
+		return err
+	}
+
+	if err := p.writer.WriteMessages(ctx, msg); err != nil {
+		slog.Error("Failed to write message to Kafka",
+			"notification_id", grouped.NotificationID,
+			"topic", p.topic,
+			"error", err,
+		)
+		return fmt.Errorf("failed to write message to Kafka: %w", err)
+	}
+
+	slog.Info("Published notification grouped event",
+		"notification_id", grouped.NotificationID,
+		"client_id", grouped.ClientID,
+		"group_id", grouped.GroupID,
+		"notifications", len(grouped.NotificationIDs),
+	)
+
+	return nil
+}
+
 // Close gracefully closes the Kafka writer and releases resources.
 func (p *Producer) Close() error {
 	slog.Info("Closing Kafka producer", "topic", p.topic)
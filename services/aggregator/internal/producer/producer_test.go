@@ -2,6 +2,12 @@ package producer
 
 import (
 	"testing"
+
+	"aggregator/internal/events"
+
+	pbnotifications "github.com/afikmenashe/alerting-platform/pkg/proto/notifications"
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+	"google.golang.org/protobuf/proto"
 )
 
 func TestNewProducer(t *testing.T) {
@@ -68,6 +74,65 @@ func TestNewProducer(t *testing.T) {
 	}
 }
 
+// TestBuildMessage tests that the notification ready event, priority included, is encoded.
+func TestBuildMessage(t *testing.T) {
+	msg, err := buildMessage(&events.NotificationReady{
+		NotificationID: "notif-1",
+		ClientID:       "client-1",
+		AlertID:        "alert-1",
+		SchemaVersion:  1,
+		Priority:       shared.PriorityCritical,
+		EvaluatedAtMs:  1700000000100,
+		AggregatedAtMs: 1700000000150,
+		ReadyAtMs:      1700000000160,
+	})
+	if err != nil {
+		t.Fatalf("buildMessage() error = %v", err)
+	}
+	if string(msg.Key) != "client-1" {
+		t.Errorf("key = %q, want client-1", msg.Key)
+	}
+
+	var pb pbnotifications.NotificationReady
+	if err := proto.Unmarshal(msg.Value, &pb); err != nil {
+		t.Fatalf("proto.Unmarshal() error = %v", err)
+	}
+	if pb.NotificationId != "notif-1" || pb.Priority != shared.PriorityCritical {
+		t.Errorf("decoded = %v, want notif-1 with CRITICAL priority", &pb)
+	}
+	if pb.EvaluatedAtMs != 1700000000100 || pb.AggregatedAtMs != 1700000000150 || pb.ReadyAtMs != 1700000000160 {
+		t.Errorf("decoded stage times = %d/%d/%d, want 1700000000100/150/160", pb.EvaluatedAtMs, pb.AggregatedAtMs, pb.ReadyAtMs)
+	}
+}
+
+// TestBuildGroupedMessage tests that the digest event, grouped notification IDs included, is encoded.
+func TestBuildGroupedMessage(t *testing.T) {
+	msg, err := buildGroupedMessage(&events.NotificationGrouped{
+		NotificationID:  "digest-1",
+		ClientID:        "client-1",
+		GroupID:         "client-1:1700000000",
+		NotificationIDs: []string{"notif-1", "notif-2"},
+		WindowStart:     1700000000,
+		WindowEnd:       1700000600,
+		SchemaVersion:   1,
+		Priority:        shared.PriorityHigh,
+	})
+	if err != nil {
+		t.Fatalf("buildGroupedMessage() error = %v", err)
+	}
+	if string(msg.Key) != "client-1" {
+		t.Errorf("key = %q, want client-1", msg.Key)
+	}
+
+	var pb pbnotifications.NotificationGrouped
+	if err := proto.Unmarshal(msg.Value, &pb); err != nil {
+		t.Fatalf("proto.Unmarshal() error = %v", err)
+	}
+	if pb.NotificationId != "digest-1" || pb.GroupId != "client-1:1700000000" || len(pb.NotificationIds) != 2 || pb.WindowEnd != 1700000600 {
+		t.Errorf("decoded = %v, want digest-1 grouping 2 notifications", &pb)
+	}
+}
+
 // Note: Publish and Close tests require a real Kafka instance
 // or refactoring to use interfaces for dependency injection.
 // The validation tests above cover the NewProducer function.
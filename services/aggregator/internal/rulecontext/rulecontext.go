@@ -0,0 +1,161 @@
+// Package rulecontext enriches notification context with the rules that matched an alert,
+// so recipients can see why they were notified. Rule details are read from the rules table
+// and cached in memory.
+package rulecontext
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"aggregator/internal/database"
+	"aggregator/internal/events"
+)
+
+// ContextKeyMatchedRules is the context key written by the Enricher.
+const ContextKeyMatchedRules = "matched_rules"
+
+// DefaultCacheTTL is how long a rule (or a missing rule) is cached.
+const DefaultCacheTTL = time.Minute
+
+// ruleSeparator joins the descriptions of multiple matched rules.
+const ruleSeparator = "; "
+
+// Lookup loads rules by ID. Unknown IDs are omitted from the result.
+type Lookup interface {
+	GetRules(ctx context.Context, ruleIDs []string) ([]database.Rule, error)
+}
+
+// cacheEntry holds a cached rule. A nil rule caches a miss.
+type cacheEntry struct {
+	rule      *database.Rule
+	expiresAt time.Time
+}
+
+// Enricher adds the matched rules' names and descriptions to matched alerts.
+// It is safe for concurrent use.
+type Enricher struct {
+	lookup Lookup
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+}
+
+// NewEnricher creates an enricher that loads rules from lookup.
+// A non-positive ttl uses DefaultCacheTTL.
+func NewEnricher(lookup Lookup, ttl time.Duration) *Enricher {
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	return &Enricher{
+		lookup: lookup,
+		ttl:    ttl,
+		now:    time.Now,
+		cache:  make(map[string]cacheEntry),
+	}
+}
+
+// Enrich sets matched_rules in matched.Context to one label per matched rule, in rule ID
+// order: the rule's description, or its severity/source/name when it has none.
+// An existing matched_rules key is never overwritten. Returns true if the key was added.
+func (e *Enricher) Enrich(ctx context.Context, matched *events.AlertMatched) bool {
+	if matched == nil || len(matched.RuleIDs) == 0 {
+		return false
+	}
+	if _, exists := matched.Context[ContextKeyMatchedRules]; exists {
+		return false
+	}
+
+	rules := e.resolve(ctx, matched.RuleIDs)
+	labels := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		labels = append(labels, label(rule))
+	}
+	if len(labels) == 0 {
+		return false
+	}
+
+	if matched.Context == nil {
+		matched.Context = make(map[string]string)
+	}
+	matched.Context[ContextKeyMatchedRules] = strings.Join(labels, ruleSeparator)
+	return true
+}
+
+// resolve returns the known rules among ruleIDs, in order, loading uncached rules in one query.
+func (e *Enricher) resolve(ctx context.Context, ruleIDs []string) []*database.Rule {
+	found, missing := e.cached(ruleIDs)
+	if len(missing) > 0 {
+		loaded, err := e.lookup.GetRules(ctx, missing)
+		if err != nil {
+			// Lookup errors are not cached so the next alert retries
+			slog.Warn("Failed to load matched rules, using cached rules only",
+				"rule_ids", missing,
+				"error", err,
+			)
+		} else {
+			e.store(missing, loaded, found)
+		}
+	}
+
+	rules := make([]*database.Rule, 0, len(ruleIDs))
+	for _, id := range ruleIDs {
+		if rule := found[id]; rule != nil {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// cached returns the cached rules among ruleIDs and the IDs that need to be loaded.
+func (e *Enricher) cached(ruleIDs []string) (map[string]*database.Rule, []string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	found := make(map[string]*database.Rule, len(ruleIDs))
+	var missing []string
+	now := e.now()
+	for _, id := range ruleIDs {
+		if _, seen := found[id]; seen {
+			continue
+		}
+		entry, ok := e.cache[id]
+		if !ok || now.After(entry.expiresAt) {
+			delete(e.cache, id)
+			found[id] = nil
+			missing = append(missing, id)
+			continue
+		}
+		found[id] = entry.rule
+	}
+	return found, missing
+}
+
+// store caches the loaded rules and records IDs that were not found as misses.
+// Loaded rules are also added to found.
+func (e *Enricher) store(requested []string, loaded []database.Rule, found map[string]*database.Rule) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	expiresAt := e.now().Add(e.ttl)
+	for _, id := range requested {
+		e.cache[id] = cacheEntry{expiresAt: expiresAt}
+	}
+	for i := range loaded {
+		rule := &loaded[i]
+		e.cache[rule.RuleID] = cacheEntry{rule: rule, expiresAt: expiresAt}
+		found[rule.RuleID] = rule
+	}
+}
+
+// label describes a rule for recipients.
+func label(rule *database.Rule) string {
+	if description := strings.TrimSpace(rule.Description); description != "" {
+		return description
+	}
+	return rule.Severity + "/" + rule.Source + "/" + rule.Name
+}
@@ -0,0 +1,133 @@
+package rulecontext
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"aggregator/internal/database"
+	"aggregator/internal/events"
+)
+
+// fakeLookup is a test fake for Lookup that serves rules from a map and records requested IDs.
+type fakeLookup struct {
+	rules     map[string]database.Rule
+	err       error
+	requested [][]string
+}
+
+func (f *fakeLookup) GetRules(ctx context.Context, ruleIDs []string) ([]database.Rule, error) {
+	f.requested = append(f.requested, ruleIDs)
+	if f.err != nil {
+		return nil, f.err
+	}
+	var rules []database.Rule
+	for _, id := range ruleIDs {
+		if rule, ok := f.rules[id]; ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+func testRules() map[string]database.Rule {
+	return map[string]database.Rule{
+		"rule-1": {RuleID: "rule-1", Severity: "HIGH", Source: "checkout", Name: "*", Description: "Checkout errors page the payments on-call"},
+		"rule-2": {RuleID: "rule-2", Severity: "CRITICAL", Source: "*", Name: "disk-full"},
+	}
+}
+
+func TestEnricher_Enrich(t *testing.T) {
+	tests := []struct {
+		name      string
+		ruleIDs   []string
+		context   map[string]string
+		wantAdded bool
+		want      string
+	}{
+		{
+			name:      "description and fallback label in rule order",
+			ruleIDs:   []string{"rule-2", "rule-1"},
+			wantAdded: true,
+			want:      "CRITICAL/*/disk-full; Checkout errors page the payments on-call",
+		},
+		{
+			name:      "unknown rules are skipped",
+			ruleIDs:   []string{"deleted", "rule-1"},
+			wantAdded: true,
+			want:      "Checkout errors page the payments on-call",
+		},
+		{
+			name:    "only unknown rules",
+			ruleIDs: []string{"deleted"},
+		},
+		{
+			name:    "no rule ids",
+			ruleIDs: nil,
+		},
+		{
+			name:    "existing key is not overwritten",
+			ruleIDs: []string{"rule-1"},
+			context: map[string]string{ContextKeyMatchedRules: "set by producer"},
+			want:    "set by producer",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewEnricher(&fakeLookup{rules: testRules()}, 0)
+			matched := &events.AlertMatched{AlertID: "alert-1", RuleIDs: tt.ruleIDs, Context: tt.context}
+
+			if got := e.Enrich(context.Background(), matched); got != tt.wantAdded {
+				t.Errorf("Enrich() = %v, want %v", got, tt.wantAdded)
+			}
+			if got := matched.Context[ContextKeyMatchedRules]; got != tt.want {
+				t.Errorf("context[%s] = %q, want %q", ContextKeyMatchedRules, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnricher_Cache(t *testing.T) {
+	lookup := &fakeLookup{rules: testRules()}
+	e := NewEnricher(lookup, time.Minute)
+	now := time.Now()
+	e.now = func() time.Time { return now }
+
+	e.Enrich(context.Background(), &events.AlertMatched{RuleIDs: []string{"rule-1", "deleted"}})
+	e.Enrich(context.Background(), &events.AlertMatched{RuleIDs: []string{"rule-1", "deleted", "rule-2"}})
+
+	// Misses are cached too, so only rule-2 is loaded the second time
+	want := [][]string{{"rule-1", "deleted"}, {"rule-2"}}
+	if !reflect.DeepEqual(lookup.requested, want) {
+		t.Errorf("requested = %v, want %v", lookup.requested, want)
+	}
+
+	now = now.Add(time.Minute + time.Second)
+	e.Enrich(context.Background(), &events.AlertMatched{RuleIDs: []string{"rule-1"}})
+	if len(lookup.requested) != 3 {
+		t.Errorf("lookups after expiry = %d, want 3", len(lookup.requested))
+	}
+}
+
+func TestEnricher_LookupError(t *testing.T) {
+	lookup := &fakeLookup{err: errors.New("connection refused")}
+	e := NewEnricher(lookup, time.Minute)
+	matched := &events.AlertMatched{RuleIDs: []string{"rule-1"}}
+
+	if e.Enrich(context.Background(), matched) {
+		t.Error("Enrich() = true, want false when the lookup fails")
+	}
+
+	// Errors are not cached
+	lookup.err = nil
+	lookup.rules = testRules()
+	if !e.Enrich(context.Background(), matched) {
+		t.Error("Enrich() after recovery = false, want true")
+	}
+	if len(lookup.requested) != 2 {
+		t.Errorf("lookups = %d, want 2", len(lookup.requested))
+	}
+}
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_notifications_open_fingerprint;
+ALTER TABLE notifications
+    DROP COLUMN IF EXISTS last_notified_at,
+    DROP COLUMN IF EXISTS repeat_count,
+    DROP COLUMN IF EXISTS fingerprint;
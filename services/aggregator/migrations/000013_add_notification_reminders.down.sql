@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_notifications_reminders;
+ALTER TABLE notifications
+    DROP COLUMN IF EXISTS last_reminded_at,
+    DROP COLUMN IF EXISTS reminder_count;
@@ -0,0 +1,12 @@
+-- Reminder state for delivered notifications that are not acknowledged.
+-- Written by the sender's reminder scheduler.
+--
+-- Migration: 000013
+-- Service: aggregator (table owner)
+-- Used by: sender
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS reminder_count INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS last_reminded_at TIMESTAMP;
+
+-- Reminder scans only look at delivered notifications
+CREATE INDEX IF NOT EXISTS idx_notifications_reminders ON notifications(severity, created_at) WHERE status = 'SENT';
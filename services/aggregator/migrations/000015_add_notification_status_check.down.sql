@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_notifications_reminders;
+CREATE INDEX IF NOT EXISTS idx_notifications_reminders ON notifications(severity, created_at) WHERE status = 'SENT';
+
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_status_check;
+ALTER TABLE notifications ALTER COLUMN status DROP NOT NULL;
+
+-- Map statuses the previous services do not know back to their closest equivalent
+UPDATE notifications SET status = 'RECEIVED' WHERE status = 'SENDING';
+UPDATE notifications SET status = 'SENT' WHERE status = 'PARTIALLY_SENT';
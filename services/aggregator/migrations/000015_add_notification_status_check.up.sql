@@ -0,0 +1,34 @@
+-- Notification status machine: RECEIVED -> SENDING -> SENT | PARTIALLY_SENT | FAILED | SIMULATED | SUPPRESSED,
+-- closed by ACKNOWLEDGED or RESOLVED. Allowed transitions are enforced by the services
+-- (pkg/shared/status.go); the database only rejects unknown statuses.
+--
+-- Migration: 000015
+-- Service: aggregator (table owner)
+-- Used by: sender, rule-service
+
+-- Rows without a status (or with the sender's never-written PENDING) are still waiting for delivery
+UPDATE notifications SET status = 'RECEIVED' WHERE status IS NULL OR status = 'PENDING';
+
+-- SENT rows whose journal records a failed endpoint were only partially sent
+UPDATE notifications n
+SET status = 'PARTIALLY_SENT'
+WHERE n.status = 'SENT'
+    AND EXISTS (
+        SELECT 1 FROM notification_events e
+        WHERE e.notification_id = n.notification_id
+            AND e.event_type = 'send_attempt'
+            AND e.error IS NOT NULL
+    );
+
+-- Any other unknown status cannot be trusted to have been delivered
+UPDATE notifications SET status = 'FAILED'
+WHERE status NOT IN ('RECEIVED', 'SENDING', 'SENT', 'PARTIALLY_SENT', 'FAILED', 'SIMULATED', 'SUPPRESSED', 'ACKNOWLEDGED', 'RESOLVED');
+
+ALTER TABLE notifications ALTER COLUMN status SET NOT NULL;
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_status_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_status_check
+    CHECK (status IN ('RECEIVED', 'SENDING', 'SENT', 'PARTIALLY_SENT', 'FAILED', 'SIMULATED', 'SUPPRESSED', 'ACKNOWLEDGED', 'RESOLVED'));
+
+-- Partially sent notifications get reminders too
+DROP INDEX IF EXISTS idx_notifications_reminders;
+CREATE INDEX IF NOT EXISTS idx_notifications_reminders ON notifications(severity, created_at) WHERE status IN ('SENT', 'PARTIALLY_SENT');
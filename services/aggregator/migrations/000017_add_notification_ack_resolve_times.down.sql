@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS trg_notifications_closed_at ON notifications;
+DROP FUNCTION IF EXISTS stamp_notification_closed_at();
+DROP INDEX IF EXISTS idx_notifications_acknowledged_at;
+DROP INDEX IF EXISTS idx_notifications_resolved_at;
+ALTER TABLE notifications
+    DROP COLUMN IF EXISTS acknowledged_at,
+    DROP COLUMN IF EXISTS resolved_at;
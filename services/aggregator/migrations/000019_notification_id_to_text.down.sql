@@ -0,0 +1,15 @@
+-- Only succeeds while every notification_id is still a UUID: delete notifications with
+-- time-ordered IDs first.
+ALTER TABLE notification_events DROP CONSTRAINT IF EXISTS notification_events_notification_id_fkey;
+
+ALTER TABLE notifications
+    ALTER COLUMN notification_id DROP DEFAULT,
+    ALTER COLUMN notification_id TYPE UUID USING notification_id::uuid,
+    ALTER COLUMN notification_id SET DEFAULT gen_random_uuid();
+
+ALTER TABLE notification_events
+    ALTER COLUMN notification_id TYPE UUID USING notification_id::uuid;
+
+ALTER TABLE notification_events
+    ADD CONSTRAINT notification_events_notification_id_fkey
+    FOREIGN KEY (notification_id) REFERENCES notifications(notification_id) ON DELETE CASCADE;
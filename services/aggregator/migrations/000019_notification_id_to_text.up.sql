@@ -0,0 +1,20 @@
+-- Notification IDs become TEXT so aggregator can supply time-ordered IDs (pkg/ids ULIDs)
+-- instead of relying on gen_random_uuid(). Existing UUIDs are kept as their text form; the
+-- default stays for writers that do not supply an ID.
+--
+-- Migration: 000019
+-- Service: aggregator (table owner)
+-- Used by: sender, rule-service, metrics-service (notification_events follows the type)
+ALTER TABLE notification_events DROP CONSTRAINT IF EXISTS notification_events_notification_id_fkey;
+
+ALTER TABLE notifications
+    ALTER COLUMN notification_id DROP DEFAULT,
+    ALTER COLUMN notification_id TYPE TEXT USING notification_id::text,
+    ALTER COLUMN notification_id SET DEFAULT gen_random_uuid()::text;
+
+ALTER TABLE notification_events
+    ALTER COLUMN notification_id TYPE TEXT USING notification_id::text;
+
+ALTER TABLE notification_events
+    ADD CONSTRAINT notification_events_notification_id_fkey
+    FOREIGN KEY (notification_id) REFERENCES notifications(notification_id) ON DELETE CASCADE;
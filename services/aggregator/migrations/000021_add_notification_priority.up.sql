@@ -0,0 +1,19 @@
+-- Delivery priority derived from severity (4 CRITICAL, 3 HIGH, 2 MEDIUM, 1 LOW, 0 unknown),
+-- set by the aggregator on insert and carried on notifications.ready. The sender delivers
+-- higher priorities first when it has a backlog.
+--
+-- Migration: 000021
+-- Service: aggregator (table owner)
+-- Used by: sender
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS priority SMALLINT NOT NULL DEFAULT 0;
+
+-- Existing notifications: derive the priority the aggregator would have set
+UPDATE notifications SET priority = CASE UPPER(severity)
+    WHEN 'CRITICAL' THEN 4
+    WHEN 'HIGH' THEN 3
+    WHEN 'MEDIUM' THEN 2
+    WHEN 'LOW' THEN 1
+    ELSE 0
+END
+WHERE priority = 0;
@@ -0,0 +1,22 @@
+-- Notification deliveries: one row per endpoint a notification was delivered to (or failed
+-- to be), after the sender's retries. Written by sender; read by rule-service so operators can
+-- see which channels of a notification succeeded or failed.
+-- endpoint_id has no foreign key so the history survives endpoint deletion; it is NULL for
+-- deliveries to the owning team's Slack channel (owner routing), which is not an endpoint.
+--
+-- Migration: 000023
+-- Service: aggregator (table owner)
+-- Used by: sender (writes), rule-service (reads)
+CREATE TABLE IF NOT EXISTS notification_deliveries (
+    delivery_id BIGSERIAL PRIMARY KEY,
+    notification_id TEXT NOT NULL REFERENCES notifications(notification_id) ON DELETE CASCADE,
+    endpoint_id VARCHAR(255),
+    channel VARCHAR(50) NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('SENT', 'FAILED', 'SIMULATED')),
+    error TEXT,
+    latency_ms INTEGER NOT NULL DEFAULT 0,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_notification ON notification_deliveries(notification_id, delivery_id);
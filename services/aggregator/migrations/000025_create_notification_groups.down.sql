@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_notifications_group;
+ALTER TABLE notifications DROP COLUMN IF EXISTS group_id;
+DROP TABLE IF EXISTS notification_groups;
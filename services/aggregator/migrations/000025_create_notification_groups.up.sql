@@ -0,0 +1,26 @@
+-- Notification groups (digest mode): the aggregator holds a grouped client's notifications in a
+-- group per client and time window instead of publishing each one, and once the window closes
+-- creates one digest notification listing them and publishes it to notifications.grouped.
+-- Grouped notifications carry their group_id and stay RECEIVED until the sender delivers the
+-- digest. The digest itself has no group_id, so it is reminded and acknowledged like any other
+-- notification.
+--
+-- Migration: 000025
+-- Service: aggregator (table owner)
+-- Used by: sender (reads group_id)
+CREATE TABLE IF NOT EXISTS notification_groups (
+    group_id TEXT PRIMARY KEY,
+    client_id VARCHAR(255) NOT NULL,
+    window_start TIMESTAMP NOT NULL,
+    window_end TIMESTAMP NOT NULL,
+    digest_notification_id TEXT REFERENCES notifications(notification_id) ON DELETE SET NULL,
+    published_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_groups_due ON notification_groups(window_end) WHERE published_at IS NULL;
+
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS group_id TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_notifications_group ON notifications(group_id) WHERE group_id IS NOT NULL;
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_notifications_throttled;
+
+-- Previous services never flush throttled notifications; they cannot be trusted to have been delivered
+UPDATE notifications SET status = 'FAILED' WHERE status = 'THROTTLED';
+
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_status_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_status_check
+    CHECK (status IN ('RECEIVED', 'SENDING', 'SENT', 'PARTIALLY_SENT', 'FAILED', 'SIMULATED', 'SUPPRESSED', 'ACKNOWLEDGED', 'RESOLVED'));
+
+ALTER TABLE notifications DROP COLUMN IF EXISTS throttled_until;
@@ -0,0 +1,16 @@
+-- Notification throttling: the sender rate limits sends per endpoint, and a notification some of
+-- whose endpoints were over their limit is marked THROTTLED with throttled_until set to when its
+-- endpoints get a token back. The sender's throttle flusher claims due THROTTLED notifications
+-- (back to SENDING) and delivers them to the endpoints that did not get them yet.
+--
+-- Migration: 000026
+-- Service: aggregator (table owner)
+-- Used by: sender (writes status THROTTLED and throttled_until)
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_status_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_status_check
+    CHECK (status IN ('RECEIVED', 'SENDING', 'SENT', 'PARTIALLY_SENT', 'FAILED', 'SIMULATED', 'SUPPRESSED', 'THROTTLED', 'ACKNOWLEDGED', 'RESOLVED'));
+
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS throttled_until TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_notifications_throttled ON notifications(throttled_until) WHERE status = 'THROTTLED';
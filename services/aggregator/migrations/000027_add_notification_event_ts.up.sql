@@ -0,0 +1,9 @@
+-- Notification event time: when the alert happened, from the event_ts of alerts.matched. The
+-- sender renders it in the client's timezone. NULL for notifications created before this
+-- migration and for digests, which group alerts from different times.
+--
+-- Migration: 000027
+-- Service: aggregator (table owner)
+-- Used by: sender (reads event_ts)
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS event_ts TIMESTAMP;
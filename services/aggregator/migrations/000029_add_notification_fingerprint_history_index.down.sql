@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_notifications_fingerprint_history;
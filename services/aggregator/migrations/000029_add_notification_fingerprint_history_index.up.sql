@@ -0,0 +1,9 @@
+-- Alert history by fingerprint: the sender lists the last notifications of a client with the
+-- same fingerprint as a notification it sends ("previously fired at"). Unlike
+-- idx_notifications_open_fingerprint, this index also covers acknowledged and resolved
+-- notifications.
+--
+-- Migration: 000029
+-- Service: aggregator (table owner)
+-- Used by: sender (related alerts)
+CREATE INDEX IF NOT EXISTS idx_notifications_fingerprint_history ON notifications(client_id, fingerprint, created_at DESC);
@@ -0,0 +1,3 @@
+ALTER TABLE notification_events
+    DROP COLUMN IF EXISTS actor,
+    DROP COLUMN IF EXISTS note;
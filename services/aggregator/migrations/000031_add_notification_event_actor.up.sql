@@ -0,0 +1,9 @@
+-- Who made a manual transition (acknowledged, resolved) and why, for the audit trail
+-- shown in the notification journal. Service-written events leave both empty.
+--
+-- Migration: 000031
+-- Service: aggregator (table owner)
+-- Used by: rule-service
+ALTER TABLE notification_events
+    ADD COLUMN IF NOT EXISTS actor VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS note TEXT;
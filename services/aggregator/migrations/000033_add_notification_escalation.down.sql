@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_notifications_escalations;
+ALTER TABLE notifications
+    DROP COLUMN IF EXISTS escalation_step,
+    DROP COLUMN IF EXISTS last_escalated_at;
@@ -0,0 +1,13 @@
+-- Escalation state of delivered notifications that are not acknowledged: how many steps of
+-- the rule's escalation policy were sent, and when the last one was.
+-- Written by the sender's escalation scheduler.
+--
+-- Migration: 000033
+-- Service: aggregator (table owner)
+-- Used by: sender
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS escalation_step INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS last_escalated_at TIMESTAMP;
+
+-- Escalation scans look up open notifications of the rules that have a policy
+CREATE INDEX IF NOT EXISTS idx_notifications_escalations ON notifications USING GIN (rule_ids) WHERE status IN ('SENT', 'PARTIALLY_SENT', 'FAILED');
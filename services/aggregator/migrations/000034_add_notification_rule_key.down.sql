@@ -0,0 +1,6 @@
+-- Restoring the per-client idempotency key fails while per-rule notifications exist
+-- (several rows for one client and alert); delete them first.
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_client_alert_rule_unique;
+ALTER TABLE notifications
+    ADD CONSTRAINT notifications_client_alert_unique UNIQUE (client_id, alert_id);
+ALTER TABLE notifications DROP COLUMN IF EXISTS rule_key;
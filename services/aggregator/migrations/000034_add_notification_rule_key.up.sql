@@ -0,0 +1,14 @@
+-- Rule key of a notification: empty when the aggregator collapses an alert's matching rules
+-- into one notification per client (the default), or the rule ID when it creates one
+-- notification per rule (-collapse-policy rule). Part of the idempotency key.
+--
+-- Migration: 000034
+-- Service: aggregator (table owner)
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS rule_key VARCHAR(255) NOT NULL DEFAULT '';
+
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_client_alert_unique;
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_client_id_alert_id_key;
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_client_alert_rule_unique;
+ALTER TABLE notifications
+    ADD CONSTRAINT notifications_client_alert_rule_unique UNIQUE (client_id, alert_id, rule_key);
@@ -0,0 +1,4 @@
+ALTER TABLE notifications
+    DROP COLUMN IF EXISTS evaluated_at,
+    DROP COLUMN IF EXISTS aggregated_at,
+    DROP COLUMN IF EXISTS ready_at;
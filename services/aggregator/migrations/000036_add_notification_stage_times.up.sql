@@ -0,0 +1,11 @@
+-- Times a notification passed each pipeline stage, so its latency can be attributed to a
+-- stage: evaluated_at when the evaluator matched the alert, aggregated_at when the aggregator
+-- stored the notification, and ready_at when it published notifications.ready. NULL if unknown
+-- (notifications created before this migration, or events from services that predate it).
+--
+-- Migration: 000036
+-- Service: aggregator (table owner)
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS evaluated_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS aggregated_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS ready_at TIMESTAMP;
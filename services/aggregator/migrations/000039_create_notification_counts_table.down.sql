@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS trg_notification_counts ON notifications;
+DROP FUNCTION IF EXISTS update_notification_counts();
+DROP TABLE IF EXISTS notification_counts;
@@ -4,18 +4,28 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"flag"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
 	"alert-producer/internal/api"
+	"alert-producer/internal/audit"
+	"alert-producer/internal/auth"
+	"alert-producer/internal/canary"
+	"alert-producer/internal/ingest"
+	"alert-producer/internal/producer"
 
+	kafkautil "github.com/afikmenashe/alerting-platform/pkg/kafka"
 	"github.com/afikmenashe/alerting-platform/pkg/metrics"
 	"github.com/afikmenashe/alerting-platform/pkg/shared"
+	_ "github.com/lib/pq"
+	"github.com/redis/go-redis/v9"
 )
 
 func main() {
@@ -29,12 +39,61 @@ func main() {
 		port                = flag.String("port", envOrDefault("PORT", "8082"), "HTTP server port")
 		defaultKafkaBrokers = flag.String("kafka-brokers", envOrDefault("KAFKA_BROKERS", "localhost:9092"), "Default Kafka broker addresses")
 		redisAddr           = flag.String("redis-addr", envOrDefault("REDIS_ADDR", ""), "Redis server address for metrics")
+		alertsTopic         = flag.String("topic", envOrDefault("ALERTS_NEW_TOPIC", "alerts.new"), "Kafka topic for canary and ingested alerts")
+		canaryInterval      = flag.Duration("canary-interval", durationEnvOrDefault("CANARY_INTERVAL", canary.DefaultInterval), "Interval between pipeline canary alerts (0 disables the canary)")
+		apiKeys             = flag.String("api-keys", envOrDefault("API_KEYS", ""), "Comma-separated name:key[:admin] API keys (empty disables authentication)")
+		postgresDSN         = flag.String("postgres-dsn", envOrDefault("POSTGRES_DSN", ""), "PostgreSQL connection string to check API keys issued by rule-service and persist job schedules (empty accepts only --api-keys and keeps schedules in memory)")
+		apiKeyCacheTTL      = flag.Duration("api-key-cache-ttl", durationEnvOrDefault("API_KEY_CACHE_TTL", shared.DefaultAPIKeyCacheTTL), "How long a valid issued API key is trusted before it is looked up again")
+		rateLimitTiers      = flag.String("rate-limit-tiers", envOrDefault("RATE_LIMIT_TIERS", ""), "Comma-separated name:rate:burst request limits per API key (empty disables rate limiting; requires Redis)")
+		rateLimitKeys       = flag.String("rate-limit-keys", envOrDefault("RATE_LIMIT_KEYS", ""), "Comma-separated key-name:tier assignments (others use the default tier)")
+		jobTTL              = flag.Duration("job-ttl", durationEnvOrDefault("JOB_TTL", api.DefaultJobTTL), "How long a finished job is kept in memory before its summary moves to the job history (0 keeps it)")
+		maxJobs             = flag.Int("max-jobs", intEnvOrDefault("MAX_JOBS", api.DefaultMaxJobs), "Most jobs kept in memory; above it the least recently used finished jobs move to the job history (0 is unlimited)")
 	)
+	var corsFlags shared.CORSFlags
+	corsFlags.Register(flag.CommandLine)
+	kafkaSecurity := kafkautil.RegisterSecurityFlags(flag.CommandLine)
 	flag.Parse()
 
+	keys, err := auth.ParseKeys(*apiKeys)
+	if err != nil {
+		slog.Error("Invalid API keys", "error", err)
+		os.Exit(1)
+	}
+	rateLimits, err := shared.ParseRateLimitPolicy(*rateLimitTiers, *rateLimitKeys)
+	if err != nil {
+		slog.Error("Invalid rate limits", "error", err)
+		os.Exit(1)
+	}
+	corsPolicy, err := corsFlags.Policy()
+	if err != nil {
+		slog.Error("Invalid CORS configuration", "error", err)
+		os.Exit(1)
+	}
+	if *jobTTL < 0 || *maxJobs < 0 {
+		slog.Error("Invalid job retention", "job_ttl", *jobTTL, "max_jobs", *maxJobs)
+		os.Exit(1)
+	}
+	if err := kafkautil.Configure(kafkaSecurity); err != nil {
+		slog.Error("Invalid Kafka security configuration", "error", err)
+		os.Exit(1)
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Accept the API keys issued by rule-service and persist job schedules (optional)
+	var db *sql.DB
+	if *postgresDSN != "" {
+		db, err = openPostgres(ctx, *postgresDSN)
+		if err != nil {
+			slog.Error("Failed to connect to PostgreSQL", "error", err)
+			os.Exit(1)
+		}
+		defer db.Close()
+		keys.UseIssuedKeys(shared.NewAPIKeyAuthenticator(shared.NewSQLAPIKeyStore(db), "", *apiKeyCacheTTL))
+		slog.Info("Issued API keys enabled", "cache_ttl", *apiKeyCacheTTL)
+	}
+
 	// Handle graceful shutdown
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -45,12 +104,14 @@ func main() {
 	}()
 
 	// Initialize Redis client for metrics (optional)
-	var metricsCollector *metrics.Collector
+	var metricsCollector metrics.Collector
+	var redisClient *redis.Client
 	if *redisAddr != "" {
 		slog.Info("Connecting to Redis for metrics", "addr", *redisAddr)
-		redisClient, err := shared.ConnectRedis(ctx, *redisAddr)
+		redisClient, err = shared.ConnectRedis(ctx, *redisAddr)
 		if err != nil {
 			slog.Warn("Failed to connect to Redis, metrics will be disabled", "error", err)
+			redisClient = nil
 		} else {
 			slog.Info("Successfully connected to Redis")
 			metricsCollector = metrics.NewCollector("alert-producer", redisClient)
@@ -60,34 +121,138 @@ func main() {
 		}
 	}
 
-	// Create job manager
-	jm := api.NewJobManager()
+	// Start the pipeline canary (requires Redis to record results)
+	if *canaryInterval > 0 && metricsCollector != nil {
+		canaryProducer, err := producer.New(*defaultKafkaBrokers, *alertsTopic)
+		if err != nil {
+			slog.Warn("Failed to create canary producer, pipeline canary disabled", "error", err)
+		} else {
+			defer canaryProducer.Close()
+			go canary.NewEmitter(canaryProducer, metricsCollector, *canaryInterval).Run(ctx)
+		}
+	} else if *canaryInterval > 0 {
+		slog.Warn("Redis not configured, pipeline canary disabled")
+	}
+
+	// Producer for alerts pushed by external systems (POST /api/v1/alerts/ingest and /batch)
+	var ingestProducer producer.AlertPublisher
+	var batchProducer producer.BatchPublisher
+	if p, err := producer.New(*defaultKafkaBrokers, *alertsTopic); err != nil {
+		slog.Warn("Failed to create ingest producer, alert ingestion disabled", "error", err)
+	} else {
+		defer p.Close()
+		ingestProducer = p
+		batchProducer = p
+	}
+
+	// Create job manager, audit trail and history of evicted jobs (kept in Redis when
+	// available, otherwise in memory)
+	auditLog := audit.NewLog(redisClient, audit.DefaultMaxEntries)
+	jm := api.NewJobManager().
+		WithAuditLog(auditLog).
+		WithRetention(*jobTTL, *maxJobs).
+		WithHistory(api.NewJobHistory(redisClient, api.DefaultJobHistoryEntries))
+	if *jobTTL > 0 {
+		go jm.EnforceRetention(ctx, jobRetentionInterval(*jobTTL))
+	}
+
+	// Halt generation jobs during an emergency stop with stop_jobs (requires Redis)
+	if redisClient != nil {
+		emergencyStop := shared.NewEmergencyStopWatcher(shared.NewEmergencyStopStore(redisClient), shared.DefaultEmergencyStopPollInterval)
+		emergencyStop.Refresh(ctx)
+		go emergencyStop.Run(ctx)
+		jm.WithStopSignal(emergencyStop)
+		go jm.EnforceEmergencyStop(ctx, shared.DefaultEmergencyStopPollInterval)
+	}
+
+	// Start recurring jobs, with schedules kept in Postgres when configured so they survive
+	// restarts and each run starts once across instances
+	var schedules api.ScheduleStore = api.NewMemoryScheduleStore()
+	if db != nil {
+		schedules = api.NewSQLScheduleStore(db)
+	} else {
+		slog.Warn("PostgreSQL not configured, job schedules are kept in memory and lost on restart")
+	}
+	jm.WithSchedules(schedules, *defaultKafkaBrokers)
+	go jm.RunSchedules(ctx, api.DefaultScheduleInterval)
+
+	// Rate limit each API key per tier, with token buckets shared by all instances in Redis
+	var limiter auth.RateLimiter
+	if rateLimits.Enabled() && redisClient != nil {
+		limiter = shared.NewRateLimiter(redisClient, "alert-producer", rateLimits)
+	} else if rateLimits.Enabled() {
+		slog.Warn("Redis not configured, rate limiting disabled")
+	}
 
 	// Setup routes
 	mux := http.NewServeMux()
 	mux.HandleFunc("/health", api.HandleHealth)
-	mux.HandleFunc("/api/v1/alerts/generate", api.HandleGenerate(jm, *defaultKafkaBrokers))
+	mux.HandleFunc("/api/v1/alerts/generate", api.HandleGenerate(jm, *defaultKafkaBrokers, auditLog))
 	mux.HandleFunc("/api/v1/alerts/generate/list", api.HandleListJobs(jm))
 	mux.HandleFunc("/api/v1/alerts/generate/status", api.HandleGetJob(jm))
-	mux.HandleFunc("/api/v1/alerts/generate/stop", api.HandleStopJob(jm))
+	mux.HandleFunc("/api/v1/alerts/generate/stop", api.HandleStopJob(jm, auditLog))
+	mux.HandleFunc("/api/v1/alerts/generate/history", api.HandleJobHistory(jm))
+	mux.HandleFunc("/api/v1/alerts/generate/audit", api.HandleAudit(auditLog))
+	mux.HandleFunc("/api/v1/alerts/schedules", api.HandleSchedules(jm, auditLog))
+	mux.HandleFunc("/api/v1/alerts/schedules/pause", api.HandlePauseSchedule(jm, auditLog, true))
+	mux.HandleFunc("/api/v1/alerts/schedules/resume", api.HandlePauseSchedule(jm, auditLog, false))
+	mux.HandleFunc("/api/v1/alerts/schedules/delete", api.HandleDeleteSchedule(jm, auditLog))
+	mux.HandleFunc("/api/v1/alerts/ingest", api.HandleIngest(ingestProducer))
+	mux.HandleFunc("/api/v1/alerts/batch", api.HandleBatch(batchProducer))
 
-	// Apply middleware: CORS first, then metrics
-	handler := corsMiddleware(mux)
+	// Apply middleware: rate limiting (per authenticated key), authentication, then CORS
+	// (so preflights skip auth), then metrics
+	handler := auth.RateLimitMiddleware(limiter, metricsCollector, "/health")(mux)
+	handler = auth.Middleware(keys, "/health")(handler)
+	handler = shared.CORSMiddleware(corsPolicy, "Retry-After")(handler)
 	handler = metricsMiddleware(metricsCollector)(handler)
 
+	if !keys.Enabled() {
+		slog.Warn("No API keys configured, the API is unauthenticated and all jobs are global")
+	}
+	if corsPolicy.AnyOrigin() {
+		slog.Warn("CORS allows any origin, set CORS_ALLOWED_ORIGINS in production")
+	}
+
 	addr := ":" + *port
 	slog.Info("Starting alert-producer API server",
 		"port", *port,
 		"kafka_brokers", *defaultKafkaBrokers,
 		"redis_addr", *redisAddr,
+		"canary_interval", *canaryInterval,
+		"api_keys", keys.Len(),
+		"rate_limit_tiers", rateLimits.Len(),
+		"job_ttl", *jobTTL,
+		"max_jobs", *maxJobs,
 	)
 
-	if err := http.ListenAndServe(addr, handler); err != nil {
+	// Ingestion bodies may be up to ingest.MaxBodyBytes
+	server := shared.NewHTTPServer(addr, handler, shared.WithMaxBodyBytes(ingest.MaxBodyBytes))
+	if err := server.ListenAndServe(); err != nil {
 		slog.Error("Server failed", "error", err)
 		os.Exit(1)
 	}
 }
 
+// openPostgres connects to PostgreSQL, which holds the issued API keys and the job schedules.
+func openPostgres(ctx context.Context, dsn string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(5)
+	db.SetMaxIdleConns(2)
+	db.SetConnMaxLifetime(5 * time.Minute)
+
+	pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := db.PingContext(pingCtx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
 // envOrDefault reads an environment variable or returns a default value.
 func envOrDefault(key, defaultVal string) string {
 	if v := os.Getenv(key); v != "" {
@@ -96,20 +261,30 @@ func envOrDefault(key, defaultVal string) string {
 	return defaultVal
 }
 
-// corsMiddleware adds CORS headers to allow requests from the UI.
-func corsMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+// durationEnvOrDefault reads a duration from an environment variable or returns a default value.
+func durationEnvOrDefault(key string, defaultVal time.Duration) time.Duration {
+	if v := os.Getenv(key); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			return d
+		}
+	}
+	return defaultVal
+}
 
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusNoContent)
-			return
+// intEnvOrDefault reads an integer from an environment variable or returns a default value.
+func intEnvOrDefault(key string, defaultVal int) int {
+	if v := os.Getenv(key); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			return n
 		}
+	}
+	return defaultVal
+}
 
-		next.ServeHTTP(w, r)
-	})
+// jobRetentionInterval is how often expired jobs are evicted: a tenth of the TTL, between a
+// second and a minute.
+func jobRetentionInterval(ttl time.Duration) time.Duration {
+	return min(max(ttl/10, time.Second), time.Minute)
 }
 
 // responseWriter wraps http.ResponseWriter to capture status code.
@@ -124,7 +299,7 @@ func (rw *responseWriter) WriteHeader(code int) {
 }
 
 // metricsMiddleware tracks HTTP request metrics.
-func metricsMiddleware(collector *metrics.Collector) func(http.Handler) http.Handler {
+func metricsMiddleware(collector metrics.Collector) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			if collector == nil {
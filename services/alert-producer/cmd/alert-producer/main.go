@@ -5,21 +5,28 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"flag"
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"alert-producer/internal/autotune"
 	"alert-producer/internal/config"
 	"alert-producer/internal/generator"
 	"alert-producer/internal/processor"
 	"alert-producer/internal/producer"
+	"alert-producer/internal/replay"
 
 	"github.com/afikmenashe/alerting-platform/pkg/metrics"
+	kafkautil "github.com/afikmenashe/alerting-platform/pkg/kafka"
 	"github.com/afikmenashe/alerting-platform/pkg/shared"
+	_ "github.com/lib/pq"
 )
 
 func main() {
@@ -46,6 +53,25 @@ func main() {
 	flag.BoolVar(&testMode, "test", false, "Test mode: generate test alert (LOW/test-source/test-name) matching afik-test rule")
 	flag.BoolVar(&singleTestMode, "single-test", false, "Single test mode: send only one test alert (LOW/test-source/test-name) and exit")
 	flag.StringVar(&cfg.RedisAddr, "redis-addr", shared.GetEnvOrDefault("REDIS_ADDR", "localhost:6379"), "Redis server address for metrics")
+	flag.StringVar(&cfg.ClientHint, "client-hint", "", "Restrict matching of generated alerts to this client's rules (empty matches all clients)")
+	flag.BoolVar(&cfg.AutoTune, "auto-tune", false, "Ramp RPS up from -rps until pipeline latency exceeds -target-latency, then report the maximum sustainable RPS")
+	flag.StringVar(&cfg.MetricsURL, "metrics-url", shared.GetEnvOrDefault("METRICS_SERVICE_URL", "http://localhost:8083"), "metrics-service base URL, polled for pipeline latency in auto-tune mode")
+	flag.StringVar(&cfg.MetricsAPIKey, "metrics-api-key", shared.GetEnvOrDefault("METRICS_API_KEY", ""), "API key for metrics-service, if it runs with -api-key-auth")
+	flag.DurationVar(&cfg.TargetLatency, "target-latency", 2*time.Second, "Auto-tune: highest sustainable end-to-end latency (evaluated to delivered)")
+	flag.Float64Var(&cfg.TuneMaxRPS, "tune-max-rps", 5000, "Auto-tune: stop ramping above this RPS")
+	flag.Float64Var(&cfg.TuneStepFactor, "tune-step-factor", 1.5, "Auto-tune: RPS multiplier between steps")
+	flag.DurationVar(&cfg.TuneStepDuration, "tune-step-duration", 30*time.Second, "Auto-tune: how long each step publishes")
+	flag.DurationVar(&cfg.TuneSettle, "tune-settle", 10*time.Second, "Auto-tune: wait after each step for its alerts to be delivered before reading latency")
+	flag.StringVar(&cfg.Replay, "replay", "", "Replay historical alerts instead of generating them: 'postgres' (the notifications table) or an NDJSON alert log file ('-' for stdin)")
+	flag.StringVar(&cfg.PostgresDSN, "postgres-dsn", shared.GetEnvOrDefault("POSTGRES_DSN", ""), "PostgreSQL connection string, read by -replay postgres")
+	flag.Func("replay-since", "Replay: alerts from this time (RFC3339, required with -replay postgres)", timeFlag(&cfg.ReplaySince))
+	flag.Func("replay-until", "Replay: alerts before this time (RFC3339, default now)", timeFlag(&cfg.ReplayUntil))
+	flag.StringVar(&cfg.ReplayClientID, "replay-client", "", "Replay: only alerts notified to this client (empty for all)")
+	flag.IntVar(&cfg.ReplayLimit, "replay-limit", 100000, "Replay: at most this many alerts, the earliest first (0 = no limit)")
+	flag.Float64Var(&cfg.ReplaySpeed, "replay-speed", 1, "Replay: time compression factor (10 = ten times faster, 0 = as fast as possible)")
+	flag.DurationVar(&cfg.ReplayMaxGap, "replay-max-gap", 0, "Replay: cap the wait between two alerts after compression (0 = no cap)")
+	flag.BoolVar(&cfg.ReplayKeepIDs, "replay-keep-ids", false, "Replay: republish with the original alert_ids (the aggregator drops already-stored notifications)")
+	kafkaSecurity := kafkautil.RegisterSecurityFlags(flag.CommandLine)
 	flag.Parse()
 
 	slog.Info("Starting alert-producer",
@@ -61,6 +87,10 @@ func main() {
 		slog.Error("Invalid configuration", "error", err)
 		os.Exit(1)
 	}
+	if err := kafkautil.Configure(kafkaSecurity); err != nil {
+		slog.Error("Invalid Kafka security configuration", "error", err)
+		os.Exit(1)
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -75,7 +105,7 @@ func main() {
 	}()
 
 	// Initialize Redis client for metrics (optional - metrics disabled if Redis unavailable)
-	var metricsCollector *metrics.Collector
+	var metricsCollector metrics.Collector
 	if cfg.RedisAddr != "" {
 		slog.Info("Connecting to Redis for metrics", "addr", cfg.RedisAddr)
 		redisClient, err := shared.ConnectRedis(ctx, cfg.RedisAddr)
@@ -117,6 +147,7 @@ func main() {
 		"severity_dist", cfg.SeverityDist,
 		"source_dist", cfg.SourceDist,
 		"name_dist", cfg.NameDist,
+		"client_hint", cfg.ClientHint,
 	)
 
 	// Initialize processor (metrics collector may be nil, processor handles it)
@@ -160,6 +191,24 @@ func main() {
 		return
 	}
 
+	// Handle replay mode - republish historical alerts
+	if cfg.Replay != "" {
+		if err := runReplay(ctx, &cfg, alertPublisher); err != nil {
+			slog.Error("Replay failed", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Handle auto-tune mode - ramp RPS until the pipeline latency exceeds the target
+	if cfg.AutoTune {
+		if err := runAutoTune(ctx, &cfg, proc); err != nil {
+			slog.Error("Auto-tune failed", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Run normal processing mode
 	if err := proc.Process(ctx); err != nil {
 		slog.Error("Processing failed", "error", err)
@@ -169,3 +218,112 @@ func main() {
 	slog.Info("Alert producer completed successfully")
 }
 
+// runAutoTune ramps the alert rate until the pipeline latency exceeds the target and logs the
+// maximum sustainable throughput.
+func runAutoTune(ctx context.Context, cfg *config.Config, proc *processor.Processor) error {
+	slog.Info("Running in auto-tune mode",
+		"start_rps", cfg.RPS,
+		"max_rps", cfg.TuneMaxRPS,
+		"step_factor", cfg.TuneStepFactor,
+		"step_duration", cfg.TuneStepDuration,
+		"target_latency", cfg.TargetLatency,
+		"metrics_url", cfg.MetricsURL,
+	)
+
+	load := func(ctx context.Context, rps float64, duration time.Duration) (int, error) {
+		sent := 0
+		err := proc.ProcessContinuousWithProgress(ctx, rps, duration, func(n int) { sent = n })
+		return sent, err
+	}
+	tuner := autotune.NewTuner(autotune.Config{
+		StartRPS:      cfg.RPS,
+		MaxRPS:        cfg.TuneMaxRPS,
+		StepFactor:    cfg.TuneStepFactor,
+		StepDuration:  cfg.TuneStepDuration,
+		Settle:        cfg.TuneSettle,
+		TargetLatency: cfg.TargetLatency,
+	}, load, autotune.NewLatencyClient(cfg.MetricsURL, cfg.MetricsAPIKey))
+
+	result, err := tuner.Run(ctx)
+	if err != nil {
+		return err
+	}
+	slog.Info("Auto-tune completed",
+		"max_sustainable_rps", result.MaxSustainableRPS,
+		"target_latency_ms", result.TargetLatencyMs,
+		"stop_reason", result.StopReason,
+		"steps", result.Steps,
+	)
+	return nil
+}
+
+// timeFlag returns a flag parser that sets dst to an RFC3339 time.
+func timeFlag(dst *time.Time) func(string) error {
+	return func(value string) error {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return err
+		}
+		*dst = t
+		return nil
+	}
+}
+
+// runReplay loads the historical alerts selected by the replay flags and republishes them.
+func runReplay(ctx context.Context, cfg *config.Config, publisher producer.AlertPublisher) error {
+	filter := replay.Filter{
+		Since:    cfg.ReplaySince,
+		Until:    cfg.ReplayUntil,
+		ClientID: cfg.ReplayClientID,
+		Limit:    cfg.ReplayLimit,
+	}
+	records, err := loadReplayRecords(ctx, cfg, filter)
+	if err != nil {
+		return err
+	}
+	slog.Info("Running in replay mode",
+		"source", cfg.Replay,
+		"alerts", len(records),
+		"speed", cfg.ReplaySpeed,
+		"max_gap", cfg.ReplayMaxGap,
+		"client_hint", cfg.ClientHint,
+	)
+
+	replayer := replay.NewReplayer(replay.Options{
+		Speed:      cfg.ReplaySpeed,
+		MaxGap:     cfg.ReplayMaxGap,
+		ClientHint: cfg.ClientHint,
+		KeepIDs:    cfg.ReplayKeepIDs,
+	}, publisher)
+	result, err := replayer.Run(ctx, records)
+	slog.Info("Replay finished",
+		"published", result.Published,
+		"skipped", result.Skipped,
+		"original_span", result.Span,
+		"duration", result.Duration,
+	)
+	return err
+}
+
+// loadReplayRecords reads the historical alerts from the notifications table or an alert log.
+func loadReplayRecords(ctx context.Context, cfg *config.Config, filter replay.Filter) ([]replay.Record, error) {
+	if cfg.Replay == config.ReplayPostgres {
+		db, err := sql.Open("postgres", cfg.PostgresDSN)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open PostgreSQL: %w", err)
+		}
+		defer db.Close()
+		return replay.LoadPostgres(ctx, db, filter)
+	}
+
+	var r io.Reader = os.Stdin
+	if cfg.Replay != "-" {
+		f, err := os.Open(cfg.Replay)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	return replay.ReadFile(r, filter)
+}
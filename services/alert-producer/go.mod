@@ -3,14 +3,23 @@ module alert-producer
 go 1.23
 
 require (
+	github.com/DATA-DOG/go-sqlmock v1.5.2
+	github.com/afikmenashe/alerting-platform/pkg/ids v0.0.0
 	github.com/afikmenashe/alerting-platform/pkg/kafka v0.0.0
 	github.com/afikmenashe/alerting-platform/pkg/metrics v0.0.0
 	github.com/afikmenashe/alerting-platform/pkg/shared v0.0.0
-	github.com/google/uuid v1.6.0
+	github.com/lib/pq v1.10.9
 	github.com/redis/go-redis/v9 v9.7.3
 	github.com/segmentio/kafka-go v0.4.47
 )
 
+require (
+	github.com/xdg-go/pbkdf2 v1.0.0 // indirect
+	github.com/xdg-go/scram v1.1.2 // indirect
+	github.com/xdg-go/stringprep v1.0.4 // indirect
+	golang.org/x/text v0.13.0 // indirect
+)
+
 require (
 	github.com/afikmenashe/alerting-platform/pkg/proto v0.0.0
 	github.com/cespare/xxhash/v2 v2.3.0 // indirect
@@ -22,6 +31,8 @@ require (
 
 replace github.com/afikmenashe/alerting-platform/pkg/proto => ../../pkg/proto
 
+replace github.com/afikmenashe/alerting-platform/pkg/ids => ../../pkg/ids
+
 replace github.com/afikmenashe/alerting-platform/pkg/kafka => ../../pkg/kafka
 
 replace github.com/afikmenashe/alerting-platform/pkg/metrics => ../../pkg/metrics
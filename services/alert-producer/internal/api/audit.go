@@ -0,0 +1,94 @@
+// Package api provides HTTP API handlers and job management for alert-producer.
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"alert-producer/internal/audit"
+	"alert-producer/internal/auth"
+)
+
+// HandleAudit handles GET /api/v1/alerts/generate/audit?limit=
+// Returns who started and stopped which jobs, newest first. Admin only.
+func HandleAudit(auditLog *audit.Log) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		if !auth.FromContext(r.Context()).Admin {
+			respondError(w, http.StatusForbidden, "Audit trail requires an admin API key")
+			return
+		}
+
+		limit := 100
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				respondError(w, http.StatusBadRequest, "limit must be a positive integer")
+				return
+			}
+			limit = n
+		}
+
+		entries, err := auditLog.Recent(r.Context(), limit)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to read audit trail: %v", err))
+			return
+		}
+		if entries == nil {
+			entries = []audit.Entry{}
+		}
+		respondJSON(w, http.StatusOK, entries)
+	}
+}
+
+// recordAudit records action on job by principal. A nil auditLog records nothing.
+func recordAudit(r *http.Request, auditLog *audit.Log, principal auth.Principal, action string, job *Job) {
+	if auditLog == nil {
+		return
+	}
+	auditLog.Record(r.Context(), audit.Entry{
+		Actor:      principal.Name,
+		Action:     action,
+		JobID:      job.ID,
+		RemoteAddr: r.RemoteAddr,
+		Summary:    jobSummary(job.Config),
+	})
+}
+
+// jobSummary describes the load a job generates, e.g. "rps=100 duration=5m topic=alerts.new".
+func jobSummary(req *GenerateRequest) string {
+	if req == nil {
+		return ""
+	}
+	var parts []string
+	if req.SingleTest {
+		parts = append(parts, "single_test")
+	}
+	if req.RPS != nil {
+		parts = append(parts, fmt.Sprintf("rps=%g", *req.RPS))
+	}
+	if req.Duration != "" {
+		parts = append(parts, "duration="+req.Duration)
+	}
+	if req.BurstSize != nil {
+		parts = append(parts, fmt.Sprintf("burst=%d", *req.BurstSize))
+	}
+	if req.Count != nil {
+		parts = append(parts, fmt.Sprintf("count=%d", *req.Count))
+	}
+	if req.ClientID != "" {
+		parts = append(parts, "client_id="+req.ClientID)
+	}
+	if req.Topic != "" {
+		parts = append(parts, "topic="+req.Topic)
+	}
+	if req.Mock {
+		parts = append(parts, "mock")
+	}
+	return strings.Join(parts, " ")
+}
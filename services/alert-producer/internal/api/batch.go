@@ -0,0 +1,133 @@
+// Package api provides HTTP API handlers and job management for alert-producer.
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"alert-producer/internal/auth"
+	"alert-producer/internal/generator"
+	"alert-producer/internal/ingest"
+	"alert-producer/internal/producer"
+)
+
+// Per-item statuses of a batch request.
+const (
+	BatchItemAccepted = "accepted" // published to Kafka
+	BatchItemRejected = "rejected" // invalid, not published
+	BatchItemFailed   = "failed"   // valid, but publishing failed; retry it with the same alert_id
+)
+
+// BatchItemResult is the outcome of one alert of a batch request.
+type BatchItemResult struct {
+	Index   int    `json:"index"`
+	Status  string `json:"status"`
+	AlertID string `json:"alert_id,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// BatchResponse represents the response to a batch request, with a result per alert in request order.
+type BatchResponse struct {
+	Accepted int               `json:"accepted"`
+	Rejected int               `json:"rejected"`
+	Failed   int               `json:"failed"`
+	Results  []BatchItemResult `json:"results"`
+}
+
+// HandleBatch handles POST /api/v1/alerts/batch
+// The body is a JSON array of alerts, like /api/v1/alerts/ingest, but every alert is validated
+// on its own: invalid alerts are rejected and the valid ones are published to alerts.new with a
+// single batched produce. A nil publisher (Kafka unavailable at startup) rejects every batch.
+func HandleBatch(publisher producer.BatchPublisher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		if publisher == nil {
+			respondError(w, http.StatusServiceUnavailable, "Alert ingestion is unavailable: no Kafka producer")
+			return
+		}
+
+		alerts, ok := decodeAlerts(w, r)
+		if !ok {
+			return
+		}
+		if len(alerts) == 0 {
+			respondError(w, http.StatusBadRequest, "Request contains no alerts")
+			return
+		}
+		if len(alerts) > ingest.MaxBatchSize {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("Batch of %d alerts exceeds the maximum of %d", len(alerts), ingest.MaxBatchSize))
+			return
+		}
+
+		now := time.Now()
+		resp := BatchResponse{Results: make([]BatchItemResult, len(alerts))}
+		for i := range resp.Results {
+			resp.Results[i] = BatchItemResult{Index: i, Status: BatchItemAccepted}
+		}
+		for _, fe := range ingest.Validate(alerts, now) {
+			resp.Results[fe.Index].Status = BatchItemRejected
+			resp.Results[fe.Index].Error = fe.Error
+			resp.Rejected++
+		}
+
+		// Publish the valid alerts, remembering their position in the request
+		valid := make([]*generator.Alert, 0, len(alerts)-resp.Rejected)
+		positions := make([]int, 0, cap(valid))
+		for i, a := range alerts {
+			if resp.Results[i].Status == BatchItemAccepted {
+				alert := ingest.ToAlert(a, now)
+				resp.Results[i].AlertID = alert.AlertID
+				valid = append(valid, alert)
+				positions = append(positions, i)
+			}
+		}
+		if len(valid) == 0 {
+			respondJSON(w, http.StatusBadRequest, resp)
+			return
+		}
+
+		principal := auth.FromContext(r.Context())
+		if err := publisher.PublishBatch(r.Context(), valid); err != nil {
+			var batchErr *producer.BatchError
+			if !errors.As(err, &batchErr) || len(batchErr.Errors) != len(valid) {
+				batchErr = &producer.BatchError{Errors: make([]error, len(valid))}
+				for i := range batchErr.Errors {
+					batchErr.Errors[i] = err
+				}
+			}
+			for i, pubErr := range batchErr.Errors {
+				if pubErr != nil {
+					item := &resp.Results[positions[i]]
+					item.Status = BatchItemFailed
+					item.Error = pubErr.Error()
+					resp.Failed++
+				}
+			}
+			slog.Error("Failed to publish alert batch",
+				"caller", principal.Name,
+				"batch_size", len(alerts),
+				"failed", resp.Failed,
+				"error", err,
+			)
+		}
+		resp.Accepted = len(alerts) - resp.Rejected - resp.Failed
+
+		slog.Info("Ingested alert batch",
+			"caller", principal.Name,
+			"accepted", resp.Accepted,
+			"rejected", resp.Rejected,
+			"failed", resp.Failed,
+		)
+		status := http.StatusAccepted
+		if resp.Failed > 0 {
+			status = http.StatusServiceUnavailable
+		}
+		respondJSON(w, status, resp)
+	}
+}
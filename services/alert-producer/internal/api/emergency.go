@@ -0,0 +1,62 @@
+// Package api provides HTTP API handlers and job management for alert-producer.
+package api
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// StopSignal reports the platform-wide emergency stop.
+type StopSignal interface {
+	Current() shared.EmergencyStop
+}
+
+// WithStopSignal makes the job manager halt generation while an emergency stop with
+// stop_jobs is active: new jobs are rejected and running jobs are cancelled by EnforceEmergencyStop.
+func (jm *JobManager) WithStopSignal(stop StopSignal) *JobManager {
+	jm.stop = stop
+	return jm
+}
+
+// jobsHalted returns the active emergency stop if it halts generation jobs.
+func (jm *JobManager) jobsHalted() (shared.EmergencyStop, bool) {
+	if jm.stop == nil {
+		return shared.EmergencyStop{}, false
+	}
+	stop := jm.stop.Current()
+	return stop, stop.Active && stop.StopJobs
+}
+
+// EnforceEmergencyStop cancels pending and running jobs every interval while a job-halting
+// emergency stop is active, until ctx is cancelled.
+func (jm *JobManager) EnforceEmergencyStop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if stop, halted := jm.jobsHalted(); halted {
+				jm.cancelActive(stop)
+			}
+		}
+	}
+}
+
+// cancelActive cancels all pending and running jobs, recording the stop reason as their error.
+func (jm *JobManager) cancelActive(stop shared.EmergencyStop) {
+	for _, job := range jm.ListJobs("", "") {
+		status := job.GetStatus()
+		if status != JobStatusPending && status != JobStatusRunning {
+			continue
+		}
+		job.SetError(fmt.Errorf("halted by emergency stop: %s", stop.Reason))
+		job.Cancel()
+		slog.Warn("Cancelled job for emergency stop", "job_id", job.ID, "owner", job.Owner, "reason", stop.Reason)
+	}
+}
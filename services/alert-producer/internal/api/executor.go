@@ -4,21 +4,29 @@ package api
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
+	"sync"
 	"time"
 
+	"alert-producer/internal/audit"
 	"alert-producer/internal/config"
 	"alert-producer/internal/generator"
 	"alert-producer/internal/processor"
 	"alert-producer/internal/producer"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
 )
 
-// RunJob executes a job in a goroutine.
+// RunJob executes a job in a goroutine. Cancelling the job stops it between alerts: once it
+// has finished, its producer is flushed and closed, the exact number of alerts sent recorded,
+// and its summary written to the audit log before Done is closed.
 func (jm *JobManager) RunJob(job *Job, kafkaBrokers string) {
 	ctx, cancel := context.WithCancel(context.Background())
 	job.SetCancelFunc(cancel)
 
 	go func() {
+		defer jm.finish(job)
 		defer cancel()
 
 		cfg, err := job.Config.ToConfig(kafkaBrokers)
@@ -27,7 +35,9 @@ func (jm *JobManager) RunJob(job *Job, kafkaBrokers string) {
 			return
 		}
 
-		if err := validateConfig(&cfg, job.Config.SingleTest); err != nil {
+		v := shared.NewValidator()
+		validateConfig(v, &cfg, job.Config.SingleTest)
+		if err := v.Err(); err != nil {
 			job.fail(err)
 			return
 		}
@@ -37,14 +47,82 @@ func (jm *JobManager) RunJob(job *Job, kafkaBrokers string) {
 			job.fail(err)
 			return
 		}
-		defer alertPublisher.Close()
+		pub := &jobPublisher{publisher: alertPublisher}
 
 		job.UpdateStatus(JobStatusRunning)
-		runErr := job.execute(ctx, alertPublisher, &cfg)
+		runErr := job.execute(ctx, pub, &cfg)
+
+		// Flush before reporting, so the count and status are final
+		sent, closeErr := pub.close()
+		job.SetAlertsSent(sent)
+		if runErr == nil && closeErr != nil {
+			runErr = fmt.Errorf("failed to flush producer: %w", closeErr)
+		}
 		job.finalize(ctx, runErr)
 	}()
 }
 
+// finish records the summary of a finished job and marks it done.
+func (jm *JobManager) finish(job *Job) {
+	sent := job.GetAlertsSent()
+	status := job.GetStatus()
+	slog.Info("Job finished", "job_id", job.ID, "status", status, "alerts_sent", sent)
+	if jm.audit != nil {
+		jm.audit.Record(context.Background(), audit.Entry{
+			Actor:      job.Owner,
+			Action:     audit.ActionJobFinished,
+			JobID:      job.ID,
+			Summary:    jobSummary(job.Config),
+			Status:     string(status),
+			AlertsSent: &sent,
+		})
+	}
+	job.touch()
+	close(job.done)
+}
+
+// jobPublisher publishes a job's alerts and counts those published. Once the job's context is
+// cancelled no new publish starts; a publish already in progress is not cancelled with it, so it
+// either completes and is counted or fails on its own, and the count is exact.
+type jobPublisher struct {
+	publisher producer.AlertPublisher
+
+	mu     sync.Mutex
+	sent   int64
+	closed bool
+}
+
+// Publish publishes alert unless the job was cancelled or its publisher closed.
+func (p *jobPublisher) Publish(ctx context.Context, alert *generator.Alert) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return context.Canceled
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := p.publisher.Publish(context.WithoutCancel(ctx), alert); err != nil {
+		return err
+	}
+	p.sent++
+	return nil
+}
+
+// Close is a no-op: the job closes its publisher with close once it has finished.
+func (p *jobPublisher) Close() error {
+	return nil
+}
+
+// close flushes and closes the underlying publisher, refusing any later publish, and returns
+// the number of alerts published.
+func (p *jobPublisher) close() (int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	return p.sent, p.publisher.Close()
+}
+
 // createPublisher initializes the appropriate alert publisher.
 func createPublisher(mock bool, cfg config.Config) (producer.AlertPublisher, error) {
 	if mock {
@@ -102,7 +180,12 @@ func (j *Job) sendCustomAlerts(ctx context.Context, pub producer.AlertPublisher,
 	}
 
 	for i := 0; i < count && ctx.Err() == nil; i++ {
-		if err := pub.Publish(ctx, generator.GenerateCustomAlert(severity, source, name)); err != nil {
+		alert := generator.GenerateCustomAlert(severity, source, name)
+		alert.ClientHint = j.Config.ClientID
+		if err := pub.Publish(ctx, alert); err != nil {
+			if errors.Is(err, context.Canceled) {
+				return context.Canceled
+			}
 			return err
 		}
 		j.IncrementAlertsSent()
@@ -5,16 +5,27 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+
+	"alert-producer/internal/audit"
+	"alert-producer/internal/auth"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
 )
 
 // HandleGenerate handles POST /api/v1/alerts/generate
-func HandleGenerate(jm *JobManager, defaultKafkaBrokers string) http.HandlerFunc {
+// The job is owned by the caller and its start is recorded in auditLog.
+func HandleGenerate(jm *JobManager, defaultKafkaBrokers string, auditLog *audit.Log) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
 			return
 		}
 
+		if stop, halted := jm.jobsHalted(); halted {
+			respondError(w, http.StatusServiceUnavailable, fmt.Sprintf("Alert generation halted by emergency stop: %s", stop.Reason))
+			return
+		}
+
 		var req GenerateRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			respondError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
@@ -22,37 +33,21 @@ func HandleGenerate(jm *JobManager, defaultKafkaBrokers string) http.HandlerFunc
 		}
 
 		// Validate configuration before creating job
+		v := shared.NewValidator()
 		cfg, err := req.ToConfig(defaultKafkaBrokers)
 		if err != nil {
-			respondError(w, http.StatusBadRequest, fmt.Sprintf("Invalid configuration: %v", err))
-			return
+			v.Add("duration", err.Error())
 		}
-		
-		// For single_test mode, skip distribution validation (not needed for custom single alert)
-		// For other modes, validate everything including distributions
-		if err := validateConfig(&cfg, req.SingleTest); err != nil {
-			respondError(w, http.StatusBadRequest, fmt.Sprintf("Configuration validation failed: %v", err))
+		req.validate(v, &cfg)
+		if err := v.Err(); err != nil {
+			shared.WriteValidationError(w, err)
 			return
 		}
-		
-		// Validate single alert properties if single_test is enabled
-		if req.SingleTest {
-			if req.Severity == "" && req.Source == "" && req.Name == "" {
-				// All empty - use defaults, this is fine
-			} else {
-				// At least one is provided, validate severity if provided
-				if req.Severity != "" {
-					validSeverities := map[string]bool{"LOW": true, "MEDIUM": true, "HIGH": true, "CRITICAL": true}
-					if !validSeverities[req.Severity] {
-						respondError(w, http.StatusBadRequest, fmt.Sprintf("Invalid severity: %s (must be LOW, MEDIUM, HIGH, or CRITICAL)", req.Severity))
-						return
-					}
-				}
-			}
-		}
 
-		// Create job
-		job := jm.CreateJob(&req)
+		// Create job owned by the caller
+		principal := auth.FromContext(r.Context())
+		job := jm.CreateJob(&req, principal.Name)
+		recordAudit(r, auditLog, principal, audit.ActionJobStarted, job)
 
 		// Start job
 		jm.RunJob(job, defaultKafkaBrokers)
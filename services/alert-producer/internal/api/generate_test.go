@@ -0,0 +1,57 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+func TestHandleGenerate_InvalidRequest(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantFields []string
+	}{
+		{
+			name:       "invalid rate, duration and distribution",
+			body:       `{"rps": 0, "duration": "soon", "severity_dist": "HIGH:many"}`,
+			wantFields: []string{"duration", "rps", "severity_dist"},
+		},
+		{
+			name:       "single test with unknown severity",
+			body:       `{"rps": 0, "single_test": true, "severity": "SEVERE"}`,
+			wantFields: []string{"severity"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jm := NewJobManager()
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/alerts/generate", strings.NewReader(tt.body))
+			HandleGenerate(jm, "localhost:9092", nil)(w, r)
+
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400", w.Code)
+			}
+			var resp shared.ValidationError
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			var fields []string
+			for _, fe := range resp.Errors {
+				fields = append(fields, fe.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("fields = %v, want %v (errors %+v)", fields, tt.wantFields, resp.Errors)
+			}
+			if len(jm.ListJobs("", "")) != 0 {
+				t.Error("job created for an invalid request")
+			}
+		})
+	}
+}
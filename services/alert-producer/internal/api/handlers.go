@@ -2,8 +2,9 @@
 // This file is kept for backward compatibility but handlers have been split into:
 // - types.go: Request/Response types
 // - generate.go: HandleGenerate
-// - job_handlers.go: HandleGetJob, HandleListJobs, HandleStopJob
+// - job_handlers.go: HandleGetJob, HandleListJobs, HandleJobHistory, HandleStopJob
 // - health.go: HandleHealth
+// - audit.go: HandleAudit
 // - helpers.go: Response helpers and validation
 package api
 
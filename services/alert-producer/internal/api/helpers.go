@@ -3,11 +3,12 @@ package api
 
 import (
 	"encoding/json"
-	"fmt"
 	"log/slog"
 	"net/http"
 
 	"alert-producer/internal/config"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
 )
 
 // jobToResponse converts a Job to a JobResponse.
@@ -17,6 +18,7 @@ func jobToResponse(job *Job) JobResponse {
 
 	return JobResponse{
 		ID:          job.ID,
+		Owner:       job.Owner,
 		Status:      string(job.Status),
 		Config:      job.Config,
 		CreatedAt:   job.CreatedAt,
@@ -41,39 +43,34 @@ func respondError(w http.ResponseWriter, statusCode int, message string) {
 	respondJSON(w, statusCode, ErrorResponse{Error: message})
 }
 
-// validateConfig validates the configuration, optionally skipping distribution validation.
-// When skipDistributions is true (single_test mode), also skips RPS/duration validation.
-func validateConfig(cfg *config.Config, skipDistributions bool) error {
-	if cfg.KafkaBrokers == "" {
-		return fmt.Errorf("kafka-brokers cannot be empty")
-	}
-	if cfg.Topic == "" {
-		return fmt.Errorf("topic cannot be empty")
+// validateConfig checks the configuration of a job, recording the invalid request fields in v.
+// When skipDistributions is true (single_test mode), RPS, duration and the distributions are
+// not checked, as a single alert needs none of them.
+func validateConfig(v *shared.Validator, cfg *config.Config, skipDistributions bool) {
+	v.Check(cfg.KafkaBrokers != "", "kafka_brokers", "kafka_brokers cannot be empty")
+	v.Check(cfg.Topic != "", "topic", "topic cannot be empty")
+	if skipDistributions {
+		return
 	}
-	
-	// For single_test mode, RPS/duration/burst are not needed (just sends one alert)
-	if !skipDistributions {
-		if cfg.RPS <= 0 && cfg.BurstSize <= 0 {
-			return fmt.Errorf("rps must be > 0 or burst must be > 0")
-		}
-		if cfg.BurstSize == 0 && cfg.Duration <= 0 {
-			return fmt.Errorf("duration must be > 0 when not in burst mode")
+
+	v.Check(cfg.RPS > 0 || cfg.BurstSize > 0, "rps", "rps must be > 0 or burst must be > 0")
+	v.Check(cfg.BurstSize != 0 || cfg.Duration > 0, "duration", "duration must be > 0 when not in burst mode")
+	for _, dist := range []struct{ field, value string }{
+		{"severity_dist", cfg.SeverityDist},
+		{"source_dist", cfg.SourceDist},
+		{"name_dist", cfg.NameDist},
+	} {
+		if _, err := config.ParseDistribution(dist.value); err != nil {
+			v.Addf(dist.field, "invalid %s: %v", dist.field, err)
 		}
 	}
-	
-	// Skip distribution validation for single_test mode (not needed)
-	if !skipDistributions {
-		// Validate distribution strings
-		if _, err := config.ParseDistribution(cfg.SeverityDist); err != nil {
-			return fmt.Errorf("invalid severity-dist: %w", err)
-		}
-		if _, err := config.ParseDistribution(cfg.SourceDist); err != nil {
-			return fmt.Errorf("invalid source-dist: %w", err)
-		}
-		if _, err := config.ParseDistribution(cfg.NameDist); err != nil {
-			return fmt.Errorf("invalid name-dist: %w", err)
-		}
+}
+
+// validate checks a generate request and the configuration built from it.
+func (req *GenerateRequest) validate(v *shared.Validator, cfg *config.Config) {
+	validateConfig(v, cfg, req.SingleTest)
+	// A single test alert uses defaults for whatever it leaves empty
+	if req.SingleTest && req.Severity != "" {
+		v.OneOf("severity", req.Severity, "LOW", "MEDIUM", "HIGH", "CRITICAL")
 	}
-	
-	return nil
 }
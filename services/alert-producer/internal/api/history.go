@@ -0,0 +1,105 @@
+// Package api provides HTTP API handlers and job management for alert-producer.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Job history defaults.
+const (
+	// JobHistoryKey is the Redis list holding the summaries of evicted jobs, newest first.
+	JobHistoryKey = "alert-producer:job-history"
+	// DefaultJobHistoryEntries is how many job summaries are retained.
+	DefaultJobHistoryEntries = 1000
+)
+
+// JobHistory keeps the final summaries of the jobs evicted from the JobManager, in a bounded
+// Redis list (or in memory when Redis is not configured), so they can still be looked up
+// once they are no longer held in memory. It is safe for concurrent use.
+type JobHistory struct {
+	redis      *redis.Client
+	maxEntries int
+
+	mu     sync.Mutex
+	memory []JobResponse // newest first; used when redis is nil
+}
+
+// NewJobHistory creates a job history stored in redisClient, or in memory if redisClient is
+// nil. A non-positive maxEntries uses DefaultJobHistoryEntries.
+func NewJobHistory(redisClient *redis.Client, maxEntries int) *JobHistory {
+	if maxEntries <= 0 {
+		maxEntries = DefaultJobHistoryEntries
+	}
+	return &JobHistory{redis: redisClient, maxEntries: maxEntries}
+}
+
+// Record prepends summary to the history. Storage errors are logged, never returned, so
+// eviction cannot fail.
+func (h *JobHistory) Record(ctx context.Context, summary JobResponse) {
+	if h.redis == nil {
+		h.mu.Lock()
+		h.memory = append([]JobResponse{summary}, h.memory...)
+		if len(h.memory) > h.maxEntries {
+			h.memory = h.memory[:h.maxEntries]
+		}
+		h.mu.Unlock()
+		return
+	}
+
+	data, err := json.Marshal(summary)
+	if err != nil {
+		slog.Error("Failed to encode job summary", "job_id", summary.ID, "error", err)
+		return
+	}
+	_, err = h.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LPush(ctx, JobHistoryKey, data)
+		pipe.LTrim(ctx, JobHistoryKey, 0, int64(h.maxEntries-1))
+		return nil
+	})
+	if err != nil {
+		slog.Error("Failed to store job summary", "job_id", summary.ID, "error", err)
+	}
+}
+
+// Recent returns the retained summaries, newest first.
+func (h *JobHistory) Recent(ctx context.Context) ([]JobResponse, error) {
+	if h.redis == nil {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		return append([]JobResponse(nil), h.memory...), nil
+	}
+
+	values, err := h.redis.LRange(ctx, JobHistoryKey, 0, int64(h.maxEntries-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+	summaries := make([]JobResponse, 0, len(values))
+	for _, value := range values {
+		var summary JobResponse
+		if err := json.Unmarshal([]byte(value), &summary); err != nil {
+			slog.Warn("Skipping malformed job summary", "error", err)
+			continue
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+// Get returns the summary of the job with the given ID, if it is retained.
+func (h *JobHistory) Get(ctx context.Context, id string) (JobResponse, bool, error) {
+	summaries, err := h.Recent(ctx)
+	if err != nil {
+		return JobResponse{}, false, err
+	}
+	for _, summary := range summaries {
+		if summary.ID == id {
+			return summary, true, nil
+		}
+	}
+	return JobResponse{}, false, nil
+}
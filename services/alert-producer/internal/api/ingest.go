@@ -0,0 +1,112 @@
+// Package api provides HTTP API handlers and job management for alert-producer.
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"alert-producer/internal/auth"
+	"alert-producer/internal/ingest"
+	"alert-producer/internal/producer"
+)
+
+// IngestResponse represents the response to an ingest request.
+// On a publish failure, AlertIDs lists the alerts published before it, in request order.
+type IngestResponse struct {
+	Accepted int                 `json:"accepted"`
+	AlertIDs []string            `json:"alert_ids"`
+	Error    string              `json:"error,omitempty"`
+	Errors   []ingest.FieldError `json:"errors,omitempty"`
+}
+
+// decodeAlerts reads the alerts of an ingestion request, as JSON or msgpack (Content-Type) and
+// optionally gzipped (Content-Encoding). It writes an error response and returns false if it cannot.
+func decodeAlerts(w http.ResponseWriter, r *http.Request) ([]ingest.Alert, bool) {
+	body := http.MaxBytesReader(w, r.Body, ingest.MaxBodyBytes)
+	alerts, err := ingest.DecodeBatch(body, r.Header.Get("Content-Type"), r.Header.Get("Content-Encoding"))
+	if err == nil {
+		return alerts, true
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, ingest.ErrUnsupportedEncoding):
+		respondError(w, http.StatusUnsupportedMediaType, err.Error())
+	case errors.Is(err, ingest.ErrBodyTooLarge), errors.As(err, &maxBytesErr):
+		respondError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body exceeds %d bytes (after decompression)", ingest.MaxBodyBytes))
+	default:
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body (want a JSON or msgpack array of alerts): %v", err))
+	}
+	return nil, false
+}
+
+// HandleIngest handles POST /api/v1/alerts/ingest
+// The body is a JSON array of alerts. The batch is validated as a whole: if any alert is invalid,
+// nothing is published and every invalid alert is reported. Valid batches are published to
+// alerts.new in order. A nil publisher (Kafka unavailable at startup) rejects every batch.
+func HandleIngest(publisher producer.AlertPublisher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		if publisher == nil {
+			respondError(w, http.StatusServiceUnavailable, "Alert ingestion is unavailable: no Kafka producer")
+			return
+		}
+
+		alerts, ok := decodeAlerts(w, r)
+		if !ok {
+			return
+		}
+		if len(alerts) == 0 {
+			respondError(w, http.StatusBadRequest, "Request contains no alerts")
+			return
+		}
+		if len(alerts) > ingest.MaxBatchSize {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("Batch of %d alerts exceeds the maximum of %d", len(alerts), ingest.MaxBatchSize))
+			return
+		}
+
+		now := time.Now()
+		if errs := ingest.Validate(alerts, now); len(errs) > 0 {
+			respondJSON(w, http.StatusBadRequest, IngestResponse{
+				AlertIDs: []string{},
+				Error:    fmt.Sprintf("%d of %d alerts are invalid, nothing was published", len(errs), len(alerts)),
+				Errors:   errs,
+			})
+			return
+		}
+
+		principal := auth.FromContext(r.Context())
+		published := make([]string, 0, len(alerts))
+		for _, a := range alerts {
+			alert := ingest.ToAlert(a, now)
+			if err := publisher.Publish(r.Context(), alert); err != nil {
+				slog.Error("Failed to publish ingested alert",
+					"caller", principal.Name,
+					"alert_id", alert.AlertID,
+					"published", len(published),
+					"batch_size", len(alerts),
+					"error", err,
+				)
+				respondJSON(w, http.StatusServiceUnavailable, IngestResponse{
+					Accepted: len(published),
+					AlertIDs: published,
+					Error:    fmt.Sprintf("Failed to publish alert %d: %v", len(published), err),
+				})
+				return
+			}
+			published = append(published, alert.AlertID)
+		}
+
+		slog.Info("Ingested alerts", "caller", principal.Name, "count", len(published))
+		respondJSON(w, http.StatusAccepted, IngestResponse{
+			Accepted: len(published),
+			AlertIDs: published,
+		})
+	}
+}
@@ -3,10 +3,13 @@ package api
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"time"
 
-	"github.com/google/uuid"
+	"alert-producer/internal/audit"
+
+	"github.com/afikmenashe/alerting-platform/pkg/ids"
 )
 
 // JobStatus represents the status of an alert generation job.
@@ -23,6 +26,7 @@ const (
 // Job represents a single alert generation job.
 type Job struct {
 	ID          string             `json:"id"`
+	Owner       string             `json:"owner"`
 	Status      JobStatus          `json:"status"`
 	Config      *GenerateRequest   `json:"config"`
 	CreatedAt   time.Time          `json:"created_at"`
@@ -31,6 +35,8 @@ type Job struct {
 	AlertsSent  int64              `json:"alerts_sent"`
 	Error       string             `json:"error,omitempty"`
 	cancelFunc  context.CancelFunc `json:"-"`
+	accessedAt  time.Time          `json:"-"` // last creation, lookup or finish; orders evictions over the max-jobs cap
+	done        chan struct{}      `json:"-"` // closed once the job has finished and its producer is flushed
 	mu          sync.RWMutex       `json:"-"`
 }
 
@@ -38,6 +44,16 @@ type Job struct {
 type JobManager struct {
 	jobs map[string]*Job
 	mu   sync.RWMutex
+	stop StopSignal // halts jobs during an emergency stop; nil disables the check
+
+	audit *audit.Log // receives the summary of every finished job; nil disables it
+
+	ttl     time.Duration // finished jobs are evicted this long after they end; 0 keeps them
+	maxJobs int           // least recently used finished jobs are evicted above this many; 0 is unlimited
+	history *JobHistory   // keeps the summaries of evicted jobs; nil discards them
+
+	schedules    ScheduleStore // recurring jobs; nil disables scheduling
+	kafkaBrokers string        // default brokers of scheduled jobs
 }
 
 // NewJobManager creates a new job manager.
@@ -47,37 +63,58 @@ func NewJobManager() *JobManager {
 	}
 }
 
-// CreateJob creates a new job and returns its ID.
-func (jm *JobManager) CreateJob(req *GenerateRequest) *Job {
-	jm.mu.Lock()
-	defer jm.mu.Unlock()
+// WithAuditLog records a job_finished entry with the final status and exact alert count of
+// every job when it ends. Passing nil disables it.
+func (jm *JobManager) WithAuditLog(auditLog *audit.Log) *JobManager {
+	jm.audit = auditLog
+	return jm
+}
 
+// CreateJob creates a new job owned by owner and returns it.
+// Creating a job beyond the max-jobs cap evicts the least recently used finished jobs.
+func (jm *JobManager) CreateJob(req *GenerateRequest, owner string) *Job {
+	now := time.Now()
 	job := &Job{
-		ID:        generateJobID(),
-		Status:    JobStatusPending,
-		Config:    req,
-		CreatedAt: time.Now(),
+		ID:         generateJobID(),
+		Owner:      owner,
+		Status:     JobStatusPending,
+		Config:     req,
+		CreatedAt:  now,
+		accessedAt: now,
+		done:       make(chan struct{}),
 	}
 
+	jm.mu.Lock()
 	jm.jobs[job.ID] = job
+	evicted := jm.evictOverCapLocked()
+	jm.mu.Unlock()
+
+	jm.archive(evicted)
 	return job
 }
 
-// GetJob retrieves a job by ID.
+// GetJob retrieves a job by ID. Jobs evicted from memory are not returned; see EvictedJob.
 func (jm *JobManager) GetJob(id string) (*Job, bool) {
 	jm.mu.RLock()
 	defer jm.mu.RUnlock()
 	job, ok := jm.jobs[id]
+	if ok {
+		job.touch()
+	}
 	return job, ok
 }
 
-// ListJobs returns all jobs, optionally filtered by status.
-func (jm *JobManager) ListJobs(statusFilter JobStatus) []*Job {
+// ListJobs returns all jobs, optionally filtered by status and owner.
+// An empty owner returns the jobs of all owners.
+func (jm *JobManager) ListJobs(statusFilter JobStatus, owner string) []*Job {
 	jm.mu.RLock()
 	defer jm.mu.RUnlock()
 
 	var jobs []*Job
 	for _, job := range jm.jobs {
+		if owner != "" && job.Owner != owner {
+			continue
+		}
 		if statusFilter == "" || job.GetStatus() == statusFilter {
 			jobs = append(jobs, job)
 		}
@@ -159,7 +196,25 @@ func (j *Job) Cancel() {
 	// Don't update status here - let the goroutine handle it when it detects cancellation
 }
 
-// generateJobID generates a unique job ID using UUID.
+// Done returns a channel that is closed once the job has finished: no alert is published after
+// it is closed, and AlertsSent is final.
+func (j *Job) Done() <-chan struct{} {
+	return j.done
+}
+
+// Stop cancels the job and waits until it has finished, so no alert is published after Stop
+// returns nil. A publish in progress completes and is counted. Returns an error if ctx ends first.
+func (j *Job) Stop(ctx context.Context) error {
+	j.Cancel()
+	select {
+	case <-j.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("job %s did not stop: %w", j.ID, ctx.Err())
+	}
+}
+
+// generateJobID generates a unique, time-ordered job ID.
 func generateJobID() string {
-	return uuid.New().String()
+	return ids.New()
 }
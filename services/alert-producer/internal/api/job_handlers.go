@@ -2,12 +2,19 @@
 package api
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"strconv"
 	"time"
+
+	"alert-producer/internal/audit"
+	"alert-producer/internal/auth"
 )
 
 // HandleGetJob handles GET /api/v1/alerts/generate/:jobId
+// Non-admin callers can only see their own jobs. Evicted jobs are served from the job history.
 func HandleGetJob(jm *JobManager) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
@@ -21,17 +28,17 @@ func HandleGetJob(jm *JobManager) http.HandlerFunc {
 			return
 		}
 
-		job, ok := jm.GetJob(jobID)
+		_, summary, ok := getOwnedJob(w, r, jm, jobID)
 		if !ok {
-			respondError(w, http.StatusNotFound, "Job not found")
 			return
 		}
 
-		respondJSON(w, http.StatusOK, jobToResponse(job))
+		respondJSON(w, http.StatusOK, summary)
 	}
 }
 
 // HandleListJobs handles GET /api/v1/alerts/generate
+// Admins see all jobs (optionally filtered by ?owner=); other callers see only their own.
 func HandleListJobs(jm *JobManager) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
@@ -40,7 +47,12 @@ func HandleListJobs(jm *JobManager) http.HandlerFunc {
 		}
 
 		statusFilter := JobStatus(r.URL.Query().Get("status"))
-		jobs := jm.ListJobs(statusFilter)
+		principal := auth.FromContext(r.Context())
+		owner := principal.Name
+		if principal.Admin {
+			owner = r.URL.Query().Get("owner")
+		}
+		jobs := jm.ListJobs(statusFilter, owner)
 
 		responses := make([]JobResponse, len(jobs))
 		for i, job := range jobs {
@@ -51,8 +63,57 @@ func HandleListJobs(jm *JobManager) http.HandlerFunc {
 	}
 }
 
+// HandleJobHistory handles GET /api/v1/alerts/generate/history
+// Returns the summaries of the jobs evicted from memory, newest first. Like HandleListJobs,
+// admins see all jobs (optionally filtered by ?owner=); other callers see only their own.
+func HandleJobHistory(jm *JobManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		limit := 100
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				respondError(w, http.StatusBadRequest, "limit must be a positive integer")
+				return
+			}
+			limit = n
+		}
+
+		statusFilter := JobStatus(r.URL.Query().Get("status"))
+		principal := auth.FromContext(r.Context())
+		owner := principal.Name
+		if principal.Admin {
+			owner = r.URL.Query().Get("owner")
+		}
+		summaries, err := jm.JobHistory(r.Context(), statusFilter, owner)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to read job history: %v", err))
+			return
+		}
+		if len(summaries) > limit {
+			summaries = summaries[:limit]
+		}
+		if summaries == nil {
+			summaries = []JobResponse{}
+		}
+		respondJSON(w, http.StatusOK, summaries)
+	}
+}
+
+// jobStopTimeout bounds how long a stop request waits for the job to finish its last publish
+// and flush its producer. It exceeds the Kafka write timeout, so it is only reached if the
+// producer hangs.
+const jobStopTimeout = 15 * time.Second
+
 // HandleStopJob handles POST /api/v1/alerts/generate/:jobId/stop
-func HandleStopJob(jm *JobManager) http.HandlerFunc {
+// Non-admin callers can only stop their own jobs. Stops are recorded in auditLog.
+// The response is sent once the job has finished, so no alert is published after it and the
+// reported alerts_sent is exact.
+func HandleStopJob(jm *JobManager, auditLog *audit.Log) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
@@ -65,27 +126,54 @@ func HandleStopJob(jm *JobManager) http.HandlerFunc {
 			return
 		}
 
-		job, ok := jm.GetJob(jobID)
+		job, summary, ok := getOwnedJob(w, r, jm, jobID)
 		if !ok {
-			respondError(w, http.StatusNotFound, "Job not found")
 			return
 		}
 
-		// Check if job can be cancelled
-		currentStatus := job.GetStatus()
-		if currentStatus != JobStatusPending && currentStatus != JobStatusRunning {
-			respondError(w, http.StatusBadRequest, fmt.Sprintf("Job cannot be cancelled. Current status: %s", currentStatus))
+		// Check if job can be cancelled (evicted jobs have finished)
+		if job == nil || (job.GetStatus() != JobStatusPending && job.GetStatus() != JobStatusRunning) {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("Job cannot be cancelled. Current status: %s", summary.Status))
 			return
 		}
 
-		// Cancel the job (this cancels the context, goroutine will update status)
-		job.Cancel()
+		// Cancel the job and wait for it to flush and record its final status
+		recordAudit(r, auditLog, auth.FromContext(r.Context()), audit.ActionJobStopped, job)
+		ctx, cancel := context.WithTimeout(r.Context(), jobStopTimeout)
+		defer cancel()
+		if err := job.Stop(ctx); err != nil {
+			slog.Error("Job did not stop in time", "job_id", job.ID, "error", err)
+			respondError(w, http.StatusGatewayTimeout, fmt.Sprintf("Job did not stop within %s; it publishes no new alerts", jobStopTimeout))
+			return
+		}
 
-		// Wait a moment for the goroutine to detect cancellation and update status
-		time.Sleep(100 * time.Millisecond)
+		respondJSON(w, http.StatusOK, jobToResponse(job))
+	}
+}
 
-		// Get updated job status
-		updatedJob, _ := jm.GetJob(jobID)
-		respondJSON(w, http.StatusOK, jobToResponse(updatedJob))
+// getOwnedJob looks up jobID and checks that the caller may access it, returning the job and
+// its summary. A job evicted from memory is returned with a nil *Job and its summary from the
+// job history. It writes a 404, 403 or 500 response and returns false otherwise.
+func getOwnedJob(w http.ResponseWriter, r *http.Request, jm *JobManager, jobID string) (*Job, JobResponse, bool) {
+	job, ok := jm.GetJob(jobID)
+	var summary JobResponse
+	if ok {
+		summary = jobToResponse(job)
+	} else {
+		var err error
+		summary, ok, err = jm.EvictedJob(r.Context(), jobID)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to read job history: %v", err))
+			return nil, JobResponse{}, false
+		}
+	}
+	if !ok {
+		respondError(w, http.StatusNotFound, "Job not found")
+		return nil, JobResponse{}, false
+	}
+	if !auth.FromContext(r.Context()).CanAccess(summary.Owner) {
+		respondError(w, http.StatusForbidden, "Job belongs to another user")
+		return nil, JobResponse{}, false
 	}
+	return job, summary, true
 }
@@ -0,0 +1,188 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"alert-producer/internal/audit"
+	"alert-producer/internal/generator"
+)
+
+// countingPublisher is an AlertPublisher that counts published alerts.
+type countingPublisher struct {
+	published int
+	closed    bool
+}
+
+func (p *countingPublisher) Publish(ctx context.Context, alert *generator.Alert) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	p.published++
+	return nil
+}
+
+func (p *countingPublisher) Close() error {
+	p.closed = true
+	return nil
+}
+
+func TestJobPublisher_StopsAfterCancel(t *testing.T) {
+	underlying := &countingPublisher{}
+	pub := &jobPublisher{publisher: underlying}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	for i := 0; i < 3; i++ {
+		if err := pub.Publish(ctx, &generator.Alert{}); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+	cancel()
+	if err := pub.Publish(ctx, &generator.Alert{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Publish() after cancel error = %v, want context.Canceled", err)
+	}
+
+	sent, err := pub.close()
+	if err != nil || sent != 3 || !underlying.closed {
+		t.Fatalf("close() = %d, %v (closed %v), want 3 sent and the publisher closed", sent, err, underlying.closed)
+	}
+	if err := pub.Publish(context.Background(), &generator.Alert{}); err == nil {
+		t.Error("Publish() after close succeeded")
+	}
+	if underlying.published != 3 {
+		t.Errorf("published = %d, want 3", underlying.published)
+	}
+}
+
+func TestJob_StopWaitsForFinalSummary(t *testing.T) {
+	auditLog := audit.NewLog(nil, 0)
+	jm := NewJobManager().WithAuditLog(auditLog)
+	rps := 200.0
+	job := jm.CreateJob(&GenerateRequest{RPS: &rps, Duration: "1m", Mock: true}, "alice")
+	jm.RunJob(job, "localhost:9092")
+
+	time.Sleep(50 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := job.Stop(ctx); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+
+	if status := job.GetStatus(); status != JobStatusCancelled {
+		t.Errorf("status = %s, want %s", status, JobStatusCancelled)
+	}
+	sent := job.GetAlertsSent()
+	time.Sleep(50 * time.Millisecond)
+	if job.GetAlertsSent() != sent {
+		t.Errorf("alerts_sent changed after Stop returned: %d, then %d", sent, job.GetAlertsSent())
+	}
+
+	entries, _ := auditLog.Recent(context.Background(), 10)
+	if len(entries) != 1 || entries[0].Action != audit.ActionJobFinished {
+		t.Fatalf("audit entries = %+v, want one job_finished entry", entries)
+	}
+	if entries[0].Status != string(JobStatusCancelled) || entries[0].AlertsSent == nil || *entries[0].AlertsSent != sent {
+		t.Errorf("job_finished entry = %+v, want cancelled with %d alerts", entries[0], sent)
+	}
+
+	// Stopping a finished job returns at once
+	if err := job.Stop(ctx); err != nil {
+		t.Errorf("Stop() of a finished job error = %v", err)
+	}
+}
+
+// finishedJob creates a job in jm and marks it completed, as RunJob does when the job ends.
+func finishedJob(jm *JobManager, owner string) *Job {
+	job := jm.CreateJob(&GenerateRequest{Mock: true}, owner)
+	job.UpdateStatus(JobStatusCompleted)
+	jm.finish(job)
+	return job
+}
+
+func TestJobManager_EvictsLeastRecentlyUsedOverCap(t *testing.T) {
+	jm := NewJobManager().WithRetention(0, 2).WithHistory(NewJobHistory(nil, 0))
+	a := finishedJob(jm, "alice")
+	b := finishedJob(jm, "bob")
+	a.accessedAt = b.accessedAt.Add(time.Second) // a was looked up after b finished
+
+	jm.CreateJob(&GenerateRequest{Mock: true}, "alice")
+	if _, ok := jm.GetJob(b.ID); ok {
+		t.Fatal("least recently used job was not evicted")
+	}
+	if _, ok := jm.GetJob(a.ID); !ok {
+		t.Fatal("recently used job was evicted")
+	}
+	summary, ok, err := jm.EvictedJob(context.Background(), b.ID)
+	if err != nil || !ok || summary.Status != string(JobStatusCompleted) || summary.Owner != "bob" {
+		t.Errorf("EvictedJob() = %+v, %v, %v, want the completed summary of bob's job", summary, ok, err)
+	}
+
+	// Pending and running jobs are never evicted, even above the cap
+	jm.CreateJob(&GenerateRequest{Mock: true}, "alice")
+	jm.CreateJob(&GenerateRequest{Mock: true}, "alice")
+	if jobs := jm.ListJobs("", ""); len(jobs) != 3 {
+		t.Errorf("ListJobs() returned %d jobs, want the 3 pending jobs", len(jobs))
+	}
+	if history, _ := jm.JobHistory(context.Background(), "", ""); len(history) != 2 || history[0].ID != a.ID {
+		t.Errorf("JobHistory() = %+v, want a then b, newest first", history)
+	}
+}
+
+func TestJobManager_EvictExpired(t *testing.T) {
+	jm := NewJobManager().WithRetention(time.Hour, 0).WithHistory(NewJobHistory(nil, 0))
+	done := finishedJob(jm, "alice")
+	pending := jm.CreateJob(&GenerateRequest{Mock: true}, "alice")
+
+	if n := jm.EvictExpired(time.Now()); n != 0 {
+		t.Errorf("EvictExpired(now) = %d, want 0", n)
+	}
+	if n := jm.EvictExpired(time.Now().Add(2 * time.Hour)); n != 1 {
+		t.Errorf("EvictExpired(+2h) = %d, want 1", n)
+	}
+	if _, ok := jm.GetJob(done.ID); ok {
+		t.Error("expired job was not evicted")
+	}
+	if _, ok := jm.GetJob(pending.ID); !ok {
+		t.Error("pending job was evicted")
+	}
+	if history, _ := jm.JobHistory(context.Background(), JobStatusCompleted, "alice"); len(history) != 1 || history[0].ID != done.ID {
+		t.Errorf("JobHistory() = %+v, want the expired job", history)
+	}
+}
+
+func TestJobHandlers_EvictedJob(t *testing.T) {
+	jm := NewJobManager().WithRetention(time.Hour, 0).WithHistory(NewJobHistory(nil, 0))
+	job := finishedJob(jm, "alice")
+	jm.EvictExpired(time.Now().Add(2 * time.Hour))
+
+	w := httptest.NewRecorder()
+	HandleGetJob(jm)(w, httptest.NewRequest(http.MethodGet, "/api/v1/alerts/generate/status?job_id="+job.ID, nil))
+	var resp JobResponse
+	if w.Code != http.StatusOK || json.NewDecoder(w.Body).Decode(&resp) != nil || resp.ID != job.ID {
+		t.Errorf("get evicted job = %d %+v, want 200 with its summary", w.Code, resp)
+	}
+
+	w = httptest.NewRecorder()
+	HandleStopJob(jm, nil)(w, httptest.NewRequest(http.MethodPost, "/api/v1/alerts/generate/stop?job_id="+job.ID, nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("stop evicted job status = %d, want 400", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	HandleJobHistory(jm)(w, httptest.NewRequest(http.MethodGet, "/api/v1/alerts/generate/history?owner=alice", nil))
+	var history []JobResponse
+	if w.Code != http.StatusOK || json.NewDecoder(w.Body).Decode(&history) != nil || len(history) != 1 {
+		t.Errorf("history = %d %+v, want 200 with the evicted job", w.Code, history)
+	}
+
+	w = httptest.NewRecorder()
+	HandleGetJob(jm)(w, httptest.NewRequest(http.MethodGet, "/api/v1/alerts/generate/status?job_id=unknown", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("get unknown job status = %d, want 404", w.Code)
+	}
+}
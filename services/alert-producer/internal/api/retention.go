@@ -0,0 +1,172 @@
+// Package api provides HTTP API handlers and job management for alert-producer.
+package api
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+// Job retention defaults.
+const (
+	// DefaultJobTTL is how long a finished job is kept in memory after it ends.
+	DefaultJobTTL = time.Hour
+	// DefaultMaxJobs is how many jobs are kept in memory.
+	DefaultMaxJobs = 1000
+)
+
+// WithRetention bounds the jobs kept in memory: finished jobs are evicted ttl after they end
+// (by EnforceRetention), and when more than maxJobs are kept the least recently used finished
+// jobs are evicted. Pending and running jobs are never evicted. A zero ttl or maxJobs disables
+// that bound.
+func (jm *JobManager) WithRetention(ttl time.Duration, maxJobs int) *JobManager {
+	jm.ttl = ttl
+	jm.maxJobs = maxJobs
+	return jm
+}
+
+// WithHistory keeps the summary of every evicted job in history, where EvictedJob and
+// JobHistory find it. Passing nil discards evicted jobs.
+func (jm *JobManager) WithHistory(history *JobHistory) *JobManager {
+	jm.history = history
+	return jm
+}
+
+// EnforceRetention evicts the finished jobs older than the TTL every interval, until ctx is
+// cancelled.
+func (jm *JobManager) EnforceRetention(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			jm.EvictExpired(time.Now())
+		}
+	}
+}
+
+// EvictExpired evicts the jobs that finished more than the TTL before now and returns how many
+// were evicted.
+func (jm *JobManager) EvictExpired(now time.Time) int {
+	if jm.ttl <= 0 {
+		return 0
+	}
+
+	jm.mu.Lock()
+	var evicted []*Job
+	for id, job := range jm.jobs {
+		if completedAt, finished := job.finishedAt(); finished && now.Sub(completedAt) > jm.ttl {
+			delete(jm.jobs, id)
+			evicted = append(evicted, job)
+		}
+	}
+	jm.mu.Unlock()
+
+	jm.archive(evicted)
+	return len(evicted)
+}
+
+// evictOverCapLocked evicts the least recently used finished jobs while more than maxJobs are
+// kept, and returns them. jm.mu must be held.
+func (jm *JobManager) evictOverCapLocked() []*Job {
+	excess := len(jm.jobs) - jm.maxJobs
+	if jm.maxJobs <= 0 || excess <= 0 {
+		return nil
+	}
+
+	var finished []*Job
+	for _, job := range jm.jobs {
+		if _, ok := job.finishedAt(); ok {
+			finished = append(finished, job)
+		}
+	}
+	sort.Slice(finished, func(i, k int) bool {
+		return finished[i].lastAccess().Before(finished[k].lastAccess())
+	})
+	if excess > len(finished) {
+		slog.Warn("More active jobs than the max-jobs cap", "jobs", len(jm.jobs), "max_jobs", jm.maxJobs)
+		excess = len(finished)
+	}
+
+	evicted := finished[:excess]
+	for _, job := range evicted {
+		delete(jm.jobs, job.ID)
+	}
+	return evicted
+}
+
+// archive records the summaries of evicted jobs in the history.
+func (jm *JobManager) archive(evicted []*Job) {
+	if len(evicted) == 0 {
+		return
+	}
+	if jm.history != nil {
+		for _, job := range evicted {
+			jm.history.Record(context.Background(), jobToResponse(job))
+		}
+	}
+	slog.Info("Evicted finished jobs", "count", len(evicted), "archived", jm.history != nil)
+}
+
+// EvictedJob returns the summary of an evicted job from the history.
+func (jm *JobManager) EvictedJob(ctx context.Context, id string) (JobResponse, bool, error) {
+	if jm.history == nil {
+		return JobResponse{}, false, nil
+	}
+	return jm.history.Get(ctx, id)
+}
+
+// JobHistory returns the summaries of evicted jobs, newest first, optionally filtered by status
+// and owner. An empty owner returns the jobs of all owners.
+func (jm *JobManager) JobHistory(ctx context.Context, statusFilter JobStatus, owner string) ([]JobResponse, error) {
+	if jm.history == nil {
+		return nil, nil
+	}
+	summaries, err := jm.history.Recent(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var jobs []JobResponse
+	for _, summary := range summaries {
+		if owner != "" && summary.Owner != owner {
+			continue
+		}
+		if statusFilter == "" || JobStatus(summary.Status) == statusFilter {
+			jobs = append(jobs, summary)
+		}
+	}
+	return jobs, nil
+}
+
+// touch records that the job was just used.
+func (j *Job) touch() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.accessedAt = time.Now()
+}
+
+// lastAccess returns when the job was last created, looked up or finished.
+func (j *Job) lastAccess() time.Time {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return j.accessedAt
+}
+
+// finishedAt returns when the job ended, and whether it has. A job has ended once it is done,
+// so its summary is final.
+func (j *Job) finishedAt() (time.Time, bool) {
+	select {
+	case <-j.done:
+	default:
+		return time.Time{}, false
+	}
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	if j.CompletedAt == nil {
+		return time.Time{}, false
+	}
+	return *j.CompletedAt, true
+}
@@ -0,0 +1,168 @@
+// Package api provides HTTP API handlers and job management for alert-producer.
+package api
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"alert-producer/internal/audit"
+	"alert-producer/internal/cron"
+)
+
+// Schedule defaults.
+const (
+	// DefaultScheduleInterval is how often schedules are checked for due runs.
+	DefaultScheduleInterval = 15 * time.Second
+	// ScheduleMisfireGrace is how late a run may start. Runs missed by more, e.g. while no
+	// instance was up, are skipped rather than started late.
+	ScheduleMisfireGrace = 10 * time.Minute
+)
+
+// Schedule is a recurring alert generation job: a job with Config, owned by Owner, is started
+// every time Cron fires in Timezone.
+type Schedule struct {
+	ID        string           `json:"id"`
+	Owner     string           `json:"owner"`
+	Name      string           `json:"name,omitempty"`
+	Cron      string           `json:"cron"`     // five-field cron expression, e.g. "0 9 * * MON-FRI"
+	Timezone  string           `json:"timezone"` // IANA time zone Cron is evaluated in
+	Config    *GenerateRequest `json:"config"`
+	Paused    bool             `json:"paused"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+	LastRunAt *time.Time       `json:"last_run_at,omitempty"` // when the last run was due, or the schedule resumed
+	LastJobID string           `json:"last_job_id,omitempty"`
+}
+
+// WithSchedules enables recurring jobs: schedules are kept in store, and RunSchedules starts
+// their jobs, publishing to defaultKafkaBrokers unless a schedule's config sets brokers.
+func (jm *JobManager) WithSchedules(store ScheduleStore, defaultKafkaBrokers string) *JobManager {
+	jm.schedules = store
+	jm.kafkaBrokers = defaultKafkaBrokers
+	return jm
+}
+
+// RunSchedules starts the jobs of due schedules every interval, until ctx is cancelled.
+func (jm *JobManager) RunSchedules(ctx context.Context, interval time.Duration) {
+	slog.Info("Starting job scheduler", "interval", interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			jm.RunDueSchedules(ctx, time.Now())
+		}
+	}
+}
+
+// RunDueSchedules starts a job for every schedule with a run due at now, and returns the jobs
+// started. Each run is claimed in the store first, so with several instances sharing a store
+// a run starts one job. Runs due during an emergency stop are skipped.
+func (jm *JobManager) RunDueSchedules(ctx context.Context, now time.Time) []*Job {
+	if jm.schedules == nil {
+		return nil
+	}
+	schedules, err := jm.schedules.List(ctx)
+	if err != nil {
+		slog.Error("Failed to list schedules", "error", err)
+		return nil
+	}
+
+	var started []*Job
+	for _, s := range schedules {
+		if s.Paused {
+			continue
+		}
+		dueAt, ok := s.dueRun(now)
+		if !ok {
+			continue
+		}
+		claimed, err := jm.schedules.ClaimRun(ctx, s.ID, dueAt)
+		if err != nil {
+			slog.Error("Failed to claim scheduled run", "schedule_id", s.ID, "error", err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		if stop, halted := jm.jobsHalted(); halted {
+			slog.Warn("Skipped scheduled run during emergency stop", "schedule_id", s.ID, "reason", stop.Reason)
+			continue
+		}
+
+		config := *s.Config
+		job := jm.CreateJob(&config, s.Owner)
+		if jm.audit != nil {
+			jm.audit.Record(ctx, audit.Entry{
+				Actor:      s.Owner,
+				Action:     audit.ActionJobStarted,
+				JobID:      job.ID,
+				ScheduleID: s.ID,
+				Summary:    jobSummary(job.Config),
+			})
+		}
+		jm.RunJob(job, jm.kafkaBrokers)
+		if err := jm.schedules.SetLastJob(ctx, s.ID, job.ID); err != nil {
+			slog.Error("Failed to record scheduled job", "schedule_id", s.ID, "job_id", job.ID, "error", err)
+		}
+		slog.Info("Started scheduled job", "schedule_id", s.ID, "job_id", job.ID, "owner", s.Owner, "due_at", dueAt)
+		started = append(started, job)
+	}
+	return started
+}
+
+// dueRun returns the latest time at or before now the schedule fires at, if it is after the
+// last run and within the misfire grace.
+func (s *Schedule) dueRun(now time.Time) (time.Time, bool) {
+	sched, loc, err := s.parse()
+	if err != nil {
+		slog.Error("Skipping invalid schedule", "schedule_id", s.ID, "error", err)
+		return time.Time{}, false
+	}
+
+	from := s.CreatedAt
+	if s.LastRunAt != nil {
+		from = *s.LastRunAt
+	}
+	if earliest := now.Add(-ScheduleMisfireGrace); from.Before(earliest) {
+		from = earliest
+	}
+
+	var due time.Time
+	for next := sched.Next(from.In(loc)); !next.IsZero() && !next.After(now); next = sched.Next(next) {
+		due = next
+	}
+	return due, !due.IsZero()
+}
+
+// nextRun returns when the schedule next fires after now, or nil if it is paused.
+func (s *Schedule) nextRun(now time.Time) *time.Time {
+	if s.Paused {
+		return nil
+	}
+	sched, loc, err := s.parse()
+	if err != nil {
+		return nil
+	}
+	next := sched.Next(now.In(loc))
+	if next.IsZero() {
+		return nil
+	}
+	return &next
+}
+
+// parse parses the schedule's cron expression and time zone.
+func (s *Schedule) parse() (*cron.Schedule, *time.Location, error) {
+	sched, err := cron.Parse(s.Cron)
+	if err != nil {
+		return nil, nil, err
+	}
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sched, loc, nil
+}
@@ -0,0 +1,239 @@
+// Package api provides HTTP API handlers and job management for alert-producer.
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"alert-producer/internal/audit"
+	"alert-producer/internal/auth"
+	"alert-producer/internal/cron"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// HandleSchedules handles GET and POST /api/v1/alerts/schedules
+// POST creates a schedule owned by the caller. GET lists schedules: admins see all schedules
+// (optionally filtered by ?owner=); other callers see only their own. Creations are recorded
+// in auditLog.
+func HandleSchedules(jm *JobManager, auditLog *audit.Log) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if jm.schedules == nil {
+			respondError(w, http.StatusServiceUnavailable, "Scheduled jobs are not enabled")
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			listSchedules(w, r, jm)
+		case http.MethodPost:
+			createSchedule(w, r, jm, auditLog)
+		default:
+			respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	}
+}
+
+// createSchedule validates the schedule and the job config it runs, and stores the schedule.
+func createSchedule(w http.ResponseWriter, r *http.Request, jm *JobManager, auditLog *audit.Log) {
+	var req ScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+	if req.Timezone == "" {
+		req.Timezone = "UTC"
+	}
+
+	v := shared.NewValidator()
+	v.MaxLength("name", req.Name, 200)
+	if _, err := cron.Parse(req.Cron); err != nil {
+		v.Add("cron", err.Error())
+	}
+	if _, err := time.LoadLocation(req.Timezone); err != nil {
+		v.Addf("timezone", "unknown time zone %q", req.Timezone)
+	}
+	cfg, err := req.Config.ToConfig(jm.kafkaBrokers)
+	if err != nil {
+		v.Add("duration", err.Error())
+	}
+	req.Config.validate(v, &cfg)
+	if err := v.Err(); err != nil {
+		shared.WriteValidationError(w, err)
+		return
+	}
+
+	principal := auth.FromContext(r.Context())
+	now := time.Now().UTC()
+	s := &Schedule{
+		ID:        generateJobID(),
+		Owner:     principal.Name,
+		Name:      req.Name,
+		Cron:      strings.Join(strings.Fields(req.Cron), " "),
+		Timezone:  req.Timezone,
+		Config:    &req.Config,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := jm.schedules.Create(r.Context(), s); err != nil {
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to create schedule: %v", err))
+		return
+	}
+	recordScheduleAudit(r, auditLog, principal, audit.ActionScheduleCreated, s)
+
+	respondJSON(w, http.StatusCreated, scheduleToResponse(s, now))
+}
+
+// listSchedules lists the schedules the caller may see, oldest first.
+func listSchedules(w http.ResponseWriter, r *http.Request, jm *JobManager) {
+	principal := auth.FromContext(r.Context())
+	owner := principal.Name
+	if principal.Admin {
+		owner = r.URL.Query().Get("owner")
+	}
+
+	schedules, err := jm.schedules.List(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to list schedules: %v", err))
+		return
+	}
+	now := time.Now()
+	responses := []ScheduleResponse{}
+	for _, s := range schedules {
+		if owner == "" || s.Owner == owner {
+			responses = append(responses, scheduleToResponse(s, now))
+		}
+	}
+	respondJSON(w, http.StatusOK, responses)
+}
+
+// HandlePauseSchedule handles POST /api/v1/alerts/schedules/pause?schedule_id= and, with
+// paused false, POST /api/v1/alerts/schedules/resume?schedule_id=
+// Non-admin callers can only pause and resume their own schedules. A resumed schedule does not
+// catch up on the runs missed while it was paused.
+func HandlePauseSchedule(jm *JobManager, auditLog *audit.Log, paused bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		s, ok := getOwnedSchedule(w, r, jm)
+		if !ok {
+			return
+		}
+
+		if s.Paused != paused {
+			found, err := jm.schedules.SetPaused(r.Context(), s.ID, paused)
+			if err != nil {
+				respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to update schedule: %v", err))
+				return
+			}
+			if !found {
+				respondError(w, http.StatusNotFound, "Schedule not found")
+				return
+			}
+			if !paused {
+				// Runs missed while paused are not started late
+				if _, err := jm.schedules.ClaimRun(r.Context(), s.ID, time.Now().UTC()); err != nil {
+					respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to update schedule: %v", err))
+					return
+				}
+			}
+			action := audit.ActionScheduleResumed
+			if paused {
+				action = audit.ActionSchedulePaused
+			}
+			recordScheduleAudit(r, auditLog, auth.FromContext(r.Context()), action, s)
+		}
+
+		s, found, err := jm.schedules.Get(r.Context(), s.ID)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to read schedule: %v", err))
+			return
+		}
+		if !found {
+			respondError(w, http.StatusNotFound, "Schedule not found")
+			return
+		}
+		respondJSON(w, http.StatusOK, scheduleToResponse(s, time.Now()))
+	}
+}
+
+// HandleDeleteSchedule handles DELETE /api/v1/alerts/schedules/delete?schedule_id=
+// Non-admin callers can only delete their own schedules. Jobs the schedule already started
+// keep running.
+func HandleDeleteSchedule(jm *JobManager, auditLog *audit.Log) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		s, ok := getOwnedSchedule(w, r, jm)
+		if !ok {
+			return
+		}
+
+		found, err := jm.schedules.Delete(r.Context(), s.ID)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete schedule: %v", err))
+			return
+		}
+		if !found {
+			respondError(w, http.StatusNotFound, "Schedule not found")
+			return
+		}
+		recordScheduleAudit(r, auditLog, auth.FromContext(r.Context()), audit.ActionScheduleDeleted, s)
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// getOwnedSchedule looks up the schedule_id parameter and checks that the caller may access
+// the schedule. It writes a 400, 404, 403, 500 or 503 response and returns false otherwise.
+func getOwnedSchedule(w http.ResponseWriter, r *http.Request, jm *JobManager) (*Schedule, bool) {
+	if jm.schedules == nil {
+		respondError(w, http.StatusServiceUnavailable, "Scheduled jobs are not enabled")
+		return nil, false
+	}
+	id := r.URL.Query().Get("schedule_id")
+	if id == "" {
+		respondError(w, http.StatusBadRequest, "schedule_id parameter is required")
+		return nil, false
+	}
+
+	s, found, err := jm.schedules.Get(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to read schedule: %v", err))
+		return nil, false
+	}
+	if !found {
+		respondError(w, http.StatusNotFound, "Schedule not found")
+		return nil, false
+	}
+	if !auth.FromContext(r.Context()).CanAccess(s.Owner) {
+		respondError(w, http.StatusForbidden, "Schedule belongs to another user")
+		return nil, false
+	}
+	return s, true
+}
+
+// scheduleToResponse converts a Schedule to a ScheduleResponse.
+func scheduleToResponse(s *Schedule, now time.Time) ScheduleResponse {
+	return ScheduleResponse{Schedule: *s, NextRunAt: s.nextRun(now)}
+}
+
+// recordScheduleAudit records action on schedule s by principal. A nil auditLog records nothing.
+func recordScheduleAudit(r *http.Request, auditLog *audit.Log, principal auth.Principal, action string, s *Schedule) {
+	if auditLog == nil {
+		return
+	}
+	auditLog.Record(r.Context(), audit.Entry{
+		Actor:      principal.Name,
+		Action:     action,
+		ScheduleID: s.ID,
+		RemoteAddr: r.RemoteAddr,
+		Summary:    strings.TrimSpace(fmt.Sprintf("cron=%q tz=%s %s", s.Cron, s.Timezone, jobSummary(s.Config))),
+	})
+}
@@ -0,0 +1,254 @@
+// Package api provides HTTP API handlers and job management for alert-producer.
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ScheduleStore persists schedules. Implementations are safe for concurrent use.
+type ScheduleStore interface {
+	Create(ctx context.Context, s *Schedule) error
+	// List returns every schedule, oldest first.
+	List(ctx context.Context) ([]*Schedule, error)
+	Get(ctx context.Context, id string) (*Schedule, bool, error)
+	// SetPaused pauses or resumes a schedule, returning false if it does not exist.
+	SetPaused(ctx context.Context, id string, paused bool) (bool, error)
+	// Delete deletes a schedule, returning false if it does not exist.
+	Delete(ctx context.Context, id string) (bool, error)
+	// ClaimRun records dueAt as the schedule's last run unless a run at or after it is already
+	// recorded, and returns whether it did: each run is claimed once.
+	ClaimRun(ctx context.Context, id string, dueAt time.Time) (bool, error)
+	// SetLastJob records the job started by the schedule's last run.
+	SetLastJob(ctx context.Context, id, jobID string) error
+}
+
+// MemoryScheduleStore keeps schedules in memory, for when Postgres is not configured.
+// Schedules are lost on restart.
+type MemoryScheduleStore struct {
+	mu        sync.Mutex
+	schedules map[string]*Schedule
+}
+
+// NewMemoryScheduleStore creates an empty in-memory schedule store.
+func NewMemoryScheduleStore() *MemoryScheduleStore {
+	return &MemoryScheduleStore{schedules: make(map[string]*Schedule)}
+}
+
+// Create adds s to the store.
+func (m *MemoryScheduleStore) Create(ctx context.Context, s *Schedule) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored := *s
+	m.schedules[s.ID] = &stored
+	return nil
+}
+
+// List returns copies of every schedule, oldest first.
+func (m *MemoryScheduleStore) List(ctx context.Context) ([]*Schedule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	schedules := make([]*Schedule, 0, len(m.schedules))
+	for _, s := range m.schedules {
+		copied := *s
+		schedules = append(schedules, &copied)
+	}
+	sort.Slice(schedules, func(i, k int) bool { return schedules[i].ID < schedules[k].ID })
+	return schedules, nil
+}
+
+// Get returns a copy of the schedule with the given ID.
+func (m *MemoryScheduleStore) Get(ctx context.Context, id string) (*Schedule, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.schedules[id]
+	if !ok {
+		return nil, false, nil
+	}
+	copied := *s
+	return &copied, true, nil
+}
+
+// SetPaused pauses or resumes a schedule.
+func (m *MemoryScheduleStore) SetPaused(ctx context.Context, id string, paused bool) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.schedules[id]
+	if !ok {
+		return false, nil
+	}
+	s.Paused = paused
+	s.UpdatedAt = time.Now().UTC()
+	return true, nil
+}
+
+// Delete deletes a schedule.
+func (m *MemoryScheduleStore) Delete(ctx context.Context, id string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.schedules[id]
+	delete(m.schedules, id)
+	return ok, nil
+}
+
+// ClaimRun records dueAt as the schedule's last run unless a later run is recorded.
+func (m *MemoryScheduleStore) ClaimRun(ctx context.Context, id string, dueAt time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.schedules[id]
+	if !ok || s.Paused || (s.LastRunAt != nil && !s.LastRunAt.Before(dueAt)) {
+		return false, nil
+	}
+	s.LastRunAt = &dueAt
+	return true, nil
+}
+
+// SetLastJob records the job started by the schedule's last run.
+func (m *MemoryScheduleStore) SetLastJob(ctx context.Context, id, jobID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s, ok := m.schedules[id]; ok {
+		s.LastJobID = jobID
+	}
+	return nil
+}
+
+// SQLScheduleStore keeps schedules in the generation_schedules table, so they survive restarts
+// and are shared by every alert-producer API instance.
+type SQLScheduleStore struct {
+	db *sql.DB
+}
+
+// NewSQLScheduleStore creates a schedule store in db.
+func NewSQLScheduleStore(db *sql.DB) *SQLScheduleStore {
+	return &SQLScheduleStore{db: db}
+}
+
+const scheduleColumns = `schedule_id, owner, name, cron, timezone, config, paused, created_at, updated_at, last_run_at, COALESCE(last_job_id, '')`
+
+// Create inserts s.
+func (st *SQLScheduleStore) Create(ctx context.Context, s *Schedule) error {
+	config, err := json.Marshal(s.Config)
+	if err != nil {
+		return fmt.Errorf("failed to encode schedule config: %w", err)
+	}
+	_, err = st.db.ExecContext(ctx, `
+		INSERT INTO generation_schedules (schedule_id, owner, name, cron, timezone, config, paused, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, s.ID, s.Owner, s.Name, s.Cron, s.Timezone, string(config), s.Paused, s.CreatedAt.UTC(), s.UpdatedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to create schedule: %w", err)
+	}
+	return nil
+}
+
+// List returns every schedule, oldest first.
+func (st *SQLScheduleStore) List(ctx context.Context) ([]*Schedule, error) {
+	rows, err := st.db.QueryContext(ctx, `SELECT `+scheduleColumns+` FROM generation_schedules ORDER BY schedule_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schedules: %w", err)
+	}
+	defer rows.Close()
+
+	var schedules []*Schedule
+	for rows.Next() {
+		s, err := scanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read schedules: %w", err)
+	}
+	return schedules, nil
+}
+
+// Get returns the schedule with the given ID.
+func (st *SQLScheduleStore) Get(ctx context.Context, id string) (*Schedule, bool, error) {
+	row := st.db.QueryRowContext(ctx, `SELECT `+scheduleColumns+` FROM generation_schedules WHERE schedule_id = $1`, id)
+	s, err := scanSchedule(row)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return s, true, nil
+}
+
+// SetPaused pauses or resumes a schedule.
+func (st *SQLScheduleStore) SetPaused(ctx context.Context, id string, paused bool) (bool, error) {
+	result, err := st.db.ExecContext(ctx,
+		`UPDATE generation_schedules SET paused = $2, updated_at = NOW() WHERE schedule_id = $1`, id, paused)
+	if err != nil {
+		return false, fmt.Errorf("failed to update schedule: %w", err)
+	}
+	return affectedOne(result)
+}
+
+// Delete deletes a schedule.
+func (st *SQLScheduleStore) Delete(ctx context.Context, id string) (bool, error) {
+	result, err := st.db.ExecContext(ctx, `DELETE FROM generation_schedules WHERE schedule_id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete schedule: %w", err)
+	}
+	return affectedOne(result)
+}
+
+// ClaimRun records dueAt as the schedule's last run unless a later run is recorded. The single
+// conditional UPDATE makes the claim safe across instances.
+func (st *SQLScheduleStore) ClaimRun(ctx context.Context, id string, dueAt time.Time) (bool, error) {
+	result, err := st.db.ExecContext(ctx, `
+		UPDATE generation_schedules SET last_run_at = $2
+		WHERE schedule_id = $1 AND NOT paused AND (last_run_at IS NULL OR last_run_at < $2)
+	`, id, dueAt.UTC())
+	if err != nil {
+		return false, fmt.Errorf("failed to claim scheduled run: %w", err)
+	}
+	return affectedOne(result)
+}
+
+// SetLastJob records the job started by the schedule's last run.
+func (st *SQLScheduleStore) SetLastJob(ctx context.Context, id, jobID string) error {
+	if _, err := st.db.ExecContext(ctx,
+		`UPDATE generation_schedules SET last_job_id = $2 WHERE schedule_id = $1`, id, jobID); err != nil {
+		return fmt.Errorf("failed to record scheduled job: %w", err)
+	}
+	return nil
+}
+
+// scanSchedule scans a row of scheduleColumns.
+func scanSchedule(row interface{ Scan(...any) error }) (*Schedule, error) {
+	var s Schedule
+	var config []byte
+	var lastRunAt sql.NullTime
+	if err := row.Scan(&s.ID, &s.Owner, &s.Name, &s.Cron, &s.Timezone, &config, &s.Paused,
+		&s.CreatedAt, &s.UpdatedAt, &lastRunAt, &s.LastJobID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan schedule: %w", err)
+	}
+	if err := json.Unmarshal(config, &s.Config); err != nil {
+		return nil, fmt.Errorf("invalid config of schedule %s: %w", s.ID, err)
+	}
+	if lastRunAt.Valid {
+		s.LastRunAt = &lastRunAt.Time
+	}
+	return &s, nil
+}
+
+// affectedOne reports whether result affected a row.
+func affectedOne(result sql.Result) (bool, error) {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,169 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"alert-producer/internal/audit"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestJobManager_RunDueSchedules(t *testing.T) {
+	created := time.Date(2024, 5, 6, 8, 0, 0, 0, time.UTC) // a Monday
+	newManager := func() (*JobManager, *MemoryScheduleStore, *audit.Log) {
+		store := NewMemoryScheduleStore()
+		auditLog := audit.NewLog(nil, 0)
+		jm := NewJobManager().WithAuditLog(auditLog).WithSchedules(store, "localhost:9092")
+		count := 5
+		store.Create(context.Background(), &Schedule{
+			ID:        "sched-1",
+			Owner:     "alice",
+			Cron:      "0 9 * * MON-FRI",
+			Timezone:  "UTC",
+			Config:    &GenerateRequest{Count: &count, Mock: true},
+			CreatedAt: created,
+		})
+		return jm, store, auditLog
+	}
+
+	t.Run("starts a due run once", func(t *testing.T) {
+		jm, store, auditLog := newManager()
+		if jobs := jm.RunDueSchedules(context.Background(), created.Add(59*time.Minute)); len(jobs) != 0 {
+			t.Fatalf("started %d jobs before 9:00, want 0", len(jobs))
+		}
+
+		now := created.Add(time.Hour + 10*time.Second)
+		jobs := jm.RunDueSchedules(context.Background(), now)
+		if len(jobs) != 1 || jobs[0].Owner != "alice" {
+			t.Fatalf("started %+v, want one job owned by alice", jobs)
+		}
+		if again := jm.RunDueSchedules(context.Background(), now.Add(15*time.Second)); len(again) != 0 {
+			t.Errorf("run started %d more jobs, want 0", len(again))
+		}
+
+		s, _, _ := store.Get(context.Background(), "sched-1")
+		if s.LastRunAt == nil || !s.LastRunAt.Equal(created.Add(time.Hour)) || s.LastJobID != jobs[0].ID {
+			t.Errorf("schedule = %+v, want the 9:00 run recorded with its job", s)
+		}
+		if next := s.nextRun(now); next == nil || !next.Equal(created.Add(25*time.Hour)) {
+			t.Errorf("nextRun() = %v, want Tuesday 9:00", next)
+		}
+		entries, _ := auditLog.Recent(context.Background(), 10)
+		if len(entries) == 0 || entries[len(entries)-1].ScheduleID != "sched-1" || entries[len(entries)-1].Action != audit.ActionJobStarted {
+			t.Errorf("audit entries = %+v, want a job_started entry for the schedule", entries)
+		}
+	})
+
+	t.Run("skips runs missed by more than the grace", func(t *testing.T) {
+		jm, _, _ := newManager()
+		if jobs := jm.RunDueSchedules(context.Background(), created.Add(time.Hour+ScheduleMisfireGrace+time.Minute)); len(jobs) != 0 {
+			t.Errorf("started %d jobs for a missed run, want 0", len(jobs))
+		}
+	})
+
+	t.Run("skips paused schedules", func(t *testing.T) {
+		jm, store, _ := newManager()
+		store.SetPaused(context.Background(), "sched-1", true)
+		if jobs := jm.RunDueSchedules(context.Background(), created.Add(time.Hour)); len(jobs) != 0 {
+			t.Errorf("started %d jobs for a paused schedule, want 0", len(jobs))
+		}
+	})
+}
+
+func TestScheduleHandlers(t *testing.T) {
+	jm := NewJobManager().WithSchedules(NewMemoryScheduleStore(), "localhost:9092")
+	auditLog := audit.NewLog(nil, 0)
+
+	w := httptest.NewRecorder()
+	body := `{"name":"weekday burst","cron":"0 9 * * 1-5","timezone":"Nowhere/City","config":{"burst":500,"rps":-1}}`
+	HandleSchedules(jm, auditLog)(w, httptest.NewRequest(http.MethodPost, "/api/v1/alerts/schedules", strings.NewReader(body)))
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "timezone") {
+		t.Fatalf("create with an unknown time zone = %d %s, want 400", w.Code, w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	body = `{"cron":"0 25 * * *","config":{"mock":true}}`
+	HandleSchedules(jm, auditLog)(w, httptest.NewRequest(http.MethodPost, "/api/v1/alerts/schedules", strings.NewReader(body)))
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "cron") {
+		t.Fatalf("create with an invalid cron = %d %s, want 400", w.Code, w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	body = `{"name":"weekday burst","cron":"0 9 * * MON-FRI","timezone":"America/New_York","config":{"burst":500,"mock":true}}`
+	HandleSchedules(jm, auditLog)(w, httptest.NewRequest(http.MethodPost, "/api/v1/alerts/schedules", strings.NewReader(body)))
+	var created ScheduleResponse
+	if w.Code != http.StatusCreated || json.NewDecoder(w.Body).Decode(&created) != nil || created.ID == "" || created.NextRunAt == nil {
+		t.Fatalf("create = %d %+v, want 201 with the schedule and its next run", w.Code, created)
+	}
+
+	w = httptest.NewRecorder()
+	HandlePauseSchedule(jm, auditLog, true)(w, httptest.NewRequest(http.MethodPost, "/api/v1/alerts/schedules/pause?schedule_id="+created.ID, nil))
+	var paused ScheduleResponse
+	if w.Code != http.StatusOK || json.NewDecoder(w.Body).Decode(&paused) != nil || !paused.Paused || paused.NextRunAt != nil {
+		t.Fatalf("pause = %d %+v, want 200 with the schedule paused", w.Code, paused)
+	}
+
+	w = httptest.NewRecorder()
+	HandleSchedules(jm, auditLog)(w, httptest.NewRequest(http.MethodGet, "/api/v1/alerts/schedules", nil))
+	var list []ScheduleResponse
+	if w.Code != http.StatusOK || json.NewDecoder(w.Body).Decode(&list) != nil || len(list) != 1 || !list[0].Paused {
+		t.Fatalf("list = %d %+v, want the paused schedule", w.Code, list)
+	}
+
+	w = httptest.NewRecorder()
+	HandleDeleteSchedule(jm, auditLog)(w, httptest.NewRequest(http.MethodDelete, "/api/v1/alerts/schedules/delete?schedule_id="+created.ID, nil))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("delete status = %d, want 204", w.Code)
+	}
+	w = httptest.NewRecorder()
+	HandlePauseSchedule(jm, auditLog, false)(w, httptest.NewRequest(http.MethodPost, "/api/v1/alerts/schedules/resume?schedule_id="+created.ID, nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("resume deleted schedule status = %d, want 404", w.Code)
+	}
+
+	entries, _ := auditLog.Recent(context.Background(), 10)
+	if len(entries) != 3 || entries[0].Action != audit.ActionScheduleDeleted || entries[2].Action != audit.ActionScheduleCreated {
+		t.Errorf("audit entries = %+v, want created, paused and deleted, newest first", entries)
+	}
+}
+
+func TestScheduleHandlers_Disabled(t *testing.T) {
+	w := httptest.NewRecorder()
+	HandleSchedules(NewJobManager(), nil)(w, httptest.NewRequest(http.MethodGet, "/api/v1/alerts/schedules", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503 without a schedule store", w.Code)
+	}
+}
+
+func TestSQLScheduleStore_ClaimRun(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	due := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+	mock.ExpectExec("UPDATE generation_schedules SET last_run_at").
+		WithArgs("sched-1", due).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE generation_schedules SET last_run_at").
+		WithArgs("sched-1", due).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	store := NewSQLScheduleStore(db)
+	if claimed, err := store.ClaimRun(context.Background(), "sched-1", due); err != nil || !claimed {
+		t.Errorf("ClaimRun() = %v, %v, want true", claimed, err)
+	}
+	if claimed, err := store.ClaimRun(context.Background(), "sched-1", due); err != nil || claimed {
+		t.Errorf("second ClaimRun() = %v, %v, want false", claimed, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
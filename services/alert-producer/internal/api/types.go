@@ -55,13 +55,6 @@ func (req *GenerateRequest) ToConfig(defaultKafkaBrokers string) (config.Config,
 	if req.RPS != nil {
 		cfg.RPS = *req.RPS
 	}
-	if req.Duration != "" {
-		duration, err := time.ParseDuration(req.Duration)
-		if err != nil {
-			return cfg, fmt.Errorf("invalid duration format: %w", err)
-		}
-		cfg.Duration = duration
-	}
 	if req.BurstSize != nil {
 		cfg.BurstSize = *req.BurstSize
 	}
@@ -77,6 +70,16 @@ func (req *GenerateRequest) ToConfig(defaultKafkaBrokers string) (config.Config,
 	if req.NameDist != "" {
 		cfg.NameDist = req.NameDist
 	}
+	cfg.ClientHint = req.ClientID
+
+	// Parsed last, so the rest of cfg is set even if the duration is invalid
+	if req.Duration != "" {
+		duration, err := time.ParseDuration(req.Duration)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid duration format: %w", err)
+		}
+		cfg.Duration = duration
+	}
 
 	return cfg, nil
 }
@@ -90,6 +93,7 @@ type GenerateResponse struct {
 // JobResponse represents a job status response.
 type JobResponse struct {
 	ID          string    `json:"id"`
+	Owner       string    `json:"owner"`
 	Status      string    `json:"status"`
 	Config      *GenerateRequest `json:"config"`
 	CreatedAt   time.Time `json:"created_at"`
@@ -103,3 +107,17 @@ type JobResponse struct {
 type ErrorResponse struct {
 	Error string `json:"error"`
 }
+
+// ScheduleRequest represents a request to create a recurring generation job.
+type ScheduleRequest struct {
+	Name     string          `json:"name,omitempty"`
+	Cron     string          `json:"cron"`               // e.g., "0 9 * * MON-FRI" (every weekday at 9:00)
+	Timezone string          `json:"timezone,omitempty"` // IANA time zone, defaults to "UTC"
+	Config   GenerateRequest `json:"config"`             // the job started on every run
+}
+
+// ScheduleResponse represents a schedule and when it next runs.
+type ScheduleResponse struct {
+	Schedule
+	NextRunAt *time.Time `json:"next_run_at,omitempty"` // unset while paused
+}
@@ -0,0 +1,141 @@
+// Package audit records who started and stopped alert generation jobs, and who changed the
+// schedules starting them.
+// Entries are logged and kept in a bounded Redis list (or in memory when Redis is not
+// configured), so a test storm can be traced back to the API key that started it.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Audit trail defaults.
+const (
+	// RedisKey is the Redis list holding the most recent audit entries, newest first.
+	RedisKey = "alert-producer:audit"
+	// DefaultMaxEntries is how many entries are retained.
+	DefaultMaxEntries = 1000
+)
+
+// Audited actions.
+const (
+	ActionJobStarted  = "job_started"
+	ActionJobStopped  = "job_stopped"
+	ActionJobFinished = "job_finished" // final summary of a job, however it ended
+
+	ActionScheduleCreated = "schedule_created"
+	ActionSchedulePaused  = "schedule_paused"
+	ActionScheduleResumed = "schedule_resumed"
+	ActionScheduleDeleted = "schedule_deleted"
+)
+
+// Entry is a single audit record.
+type Entry struct {
+	Time       time.Time `json:"time"`
+	Actor      string    `json:"actor"`
+	Action     string    `json:"action"`
+	JobID      string    `json:"job_id"`
+	RemoteAddr string    `json:"remote_addr,omitempty"`
+	// ScheduleID is the schedule changed, or that started the job.
+	ScheduleID string `json:"schedule_id,omitempty"`
+	// Summary describes the job, e.g. "rps=100 duration=5m".
+	Summary string `json:"summary,omitempty"`
+	// Status and AlertsSent are the outcome of a finished job (ActionJobFinished only).
+	Status     string `json:"status,omitempty"`
+	AlertsSent *int64 `json:"alerts_sent,omitempty"`
+}
+
+// Log is an append-only audit trail. It is safe for concurrent use.
+type Log struct {
+	redis      *redis.Client
+	maxEntries int
+
+	mu     sync.Mutex
+	memory []Entry // newest first; used when redis is nil
+}
+
+// NewLog creates an audit log stored in redisClient, or in memory if redisClient is nil.
+// A non-positive maxEntries uses DefaultMaxEntries.
+func NewLog(redisClient *redis.Client, maxEntries int) *Log {
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+	return &Log{redis: redisClient, maxEntries: maxEntries}
+}
+
+// Record appends e to the trail. Storage errors are logged, never returned, so auditing
+// cannot block job control.
+func (l *Log) Record(ctx context.Context, e Entry) {
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	slog.Info("Audit",
+		"actor", e.Actor,
+		"action", e.Action,
+		"job_id", e.JobID,
+		"schedule_id", e.ScheduleID,
+		"remote_addr", e.RemoteAddr,
+		"summary", e.Summary,
+		"status", e.Status,
+	)
+
+	if l.redis == nil {
+		l.mu.Lock()
+		l.memory = append([]Entry{e}, l.memory...)
+		if len(l.memory) > l.maxEntries {
+			l.memory = l.memory[:l.maxEntries]
+		}
+		l.mu.Unlock()
+		return
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		slog.Error("Failed to encode audit entry", "error", err)
+		return
+	}
+	_, err = l.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LPush(ctx, RedisKey, data)
+		pipe.LTrim(ctx, RedisKey, 0, int64(l.maxEntries-1))
+		return nil
+	})
+	if err != nil {
+		slog.Error("Failed to store audit entry", "job_id", e.JobID, "error", err)
+	}
+}
+
+// Recent returns up to limit entries, newest first.
+func (l *Log) Recent(ctx context.Context, limit int) ([]Entry, error) {
+	if limit <= 0 || limit > l.maxEntries {
+		limit = l.maxEntries
+	}
+
+	if l.redis == nil {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if limit > len(l.memory) {
+			limit = len(l.memory)
+		}
+		return append([]Entry(nil), l.memory[:limit]...), nil
+	}
+
+	values, err := l.redis.LRange(ctx, RedisKey, 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, 0, len(values))
+	for _, value := range values {
+		var e Entry
+		if err := json.Unmarshal([]byte(value), &e); err != nil {
+			slog.Warn("Skipping malformed audit entry", "error", err)
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
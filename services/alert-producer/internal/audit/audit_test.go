@@ -0,0 +1,33 @@
+package audit
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLog_InMemory(t *testing.T) {
+	log := NewLog(nil, 2)
+	ctx := context.Background()
+
+	log.Record(ctx, Entry{Actor: "alice", Action: ActionJobStarted, JobID: "job-1"})
+	log.Record(ctx, Entry{Actor: "alice", Action: ActionJobStopped, JobID: "job-1"})
+	log.Record(ctx, Entry{Actor: "bob", Action: ActionJobStarted, JobID: "job-2"})
+
+	entries, err := log.Recent(ctx, 10)
+	if err != nil {
+		t.Fatalf("Recent() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Recent() returned %d entries, want 2 (bounded by maxEntries)", len(entries))
+	}
+	if entries[0].JobID != "job-2" || entries[1].Action != ActionJobStopped {
+		t.Errorf("Recent() = %+v, want newest first", entries)
+	}
+	if entries[0].Time.IsZero() {
+		t.Error("Record() should set the entry time")
+	}
+
+	if entries, _ := log.Recent(ctx, 1); len(entries) != 1 || entries[0].Actor != "bob" {
+		t.Errorf("Recent(1) = %+v, want the newest entry", entries)
+	}
+}
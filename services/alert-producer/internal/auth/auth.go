@@ -0,0 +1,179 @@
+// Package auth provides API key authentication for the alert-producer HTTP API.
+// Each key belongs to a named caller; admin keys may see and stop every caller's jobs.
+// Keys are configured statically and/or issued by rule-service. When there are neither,
+// authentication is disabled and every request acts as an admin.
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// MinKeyLength is the minimum length of an API key.
+const MinKeyLength = 16
+
+// Anonymous is the principal of requests when authentication is disabled.
+var Anonymous = Principal{Name: "anonymous", Admin: true}
+
+// Principal identifies the caller of an authenticated request.
+type Principal struct {
+	Name  string
+	Admin bool
+}
+
+// CanAccess reports whether p may see or stop a job owned by owner.
+func (p Principal) CanAccess(owner string) bool {
+	return p.Admin || p.Name == owner
+}
+
+// Keys holds the configured API keys, indexed by their SHA-256 digest so lookups
+// do not compare secrets byte by byte.
+type Keys struct {
+	principals map[[sha256.Size]byte]Principal
+	issued     *shared.APIKeyAuthenticator
+}
+
+// ParseKeys parses a comma-separated list of name:key or name:key:admin entries.
+// An empty spec returns an empty key set, which disables authentication.
+func ParseKeys(spec string) (*Keys, error) {
+	keys := &Keys{principals: make(map[[sha256.Size]byte]Principal)}
+	names := make(map[string]bool)
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.Split(entry, ":")
+		if len(parts) < 2 || len(parts) > 3 {
+			return nil, fmt.Errorf("invalid api key entry %q: want name:key or name:key:admin", entry)
+		}
+		name, key := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		if name == "" {
+			return nil, fmt.Errorf("api key entry has an empty name")
+		}
+		if len(key) < MinKeyLength {
+			return nil, fmt.Errorf("api key for %s must be at least %d characters", name, MinKeyLength)
+		}
+		admin := false
+		if len(parts) == 3 {
+			if strings.TrimSpace(parts[2]) != "admin" {
+				return nil, fmt.Errorf("invalid api key role %q for %s: only admin is supported", parts[2], name)
+			}
+			admin = true
+		}
+		if names[name] {
+			return nil, fmt.Errorf("duplicate api key name %s", name)
+		}
+		digest := sha256.Sum256([]byte(key))
+		if _, exists := keys.principals[digest]; exists {
+			return nil, fmt.Errorf("api key for %s is already assigned to another name", name)
+		}
+		names[name] = true
+		keys.principals[digest] = Principal{Name: name, Admin: admin}
+	}
+	return keys, nil
+}
+
+// UseIssuedKeys also accepts the API keys issued by rule-service, checked with issued.
+// A client key acts as a caller named after its client; an admin key keeps its name.
+func (k *Keys) UseIssuedKeys(issued *shared.APIKeyAuthenticator) {
+	k.issued = issued
+}
+
+// Enabled reports whether any keys are configured.
+func (k *Keys) Enabled() bool {
+	return k != nil && (len(k.principals) > 0 || k.issued != nil)
+}
+
+// Len returns the number of configured keys.
+func (k *Keys) Len() int {
+	if k == nil {
+		return 0
+	}
+	return len(k.principals)
+}
+
+// Authenticate returns the principal for the key in r, read from the X-API-Key header
+// or an "Authorization: Bearer" header. Static keys are checked first, then issued keys;
+// err is set only if issued keys could not be checked.
+func (k *Keys) Authenticate(r *http.Request) (p Principal, ok bool, err error) {
+	key := r.Header.Get("X-API-Key")
+	if key == "" {
+		if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			key = strings.TrimSpace(bearer)
+		}
+	}
+	if key == "" || k == nil {
+		return Principal{}, false, nil
+	}
+	if p, ok := k.principals[sha256.Sum256([]byte(key))]; ok {
+		return p, true, nil
+	}
+	if k.issued == nil {
+		return Principal{}, false, nil
+	}
+	issued, err := k.issued.Authenticate(r.Context(), key)
+	if err != nil || issued == nil {
+		return Principal{}, false, err
+	}
+	if issued.Admin() {
+		return Principal{Name: issued.Name, Admin: true}, true, nil
+	}
+	return Principal{Name: issued.ClientID}, true, nil
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying p.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal stored in ctx, or Anonymous if there is none.
+func FromContext(ctx context.Context) Principal {
+	if p, ok := ctx.Value(principalKey{}).(Principal); ok {
+		return p
+	}
+	return Anonymous
+}
+
+// Middleware rejects requests without a valid API key with 401, and with 503 if an issued key
+// cannot be checked, and stores the caller's principal in the request context. Paths in public
+// (e.g. /health) are not authenticated. When keys is empty, requests pass through as Anonymous.
+func Middleware(keys *Keys, public ...string) func(http.Handler) http.Handler {
+	open := make(map[string]bool, len(public))
+	for _, path := range public {
+		open[path] = true
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !keys.Enabled() || open[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+			p, ok, err := keys.Authenticate(r)
+			if err != nil {
+				slog.Error("Failed to authenticate API key", "path", r.URL.Path, "error", err)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				w.Write([]byte(`{"error":"authentication unavailable"}` + "\n"))
+				return
+			}
+			if !ok {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("WWW-Authenticate", `Bearer realm="alert-producer"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"error":"missing or invalid API key"}` + "\n"))
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), p)))
+		})
+	}
+}
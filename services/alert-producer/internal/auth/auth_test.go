@@ -0,0 +1,186 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+const (
+	aliceKey = "alice-key-0123456789"
+	opsKey   = "ops-key-0123456789ab"
+)
+
+func TestParseKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		wantLen int
+		wantErr bool
+	}{
+		{name: "empty disables auth", spec: "", wantLen: 0},
+		{name: "user and admin", spec: "alice:" + aliceKey + ", ops:" + opsKey + ":admin", wantLen: 2},
+		{name: "missing key", spec: "alice", wantErr: true},
+		{name: "short key", spec: "alice:short", wantErr: true},
+		{name: "unknown role", spec: "alice:" + aliceKey + ":root", wantErr: true},
+		{name: "duplicate name", spec: "alice:" + aliceKey + ",alice:" + opsKey, wantErr: true},
+		{name: "shared key", spec: "alice:" + aliceKey + ",bob:" + aliceKey, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := ParseKeys(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseKeys() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && keys.Len() != tt.wantLen {
+				t.Errorf("Len() = %d, want %d", keys.Len(), tt.wantLen)
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	keys, err := ParseKeys("alice:" + aliceKey + ",ops:" + opsKey + ":admin")
+	if err != nil {
+		t.Fatalf("ParseKeys() error = %v", err)
+	}
+
+	var got Principal
+	handler := Middleware(keys, "/health")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = FromContext(r.Context())
+	}))
+
+	tests := []struct {
+		name       string
+		path       string
+		header     string
+		value      string
+		wantStatus int
+		want       Principal
+	}{
+		{name: "x-api-key", path: "/api", header: "X-API-Key", value: aliceKey, wantStatus: http.StatusOK, want: Principal{Name: "alice"}},
+		{name: "bearer admin", path: "/api", header: "Authorization", value: "Bearer " + opsKey, wantStatus: http.StatusOK, want: Principal{Name: "ops", Admin: true}},
+		{name: "missing key", path: "/api", wantStatus: http.StatusUnauthorized},
+		{name: "wrong key", path: "/api", header: "X-API-Key", value: "not-a-valid-key-at-all", wantStatus: http.StatusUnauthorized},
+		{name: "public path", path: "/health", wantStatus: http.StatusOK, want: Anonymous},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = Principal{}
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got != tt.want {
+				t.Errorf("principal = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("no keys configured", func(t *testing.T) {
+		got = Principal{}
+		Middleware(&Keys{}, "/health")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = FromContext(r.Context())
+		})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api", nil))
+		if got != Anonymous {
+			t.Errorf("principal = %+v, want Anonymous", got)
+		}
+	})
+}
+
+// stubIssuedKeys is a shared.APIKeyStore of fixed issued keys.
+type stubIssuedKeys struct {
+	keys map[string]shared.APIPrincipal
+	err  error
+}
+
+func (s stubIssuedKeys) LookupAPIKey(ctx context.Context, keyHash string) (*shared.APIPrincipal, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	for key, p := range s.keys {
+		if shared.HashAPIKey(key) == keyHash {
+			return &p, nil
+		}
+	}
+	return nil, nil
+}
+
+func TestMiddleware_IssuedKeys(t *testing.T) {
+	keys, err := ParseKeys("alice:" + aliceKey)
+	if err != nil {
+		t.Fatalf("ParseKeys() error = %v", err)
+	}
+	keys.UseIssuedKeys(shared.NewAPIKeyAuthenticator(stubIssuedKeys{keys: map[string]shared.APIPrincipal{
+		"ak_client": {KeyID: "k1", Name: "ci", ClientID: "acme"},
+		"ak_admin":  {KeyID: "k2", Name: "ops"},
+	}}, "", 0))
+
+	var got Principal
+	handler := Middleware(keys, "/health")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = FromContext(r.Context())
+	}))
+
+	tests := []struct {
+		name       string
+		key        string
+		wantStatus int
+		want       Principal
+	}{
+		{name: "static key", key: aliceKey, wantStatus: http.StatusOK, want: Principal{Name: "alice"}},
+		{name: "issued client key", key: "ak_client", wantStatus: http.StatusOK, want: Principal{Name: "acme"}},
+		{name: "issued admin key", key: "ak_admin", wantStatus: http.StatusOK, want: Principal{Name: "ops", Admin: true}},
+		{name: "unknown key", key: "ak_unknown", wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = Principal{}
+			req := httptest.NewRequest(http.MethodGet, "/api", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.key)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got != tt.want {
+				t.Errorf("principal = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("store unavailable", func(t *testing.T) {
+		keys := &Keys{}
+		keys.UseIssuedKeys(shared.NewAPIKeyAuthenticator(stubIssuedKeys{err: errors.New("connection refused")}, "", 0))
+		req := httptest.NewRequest(http.MethodGet, "/api", nil)
+		req.Header.Set("X-API-Key", "ak_client")
+		w := httptest.NewRecorder()
+		Middleware(keys)(http.NotFoundHandler()).ServeHTTP(w, req)
+
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("status = %d, want %d", w.Code, http.StatusServiceUnavailable)
+		}
+	})
+}
+
+func TestPrincipal_CanAccess(t *testing.T) {
+	alice := Principal{Name: "alice"}
+	if !alice.CanAccess("alice") || alice.CanAccess("bob") {
+		t.Error("non-admin should access only its own jobs")
+	}
+	if !(Principal{Name: "ops", Admin: true}).CanAccess("bob") {
+		t.Error("admin should access every job")
+	}
+}
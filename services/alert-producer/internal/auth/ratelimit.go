@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+
+	"github.com/afikmenashe/alerting-platform/pkg/metrics"
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// RateLimiter decides whether a caller may make another request.
+// Implemented by *shared.RateLimiter.
+type RateLimiter interface {
+	Allow(ctx context.Context, caller string) (shared.RateLimitDecision, error)
+}
+
+// rateLimitCaller identifies the caller of r for rate limiting: the name of its API key,
+// or its remote address when authentication is disabled.
+func rateLimitCaller(r *http.Request) string {
+	if p, ok := r.Context().Value(principalKey{}).(Principal); ok {
+		return p.Name
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// RateLimitMiddleware rejects callers over their tier's limit with 429 and a Retry-After header,
+// counting them in collector. It must run after Middleware so callers are identified by API key.
+// Paths in public are not limited. If the limiter fails (e.g. Redis is down), requests are let through.
+func RateLimitMiddleware(limiter RateLimiter, collector metrics.Collector, public ...string) func(http.Handler) http.Handler {
+	open := make(map[string]bool, len(public))
+	for _, path := range public {
+		open[path] = true
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if limiter == nil || open[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			caller := rateLimitCaller(r)
+			decision, err := limiter.Allow(r.Context(), caller)
+			if err != nil {
+				slog.Warn("Rate limit check failed, allowing request", "error", err, "caller", caller)
+				if collector != nil {
+					collector.IncrementCustom("rate_limit_errors")
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !decision.Allowed {
+				if collector != nil {
+					collector.IncrementCustom("rate_limited_requests")
+					collector.IncrementCustom("rate_limited_" + decision.Tier)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Retry-After", shared.RetryAfterSeconds(decision.RetryAfter))
+				w.WriteHeader(http.StatusTooManyRequests)
+				w.Write([]byte(`{"error":"rate limit exceeded"}` + "\n"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
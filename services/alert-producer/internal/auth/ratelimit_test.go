@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/afikmenashe/alerting-platform/pkg/metrics"
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// fakeLimiter is a test fake for RateLimiter returning a fixed decision and recording callers.
+type fakeLimiter struct {
+	decision shared.RateLimitDecision
+	err      error
+	callers  []string
+}
+
+func (f *fakeLimiter) Allow(ctx context.Context, caller string) (shared.RateLimitDecision, error) {
+	f.callers = append(f.callers, caller)
+	return f.decision, f.err
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	keys, err := ParseKeys("alice:" + aliceKey)
+	if err != nil {
+		t.Fatalf("ParseKeys() error = %v", err)
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name           string
+		limiter        *fakeLimiter
+		wantStatus     int
+		wantRetryAfter string
+		wantCounter    string
+	}{
+		{name: "allowed", limiter: &fakeLimiter{decision: shared.RateLimitDecision{Allowed: true, Tier: "default"}}, wantStatus: http.StatusOK},
+		{name: "throttled", limiter: &fakeLimiter{decision: shared.RateLimitDecision{Tier: "default", RetryAfter: 200 * time.Millisecond}}, wantStatus: http.StatusTooManyRequests, wantRetryAfter: "1", wantCounter: "rate_limited_requests"},
+		{name: "limiter error fails open", limiter: &fakeLimiter{err: errors.New("redis down")}, wantStatus: http.StatusOK, wantCounter: "rate_limit_errors"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collector := metrics.NewMemoryCollector("alert-producer")
+			handler := Middleware(keys, "/health")(RateLimitMiddleware(tt.limiter, collector, "/health")(ok))
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/alerts/ingest", nil)
+			req.Header.Set("X-API-Key", aliceKey)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Retry-After"); got != tt.wantRetryAfter {
+				t.Errorf("Retry-After = %q, want %q", got, tt.wantRetryAfter)
+			}
+			if len(tt.limiter.callers) != 1 || tt.limiter.callers[0] != "alice" {
+				t.Errorf("callers = %v, want [alice]", tt.limiter.callers)
+			}
+			if tt.wantCounter != "" && collector.Custom(tt.wantCounter) != 1 {
+				t.Errorf("%s = %d, want 1", tt.wantCounter, collector.Custom(tt.wantCounter))
+			}
+		})
+	}
+}
+
+func TestRateLimitMiddleware_Unauthenticated(t *testing.T) {
+	limiter := &fakeLimiter{decision: shared.RateLimitDecision{Allowed: true}}
+	handler := RateLimitMiddleware(limiter, nil, "/health")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/alerts/generate/list", nil)
+	req.RemoteAddr = "10.0.0.7:51234"
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	if len(limiter.callers) != 1 || limiter.callers[0] != "ip:10.0.0.7" {
+		t.Errorf("callers = %v, want [ip:10.0.0.7]", limiter.callers)
+	}
+}
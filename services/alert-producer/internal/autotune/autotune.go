@@ -0,0 +1,203 @@
+// Package autotune finds the highest alert rate the pipeline sustains within a latency target.
+// It publishes alerts in steps of increasing RPS and, after each step, reads the pipeline
+// latency that metrics-service reports for the notifications delivered during the step.
+package autotune
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/afikmenashe/alerting-platform/pkg/metrics"
+)
+
+// Reasons the ramp stopped.
+const (
+	// StopLatencyExceeded means a step's end-to-end latency exceeded the target.
+	StopLatencyExceeded = "latency_exceeded"
+	// StopProducerLimited means the producer could not publish at a step's rate, so the pipeline
+	// was not the bottleneck; the result is a lower bound.
+	StopProducerLimited = "producer_limited"
+	// StopNoNotifications means no notification was delivered during a step, so latency could
+	// not be measured (no rule matched the generated alerts, or the pipeline is down).
+	StopNoNotifications = "no_notifications"
+	// StopMaxRPS means the next step would exceed the maximum RPS; the result is a lower bound.
+	StopMaxRPS = "max_rps"
+)
+
+// minActualRatio is the fraction of a step's target RPS the producer must reach for the step to count.
+const minActualRatio = 0.9
+
+// LatencySource reports the cumulative latency of each pipeline stage.
+// It is implemented by LatencyClient.
+type LatencySource interface {
+	PipelineLatency(ctx context.Context) (*metrics.PipelineLatency, error)
+}
+
+// LoadFunc publishes alerts at rps for duration and returns how many it published.
+type LoadFunc func(ctx context.Context, rps float64, duration time.Duration) (int, error)
+
+// Config controls the ramp.
+type Config struct {
+	StartRPS      float64
+	MaxRPS        float64
+	StepFactor    float64       // RPS multiplier between steps, > 1
+	StepDuration  time.Duration // how long each step publishes
+	Settle        time.Duration // wait after a step for its alerts to be delivered before reading latency
+	TargetLatency time.Duration // highest sustainable end-to-end latency
+}
+
+// Step is the outcome of publishing at one rate.
+type Step struct {
+	RPS           float64 `json:"rps"`
+	ActualRPS     float64 `json:"actual_rps"`
+	Notifications uint64  `json:"notifications"`
+	LatencyMs     float64 `json:"latency_ms"`
+	Sustained     bool    `json:"sustained"`
+}
+
+// Result reports the highest rate sustained within the latency target.
+type Result struct {
+	// MaxSustainableRPS is the rate of the last sustained step, 0 if none was
+	MaxSustainableRPS float64 `json:"max_sustainable_rps"`
+	TargetLatencyMs   int64   `json:"target_latency_ms"`
+	StopReason        string  `json:"stop_reason"`
+	Steps             []Step  `json:"steps"`
+}
+
+// Tuner ramps the alert rate until the pipeline's latency exceeds the target.
+type Tuner struct {
+	cfg     Config
+	load    LoadFunc
+	latency LatencySource
+	sleep   func(ctx context.Context, d time.Duration) error
+}
+
+// NewTuner creates a tuner that publishes with load and measures with latency.
+func NewTuner(cfg Config, load LoadFunc, latency LatencySource) *Tuner {
+	return &Tuner{
+		cfg:     cfg,
+		load:    load,
+		latency: latency,
+		sleep:   sleepContext,
+	}
+}
+
+// Run ramps from StartRPS, multiplying the rate by StepFactor after each sustained step, until a
+// step is not sustained or the next one would exceed MaxRPS.
+// End-to-end latency is the sum of the average latency of each pipeline stage (evaluated to
+// delivered) over the notifications recorded during the step and its settle time.
+func (t *Tuner) Run(ctx context.Context) (*Result, error) {
+	result := &Result{TargetLatencyMs: t.cfg.TargetLatency.Milliseconds()}
+	for rps := t.cfg.StartRPS; ; rps *= t.cfg.StepFactor {
+		if rps > t.cfg.MaxRPS {
+			result.StopReason = StopMaxRPS
+			return result, nil
+		}
+
+		step, reason, err := t.runStep(ctx, rps)
+		if err != nil {
+			return result, err
+		}
+		result.Steps = append(result.Steps, *step)
+		slog.Info("Auto-tune step finished",
+			"rps", step.RPS,
+			"actual_rps", formatRate(step.ActualRPS),
+			"notifications", step.Notifications,
+			"latency_ms", formatRate(step.LatencyMs),
+			"sustained", step.Sustained,
+		)
+		if !step.Sustained {
+			result.StopReason = reason
+			return result, nil
+		}
+		result.MaxSustainableRPS = rps
+	}
+}
+
+// runStep publishes at rps for a step and measures it. Returns the reason the step was not
+// sustained, if it was not.
+func (t *Tuner) runStep(ctx context.Context, rps float64) (*Step, string, error) {
+	before, err := t.latency.PipelineLatency(ctx)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read pipeline latency: %w", err)
+	}
+
+	start := time.Now()
+	sent, err := t.load(ctx, rps, t.cfg.StepDuration)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to publish at %.1f rps: %w", rps, err)
+	}
+	elapsed := time.Since(start)
+
+	if err := t.sleep(ctx, t.cfg.Settle); err != nil {
+		return nil, "", err
+	}
+	after, err := t.latency.PipelineLatency(ctx)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read pipeline latency: %w", err)
+	}
+
+	step := &Step{RPS: rps}
+	if elapsed > 0 {
+		step.ActualRPS = float64(sent) / elapsed.Seconds()
+	}
+	step.LatencyMs, step.Notifications = endToEndLatency(before, after)
+
+	switch {
+	case step.ActualRPS < rps*minActualRatio:
+		return step, StopProducerLimited, nil
+	case step.Notifications == 0:
+		return step, StopNoNotifications, nil
+	case step.LatencyMs > float64(t.cfg.TargetLatency.Milliseconds()):
+		return step, StopLatencyExceeded, nil
+	}
+	step.Sustained = true
+	return step, "", nil
+}
+
+// endToEndLatency returns the sum of the average latency of each stage over the notifications
+// recorded between before and after, and how many notifications were delivered in between.
+func endToEndLatency(before, after *metrics.PipelineLatency) (float64, uint64) {
+	var totalMs float64
+	for _, stage := range []string{metrics.LatencyStageAggregation, metrics.LatencyStageReady, metrics.LatencyStageDelivery} {
+		b, a := stageOf(before, stage), stageOf(after, stage)
+		if a.Count <= b.Count {
+			continue
+		}
+		stageTotalMs := a.AvgLatencyMs*float64(a.Count) - b.AvgLatencyMs*float64(b.Count)
+		totalMs += stageTotalMs / float64(a.Count-b.Count)
+	}
+
+	delivered := stageOf(after, metrics.LatencyStageDelivery).Count
+	if d := stageOf(before, metrics.LatencyStageDelivery).Count; d < delivered {
+		return totalMs, delivered - d
+	}
+	return totalMs, 0
+}
+
+// stageOf returns the latency of a stage, zero if it was never recorded.
+func stageOf(latency *metrics.PipelineLatency, stage string) metrics.StageLatency {
+	if latency == nil || latency.Stages[stage] == nil {
+		return metrics.StageLatency{}
+	}
+	return *latency.Stages[stage]
+}
+
+// formatRate rounds a rate for logging.
+func formatRate(rate float64) string {
+	return fmt.Sprintf("%.1f", rate)
+}
+
+// sleepContext waits for d, or until ctx is cancelled.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,179 @@
+package autotune
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/afikmenashe/alerting-platform/pkg/metrics"
+)
+
+// fakePipeline is a LatencySource whose stage latency grows with the rate of the last load.
+type fakePipeline struct {
+	latency       map[string]*metrics.StageLatency
+	msPerRPS      float64 // delivery latency per alert/s
+	deliveredFrac float64 // fraction of published alerts delivered
+}
+
+func newFakePipeline(msPerRPS float64) *fakePipeline {
+	return &fakePipeline{latency: make(map[string]*metrics.StageLatency), msPerRPS: msPerRPS, deliveredFrac: 1}
+}
+
+func (f *fakePipeline) PipelineLatency(context.Context) (*metrics.PipelineLatency, error) {
+	stages := make(map[string]*metrics.StageLatency, len(f.latency))
+	for stage, l := range f.latency {
+		copied := *l
+		stages[stage] = &copied
+	}
+	return &metrics.PipelineLatency{Stages: stages}, nil
+}
+
+// record adds n notifications that spent latencyMs in stage.
+func (f *fakePipeline) record(stage string, n uint64, latencyMs float64) {
+	l := f.latency[stage]
+	if l == nil {
+		l = &metrics.StageLatency{}
+		f.latency[stage] = l
+	}
+	total := l.AvgLatencyMs*float64(l.Count) + latencyMs*float64(n)
+	l.Count += n
+	l.AvgLatencyMs = total / float64(l.Count)
+}
+
+func (f *fakePipeline) load(_ context.Context, rps float64, duration time.Duration) (int, error) {
+	sent := int(rps * duration.Seconds())
+	delivered := uint64(float64(sent) * f.deliveredFrac)
+	f.record(metrics.LatencyStageAggregation, delivered, 5)
+	f.record(metrics.LatencyStageReady, delivered, 5)
+	f.record(metrics.LatencyStageDelivery, delivered, rps*f.msPerRPS)
+	return sent, nil
+}
+
+func newTestTuner(pipeline *fakePipeline, cfg Config) *Tuner {
+	tuner := NewTuner(cfg, pipeline.load, pipeline)
+	tuner.sleep = func(context.Context, time.Duration) error { return nil }
+	return tuner
+}
+
+func TestTuner_StopsWhenLatencyExceedsTarget(t *testing.T) {
+	// Delivery takes 1ms per alert/s, plus 10ms in the other stages: 400 rps takes 410ms, 800 rps 810ms
+	pipeline := newFakePipeline(1)
+	tuner := newTestTuner(pipeline, Config{
+		StartRPS:      100,
+		MaxRPS:        10000,
+		StepFactor:    2,
+		StepDuration:  time.Second,
+		TargetLatency: 600 * time.Millisecond,
+	})
+
+	result, err := tuner.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.MaxSustainableRPS != 400 || result.StopReason != StopLatencyExceeded {
+		t.Errorf("Run() = %v rps (%s), want 400 rps (latency_exceeded)", result.MaxSustainableRPS, result.StopReason)
+	}
+	if len(result.Steps) != 4 {
+		t.Fatalf("steps = %+v, want 100, 200, 400 and 800 rps", result.Steps)
+	}
+	last := result.Steps[3]
+	if last.Sustained || last.LatencyMs != 810 || last.Notifications != 800 {
+		t.Errorf("last step = %+v, want 800 notifications at 810ms, not sustained", last)
+	}
+}
+
+func TestTuner_StopReasons(t *testing.T) {
+	t.Run("max rps", func(t *testing.T) {
+		tuner := newTestTuner(newFakePipeline(0), Config{
+			StartRPS: 10, MaxRPS: 50, StepFactor: 2, StepDuration: time.Second, TargetLatency: time.Second,
+		})
+		result, err := tuner.Run(context.Background())
+		if err != nil || result.MaxSustainableRPS != 40 || result.StopReason != StopMaxRPS {
+			t.Errorf("Run() = %+v, %v, want 40 rps (max_rps)", result, err)
+		}
+	})
+
+	t.Run("no notifications", func(t *testing.T) {
+		pipeline := newFakePipeline(0)
+		pipeline.deliveredFrac = 0
+		tuner := newTestTuner(pipeline, Config{
+			StartRPS: 10, MaxRPS: 50, StepFactor: 2, StepDuration: time.Second, TargetLatency: time.Second,
+		})
+		result, err := tuner.Run(context.Background())
+		if err != nil || result.MaxSustainableRPS != 0 || result.StopReason != StopNoNotifications {
+			t.Errorf("Run() = %+v, %v, want 0 rps (no_notifications)", result, err)
+		}
+	})
+
+	t.Run("producer limited", func(t *testing.T) {
+		pipeline := newFakePipeline(0)
+		tuner := NewTuner(Config{
+			StartRPS: 10, MaxRPS: 50, StepFactor: 2, StepDuration: time.Second, TargetLatency: time.Second,
+		}, func(ctx context.Context, rps float64, duration time.Duration) (int, error) {
+			time.Sleep(10 * time.Millisecond)
+			return 0, nil
+		}, pipeline)
+		tuner.sleep = func(context.Context, time.Duration) error { return nil }
+
+		result, err := tuner.Run(context.Background())
+		if err != nil || result.StopReason != StopProducerLimited {
+			t.Errorf("Run() = %+v, %v, want producer_limited", result, err)
+		}
+	})
+
+	t.Run("load failure", func(t *testing.T) {
+		tuner := NewTuner(Config{
+			StartRPS: 10, MaxRPS: 50, StepFactor: 2, StepDuration: time.Second, TargetLatency: time.Second,
+		}, func(context.Context, float64, time.Duration) (int, error) {
+			return 0, errors.New("kafka down")
+		}, newFakePipeline(0))
+		if _, err := tuner.Run(context.Background()); err == nil {
+			t.Error("Run() expected error when publishing fails")
+		}
+	})
+}
+
+func TestEndToEndLatency(t *testing.T) {
+	before := &metrics.PipelineLatency{Stages: map[string]*metrics.StageLatency{
+		metrics.LatencyStageDelivery: {Count: 10, AvgLatencyMs: 100},
+	}}
+	after := &metrics.PipelineLatency{Stages: map[string]*metrics.StageLatency{
+		metrics.LatencyStageAggregation: {Count: 10, AvgLatencyMs: 20},
+		metrics.LatencyStageDelivery:    {Count: 20, AvgLatencyMs: 200},
+	}}
+
+	latencyMs, delivered := endToEndLatency(before, after)
+	// Delivery: the 10 new notifications took (20*200 - 10*100) / 10 = 300ms
+	if latencyMs != 320 || delivered != 10 {
+		t.Errorf("endToEndLatency() = %v, %d, want 320, 10", latencyMs, delivered)
+	}
+}
+
+func TestLatencyClient_PipelineLatency(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/latency" || r.Header.Get("X-API-Key") != "secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(metrics.PipelineLatency{Stages: map[string]*metrics.StageLatency{
+			metrics.LatencyStageDelivery: {Count: 3, AvgLatencyMs: 12.5},
+		}})
+	}))
+	defer server.Close()
+
+	latency, err := NewLatencyClient(server.URL+"/", "secret").PipelineLatency(context.Background())
+	if err != nil {
+		t.Fatalf("PipelineLatency() error = %v", err)
+	}
+	if got := latency.Stages[metrics.LatencyStageDelivery]; got == nil || got.Count != 3 || got.AvgLatencyMs != 12.5 {
+		t.Errorf("delivery latency = %+v, want 3 at 12.5ms", got)
+	}
+
+	if _, err := NewLatencyClient(server.URL, "").PipelineLatency(context.Background()); err == nil {
+		t.Error("PipelineLatency() expected error for a rejected request")
+	}
+}
@@ -0,0 +1,60 @@
+package autotune
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/afikmenashe/alerting-platform/pkg/metrics"
+)
+
+// latencyTimeout bounds a single latency request to metrics-service.
+const latencyTimeout = 5 * time.Second
+
+// LatencyClient reads pipeline latency from metrics-service's GET /api/v1/latency.
+type LatencyClient struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewLatencyClient creates a client for the metrics-service at baseURL (e.g. http://localhost:8083).
+// apiKey is sent as X-API-Key when non-empty, for a metrics-service running with -api-key-auth.
+func NewLatencyClient(baseURL, apiKey string) *LatencyClient {
+	return &LatencyClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		httpClient: &http.Client{Timeout: latencyTimeout},
+	}
+}
+
+// PipelineLatency returns the cumulative latency recorded for each pipeline stage.
+func (c *LatencyClient) PipelineLatency(ctx context.Context) (*metrics.PipelineLatency, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/api/v1/latency", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create latency request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("latency request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("metrics-service returned status %d", resp.StatusCode)
+	}
+
+	var latency metrics.PipelineLatency
+	if err := json.NewDecoder(resp.Body).Decode(&latency); err != nil {
+		return nil, fmt.Errorf("failed to decode pipeline latency: %w", err)
+	}
+	return &latency, nil
+}
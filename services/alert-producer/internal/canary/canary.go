@@ -0,0 +1,80 @@
+// Package canary emits synthetic pipeline canary alerts.
+// Each canary matches the seeded canary rule, flows through evaluator, aggregator, and
+// sender to a null endpoint, and the sender records its end-to-end latency.
+package canary
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"alert-producer/internal/generator"
+	"alert-producer/internal/producer"
+)
+
+// DefaultInterval is the default time between canary alerts.
+const DefaultInterval = 30 * time.Second
+
+// SentRecorder records canary emissions.
+type SentRecorder interface {
+	RecordCanarySent(ctx context.Context, alertID string, sentAt time.Time) error
+}
+
+// Emitter periodically publishes canary alerts.
+type Emitter struct {
+	publisher producer.AlertPublisher
+	recorder  SentRecorder
+	interval  time.Duration
+	now       func() time.Time
+}
+
+// NewEmitter creates a canary emitter. A non-positive interval uses DefaultInterval.
+func NewEmitter(publisher producer.AlertPublisher, recorder SentRecorder, interval time.Duration) *Emitter {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	return &Emitter{
+		publisher: publisher,
+		recorder:  recorder,
+		interval:  interval,
+		now:       time.Now,
+	}
+}
+
+// Run emits a canary immediately and then every interval until ctx is cancelled.
+func (e *Emitter) Run(ctx context.Context) {
+	slog.Info("Starting pipeline canary", "interval", e.interval)
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := e.EmitOnce(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("Failed to emit canary alert", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			slog.Info("Pipeline canary stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// EmitOnce publishes a single canary alert and records it as sent.
+func (e *Emitter) EmitOnce(ctx context.Context) error {
+	sentAt := e.now()
+	alert := generator.GenerateCanaryAlert(sentAt)
+
+	if err := e.publisher.Publish(ctx, alert); err != nil {
+		return err
+	}
+
+	if err := e.recorder.RecordCanarySent(ctx, alert.AlertID, sentAt); err != nil {
+		slog.Warn("Failed to record canary sent", "alert_id", alert.AlertID, "error", err)
+	}
+
+	slog.Debug("Emitted canary alert", "alert_id", alert.AlertID)
+	return nil
+}
@@ -0,0 +1,87 @@
+package canary
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"alert-producer/internal/generator"
+
+	"github.com/afikmenashe/alerting-platform/pkg/metrics"
+)
+
+// fakePublisher is a test fake for producer.AlertPublisher.
+type fakePublisher struct {
+	published []*generator.Alert
+	err       error
+}
+
+func (f *fakePublisher) Publish(ctx context.Context, alert *generator.Alert) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.published = append(f.published, alert)
+	return nil
+}
+
+func (f *fakePublisher) Close() error { return nil }
+
+// fakeRecorder is a test fake for SentRecorder.
+type fakeRecorder struct {
+	alertIDs []string
+	sentAt   []time.Time
+}
+
+func (f *fakeRecorder) RecordCanarySent(ctx context.Context, alertID string, sentAt time.Time) error {
+	f.alertIDs = append(f.alertIDs, alertID)
+	f.sentAt = append(f.sentAt, sentAt)
+	return nil
+}
+
+func TestEmitter_EmitOnce(t *testing.T) {
+	publisher := &fakePublisher{}
+	recorder := &fakeRecorder{}
+	now := time.UnixMilli(1700000000123)
+
+	e := NewEmitter(publisher, recorder, time.Second)
+	e.now = func() time.Time { return now }
+
+	if err := e.EmitOnce(context.Background()); err != nil {
+		t.Fatalf("EmitOnce() error = %v", err)
+	}
+	if len(publisher.published) != 1 {
+		t.Fatalf("published %d alerts, want 1", len(publisher.published))
+	}
+
+	alert := publisher.published[0]
+	if alert.Severity != metrics.CanarySeverity || alert.Source != metrics.CanarySource || alert.Name != metrics.CanaryName {
+		t.Errorf("alert = %+v, want canary severity/source/name", alert)
+	}
+	if !metrics.IsCanary(alert.Context) {
+		t.Errorf("alert context %v is not marked as canary", alert.Context)
+	}
+	if sentAt, ok := metrics.CanarySentAt(alert.Context); !ok || !sentAt.Equal(now) {
+		t.Errorf("canary sent at = %v, want %v", sentAt, now)
+	}
+	if len(recorder.alertIDs) != 1 || recorder.alertIDs[0] != alert.AlertID {
+		t.Errorf("recorded %v, want [%s]", recorder.alertIDs, alert.AlertID)
+	}
+}
+
+func TestEmitter_EmitOnce_PublishError(t *testing.T) {
+	publisher := &fakePublisher{err: errors.New("kafka down")}
+	recorder := &fakeRecorder{}
+
+	e := NewEmitter(publisher, recorder, 0)
+
+	if err := e.EmitOnce(context.Background()); err == nil {
+		t.Error("EmitOnce() expected error")
+	}
+	if len(recorder.alertIDs) != 0 {
+		t.Errorf("recorded %v after failed publish, want none", recorder.alertIDs)
+	}
+	if e.interval != DefaultInterval {
+		t.Errorf("interval = %v, want %v", e.interval, DefaultInterval)
+	}
+}
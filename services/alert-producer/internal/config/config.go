@@ -20,8 +20,36 @@ type Config struct {
 	SourceDist   string
 	NameDist     string
 	RedisAddr    string
+	// ClientHint restricts matching of generated alerts to this client's rules (empty for all clients)
+	ClientHint string
+
+	// AutoTune ramps the rate up from RPS until the pipeline latency reported by the
+	// metrics-service at MetricsURL exceeds TargetLatency, instead of publishing at RPS
+	AutoTune         bool
+	MetricsURL       string
+	MetricsAPIKey    string
+	TargetLatency    time.Duration
+	TuneMaxRPS       float64
+	TuneStepFactor   float64
+	TuneStepDuration time.Duration
+	TuneSettle       time.Duration
+
+	// Replay republishes historical alerts instead of generating them, from the notifications
+	// table at PostgresDSN ("postgres") or from an NDJSON alert log (a file path, "-" for stdin)
+	Replay         string
+	PostgresDSN    string
+	ReplaySince    time.Time
+	ReplayUntil    time.Time
+	ReplayClientID string
+	ReplayLimit    int
+	ReplaySpeed    float64
+	ReplayMaxGap   time.Duration
+	ReplayKeepIDs  bool
 }
 
+// ReplayPostgres is the Replay source that reads the notifications table.
+const ReplayPostgres = "postgres"
+
 // Validate checks that all required configuration fields are set and have valid values.
 // It also validates that distribution strings are properly formatted and sum to 100.
 // Returns an error if validation fails, nil otherwise.
@@ -49,10 +77,74 @@ func (c *Config) Validate() error {
 	if _, err := ParseDistribution(c.NameDist); err != nil {
 		return fmt.Errorf("invalid name-dist: %w", err)
 	}
+	if c.AutoTune {
+		if err := c.validateAutoTune(); err != nil {
+			return err
+		}
+	}
+	if c.Replay != "" {
+		if err := c.validateReplay(); err != nil {
+			return err
+		}
+	}
 	
 	return nil
 }
 
+// validateAutoTune checks the auto-tune parameters.
+func (c *Config) validateAutoTune() error {
+	if c.BurstSize > 0 {
+		return fmt.Errorf("auto-tune cannot be combined with burst")
+	}
+	if c.MetricsURL == "" {
+		return fmt.Errorf("metrics-url cannot be empty with auto-tune")
+	}
+	if c.TargetLatency <= 0 {
+		return fmt.Errorf("target-latency must be > 0")
+	}
+	if c.TuneStepFactor <= 1 {
+		return fmt.Errorf("tune-step-factor must be > 1")
+	}
+	if c.TuneStepDuration <= 0 {
+		return fmt.Errorf("tune-step-duration must be > 0")
+	}
+	if c.TuneSettle < 0 {
+		return fmt.Errorf("tune-settle cannot be negative")
+	}
+	if c.TuneMaxRPS < c.RPS {
+		return fmt.Errorf("tune-max-rps must be >= rps")
+	}
+	return nil
+}
+
+// validateReplay checks the replay parameters.
+func (c *Config) validateReplay() error {
+	if c.AutoTune || c.BurstSize > 0 {
+		return fmt.Errorf("replay cannot be combined with auto-tune or burst")
+	}
+	if c.Replay == ReplayPostgres {
+		if c.PostgresDSN == "" {
+			return fmt.Errorf("postgres-dsn cannot be empty when replaying from postgres")
+		}
+		if c.ReplaySince.IsZero() {
+			return fmt.Errorf("replay-since is required when replaying from postgres")
+		}
+	}
+	if !c.ReplaySince.IsZero() && !c.ReplayUntil.IsZero() && !c.ReplayUntil.After(c.ReplaySince) {
+		return fmt.Errorf("replay-until must be after replay-since")
+	}
+	if c.ReplayLimit < 0 {
+		return fmt.Errorf("replay-limit cannot be negative")
+	}
+	if c.ReplaySpeed < 0 {
+		return fmt.Errorf("replay-speed cannot be negative")
+	}
+	if c.ReplayMaxGap < 0 {
+		return fmt.Errorf("replay-max-gap cannot be negative")
+	}
+	return nil
+}
+
 // ParseDistribution parses a weighted distribution string into a map of values to percentages.
 //
 // Format: "KEY1:PERCENT1,KEY2:PERCENT2,..." where percentages must sum to 100.
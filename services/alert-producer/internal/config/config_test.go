@@ -2,6 +2,7 @@ package config
 
 import (
 	"testing"
+	"time"
 )
 
 func TestParseDistribution(t *testing.T) {
@@ -204,6 +205,125 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "valid auto-tune",
+			config: Config{
+				KafkaBrokers:     "localhost:9092",
+				Topic:            "alerts.new",
+				RPS:              50,
+				Duration:         60,
+				SeverityDist:     "HIGH:100",
+				SourceDist:       "api:100",
+				NameDist:         "error:100",
+				AutoTune:         true,
+				MetricsURL:       "http://localhost:8083",
+				TargetLatency:    2 * time.Second,
+				TuneMaxRPS:       5000,
+				TuneStepFactor:   1.5,
+				TuneStepDuration: 30 * time.Second,
+			},
+			wantErr: false,
+		},
+		{
+			name: "auto-tune with a step factor of 1",
+			config: Config{
+				KafkaBrokers:     "localhost:9092",
+				Topic:            "alerts.new",
+				RPS:              50,
+				Duration:         60,
+				SeverityDist:     "HIGH:100",
+				SourceDist:       "api:100",
+				NameDist:         "error:100",
+				AutoTune:         true,
+				MetricsURL:       "http://localhost:8083",
+				TargetLatency:    2 * time.Second,
+				TuneMaxRPS:       5000,
+				TuneStepFactor:   1,
+				TuneStepDuration: 30 * time.Second,
+			},
+			wantErr: true,
+		},
+		{
+			name: "auto-tune with burst",
+			config: Config{
+				KafkaBrokers:     "localhost:9092",
+				Topic:            "alerts.new",
+				BurstSize:        100,
+				SeverityDist:     "HIGH:100",
+				SourceDist:       "api:100",
+				NameDist:         "error:100",
+				AutoTune:         true,
+				MetricsURL:       "http://localhost:8083",
+				TargetLatency:    2 * time.Second,
+				TuneMaxRPS:       5000,
+				TuneStepFactor:   1.5,
+				TuneStepDuration: 30 * time.Second,
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid replay from postgres",
+			config: Config{
+				KafkaBrokers: "localhost:9092",
+				Topic:        "alerts.new",
+				RPS:          10,
+				Duration:     60,
+				SeverityDist: "HIGH:100",
+				SourceDist:   "api:100",
+				NameDist:     "error:100",
+				Replay:       ReplayPostgres,
+				PostgresDSN:  "postgres://localhost/alerting",
+				ReplaySince:  time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+				ReplaySpeed:  10,
+			},
+			wantErr: false,
+		},
+		{
+			name: "replay from postgres without since",
+			config: Config{
+				KafkaBrokers: "localhost:9092",
+				Topic:        "alerts.new",
+				RPS:          10,
+				Duration:     60,
+				SeverityDist: "HIGH:100",
+				SourceDist:   "api:100",
+				NameDist:     "error:100",
+				Replay:       ReplayPostgres,
+				PostgresDSN:  "postgres://localhost/alerting",
+			},
+			wantErr: true,
+		},
+		{
+			name: "replay with until before since",
+			config: Config{
+				KafkaBrokers: "localhost:9092",
+				Topic:        "alerts.new",
+				RPS:          10,
+				Duration:     60,
+				SeverityDist: "HIGH:100",
+				SourceDist:   "api:100",
+				NameDist:     "error:100",
+				Replay:       "incident.ndjson",
+				ReplaySince:  time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+				ReplayUntil:  time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC),
+			},
+			wantErr: true,
+		},
+		{
+			name: "replay with negative speed",
+			config: Config{
+				KafkaBrokers: "localhost:9092",
+				Topic:        "alerts.new",
+				RPS:          10,
+				Duration:     60,
+				SeverityDist: "HIGH:100",
+				SourceDist:   "api:100",
+				NameDist:     "error:100",
+				Replay:       "incident.ndjson",
+				ReplaySpeed:  -1,
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,81 @@
+package cron
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSchedule_Next(t *testing.T) {
+	// Wednesday 2024-05-01 10:30 UTC
+	from := time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2024, 5, 1, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 5, 1, 10, 45, 0, 0, time.UTC)},
+		{"0 9 * * MON-FRI", time.Date(2024, 5, 2, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.Date(2024, 5, 2, 9, 0, 0, 0, time.UTC)},
+		{"30 10 * * *", time.Date(2024, 5, 2, 10, 30, 0, 0, time.UTC)},
+		{"0 8-18/2 * * *", time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+		{"0 0 * * sun", time.Date(2024, 5, 5, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 5, 5, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 JAN *", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// Both day fields restricted: either matches (the 15th, or a Friday)
+		{"0 0 15 * FRI", time.Date(2024, 5, 3, 0, 0, 0, 0, time.UTC)},
+		{"5,10 11 * * *", time.Date(2024, 5, 1, 11, 5, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := s.Next(from); !got.Equal(tt.want) {
+				t.Errorf("Next() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchedule_NextInLocation(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+	s, err := Parse("0 9 * * *")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	got := s.Next(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC).In(loc))
+	if want := time.Date(2024, 5, 1, 13, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Next() = %s, want 9:00 New York time (%s)", got, want)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr string
+	}{
+		{"0 9 * *", "5 fields"},
+		{"60 * * * *", "invalid minute"},
+		{"* 24 * * *", "invalid hour"},
+		{"* * 0 * *", "invalid day-of-month"},
+		{"* * * FOO *", "invalid month"},
+		{"* * * * 5-1", "invalid day-of-week range"},
+		{"*/0 * * * *", "invalid minute step"},
+		{"0 0 30 2 *", "never fires"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := Parse(tt.expr)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Parse() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
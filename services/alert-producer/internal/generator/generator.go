@@ -9,7 +9,8 @@ import (
 
 	"alert-producer/internal/config"
 
-	"github.com/google/uuid"
+	"github.com/afikmenashe/alerting-platform/pkg/ids"
+	"github.com/afikmenashe/alerting-platform/pkg/metrics"
 )
 
 // Alert represents a single alert event that will be published to Kafka.
@@ -22,6 +23,7 @@ type Alert struct {
 	Source        string            `json:"source"`
 	Name          string            `json:"name"`
 	Context       map[string]string `json:"context,omitempty"`
+	ClientHint    string            `json:"client_hint,omitempty"` // Restricts matching to this client's rules
 }
 
 // Generator creates alerts according to configured distributions.
@@ -32,6 +34,7 @@ type Generator struct {
 	sourceDist    []weightedValue
 	nameDist      []weightedValue
 	schemaVersion int
+	clientHint    string
 }
 
 // weightedValue represents a single value in a weighted distribution.
@@ -53,6 +56,7 @@ const (
 func New(cfg config.Config) *Generator {
 	gen := &Generator{
 		schemaVersion: 1,
+		clientHint:    cfg.ClientHint,
 	}
 
 	// Initialize RNG
@@ -100,17 +104,18 @@ func parseWeightedDistribution(distStr string) ([]weightedValue, error) {
 }
 
 // Generate creates a new alert with random values according to the configured distributions.
-// Each alert gets a unique UUID, current timestamp, and values selected from weighted distributions.
+// Each alert gets a unique, time-ordered ID, current timestamp, and values selected from weighted distributions.
 // Optional context fields are added probabilistically.
 func (g *Generator) Generate() *Alert {
 	alert := &Alert{
-		AlertID:       uuid.New().String(),
+		AlertID:       ids.New(),
 		SchemaVersion: g.schemaVersion,
 		EventTS:       time.Now().Unix(),
 		Severity:      g.selectWeighted(g.severityDist),
 		Source:        g.selectWeighted(g.sourceDist),
 		Name:          g.selectWeighted(g.nameDist),
 		Context:       make(map[string]string),
+		ClientHint:    g.clientHint,
 	}
 
 	// Add optional context fields probabilistically for more realistic test data
@@ -165,7 +170,7 @@ func (g *Generator) selectFrom(choices []string) string {
 // Uses HIGH severity, api source, timeout name - a common rule combination.
 func GenerateBoilerplate() *Alert {
 	return &Alert{
-		AlertID:       uuid.New().String(),
+		AlertID:       ids.New(),
 		SchemaVersion: 1,
 		EventTS:       time.Now().Unix(),
 		Severity:      "HIGH",
@@ -179,7 +184,7 @@ func GenerateBoilerplate() *Alert {
 // This matches the test rule for client afik-test.
 func GenerateTestAlert() *Alert {
 	return &Alert{
-		AlertID:       uuid.New().String(),
+		AlertID:       ids.New(),
 		SchemaVersion: 1,
 		EventTS:       time.Now().Unix(),
 		Severity:      "LOW",
@@ -192,7 +197,7 @@ func GenerateTestAlert() *Alert {
 // GenerateCustomAlert creates an alert with user-specified severity, source, and name.
 func GenerateCustomAlert(severity, source, name string) *Alert {
 	return &Alert{
-		AlertID:       uuid.New().String(),
+		AlertID:       ids.New(),
 		SchemaVersion: 1,
 		EventTS:       time.Now().Unix(),
 		Severity:      severity,
@@ -201,3 +206,17 @@ func GenerateCustomAlert(severity, source, name string) *Alert {
 		Context:       make(map[string]string),
 	}
 }
+
+// GenerateCanaryAlert creates a pipeline canary alert emitted at sentAt.
+// It matches the seeded canary rule and carries its emission time for end-to-end latency.
+func GenerateCanaryAlert(sentAt time.Time) *Alert {
+	return &Alert{
+		AlertID:       ids.NewAt(sentAt),
+		SchemaVersion: 1,
+		EventTS:       sentAt.Unix(),
+		Severity:      metrics.CanarySeverity,
+		Source:        metrics.CanarySource,
+		Name:          metrics.CanaryName,
+		Context:       metrics.NewCanaryContext(sentAt),
+	}
+}
@@ -0,0 +1,71 @@
+package ingest
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+)
+
+// MaxBodyBytes bounds the body of an ingestion request after decompression, so a small gzip
+// body cannot expand into an unbounded one.
+const MaxBodyBytes = 4 << 20
+
+// Decoding errors that are not the caller's malformed input.
+var (
+	ErrBodyTooLarge        = fmt.Errorf("body exceeds %d bytes", MaxBodyBytes)
+	ErrUnsupportedEncoding = errors.New("unsupported content encoding (want gzip or none)")
+)
+
+// msgpackContentTypes are the media types accepted for msgpack bodies; anything else is JSON.
+var msgpackContentTypes = map[string]bool{
+	"application/msgpack":     true,
+	"application/x-msgpack":   true,
+	"application/vnd.msgpack": true,
+}
+
+// DecodeBatch reads a batch of alerts from body. The body is gunzipped if contentEncoding is gzip,
+// then decoded as msgpack if contentType is a msgpack media type, or as JSON otherwise.
+// A msgpack body has the same shape as the JSON one: an array of maps with the same keys.
+func DecodeBatch(body io.Reader, contentType, contentEncoding string) ([]Alert, error) {
+	switch strings.ToLower(strings.TrimSpace(contentEncoding)) {
+	case "", "identity":
+	case "gzip", "x-gzip":
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip body: %w", err)
+		}
+		defer gz.Close()
+		body = gz
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedEncoding, contentEncoding)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(body, MaxBodyBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read body: %w", err)
+	}
+	if len(data) > MaxBodyBytes {
+		return nil, ErrBodyTooLarge
+	}
+
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil && msgpackContentTypes[mediaType] {
+		value, err := decodeMsgpack(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid msgpack: %w", err)
+		}
+		// Reuse the JSON field names and type checks of Alert
+		if data, err = json.Marshal(value); err != nil {
+			return nil, fmt.Errorf("invalid msgpack: %w", err)
+		}
+	}
+
+	var alerts []Alert
+	if err := json.Unmarshal(data, &alerts); err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
@@ -0,0 +1,131 @@
+package ingest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// fixstr encodes s as a msgpack fixstr (up to 31 bytes).
+func fixstr(s string) []byte {
+	return append([]byte{0xa0 | byte(len(s))}, s...)
+}
+
+// msgpackBatch encodes a batch with one alert, using several msgpack encodings.
+func msgpackBatch() []byte {
+	b := []byte{0x91, 0x85} // array of 1, map of 5
+	b = append(b, fixstr("severity")...)
+	b = append(b, fixstr("high")...)
+	b = append(b, fixstr("source")...)
+	b = append(b, 0xd9, 3, 'a', 'p', 'i') // str8
+	b = append(b, fixstr("name")...)
+	b = append(b, 0xc4, 7, 't', 'i', 'm', 'e', 'o', 'u', 't') // bin8
+	b = append(b, fixstr("event_ts")...)
+	b = append(b, 0xce, 0x65, 0xa5, 0x0b, 0x28) // uint32 1705315112
+	b = append(b, fixstr("context")...)
+	b = append(b, 0x81)
+	b = append(b, fixstr("region")...)
+	b = append(b, fixstr("us-east-1")...)
+	return b
+}
+
+func gzipped(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeBatch(t *testing.T) {
+	jsonBody := []byte(`[{"severity":"high","source":"api","name":"timeout","event_ts":1705315112,"context":{"region":"us-east-1"}}]`)
+
+	tests := []struct {
+		name        string
+		body        []byte
+		contentType string
+		encoding    string
+	}{
+		{name: "json", body: jsonBody, contentType: "application/json"},
+		{name: "json without content type", body: jsonBody},
+		{name: "gzipped json", body: gzipped(t, jsonBody), contentType: "application/json", encoding: "gzip"},
+		{name: "msgpack", body: msgpackBatch(), contentType: "application/msgpack"},
+		{name: "gzipped msgpack", body: gzipped(t, msgpackBatch()), contentType: "application/x-msgpack; charset=binary", encoding: "GZIP"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alerts, err := DecodeBatch(bytes.NewReader(tt.body), tt.contentType, tt.encoding)
+			if err != nil {
+				t.Fatalf("DecodeBatch() error = %v", err)
+			}
+			if len(alerts) != 1 {
+				t.Fatalf("DecodeBatch() returned %d alerts, want 1", len(alerts))
+			}
+			a := alerts[0]
+			if a.Severity != "high" || a.Source != "api" || a.Name != "timeout" || a.EventTS != 1705315112 || a.Context["region"] != "us-east-1" {
+				t.Errorf("DecodeBatch() = %+v", a)
+			}
+		})
+	}
+}
+
+func TestDecodeBatch_Errors(t *testing.T) {
+	bomb := gzipped(t, []byte("["+strings.Repeat(" ", MaxBodyBytes)+"]"))
+
+	tests := []struct {
+		name        string
+		body        []byte
+		contentType string
+		encoding    string
+		want        error
+	}{
+		{name: "decompressed size limit", body: bomb, encoding: "gzip", want: ErrBodyTooLarge},
+		{name: "unsupported encoding", body: []byte("[]"), encoding: "br", want: ErrUnsupportedEncoding},
+		{name: "invalid gzip", body: []byte("[]"), encoding: "gzip"},
+		{name: "truncated msgpack", body: msgpackBatch()[:20], contentType: "application/msgpack"},
+		{name: "msgpack trailing bytes", body: append(msgpackBatch(), 0xc0), contentType: "application/msgpack"},
+		{name: "msgpack bogus array length", body: []byte{0xdd, 0xff, 0xff, 0xff, 0xff}, contentType: "application/msgpack"},
+		{name: "msgpack ext type", body: []byte{0x91, 0xd4, 0x01, 0x00}, contentType: "application/msgpack"},
+		{name: "msgpack non-string key", body: []byte{0x91, 0x81, 0x01, 0x01}, contentType: "application/msgpack"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeBatch(bytes.NewReader(tt.body), tt.contentType, tt.encoding)
+			if err == nil {
+				t.Fatal("DecodeBatch() error = nil")
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("DecodeBatch() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeMsgpack_Integers(t *testing.T) {
+	tests := []struct {
+		data []byte
+		want interface{}
+	}{
+		{[]byte{0x7f}, int64(127)},
+		{[]byte{0xff}, int64(-1)},
+		{[]byte{0xd0, 0x80}, int64(-128)},
+		{[]byte{0xd1, 0xff, 0x00}, int64(-256)},
+		{[]byte{0xcd, 0x01, 0x00}, uint64(256)},
+		{[]byte{0xd3, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xfe}, int64(-2)},
+	}
+	for _, tt := range tests {
+		got, err := decodeMsgpack(tt.data)
+		if err != nil || got != tt.want {
+			t.Errorf("decodeMsgpack(% x) = %v (%T), %v, want %v", tt.data, got, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+// Package ingest validates alerts pushed by external systems over the HTTP API and converts
+// them to the alerts.new event, so they enter the pipeline exactly like generated alerts.
+package ingest
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"alert-producer/internal/generator"
+
+	"github.com/afikmenashe/alerting-platform/pkg/ids"
+	"github.com/afikmenashe/alerting-platform/pkg/metrics"
+)
+
+// Limits on ingested alerts. Severity, source, name and alert_id are stored in VARCHAR(255)
+// columns downstream; context is stored as JSONB on every notification.
+const (
+	MaxBatchSize       = 1000
+	MaxFieldLength     = 255
+	MaxContextEntries  = 50
+	MaxContextKeyLen   = 128
+	MaxContextValueLen = 1024
+	// MaxEventSkew is how far in the future event_ts may be, for clock skew.
+	MaxEventSkew = 5 * time.Minute
+)
+
+// schemaVersion is the alerts.new schema version of ingested alerts.
+const schemaVersion = 1
+
+var validSeverities = map[string]bool{"LOW": true, "MEDIUM": true, "HIGH": true, "CRITICAL": true}
+
+// Alert is an alert as submitted to POST /api/v1/alerts/ingest.
+type Alert struct {
+	AlertID    string            `json:"alert_id,omitempty"` // generated if empty; reuse it when retrying
+	EventTS    int64             `json:"event_ts,omitempty"` // Unix seconds; now if empty
+	Severity   string            `json:"severity"`           // LOW, MEDIUM, HIGH or CRITICAL (any case)
+	Source     string            `json:"source"`
+	Name       string            `json:"name"`
+	Context    map[string]string `json:"context,omitempty"`
+	ClientHint string            `json:"client_hint,omitempty"` // restricts matching to this client's rules
+}
+
+// FieldError is a validation error of one alert of a batch.
+type FieldError struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+// Validate checks every alert of a batch and returns an error per invalid alert.
+// An empty result means the whole batch is valid.
+func Validate(alerts []Alert, now time.Time) []FieldError {
+	var errs []FieldError
+	for i := range alerts {
+		if err := validateAlert(&alerts[i], now); err != nil {
+			errs = append(errs, FieldError{Index: i, Error: err.Error()})
+		}
+	}
+	return errs
+}
+
+func validateAlert(a *Alert, now time.Time) error {
+	if !validSeverities[strings.ToUpper(strings.TrimSpace(a.Severity))] {
+		return fmt.Errorf("invalid severity %q (must be LOW, MEDIUM, HIGH, or CRITICAL)", a.Severity)
+	}
+	if err := validateField("source", a.Source); err != nil {
+		return err
+	}
+	if err := validateField("name", a.Name); err != nil {
+		return err
+	}
+	if len(a.AlertID) > MaxFieldLength {
+		return fmt.Errorf("alert_id exceeds %d characters", MaxFieldLength)
+	}
+	if len(a.ClientHint) > MaxFieldLength {
+		return fmt.Errorf("client_hint exceeds %d characters", MaxFieldLength)
+	}
+	if a.ClientHint == metrics.CanaryClientID {
+		return fmt.Errorf("client_hint %q is reserved for the pipeline canary", a.ClientHint)
+	}
+	if a.EventTS < 0 {
+		return fmt.Errorf("event_ts cannot be negative")
+	}
+	if a.EventTS > now.Add(MaxEventSkew).Unix() {
+		return fmt.Errorf("event_ts is more than %s in the future", MaxEventSkew)
+	}
+	return validateContext(a.Context)
+}
+
+func validateField(field, value string) error {
+	if strings.TrimSpace(value) == "" {
+		return fmt.Errorf("%s cannot be empty", field)
+	}
+	if len(value) > MaxFieldLength {
+		return fmt.Errorf("%s exceeds %d characters", field, MaxFieldLength)
+	}
+	return nil
+}
+
+func validateContext(context map[string]string) error {
+	if len(context) > MaxContextEntries {
+		return fmt.Errorf("context has more than %d entries", MaxContextEntries)
+	}
+	for k, v := range context {
+		if strings.TrimSpace(k) == "" {
+			return fmt.Errorf("context keys cannot be empty")
+		}
+		if len(k) > MaxContextKeyLen {
+			return fmt.Errorf("context key %q exceeds %d characters", k[:32]+"...", MaxContextKeyLen)
+		}
+		if len(v) > MaxContextValueLen {
+			return fmt.Errorf("context value of %q exceeds %d characters", k, MaxContextValueLen)
+		}
+		// Canary keys would make the alert count as a pipeline canary
+		if k == metrics.CanaryContextKey || k == metrics.CanarySentAtContextKey {
+			return fmt.Errorf("context key %q is reserved for the pipeline canary", k)
+		}
+	}
+	return nil
+}
+
+// ToAlert converts a validated alert to the alerts.new event, filling in alert_id and event_ts.
+func ToAlert(a Alert, now time.Time) *generator.Alert {
+	alertID := a.AlertID
+	if alertID == "" {
+		alertID = ids.NewAt(now)
+	}
+	eventTS := a.EventTS
+	if eventTS == 0 {
+		eventTS = now.Unix()
+	}
+	return &generator.Alert{
+		AlertID:       alertID,
+		SchemaVersion: schemaVersion,
+		EventTS:       eventTS,
+		Severity:      strings.ToUpper(strings.TrimSpace(a.Severity)),
+		Source:        a.Source,
+		Name:          a.Name,
+		Context:       a.Context,
+		ClientHint:    a.ClientHint,
+	}
+}
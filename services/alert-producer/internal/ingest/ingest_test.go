@@ -0,0 +1,65 @@
+package ingest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/afikmenashe/alerting-platform/pkg/metrics"
+)
+
+func valid() Alert {
+	return Alert{Severity: "high", Source: "payments", Name: "timeout", Context: map[string]string{"region": "eu-west-1"}}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := map[string]func(a *Alert){
+		"unknown severity":   func(a *Alert) { a.Severity = "URGENT" },
+		"empty source":       func(a *Alert) { a.Source = " " },
+		"empty name":         func(a *Alert) { a.Name = "" },
+		"long name":          func(a *Alert) { a.Name = strings.Repeat("x", MaxFieldLength+1) },
+		"long alert id":      func(a *Alert) { a.AlertID = strings.Repeat("x", MaxFieldLength+1) },
+		"canary client hint": func(a *Alert) { a.ClientHint = metrics.CanaryClientID },
+		"negative event ts":  func(a *Alert) { a.EventTS = -1 },
+		"future event ts":    func(a *Alert) { a.EventTS = now.Add(time.Hour).Unix() },
+		"empty context key":  func(a *Alert) { a.Context[""] = "x" },
+		"long context key":   func(a *Alert) { a.Context[strings.Repeat("k", MaxContextKeyLen+1)] = "x" },
+		"long context value": func(a *Alert) { a.Context["trace"] = strings.Repeat("v", MaxContextValueLen+1) },
+		"canary context key": func(a *Alert) { a.Context[metrics.CanaryContextKey] = "true" },
+		"too many context keys": func(a *Alert) {
+			for i := 0; i <= MaxContextEntries; i++ {
+				a.Context[strings.Repeat("k", i+1)] = "v"
+			}
+		},
+	}
+	for name, mutate := range tests {
+		a := valid()
+		mutate(&a)
+		errs := Validate([]Alert{valid(), a}, now)
+		if len(errs) != 1 || errs[0].Index != 1 {
+			t.Errorf("%s: Validate() = %v, want one error for index 1", name, errs)
+		}
+	}
+
+	if errs := Validate([]Alert{valid(), {Severity: "CRITICAL", Source: "db", Name: "down", EventTS: now.Unix()}}, now); len(errs) != 0 {
+		t.Errorf("Validate() = %v, want no errors", errs)
+	}
+}
+
+func TestToAlert(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	got := ToAlert(valid(), now)
+	if got.AlertID == "" || got.EventTS != now.Unix() || got.Severity != "HIGH" || got.SchemaVersion != schemaVersion {
+		t.Errorf("ToAlert() = %+v, want a generated ID, now as event_ts and upper-case severity", got)
+	}
+
+	a := valid()
+	a.AlertID, a.EventTS, a.ClientHint = "ext-42", 1773100000, "client-1"
+	got = ToAlert(a, now)
+	if got.AlertID != "ext-42" || got.EventTS != 1773100000 || got.ClientHint != "client-1" {
+		t.Errorf("ToAlert() = %+v, want the submitted alert_id, event_ts and client_hint kept", got)
+	}
+}
@@ -0,0 +1,207 @@
+package ingest
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// maxMsgpackDepth bounds the nesting of msgpack containers. Alerts need 3 levels
+// (batch, alert, context).
+const maxMsgpackDepth = 16
+
+var errMsgpackTruncated = errors.New("unexpected end of data")
+
+// decodeMsgpack decodes a msgpack document into the values encoding/json produces:
+// nil, bool, int64, uint64, float64, string, []interface{} and map[string]interface{}.
+// bin values decode as strings; ext values and non-string map keys are rejected.
+func decodeMsgpack(data []byte) (interface{}, error) {
+	d := &msgpackDecoder{data: data}
+	v, err := d.value(0)
+	if err != nil {
+		return nil, err
+	}
+	if d.pos != len(d.data) {
+		return nil, fmt.Errorf("%d trailing bytes", len(d.data)-d.pos)
+	}
+	return v, nil
+}
+
+// msgpackDecoder reads msgpack values from data, starting at pos.
+type msgpackDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *msgpackDecoder) read(n int) ([]byte, error) {
+	if n < 0 || n > len(d.data)-d.pos {
+		return nil, errMsgpackTruncated
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+// length reads a big-endian length of size bytes.
+func (d *msgpackDecoder) length(size int) (int, error) {
+	b, err := d.read(size)
+	if err != nil {
+		return 0, err
+	}
+	switch size {
+	case 1:
+		return int(b[0]), nil
+	case 2:
+		return int(binary.BigEndian.Uint16(b)), nil
+	default:
+		n := binary.BigEndian.Uint32(b)
+		if uint64(n) > uint64(len(d.data)) {
+			return 0, errMsgpackTruncated
+		}
+		return int(n), nil
+	}
+}
+
+func (d *msgpackDecoder) value(depth int) (interface{}, error) {
+	if depth > maxMsgpackDepth {
+		return nil, fmt.Errorf("nesting exceeds %d levels", maxMsgpackDepth)
+	}
+	b, err := d.read(1)
+	if err != nil {
+		return nil, err
+	}
+	c := b[0]
+
+	switch {
+	case c <= 0x7f: // positive fixint
+		return int64(c), nil
+	case c >= 0xe0: // negative fixint
+		return int64(int8(c)), nil
+	case c >= 0x80 && c <= 0x8f:
+		return d.mapOf(int(c&0x0f), depth)
+	case c >= 0x90 && c <= 0x9f:
+		return d.arrayOf(int(c&0x0f), depth)
+	case c >= 0xa0 && c <= 0xbf:
+		return d.str(int(c & 0x1f))
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xd9: // bin8, str8
+		return d.sizedStr(1)
+	case 0xc5, 0xda: // bin16, str16
+		return d.sizedStr(2)
+	case 0xc6, 0xdb: // bin32, str32
+		return d.sizedStr(4)
+	case 0xca:
+		b, err := d.read(4)
+		if err != nil {
+			return nil, err
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), nil
+	case 0xcb:
+		b, err := d.read(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), nil
+	case 0xcc, 0xcd, 0xce, 0xcf: // uint8-64
+		b, err := d.read(1 << (c - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		return uint64BE(b), nil
+	case 0xd0, 0xd1, 0xd2, 0xd3: // int8-64
+		size := 1 << (c - 0xd0)
+		b, err := d.read(size)
+		if err != nil {
+			return nil, err
+		}
+		// Sign-extend from the value's width
+		shift := 64 - 8*size
+		return int64(uint64BE(b)<<shift) >> shift, nil
+	case 0xdc, 0xdd: // array16, array32
+		n, err := d.length(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.arrayOf(n, depth)
+	case 0xde, 0xdf: // map16, map32
+		n, err := d.length(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.mapOf(n, depth)
+	}
+	return nil, fmt.Errorf("unsupported msgpack type 0x%02x", c)
+}
+
+func (d *msgpackDecoder) str(n int) (string, error) {
+	b, err := d.read(n)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func (d *msgpackDecoder) sizedStr(size int) (interface{}, error) {
+	n, err := d.length(size)
+	if err != nil {
+		return nil, err
+	}
+	return d.str(n)
+}
+
+func (d *msgpackDecoder) arrayOf(n, depth int) (interface{}, error) {
+	// Every element takes at least a byte, so a bogus length cannot allocate past the data
+	if n > len(d.data)-d.pos {
+		return nil, errMsgpackTruncated
+	}
+	arr := make([]interface{}, n)
+	for i := range arr {
+		v, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		arr[i] = v
+	}
+	return arr, nil
+}
+
+func (d *msgpackDecoder) mapOf(n, depth int) (interface{}, error) {
+	if n > (len(d.data)-d.pos)/2 {
+		return nil, errMsgpackTruncated
+	}
+	m := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		k, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		key, ok := k.(string)
+		if !ok {
+			return nil, fmt.Errorf("map key %v is not a string", k)
+		}
+		v, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		m[key] = v
+	}
+	return m, nil
+}
+
+// uint64BE decodes a big-endian unsigned integer of up to 8 bytes.
+func uint64BE(b []byte) uint64 {
+	var n uint64
+	for _, x := range b {
+		n = n<<8 | uint64(x)
+	}
+	return n
+}
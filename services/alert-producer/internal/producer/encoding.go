@@ -42,6 +42,7 @@ func alertToProto(alert *generator.Alert) *pbalerts.AlertNew {
 		Source:        alert.Source,
 		Name:          alert.Name,
 		Context:       alert.Context,
+		ClientHint:    alert.ClientHint,
 	}
 }
 
@@ -15,8 +15,9 @@ type MockProducer struct {
 	topic string
 }
 
-// Ensure MockProducer implements AlertPublisher interface
+// Ensure MockProducer implements AlertPublisher and BatchPublisher interfaces
 var _ AlertPublisher = (*MockProducer)(nil)
+var _ BatchPublisher = (*MockProducer)(nil)
 
 // NewMock creates a new mock producer that logs alerts instead of publishing to Kafka.
 func NewMock(topic string) *MockProducer {
@@ -54,6 +55,21 @@ func (p *MockProducer) Publish(ctx context.Context, alert *generator.Alert) erro
 	return nil
 }
 
+// PublishBatch logs every alert of the batch as JSON instead of publishing to Kafka.
+func (p *MockProducer) PublishBatch(ctx context.Context, alerts []*generator.Alert) error {
+	errs := make([]error, len(alerts))
+	failed := false
+	for i, alert := range alerts {
+		if errs[i] = p.Publish(ctx, alert); errs[i] != nil {
+			failed = true
+		}
+	}
+	if failed {
+		return &BatchError{Errors: errs}
+	}
+	return nil
+}
+
 // Close is a no-op for the mock producer.
 func (p *MockProducer) Close() error {
 	slog.Info("Mock producer closed", "topic", p.topic)
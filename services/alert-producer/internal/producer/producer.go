@@ -22,15 +22,52 @@ type AlertPublisher interface {
 	Close() error
 }
 
+// BatchPublisher publishes many alerts with a single batched produce.
+type BatchPublisher interface {
+	PublishBatch(ctx context.Context, alerts []*generator.Alert) error
+}
+
+// BatchError reports the alerts of a batch that failed to publish.
+// Errors is indexed like the batch; a nil entry means the alert was published.
+type BatchError struct {
+	Errors []error
+}
+
+// Error summarizes the failures with the first one.
+func (e *BatchError) Error() string {
+	failed := 0
+	var first error
+	for _, err := range e.Errors {
+		if err != nil {
+			if first == nil {
+				first = err
+			}
+			failed++
+		}
+	}
+	return fmt.Sprintf("failed to publish %d of %d alerts: %v", failed, len(e.Errors), first)
+}
+
 // Producer wraps a Kafka writer and provides a simple interface for publishing alerts.
 // Messages are keyed by alert_id for even distribution across partitions.
 type Producer struct {
 	writer *kafka.Writer
-	topic  string
+	// batchWriter sends each PublishBatch call as one produce request per partition.
+	batchWriter *kafka.Writer
+	topic       string
 }
 
-// Ensure Producer implements AlertPublisher interface
+// Ensure Producer implements AlertPublisher and BatchPublisher interfaces
 var _ AlertPublisher = (*Producer)(nil)
+var _ BatchPublisher = (*Producer)(nil)
+
+// Batch writer limits. kafka-go flushes a partition's batch when it is full or batchTimeout after
+// its first message, so a batch adds at most batchTimeout of latency. maxBatchMessages matches
+// the ingest batch limit, so a request is never split into several produce requests per partition.
+const (
+	maxBatchMessages = 1000
+	batchTimeout     = 10 * time.Millisecond
+)
 
 // New creates a new Kafka producer with the specified brokers and topic.
 // The producer is configured for at-least-once delivery semantics with synchronous writes.
@@ -55,6 +92,7 @@ func New(brokers string, topic string) (*Producer, error) {
 	// Use Hash balancer to partition by key (alert_id) for even distribution
 	writer := &kafka.Writer{
 		Addr:         kafka.TCP(brokerList...),
+		Transport:    kafkautil.Transport(),
 		Topic:        topic,
 		Balancer:     &kafka.Hash{}, // Key-based partitioning (hashes the message key)
 		WriteTimeout: kafkautil.WriteTimeout,
@@ -71,9 +109,23 @@ func New(brokers string, topic string) (*Producer, error) {
 		"partition_key", "alert_id (hashed)",
 	)
 
+	// Same delivery guarantees, but messages of a batch are sent together
+	batchWriter := &kafka.Writer{
+		Addr:         kafka.TCP(brokerList...),
+		Transport:    kafkautil.Transport(),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		WriteTimeout: kafkautil.WriteTimeout,
+		RequiredAcks: kafka.RequireOne,
+		Async:        false,
+		BatchSize:    maxBatchMessages,
+		BatchTimeout: batchTimeout,
+	}
+
 	return &Producer{
-		writer: writer,
-		topic:  topic,
+		writer:      writer,
+		batchWriter: batchWriter,
+		topic:       topic,
 	}, nil
 }
 
@@ -102,6 +154,43 @@ func (p *Producer) Publish(ctx context.Context, alert *generator.Alert) error {
 	return p.writeWithRetry(ctx, msg, alert.AlertID)
 }
 
+// PublishBatch serializes alerts to protobuf and publishes them to Kafka in one batched write.
+// If some alerts fail to publish, the error is a *BatchError telling which; the others were published.
+func (p *Producer) PublishBatch(ctx context.Context, alerts []*generator.Alert) error {
+	msgs := make([]kafka.Message, len(alerts))
+	for i, alert := range alerts {
+		payload, err := encodeAlert(alert)
+		if err != nil {
+			return fmt.Errorf("failed to encode alert %s: %w", alert.AlertID, err)
+		}
+		msgs[i] = buildKafkaMessage(alert, payload)
+	}
+
+	if err := p.batchWriter.WriteMessages(ctx, msgs...); err != nil {
+		slog.Error("Failed to write batch to Kafka",
+			"topic", p.topic,
+			"batch_size", len(msgs),
+			"error", err,
+		)
+		return batchError(err, len(msgs))
+	}
+	return nil
+}
+
+// batchError converts a batch write error to a *BatchError. kafka-go reports per-message errors
+// as kafka.WriteErrors; any other error failed the whole batch.
+func batchError(err error, size int) *BatchError {
+	var writeErrs kafka.WriteErrors
+	if errors.As(err, &writeErrs) && len(writeErrs) == size {
+		return &BatchError{Errors: []error(writeErrs)}
+	}
+	errs := make([]error, size)
+	for i := range errs {
+		errs[i] = err
+	}
+	return &BatchError{Errors: errs}
+}
+
 // writeWithRetry writes a message to Kafka with retry logic for transient errors.
 // Retries once if the topic is not ready (handles async topic creation).
 func (p *Producer) writeWithRetry(ctx context.Context, msg kafka.Message, alertID string) error {
@@ -157,6 +246,10 @@ func (p *Producer) Close() error {
 		slog.Error("Error closing Kafka producer", "error", err)
 		return err
 	}
+	if err := p.batchWriter.Close(); err != nil {
+		slog.Error("Error closing Kafka batch producer", "error", err)
+		return err
+	}
 	slog.Info("Kafka producer closed successfully")
 	return nil
 }
@@ -3,10 +3,14 @@ package producer
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
 	"alert-producer/internal/generator"
+
+	"github.com/segmentio/kafka-go"
 )
 
 func TestNew_ValidInputs(t *testing.T) {
@@ -313,3 +317,33 @@ func TestHashAlertID_Distribution(t *testing.T) {
 		t.Errorf("Expected at least 900 unique hashes from 1000 inputs, got %d", uniqueHashes)
 	}
 }
+
+func TestBatchError(t *testing.T) {
+	failure := errors.New("leader not available")
+
+	// Per-message errors are kept as reported
+	err := batchError(kafka.WriteErrors{nil, failure, nil}, 3)
+	if len(err.Errors) != 3 || err.Errors[0] != nil || err.Errors[1] != failure || err.Errors[2] != nil {
+		t.Errorf("batchError() = %v, want only the second alert failed", err.Errors)
+	}
+	if want := "failed to publish 1 of 3 alerts: leader not available"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+
+	// Any other error fails every alert
+	err = batchError(fmt.Errorf("dial: %w", failure), 2)
+	for i, e := range err.Errors {
+		if !errors.Is(e, failure) {
+			t.Errorf("Errors[%d] = %v, want the write error", i, e)
+		}
+	}
+}
+
+func TestMockProducer_PublishBatch(t *testing.T) {
+	mock := NewMock("test-topic")
+	alerts := []*generator.Alert{generator.GenerateTestAlert(), generator.GenerateTestAlert()}
+
+	if err := mock.PublishBatch(context.Background(), alerts); err != nil {
+		t.Errorf("MockProducer.PublishBatch should not error, got: %v", err)
+	}
+}
@@ -5,13 +5,14 @@ import (
 	"log/slog"
 	"time"
 
+	kafkautil "github.com/afikmenashe/alerting-platform/pkg/kafka"
 	"github.com/segmentio/kafka-go"
 )
 
 // createTopicIfNotExists attempts to create the topic if it doesn't exist.
 // This is a best-effort operation and failures are logged but don't prevent producer creation.
 func createTopicIfNotExists(broker, topic string) {
-	conn, err := kafka.Dial("tcp", broker)
+	conn, err := kafkautil.Dial(broker)
 	if err != nil {
 		slog.Warn("Could not connect to Kafka to check/create topic",
 			"broker", broker,
@@ -81,7 +82,7 @@ func createTopicIfNotExists(broker, topic string) {
 
 	// If we still can't read partitions, try one more time with a fresh connection
 	// This handles the case where the connection was stale
-	verifyConn, err := kafka.Dial("tcp", broker)
+	verifyConn, err := kafkautil.Dial(broker)
 	if err == nil {
 		defer verifyConn.Close()
 		partitions, err := verifyConn.ReadPartitions(topic)
@@ -0,0 +1,146 @@
+// Package replay republishes historical alerts to alerts.new, so operators can reproduce an
+// incident or load-test rule changes against real traffic. Alerts are read from the
+// notifications table or from an exported alert log, and republished with their original
+// inter-arrival gaps, optionally compressed in time.
+package replay
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"alert-producer/internal/ingest"
+	"alert-producer/internal/producer"
+)
+
+// progressEvery is how many published alerts are between two progress logs.
+const progressEvery = 1000
+
+// Record is a historical alert and when it originally happened.
+type Record struct {
+	At    time.Time
+	Alert ingest.Alert
+}
+
+// Options control how records are republished.
+type Options struct {
+	// Speed compresses time: 1 replays in real time, 10 ten times faster. 0 publishes as fast
+	// as possible, ignoring the original gaps.
+	Speed float64
+	// MaxGap caps the wait between two alerts after compression, so quiet periods of the
+	// original traffic are skipped; 0 for no cap.
+	MaxGap time.Duration
+	// ClientHint, when set, replaces the client_hint of every alert, so the replay only
+	// matches one (e.g. a staging) client's rules.
+	ClientHint string
+	// KeepIDs republishes alerts with their original alert_id. By default every alert gets a
+	// new one, because the aggregator drops notifications it has already stored for an alert.
+	KeepIDs bool
+}
+
+// Result summarizes a replay.
+type Result struct {
+	Published int
+	Skipped   int           // records that failed validation
+	Span      time.Duration // time between the first and last original alert
+	Duration  time.Duration // how long the replay took
+}
+
+// Replayer republishes records with a publisher.
+type Replayer struct {
+	opts      Options
+	publisher producer.AlertPublisher
+	now       func() time.Time
+	sleep     func(ctx context.Context, d time.Duration) error
+}
+
+// NewReplayer creates a replayer that publishes with publisher.
+func NewReplayer(opts Options, publisher producer.AlertPublisher) *Replayer {
+	return &Replayer{
+		opts:      opts,
+		publisher: publisher,
+		now:       time.Now,
+		sleep:     sleepContext,
+	}
+}
+
+// Run republishes records, which must be sorted by time, and returns what was published.
+// Each alert's event_ts is the time it is republished, so pipeline latency and event age are
+// measured as for live traffic. Invalid records (e.g. pipeline canaries) are skipped.
+// A publish error stops the replay.
+func (r *Replayer) Run(ctx context.Context, records []Record) (Result, error) {
+	var result Result
+	if len(records) == 0 {
+		return result, nil
+	}
+	result.Span = records[len(records)-1].At.Sub(records[0].At)
+
+	start := r.now()
+	var offset time.Duration // when the current record is due, relative to start
+	for i, rec := range records {
+		if i > 0 && r.opts.Speed > 0 {
+			offset += r.gap(records[i-1].At, rec.At)
+			if wait := offset - r.now().Sub(start); wait > 0 {
+				if err := r.sleep(ctx, wait); err != nil {
+					result.Duration = r.now().Sub(start)
+					return result, err
+				}
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			result.Duration = r.now().Sub(start)
+			return result, err
+		}
+
+		now := r.now()
+		alert := rec.Alert
+		alert.EventTS = 0
+		if !r.opts.KeepIDs {
+			alert.AlertID = ""
+		}
+		if r.opts.ClientHint != "" {
+			alert.ClientHint = r.opts.ClientHint
+		}
+		if errs := ingest.Validate([]ingest.Alert{alert}, now); len(errs) > 0 {
+			slog.Warn("Skipping invalid alert", "alert_id", rec.Alert.AlertID, "error", errs[0].Error)
+			result.Skipped++
+			continue
+		}
+
+		if err := r.publisher.Publish(ctx, ingest.ToAlert(alert, now)); err != nil {
+			result.Duration = r.now().Sub(start)
+			return result, fmt.Errorf("publish alert %d of %d: %w", i+1, len(records), err)
+		}
+		result.Published++
+		if result.Published%progressEvery == 0 {
+			slog.Info("Replay progress", "published", result.Published, "total", len(records))
+		}
+	}
+
+	result.Duration = r.now().Sub(start)
+	return result, nil
+}
+
+// gap returns how long to wait between two alerts that originally happened at prev and next.
+func (r *Replayer) gap(prev, next time.Time) time.Duration {
+	gap := time.Duration(float64(next.Sub(prev)) / r.opts.Speed)
+	if gap < 0 {
+		return 0
+	}
+	if r.opts.MaxGap > 0 && gap > r.opts.MaxGap {
+		return r.opts.MaxGap
+	}
+	return gap
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,269 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"alert-producer/internal/generator"
+	"alert-producer/internal/ingest"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// recordingPublisher records published alerts and when they were published.
+type recordingPublisher struct {
+	clock  *fakeClock
+	alerts []*generator.Alert
+	at     []time.Duration
+	err    error
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, alert *generator.Alert) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.alerts = append(p.alerts, alert)
+	p.at = append(p.at, p.clock.elapsed)
+	return nil
+}
+
+func (p *recordingPublisher) Close() error { return nil }
+
+// fakeClock advances only when the replayer sleeps.
+type fakeClock struct {
+	start   time.Time
+	elapsed time.Duration
+}
+
+func (c *fakeClock) now() time.Time { return c.start.Add(c.elapsed) }
+
+func (c *fakeClock) sleep(_ context.Context, d time.Duration) error {
+	c.elapsed += d
+	return nil
+}
+
+func newTestReplayer(opts Options) (*Replayer, *recordingPublisher) {
+	clock := &fakeClock{start: time.Unix(1700000000, 0)}
+	pub := &recordingPublisher{clock: clock}
+	r := NewReplayer(opts, pub)
+	r.now = clock.now
+	r.sleep = clock.sleep
+	return r, pub
+}
+
+func testRecords(offsets ...time.Duration) []Record {
+	base := time.Unix(1600000000, 0)
+	records := make([]Record, len(offsets))
+	for i, offset := range offsets {
+		records[i] = Record{
+			At: base.Add(offset),
+			Alert: ingest.Alert{
+				AlertID:  "original-" + string(rune('a'+i)),
+				EventTS:  base.Add(offset).Unix(),
+				Severity: "high",
+				Source:   "api",
+				Name:     "timeout",
+				Context:  map[string]string{"region": "eu"},
+			},
+		}
+	}
+	return records
+}
+
+func TestReplayer_Run(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   Options
+		offset []time.Duration
+		wantAt []time.Duration
+	}{
+		{
+			name:   "real time keeps the original gaps",
+			opts:   Options{Speed: 1},
+			offset: []time.Duration{0, 10 * time.Second, 40 * time.Second},
+			wantAt: []time.Duration{0, 10 * time.Second, 40 * time.Second},
+		},
+		{
+			name:   "speed compresses the gaps",
+			opts:   Options{Speed: 10},
+			offset: []time.Duration{0, 10 * time.Second, 40 * time.Second},
+			wantAt: []time.Duration{0, time.Second, 4 * time.Second},
+		},
+		{
+			name:   "max gap skips quiet periods",
+			opts:   Options{Speed: 1, MaxGap: 5 * time.Second},
+			offset: []time.Duration{0, 2 * time.Second, time.Hour},
+			wantAt: []time.Duration{0, 2 * time.Second, 7 * time.Second},
+		},
+		{
+			name:   "speed 0 publishes as fast as possible",
+			opts:   Options{},
+			offset: []time.Duration{0, time.Minute, time.Hour},
+			wantAt: []time.Duration{0, 0, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, pub := newTestReplayer(tt.opts)
+			result, err := r.Run(context.Background(), testRecords(tt.offset...))
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if result.Published != len(tt.offset) || result.Span != tt.offset[len(tt.offset)-1] {
+				t.Errorf("Run() = %+v, want %d published over %s", result, len(tt.offset), tt.offset[len(tt.offset)-1])
+			}
+			for i, want := range tt.wantAt {
+				if pub.at[i] != want {
+					t.Errorf("alert %d published at %s, want %s", i, pub.at[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestReplayer_RunRewritesAlerts(t *testing.T) {
+	t.Run("new alert ids and replay event time", func(t *testing.T) {
+		r, pub := newTestReplayer(Options{Speed: 1, ClientHint: "staging"})
+		records := testRecords(0, time.Minute)
+		if _, err := r.Run(context.Background(), records); err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		for i, alert := range pub.alerts {
+			if alert.AlertID == "" || alert.AlertID == records[i].Alert.AlertID {
+				t.Errorf("alert %d id = %q, want a new id", i, alert.AlertID)
+			}
+			if want := time.Unix(1700000000, 0).Add(pub.at[i]).Unix(); alert.EventTS != want {
+				t.Errorf("alert %d event_ts = %d, want %d", i, alert.EventTS, want)
+			}
+			if alert.Severity != "HIGH" || alert.ClientHint != "staging" || alert.Context["region"] != "eu" {
+				t.Errorf("alert %d = %+v, want HIGH for staging with the original context", i, alert)
+			}
+		}
+	})
+
+	t.Run("keep ids", func(t *testing.T) {
+		r, pub := newTestReplayer(Options{KeepIDs: true})
+		records := testRecords(0)
+		if _, err := r.Run(context.Background(), records); err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		if pub.alerts[0].AlertID != records[0].Alert.AlertID {
+			t.Errorf("alert id = %q, want %q", pub.alerts[0].AlertID, records[0].Alert.AlertID)
+		}
+	})
+
+	t.Run("invalid alerts are skipped", func(t *testing.T) {
+		r, pub := newTestReplayer(Options{})
+		records := testRecords(0, time.Second)
+		records[0].Alert.Severity = "unknown"
+		result, err := r.Run(context.Background(), records)
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		if result.Published != 1 || result.Skipped != 1 || len(pub.alerts) != 1 {
+			t.Errorf("Run() = %+v, want 1 published and 1 skipped", result)
+		}
+	})
+
+	t.Run("publish error stops the replay", func(t *testing.T) {
+		r, pub := newTestReplayer(Options{})
+		pub.err = errors.New("broker down")
+		result, err := r.Run(context.Background(), testRecords(0, time.Second))
+		if err == nil || result.Published != 0 {
+			t.Errorf("Run() = %+v, %v, want the publish error", result, err)
+		}
+	})
+}
+
+func TestReadFile(t *testing.T) {
+	log := strings.Join([]string{
+		// A notification export: a row per notified client
+		`{"notification_id":"n1","client_id":"team-a","alert_id":"a2","severity":"HIGH","source":"db","name":"slow","context":{"region":"eu","matched_rules":"r1:High DB","owner":"group:default/db","team":"db","slack_channel":"#db"},"created_at":"2024-05-01T10:00:30Z"}`,
+		`{"notification_id":"n2","client_id":"team-b","alert_id":"a2","severity":"HIGH","source":"db","name":"slow","context":{"region":"eu"},"created_at":"2024-05-01T10:00:31Z"}`,
+		``,
+		// An ingest-format alert, whose context is replayed as is
+		`{"alert_id":"a1","event_ts":1714557600,"severity":"LOW","source":"api","name":"timeout","context":{"team":"api"}}`,
+		`{"alert_id":"a3","severity":"LOW","source":"api","name":"error","client_id":"team-b","created_at":"2024-05-01T11:00:00Z"}`,
+	}, "\n")
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{name: "all, deduplicated and sorted", want: []string{"a1", "a2", "a3"}},
+		{name: "client", filter: Filter{ClientID: "team-a"}, want: []string{"a2"}},
+		{name: "window", filter: Filter{Since: time.Date(2024, 5, 1, 10, 0, 1, 0, time.UTC), Until: time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC)}, want: []string{"a2"}},
+		{name: "limit", filter: Filter{Limit: 2}, want: []string{"a1", "a2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, err := ReadFile(strings.NewReader(log), tt.filter)
+			if err != nil {
+				t.Fatalf("ReadFile() error = %v", err)
+			}
+			var got []string
+			for _, rec := range records {
+				got = append(got, rec.Alert.AlertID)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("ReadFile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("strips enrichment of notifications", func(t *testing.T) {
+		records, err := ReadFile(strings.NewReader(log), Filter{})
+		if err != nil {
+			t.Fatalf("ReadFile() error = %v", err)
+		}
+		if ctx := records[1].Alert.Context; len(ctx) != 1 || ctx["region"] != "eu" {
+			t.Errorf("notification context = %v, want only region", ctx)
+		}
+		if ctx := records[0].Alert.Context; ctx["team"] != "api" {
+			t.Errorf("alert context = %v, want it unchanged", ctx)
+		}
+	})
+
+	t.Run("malformed line", func(t *testing.T) {
+		_, err := ReadFile(strings.NewReader("{}\n{"), Filter{})
+		if err == nil || !strings.Contains(err.Error(), "line 1") {
+			t.Errorf("ReadFile() error = %v, want an error on line 1", err)
+		}
+	})
+}
+
+func TestLoadPostgres(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	since := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	until := since.Add(time.Hour)
+	mock.ExpectQuery(`SELECT DISTINCT ON \(alert_id\)(.|\n)*FROM notifications(.|\n)*LIMIT \$4`).
+		WithArgs(since, until, "team-a", 100).
+		WillReturnRows(sqlmock.NewRows([]string{"alert_id", "severity", "source", "name", "context", "at"}).
+			AddRow("a1", "HIGH", "api", "timeout", []byte(`{"region":"eu","matched_rules":"r1:High API","owner":"group:default/api","team":"api","slack_channel":"#api"}`), since.Add(time.Minute)).
+			AddRow("a2", "LOW", "db", "slow", []byte(`{}`), since.Add(2*time.Minute)))
+
+	records, err := LoadPostgres(context.Background(), db, Filter{Since: since, Until: until, ClientID: "team-a", Limit: 100})
+	if err != nil {
+		t.Fatalf("LoadPostgres() error = %v", err)
+	}
+	if len(records) != 2 || !records[1].At.Equal(since.Add(2*time.Minute)) {
+		t.Errorf("LoadPostgres() = %+v, want a1 and a2", records)
+	}
+	if ctx := records[0].Alert.Context; len(ctx) != 1 || ctx["region"] != "eu" {
+		t.Errorf("a1 context = %v, want only region, without the aggregator's enrichment", ctx)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Mock expectations were not met: %v", err)
+	}
+}
@@ -0,0 +1,164 @@
+package replay
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"alert-producer/internal/ingest"
+)
+
+// maxLineSize is the longest line ReadFile accepts.
+const maxLineSize = 1 << 20
+
+// Filter selects the historical alerts to replay.
+type Filter struct {
+	Since    time.Time // zero for no lower bound
+	Until    time.Time // exclusive; zero for no upper bound
+	ClientID string    // only alerts notified to this client; empty for all
+	Limit    int       // the earliest Limit alerts; 0 for all
+}
+
+// includes reports whether an alert that happened at t is within the window.
+func (f Filter) includes(t time.Time) bool {
+	return (f.Since.IsZero() || !t.Before(f.Since)) && (f.Until.IsZero() || t.Before(f.Until))
+}
+
+// enrichedContextKeys are the context keys the aggregator adds to a notification: the matched
+// rules and the owner of the alert's source. They describe the original run, and the
+// aggregator keeps existing values, so they are removed before an alert is replayed.
+var enrichedContextKeys = []string{"matched_rules", "owner", "team", "slack_channel"}
+
+// stripEnrichment removes the aggregator's additions from a notification's context.
+func stripEnrichment(alertContext map[string]string) {
+	for _, key := range enrichedContextKeys {
+		delete(alertContext, key)
+	}
+}
+
+// fileLine is a line of an alert log: an alert in the ingest format, or a notification as
+// exported by rule-service (GET /api/v1/notifications/export?format=ndjson).
+type fileLine struct {
+	ingest.Alert
+	NotificationID string    `json:"notification_id"`
+	ClientID       string    `json:"client_id"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// isNotification reports whether the line is an exported notification rather than an alert.
+func (l *fileLine) isNotification() bool {
+	return l.NotificationID != "" || !l.CreatedAt.IsZero()
+}
+
+// ReadFile reads the alerts of an NDJSON alert log selected by filter, sorted by time. Each
+// line is an alert in the ingest format (timed by event_ts) or an exported notification
+// (timed by created_at). A notification export has a row per notified client, so alerts are
+// deduplicated by alert_id, and the aggregator's enrichment is removed from their context.
+func ReadFile(r io.Reader, filter Filter) ([]Record, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	var records []Record
+	seen := make(map[string]bool)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var l fileLine
+		if err := json.Unmarshal([]byte(line), &l); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+
+		at := l.CreatedAt
+		if l.EventTS > 0 {
+			at = time.Unix(l.EventTS, 0)
+		}
+		if at.IsZero() {
+			return nil, fmt.Errorf("line %d: alert has neither event_ts nor created_at", lineNo)
+		}
+		if !filter.includes(at) {
+			continue
+		}
+		if filter.ClientID != "" && l.ClientID != filter.ClientID && l.ClientHint != filter.ClientID {
+			continue
+		}
+		if l.AlertID != "" {
+			if seen[l.AlertID] {
+				continue
+			}
+			seen[l.AlertID] = true
+		}
+		if l.isNotification() {
+			stripEnrichment(l.Context)
+		}
+		records = append(records, Record{At: at, Alert: l.Alert})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(records, func(i, k int) bool { return records[i].At.Before(records[k].At) })
+	if filter.Limit > 0 && len(records) > filter.Limit {
+		records = records[:filter.Limit]
+	}
+	return records, nil
+}
+
+// LoadPostgres reads the alerts notified within filter's window (by notification created_at)
+// from the notifications table, one per alert_id, sorted by event time. Only alerts that
+// matched at least one rule were notified, so unmatched traffic is not replayed. The
+// aggregator's enrichment is removed from the context.
+func LoadPostgres(ctx context.Context, db *sql.DB, filter Filter) ([]Record, error) {
+	until := filter.Until
+	if until.IsZero() {
+		until = time.Now()
+	}
+
+	query := `
+		SELECT alert_id, severity, source, name, context, at FROM (
+			SELECT DISTINCT ON (alert_id) alert_id, severity, source, name, context,
+				COALESCE(event_ts, created_at) AS at
+			FROM notifications
+			WHERE created_at >= $1 AND created_at < $2 AND ($3 = '' OR client_id = $3)
+			ORDER BY alert_id, created_at
+		) alerts
+		ORDER BY at, alert_id`
+	args := []interface{}{filter.Since.UTC(), until.UTC(), filter.ClientID}
+	if filter.Limit > 0 {
+		query += ` LIMIT $4`
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notifications: %w", err)
+	}
+	defer rows.Close()
+
+	var records []Record
+	for rows.Next() {
+		var rec Record
+		var contextJSON []byte
+		if err := rows.Scan(&rec.Alert.AlertID, &rec.Alert.Severity, &rec.Alert.Source, &rec.Alert.Name, &contextJSON, &rec.At); err != nil {
+			return nil, fmt.Errorf("failed to scan notification: %w", err)
+		}
+		if len(contextJSON) > 0 {
+			if err := json.Unmarshal(contextJSON, &rec.Alert.Context); err != nil {
+				return nil, fmt.Errorf("invalid context of alert %s: %w", rec.Alert.AlertID, err)
+			}
+			stripEnrichment(rec.Alert.Context)
+		}
+		records = append(records, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read notifications: %w", err)
+	}
+	return records, nil
+}
@@ -2,23 +2,33 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"log/slog"
 	"os"
 	"os/signal"
+	"regexp"
+	"strings"
 	"syscall"
 	"time"
 
 	"evaluator/internal/config"
 	"evaluator/internal/consumer"
+	"evaluator/internal/events"
+	"evaluator/internal/health"
 	"evaluator/internal/indexes"
 	"evaluator/internal/matcher"
 	"evaluator/internal/processor"
 	"evaluator/internal/producer"
+	"evaluator/internal/publishlog"
 	"evaluator/internal/reloader"
 	"evaluator/internal/ruleconsumer"
 	"evaluator/internal/snapshot"
+	"evaluator/internal/validation"
+	"evaluator/internal/warmup"
 
+	kafkautil "github.com/afikmenashe/alerting-platform/pkg/kafka"
+	"github.com/afikmenashe/alerting-platform/pkg/lifecycle"
 	"github.com/afikmenashe/alerting-platform/pkg/metrics"
 	"github.com/afikmenashe/alerting-platform/pkg/shared"
 )
@@ -27,13 +37,37 @@ func main() {
 	// Parse command-line flags with environment variable fallbacks
 	cfg := &config.Config{}
 	flag.StringVar(&cfg.KafkaBrokers, "kafka-brokers", shared.GetEnvOrDefault("KAFKA_BROKERS", "localhost:9092"), "Kafka broker addresses (comma-separated)")
-	flag.StringVar(&cfg.AlertsNewTopic, "alerts-new-topic", shared.GetEnvOrDefault("ALERTS_NEW_TOPIC", "alerts.new"), "Kafka topic for incoming alerts")
+	flag.StringVar(&cfg.AlertsNewTopic, "alerts-new-topic", shared.GetEnvOrDefault("ALERTS_NEW_TOPIC", "alerts.new"), "Kafka topics for incoming alerts (comma-separated, e.g. alerts.new.eu,alerts.new.us)")
+	flag.StringVar(&cfg.AlertsNewTopicPattern, "alerts-new-topic-pattern", shared.GetEnvOrDefault("ALERTS_NEW_TOPIC_PATTERN", ""), "Read incoming alerts from every topic matching this regular expression, resolved at startup (overrides -alerts-new-topic)")
 	flag.StringVar(&cfg.AlertsMatchedTopic, "alerts-matched-topic", shared.GetEnvOrDefault("ALERTS_MATCHED_TOPIC", "alerts.matched"), "Kafka topic for matched alerts")
 	flag.StringVar(&cfg.RuleChangedTopic, "rule-changed-topic", shared.GetEnvOrDefault("RULE_CHANGED_TOPIC", "rule.changed"), "Kafka topic for rule change events")
 	flag.StringVar(&cfg.ConsumerGroupID, "consumer-group-id", shared.GetEnvOrDefault("CONSUMER_GROUP_ID", "evaluator-group"), "Kafka consumer group ID for alerts.new")
 	flag.StringVar(&cfg.RuleChangedGroupID, "rule-changed-group-id", shared.GetEnvOrDefault("RULE_CHANGED_GROUP_ID", "evaluator-rule-changed-group"), "Kafka consumer group ID for rule.changed")
-	flag.StringVar(&cfg.RedisAddr, "redis-addr", shared.GetEnvOrDefault("REDIS_ADDR", "localhost:6379"), "Redis server address")
-	flag.DurationVar(&cfg.VersionPollInterval, "version-poll-interval", 5*time.Second, "Interval for polling Redis version")
+	flag.StringVar(&cfg.RedisAddr, "redis-addr", shared.GetEnvOrDefault("REDIS_ADDR", "localhost:6379"), "Redis server address, or comma-separated Sentinel or cluster node addresses")
+	flag.StringVar(&cfg.RedisSentinelMaster, "redis-sentinel-master", shared.GetEnvOrDefault("REDIS_SENTINEL_MASTER", ""), "Sentinel master name; connects through the Sentinels listed in -redis-addr")
+	flag.BoolVar(&cfg.RedisCluster, "redis-cluster", shared.GetEnvOrDefault("REDIS_CLUSTER", "false") == "true", "Connect to a Redis Cluster; -redis-addr lists seed nodes")
+	flag.DurationVar(&cfg.VersionPollInterval, "version-poll-interval", 30*time.Second, "Interval for polling Redis version, a fallback for missed rules:changed announcements")
+	flag.StringVar(&cfg.MatchedFanOut, "matched-fanout", shared.GetEnvOrDefault("MATCHED_FANOUT", events.FanOutPerClient), "alerts.matched fan-out policy: per-client (keyed by client_id) or combined (one event per alert, keyed by alert_id)")
+	flag.StringVar(&cfg.UnmatchedPolicy, "unmatched-policy", shared.GetEnvOrDefault("UNMATCHED_POLICY", events.UnmatchedDrop), "Handling of alerts that match no rule: drop, publish (to -alerts-unmatched-topic) or count (per severity and source in metrics)")
+	flag.StringVar(&cfg.AlertsUnmatchedTopic, "alerts-unmatched-topic", shared.GetEnvOrDefault("ALERTS_UNMATCHED_TOPIC", "alerts.unmatched"), "Kafka topic for alerts that match no rule, with -unmatched-policy publish")
+	flag.BoolVar(&cfg.ValidateAlerts, "validate-alerts", shared.GetEnvOrDefault("VALIDATE_ALERTS", "true") == "true", "Reject malformed alerts (missing fields, unknown severity, bad timestamps) before matching")
+	flag.StringVar(&cfg.AllowedSeverities, "allowed-severities", shared.GetEnvOrDefault("ALLOWED_SEVERITIES", strings.Join(validation.DefaultSeverities, ",")), "Allowed alert severities (comma-separated)")
+	flag.DurationVar(&cfg.MaxClockSkew, "max-clock-skew", validation.DefaultMaxClockSkew, "Reject alerts whose event_ts is further in the future than this (0 = no limit)")
+	flag.DurationVar(&cfg.MaxAlertAge, "max-alert-age", validation.DefaultMaxAlertAge, "Reject alerts whose event_ts is older than this (0 = no limit)")
+	flag.IntVar(&cfg.MaxSchemaVersion, "max-schema-version", validation.CurrentSchemaVersion, "Reject alerts whose schema_version is newer than this")
+	flag.IntVar(&cfg.MaxContextKeys, "max-context-keys", validation.DefaultMaxContextKeys, "Reject alerts with more context entries than this (0 = no limit)")
+	flag.IntVar(&cfg.MaxContextBytes, "max-context-bytes", validation.DefaultMaxContextBytes, "Reject alerts whose context keys and values total more bytes than this (0 = no limit)")
+	flag.StringVar(&cfg.DLQTopic, "dlq-topic", shared.GetEnvOrDefault("DLQ_TOPIC", kafkautil.DLQTopic("alerts.new")), "Kafka topic for alerts that cannot be decoded, are rejected, or keep failing to publish (empty = disabled)")
+	flag.IntVar(&cfg.DLQMaxFailures, "dlq-max-failures", kafkautil.DefaultMaxFailures, "Failed publish attempts before an alert is dead-lettered")
+	flag.DurationVar(&cfg.PublishLogTTL, "publish-log-ttl", 0, "Remember published matched alerts in Redis this long, so a redelivered alert does not publish them again (0 = disabled)")
+	flag.StringVar(&cfg.DebugEvaluationsTopic, "debug-evaluations-topic", shared.GetEnvOrDefault("DEBUG_EVALUATIONS_TOPIC", "debug.evaluations"), "Kafka topic for sampled evaluation results")
+	flag.IntVar(&cfg.EvaluationSampleRate, "evaluation-sample-rate", 0, "Publish 1 in N evaluation results, including non-matches, to the debug topic (0 = disabled)")
+	flag.DurationVar(&cfg.EvaluationDeadline, "evaluation-deadline", 100*time.Millisecond, "Log and count the slowest rules of alerts whose matching takes longer than this (0 = disabled)")
+	flag.IntVar(&cfg.SlowRuleDisableAfter, "slow-rule-disable-after", 0, "Disable a rule via rule.changed after it is among the slowest rules this many times (0 = never)")
+	flag.DurationVar(&cfg.SlowRuleWindow, "slow-rule-window", 10*time.Minute, "Window in which slow-rule offenses are counted (0 = no window)")
+	flag.StringVar(&cfg.HealthPort, "health-port", shared.GetEnvOrDefault("HEALTH_PORT", "8084"), "Port of the /health and /readyz endpoints (empty = disabled)")
+	flag.IntVar(&cfg.WarmUpAlerts, "warm-up-alerts", warmup.DefaultAlerts, "Synthetic alerts replayed through the matcher before /readyz reports ready (0 = no warm-up)")
+	kafkaSecurity := kafkautil.RegisterSecurityFlags(flag.CommandLine)
 	flag.Parse()
 
 	// Set up structured logging
@@ -44,18 +78,40 @@ func main() {
 	slog.Info("Starting evaluator service",
 		"kafka_brokers", cfg.KafkaBrokers,
 		"alerts_new_topic", cfg.AlertsNewTopic,
+		"alerts_new_topic_pattern", cfg.AlertsNewTopicPattern,
 		"alerts_matched_topic", cfg.AlertsMatchedTopic,
 		"rule_changed_topic", cfg.RuleChangedTopic,
 		"consumer_group_id", cfg.ConsumerGroupID,
 		"rule_changed_group_id", cfg.RuleChangedGroupID,
 		"redis_addr", cfg.RedisAddr,
+		"redis_sentinel_master", cfg.RedisSentinelMaster,
+		"redis_cluster", cfg.RedisCluster,
 		"version_poll_interval", cfg.VersionPollInterval,
+		"unmatched_policy", cfg.UnmatchedPolicy,
+		"validate_alerts", cfg.ValidateAlerts,
+		"max_clock_skew", cfg.MaxClockSkew,
+		"max_alert_age", cfg.MaxAlertAge,
+		"max_schema_version", cfg.MaxSchemaVersion,
+		"max_context_keys", cfg.MaxContextKeys,
+		"max_context_bytes", cfg.MaxContextBytes,
+		"dlq_topic", cfg.DLQTopic,
+		"dlq_max_failures", cfg.DLQMaxFailures,
+		"publish_log_ttl", cfg.PublishLogTTL,
+		"evaluation_sample_rate", cfg.EvaluationSampleRate,
+		"evaluation_deadline", cfg.EvaluationDeadline,
+		"slow_rule_disable_after", cfg.SlowRuleDisableAfter,
+		"health_port", cfg.HealthPort,
+		"warm_up_alerts", cfg.WarmUpAlerts,
 	)
 
 	if err := cfg.Validate(); err != nil {
 		slog.Error("Invalid configuration", "error", err)
 		os.Exit(1)
 	}
+	if err := kafkautil.Configure(kafkaSecurity); err != nil {
+		slog.Error("Invalid Kafka security configuration", "error", err)
+		os.Exit(1)
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -69,21 +125,37 @@ func main() {
 		cancel()
 	}()
 
-	// Initialize Redis client
-	slog.Info("Connecting to Redis", "addr", cfg.RedisAddr)
-	redisClient, err := shared.ConnectRedis(ctx, cfg.RedisAddr)
+	// Components are registered as they are created and stopped in reverse order
+	lc := lifecycle.New()
+
+	// Serve /health and /readyz; /readyz reports ready once the matcher is warmed up.
+	// The health server starts at once, so probes are answered while the snapshot loads
+	healthHandler := health.NewHandler()
+	if cfg.HealthPort != "" {
+		healthServer := shared.NewHTTPServer(":"+cfg.HealthPort, healthHandler, shared.WithQuietPaths("/readyz"))
+		lc.Append(lifecycle.Server("health server", healthServer))
+		if err := lc.Start(ctx); err != nil {
+			slog.Error("Failed to start health server", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	// Initialize Redis client; Validate already parsed the Redis options
+	redisOpts, _ := shared.ParseRedisOptions(cfg.RedisAddr, cfg.RedisSentinelMaster, cfg.RedisCluster)
+	slog.Info("Connecting to Redis", "addr", cfg.RedisAddr, "mode", redisOpts.Mode())
+	redisClient, err := shared.ConnectRedisUniversal(ctx, redisOpts)
 	if err != nil {
 		slog.Error("Failed to connect to Redis", "error", err)
 		slog.Info("Tip: Start Redis with 'docker compose up -d redis' or ensure Redis is running")
 		os.Exit(1)
 	}
-	defer redisClient.Close()
+	lc.Append(lifecycle.Closer("redis", redisClient))
 	slog.Info("Successfully connected to Redis")
 
 	// Initialize shared metrics collector
 	metricsCollector := metrics.NewCollector("evaluator", redisClient)
 	metricsCollector.Start(ctx)
-	defer metricsCollector.Stop()
+	lc.Append(lifecycle.StopFunc("metrics collector", metricsCollector.Stop))
 
 	// Initialize snapshot loader
 	loader := snapshot.NewLoader(redisClient)
@@ -93,7 +165,11 @@ func main() {
 	snap, err := loader.LoadSnapshot(ctx)
 	if err != nil {
 		slog.Error("Failed to load initial snapshot", "error", err)
-		slog.Info("Tip: Ensure rule-updater has created the snapshot in Redis")
+		if errors.Is(err, snapshot.ErrChecksumMismatch) {
+			slog.Info("Tip: rule-updater rewrites the snapshot on its next rule change or restart")
+		} else {
+			slog.Info("Tip: Ensure rule-updater has created the snapshot in Redis")
+		}
 		os.Exit(1)
 	}
 
@@ -102,10 +178,21 @@ func main() {
 	ruleMatcher := matcher.NewMatcher(initialIndexes)
 	slog.Info("Initial indexes built",
 		"rules_count", initialIndexes.RuleCount(),
+		"snapshot_version", snap.Version,
 	)
 
-	// Start version reloader (polls Redis for version changes)
-	reload := reloader.NewReloader(loader, ruleMatcher, cfg.VersionPollInterval)
+	// Replay synthetic alerts before reporting ready; indexes that miss their own rules are corrupt
+	if cfg.WarmUpAlerts > 0 {
+		if _, err := warmup.Run(ruleMatcher, cfg.WarmUpAlerts); err != nil {
+			healthHandler.SetFailed(err)
+			slog.Error("Matcher warm-up failed", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	// Start version reloader (reloads on rules:changed announcements, polls Redis as a fallback)
+	reload := reloader.NewReloader(loader, ruleMatcher, cfg.VersionPollInterval).
+		WithSnapshotVersion(snap.Version)
 	if err := reload.Start(ctx); err != nil {
 		slog.Error("Failed to start version reloader", "error", err)
 		os.Exit(1)
@@ -119,22 +206,37 @@ func main() {
 		slog.Info("Tip: Start Kafka with 'docker compose up -d kafka'")
 		os.Exit(1)
 	}
-	defer ruleChangedConsumer.Close()
+	lc.Append(lifecycle.Closer("rule.changed consumer", ruleChangedConsumer))
 	slog.Info("Successfully connected to rule.changed consumer")
 
 	// Initialize rule change handler
-	ruleHandler := processor.NewRuleHandler(ruleChangedConsumer, reload)
-	go ruleHandler.HandleRuleChanged(ctx)
+	ruleHandler := processor.NewRuleHandler(ruleChangedConsumer, reload, ruleMatcher).
+		WithMetrics(metricsCollector).
+		WithPropagation(metricsCollector).
+		WithPinState(reload)
+	lc.Append(lifecycle.Go("rule change handler", ruleHandler.HandleRuleChanged))
 
 	// Initialize Kafka consumer
-	slog.Info("Connecting to Kafka consumer", "topic", cfg.AlertsNewTopic)
-	kafkaConsumer, err := consumer.NewConsumer(cfg.KafkaBrokers, cfg.AlertsNewTopic, cfg.ConsumerGroupID)
+	alertTopics := cfg.AlertsNewTopics()
+	if cfg.AlertsNewTopicPattern != "" {
+		alertTopics, err = consumer.ResolveTopics(ctx, cfg.KafkaBrokers, regexp.MustCompile(cfg.AlertsNewTopicPattern))
+		if err != nil {
+			slog.Error("Failed to resolve alert topics", "pattern", cfg.AlertsNewTopicPattern, "error", err)
+			os.Exit(1)
+		}
+		if len(alertTopics) == 0 {
+			slog.Error("No topic matches the alert topic pattern", "pattern", cfg.AlertsNewTopicPattern)
+			os.Exit(1)
+		}
+	}
+	slog.Info("Connecting to Kafka consumer", "topics", alertTopics)
+	kafkaConsumer, err := consumer.NewMultiTopicConsumer(cfg.KafkaBrokers, alertTopics, cfg.ConsumerGroupID)
 	if err != nil {
 		slog.Error("Failed to create Kafka consumer", "error", err)
 		slog.Info("Tip: Start Kafka with 'docker compose up -d kafka'")
 		os.Exit(1)
 	}
-	defer kafkaConsumer.Close()
+	lc.Append(lifecycle.Closer("kafka consumer", kafkaConsumer))
 	slog.Info("Successfully connected to Kafka consumer")
 
 	// Initialize Kafka producer
@@ -144,19 +246,95 @@ func main() {
 		slog.Error("Failed to create Kafka producer", "error", err)
 		os.Exit(1)
 	}
-	defer kafkaProducer.Close()
+	lc.Append(lifecycle.Closer("kafka producer", kafkaProducer))
 	slog.Info("Successfully connected to Kafka producer")
 
 	// Initialize processor with metrics
-	proc := processor.NewProcessorWithMetrics(kafkaConsumer, kafkaProducer, ruleMatcher, metricsCollector)
+	proc := processor.NewProcessorWithMetrics(kafkaConsumer, kafkaProducer, ruleMatcher, metricsCollector).
+		WithTracer(metricsCollector).
+		WithFanOut(cfg.MatchedFanOut)
+	slog.Info("Matched alert fan-out configured", "policy", cfg.MatchedFanOut)
+	if cfg.ValidateAlerts {
+		proc.WithValidator(validation.NewValidator(validation.Options{
+			Severities:       validation.ParseSeverities(cfg.AllowedSeverities),
+			MaxClockSkew:     cfg.MaxClockSkew,
+			MaxAlertAge:      cfg.MaxAlertAge,
+			MaxSchemaVersion: cfg.MaxSchemaVersion,
+			MaxContextKeys:   cfg.MaxContextKeys,
+			MaxContextBytes:  cfg.MaxContextBytes,
+		}))
+	}
+
+	if cfg.DLQEnabled() {
+		dlq, err := kafkautil.NewDeadLetterQueue(cfg.KafkaBrokers, cfg.DLQTopic, cfg.DLQMaxFailures)
+		if err != nil {
+			slog.Error("Failed to create dead-letter queue", "error", err)
+			os.Exit(1)
+		}
+		lc.Append(lifecycle.Closer("dead-letter queue", dlq))
+		proc.WithDeadLetterQueue(dlq)
+	}
+
+	if cfg.PublishLogEnabled() {
+		proc.WithPublishLog(publishlog.NewRedisLog(redisClient, cfg.PublishLogTTL))
+		slog.Info("Idempotent publishing enabled", "publish_log_ttl", cfg.PublishLogTTL)
+	}
+
+	var unmatchedPublisher processor.UnmatchedPublisher
+	if cfg.UnmatchedPublishEnabled() {
+		unmatchedProducer, err := producer.NewUnmatchedProducer(cfg.KafkaBrokers, cfg.AlertsUnmatchedTopic)
+		if err != nil {
+			slog.Error("Failed to create unmatched alert producer", "error", err)
+			os.Exit(1)
+		}
+		lc.Append(lifecycle.Closer("unmatched alert producer", unmatchedProducer))
+		unmatchedPublisher = unmatchedProducer
+		slog.Info("Publishing unmatched alerts", "topic", cfg.AlertsUnmatchedTopic)
+	}
+	proc.WithUnmatchedPolicy(cfg.UnmatchedPolicy, unmatchedPublisher)
+	slog.Info("Unmatched alert policy configured", "policy", cfg.UnmatchedPolicy)
+
+	if cfg.SamplingEnabled() {
+		sampleProducer, err := producer.NewSampleProducer(cfg.KafkaBrokers, cfg.DebugEvaluationsTopic)
+		if err != nil {
+			slog.Error("Failed to create evaluation sample producer", "error", err)
+			os.Exit(1)
+		}
+		lc.Append(lifecycle.Closer("evaluation sample producer", sampleProducer))
+		proc.WithSampling(sampleProducer, cfg.EvaluationSampleRate)
+		slog.Info("Evaluation sampling enabled", "topic", cfg.DebugEvaluationsTopic, "sample_rate", cfg.EvaluationSampleRate)
+	}
+
+	proc.WithEvaluationDeadline(cfg.EvaluationDeadline)
+	if cfg.SlowRuleDisableEnabled() {
+		ruleChangedProducer, err := producer.NewRuleChangedProducer(cfg.KafkaBrokers, cfg.RuleChangedTopic)
+		if err != nil {
+			slog.Error("Failed to create rule.changed producer", "error", err)
+			os.Exit(1)
+		}
+		lc.Append(lifecycle.Closer("rule.changed producer", ruleChangedProducer))
+		proc.WithSlowRuleDisabler(ruleChangedProducer, cfg.SlowRuleDisableAfter, cfg.SlowRuleWindow)
+		slog.Info("Slow-rule auto-disable enabled", "deadline", cfg.EvaluationDeadline, "after", cfg.SlowRuleDisableAfter, "window", cfg.SlowRuleWindow)
+	}
+
+	if err := lc.Start(ctx); err != nil {
+		slog.Error("Failed to start", "error", err)
+		lc.Stop(context.Background())
+		os.Exit(1)
+	}
 
 	// Main processing loop
+	healthHandler.SetReady()
 	slog.Info("Starting alert evaluation loop")
 	if err := proc.ProcessAlerts(ctx); err != nil {
 		slog.Error("Alert processing failed", "error", err)
+		lc.Stop(context.Background())
 		os.Exit(1)
 	}
 
+	if err := lc.Stop(context.Background()); err != nil {
+		slog.Error("Failed to stop cleanly", "error", err)
+		os.Exit(1)
+	}
 	slog.Info("Evaluator service stopped")
 }
-
@@ -3,19 +3,104 @@ package config
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
 	"time"
+
+	"evaluator/internal/events"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
 )
 
 // Config holds all configuration parameters for the evaluator service.
 type Config struct {
 	KafkaBrokers        string
-	AlertsNewTopic      string
+	AlertsNewTopic      string // Comma-separated list of topics to read alerts from
 	AlertsMatchedTopic  string
 	RuleChangedTopic    string
 	ConsumerGroupID     string
 	RuleChangedGroupID  string
 	RedisAddr           string
+	RedisSentinelMaster string // Sentinel master name; RedisAddr then lists the Sentinels
+	RedisCluster        bool   // Connect to a Redis Cluster; RedisAddr then lists seed nodes
 	VersionPollInterval time.Duration
+	MatchedFanOut       string // alerts.matched fan-out policy: per-client (default) or combined
+
+	// Topic pattern subscription (empty = read the topics listed in AlertsNewTopic)
+	AlertsNewTopicPattern string // Read every topic matching this regular expression, resolved at startup
+
+	// Alert validation
+	ValidateAlerts    bool          // Reject malformed alerts before matching
+	AllowedSeverities string        // Comma-separated severity enum
+	MaxClockSkew      time.Duration // How far in the future event_ts may be (0 = no limit)
+	MaxAlertAge       time.Duration // How far in the past event_ts may be (0 = no limit)
+	MaxSchemaVersion  int           // Newest alerts.new schema_version accepted
+	MaxContextKeys    int           // Context entries an alert may have (0 = no limit)
+	MaxContextBytes   int           // Total size of an alert's context keys and values (0 = no limit)
+
+	// Dead-letter queue for alerts that cannot be processed (disabled when DLQTopic is empty)
+	DLQTopic       string
+	DLQMaxFailures int // Failed publish attempts before an alert is dead-lettered
+
+	// Idempotent publishing: published alerts.matched events are remembered in Redis for
+	// PublishLogTTL, and a redelivered alert skips them (0 = disabled)
+	PublishLogTTL time.Duration
+
+	// Handling of alerts that match no rule: drop (default), publish to AlertsUnmatchedTopic,
+	// or count per severity and source
+	UnmatchedPolicy      string
+	AlertsUnmatchedTopic string
+
+	// Sampled evaluation results for offline QA (disabled when EvaluationSampleRate is 0)
+	DebugEvaluationsTopic string
+	EvaluationSampleRate  int // Publish 1 in N evaluation results
+
+	// Slow-rule detection (disabled when EvaluationDeadline is 0)
+	EvaluationDeadline   time.Duration // Time matching one alert may take before its slowest rules are reported
+	SlowRuleDisableAfter int           // Disable a rule after this many slow evaluations (0 = never)
+	SlowRuleWindow       time.Duration // Window the slow evaluations are counted in (0 = no window)
+
+	// Startup
+	HealthPort   string // Port of the /health and /readyz endpoints (empty = disabled)
+	WarmUpAlerts int    // Synthetic alerts replayed through the matcher before ready (0 = no warm-up)
+}
+
+// AlertsNewTopics returns the topics listed in AlertsNewTopic.
+func (c *Config) AlertsNewTopics() []string {
+	var topics []string
+	for _, topic := range strings.Split(c.AlertsNewTopic, ",") {
+		if topic = strings.TrimSpace(topic); topic != "" {
+			topics = append(topics, topic)
+		}
+	}
+	return topics
+}
+
+// SlowRuleDisableEnabled reports whether rules that keep exceeding the evaluation deadline
+// are disabled.
+func (c *Config) SlowRuleDisableEnabled() bool {
+	return c.EvaluationDeadline > 0 && c.SlowRuleDisableAfter > 0
+}
+
+// PublishLogEnabled reports whether published matched events are remembered so redelivered
+// alerts do not publish them again.
+func (c *Config) PublishLogEnabled() bool {
+	return c.PublishLogTTL > 0
+}
+
+// SamplingEnabled reports whether evaluation results are sampled to the debug topic.
+func (c *Config) SamplingEnabled() bool {
+	return c.EvaluationSampleRate > 0
+}
+
+// UnmatchedPublishEnabled reports whether unmatched alerts are published to their topic.
+func (c *Config) UnmatchedPublishEnabled() bool {
+	return c.UnmatchedPolicy == events.UnmatchedPublish
+}
+
+// DLQEnabled reports whether unprocessable alerts are dead-lettered.
+func (c *Config) DLQEnabled() bool {
+	return c.DLQTopic != ""
 }
 
 // Validate checks that all required configuration fields are set and have valid values.
@@ -24,7 +109,11 @@ func (c *Config) Validate() error {
 	if c.KafkaBrokers == "" {
 		return fmt.Errorf("kafka-brokers cannot be empty")
 	}
-	if c.AlertsNewTopic == "" {
+	if c.AlertsNewTopicPattern != "" {
+		if _, err := regexp.Compile(c.AlertsNewTopicPattern); err != nil {
+			return fmt.Errorf("alerts-new-topic-pattern is not a valid regular expression: %w", err)
+		}
+	} else if len(c.AlertsNewTopics()) == 0 {
 		return fmt.Errorf("alerts-new-topic cannot be empty")
 	}
 	if c.AlertsMatchedTopic == "" {
@@ -39,11 +128,69 @@ func (c *Config) Validate() error {
 	if c.RuleChangedGroupID == "" {
 		return fmt.Errorf("rule-changed-group-id cannot be empty")
 	}
-	if c.RedisAddr == "" {
-		return fmt.Errorf("redis-addr cannot be empty")
+	if _, err := shared.ParseRedisOptions(c.RedisAddr, c.RedisSentinelMaster, c.RedisCluster); err != nil {
+		return err
 	}
 	if c.VersionPollInterval <= 0 {
 		return fmt.Errorf("version-poll-interval must be > 0")
 	}
+	switch c.MatchedFanOut {
+	case "", events.FanOutPerClient, events.FanOutCombined:
+	default:
+		return fmt.Errorf("matched-fanout must be %q or %q", events.FanOutPerClient, events.FanOutCombined)
+	}
+	switch c.UnmatchedPolicy {
+	case "", events.UnmatchedDrop, events.UnmatchedPublish, events.UnmatchedCount:
+	default:
+		return fmt.Errorf("unmatched-policy must be %q, %q or %q", events.UnmatchedDrop, events.UnmatchedPublish, events.UnmatchedCount)
+	}
+	if c.UnmatchedPublishEnabled() && c.AlertsUnmatchedTopic == "" {
+		return fmt.Errorf("alerts-unmatched-topic cannot be empty when unmatched alerts are published")
+	}
+	if c.ValidateAlerts && c.AllowedSeverities == "" {
+		return fmt.Errorf("allowed-severities cannot be empty when alert validation is enabled")
+	}
+	if c.MaxClockSkew < 0 {
+		return fmt.Errorf("max-clock-skew cannot be negative")
+	}
+	if c.MaxAlertAge < 0 {
+		return fmt.Errorf("max-alert-age cannot be negative")
+	}
+	if c.MaxSchemaVersion < 0 {
+		return fmt.Errorf("max-schema-version cannot be negative")
+	}
+	if c.MaxContextKeys < 0 {
+		return fmt.Errorf("max-context-keys cannot be negative")
+	}
+	if c.MaxContextBytes < 0 {
+		return fmt.Errorf("max-context-bytes cannot be negative")
+	}
+	if c.DLQEnabled() && c.DLQMaxFailures <= 0 {
+		return fmt.Errorf("dlq-max-failures must be positive")
+	}
+	if c.PublishLogTTL < 0 {
+		return fmt.Errorf("publish-log-ttl cannot be negative")
+	}
+	if c.EvaluationSampleRate < 0 {
+		return fmt.Errorf("evaluation-sample-rate cannot be negative")
+	}
+	if c.SamplingEnabled() && c.DebugEvaluationsTopic == "" {
+		return fmt.Errorf("debug-evaluations-topic cannot be empty when evaluation sampling is enabled")
+	}
+	if c.EvaluationDeadline < 0 {
+		return fmt.Errorf("evaluation-deadline cannot be negative")
+	}
+	if c.SlowRuleDisableAfter < 0 {
+		return fmt.Errorf("slow-rule-disable-after cannot be negative")
+	}
+	if c.SlowRuleDisableAfter > 0 && c.EvaluationDeadline == 0 {
+		return fmt.Errorf("slow-rule-disable-after requires an evaluation-deadline")
+	}
+	if c.SlowRuleWindow < 0 {
+		return fmt.Errorf("slow-rule-window cannot be negative")
+	}
+	if c.WarmUpAlerts < 0 {
+		return fmt.Errorf("warm-up-alerts cannot be negative")
+	}
 	return nil
 }
@@ -26,6 +26,86 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "validation enabled without severities",
+			config: &Config{
+				KafkaBrokers:        "localhost:9092",
+				AlertsNewTopic:      "alerts.new",
+				AlertsMatchedTopic:  "alerts.matched",
+				RuleChangedTopic:    "rule.changed",
+				ConsumerGroupID:     "evaluator-group",
+				RuleChangedGroupID:  "evaluator-rule-changed-group",
+				RedisAddr:           "localhost:6379",
+				VersionPollInterval: 5 * time.Second,
+				ValidateAlerts:      true,
+			},
+			wantErr: true,
+			errMsg:  "allowed-severities cannot be empty when alert validation is enabled",
+		},
+		{
+			name: "negative max clock skew",
+			config: &Config{
+				KafkaBrokers:        "localhost:9092",
+				AlertsNewTopic:      "alerts.new",
+				AlertsMatchedTopic:  "alerts.matched",
+				RuleChangedTopic:    "rule.changed",
+				ConsumerGroupID:     "evaluator-group",
+				RuleChangedGroupID:  "evaluator-rule-changed-group",
+				RedisAddr:           "localhost:6379",
+				VersionPollInterval: 5 * time.Second,
+				MaxClockSkew:        -time.Second,
+			},
+			wantErr: true,
+			errMsg:  "max-clock-skew cannot be negative",
+		},
+		{
+			name: "negative max schema version",
+			config: &Config{
+				KafkaBrokers:        "localhost:9092",
+				AlertsNewTopic:      "alerts.new",
+				AlertsMatchedTopic:  "alerts.matched",
+				RuleChangedTopic:    "rule.changed",
+				ConsumerGroupID:     "evaluator-group",
+				RuleChangedGroupID:  "evaluator-rule-changed-group",
+				RedisAddr:           "localhost:6379",
+				VersionPollInterval: 5 * time.Second,
+				MaxSchemaVersion:    -1,
+			},
+			wantErr: true,
+			errMsg:  "max-schema-version cannot be negative",
+		},
+		{
+			name: "negative max context keys",
+			config: &Config{
+				KafkaBrokers:        "localhost:9092",
+				AlertsNewTopic:      "alerts.new",
+				AlertsMatchedTopic:  "alerts.matched",
+				RuleChangedTopic:    "rule.changed",
+				ConsumerGroupID:     "evaluator-group",
+				RuleChangedGroupID:  "evaluator-rule-changed-group",
+				RedisAddr:           "localhost:6379",
+				VersionPollInterval: 5 * time.Second,
+				MaxContextKeys:      -1,
+			},
+			wantErr: true,
+			errMsg:  "max-context-keys cannot be negative",
+		},
+		{
+			name: "negative max context bytes",
+			config: &Config{
+				KafkaBrokers:        "localhost:9092",
+				AlertsNewTopic:      "alerts.new",
+				AlertsMatchedTopic:  "alerts.matched",
+				RuleChangedTopic:    "rule.changed",
+				ConsumerGroupID:     "evaluator-group",
+				RuleChangedGroupID:  "evaluator-rule-changed-group",
+				RedisAddr:           "localhost:6379",
+				VersionPollInterval: 5 * time.Second,
+				MaxContextBytes:     -1,
+			},
+			wantErr: true,
+			errMsg:  "max-context-bytes cannot be negative",
+		},
 		{
 			name: "empty kafka brokers",
 			config: &Config{
@@ -131,6 +211,36 @@ func TestConfig_Validate(t *testing.T) {
 			wantErr: true,
 			errMsg:  "redis-addr cannot be empty",
 		},
+		{
+			name: "valid cluster config",
+			config: &Config{
+				KafkaBrokers:        "localhost:9092",
+				AlertsNewTopic:      "alerts.new",
+				AlertsMatchedTopic:  "alerts.matched",
+				RuleChangedTopic:    "rule.changed",
+				ConsumerGroupID:     "evaluator-group",
+				RuleChangedGroupID:  "evaluator-rule-changed-group",
+				RedisAddr:           "redis-1:6379, redis-2:6379,redis-3:6379",
+				RedisCluster:        true,
+				VersionPollInterval: 5 * time.Second,
+			},
+			wantErr: false,
+		},
+		{
+			name: "multiple redis addrs without sentinel or cluster",
+			config: &Config{
+				KafkaBrokers:        "localhost:9092",
+				AlertsNewTopic:      "alerts.new",
+				AlertsMatchedTopic:  "alerts.matched",
+				RuleChangedTopic:    "rule.changed",
+				ConsumerGroupID:     "evaluator-group",
+				RuleChangedGroupID:  "evaluator-rule-changed-group",
+				RedisAddr:           "redis-1:6379,redis-2:6379",
+				VersionPollInterval: 5 * time.Second,
+			},
+			wantErr: true,
+			errMsg:  "redis-addr lists 2 addresses: set redis-sentinel-master or redis-cluster",
+		},
 		{
 			name: "zero version poll interval",
 			config: &Config{
@@ -161,6 +271,259 @@ func TestConfig_Validate(t *testing.T) {
 			wantErr: true,
 			errMsg:  "version-poll-interval must be > 0",
 		},
+		{
+			name: "combined fan-out",
+			config: &Config{
+				KafkaBrokers:        "localhost:9092",
+				AlertsNewTopic:      "alerts.new",
+				AlertsMatchedTopic:  "alerts.matched",
+				RuleChangedTopic:    "rule.changed",
+				ConsumerGroupID:     "evaluator-group",
+				RuleChangedGroupID:  "evaluator-rule-changed-group",
+				RedisAddr:           "localhost:6379",
+				VersionPollInterval: 5 * time.Second,
+				MatchedFanOut:       "combined",
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown fan-out",
+			config: &Config{
+				KafkaBrokers:        "localhost:9092",
+				AlertsNewTopic:      "alerts.new",
+				AlertsMatchedTopic:  "alerts.matched",
+				RuleChangedTopic:    "rule.changed",
+				ConsumerGroupID:     "evaluator-group",
+				RuleChangedGroupID:  "evaluator-rule-changed-group",
+				RedisAddr:           "localhost:6379",
+				VersionPollInterval: 5 * time.Second,
+				MatchedFanOut:       "broadcast",
+			},
+			wantErr: true,
+			errMsg:  `matched-fanout must be "per-client" or "combined"`,
+		},
+		{
+			name: "publish unmatched alerts",
+			config: &Config{
+				KafkaBrokers:         "localhost:9092",
+				AlertsNewTopic:       "alerts.new",
+				AlertsMatchedTopic:   "alerts.matched",
+				RuleChangedTopic:     "rule.changed",
+				ConsumerGroupID:      "evaluator-group",
+				RuleChangedGroupID:   "evaluator-rule-changed-group",
+				RedisAddr:            "localhost:6379",
+				VersionPollInterval:  5 * time.Second,
+				UnmatchedPolicy:      "publish",
+				AlertsUnmatchedTopic: "alerts.unmatched",
+			},
+			wantErr: false,
+		},
+		{
+			name: "publish unmatched alerts without topic",
+			config: &Config{
+				KafkaBrokers:        "localhost:9092",
+				AlertsNewTopic:      "alerts.new",
+				AlertsMatchedTopic:  "alerts.matched",
+				RuleChangedTopic:    "rule.changed",
+				ConsumerGroupID:     "evaluator-group",
+				RuleChangedGroupID:  "evaluator-rule-changed-group",
+				RedisAddr:           "localhost:6379",
+				VersionPollInterval: 5 * time.Second,
+				UnmatchedPolicy:     "publish",
+			},
+			wantErr: true,
+			errMsg:  "alerts-unmatched-topic cannot be empty when unmatched alerts are published",
+		},
+		{
+			name: "unknown unmatched policy",
+			config: &Config{
+				KafkaBrokers:        "localhost:9092",
+				AlertsNewTopic:      "alerts.new",
+				AlertsMatchedTopic:  "alerts.matched",
+				RuleChangedTopic:    "rule.changed",
+				ConsumerGroupID:     "evaluator-group",
+				RuleChangedGroupID:  "evaluator-rule-changed-group",
+				RedisAddr:           "localhost:6379",
+				VersionPollInterval: 5 * time.Second,
+				UnmatchedPolicy:     "retry",
+			},
+			wantErr: true,
+			errMsg:  `unmatched-policy must be "drop", "publish" or "count"`,
+		},
+		{
+			name: "dlq without max failures",
+			config: &Config{
+				KafkaBrokers:        "localhost:9092",
+				AlertsNewTopic:      "alerts.new",
+				AlertsMatchedTopic:  "alerts.matched",
+				RuleChangedTopic:    "rule.changed",
+				ConsumerGroupID:     "evaluator-group",
+				RuleChangedGroupID:  "evaluator-rule-changed-group",
+				RedisAddr:           "localhost:6379",
+				VersionPollInterval: 5 * time.Second,
+				DLQTopic:            "alerts.new.dlq",
+			},
+			wantErr: true,
+			errMsg:  "dlq-max-failures must be positive",
+		},
+		{
+			name: "sampling without topic",
+			config: &Config{
+				KafkaBrokers:         "localhost:9092",
+				AlertsNewTopic:       "alerts.new",
+				AlertsMatchedTopic:   "alerts.matched",
+				RuleChangedTopic:     "rule.changed",
+				ConsumerGroupID:      "evaluator-group",
+				RuleChangedGroupID:   "evaluator-rule-changed-group",
+				RedisAddr:            "localhost:6379",
+				VersionPollInterval:  5 * time.Second,
+				EvaluationSampleRate: 100,
+			},
+			wantErr: true,
+			errMsg:  "debug-evaluations-topic cannot be empty when evaluation sampling is enabled",
+		},
+		{
+			name: "negative publish log ttl",
+			config: &Config{
+				KafkaBrokers:        "localhost:9092",
+				AlertsNewTopic:      "alerts.new",
+				AlertsMatchedTopic:  "alerts.matched",
+				RuleChangedTopic:    "rule.changed",
+				ConsumerGroupID:     "evaluator-group",
+				RuleChangedGroupID:  "evaluator-rule-changed-group",
+				RedisAddr:           "localhost:6379",
+				VersionPollInterval: 5 * time.Second,
+				PublishLogTTL:       -time.Minute,
+			},
+			wantErr: true,
+			errMsg:  "publish-log-ttl cannot be negative",
+		},
+		{
+			name: "negative sample rate",
+			config: &Config{
+				KafkaBrokers:          "localhost:9092",
+				AlertsNewTopic:        "alerts.new",
+				AlertsMatchedTopic:    "alerts.matched",
+				RuleChangedTopic:      "rule.changed",
+				ConsumerGroupID:       "evaluator-group",
+				RuleChangedGroupID:    "evaluator-rule-changed-group",
+				RedisAddr:             "localhost:6379",
+				VersionPollInterval:   5 * time.Second,
+				DebugEvaluationsTopic: "debug.evaluations",
+				EvaluationSampleRate:  -1,
+			},
+			wantErr: true,
+			errMsg:  "evaluation-sample-rate cannot be negative",
+		},
+		{
+			name: "slow-rule disable",
+			config: &Config{
+				KafkaBrokers:         "localhost:9092",
+				AlertsNewTopic:       "alerts.new",
+				AlertsMatchedTopic:   "alerts.matched",
+				RuleChangedTopic:     "rule.changed",
+				ConsumerGroupID:      "evaluator-group",
+				RuleChangedGroupID:   "evaluator-rule-changed-group",
+				RedisAddr:            "localhost:6379",
+				VersionPollInterval:  5 * time.Second,
+				EvaluationDeadline:   50 * time.Millisecond,
+				SlowRuleDisableAfter: 10,
+				SlowRuleWindow:       10 * time.Minute,
+			},
+			wantErr: false,
+		},
+		{
+			name: "negative evaluation deadline",
+			config: &Config{
+				KafkaBrokers:        "localhost:9092",
+				AlertsNewTopic:      "alerts.new",
+				AlertsMatchedTopic:  "alerts.matched",
+				RuleChangedTopic:    "rule.changed",
+				ConsumerGroupID:     "evaluator-group",
+				RuleChangedGroupID:  "evaluator-rule-changed-group",
+				RedisAddr:           "localhost:6379",
+				VersionPollInterval: 5 * time.Second,
+				EvaluationDeadline:  -time.Millisecond,
+			},
+			wantErr: true,
+			errMsg:  "evaluation-deadline cannot be negative",
+		},
+		{
+			name: "slow-rule disable without deadline",
+			config: &Config{
+				KafkaBrokers:         "localhost:9092",
+				AlertsNewTopic:       "alerts.new",
+				AlertsMatchedTopic:   "alerts.matched",
+				RuleChangedTopic:     "rule.changed",
+				ConsumerGroupID:      "evaluator-group",
+				RuleChangedGroupID:   "evaluator-rule-changed-group",
+				RedisAddr:            "localhost:6379",
+				VersionPollInterval:  5 * time.Second,
+				SlowRuleDisableAfter: 10,
+			},
+			wantErr: true,
+			errMsg:  "slow-rule-disable-after requires an evaluation-deadline",
+		},
+		{
+			name: "negative warm-up alerts",
+			config: &Config{
+				KafkaBrokers:        "localhost:9092",
+				AlertsNewTopic:      "alerts.new",
+				AlertsMatchedTopic:  "alerts.matched",
+				RuleChangedTopic:    "rule.changed",
+				ConsumerGroupID:     "evaluator-group",
+				RuleChangedGroupID:  "evaluator-rule-changed-group",
+				RedisAddr:           "localhost:6379",
+				VersionPollInterval: 5 * time.Second,
+				WarmUpAlerts:        -1,
+			},
+			wantErr: true,
+			errMsg:  "warm-up-alerts cannot be negative",
+		},
+		{
+			name: "topic list separated by commas only",
+			config: &Config{
+				KafkaBrokers:        "localhost:9092",
+				AlertsNewTopic:      " , ",
+				AlertsMatchedTopic:  "alerts.matched",
+				RuleChangedTopic:    "rule.changed",
+				ConsumerGroupID:     "evaluator-group",
+				RuleChangedGroupID:  "evaluator-rule-changed-group",
+				RedisAddr:           "localhost:6379",
+				VersionPollInterval: 5 * time.Second,
+			},
+			wantErr: true,
+			errMsg:  "alerts-new-topic cannot be empty",
+		},
+		{
+			name: "topic pattern instead of topics",
+			config: &Config{
+				KafkaBrokers:          "localhost:9092",
+				AlertsNewTopicPattern: `^alerts\.new\.`,
+				AlertsMatchedTopic:    "alerts.matched",
+				RuleChangedTopic:      "rule.changed",
+				ConsumerGroupID:       "evaluator-group",
+				RuleChangedGroupID:    "evaluator-rule-changed-group",
+				RedisAddr:             "localhost:6379",
+				VersionPollInterval:   5 * time.Second,
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid topic pattern",
+			config: &Config{
+				KafkaBrokers:          "localhost:9092",
+				AlertsNewTopicPattern: "alerts.(",
+				AlertsMatchedTopic:    "alerts.matched",
+				RuleChangedTopic:      "rule.changed",
+				ConsumerGroupID:       "evaluator-group",
+				RuleChangedGroupID:    "evaluator-rule-changed-group",
+				RedisAddr:             "localhost:6379",
+				VersionPollInterval:   5 * time.Second,
+			},
+			wantErr: true,
+			errMsg:  "alerts-new-topic-pattern is not a valid regular expression: error parsing regexp: missing closing ): `alerts.(`",
+		},
 	}
 
 	for _, tt := range tests {
@@ -176,3 +539,11 @@ func TestConfig_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestConfig_AlertsNewTopics(t *testing.T) {
+	cfg := &Config{AlertsNewTopic: "alerts.new.eu, alerts.new.us,"}
+	got := cfg.AlertsNewTopics()
+	if len(got) != 2 || got[0] != "alerts.new.eu" || got[1] != "alerts.new.us" {
+		t.Errorf("AlertsNewTopics() = %v, want [alerts.new.eu alerts.new.us]", got)
+	}
+}
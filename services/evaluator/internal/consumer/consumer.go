@@ -5,6 +5,9 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"regexp"
+	"sort"
+	"strings"
 
 	kafkautil "github.com/afikmenashe/alerting-platform/pkg/kafka"
 	pbalerts "github.com/afikmenashe/alerting-platform/pkg/proto/alerts"
@@ -16,14 +19,25 @@ import (
 // Consumer wraps a Kafka reader and provides a simple interface for consuming alerts.
 type Consumer struct {
 	reader *kafka.Reader
-	topic  string
+	topics []string
 }
 
 // NewConsumer creates a new Kafka consumer with the specified brokers, topic, and group ID.
 // The consumer is configured for at-least-once delivery semantics.
 func NewConsumer(brokers string, topic string, groupID string) (*Consumer, error) {
-	if err := kafkautil.ValidateConsumerParams(brokers, topic, groupID); err != nil {
-		return nil, err
+	return NewMultiTopicConsumer(brokers, []string{topic}, groupID)
+}
+
+// NewMultiTopicConsumer creates a Kafka consumer that reads alerts from every topic in topics
+// as one consumer group, e.g. one topic per region. Each message keeps its topic in Topic.
+func NewMultiTopicConsumer(brokers string, topics []string, groupID string) (*Consumer, error) {
+	if len(topics) == 0 {
+		return nil, fmt.Errorf("topic cannot be empty")
+	}
+	for _, topic := range topics {
+		if err := kafkautil.ValidateConsumerParams(brokers, topic, groupID); err != nil {
+			return nil, err
+		}
 	}
 
 	// Parse comma-separated broker list
@@ -31,24 +45,78 @@ func NewConsumer(brokers string, topic string, groupID string) (*Consumer, error
 
 	slog.Info("Initializing Kafka consumer",
 		"brokers", brokerList,
-		"topic", topic,
+		"topics", topics,
 		"group_id", groupID,
 	)
 
 	// Configure Kafka reader for at-least-once delivery
 	// StartOffset only applies when no committed offset exists for the consumer group
 	// Using FirstOffset ensures we read all messages when starting fresh
-	reader := kafka.NewReader(kafkautil.NewReaderConfig(brokerList, topic, groupID))
+	readerConfig := kafkautil.NewReaderConfig(brokerList, topics[0], groupID)
+	if len(topics) > 1 {
+		readerConfig.Topic = ""
+		readerConfig.GroupTopics = topics
+	}
+	reader := kafka.NewReader(readerConfig)
 
 	// Log config from centralized source
 	kafkautil.LogReaderConfig()
 
 	return &Consumer{
 		reader: reader,
-		topic:  topic,
+		topics: topics,
 	}, nil
 }
 
+// ResolveTopics returns the topics on the brokers whose name matches pattern, sorted.
+// Kafka's internal topics (prefixed "__") and dead-letter topics are never matched, so a
+// pattern such as "^alerts\.new" does not consume the evaluator's own dead letters.
+func ResolveTopics(ctx context.Context, brokers string, pattern *regexp.Regexp) ([]string, error) {
+	brokerList := kafkautil.ParseBrokers(brokers)
+	if len(brokerList) == 0 {
+		return nil, fmt.Errorf("brokers cannot be empty")
+	}
+
+	conn, err := kafkautil.DialContext(ctx, brokerList[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Kafka: %w", err)
+	}
+	defer conn.Close()
+
+	partitions, err := conn.ReadPartitions()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list topics: %w", err)
+	}
+	names := make([]string, 0, len(partitions))
+	for _, p := range partitions {
+		names = append(names, p.Topic)
+	}
+	return matchTopics(names, pattern), nil
+}
+
+// matchTopics returns the distinct topics in names that match pattern, sorted, skipping
+// internal and dead-letter topics.
+func matchTopics(names []string, pattern *regexp.Regexp) []string {
+	seen := make(map[string]bool)
+	var topics []string
+	for _, name := range names {
+		if seen[name] || strings.HasPrefix(name, "__") || strings.HasSuffix(name, kafkautil.DLQSuffix) {
+			continue
+		}
+		seen[name] = true
+		if pattern.MatchString(name) {
+			topics = append(topics, name)
+		}
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// Topics returns the topics the consumer reads.
+func (c *Consumer) Topics() []string {
+	return c.topics
+}
+
 // ReadMessage reads the next message from Kafka and deserializes it as an AlertNew.
 // Returns an error if reading or deserialization fails.
 func (c *Consumer) ReadMessage(ctx context.Context) (*events.AlertNew, *kafka.Message, error) {
@@ -70,6 +138,7 @@ func (c *Consumer) ReadMessage(ctx context.Context) (*events.AlertNew, *kafka.Me
 		Source:        pb.Source,
 		Name:          pb.Name,
 		Context:       pb.Context,
+		ClientHint:    pb.ClientHint,
 	}
 
 	return alert, &msg, nil
@@ -83,7 +152,7 @@ func (c *Consumer) CommitMessage(ctx context.Context, msg *kafka.Message) error
 
 // Close gracefully closes the Kafka reader and releases resources.
 func (c *Consumer) Close() error {
-	slog.Info("Closing Kafka consumer", "topic", c.topic)
+	slog.Info("Closing Kafka consumer", "topics", c.topics)
 	if err := c.reader.Close(); err != nil {
 		slog.Error("Error closing Kafka consumer", "error", err)
 		return err
@@ -2,6 +2,7 @@ package consumer
 
 import (
 	"context"
+	"regexp"
 	"testing"
 )
 
@@ -125,3 +126,36 @@ func TestConsumer_ReadMessage_InvalidJSON(t *testing.T) {
 // For full coverage of ReadMessage, you would need:
 // 1. Interface-based refactoring with mocks, OR
 // 2. Integration tests with testcontainers or real Kafka instance
+
+func TestNewMultiTopicConsumer(t *testing.T) {
+	if _, err := NewMultiTopicConsumer("localhost:9092", nil, "test-group"); err == nil {
+		t.Error("NewMultiTopicConsumer() expected error without topics")
+	}
+	if _, err := NewMultiTopicConsumer("localhost:9092", []string{"alerts.new.eu", ""}, "test-group"); err == nil {
+		t.Error("NewMultiTopicConsumer() expected error for an empty topic")
+	}
+
+	topics := []string{"alerts.new.eu", "alerts.new.us"}
+	consumer, err := NewMultiTopicConsumer("localhost:9092", topics, "test-group")
+	if err != nil {
+		t.Fatalf("NewMultiTopicConsumer() error = %v", err)
+	}
+	defer consumer.Close()
+	if got := consumer.Topics(); len(got) != 2 || got[0] != topics[0] || got[1] != topics[1] {
+		t.Errorf("Topics() = %v, want %v", got, topics)
+	}
+	if config := consumer.reader.Config(); config.Topic != "" || len(config.GroupTopics) != 2 {
+		t.Errorf("reader topic = %q, group topics = %v, want both topics in one group", config.Topic, config.GroupTopics)
+	}
+}
+
+func TestMatchTopics(t *testing.T) {
+	names := []string{
+		"alerts.new.us", "alerts.new.eu", "alerts.new.eu", // one entry per partition
+		"alerts.new.dlq", "alerts.matched", "__consumer_offsets",
+	}
+	got := matchTopics(names, regexp.MustCompile(`^alerts\.new\.`))
+	if len(got) != 2 || got[0] != "alerts.new.eu" || got[1] != "alerts.new.us" {
+		t.Errorf("matchTopics() = %v, want [alerts.new.eu alerts.new.us]", got)
+	}
+}
@@ -1,6 +1,28 @@
 // Package events defines the event structures for alerts.new and alerts.matched topics.
 package events
 
+import "sort"
+
+// Fan-out policies for alerts.matched.
+const (
+	// FanOutPerClient publishes one message per matching client, keyed by client_id.
+	// All of a client's matches land on one partition, in order.
+	FanOutPerClient = "per-client"
+	// FanOutCombined publishes one message per alert listing every matching client, keyed by alert_id.
+	// The alert's matches are written atomically, but one client's matches spread across partitions.
+	FanOutCombined = "combined"
+)
+
+// Policies for alerts that match no rule. Every unmatched alert is counted in alerts_unmatched.
+const (
+	// UnmatchedDrop discards unmatched alerts.
+	UnmatchedDrop = "drop"
+	// UnmatchedPublish publishes unmatched alerts to the alerts.unmatched topic.
+	UnmatchedPublish = "publish"
+	// UnmatchedCount counts unmatched alerts per severity and source in metrics.
+	UnmatchedCount = "count"
+)
+
 // AlertNew represents an alert event from the alerts.new topic.
 type AlertNew struct {
 	AlertID       string            `json:"alert_id"`
@@ -10,10 +32,14 @@ type AlertNew struct {
 	Source        string            `json:"source"`
 	Name          string            `json:"name"`
 	Context       map[string]string `json:"context,omitempty"`
+	// ClientHint restricts matching to one client's rules, for sources shared by several
+	// clients. Empty matches every client's rules.
+	ClientHint string `json:"client_hint,omitempty"`
 }
 
 // AlertMatched represents a matched alert event to be published to alerts.matched topic.
-// One message per client_id, containing the alert and the rule_ids that matched for that client.
+// With per-client fan-out, ClientID and RuleIDs hold the matches of one client;
+// with combined fan-out, Matches holds the matches of every client and ClientID is empty.
 type AlertMatched struct {
 	AlertID       string            `json:"alert_id"`
 	SchemaVersion int               `json:"schema_version"`
@@ -22,8 +48,18 @@ type AlertMatched struct {
 	Source        string            `json:"source"`
 	Name          string            `json:"name"`
 	Context       map[string]string `json:"context,omitempty"`
-	ClientID      string            `json:"client_id"` // The client this message is for
-	RuleIDs       []string          `json:"rule_ids"` // All rule IDs that matched for this client
+	ClientID      string            `json:"client_id"`         // The client this message is for
+	RuleIDs       []string          `json:"rule_ids"`          // All rule IDs that matched for this client
+	Matches       []ClientMatch     `json:"matches,omitempty"` // Every matching client (combined fan-out)
+	// EvaluatedAtMs is when the evaluator matched the alert, in Unix milliseconds. Later stages
+	// stamp their own times so latency can be attributed to the stage that added it.
+	EvaluatedAtMs int64 `json:"evaluated_at_ms,omitempty"`
+}
+
+// ClientMatch is the set of rules that matched an alert for one client.
+type ClientMatch struct {
+	ClientID string   `json:"client_id"`
+	RuleIDs  []string `json:"rule_ids"`
 }
 
 // NewAlertMatched creates a new AlertMatched event from an AlertNew event for a specific client.
@@ -41,12 +77,105 @@ func NewAlertMatched(alert *AlertNew, clientID string, ruleIDs []string) *AlertM
 	}
 }
 
+// NewCombinedAlertMatched creates a single AlertMatched event from an AlertNew event
+// carrying the matches of every client, ordered by client_id.
+func NewCombinedAlertMatched(alert *AlertNew, matches map[string][]string) *AlertMatched {
+	clientIDs := make([]string, 0, len(matches))
+	for clientID := range matches {
+		clientIDs = append(clientIDs, clientID)
+	}
+	sort.Strings(clientIDs)
+
+	matched := NewAlertMatched(alert, "", nil)
+	matched.Matches = make([]ClientMatch, 0, len(clientIDs))
+	for _, clientID := range clientIDs {
+		matched.Matches = append(matched.Matches, ClientMatch{ClientID: clientID, RuleIDs: matches[clientID]})
+	}
+	return matched
+}
+
+// PartitionKey returns the Kafka key of the event: client_id for per-client events,
+// alert_id for combined events.
+func (m *AlertMatched) PartitionKey() string {
+	if len(m.Matches) > 0 {
+		return m.AlertID
+	}
+	return m.ClientID
+}
+
 // RuleChanged represents a rule change event from the rule.changed topic.
 type RuleChanged struct {
 	RuleID        string `json:"rule_id"`
 	ClientID      string `json:"client_id"`
-	Action        string `json:"action"` // CREATED, UPDATED, DELETED, DISABLED
+	Action        string `json:"action"` // CREATED, UPDATED, DELETED, DISABLED, REBUILD
 	Version       int    `json:"version"`
 	UpdatedAt     int64  `json:"updated_at"` // Unix timestamp
 	SchemaVersion int    `json:"schema_version"`
+	// Rule carries the rule's matching fields after the change; nil for DELETED
+	// and for events from producers that predate the payload.
+	Rule *RulePayload `json:"rule,omitempty"`
+	// PublishedAtMs is when rule-service published the event (Unix milliseconds); 0 if unknown.
+	PublishedAtMs int64 `json:"published_at_ms,omitempty"`
+}
+
+// RulePayload is the rule state embedded in a rule.changed event.
+type RulePayload struct {
+	Severity       string   `json:"severity"`
+	Source         string   `json:"source"`
+	Name           string   `json:"name"`
+	Enabled        bool     `json:"enabled"`
+	ExcludeSources []string `json:"exclude_sources,omitempty"`
+	ExcludeNames   []string `json:"exclude_names,omitempty"`
+	// Conditions on the alert context, all of which must hold for the rule to match.
+	Conditions []ContextCondition `json:"conditions,omitempty"`
+}
+
+// ContextCondition matches one key of an alert's context with == or !=.
+type ContextCondition struct {
+	Key   string `json:"key"`
+	Op    string `json:"op"`
+	Value string `json:"value"`
+}
+
+// EvaluationSample is a sampled evaluation result published to debug.evaluations for offline
+// QA of the matcher. Unlike alerts.matched, it is published for non-matches too, with the
+// candidate sets the matcher considered.
+type EvaluationSample struct {
+	AlertID    string              `json:"alert_id"`
+	EventTS    int64               `json:"event_ts"`
+	Severity   string              `json:"severity"`
+	Source     string              `json:"source"`
+	Name       string              `json:"name"`
+	Context    map[string]string   `json:"context,omitempty"`
+	ClientHint string              `json:"client_hint,omitempty"`
+	Matched    bool                `json:"matched"`
+	Matches    map[string][]string `json:"matches"`    // client_id -> rule_ids
+	Candidates SampleCandidates    `json:"candidates"` // rule_ids considered, by index
+	RuleCount  int                 `json:"rule_count"` // Rules loaded when the alert was evaluated
+	SampleRate int                 `json:"sample_rate"`
+	SampledAt  int64               `json:"sampled_at"` // Unix milliseconds
+}
+
+// UnmatchedAlert is an alert that matched no rule, published to alerts.unmatched with the
+// UnmatchedPublish policy, to detect misconfigured producers and missing rules.
+type UnmatchedAlert struct {
+	AlertID     string            `json:"alert_id"`
+	EventTS     int64             `json:"event_ts"`
+	Severity    string            `json:"severity"`
+	Source      string            `json:"source"`
+	Name        string            `json:"name"`
+	Context     map[string]string `json:"context,omitempty"`
+	ClientHint  string            `json:"client_hint,omitempty"`
+	RuleCount   int               `json:"rule_count"`   // Rules loaded when the alert was evaluated
+	EvaluatedAt int64             `json:"evaluated_at"` // Unix milliseconds
+}
+
+// SampleCandidates holds the candidate rules of a sampled evaluation, by rule_id.
+type SampleCandidates struct {
+	Severity []string `json:"severity"`
+	Source   []string `json:"source"`
+	Name     []string `json:"name"`
+	// Intersected holds the rules in all three sets, before exclusions, context conditions,
+	// and the client hint were applied.
+	Intersected []string `json:"intersected"`
 }
@@ -270,3 +270,36 @@ func TestRuleChanged_JSON(t *testing.T) {
 		})
 	}
 }
+
+func TestNewCombinedAlertMatched(t *testing.T) {
+	alert := &AlertNew{AlertID: "alert-1", SchemaVersion: 1, EventTS: 1234567890, Severity: "HIGH", Source: "api", Name: "timeout"}
+	matched := NewCombinedAlertMatched(alert, map[string][]string{
+		"client-b": {"rule-2"},
+		"client-a": {"rule-1", "rule-3"},
+	})
+
+	if matched.ClientID != "" || matched.RuleIDs != nil {
+		t.Errorf("combined event ClientID = %q, RuleIDs = %v, want both unset", matched.ClientID, matched.RuleIDs)
+	}
+	if len(matched.Matches) != 2 || matched.Matches[0].ClientID != "client-a" || matched.Matches[1].ClientID != "client-b" {
+		t.Fatalf("Matches = %+v, want client-a then client-b", matched.Matches)
+	}
+	if len(matched.Matches[0].RuleIDs) != 2 {
+		t.Errorf("client-a RuleIDs = %v, want 2 rules", matched.Matches[0].RuleIDs)
+	}
+	if matched.AlertID != "alert-1" || matched.Severity != "HIGH" {
+		t.Errorf("combined event did not copy the alert: %+v", matched)
+	}
+}
+
+func TestAlertMatched_PartitionKey(t *testing.T) {
+	alert := &AlertNew{AlertID: "alert-1"}
+
+	if got := NewAlertMatched(alert, "client-1", []string{"rule-1"}).PartitionKey(); got != "client-1" {
+		t.Errorf("per-client PartitionKey() = %q, want client-1", got)
+	}
+	combined := NewCombinedAlertMatched(alert, map[string][]string{"client-1": {"rule-1"}})
+	if got := combined.PartitionKey(); got != "alert-1" {
+		t.Errorf("combined PartitionKey() = %q, want alert-1", got)
+	}
+}
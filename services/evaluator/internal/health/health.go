@@ -0,0 +1,88 @@
+// Package health serves the evaluator's liveness and readiness endpoints.
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+)
+
+// Readiness states reported by /readyz.
+const (
+	StatusStarting = "starting" // the snapshot is loading or the matcher is warming up
+	StatusReady    = "ready"
+	StatusFailed   = "failed" // startup failed; the process is about to exit
+)
+
+// ReadinessResponse is returned by /readyz.
+type ReadinessResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Handler serves /health, which is always OK while the process runs, and /readyz, which is
+// OK only once the initial snapshot is loaded and the matcher has warmed up.
+type Handler struct {
+	mu        sync.RWMutex
+	readiness ReadinessResponse
+	mux       *http.ServeMux
+}
+
+// NewHandler returns a handler that reports starting until SetReady is called.
+func NewHandler() *Handler {
+	h := &Handler{readiness: ReadinessResponse{Status: StatusStarting}, mux: http.NewServeMux()}
+	h.mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK"))
+	})
+	h.mux.HandleFunc("/readyz", h.readyz)
+	return h
+}
+
+// SetReady marks the evaluator ready.
+func (h *Handler) SetReady() {
+	h.set(ReadinessResponse{Status: StatusReady})
+}
+
+// SetFailed marks startup failed with err.
+func (h *Handler) SetFailed(err error) {
+	h.set(ReadinessResponse{Status: StatusFailed, Error: err.Error()})
+}
+
+// Ready reports whether the evaluator is ready.
+func (h *Handler) Ready() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.readiness.Status == StatusReady
+}
+
+func (h *Handler) set(readiness ReadinessResponse) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.readiness = readiness
+}
+
+// ServeHTTP serves the health endpoints.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// readyz returns 200 once the evaluator is ready and 503 before.
+// GET /readyz
+func (h *Handler) readyz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	h.mu.RLock()
+	readiness := h.readiness
+	h.mu.RUnlock()
+
+	status := http.StatusOK
+	if readiness.Status != StatusReady {
+		status = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(readiness)
+}
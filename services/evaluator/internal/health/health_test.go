@@ -0,0 +1,50 @@
+package health
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func readyz(t *testing.T, h *Handler) (int, ReadinessResponse) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var resp ReadinessResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	return w.Code, resp
+}
+
+func TestHandler(t *testing.T) {
+	h := NewHandler()
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("/health status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	if code, resp := readyz(t, h); code != http.StatusServiceUnavailable || resp.Status != StatusStarting {
+		t.Errorf("/readyz before ready = %d %+v, want 503 starting", code, resp)
+	}
+
+	h.SetReady()
+	if code, resp := readyz(t, h); code != http.StatusOK || resp.Status != StatusReady || !h.Ready() {
+		t.Errorf("/readyz when ready = %d %+v, want 200 ready", code, resp)
+	}
+
+	h.SetFailed(errors.New("warm-up failed"))
+	if code, resp := readyz(t, h); code != http.StatusServiceUnavailable || resp.Status != StatusFailed || resp.Error != "warm-up failed" {
+		t.Errorf("/readyz after failure = %d %+v, want 503 failed", code, resp)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/readyz", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST /readyz status = %d, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+}
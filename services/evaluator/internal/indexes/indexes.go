@@ -2,20 +2,48 @@
 package indexes
 
 import (
+	"log/slog"
+	"sort"
+	"time"
+
 	"evaluator/internal/snapshot"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
 )
 
 // Indexes holds the in-memory rule indexes for fast matching.
-// These are built from a snapshot and can be atomically swapped.
+// These are built from a snapshot and can be atomically swapped, or changed one rule
+// at a time with PutRule and RemoveRule. Indexes are not safe for concurrent mutation;
+// the matcher serializes access.
 type Indexes struct {
 	bySeverity map[string][]int // severity -> []ruleInt
 	bySource   map[string][]int // source -> []ruleInt
 	byName     map[string][]int // name -> []ruleInt
-	rules      map[int]snapshot.RuleInfo // ruleInt -> {rule_id, client_id}
+	rules      map[int]snapshot.RuleInfo // ruleInt -> {rule_id, client_id, exclusions, conditions}
+
+	ruleInts    map[string]int     // rule_id -> ruleInt
+	ruleKeys    map[int]ruleKeyset // ruleInt -> index keys the rule is stored under
+	clientRules map[string]int     // client_id -> number of rules
+	nextRuleInt int
+
+	// normalization is applied to alert fields and to rules put in place, matching the
+	// normalization rule-updater applied to the snapshot's rules.
+	normalization shared.Normalization
+}
+
+// ruleKeyset holds the severity, source, and name keys of one rule.
+type ruleKeyset struct {
+	severity, source, name string
 }
 
 // NewIndexes creates new indexes from a snapshot.
+// An unknown snapshot normalization falls back to exact matching.
 func NewIndexes(snap *snapshot.Snapshot) *Indexes {
+	normalization, err := shared.ParseNormalization(snap.Normalization)
+	if err != nil {
+		slog.Error("Unknown snapshot normalization, matching exactly", "normalization", snap.Normalization, "error", err)
+	}
+
 	// Deep copy the maps to ensure we own the data
 	bySeverity := make(map[string][]int)
 	for k, v := range snap.BySeverity {
@@ -36,36 +64,168 @@ func NewIndexes(snap *snapshot.Snapshot) *Indexes {
 	}
 
 	rules := make(map[int]snapshot.RuleInfo)
+	ruleInts := make(map[string]int, len(snap.Rules))
+	clientRules := make(map[string]int)
+	nextRuleInt := 1
 	for k, v := range snap.Rules {
 		rules[k] = v
+		ruleInts[v.RuleID] = k
+		clientRules[v.ClientID]++
+		if k >= nextRuleInt {
+			nextRuleInt = k + 1
+		}
+	}
+
+	// Reverse the inverted indexes so single-rule removal doesn't scan every key
+	ruleKeys := make(map[int]ruleKeyset, len(snap.Rules))
+	for key, ruleIntList := range bySeverity {
+		for _, ruleInt := range ruleIntList {
+			keys := ruleKeys[ruleInt]
+			keys.severity = key
+			ruleKeys[ruleInt] = keys
+		}
+	}
+	for key, ruleIntList := range bySource {
+		for _, ruleInt := range ruleIntList {
+			keys := ruleKeys[ruleInt]
+			keys.source = key
+			ruleKeys[ruleInt] = keys
+		}
+	}
+	for key, ruleIntList := range byName {
+		for _, ruleInt := range ruleIntList {
+			keys := ruleKeys[ruleInt]
+			keys.name = key
+			ruleKeys[ruleInt] = keys
+		}
 	}
 
 	return &Indexes{
-		bySeverity: bySeverity,
-		bySource:   bySource,
-		byName:     byName,
-		rules:      rules,
+		bySeverity:  bySeverity,
+		bySource:    bySource,
+		byName:      byName,
+		rules:       rules,
+		ruleInts:    ruleInts,
+		ruleKeys:    ruleKeys,
+		clientRules: clientRules,
+		nextRuleInt: nextRuleInt,
+
+		normalization: normalization,
+	}
+}
+
+// PutRule adds a rule to the indexes, replacing its previous fields if it is already present.
+// excludeSources and excludeNames are the alert sources and names the rule does not match,
+// and conditions are the alert context conditions it requires.
+// The fields and condition values are normalized like the snapshot's rules.
+func (idx *Indexes) PutRule(ruleID, clientID, severity, source, name string, excludeSources, excludeNames []string, conditions []snapshot.ContextCondition) {
+	idx.RemoveRule(ruleID)
+	severity, source, name = idx.normalization.Apply(severity), idx.normalization.Apply(source), idx.normalization.Apply(name)
+
+	ruleInt := idx.nextRuleInt
+	idx.nextRuleInt++
+
+	idx.bySeverity[severity] = append(idx.bySeverity[severity], ruleInt)
+	idx.bySource[source] = append(idx.bySource[source], ruleInt)
+	idx.byName[name] = append(idx.byName[name], ruleInt)
+	idx.rules[ruleInt] = snapshot.RuleInfo{
+		RuleID:         ruleID,
+		ClientID:       clientID,
+		ExcludeSources: idx.normalizeAll(excludeSources),
+		ExcludeNames:   idx.normalizeAll(excludeNames),
+		Conditions:     idx.normalizeConditions(conditions),
+	}
+	idx.ruleInts[ruleID] = ruleInt
+	idx.ruleKeys[ruleInt] = ruleKeyset{severity: severity, source: source, name: name}
+	idx.clientRules[clientID]++
+}
+
+// normalizeAll returns a normalized copy of values.
+func (idx *Indexes) normalizeAll(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	normalized := make([]string, len(values))
+	for i, v := range values {
+		normalized[i] = idx.normalization.Apply(v)
 	}
+	return normalized
+}
+
+// normalizeConditions returns a copy of conditions with normalized values. Keys are matched exactly.
+func (idx *Indexes) normalizeConditions(conditions []snapshot.ContextCondition) []snapshot.ContextCondition {
+	if len(conditions) == 0 {
+		return nil
+	}
+	normalized := make([]snapshot.ContextCondition, len(conditions))
+	for i, c := range conditions {
+		c.Value = idx.normalization.Apply(c.Value)
+		normalized[i] = c
+	}
+	return normalized
+}
+
+// RemoveRule removes a rule from the indexes.
+// Returns false if the rule was not present.
+func (idx *Indexes) RemoveRule(ruleID string) bool {
+	ruleInt, exists := idx.ruleInts[ruleID]
+	if !exists {
+		return false
+	}
+
+	keys := idx.ruleKeys[ruleInt]
+	removeFromIndex(idx.bySeverity, keys.severity, ruleInt)
+	removeFromIndex(idx.bySource, keys.source, ruleInt)
+	removeFromIndex(idx.byName, keys.name, ruleInt)
+	if clientID := idx.rules[ruleInt].ClientID; idx.clientRules[clientID] <= 1 {
+		delete(idx.clientRules, clientID)
+	} else {
+		idx.clientRules[clientID]--
+	}
+	delete(idx.rules, ruleInt)
+	delete(idx.ruleInts, ruleID)
+	delete(idx.ruleKeys, ruleInt)
+	return true
+}
+
+// removeFromIndex removes ruleInt from the list stored under key, dropping the key once empty.
+func removeFromIndex(index map[string][]int, key string, ruleInt int) {
+	list := index[key]
+	for i, v := range list {
+		if v == ruleInt {
+			list = append(list[:i], list[i+1:]...)
+			break
+		}
+	}
+	if len(list) == 0 {
+		delete(index, key)
+		return
+	}
+	index[key] = list
 }
 
 // Match finds all rules that match the given alert fields using intersection.
 // Supports wildcard "*" values which match any value for that field.
+// Rules with context conditions only match if the alert context satisfies all of them.
 // Returns a map of client_id -> []rule_id for all matching rules.
-func (idx *Indexes) Match(severity, source, name string) map[string][]string {
-	// Get candidate lists for each field (exact matches)
-	severityRules := idx.bySeverity[severity]
-	sourceRules := idx.bySource[source]
-	nameRules := idx.byName[name]
-
-	// Also get wildcard matches ("*" matches any value)
-	wildcardSeverityRules := idx.bySeverity["*"]
-	wildcardSourceRules := idx.bySource["*"]
-	wildcardNameRules := idx.byName["*"]
-
-	// Combine exact matches with wildcard matches
-	allSeverityRules := combineLists(severityRules, wildcardSeverityRules)
-	allSourceRules := combineLists(sourceRules, wildcardSourceRules)
-	allNameRules := combineLists(nameRules, wildcardNameRules)
+func (idx *Indexes) Match(severity, source, name string, context map[string]string) map[string][]string {
+	return idx.match(severity, source, name, context, "")
+}
+
+// MatchClient is Match restricted to the rules of one client.
+func (idx *Indexes) MatchClient(clientID, severity, source, name string, context map[string]string) map[string][]string {
+	return idx.match(severity, source, name, context, clientID)
+}
+
+// HasClient reports whether the client has any rule in the indexes.
+func (idx *Indexes) HasClient(clientID string) bool {
+	return idx.clientRules[clientID] > 0
+}
+
+// match finds the rules matching the alert fields and context, only for clientID unless it is empty.
+func (idx *Indexes) match(severity, source, name string, context map[string]string, clientID string) map[string][]string {
+	severity, source, name = idx.normalization.Apply(severity), idx.normalization.Apply(source), idx.normalization.Apply(name)
+	allSeverityRules, allSourceRules, allNameRules := idx.candidateLists(severity, source, name)
 
 	// Find the smallest list to start intersection (minimizes work)
 	var candidates []int
@@ -113,12 +273,211 @@ func (idx *Indexes) Match(severity, source, name string) map[string][]string {
 		if !exists {
 			continue // Skip invalid ruleInt
 		}
+		if clientID != "" && ruleInfo.ClientID != clientID {
+			continue
+		}
+		if !idx.ruleHolds(ruleInfo, source, name, context) {
+			continue
+		}
 		result[ruleInfo.ClientID] = append(result[ruleInfo.ClientID], ruleInfo.RuleID)
 	}
 
 	return result
 }
 
+// ruleHolds reports whether a candidate rule matches the normalized alert source and name and
+// the alert context.
+func (idx *Indexes) ruleHolds(ruleInfo snapshot.RuleInfo, source, name string, context map[string]string) bool {
+	// Exclusions are not indexed; drop rules whose wildcards exclude this alert
+	if contains(ruleInfo.ExcludeSources, source) || contains(ruleInfo.ExcludeNames, name) {
+		return false
+	}
+	// Neither are context conditions
+	return idx.conditionsHold(ruleInfo.Conditions, context)
+}
+
+// candidateLists returns the rules whose severity, source, and name keys fit the normalized
+// alert fields, exactly or by wildcard ("*" matches any value).
+func (idx *Indexes) candidateLists(severity, source, name string) (severityRules, sourceRules, nameRules []int) {
+	return combineLists(idx.bySeverity[severity], idx.bySeverity["*"]),
+		combineLists(idx.bySource[source], idx.bySource["*"]),
+		combineLists(idx.byName[name], idx.byName["*"])
+}
+
+// Evaluation explains how an alert was matched: the candidate sets considered and the result.
+// Candidate rules are listed by rule_id and sorted.
+type Evaluation struct {
+	SeverityCandidates []string
+	SourceCandidates   []string
+	NameCandidates     []string
+	// Intersected holds the rules in all three candidate sets, before exclusions, context
+	// conditions, and the client filter were applied.
+	Intersected []string
+	// Matches holds the matching rules by client_id, as returned by Match.
+	Matches map[string][]string
+}
+
+// Explain matches an alert like Match (or MatchClient, if clientID is set) and also returns
+// the candidate sets considered. It is slower than Match and meant for sampled alerts.
+func (idx *Indexes) Explain(clientID, severity, source, name string, context map[string]string) Evaluation {
+	severityRules, sourceRules, nameRules := idx.candidateLists(
+		idx.normalization.Apply(severity), idx.normalization.Apply(source), idx.normalization.Apply(name))
+
+	return Evaluation{
+		SeverityCandidates: idx.ruleIDs(severityRules),
+		SourceCandidates:   idx.ruleIDs(sourceRules),
+		NameCandidates:     idx.ruleIDs(nameRules),
+		Intersected:        idx.ruleIDs(intersect(severityRules, sourceRules, nameRules)),
+		Matches:            idx.match(severity, source, name, context, clientID),
+	}
+}
+
+// RuleCost is the time one candidate rule took to check against an alert.
+type RuleCost struct {
+	RuleID   string
+	ClientID string
+	Duration time.Duration
+}
+
+// Profile checks an alert against each rule in all three candidate sets (only the rules of
+// clientID, if set) and times every rule's exclusions and context conditions. Costs are
+// sorted slowest first. Like Explain it is slower than Match; it is meant for alerts whose
+// matching was already found to be slow.
+func (idx *Indexes) Profile(clientID, severity, source, name string, context map[string]string) []RuleCost {
+	severity, source, name = idx.normalization.Apply(severity), idx.normalization.Apply(source), idx.normalization.Apply(name)
+	severityRules, sourceRules, nameRules := idx.candidateLists(severity, source, name)
+
+	var costs []RuleCost
+	for _, ruleInt := range intersect(severityRules, sourceRules, nameRules) {
+		ruleInfo, exists := idx.rules[ruleInt]
+		if !exists || (clientID != "" && ruleInfo.ClientID != clientID) {
+			continue
+		}
+		start := time.Now()
+		idx.ruleHolds(ruleInfo, source, name, context)
+		costs = append(costs, RuleCost{RuleID: ruleInfo.RuleID, ClientID: ruleInfo.ClientID, Duration: time.Since(start)})
+	}
+	sort.SliceStable(costs, func(i, j int) bool { return costs[i].Duration > costs[j].Duration })
+	return costs
+}
+
+// Probe is a synthetic alert built from one rule's index keys, for warming up the matcher.
+type Probe struct {
+	RuleID   string
+	ClientID string
+	Severity string
+	Source   string
+	Name     string
+	Context  map[string]string
+	// Expected is true if the rule's exclusions and context conditions accept the probe, so
+	// the rule must match it; probes of other rules may match it as well.
+	Expected bool
+}
+
+// probeWildcardValue stands in for fields a rule matches with "*".
+const probeWildcardValue = "warm-up-probe"
+
+// Probes returns up to limit synthetic alerts, built from rules spread evenly over the
+// indexes. A probe carries the severity, source, and name keys its rule is indexed under,
+// so a rule missing from one of the inverted indexes (a truncated or corrupt snapshot)
+// does not match its own probe. Context conditions are satisfied where they can be.
+func (idx *Indexes) Probes(limit int) []Probe {
+	if limit <= 0 || len(idx.rules) == 0 {
+		return nil
+	}
+	ruleInts := make([]int, 0, len(idx.rules))
+	for ruleInt := range idx.rules {
+		ruleInts = append(ruleInts, ruleInt)
+	}
+	sort.Ints(ruleInts)
+	step := 1
+	if len(ruleInts) > limit {
+		step = len(ruleInts) / limit
+	}
+
+	probes := make([]Probe, 0, min(limit, len(ruleInts)))
+	for i := 0; i < len(ruleInts) && len(probes) < limit; i += step {
+		ruleInfo := idx.rules[ruleInts[i]]
+		keys := idx.ruleKeys[ruleInts[i]]
+		probe := Probe{
+			RuleID:   ruleInfo.RuleID,
+			ClientID: ruleInfo.ClientID,
+			Severity: probeValue(keys.severity),
+			Source:   probeValue(keys.source),
+			Name:     probeValue(keys.name),
+			Context:  make(map[string]string),
+		}
+		for _, c := range ruleInfo.Conditions {
+			if c.Op == shared.ConditionOpEqual {
+				probe.Context[c.Key] = c.Value
+			}
+		}
+		probe.Expected = idx.ruleHolds(ruleInfo, probe.Source, probe.Name, probe.Context)
+		probes = append(probes, probe)
+	}
+	return probes
+}
+
+// probeValue returns the alert field value that matches an index key.
+func probeValue(key string) string {
+	if key == "*" {
+		return probeWildcardValue
+	}
+	return key
+}
+
+// intersect returns the rules of severityRules that are also in sourceRules and nameRules.
+func intersect(severityRules, sourceRules, nameRules []int) []int {
+	inSource := make(map[int]bool, len(sourceRules))
+	for _, ruleInt := range sourceRules {
+		inSource[ruleInt] = true
+	}
+	inName := make(map[int]bool, len(nameRules))
+	for _, ruleInt := range nameRules {
+		inName[ruleInt] = true
+	}
+	var intersected []int
+	for _, ruleInt := range severityRules {
+		if inSource[ruleInt] && inName[ruleInt] {
+			intersected = append(intersected, ruleInt)
+		}
+	}
+	return intersected
+}
+
+// ruleIDs returns the sorted rule_ids of ruleInts.
+func (idx *Indexes) ruleIDs(ruleInts []int) []string {
+	ids := make([]string, 0, len(ruleInts))
+	for _, ruleInt := range ruleInts {
+		if ruleInfo, exists := idx.rules[ruleInt]; exists {
+			ids = append(ids, ruleInfo.RuleID)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// conditionsHold reports whether context satisfies every condition. Condition values are
+// stored normalized, so context values are normalized before they are compared.
+func (idx *Indexes) conditionsHold(conditions []snapshot.ContextCondition, context map[string]string) bool {
+	for _, c := range conditions {
+		if !shared.ContextConditionHolds(idx.normalization, c.Key, c.Op, c.Value, context) {
+			return false
+		}
+	}
+	return true
+}
+
+// contains reports whether values contains v.
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
 // combineLists combines two lists, removing duplicates.
 func combineLists(list1, list2 []int) []int {
 	if len(list2) == 0 {
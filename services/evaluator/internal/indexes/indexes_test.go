@@ -3,7 +3,10 @@ package indexes
 import (
 	"evaluator/internal/snapshot"
 	"reflect"
+	"sort"
 	"testing"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
 )
 
 func TestNewIndexes(t *testing.T) {
@@ -150,7 +153,7 @@ func TestIndexes_Match(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := idx.Match(tt.severity, tt.source, tt.nameField)
+			result := idx.Match(tt.severity, tt.source, tt.nameField, nil)
 
 			// Check client IDs
 			gotClientIDs := make([]string, 0, len(result))
@@ -216,7 +219,7 @@ func TestIndexes_Match_Intersection(t *testing.T) {
 	idx := NewIndexes(snap)
 
 	// Test: HIGH + service-a + disk-full should match only rule-1
-	result := idx.Match("HIGH", "service-a", "disk-full")
+	result := idx.Match("HIGH", "service-a", "disk-full", nil)
 	if len(result) != 1 {
 		t.Fatalf("Match() returned %d clients, want 1", len(result))
 	}
@@ -229,7 +232,7 @@ func TestIndexes_Match_Intersection(t *testing.T) {
 	}
 
 	// Test: HIGH + service-a + cpu-high should match rule-2
-	result = idx.Match("HIGH", "service-a", "cpu-high")
+	result = idx.Match("HIGH", "service-a", "cpu-high", nil)
 	if len(result) != 1 {
 		t.Fatalf("Match() returned %d clients, want 1", len(result))
 	}
@@ -242,7 +245,7 @@ func TestIndexes_Match_Intersection(t *testing.T) {
 	}
 
 	// Test: HIGH + service-b + cpu-high should match rule-3
-	result = idx.Match("HIGH", "service-b", "cpu-high")
+	result = idx.Match("HIGH", "service-b", "cpu-high", nil)
 	if len(result) != 1 {
 		t.Fatalf("Match() returned %d clients, want 1", len(result))
 	}
@@ -369,7 +372,7 @@ func TestIndexes_Match_InvalidRuleInt(t *testing.T) {
 	}
 
 	idx := NewIndexes(snap)
-	result := idx.Match("HIGH", "service-a", "disk-full")
+	result := idx.Match("HIGH", "service-a", "disk-full", nil)
 
 	// Should only return rule-1, not crash on 999
 	if len(result) != 1 {
@@ -383,3 +386,280 @@ func TestIndexes_Match_InvalidRuleInt(t *testing.T) {
 		t.Errorf("Match() rules = %v, want [rule-1]", rules)
 	}
 }
+
+func TestIndexes_PutAndRemoveRule(t *testing.T) {
+	snap := &snapshot.Snapshot{
+		BySeverity: map[string][]int{"HIGH": {1}, "LOW": {2}},
+		BySource:   map[string][]int{"service-a": {1, 2}},
+		ByName:     map[string][]int{"disk-full": {1}, "*": {2}},
+		Rules: map[int]snapshot.RuleInfo{
+			1: {RuleID: "rule-1", ClientID: "client-1"},
+			2: {RuleID: "rule-2", ClientID: "client-2"},
+		},
+	}
+	idx := NewIndexes(snap)
+
+	// New rule
+	idx.PutRule("rule-3", "client-3", "HIGH", "service-b", "cpu-high", nil, nil, nil)
+	if got := idx.Match("HIGH", "service-b", "cpu-high", nil); !reflect.DeepEqual(got, map[string][]string{"client-3": {"rule-3"}}) {
+		t.Errorf("Match() after PutRule = %v", got)
+	}
+
+	// Replacing a snapshot rule moves it to its new keys
+	idx.PutRule("rule-1", "client-1", "LOW", "service-a", "disk-full", nil, nil, nil)
+	if got := idx.Match("HIGH", "service-a", "disk-full", nil); len(got) != 0 {
+		t.Errorf("Match() on replaced rule's old fields = %v, want none", got)
+	}
+	if got := idx.Match("LOW", "service-a", "disk-full", nil); len(got["client-1"]) != 1 || len(got["client-2"]) != 1 {
+		t.Errorf("Match() on replaced rule's new fields = %v, want rule-1 and wildcard rule-2", got)
+	}
+	if idx.RuleCount() != 3 {
+		t.Errorf("RuleCount() = %d, want 3", idx.RuleCount())
+	}
+
+	if !idx.RemoveRule("rule-2") {
+		t.Error("RemoveRule(rule-2) = false, want true")
+	}
+	if idx.RemoveRule("rule-2") {
+		t.Error("RemoveRule(rule-2) twice = true, want false")
+	}
+	if _, exists := idx.byName["*"]; exists {
+		t.Error("RemoveRule() left an empty wildcard name list")
+	}
+	if idx.RuleCount() != 2 {
+		t.Errorf("RuleCount() = %d, want 2", idx.RuleCount())
+	}
+}
+
+func TestIndexes_MatchClient(t *testing.T) {
+	snap := &snapshot.Snapshot{
+		BySeverity: map[string][]int{"HIGH": {1, 2}},
+		BySource:   map[string][]int{"shared-db": {1, 2}},
+		ByName:     map[string][]int{"disk-full": {1, 2}},
+		Rules: map[int]snapshot.RuleInfo{
+			1: {RuleID: "rule-1", ClientID: "client-1"},
+			2: {RuleID: "rule-2", ClientID: "client-2"},
+		},
+	}
+	idx := NewIndexes(snap)
+
+	if got := idx.MatchClient("client-1", "HIGH", "shared-db", "disk-full", nil); !reflect.DeepEqual(got, map[string][]string{"client-1": {"rule-1"}}) {
+		t.Errorf("MatchClient(client-1) = %v, want only rule-1", got)
+	}
+	if got := idx.MatchClient("client-3", "HIGH", "shared-db", "disk-full", nil); len(got) != 0 {
+		t.Errorf("MatchClient(client-3) = %v, want none", got)
+	}
+
+	// Known clients follow rule changes
+	if !idx.HasClient("client-2") || idx.HasClient("client-3") {
+		t.Error("HasClient() does not reflect the snapshot's clients")
+	}
+	idx.PutRule("rule-3", "client-3", "LOW", "api", "timeout", nil, nil, nil)
+	idx.PutRule("rule-2", "client-3", "HIGH", "shared-db", "disk-full", nil, nil, nil) // moves rule-2 to client-3
+	if idx.HasClient("client-2") || !idx.HasClient("client-3") {
+		t.Error("HasClient() after PutRule, want client-3 known and client-2 unknown")
+	}
+	idx.RemoveRule("rule-2")
+	if !idx.HasClient("client-3") {
+		t.Error("HasClient(client-3) = false with rule-3 left")
+	}
+	idx.RemoveRule("rule-3")
+	if idx.HasClient("client-3") {
+		t.Error("HasClient(client-3) = true after removing its rules")
+	}
+}
+
+func TestIndexes_Normalization(t *testing.T) {
+	// rule-updater stored the rule's fields normalized with trim,fold
+	norm := shared.Normalization{Trim: true, FoldCase: true}
+	snap := &snapshot.Snapshot{
+		BySeverity:    map[string][]int{norm.Apply("HIGH"): {1}},
+		BySource:      map[string][]int{norm.Apply("api"): {1}},
+		ByName:        map[string][]int{"*": {1}},
+		Rules:         map[int]snapshot.RuleInfo{1: {RuleID: "rule-1", ClientID: "client-1"}},
+		Normalization: "trim,fold",
+	}
+	idx := NewIndexes(snap)
+
+	for _, source := range []string{"api", "API", " Api "} {
+		if got := idx.Match("HIGH", source, "timeout", nil); len(got["client-1"]) != 1 {
+			t.Errorf("Match(source %q) = %v, want rule-1", source, got)
+		}
+	}
+
+	// Rules applied in place are normalized like the snapshot's
+	idx.PutRule("rule-2", "client-2", "LOW", " Payments ", "Card-Declined", nil, nil, nil)
+	if got := idx.Match("low", "PAYMENTS", "card-declined", nil); len(got["client-2"]) != 1 {
+		t.Errorf("Match() after PutRule = %v, want rule-2", got)
+	}
+
+	// Snapshots without normalization keep legacy exact matching
+	snap.Normalization = ""
+	snap.BySource = map[string][]int{"api": {1}}
+	snap.BySeverity = map[string][]int{"HIGH": {1}}
+	exact := NewIndexes(snap)
+	if got := exact.Match("HIGH", "API", "timeout", nil); len(got) != 0 {
+		t.Errorf("exact Match(source API) = %v, want none", got)
+	}
+}
+
+// TestIndexes_Exclusions tests that wildcard rules skip the sources and names they exclude.
+func TestIndexes_Exclusions(t *testing.T) {
+	snap := &snapshot.Snapshot{
+		BySeverity: map[string][]int{"high": {1}},
+		BySource:   map[string][]int{"*": {1}},
+		ByName:     map[string][]int{"timeout": {1}},
+		Rules: map[int]snapshot.RuleInfo{
+			// rule-updater stored the exclusions normalized
+			1: {RuleID: "rule-1", ClientID: "client-1", ExcludeSources: []string{"staging", "canary"}},
+		},
+		Normalization: "trim,fold",
+	}
+	idx := NewIndexes(snap)
+
+	if got := idx.Match("HIGH", "api", "timeout", nil); len(got["client-1"]) != 1 {
+		t.Errorf("Match(api) = %v, want rule-1", got)
+	}
+	for _, source := range []string{"staging", " Canary "} {
+		if got := idx.Match("HIGH", source, "timeout", nil); len(got) != 0 {
+			t.Errorf("Match(%q) = %v, want none (excluded)", source, got)
+		}
+	}
+
+	// Exclusions of rules applied in place are normalized too
+	idx.PutRule("rule-2", "client-2", "HIGH", "api", "*", nil, []string{"Heartbeat"}, nil)
+	if got := idx.Match("HIGH", "api", "heartbeat", nil); len(got["client-2"]) != 0 {
+		t.Errorf("Match(heartbeat) = %v, want rule-2 excluded", got)
+	}
+	if got := idx.Match("HIGH", "api", "timeout", nil); len(got["client-2"]) != 1 {
+		t.Errorf("Match(timeout) = %v, want rule-2", got)
+	}
+}
+
+// TestIndexes_Conditions tests that rules with context conditions only match alerts whose context satisfies all of them.
+func TestIndexes_Conditions(t *testing.T) {
+	snap := &snapshot.Snapshot{
+		BySeverity: map[string][]int{"high": {1}},
+		BySource:   map[string][]int{"api": {1}},
+		ByName:     map[string][]int{"timeout": {1}},
+		Rules: map[int]snapshot.RuleInfo{
+			// rule-updater stored the condition values normalized
+			1: {RuleID: "rule-1", ClientID: "client-1", Conditions: []snapshot.ContextCondition{
+				{Key: "region", Op: "==", Value: "eu-west-1"},
+				{Key: "env", Op: "!=", Value: "staging"},
+			}},
+		},
+		Normalization: "trim,fold",
+	}
+	idx := NewIndexes(snap)
+
+	tests := []struct {
+		name    string
+		context map[string]string
+		want    bool
+	}{
+		{"all conditions hold", map[string]string{"region": "eu-west-1", "env": "prod"}, true},
+		{"values are normalized", map[string]string{"region": " EU-West-1 "}, true},
+		{"!= holds when the key is absent", map[string]string{"region": "eu-west-1"}, true},
+		{"== fails on another value", map[string]string{"region": "us-east-1"}, false},
+		{"== fails when the key is absent", map[string]string{"env": "prod"}, false},
+		{"!= fails on the value", map[string]string{"region": "eu-west-1", "env": "Staging"}, false},
+		{"keys are matched exactly", map[string]string{"Region": "eu-west-1"}, false},
+		{"no context", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := idx.Match("HIGH", "api", "timeout", tt.context)
+			if matched := len(got["client-1"]) == 1; matched != tt.want {
+				t.Errorf("Match(%v) = %v, want matched %v", tt.context, got, tt.want)
+			}
+		})
+	}
+
+	// Conditions of rules applied in place are normalized too; unknown operators never match
+	idx.PutRule("rule-2", "client-2", "HIGH", "api", "timeout", nil, nil, []snapshot.ContextCondition{{Key: "region", Op: "==", Value: "US-East-1"}})
+	idx.PutRule("rule-3", "client-3", "HIGH", "api", "timeout", nil, nil, []snapshot.ContextCondition{{Key: "region", Op: "~", Value: "us-east-1"}})
+	got := idx.MatchClient("client-2", "HIGH", "api", "timeout", map[string]string{"region": "us-east-1"})
+	if len(got["client-2"]) != 1 {
+		t.Errorf("MatchClient(client-2) = %v, want rule-2", got)
+	}
+	if got := idx.Match("HIGH", "api", "timeout", map[string]string{"region": "us-east-1"}); len(got["client-3"]) != 0 {
+		t.Errorf("Match() = %v, want rule-3 (unknown operator) not matched", got)
+	}
+}
+
+// TestIndexes_Explain tests that Explain reports the candidate sets considered alongside Match's result.
+func TestIndexes_Explain(t *testing.T) {
+	snap := &snapshot.Snapshot{
+		BySeverity: map[string][]int{"HIGH": {1, 2}, "*": {3}},
+		BySource:   map[string][]int{"api": {1, 3}, "db": {2}},
+		ByName:     map[string][]int{"timeout": {1, 2, 3}},
+		Rules: map[int]snapshot.RuleInfo{
+			1: {RuleID: "rule-1", ClientID: "client-1"},
+			2: {RuleID: "rule-2", ClientID: "client-1"},
+			3: {RuleID: "rule-3", ClientID: "client-2", Conditions: []snapshot.ContextCondition{{Key: "env", Op: "==", Value: "prod"}}},
+		},
+	}
+	idx := NewIndexes(snap)
+
+	got := idx.Explain("", "HIGH", "api", "timeout", map[string]string{"env": "staging"})
+	want := Evaluation{
+		SeverityCandidates: []string{"rule-1", "rule-2", "rule-3"},
+		SourceCandidates:   []string{"rule-1", "rule-3"},
+		NameCandidates:     []string{"rule-1", "rule-2", "rule-3"},
+		Intersected:        []string{"rule-1", "rule-3"},
+		Matches:            map[string][]string{"client-1": {"rule-1"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Explain() = %+v, want %+v", got, want)
+	}
+
+	// A non-match still lists its candidates
+	got = idx.Explain("", "LOW", "db", "timeout", nil)
+	if len(got.Matches) != 0 || !reflect.DeepEqual(got.SourceCandidates, []string{"rule-2"}) || len(got.Intersected) != 0 {
+		t.Errorf("Explain(LOW, db) = %+v, want no match with rule-2 as source candidate", got)
+	}
+
+	// The client filter applies to the matches only
+	got = idx.Explain("client-2", "HIGH", "api", "timeout", map[string]string{"env": "prod"})
+	if !reflect.DeepEqual(got.Matches, map[string][]string{"client-2": {"rule-3"}}) || len(got.Intersected) != 2 {
+		t.Errorf("Explain(client-2) = %+v, want rule-3 matched of 2 intersected", got)
+	}
+}
+
+// TestIndexes_Profile tests that Profile times every intersected rule, filtered by client.
+func TestIndexes_Profile(t *testing.T) {
+	snap := &snapshot.Snapshot{
+		BySeverity: map[string][]int{"HIGH": {1, 2}, "*": {3}},
+		BySource:   map[string][]int{"api": {1, 3}, "db": {2}},
+		ByName:     map[string][]int{"timeout": {1, 2, 3}},
+		Rules: map[int]snapshot.RuleInfo{
+			1: {RuleID: "rule-1", ClientID: "client-1"},
+			2: {RuleID: "rule-2", ClientID: "client-1"},
+			3: {RuleID: "rule-3", ClientID: "client-2", Conditions: []snapshot.ContextCondition{{Key: "env", Op: "==", Value: "prod"}}},
+		},
+	}
+	idx := NewIndexes(snap)
+
+	costs := idx.Profile("", "HIGH", "api", "timeout", map[string]string{"env": "staging"})
+	var ids []string
+	for i, cost := range costs {
+		ids = append(ids, cost.RuleID)
+		if i > 0 && cost.Duration > costs[i-1].Duration {
+			t.Errorf("Profile() not sorted slowest first: %+v", costs)
+		}
+	}
+	sort.Strings(ids)
+	if !reflect.DeepEqual(ids, []string{"rule-1", "rule-3"}) {
+		t.Errorf("Profile() rules = %v, want [rule-1 rule-3]", ids)
+	}
+
+	costs = idx.Profile("client-2", "HIGH", "api", "timeout", nil)
+	if len(costs) != 1 || costs[0].RuleID != "rule-3" || costs[0].ClientID != "client-2" {
+		t.Errorf("Profile(client-2) = %+v, want rule-3 only", costs)
+	}
+
+	if costs := idx.Profile("", "LOW", "db", "timeout", nil); len(costs) != 0 {
+		t.Errorf("Profile(LOW, db) = %+v, want none", costs)
+	}
+}
@@ -3,6 +3,7 @@ package matcher
 
 import (
 	"evaluator/internal/indexes"
+	"evaluator/internal/snapshot"
 	"sync"
 )
 
@@ -20,13 +21,50 @@ func NewMatcher(idx *indexes.Indexes) *Matcher {
 	}
 }
 
-// Match finds all rules that match the given alert fields.
+// Match finds all rules that match the given alert fields and context.
 // Returns a map of client_id -> []rule_id for all matching rules.
 // Thread-safe: uses read lock for concurrent access.
-func (m *Matcher) Match(severity, source, name string) map[string][]string {
+func (m *Matcher) Match(severity, source, name string, context map[string]string) map[string][]string {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
-	return m.indexes.Match(severity, source, name)
+	return m.indexes.Match(severity, source, name, context)
+}
+
+// MatchClient finds the rules of one client that match the given alert fields.
+// known is false if the client has no rules, in which case nothing is matched.
+// Thread-safe: uses read lock for concurrent access.
+func (m *Matcher) MatchClient(clientID, severity, source, name string, context map[string]string) (matches map[string][]string, known bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if !m.indexes.HasClient(clientID) {
+		return nil, false
+	}
+	return m.indexes.MatchClient(clientID, severity, source, name, context), true
+}
+
+// Explain matches an alert like Match (or MatchClient, if clientID is set) and also returns
+// the candidate sets considered, for sampled evaluation results.
+// Thread-safe: uses read lock for concurrent access.
+func (m *Matcher) Explain(clientID, severity, source, name string, context map[string]string) indexes.Evaluation {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.indexes.Explain(clientID, severity, source, name, context)
+}
+
+// Profile times the check of each candidate rule against an alert, slowest first.
+// Thread-safe: uses read lock for concurrent access.
+func (m *Matcher) Profile(clientID, severity, source, name string, context map[string]string) []indexes.RuleCost {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.indexes.Profile(clientID, severity, source, name, context)
+}
+
+// Probes returns up to limit synthetic alerts built from the rules in the indexes.
+// Thread-safe: uses read lock for concurrent access.
+func (m *Matcher) Probes(limit int) []indexes.Probe {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.indexes.Probes(limit)
 }
 
 // UpdateIndexes atomically swaps the indexes with new ones.
@@ -37,6 +75,22 @@ func (m *Matcher) UpdateIndexes(idx *indexes.Indexes) {
 	m.indexes = idx
 }
 
+// PutRule adds or replaces a single rule in the current indexes.
+// Thread-safe: uses write lock so matches never see a partially applied change.
+func (m *Matcher) PutRule(ruleID, clientID, severity, source, name string, excludeSources, excludeNames []string, conditions []snapshot.ContextCondition) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.indexes.PutRule(ruleID, clientID, severity, source, name, excludeSources, excludeNames, conditions)
+}
+
+// RemoveRule removes a single rule from the current indexes.
+// Returns false if the rule was not present.
+func (m *Matcher) RemoveRule(ruleID string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.indexes.RemoveRule(ruleID)
+}
+
 // RuleCount returns the current number of rules in the indexes.
 func (m *Matcher) RuleCount() int {
 	m.mu.RLock()
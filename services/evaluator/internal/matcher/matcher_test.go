@@ -73,7 +73,7 @@ func TestMatcher_Match(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := matcher.Match(tt.severity, tt.source, tt.nameField)
+			result := matcher.Match(tt.severity, tt.source, tt.nameField, nil)
 
 			if len(result) != len(tt.wantClientIDs) {
 				t.Errorf("Match() returned %d clients, want %d", len(result), len(tt.wantClientIDs))
@@ -123,7 +123,7 @@ func TestMatcher_UpdateIndexes(t *testing.T) {
 	}
 
 	// Verify new rules are matched
-	result := matcher.Match("LOW", "service-b", "cpu-high")
+	result := matcher.Match("LOW", "service-b", "cpu-high", nil)
 	if len(result) != 1 {
 		t.Fatalf("Match() after update returned %d clients, want 1", len(result))
 	}
@@ -152,7 +152,7 @@ func TestMatcher_ConcurrentAccess(t *testing.T) {
 		go func() {
 			defer wg.Done()
 			for j := 0; j < numReads; j++ {
-				_ = matcher.Match("HIGH", "service-a", "disk-full")
+				_ = matcher.Match("HIGH", "service-a", "disk-full", nil)
 				_ = matcher.RuleCount()
 			}
 		}()
@@ -164,7 +164,7 @@ func TestMatcher_ConcurrentAccess(t *testing.T) {
 	go func() {
 		defer wg.Done()
 		for i := 0; i < numReads; i++ {
-			_ = matcher.Match("HIGH", "service-a", "disk-full")
+			_ = matcher.Match("HIGH", "service-a", "disk-full", nil)
 		}
 	}()
 	go func() {
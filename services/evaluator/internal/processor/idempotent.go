@@ -0,0 +1,63 @@
+package processor
+
+import (
+	"context"
+	"log/slog"
+
+	"evaluator/internal/events"
+)
+
+// PublishLog remembers which alerts.matched events were published. It is implemented by
+// publishlog.RedisLog.
+type PublishLog interface {
+	// Published reports whether the event with key was published.
+	Published(ctx context.Context, key string) (bool, error)
+	// MarkPublished records that the event with key was published.
+	MarkPublished(ctx context.Context, key string) error
+}
+
+// WithPublishLog makes publishing idempotent: an alert redelivered after a crash, a rebalance
+// or a partly failed publish skips the matched events its earlier delivery already published.
+// An event published just before a crash, but not yet recorded in the log, is still published
+// twice. If the log cannot be read, the event is published anyway.
+func (p *Processor) WithPublishLog(l PublishLog) *Processor {
+	p.publishLog = l
+	return p
+}
+
+// publishKey returns the key the publish log remembers an event by: its alert and client, or
+// its alert alone for a combined event.
+func publishKey(matched *events.AlertMatched) string {
+	if len(matched.Matches) > 0 {
+		return matched.AlertID
+	}
+	return matched.AlertID + "/" + matched.ClientID
+}
+
+// publish publishes a matched event, unless the publish log has it. Returns true if it was
+// skipped.
+func (p *Processor) publish(ctx context.Context, matched *events.AlertMatched) (bool, error) {
+	if p.publishLog == nil {
+		return false, p.producer.Publish(ctx, matched)
+	}
+
+	key := publishKey(matched)
+	published, err := p.publishLog.Published(ctx, key)
+	if err != nil {
+		// A duplicate is deduplicated by the aggregator; a skipped match would be lost
+		slog.Warn("Failed to read publish log, publishing", "key", key, "error", err)
+		p.metrics.IncrementCustom("publish_log_errors")
+	} else if published {
+		p.metrics.IncrementCustom("matches_republish_skipped")
+		return true, nil
+	}
+
+	if err := p.producer.Publish(ctx, matched); err != nil {
+		return false, err
+	}
+	if err := p.publishLog.MarkPublished(ctx, key); err != nil {
+		slog.Warn("Failed to record published match", "key", key, "error", err)
+		p.metrics.IncrementCustom("publish_log_errors")
+	}
+	return false, nil
+}
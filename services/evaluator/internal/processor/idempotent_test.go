@@ -0,0 +1,101 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"evaluator/internal/events"
+	"evaluator/internal/indexes"
+	"evaluator/internal/matcher"
+	"evaluator/internal/snapshot"
+)
+
+// fakeMatchedPublisher records published matched alerts.
+type fakeMatchedPublisher struct {
+	published []*events.AlertMatched
+	err       error
+}
+
+func (f *fakeMatchedPublisher) Publish(ctx context.Context, matched *events.AlertMatched) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.published = append(f.published, matched)
+	return nil
+}
+
+// fakePublishLog is an in-memory PublishLog.
+type fakePublishLog struct {
+	keys    map[string]bool
+	readErr error
+}
+
+func (f *fakePublishLog) Published(ctx context.Context, key string) (bool, error) {
+	return f.keys[key], f.readErr
+}
+
+func (f *fakePublishLog) MarkPublished(ctx context.Context, key string) error {
+	f.keys[key] = true
+	return nil
+}
+
+func TestProcessor_PublishLog(t *testing.T) {
+	snap := &snapshot.Snapshot{
+		BySeverity: map[string][]int{"HIGH": {1, 2}},
+		BySource:   map[string][]int{"shared-db": {1, 2}},
+		ByName:     map[string][]int{"disk-full": {1, 2}},
+		Rules: map[int]snapshot.RuleInfo{
+			1: {RuleID: "rule-1", ClientID: "client-1"},
+			2: {RuleID: "rule-2", ClientID: "client-2"},
+		},
+	}
+	alert := &events.AlertNew{AlertID: "alert-1", Severity: "HIGH", Source: "shared-db", Name: "disk-full"}
+	publisher := &fakeMatchedPublisher{}
+	log := &fakePublishLog{keys: map[string]bool{}}
+	mock := newMockCollector()
+	p := NewProcessor(nil, publisher, matcher.NewMatcher(indexes.NewIndexes(snap))).WithPublishLog(log)
+	p.metrics = wrapMetrics(mock)
+
+	if result := p.processOne(context.Background(), alert); result.publishedCount != 2 || len(publisher.published) != 2 {
+		t.Fatalf("first delivery published %d (counted %d), want 2", len(publisher.published), result.publishedCount)
+	}
+	if !log.keys["alert-1/client-1"] || !log.keys["alert-1/client-2"] {
+		t.Errorf("publish log = %v, want both clients' events", log.keys)
+	}
+
+	// A redelivery after one client's publish failed only publishes the other's
+	delete(log.keys, "alert-1/client-2")
+	result := p.processOne(context.Background(), alert)
+	if !result.allPublishesSucceeded || len(publisher.published) != 3 || publisher.published[2].ClientID != "client-2" {
+		t.Errorf("redelivery = %+v, published %d, want only client-2 published again", result, len(publisher.published))
+	}
+	if mock.customCounts["matches_republish_skipped"] != 1 {
+		t.Errorf("matches_republish_skipped = %d, want 1", mock.customCounts["matches_republish_skipped"])
+	}
+
+	// An unreadable log publishes anyway
+	log.readErr = errors.New("redis down")
+	p.processOne(context.Background(), alert)
+	if len(publisher.published) != 5 || mock.customCounts["publish_log_errors"] != 2 {
+		t.Errorf("published %d, publish_log_errors = %d with an unreadable log, want 5 and 2", len(publisher.published), mock.customCounts["publish_log_errors"])
+	}
+}
+
+func TestProcessor_PublishLogSkipsFailedPublishes(t *testing.T) {
+	log := &fakePublishLog{keys: map[string]bool{}}
+	p := NewProcessor(nil, &fakeMatchedPublisher{err: errors.New("broker down")}, nil).WithPublishLog(log)
+
+	matched := events.NewAlertMatched(&events.AlertNew{AlertID: "alert-1"}, "client-1", []string{"rule-1"})
+	if _, err := p.publish(context.Background(), matched); err == nil {
+		t.Fatal("publish() succeeded, want the publisher's error")
+	}
+	if len(log.keys) != 0 {
+		t.Errorf("publish log = %v after a failed publish, want empty", log.keys)
+	}
+
+	combined := events.NewCombinedAlertMatched(&events.AlertNew{AlertID: "alert-1"}, map[string][]string{"client-1": {"rule-1"}})
+	if got := publishKey(combined); got != "alert-1" {
+		t.Errorf("publishKey(combined) = %q, want alert-1", got)
+	}
+}
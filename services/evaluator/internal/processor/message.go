@@ -2,12 +2,22 @@ package processor
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"time"
 
 	"evaluator/internal/events"
+	"evaluator/internal/validation"
+
+	"github.com/afikmenashe/alerting-platform/pkg/metrics"
 )
 
+// Tracer records per-alert pipeline events for debugging a single alert's journey.
+type Tracer interface {
+	RecordTrace(ctx context.Context, alertID string, event metrics.TraceEvent)
+}
+
 // processResult contains the outcome of processing a single message.
 type processResult struct {
 	// allPublishesSucceeded is true if all matched alerts were published successfully.
@@ -21,49 +31,86 @@ type processResult struct {
 // Returns the processing result and records metrics.
 //
 // Responsibilities:
-//   - Match alert against rules via matcher
-//   - Publish one message per matching client
+//   - Match alert against rules via matcher (only the hinted client's rules if client_hint is set)
+//   - Publish one message per matching client, or one combined message (see matchedEvents)
+//   - Skip the messages an earlier delivery of the alert published, if a publish log is set
+//   - Report the slowest rules if matching exceeded the evaluation deadline
+//   - Publish a sampled copy of the evaluation to debug.evaluations, if sampling is enabled
+//   - Drop, publish to alerts.unmatched, or count alerts that match no rule (see WithUnmatchedPolicy)
 //   - Track success/failure for commit decision
 //   - Record metrics (received, published, errors, latency)
 func (p *Processor) processOne(ctx context.Context, alert *events.AlertNew) processResult {
 	startTime := time.Now()
 
-	// Match alert against rules
-	matches := p.matcher.Match(alert.Severity, alert.Source, alert.Name)
-
 	result := processResult{
 		allPublishesSucceeded: true,
 		publishedCount:        0,
 	}
 
+	// Match alert against rules; a client hint restricts matching to that client's rules
+	var matches map[string][]string
+	matchStart := time.Now()
+	if alert.ClientHint != "" {
+		var known bool
+		matches, known = p.matcher.MatchClient(alert.ClientHint, alert.Severity, alert.Source, alert.Name, alert.Context)
+		if !known {
+			p.recordRejection(ctx, alert, &validation.Error{
+				Reason:  validation.ReasonUnknownClientHint,
+				Message: fmt.Sprintf("client_hint %q is not a known client", alert.ClientHint),
+			})
+			p.metrics.RecordProcessed(time.Since(startTime))
+			return result
+		}
+		p.metrics.IncrementCustom("alerts_client_hinted")
+	} else {
+		matches = p.matcher.Match(alert.Severity, alert.Source, alert.Name, alert.Context)
+	}
+	evaluatedAt := time.Now()
+	p.checkDeadline(ctx, alert, evaluatedAt.Sub(matchStart))
+	p.sample(ctx, alert)
+
 	if len(matches) == 0 {
 		p.metrics.RecordProcessed(time.Since(startTime))
 		p.metrics.IncrementCustom("alerts_unmatched")
+		p.trace(ctx, alert, metrics.TraceEvent{Event: "unmatched"})
+		p.handleUnmatched(ctx, alert, evaluatedAt)
 		return result
 	}
 
-	// Publish one message per client_id
-	for clientID, ruleIDs := range matches {
-		matched := events.NewAlertMatched(alert, clientID, ruleIDs)
-
-		if err := p.producer.Publish(ctx, matched); err != nil {
+	for _, matched := range p.matchedEvents(alert, matches, evaluatedAt) {
+		skipped, err := p.publish(ctx, matched)
+		if err != nil {
 			slog.Error("Failed to publish matched alert",
 				"alert_id", alert.AlertID,
-				"client_id", clientID,
+				"partition_key", matched.PartitionKey(),
 				"error", err,
 			)
 			p.metrics.RecordError()
+			for _, m := range clientMatches(matched) {
+				p.trace(ctx, alert, metrics.TraceEvent{Event: "publish_failed", ClientID: m.ClientID, RuleIDs: m.RuleIDs, Error: err.Error()})
+			}
 			result.allPublishesSucceeded = false
 			continue
 		}
 
+		if skipped {
+			slog.Debug("Skipped matched alert published by an earlier delivery",
+				"alert_id", alert.AlertID,
+				"partition_key", matched.PartitionKey(),
+			)
+			continue
+		}
+
 		result.publishedCount++
 		p.metrics.RecordPublished()
+		for _, m := range clientMatches(matched) {
+			p.trace(ctx, alert, metrics.TraceEvent{Event: "matched", ClientID: m.ClientID, RuleIDs: m.RuleIDs})
+		}
 
 		slog.Debug("Published matched alert",
 			"alert_id", alert.AlertID,
-			"client_id", clientID,
-			"rule_ids", ruleIDs,
+			"partition_key", matched.PartitionKey(),
+			"clients", len(clientMatches(matched)),
 		)
 	}
 
@@ -72,3 +119,70 @@ func (p *Processor) processOne(ctx context.Context, alert *events.AlertNew) proc
 
 	return result
 }
+
+// matchedEvents builds the alerts.matched events for an alert according to the fan-out policy:
+// one event per client_id, or a single combined event carrying every client's matches.
+// Every event is stamped with evaluatedAt, the time matching finished.
+func (p *Processor) matchedEvents(alert *events.AlertNew, matches map[string][]string, evaluatedAt time.Time) []*events.AlertMatched {
+	var out []*events.AlertMatched
+	if p.fanOut == events.FanOutCombined {
+		out = []*events.AlertMatched{events.NewCombinedAlertMatched(alert, matches)}
+	} else {
+		out = make([]*events.AlertMatched, 0, len(matches))
+		for clientID, ruleIDs := range matches {
+			out = append(out, events.NewAlertMatched(alert, clientID, ruleIDs))
+		}
+	}
+	for _, matched := range out {
+		matched.EvaluatedAtMs = evaluatedAt.UnixMilli()
+	}
+	return out
+}
+
+// clientMatches returns the per-client matches carried by an event, for tracing.
+func clientMatches(matched *events.AlertMatched) []events.ClientMatch {
+	if len(matched.Matches) > 0 {
+		return matched.Matches
+	}
+	return []events.ClientMatch{{ClientID: matched.ClientID, RuleIDs: matched.RuleIDs}}
+}
+
+// recordRejection logs a rejected alert and records the rejection metrics:
+// a total "alerts_rejected" counter plus one counter per rejection reason.
+func (p *Processor) recordRejection(ctx context.Context, alert *events.AlertNew, err error) {
+	reason := "invalid"
+	var verr *validation.Error
+	if errors.As(err, &verr) {
+		reason = verr.Reason
+	}
+
+	p.metrics.IncrementCustom("alerts_rejected")
+	p.metrics.IncrementCustom("alerts_rejected_" + reason)
+	p.trace(ctx, alert, metrics.TraceEvent{Event: "rejected", Error: err.Error(), Details: map[string]string{"reason": reason}})
+
+	slog.Warn("Rejected invalid alert",
+		"alert_id", alert.AlertID,
+		"source", alert.Source,
+		"reason", reason,
+		"error", err,
+	)
+}
+
+// trace records an evaluator event in the alert's trace log, if tracing is enabled.
+// The alert's severity, source, and name are added to the event details.
+func (p *Processor) trace(ctx context.Context, alert *events.AlertNew, event metrics.TraceEvent) {
+	if p.tracer == nil {
+		return
+	}
+	event.Stage = metrics.StageEvaluator
+	if event.Details == nil {
+		event.Details = make(map[string]string, 3)
+	}
+	event.Details["severity"] = alert.Severity
+	event.Details["source"] = alert.Source
+	event.Details["name"] = alert.Name
+	if alert.ClientHint != "" {
+		event.Details["client_hint"] = alert.ClientHint
+	}
+	p.tracer.RecordTrace(ctx, alert.AlertID, event)
+}
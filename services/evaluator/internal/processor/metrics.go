@@ -31,13 +31,13 @@ func (NoOpMetrics) RecordProcessed(time.Duration)  {}
 func (NoOpMetrics) IncrementCustom(string)         {}
 func (NoOpMetrics) AddCustom(string, uint64)       {}
 
-// collectorAdapter adapts *metrics.Collector to the Metrics interface.
+// collectorAdapter adapts metrics.Collector to the Metrics interface.
 // This keeps the processor package decoupled from the concrete metrics implementation.
 type collectorAdapter struct {
 	c metricsCollector
 }
 
-// metricsCollector is the minimal interface we need from *metrics.Collector.
+// metricsCollector is the minimal interface we need from metrics.Collector.
 // This avoids importing the metrics package in the interface definition.
 type metricsCollector interface {
 	RecordReceived()
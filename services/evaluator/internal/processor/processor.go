@@ -4,47 +4,126 @@ package processor
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"time"
 
 	"evaluator/internal/consumer"
+	"evaluator/internal/events"
 	"evaluator/internal/matcher"
-	"evaluator/internal/producer"
+	"evaluator/internal/validation"
 
 	"github.com/afikmenashe/alerting-platform/pkg/metrics"
+	"github.com/segmentio/kafka-go"
 )
 
+// DeadLetterQueue moves messages that cannot be processed to a dead-letter topic.
+// It is implemented by pkg/kafka's DeadLetterQueue.
+type DeadLetterQueue interface {
+	// Reject dead-letters a message that will never succeed. Returns true if it was published.
+	Reject(ctx context.Context, msg *kafka.Message, reason error) (bool, error)
+	// Fail records a failed attempt and dead-letters the message once it has failed too often.
+	// Returns true if it was published.
+	Fail(ctx context.Context, msg *kafka.Message, reason error) (bool, error)
+}
+
+// MatchedPublisher publishes matched alerts to the alerts.matched topic.
+// It is implemented by producer.Producer.
+type MatchedPublisher interface {
+	Publish(ctx context.Context, matched *events.AlertMatched) error
+}
+
 // Processor orchestrates alert evaluation and matching.
 type Processor struct {
 	consumer *consumer.Consumer
-	producer *producer.Producer
+	producer MatchedPublisher
 	matcher  *matcher.Matcher
 	metrics  Metrics
+	// validator rejects malformed alerts before matching (nil disables validation).
+	validator *validation.Validator
+	// tracer records per-alert outcomes for the debug trace endpoint (nil disables tracing).
+	tracer Tracer
+	// fanOut selects how matches are published: events.FanOutPerClient or events.FanOutCombined.
+	fanOut string
+	// dlq receives alerts that cannot be decoded, are rejected, or fail to publish too often (nil disables it).
+	dlq DeadLetterQueue
+	// publishLog remembers published alerts.matched events so a redelivered alert does not
+	// publish them again (nil publishes every time).
+	publishLog PublishLog
+	// sampler receives a copy of 1 in sampleEvery evaluation results (nil disables sampling).
+	sampler     SamplePublisher
+	sampleEvery int
+	// unmatchedPolicy selects what happens to alerts that match no rule (events.UnmatchedDrop,
+	// events.UnmatchedPublish or events.UnmatchedCount). unmatchedSources is only used by the
+	// processing loop.
+	unmatchedPolicy    string
+	unmatchedPublisher UnmatchedPublisher
+	unmatchedSources   map[string]bool
+	// evalDeadline is the time matching one alert may take before its slowest rules are
+	// reported (0 disables the check).
+	evalDeadline time.Duration
+	// disabler disables rules that exceed the deadline disableAfter times within offenseWindow
+	// (nil disables auto-disable). offenses is only used by the processing loop.
+	disabler      RuleDisabler
+	disableAfter  int
+	offenseWindow time.Duration
+	offenses      map[string]*offense
 	// rawMetrics holds the original collector for external access via GetMetrics().
-	rawMetrics *metrics.Collector
+	rawMetrics metrics.Collector
 }
 
 // NewProcessor creates a new alert evaluation processor without metrics.
-func NewProcessor(consumer *consumer.Consumer, producer *producer.Producer, matcher *matcher.Matcher) *Processor {
+func NewProcessor(consumer *consumer.Consumer, producer MatchedPublisher, matcher *matcher.Matcher) *Processor {
 	return &Processor{
-		consumer:   consumer,
-		producer:   producer,
-		matcher:    matcher,
-		metrics:    NoOpMetrics{},
-		rawMetrics: nil,
+		consumer:        consumer,
+		producer:        producer,
+		matcher:         matcher,
+		metrics:         NoOpMetrics{},
+		fanOut:          events.FanOutPerClient,
+		unmatchedPolicy: events.UnmatchedDrop,
+		rawMetrics:      nil,
 	}
 }
 
 // NewProcessorWithMetrics creates a processor with a shared metrics collector.
-func NewProcessorWithMetrics(consumer *consumer.Consumer, producer *producer.Producer, matcher *matcher.Matcher, m *metrics.Collector) *Processor {
+func NewProcessorWithMetrics(consumer *consumer.Consumer, producer MatchedPublisher, matcher *matcher.Matcher, m metrics.Collector) *Processor {
 	return &Processor{
-		consumer:   consumer,
-		producer:   producer,
-		matcher:    matcher,
-		metrics:    wrapMetrics(m),
-		rawMetrics: m,
+		consumer:        consumer,
+		producer:        producer,
+		matcher:         matcher,
+		metrics:         wrapMetrics(m),
+		fanOut:          events.FanOutPerClient,
+		unmatchedPolicy: events.UnmatchedDrop,
+		rawMetrics:      m,
 	}
 }
 
+// WithValidator enables strict validation of incoming alerts.
+// Rejected alerts are counted per reason and their offsets committed so they are not redelivered.
+func (p *Processor) WithValidator(v *validation.Validator) *Processor {
+	p.validator = v
+	return p
+}
+
+// WithTracer enables per-alert trace events (matched, unmatched, rejected, publish failures).
+func (p *Processor) WithTracer(t Tracer) *Processor {
+	p.tracer = t
+	return p
+}
+
+// WithFanOut selects the alerts.matched fan-out policy (events.FanOutPerClient or events.FanOutCombined).
+func (p *Processor) WithFanOut(policy string) *Processor {
+	p.fanOut = policy
+	return p
+}
+
+// WithDeadLetterQueue dead-letters alerts that fail to decode or are rejected by the validator,
+// and alerts whose matches fail to publish too many times, then commits them.
+func (p *Processor) WithDeadLetterQueue(q DeadLetterQueue) *Processor {
+	p.dlq = q
+	return p
+}
+
 // ProcessAlerts continuously reads alerts from Kafka, matches them against rules,
 // and publishes matched alerts to the output topic.
 //
@@ -80,10 +159,37 @@ func (p *Processor) processNextMessage(ctx context.Context) error {
 			return nil // Context cancelled, exit gracefully
 		}
 		slog.Error("Failed to read alert", "error", err)
+		// A message that was read but could not be decoded never will be
+		if msg != nil && p.dlq != nil {
+			dead, dlqErr := p.dlq.Reject(ctx, msg, err)
+			p.handleDeadLetter(ctx, msg, dead, dlqErr)
+		}
 		return nil // Continue processing
 	}
 
 	p.metrics.RecordReceived()
+	// Per-topic count, for ingestion split across topics (e.g. one per region)
+	p.metrics.IncrementCustom("alerts_received_" + msg.Topic)
+
+	// Reject malformed alerts before matching; redelivery would not fix them
+	if p.validator != nil {
+		if err := p.validator.Validate(alert); err != nil {
+			p.recordRejection(ctx, alert, err)
+			if p.dlq != nil {
+				if _, dlqErr := p.dlq.Reject(ctx, msg, err); dlqErr != nil {
+					slog.Error("Failed to dead-letter rejected alert", "alert_id", alert.AlertID, "error", dlqErr)
+					p.metrics.RecordError()
+				} else {
+					p.metrics.IncrementCustom("messages_dead_lettered")
+				}
+			}
+			if err := p.consumer.CommitMessage(ctx, msg); err != nil {
+				slog.Error("Failed to commit offset", "alert_id", alert.AlertID, "error", err)
+				p.metrics.RecordError()
+			}
+			return nil
+		}
+	}
 
 	// Process the alert (match + publish)
 	result := p.processOne(ctx, alert)
@@ -98,6 +204,14 @@ func (p *Processor) processNextMessage(ctx context.Context) error {
 			p.metrics.RecordError()
 		}
 	} else {
+		if p.dlq != nil {
+			reason := fmt.Errorf("failed to publish matches of alert %s", alert.AlertID)
+			dead, dlqErr := p.dlq.Fail(ctx, msg, reason)
+			p.handleDeadLetter(ctx, msg, dead, dlqErr)
+			if dead {
+				return nil
+			}
+		}
 		slog.Warn("Skipping offset commit due to publish failures, message will be redelivered",
 			"alert_id", alert.AlertID,
 		)
@@ -106,8 +220,24 @@ func (p *Processor) processNextMessage(ctx context.Context) error {
 	return nil
 }
 
+// handleDeadLetter commits a message once it is in the dead-letter topic.
+func (p *Processor) handleDeadLetter(ctx context.Context, msg *kafka.Message, dead bool, err error) {
+	if err != nil {
+		slog.Error("Failed to dead-letter message", "offset", msg.Offset, "error", err)
+		p.metrics.RecordError()
+		return
+	}
+	if !dead {
+		return
+	}
+	p.metrics.IncrementCustom("messages_dead_lettered")
+	if err := p.consumer.CommitMessage(ctx, msg); err != nil {
+		slog.Error("Failed to commit dead-lettered message", "offset", msg.Offset, "error", err)
+	}
+}
+
 // GetMetrics returns the underlying metrics collector for external access.
 // Returns nil if the processor was created without metrics.
-func (p *Processor) GetMetrics() *metrics.Collector {
+func (p *Processor) GetMetrics() metrics.Collector {
 	return p.rawMetrics
 }
@@ -1,13 +1,21 @@
 package processor
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	"evaluator/internal/consumer"
+	"evaluator/internal/events"
 	"evaluator/internal/indexes"
 	"evaluator/internal/matcher"
 	"evaluator/internal/producer"
 	"evaluator/internal/snapshot"
+	"evaluator/internal/validation"
+
+	"github.com/afikmenashe/alerting-platform/pkg/metrics"
 )
 
 func TestNewProcessor(t *testing.T) {
@@ -43,3 +51,283 @@ func TestNewProcessor(t *testing.T) {
 
 // Note: ProcessAlerts() tests require real Kafka instances and are better suited for integration tests.
 // The constructor test above validates that NewProcessor works correctly.
+
+func TestProcessor_RecordRejection(t *testing.T) {
+	mock := newMockCollector()
+	p := &Processor{metrics: wrapMetrics(mock)}
+
+	p.recordRejection(context.Background(), &events.AlertNew{AlertID: "alert-1"}, &validation.Error{Reason: validation.ReasonTimestampInFuture, Message: "too far ahead"})
+	p.recordRejection(context.Background(), &events.AlertNew{AlertID: "alert-2"}, errors.New("unexpected"))
+
+	if got := mock.customCounts["alerts_rejected"]; got != 2 {
+		t.Errorf("alerts_rejected = %d, want 2", got)
+	}
+	if got := mock.customCounts["alerts_rejected_timestamp_in_future"]; got != 1 {
+		t.Errorf("alerts_rejected_timestamp_in_future = %d, want 1", got)
+	}
+	if got := mock.customCounts["alerts_rejected_invalid"]; got != 1 {
+		t.Errorf("alerts_rejected_invalid = %d, want 1", got)
+	}
+}
+
+func TestProcessor_WithValidator(t *testing.T) {
+	v := validation.NewValidator(validation.Options{})
+	p := NewProcessor(nil, nil, nil).WithValidator(v)
+	if p.validator != v {
+		t.Error("WithValidator() did not set validator")
+	}
+}
+
+// fakeTracer records trace events per alert.
+type fakeTracer struct {
+	events map[string][]metrics.TraceEvent
+}
+
+func (f *fakeTracer) RecordTrace(ctx context.Context, alertID string, event metrics.TraceEvent) {
+	if f.events == nil {
+		f.events = make(map[string][]metrics.TraceEvent)
+	}
+	f.events[alertID] = append(f.events[alertID], event)
+}
+
+func TestProcessor_TracesUnmatchedAndRejected(t *testing.T) {
+	snap := &snapshot.Snapshot{
+		BySeverity: map[string][]int{"HIGH": {1}},
+		BySource:   map[string][]int{"service-a": {1}},
+		ByName:     map[string][]int{"disk-full": {1}},
+		Rules:      map[int]snapshot.RuleInfo{1: {RuleID: "rule-1", ClientID: "client-1"}},
+	}
+	tracer := &fakeTracer{}
+	p := NewProcessor(nil, nil, matcher.NewMatcher(indexes.NewIndexes(snap))).WithTracer(tracer)
+
+	p.processOne(context.Background(), &events.AlertNew{AlertID: "alert-1", Severity: "LOW", Source: "service-b", Name: "cpu"})
+	p.recordRejection(context.Background(), &events.AlertNew{AlertID: "alert-2"}, &validation.Error{Reason: validation.ReasonMissingSource, Message: "source is required"})
+
+	unmatched := tracer.events["alert-1"]
+	if len(unmatched) != 1 || unmatched[0].Event != "unmatched" || unmatched[0].Stage != metrics.StageEvaluator {
+		t.Fatalf("alert-1 trace = %+v, want one evaluator unmatched event", unmatched)
+	}
+	if unmatched[0].Details["source"] != "service-b" {
+		t.Errorf("unmatched details = %v, want source service-b", unmatched[0].Details)
+	}
+
+	rejected := tracer.events["alert-2"]
+	if len(rejected) != 1 || rejected[0].Event != "rejected" || rejected[0].Details["reason"] != validation.ReasonMissingSource {
+		t.Errorf("alert-2 trace = %+v, want one rejected event with reason", rejected)
+	}
+}
+
+func TestProcessor_MatchedEvents(t *testing.T) {
+	alert := &events.AlertNew{AlertID: "alert-1", Severity: "HIGH", Source: "service-a", Name: "disk-full"}
+	matches := map[string][]string{
+		"client-1": {"rule-1"},
+		"client-2": {"rule-2", "rule-3"},
+	}
+
+	evaluatedAt := time.UnixMilli(1700000000123)
+
+	perClient := NewProcessor(nil, nil, nil).matchedEvents(alert, matches, evaluatedAt)
+	if len(perClient) != 2 {
+		t.Fatalf("per-client fan-out returned %d events, want 2", len(perClient))
+	}
+	for _, e := range perClient {
+		if e.PartitionKey() != e.ClientID || len(e.Matches) != 0 || len(e.RuleIDs) != len(matches[e.ClientID]) {
+			t.Errorf("per-client event = %+v, want keyed by its client with that client's rules", e)
+		}
+		if e.EvaluatedAtMs != 1700000000123 {
+			t.Errorf("per-client EvaluatedAtMs = %d, want 1700000000123", e.EvaluatedAtMs)
+		}
+	}
+
+	combined := NewProcessor(nil, nil, nil).WithFanOut(events.FanOutCombined).matchedEvents(alert, matches, evaluatedAt)
+	if len(combined) != 1 {
+		t.Fatalf("combined fan-out returned %d events, want 1", len(combined))
+	}
+	if combined[0].PartitionKey() != "alert-1" || len(combined[0].Matches) != 2 {
+		t.Errorf("combined event = %+v, want keyed by alert_id with both clients", combined[0])
+	}
+	if combined[0].EvaluatedAtMs != 1700000000123 {
+		t.Errorf("combined EvaluatedAtMs = %d, want 1700000000123", combined[0].EvaluatedAtMs)
+	}
+	if got := clientMatches(combined[0]); len(got) != 2 || got[0].ClientID != "client-1" {
+		t.Errorf("clientMatches(combined) = %+v, want client-1 and client-2", got)
+	}
+}
+
+func TestProcessor_RejectsUnknownClientHint(t *testing.T) {
+	snap := &snapshot.Snapshot{
+		BySeverity: map[string][]int{"HIGH": {1}},
+		BySource:   map[string][]int{"shared-db": {1}},
+		ByName:     map[string][]int{"disk-full": {1}},
+		Rules:      map[int]snapshot.RuleInfo{1: {RuleID: "rule-1", ClientID: "client-1"}},
+	}
+	mock := newMockCollector()
+	tracer := &fakeTracer{}
+	p := NewProcessor(nil, nil, matcher.NewMatcher(indexes.NewIndexes(snap))).WithTracer(tracer)
+	p.metrics = wrapMetrics(mock)
+
+	// The alert matches client-1's rule, but is hinted for an unknown client: no global fallback
+	alert := &events.AlertNew{AlertID: "alert-1", Severity: "HIGH", Source: "shared-db", Name: "disk-full", ClientHint: "client-9"}
+	result := p.processOne(context.Background(), alert)
+
+	if !result.allPublishesSucceeded || result.publishedCount != 0 {
+		t.Errorf("processOne() = %+v, want nothing published and the offset committed", result)
+	}
+	if got := mock.customCounts["alerts_rejected_"+validation.ReasonUnknownClientHint]; got != 1 {
+		t.Errorf("alerts_rejected_%s = %d, want 1", validation.ReasonUnknownClientHint, got)
+	}
+	trace := tracer.events["alert-1"]
+	if len(trace) != 1 || trace[0].Event != "rejected" || trace[0].Details["client_hint"] != "client-9" {
+		t.Errorf("alert-1 trace = %+v, want one rejected event with the client hint", trace)
+	}
+
+	// A known client with no matching rule is unmatched, not rejected
+	alert = &events.AlertNew{AlertID: "alert-2", Severity: "LOW", Source: "shared-db", Name: "disk-full", ClientHint: "client-1"}
+	p.processOne(context.Background(), alert)
+	if trace := tracer.events["alert-2"]; len(trace) != 1 || trace[0].Event != "unmatched" {
+		t.Errorf("alert-2 trace = %+v, want one unmatched event", trace)
+	}
+}
+
+// fakeSampler records published evaluation samples.
+type fakeSampler struct {
+	samples []*events.EvaluationSample
+}
+
+func (f *fakeSampler) PublishSample(ctx context.Context, sample *events.EvaluationSample) error {
+	f.samples = append(f.samples, sample)
+	return nil
+}
+
+func TestProcessor_SamplesEvaluations(t *testing.T) {
+	snap := &snapshot.Snapshot{
+		BySeverity: map[string][]int{"HIGH": {1}},
+		BySource:   map[string][]int{"service-a": {1}},
+		ByName:     map[string][]int{"disk-full": {1}},
+		Rules:      map[int]snapshot.RuleInfo{1: {RuleID: "rule-1", ClientID: "client-1"}},
+	}
+	sampler := &fakeSampler{}
+	mock := newMockCollector()
+	p := NewProcessor(nil, nil, matcher.NewMatcher(indexes.NewIndexes(snap))).WithSampling(sampler, 1)
+	p.metrics = wrapMetrics(mock)
+
+	// Every non-match is sampled with its candidates
+	p.processOne(context.Background(), &events.AlertNew{AlertID: "alert-1", Severity: "HIGH", Source: "service-b", Name: "disk-full"})
+	if len(sampler.samples) != 1 {
+		t.Fatalf("published %d samples, want 1", len(sampler.samples))
+	}
+	sample := sampler.samples[0]
+	if sample.AlertID != "alert-1" || sample.Matched || sample.SampleRate != 1 || sample.RuleCount != 1 {
+		t.Errorf("sample = %+v, want an unmatched sample of alert-1", sample)
+	}
+	if len(sample.Candidates.Severity) != 1 || len(sample.Candidates.Source) != 0 {
+		t.Errorf("candidates = %+v, want rule-1 by severity only", sample.Candidates)
+	}
+	if mock.customCounts["evaluation_samples_published"] != 1 {
+		t.Errorf("evaluation_samples_published = %d, want 1", mock.customCounts["evaluation_samples_published"])
+	}
+
+	// Sampling is deterministic by alert_id: 1 in N of many alerts, the same ones every time
+	p.WithSampling(sampler, 10)
+	sampled := 0
+	for i := 0; i < 1000; i++ {
+		alertID := fmt.Sprintf("alert-%d", i)
+		if p.sampled(alertID) {
+			sampled++
+		}
+		if p.sampled(alertID) != p.sampled(alertID) {
+			t.Fatalf("sampled(%s) is not deterministic", alertID)
+		}
+	}
+	if sampled < 50 || sampled > 150 {
+		t.Errorf("sampled %d of 1000 alerts at 1 in 10, want about 100", sampled)
+	}
+
+	if NewProcessor(nil, nil, nil).WithSampling(sampler, 0).sampled("alert-1") {
+		t.Error("sampled() with sampling disabled returned true")
+	}
+}
+
+// fakeUnmatchedPublisher records published unmatched alerts.
+type fakeUnmatchedPublisher struct {
+	alerts []*events.UnmatchedAlert
+	err    error
+}
+
+func (f *fakeUnmatchedPublisher) PublishUnmatched(ctx context.Context, alert *events.UnmatchedAlert) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.alerts = append(f.alerts, alert)
+	return nil
+}
+
+func TestProcessor_UnmatchedPolicy(t *testing.T) {
+	snap := &snapshot.Snapshot{
+		BySeverity: map[string][]int{"HIGH": {1}},
+		BySource:   map[string][]int{"service-a": {1}},
+		ByName:     map[string][]int{"disk-full": {1}},
+		Rules:      map[int]snapshot.RuleInfo{1: {RuleID: "rule-1", ClientID: "client-1"}},
+	}
+	unmatched := &events.AlertNew{AlertID: "alert-1", EventTS: 1700000000, Severity: "LOW", Source: "service-b", Name: "cpu", ClientHint: "client-1"}
+
+	newProcessor := func(policy string, publisher UnmatchedPublisher) (*Processor, *mockCollector) {
+		mock := newMockCollector()
+		p := NewProcessor(nil, nil, matcher.NewMatcher(indexes.NewIndexes(snap))).WithUnmatchedPolicy(policy, publisher)
+		p.metrics = wrapMetrics(mock)
+		return p, mock
+	}
+
+	t.Run("drop", func(t *testing.T) {
+		p, mock := newProcessor(events.UnmatchedDrop, nil)
+		result := p.processOne(context.Background(), unmatched)
+		if !result.allPublishesSucceeded || mock.customCounts["alerts_unmatched"] != 1 {
+			t.Errorf("processOne() = %+v, alerts_unmatched = %d, want one committed unmatched alert", result, mock.customCounts["alerts_unmatched"])
+		}
+		if len(mock.customCounts) != 2 { // alerts_client_hinted and alerts_unmatched
+			t.Errorf("custom counters = %v, want only alerts_client_hinted and alerts_unmatched", mock.customCounts)
+		}
+	})
+
+	t.Run("publish", func(t *testing.T) {
+		publisher := &fakeUnmatchedPublisher{}
+		p, mock := newProcessor(events.UnmatchedPublish, publisher)
+		p.processOne(context.Background(), unmatched)
+
+		if len(publisher.alerts) != 1 {
+			t.Fatalf("published %d unmatched alerts, want 1", len(publisher.alerts))
+		}
+		got := publisher.alerts[0]
+		if got.AlertID != "alert-1" || got.Source != "service-b" || got.ClientHint != "client-1" || got.RuleCount != 1 || got.EvaluatedAt == 0 {
+			t.Errorf("unmatched alert = %+v, want alert-1 with the rule count and evaluation time", got)
+		}
+		if mock.customCounts["alerts_unmatched_published"] != 1 {
+			t.Errorf("alerts_unmatched_published = %d, want 1", mock.customCounts["alerts_unmatched_published"])
+		}
+
+		publisher.err = errors.New("queue full")
+		if result := p.processOne(context.Background(), unmatched); !result.allPublishesSucceeded {
+			t.Error("a failed unmatched publish blocked the offset commit")
+		}
+		if mock.customCounts["alerts_unmatched_publish_failed"] != 1 {
+			t.Errorf("alerts_unmatched_publish_failed = %d, want 1", mock.customCounts["alerts_unmatched_publish_failed"])
+		}
+	})
+
+	t.Run("count", func(t *testing.T) {
+		p, mock := newProcessor(events.UnmatchedCount, nil)
+		p.processOne(context.Background(), unmatched)
+		p.processOne(context.Background(), unmatched)
+		if mock.customCounts["alerts_unmatched_severity_LOW"] != 2 || mock.customCounts["alerts_unmatched_source_service-b"] != 2 {
+			t.Errorf("custom counters = %v, want 2 unmatched LOW alerts from service-b", mock.customCounts)
+		}
+
+		// Sources beyond the cap share one counter
+		for i := 0; i < maxUnmatchedSources+5; i++ {
+			p.processOne(context.Background(), &events.AlertNew{AlertID: "alert-x", Severity: "LOW", Source: fmt.Sprintf("source-%d", i), Name: "cpu"})
+		}
+		if got := mock.customCounts["alerts_unmatched_source_other"]; got != 6 {
+			t.Errorf("alerts_unmatched_source_other = %d, want 6", got)
+		}
+	})
+}
@@ -4,26 +4,99 @@ package processor
 import (
 	"context"
 	"log/slog"
+	"time"
 
-	"evaluator/internal/reloader"
-	"evaluator/internal/ruleconsumer"
+	"evaluator/internal/events"
+	"evaluator/internal/snapshot"
+
+	"github.com/afikmenashe/alerting-platform/pkg/metrics"
+)
+
+// Rule change actions carried by rule.changed events.
+const (
+	actionCreated  = "CREATED"
+	actionUpdated  = "UPDATED"
+	actionDeleted  = "DELETED"
+	actionDisabled = "DISABLED"
+	// actionRebuild carries no rule: many rules changed at once (a bulk import).
+	actionRebuild = "REBUILD"
 )
 
-// RuleHandler handles rule.changed events and triggers immediate reloads.
+// RuleChangeReader reads rule.changed events.
+type RuleChangeReader interface {
+	ReadMessage(ctx context.Context) (*events.RuleChanged, error)
+}
+
+// IndexReloader rebuilds the rule indexes from the Redis snapshot.
+type IndexReloader interface {
+	ReloadNow(ctx context.Context) error
+}
+
+// RuleIndex applies single-rule changes to the in-memory indexes.
+type RuleIndex interface {
+	PutRule(ruleID, clientID, severity, source, name string, excludeSources, excludeNames []string, conditions []snapshot.ContextCondition)
+	RemoveRule(ruleID string) bool
+}
+
+// PinState reports whether the indexes are pinned to a kept snapshot version.
+// It is implemented by reloader.Reloader.
+type PinState interface {
+	Pinned() bool
+}
+
+// PropagationRecorder records when a rule change reached a pipeline stage.
+type PropagationRecorder interface {
+	RecordRulePropagation(ctx context.Context, stage, ruleID string, publishedAt, appliedAt time.Time) error
+}
+
+// RuleHandler handles rule.changed events.
+// Events carrying the rule payload are applied to the in-memory indexes directly; a full
+// reload from the Redis snapshot is only triggered when a rule's version skips ahead
+// (an event was missed), the event has no payload, or it announces a rebuild.
 type RuleHandler struct {
-	consumer *ruleconsumer.Consumer
-	reload   *reloader.Reloader
+	consumer RuleChangeReader
+	reload   IndexReloader
+	index    RuleIndex
+	metrics  Metrics
+	// versions holds the last applied version per rule_id, used to drop stale events and detect gaps.
+	versions map[string]int
+	// propagation records how long changes take to reach the indexes (nil disables recording).
+	propagation PropagationRecorder
+	// pins reports whether the indexes are pinned (nil means never pinned).
+	pins PinState
 }
 
 // NewRuleHandler creates a new rule change handler.
-func NewRuleHandler(consumer *ruleconsumer.Consumer, reload *reloader.Reloader) *RuleHandler {
+func NewRuleHandler(consumer RuleChangeReader, reload IndexReloader, index RuleIndex) *RuleHandler {
 	return &RuleHandler{
 		consumer: consumer,
 		reload:   reload,
+		index:    index,
+		metrics:  NoOpMetrics{},
+		versions: make(map[string]int),
 	}
 }
 
-// HandleRuleChanged consumes rule.changed events and triggers immediate reloads.
+// WithMetrics records how rule.changed events were handled (applied, skipped, or reloaded).
+func (h *RuleHandler) WithMetrics(m metricsCollector) *RuleHandler {
+	h.metrics = wrapMetrics(m)
+	return h
+}
+
+// WithPropagation records the delay between rule-service publishing a change and the indexes applying it.
+func (h *RuleHandler) WithPropagation(r PropagationRecorder) *RuleHandler {
+	h.propagation = r
+	return h
+}
+
+// WithPinState skips rule.changed events while the indexes are pinned to a kept snapshot
+// version. Clearing the pin reloads the current snapshot, which has the skipped changes.
+func (h *RuleHandler) WithPinState(p PinState) *RuleHandler {
+	h.pins = p
+	return h
+}
+
+// HandleRuleChanged consumes rule.changed events and applies them to the indexes.
 func (h *RuleHandler) HandleRuleChanged(ctx context.Context) {
 	slog.Info("Starting rule.changed event handler")
 
@@ -51,21 +124,129 @@ func (h *RuleHandler) HandleRuleChanged(ctx context.Context) {
 				"version", ruleChanged.Version,
 			)
 
-			// Trigger immediate reload from Redis snapshot
-			// The rule-updater should have already updated the snapshot
-			if err := h.reload.ReloadNow(ctx); err != nil {
-				slog.Error("Failed to reload rules after rule.changed event",
-					"rule_id", ruleChanged.RuleID,
-					"action", ruleChanged.Action,
-					"error", err,
-				)
-				// Continue - polling will catch up eventually
-			} else {
-				slog.Info("Rules reloaded after rule.changed event",
-					"rule_id", ruleChanged.RuleID,
-					"action", ruleChanged.Action,
-				)
+			h.ApplyRuleChanged(ctx, ruleChanged)
+		}
+	}
+}
+
+// ApplyRuleChanged applies a single rule.changed event.
+func (h *RuleHandler) ApplyRuleChanged(ctx context.Context, ruleChanged *events.RuleChanged) {
+	if h.pins != nil && h.pins.Pinned() {
+		slog.Info("Rule snapshot pinned, skipping rule.changed event",
+			"rule_id", ruleChanged.RuleID,
+			"version", ruleChanged.Version,
+		)
+		h.metrics.IncrementCustom("rule_changes_pinned")
+		return
+	}
+
+	// The evaluator's slow-rule detector disables rules without knowing their version. Such
+	// removals are always applied and leave the version alone, so the rule's next update is
+	// applied as usual and puts it back.
+	if ruleChanged.Action == actionDisabled && ruleChanged.Version == 0 {
+		h.index.RemoveRule(ruleChanged.RuleID)
+		h.metrics.IncrementCustom("rule_changes_applied")
+		h.recordPropagation(ctx, ruleChanged)
+		slog.Info("Applied unversioned rule disable to indexes", "rule_id", ruleChanged.RuleID)
+		return
+	}
+
+	if ruleChanged.Action == actionRebuild {
+		h.reloadAll(ctx, ruleChanged, "rebuild")
+		return
+	}
+
+	lastVersion, seen := h.versions[ruleChanged.RuleID]
+	if seen && isStaleRuleChange(ruleChanged, lastVersion) {
+		slog.Debug("Skipping stale rule.changed event",
+			"rule_id", ruleChanged.RuleID,
+			"version", ruleChanged.Version,
+			"last_version", lastVersion,
+		)
+		h.metrics.IncrementCustom("rule_changes_skipped")
+		return
+	}
+
+	switch {
+	case seen && ruleChanged.Version > lastVersion+1:
+		h.reloadAll(ctx, ruleChanged, "version gap")
+		return
+	case ruleChanged.Rule == nil && ruleChanged.Action != actionDeleted:
+		h.reloadAll(ctx, ruleChanged, "missing rule payload")
+		return
+	}
+
+	switch ruleChanged.Action {
+	case actionCreated, actionUpdated:
+		if ruleChanged.Rule.Enabled {
+			rule := ruleChanged.Rule
+			var conditions []snapshot.ContextCondition
+			for _, c := range rule.Conditions {
+				conditions = append(conditions, snapshot.ContextCondition{Key: c.Key, Op: c.Op, Value: c.Value})
 			}
+			h.index.PutRule(ruleChanged.RuleID, ruleChanged.ClientID, rule.Severity, rule.Source, rule.Name, rule.ExcludeSources, rule.ExcludeNames, conditions)
+		} else {
+			h.index.RemoveRule(ruleChanged.RuleID)
 		}
+	case actionDeleted, actionDisabled:
+		h.index.RemoveRule(ruleChanged.RuleID)
+	default:
+		h.reloadAll(ctx, ruleChanged, "unknown action")
+		return
+	}
+
+	h.versions[ruleChanged.RuleID] = ruleChanged.Version
+	h.metrics.IncrementCustom("rule_changes_applied")
+	h.recordPropagation(ctx, ruleChanged)
+	slog.Info("Applied rule.changed event to indexes",
+		"rule_id", ruleChanged.RuleID,
+		"action", ruleChanged.Action,
+		"version", ruleChanged.Version,
+	)
+}
+
+// isStaleRuleChange reports whether an event is older than, or a redelivery of, the last
+// applied change. Deletions keep the rule's last version, so they are only stale when older.
+func isStaleRuleChange(ruleChanged *events.RuleChanged, lastVersion int) bool {
+	if ruleChanged.Action == actionDeleted {
+		return ruleChanged.Version < lastVersion
+	}
+	return ruleChanged.Version <= lastVersion
+}
+
+// reloadAll falls back to a full reload from the Redis snapshot.
+func (h *RuleHandler) reloadAll(ctx context.Context, ruleChanged *events.RuleChanged, reason string) {
+	h.metrics.IncrementCustom("rule_change_reloads")
+	slog.Info("Reloading rules after rule.changed event",
+		"rule_id", ruleChanged.RuleID,
+		"action", ruleChanged.Action,
+		"version", ruleChanged.Version,
+		"reason", reason,
+	)
+
+	// The rule-updater should have already updated the snapshot; if not, polling will catch up
+	if err := h.reload.ReloadNow(ctx); err != nil {
+		slog.Error("Failed to reload rules after rule.changed event",
+			"rule_id", ruleChanged.RuleID,
+			"action", ruleChanged.Action,
+			"error", err,
+		)
+		return
+	}
+	if ruleChanged.RuleID != "" {
+		h.versions[ruleChanged.RuleID] = ruleChanged.Version
+	}
+	h.recordPropagation(ctx, ruleChanged)
+}
+
+// recordPropagation records the change reaching the indexes. Events from producers that
+// predate the publish timestamp, and rebuild events, which have no rule, are skipped.
+func (h *RuleHandler) recordPropagation(ctx context.Context, ruleChanged *events.RuleChanged) {
+	if h.propagation == nil || ruleChanged.PublishedAtMs <= 0 || ruleChanged.RuleID == "" {
+		return
+	}
+	publishedAt := time.UnixMilli(ruleChanged.PublishedAtMs)
+	if err := h.propagation.RecordRulePropagation(ctx, metrics.PropagationStageEvaluator, ruleChanged.RuleID, publishedAt, time.Now()); err != nil {
+		slog.Debug("Failed to record rule propagation", "rule_id", ruleChanged.RuleID, "error", err)
 	}
 }
@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"evaluator/internal/events"
 	"evaluator/internal/indexes"
 	"evaluator/internal/matcher"
 	"evaluator/internal/reloader"
@@ -45,11 +46,209 @@ func TestNewRuleHandler(t *testing.T) {
 	m := matcher.NewMatcher(idx)
 	reload := reloader.NewReloader(loader, m, 5*time.Second)
 
-	handler := NewRuleHandler(consumer, reload)
+	handler := NewRuleHandler(consumer, reload, m)
 	if handler == nil {
 		t.Fatal("NewRuleHandler() returned nil")
 	}
 }
 
 // Note: HandleRuleChanged() tests require real Kafka/Redis instances and are better suited for integration tests.
-// The constructor test above validates that NewRuleHandler works correctly.
+// ApplyRuleChanged is covered below with a real matcher and a counting reloader.
+
+type countingReloader struct {
+	calls int
+}
+
+func (r *countingReloader) ReloadNow(ctx context.Context) error {
+	r.calls++
+	return nil
+}
+
+func newTestRuleHandler() (*RuleHandler, *matcher.Matcher, *countingReloader, *mockCollector) {
+	m := matcher.NewMatcher(indexes.NewIndexes(&snapshot.Snapshot{}))
+	reload := &countingReloader{}
+	collector := newMockCollector()
+	return NewRuleHandler(nil, reload, m).WithMetrics(collector), m, reload, collector
+}
+
+func ruleEvent(action string, version int, payload *events.RulePayload) *events.RuleChanged {
+	return &events.RuleChanged{RuleID: "rule-1", ClientID: "client-1", Action: action, Version: version, Rule: payload}
+}
+
+func TestRuleHandler_AppliesChangesWithoutReload(t *testing.T) {
+	h, m, reload, collector := newTestRuleHandler()
+	ctx := context.Background()
+
+	h.ApplyRuleChanged(ctx, ruleEvent("CREATED", 1, &events.RulePayload{Severity: "HIGH", Source: "api", Name: "cpu", Enabled: true}))
+	if got := m.Match("HIGH", "api", "cpu", nil); len(got["client-1"]) != 1 {
+		t.Fatalf("after CREATED Match() = %v, want rule-1 for client-1", got)
+	}
+
+	h.ApplyRuleChanged(ctx, ruleEvent("UPDATED", 2, &events.RulePayload{Severity: "LOW", Source: "api", Name: "cpu", Enabled: true}))
+	if got := m.Match("HIGH", "api", "cpu", nil); len(got) != 0 {
+		t.Errorf("after UPDATED old fields still match: %v", got)
+	}
+	if got := m.Match("LOW", "api", "cpu", nil); len(got["client-1"]) != 1 {
+		t.Errorf("after UPDATED Match() = %v, want rule-1 for client-1", got)
+	}
+
+	h.ApplyRuleChanged(ctx, ruleEvent("DISABLED", 3, &events.RulePayload{Severity: "LOW", Source: "api", Name: "cpu"}))
+	if m.RuleCount() != 0 {
+		t.Errorf("after DISABLED RuleCount() = %d, want 0", m.RuleCount())
+	}
+
+	h.ApplyRuleChanged(ctx, ruleEvent("UPDATED", 4, &events.RulePayload{Severity: "LOW", Source: "api", Name: "cpu", Enabled: true}))
+	h.ApplyRuleChanged(ctx, ruleEvent("DELETED", 4, nil))
+	if m.RuleCount() != 0 {
+		t.Errorf("after DELETED RuleCount() = %d, want 0", m.RuleCount())
+	}
+
+	if reload.calls != 0 {
+		t.Errorf("ReloadNow() called %d times, want 0", reload.calls)
+	}
+	if collector.customCounts["rule_changes_applied"] != 5 {
+		t.Errorf("rule_changes_applied = %d, want 5", collector.customCounts["rule_changes_applied"])
+	}
+}
+
+func TestRuleHandler_SkipsStaleEvents(t *testing.T) {
+	h, m, reload, collector := newTestRuleHandler()
+	ctx := context.Background()
+
+	h.ApplyRuleChanged(ctx, ruleEvent("UPDATED", 2, &events.RulePayload{Severity: "LOW", Source: "api", Name: "cpu", Enabled: true}))
+	// Redelivery of an older event must not roll the rule back
+	h.ApplyRuleChanged(ctx, ruleEvent("CREATED", 1, &events.RulePayload{Severity: "HIGH", Source: "api", Name: "cpu", Enabled: true}))
+	h.ApplyRuleChanged(ctx, ruleEvent("UPDATED", 2, &events.RulePayload{Severity: "HIGH", Source: "api", Name: "cpu", Enabled: true}))
+
+	if got := m.Match("LOW", "api", "cpu", nil); len(got["client-1"]) != 1 {
+		t.Errorf("Match() = %v, want rule-1 to keep its version 2 fields", got)
+	}
+	if reload.calls != 0 {
+		t.Errorf("ReloadNow() called %d times, want 0", reload.calls)
+	}
+	if collector.customCounts["rule_changes_skipped"] != 2 {
+		t.Errorf("rule_changes_skipped = %d, want 2", collector.customCounts["rule_changes_skipped"])
+	}
+}
+
+func TestRuleHandler_AppliesUnversionedDisable(t *testing.T) {
+	h, m, reload, _ := newTestRuleHandler()
+	ctx := context.Background()
+
+	h.ApplyRuleChanged(ctx, ruleEvent("UPDATED", 3, &events.RulePayload{Severity: "LOW", Source: "api", Name: "cpu", Enabled: true}))
+	// Slow-rule disables carry no version and no payload
+	h.ApplyRuleChanged(ctx, ruleEvent("DISABLED", 0, nil))
+	if m.RuleCount() != 0 {
+		t.Errorf("after unversioned DISABLED RuleCount() = %d, want 0", m.RuleCount())
+	}
+
+	// The rule's next update puts it back without a version gap reload
+	h.ApplyRuleChanged(ctx, ruleEvent("UPDATED", 4, &events.RulePayload{Severity: "LOW", Source: "api", Name: "cpu", Enabled: true}))
+	if m.RuleCount() != 1 {
+		t.Errorf("after UPDATED RuleCount() = %d, want 1", m.RuleCount())
+	}
+	if reload.calls != 0 {
+		t.Errorf("ReloadNow() called %d times, want 0", reload.calls)
+	}
+}
+
+func TestRuleHandler_ReloadsOnVersionGap(t *testing.T) {
+	h, _, reload, collector := newTestRuleHandler()
+	ctx := context.Background()
+
+	h.ApplyRuleChanged(ctx, ruleEvent("CREATED", 1, &events.RulePayload{Severity: "HIGH", Source: "api", Name: "cpu", Enabled: true}))
+	h.ApplyRuleChanged(ctx, ruleEvent("UPDATED", 3, &events.RulePayload{Severity: "LOW", Source: "api", Name: "cpu", Enabled: true}))
+	if reload.calls != 1 {
+		t.Fatalf("ReloadNow() called %d times after version gap, want 1", reload.calls)
+	}
+
+	// The reload catches the handler up, so the next consecutive version applies directly
+	h.ApplyRuleChanged(ctx, ruleEvent("UPDATED", 4, &events.RulePayload{Severity: "LOW", Source: "api", Name: "cpu", Enabled: true}))
+	if reload.calls != 1 {
+		t.Errorf("ReloadNow() called %d times, want 1", reload.calls)
+	}
+	if collector.customCounts["rule_change_reloads"] != 1 {
+		t.Errorf("rule_change_reloads = %d, want 1", collector.customCounts["rule_change_reloads"])
+	}
+}
+
+type recordedPropagation struct {
+	stage, ruleID string
+	latency       time.Duration
+}
+
+type fakePropagation struct {
+	recorded []recordedPropagation
+}
+
+func (f *fakePropagation) RecordRulePropagation(ctx context.Context, stage, ruleID string, publishedAt, appliedAt time.Time) error {
+	f.recorded = append(f.recorded, recordedPropagation{stage: stage, ruleID: ruleID, latency: appliedAt.Sub(publishedAt)})
+	return nil
+}
+
+func TestRuleHandler_RecordsPropagation(t *testing.T) {
+	h, _, _, _ := newTestRuleHandler()
+	propagation := &fakePropagation{}
+	h.WithPropagation(propagation)
+	ctx := context.Background()
+
+	created := ruleEvent("CREATED", 1, &events.RulePayload{Severity: "HIGH", Source: "api", Name: "cpu", Enabled: true})
+	created.PublishedAtMs = time.Now().Add(-time.Second).UnixMilli()
+	h.ApplyRuleChanged(ctx, created)
+	// Stale events are not applied, so they are not recorded
+	h.ApplyRuleChanged(ctx, created)
+	// Events without a publish timestamp are not recorded
+	h.ApplyRuleChanged(ctx, ruleEvent("UPDATED", 2, &events.RulePayload{Severity: "LOW", Source: "api", Name: "cpu", Enabled: true}))
+
+	if len(propagation.recorded) != 1 {
+		t.Fatalf("recorded %d propagations, want 1: %+v", len(propagation.recorded), propagation.recorded)
+	}
+	got := propagation.recorded[0]
+	if got.stage != "evaluator" || got.ruleID != "rule-1" || got.latency < time.Second {
+		t.Errorf("recorded propagation = %+v, want evaluator stage for rule-1 with at least 1s latency", got)
+	}
+}
+
+func TestRuleHandler_ReloadsWithoutPayload(t *testing.T) {
+	h, _, reload, _ := newTestRuleHandler()
+
+	h.ApplyRuleChanged(context.Background(), ruleEvent("UPDATED", 2, nil))
+	if reload.calls != 1 {
+		t.Errorf("ReloadNow() called %d times for event without payload, want 1", reload.calls)
+	}
+}
+
+func TestRuleHandler_ReloadsOnRebuild(t *testing.T) {
+	h, _, reload, _ := newTestRuleHandler()
+
+	h.ApplyRuleChanged(context.Background(), &events.RuleChanged{ClientID: "client-1", Action: "REBUILD"})
+	if reload.calls != 1 {
+		t.Errorf("ReloadNow() called %d times for rebuild event, want 1", reload.calls)
+	}
+	if _, ok := h.versions[""]; ok {
+		t.Error("rebuild event recorded a version for an empty rule_id")
+	}
+}
+
+// fixedPin reports a fixed pin state.
+type fixedPin bool
+
+func (p fixedPin) Pinned() bool { return bool(p) }
+
+func TestRuleHandler_SkipsChangesWhilePinned(t *testing.T) {
+	h, m, reload, collector := newTestRuleHandler()
+	h.WithPinState(fixedPin(true))
+	ctx := context.Background()
+
+	h.ApplyRuleChanged(ctx, ruleEvent("CREATED", 1, &events.RulePayload{Severity: "HIGH", Source: "api", Name: "cpu", Enabled: true}))
+
+	if m.RuleCount() != 0 {
+		t.Errorf("RuleCount() = %d, want 0 (pinned indexes are not changed)", m.RuleCount())
+	}
+	if reload.calls != 0 {
+		t.Errorf("ReloadNow() called %d times, want 0", reload.calls)
+	}
+	if collector.customCounts["rule_changes_pinned"] != 1 {
+		t.Errorf("rule_changes_pinned = %d, want 1", collector.customCounts["rule_changes_pinned"])
+	}
+}
@@ -0,0 +1,72 @@
+package processor
+
+import (
+	"context"
+	"hash/fnv"
+	"log/slog"
+	"time"
+
+	"evaluator/internal/events"
+)
+
+// SamplePublisher publishes sampled evaluation results to the debug.evaluations topic.
+// It is implemented by producer.SampleProducer.
+type SamplePublisher interface {
+	PublishSample(ctx context.Context, sample *events.EvaluationSample) error
+}
+
+// WithSampling publishes a copy of one in every `every` evaluation results, matches and
+// non-matches alike, with the candidate sets considered. Sampling is by alert_id, so a
+// redelivered alert is sampled again and every evaluator instance samples the same alerts.
+// A non-positive every disables sampling. Samples never affect alerts.matched or commits.
+func (p *Processor) WithSampling(s SamplePublisher, every int) *Processor {
+	p.sampler = s
+	p.sampleEvery = every
+	return p
+}
+
+// sampled reports whether the alert's evaluation is sampled.
+func (p *Processor) sampled(alertID string) bool {
+	if p.sampler == nil || p.sampleEvery <= 0 {
+		return false
+	}
+	h := fnv.New32a()
+	h.Write([]byte(alertID))
+	return h.Sum32()%uint32(p.sampleEvery) == 0
+}
+
+// sample publishes the alert's evaluation if it is sampled. The alert is matched again,
+// collecting the candidate sets, so alerts that are not sampled pay nothing for it.
+// Failures are logged and counted only.
+func (p *Processor) sample(ctx context.Context, alert *events.AlertNew) {
+	if !p.sampled(alert.AlertID) {
+		return
+	}
+	evaluation := p.matcher.Explain(alert.ClientHint, alert.Severity, alert.Source, alert.Name, alert.Context)
+	sample := &events.EvaluationSample{
+		AlertID:    alert.AlertID,
+		EventTS:    alert.EventTS,
+		Severity:   alert.Severity,
+		Source:     alert.Source,
+		Name:       alert.Name,
+		Context:    alert.Context,
+		ClientHint: alert.ClientHint,
+		Matched:    len(evaluation.Matches) > 0,
+		Matches:    evaluation.Matches,
+		Candidates: events.SampleCandidates{
+			Severity:    evaluation.SeverityCandidates,
+			Source:      evaluation.SourceCandidates,
+			Name:        evaluation.NameCandidates,
+			Intersected: evaluation.Intersected,
+		},
+		RuleCount:  p.matcher.RuleCount(),
+		SampleRate: p.sampleEvery,
+		SampledAt:  time.Now().UnixMilli(),
+	}
+	if err := p.sampler.PublishSample(ctx, sample); err != nil {
+		slog.Warn("Failed to publish evaluation sample", "alert_id", alert.AlertID, "error", err)
+		p.metrics.IncrementCustom("evaluation_samples_failed")
+		return
+	}
+	p.metrics.IncrementCustom("evaluation_samples_published")
+}
@@ -0,0 +1,154 @@
+package processor
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"evaluator/internal/events"
+	"evaluator/internal/indexes"
+)
+
+// RuleDisabler takes a rule out of matching by publishing a rule.changed DISABLED event.
+// It is implemented by producer.RuleChangedProducer.
+type RuleDisabler interface {
+	DisableRule(ctx context.Context, ruleID, clientID string) error
+}
+
+// maxSlowRules is the number of slowest candidate rules blamed for one slow evaluation.
+const maxSlowRules = 5
+
+// minOffenseShare is the share of the deadline (1/minOffenseShare) a rule's own profiled time
+// must reach to be charged an offense, so cheap rules are never blamed.
+const minOffenseShare = maxSlowRules
+
+// maxTrackedOffenders bounds the slow-rule offenses kept; expired ones are dropped beyond it.
+const maxTrackedOffenders = 10000
+
+// offense counts a rule's slow evaluations since the start of its window.
+type offense struct {
+	count int
+	since time.Time
+}
+
+// WithEvaluationDeadline sets the time matching one alert may take. Matching is never cut
+// short, since that would drop matches; an alert that takes longer is matched again with
+// every candidate rule timed, and the slowest rules are logged and counted as offenders.
+// A non-positive deadline disables the check.
+func (p *Processor) WithEvaluationDeadline(deadline time.Duration) *Processor {
+	p.evalDeadline = deadline
+	return p
+}
+
+// WithSlowRuleDisabler disables rules that are among the slowest of `after` evaluations over
+// the deadline within window (0 = no window). A non-positive after disables auto-disable.
+func (p *Processor) WithSlowRuleDisabler(d RuleDisabler, after int, window time.Duration) *Processor {
+	p.disabler = d
+	p.disableAfter = after
+	p.offenseWindow = window
+	p.offenses = make(map[string]*offense)
+	return p
+}
+
+// checkDeadline reports the slowest rules of an alert whose matching took longer than the
+// evaluation deadline, and disables rules that keep offending, if auto-disable is enabled.
+func (p *Processor) checkDeadline(ctx context.Context, alert *events.AlertNew, elapsed time.Duration) {
+	if p.evalDeadline <= 0 || elapsed <= p.evalDeadline {
+		return
+	}
+	costs := p.matcher.Profile(alert.ClientHint, alert.Severity, alert.Source, alert.Name, alert.Context)
+	p.reportSlowRules(ctx, alert, elapsed, costs)
+}
+
+// reportSlowRules logs the slowest of the profiled rule costs (slowest first) of a slow alert
+// and charges offenses to the rules that made it slow. The elapsed time also covers waiting
+// for the matcher lock during index swaps and GC pauses, so rules are charged only when the
+// profiled times add up to the deadline, and then only the rules that took at least
+// 1/minOffenseShare of it themselves.
+func (p *Processor) reportSlowRules(ctx context.Context, alert *events.AlertNew, elapsed time.Duration, costs []indexes.RuleCost) {
+	p.metrics.IncrementCustom("alerts_slow_evaluations")
+
+	var profiled time.Duration
+	for _, cost := range costs {
+		profiled += cost.Duration
+	}
+	candidates := len(costs)
+	if len(costs) > maxSlowRules {
+		costs = costs[:maxSlowRules]
+	}
+	slowest := make([]string, 0, len(costs))
+	for _, cost := range costs {
+		slowest = append(slowest, cost.RuleID+"="+cost.Duration.String())
+	}
+	slog.Warn("Alert evaluation exceeded deadline",
+		"alert_id", alert.AlertID,
+		"elapsed", elapsed,
+		"profiled", profiled,
+		"deadline", p.evalDeadline,
+		"candidates", candidates,
+		"slowest_rules", slowest,
+	)
+
+	if profiled < p.evalDeadline {
+		// The rules were not the slow part: the time went to lock waits or pauses
+		p.metrics.IncrementCustom("slow_evaluations_unattributed")
+		return
+	}
+	threshold := p.evalDeadline / minOffenseShare
+	for _, cost := range costs {
+		if cost.Duration < threshold {
+			continue
+		}
+		p.metrics.IncrementCustom("slow_rule_offenses")
+		p.recordOffense(ctx, cost)
+	}
+}
+
+// recordOffense counts a slow-rule offense and disables the rule once it has offended
+// disableAfter times within the window.
+func (p *Processor) recordOffense(ctx context.Context, cost indexes.RuleCost) {
+	if p.disabler == nil || p.disableAfter <= 0 {
+		return
+	}
+	now := time.Now()
+	o := p.offenses[cost.RuleID]
+	if o == nil || p.expired(o, now) {
+		if len(p.offenses) >= maxTrackedOffenders {
+			p.pruneOffenses(now)
+		}
+		o = &offense{since: now}
+		p.offenses[cost.RuleID] = o
+	}
+	o.count++
+	if o.count < p.disableAfter {
+		return
+	}
+
+	delete(p.offenses, cost.RuleID)
+	if err := p.disabler.DisableRule(ctx, cost.RuleID, cost.ClientID); err != nil {
+		slog.Error("Failed to disable slow rule", "rule_id", cost.RuleID, "client_id", cost.ClientID, "error", err)
+		p.metrics.IncrementCustom("slow_rules_disable_failed")
+		return
+	}
+	slog.Warn("Disabled slow rule",
+		"rule_id", cost.RuleID,
+		"client_id", cost.ClientID,
+		"offenses", o.count,
+		"window", p.offenseWindow,
+	)
+	p.metrics.IncrementCustom("slow_rules_disabled")
+}
+
+// expired reports whether an offense's window has passed.
+func (p *Processor) expired(o *offense, now time.Time) bool {
+	return p.offenseWindow > 0 && now.Sub(o.since) > p.offenseWindow
+}
+
+// pruneOffenses drops offenses whose window has passed.
+func (p *Processor) pruneOffenses(now time.Time) {
+	for ruleID, o := range p.offenses {
+		if p.expired(o, now) {
+			delete(p.offenses, ruleID)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"evaluator/internal/events"
+	"evaluator/internal/indexes"
+	"evaluator/internal/matcher"
+	"evaluator/internal/snapshot"
+)
+
+// fakeDisabler records disabled rules.
+type fakeDisabler struct {
+	disabled []string
+	err      error
+}
+
+func (f *fakeDisabler) DisableRule(ctx context.Context, ruleID, clientID string) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.disabled = append(f.disabled, clientID+"/"+ruleID)
+	return nil
+}
+
+func newSlowRuleProcessor() (*Processor, *mockCollector) {
+	snap := &snapshot.Snapshot{
+		BySeverity: map[string][]int{"HIGH": {1}},
+		BySource:   map[string][]int{"service-a": {1}},
+		ByName:     map[string][]int{"disk-full": {1}},
+		Rules:      map[int]snapshot.RuleInfo{1: {RuleID: "rule-1", ClientID: "client-1"}},
+	}
+	mock := newMockCollector()
+	p := NewProcessor(nil, nil, matcher.NewMatcher(indexes.NewIndexes(snap)))
+	p.metrics = wrapMetrics(mock)
+	return p, mock
+}
+
+func TestProcessor_EvaluationDeadline(t *testing.T) {
+	alert := &events.AlertNew{AlertID: "alert-1", Severity: "HIGH", Source: "service-a", Name: "disk-full"}
+
+	// Disabled by default
+	p, mock := newSlowRuleProcessor()
+	p.checkDeadline(context.Background(), alert, time.Hour)
+	if mock.customCounts["alerts_slow_evaluations"] != 0 {
+		t.Errorf("alerts_slow_evaluations = %d without a deadline, want 0", mock.customCounts["alerts_slow_evaluations"])
+	}
+
+	p.WithEvaluationDeadline(10 * time.Millisecond)
+	p.checkDeadline(context.Background(), alert, time.Millisecond)
+	if mock.customCounts["alerts_slow_evaluations"] != 0 {
+		t.Errorf("alerts_slow_evaluations = %d within the deadline, want 0", mock.customCounts["alerts_slow_evaluations"])
+	}
+
+	// Over the deadline the slow rules are blamed, but nothing is disabled without a disabler
+	p.reportSlowRules(context.Background(), alert, 20*time.Millisecond, []indexes.RuleCost{
+		{RuleID: "rule-1", ClientID: "client-1", Duration: 15 * time.Millisecond},
+	})
+	if mock.customCounts["alerts_slow_evaluations"] != 1 || mock.customCounts["slow_rule_offenses"] != 1 {
+		t.Errorf("counts = %v, want one slow evaluation blaming rule-1", mock.customCounts)
+	}
+	if len(p.offenses) != 0 {
+		t.Errorf("offenses = %v, want none tracked without a disabler", p.offenses)
+	}
+}
+
+func TestProcessor_CheapRulesNotBlamed(t *testing.T) {
+	ctx := context.Background()
+	alert := &events.AlertNew{AlertID: "alert-1", Severity: "HIGH", Source: "service-a", Name: "disk-full"}
+	disabler := &fakeDisabler{}
+	p, mock := newSlowRuleProcessor()
+	p.WithEvaluationDeadline(10*time.Millisecond).WithSlowRuleDisabler(disabler, 1, time.Minute)
+
+	// The deadline is exceeded (e.g. a lock wait during an index swap), but rule-1 is cheap
+	p.checkDeadline(ctx, alert, time.Second)
+	if mock.customCounts["alerts_slow_evaluations"] != 1 || mock.customCounts["slow_evaluations_unattributed"] != 1 {
+		t.Errorf("counts = %v, want one unattributed slow evaluation", mock.customCounts)
+	}
+
+	// The profiled times reach the deadline, but only the rule that took a share of it is charged
+	p.reportSlowRules(ctx, alert, time.Second, []indexes.RuleCost{
+		{RuleID: "rule-slow", ClientID: "client-1", Duration: 9 * time.Millisecond},
+		{RuleID: "rule-2", ClientID: "client-1", Duration: time.Millisecond},
+		{RuleID: "rule-3", ClientID: "client-1", Duration: time.Millisecond},
+	})
+	if mock.customCounts["slow_rule_offenses"] != 1 {
+		t.Errorf("slow_rule_offenses = %d, want 1", mock.customCounts["slow_rule_offenses"])
+	}
+	if len(disabler.disabled) != 1 || disabler.disabled[0] != "client-1/rule-slow" {
+		t.Errorf("disabled = %v, want only client-1/rule-slow", disabler.disabled)
+	}
+}
+
+func TestProcessor_DisablesRepeatedSlowRules(t *testing.T) {
+	ctx := context.Background()
+	alert := &events.AlertNew{AlertID: "alert-1", Severity: "HIGH", Source: "service-a", Name: "disk-full"}
+	disabler := &fakeDisabler{}
+	p, mock := newSlowRuleProcessor()
+	p.WithEvaluationDeadline(time.Millisecond).WithSlowRuleDisabler(disabler, 3, time.Minute)
+	slow := []indexes.RuleCost{{RuleID: "rule-1", ClientID: "client-1", Duration: time.Second}}
+
+	p.reportSlowRules(ctx, alert, time.Second, slow)
+	p.reportSlowRules(ctx, alert, time.Second, slow)
+	if len(disabler.disabled) != 0 {
+		t.Fatalf("disabled %v after 2 offenses, want none", disabler.disabled)
+	}
+
+	// Offenses outside the window start over
+	p.offenses["rule-1"].since = time.Now().Add(-2 * time.Minute)
+	p.reportSlowRules(ctx, alert, time.Second, slow)
+	p.reportSlowRules(ctx, alert, time.Second, slow)
+	if len(disabler.disabled) != 0 {
+		t.Fatalf("disabled %v after expired offenses, want none", disabler.disabled)
+	}
+
+	p.reportSlowRules(ctx, alert, time.Second, slow)
+	if len(disabler.disabled) != 1 || disabler.disabled[0] != "client-1/rule-1" {
+		t.Fatalf("disabled = %v, want client-1/rule-1", disabler.disabled)
+	}
+	if mock.customCounts["slow_rules_disabled"] != 1 || len(p.offenses) != 0 {
+		t.Errorf("slow_rules_disabled = %d with offenses %v, want 1 and none", mock.customCounts["slow_rules_disabled"], p.offenses)
+	}
+
+	// Failures are counted
+	disabler.err = errors.New("kafka down")
+	for i := 0; i < 3; i++ {
+		p.reportSlowRules(ctx, alert, time.Second, slow)
+	}
+	if mock.customCounts["slow_rules_disable_failed"] != 1 {
+		t.Errorf("slow_rules_disable_failed = %d, want 1", mock.customCounts["slow_rules_disable_failed"])
+	}
+}
@@ -0,0 +1,76 @@
+package processor
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"evaluator/internal/events"
+)
+
+// UnmatchedPublisher publishes alerts that matched no rule to the alerts.unmatched topic.
+// It is implemented by producer.UnmatchedProducer.
+type UnmatchedPublisher interface {
+	PublishUnmatched(ctx context.Context, alert *events.UnmatchedAlert) error
+}
+
+// maxUnmatchedSources bounds the sources counted separately by the count policy, so a producer
+// sending random sources cannot create unbounded metrics; later sources are counted as "other".
+const maxUnmatchedSources = 100
+
+// unmatchedOtherSource is the metric suffix of the sources beyond maxUnmatchedSources.
+const unmatchedOtherSource = "other"
+
+// WithUnmatchedPolicy selects what happens to alerts that match no rule, besides counting them
+// in alerts_unmatched: events.UnmatchedDrop discards them, events.UnmatchedPublish publishes
+// them with publisher, and events.UnmatchedCount counts them per severity and source.
+// Unmatched alerts never affect offset commits.
+func (p *Processor) WithUnmatchedPolicy(policy string, publisher UnmatchedPublisher) *Processor {
+	p.unmatchedPolicy = policy
+	p.unmatchedPublisher = publisher
+	p.unmatchedSources = make(map[string]bool)
+	return p
+}
+
+// handleUnmatched applies the unmatched policy to an alert that matched no rule.
+func (p *Processor) handleUnmatched(ctx context.Context, alert *events.AlertNew, evaluatedAt time.Time) {
+	switch p.unmatchedPolicy {
+	case events.UnmatchedPublish:
+		if p.unmatchedPublisher == nil {
+			return
+		}
+		unmatched := &events.UnmatchedAlert{
+			AlertID:     alert.AlertID,
+			EventTS:     alert.EventTS,
+			Severity:    alert.Severity,
+			Source:      alert.Source,
+			Name:        alert.Name,
+			Context:     alert.Context,
+			ClientHint:  alert.ClientHint,
+			RuleCount:   p.matcher.RuleCount(),
+			EvaluatedAt: evaluatedAt.UnixMilli(),
+		}
+		if err := p.unmatchedPublisher.PublishUnmatched(ctx, unmatched); err != nil {
+			slog.Warn("Failed to publish unmatched alert", "alert_id", alert.AlertID, "error", err)
+			p.metrics.IncrementCustom("alerts_unmatched_publish_failed")
+			return
+		}
+		p.metrics.IncrementCustom("alerts_unmatched_published")
+	case events.UnmatchedCount:
+		p.metrics.IncrementCustom("alerts_unmatched_severity_" + alert.Severity)
+		p.metrics.IncrementCustom("alerts_unmatched_source_" + p.unmatchedSource(alert.Source))
+	}
+}
+
+// unmatchedSource returns the metric suffix of an unmatched alert's source: the source itself
+// for the first maxUnmatchedSources sources seen, "other" after.
+func (p *Processor) unmatchedSource(source string) string {
+	if p.unmatchedSources[source] {
+		return source
+	}
+	if len(p.unmatchedSources) >= maxUnmatchedSources {
+		return unmatchedOtherSource
+	}
+	p.unmatchedSources[source] = true
+	return source
+}
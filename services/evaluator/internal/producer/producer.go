@@ -39,9 +39,10 @@ func NewProducer(brokers string, topic string) (*Producer, error) {
 	createTopicIfNotExists(brokerList[0], topic)
 
 	// Configure Kafka writer for at-least-once delivery
-	// Use Hash balancer to partition by client_id for tenant locality
+	// Use Hash balancer to partition by the event's key (client_id, or alert_id for combined events)
 	writer := &kafka.Writer{
 		Addr:         kafka.TCP(brokerList...),
+		Transport:    kafkautil.Transport(),
 		Topic:        topic,
 		Balancer:     &kafka.Hash{}, // Key-based partitioning (hashes the message key)
 		WriteTimeout: kafkautil.WriteTimeout,
@@ -55,7 +56,7 @@ func NewProducer(brokers string, topic string) (*Producer, error) {
 		"required_acks", "RequireOne",
 		"async", false,
 		"balancer", "Hash (key-based partitioning)",
-		"partition_key", "client_id (hashed)",
+		"partition_key", "client_id, or alert_id for combined events (hashed)",
 	)
 
 	return &Producer{
@@ -67,7 +68,7 @@ func NewProducer(brokers string, topic string) (*Producer, error) {
 // createTopicIfNotExists attempts to create the topic if it doesn't exist.
 // This is a best-effort operation and failures are logged but don't prevent producer creation.
 func createTopicIfNotExists(broker, topic string) {
-	conn, err := kafka.Dial("tcp", broker)
+	conn, err := kafkautil.Dial(broker)
 	if err != nil {
 		slog.Warn("Could not connect to Kafka to check/create topic",
 			"broker", broker,
@@ -114,7 +115,7 @@ func createTopicIfNotExists(broker, topic string) {
 }
 
 // Publish serializes a matched alert to protobuf and publishes it to Kafka.
-// The message is keyed by client_id for partition distribution (tenant locality).
+// Per-client events are keyed by client_id (tenant locality); combined events by alert_id.
 // Returns an error if serialization or publishing fails.
 func (p *Producer) Publish(ctx context.Context, matched *events.AlertMatched) error {
 	pb := &pbalerts.AlertMatched{
@@ -127,6 +128,10 @@ func (p *Producer) Publish(ctx context.Context, matched *events.AlertMatched) er
 		Context:       matched.Context,
 		ClientId:      matched.ClientID,
 		RuleIds:       matched.RuleIDs,
+		EvaluatedAtMs: matched.EvaluatedAtMs,
+	}
+	for _, m := range matched.Matches {
+		pb.Matches = append(pb.Matches, &pbalerts.ClientMatch{ClientId: m.ClientID, RuleIds: m.RuleIDs})
 	}
 
 	payload, err := proto.Marshal(pb)
@@ -139,8 +144,7 @@ func (p *Producer) Publish(ctx context.Context, matched *events.AlertMatched) er
 		return fmt.Errorf("failed to marshal matched alert: %w", err)
 	}
 
-	// Partition key: use client_id for tenant locality
-	partitionKey := []byte(matched.ClientID)
+	partitionKey := []byte(matched.PartitionKey())
 
 	// Create Kafka message with key, value, headers, and timestamp
 	msg := kafka.Message{
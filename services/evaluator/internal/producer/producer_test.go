@@ -2,9 +2,15 @@ package producer
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
+	"time"
 
 	"evaluator/internal/events"
+
+	protocommon "github.com/afikmenashe/alerting-platform/pkg/proto/common"
+	protorules "github.com/afikmenashe/alerting-platform/pkg/proto/rules"
+	"google.golang.org/protobuf/proto"
 )
 
 func TestNewProducer(t *testing.T) {
@@ -169,3 +175,84 @@ func TestProducer_CreateTopicIfNotExists_Integration(t *testing.T) {
 // For full coverage of Publish, you would need:
 // 1. Interface-based refactoring with mocks, OR
 // 2. Integration tests with testcontainers or real Kafka instance
+
+func TestBuildSampleMessage(t *testing.T) {
+	sample := &events.EvaluationSample{
+		AlertID:    "alert-1",
+		Severity:   "HIGH",
+		Source:     "api",
+		Name:       "timeout",
+		Matches:    map[string][]string{},
+		Candidates: events.SampleCandidates{Severity: []string{"rule-1"}},
+		SampleRate: 100,
+		SampledAt:  1700000000123,
+	}
+
+	msg, err := buildSampleMessage(sample)
+	if err != nil {
+		t.Fatalf("buildSampleMessage() error = %v", err)
+	}
+	if string(msg.Key) != "alert-1" {
+		t.Errorf("key = %q, want alert-1", msg.Key)
+	}
+	if msg.Time.UnixMilli() != 1700000000123 {
+		t.Errorf("time = %v, want the sample time", msg.Time)
+	}
+	var decoded events.EvaluationSample
+	if err := json.Unmarshal(msg.Value, &decoded); err != nil {
+		t.Fatalf("value is not JSON: %v", err)
+	}
+	if decoded.Matched || decoded.Candidates.Severity[0] != "rule-1" || decoded.SampleRate != 100 {
+		t.Errorf("decoded = %+v, want the unmatched sample", decoded)
+	}
+}
+
+func TestBuildUnmatchedMessage(t *testing.T) {
+	alert := &events.UnmatchedAlert{
+		AlertID:     "alert-1",
+		Severity:    "HIGH",
+		Source:      "api",
+		Name:        "timeout",
+		RuleCount:   12,
+		EvaluatedAt: 1700000000123,
+	}
+
+	msg, err := buildUnmatchedMessage(alert)
+	if err != nil {
+		t.Fatalf("buildUnmatchedMessage() error = %v", err)
+	}
+	if string(msg.Key) != "api" {
+		t.Errorf("key = %q, want the source", msg.Key)
+	}
+	if msg.Time.UnixMilli() != 1700000000123 {
+		t.Errorf("time = %v, want the evaluation time", msg.Time)
+	}
+	var decoded events.UnmatchedAlert
+	if err := json.Unmarshal(msg.Value, &decoded); err != nil {
+		t.Fatalf("value is not JSON: %v", err)
+	}
+	if decoded.AlertID != "alert-1" || decoded.RuleCount != 12 {
+		t.Errorf("decoded = %+v, want alert-1 with its rule count", decoded)
+	}
+}
+
+func TestBuildDisableMessage(t *testing.T) {
+	now := time.UnixMilli(1700000000123)
+	msg, err := buildDisableMessage("rule-1", "client-1", now)
+	if err != nil {
+		t.Fatalf("buildDisableMessage() error = %v", err)
+	}
+	if string(msg.Key) != "rule-1" {
+		t.Errorf("key = %q, want rule-1", msg.Key)
+	}
+	var decoded protorules.RuleChanged
+	if err := proto.Unmarshal(msg.Value, &decoded); err != nil {
+		t.Fatalf("value is not a RuleChanged: %v", err)
+	}
+	if decoded.RuleId != "rule-1" || decoded.ClientId != "client-1" || decoded.Action != protocommon.RuleAction_RULE_ACTION_DISABLED {
+		t.Errorf("decoded = %v, want rule-1 of client-1 disabled", &decoded)
+	}
+	if decoded.Version != 0 || decoded.Rule != nil || decoded.PublishedAtMs != 1700000000123 {
+		t.Errorf("decoded = %v, want an unversioned event without payload", &decoded)
+	}
+}
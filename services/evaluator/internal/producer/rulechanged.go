@@ -0,0 +1,102 @@
+package producer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	kafkautil "github.com/afikmenashe/alerting-platform/pkg/kafka"
+	protocommon "github.com/afikmenashe/alerting-platform/pkg/proto/common"
+	protorules "github.com/afikmenashe/alerting-platform/pkg/proto/rules"
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/protobuf/proto"
+)
+
+// ruleChangedSchemaVersion is the rule.changed schema version published by rule-service.
+const ruleChangedSchemaVersion = 1
+
+// RuleChangedProducer publishes rule.changed DISABLED events for rules the evaluator takes
+// out of matching, such as rules that keep exceeding the evaluation deadline.
+//
+// The events carry no rule version (0), since the evaluator does not know it: rule-updater
+// removes the rule from the snapshot and evaluators remove it from their indexes, but the rule
+// stays enabled in rule-service and comes back on its next update or a full snapshot rebuild.
+type RuleChangedProducer struct {
+	writer *kafka.Writer
+	topic  string
+}
+
+// NewRuleChangedProducer creates a synchronous producer for the rule.changed topic.
+func NewRuleChangedProducer(brokers string, topic string) (*RuleChangedProducer, error) {
+	if err := kafkautil.ValidateProducerParams(brokers, topic); err != nil {
+		return nil, err
+	}
+	brokerList := kafkautil.ParseBrokers(brokers)
+
+	slog.Info("Initializing rule.changed producer",
+		"brokers", brokerList,
+		"topic", topic,
+	)
+
+	createTopicIfNotExists(brokerList[0], topic)
+
+	writer := &kafka.Writer{
+		Addr:         kafka.TCP(brokerList...),
+		Transport:    kafkautil.Transport(),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{}, // Keyed by rule_id, like rule-service's events
+		WriteTimeout: kafkautil.WriteTimeout,
+		RequiredAcks: kafka.RequireOne,
+		BatchSize:    1,
+	}
+
+	return &RuleChangedProducer{
+		writer: writer,
+		topic:  topic,
+	}, nil
+}
+
+// buildDisableMessage serializes a rule.changed DISABLED event to protobuf, keyed by rule_id.
+func buildDisableMessage(ruleID, clientID string, now time.Time) (kafka.Message, error) {
+	payload, err := proto.Marshal(&protorules.RuleChanged{
+		RuleId:        ruleID,
+		ClientId:      clientID,
+		Action:        protocommon.RuleAction_RULE_ACTION_DISABLED,
+		UpdatedAt:     now.Unix(),
+		SchemaVersion: ruleChangedSchemaVersion,
+		PublishedAtMs: now.UnixMilli(),
+	})
+	if err != nil {
+		return kafka.Message{}, fmt.Errorf("failed to marshal rule changed event: %w", err)
+	}
+	return kafka.Message{
+		Key:   []byte(ruleID),
+		Value: payload,
+		Headers: []kafka.Header{
+			{Key: "content-type", Value: []byte("application/x-protobuf")},
+			{Key: "schema_version", Value: []byte(fmt.Sprintf("%d", ruleChangedSchemaVersion))},
+			{Key: "action", Value: []byte("DISABLED")},
+			{Key: "rule_id", Value: []byte(ruleID)},
+		},
+		Time: now,
+	}, nil
+}
+
+// DisableRule publishes a rule.changed DISABLED event for the rule and waits for the ack.
+func (p *RuleChangedProducer) DisableRule(ctx context.Context, ruleID, clientID string) error {
+	msg, err := buildDisableMessage(ruleID, clientID, time.Now())
+	if err != nil {
+		return err
+	}
+	if err := p.writer.WriteMessages(ctx, msg); err != nil {
+		return fmt.Errorf("failed to publish rule disable event: %w", err)
+	}
+	return nil
+}
+
+// Close closes the Kafka writer.
+func (p *RuleChangedProducer) Close() error {
+	slog.Info("Closing rule.changed producer", "topic", p.topic)
+	return p.writer.Close()
+}
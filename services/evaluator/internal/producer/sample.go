@@ -0,0 +1,93 @@
+package producer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"evaluator/internal/events"
+
+	kafkautil "github.com/afikmenashe/alerting-platform/pkg/kafka"
+	"github.com/segmentio/kafka-go"
+)
+
+// SampleProducer publishes sampled evaluation results to the debug.evaluations topic.
+// Writes are asynchronous, so sampling never adds latency to alert processing; a failed
+// write is logged and the sample is lost.
+type SampleProducer struct {
+	writer *kafka.Writer
+	topic  string
+}
+
+// NewSampleProducer creates an asynchronous producer for sampled evaluation results.
+func NewSampleProducer(brokers string, topic string) (*SampleProducer, error) {
+	if err := kafkautil.ValidateProducerParams(brokers, topic); err != nil {
+		return nil, err
+	}
+	brokerList := kafkautil.ParseBrokers(brokers)
+
+	slog.Info("Initializing evaluation sample producer",
+		"brokers", brokerList,
+		"topic", topic,
+	)
+
+	createTopicIfNotExists(brokerList[0], topic)
+
+	writer := &kafka.Writer{
+		Addr:         kafka.TCP(brokerList...),
+		Transport:    kafkautil.Transport(),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{}, // Keyed by alert_id
+		WriteTimeout: kafkautil.WriteTimeout,
+		RequiredAcks: kafka.RequireOne,
+		Async:        true, // Never block alert processing on the debug topic
+		Completion: func(messages []kafka.Message, err error) {
+			if err != nil {
+				slog.Warn("Failed to write evaluation samples", "topic", topic, "count", len(messages), "error", err)
+			}
+		},
+	}
+
+	return &SampleProducer{
+		writer: writer,
+		topic:  topic,
+	}, nil
+}
+
+// buildSampleMessage serializes a sampled evaluation to JSON, keyed by alert_id.
+func buildSampleMessage(sample *events.EvaluationSample) (kafka.Message, error) {
+	payload, err := json.Marshal(sample)
+	if err != nil {
+		return kafka.Message{}, fmt.Errorf("failed to marshal evaluation sample: %w", err)
+	}
+	return kafka.Message{
+		Key:   []byte(sample.AlertID),
+		Value: payload,
+		Headers: []kafka.Header{
+			{Key: "content-type", Value: []byte("application/json")},
+			{Key: "alert_id", Value: []byte(sample.AlertID)},
+		},
+		Time: time.UnixMilli(sample.SampledAt),
+	}, nil
+}
+
+// PublishSample queues a sampled evaluation for publishing. An error is only returned if the
+// sample cannot be serialized or queued; write failures are logged asynchronously.
+func (p *SampleProducer) PublishSample(ctx context.Context, sample *events.EvaluationSample) error {
+	msg, err := buildSampleMessage(sample)
+	if err != nil {
+		return err
+	}
+	if err := p.writer.WriteMessages(ctx, msg); err != nil {
+		return fmt.Errorf("failed to queue evaluation sample: %w", err)
+	}
+	return nil
+}
+
+// Close flushes queued samples and closes the Kafka writer.
+func (p *SampleProducer) Close() error {
+	slog.Info("Closing evaluation sample producer", "topic", p.topic)
+	return p.writer.Close()
+}
@@ -0,0 +1,94 @@
+package producer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"evaluator/internal/events"
+
+	kafkautil "github.com/afikmenashe/alerting-platform/pkg/kafka"
+	"github.com/segmentio/kafka-go"
+)
+
+// UnmatchedProducer publishes alerts that matched no rule to the alerts.unmatched topic.
+// Like SampleProducer, writes are asynchronous so an unmatched alert never delays alert
+// processing; a failed write is logged and the alert is lost from the topic.
+type UnmatchedProducer struct {
+	writer *kafka.Writer
+	topic  string
+}
+
+// NewUnmatchedProducer creates an asynchronous producer for unmatched alerts.
+func NewUnmatchedProducer(brokers string, topic string) (*UnmatchedProducer, error) {
+	if err := kafkautil.ValidateProducerParams(brokers, topic); err != nil {
+		return nil, err
+	}
+	brokerList := kafkautil.ParseBrokers(brokers)
+
+	slog.Info("Initializing unmatched alert producer",
+		"brokers", brokerList,
+		"topic", topic,
+	)
+
+	createTopicIfNotExists(brokerList[0], topic)
+
+	writer := &kafka.Writer{
+		Addr:         kafka.TCP(brokerList...),
+		Transport:    kafkautil.Transport(),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{}, // Keyed by source
+		WriteTimeout: kafkautil.WriteTimeout,
+		RequiredAcks: kafka.RequireOne,
+		Async:        true, // Never block alert processing on unmatched alerts
+		Completion: func(messages []kafka.Message, err error) {
+			if err != nil {
+				slog.Warn("Failed to write unmatched alerts", "topic", topic, "count", len(messages), "error", err)
+			}
+		},
+	}
+
+	return &UnmatchedProducer{
+		writer: writer,
+		topic:  topic,
+	}, nil
+}
+
+// buildUnmatchedMessage serializes an unmatched alert to JSON, keyed by source so one
+// producer's unmatched alerts stay together.
+func buildUnmatchedMessage(alert *events.UnmatchedAlert) (kafka.Message, error) {
+	payload, err := json.Marshal(alert)
+	if err != nil {
+		return kafka.Message{}, fmt.Errorf("failed to marshal unmatched alert: %w", err)
+	}
+	return kafka.Message{
+		Key:   []byte(alert.Source),
+		Value: payload,
+		Headers: []kafka.Header{
+			{Key: "content-type", Value: []byte("application/json")},
+			{Key: "alert_id", Value: []byte(alert.AlertID)},
+		},
+		Time: time.UnixMilli(alert.EvaluatedAt),
+	}, nil
+}
+
+// PublishUnmatched queues an unmatched alert for publishing. An error is only returned if the
+// alert cannot be serialized or queued; write failures are logged asynchronously.
+func (p *UnmatchedProducer) PublishUnmatched(ctx context.Context, alert *events.UnmatchedAlert) error {
+	msg, err := buildUnmatchedMessage(alert)
+	if err != nil {
+		return err
+	}
+	if err := p.writer.WriteMessages(ctx, msg); err != nil {
+		return fmt.Errorf("failed to queue unmatched alert: %w", err)
+	}
+	return nil
+}
+
+// Close flushes queued unmatched alerts and closes the Kafka writer.
+func (p *UnmatchedProducer) Close() error {
+	slog.Info("Closing unmatched alert producer", "topic", p.topic)
+	return p.writer.Close()
+}
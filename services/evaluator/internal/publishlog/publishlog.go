@@ -0,0 +1,43 @@
+// Package publishlog remembers the alerts.matched events the evaluator published, in Redis,
+// so an alert redelivered after a crash or rebalance does not publish them again.
+package publishlog
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// keyPrefix prefixes the Redis key of every published event.
+const keyPrefix = "evaluator:published:"
+
+// RedisLog records published events as Redis keys that expire after a TTL.
+type RedisLog struct {
+	client redis.UniversalClient
+	ttl    time.Duration
+}
+
+// NewRedisLog creates a publish log remembering events for ttl, which should cover the time
+// an alert can take to be redelivered (its consumer group's session and rebalance timeouts).
+func NewRedisLog(client redis.UniversalClient, ttl time.Duration) *RedisLog {
+	return &RedisLog{client: client, ttl: ttl}
+}
+
+// Published reports whether the event with key was published within the TTL.
+func (l *RedisLog) Published(ctx context.Context, key string) (bool, error) {
+	n, err := l.client.Exists(ctx, keyPrefix+key).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to read publish log: %w", err)
+	}
+	return n > 0, nil
+}
+
+// MarkPublished records that the event with key was published.
+func (l *RedisLog) MarkPublished(ctx context.Context, key string) error {
+	if err := l.client.Set(ctx, keyPrefix+key, 1, l.ttl).Err(); err != nil {
+		return fmt.Errorf("failed to write publish log: %w", err)
+	}
+	return nil
+}
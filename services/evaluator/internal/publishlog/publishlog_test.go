@@ -0,0 +1,40 @@
+package publishlog
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRedisLog_Integration(t *testing.T) {
+	// Integration test - requires Redis
+	client := redis.NewClient(&redis.Options{
+		Addr: "localhost:6379",
+	})
+	defer client.Close()
+
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("Skipping integration test: Redis not available: %v", err)
+	}
+
+	key := "test-alert/client-1"
+	client.Del(ctx, keyPrefix+key)
+	defer client.Del(ctx, keyPrefix+key)
+
+	log := NewRedisLog(client, time.Minute)
+	if published, err := log.Published(ctx, key); err != nil || published {
+		t.Fatalf("Published() = %v, %v before MarkPublished, want false", published, err)
+	}
+	if err := log.MarkPublished(ctx, key); err != nil {
+		t.Fatalf("MarkPublished() error = %v", err)
+	}
+	if published, err := log.Published(ctx, key); err != nil || !published {
+		t.Errorf("Published() = %v, %v after MarkPublished, want true", published, err)
+	}
+	if ttl := client.TTL(ctx, keyPrefix+key).Val(); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("key TTL = %v, want at most a minute", ttl)
+	}
+}
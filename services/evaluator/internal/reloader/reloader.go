@@ -1,24 +1,42 @@
-// Package reloader handles polling Redis for rule version changes and hot-reloading indexes.
+// Package reloader hot-reloads rule indexes when rule-updater writes a new snapshot, as announced
+// on a Redis pub/sub channel, with version polling as a fallback for missed announcements.
 // It also supports consuming rule.changed events from Kafka for immediate updates.
 package reloader
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"evaluator/internal/indexes"
 	"evaluator/internal/matcher"
 	"evaluator/internal/snapshot"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
 )
 
-// Reloader polls Redis for version changes and reloads rule indexes when needed.
-// It can also consume rule.changed events from Kafka for immediate updates.
+// Reloader reloads rule indexes when the snapshot version changes, as announced by rule-updater
+// or found by polling Redis. It can also consume rule.changed events from Kafka for immediate updates.
 type Reloader struct {
+	// mu serializes reloads from the poller, snapshot and control announcements and rule.changed events.
+	mu sync.Mutex
+
 	loader         *snapshot.Loader
 	matcher        *matcher.Matcher
 	pollInterval   time.Duration
 	currentVersion int64
+
+	// snapshotVersion is the version embedded in the snapshot currently loaded.
+	snapshotVersion int64
+	// pinnedVersion is the kept snapshot version the indexes are pinned to, 0 if not pinned.
+	// It is read by the rule.changed handler, so it is atomic.
+	pinnedVersion atomic.Int64
+	// reloadGeneration is the last forced reload request applied.
+	reloadGeneration int64
 }
 
 // NewReloader creates a new reloader with the given dependencies.
@@ -30,9 +48,16 @@ func NewReloader(loader *snapshot.Loader, matcher *matcher.Matcher, pollInterval
 	}
 }
 
-// Start begins polling Redis for version changes in a background goroutine.
-// It will reload indexes atomically when the version changes.
-// The goroutine will exit when ctx is cancelled.
+// WithSnapshotVersion records the embedded version of the snapshot the indexes were built from,
+// so the reloader never replaces them with older content.
+func (r *Reloader) WithSnapshotVersion(version int64) *Reloader {
+	r.snapshotVersion = version
+	return r
+}
+
+// Start subscribes to snapshot write announcements and begins polling Redis for version
+// changes in background goroutines. It will reload indexes atomically when the version changes.
+// The goroutines will exit when ctx is cancelled.
 func (r *Reloader) Start(ctx context.Context) error {
 	// Get initial version
 	version, err := r.loader.GetVersion(ctx)
@@ -41,16 +66,98 @@ func (r *Reloader) Start(ctx context.Context) error {
 	}
 	r.currentVersion = version
 
-	slog.Info("Starting version poller",
+	// Reload requests made before startup are already applied by the initial load
+	generation, err := r.loader.GetReloadGeneration(ctx)
+	if err != nil {
+		return err
+	}
+	r.reloadGeneration = generation
+
+	// Apply a pin right away, so a restarted evaluator does not match against the
+	// snapshot operators rolled back from until the first poll
+	if err := r.checkAndReload(ctx); err != nil {
+		slog.Error("Failed to apply rule snapshot pin at startup", "error", err)
+	}
+
+	slog.Info("Starting version poller and snapshot change subscription",
+		"channel", shared.RuleSnapshotChangedChannel,
 		"poll_interval", r.pollInterval,
 		"initial_version", r.currentVersion,
+		"pinned_version", r.pinnedVersion.Load(),
 	)
 
 	go r.pollLoop(ctx)
+	go r.watchChanges(ctx)
+	go r.watchControl(ctx)
 	return nil
 }
 
-// pollLoop continuously polls Redis for version changes.
+// watchChanges reloads as soon as rule-updater announces a snapshot write, instead of at the
+// next poll. Announcements of versions already loaded are ignored. Announcements are best
+// effort; the poller still picks up anything missed, e.g. while Redis reconnects.
+func (r *Reloader) watchChanges(ctx context.Context) {
+	sub := r.loader.SubscribeChanges(ctx)
+	defer sub.Close()
+
+	messages := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			version, err := strconv.ParseInt(msg.Payload, 10, 64)
+			if err == nil && version <= r.loadedVersion() {
+				continue
+			}
+			slog.Info("Rule snapshot change announced", "version", msg.Payload)
+			if err := r.checkAndReload(ctx); err != nil {
+				slog.Error("Failed to reload announced rule snapshot",
+					"version", msg.Payload,
+					"error", err,
+				)
+			}
+		}
+	}
+}
+
+// loadedVersion returns the {rules}:version the indexes were last reloaded at.
+func (r *Reloader) loadedVersion() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.currentVersion
+}
+
+// watchControl applies pin, unpin and reload announcements as they are published, instead
+// of at the next poll. Announcements are best effort; the poller still picks up anything missed.
+func (r *Reloader) watchControl(ctx context.Context) {
+	sub := r.loader.SubscribeControl(ctx)
+	defer sub.Close()
+
+	messages := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			slog.Info("Rule snapshot control change announced", "action", msg.Payload)
+			if err := r.checkAndReload(ctx); err != nil {
+				slog.Error("Failed to apply rule snapshot control change",
+					"action", msg.Payload,
+					"error", err,
+				)
+			}
+		}
+	}
+}
+
+// pollLoop continuously polls Redis for version changes, catching anything the
+// announcements missed.
 func (r *Reloader) pollLoop(ctx context.Context) {
 	ticker := time.NewTicker(r.pollInterval)
 	defer ticker.Stop()
@@ -71,16 +178,74 @@ func (r *Reloader) pollLoop(ctx context.Context) {
 	}
 }
 
-// checkAndReload checks if the version has changed and reloads if needed.
+// Pinned reports whether the indexes are pinned to a kept snapshot version, in which case
+// rule changes must not be applied to them.
+func (r *Reloader) Pinned() bool {
+	return r.pinnedVersion.Load() != 0
+}
+
+// checkAndReload checks if the version has changed and reloads if needed. While an operator
+// pins the evaluator to a kept snapshot version, that version is loaded instead, and rule
+// changes wait until the pin is cleared. A forced reload request rebuilds the indexes even if
+// the version did not change.
 func (r *Reloader) checkAndReload(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	generation, err := r.loader.GetReloadGeneration(ctx)
+	if err != nil {
+		return err
+	}
+	forced := generation != r.reloadGeneration
+
+	pinned, err := r.loader.GetPinnedVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if pinned > 0 {
+		if err := r.pin(ctx, pinned, forced); err != nil {
+			return err
+		}
+		r.reloadGeneration = generation
+		return nil
+	}
+
 	version, err := r.loader.GetVersion(ctx)
 	if err != nil {
 		return err
 	}
 
-	if version == r.currentVersion {
+	unpinned := r.Pinned()
+	if version == r.currentVersion && !unpinned && !forced {
 		return nil // No change
 	}
+	if unpinned {
+		slog.Warn("Rule snapshot pin cleared, going back to the current snapshot",
+			"pinned_version", r.pinnedVersion.Load(),
+			"version", version,
+		)
+	}
+	if forced {
+		slog.Warn("Forced rule snapshot reload requested",
+			"reload_generation", generation,
+			"version", version,
+		)
+	}
+
+	// Apply the recorded rule changes instead of rebuilding the indexes, when there is no
+	// pin to clear or reload to force
+	if !unpinned && !forced {
+		applied, err := r.applyDeltas(ctx, version)
+		if err != nil {
+			slog.Warn("Failed to apply rule snapshot deltas, reloading the snapshot",
+				"version", version,
+				"error", err,
+			)
+		}
+		if applied {
+			return nil
+		}
+	}
 
 	slog.Info("Rule version changed, reloading indexes",
 		"old_version", r.currentVersion,
@@ -93,12 +258,21 @@ func (r *Reloader) checkAndReload(ctx context.Context) error {
 		return err
 	}
 
+	// Fencing: never go back to older content than what is loaded. The version is left
+	// unchanged so the next poll retries once a newer snapshot is written.
+	if err := r.checkSnapshotVersion(snap); err != nil {
+		return err
+	}
+
 	// Build new indexes
 	newIndexes := indexes.NewIndexes(snap)
 
 	// Atomically swap indexes
 	r.matcher.UpdateIndexes(newIndexes)
 	r.currentVersion = version
+	r.snapshotVersion = snap.Version
+	r.pinnedVersion.Store(0)
+	r.reloadGeneration = generation
 
 	slog.Info("Indexes reloaded successfully",
 		"version", version,
@@ -108,6 +282,103 @@ func (r *Reloader) checkAndReload(ctx context.Context) error {
 	return nil
 }
 
+// applyDeltas brings the indexes from the loaded snapshot version up to version by applying the
+// rule changes rule-updater recorded in between. It returns false, for a full reload, if the
+// loaded snapshot predates versioning or if the changes cannot be applied: an entry is missing
+// (dropped, or not recorded) or the snapshot was rebuilt.
+func (r *Reloader) applyDeltas(ctx context.Context, version int64) (bool, error) {
+	base := r.snapshotVersion
+	if base <= 0 || version <= base {
+		return false, nil
+	}
+
+	deltas, err := r.loader.LoadDeltas(ctx, base, version)
+	if err != nil {
+		return false, err
+	}
+	if reason := checkDeltas(deltas, base, version); reason != "" {
+		slog.Info("Rule snapshot deltas not applicable, reloading the snapshot",
+			"from_version", base,
+			"to_version", version,
+			"reason", reason,
+		)
+		return false, nil
+	}
+
+	for _, delta := range deltas {
+		switch delta.Op {
+		case shared.RuleDeltaPut:
+			r.matcher.PutRule(delta.RuleID, delta.ClientID, delta.Severity, delta.Source, delta.Name, delta.ExcludeSources, delta.ExcludeNames, delta.Conditions)
+		case shared.RuleDeltaRemove:
+			r.matcher.RemoveRule(delta.RuleID)
+		}
+	}
+	r.currentVersion = version
+	r.snapshotVersion = version
+
+	slog.Info("Rule snapshot deltas applied",
+		"from_version", base,
+		"to_version", version,
+		"deltas", len(deltas),
+		"rules_count", r.matcher.RuleCount(),
+	)
+	return true, nil
+}
+
+// checkDeltas returns why deltas cannot bring the indexes from version after to version upTo,
+// or "" if they can: there must be one entry per version, each a rule change or a compaction.
+func checkDeltas(deltas []snapshot.Delta, after, upTo int64) string {
+	if int64(len(deltas)) != upTo-after {
+		return fmt.Sprintf("%d of %d versions recorded", len(deltas), upTo-after)
+	}
+	for i, delta := range deltas {
+		if want := after + int64(i) + 1; delta.Version != want {
+			return fmt.Sprintf("version %d not recorded", want)
+		}
+		switch delta.Op {
+		case shared.RuleDeltaPut, shared.RuleDeltaRemove, shared.RuleDeltaCompact:
+		case shared.RuleDeltaSnapshot:
+			return fmt.Sprintf("snapshot rebuilt at version %d", delta.Version)
+		default:
+			return fmt.Sprintf("unknown op %q at version %d", delta.Op, delta.Version)
+		}
+	}
+	return ""
+}
+
+// pin loads the kept snapshot version the evaluator is pinned to, unless it is loaded already
+// and no reload is forced. Pinning goes back to older content on purpose, so it is not fenced.
+func (r *Reloader) pin(ctx context.Context, version int64, forced bool) error {
+	if r.pinnedVersion.Load() == version && !forced {
+		return nil
+	}
+
+	snap, err := r.loader.LoadSnapshotVersion(ctx, version)
+	if err != nil {
+		return fmt.Errorf("failed to load pinned snapshot version %d: %w", version, err)
+	}
+
+	newIndexes := indexes.NewIndexes(snap)
+	r.matcher.UpdateIndexes(newIndexes)
+	r.snapshotVersion = snap.Version
+	r.pinnedVersion.Store(version)
+
+	slog.Warn("Indexes pinned to rule snapshot version",
+		"pinned_version", version,
+		"current_version", r.currentVersion,
+		"rules_count", newIndexes.RuleCount(),
+	)
+	return nil
+}
+
+// checkSnapshotVersion rejects a snapshot whose embedded version is older than the loaded one.
+func (r *Reloader) checkSnapshotVersion(snap *snapshot.Snapshot) error {
+	if snap.Version < r.snapshotVersion {
+		return fmt.Errorf("rejecting stale snapshot: embedded version %d is older than loaded version %d", snap.Version, r.snapshotVersion)
+	}
+	return nil
+}
+
 // ReloadNow forces an immediate reload of indexes from Redis snapshot.
 // This can be called when a rule.changed event is received.
 func (r *Reloader) ReloadNow(ctx context.Context) error {
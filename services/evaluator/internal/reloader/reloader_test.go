@@ -2,6 +2,7 @@ package reloader
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -9,6 +10,7 @@ import (
 	"evaluator/internal/matcher"
 	"evaluator/internal/snapshot"
 
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -223,3 +225,242 @@ func TestReloader_Start_ErrorHandling(t *testing.T) {
 		t.Logf("Start() error (expected): %v", err)
 	}
 }
+
+func TestReloader_CheckSnapshotVersion(t *testing.T) {
+	r := NewReloader(nil, nil, time.Second).WithSnapshotVersion(5)
+
+	tests := []struct {
+		name    string
+		version int64
+		wantErr bool
+	}{
+		{"older snapshot rejected", 4, true},
+		{"unversioned snapshot rejected", 0, true},
+		{"same version accepted", 5, false},
+		{"newer version accepted", 6, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := r.checkSnapshotVersion(&snapshot.Snapshot{Version: tt.version})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkSnapshotVersion(%d) error = %v, wantErr %v", tt.version, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckDeltas(t *testing.T) {
+	put := func(version int64) snapshot.Delta {
+		return snapshot.Delta{Version: version, Op: shared.RuleDeltaPut, RuleID: "rule-1"}
+	}
+	tests := []struct {
+		name    string
+		deltas  []snapshot.Delta
+		wantErr bool
+	}{
+		{"every version recorded", []snapshot.Delta{put(6), {Version: 7, Op: shared.RuleDeltaRemove}, {Version: 8, Op: shared.RuleDeltaCompact}}, false},
+		{"oldest versions dropped", []snapshot.Delta{put(7), put(8)}, true},
+		{"version missing", []snapshot.Delta{put(6), put(8), put(9)}, true},
+		{"snapshot rebuilt", []snapshot.Delta{put(6), {Version: 7, Op: shared.RuleDeltaSnapshot}, put(8)}, true},
+		{"unknown op", []snapshot.Delta{put(6), {Version: 7, Op: "rename"}, put(8)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason := checkDeltas(tt.deltas, 5, 8)
+			if (reason != "") != tt.wantErr {
+				t.Errorf("checkDeltas() = %q, wantErr %v", reason, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReloader_Deltas_Integration(t *testing.T) {
+	// Integration test - requires Redis
+	client := redis.NewClient(&redis.Options{
+		Addr: "localhost:6379",
+	})
+	defer client.Close()
+
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("Skipping integration test: Redis not available: %v", err)
+	}
+
+	// The snapshot in Redis is left at version 2, so only the deltas can add the rules
+	client.Set(ctx, snapshot.SnapshotKey, `{"schema_version":1,"version":2,"rules":{}}`, 0)
+	client.Set(ctx, snapshot.VersionKey, 2, 0)
+	client.Del(ctx, shared.RuleSnapshotPinKey, shared.RuleSnapshotDeltasKey, shared.RuleSnapshotChecksumKey(snapshot.SnapshotKey))
+	defer client.Del(ctx, shared.RuleSnapshotDeltasKey)
+
+	m := matcher.NewMatcher(indexes.NewIndexes(&snapshot.Snapshot{Version: 2}))
+	reloader := NewReloader(snapshot.NewLoader(client), m, time.Minute).WithSnapshotVersion(2)
+	if err := reloader.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	for _, delta := range []string{
+		`{"version":3,"op":"put","rule_id":"rule-1","client_id":"client-1","severity":"HIGH","source":"api","name":"cpu"}`,
+		`{"version":4,"op":"put","rule_id":"rule-2","client_id":"client-2","severity":"HIGH","source":"api","name":"cpu","conditions":[{"key":"region","op":"==","value":"eu"}]}`,
+		`{"version":5,"op":"remove","rule_id":"rule-1"}`,
+		`{"version":6,"op":"compact"}`,
+	} {
+		var d snapshot.Delta
+		if err := json.Unmarshal([]byte(delta), &d); err != nil {
+			t.Fatalf("delta %s: %v", delta, err)
+		}
+		client.ZAdd(ctx, shared.RuleSnapshotDeltasKey, redis.Z{Score: float64(d.Version), Member: delta})
+	}
+	client.Set(ctx, snapshot.VersionKey, 6, 0)
+
+	if err := reloader.ReloadNow(ctx); err != nil {
+		t.Fatalf("ReloadNow() error = %v", err)
+	}
+	matches := m.Match("HIGH", "api", "cpu", map[string]string{"region": "eu"})
+	if len(matches) != 1 || len(matches["client-2"]) != 1 {
+		t.Errorf("Match() after deltas = %v, want only rule-2", matches)
+	}
+	if got := reloader.loadedVersion(); got != 6 {
+		t.Errorf("loadedVersion() = %d, want 6", got)
+	}
+
+	// A rebuild cannot be applied as a delta: the snapshot is reloaded
+	client.ZAdd(ctx, shared.RuleSnapshotDeltasKey, redis.Z{Score: 7, Member: `{"version":7,"op":"snapshot"}`})
+	client.Set(ctx, snapshot.SnapshotKey, `{"schema_version":1,"version":7,"rules":{}}`, 0)
+	client.Set(ctx, snapshot.VersionKey, 7, 0)
+	if err := reloader.ReloadNow(ctx); err != nil {
+		t.Fatalf("ReloadNow() error = %v", err)
+	}
+	if got := m.RuleCount(); got != 0 {
+		t.Errorf("RuleCount() after rebuild = %d, want the snapshot's 0", got)
+	}
+}
+
+func TestReloader_Pin_Integration(t *testing.T) {
+	// Integration test - requires Redis
+	client := redis.NewClient(&redis.Options{
+		Addr: "localhost:6379",
+	})
+	defer client.Close()
+
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("Skipping integration test: Redis not available: %v", err)
+	}
+
+	current := `{"schema_version":1,"version":2,"by_severity":{"HIGH":[1]},"by_source":{"api":[1]},"by_name":{"cpu":[1]},"rules":{"1":{"rule_id":"rule-2","client_id":"client-1"}}}`
+	kept := `{"schema_version":1,"version":1,"by_severity":{"LOW":[1]},"by_source":{"api":[1]},"by_name":{"cpu":[1]},"rules":{"1":{"rule_id":"rule-1","client_id":"client-1"}}}`
+	client.Set(ctx, snapshot.SnapshotKey, current, 0)
+	client.Set(ctx, snapshot.VersionKey, 2, 0)
+	client.Set(ctx, shared.RuleSnapshotKeyAt(1), kept, 0)
+	defer client.Del(ctx, shared.RuleSnapshotKeyAt(1), shared.RuleSnapshotPinKey)
+
+	store := shared.NewRuleSnapshotStore(client)
+	if err := store.SetRuleSnapshotPin(ctx, shared.RuleSnapshotPin{Version: 1, Reason: "bad rule rollout"}); err != nil {
+		t.Fatalf("SetRuleSnapshotPin() error = %v", err)
+	}
+
+	m := matcher.NewMatcher(indexes.NewIndexes(&snapshot.Snapshot{}))
+	reloader := NewReloader(snapshot.NewLoader(client), m, time.Minute)
+	if err := reloader.ReloadNow(ctx); err != nil {
+		t.Fatalf("ReloadNow() pinned error = %v", err)
+	}
+	if !reloader.Pinned() || len(m.Match("LOW", "api", "cpu", nil)) != 1 {
+		t.Fatalf("pinned reloader matched %v, want the kept version 1", m.Match("LOW", "api", "cpu", nil))
+	}
+
+	if err := store.ClearRuleSnapshotPin(ctx); err != nil {
+		t.Fatalf("ClearRuleSnapshotPin() error = %v", err)
+	}
+	if err := reloader.ReloadNow(ctx); err != nil {
+		t.Fatalf("ReloadNow() unpinned error = %v", err)
+	}
+	if reloader.Pinned() || len(m.Match("HIGH", "api", "cpu", nil)) != 1 {
+		t.Errorf("unpinned reloader matched %v, want the current version 2", m.Match("HIGH", "api", "cpu", nil))
+	}
+}
+
+func TestReloader_ForcedReload_Integration(t *testing.T) {
+	// Integration test - requires Redis
+	client := redis.NewClient(&redis.Options{
+		Addr: "localhost:6379",
+	})
+	defer client.Close()
+
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("Skipping integration test: Redis not available: %v", err)
+	}
+
+	current := `{"schema_version":1,"version":3,"by_severity":{"HIGH":[1]},"by_source":{"api":[1]},"by_name":{"cpu":[1]},"rules":{"1":{"rule_id":"rule-3","client_id":"client-1"}}}`
+	client.Set(ctx, snapshot.SnapshotKey, current, 0)
+	client.Set(ctx, snapshot.VersionKey, 3, 0)
+	client.Del(ctx, shared.RuleSnapshotPinKey)
+
+	// Indexes that drifted from the snapshot at the same version
+	m := matcher.NewMatcher(indexes.NewIndexes(&snapshot.Snapshot{Version: 3}))
+	reloader := NewReloader(snapshot.NewLoader(client), m, time.Minute).WithSnapshotVersion(3)
+	if err := reloader.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if err := reloader.ReloadNow(ctx); err != nil {
+		t.Fatalf("ReloadNow() error = %v", err)
+	}
+	if len(m.Match("HIGH", "api", "cpu", nil)) != 0 {
+		t.Fatal("reloader rebuilt the indexes without a version change or reload request")
+	}
+
+	if _, err := shared.NewRuleSnapshotStore(client).RequestRuleSnapshotReload(ctx); err != nil {
+		t.Fatalf("RequestRuleSnapshotReload() error = %v", err)
+	}
+	if err := reloader.ReloadNow(ctx); err != nil {
+		t.Fatalf("ReloadNow() forced error = %v", err)
+	}
+	if len(m.Match("HIGH", "api", "cpu", nil)) != 1 {
+		t.Errorf("forced reload matched %v, want the snapshot's rule", m.Match("HIGH", "api", "cpu", nil))
+	}
+}
+
+func TestReloader_SnapshotChanged_Integration(t *testing.T) {
+	// Integration test - requires Redis
+	client := redis.NewClient(&redis.Options{
+		Addr: "localhost:6379",
+	})
+	defer client.Close()
+
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("Skipping integration test: Redis not available: %v", err)
+	}
+
+	client.Set(ctx, snapshot.SnapshotKey, `{"schema_version":1,"version":4,"rules":{}}`, 0)
+	client.Set(ctx, snapshot.VersionKey, 4, 0)
+	client.Del(ctx, shared.RuleSnapshotPinKey, shared.RuleSnapshotDeltasKey, shared.RuleSnapshotChecksumKey(snapshot.SnapshotKey))
+	defer client.Del(ctx, shared.RuleSnapshotChecksumKey(snapshot.SnapshotKey))
+
+	// Polls far less often than the test waits, so only the announcement can trigger the reload
+	m := matcher.NewMatcher(indexes.NewIndexes(&snapshot.Snapshot{Version: 4}))
+	reloader := NewReloader(snapshot.NewLoader(client), m, time.Hour).WithSnapshotVersion(4)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if err := reloader.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	time.Sleep(100 * time.Millisecond) // let the subscription start
+
+	current := `{"schema_version":1,"version":5,"by_severity":{"HIGH":[1]},"by_source":{"api":[1]},"by_name":{"cpu":[1]},"rules":{"1":{"rule_id":"rule-5","client_id":"client-1"}}}`
+	client.Set(ctx, snapshot.SnapshotKey, current, 0)
+	client.Set(ctx, shared.RuleSnapshotChecksumKey(snapshot.SnapshotKey), shared.RuleSnapshotChecksum([]byte(current)), 0)
+	client.Set(ctx, snapshot.VersionKey, 5, 0)
+	shared.NewRuleSnapshotStore(client).AnnounceRuleSnapshotChanged(ctx, 5)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(m.Match("HIGH", "api", "cpu", nil)) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("reloader did not reload the announced snapshot")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := reloader.loadedVersion(); got != 5 {
+		t.Errorf("loadedVersion() = %d, want 5", got)
+	}
+}
@@ -31,6 +31,8 @@ func fromProtoRuleAction(action protocommon.RuleAction) string {
 		return "DELETED"
 	case protocommon.RuleAction_RULE_ACTION_DISABLED:
 		return "DISABLED"
+	case protocommon.RuleAction_RULE_ACTION_REBUILD:
+		return "REBUILD"
 	default:
 		return ""
 	}
@@ -75,14 +77,30 @@ func (c *Consumer) ReadMessage(ctx context.Context) (*events.RuleChanged, error)
 		return nil, fmt.Errorf("failed to unmarshal protobuf rule changed event: %w", err)
 	}
 
-	return &events.RuleChanged{
+	changed := &events.RuleChanged{
 		RuleID:        pb.RuleId,
 		ClientID:      pb.ClientId,
 		Action:        fromProtoRuleAction(pb.Action), // Convert protobuf enum to simple action string
 		Version:       int(pb.Version),
 		UpdatedAt:     pb.UpdatedAt,
 		SchemaVersion: int(pb.SchemaVersion),
-	}, nil
+		PublishedAtMs: pb.PublishedAtMs,
+	}
+	if rule := pb.GetRule(); rule != nil {
+		changed.Rule = &events.RulePayload{
+			Severity: rule.Severity,
+			Source:   rule.Source,
+			Name:     rule.Name,
+			Enabled:  rule.Enabled,
+
+			ExcludeSources: rule.ExcludeSources,
+			ExcludeNames:   rule.ExcludeNames,
+		}
+		for _, c := range rule.Conditions {
+			changed.Rule.Conditions = append(changed.Rule.Conditions, events.ContextCondition{Key: c.Key, Op: c.Op, Value: c.Value})
+		}
+	}
+	return changed, nil
 }
 
 // Close gracefully closes the Kafka reader.
@@ -4,22 +4,31 @@ package snapshot
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strconv"
 
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
 	"github.com/redis/go-redis/v9"
 )
 
 const (
 	// SnapshotKey is the Redis key where the rule snapshot is stored.
-	SnapshotKey = "rules:snapshot"
+	SnapshotKey = shared.RuleSnapshotKey
 	// VersionKey is the Redis key where the rule version is stored.
-	VersionKey = "rules:version"
+	VersionKey = shared.RuleVersionKey
 )
 
+// ErrChecksumMismatch is returned when a snapshot does not match its stored checksum, meaning
+// it is truncated or corrupt.
+var ErrChecksumMismatch = errors.New("rule snapshot checksum mismatch")
+
 // Snapshot represents the serialized rule indexes loaded from Redis.
 type Snapshot struct {
 	SchemaVersion int                    `json:"schema_version"`
+	// Version is the {rules}:version the snapshot was written at (0 for snapshots that predate fencing).
+	Version       int64                  `json:"version"`
 	SeverityDict  map[string]int         `json:"severity_dict"`
 	SourceDict    map[string]int          `json:"source_dict"`
 	NameDict      map[string]int          `json:"name_dict"`
@@ -27,36 +36,114 @@ type Snapshot struct {
 	BySource      map[string][]int        `json:"by_source"`   // source -> []ruleInt
 	ByName        map[string][]int        `json:"by_name"`     // name -> []ruleInt
 	Rules         map[int]RuleInfo         `json:"rules"`       // ruleInt -> {rule_id, client_id}
+	// Normalization is how rule-updater normalized the rule fields; alerts are normalized the
+	// same way before matching. Empty (exact matching) for snapshots that predate normalization.
+	Normalization string `json:"normalization,omitempty"`
 }
 
-// RuleInfo contains the rule ID and client ID for a given ruleInt.
+// RuleInfo contains the rule ID and client ID for a given ruleInt, the alert sources
+// and names the rule's wildcards do not match, and the alert context conditions the rule
+// requires (values normalized like the indexes).
 type RuleInfo struct {
-	RuleID   string `json:"rule_id"`
-	ClientID string `json:"client_id"`
+	RuleID         string             `json:"rule_id"`
+	ClientID       string             `json:"client_id"`
+	ExcludeSources []string           `json:"exclude_sources,omitempty"`
+	ExcludeNames   []string           `json:"exclude_names,omitempty"`
+	Conditions     []ContextCondition `json:"conditions,omitempty"`
+}
+
+// ContextCondition matches one key of an alert's context. Op is == (the key is present with
+// Value) or != (the key is absent or has another value).
+type ContextCondition struct {
+	Key   string `json:"key"`
+	Op    string `json:"op"`
+	Value string `json:"value"`
+}
+
+// Delta is one change rule-updater recorded with a snapshot write (see shared.RuleSnapshotDeltasKey).
+// Op is shared.RuleDeltaPut or shared.RuleDeltaRemove for a single rule, shared.RuleDeltaCompact
+// for a compaction, which does not change the rules, or shared.RuleDeltaSnapshot for a full
+// rebuild, which readers can only apply by reloading the snapshot. The rule fields of a put are
+// normalized like the snapshot's.
+type Delta struct {
+	Version        int64              `json:"version"`
+	Op             string             `json:"op"`
+	RuleID         string             `json:"rule_id,omitempty"`
+	ClientID       string             `json:"client_id,omitempty"`
+	Severity       string             `json:"severity,omitempty"`
+	Source         string             `json:"source,omitempty"`
+	Name           string             `json:"name,omitempty"`
+	ExcludeSources []string           `json:"exclude_sources,omitempty"`
+	ExcludeNames   []string           `json:"exclude_names,omitempty"`
+	Conditions     []ContextCondition `json:"conditions,omitempty"`
 }
 
 // Loader handles loading snapshots from Redis.
 type Loader struct {
-	client *redis.Client
+	client  redis.UniversalClient
+	history *shared.RuleSnapshotStore
 }
 
 // NewLoader creates a new snapshot loader with the given Redis client.
-func NewLoader(client *redis.Client) *Loader {
+func NewLoader(client redis.UniversalClient) *Loader {
 	return &Loader{
-		client: client,
+		client:  client,
+		history: shared.NewRuleSnapshotStore(client),
 	}
 }
 
 // LoadSnapshot loads the rule snapshot from Redis and deserializes it.
 // Returns an error if the snapshot doesn't exist or deserialization fails.
 func (l *Loader) LoadSnapshot(ctx context.Context) (*Snapshot, error) {
-	data, err := l.client.Get(ctx, SnapshotKey).Result()
-	if err == redis.Nil {
-		return nil, fmt.Errorf("snapshot not found in Redis (key: %s)", SnapshotKey)
+	return l.load(ctx, SnapshotKey)
+}
+
+// LoadSnapshotVersion loads the copy of the snapshot rule-updater kept at version.
+// Returns an error if that version is not kept (anymore) or deserialization fails.
+func (l *Loader) LoadSnapshotVersion(ctx context.Context, version int64) (*Snapshot, error) {
+	return l.load(ctx, shared.RuleSnapshotKeyAt(version))
+}
+
+// GetPinnedVersion returns the snapshot version the evaluator is pinned to, or 0 if it
+// is not pinned.
+func (l *Loader) GetPinnedVersion(ctx context.Context) (int64, error) {
+	pin, err := l.history.GetRuleSnapshotPin(ctx)
+	if err != nil || pin == nil {
+		return 0, err
 	}
+	return pin.Version, nil
+}
+
+// GetReloadGeneration returns how many forced reloads operators have requested so far.
+func (l *Loader) GetReloadGeneration(ctx context.Context) (int64, error) {
+	return l.history.GetRuleSnapshotReloadGeneration(ctx)
+}
+
+// SubscribeControl subscribes to pin, unpin and reload announcements.
+func (l *Loader) SubscribeControl(ctx context.Context) *redis.PubSub {
+	return l.history.SubscribeRuleSnapshotControl(ctx)
+}
+
+// SubscribeChanges subscribes to the versions rule-updater announces after every snapshot write.
+func (l *Loader) SubscribeChanges(ctx context.Context) *redis.PubSub {
+	return l.history.SubscribeRuleSnapshotChanged(ctx)
+}
+
+// load loads the snapshot stored at key, verifies its checksum, and deserializes it.
+func (l *Loader) load(ctx context.Context, key string) (*Snapshot, error) {
+	// MGET reads the snapshot and its checksum atomically, so a concurrent write cannot mismatch them
+	values, err := l.client.MGet(ctx, key, shared.RuleSnapshotChecksumKey(key)).Result()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get snapshot from Redis: %w", err)
 	}
+	data, ok := values[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("snapshot not found in Redis (key: %s)", key)
+	}
+	checksum, _ := values[1].(string)
+	if err := verifyChecksum(key, []byte(data), checksum); err != nil {
+		return nil, err
+	}
 
 	var snapshot Snapshot
 	if err := json.Unmarshal([]byte(data), &snapshot); err != nil {
@@ -64,13 +151,29 @@ func (l *Loader) LoadSnapshot(ctx context.Context) (*Snapshot, error) {
 	}
 
 	slog.Info("Loaded rule snapshot from Redis",
+		"key", key,
 		"schema_version", snapshot.SchemaVersion,
+		"version", snapshot.Version,
 		"rules_count", len(snapshot.Rules),
+		"normalization", snapshot.Normalization,
 	)
 
 	return &snapshot, nil
 }
 
+// verifyChecksum checks the snapshot data stored at key against its stored checksum.
+// Snapshots written before checksums were introduced have none and are accepted.
+func verifyChecksum(key string, data []byte, checksum string) error {
+	if checksum == "" {
+		slog.Warn("Rule snapshot has no checksum, skipping verification", "key", key)
+		return nil
+	}
+	if got := shared.RuleSnapshotChecksum(data); got != checksum {
+		return fmt.Errorf("%w (key: %s, %d bytes): checksum %s, want %s", ErrChecksumMismatch, key, len(data), got, checksum)
+	}
+	return nil
+}
+
 // GetVersion returns the current rule version from Redis.
 // Returns 0 if the version doesn't exist (no rules yet).
 func (l *Loader) GetVersion(ctx context.Context) (int64, error) {
@@ -84,3 +187,26 @@ func (l *Loader) GetVersion(ctx context.Context) (int64, error) {
 	}
 	return version, nil
 }
+
+// LoadDeltas returns the changes recorded after version after, up to and including version upTo,
+// in version order. Entries rule-updater no longer keeps are missing, so callers must check
+// that every version is there.
+func (l *Loader) LoadDeltas(ctx context.Context, after, upTo int64) ([]Delta, error) {
+	entries, err := l.client.ZRangeByScore(ctx, shared.RuleSnapshotDeltasKey, &redis.ZRangeBy{
+		Min: "(" + strconv.FormatInt(after, 10),
+		Max: strconv.FormatInt(upTo, 10),
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get snapshot deltas from Redis: %w", err)
+	}
+
+	deltas := make([]Delta, 0, len(entries))
+	for _, entry := range entries {
+		var delta Delta
+		if err := json.Unmarshal([]byte(entry), &delta); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal snapshot delta: %w", err)
+		}
+		deltas = append(deltas, delta)
+	}
+	return deltas, nil
+}
@@ -3,9 +3,11 @@ package snapshot
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"testing"
 	"time"
 
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -51,7 +53,7 @@ func TestLoader_LoadSnapshot_Integration(t *testing.T) {
 		Rules:         map[int]RuleInfo{1: {RuleID: "rule-1", ClientID: "client-1"}},
 	}
 	data, _ := json.Marshal(snap)
-	client.Set(ctx, "rules:snapshot", data, 0)
+	client.Set(ctx, "{rules}:snapshot", data, 0)
 
 	// Now test loading the snapshot
 	loadedSnap, err := loader.LoadSnapshot(ctx)
@@ -62,7 +64,7 @@ func TestLoader_LoadSnapshot_Integration(t *testing.T) {
 			t.Errorf("LoadSnapshot() SchemaVersion = %v, want %v", loadedSnap.SchemaVersion, snap.SchemaVersion)
 		}
 		// Clean up
-		client.Del(ctx, "rules:snapshot")
+		client.Del(ctx, "{rules}:snapshot")
 	}
 }
 
@@ -90,7 +92,7 @@ func TestLoader_GetVersion_Integration(t *testing.T) {
 	}
 
 	// Test GetVersion with existing version
-	client.Set(ctx, "rules:version", 42, 0)
+	client.Set(ctx, "{rules}:version", 42, 0)
 	version, err = loader.GetVersion(ctx)
 	if err != nil {
 		t.Errorf("GetVersion() error = %v, want nil", err)
@@ -99,7 +101,7 @@ func TestLoader_GetVersion_Integration(t *testing.T) {
 		t.Errorf("GetVersion() = %v, want 42", version)
 	}
 	// Clean up
-	client.Del(ctx, "rules:version")
+	client.Del(ctx, "{rules}:version")
 
 	// Test GetVersion with invalid Redis connection (error path)
 	invalidClient := redis.NewClient(&redis.Options{
@@ -196,6 +198,22 @@ func TestSnapshot_LoadSnapshot_JSONError(t *testing.T) {
 	}
 }
 
+func TestVerifyChecksum(t *testing.T) {
+	data := []byte(`{"schema_version":1,"version":3,"rules":{}}`)
+	checksum := shared.RuleSnapshotChecksum(data)
+
+	if err := verifyChecksum(SnapshotKey, data, checksum); err != nil {
+		t.Errorf("verifyChecksum() error = %v, want nil", err)
+	}
+	if err := verifyChecksum(SnapshotKey, data, ""); err != nil {
+		t.Errorf("verifyChecksum() without checksum error = %v, want nil", err)
+	}
+	// A truncated snapshot no longer matches
+	if err := verifyChecksum(SnapshotKey, data[:len(data)-5], checksum); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("verifyChecksum() of truncated data error = %v, want ErrChecksumMismatch", err)
+	}
+}
+
 func TestRuleInfo_Structure(t *testing.T) {
 	ruleInfo := RuleInfo{
 		RuleID:   "rule-123",
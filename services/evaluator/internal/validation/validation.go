@@ -0,0 +1,182 @@
+// Package validation provides strict validation of incoming alerts.
+// Alerts that fail validation are rejected before rule matching, with a reason code
+// that is recorded as a metric so malformed producers are easy to spot.
+package validation
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"evaluator/internal/events"
+)
+
+// Rejection reasons. Each is recorded as the metric "alerts_rejected_<reason>".
+const (
+	ReasonMissingSchemaVersion     = "missing_schema_version"
+	ReasonUnsupportedSchemaVersion = "unsupported_schema_version"
+	ReasonMissingAlertID           = "missing_alert_id"
+	ReasonMissingSource            = "missing_source"
+	ReasonMissingName              = "missing_name"
+	ReasonInvalidSeverity          = "invalid_severity"
+	ReasonMissingTimestamp         = "missing_timestamp"
+	ReasonTimestampInFuture        = "timestamp_in_future"
+	ReasonTimestampTooOld          = "timestamp_too_old"
+	ReasonContextTooLarge          = "context_too_large"
+	// ReasonUnknownClientHint is recorded when client_hint names no client with rules.
+	// The alert is rejected rather than matched globally, so it cannot reach other clients.
+	ReasonUnknownClientHint = "unknown_client_hint"
+)
+
+// Defaults for timestamp sanity checks.
+const (
+	DefaultMaxClockSkew = 5 * time.Minute
+	DefaultMaxAlertAge  = 24 * time.Hour
+)
+
+// CurrentSchemaVersion is the newest alerts.new schema version this evaluator understands.
+// Alerts of a newer version come from a producer that is ahead of the evaluator.
+const CurrentSchemaVersion = 1
+
+// Defaults for the bounds on an alert's context.
+const (
+	DefaultMaxContextKeys  = 64
+	DefaultMaxContextBytes = 8 << 10
+)
+
+// DefaultSeverities is the allowed severity enum.
+var DefaultSeverities = []string{"LOW", "MEDIUM", "HIGH", "CRITICAL"}
+
+// Options configures the validator.
+type Options struct {
+	// Severities is the allowed severity enum. Empty uses DefaultSeverities.
+	Severities []string
+	// MaxClockSkew is how far in the future event_ts may be. Zero disables the check.
+	MaxClockSkew time.Duration
+	// MaxAlertAge is how far in the past event_ts may be. Zero disables the check.
+	MaxAlertAge time.Duration
+	// MaxSchemaVersion is the newest schema_version accepted. Zero uses CurrentSchemaVersion.
+	MaxSchemaVersion int
+	// MaxContextKeys is how many context entries an alert may have. Zero disables the check.
+	MaxContextKeys int
+	// MaxContextBytes is the total size of the context's keys and values. Zero disables the check.
+	MaxContextBytes int
+}
+
+// Error describes why an alert was rejected.
+type Error struct {
+	Reason  string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Reason, e.Message)
+}
+
+// Validator checks alerts against the configured rules.
+type Validator struct {
+	severities   map[string]bool
+	maxClockSkew time.Duration
+	maxAlertAge  time.Duration
+	maxSchema    int
+	maxKeys      int
+	maxBytes     int
+	now          func() time.Time
+}
+
+// NewValidator creates a validator with the given options.
+func NewValidator(opts Options) *Validator {
+	severities := opts.Severities
+	if len(severities) == 0 {
+		severities = DefaultSeverities
+	}
+	allowed := make(map[string]bool, len(severities))
+	for _, s := range severities {
+		allowed[strings.ToUpper(strings.TrimSpace(s))] = true
+	}
+
+	maxSchema := opts.MaxSchemaVersion
+	if maxSchema <= 0 {
+		maxSchema = CurrentSchemaVersion
+	}
+	return &Validator{
+		severities:   allowed,
+		maxClockSkew: opts.MaxClockSkew,
+		maxAlertAge:  opts.MaxAlertAge,
+		maxSchema:    maxSchema,
+		maxKeys:      opts.MaxContextKeys,
+		maxBytes:     opts.MaxContextBytes,
+		now:          time.Now,
+	}
+}
+
+// ParseSeverities parses a comma-separated severity list.
+func ParseSeverities(s string) []string {
+	var severities []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			severities = append(severities, strings.ToUpper(part))
+		}
+	}
+	return severities
+}
+
+// Validate returns a *Error describing the first problem found, or nil if the alert is valid.
+func (v *Validator) Validate(alert *events.AlertNew) error {
+	if alert.SchemaVersion <= 0 {
+		return &Error{Reason: ReasonMissingSchemaVersion, Message: "schema_version is required"}
+	}
+	if alert.SchemaVersion > v.maxSchema {
+		return &Error{Reason: ReasonUnsupportedSchemaVersion, Message: fmt.Sprintf("schema_version %d is newer than the supported %d", alert.SchemaVersion, v.maxSchema)}
+	}
+	if strings.TrimSpace(alert.AlertID) == "" {
+		return &Error{Reason: ReasonMissingAlertID, Message: "alert_id is required"}
+	}
+	if strings.TrimSpace(alert.Source) == "" {
+		return &Error{Reason: ReasonMissingSource, Message: "source is required"}
+	}
+	if strings.TrimSpace(alert.Name) == "" {
+		return &Error{Reason: ReasonMissingName, Message: "name is required"}
+	}
+	if !v.severities[alert.Severity] {
+		return &Error{Reason: ReasonInvalidSeverity, Message: fmt.Sprintf("severity %q is not allowed", alert.Severity)}
+	}
+	if err := v.validateTimestamp(alert.EventTS); err != nil {
+		return err
+	}
+	return v.validateContext(alert.Context)
+}
+
+// validateTimestamp checks event_ts (Unix seconds) against the clock-skew and age limits.
+func (v *Validator) validateTimestamp(eventTS int64) error {
+	if eventTS <= 0 {
+		return &Error{Reason: ReasonMissingTimestamp, Message: "event_ts is required"}
+	}
+
+	now := v.now()
+	eventTime := time.Unix(eventTS, 0)
+	if v.maxClockSkew > 0 && eventTime.After(now.Add(v.maxClockSkew)) {
+		return &Error{Reason: ReasonTimestampInFuture, Message: fmt.Sprintf("event_ts %s is %s in the future (max %s)", eventTime.UTC().Format(time.RFC3339), eventTime.Sub(now).Round(time.Second), v.maxClockSkew)}
+	}
+	if v.maxAlertAge > 0 && eventTime.Before(now.Add(-v.maxAlertAge)) {
+		return &Error{Reason: ReasonTimestampTooOld, Message: fmt.Sprintf("event_ts %s is %s old (max %s)", eventTime.UTC().Format(time.RFC3339), now.Sub(eventTime).Round(time.Second), v.maxAlertAge)}
+	}
+	return nil
+}
+
+// validateContext checks the number and total size of the context entries.
+func (v *Validator) validateContext(context map[string]string) error {
+	if v.maxKeys > 0 && len(context) > v.maxKeys {
+		return &Error{Reason: ReasonContextTooLarge, Message: fmt.Sprintf("context has %d entries (max %d)", len(context), v.maxKeys)}
+	}
+	if v.maxBytes > 0 {
+		size := 0
+		for k, val := range context {
+			size += len(k) + len(val)
+		}
+		if size > v.maxBytes {
+			return &Error{Reason: ReasonContextTooLarge, Message: fmt.Sprintf("context is %d bytes (max %d)", size, v.maxBytes)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package validation
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"evaluator/internal/events"
+)
+
+func validAlert(now time.Time) *events.AlertNew {
+	return &events.AlertNew{
+		AlertID:       "alert-1",
+		SchemaVersion: 1,
+		EventTS:       now.Unix(),
+		Severity:      "HIGH",
+		Source:        "api",
+		Name:          "timeout",
+	}
+}
+
+func TestValidator_Validate(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		modify     func(a *events.AlertNew)
+		wantReason string
+	}{
+		{name: "valid alert", modify: func(a *events.AlertNew) {}},
+		{name: "missing alert_id", modify: func(a *events.AlertNew) { a.AlertID = "" }, wantReason: ReasonMissingAlertID},
+		{name: "blank source", modify: func(a *events.AlertNew) { a.Source = "  " }, wantReason: ReasonMissingSource},
+		{name: "missing name", modify: func(a *events.AlertNew) { a.Name = "" }, wantReason: ReasonMissingName},
+		{name: "unspecified severity", modify: func(a *events.AlertNew) { a.Severity = "UNSPECIFIED" }, wantReason: ReasonInvalidSeverity},
+		{name: "missing timestamp", modify: func(a *events.AlertNew) { a.EventTS = 0 }, wantReason: ReasonMissingTimestamp},
+		{name: "within clock skew", modify: func(a *events.AlertNew) { a.EventTS = now.Add(4 * time.Minute).Unix() }},
+		{name: "too far in future", modify: func(a *events.AlertNew) { a.EventTS = now.Add(10 * time.Minute).Unix() }, wantReason: ReasonTimestampInFuture},
+		{name: "too old", modify: func(a *events.AlertNew) { a.EventTS = now.Add(-25 * time.Hour).Unix() }, wantReason: ReasonTimestampTooOld},
+		{name: "missing schema_version", modify: func(a *events.AlertNew) { a.SchemaVersion = 0 }, wantReason: ReasonMissingSchemaVersion},
+		{name: "newer schema_version", modify: func(a *events.AlertNew) { a.SchemaVersion = CurrentSchemaVersion + 1 }, wantReason: ReasonUnsupportedSchemaVersion},
+		{name: "context within bounds", modify: func(a *events.AlertNew) { a.Context = map[string]string{"region": "eu-west-1", "env": "prod"} }},
+		{name: "too many context entries", modify: func(a *events.AlertNew) { a.Context = map[string]string{"a": "1", "b": "2", "c": "3", "d": "4"} }, wantReason: ReasonContextTooLarge},
+		{name: "context too large", modify: func(a *events.AlertNew) { a.Context = map[string]string{"trace": strings.Repeat("x", 64)} }, wantReason: ReasonContextTooLarge},
+	}
+
+	v := NewValidator(Options{MaxClockSkew: DefaultMaxClockSkew, MaxAlertAge: DefaultMaxAlertAge, MaxContextKeys: 3, MaxContextBytes: 64})
+	v.now = func() time.Time { return now }
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alert := validAlert(now)
+			tt.modify(alert)
+
+			err := v.Validate(alert)
+			if tt.wantReason == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+
+			var verr *Error
+			if !errors.As(err, &verr) {
+				t.Fatalf("Validate() error = %v, want *Error", err)
+			}
+			if verr.Reason != tt.wantReason {
+				t.Errorf("Validate() reason = %q, want %q", verr.Reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestValidator_DisabledTimestampLimits(t *testing.T) {
+	now := time.Now()
+	v := NewValidator(Options{})
+
+	alert := validAlert(now)
+	alert.EventTS = now.Add(-30 * 24 * time.Hour).Unix()
+	if err := v.Validate(alert); err != nil {
+		t.Errorf("Validate() with no age limit error = %v, want nil", err)
+	}
+
+	alert.EventTS = now.Add(time.Hour).Unix()
+	if err := v.Validate(alert); err != nil {
+		t.Errorf("Validate() with no skew limit error = %v, want nil", err)
+	}
+}
+
+func TestValidator_CustomSeverities(t *testing.T) {
+	v := NewValidator(Options{Severities: []string{"high", "critical"}})
+
+	alert := validAlert(time.Now())
+	if err := v.Validate(alert); err != nil {
+		t.Errorf("Validate(HIGH) error = %v, want nil", err)
+	}
+
+	alert.Severity = "LOW"
+	if err := v.Validate(alert); err == nil {
+		t.Error("Validate(LOW) expected error when LOW is not allowed")
+	}
+}
+
+func TestParseSeverities(t *testing.T) {
+	got := ParseSeverities(" low, High ,,CRITICAL")
+	want := []string{"LOW", "HIGH", "CRITICAL"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseSeverities() = %v, want %v", got, want)
+	}
+}
+
+func TestValidator_SchemaVersions(t *testing.T) {
+	alert := validAlert(time.Now())
+	alert.SchemaVersion = 2
+	if err := NewValidator(Options{}).Validate(alert); err == nil {
+		t.Error("Validate(schema_version 2) expected error with the default max version")
+	}
+	if err := NewValidator(Options{MaxSchemaVersion: 2}).Validate(alert); err != nil {
+		t.Errorf("Validate(schema_version 2) with max 2 error = %v, want nil", err)
+	}
+}
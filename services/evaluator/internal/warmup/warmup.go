@@ -0,0 +1,74 @@
+// Package warmup replays synthetic alerts through the matcher before the evaluator reports
+// ready, so a snapshot whose indexes do not hold their own rules is caught at startup and the
+// first real alerts do not pay for cold caches.
+package warmup
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"evaluator/internal/matcher"
+)
+
+// DefaultAlerts is the default number of synthetic alerts replayed.
+const DefaultAlerts = 100
+
+// maxReportedMisses bounds the rule IDs listed in a warm-up error.
+const maxReportedMisses = 10
+
+// Result summarizes a warm-up.
+type Result struct {
+	Alerts   int           // Synthetic alerts replayed
+	Matched  int           // Alerts that matched the rule they were built from
+	Missed   []string      // Rules that did not match the alert built from them
+	Duration time.Duration // Time spent matching
+}
+
+// Run replays up to alerts synthetic alerts, each built from one rule's index keys, and
+// checks that every rule matches its own alert. Returns an error listing the rules that did
+// not, which means the indexes are inconsistent. An empty rule set passes trivially.
+func Run(m *matcher.Matcher, alerts int) (Result, error) {
+	probes := m.Probes(alerts)
+	result := Result{Alerts: len(probes)}
+
+	start := time.Now()
+	for _, probe := range probes {
+		matches := m.Match(probe.Severity, probe.Source, probe.Name, probe.Context)
+		if !probe.Expected {
+			continue
+		}
+		if contains(matches[probe.ClientID], probe.RuleID) {
+			result.Matched++
+		} else {
+			result.Missed = append(result.Missed, probe.RuleID)
+		}
+	}
+	result.Duration = time.Since(start)
+
+	slog.Info("Matcher warm-up finished",
+		"alerts", result.Alerts,
+		"matched", result.Matched,
+		"missed", len(result.Missed),
+		"duration", result.Duration,
+	)
+
+	if len(result.Missed) > 0 {
+		missed := result.Missed
+		if len(missed) > maxReportedMisses {
+			missed = missed[:maxReportedMisses]
+		}
+		return result, fmt.Errorf("warm-up: %d of %d rules did not match their synthetic alert: %v", len(result.Missed), result.Alerts, missed)
+	}
+	return result, nil
+}
+
+// contains reports whether values contains v.
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package warmup
+
+import (
+	"testing"
+
+	"evaluator/internal/indexes"
+	"evaluator/internal/matcher"
+	"evaluator/internal/snapshot"
+)
+
+func TestRun(t *testing.T) {
+	snap := &snapshot.Snapshot{
+		BySeverity: map[string][]int{"HIGH": {1, 2}, "*": {3}},
+		BySource:   map[string][]int{"api": {1, 3}, "*": {2}},
+		ByName:     map[string][]int{"timeout": {1, 2, 3}},
+		Rules: map[int]snapshot.RuleInfo{
+			1: {RuleID: "rule-1", ClientID: "client-1"},
+			2: {RuleID: "rule-2", ClientID: "client-1", ExcludeSources: []string{"db"}},
+			3: {RuleID: "rule-3", ClientID: "client-2", Conditions: []snapshot.ContextCondition{{Key: "env", Op: "==", Value: "prod"}, {Key: "region", Op: "!=", Value: "eu"}}},
+		},
+	}
+	result, err := Run(matcher.NewMatcher(indexes.NewIndexes(snap)), DefaultAlerts)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.Alerts != 3 || result.Matched != 3 || len(result.Missed) != 0 {
+		t.Errorf("Run() = %+v, want 3 of 3 matched", result)
+	}
+
+	// Limited to the requested number of alerts
+	if result, _ := Run(matcher.NewMatcher(indexes.NewIndexes(snap)), 2); result.Alerts != 2 {
+		t.Errorf("Run(2) replayed %d alerts, want 2", result.Alerts)
+	}
+
+	// Nothing to replay without rules
+	if result, err := Run(matcher.NewMatcher(indexes.NewIndexes(&snapshot.Snapshot{})), DefaultAlerts); err != nil || result.Alerts != 0 {
+		t.Errorf("Run() without rules = %+v, %v, want nothing replayed", result, err)
+	}
+}
+
+func TestRun_InconsistentIndexes(t *testing.T) {
+	// rule-2 is missing from the name index, as in a truncated snapshot
+	snap := &snapshot.Snapshot{
+		BySeverity: map[string][]int{"HIGH": {1, 2}},
+		BySource:   map[string][]int{"api": {1, 2}},
+		ByName:     map[string][]int{"timeout": {1}},
+		Rules: map[int]snapshot.RuleInfo{
+			1: {RuleID: "rule-1", ClientID: "client-1"},
+			2: {RuleID: "rule-2", ClientID: "client-1"},
+		},
+	}
+	result, err := Run(matcher.NewMatcher(indexes.NewIndexes(snap)), DefaultAlerts)
+	if err == nil {
+		t.Fatal("Run() error = nil, want an error for rule-2")
+	}
+	if len(result.Missed) != 1 || result.Missed[0] != "rule-2" {
+		t.Errorf("Missed = %v, want [rule-2]", result.Missed)
+	}
+}
@@ -9,12 +9,13 @@ import (
 	"log"
 	"os"
 
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
 	"github.com/redis/go-redis/v9"
 )
 
 const (
-	SnapshotKey = "rules:snapshot"
-	VersionKey = "rules:version"
+	SnapshotKey = "{rules}:snapshot"
+	VersionKey = "{rules}:version"
 )
 
 // Snapshot represents the serialized rule indexes.
@@ -123,6 +124,11 @@ func main() {
 		log.Fatalf("Failed to write snapshot to Redis: %v", err)
 	}
 
+	// Write its checksum, which the evaluator verifies on load
+	if err := client.Set(ctx, shared.RuleSnapshotChecksumKey(SnapshotKey), shared.RuleSnapshotChecksum(data), 0).Err(); err != nil {
+		log.Fatalf("Failed to write snapshot checksum to Redis: %v", err)
+	}
+
 	// Set version
 	if err := client.Set(ctx, VersionKey, 1, 0).Err(); err != nil {
 		log.Fatalf("Failed to write version to Redis: %v", err)
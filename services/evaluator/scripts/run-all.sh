@@ -69,7 +69,7 @@ echo ""
 echo_step "4/5 Checking for rule snapshot in Redis..."
 REDIS_CONTAINER="alerting-platform-redis"
 if docker ps --format "{{.Names}}" | grep -q "^${REDIS_CONTAINER}$"; then
-    SNAPSHOT_EXISTS=$(docker exec "$REDIS_CONTAINER" redis-cli EXISTS rules:snapshot 2>/dev/null | grep -q "1" && echo "yes" || echo "no")
+    SNAPSHOT_EXISTS=$(docker exec "$REDIS_CONTAINER" redis-cli EXISTS "{rules}:snapshot" 2>/dev/null | grep -q "1" && echo "yes" || echo "no")
     if [ "$SNAPSHOT_EXISTS" != "yes" ]; then
         echo_warn "Rule snapshot not found in Redis"
         echo_warn "This is OK - rule-updater will create it when rules are added"
@@ -95,7 +95,7 @@ fi
 # Run with provided args or defaults
 KAFKA_BROKERS="${KAFKA_BROKERS:-localhost:9092}"
 REDIS_ADDR="${REDIS_ADDR:-localhost:6379}"
-VERSION_POLL_INTERVAL="${VERSION_POLL_INTERVAL:-5s}"
+VERSION_POLL_INTERVAL="${VERSION_POLL_INTERVAL:-30s}"
 
 ARGS="${ARGS:--kafka-brokers $KAFKA_BROKERS -redis-addr $REDIS_ADDR -version-poll-interval $VERSION_POLL_INTERVAL}"
 
@@ -3,6 +3,11 @@ package config
 
 import (
 	"fmt"
+	"time"
+
+	"metrics-service/internal/lag"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
 )
 
 // Config holds all configuration parameters for the metrics-service.
@@ -10,6 +15,34 @@ type Config struct {
 	HTTPPort    string
 	PostgresDSN string
 	RedisAddr   string
+
+	// CanaryMaxAge is how long after the last canary delivery the pipeline is reported healthy
+	CanaryMaxAge time.Duration
+	// PropagationThreshold is the rule propagation latency above which rules are reported as slow
+	PropagationThreshold time.Duration
+	// KPIInterval is how often MTTA/MTTR are aggregated from newly closed notifications (0 disables)
+	KPIInterval time.Duration
+	// SLOFile is a JSON file of SLOs per pipeline stage (empty uses the default SLOs)
+	SLOFile string
+	// SLOInterval is how often stage metrics are sampled for SLO error budgets (0 disables)
+	SLOInterval time.Duration
+	// KafkaBrokers is where consumer group lag is read from (empty disables lag monitoring)
+	KafkaBrokers string
+	// LagTargets are the group:topic pairs whose lag is exported for autoscaling
+	LagTargets string
+	// LagInterval is how often consumer group lag is measured
+	LagInterval time.Duration
+	// APIKeyAuth requires an API key on every HTTP route except health checks and the lag scrape
+	APIKeyAuth bool
+	// APIKeyCacheTTL is how long a valid API key is trusted before it is looked up again (0 uses the default)
+	APIKeyCacheTTL time.Duration
+	// HealthCacheTTL is how long a /healthz and /readyz dependency check is reused by later probes
+	HealthCacheTTL time.Duration
+	// AdminToken is accepted as a static admin API key (empty accepts only issued keys)
+	AdminToken string
+
+	// CORS sets which browser origins may call the HTTP API.
+	CORS shared.CORSFlags
 }
 
 // Validate checks that all required configuration fields are set and have valid values.
@@ -23,5 +56,34 @@ func (c *Config) Validate() error {
 	if c.RedisAddr == "" {
 		return fmt.Errorf("redis-addr cannot be empty")
 	}
+	if c.CanaryMaxAge <= 0 {
+		return fmt.Errorf("canary-max-age must be positive")
+	}
+	if c.PropagationThreshold <= 0 {
+		return fmt.Errorf("propagation-threshold must be positive")
+	}
+	if c.KPIInterval < 0 {
+		return fmt.Errorf("kpi-interval cannot be negative")
+	}
+	if c.SLOInterval < 0 {
+		return fmt.Errorf("slo-interval cannot be negative")
+	}
+	if c.APIKeyCacheTTL < 0 {
+		return fmt.Errorf("api-key-cache-ttl cannot be negative")
+	}
+	if c.HealthCacheTTL < 0 {
+		return fmt.Errorf("health-cache-ttl cannot be negative")
+	}
+	if c.KafkaBrokers != "" {
+		if _, err := lag.ParseTargets(c.LagTargets); err != nil {
+			return fmt.Errorf("invalid lag-targets: %w", err)
+		}
+		if c.LagInterval <= 0 {
+			return fmt.Errorf("lag-interval must be positive")
+		}
+	}
+	if _, err := c.CORS.Policy(); err != nil {
+		return fmt.Errorf("invalid CORS configuration: %w", err)
+	}
 	return nil
 }
@@ -0,0 +1,100 @@
+// Package database provides database operations for the metrics-service.
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/afikmenashe/alerting-platform/pkg/metrics"
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+	"github.com/lib/pq"
+)
+
+// AlertNotification is a notification created for an alert, as stored by the aggregator.
+// The stage times are nil if unknown; StageLatencyMs attributes the notification's latency to
+// the pipeline stages whose start and end are known (see stageLatencies).
+type AlertNotification struct {
+	NotificationID string           `json:"notification_id"`
+	ClientID       string           `json:"client_id"`
+	Status         string           `json:"status"`
+	RuleIDs        []string         `json:"rule_ids"`
+	EvaluatedAt    *time.Time       `json:"evaluated_at,omitempty"`
+	AggregatedAt   *time.Time       `json:"aggregated_at,omitempty"`
+	ReadyAt        *time.Time       `json:"ready_at,omitempty"`
+	StageLatencyMs map[string]int64 `json:"stage_latency_ms,omitempty"`
+	CreatedAt      time.Time        `json:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+}
+
+// GetNotificationsByAlertID returns every notification created for an alert, oldest first.
+// An alert matching several clients has one notification per client.
+func (db *DB) GetNotificationsByAlertID(ctx context.Context, alertID string) ([]AlertNotification, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	rows, err := db.conn.QueryContext(queryCtx, `
+		SELECT notification_id, client_id, COALESCE(status, ''), COALESCE(rule_ids, '{}'),
+			evaluated_at, aggregated_at, ready_at, created_at, updated_at
+		FROM notifications
+		WHERE alert_id = $1
+		ORDER BY created_at ASC, client_id ASC
+	`, alertID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query alert notifications: %w", err)
+	}
+	defer rows.Close()
+
+	notifications := make([]AlertNotification, 0)
+	for rows.Next() {
+		var n AlertNotification
+		var evaluatedAt, aggregatedAt, readyAt sql.NullTime
+		if err := rows.Scan(&n.NotificationID, &n.ClientID, &n.Status, pq.Array(&n.RuleIDs),
+			&evaluatedAt, &aggregatedAt, &readyAt, &n.CreatedAt, &n.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan alert notification: %w", err)
+		}
+		n.EvaluatedAt = timePtr(evaluatedAt)
+		n.AggregatedAt = timePtr(aggregatedAt)
+		n.ReadyAt = timePtr(readyAt)
+		n.StageLatencyMs = stageLatencies(n)
+		notifications = append(notifications, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating alert notifications: %w", err)
+	}
+	return notifications, nil
+}
+
+// stageLatencies attributes a notification's latency to the pipeline stages: aggregation
+// (evaluated → aggregated) and ready (aggregated → ready) from the stored stage times, and
+// delivery (ready → updated) while the notification's last update is its delivery, i.e. it
+// is SENT or PARTIALLY_SENT. Stages whose start or end is unknown are omitted.
+func stageLatencies(n AlertNotification) map[string]int64 {
+	latencies := make(map[string]int64, 3)
+	addStage := func(stage string, start, end *time.Time) {
+		if start == nil || end == nil {
+			return
+		}
+		// Clocks differ across hosts; never report a negative latency
+		latencies[stage] = max(end.Sub(*start).Milliseconds(), 0)
+	}
+	addStage(metrics.LatencyStageAggregation, n.EvaluatedAt, n.AggregatedAt)
+	addStage(metrics.LatencyStageReady, n.AggregatedAt, n.ReadyAt)
+	switch shared.NotificationStatus(n.Status) {
+	case shared.NotificationSent, shared.NotificationPartiallySent:
+		addStage(metrics.LatencyStageDelivery, n.ReadyAt, &n.UpdatedAt)
+	}
+	if len(latencies) == 0 {
+		return nil
+	}
+	return latencies
+}
+
+// timePtr returns a nullable timestamp as a pointer, nil if NULL.
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
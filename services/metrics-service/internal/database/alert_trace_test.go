@@ -0,0 +1,56 @@
+// Package database provides tests for alert trace queries.
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+// TestStageLatencies tests that latency is attributed to the stages whose times are known.
+func TestStageLatencies(t *testing.T) {
+	at := func(ms int) *time.Time {
+		t := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(ms) * time.Millisecond)
+		return &t
+	}
+
+	tests := []struct {
+		name string
+		n    AlertNotification
+		want map[string]int64
+	}{
+		{
+			name: "sent",
+			n:    AlertNotification{Status: "SENT", EvaluatedAt: at(0), AggregatedAt: at(20), ReadyAt: at(25), UpdatedAt: *at(400)},
+			want: map[string]int64{"aggregation": 20, "ready": 5, "delivery": 375},
+		},
+		{
+			name: "not delivered yet",
+			n:    AlertNotification{Status: "SENDING", EvaluatedAt: at(0), AggregatedAt: at(20), ReadyAt: at(25), UpdatedAt: *at(30)},
+			want: map[string]int64{"aggregation": 20, "ready": 5},
+		},
+		{
+			name: "evaluation time unknown and clock skew",
+			n:    AlertNotification{Status: "PARTIALLY_SENT", AggregatedAt: at(20), ReadyAt: at(15), UpdatedAt: *at(100)},
+			want: map[string]int64{"ready": 0, "delivery": 85},
+		},
+		{
+			name: "stored before stage times",
+			n:    AlertNotification{Status: "SENT", UpdatedAt: *at(100)},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := stageLatencies(tt.n)
+			if len(got) != len(tt.want) || (tt.want == nil) != (got == nil) {
+				t.Fatalf("stageLatencies() = %v, want %v", got, tt.want)
+			}
+			for stage, ms := range tt.want {
+				if got[stage] != ms {
+					t.Errorf("stageLatencies()[%s] = %d, want %d", stage, got[stage], ms)
+				}
+			}
+		})
+	}
+}
@@ -6,14 +6,19 @@ import (
 	"database/sql"
 	"fmt"
 	"log/slog"
+	"sync"
 	"time"
 
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
 	_ "github.com/lib/pq"
 )
 
 // DB wraps a database connection and provides read-only metrics queries.
 type DB struct {
 	conn *sql.DB
+
+	stmtMu sync.Mutex
+	stmts  map[string]*sql.Stmt // prepared report statements, by report name
 }
 
 // NewDB creates a new database connection using the provided DSN.
@@ -43,9 +48,27 @@ func NewDB(dsn string) (*DB, error) {
 
 // Close closes the database connection.
 func (db *DB) Close() error {
+	db.stmtMu.Lock()
+	for _, stmt := range db.stmts {
+		stmt.Close()
+	}
+	db.stmts = nil
+	db.stmtMu.Unlock()
+
 	if db.conn != nil {
 		slog.Info("Closing database connection")
 		return db.conn.Close()
 	}
 	return nil
 }
+
+// Ping checks that the database is reachable, for health and readiness probes.
+func (db *DB) Ping(ctx context.Context) error {
+	return db.conn.PingContext(ctx)
+}
+
+// LookupAPIKey returns the principal of the unrevoked API key with the given digest, or nil.
+// Keys are issued by rule-service.
+func (db *DB) LookupAPIKey(ctx context.Context, keyHash string) (*shared.APIPrincipal, error) {
+	return shared.NewSQLAPIKeyStore(db.conn).LookupAPIKey(ctx, keyHash)
+}
@@ -0,0 +1,59 @@
+// Package database provides database operations for the metrics-service.
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ClosedNotification is a notification that was acknowledged or resolved.
+type ClosedNotification struct {
+	ClientID       string
+	RuleIDs        []string
+	CreatedAt      time.Time
+	AcknowledgedAt *time.Time
+	ResolvedAt     *time.Time
+}
+
+// closedNotificationsQuery selects notifications acknowledged or resolved in ($1, $2].
+const closedNotificationsQuery = `
+	SELECT client_id, rule_ids, created_at, acknowledged_at, resolved_at
+	FROM notifications
+	WHERE (acknowledged_at > $1 AND acknowledged_at <= $2)
+	   OR (resolved_at > $1 AND resolved_at <= $2)
+`
+
+// ListClosedNotifications streams the notifications acknowledged or resolved in (after, until]
+// to fn. Iteration stops at the first error returned by fn.
+func (db *DB) ListClosedNotifications(ctx context.Context, after, until time.Time, fn func(ClosedNotification) error) error {
+	rows, err := db.conn.QueryContext(ctx, closedNotificationsQuery, after, until)
+	if err != nil {
+		return fmt.Errorf("failed to query closed notifications: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var n ClosedNotification
+		var acknowledgedAt, resolvedAt sql.NullTime
+		if err := rows.Scan(&n.ClientID, pq.Array(&n.RuleIDs), &n.CreatedAt, &acknowledgedAt, &resolvedAt); err != nil {
+			return fmt.Errorf("failed to scan closed notification: %w", err)
+		}
+		if acknowledgedAt.Valid {
+			n.AcknowledgedAt = &acknowledgedAt.Time
+		}
+		if resolvedAt.Valid {
+			n.ResolvedAt = &resolvedAt.Time
+		}
+		if err := fn(n); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating closed notifications: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+// Package database provides database operations for the metrics-service.
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ErrUnknownReport is returned for report names that are not in the allow-list.
+var ErrUnknownReport = errors.New("unknown report")
+
+// ReportParams are the only inputs a report accepts. They are bound as query parameters
+// ($1 from, $2 to, $3 client_id), never interpolated into SQL.
+type ReportParams struct {
+	From     time.Time
+	To       time.Time
+	ClientID string // empty means all clients
+}
+
+// ReportInfo describes an available report.
+type ReportInfo struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Columns     []string `json:"columns"`
+}
+
+// Report is the result of a report query.
+type Report struct {
+	ReportInfo
+	From        time.Time                `json:"from"`
+	To          time.Time                `json:"to"`
+	ClientID    string                   `json:"client_id,omitempty"`
+	Rows        []map[string]interface{} `json:"rows"`
+	CollectedAt time.Time                `json:"collected_at"`
+}
+
+// reportDefinition is an allow-listed report query.
+type reportDefinition struct {
+	ReportInfo
+	query string
+}
+
+// reports is the allow-list of report queries, keyed by name.
+var reports = map[string]reportDefinition{
+	"severity-daily": {
+		ReportInfo: ReportInfo{
+			Name:        "severity-daily",
+			Description: "Notifications per day and severity",
+			Columns:     []string{"day", "severity", "notifications"},
+		},
+		query: `
+			SELECT date_trunc('day', created_at) AS day, severity, COUNT(*) AS notifications
+			FROM notifications
+			WHERE created_at >= $1 AND created_at < $2
+			  AND ($3 = '' OR client_id = $3)
+			GROUP BY 1, 2
+			ORDER BY 1, 2
+		`,
+	},
+	"delivery-success-weekly": {
+		ReportInfo: ReportInfo{
+			Name:        "delivery-success-weekly",
+			Description: "Send attempts and success rate per week and channel",
+			Columns:     []string{"week", "channel", "attempts", "succeeded", "success_rate_pct"},
+		},
+		query: `
+			SELECT
+				date_trunc('week', e.created_at) AS week,
+				e.endpoint_type AS channel,
+				COUNT(*) AS attempts,
+				COUNT(*) FILTER (WHERE e.error IS NULL) AS succeeded,
+				ROUND(100.0 * COUNT(*) FILTER (WHERE e.error IS NULL) / COUNT(*), 2)::float8 AS success_rate_pct
+			FROM notification_events e
+			JOIN notifications n ON n.notification_id = e.notification_id
+			WHERE e.event_type = 'send_attempt' AND e.endpoint_type IS NOT NULL
+			  AND e.created_at >= $1 AND e.created_at < $2
+			  AND ($3 = '' OR n.client_id = $3)
+			GROUP BY 1, 2
+			ORDER BY 1, 2
+		`,
+	},
+	"time-to-acknowledge": {
+		ReportInfo: ReportInfo{
+			Name:        "time-to-acknowledge",
+			Description: "Mean and median seconds from notification to acknowledgement, per severity",
+			Columns:     []string{"severity", "acknowledged", "mean_seconds", "median_seconds"},
+		},
+		query: `
+			SELECT
+				severity,
+				COUNT(*) AS acknowledged,
+				ROUND(AVG(EXTRACT(EPOCH FROM (acknowledged_at - created_at)))::numeric, 1)::float8 AS mean_seconds,
+				ROUND((PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM (acknowledged_at - created_at))))::numeric, 1)::float8 AS median_seconds
+			FROM notifications
+			WHERE acknowledged_at IS NOT NULL
+			  AND created_at >= $1 AND created_at < $2
+			  AND ($3 = '' OR client_id = $3)
+			GROUP BY severity
+			ORDER BY severity
+		`,
+	},
+}
+
+// Reports returns the available reports, ordered by name.
+func Reports() []ReportInfo {
+	infos := make([]ReportInfo, 0, len(reports))
+	for _, def := range reports {
+		infos = append(infos, def.ReportInfo)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// RunReport runs the named report. Report statements are prepared on first use and reused.
+// Returns ErrUnknownReport if name is not an allow-listed report.
+func (db *DB) RunReport(ctx context.Context, name string, params ReportParams) (*Report, error) {
+	def, ok := reports[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownReport, name)
+	}
+
+	queryCtx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	stmt, err := db.prepare(queryCtx, name, def.query)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.QueryContext(queryCtx, params.From, params.To, params.ClientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run report %s: %w", name, err)
+	}
+	defer rows.Close()
+
+	result, err := scanReportRows(rows, def.Columns)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan report %s: %w", name, err)
+	}
+
+	return &Report{
+		ReportInfo:  def.ReportInfo,
+		From:        params.From,
+		To:          params.To,
+		ClientID:    params.ClientID,
+		Rows:        result,
+		CollectedAt: time.Now().UTC(),
+	}, nil
+}
+
+// prepare returns the prepared statement for a report, preparing it on first use.
+func (db *DB) prepare(ctx context.Context, name, query string) (*sql.Stmt, error) {
+	db.stmtMu.Lock()
+	defer db.stmtMu.Unlock()
+
+	if stmt, ok := db.stmts[name]; ok {
+		return stmt, nil
+	}
+	stmt, err := db.conn.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare report %s: %w", name, err)
+	}
+	if db.stmts == nil {
+		db.stmts = make(map[string]*sql.Stmt)
+	}
+	db.stmts[name] = stmt
+	return stmt, nil
+}
+
+// scanReportRows scans rows into maps keyed by the report's columns.
+func scanReportRows(rows *sql.Rows, columns []string) ([]map[string]interface{}, error) {
+	result := make([]map[string]interface{}, 0)
+	values := make([]interface{}, len(columns))
+	ptrs := make([]interface{}, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		row := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			if b, ok := values[i].([]byte); ok {
+				row[column] = string(b)
+			} else {
+				row[column] = values[i]
+			}
+		}
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
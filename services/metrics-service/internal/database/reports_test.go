@@ -0,0 +1,70 @@
+// Package database provides tests for report queries.
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// TestDB_RunReport tests that a report's statement is prepared once and reused.
+func TestDB_RunReport(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	d := &DB{conn: db}
+	ctx := context.Background()
+
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)
+	prep := mock.ExpectPrepare("FROM notifications")
+	prep.ExpectQuery().WithArgs(from, to, "client-1").
+		WillReturnRows(sqlmock.NewRows([]string{"day", "severity", "notifications"}).
+			AddRow(from, "HIGH", int64(4)).
+			AddRow(from, "LOW", int64(9)))
+	// The prepared statement is reused by the next run
+	prep.ExpectQuery().WithArgs(from, to, "").
+		WillReturnRows(sqlmock.NewRows([]string{"day", "severity", "notifications"}))
+
+	report, err := d.RunReport(ctx, "severity-daily", ReportParams{From: from, To: to, ClientID: "client-1"})
+	if err != nil {
+		t.Fatalf("RunReport() error = %v", err)
+	}
+	if report.Name != "severity-daily" || report.ClientID != "client-1" || len(report.Rows) != 2 {
+		t.Fatalf("RunReport() = %+v, want 2 severity-daily rows for client-1", report)
+	}
+	if report.Rows[1]["severity"] != "LOW" || report.Rows[1]["notifications"] != int64(9) {
+		t.Errorf("row = %v, want LOW with 9 notifications", report.Rows[1])
+	}
+
+	if _, err := d.RunReport(ctx, "severity-daily", ReportParams{From: from, To: to}); err != nil {
+		t.Errorf("second RunReport() error = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Mock expectations were not met: %v", err)
+	}
+}
+
+// TestDB_RunReport_Unknown tests that names outside the allow-list are rejected without a query.
+func TestDB_RunReport_Unknown(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	d := &DB{conn: db}
+
+	if _, err := d.RunReport(context.Background(), "drop-tables", ReportParams{}); !errors.Is(err, ErrUnknownReport) {
+		t.Errorf("RunReport() error = %v, want %v", err, ErrUnknownReport)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Mock expectations were not met: %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+// Package database provides database operations for the metrics-service.
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// DefaultNoisiestRulesLimit is the default number of rules returned by the noisiest rules report.
+const DefaultNoisiestRulesLimit = 10
+
+// RuleRate holds notification counts and rates for a single rule.
+type RuleRate struct {
+	RuleID   string `json:"rule_id"`
+	ClientID string `json:"client_id,omitempty"`
+	Severity string `json:"severity,omitempty"`
+	Source   string `json:"source,omitempty"`
+	Name     string `json:"name,omitempty"`
+
+	// Notification counts per window
+	Last1h  int64 `json:"last_1h"`
+	Last24h int64 `json:"last_24h"`
+	Last7d  int64 `json:"last_7d"`
+
+	// Average notifications per hour over each window
+	RatePerHour1h  float64 `json:"rate_per_hour_1h"`
+	RatePerHour24h float64 `json:"rate_per_hour_24h"`
+	RatePerHour7d  float64 `json:"rate_per_hour_7d"`
+
+	// Week-over-week comparison: last 7 days vs the 7 days before
+	Previous7d         int64    `json:"previous_7d"`
+	WeekOverWeekDelta  int64    `json:"week_over_week_delta"`
+	WeekOverWeekChange *float64 `json:"week_over_week_change_pct,omitempty"` // nil when the previous week had no notifications
+}
+
+// RuleRatesReport wraps per-rule rates with the time they were computed.
+type RuleRatesReport struct {
+	Rules       []RuleRate `json:"rules"`
+	CollectedAt time.Time  `json:"collected_at"`
+}
+
+// ruleRatesQuery counts notifications per rule over the last 14 days.
+// rule_ids is unnested so a notification matching several rules counts once for each.
+// $1 optionally restricts to a single rule_id, $2 limits the number of rows.
+const ruleRatesQuery = `
+	WITH per_rule AS (
+		SELECT
+			r.rule_id,
+			COUNT(*) FILTER (WHERE n.created_at >= NOW() - INTERVAL '1 hour') AS last_1h,
+			COUNT(*) FILTER (WHERE n.created_at >= NOW() - INTERVAL '24 hours') AS last_24h,
+			COUNT(*) FILTER (WHERE n.created_at >= NOW() - INTERVAL '7 days') AS last_7d,
+			COUNT(*) FILTER (WHERE n.created_at < NOW() - INTERVAL '7 days') AS previous_7d
+		FROM notifications n
+		CROSS JOIN LATERAL unnest(n.rule_ids) AS r(rule_id)
+		WHERE n.created_at >= NOW() - INTERVAL '14 days'
+		  AND ($1 = '' OR r.rule_id = $1)
+		GROUP BY r.rule_id
+	)
+	SELECT
+		p.rule_id,
+		COALESCE(ru.client_id, ''),
+		COALESCE(ru.severity, ''),
+		COALESCE(ru.source, ''),
+		COALESCE(ru.name, ''),
+		p.last_1h, p.last_24h, p.last_7d, p.previous_7d
+	FROM per_rule p
+	LEFT JOIN rules ru ON ru.rule_id = p.rule_id
+	ORDER BY p.last_7d DESC, p.last_24h DESC, p.rule_id ASC
+	LIMIT $2
+`
+
+// GetRuleRates returns notification rates for rules ordered from noisiest to quietest.
+// If ruleID is non-empty only that rule is returned. limit must be positive.
+func (db *DB) GetRuleRates(ctx context.Context, ruleID string, limit int) (*RuleRatesReport, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	rows, err := db.conn.QueryContext(queryCtx, ruleRatesQuery, ruleID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rule rates: %w", err)
+	}
+	defer rows.Close()
+
+	rules, err := scanRuleRates(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RuleRatesReport{
+		Rules:       rules,
+		CollectedAt: time.Now().UTC(),
+	}, nil
+}
+
+// scanRuleRates scans rule rate rows and derives rates and week-over-week deltas.
+func scanRuleRates(rows *sql.Rows) ([]RuleRate, error) {
+	rules := make([]RuleRate, 0)
+	for rows.Next() {
+		var rr RuleRate
+		if err := rows.Scan(
+			&rr.RuleID, &rr.ClientID, &rr.Severity, &rr.Source, &rr.Name,
+			&rr.Last1h, &rr.Last24h, &rr.Last7d, &rr.Previous7d,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan rule rate: %w", err)
+		}
+		rr.computeDerived()
+		rules = append(rules, rr)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rule rates: %w", err)
+	}
+	return rules, nil
+}
+
+// computeDerived fills in per-hour rates and week-over-week comparison from the raw counts.
+func (rr *RuleRate) computeDerived() {
+	rr.RatePerHour1h = float64(rr.Last1h)
+	rr.RatePerHour24h = float64(rr.Last24h) / 24
+	rr.RatePerHour7d = float64(rr.Last7d) / (7 * 24)
+
+	rr.WeekOverWeekDelta = rr.Last7d - rr.Previous7d
+	if rr.Previous7d > 0 {
+		pct := float64(rr.WeekOverWeekDelta) / float64(rr.Previous7d) * 100
+		rr.WeekOverWeekChange = &pct
+	}
+}
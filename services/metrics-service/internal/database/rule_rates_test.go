@@ -0,0 +1,45 @@
+// Package database provides tests for rule rate queries.
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// TestDB_GetRuleRates tests that rates and week-over-week deltas are derived from the counts.
+func TestDB_GetRuleRates(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	d := &DB{conn: db}
+
+	rows := sqlmock.NewRows([]string{"rule_id", "client_id", "severity", "source", "name", "last_1h", "last_24h", "last_7d", "previous_7d"}).
+		AddRow("rule-1", "client-1", "HIGH", "payments", "timeout", int64(2), int64(48), int64(336), int64(168))
+	mock.ExpectQuery("WITH per_rule AS").WithArgs("rule-1", 50).WillReturnRows(rows)
+
+	report, err := d.GetRuleRates(context.Background(), "rule-1", 50)
+	if err != nil {
+		t.Fatalf("GetRuleRates() error = %v", err)
+	}
+	if len(report.Rules) != 1 {
+		t.Fatalf("GetRuleRates() returned %d rules, want 1", len(report.Rules))
+	}
+	rr := report.Rules[0]
+	if rr.RatePerHour24h != 2 || rr.RatePerHour7d != 2 {
+		t.Errorf("rates = %v/%v, want 2/2", rr.RatePerHour24h, rr.RatePerHour7d)
+	}
+	if rr.WeekOverWeekDelta != 168 {
+		t.Errorf("WeekOverWeekDelta = %v, want 168", rr.WeekOverWeekDelta)
+	}
+	if rr.WeekOverWeekChange == nil || *rr.WeekOverWeekChange != 100 {
+		t.Errorf("WeekOverWeekChange = %v, want 100", rr.WeekOverWeekChange)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Mock expectations were not met: %v", err)
+	}
+}
@@ -0,0 +1,37 @@
+// Package handlers provides HTTP handlers for the metrics-service API.
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// SetCanaryMaxAge sets how long after the last canary delivery the pipeline is reported healthy.
+func (h *Handlers) SetCanaryMaxAge(maxAge time.Duration) {
+	h.canaryMaxAge = maxAge
+}
+
+// GetCanaryStatus returns end-to-end pipeline health from the synthetic canary loop:
+// status, last delivery latency, and sent/delivered counts.
+// GET /api/v1/canary
+func (h *Handlers) GetCanaryStatus(w http.ResponseWriter, r *http.Request) {
+	if h.metricsReader == nil {
+		slog.Error("Metrics reader not configured")
+		http.Error(w, "Metrics reader not available", http.StatusInternalServerError)
+		return
+	}
+
+	status, err := h.metricsReader.GetCanaryStatus(r.Context(), h.canaryMaxAge)
+	if err != nil {
+		slog.Error("Failed to get canary status", "error", err)
+		http.Error(w, "Failed to retrieve canary status", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		slog.Error("Failed to encode canary status response", "error", err)
+	}
+}
@@ -0,0 +1,88 @@
+// Package handlers provides HTTP handlers for the metrics-service API.
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"metrics-service/internal/lag"
+)
+
+// LagReader reports the latest measured lag of the pipeline's consumer groups.
+type LagReader interface {
+	Lags() []lag.GroupLag
+	Lag(group string) (int64, bool)
+}
+
+// SetLagReader sets where consumer group lag is read from.
+func (h *Handlers) SetLagReader(reader LagReader) {
+	h.lag = reader
+}
+
+// ConsumerGroupLagResponse is the lag of one consumer group, shaped for the KEDA metrics-api
+// scaler (valueLocation "lag").
+type ConsumerGroupLagResponse struct {
+	Group string `json:"group"`
+	Lag   int64  `json:"lag"`
+}
+
+// GetConsumerLag returns the latest lag of every monitored consumer group per topic and partition.
+// GET /api/v1/consumer-lag
+func (h *Handlers) GetConsumerLag(w http.ResponseWriter, r *http.Request) {
+	if h.lag == nil {
+		http.Error(w, "Consumer lag not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(h.lag.Lags()); err != nil {
+		slog.Error("Failed to encode consumer lag response", "error", err)
+	}
+}
+
+// GetConsumerGroupLag returns the total lag of one consumer group.
+// GET /api/v1/consumer-lag/{group}
+func (h *Handlers) GetConsumerGroupLag(w http.ResponseWriter, r *http.Request) {
+	if h.lag == nil {
+		http.Error(w, "Consumer lag not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	group := r.PathValue("group")
+	total, ok := h.lag.Lag(group)
+	if !ok {
+		http.Error(w, "Consumer group not monitored or not measured yet", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(ConsumerGroupLagResponse{Group: group, Lag: total}); err != nil {
+		slog.Error("Failed to encode consumer group lag response", "error", err)
+	}
+}
+
+// GetConsumerLagPrometheus returns consumer group lag in the Prometheus text format, for an
+// HPA external metric served through prometheus-adapter.
+// GET /metrics/consumer-lag
+func (h *Handlers) GetConsumerLagPrometheus(w http.ResponseWriter, r *http.Request) {
+	if h.lag == nil {
+		http.Error(w, "Consumer lag not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("# HELP kafka_consumergroup_lag Messages the consumer group has not committed yet.\n")
+	b.WriteString("# TYPE kafka_consumergroup_lag gauge\n")
+	for _, gl := range h.lag.Lags() {
+		if gl.MeasuredAt.IsZero() {
+			continue
+		}
+		fmt.Fprintf(&b, "kafka_consumergroup_lag{group=%q,topic=%q} %d\n", gl.Group, gl.Topic, gl.Lag)
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Write([]byte(b.String()))
+}
@@ -0,0 +1,127 @@
+// Package handlers provides tests for HTTP handlers.
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"metrics-service/internal/lag"
+)
+
+// fakeLagReader returns fixed consumer group lags.
+type fakeLagReader struct {
+	lags []lag.GroupLag
+}
+
+func (r *fakeLagReader) Lags() []lag.GroupLag {
+	return r.lags
+}
+
+func (r *fakeLagReader) Lag(group string) (int64, bool) {
+	var total int64
+	found := false
+	for _, gl := range r.lags {
+		if gl.Group == group {
+			total += gl.Lag
+			found = true
+		}
+	}
+	return total, found
+}
+
+func newLagHandlers() *Handlers {
+	h := NewHandlers(nil, nil, nil)
+	h.SetLagReader(&fakeLagReader{lags: []lag.GroupLag{
+		{Group: "evaluator-group", Topic: "alerts.new", Lag: 12, MeasuredAt: time.Now()},
+		{Group: "sender-group", Topic: "notifications.ready", Lag: 3, MeasuredAt: time.Now()},
+	}})
+	return h
+}
+
+// TestHandlers_ConsumerLag_NotConfigured tests that the lag endpoints report 503 without a reader.
+func TestHandlers_ConsumerLag_NotConfigured(t *testing.T) {
+	h := NewHandlers(nil, nil, nil)
+	for _, handle := range []http.HandlerFunc{h.GetConsumerLag, h.GetConsumerGroupLag, h.GetConsumerLagPrometheus} {
+		w := httptest.NewRecorder()
+		handle(w, httptest.NewRequest(http.MethodGet, "/api/v1/consumer-lag", nil))
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("status = %v, want %v", w.Code, http.StatusServiceUnavailable)
+		}
+	}
+}
+
+// TestHandlers_GetConsumerLag tests the GetConsumerLag handler.
+func TestHandlers_GetConsumerLag(t *testing.T) {
+	h := newLagHandlers()
+	w := httptest.NewRecorder()
+	h.GetConsumerLag(w, httptest.NewRequest(http.MethodGet, "/api/v1/consumer-lag", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("GetConsumerLag() status = %v, want %v", w.Code, http.StatusOK)
+	}
+	var lags []lag.GroupLag
+	if err := json.NewDecoder(w.Body).Decode(&lags); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(lags) != 2 || lags[0].Lag != 12 {
+		t.Errorf("GetConsumerLag() = %+v, want both groups", lags)
+	}
+}
+
+// TestHandlers_GetConsumerGroupLag tests the GetConsumerGroupLag handler.
+func TestHandlers_GetConsumerGroupLag(t *testing.T) {
+	h := newLagHandlers()
+
+	t.Run("monitored group", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/consumer-lag/sender-group", nil)
+		req.SetPathValue("group", "sender-group")
+		w := httptest.NewRecorder()
+		h.GetConsumerGroupLag(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("GetConsumerGroupLag() status = %v, want %v", w.Code, http.StatusOK)
+		}
+		var resp ConsumerGroupLagResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if resp.Group != "sender-group" || resp.Lag != 3 {
+			t.Errorf("GetConsumerGroupLag() = %+v, want sender-group lag 3", resp)
+		}
+	})
+
+	t.Run("unknown group", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/consumer-lag/other", nil)
+		req.SetPathValue("group", "other")
+		w := httptest.NewRecorder()
+		h.GetConsumerGroupLag(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("GetConsumerGroupLag() status = %v, want %v", w.Code, http.StatusNotFound)
+		}
+	})
+}
+
+// TestHandlers_GetConsumerLagPrometheus tests the Prometheus text output.
+func TestHandlers_GetConsumerLagPrometheus(t *testing.T) {
+	h := newLagHandlers()
+	w := httptest.NewRecorder()
+	h.GetConsumerLagPrometheus(w, httptest.NewRequest(http.MethodGet, "/metrics/consumer-lag", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("GetConsumerLagPrometheus() status = %v, want %v", w.Code, http.StatusOK)
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		"# TYPE kafka_consumergroup_lag gauge",
+		`kafka_consumergroup_lag{group="evaluator-group",topic="alerts.new"} 12`,
+		`kafka_consumergroup_lag{group="sender-group",topic="notifications.ready"} 3`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("GetConsumerLagPrometheus() body missing %q:\n%s", want, body)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// Package handlers provides HTTP handlers for the metrics-service API.
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sort"
+
+	"metrics-service/internal/database"
+
+	"github.com/afikmenashe/alerting-platform/pkg/metrics"
+)
+
+// AlertTraceResponse is the end-to-end trace of a single alert through the pipeline.
+type AlertTraceResponse struct {
+	AlertID       string                       `json:"alert_id"`
+	Events        []metrics.TraceEvent         `json:"events"`
+	Notifications []database.AlertNotification `json:"notifications"`
+}
+
+// GetAlertTrace returns everything the pipeline recorded about one alert: the evaluator,
+// aggregator, and sender trace events in time order, plus the notifications stored for it.
+// Trace events expire after metrics.TraceTTL; notifications are kept until retention removes them.
+// GET /api/v1/debug/alert/{alert_id}
+func (h *Handlers) GetAlertTrace(w http.ResponseWriter, r *http.Request) {
+	alertID := r.PathValue("alert_id")
+	if alertID == "" {
+		http.Error(w, "alert_id is required", http.StatusBadRequest)
+		return
+	}
+	if h.metricsReader == nil {
+		slog.Error("Metrics reader not configured")
+		http.Error(w, "Metrics reader not available", http.StatusInternalServerError)
+		return
+	}
+
+	events, err := h.metricsReader.GetAlertTrace(r.Context(), alertID)
+	if err != nil {
+		slog.Error("Failed to get alert trace", "alert_id", alertID, "error", err)
+		http.Error(w, "Failed to retrieve alert trace", http.StatusInternalServerError)
+		return
+	}
+	// Services write independently, so order by event time rather than write order
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp.Before(events[j].Timestamp)
+	})
+
+	notifications, err := h.db.GetNotificationsByAlertID(r.Context(), alertID)
+	if err != nil {
+		slog.Error("Failed to get alert notifications", "alert_id", alertID, "error", err)
+		http.Error(w, "Failed to retrieve alert notifications", http.StatusInternalServerError)
+		return
+	}
+
+	if len(events) == 0 && len(notifications) == 0 {
+		http.Error(w, "No trace found for alert: "+alertID, http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(AlertTraceResponse{
+		AlertID:       alertID,
+		Events:        events,
+		Notifications: notifications,
+	}); err != nil {
+		slog.Error("Failed to encode alert trace response", "error", err)
+	}
+}
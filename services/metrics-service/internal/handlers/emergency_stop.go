@@ -0,0 +1,56 @@
+// Package handlers provides HTTP handlers for the metrics-service API.
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// EmergencyStopReader reads the platform-wide emergency stop.
+type EmergencyStopReader interface {
+	GetEmergencyStop(ctx context.Context) (shared.EmergencyStop, error)
+}
+
+// ReadinessResponse is returned by /readyz.
+type ReadinessResponse struct {
+	Status        string                             `json:"status"` // ready, unavailable, emergency_stop, or unknown
+	Dependencies  map[string]shared.DependencyStatus `json:"dependencies,omitempty"`
+	EmergencyStop *shared.EmergencyStop              `json:"emergency_stop,omitempty"`
+	Error         string                             `json:"error,omitempty"`
+}
+
+// SetEmergencyStopReader sets where the emergency stop is read from for /readyz and service metrics.
+func (h *Handlers) SetEmergencyStopReader(reader EmergencyStopReader) {
+	h.emergencyStop = reader
+}
+
+// Readiness reports whether the service can serve requests and the platform is delivering
+// notifications: 503 with the status of each dependency while one is down, 503 with the stop
+// reason while the emergency stop is active, 200 otherwise.
+// GET /readyz
+func (h *Handlers) Readiness(w http.ResponseWriter, r *http.Request) {
+	report := h.checkHealth(r)
+	status, response := http.StatusOK, ReadinessResponse{Status: "ready", Dependencies: report.Dependencies}
+	switch {
+	case !report.Healthy():
+		status, response.Status = http.StatusServiceUnavailable, "unavailable"
+	case h.emergencyStop != nil:
+		stop, err := h.emergencyStop.GetEmergencyStop(r.Context())
+		switch {
+		case err != nil:
+			status, response.Status, response.Error = http.StatusServiceUnavailable, "unknown", err.Error()
+		case stop.Active:
+			status, response.Status, response.EmergencyStop = http.StatusServiceUnavailable, "emergency_stop", &stop
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.Error("Failed to encode readiness response", "error", err)
+	}
+}
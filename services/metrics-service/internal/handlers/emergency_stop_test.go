@@ -0,0 +1,60 @@
+// Package handlers provides tests for HTTP handlers.
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// fakeEmergencyStopReader returns a fixed emergency stop.
+type fakeEmergencyStopReader struct {
+	stop shared.EmergencyStop
+	err  error
+}
+
+func (f *fakeEmergencyStopReader) GetEmergencyStop(ctx context.Context) (shared.EmergencyStop, error) {
+	return f.stop, f.err
+}
+
+// TestHandlers_Readiness tests that /readyz surfaces the emergency stop.
+func TestHandlers_Readiness(t *testing.T) {
+	tests := []struct {
+		name       string
+		reader     EmergencyStopReader
+		wantStatus int
+		want       string
+	}{
+		{name: "not configured", reader: nil, wantStatus: http.StatusOK, want: "ready"},
+		{name: "not stopped", reader: &fakeEmergencyStopReader{}, wantStatus: http.StatusOK, want: "ready"},
+		{name: "stopped", reader: &fakeEmergencyStopReader{stop: shared.EmergencyStop{Active: true, Reason: "webhook storm"}}, wantStatus: http.StatusServiceUnavailable, want: "emergency_stop"},
+		{name: "redis error", reader: &fakeEmergencyStopReader{err: errors.New("connection refused")}, wantStatus: http.StatusServiceUnavailable, want: "unknown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandlers(nil, nil, nil)
+			if tt.reader != nil {
+				h.SetEmergencyStopReader(tt.reader)
+			}
+			w := httptest.NewRecorder()
+			h.Readiness(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			var resp ReadinessResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if w.Code != tt.wantStatus || resp.Status != tt.want {
+				t.Errorf("Readiness() = %d %q, want %d %q", w.Code, resp.Status, tt.wantStatus, tt.want)
+			}
+			if tt.want == "emergency_stop" && (resp.EmergencyStop == nil || resp.EmergencyStop.Reason != "webhook storm") {
+				t.Errorf("emergency_stop = %+v, want the stop reason", resp.EmergencyStop)
+			}
+		})
+	}
+}
@@ -2,33 +2,53 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"metrics-service/internal/database"
 
 	"github.com/afikmenashe/alerting-platform/pkg/metrics"
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
 )
 
+// Store is the read-only data access used by the handlers, implemented by *database.DB.
+type Store interface {
+	GetSystemMetrics(ctx context.Context) (*database.SystemMetrics, error)
+	GetRuleRates(ctx context.Context, ruleID string, limit int) (*database.RuleRatesReport, error)
+	RunReport(ctx context.Context, name string, params database.ReportParams) (*database.Report, error)
+	GetNotificationsByAlertID(ctx context.Context, alertID string) ([]database.AlertNotification, error)
+}
+
 // Handlers wraps dependencies for HTTP handlers.
 type Handlers struct {
-	db               *database.DB
-	metricsReader    *metrics.Reader
-	metricsCollector *metrics.Collector
+	db                   Store
+	metricsReader        metrics.Reader
+	metricsCollector     metrics.Collector
+	canaryMaxAge         time.Duration
+	propagationThreshold time.Duration
+	emergencyStop        EmergencyStopReader   // nil omits the emergency stop
+	kpis                 KPIReader             // nil disables the KPI endpoint
+	slo                  SLOReader             // nil disables the SLO endpoint
+	lag                  LagReader             // nil disables the consumer lag endpoints
+	health               *shared.HealthChecker // nil reports the dependencies healthy
 }
 
 // NewHandlers creates a new handlers instance.
-func NewHandlers(db *database.DB, metricsReader *metrics.Reader, metricsCollector *metrics.Collector) *Handlers {
+func NewHandlers(db Store, metricsReader metrics.Reader, metricsCollector metrics.Collector) *Handlers {
 	return &Handlers{
-		db:               db,
-		metricsReader:    metricsReader,
-		metricsCollector: metricsCollector,
+		db:                   db,
+		metricsReader:        metricsReader,
+		metricsCollector:     metricsCollector,
+		canaryMaxAge:         metrics.DefaultCanaryMaxAge,
+		propagationThreshold: metrics.DefaultPropagationThreshold,
 	}
 }
 
 // GetMetricsCollector returns the metrics collector for middleware use.
-func (h *Handlers) GetMetricsCollector() *metrics.Collector {
+func (h *Handlers) GetMetricsCollector() metrics.Collector {
 	return h.metricsCollector
 }
 
@@ -36,6 +56,7 @@ func (h *Handlers) GetMetricsCollector() *metrics.Collector {
 type ServiceMetricsResponse struct {
 	Services      map[string]*metrics.ServiceMetrics `json:"services"`
 	KnownServices []string                           `json:"known_services"`
+	EmergencyStop *shared.EmergencyStop              `json:"emergency_stop,omitempty"`
 }
 
 // GetSystemMetrics returns aggregated system metrics from the database.
@@ -109,6 +130,13 @@ func (h *Handlers) GetServiceMetrics(w http.ResponseWriter, r *http.Request) {
 		Services:      allMetrics,
 		KnownServices: metrics.ServiceNames,
 	}
+	if h.emergencyStop != nil {
+		if stop, err := h.emergencyStop.GetEmergencyStop(ctx); err != nil {
+			slog.Warn("Failed to read emergency stop", "error", err)
+		} else {
+			response.EmergencyStop = &stop
+		}
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
@@ -2,71 +2,58 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
-	"unsafe"
 
-	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/afikmenashe/alerting-platform/pkg/metrics"
 	"metrics-service/internal/database"
 )
 
-// setupTestDB creates a mock database connection for testing.
-func setupTestDB(t *testing.T) (*database.DB, sqlmock.Sqlmock) {
-	dbConn, mock, err := sqlmock.New()
-	if err != nil {
-		t.Fatalf("Failed to create mock: %v", err)
-	}
+// fakeStore returns fixed results and records the arguments it was called with.
+type fakeStore struct {
+	systemMetrics *database.SystemMetrics
+	ruleRates     *database.RuleRatesReport
+	report        *database.Report
+	notifications []database.AlertNotification
+	err           error
 
-	db := &database.DB{}
-	dbPtr := (*struct{ conn *sql.DB })(unsafe.Pointer(db))
-	dbPtr.conn = dbConn
+	ruleID     string
+	limit      int
+	reportName string
+	params     database.ReportParams
+}
 
-	return db, mock
+func (s *fakeStore) GetSystemMetrics(ctx context.Context) (*database.SystemMetrics, error) {
+	return s.systemMetrics, s.err
 }
 
-// TestHandlers_GetSystemMetrics tests the GetSystemMetrics handler.
-func TestHandlers_GetSystemMetrics(t *testing.T) {
-	db, mock := setupTestDB(t)
-	defer db.Close()
+func (s *fakeStore) GetRuleRates(ctx context.Context, ruleID string, limit int) (*database.RuleRatesReport, error) {
+	s.ruleID, s.limit = ruleID, limit
+	return s.ruleRates, s.err
+}
 
-	h := NewHandlers(db, nil, nil)
+func (s *fakeStore) RunReport(ctx context.Context, name string, params database.ReportParams) (*database.Report, error) {
+	s.reportName, s.params = name, params
+	return s.report, s.err
+}
+
+func (s *fakeStore) GetNotificationsByAlertID(ctx context.Context, alertID string) ([]database.AlertNotification, error) {
+	return s.notifications, s.err
+}
 
+// TestHandlers_GetSystemMetrics tests the GetSystemMetrics handler.
+func TestHandlers_GetSystemMetrics(t *testing.T) {
 	t.Run("successful get", func(t *testing.T) {
-		// Mock notification status query
-		statusRows := sqlmock.NewRows([]string{"status", "count"}).
-			AddRow("SENT", int64(10)).
-			AddRow("RECEIVED", int64(5))
-		mock.ExpectQuery("SELECT status, COUNT").WillReturnRows(statusRows)
-
-		// Mock last 24h query
-		mock.ExpectQuery("SELECT COUNT.*FROM notifications.*24 hours").
-			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(int64(8)))
-
-		// Mock last hour query
-		mock.ExpectQuery("SELECT COUNT.*FROM notifications.*1 hour").
-			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(int64(2)))
-
-		// Mock hourly query
-		hourlyRows := sqlmock.NewRows([]string{"hour", "count"}).
-			AddRow(time.Now().Truncate(time.Hour), int64(3))
-		mock.ExpectQuery("SELECT.*date_trunc").WillReturnRows(hourlyRows)
-
-		// Mock rules query
-		mock.ExpectQuery("SELECT.*COUNT.*FROM rules").
-			WillReturnRows(sqlmock.NewRows([]string{"total", "enabled", "disabled"}).AddRow(int64(5), int64(3), int64(2)))
-
-		// Mock clients query
-		mock.ExpectQuery("SELECT COUNT.*FROM clients").
-			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(int64(2)))
-
-		// Mock endpoints query
-		endpointRows := sqlmock.NewRows([]string{"type", "count", "enabled_count"}).
-			AddRow("email", int64(3), int64(2)).
-			AddRow("webhook", int64(1), int64(1))
-		mock.ExpectQuery("SELECT.*type.*COUNT.*FROM endpoints").WillReturnRows(endpointRows)
+		h := NewHandlers(&fakeStore{systemMetrics: &database.SystemMetrics{
+			TotalNotifications:    15,
+			NotificationsByStatus: map[string]int64{"SENT": 10, "RECEIVED": 5},
+		}}, nil, nil)
 
 		req := httptest.NewRequest(http.MethodGet, "/api/v1/metrics", nil)
 		w := httptest.NewRecorder()
@@ -74,16 +61,19 @@ func TestHandlers_GetSystemMetrics(t *testing.T) {
 		h.GetSystemMetrics(w, req)
 
 		if w.Code != http.StatusOK {
-			t.Errorf("GetSystemMetrics() status = %v, want %v", w.Code, http.StatusOK)
+			t.Fatalf("GetSystemMetrics() status = %v, want %v", w.Code, http.StatusOK)
+		}
+		var got database.SystemMetrics
+		if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
 		}
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Mock expectations were not met: %v", err)
+		if got.TotalNotifications != 15 || got.NotificationsByStatus["SENT"] != 10 {
+			t.Errorf("GetSystemMetrics() = %+v, want 15 notifications with 10 sent", got)
 		}
 	})
 
 	t.Run("database error", func(t *testing.T) {
-		mock.ExpectQuery("SELECT status, COUNT").
-			WillReturnError(sql.ErrConnDone)
+		h := NewHandlers(&fakeStore{err: sql.ErrConnDone}, nil, nil)
 
 		req := httptest.NewRequest(http.MethodGet, "/api/v1/metrics", nil)
 		w := httptest.NewRecorder()
@@ -93,9 +83,6 @@ func TestHandlers_GetSystemMetrics(t *testing.T) {
 		if w.Code != http.StatusInternalServerError {
 			t.Errorf("GetSystemMetrics() status = %v, want %v", w.Code, http.StatusInternalServerError)
 		}
-		if err := mock.ExpectationsWereMet(); err != nil {
-			t.Errorf("Mock expectations were not met: %v", err)
-		}
 	})
 }
 
@@ -124,11 +111,143 @@ func TestHandlers_GetServiceMetrics(t *testing.T) {
 			t.Errorf("GetServiceMetrics() status = %v, want %v", w.Code, http.StatusInternalServerError)
 		}
 	})
+
+	t.Run("reports running and offline services", func(t *testing.T) {
+		collector := metrics.NewMemoryCollector("evaluator")
+		collector.RecordReceived()
+		collector.IncrementCustom("alerts_matched")
+		h := NewHandlers(nil, collector, nil)
+		w := httptest.NewRecorder()
+
+		h.GetServiceMetrics(w, httptest.NewRequest(http.MethodGet, "/api/v1/services/metrics", nil))
+
+		var resp ServiceMetricsResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		evaluator := resp.Services["evaluator"]
+		if evaluator == nil || evaluator.MessagesReceived != 1 || evaluator.CustomCounters["alerts_matched"] != 1 {
+			t.Errorf("evaluator = %+v, want the recorded counters", evaluator)
+		}
+		if sender := resp.Services["sender"]; sender == nil || sender.Status != "offline" {
+			t.Errorf("sender = %+v, want offline", sender)
+		}
+	})
+}
+
+// TestHandlers_GetCanaryStatus tests the GetCanaryStatus handler.
+func TestHandlers_GetCanaryStatus(t *testing.T) {
+	h := NewHandlers(nil, nil, nil)
+
+	t.Run("no reader returns error", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/canary", nil)
+		w := httptest.NewRecorder()
+
+		h.GetCanaryStatus(w, req)
+
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("GetCanaryStatus() status = %v, want %v", w.Code, http.StatusInternalServerError)
+		}
+	})
+
+	t.Run("reports the recorded canaries", func(t *testing.T) {
+		collector := metrics.NewMemoryCollector("metrics-service")
+		sentAt := time.Now().Add(-time.Second)
+		collector.RecordCanarySent(context.Background(), "canary-1", sentAt)
+		collector.RecordCanaryDelivered(context.Background(), "canary-1", sentAt, sentAt.Add(250*time.Millisecond))
+		h := NewHandlers(nil, collector, nil)
+		w := httptest.NewRecorder()
+
+		h.GetCanaryStatus(w, httptest.NewRequest(http.MethodGet, "/api/v1/canary", nil))
+
+		var status metrics.CanaryStatus
+		if err := json.NewDecoder(w.Body).Decode(&status); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if status.Status != metrics.CanaryHealthy || status.LastLatencyMs != 250 || status.Delivered != 1 {
+			t.Errorf("GetCanaryStatus() = %+v, want healthy with one delivery in 250ms", status)
+		}
+	})
+
+	t.Run("max age defaults and can be overridden", func(t *testing.T) {
+		if h.canaryMaxAge != metrics.DefaultCanaryMaxAge {
+			t.Errorf("canaryMaxAge = %v, want %v", h.canaryMaxAge, metrics.DefaultCanaryMaxAge)
+		}
+		h.SetCanaryMaxAge(5 * time.Minute)
+		if h.canaryMaxAge != 5*time.Minute {
+			t.Errorf("canaryMaxAge = %v, want %v", h.canaryMaxAge, 5*time.Minute)
+		}
+	})
+}
+
+// TestHandlers_GetPipelineLatency tests the GetPipelineLatency handler.
+func TestHandlers_GetPipelineLatency(t *testing.T) {
+	collector := metrics.NewMemoryCollector("metrics-service")
+	readyAt := time.Now().Add(-time.Second)
+	collector.RecordStageLatency(context.Background(), metrics.LatencyStageDelivery, readyAt, readyAt.Add(300*time.Millisecond))
+	collector.RecordStageLatency(context.Background(), metrics.LatencyStageDelivery, readyAt, readyAt.Add(100*time.Millisecond))
+	h := NewHandlers(nil, collector, nil)
+	w := httptest.NewRecorder()
+
+	h.GetPipelineLatency(w, httptest.NewRequest(http.MethodGet, "/api/v1/latency", nil))
+
+	var latency metrics.PipelineLatency
+	if err := json.NewDecoder(w.Body).Decode(&latency); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	delivery := latency.Stages[metrics.LatencyStageDelivery]
+	if len(latency.Stages) != 1 || delivery == nil || delivery.Count != 2 || delivery.AvgLatencyMs != 200 || delivery.LastLatencyMs != 100 {
+		t.Errorf("GetPipelineLatency() = %+v, want two deliveries averaging 200ms", latency.Stages)
+	}
+}
+
+// TestHandlers_GetAlertTrace tests the GetAlertTrace handler.
+func TestHandlers_GetAlertTrace(t *testing.T) {
+	h := NewHandlers(nil, nil, nil)
+
+	t.Run("missing alert id", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/debug/alert/", nil)
+		w := httptest.NewRecorder()
+
+		h.GetAlertTrace(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("GetAlertTrace() status = %v, want %v", w.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("no reader returns error", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/debug/alert/alert-1", nil)
+		req.SetPathValue("alert_id", "alert-1")
+		w := httptest.NewRecorder()
+
+		h.GetAlertTrace(w, req)
+
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("GetAlertTrace() status = %v, want %v", w.Code, http.StatusInternalServerError)
+		}
+	})
+
+	t.Run("returns the recorded trace", func(t *testing.T) {
+		collector := metrics.NewMemoryCollector("metrics-service")
+		collector.RecordTrace(context.Background(), "alert-1", metrics.TraceEvent{Stage: metrics.StageEvaluator, Event: "matched"})
+		collector.RecordTrace(context.Background(), "alert-1", metrics.TraceEvent{Stage: metrics.StageAggregator, Event: "created"})
+		h := NewHandlers(&fakeStore{}, collector, nil)
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/debug/alert/alert-1", nil)
+		req.SetPathValue("alert_id", "alert-1")
+		w := httptest.NewRecorder()
+
+		h.GetAlertTrace(w, req)
+
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"stage":"aggregator"`) {
+			t.Errorf("GetAlertTrace() status = %v, body = %s, want both events", w.Code, w.Body.String())
+		}
+	})
 }
 
 // TestNewHandlers tests the NewHandlers constructor.
 func TestNewHandlers(t *testing.T) {
-	db := &database.DB{}
+	db := &fakeStore{}
 
 	h := NewHandlers(db, nil, nil)
 	if h == nil {
@@ -0,0 +1,41 @@
+// Package handlers provides HTTP handlers for the metrics-service API.
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// SetHealthChecker sets how /healthz and /readyz check the service's dependencies.
+func (h *Handlers) SetHealthChecker(checker *shared.HealthChecker) {
+	h.health = checker
+}
+
+// checkHealth returns the dependencies' health, or an empty healthy report if no checker is set.
+func (h *Handlers) checkHealth(r *http.Request) shared.HealthReport {
+	if h.health == nil {
+		return shared.HealthReport{Status: shared.HealthStatusUp}
+	}
+	return h.health.Check(r.Context())
+}
+
+// Healthz reports whether Postgres, Redis and, with lag monitoring, Kafka are reachable, with
+// the status and latency of each: 503 if any of them is down, 200 otherwise. Reports are
+// cached for a few seconds, so frequent probes do not load the dependencies.
+// GET /healthz
+func (h *Handlers) Healthz(w http.ResponseWriter, r *http.Request) {
+	report := h.checkHealth(r)
+	status := http.StatusOK
+	if !report.Healthy() {
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		slog.Error("Failed to encode health response", "error", err)
+	}
+}
@@ -0,0 +1,84 @@
+// Package handlers provides tests for HTTP handlers.
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// newHealthChecker returns a checker of postgres and redis, with redis failing with redisErr.
+func newHealthChecker(redisErr error) *shared.HealthChecker {
+	return shared.NewHealthChecker(
+		shared.DependencyCheck{Name: "postgres", Check: func(ctx context.Context) error { return nil }},
+		shared.DependencyCheck{Name: "redis", Check: func(ctx context.Context) error { return redisErr }},
+	)
+}
+
+// TestHandlers_Healthz tests that /healthz reports each dependency.
+func TestHandlers_Healthz(t *testing.T) {
+	tests := []struct {
+		name       string
+		redisErr   error
+		wantStatus int
+		want       string
+	}{
+		{name: "all up", wantStatus: http.StatusOK, want: shared.HealthStatusUp},
+		{name: "redis down", redisErr: errors.New("connection refused"), wantStatus: http.StatusServiceUnavailable, want: shared.HealthStatusDown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandlers(nil, nil, nil)
+			h.SetHealthChecker(newHealthChecker(tt.redisErr))
+			w := httptest.NewRecorder()
+			h.Healthz(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+			var resp shared.HealthReport
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if w.Code != tt.wantStatus || resp.Status != tt.want {
+				t.Errorf("Healthz() = %d %q, want %d %q", w.Code, resp.Status, tt.wantStatus, tt.want)
+			}
+			if resp.Dependencies["postgres"].Status != shared.HealthStatusUp || resp.Dependencies["redis"].Status != tt.want {
+				t.Errorf("dependencies = %+v, want postgres up and redis %s", resp.Dependencies, tt.want)
+			}
+		})
+	}
+}
+
+// TestHandlers_Readiness_Dependencies tests that /readyz fails while a dependency is down,
+// before looking at the emergency stop.
+func TestHandlers_Readiness_Dependencies(t *testing.T) {
+	h := NewHandlers(nil, nil, nil)
+	h.SetHealthChecker(newHealthChecker(errors.New("connection refused")))
+	h.SetEmergencyStopReader(&fakeEmergencyStopReader{stop: shared.EmergencyStop{Active: true, Reason: "webhook storm"}})
+	w := httptest.NewRecorder()
+	h.Readiness(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	var resp ReadinessResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if w.Code != http.StatusServiceUnavailable || resp.Status != "unavailable" {
+		t.Errorf("Readiness() = %d %q, want 503 unavailable", w.Code, resp.Status)
+	}
+	if s := resp.Dependencies["redis"]; s.Status != shared.HealthStatusDown || s.Error != "connection refused" {
+		t.Errorf("redis = %+v, want down with its error", s)
+	}
+
+	h.SetHealthChecker(newHealthChecker(nil))
+	w = httptest.NewRecorder()
+	h.Readiness(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	resp = ReadinessResponse{}
+	json.NewDecoder(w.Body).Decode(&resp)
+	if w.Code != http.StatusServiceUnavailable || resp.Status != "emergency_stop" || len(resp.Dependencies) != 2 {
+		t.Errorf("Readiness() with healthy dependencies = %d %+v, want 503 emergency_stop with the dependencies", w.Code, resp)
+	}
+}
@@ -0,0 +1,98 @@
+// Package handlers provides HTTP handlers for the metrics-service API.
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"metrics-service/internal/database"
+	"metrics-service/internal/kpi"
+)
+
+// defaultKPIDays is the default KPI report period.
+const defaultKPIDays = 7
+
+// KPIReader reports MTTA and MTTR.
+type KPIReader interface {
+	Report(ctx context.Context, days int, clientID string) (*kpi.Report, error)
+}
+
+// WeeklySummaryResponse is the weekly summary report: the noisiest rules and the MTTA/MTTR
+// of the last 7 days.
+type WeeklySummaryResponse struct {
+	From          time.Time           `json:"from"`
+	To            time.Time           `json:"to"`
+	NoisiestRules []database.RuleRate `json:"noisiest_rules"`
+	KPIs          *kpi.Report         `json:"kpis,omitempty"`
+}
+
+// SetKPIReader sets where MTTA and MTTR are read from.
+func (h *Handlers) SetKPIReader(reader KPIReader) {
+	h.kpis = reader
+}
+
+// GetKPIs returns MTTA and MTTR overall, per client and per rule.
+// GET /api/v1/kpis?days=...&client_id=...
+func (h *Handlers) GetKPIs(w http.ResponseWriter, r *http.Request) {
+	if h.kpis == nil {
+		http.Error(w, "KPIs not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	days := defaultKPIDays
+	if raw := r.URL.Query().Get("days"); raw != "" {
+		d, err := strconv.Atoi(raw)
+		if err != nil || d <= 0 || d > kpi.MaxReportDays {
+			http.Error(w, "days must be between 1 and "+strconv.Itoa(kpi.MaxReportDays), http.StatusBadRequest)
+			return
+		}
+		days = d
+	}
+
+	report, err := h.kpis.Report(r.Context(), days, r.URL.Query().Get("client_id"))
+	if err != nil {
+		slog.Error("Failed to get KPIs", "error", err)
+		http.Error(w, "Failed to retrieve KPIs", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		slog.Error("Failed to encode KPI response", "error", err)
+	}
+}
+
+// GetWeeklySummary returns the weekly summary report. KPIs are omitted if unavailable.
+// GET /api/v1/summary/weekly
+func (h *Handlers) GetWeeklySummary(w http.ResponseWriter, r *http.Request) {
+	rates, err := h.db.GetRuleRates(r.Context(), "", database.DefaultNoisiestRulesLimit)
+	if err != nil {
+		slog.Error("Failed to get noisiest rules for weekly summary", "error", err)
+		http.Error(w, "Failed to retrieve weekly summary", http.StatusInternalServerError)
+		return
+	}
+
+	to := time.Now().UTC()
+	response := WeeklySummaryResponse{
+		From:          to.AddDate(0, 0, -7),
+		To:            to,
+		NoisiestRules: rates.Rules,
+	}
+	if h.kpis != nil {
+		report, err := h.kpis.Report(r.Context(), 7, "")
+		if err != nil {
+			slog.Warn("Failed to get KPIs for weekly summary", "error", err)
+		} else {
+			response.KPIs = report
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.Error("Failed to encode weekly summary", "error", err)
+	}
+}
@@ -0,0 +1,97 @@
+// Package handlers provides tests for HTTP handlers.
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"metrics-service/internal/database"
+	"metrics-service/internal/kpi"
+)
+
+// fakeKPIReader returns a fixed report and records the requested period.
+type fakeKPIReader struct {
+	days     int
+	clientID string
+}
+
+func (r *fakeKPIReader) Report(ctx context.Context, days int, clientID string) (*kpi.Report, error) {
+	r.days, r.clientID = days, clientID
+	mtta := 42.0
+	return &kpi.Report{Days: days, ClientID: clientID, Overall: kpi.KPI{Acknowledged: 3, MTTASeconds: &mtta}}, nil
+}
+
+// TestHandlers_GetKPIs tests the GetKPIs handler.
+func TestHandlers_GetKPIs(t *testing.T) {
+	t.Run("not configured", func(t *testing.T) {
+		h := NewHandlers(nil, nil, nil)
+		w := httptest.NewRecorder()
+		h.GetKPIs(w, httptest.NewRequest(http.MethodGet, "/api/v1/kpis", nil))
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("GetKPIs() status = %v, want %v", w.Code, http.StatusServiceUnavailable)
+		}
+	})
+
+	t.Run("report for a client", func(t *testing.T) {
+		reader := &fakeKPIReader{}
+		h := NewHandlers(nil, nil, nil)
+		h.SetKPIReader(reader)
+
+		w := httptest.NewRecorder()
+		h.GetKPIs(w, httptest.NewRequest(http.MethodGet, "/api/v1/kpis?days=30&client_id=client-1", nil))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("GetKPIs() status = %v, want %v", w.Code, http.StatusOK)
+		}
+		if reader.days != 30 || reader.clientID != "client-1" {
+			t.Errorf("Report(%d, %q), want Report(30, \"client-1\")", reader.days, reader.clientID)
+		}
+		var report kpi.Report
+		if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if report.Overall.MTTASeconds == nil || *report.Overall.MTTASeconds != 42 {
+			t.Errorf("overall = %+v, want MTTA 42s", report.Overall)
+		}
+	})
+
+	t.Run("invalid days", func(t *testing.T) {
+		h := NewHandlers(nil, nil, nil)
+		h.SetKPIReader(&fakeKPIReader{})
+		for _, days := range []string{"0", "abc", "1000"} {
+			w := httptest.NewRecorder()
+			h.GetKPIs(w, httptest.NewRequest(http.MethodGet, "/api/v1/kpis?days="+days, nil))
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("GetKPIs(days=%s) status = %v, want %v", days, w.Code, http.StatusBadRequest)
+			}
+		}
+	})
+}
+
+// TestHandlers_GetWeeklySummary tests that the weekly summary includes the noisiest rules and KPIs.
+func TestHandlers_GetWeeklySummary(t *testing.T) {
+	store := &fakeStore{ruleRates: &database.RuleRatesReport{Rules: []database.RuleRate{{RuleID: "rule-1", Last7d: 336}}}}
+	h := NewHandlers(store, nil, nil)
+	reader := &fakeKPIReader{}
+	h.SetKPIReader(reader)
+
+	w := httptest.NewRecorder()
+	h.GetWeeklySummary(w, httptest.NewRequest(http.MethodGet, "/api/v1/summary/weekly", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("GetWeeklySummary() status = %v, want %v", w.Code, http.StatusOK)
+	}
+	var summary WeeklySummaryResponse
+	if err := json.NewDecoder(w.Body).Decode(&summary); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(summary.NoisiestRules) != 1 || summary.KPIs == nil || summary.KPIs.Days != 7 {
+		t.Errorf("GetWeeklySummary() = %+v, want 1 noisy rule and 7-day KPIs", summary)
+	}
+	if store.limit != database.DefaultNoisiestRulesLimit {
+		t.Errorf("GetRuleRates() limit = %d, want %d", store.limit, database.DefaultNoisiestRulesLimit)
+	}
+}
@@ -0,0 +1,32 @@
+// Package handlers provides HTTP handlers for the metrics-service API.
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+)
+
+// GetPipelineLatency returns how long notifications spend in each pipeline stage: from the
+// evaluator to the aggregator, in the aggregator until notifications.ready, and from there
+// until the sender delivered them.
+// GET /api/v1/latency
+func (h *Handlers) GetPipelineLatency(w http.ResponseWriter, r *http.Request) {
+	if h.metricsReader == nil {
+		slog.Error("Metrics reader not configured")
+		http.Error(w, "Metrics reader not available", http.StatusInternalServerError)
+		return
+	}
+
+	latency, err := h.metricsReader.GetPipelineLatency(r.Context())
+	if err != nil {
+		slog.Error("Failed to get pipeline latency", "error", err)
+		http.Error(w, "Failed to retrieve pipeline latency", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(latency); err != nil {
+		slog.Error("Failed to encode pipeline latency response", "error", err)
+	}
+}
@@ -0,0 +1,42 @@
+// Package handlers provides HTTP handlers for the metrics-service API.
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/afikmenashe/alerting-platform/pkg/metrics"
+)
+
+// SetPropagationThreshold sets the rule propagation latency above which rules are reported as slow.
+func (h *Handlers) SetPropagationThreshold(threshold time.Duration) {
+	h.propagationThreshold = threshold
+}
+
+// GetRulePropagation returns how long rule changes take to propagate from rule-service
+// to the Redis snapshot and the evaluator indexes, and whether that exceeds the threshold.
+// GET /api/v1/propagation
+func (h *Handlers) GetRulePropagation(w http.ResponseWriter, r *http.Request) {
+	if h.metricsReader == nil {
+		slog.Error("Metrics reader not configured")
+		http.Error(w, "Metrics reader not available", http.StatusInternalServerError)
+		return
+	}
+
+	status, err := h.metricsReader.GetRulePropagation(r.Context(), h.propagationThreshold)
+	if err != nil {
+		slog.Error("Failed to get rule propagation", "error", err)
+		http.Error(w, "Failed to retrieve rule propagation", http.StatusInternalServerError)
+		return
+	}
+	if status.Status == metrics.PropagationSlow {
+		slog.Warn("Rule propagation exceeds threshold", "threshold", h.propagationThreshold, "stages", status.Stages)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		slog.Error("Failed to encode rule propagation response", "error", err)
+	}
+}
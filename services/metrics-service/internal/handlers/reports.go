@@ -0,0 +1,101 @@
+// Package handlers provides HTTP handlers for the metrics-service API.
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"metrics-service/internal/database"
+)
+
+// Report time range bounds.
+const (
+	defaultReportRange = 30 * 24 * time.Hour
+	maxReportRange     = 366 * 24 * time.Hour
+)
+
+// ReportListResponse lists the available reports.
+type ReportListResponse struct {
+	Reports []database.ReportInfo `json:"reports"`
+}
+
+// ListReports returns the allow-listed reports.
+// GET /api/v1/reports
+func (h *Handlers) ListReports(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(ReportListResponse{Reports: database.Reports()}); err != nil {
+		slog.Error("Failed to encode report list", "error", err)
+	}
+}
+
+// GetReport runs an allow-listed report over [from, to), optionally for one client.
+// from and to are RFC 3339 timestamps or YYYY-MM-DD dates; to defaults to now and
+// from to 30 days before to. The range may span at most 366 days.
+// GET /api/v1/reports/{name}?from=...&to=...&client_id=...
+func (h *Handlers) GetReport(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	params, err := parseReportParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.db.RunReport(r.Context(), name, params)
+	if errors.Is(err, database.ErrUnknownReport) {
+		http.Error(w, "Unknown report: "+name, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to run report", "report", name, "error", err)
+		http.Error(w, "Failed to run report", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		slog.Error("Failed to encode report response", "report", name, "error", err)
+	}
+}
+
+// parseReportParams reads and validates the report query parameters.
+func parseReportParams(r *http.Request) (database.ReportParams, error) {
+	q := r.URL.Query()
+	params := database.ReportParams{ClientID: q.Get("client_id")}
+
+	params.To = time.Now().UTC()
+	if raw := q.Get("to"); raw != "" {
+		to, err := parseReportTime(raw)
+		if err != nil {
+			return params, fmt.Errorf("to must be an RFC 3339 timestamp or YYYY-MM-DD date")
+		}
+		params.To = to
+	}
+	params.From = params.To.Add(-defaultReportRange)
+	if raw := q.Get("from"); raw != "" {
+		from, err := parseReportTime(raw)
+		if err != nil {
+			return params, fmt.Errorf("from must be an RFC 3339 timestamp or YYYY-MM-DD date")
+		}
+		params.From = from
+	}
+
+	if !params.To.After(params.From) {
+		return params, fmt.Errorf("to must be after from")
+	}
+	if params.To.Sub(params.From) > maxReportRange {
+		return params, fmt.Errorf("range must be at most 366 days")
+	}
+	return params, nil
+}
+
+// parseReportTime parses an RFC 3339 timestamp or a YYYY-MM-DD date (UTC midnight).
+func parseReportTime(raw string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, raw)
+}
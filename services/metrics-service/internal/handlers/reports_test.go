@@ -0,0 +1,111 @@
+// Package handlers provides tests for HTTP handlers.
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"metrics-service/internal/database"
+)
+
+// TestHandlers_GetReport tests running an allow-listed report.
+func TestHandlers_GetReport(t *testing.T) {
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)
+	store := &fakeStore{report: &database.Report{
+		ReportInfo: database.ReportInfo{Name: "severity-daily"},
+		ClientID:   "client-1",
+		Rows:       []map[string]interface{}{{"severity": "HIGH", "notifications": int64(4)}, {"severity": "LOW", "notifications": int64(9)}},
+	}}
+	h := NewHandlers(store, nil, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/reports/severity-daily?from=2026-03-01&to=2026-03-08T00:00:00Z&client_id=client-1", nil)
+	req.SetPathValue("name", "severity-daily")
+	w := httptest.NewRecorder()
+	h.GetReport(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("GetReport() status = %v, want %v: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	want := database.ReportParams{From: from, To: to, ClientID: "client-1"}
+	if store.reportName != "severity-daily" || store.params != want {
+		t.Errorf("RunReport(%q, %+v), want RunReport(\"severity-daily\", %+v)", store.reportName, store.params, want)
+	}
+	var report database.Report
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if report.Name != "severity-daily" || len(report.Rows) != 2 {
+		t.Fatalf("GetReport() = %+v, want 2 severity-daily rows", report)
+	}
+	if report.Rows[1]["severity"] != "LOW" || report.Rows[1]["notifications"] != float64(9) {
+		t.Errorf("row = %v, want LOW with 9 notifications", report.Rows[1])
+	}
+}
+
+// TestHandlers_GetReport_UnknownReport tests that unknown reports from the store are not found.
+func TestHandlers_GetReport_UnknownReport(t *testing.T) {
+	h := NewHandlers(&fakeStore{err: database.ErrUnknownReport}, nil, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/reports/drop-tables", nil)
+	req.SetPathValue("name", "drop-tables")
+	w := httptest.NewRecorder()
+	h.GetReport(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("GetReport() status = %v, want %v", w.Code, http.StatusNotFound)
+	}
+}
+
+// TestHandlers_GetReport_Validation tests report name and parameter validation.
+func TestHandlers_GetReport_Validation(t *testing.T) {
+	tests := []struct {
+		name       string
+		report     string
+		query      string
+		wantStatus int
+	}{
+		{"invalid from", "severity-daily", "from=last-week", http.StatusBadRequest},
+		{"to before from", "severity-daily", "from=2026-03-08&to=2026-03-01", http.StatusBadRequest},
+		{"range too long", "severity-daily", "from=2024-01-01&to=2026-01-01", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandlers(&fakeStore{}, nil, nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/reports/"+tt.report+"?"+tt.query, nil)
+			req.SetPathValue("name", tt.report)
+			w := httptest.NewRecorder()
+			h.GetReport(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("GetReport() status = %v, want %v", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+// TestHandlers_ListReports tests that every allow-listed report is listed.
+func TestHandlers_ListReports(t *testing.T) {
+	h := NewHandlers(nil, nil, nil)
+	w := httptest.NewRecorder()
+	h.ListReports(w, httptest.NewRequest(http.MethodGet, "/api/v1/reports", nil))
+
+	var resp ReportListResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	want := []string{"delivery-success-weekly", "severity-daily", "time-to-acknowledge"}
+	if len(resp.Reports) != len(want) {
+		t.Fatalf("ListReports() returned %d reports, want %d", len(resp.Reports), len(want))
+	}
+	for i, name := range want {
+		if resp.Reports[i].Name != name {
+			t.Errorf("reports[%d] = %q, want %q", i, resp.Reports[i].Name, name)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+// Package handlers provides HTTP handlers for the metrics-service API.
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"metrics-service/internal/database"
+)
+
+// Limits for the rule rates endpoints.
+const (
+	defaultRuleRatesLimit = 50
+	maxRuleRatesLimit     = 200
+)
+
+// GetRuleRates returns per-rule notification rates over 1h/24h/7d with week-over-week deltas.
+// GET /api/v1/rules/rates?rule_id=...&limit=...
+func (h *Handlers) GetRuleRates(w http.ResponseWriter, r *http.Request) {
+	limit, ok := parseLimit(w, r, defaultRuleRatesLimit)
+	if !ok {
+		return
+	}
+	h.writeRuleRates(w, r, r.URL.Query().Get("rule_id"), limit)
+}
+
+// GetNoisiestRules returns the rules that produced the most notifications in the last 7 days.
+// Intended for the weekly summary email and the UI.
+// GET /api/v1/rules/noisiest?limit=...
+func (h *Handlers) GetNoisiestRules(w http.ResponseWriter, r *http.Request) {
+	limit, ok := parseLimit(w, r, database.DefaultNoisiestRulesLimit)
+	if !ok {
+		return
+	}
+	h.writeRuleRates(w, r, "", limit)
+}
+
+// writeRuleRates queries rule rates and writes them as JSON.
+func (h *Handlers) writeRuleRates(w http.ResponseWriter, r *http.Request, ruleID string, limit int) {
+	report, err := h.db.GetRuleRates(r.Context(), ruleID, limit)
+	if err != nil {
+		slog.Error("Failed to get rule rates", "rule_id", ruleID, "error", err)
+		http.Error(w, "Failed to retrieve rule rates", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		slog.Error("Failed to encode rule rates response", "error", err)
+	}
+}
+
+// parseLimit reads the optional "limit" query parameter, capped at maxRuleRatesLimit.
+// Writes a 400 response and returns false if the value is invalid.
+func parseLimit(w http.ResponseWriter, r *http.Request, defaultLimit int) (int, bool) {
+	raw := r.URL.Query().Get("limit")
+	if raw == "" {
+		return defaultLimit, true
+	}
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit <= 0 {
+		http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+		return 0, false
+	}
+	if limit > maxRuleRatesLimit {
+		limit = maxRuleRatesLimit
+	}
+	return limit, true
+}
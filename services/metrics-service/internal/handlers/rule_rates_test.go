@@ -0,0 +1,96 @@
+// Package handlers provides tests for HTTP handlers.
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"metrics-service/internal/database"
+)
+
+// TestHandlers_GetRuleRates tests the GetRuleRates handler.
+func TestHandlers_GetRuleRates(t *testing.T) {
+	t.Run("successful get", func(t *testing.T) {
+		store := &fakeStore{ruleRates: &database.RuleRatesReport{Rules: []database.RuleRate{
+			{RuleID: "rule-1", ClientID: "client-1", Last24h: 48, RatePerHour24h: 2},
+		}}}
+		h := NewHandlers(store, nil, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/rules/rates?rule_id=rule-1", nil)
+		w := httptest.NewRecorder()
+		h.GetRuleRates(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("GetRuleRates() status = %v, want %v", w.Code, http.StatusOK)
+		}
+		if store.ruleID != "rule-1" || store.limit != defaultRuleRatesLimit {
+			t.Errorf("GetRuleRates(%q, %d), want GetRuleRates(\"rule-1\", %d)", store.ruleID, store.limit, defaultRuleRatesLimit)
+		}
+
+		var report database.RuleRatesReport
+		if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if len(report.Rules) != 1 || report.Rules[0].RatePerHour24h != 2 {
+			t.Errorf("GetRuleRates() = %+v, want rule-1 at 2/h", report.Rules)
+		}
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		h := NewHandlers(nil, nil, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/rules/rates?limit=abc", nil)
+		w := httptest.NewRecorder()
+		h.GetRuleRates(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("GetRuleRates() status = %v, want %v", w.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		h := NewHandlers(&fakeStore{err: errors.New("boom")}, nil, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/rules/rates", nil)
+		w := httptest.NewRecorder()
+		h.GetRuleRates(w, req)
+
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("GetRuleRates() status = %v, want %v", w.Code, http.StatusInternalServerError)
+		}
+	})
+}
+
+// TestHandlers_GetNoisiestRules tests the GetNoisiestRules handler.
+func TestHandlers_GetNoisiestRules(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		wantLimit int
+	}{
+		{"default limit", "", database.DefaultNoisiestRulesLimit},
+		{"custom limit", "?limit=5", 5},
+		{"limit is capped", "?limit=10000", maxRuleRatesLimit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeStore{ruleRates: &database.RuleRatesReport{}}
+			h := NewHandlers(store, nil, nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/rules/noisiest"+tt.query, nil)
+			w := httptest.NewRecorder()
+			h.GetNoisiestRules(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Errorf("GetNoisiestRules() status = %v, want %v", w.Code, http.StatusOK)
+			}
+			if store.ruleID != "" || store.limit != tt.wantLimit {
+				t.Errorf("GetRuleRates(%q, %d), want GetRuleRates(\"\", %d)", store.ruleID, store.limit, tt.wantLimit)
+			}
+		})
+	}
+}
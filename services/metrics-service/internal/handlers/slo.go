@@ -0,0 +1,43 @@
+// Package handlers provides HTTP handlers for the metrics-service API.
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"metrics-service/internal/slo"
+)
+
+// SLOReader reports the error-budget consumption of the SLOs.
+type SLOReader interface {
+	Report(ctx context.Context) (*slo.Report, error)
+}
+
+// SetSLOReader sets where SLO error budgets are read from.
+func (h *Handlers) SetSLOReader(reader SLOReader) {
+	h.slo = reader
+}
+
+// GetSLO returns the availability and latency error-budget consumption per pipeline stage
+// over the rolling window, and the stages that exhausted their budget.
+// GET /api/v1/slo
+func (h *Handlers) GetSLO(w http.ResponseWriter, r *http.Request) {
+	if h.slo == nil {
+		http.Error(w, "SLOs not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	report, err := h.slo.Report(r.Context())
+	if err != nil {
+		slog.Error("Failed to get SLO report", "error", err)
+		http.Error(w, "Failed to retrieve SLO report", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		slog.Error("Failed to encode SLO response", "error", err)
+	}
+}
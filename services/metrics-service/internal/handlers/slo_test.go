@@ -0,0 +1,64 @@
+// Package handlers provides tests for HTTP handlers.
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"metrics-service/internal/slo"
+)
+
+// fakeSLOReader returns a fixed report or error.
+type fakeSLOReader struct {
+	report *slo.Report
+	err    error
+}
+
+func (r *fakeSLOReader) Report(ctx context.Context) (*slo.Report, error) {
+	return r.report, r.err
+}
+
+// TestHandlers_GetSLO tests the GetSLO handler.
+func TestHandlers_GetSLO(t *testing.T) {
+	t.Run("not configured", func(t *testing.T) {
+		h := NewHandlers(nil, nil, nil)
+		w := httptest.NewRecorder()
+		h.GetSLO(w, httptest.NewRequest(http.MethodGet, "/api/v1/slo", nil))
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("GetSLO() status = %v, want %v", w.Code, http.StatusServiceUnavailable)
+		}
+	})
+
+	t.Run("report", func(t *testing.T) {
+		h := NewHandlers(nil, nil, nil)
+		h.SetSLOReader(&fakeSLOReader{report: &slo.Report{WindowDays: 30, Exhausted: []string{"sender"}}})
+
+		w := httptest.NewRecorder()
+		h.GetSLO(w, httptest.NewRequest(http.MethodGet, "/api/v1/slo", nil))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("GetSLO() status = %v, want %v", w.Code, http.StatusOK)
+		}
+		var report slo.Report
+		if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if report.WindowDays != 30 || len(report.Exhausted) != 1 || report.Exhausted[0] != "sender" {
+			t.Errorf("GetSLO() = %+v, want a 30-day report with sender exhausted", report)
+		}
+	})
+
+	t.Run("store error", func(t *testing.T) {
+		h := NewHandlers(nil, nil, nil)
+		h.SetSLOReader(&fakeSLOReader{err: errors.New("connection refused")})
+		w := httptest.NewRecorder()
+		h.GetSLO(w, httptest.NewRequest(http.MethodGet, "/api/v1/slo", nil))
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("GetSLO() status = %v, want %v", w.Code, http.StatusInternalServerError)
+		}
+	})
+}
@@ -0,0 +1,346 @@
+// Package kpi computes mean time to acknowledge (MTTA) and mean time to resolve (MTTR) per
+// client and per rule. An incremental job folds newly acknowledged and resolved notifications
+// into daily totals, so reports never rescan the notifications table.
+package kpi
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"metrics-service/internal/database"
+)
+
+// KPI aggregation defaults.
+const (
+	// DefaultInterval is how often newly closed notifications are aggregated.
+	DefaultInterval = time.Minute
+	// DefaultBackfill is how far back the first run aggregates.
+	DefaultBackfill = 30 * 24 * time.Hour
+	// Retention is how long daily totals are kept, and so the longest report.
+	Retention = 90 * 24 * time.Hour
+	// MaxReportDays is the longest report, in days.
+	MaxReportDays = 90
+	// settleDelay keeps the newest rows out of a run: a status change stamped just before the
+	// run may not be committed yet, and would otherwise fall behind the watermark.
+	settleDelay = 30 * time.Second
+	// runTimeout bounds one aggregation run.
+	runTimeout = 2 * time.Minute
+)
+
+// dayLayout formats the day of a daily bucket.
+const dayLayout = "2006-01-02"
+
+// Metric is a closing event that is timed.
+type Metric string
+
+const (
+	MetricAcknowledge Metric = "ack"
+	MetricResolve     Metric = "resolve"
+)
+
+// Source streams notifications acknowledged or resolved in a time window.
+type Source interface {
+	ListClosedNotifications(ctx context.Context, after, until time.Time, fn func(database.ClosedNotification) error) error
+}
+
+// Store persists daily totals and the aggregation watermark.
+type Store interface {
+	// Lock takes the aggregation lock so that only one instance aggregates a window.
+	Lock(ctx context.Context, ttl time.Duration) (bool, error)
+	Unlock(ctx context.Context) error
+	// Watermark returns the end of the last aggregated window, or the zero time.
+	Watermark(ctx context.Context) (time.Time, error)
+	// Apply adds buckets to the stored totals and advances the watermark, atomically.
+	Apply(ctx context.Context, buckets Buckets, watermark time.Time) error
+	// Load returns the totals of one metric on one day.
+	Load(ctx context.Context, metric Metric, day string) (Bucket, error)
+}
+
+// Totals is the number of closed notifications and the sum of their times to close.
+type Totals struct {
+	Count   int64
+	Seconds float64
+}
+
+// Bucket holds the totals of one metric on one day, keyed by ClientKey or RuleKey.
+type Bucket map[string]Totals
+
+// ClientKey is the bucket key of a client's totals.
+func ClientKey(clientID string) string {
+	return "client:" + clientID
+}
+
+// RuleKey is the bucket key of a rule's totals. The client is included so rule totals can
+// be filtered by client.
+func RuleKey(clientID, ruleID string) string {
+	return "rule:" + clientID + ":" + ruleID
+}
+
+// BucketID identifies a daily bucket.
+type BucketID struct {
+	Metric Metric
+	Day    string
+}
+
+// Buckets accumulates the totals of one aggregation run.
+type Buckets map[BucketID]Bucket
+
+// add counts one notification closed at closedAt after seconds.
+func (b Buckets) add(metric Metric, closedAt time.Time, n database.ClosedNotification, seconds float64) {
+	id := BucketID{Metric: metric, Day: closedAt.UTC().Format(dayLayout)}
+	bucket, ok := b[id]
+	if !ok {
+		bucket = make(Bucket)
+		b[id] = bucket
+	}
+	keys := []string{ClientKey(n.ClientID)}
+	for _, ruleID := range n.RuleIDs {
+		keys = append(keys, RuleKey(n.ClientID, ruleID))
+	}
+	for _, key := range keys {
+		t := bucket[key]
+		t.Count++
+		t.Seconds += seconds
+		bucket[key] = t
+	}
+}
+
+// Add counts the acknowledgement and resolution of n that fall in (after, until].
+func (b Buckets) Add(n database.ClosedNotification, after, until time.Time) {
+	inWindow := func(t *time.Time) bool {
+		return t != nil && t.After(after) && !t.After(until)
+	}
+	if inWindow(n.AcknowledgedAt) {
+		b.add(MetricAcknowledge, *n.AcknowledgedAt, n, secondsBetween(n.CreatedAt, *n.AcknowledgedAt))
+	}
+	if inWindow(n.ResolvedAt) {
+		b.add(MetricResolve, *n.ResolvedAt, n, secondsBetween(n.CreatedAt, *n.ResolvedAt))
+	}
+}
+
+// secondsBetween returns the seconds from start to end, never negative.
+func secondsBetween(start, end time.Time) float64 {
+	if end.Before(start) {
+		return 0
+	}
+	return end.Sub(start).Seconds()
+}
+
+// Aggregator incrementally aggregates MTTA and MTTR and serves reports.
+type Aggregator struct {
+	source   Source
+	store    Store
+	interval time.Duration
+	backfill time.Duration
+	now      func() time.Time
+}
+
+// NewAggregator creates an aggregator that reads closed notifications from source and keeps
+// totals in store. A non-positive interval uses DefaultInterval.
+func NewAggregator(source Source, store Store, interval time.Duration) *Aggregator {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	return &Aggregator{
+		source:   source,
+		store:    store,
+		interval: interval,
+		backfill: DefaultBackfill,
+		now:      time.Now,
+	}
+}
+
+// Run aggregates every interval until ctx is cancelled.
+func (a *Aggregator) Run(ctx context.Context) {
+	slog.Info("Starting KPI aggregation", "interval", a.interval)
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+	for {
+		if err := a.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("KPI aggregation failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce aggregates the notifications closed since the watermark. It does nothing if
+// another instance holds the aggregation lock.
+func (a *Aggregator) RunOnce(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, runTimeout)
+	defer cancel()
+
+	locked, err := a.store.Lock(ctx, runTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to take KPI lock: %w", err)
+	}
+	if !locked {
+		return nil
+	}
+	defer a.store.Unlock(context.WithoutCancel(ctx))
+
+	now := a.now().UTC()
+	after, err := a.store.Watermark(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read KPI watermark: %w", err)
+	}
+	if oldest := now.Add(-a.backfill); after.Before(oldest) {
+		after = oldest
+	}
+	until := now.Add(-settleDelay)
+	if !until.After(after) {
+		return nil
+	}
+
+	buckets := make(Buckets)
+	var closed int
+	err = a.source.ListClosedNotifications(ctx, after, until, func(n database.ClosedNotification) error {
+		buckets.Add(n, after, until)
+		closed++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := a.store.Apply(ctx, buckets, until); err != nil {
+		return fmt.Errorf("failed to store KPI totals: %w", err)
+	}
+
+	if closed > 0 {
+		slog.Info("Aggregated KPIs", "closed_notifications", closed, "after", after, "until", until)
+	}
+	return nil
+}
+
+// KPI is the MTTA and MTTR of a client, a rule, or overall. Means are nil when nothing
+// was acknowledged or resolved.
+type KPI struct {
+	ClientID     string   `json:"client_id,omitempty"`
+	RuleID       string   `json:"rule_id,omitempty"`
+	Acknowledged int64    `json:"acknowledged"`
+	MTTASeconds  *float64 `json:"mtta_seconds,omitempty"`
+	Resolved     int64    `json:"resolved"`
+	MTTRSeconds  *float64 `json:"mttr_seconds,omitempty"`
+}
+
+// Report is the MTTA and MTTR over the last Days days.
+type Report struct {
+	Days           int        `json:"days"`
+	From           time.Time  `json:"from"`
+	To             time.Time  `json:"to"`
+	ClientID       string     `json:"client_id,omitempty"`
+	Overall        KPI        `json:"overall"`
+	Clients        []KPI      `json:"clients"`
+	Rules          []KPI      `json:"rules"`
+	UpdatedThrough *time.Time `json:"updated_through,omitempty"` // notifications closed after this are not counted yet
+}
+
+// Report returns MTTA and MTTR per client and per rule for notifications closed on the last
+// days days (today included), optionally for one client. Clients and rules are ordered by
+// the number of notifications closed, most first.
+func (a *Aggregator) Report(ctx context.Context, days int, clientID string) (*Report, error) {
+	if days <= 0 || days > MaxReportDays {
+		return nil, fmt.Errorf("days must be between 1 and %d", MaxReportDays)
+	}
+
+	now := a.now().UTC()
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1-days)
+	report := &Report{Days: days, From: from, To: now, ClientID: clientID}
+
+	type totals struct{ ack, resolve Totals }
+	clients := make(map[string]*totals)
+	rules := make(map[[2]string]*totals)
+
+	for day := from; !day.After(now); day = day.AddDate(0, 0, 1) {
+		for _, metric := range []Metric{MetricAcknowledge, MetricResolve} {
+			bucket, err := a.store.Load(ctx, metric, day.Format(dayLayout))
+			if err != nil {
+				return nil, fmt.Errorf("failed to load KPI totals: %w", err)
+			}
+			for key, t := range bucket {
+				var entry *totals
+				if id, ok := parseClientKey(key); ok {
+					if clientID != "" && id != clientID {
+						continue
+					}
+					if entry = clients[id]; entry == nil {
+						entry = &totals{}
+						clients[id] = entry
+					}
+				} else if client, rule, ok := parseRuleKey(key); ok {
+					if clientID != "" && client != clientID {
+						continue
+					}
+					if entry = rules[[2]string{client, rule}]; entry == nil {
+						entry = &totals{}
+						rules[[2]string{client, rule}] = entry
+					}
+				} else {
+					continue
+				}
+				sum := &entry.ack
+				if metric == MetricResolve {
+					sum = &entry.resolve
+				}
+				sum.Count += t.Count
+				sum.Seconds += t.Seconds
+			}
+		}
+	}
+
+	var overall totals
+	report.Clients = make([]KPI, 0, len(clients))
+	for id, t := range clients {
+		report.Clients = append(report.Clients, newKPI(id, "", t.ack, t.resolve))
+		overall.ack.Count += t.ack.Count
+		overall.ack.Seconds += t.ack.Seconds
+		overall.resolve.Count += t.resolve.Count
+		overall.resolve.Seconds += t.resolve.Seconds
+	}
+	report.Overall = newKPI(clientID, "", overall.ack, overall.resolve)
+	report.Rules = make([]KPI, 0, len(rules))
+	for id, t := range rules {
+		report.Rules = append(report.Rules, newKPI(id[0], id[1], t.ack, t.resolve))
+	}
+	sortKPIs(report.Clients)
+	sortKPIs(report.Rules)
+
+	if watermark, err := a.store.Watermark(ctx); err == nil && !watermark.IsZero() {
+		report.UpdatedThrough = &watermark
+	}
+	return report, nil
+}
+
+// newKPI computes means from totals.
+func newKPI(clientID, ruleID string, ack, resolve Totals) KPI {
+	k := KPI{ClientID: clientID, RuleID: ruleID, Acknowledged: ack.Count, Resolved: resolve.Count}
+	if ack.Count > 0 {
+		mean := ack.Seconds / float64(ack.Count)
+		k.MTTASeconds = &mean
+	}
+	if resolve.Count > 0 {
+		mean := resolve.Seconds / float64(resolve.Count)
+		k.MTTRSeconds = &mean
+	}
+	return k
+}
+
+// sortKPIs orders KPIs by notifications closed, most first.
+func sortKPIs(kpis []KPI) {
+	sort.Slice(kpis, func(i, j int) bool {
+		ci, cj := kpis[i].Acknowledged+kpis[i].Resolved, kpis[j].Acknowledged+kpis[j].Resolved
+		if ci != cj {
+			return ci > cj
+		}
+		if kpis[i].ClientID != kpis[j].ClientID {
+			return kpis[i].ClientID < kpis[j].ClientID
+		}
+		return kpis[i].RuleID < kpis[j].RuleID
+	})
+}
@@ -0,0 +1,219 @@
+// Package kpi provides tests for MTTA/MTTR aggregation.
+package kpi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"metrics-service/internal/database"
+)
+
+// fakeSource returns fixed notifications, filtered like the database query.
+type fakeSource struct {
+	notifications []database.ClosedNotification
+	windows       [][2]time.Time
+}
+
+func (s *fakeSource) ListClosedNotifications(ctx context.Context, after, until time.Time, fn func(database.ClosedNotification) error) error {
+	s.windows = append(s.windows, [2]time.Time{after, until})
+	for _, n := range s.notifications {
+		in := func(t *time.Time) bool { return t != nil && t.After(after) && !t.After(until) }
+		if in(n.AcknowledgedAt) || in(n.ResolvedAt) {
+			if err := fn(n); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// memoryStore keeps totals in memory.
+type memoryStore struct {
+	locked    bool
+	watermark time.Time
+	buckets   Buckets
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{buckets: make(Buckets)}
+}
+
+func (s *memoryStore) Lock(ctx context.Context, ttl time.Duration) (bool, error) {
+	if s.locked {
+		return false, nil
+	}
+	s.locked = true
+	return true, nil
+}
+
+func (s *memoryStore) Unlock(ctx context.Context) error {
+	s.locked = false
+	return nil
+}
+
+func (s *memoryStore) Watermark(ctx context.Context) (time.Time, error) {
+	return s.watermark, nil
+}
+
+func (s *memoryStore) Apply(ctx context.Context, buckets Buckets, watermark time.Time) error {
+	for id, bucket := range buckets {
+		stored, ok := s.buckets[id]
+		if !ok {
+			stored = make(Bucket)
+			s.buckets[id] = stored
+		}
+		for key, t := range bucket {
+			sum := stored[key]
+			sum.Count += t.Count
+			sum.Seconds += t.Seconds
+			stored[key] = sum
+		}
+	}
+	s.watermark = watermark
+	return nil
+}
+
+func (s *memoryStore) Load(ctx context.Context, metric Metric, day string) (Bucket, error) {
+	return s.buckets[BucketID{Metric: metric, Day: day}], nil
+}
+
+func at(t time.Time) *time.Time { return &t }
+
+// TestBuckets_Add tests that only closings inside the window are counted, per client and rule.
+func TestBuckets_Add(t *testing.T) {
+	created := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	after, until := created, created.Add(time.Hour)
+	n := database.ClosedNotification{
+		ClientID:       "client-1",
+		RuleIDs:        []string{"rule-a", "rule-b"},
+		CreatedAt:      created,
+		AcknowledgedAt: at(created.Add(2 * time.Minute)),
+		ResolvedAt:     at(created.Add(2 * time.Hour)), // after the window
+	}
+
+	buckets := make(Buckets)
+	buckets.Add(n, after, until)
+
+	ack := buckets[BucketID{Metric: MetricAcknowledge, Day: "2026-03-10"}]
+	for _, key := range []string{ClientKey("client-1"), RuleKey("client-1", "rule-a"), RuleKey("client-1", "rule-b")} {
+		if got := ack[key]; got.Count != 1 || got.Seconds != 120 {
+			t.Errorf("ack[%s] = %+v, want 1 notification in 120s", key, got)
+		}
+	}
+	if _, ok := buckets[BucketID{Metric: MetricResolve, Day: "2026-03-10"}]; ok {
+		t.Error("resolution outside the window was counted")
+	}
+}
+
+// TestAggregator_RunOnce tests incremental aggregation and the lock.
+func TestAggregator_RunOnce(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	created := now.Add(-time.Hour)
+	source := &fakeSource{notifications: []database.ClosedNotification{{
+		ClientID:       "client-1",
+		RuleIDs:        []string{"rule-a"},
+		CreatedAt:      created,
+		AcknowledgedAt: at(created.Add(time.Minute)),
+	}}}
+	store := newMemoryStore()
+	a := NewAggregator(source, store, time.Minute)
+	a.now = func() time.Time { return now }
+
+	if err := a.RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce() error = %v", err)
+	}
+	if want := now.Add(-settleDelay); !store.watermark.Equal(want) {
+		t.Errorf("watermark = %v, want %v", store.watermark, want)
+	}
+	if got := store.buckets[BucketID{MetricAcknowledge, "2026-03-10"}][ClientKey("client-1")]; got.Count != 1 {
+		t.Errorf("client totals = %+v, want 1 acknowledged", got)
+	}
+
+	// The next run starts at the watermark, so nothing is counted twice
+	now = now.Add(time.Minute)
+	if err := a.RunOnce(context.Background()); err != nil {
+		t.Fatalf("second RunOnce() error = %v", err)
+	}
+	if got := source.windows[1][0]; !got.Equal(source.windows[0][1]) {
+		t.Errorf("second window starts at %v, want %v", got, source.windows[0][1])
+	}
+	if got := store.buckets[BucketID{MetricAcknowledge, "2026-03-10"}][ClientKey("client-1")]; got.Count != 1 {
+		t.Errorf("client totals after second run = %+v, want 1 acknowledged", got)
+	}
+
+	// Another instance holds the lock
+	store.locked = true
+	if err := a.RunOnce(context.Background()); err != nil {
+		t.Fatalf("locked RunOnce() error = %v", err)
+	}
+	if len(source.windows) != 2 {
+		t.Errorf("source queried %d times, want 2", len(source.windows))
+	}
+}
+
+// TestAggregator_Report tests means per client and rule, and the client filter.
+func TestAggregator_Report(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	store := newMemoryStore()
+	store.watermark = now
+	store.buckets[BucketID{MetricAcknowledge, "2026-03-10"}] = Bucket{
+		ClientKey("client-1"):         {Count: 2, Seconds: 120},
+		RuleKey("client-1", "rule-a"): {Count: 2, Seconds: 120},
+		ClientKey("client-2"):         {Count: 1, Seconds: 30},
+		RuleKey("client-2", "rule-b"): {Count: 1, Seconds: 30},
+	}
+	store.buckets[BucketID{MetricResolve, "2026-03-09"}] = Bucket{
+		ClientKey("client-1"):         {Count: 1, Seconds: 600},
+		RuleKey("client-1", "rule-a"): {Count: 1, Seconds: 600},
+	}
+	// Outside a 2-day report
+	store.buckets[BucketID{MetricResolve, "2026-03-08"}] = Bucket{
+		ClientKey("client-1"): {Count: 5, Seconds: 5},
+	}
+	a := NewAggregator(&fakeSource{}, store, time.Minute)
+	a.now = func() time.Time { return now }
+
+	report, err := a.Report(context.Background(), 2, "")
+	if err != nil {
+		t.Fatalf("Report() error = %v", err)
+	}
+	if report.Overall.Acknowledged != 3 || *report.Overall.MTTASeconds != 50 {
+		t.Errorf("overall = %+v, want 3 acknowledged with MTTA 50s", report.Overall)
+	}
+	if report.Overall.Resolved != 1 || *report.Overall.MTTRSeconds != 600 {
+		t.Errorf("overall = %+v, want 1 resolved with MTTR 600s", report.Overall)
+	}
+	if len(report.Clients) != 2 || report.Clients[0].ClientID != "client-1" || *report.Clients[0].MTTASeconds != 60 {
+		t.Errorf("clients = %+v, want client-1 first with MTTA 60s", report.Clients)
+	}
+	if len(report.Rules) != 2 || report.Rules[0].RuleID != "rule-a" || report.Rules[1].MTTRSeconds != nil {
+		t.Errorf("rules = %+v, want rule-a first and no MTTR for rule-b", report.Rules)
+	}
+	if report.UpdatedThrough == nil || !report.UpdatedThrough.Equal(now) {
+		t.Errorf("updated_through = %v, want %v", report.UpdatedThrough, now)
+	}
+
+	report, err = a.Report(context.Background(), 2, "client-2")
+	if err != nil {
+		t.Fatalf("Report(client-2) error = %v", err)
+	}
+	if len(report.Clients) != 1 || len(report.Rules) != 1 || report.Overall.Acknowledged != 1 {
+		t.Errorf("Report(client-2) = %+v, want only client-2", report)
+	}
+
+	if _, err := a.Report(context.Background(), MaxReportDays+1, ""); err == nil {
+		t.Error("Report() with too many days should fail")
+	}
+}
+
+// TestParseRuleKey tests that client IDs containing ":" round-trip.
+func TestParseRuleKey(t *testing.T) {
+	clientID, ruleID, ok := parseRuleKey(RuleKey("tenant:eu", "rule-1"))
+	if !ok || clientID != "tenant:eu" || ruleID != "rule-1" {
+		t.Errorf("parseRuleKey() = %q, %q, %v", clientID, ruleID, ok)
+	}
+	if _, _, ok := parseRuleKey(ClientKey("client-1")); ok {
+		t.Error("parseRuleKey() accepted a client key")
+	}
+}
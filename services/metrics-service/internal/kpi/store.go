@@ -0,0 +1,130 @@
+package kpi
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis keys. Daily totals live in a hash per metric and day (kpi:{metric}:{day}) with
+// "count:{key}" and "seconds:{key}" fields, where key is a ClientKey or RuleKey.
+const (
+	watermarkKey = "kpi:watermark"
+	lockKey      = "kpi:lock"
+)
+
+// RedisStore keeps KPI totals in Redis.
+type RedisStore struct {
+	redis *redis.Client
+}
+
+// NewRedisStore creates a store backed by redisClient.
+func NewRedisStore(redisClient *redis.Client) *RedisStore {
+	return &RedisStore{redis: redisClient}
+}
+
+func bucketKey(metric Metric, day string) string {
+	return "kpi:" + string(metric) + ":" + day
+}
+
+// Lock takes the aggregation lock for ttl.
+func (s *RedisStore) Lock(ctx context.Context, ttl time.Duration) (bool, error) {
+	return s.redis.SetNX(ctx, lockKey, "1", ttl).Result()
+}
+
+// Unlock releases the aggregation lock.
+func (s *RedisStore) Unlock(ctx context.Context) error {
+	return s.redis.Del(ctx, lockKey).Err()
+}
+
+// Watermark returns the end of the last aggregated window, or the zero time.
+func (s *RedisStore) Watermark(ctx context.Context) (time.Time, error) {
+	ms, err := s.redis.Get(ctx, watermarkKey).Int64()
+	if err == redis.Nil {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(ms).UTC(), nil
+}
+
+// Apply adds buckets to the stored totals and advances the watermark in one transaction.
+func (s *RedisStore) Apply(ctx context.Context, buckets Buckets, watermark time.Time) error {
+	_, err := s.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for id, bucket := range buckets {
+			key := bucketKey(id.Metric, id.Day)
+			for field, t := range bucket {
+				pipe.HIncrBy(ctx, key, "count:"+field, t.Count)
+				pipe.HIncrByFloat(ctx, key, "seconds:"+field, t.Seconds)
+			}
+			// Keep a day longer than the retention so the oldest reported day is complete
+			pipe.Expire(ctx, key, Retention+24*time.Hour)
+		}
+		pipe.Set(ctx, watermarkKey, watermark.UnixMilli(), 0)
+		return nil
+	})
+	return err
+}
+
+// Load returns the totals of one metric on one day.
+func (s *RedisStore) Load(ctx context.Context, metric Metric, day string) (Bucket, error) {
+	fields, err := s.redis.HGetAll(ctx, bucketKey(metric, day)).Result()
+	if err != nil {
+		return nil, err
+	}
+	return parseBucket(fields)
+}
+
+// parseBucket parses the fields of a daily totals hash.
+func parseBucket(fields map[string]string) (Bucket, error) {
+	bucket := make(Bucket)
+	for field, value := range fields {
+		kind, key, ok := strings.Cut(field, ":")
+		if !ok {
+			continue
+		}
+		t := bucket[key]
+		switch kind {
+		case "count":
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid KPI count %q: %w", field, err)
+			}
+			t.Count = n
+		case "seconds":
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid KPI seconds %q: %w", field, err)
+			}
+			t.Seconds = f
+		default:
+			continue
+		}
+		bucket[key] = t
+	}
+	return bucket, nil
+}
+
+// parseClientKey returns the client of a ClientKey.
+func parseClientKey(key string) (string, bool) {
+	return strings.CutPrefix(key, "client:")
+}
+
+// parseRuleKey returns the client and rule of a RuleKey. Rule IDs never contain ":",
+// client IDs may.
+func parseRuleKey(key string) (clientID, ruleID string, ok bool) {
+	rest, ok := strings.CutPrefix(key, "rule:")
+	if !ok {
+		return "", "", false
+	}
+	i := strings.LastIndex(rest, ":")
+	if i < 0 {
+		return "", "", false
+	}
+	return rest[:i], rest[i+1:], true
+}
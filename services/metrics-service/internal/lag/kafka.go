@@ -0,0 +1,94 @@
+package lag
+
+import (
+	"context"
+	"fmt"
+
+	kafkautil "github.com/afikmenashe/alerting-platform/pkg/kafka"
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaOffsets reads offsets from the Kafka cluster with the kafka-go admin client.
+type KafkaOffsets struct {
+	client *kafka.Client
+}
+
+// NewKafkaOffsets creates an offsets reader for a comma-separated list of brokers.
+func NewKafkaOffsets(brokers string) (*KafkaOffsets, error) {
+	brokerList := kafkautil.ParseBrokers(brokers)
+	if len(brokerList) == 0 {
+		return nil, fmt.Errorf("brokers cannot be empty")
+	}
+	return &KafkaOffsets{
+		client: &kafka.Client{
+			Addr:      kafka.TCP(brokerList...),
+			Timeout:   kafkautil.ReadTimeout,
+			Transport: kafkautil.Transport(),
+		},
+	}, nil
+}
+
+// Ping checks that the brokers are reachable, for health and readiness probes.
+func (k *KafkaOffsets) Ping(ctx context.Context) error {
+	return kafkautil.Ping(ctx, k.client.Addr)
+}
+
+// EndOffsets returns the high watermark of each partition of topic.
+func (k *KafkaOffsets) EndOffsets(ctx context.Context, topic string) (map[int]int64, error) {
+	meta, err := k.client.Metadata(ctx, &kafka.MetadataRequest{Topics: []string{topic}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata: %w", err)
+	}
+	var requests []kafka.OffsetRequest
+	for _, t := range meta.Topics {
+		if t.Name != topic {
+			continue
+		}
+		if t.Error != nil {
+			return nil, fmt.Errorf("failed to read metadata of topic %s: %w", topic, t.Error)
+		}
+		for _, p := range t.Partitions {
+			requests = append(requests, kafka.LastOffsetOf(p.ID))
+		}
+	}
+	if len(requests) == 0 {
+		return nil, fmt.Errorf("topic %s has no partitions", topic)
+	}
+
+	resp, err := k.client.ListOffsets(ctx, &kafka.ListOffsetsRequest{
+		Topics: map[string][]kafka.OffsetRequest{topic: requests},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list offsets: %w", err)
+	}
+	end := make(map[int]int64, len(requests))
+	for _, p := range resp.Topics[topic] {
+		if p.Error != nil {
+			return nil, fmt.Errorf("failed to list offsets of %s/%d: %w", topic, p.Partition, p.Error)
+		}
+		end[p.Partition] = p.LastOffset
+	}
+	return end, nil
+}
+
+// CommittedOffsets returns the group's committed offsets of the topic's partitions.
+func (k *KafkaOffsets) CommittedOffsets(ctx context.Context, group, topic string, partitions []int) (map[int]int64, error) {
+	resp, err := k.client.OffsetFetch(ctx, &kafka.OffsetFetchRequest{
+		GroupID: group,
+		Topics:  map[string][]int{topic: partitions},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch committed offsets: %w", err)
+	}
+	if resp.Error != nil {
+		return nil, fmt.Errorf("failed to fetch committed offsets: %w", resp.Error)
+	}
+	committed := make(map[int]int64, len(partitions))
+	for _, p := range resp.Topics[topic] {
+		if p.Error != nil {
+			return nil, fmt.Errorf("failed to fetch committed offset of %s/%d: %w", topic, p.Partition, p.Error)
+		}
+		committed[p.Partition] = p.CommittedOffset
+	}
+	return committed, nil
+}
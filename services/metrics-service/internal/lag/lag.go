@@ -0,0 +1,214 @@
+// Package lag measures the backlog of the pipeline's Kafka consumer groups, the difference
+// between each partition's end offset and the group's committed offset, so the evaluator,
+// aggregator and sender deployments can autoscale on backlog (KEDA or an HPA external metric)
+// instead of CPU.
+package lag
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Lag monitoring defaults.
+const (
+	// DefaultInterval is how often consumer group lag is measured.
+	DefaultInterval = 15 * time.Second
+	// DefaultTargets are the pipeline's consumer groups and the topics they consume.
+	DefaultTargets = "evaluator-group:alerts.new,aggregator-group:alerts.matched,sender-group:notifications.ready"
+	// runTimeout bounds one measuring run.
+	runTimeout = 10 * time.Second
+)
+
+// Target is a consumer group and the topic it consumes.
+type Target struct {
+	Group string
+	Topic string
+}
+
+// ParseTargets parses a comma-separated list of group:topic pairs.
+func ParseTargets(s string) ([]Target, error) {
+	var targets []Target
+	seen := make(map[Target]bool)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		group, topic, ok := strings.Cut(item, ":")
+		group, topic = strings.TrimSpace(group), strings.TrimSpace(topic)
+		if !ok || group == "" || topic == "" {
+			return nil, fmt.Errorf("invalid lag target %q (expected group:topic)", item)
+		}
+		t := Target{Group: group, Topic: topic}
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		targets = append(targets, t)
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no lag targets")
+	}
+	return targets, nil
+}
+
+// Offsets reads partition offsets from Kafka.
+type Offsets interface {
+	// EndOffsets returns the offset the next message of each partition of topic will get.
+	EndOffsets(ctx context.Context, topic string) (map[int]int64, error)
+	// CommittedOffsets returns the group's committed offset of each of the topic's partitions.
+	// Partitions the group never committed are missing or negative.
+	CommittedOffsets(ctx context.Context, group, topic string, partitions []int) (map[int]int64, error)
+}
+
+// GroupLag is the lag of a consumer group on a topic.
+type GroupLag struct {
+	Group      string        `json:"group"`
+	Topic      string        `json:"topic"`
+	Lag        int64         `json:"lag"`
+	Partitions map[int]int64 `json:"partitions"`
+	MeasuredAt time.Time     `json:"measured_at"`
+	Error      string        `json:"error,omitempty"`
+}
+
+// Monitor measures the lag of its targets every interval and keeps the latest measurements.
+type Monitor struct {
+	targets  []Target
+	offsets  Offsets
+	interval time.Duration
+	now      func() time.Time
+
+	mu   sync.RWMutex
+	lags map[Target]GroupLag
+}
+
+// NewMonitor creates a monitor measuring targets every interval.
+// A non-positive interval uses DefaultInterval.
+func NewMonitor(targets []Target, offsets Offsets, interval time.Duration) *Monitor {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	return &Monitor{
+		targets:  targets,
+		offsets:  offsets,
+		interval: interval,
+		now:      time.Now,
+		lags:     make(map[Target]GroupLag),
+	}
+}
+
+// Run measures every interval until ctx is cancelled.
+func (m *Monitor) Run(ctx context.Context) {
+	slog.Info("Starting consumer lag monitoring", "interval", m.interval, "targets", len(m.targets))
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		if err := m.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("Consumer lag measurement failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce measures every target. A target that cannot be measured keeps its last lag and
+// records the error; the first such error is returned after all targets were tried.
+func (m *Monitor) RunOnce(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, runTimeout)
+	defer cancel()
+
+	var firstErr error
+	for _, t := range m.targets {
+		measured, err := m.measure(ctx, t)
+		m.mu.Lock()
+		if err != nil {
+			prev := m.lags[t]
+			prev.Group, prev.Topic, prev.Error = t.Group, t.Topic, err.Error()
+			m.lags[t] = prev
+		} else {
+			m.lags[t] = measured
+		}
+		m.mu.Unlock()
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to measure lag of %s on %s: %w", t.Group, t.Topic, err)
+		}
+	}
+	return firstErr
+}
+
+// measure computes the lag of one target. Partitions the group never committed have no lag:
+// consumers start from the latest offset when they have no committed offset.
+func (m *Monitor) measure(ctx context.Context, t Target) (GroupLag, error) {
+	end, err := m.offsets.EndOffsets(ctx, t.Topic)
+	if err != nil {
+		return GroupLag{}, err
+	}
+	partitions := make([]int, 0, len(end))
+	for p := range end {
+		partitions = append(partitions, p)
+	}
+	sort.Ints(partitions)
+
+	committed, err := m.offsets.CommittedOffsets(ctx, t.Group, t.Topic, partitions)
+	if err != nil {
+		return GroupLag{}, err
+	}
+
+	gl := GroupLag{
+		Group:      t.Group,
+		Topic:      t.Topic,
+		Partitions: make(map[int]int64, len(partitions)),
+		MeasuredAt: m.now(),
+	}
+	for _, p := range partitions {
+		var lag int64
+		if c, ok := committed[p]; ok && c >= 0 && end[p] > c {
+			lag = end[p] - c
+		}
+		gl.Partitions[p] = lag
+		gl.Lag += lag
+	}
+	return gl, nil
+}
+
+// Lags returns the latest lag of every measured target, ordered by group and topic.
+func (m *Monitor) Lags() []GroupLag {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	lags := make([]GroupLag, 0, len(m.lags))
+	for _, gl := range m.lags {
+		lags = append(lags, gl)
+	}
+	sort.Slice(lags, func(i, j int) bool {
+		if lags[i].Group != lags[j].Group {
+			return lags[i].Group < lags[j].Group
+		}
+		return lags[i].Topic < lags[j].Topic
+	})
+	return lags
+}
+
+// Lag returns the latest total lag of a group over all its topics.
+// Returns false if the group is not a target or has not been measured yet.
+func (m *Monitor) Lag(group string) (int64, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var total int64
+	found := false
+	for t, gl := range m.lags {
+		if t.Group != group || gl.MeasuredAt.IsZero() {
+			continue
+		}
+		total += gl.Lag
+		found = true
+	}
+	return total, found
+}
@@ -0,0 +1,125 @@
+// Package lag provides tests for consumer lag monitoring.
+package lag
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeOffsets returns fixed offsets per topic and group.
+type fakeOffsets struct {
+	end       map[string]map[int]int64 // by topic
+	committed map[string]map[int]int64 // by group
+	endErr    error
+}
+
+func (f *fakeOffsets) EndOffsets(ctx context.Context, topic string) (map[int]int64, error) {
+	if f.endErr != nil {
+		return nil, f.endErr
+	}
+	return f.end[topic], nil
+}
+
+func (f *fakeOffsets) CommittedOffsets(ctx context.Context, group, topic string, partitions []int) (map[int]int64, error) {
+	return f.committed[group], nil
+}
+
+func TestParseTargets(t *testing.T) {
+	targets, err := ParseTargets(DefaultTargets)
+	if err != nil {
+		t.Fatalf("ParseTargets() error = %v", err)
+	}
+	if len(targets) != 3 || targets[0] != (Target{Group: "evaluator-group", Topic: "alerts.new"}) {
+		t.Errorf("ParseTargets() = %v", targets)
+	}
+
+	targets, err = ParseTargets(" a : t1 , a:t1, b:t2 ")
+	if err != nil {
+		t.Fatalf("ParseTargets() error = %v", err)
+	}
+	if len(targets) != 2 || targets[0] != (Target{Group: "a", Topic: "t1"}) {
+		t.Errorf("ParseTargets() = %v, want duplicates removed and spaces trimmed", targets)
+	}
+
+	for _, s := range []string{"", " , ", "group", "group:", ":topic"} {
+		if _, err := ParseTargets(s); err == nil {
+			t.Errorf("ParseTargets(%q) expected error", s)
+		}
+	}
+}
+
+func TestMonitor_RunOnce(t *testing.T) {
+	offsets := &fakeOffsets{
+		end: map[string]map[int]int64{
+			"alerts.new":     {0: 100, 1: 50, 2: 10},
+			"alerts.matched": {0: 20},
+		},
+		committed: map[string]map[int]int64{
+			// Partition 2 was never committed
+			"evaluator-group":  {0: 90, 1: 50, 2: -1},
+			"aggregator-group": {0: 5},
+		},
+	}
+	targets := []Target{
+		{Group: "evaluator-group", Topic: "alerts.new"},
+		{Group: "aggregator-group", Topic: "alerts.matched"},
+	}
+	m := NewMonitor(targets, offsets, time.Second)
+	measuredAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return measuredAt }
+
+	if _, ok := m.Lag("evaluator-group"); ok {
+		t.Error("Lag() before measuring should report not found")
+	}
+	if err := m.RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce() error = %v", err)
+	}
+
+	if lag, ok := m.Lag("evaluator-group"); !ok || lag != 10 {
+		t.Errorf("Lag(evaluator-group) = %d, %v, want 10, true", lag, ok)
+	}
+	if lag, ok := m.Lag("aggregator-group"); !ok || lag != 15 {
+		t.Errorf("Lag(aggregator-group) = %d, %v, want 15, true", lag, ok)
+	}
+	if _, ok := m.Lag("sender-group"); ok {
+		t.Error("Lag() of an unknown group should report not found")
+	}
+
+	lags := m.Lags()
+	if len(lags) != 2 || lags[0].Group != "aggregator-group" || lags[1].Group != "evaluator-group" {
+		t.Fatalf("Lags() = %v, want ordered by group", lags)
+	}
+	if p := lags[1].Partitions; p[0] != 10 || p[1] != 0 || p[2] != 0 {
+		t.Errorf("evaluator partitions = %v, want 10/0/0", p)
+	}
+	if !lags[1].MeasuredAt.Equal(measuredAt) {
+		t.Errorf("MeasuredAt = %v, want %v", lags[1].MeasuredAt, measuredAt)
+	}
+}
+
+func TestMonitor_RunOnce_KeepsLastLagOnError(t *testing.T) {
+	offsets := &fakeOffsets{
+		end:       map[string]map[int]int64{"alerts.new": {0: 100}},
+		committed: map[string]map[int]int64{"evaluator-group": {0: 40}},
+	}
+	m := NewMonitor([]Target{{Group: "evaluator-group", Topic: "alerts.new"}}, offsets, 0)
+	if m.interval != DefaultInterval {
+		t.Errorf("interval = %v, want %v", m.interval, DefaultInterval)
+	}
+	if err := m.RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce() error = %v", err)
+	}
+
+	offsets.endErr = errors.New("broker down")
+	if err := m.RunOnce(context.Background()); err == nil {
+		t.Fatal("RunOnce() expected error")
+	}
+	if lag, ok := m.Lag("evaluator-group"); !ok || lag != 60 {
+		t.Errorf("Lag() = %d, %v, want last lag 60, true", lag, ok)
+	}
+	if lags := m.Lags(); len(lags) != 1 || lags[0].Error != "broker down" {
+		t.Errorf("Lags() = %v, want the error recorded", lags)
+	}
+}
@@ -0,0 +1,45 @@
+// Package router provides HTTP routing configuration for the metrics-service API.
+package router
+
+import (
+	"net/http"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// publicPaths are served without an API key: probes and the consumer lag scrape used for autoscaling.
+var publicPaths = []string{"/health", "/healthz", "/readyz", "/metrics/consumer-lag"}
+
+// clientScopedPatterns are the routes a client-scoped API key may call, all of which take a
+// client_id filter. Platform-wide metrics need an admin key.
+var clientScopedPatterns = map[string]bool{
+	"/api/v1/kpis":           true,
+	"/api/v1/reports/{name}": true,
+}
+
+// clientScopeMiddleware limits client-scoped API keys to clientScopedPatterns, with the
+// client_id filter set to their own client; other routes and other clients get 403.
+// Admin keys and unauthenticated requests pass through.
+func clientScopeMiddleware(mux *http.ServeMux) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, ok := shared.APIPrincipalFromContext(r.Context())
+			if !ok || p.Admin() {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if _, pattern := mux.Handler(r); !clientScopedPatterns[pattern] {
+				http.Error(w, "Admin API key required", http.StatusForbidden)
+				return
+			}
+			q := r.URL.Query()
+			if clientID := q.Get("client_id"); clientID != "" && clientID != p.ClientID {
+				http.Error(w, "API key is not authorized for client "+clientID, http.StatusForbidden)
+				return
+			}
+			q.Set("client_id", p.ClientID)
+			r.URL.RawQuery = q.Encode()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,82 @@
+// Package router provides tests for API key authentication and client scoping.
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"metrics-service/internal/database"
+	"metrics-service/internal/handlers"
+	"metrics-service/internal/kpi"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// stubAPIKeyStore authenticates fixed keys.
+type stubAPIKeyStore map[string]shared.APIPrincipal
+
+func (s stubAPIKeyStore) LookupAPIKey(ctx context.Context, keyHash string) (*shared.APIPrincipal, error) {
+	for key, p := range s {
+		if shared.HashAPIKey(key) == keyHash {
+			return &p, nil
+		}
+	}
+	return nil, nil
+}
+
+// recordingKPIReader records the client of the last KPI report.
+type recordingKPIReader struct {
+	clientID string
+}
+
+func (r *recordingKPIReader) Report(ctx context.Context, days int, clientID string) (*kpi.Report, error) {
+	r.clientID = clientID
+	return &kpi.Report{}, nil
+}
+
+// TestRouter_APIKeyAuth tests that routes require a key and client keys only read their own KPIs.
+func TestRouter_APIKeyAuth(t *testing.T) {
+	store := stubAPIKeyStore{
+		"client-key": {KeyID: "k1", Name: "ci", ClientID: "client-1"},
+		"admin-key":  {KeyID: "k2", Name: "ops"},
+	}
+	kpis := &recordingKPIReader{}
+	h := handlers.NewHandlers(&database.DB{}, nil, nil)
+	h.SetKPIReader(kpis)
+	handler := NewRouter(h, WithAuthenticator(shared.NewAPIKeyAuthenticator(store, "", 0))).Handler()
+
+	tests := []struct {
+		name         string
+		path         string
+		key          string
+		wantStatus   int
+		wantClientID string
+	}{
+		{name: "health is public", path: "/health", wantStatus: http.StatusOK},
+		{name: "missing key", path: "/api/v1/kpis", wantStatus: http.StatusUnauthorized},
+		{name: "client key on platform metrics", path: "/api/v1/canary", key: "client-key", wantStatus: http.StatusForbidden},
+		{name: "client key on other client", path: "/api/v1/kpis?client_id=client-2", key: "client-key", wantStatus: http.StatusForbidden},
+		{name: "client key scoped to own client", path: "/api/v1/kpis", key: "client-key", wantStatus: http.StatusOK, wantClientID: "client-1"},
+		{name: "admin key reads all clients", path: "/api/v1/kpis", key: "admin-key", wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kpis.clientID = "unset"
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.key != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.key)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("GET %s status = %v, want %v: %s", tt.path, w.Code, tt.wantStatus, w.Body.String())
+			}
+			if w.Code == http.StatusOK && tt.path == "/api/v1/kpis" && kpis.clientID != tt.wantClientID {
+				t.Errorf("KPI client = %q, want %q", kpis.clientID, tt.wantClientID)
+			}
+		})
+	}
+}
@@ -8,22 +8,6 @@ import (
 	"github.com/afikmenashe/alerting-platform/pkg/metrics"
 )
 
-// corsMiddleware applies CORS headers to all requests.
-func corsMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-
-		next.ServeHTTP(w, r)
-	})
-}
-
 // responseWriter wraps http.ResponseWriter to capture status code.
 type responseWriter struct {
 	http.ResponseWriter
@@ -36,7 +20,7 @@ func (rw *responseWriter) WriteHeader(code int) {
 }
 
 // metricsMiddleware tracks HTTP request metrics.
-func metricsMiddleware(collector *metrics.Collector) func(http.Handler) http.Handler {
+func metricsMiddleware(collector metrics.Collector) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			if collector == nil {
@@ -44,8 +28,8 @@ func metricsMiddleware(collector *metrics.Collector) func(http.Handler) http.Han
 				return
 			}
 
-			// Skip health endpoint to avoid noise
-			if r.URL.Path == "/health" {
+			// Skip health and readiness probes to avoid noise
+			if r.URL.Path == "/health" || r.URL.Path == "/healthz" || r.URL.Path == "/readyz" {
 				next.ServeHTTP(w, r)
 				return
 			}
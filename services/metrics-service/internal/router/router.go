@@ -5,27 +5,54 @@ import (
 	"net/http"
 
 	"metrics-service/internal/handlers"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
 )
 
 // Router wraps the HTTP mux and provides route configuration.
 type Router struct {
 	mux      *http.ServeMux
 	handlers *handlers.Handlers
+	auth     *shared.APIKeyAuthenticator
+	cors     *shared.CORSPolicy
+}
+
+// Option configures optional router features.
+type Option func(*Router)
+
+// WithAuthenticator requires an API key on every route except health checks and the consumer
+// lag scrape. Keys scoped to a client can only read that client's KPIs and reports.
+func WithAuthenticator(auth *shared.APIKeyAuthenticator) Option {
+	return func(r *Router) {
+		r.auth = auth
+	}
+}
+
+// WithCORS sets which browser origins may call the API (default: any origin).
+func WithCORS(policy *shared.CORSPolicy) Option {
+	return func(r *Router) {
+		r.cors = policy
+	}
 }
 
 // NewRouter creates a new router with all routes configured.
-func NewRouter(h *handlers.Handlers) *Router {
+func NewRouter(h *handlers.Handlers, opts ...Option) *Router {
 	r := &Router{
 		mux:      http.NewServeMux(),
 		handlers: h,
 	}
+	for _, opt := range opts {
+		opt(r)
+	}
 	r.setupRoutes()
 	return r
 }
 
-// Handler returns the HTTP handler with CORS and metrics middleware applied.
+// Handler returns the HTTP handler with CORS, metrics and authentication middleware applied.
 func (r *Router) Handler() http.Handler {
-	handler := corsMiddleware(r.mux)
+	handler := clientScopeMiddleware(r.mux)(r.mux)
+	handler = shared.APIKeyMiddleware(r.auth, "metrics-service", publicPaths...)(handler)
+	handler = shared.CORSMiddleware(r.cors)(handler)
 	handler = metricsMiddleware(r.handlers.GetMetricsCollector())(handler)
 	return handler
 }
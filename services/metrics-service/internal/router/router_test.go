@@ -92,6 +92,9 @@ func TestNewServer(t *testing.T) {
 	if server.Handler == nil {
 		t.Error("NewServer() Handler is nil")
 	}
+	if server.ReadHeaderTimeout <= 0 {
+		t.Error("NewServer() ReadHeaderTimeout is not set")
+	}
 }
 
 // TestRouter_MethodNotAllowed tests that non-GET methods return 405.
@@ -112,6 +115,13 @@ func TestRouter_MethodNotAllowed(t *testing.T) {
 		{"metrics DELETE", http.MethodDelete, "/api/v1/metrics"},
 		{"services/metrics POST", http.MethodPost, "/api/v1/services/metrics"},
 		{"services/metrics PUT", http.MethodPut, "/api/v1/services/metrics"},
+		{"rules/rates POST", http.MethodPost, "/api/v1/rules/rates"},
+		{"rules/noisiest POST", http.MethodPost, "/api/v1/rules/noisiest"},
+		{"canary POST", http.MethodPost, "/api/v1/canary"},
+		{"debug/alert POST", http.MethodPost, "/api/v1/debug/alert/alert-1"},
+		{"slo POST", http.MethodPost, "/api/v1/slo"},
+		{"consumer-lag POST", http.MethodPost, "/api/v1/consumer-lag"},
+		{"consumer-lag/group POST", http.MethodPost, "/api/v1/consumer-lag/sender-group"},
 	}
 
 	for _, tt := range tests {
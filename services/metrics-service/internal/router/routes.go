@@ -25,6 +25,148 @@ func (r *Router) setupRoutes() {
 		}
 	})
 
+	// Per-rule notification rates (1h/24h/7d with week-over-week deltas)
+	r.mux.HandleFunc("/api/v1/rules/rates", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodGet {
+			r.handlers.GetRuleRates(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Noisiest rules report
+	r.mux.HandleFunc("/api/v1/rules/noisiest", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodGet {
+			r.handlers.GetNoisiestRules(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// End-to-end pipeline health from the synthetic canary loop
+	r.mux.HandleFunc("/api/v1/canary", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodGet {
+			r.handlers.GetCanaryStatus(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Rule propagation latency from rule-service to the snapshot and evaluator
+	r.mux.HandleFunc("/api/v1/propagation", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodGet {
+			r.handlers.GetRulePropagation(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Notification latency per pipeline stage, from the times each stage stamps on the events
+	r.mux.HandleFunc("/api/v1/latency", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodGet {
+			r.handlers.GetPipelineLatency(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Per-alert trace through evaluator, aggregator, and sender
+	r.mux.HandleFunc("/api/v1/debug/alert/{alert_id}", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodGet {
+			r.handlers.GetAlertTrace(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Allow-listed reporting queries for analysts
+	r.mux.HandleFunc("/api/v1/reports", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodGet {
+			r.handlers.ListReports(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.mux.HandleFunc("/api/v1/reports/{name}", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodGet {
+			r.handlers.GetReport(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Mean time to acknowledge / resolve per client and rule
+	r.mux.HandleFunc("/api/v1/kpis", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodGet {
+			r.handlers.GetKPIs(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Error-budget consumption of the pipeline stages' SLOs
+	r.mux.HandleFunc("/api/v1/slo", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodGet {
+			r.handlers.GetSLO(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Consumer group lag, for autoscaling the pipeline on backlog (KEDA metrics-api scaler)
+	r.mux.HandleFunc("/api/v1/consumer-lag", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodGet {
+			r.handlers.GetConsumerLag(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.mux.HandleFunc("/api/v1/consumer-lag/{group}", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodGet {
+			r.handlers.GetConsumerGroupLag(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Consumer group lag in the Prometheus text format (HPA external metric via prometheus-adapter)
+	r.mux.HandleFunc("/metrics/consumer-lag", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodGet {
+			r.handlers.GetConsumerLagPrometheus(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Weekly summary report (noisiest rules and KPIs)
+	r.mux.HandleFunc("/api/v1/summary/weekly", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodGet {
+			r.handlers.GetWeeklySummary(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Dependency health (Postgres, Redis, Kafka), cached between probes
+	r.mux.HandleFunc("/healthz", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodGet {
+			r.handlers.Healthz(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Readiness endpoint (reports unhealthy dependencies and the emergency stop)
+	r.mux.HandleFunc("/readyz", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodGet {
+			r.handlers.Readiness(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
 	// Health check endpoint
 	r.mux.HandleFunc("/health", func(w http.ResponseWriter, req *http.Request) {
 		w.WriteHeader(http.StatusOK)
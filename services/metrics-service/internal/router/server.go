@@ -3,19 +3,14 @@ package router
 
 import (
 	"net/http"
-	"time"
 
 	"metrics-service/internal/handlers"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
 )
 
-// NewServer creates a new HTTP server with the router configured.
-func NewServer(port string, h *handlers.Handlers) *http.Server {
-	router := NewRouter(h)
-	return &http.Server{
-		Addr:         ":" + port,
-		Handler:      router.Handler(),
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
-	}
+// NewServer creates a new HTTP server with the router configured, hardened by shared.NewHTTPServer.
+func NewServer(port string, h *handlers.Handlers, opts ...Option) *http.Server {
+	router := NewRouter(h, opts...)
+	return shared.NewHTTPServer(":"+port, router.Handler(), shared.WithQuietPaths("/healthz", "/readyz"))
 }
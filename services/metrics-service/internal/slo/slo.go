@@ -0,0 +1,376 @@
+// Package slo tracks service level objectives (availability and latency targets) per
+// pipeline stage and their rolling error-budget consumption. A sampler folds the growth of
+// each stage's metrics counters into hourly totals, so the budget survives service restarts
+// and metrics-service restarts, and reports never depend on how long a service has been up.
+package slo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/afikmenashe/alerting-platform/pkg/metrics"
+)
+
+// SLO tracking defaults.
+const (
+	// DefaultInterval is how often stage metrics are sampled.
+	DefaultInterval = time.Minute
+	// DefaultWindowDays is the default rolling error-budget window.
+	DefaultWindowDays = 30
+	// MaxWindowDays is the longest window, and so how long hourly totals are kept.
+	MaxWindowDays = 30
+	// runTimeout bounds one sampling run.
+	runTimeout = 30 * time.Second
+)
+
+// hourLayout formats the hour of an hourly bucket.
+const hourLayout = "2006-01-02T15"
+
+// Objective is the SLO of one pipeline stage (a service reporting metrics).
+//
+// Availability is the target fraction of received messages processed without error.
+// LatencyMs and LatencyTarget are the target fraction of samples whose average processing
+// latency stays within LatencyMs. A zero target disables that objective.
+type Objective struct {
+	Stage         string  `json:"stage"`
+	Availability  float64 `json:"availability,omitempty"`
+	LatencyMs     float64 `json:"latency_ms,omitempty"`
+	LatencyTarget float64 `json:"latency_target,omitempty"`
+}
+
+// Config is the set of SLOs and the rolling window their error budgets are computed over.
+type Config struct {
+	WindowDays int         `json:"window_days"`
+	Objectives []Objective `json:"objectives"`
+}
+
+// DefaultConfig returns the SLOs used when no SLO file is configured.
+func DefaultConfig() Config {
+	return Config{
+		WindowDays: DefaultWindowDays,
+		Objectives: []Objective{
+			{Stage: "evaluator", Availability: 0.999, LatencyMs: 50, LatencyTarget: 0.99},
+			{Stage: "aggregator", Availability: 0.999, LatencyMs: 100, LatencyTarget: 0.99},
+			{Stage: "sender", Availability: 0.995, LatencyMs: 2000, LatencyTarget: 0.95},
+		},
+	}
+}
+
+// LoadConfig reads SLOs from a JSON file of the form:
+//
+//	{"window_days": 30, "objectives": [{"stage": "evaluator", "availability": 0.999, "latency_ms": 50, "latency_target": 0.99}]}
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to read SLO file: %w", err)
+	}
+
+	cfg := Config{WindowDays: DefaultWindowDays}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("failed to parse SLO file: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid SLO file: %w", err)
+	}
+	return cfg, nil
+}
+
+// Validate checks that the window and every objective are usable.
+func (c Config) Validate() error {
+	if c.WindowDays <= 0 || c.WindowDays > MaxWindowDays {
+		return fmt.Errorf("window_days must be between 1 and %d", MaxWindowDays)
+	}
+	if len(c.Objectives) == 0 {
+		return fmt.Errorf("at least one objective is required")
+	}
+	seen := make(map[string]bool, len(c.Objectives))
+	for _, o := range c.Objectives {
+		if o.Stage == "" {
+			return fmt.Errorf("objective stage cannot be empty")
+		}
+		if seen[o.Stage] {
+			return fmt.Errorf("duplicate objective for stage %q", o.Stage)
+		}
+		seen[o.Stage] = true
+		if o.Availability < 0 || o.Availability >= 1 {
+			return fmt.Errorf("stage %q: availability must be in [0, 1)", o.Stage)
+		}
+		if o.LatencyTarget < 0 || o.LatencyTarget >= 1 {
+			return fmt.Errorf("stage %q: latency_target must be in [0, 1)", o.Stage)
+		}
+		if (o.LatencyTarget > 0) != (o.LatencyMs > 0) {
+			return fmt.Errorf("stage %q: latency_ms and latency_target must be set together", o.Stage)
+		}
+		if o.Availability == 0 && o.LatencyTarget == 0 {
+			return fmt.Errorf("stage %q: no availability or latency target", o.Stage)
+		}
+	}
+	return nil
+}
+
+// MetricsSource reads the current metrics of a service.
+type MetricsSource interface {
+	GetServiceMetrics(ctx context.Context, serviceName string) (*metrics.ServiceMetrics, error)
+}
+
+// Store persists hourly totals and the last sample of each stage.
+type Store interface {
+	// Lock takes the sampling lock so that only one instance samples at a time.
+	Lock(ctx context.Context, ttl time.Duration) (bool, error)
+	Unlock(ctx context.Context) error
+	// LastSample returns the last sample of a stage, or nil if there is none.
+	LastSample(ctx context.Context, stage string) (*Sample, error)
+	// Apply adds totals to a stage's hourly bucket and stores its new last sample, atomically.
+	Apply(ctx context.Context, stage, hour string, totals Totals, sample Sample) error
+	// Load returns the sum of a stage's totals over the given hours.
+	Load(ctx context.Context, stage string, hours []string) (Totals, error)
+}
+
+// Sample is the cumulative counters of a stage as last reported.
+type Sample struct {
+	StartedAt time.Time `json:"started_at"`
+	Reported  time.Time `json:"reported"`
+	Received  uint64    `json:"received"`
+	Errors    uint64    `json:"errors"`
+	Processed uint64    `json:"processed"`
+	LatencyNs float64   `json:"latency_ns"` // total processing latency of the processed messages
+}
+
+// newSample takes a sample from reported service metrics.
+func newSample(m *metrics.ServiceMetrics) Sample {
+	return Sample{
+		StartedAt: m.StartedAt,
+		Reported:  m.LastUpdated,
+		Received:  m.MessagesReceived,
+		Errors:    m.ProcessingErrors,
+		Processed: m.MessagesProcessed,
+		LatencyNs: m.AvgProcessingLatencyNs * float64(m.MessagesProcessed),
+	}
+}
+
+// since returns the growth of s since prev. A restarted service starts its counters over,
+// so everything it counted since the restart is new.
+func (s Sample) since(prev Sample) Sample {
+	if !s.StartedAt.Equal(prev.StartedAt) || s.Received < prev.Received || s.Errors < prev.Errors || s.Processed < prev.Processed {
+		return s
+	}
+	return Sample{
+		Received:  s.Received - prev.Received,
+		Errors:    s.Errors - prev.Errors,
+		Processed: s.Processed - prev.Processed,
+		LatencyNs: s.LatencyNs - prev.LatencyNs,
+	}
+}
+
+// Totals counts a stage's events and latency samples in a period.
+type Totals struct {
+	Events         int64 // messages received
+	Errors         int64 // processing errors
+	LatencySamples int64 // samples in which messages were processed
+	LatencyGood    int64 // of which the average latency was within the objective
+}
+
+// Add returns the sum of t and o.
+func (t Totals) Add(o Totals) Totals {
+	return Totals{
+		Events:         t.Events + o.Events,
+		Errors:         t.Errors + o.Errors,
+		LatencySamples: t.LatencySamples + o.LatencySamples,
+		LatencyGood:    t.LatencyGood + o.LatencyGood,
+	}
+}
+
+// Tracker samples stage metrics and reports error-budget consumption.
+type Tracker struct {
+	config   Config
+	source   MetricsSource
+	store    Store
+	interval time.Duration
+	now      func() time.Time
+}
+
+// NewTracker creates a tracker for the SLOs in config that samples source every interval and
+// keeps totals in store. A non-positive interval uses DefaultInterval.
+func NewTracker(config Config, source MetricsSource, store Store, interval time.Duration) *Tracker {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	return &Tracker{
+		config:   config,
+		source:   source,
+		store:    store,
+		interval: interval,
+		now:      time.Now,
+	}
+}
+
+// Run samples every interval until ctx is cancelled.
+func (t *Tracker) Run(ctx context.Context) {
+	slog.Info("Starting SLO tracking", "interval", t.interval, "objectives", len(t.config.Objectives), "window_days", t.config.WindowDays)
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+	for {
+		if err := t.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("SLO sampling failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce samples every stage with an objective. It does nothing if another instance holds
+// the sampling lock. Stages not reporting metrics are skipped: they neither spend nor earn
+// budget until they report again.
+func (t *Tracker) RunOnce(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, runTimeout)
+	defer cancel()
+
+	locked, err := t.store.Lock(ctx, runTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to take SLO lock: %w", err)
+	}
+	if !locked {
+		return nil
+	}
+	defer t.store.Unlock(context.WithoutCancel(ctx))
+
+	for _, o := range t.config.Objectives {
+		m, err := t.source.GetServiceMetrics(ctx, o.Stage)
+		if err != nil {
+			slog.Debug("No metrics for SLO stage, skipping", "stage", o.Stage, "error", err)
+			continue
+		}
+		if err := t.sample(ctx, o, newSample(m)); err != nil {
+			return fmt.Errorf("failed to sample stage %s: %w", o.Stage, err)
+		}
+	}
+	return nil
+}
+
+// sample folds the growth of a stage's counters since its last sample into the hourly totals.
+func (t *Tracker) sample(ctx context.Context, o Objective, cur Sample) error {
+	prev, err := t.store.LastSample(ctx, o.Stage)
+	if err != nil {
+		return err
+	}
+	if prev != nil && !cur.Reported.After(prev.Reported) {
+		return nil // no new report since the last sample
+	}
+
+	var totals Totals
+	if prev != nil {
+		delta := cur.since(*prev)
+		totals.Events = int64(delta.Received)
+		totals.Errors = int64(delta.Errors)
+		if o.LatencyTarget > 0 && delta.Processed > 0 {
+			totals.LatencySamples = 1
+			if avgMs := delta.LatencyNs / float64(delta.Processed) / 1e6; avgMs <= o.LatencyMs {
+				totals.LatencyGood = 1
+			}
+		}
+	}
+	return t.store.Apply(ctx, o.Stage, cur.Reported.UTC().Format(hourLayout), totals, cur)
+}
+
+// Budget is the state of one objective over the window.
+type Budget struct {
+	Target float64  `json:"target"`
+	Actual *float64 `json:"actual,omitempty"` // nil when there were no events
+	Good   int64    `json:"good"`
+	Total  int64    `json:"total"`
+	// Consumed is the fraction of the error budget spent; above 1 the objective is missed.
+	Consumed  float64 `json:"consumed"`
+	Remaining float64 `json:"remaining"`
+	Exhausted bool    `json:"exhausted"`
+}
+
+// newBudget computes the budget of target given good out of total events.
+func newBudget(target float64, good, total int64) *Budget {
+	b := &Budget{Target: target, Good: good, Total: total, Remaining: 1}
+	if total == 0 {
+		return b
+	}
+	actual := float64(good) / float64(total)
+	b.Actual = &actual
+	allowed := (1 - target) * float64(total)
+	b.Consumed = float64(total-good) / allowed
+	b.Remaining = max(0, 1-b.Consumed)
+	b.Exhausted = b.Consumed >= 1
+	return b
+}
+
+// StageStatus is the SLO state of one stage.
+type StageStatus struct {
+	Stage        string  `json:"stage"`
+	Availability *Budget `json:"availability,omitempty"`
+	LatencyMs    float64 `json:"latency_ms,omitempty"`
+	Latency      *Budget `json:"latency,omitempty"`
+	Exhausted    bool    `json:"exhausted"`
+}
+
+// Report is the error-budget consumption of every objective over the rolling window.
+type Report struct {
+	WindowDays int           `json:"window_days"`
+	From       time.Time     `json:"from"`
+	To         time.Time     `json:"to"`
+	Stages     []StageStatus `json:"stages"`
+	// Exhausted lists the stages that spent their whole budget, for alerting on the pipeline itself.
+	Exhausted []string `json:"exhausted"`
+}
+
+// Report returns the error-budget consumption of every objective over the last WindowDays
+// days, counted in whole hours (the current hour included).
+func (t *Tracker) Report(ctx context.Context) (*Report, error) {
+	to := t.now().UTC()
+	hours := windowHours(to, t.config.WindowDays)
+	from, _ := time.Parse(hourLayout, hours[0])
+
+	report := &Report{
+		WindowDays: t.config.WindowDays,
+		From:       from,
+		To:         to,
+		Stages:     make([]StageStatus, 0, len(t.config.Objectives)),
+		Exhausted:  []string{},
+	}
+	for _, o := range t.config.Objectives {
+		totals, err := t.store.Load(ctx, o.Stage, hours)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load SLO totals for %s: %w", o.Stage, err)
+		}
+
+		status := StageStatus{Stage: o.Stage}
+		if o.Availability > 0 {
+			good := max(0, totals.Events-totals.Errors)
+			status.Availability = newBudget(o.Availability, good, totals.Events)
+			status.Exhausted = status.Availability.Exhausted
+		}
+		if o.LatencyTarget > 0 {
+			status.LatencyMs = o.LatencyMs
+			status.Latency = newBudget(o.LatencyTarget, totals.LatencyGood, totals.LatencySamples)
+			status.Exhausted = status.Exhausted || status.Latency.Exhausted
+		}
+		if status.Exhausted {
+			report.Exhausted = append(report.Exhausted, o.Stage)
+		}
+		report.Stages = append(report.Stages, status)
+	}
+	return report, nil
+}
+
+// windowHours returns the hourly buckets of the days-long window ending at to, oldest first.
+func windowHours(to time.Time, days int) []string {
+	end := to.Truncate(time.Hour)
+	n := days * 24
+	hours := make([]string, n)
+	for i := 0; i < n; i++ {
+		hours[i] = end.Add(-time.Duration(n-1-i) * time.Hour).Format(hourLayout)
+	}
+	return hours
+}
@@ -0,0 +1,218 @@
+// Package slo provides tests for SLO error-budget tracking.
+package slo
+
+import (
+	"context"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/afikmenashe/alerting-platform/pkg/metrics"
+)
+
+// fakeSource returns the metrics currently set per service.
+type fakeSource struct {
+	services map[string]*metrics.ServiceMetrics
+}
+
+func (s *fakeSource) GetServiceMetrics(ctx context.Context, serviceName string) (*metrics.ServiceMetrics, error) {
+	m, ok := s.services[serviceName]
+	if !ok {
+		return nil, errors.New("no metrics found for service: " + serviceName)
+	}
+	copied := *m
+	return &copied, nil
+}
+
+// memoryStore keeps totals in memory.
+type memoryStore struct {
+	locked  bool
+	samples map[string]Sample
+	buckets map[string]Totals // by bucketKey
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{samples: make(map[string]Sample), buckets: make(map[string]Totals)}
+}
+
+func (s *memoryStore) Lock(ctx context.Context, ttl time.Duration) (bool, error) {
+	if s.locked {
+		return false, nil
+	}
+	s.locked = true
+	return true, nil
+}
+
+func (s *memoryStore) Unlock(ctx context.Context) error {
+	s.locked = false
+	return nil
+}
+
+func (s *memoryStore) LastSample(ctx context.Context, stage string) (*Sample, error) {
+	sample, ok := s.samples[stage]
+	if !ok {
+		return nil, nil
+	}
+	return &sample, nil
+}
+
+func (s *memoryStore) Apply(ctx context.Context, stage, hour string, totals Totals, sample Sample) error {
+	key := bucketKey(stage, hour)
+	s.buckets[key] = s.buckets[key].Add(totals)
+	s.samples[stage] = sample
+	return nil
+}
+
+func (s *memoryStore) Load(ctx context.Context, stage string, hours []string) (Totals, error) {
+	var sum Totals
+	for _, hour := range hours {
+		sum = sum.Add(s.buckets[bucketKey(stage, hour)])
+	}
+	return sum, nil
+}
+
+// report sets a service's cumulative counters as reported at reported.
+func report(startedAt, reported time.Time, received, errs, processed uint64, avgLatency time.Duration) *metrics.ServiceMetrics {
+	return &metrics.ServiceMetrics{
+		ServiceName:            "evaluator",
+		StartedAt:              startedAt,
+		LastUpdated:            reported,
+		MessagesReceived:       received,
+		ProcessingErrors:       errs,
+		MessagesProcessed:      processed,
+		AvgProcessingLatencyNs: float64(avgLatency.Nanoseconds()),
+	}
+}
+
+// approx reports whether a and b are equal up to float rounding.
+func approx(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+// TestTracker_RunOnce tests that counter growth is folded into hourly totals, across restarts.
+func TestTracker_RunOnce(t *testing.T) {
+	config := Config{WindowDays: 1, Objectives: []Objective{
+		{Stage: "evaluator", Availability: 0.99, LatencyMs: 50, LatencyTarget: 0.9},
+		{Stage: "sender", Availability: 0.99}, // not reporting
+	}}
+	started := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	source := &fakeSource{services: map[string]*metrics.ServiceMetrics{}}
+	store := newMemoryStore()
+	tracker := NewTracker(config, source, store, time.Minute)
+	run := func(m *metrics.ServiceMetrics) {
+		t.Helper()
+		source.services["evaluator"] = m
+		if err := tracker.RunOnce(context.Background()); err != nil {
+			t.Fatalf("RunOnce() error = %v", err)
+		}
+	}
+
+	// The first sample is the baseline
+	run(report(started, started.Add(time.Minute), 1000, 0, 1000, 10*time.Millisecond))
+	// 1000 more messages, 5 errors; average over them: (2000*20ms - 1000*10ms) / 1000 = 30ms
+	run(report(started, started.Add(2*time.Minute), 2000, 5, 2000, 20*time.Millisecond))
+	// Same report again: nothing new
+	run(report(started, started.Add(2*time.Minute), 2000, 5, 2000, 20*time.Millisecond))
+	// Restart: 100 messages since, 10 errors, 200ms average
+	run(report(started.Add(3*time.Minute), started.Add(4*time.Minute), 100, 10, 100, 200*time.Millisecond))
+
+	got := store.buckets[bucketKey("evaluator", "2026-03-10T12")]
+	want := Totals{Events: 1100, Errors: 15, LatencySamples: 2, LatencyGood: 1}
+	if got != want {
+		t.Errorf("totals = %+v, want %+v", got, want)
+	}
+	if _, ok := store.samples["sender"]; ok {
+		t.Error("stage without metrics was sampled")
+	}
+
+	// Another instance holds the lock
+	store.locked = true
+	run(report(started.Add(3*time.Minute), started.Add(5*time.Minute), 200, 10, 200, 200*time.Millisecond))
+	if got := store.buckets[bucketKey("evaluator", "2026-03-10T12")]; got != want {
+		t.Errorf("totals while locked = %+v, want %+v", got, want)
+	}
+}
+
+// TestTracker_Report tests budget consumption over the window.
+func TestTracker_Report(t *testing.T) {
+	config := Config{WindowDays: 1, Objectives: []Objective{
+		{Stage: "evaluator", Availability: 0.99, LatencyMs: 50, LatencyTarget: 0.9},
+		{Stage: "sender", Availability: 0.999},
+	}}
+	now := time.Date(2026, 3, 10, 12, 30, 0, 0, time.UTC)
+	store := newMemoryStore()
+	store.buckets[bucketKey("evaluator", "2026-03-10T12")] = Totals{Events: 1000, Errors: 5, LatencySamples: 20, LatencyGood: 19}
+	store.buckets[bucketKey("evaluator", "2026-03-09T13")] = Totals{Events: 1000, Errors: 0}
+	store.buckets[bucketKey("evaluator", "2026-03-09T12")] = Totals{Events: 1000, Errors: 1000} // outside the window
+	store.buckets[bucketKey("sender", "2026-03-10T11")] = Totals{Events: 1000, Errors: 2}
+	tracker := NewTracker(config, &fakeSource{}, store, time.Minute)
+	tracker.now = func() time.Time { return now }
+
+	report, err := tracker.Report(context.Background())
+	if err != nil {
+		t.Fatalf("Report() error = %v", err)
+	}
+	if want := time.Date(2026, 3, 9, 13, 0, 0, 0, time.UTC); !report.From.Equal(want) {
+		t.Errorf("from = %v, want %v", report.From, want)
+	}
+
+	evaluator := report.Stages[0]
+	// 5 errors out of 2000 events with a budget of 1% (20 errors)
+	if a := evaluator.Availability; a.Total != 2000 || a.Good != 1995 || !approx(a.Consumed, 0.25) || a.Exhausted {
+		t.Errorf("evaluator availability = %+v, want 25%% of the budget consumed", a)
+	}
+	// 1 slow sample out of 20 with a budget of 10% (2 samples)
+	if l := evaluator.Latency; l.Total != 20 || !approx(l.Consumed, 0.5) || !approx(l.Remaining, 0.5) {
+		t.Errorf("evaluator latency = %+v, want 50%% of the budget consumed", l)
+	}
+
+	sender := report.Stages[1]
+	// 2 errors out of 1000 with a budget of 0.1% (1 error)
+	if a := sender.Availability; !a.Exhausted || a.Remaining != 0 || sender.Latency != nil {
+		t.Errorf("sender = %+v, want an exhausted availability budget and no latency objective", sender)
+	}
+	if len(report.Exhausted) != 1 || report.Exhausted[0] != "sender" {
+		t.Errorf("exhausted = %v, want [sender]", report.Exhausted)
+	}
+}
+
+// TestConfig_Validate tests SLO validation.
+func TestConfig_Validate(t *testing.T) {
+	if err := DefaultConfig().Validate(); err != nil {
+		t.Errorf("DefaultConfig().Validate() error = %v", err)
+	}
+
+	tests := map[string]Config{
+		"no objectives":    {WindowDays: 30},
+		"window too long":  {WindowDays: MaxWindowDays + 1, Objectives: []Objective{{Stage: "sender", Availability: 0.99}}},
+		"empty stage":      {WindowDays: 30, Objectives: []Objective{{Availability: 0.99}}},
+		"duplicate stage":  {WindowDays: 30, Objectives: []Objective{{Stage: "sender", Availability: 0.99}, {Stage: "sender", Availability: 0.9}}},
+		"availability 1":   {WindowDays: 30, Objectives: []Objective{{Stage: "sender", Availability: 1}}},
+		"latency no limit": {WindowDays: 30, Objectives: []Objective{{Stage: "sender", LatencyTarget: 0.99}}},
+		"no target":        {WindowDays: 30, Objectives: []Objective{{Stage: "sender"}}},
+	}
+	for name, config := range tests {
+		if err := config.Validate(); err == nil {
+			t.Errorf("%s: Validate() error = nil, want an error", name)
+		}
+	}
+}
+
+// TestLoadConfig tests that the window defaults when the file omits it.
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "slo.json")
+	if err := os.WriteFile(path, []byte(`{"objectives": [{"stage": "sender", "availability": 0.995}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if config.WindowDays != DefaultWindowDays || len(config.Objectives) != 1 || config.Objectives[0].Availability != 0.995 {
+		t.Errorf("LoadConfig() = %+v", config)
+	}
+}
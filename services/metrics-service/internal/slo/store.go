@@ -0,0 +1,132 @@
+package slo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis keys. Hourly totals live in a hash per stage and hour (slo:{stage}:{hour}) with one
+// field per Totals counter; the last sample of a stage is JSON at slo:sample:{stage}.
+const (
+	lockKey         = "slo:lock"
+	sampleKeyPrefix = "slo:sample:"
+)
+
+// Hourly totals hash fields.
+const (
+	fieldEvents         = "events"
+	fieldErrors         = "errors"
+	fieldLatencySamples = "latency_samples"
+	fieldLatencyGood    = "latency_good"
+)
+
+// RedisStore keeps SLO totals in Redis.
+type RedisStore struct {
+	redis *redis.Client
+}
+
+// NewRedisStore creates a store backed by redisClient.
+func NewRedisStore(redisClient *redis.Client) *RedisStore {
+	return &RedisStore{redis: redisClient}
+}
+
+func bucketKey(stage, hour string) string {
+	return "slo:" + stage + ":" + hour
+}
+
+// Lock takes the sampling lock for ttl.
+func (s *RedisStore) Lock(ctx context.Context, ttl time.Duration) (bool, error) {
+	return s.redis.SetNX(ctx, lockKey, "1", ttl).Result()
+}
+
+// Unlock releases the sampling lock.
+func (s *RedisStore) Unlock(ctx context.Context) error {
+	return s.redis.Del(ctx, lockKey).Err()
+}
+
+// LastSample returns the last sample of a stage, or nil if there is none.
+func (s *RedisStore) LastSample(ctx context.Context, stage string) (*Sample, error) {
+	data, err := s.redis.Get(ctx, sampleKeyPrefix+stage).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var sample Sample
+	if err := json.Unmarshal(data, &sample); err != nil {
+		return nil, fmt.Errorf("invalid SLO sample for %s: %w", stage, err)
+	}
+	return &sample, nil
+}
+
+// Apply adds totals to a stage's hourly bucket and stores its last sample in one transaction.
+func (s *RedisStore) Apply(ctx context.Context, stage, hour string, totals Totals, sample Sample) error {
+	data, err := json.Marshal(sample)
+	if err != nil {
+		return err
+	}
+	key := bucketKey(stage, hour)
+	_, err = s.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HIncrBy(ctx, key, fieldEvents, totals.Events)
+		pipe.HIncrBy(ctx, key, fieldErrors, totals.Errors)
+		pipe.HIncrBy(ctx, key, fieldLatencySamples, totals.LatencySamples)
+		pipe.HIncrBy(ctx, key, fieldLatencyGood, totals.LatencyGood)
+		// Keep an hour longer than the longest window so the oldest reported hour is complete
+		pipe.Expire(ctx, key, MaxWindowDays*24*time.Hour+time.Hour)
+		pipe.Set(ctx, sampleKeyPrefix+stage, data, 0)
+		return nil
+	})
+	return err
+}
+
+// Load returns the sum of a stage's totals over the given hours.
+func (s *RedisStore) Load(ctx context.Context, stage string, hours []string) (Totals, error) {
+	cmds := make([]*redis.MapStringStringCmd, len(hours))
+	_, err := s.redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, hour := range hours {
+			cmds[i] = pipe.HGetAll(ctx, bucketKey(stage, hour))
+		}
+		return nil
+	})
+	if err != nil {
+		return Totals{}, err
+	}
+
+	var sum Totals
+	for _, cmd := range cmds {
+		totals, err := parseTotals(cmd.Val())
+		if err != nil {
+			return Totals{}, err
+		}
+		sum = sum.Add(totals)
+	}
+	return sum, nil
+}
+
+// parseTotals parses the fields of an hourly totals hash.
+func parseTotals(fields map[string]string) (Totals, error) {
+	var t Totals
+	for field, value := range fields {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return Totals{}, fmt.Errorf("invalid SLO total %q: %w", field, err)
+		}
+		switch field {
+		case fieldEvents:
+			t.Events = n
+		case fieldErrors:
+			t.Errors = n
+		case fieldLatencySamples:
+			t.LatencySamples = n
+		case fieldLatencyGood:
+			t.LatencyGood = n
+		}
+	}
+	return t, nil
+}
@@ -4,13 +4,16 @@ go 1.23
 
 require (
 	github.com/DATA-DOG/go-sqlmock v1.5.2
+	github.com/afikmenashe/alerting-platform/pkg/ids v0.0.0
 	github.com/afikmenashe/alerting-platform/pkg/kafka v0.0.0
+	github.com/afikmenashe/alerting-platform/pkg/lifecycle v0.0.0
 	github.com/afikmenashe/alerting-platform/pkg/metrics v0.0.0
 	github.com/afikmenashe/alerting-platform/pkg/proto v0.0.0
 	github.com/afikmenashe/alerting-platform/pkg/shared v0.0.0
 	github.com/lib/pq v1.10.9
 	github.com/segmentio/kafka-go v0.4.47
 	google.golang.org/protobuf v1.32.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -19,12 +22,20 @@ require (
 	github.com/klauspost/compress v1.15.9 // indirect
 	github.com/pierrec/lz4/v4 v4.1.15 // indirect
 	github.com/redis/go-redis/v9 v9.7.3 // indirect
+	github.com/xdg-go/pbkdf2 v1.0.0 // indirect
+	github.com/xdg-go/scram v1.1.2 // indirect
+	github.com/xdg-go/stringprep v1.0.4 // indirect
+	golang.org/x/text v0.13.0 // indirect
 )
 
 replace github.com/afikmenashe/alerting-platform/pkg/proto => ../../pkg/proto
 
+replace github.com/afikmenashe/alerting-platform/pkg/ids => ../../pkg/ids
+
 replace github.com/afikmenashe/alerting-platform/pkg/kafka => ../../pkg/kafka
 
 replace github.com/afikmenashe/alerting-platform/pkg/metrics => ../../pkg/metrics
 
 replace github.com/afikmenashe/alerting-platform/pkg/shared => ../../pkg/shared
+
+replace github.com/afikmenashe/alerting-platform/pkg/lifecycle => ../../pkg/lifecycle
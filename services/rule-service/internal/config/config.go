@@ -3,6 +3,9 @@ package config
 
 import (
 	"fmt"
+	"time"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
 )
 
 // Config holds all configuration parameters for the rule-service.
@@ -10,8 +13,78 @@ type Config struct {
 	HTTPPort         string
 	KafkaBrokers     string
 	RuleChangedTopic string
+	AlertsNewTopic   string
 	PostgresDSN      string
 	RedisAddr        string
+
+	// HeartbeatCheckInterval is how often missed heartbeats are checked for
+	HeartbeatCheckInterval time.Duration
+
+	// EndpointChangedTopic receives endpoint change events relayed from the endpoint outbox
+	EndpointChangedTopic string
+	// EndpointOutboxInterval is how often the endpoint outbox is polled for pending changes
+	EndpointOutboxInterval time.Duration
+
+	// EndpointSecretKey encrypts secret webhook header values (must match the sender's key).
+	// If empty, secret headers are rejected.
+	EndpointSecretKey string
+
+	// AdminToken is required as a bearer token by the admin (emergency stop) endpoints.
+	// If empty, the admin endpoints are unauthenticated.
+	AdminToken string
+
+	// APIKeyAuth requires an API key (or AdminToken, as an admin key) on every HTTP route
+	// except health checks.
+	APIKeyAuth bool
+	// APIKeyCacheTTL is how long a valid API key is trusted before it is looked up again
+	// (0 uses the default).
+	APIKeyCacheTTL time.Duration
+
+	// HealthCacheTTL is how long a /healthz and /readyz dependency check is reused by later probes.
+	HealthCacheTTL time.Duration
+
+	// ExportDir holds the files written by asynchronous notification export jobs.
+	ExportDir string
+	// ExportTTL is how long a finished export job and its file are kept (0 uses the default).
+	ExportTTL time.Duration
+
+	// SeverityStyles overrides the color, emoji and label of severities served to the UI: a
+	// JSON object keyed by severity (see shared.ConfigureSeverityStyles).
+	SeverityStyles string
+
+	// Normalize is how rule-updater normalizes rule and alert fields (its -normalize), which
+	// rule tests match sample alerts with.
+	Normalize string
+
+	// WebhookDispatchInterval is how often due meta-webhook deliveries are sent (0 uses the default).
+	WebhookDispatchInterval time.Duration
+	// DeadLetterTopic is the sender's dead-letter topic, turned into notification.dead_lettered
+	// meta-webhook events. If empty, those events are not emitted.
+	DeadLetterTopic string
+	// DeadLetterGroupID is the consumer group reading DeadLetterTopic.
+	DeadLetterGroupID string
+
+	// UnmatchedTopic is the evaluator's alerts.unmatched topic, counted into daily unmatched
+	// alert reports. If empty, unmatched alerts are not reported.
+	UnmatchedTopic string
+	// UnmatchedGroupID is the consumer group reading UnmatchedTopic.
+	UnmatchedGroupID string
+	// UnmatchedReportHour is the UTC hour after which the previous day's report is sent.
+	UnmatchedReportHour int
+	// UnmatchedReportTop is how many alert signatures a report lists.
+	UnmatchedReportTop int
+	// UnmatchedReportClient receives the platform-wide report as a synthetic alert.
+	// If empty, only clients' own reports are sent, to their meta-webhooks.
+	UnmatchedReportClient string
+
+	// RateLimitTiers is a comma-separated list of name:rate:burst request limits.
+	// If empty, requests are not rate limited.
+	RateLimitTiers string
+	// RateLimitClients assigns clients to tiers as client_id:tier; others use the "default" tier.
+	RateLimitClients string
+
+	// CORS sets which browser origins may call the HTTP API.
+	CORS shared.CORSFlags
 }
 
 // Validate checks that all required configuration fields are set and have valid values.
@@ -26,11 +99,58 @@ func (c *Config) Validate() error {
 	if c.RuleChangedTopic == "" {
 		return fmt.Errorf("rule-changed-topic cannot be empty")
 	}
+	if c.AlertsNewTopic == "" {
+		return fmt.Errorf("alerts-new-topic cannot be empty")
+	}
 	if c.PostgresDSN == "" {
 		return fmt.Errorf("postgres-dsn cannot be empty")
 	}
 	if c.RedisAddr == "" {
 		return fmt.Errorf("redis-addr cannot be empty")
 	}
+	if c.HeartbeatCheckInterval <= 0 {
+		return fmt.Errorf("heartbeat-check-interval must be positive")
+	}
+	if c.EndpointChangedTopic == "" {
+		return fmt.Errorf("endpoint-changed-topic cannot be empty")
+	}
+	if c.EndpointOutboxInterval <= 0 {
+		return fmt.Errorf("endpoint-outbox-interval must be positive")
+	}
+	if c.APIKeyCacheTTL < 0 {
+		return fmt.Errorf("api-key-cache-ttl cannot be negative")
+	}
+	if c.HealthCacheTTL < 0 {
+		return fmt.Errorf("health-cache-ttl cannot be negative")
+	}
+	if c.ExportTTL < 0 {
+		return fmt.Errorf("export-ttl cannot be negative")
+	}
+	if _, err := shared.ParseNormalization(c.Normalize); err != nil {
+		return fmt.Errorf("invalid normalize: %w", err)
+	}
+	if c.WebhookDispatchInterval < 0 {
+		return fmt.Errorf("webhook-dispatch-interval cannot be negative")
+	}
+	if c.DeadLetterTopic != "" && c.DeadLetterGroupID == "" {
+		return fmt.Errorf("dead-letter-group-id cannot be empty when dead-letter-topic is set")
+	}
+	if c.UnmatchedTopic != "" {
+		if c.UnmatchedGroupID == "" {
+			return fmt.Errorf("unmatched-group-id cannot be empty when unmatched-topic is set")
+		}
+		if c.UnmatchedReportHour < 0 || c.UnmatchedReportHour > 23 {
+			return fmt.Errorf("unmatched-report-hour must be between 0 and 23")
+		}
+		if c.UnmatchedReportTop < 1 || c.UnmatchedReportTop > 100 {
+			return fmt.Errorf("unmatched-report-top must be between 1 and 100")
+		}
+	}
+	if _, err := shared.ParseRateLimitPolicy(c.RateLimitTiers, c.RateLimitClients); err != nil {
+		return fmt.Errorf("invalid rate limits: %w", err)
+	}
+	if _, err := c.CORS.Policy(); err != nil {
+		return fmt.Errorf("invalid CORS configuration: %w", err)
+	}
 	return nil
 }
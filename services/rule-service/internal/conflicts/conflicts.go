@@ -0,0 +1,159 @@
+// Package conflicts detects redundant rules within a client's rule set.
+// A client's rules are loaded into the same inverted severity/source/name indexes the
+// evaluator matches alerts against, and each rule's own criteria are then matched against
+// them. Because "*" is indexed as a wildcard, matching a rule returns exactly the rules
+// that accept every alert it accepts, before context conditions: a rule only covers another
+// if its conditions are a subset of the other rule's.
+package conflicts
+
+import (
+	"fmt"
+	"sort"
+
+	"rule-service/internal/database"
+)
+
+// Conflict types.
+const (
+	// TypeDuplicate means two rules have identical severity, source, and name.
+	TypeDuplicate = "duplicate"
+	// TypeShadowed means a broader rule matches every alert the rule matches.
+	TypeShadowed = "shadowed"
+)
+
+// Overlap severities, from most to least actionable.
+const (
+	// OverlapHigh: both rules are enabled and match exactly the same alerts.
+	OverlapHigh = "high"
+	// OverlapMedium: both rules are enabled and the narrower rule is fully covered.
+	OverlapMedium = "medium"
+	// OverlapLow: one of the rules is disabled, so the overlap has no effect today.
+	OverlapLow = "low"
+)
+
+var overlapRank = map[string]int{OverlapHigh: 0, OverlapMedium: 1, OverlapLow: 2}
+
+// Criteria are the match fields of a rule.
+type Criteria struct {
+	Severity string `json:"severity"`
+	Source   string `json:"source"`
+	Name     string `json:"name"`
+	Enabled  bool   `json:"enabled"`
+
+	Conditions database.RuleConditions `json:"conditions,omitempty"`
+}
+
+// Conflict reports a rule made redundant by another rule of the same client.
+type Conflict struct {
+	Type            string   `json:"type"`
+	Severity        string   `json:"severity"`
+	RuleID          string   `json:"rule_id"` // the redundant rule
+	Rule            Criteria `json:"rule"`
+	CoveredByRuleID string   `json:"covered_by_rule_id"`
+	CoveredBy       Criteria `json:"covered_by"`
+	Suggestion      string   `json:"suggestion"`
+}
+
+// Report is the conflict analysis of one client's rules.
+type Report struct {
+	ClientID      string     `json:"client_id"`
+	RulesAnalyzed int        `json:"rules_analyzed"`
+	Conflicts     []Conflict `json:"conflicts"`
+}
+
+// Analyze reports duplicate and shadowed rules among a client's rules.
+// Rules should be ordered oldest first: of two duplicates, the newer one is reported as redundant.
+// Conflicts are ordered by overlap severity, then by the order of the redundant rule.
+func Analyze(clientID string, rules []*database.Rule) *Report {
+	idx := newIndex(rules)
+	conflicts := make([]Conflict, 0)
+
+	for ruleInt, rule := range rules {
+		for _, coverInt := range idx.match(rule.Severity, rule.Source, rule.Name) {
+			if coverInt == ruleInt {
+				continue
+			}
+			cover := rules[coverInt]
+			if !conditionsCover(cover.Conditions, rule.Conditions) {
+				continue
+			}
+			duplicate := sameCriteria(rule, cover) && conditionsCover(rule.Conditions, cover.Conditions)
+			// Duplicates cover each other; report the pair once, against the older rule
+			if duplicate && coverInt > ruleInt {
+				continue
+			}
+			conflicts = append(conflicts, newConflict(rule, cover, duplicate))
+		}
+	}
+
+	sort.SliceStable(conflicts, func(i, j int) bool {
+		return overlapRank[conflicts[i].Severity] < overlapRank[conflicts[j].Severity]
+	})
+
+	return &Report{
+		ClientID:      clientID,
+		RulesAnalyzed: len(rules),
+		Conflicts:     conflicts,
+	}
+}
+
+// newConflict describes rule being made redundant by cover.
+func newConflict(rule, cover *database.Rule, duplicate bool) Conflict {
+	c := Conflict{
+		Type:            TypeShadowed,
+		RuleID:          rule.RuleID,
+		Rule:            criteriaOf(rule),
+		CoveredByRuleID: cover.RuleID,
+		CoveredBy:       criteriaOf(cover),
+	}
+	if duplicate {
+		c.Type = TypeDuplicate
+	}
+
+	switch {
+	case !rule.Enabled:
+		c.Severity = OverlapLow
+		c.Suggestion = fmt.Sprintf("Rule %s is disabled and rule %s already matches every alert it would match; delete rule %s.",
+			rule.RuleID, cover.RuleID, rule.RuleID)
+	case !cover.Enabled:
+		c.Severity = OverlapLow
+		c.Suggestion = fmt.Sprintf("Rule %s is disabled; if it is re-enabled, rule %s becomes redundant. Delete rule %s if it is no longer needed.",
+			cover.RuleID, rule.RuleID, cover.RuleID)
+	case duplicate:
+		c.Severity = OverlapHigh
+		c.Suggestion = fmt.Sprintf("Rules %s and %s match exactly the same alerts; move the endpoints of rule %s to rule %s and delete rule %s.",
+			cover.RuleID, rule.RuleID, rule.RuleID, cover.RuleID, rule.RuleID)
+	default:
+		c.Severity = OverlapMedium
+		c.Suggestion = fmt.Sprintf("Rule %s matches every alert rule %s matches; unless rule %s routes to endpoints rule %s does not, delete rule %s.",
+			cover.RuleID, rule.RuleID, rule.RuleID, cover.RuleID, rule.RuleID)
+	}
+	return c
+}
+
+func sameCriteria(a, b *database.Rule) bool {
+	return a.Severity == b.Severity && a.Source == b.Source && a.Name == b.Name
+}
+
+// conditionsCover reports whether every condition of cover is also a condition of rule, so cover's
+// conditions hold for every alert rule's hold. Conditions implied by others (env == prod implying
+// env != dev) are not recognized, which misses some conflicts but never reports a false one.
+func conditionsCover(cover, rule database.RuleConditions) bool {
+	for _, c := range cover {
+		found := false
+		for _, r := range rule {
+			if c == r {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func criteriaOf(rule *database.Rule) Criteria {
+	return Criteria{Severity: rule.Severity, Source: rule.Source, Name: rule.Name, Enabled: rule.Enabled, Conditions: rule.Conditions}
+}
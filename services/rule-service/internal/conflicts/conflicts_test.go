@@ -0,0 +1,126 @@
+// Package conflicts provides tests for rule conflict detection.
+package conflicts
+
+import (
+	"testing"
+
+	"rule-service/internal/database"
+)
+
+func rule(id, severity, source, name string, enabled bool) *database.Rule {
+	return &database.Rule{RuleID: id, ClientID: "client-1", Severity: severity, Source: source, Name: name, Enabled: enabled}
+}
+
+// TestAnalyze_Shadowed tests that wildcard rules shadow the narrower rules they cover.
+func TestAnalyze_Shadowed(t *testing.T) {
+	rules := []*database.Rule{
+		rule("broad", "*", "api", "*", true),
+		rule("narrow", "HIGH", "api", "timeout", true),
+		rule("other-source", "HIGH", "db", "timeout", true),
+		rule("all", "*", "*", "*", false),
+	}
+
+	report := Analyze("client-1", rules)
+
+	if report.ClientID != "client-1" || report.RulesAnalyzed != 4 {
+		t.Errorf("Analyze() report = %+v, want client-1 with 4 rules", report)
+	}
+
+	want := []struct {
+		ruleID, coveredBy, severity string
+	}{
+		// Medium first: both rules enabled
+		{"narrow", "broad", OverlapMedium},
+		// Low: the catch-all rule is disabled
+		{"broad", "all", OverlapLow},
+		{"narrow", "all", OverlapLow},
+		{"other-source", "all", OverlapLow},
+	}
+	if len(report.Conflicts) != len(want) {
+		t.Fatalf("Analyze() returned %d conflicts, want %d: %+v", len(report.Conflicts), len(want), report.Conflicts)
+	}
+	for i, w := range want {
+		c := report.Conflicts[i]
+		if c.Type != TypeShadowed || c.RuleID != w.ruleID || c.CoveredByRuleID != w.coveredBy || c.Severity != w.severity {
+			t.Errorf("conflict[%d] = %s %s covered by %s (%s), want shadowed %s covered by %s (%s)",
+				i, c.Type, c.RuleID, c.CoveredByRuleID, c.Severity, w.ruleID, w.coveredBy, w.severity)
+		}
+		if c.Suggestion == "" {
+			t.Errorf("conflict[%d] has no suggestion", i)
+		}
+	}
+}
+
+// TestAnalyze_Duplicate tests that identical rules are reported once, against the older rule.
+func TestAnalyze_Duplicate(t *testing.T) {
+	rules := []*database.Rule{
+		rule("older", "HIGH", "api", "timeout", true),
+		rule("newer", "HIGH", "api", "timeout", true),
+	}
+
+	report := Analyze("client-1", rules)
+
+	if len(report.Conflicts) != 1 {
+		t.Fatalf("Analyze() returned %d conflicts, want 1: %+v", len(report.Conflicts), report.Conflicts)
+	}
+	c := report.Conflicts[0]
+	if c.Type != TypeDuplicate || c.Severity != OverlapHigh || c.RuleID != "newer" || c.CoveredByRuleID != "older" {
+		t.Errorf("conflict = %+v, want high duplicate newer covered by older", c)
+	}
+}
+
+// TestAnalyze_NoConflicts tests that disjoint rules and partial overlaps are not reported.
+func TestAnalyze_NoConflicts(t *testing.T) {
+	rules := []*database.Rule{
+		rule("r1", "HIGH", "api", "timeout", true),
+		rule("r2", "LOW", "api", "timeout", true),
+		// Both match HIGH/api/latency, but neither rule covers the other
+		rule("r3", "HIGH", "*", "latency", true),
+		rule("r4", "*", "api", "latency", true),
+	}
+
+	report := Analyze("client-1", rules)
+
+	if len(report.Conflicts) != 0 {
+		t.Errorf("Analyze() returned conflicts %+v, want none", report.Conflicts)
+	}
+	if report.Conflicts == nil {
+		t.Error("Analyze() conflicts should be an empty slice, not nil")
+	}
+}
+
+// TestAnalyze_Conditions tests that a rule only covers rules with at least its context conditions.
+func TestAnalyze_Conditions(t *testing.T) {
+	eu := database.ContextCondition{Key: "region", Op: database.ConditionOpEqual, Value: "eu-west-1"}
+	us := database.ContextCondition{Key: "region", Op: database.ConditionOpEqual, Value: "us-east-1"}
+	prod := database.ContextCondition{Key: "env", Op: database.ConditionOpEqual, Value: "prod"}
+
+	withConditions := func(r *database.Rule, conditions ...database.ContextCondition) *database.Rule {
+		r.Conditions = conditions
+		return r
+	}
+	rules := []*database.Rule{
+		// Same criteria routed per region: neither covers the other
+		withConditions(rule("eu", "HIGH", "api", "timeout", true), eu),
+		withConditions(rule("us", "HIGH", "api", "timeout", true), us),
+		// Narrower than "eu": it also requires env == prod
+		withConditions(rule("eu-prod", "HIGH", "api", "timeout", true), eu, prod),
+		// Covers eu-prod, but not the rules without env == prod
+		withConditions(rule("broad-prod", "*", "api", "*", true), prod),
+	}
+
+	report := Analyze("client-1", rules)
+
+	want := map[[2]string]string{
+		{"eu-prod", "eu"}:         TypeShadowed,
+		{"eu-prod", "broad-prod"}: TypeShadowed,
+	}
+	if len(report.Conflicts) != len(want) {
+		t.Fatalf("Analyze() returned %d conflicts, want %d: %+v", len(report.Conflicts), len(want), report.Conflicts)
+	}
+	for _, c := range report.Conflicts {
+		if want[[2]string{c.RuleID, c.CoveredByRuleID}] != c.Type {
+			t.Errorf("unexpected conflict %+v", c)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package conflicts
+
+import (
+	"sort"
+
+	"rule-service/internal/database"
+)
+
+// index mirrors the evaluator's inverted rule indexes: each field value maps to the
+// ruleInts (positions in the analyzed slice) stored under it, with "*" as the wildcard key.
+type index struct {
+	bySeverity map[string][]int // severity -> []ruleInt
+	bySource   map[string][]int // source -> []ruleInt
+	byName     map[string][]int // name -> []ruleInt
+}
+
+// newIndex indexes rules by position.
+func newIndex(rules []*database.Rule) *index {
+	idx := &index{
+		bySeverity: make(map[string][]int),
+		bySource:   make(map[string][]int),
+		byName:     make(map[string][]int),
+	}
+	for ruleInt, rule := range rules {
+		idx.bySeverity[rule.Severity] = append(idx.bySeverity[rule.Severity], ruleInt)
+		idx.bySource[rule.Source] = append(idx.bySource[rule.Source], ruleInt)
+		idx.byName[rule.Name] = append(idx.byName[rule.Name], ruleInt)
+	}
+	return idx
+}
+
+// match returns the ruleInts, in ascending order, that match the given field values the
+// way the evaluator matches alerts: each field matches its exact value or "*".
+func (idx *index) match(severity, source, name string) []int {
+	severityRules := withWildcard(idx.bySeverity, severity)
+	sourceRules := withWildcard(idx.bySource, source)
+	nameRules := withWildcard(idx.byName, name)
+
+	matched := make([]int, 0)
+	for ruleInt := range severityRules {
+		if sourceRules[ruleInt] && nameRules[ruleInt] {
+			matched = append(matched, ruleInt)
+		}
+	}
+	sort.Ints(matched)
+	return matched
+}
+
+// withWildcard returns the set of ruleInts stored under key or under "*".
+func withWildcard(byField map[string][]int, key string) map[int]bool {
+	set := make(map[int]bool, len(byField[key])+len(byField["*"]))
+	for _, ruleInt := range byField[key] {
+		set[ruleInt] = true
+	}
+	for _, ruleInt := range byField["*"] {
+		set[ruleInt] = true
+	}
+	return set
+}
@@ -0,0 +1,113 @@
+// Package database provides database operations for clients, rules, and endpoints.
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+	"github.com/lib/pq"
+)
+
+const apiKeyColumns = `key_id, COALESCE(client_id, ''), name, key_prefix, created_at, revoked_at`
+
+var _ shared.APIKeyStore = (*DB)(nil)
+
+// CreateAPIKey stores a key by its digest (see shared.HashAPIKey). An empty clientID creates
+// an admin key.
+func (db *DB) CreateAPIKey(ctx context.Context, clientID, name, keyHash, keyPrefix string) (*APIKey, error) {
+	query := `
+		INSERT INTO api_keys (client_id, name, key_hash, key_prefix, created_at)
+		VALUES (NULLIF($1, ''), $2, $3, $4, NOW())
+		RETURNING ` + apiKeyColumns
+
+	key, err := scanAPIKey(db.conn.QueryRowContext(ctx, query, clientID, name, keyHash, keyPrefix))
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" { // foreign_key_violation
+			return nil, fmt.Errorf("client not found: %s", clientID)
+		}
+		return nil, fmt.Errorf("failed to create api key: %w", err)
+	}
+	return key, nil
+}
+
+// GetAPIKey retrieves an API key by ID.
+func (db *DB) GetAPIKey(ctx context.Context, keyID string) (*APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_id = $1`
+	key, err := scanAPIKey(db.conn.QueryRowContext(ctx, query, keyID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("api key not found: %s", keyID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+	return key, nil
+}
+
+// ListAPIKeys retrieves API keys, revoked ones included, newest first. A nil clientID lists
+// every key; an empty one lists the admin keys. Keys are few per client, so results are not paginated.
+func (db *DB) ListAPIKeys(ctx context.Context, clientID *string) ([]*APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys`
+	var args []interface{}
+	if clientID != nil {
+		query += ` WHERE COALESCE(client_id, '') = $1`
+		args = append(args, *clientID)
+	}
+	query += ` ORDER BY created_at DESC`
+
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make([]*APIKey, 0)
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan api key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey revokes an API key. Revoking a revoked key keeps its original revocation time.
+func (db *DB) RevokeAPIKey(ctx context.Context, keyID string) (*APIKey, error) {
+	query := `
+		UPDATE api_keys SET revoked_at = COALESCE(revoked_at, NOW())
+		WHERE key_id = $1
+		RETURNING ` + apiKeyColumns
+	key, err := scanAPIKey(db.conn.QueryRowContext(ctx, query, keyID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("api key not found: %s", keyID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	return key, nil
+}
+
+// LookupAPIKey returns the principal of the unrevoked key with the given digest, or nil.
+func (db *DB) LookupAPIKey(ctx context.Context, keyHash string) (*shared.APIPrincipal, error) {
+	return shared.NewSQLAPIKeyStore(db.conn).LookupAPIKey(ctx, keyHash)
+}
+
+// scanAPIKey scans an API key from a sql.Row or sql.Rows selecting apiKeyColumns.
+func scanAPIKey(scanner interface {
+	Scan(dest ...interface{}) error
+}) (*APIKey, error) {
+	var key APIKey
+	var revokedAt sql.NullTime
+	if err := scanner.Scan(&key.KeyID, &key.ClientID, &key.Name, &key.KeyPrefix, &key.CreatedAt, &revokedAt); err != nil {
+		return nil, err
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	return &key, nil
+}
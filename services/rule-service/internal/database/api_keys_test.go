@@ -0,0 +1,141 @@
+// Package database provides tests for API key database operations.
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+)
+
+var apiKeyRowColumns = []string{"key_id", "client_id", "name", "key_prefix", "created_at", "revoked_at"}
+
+// TestDB_CreateAPIKey tests the CreateAPIKey method.
+func TestDB_CreateAPIKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	d := &DB{conn: db}
+	ctx := context.Background()
+	now := time.Now()
+
+	t.Run("successful create", func(t *testing.T) {
+		mock.ExpectQuery("INSERT INTO api_keys").
+			WithArgs("client-1", "ci", "hash", "ak_abcdefgh").
+			WillReturnRows(sqlmock.NewRows(apiKeyRowColumns).AddRow("key-1", "client-1", "ci", "ak_abcdefgh", now, nil))
+
+		key, err := d.CreateAPIKey(ctx, "client-1", "ci", "hash", "ak_abcdefgh")
+		if err != nil {
+			t.Fatalf("CreateAPIKey() error = %v", err)
+		}
+		if key.KeyID != "key-1" || key.ClientID != "client-1" || key.RevokedAt != nil {
+			t.Errorf("CreateAPIKey() = %+v", key)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Mock expectations were not met: %v", err)
+		}
+	})
+
+	t.Run("client not found", func(t *testing.T) {
+		mock.ExpectQuery("INSERT INTO api_keys").
+			WithArgs("missing", "ci", "hash", "ak_abcdefgh").
+			WillReturnError(&pq.Error{Code: "23503"})
+
+		_, err := d.CreateAPIKey(ctx, "missing", "ci", "hash", "ak_abcdefgh")
+		if err == nil || !contains(err.Error(), "client not found") {
+			t.Errorf("CreateAPIKey() error = %v, want 'client not found'", err)
+		}
+	})
+}
+
+// TestDB_ListAPIKeys tests that the client filter matches admin keys by an empty client.
+func TestDB_ListAPIKeys(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	d := &DB{conn: db}
+	now := time.Now()
+	admin := ""
+
+	mock.ExpectQuery(`FROM api_keys WHERE COALESCE\(client_id, ''\) = \$1`).
+		WithArgs("").
+		WillReturnRows(sqlmock.NewRows(apiKeyRowColumns).AddRow("key-1", "", "ops", "ak_abcdefgh", now, now))
+
+	keys, err := d.ListAPIKeys(context.Background(), &admin)
+	if err != nil {
+		t.Fatalf("ListAPIKeys() error = %v", err)
+	}
+	if len(keys) != 1 || keys[0].ClientID != "" || keys[0].RevokedAt == nil {
+		t.Errorf("ListAPIKeys() = %+v", keys)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Mock expectations were not met: %v", err)
+	}
+}
+
+// TestDB_RevokeAPIKey tests the RevokeAPIKey method.
+func TestDB_RevokeAPIKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	d := &DB{conn: db}
+	ctx := context.Background()
+
+	mock.ExpectQuery("UPDATE api_keys SET revoked_at").
+		WithArgs("key-1").
+		WillReturnError(sql.ErrNoRows)
+
+	_, err = d.RevokeAPIKey(ctx, "key-1")
+	if err == nil || !contains(err.Error(), "api key not found") {
+		t.Errorf("RevokeAPIKey() error = %v, want 'api key not found'", err)
+	}
+}
+
+// TestDB_LookupAPIKey tests that only unrevoked keys authenticate.
+func TestDB_LookupAPIKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	d := &DB{conn: db}
+	ctx := context.Background()
+
+	t.Run("valid key", func(t *testing.T) {
+		mock.ExpectQuery("FROM api_keys").
+			WithArgs("hash").
+			WillReturnRows(sqlmock.NewRows([]string{"key_id", "name", "client_id"}).AddRow("key-1", "ci", "client-1"))
+
+		p, err := d.LookupAPIKey(ctx, "hash")
+		if err != nil {
+			t.Fatalf("LookupAPIKey() error = %v", err)
+		}
+		if p == nil || p.ClientID != "client-1" || p.Admin() {
+			t.Errorf("LookupAPIKey() = %+v, want client-1 principal", p)
+		}
+	})
+
+	t.Run("unknown or revoked key", func(t *testing.T) {
+		mock.ExpectQuery("revoked_at IS NULL").
+			WithArgs("hash").
+			WillReturnError(sql.ErrNoRows)
+
+		p, err := d.LookupAPIKey(ctx, "hash")
+		if err != nil || p != nil {
+			t.Errorf("LookupAPIKey() = %+v, %v, want nil, nil", p, err)
+		}
+	})
+}
@@ -0,0 +1,86 @@
+// Package database provides database operations for clients, rules, and endpoints.
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// BootstrapClient creates a client together with its rules and their endpoints in one transaction.
+// Either everything is created or nothing is: any failure rolls the whole bootstrap back.
+func (db *DB) BootstrapClient(ctx context.Context, clientID, name string, rules []BootstrapRule) (*BootstrapResult, error) {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin bootstrap transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var client Client
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO clients (client_id, name, created_at, updated_at)
+		VALUES ($1, $2, NOW(), NOW())
+		RETURNING client_id, name, created_at, updated_at
+	`, clientID, name).Scan(&client.ClientID, &client.Name, &client.CreatedAt, &client.UpdatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, fmt.Errorf("client already exists: %s", clientID)
+		}
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+
+	created, err := insertRuleSet(ctx, tx, clientID, rules)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit bootstrap transaction: %w", err)
+	}
+	return &BootstrapResult{Client: &client, Rules: created}, nil
+}
+
+// insertRuleSet creates rules and their endpoints for clientID within tx.
+func insertRuleSet(ctx context.Context, tx *sql.Tx, clientID string, rules []BootstrapRule) ([]*BootstrappedRule, error) {
+	created := make([]*BootstrappedRule, 0, len(rules))
+	for _, r := range rules {
+		row := tx.QueryRowContext(ctx, `
+			INSERT INTO rules (client_id, severity, source, name, description, exclude_sources, exclude_names, context_conditions, enabled, version, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, 1, NOW(), NOW())
+			RETURNING rule_id, client_id, severity, source, name, description, exclude_sources, exclude_names, context_conditions, enabled, version, created_at, updated_at
+		`, clientID, r.Severity, r.Source, r.Name, r.Description,
+			pq.Array(nonNilStrings(r.Exclusions.Sources)), pq.Array(nonNilStrings(r.Exclusions.Names)), r.Conditions, !r.Disabled)
+		rule, err := scanRule(row)
+		if err != nil {
+			if isUniqueViolation(err) {
+				return nil, fmt.Errorf("rule already exists for client %s with criteria (severity=%s, source=%s, name=%s)", clientID, r.Severity, r.Source, r.Name)
+			}
+			return nil, fmt.Errorf("failed to create rule: %w", err)
+		}
+
+		withEndpoints := &BootstrappedRule{Rule: rule, Endpoints: make([]*Endpoint, 0, len(r.Endpoints))}
+		for _, e := range r.Endpoints {
+			endpoint, err := scanEndpoint(tx.QueryRowContext(ctx, `
+				INSERT INTO endpoints (rule_id, type, value, enabled, created_at, updated_at)
+				VALUES ($1, $2, $3, $4, NOW(), NOW())
+				RETURNING `+endpointColumns, rule.RuleID, e.Type, e.Value, !e.Disabled))
+			if err != nil {
+				if isUniqueViolation(err) {
+					return nil, fmt.Errorf("endpoint already exists for rule %s with type %s and value %s", rule.RuleID, e.Type, e.Value)
+				}
+				return nil, fmt.Errorf("failed to create endpoint: %w", err)
+			}
+			withEndpoints.Endpoints = append(withEndpoints.Endpoints, endpoint)
+		}
+		created = append(created, withEndpoints)
+	}
+	return created, nil
+}
+
+// isUniqueViolation reports whether err is a Postgres unique_violation.
+func isUniqueViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == "23505"
+}
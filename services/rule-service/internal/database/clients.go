@@ -9,14 +9,14 @@ import (
 	"github.com/lib/pq"
 )
 
-// CreateClient creates a new client in the database.
+// CreateClient creates a new client in the database. timezone and locale may be empty.
 // Returns an error if the client already exists.
-func (db *DB) CreateClient(ctx context.Context, clientID, name string) error {
+func (db *DB) CreateClient(ctx context.Context, clientID, name, timezone, locale string) error {
 	query := `
-		INSERT INTO clients (client_id, name, created_at, updated_at)
-		VALUES ($1, $2, NOW(), NOW())
+		INSERT INTO clients (client_id, name, timezone, locale, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, NOW(), NOW())
 	`
-	_, err := db.conn.ExecContext(ctx, query, clientID, name)
+	_, err := db.conn.ExecContext(ctx, query, clientID, name, timezone, locale)
 	if err != nil {
 		if pqErr, ok := err.(*pq.Error); ok {
 			if pqErr.Code == "23505" { // unique_violation
@@ -31,7 +31,7 @@ func (db *DB) CreateClient(ctx context.Context, clientID, name string) error {
 // GetClient retrieves a client by ID.
 func (db *DB) GetClient(ctx context.Context, clientID string) (*Client, error) {
 	query := `
-		SELECT client_id, name, created_at, updated_at
+		SELECT client_id, name, timezone, locale, created_at, updated_at
 		FROM clients
 		WHERE client_id = $1
 	`
@@ -39,6 +39,8 @@ func (db *DB) GetClient(ctx context.Context, clientID string) (*Client, error) {
 	err := db.conn.QueryRowContext(ctx, query, clientID).Scan(
 		&client.ClientID,
 		&client.Name,
+		&client.Timezone,
+		&client.Locale,
 		&client.CreatedAt,
 		&client.UpdatedAt,
 	)
@@ -51,6 +53,32 @@ func (db *DB) GetClient(ctx context.Context, clientID string) (*Client, error) {
 	return &client, nil
 }
 
+// UpdateClientSettings changes a client's timezone and locale. Nil fields keep their value.
+func (db *DB) UpdateClientSettings(ctx context.Context, clientID string, timezone, locale *string) (*Client, error) {
+	query := `
+		UPDATE clients
+		SET timezone = COALESCE($2, timezone), locale = COALESCE($3, locale), updated_at = NOW()
+		WHERE client_id = $1
+		RETURNING client_id, name, timezone, locale, created_at, updated_at
+	`
+	var client Client
+	err := db.conn.QueryRowContext(ctx, query, clientID, timezone, locale).Scan(
+		&client.ClientID,
+		&client.Name,
+		&client.Timezone,
+		&client.Locale,
+		&client.CreatedAt,
+		&client.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("client not found: %s", clientID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update client settings: %w", err)
+	}
+	return &client, nil
+}
+
 // ListClients retrieves clients with pagination.
 // Default limit is 50, max limit is 200.
 func (db *DB) ListClients(ctx context.Context, limit, offset int) (*ClientListResult, error) {
@@ -78,7 +106,7 @@ func (db *DB) ListClients(ctx context.Context, limit, offset int) (*ClientListRe
 
 	// Get paginated results
 	query := `
-		SELECT client_id, name, created_at, updated_at
+		SELECT client_id, name, timezone, locale, created_at, updated_at
 		FROM clients
 		ORDER BY created_at DESC
 		LIMIT $1 OFFSET $2
@@ -95,6 +123,8 @@ func (db *DB) ListClients(ctx context.Context, limit, offset int) (*ClientListRe
 		if err := rows.Scan(
 			&client.ClientID,
 			&client.Name,
+			&client.Timezone,
+			&client.Locale,
 			&client.CreatedAt,
 			&client.UpdatedAt,
 		); err != nil {
@@ -5,6 +5,7 @@ package database
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"encoding/json"
 	"strings"
 	"testing"
@@ -80,7 +81,7 @@ func TestDB_CreateClient(t *testing.T) {
 			nameValue: "Test Client",
 			setupMock: func() {
 				mock.ExpectExec("INSERT INTO clients").
-					WithArgs("client-1", "Test Client").
+					WithArgs("client-1", "Test Client", "", "").
 					WillReturnResult(sqlmock.NewResult(1, 1))
 			},
 			wantErr: false,
@@ -91,7 +92,7 @@ func TestDB_CreateClient(t *testing.T) {
 			nameValue: "Test Client",
 			setupMock: func() {
 				mock.ExpectExec("INSERT INTO clients").
-					WithArgs("client-1", "Test Client").
+					WithArgs("client-1", "Test Client", "", "").
 					WillReturnError(&pq.Error{Code: "23505"})
 			},
 			wantErr: true,
@@ -103,7 +104,7 @@ func TestDB_CreateClient(t *testing.T) {
 			nameValue: "Test Client",
 			setupMock: func() {
 				mock.ExpectExec("INSERT INTO clients").
-					WithArgs("client-1", "Test Client").
+					WithArgs("client-1", "Test Client", "", "").
 					WillReturnError(sql.ErrConnDone)
 			},
 			wantErr: true,
@@ -113,7 +114,7 @@ func TestDB_CreateClient(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.setupMock()
-			err := d.CreateClient(ctx, tt.clientID, tt.nameValue)
+			err := d.CreateClient(ctx, tt.clientID, tt.nameValue, "", "")
 			if (err != nil) != tt.wantErr {
 				t.Errorf("CreateClient() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -151,9 +152,9 @@ func TestDB_GetClient(t *testing.T) {
 			name:     "successful get",
 			clientID: "client-1",
 			setupMock: func() {
-				rows := sqlmock.NewRows([]string{"client_id", "name", "created_at", "updated_at"}).
-					AddRow("client-1", "Test Client", time.Now(), time.Now())
-				mock.ExpectQuery("SELECT client_id, name, created_at, updated_at").
+				rows := sqlmock.NewRows([]string{"client_id", "name", "timezone", "locale", "created_at", "updated_at"}).
+					AddRow("client-1", "Test Client", "Europe/Berlin", "de-DE", time.Now(), time.Now())
+				mock.ExpectQuery("SELECT client_id, name, timezone, locale, created_at, updated_at").
 					WithArgs("client-1").
 					WillReturnRows(rows)
 			},
@@ -163,7 +164,7 @@ func TestDB_GetClient(t *testing.T) {
 			name:     "client not found",
 			clientID: "client-999",
 			setupMock: func() {
-				mock.ExpectQuery("SELECT client_id, name, created_at, updated_at").
+				mock.ExpectQuery("SELECT client_id, name, timezone, locale, created_at, updated_at").
 					WithArgs("client-999").
 					WillReturnError(sql.ErrNoRows)
 			},
@@ -174,7 +175,7 @@ func TestDB_GetClient(t *testing.T) {
 			name:     "database error",
 			clientID: "client-1",
 			setupMock: func() {
-				mock.ExpectQuery("SELECT client_id, name, created_at, updated_at").
+				mock.ExpectQuery("SELECT client_id, name, timezone, locale, created_at, updated_at").
 					WithArgs("client-1").
 					WillReturnError(sql.ErrConnDone)
 			},
@@ -205,6 +206,51 @@ func TestDB_GetClient(t *testing.T) {
 	}
 }
 
+// TestDB_UpdateClientSettings tests UpdateClientSettings.
+func TestDB_UpdateClientSettings(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	d := &DB{conn: db}
+	ctx := context.Background()
+	timezone := "Asia/Tokyo"
+
+	t.Run("successful update", func(t *testing.T) {
+		mock.ExpectQuery("UPDATE clients").
+			WithArgs("client-1", &timezone, nil).
+			WillReturnRows(sqlmock.NewRows([]string{"client_id", "name", "timezone", "locale", "created_at", "updated_at"}).
+				AddRow("client-1", "Test Client", "Asia/Tokyo", "ja-JP", time.Now(), time.Now()))
+
+		client, err := d.UpdateClientSettings(ctx, "client-1", &timezone, nil)
+		if err != nil {
+			t.Fatalf("UpdateClientSettings() error = %v", err)
+		}
+		if client.Timezone != "Asia/Tokyo" || client.Locale != "ja-JP" {
+			t.Errorf("UpdateClientSettings() = %q %q, want Asia/Tokyo ja-JP", client.Timezone, client.Locale)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Mock expectations were not met: %v", err)
+		}
+	})
+
+	t.Run("client not found", func(t *testing.T) {
+		mock.ExpectQuery("UPDATE clients").
+			WithArgs("client-999", &timezone, nil).
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := d.UpdateClientSettings(ctx, "client-999", &timezone, nil)
+		if err == nil || !contains(err.Error(), "client not found") {
+			t.Errorf("UpdateClientSettings() error = %v, want client not found", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Mock expectations were not met: %v", err)
+		}
+	})
+}
+
 // TestDB_ListClients tests ListClients with pagination.
 func TestDB_ListClients(t *testing.T) {
 	db, mock, err := sqlmock.New()
@@ -219,10 +265,10 @@ func TestDB_ListClients(t *testing.T) {
 	t.Run("successful list", func(t *testing.T) {
 		mock.ExpectQuery("SELECT COUNT").
 			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
-		rows := sqlmock.NewRows([]string{"client_id", "name", "created_at", "updated_at"}).
-			AddRow("client-1", "Client 1", time.Now(), time.Now()).
-			AddRow("client-2", "Client 2", time.Now(), time.Now())
-		mock.ExpectQuery("SELECT client_id, name, created_at, updated_at").
+		rows := sqlmock.NewRows([]string{"client_id", "name", "timezone", "locale", "created_at", "updated_at"}).
+			AddRow("client-1", "Client 1", "", "", time.Now(), time.Now()).
+			AddRow("client-2", "Client 2", "America/New_York", "en-US", time.Now(), time.Now())
+		mock.ExpectQuery("SELECT client_id, name, timezone, locale, created_at, updated_at").
 			WithArgs(50, 0).
 			WillReturnRows(rows)
 
@@ -244,8 +290,8 @@ func TestDB_ListClients(t *testing.T) {
 	t.Run("empty list", func(t *testing.T) {
 		mock.ExpectQuery("SELECT COUNT").
 			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
-		rows := sqlmock.NewRows([]string{"client_id", "name", "created_at", "updated_at"})
-		mock.ExpectQuery("SELECT client_id, name, created_at, updated_at").
+		rows := sqlmock.NewRows([]string{"client_id", "name", "timezone", "locale", "created_at", "updated_at"})
+		mock.ExpectQuery("SELECT client_id, name, timezone, locale, created_at, updated_at").
 			WithArgs(50, 0).
 			WillReturnRows(rows)
 
@@ -280,7 +326,7 @@ func TestDB_ListClients(t *testing.T) {
 	t.Run("database error on query", func(t *testing.T) {
 		mock.ExpectQuery("SELECT COUNT").
 			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
-		mock.ExpectQuery("SELECT client_id, name, created_at, updated_at").
+		mock.ExpectQuery("SELECT client_id, name, timezone, locale, created_at, updated_at").
 			WithArgs(50, 0).
 			WillReturnError(sql.ErrConnDone)
 
@@ -306,13 +352,13 @@ func TestDB_CreateRule(t *testing.T) {
 	ctx := context.Background()
 
 	t.Run("successful create", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"rule_id", "client_id", "severity", "source", "name", "enabled", "version", "created_at", "updated_at"}).
-			AddRow("rule-1", "client-1", "HIGH", "source-1", "alert-1", true, 1, time.Now(), time.Now())
+		rows := sqlmock.NewRows([]string{"rule_id", "client_id", "severity", "source", "name", "description", "exclude_sources", "exclude_names", "context_conditions", "enabled", "version", "created_at", "updated_at"}).
+			AddRow("rule-1", "client-1", "HIGH", "source-1", "alert-1", "", "{}", "{}", "[]", true, 1, time.Now(), time.Now())
 		mock.ExpectQuery("INSERT INTO rules").
-			WithArgs("client-1", "HIGH", "source-1", "alert-1").
+			WithArgs("client-1", "HIGH", "source-1", "alert-1", "", pq.Array([]string{}), pq.Array([]string{}), "[]").
 			WillReturnRows(rows)
 
-		rule, err := d.CreateRule(ctx, "client-1", "HIGH", "source-1", "alert-1")
+		rule, err := d.CreateRule(ctx, "client-1", "HIGH", "source-1", "alert-1", "", RuleExclusions{}, nil)
 		if err != nil {
 			t.Errorf("CreateRule() error = %v", err)
 		}
@@ -324,12 +370,52 @@ func TestDB_CreateRule(t *testing.T) {
 		}
 	})
 
+	t.Run("with exclusions", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"rule_id", "client_id", "severity", "source", "name", "description", "exclude_sources", "exclude_names", "context_conditions", "enabled", "version", "created_at", "updated_at"}).
+			AddRow("rule-2", "client-1", "HIGH", "*", "alert-1", "", "{staging,canary}", "{}", "[]", true, 1, time.Now(), time.Now())
+		mock.ExpectQuery("INSERT INTO rules").
+			WithArgs("client-1", "HIGH", "*", "alert-1", "", pq.Array([]string{"staging", "canary"}), pq.Array([]string{}), "[]").
+			WillReturnRows(rows)
+
+		rule, err := d.CreateRule(ctx, "client-1", "HIGH", "*", "alert-1", "", RuleExclusions{Sources: []string{"staging", "canary"}}, nil)
+		if err != nil {
+			t.Fatalf("CreateRule() error = %v", err)
+		}
+		if len(rule.ExcludeSources) != 2 || rule.ExcludeSources[1] != "canary" || len(rule.ExcludeNames) != 0 {
+			t.Errorf("CreateRule() exclusions = %v, %v, want [staging canary], []", rule.ExcludeSources, rule.ExcludeNames)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Mock expectations were not met: %v", err)
+		}
+	})
+
+	t.Run("with conditions", func(t *testing.T) {
+		conditionsJSON := `[{"key":"region","op":"==","value":"eu-west-1"}]`
+		rows := sqlmock.NewRows([]string{"rule_id", "client_id", "severity", "source", "name", "description", "exclude_sources", "exclude_names", "context_conditions", "enabled", "version", "created_at", "updated_at"}).
+			AddRow("rule-3", "client-1", "HIGH", "source-1", "alert-1", "", "{}", "{}", []byte(conditionsJSON), true, 1, time.Now(), time.Now())
+		mock.ExpectQuery("INSERT INTO rules").
+			WithArgs("client-1", "HIGH", "source-1", "alert-1", "", pq.Array([]string{}), pq.Array([]string{}), conditionsJSON).
+			WillReturnRows(rows)
+
+		conditions := RuleConditions{{Key: "region", Op: ConditionOpEqual, Value: "eu-west-1"}}
+		rule, err := d.CreateRule(ctx, "client-1", "HIGH", "source-1", "alert-1", "", RuleExclusions{}, conditions)
+		if err != nil {
+			t.Fatalf("CreateRule() error = %v", err)
+		}
+		if len(rule.Conditions) != 1 || rule.Conditions[0] != conditions[0] {
+			t.Errorf("CreateRule() conditions = %v, want %v", rule.Conditions, conditions)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Mock expectations were not met: %v", err)
+		}
+	})
+
 	t.Run("duplicate rule (exact match)", func(t *testing.T) {
 		mock.ExpectQuery("INSERT INTO rules").
-			WithArgs("client-1", "HIGH", "source-1", "alert-1").
+			WithArgs("client-1", "HIGH", "source-1", "alert-1", "", pq.Array([]string{}), pq.Array([]string{}), "[]").
 			WillReturnError(&pq.Error{Code: "23505"})
 
-		_, err := d.CreateRule(ctx, "client-1", "HIGH", "source-1", "alert-1")
+		_, err := d.CreateRule(ctx, "client-1", "HIGH", "source-1", "alert-1", "", RuleExclusions{}, nil)
 		if err == nil {
 			t.Error("CreateRule() expected error for duplicate")
 		}
@@ -340,10 +426,10 @@ func TestDB_CreateRule(t *testing.T) {
 
 	t.Run("client not found", func(t *testing.T) {
 		mock.ExpectQuery("INSERT INTO rules").
-			WithArgs("client-999", "HIGH", "source-1", "alert-1").
+			WithArgs("client-999", "HIGH", "source-1", "alert-1", "", pq.Array([]string{}), pq.Array([]string{}), "[]").
 			WillReturnError(&pq.Error{Code: "23503"})
 
-		_, err := d.CreateRule(ctx, "client-999", "HIGH", "source-1", "alert-1")
+		_, err := d.CreateRule(ctx, "client-999", "HIGH", "source-1", "alert-1", "", RuleExclusions{}, nil)
 		if err == nil {
 			t.Error("CreateRule() expected error for missing client")
 		}
@@ -368,9 +454,9 @@ func TestDB_GetRule(t *testing.T) {
 	ctx := context.Background()
 
 	t.Run("successful get", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"rule_id", "client_id", "severity", "source", "name", "enabled", "version", "created_at", "updated_at"}).
-			AddRow("rule-1", "client-1", "HIGH", "source-1", "alert-1", true, 1, time.Now(), time.Now())
-		mock.ExpectQuery("SELECT rule_id, client_id, severity, source, name, enabled, version, created_at, updated_at").
+		rows := sqlmock.NewRows([]string{"rule_id", "client_id", "severity", "source", "name", "description", "exclude_sources", "exclude_names", "context_conditions", "enabled", "version", "created_at", "updated_at"}).
+			AddRow("rule-1", "client-1", "HIGH", "source-1", "alert-1", "", "{}", "{}", "[]", true, 1, time.Now(), time.Now())
+		mock.ExpectQuery("SELECT rule_id, client_id, severity, source, name, description, exclude_sources, exclude_names, context_conditions, enabled, version, created_at, updated_at").
 			WithArgs("rule-1").
 			WillReturnRows(rows)
 
@@ -387,7 +473,7 @@ func TestDB_GetRule(t *testing.T) {
 	})
 
 	t.Run("rule not found", func(t *testing.T) {
-		mock.ExpectQuery("SELECT rule_id, client_id, severity, source, name, enabled, version, created_at, updated_at").
+		mock.ExpectQuery("SELECT rule_id, client_id, severity, source, name, description, exclude_sources, exclude_names, context_conditions, enabled, version, created_at, updated_at").
 			WithArgs("rule-999").
 			WillReturnError(sql.ErrNoRows)
 
@@ -418,13 +504,13 @@ func TestDB_ListRules(t *testing.T) {
 	t.Run("list all rules", func(t *testing.T) {
 		mock.ExpectQuery("SELECT COUNT").
 			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
-		rows := sqlmock.NewRows([]string{"rule_id", "client_id", "severity", "source", "name", "enabled", "version", "created_at", "updated_at"}).
-			AddRow("rule-1", "client-1", "HIGH", "source-1", "alert-1", true, 1, time.Now(), time.Now())
-		mock.ExpectQuery("SELECT rule_id, client_id, severity, source, name, enabled, version, created_at, updated_at").
+		rows := sqlmock.NewRows([]string{"rule_id", "client_id", "severity", "source", "name", "description", "exclude_sources", "exclude_names", "context_conditions", "enabled", "version", "created_at", "updated_at"}).
+			AddRow("rule-1", "client-1", "HIGH", "source-1", "alert-1", "", "{}", "{}", "[]", true, 1, time.Now(), time.Now())
+		mock.ExpectQuery("SELECT rule_id, client_id, severity, source, name, description, exclude_sources, exclude_names, context_conditions, enabled, version, created_at, updated_at").
 			WithArgs(50, 0).
 			WillReturnRows(rows)
 
-		result, err := d.ListRules(ctx, nil, 50, 0)
+		result, err := d.ListRules(ctx, RuleFilter{}, 50, 0)
 		if err != nil {
 			t.Errorf("ListRules() error = %v", err)
 		}
@@ -444,13 +530,13 @@ func TestDB_ListRules(t *testing.T) {
 		mock.ExpectQuery("SELECT COUNT").
 			WithArgs(clientID).
 			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
-		rows := sqlmock.NewRows([]string{"rule_id", "client_id", "severity", "source", "name", "enabled", "version", "created_at", "updated_at"}).
-			AddRow("rule-1", "client-1", "HIGH", "source-1", "alert-1", true, 1, time.Now(), time.Now())
-		mock.ExpectQuery("SELECT rule_id, client_id, severity, source, name, enabled, version, created_at, updated_at").
+		rows := sqlmock.NewRows([]string{"rule_id", "client_id", "severity", "source", "name", "description", "exclude_sources", "exclude_names", "context_conditions", "enabled", "version", "created_at", "updated_at"}).
+			AddRow("rule-1", "client-1", "HIGH", "source-1", "alert-1", "", "{}", "{}", "[]", true, 1, time.Now(), time.Now())
+		mock.ExpectQuery("SELECT rule_id, client_id, severity, source, name, description, exclude_sources, exclude_names, context_conditions, enabled, version, created_at, updated_at").
 			WithArgs(clientID, 50, 0).
 			WillReturnRows(rows)
 
-		result, err := d.ListRules(ctx, &clientID, 50, 0)
+		result, err := d.ListRules(ctx, RuleFilter{ClientID: &clientID}, 50, 0)
 		if err != nil {
 			t.Errorf("ListRules() error = %v", err)
 		}
@@ -466,6 +552,47 @@ func TestDB_ListRules(t *testing.T) {
 	})
 }
 
+// TestDB_ListRules_Filters tests that ListRules combines filters at the SQL layer.
+func TestDB_ListRules_Filters(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	d := &DB{conn: db}
+	ctx := context.Background()
+
+	enabled := true
+	severity := "HIGH"
+	source := "api"
+	name := "50%_disk"
+	since := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	filter := RuleFilter{Enabled: &enabled, Severity: &severity, Source: &source, NameContains: &name, UpdatedSince: &since}
+
+	// Wildcards in the name substring are escaped so they match literally
+	wantArgs := []driver.Value{true, "HIGH", "api", `%50\%\_disk%`, since}
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM rules WHERE enabled = \$1 AND severity = \$2 AND source = \$3 AND name ILIKE \$4 AND updated_at >= \$5`).
+		WithArgs(wantArgs...).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	rows := sqlmock.NewRows([]string{"rule_id", "client_id", "severity", "source", "name", "description", "exclude_sources", "exclude_names", "context_conditions", "enabled", "version", "created_at", "updated_at"}).
+		AddRow("rule-1", "client-1", "HIGH", "api", "50%_disk-full", "", "{}", "{}", "[]", true, 1, time.Now(), time.Now())
+	mock.ExpectQuery(`LIMIT \$6 OFFSET \$7`).
+		WithArgs(append(wantArgs, 50, 0)...).
+		WillReturnRows(rows)
+
+	result, err := d.ListRules(ctx, filter, 50, 0)
+	if err != nil {
+		t.Fatalf("ListRules() error = %v", err)
+	}
+	if len(result.Rules) != 1 || result.Total != 1 {
+		t.Errorf("ListRules() returned %d rules, total %d; want 1, 1", len(result.Rules), result.Total)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Mock expectations were not met: %v", err)
+	}
+}
+
 // TestDB_UpdateRule tests UpdateRule with optimistic locking.
 func TestDB_UpdateRule(t *testing.T) {
 	db, mock, err := sqlmock.New()
@@ -478,13 +605,13 @@ func TestDB_UpdateRule(t *testing.T) {
 	ctx := context.Background()
 
 	t.Run("successful update", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"rule_id", "client_id", "severity", "source", "name", "enabled", "version", "created_at", "updated_at"}).
-			AddRow("rule-1", "client-1", "CRITICAL", "source-2", "alert-2", true, 2, time.Now(), time.Now())
+		rows := sqlmock.NewRows([]string{"rule_id", "client_id", "severity", "source", "name", "description", "exclude_sources", "exclude_names", "context_conditions", "enabled", "version", "created_at", "updated_at"}).
+			AddRow("rule-1", "client-1", "CRITICAL", "source-2", "alert-2", "", "{}", "{}", "[]", true, 2, time.Now(), time.Now())
 		mock.ExpectQuery("UPDATE rules").
-			WithArgs("rule-1", "CRITICAL", "source-2", "alert-2", 1).
+			WithArgs("rule-1", "CRITICAL", "source-2", "alert-2", 1, nil, nil, nil, nil).
 			WillReturnRows(rows)
 
-		rule, err := d.UpdateRule(ctx, "rule-1", "CRITICAL", "source-2", "alert-2", 1)
+		rule, err := d.UpdateRule(ctx, "rule-1", "CRITICAL", "source-2", "alert-2", nil, nil, nil, 1)
 		if err != nil {
 			t.Errorf("UpdateRule() error = %v", err)
 		}
@@ -498,13 +625,13 @@ func TestDB_UpdateRule(t *testing.T) {
 
 	t.Run("version mismatch", func(t *testing.T) {
 		mock.ExpectQuery("UPDATE rules").
-			WithArgs("rule-1", "CRITICAL", "source-2", "alert-2", 1).
+			WithArgs("rule-1", "CRITICAL", "source-2", "alert-2", 1, nil, nil, nil, nil).
 			WillReturnError(sql.ErrNoRows)
 		mock.ExpectQuery("SELECT EXISTS").
 			WithArgs("rule-1").
 			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
 
-		_, err := d.UpdateRule(ctx, "rule-1", "CRITICAL", "source-2", "alert-2", 1)
+		_, err := d.UpdateRule(ctx, "rule-1", "CRITICAL", "source-2", "alert-2", nil, nil, nil, 1)
 		if err == nil {
 			t.Error("UpdateRule() expected error for version mismatch")
 		}
@@ -518,13 +645,13 @@ func TestDB_UpdateRule(t *testing.T) {
 
 	t.Run("rule not found", func(t *testing.T) {
 		mock.ExpectQuery("UPDATE rules").
-			WithArgs("rule-999", "CRITICAL", "source-2", "alert-2", 1).
+			WithArgs("rule-999", "CRITICAL", "source-2", "alert-2", 1, nil, nil, nil, nil).
 			WillReturnError(sql.ErrNoRows)
 		mock.ExpectQuery("SELECT EXISTS").
 			WithArgs("rule-999").
 			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
 
-		_, err := d.UpdateRule(ctx, "rule-999", "CRITICAL", "source-2", "alert-2", 1)
+		_, err := d.UpdateRule(ctx, "rule-999", "CRITICAL", "source-2", "alert-2", nil, nil, nil, 1)
 		if err == nil {
 			t.Error("UpdateRule() expected error for missing rule")
 		}
@@ -549,8 +676,8 @@ func TestDB_ToggleRuleEnabled(t *testing.T) {
 	ctx := context.Background()
 
 	t.Run("successful toggle", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"rule_id", "client_id", "severity", "source", "name", "enabled", "version", "created_at", "updated_at"}).
-			AddRow("rule-1", "client-1", "HIGH", "source-1", "alert-1", false, 2, time.Now(), time.Now())
+		rows := sqlmock.NewRows([]string{"rule_id", "client_id", "severity", "source", "name", "description", "exclude_sources", "exclude_names", "context_conditions", "enabled", "version", "created_at", "updated_at"}).
+			AddRow("rule-1", "client-1", "HIGH", "source-1", "alert-1", "", "{}", "{}", "[]", false, 2, time.Now(), time.Now())
 		mock.ExpectQuery("UPDATE rules").
 			WithArgs("rule-1", false, 1).
 			WillReturnRows(rows)
@@ -644,9 +771,9 @@ func TestDB_GetRulesUpdatedSince(t *testing.T) {
 
 	t.Run("successful get", func(t *testing.T) {
 		since := time.Now().Add(-1 * time.Hour)
-		rows := sqlmock.NewRows([]string{"rule_id", "client_id", "severity", "source", "name", "enabled", "version", "created_at", "updated_at"}).
-			AddRow("rule-1", "client-1", "HIGH", "source-1", "alert-1", true, 1, time.Now(), time.Now())
-		mock.ExpectQuery("SELECT rule_id, client_id, severity, source, name, enabled, version, created_at, updated_at").
+		rows := sqlmock.NewRows([]string{"rule_id", "client_id", "severity", "source", "name", "description", "exclude_sources", "exclude_names", "context_conditions", "enabled", "version", "created_at", "updated_at"}).
+			AddRow("rule-1", "client-1", "HIGH", "source-1", "alert-1", "", "{}", "{}", "[]", true, 1, time.Now(), time.Now())
+		mock.ExpectQuery("SELECT rule_id, client_id, severity, source, name, description, exclude_sources, exclude_names, context_conditions, enabled, version, created_at, updated_at").
 			WithArgs(since).
 			WillReturnRows(rows)
 
@@ -663,6 +790,136 @@ func TestDB_GetRulesUpdatedSince(t *testing.T) {
 	})
 }
 
+// TestDB_ListClientRules tests ListClientRules.
+func TestDB_ListClientRules(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	d := &DB{conn: db}
+	ctx := context.Background()
+
+	rows := sqlmock.NewRows([]string{"rule_id", "client_id", "severity", "source", "name", "description", "exclude_sources", "exclude_names", "context_conditions", "enabled", "version", "created_at", "updated_at"}).
+		AddRow("rule-1", "client-1", "*", "api", "*", "", "{}", "{}", "[]", true, 1, time.Now(), time.Now()).
+		AddRow("rule-2", "client-1", "HIGH", "api", "timeout", "", "{}", "{}", "[]", false, 2, time.Now(), time.Now())
+	mock.ExpectQuery(`WHERE client_id = \$1\s+ORDER BY created_at ASC`).
+		WithArgs("client-1").
+		WillReturnRows(rows)
+
+	rules, err := d.ListClientRules(ctx, "client-1")
+	if err != nil {
+		t.Fatalf("ListClientRules() error = %v", err)
+	}
+	if len(rules) != 2 {
+		t.Errorf("ListClientRules() returned %d rules, want 2", len(rules))
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Mock expectations were not met: %v", err)
+	}
+}
+
+// TestDB_GetRuleImpact tests GetRuleImpact.
+func TestDB_GetRuleImpact(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	d := &DB{conn: db}
+	ctx := context.Background()
+
+	since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{"day", "notifications", "new_notifications"}).
+		AddRow(since, 4, 3).
+		AddRow(since.AddDate(0, 0, 2), 1, 0)
+	mock.ExpectQuery(`FROM notifications`).
+		WithArgs(since, "*", "api", "timeout", "client-1").
+		WillReturnRows(rows)
+
+	days, err := d.GetRuleImpact(ctx, "client-1", "*", "api", "timeout", since)
+	if err != nil {
+		t.Fatalf("GetRuleImpact() error = %v", err)
+	}
+	if len(days) != 2 || days[0].Notifications != 4 || days[0].NewNotifications != 3 {
+		t.Errorf("GetRuleImpact() = %+v, want 2 days starting with 4 notifications, 3 new", days)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Mock expectations were not met: %v", err)
+	}
+}
+
+// TestDB_BootstrapClient tests that BootstrapClient creates everything in one transaction.
+func TestDB_BootstrapClient(t *testing.T) {
+	rules := []BootstrapRule{{
+		Severity:  "CRITICAL",
+		Source:    "*",
+		Name:      "*",
+		Endpoints: []BootstrapEndpoint{{Type: "email", Value: "oncall@team-a.example"}},
+	}}
+
+	t.Run("successful bootstrap", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("Failed to create mock: %v", err)
+		}
+		defer db.Close()
+		d := &DB{conn: db}
+
+		now := time.Now()
+		mock.ExpectBegin()
+		mock.ExpectQuery("INSERT INTO clients").
+			WithArgs("team-a", "Team A").
+			WillReturnRows(sqlmock.NewRows([]string{"client_id", "name", "created_at", "updated_at"}).
+				AddRow("team-a", "Team A", now, now))
+		mock.ExpectQuery("INSERT INTO rules").
+			WithArgs("team-a", "CRITICAL", "*", "*", "", pq.Array([]string{}), pq.Array([]string{}), "[]", true).
+			WillReturnRows(sqlmock.NewRows([]string{"rule_id", "client_id", "severity", "source", "name", "description", "exclude_sources", "exclude_names", "context_conditions", "enabled", "version", "created_at", "updated_at"}).
+				AddRow("rule-1", "team-a", "CRITICAL", "*", "*", "", "{}", "{}", "[]", true, 1, now, now))
+		mock.ExpectQuery("INSERT INTO endpoints").
+			WithArgs("rule-1", "email", "oncall@team-a.example", true).
+			WillReturnRows(sqlmock.NewRows([]string{"endpoint_id", "rule_id", "type", "value", "enabled", "metadata", "template_id", "created_at", "updated_at"}).
+				AddRow("endpoint-1", "rule-1", "email", "oncall@team-a.example", true, []byte("{}"), "", now, now))
+		mock.ExpectCommit()
+
+		result, err := d.BootstrapClient(context.Background(), "team-a", "Team A", rules)
+		if err != nil {
+			t.Fatalf("BootstrapClient() error = %v", err)
+		}
+		if result.Client.ClientID != "team-a" || len(result.Rules) != 1 || len(result.Rules[0].Endpoints) != 1 {
+			t.Errorf("BootstrapClient() = %+v, want one client, rule, and endpoint", result)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Mock expectations were not met: %v", err)
+		}
+	})
+
+	t.Run("existing client rolls back", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("Failed to create mock: %v", err)
+		}
+		defer db.Close()
+		d := &DB{conn: db}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("INSERT INTO clients").
+			WithArgs("team-a", "Team A").
+			WillReturnError(&pq.Error{Code: "23505"})
+		mock.ExpectRollback()
+
+		_, err = d.BootstrapClient(context.Background(), "team-a", "Team A", rules)
+		if err == nil || !strings.Contains(err.Error(), "client already exists") {
+			t.Errorf("BootstrapClient() error = %v, want client already exists", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Mock expectations were not met: %v", err)
+		}
+	})
+}
+
 // TestDB_CreateEndpoint tests CreateEndpoint.
 func TestDB_CreateEndpoint(t *testing.T) {
 	db, mock, err := sqlmock.New()
@@ -675,13 +932,13 @@ func TestDB_CreateEndpoint(t *testing.T) {
 	ctx := context.Background()
 
 	t.Run("successful create", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"endpoint_id", "rule_id", "type", "value", "enabled", "created_at", "updated_at"}).
-			AddRow("endpoint-1", "rule-1", "email", "test@example.com", true, time.Now(), time.Now())
+		rows := sqlmock.NewRows([]string{"endpoint_id", "rule_id", "type", "value", "enabled", "metadata", "template_id", "created_at", "updated_at"}).
+			AddRow("endpoint-1", "rule-1", "email", "test@example.com", true, []byte("{}"), "", time.Now(), time.Now())
 		mock.ExpectQuery("INSERT INTO endpoints").
-			WithArgs("rule-1", "email", "test@example.com").
+			WithArgs("rule-1", "email", "test@example.com", "{}", "").
 			WillReturnRows(rows)
 
-		endpoint, err := d.CreateEndpoint(ctx, "rule-1", "email", "test@example.com")
+		endpoint, err := d.CreateEndpoint(ctx, "rule-1", "email", "test@example.com", EndpointMetadata{}, "")
 		if err != nil {
 			t.Errorf("CreateEndpoint() error = %v", err)
 		}
@@ -695,10 +952,10 @@ func TestDB_CreateEndpoint(t *testing.T) {
 
 	t.Run("duplicate endpoint", func(t *testing.T) {
 		mock.ExpectQuery("INSERT INTO endpoints").
-			WithArgs("rule-1", "email", "test@example.com").
+			WithArgs("rule-1", "email", "test@example.com", "{}", "").
 			WillReturnError(&pq.Error{Code: "23505"})
 
-		_, err := d.CreateEndpoint(ctx, "rule-1", "email", "test@example.com")
+		_, err := d.CreateEndpoint(ctx, "rule-1", "email", "test@example.com", EndpointMetadata{}, "")
 		if err == nil {
 			t.Error("CreateEndpoint() expected error for duplicate")
 		}
@@ -709,10 +966,10 @@ func TestDB_CreateEndpoint(t *testing.T) {
 
 	t.Run("rule not found", func(t *testing.T) {
 		mock.ExpectQuery("INSERT INTO endpoints").
-			WithArgs("rule-999", "email", "test@example.com").
+			WithArgs("rule-999", "email", "test@example.com", "{}", "").
 			WillReturnError(&pq.Error{Code: "23503"})
 
-		_, err := d.CreateEndpoint(ctx, "rule-999", "email", "test@example.com")
+		_, err := d.CreateEndpoint(ctx, "rule-999", "email", "test@example.com", EndpointMetadata{}, "")
 		if err == nil {
 			t.Error("CreateEndpoint() expected error for missing rule")
 		}
@@ -737,9 +994,9 @@ func TestDB_GetEndpoint(t *testing.T) {
 	ctx := context.Background()
 
 	t.Run("successful get", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"endpoint_id", "rule_id", "type", "value", "enabled", "created_at", "updated_at"}).
-			AddRow("endpoint-1", "rule-1", "email", "test@example.com", true, time.Now(), time.Now())
-		mock.ExpectQuery("SELECT endpoint_id, rule_id, type, value, enabled, created_at, updated_at").
+		rows := sqlmock.NewRows([]string{"endpoint_id", "rule_id", "type", "value", "enabled", "metadata", "template_id", "created_at", "updated_at"}).
+			AddRow("endpoint-1", "rule-1", "email", "test@example.com", true, []byte("{}"), "", time.Now(), time.Now())
+		mock.ExpectQuery("SELECT endpoint_id, rule_id, type, value, enabled, metadata").
 			WithArgs("endpoint-1").
 			WillReturnRows(rows)
 
@@ -756,7 +1013,7 @@ func TestDB_GetEndpoint(t *testing.T) {
 	})
 
 	t.Run("endpoint not found", func(t *testing.T) {
-		mock.ExpectQuery("SELECT endpoint_id, rule_id, type, value, enabled, created_at, updated_at").
+		mock.ExpectQuery("SELECT endpoint_id, rule_id, type, value, enabled, metadata").
 			WithArgs("endpoint-999").
 			WillReturnError(sql.ErrNoRows)
 
@@ -787,9 +1044,9 @@ func TestDB_ListEndpoints(t *testing.T) {
 	t.Run("list all endpoints", func(t *testing.T) {
 		mock.ExpectQuery("SELECT COUNT").
 			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
-		rows := sqlmock.NewRows([]string{"endpoint_id", "rule_id", "type", "value", "enabled", "created_at", "updated_at"}).
-			AddRow("endpoint-1", "rule-1", "email", "test@example.com", true, time.Now(), time.Now())
-		mock.ExpectQuery("SELECT endpoint_id, rule_id, type, value, enabled, created_at, updated_at").
+		rows := sqlmock.NewRows([]string{"endpoint_id", "rule_id", "type", "value", "enabled", "metadata", "template_id", "created_at", "updated_at"}).
+			AddRow("endpoint-1", "rule-1", "email", "test@example.com", true, []byte("{}"), "", time.Now(), time.Now())
+		mock.ExpectQuery("SELECT endpoint_id, rule_id, type, value, enabled, metadata").
 			WithArgs(50, 0).
 			WillReturnRows(rows)
 
@@ -813,9 +1070,9 @@ func TestDB_ListEndpoints(t *testing.T) {
 		mock.ExpectQuery("SELECT COUNT").
 			WithArgs(ruleID).
 			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
-		rows := sqlmock.NewRows([]string{"endpoint_id", "rule_id", "type", "value", "enabled", "created_at", "updated_at"}).
-			AddRow("endpoint-1", "rule-1", "email", "test@example.com", true, time.Now(), time.Now())
-		mock.ExpectQuery("SELECT endpoint_id, rule_id, type, value, enabled, created_at, updated_at").
+		rows := sqlmock.NewRows([]string{"endpoint_id", "rule_id", "type", "value", "enabled", "metadata", "template_id", "created_at", "updated_at"}).
+			AddRow("endpoint-1", "rule-1", "email", "test@example.com", true, []byte("{}"), "", time.Now(), time.Now())
+		mock.ExpectQuery("SELECT endpoint_id, rule_id, type, value, enabled, metadata").
 			WithArgs(ruleID, 50, 0).
 			WillReturnRows(rows)
 
@@ -847,18 +1104,23 @@ func TestDB_UpdateEndpoint(t *testing.T) {
 	ctx := context.Background()
 
 	t.Run("successful update", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"endpoint_id", "rule_id", "type", "value", "enabled", "created_at", "updated_at"}).
-			AddRow("endpoint-1", "rule-1", "webhook", "https://example.com", true, time.Now(), time.Now())
+		metadataJSON := `{"headers":[{"name":"Authorization","value":"sealed","secret":true}]}`
+		rows := sqlmock.NewRows([]string{"endpoint_id", "rule_id", "type", "value", "enabled", "metadata", "template_id", "created_at", "updated_at"}).
+			AddRow("endpoint-1", "rule-1", "webhook", "https://example.com", true, []byte(metadataJSON), "", time.Now(), time.Now())
 		mock.ExpectQuery("UPDATE endpoints").
-			WithArgs("endpoint-1", "webhook", "https://example.com").
+			WithArgs("endpoint-1", "webhook", "https://example.com", metadataJSON, nil).
 			WillReturnRows(rows)
 
-		endpoint, err := d.UpdateEndpoint(ctx, "endpoint-1", "webhook", "https://example.com")
+		metadata := &EndpointMetadata{Headers: []EndpointHeader{{Name: "Authorization", Value: "sealed", Secret: true}}}
+		endpoint, err := d.UpdateEndpoint(ctx, "endpoint-1", "webhook", "https://example.com", metadata, nil)
 		if err != nil {
 			t.Errorf("UpdateEndpoint() error = %v", err)
 		}
 		if endpoint == nil {
-			t.Error("UpdateEndpoint() returned nil endpoint")
+			t.Fatal("UpdateEndpoint() returned nil endpoint")
+		}
+		if len(endpoint.Metadata.Headers) != 1 || endpoint.Metadata.Headers[0] != metadata.Headers[0] {
+			t.Errorf("UpdateEndpoint() metadata = %+v, want %+v", endpoint.Metadata, *metadata)
 		}
 		if err := mock.ExpectationsWereMet(); err != nil {
 			t.Errorf("Mock expectations were not met: %v", err)
@@ -867,10 +1129,10 @@ func TestDB_UpdateEndpoint(t *testing.T) {
 
 	t.Run("endpoint not found", func(t *testing.T) {
 		mock.ExpectQuery("UPDATE endpoints").
-			WithArgs("endpoint-999", "webhook", "https://example.com").
+			WithArgs("endpoint-999", "webhook", "https://example.com", nil, nil).
 			WillReturnError(sql.ErrNoRows)
 
-		_, err := d.UpdateEndpoint(ctx, "endpoint-999", "webhook", "https://example.com")
+		_, err := d.UpdateEndpoint(ctx, "endpoint-999", "webhook", "https://example.com", nil, nil)
 		if err == nil {
 			t.Error("UpdateEndpoint() expected error")
 		}
@@ -895,8 +1157,8 @@ func TestDB_ToggleEndpointEnabled(t *testing.T) {
 	ctx := context.Background()
 
 	t.Run("successful toggle", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"endpoint_id", "rule_id", "type", "value", "enabled", "created_at", "updated_at"}).
-			AddRow("endpoint-1", "rule-1", "email", "test@example.com", false, time.Now(), time.Now())
+		rows := sqlmock.NewRows([]string{"endpoint_id", "rule_id", "type", "value", "enabled", "metadata", "template_id", "created_at", "updated_at"}).
+			AddRow("endpoint-1", "rule-1", "email", "test@example.com", false, []byte("{}"), "", time.Now(), time.Now())
 		mock.ExpectQuery("UPDATE endpoints").
 			WithArgs("endpoint-1", false).
 			WillReturnRows(rows)
@@ -1103,18 +1365,18 @@ func TestDB_ListNotifications(t *testing.T) {
 		}
 	})
 
-	t.Run("list by status", func(t *testing.T) {
-		status := "RECEIVED"
+	t.Run("list by statuses", func(t *testing.T) {
+		statuses := []string{"SENT", "PARTIALLY_SENT"}
 		mock.ExpectQuery("SELECT COUNT").
-			WithArgs(status).
+			WithArgs(pq.Array(statuses)).
 			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
 		rows := sqlmock.NewRows([]string{"notification_id", "client_id", "alert_id", "severity", "source", "name", "context", "rule_ids", "status", "created_at", "updated_at"}).
 			AddRow("notif-1", "client-1", "alert-1", "HIGH", "source-1", "alert-1", nil, pq.Array([]string{"rule-1"}), "RECEIVED", time.Now(), time.Now())
 		mock.ExpectQuery("SELECT notification_id, client_id, alert_id, severity, source, name, context, rule_ids, status, created_at, updated_at").
-			WithArgs(status, 50, 0).
+			WithArgs(pq.Array(statuses), 50, 0).
 			WillReturnRows(rows)
 
-		result, err := d.ListNotifications(ctx, nil, &status, 50, 0)
+		result, err := d.ListNotifications(ctx, nil, statuses, 50, 0)
 		if err != nil {
 			t.Errorf("ListNotifications() error = %v", err)
 		}
@@ -1131,17 +1393,17 @@ func TestDB_ListNotifications(t *testing.T) {
 
 	t.Run("list by client and status", func(t *testing.T) {
 		clientID := "client-1"
-		status := "RECEIVED"
+		statuses := []string{"RECEIVED"}
 		mock.ExpectQuery("SELECT COUNT").
-			WithArgs(clientID, status).
+			WithArgs(clientID, pq.Array(statuses)).
 			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
 		rows := sqlmock.NewRows([]string{"notification_id", "client_id", "alert_id", "severity", "source", "name", "context", "rule_ids", "status", "created_at", "updated_at"}).
 			AddRow("notif-1", "client-1", "alert-1", "HIGH", "source-1", "alert-1", nil, pq.Array([]string{"rule-1"}), "RECEIVED", time.Now(), time.Now())
 		mock.ExpectQuery("SELECT notification_id, client_id, alert_id, severity, source, name, context, rule_ids, status, created_at, updated_at").
-			WithArgs(clientID, status, 50, 0).
+			WithArgs(clientID, pq.Array(statuses), 50, 0).
 			WillReturnRows(rows)
 
-		result, err := d.ListNotifications(ctx, &clientID, &status, 50, 0)
+		result, err := d.ListNotifications(ctx, &clientID, statuses, 50, 0)
 		if err != nil {
 			t.Errorf("ListNotifications() error = %v", err)
 		}
@@ -8,6 +8,8 @@ import (
 	"fmt"
 	"log/slog"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 // DB wraps a database connection and provides client, rule, and endpoint operations.
@@ -66,6 +68,11 @@ func (db *DB) Close() error {
 	return nil
 }
 
+// Ping checks that the database is reachable, for health and readiness probes.
+func (db *DB) Ping(ctx context.Context) error {
+	return db.conn.PingContext(ctx)
+}
+
 // scanRule scans a rule from a sql.Row or sql.Rows into a Rule struct.
 // Used by GetRule, ListRules, GetRulesUpdatedSince, UpdateRule, ToggleRuleEnabled, and CreateRule.
 func scanRule(scanner interface {
@@ -78,6 +85,10 @@ func scanRule(scanner interface {
 		&rule.Severity,
 		&rule.Source,
 		&rule.Name,
+		&rule.Description,
+		pq.Array(&rule.ExcludeSources),
+		pq.Array(&rule.ExcludeNames),
+		&rule.Conditions,
 		&rule.Enabled,
 		&rule.Version,
 		&rule.CreatedAt,
@@ -0,0 +1,85 @@
+// Package database provides database operations for clients, rules, and endpoints.
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// PublishEndpointChanges hands up to limit pending endpoint changes to publish, oldest first,
+// and marks the ones it published. Publishing stops at the first failure so events are never
+// reordered; the failed change and those after it stay pending for the next call.
+// A transaction-scoped advisory lock lets only one rule-service instance relay at a time;
+// if another instance holds it, nothing is published.
+func (db *DB) PublishEndpointChanges(ctx context.Context, limit int, publish func(*EndpointChange) error) (int, error) {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin outbox transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var locked bool
+	if err := tx.QueryRowContext(ctx, `SELECT pg_try_advisory_xact_lock(hashtext('endpoint_outbox'))`).Scan(&locked); err != nil {
+		return 0, fmt.Errorf("failed to lock endpoint outbox: %w", err)
+	}
+	if !locked {
+		return 0, nil
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT event_id, endpoint_id, rule_id, COALESCE(client_id, ''), action, endpoint_type, enabled, created_at
+		FROM endpoint_outbox
+		WHERE published_at IS NULL
+		ORDER BY event_id
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query endpoint outbox: %w", err)
+	}
+	var changes []*EndpointChange
+	for rows.Next() {
+		var c EndpointChange
+		if err := rows.Scan(&c.EventID, &c.EndpointID, &c.RuleID, &c.ClientID, &c.Action, &c.EndpointType, &c.Enabled, &c.CreatedAt); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan endpoint change: %w", err)
+		}
+		changes = append(changes, &c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating endpoint outbox: %w", err)
+	}
+
+	published := make([]int64, 0, len(changes))
+	var publishErr error
+	for _, c := range changes {
+		if publishErr = publish(c); publishErr != nil {
+			break
+		}
+		published = append(published, c.EventID)
+	}
+	if len(published) == 0 {
+		return 0, publishErr
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE endpoint_outbox SET published_at = NOW() WHERE event_id = ANY($1)`, pq.Array(published)); err != nil {
+		return 0, fmt.Errorf("failed to mark endpoint changes published: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit endpoint outbox: %w", err)
+	}
+	return len(published), publishErr
+}
+
+// PruneEndpointOutbox deletes endpoint changes published before the given time.
+// Returns the number of rows deleted.
+func (db *DB) PruneEndpointOutbox(ctx context.Context, before time.Time) (int64, error) {
+	result, err := db.conn.ExecContext(ctx, `DELETE FROM endpoint_outbox WHERE published_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune endpoint outbox: %w", err)
+	}
+	return result.RowsAffected()
+}
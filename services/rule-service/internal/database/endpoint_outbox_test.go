@@ -0,0 +1,142 @@
+// Package database provides tests for endpoint outbox operations.
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+)
+
+var endpointOutboxColumns = []string{
+	"event_id", "endpoint_id", "rule_id", "client_id", "action", "endpoint_type", "enabled", "created_at",
+}
+
+// TestDB_PublishEndpointChanges tests the PublishEndpointChanges method.
+func TestDB_PublishEndpointChanges(t *testing.T) {
+	now := time.Now()
+	pending := func() *sqlmock.Rows {
+		return sqlmock.NewRows(endpointOutboxColumns).
+			AddRow(int64(1), "endpoint-1", "rule-1", "client-1", "CREATED", "email", true, now).
+			AddRow(int64(2), "endpoint-1", "rule-1", "client-1", "DISABLED", "email", false, now).
+			AddRow(int64(3), "endpoint-2", "rule-2", "", "DELETED", "webhook", false, now)
+	}
+
+	t.Run("publishes and marks in order", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("Failed to create mock: %v", err)
+		}
+		defer db.Close()
+		d := &DB{conn: db}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("pg_try_advisory_xact_lock").
+			WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+		mock.ExpectQuery("SELECT (.+) FROM endpoint_outbox").WithArgs(100).WillReturnRows(pending())
+		mock.ExpectExec("UPDATE endpoint_outbox SET published_at").
+			WithArgs(pq.Array([]int64{1, 2, 3})).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectCommit()
+
+		var got []*EndpointChange
+		n, err := d.PublishEndpointChanges(context.Background(), 100, func(c *EndpointChange) error {
+			got = append(got, c)
+			return nil
+		})
+		if err != nil || n != 3 {
+			t.Fatalf("PublishEndpointChanges() = %d, %v, want 3 published", n, err)
+		}
+		if got[1].Action != "DISABLED" || got[1].Enabled || got[2].ClientID != "" {
+			t.Errorf("published changes = %+v, %+v", got[1], got[2])
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Mock expectations were not met: %v", err)
+		}
+	})
+
+	t.Run("stops at the first failure", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("Failed to create mock: %v", err)
+		}
+		defer db.Close()
+		d := &DB{conn: db}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("pg_try_advisory_xact_lock").
+			WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+		mock.ExpectQuery("SELECT (.+) FROM endpoint_outbox").WithArgs(100).WillReturnRows(pending())
+		mock.ExpectExec("UPDATE endpoint_outbox SET published_at").
+			WithArgs(pq.Array([]int64{1})).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		calls := 0
+		kafkaErr := errors.New("kafka unavailable")
+		n, err := d.PublishEndpointChanges(context.Background(), 100, func(c *EndpointChange) error {
+			calls++
+			if c.EventID == 2 {
+				return kafkaErr
+			}
+			return nil
+		})
+		if !errors.Is(err, kafkaErr) || n != 1 || calls != 2 {
+			t.Errorf("PublishEndpointChanges() = %d, %v after %d calls, want 1 published and the publish error", n, err, calls)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Mock expectations were not met: %v", err)
+		}
+	})
+
+	t.Run("another instance holds the lock", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("Failed to create mock: %v", err)
+		}
+		defer db.Close()
+		d := &DB{conn: db}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("pg_try_advisory_xact_lock").
+			WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(false))
+		mock.ExpectRollback()
+
+		n, err := d.PublishEndpointChanges(context.Background(), 100, func(c *EndpointChange) error {
+			t.Error("publish called without the lock")
+			return nil
+		})
+		if err != nil || n != 0 {
+			t.Errorf("PublishEndpointChanges() = %d, %v, want nothing published", n, err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Mock expectations were not met: %v", err)
+		}
+	})
+}
+
+// TestDB_PruneEndpointOutbox tests the PruneEndpointOutbox method.
+func TestDB_PruneEndpointOutbox(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+	d := &DB{conn: db}
+
+	before := time.Now().Add(-7 * 24 * time.Hour)
+	mock.ExpectExec("DELETE FROM endpoint_outbox").
+		WithArgs(before).
+		WillReturnResult(sqlmock.NewResult(0, 42))
+
+	n, err := d.PruneEndpointOutbox(context.Background(), before)
+	if err != nil || n != 42 {
+		t.Errorf("PruneEndpointOutbox() = %d, %v, want 42", n, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Mock expectations were not met: %v", err)
+	}
+}
@@ -4,66 +4,82 @@ package database
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
 	"fmt"
 
 	"github.com/lib/pq"
 )
 
-// CreateEndpoint creates a new endpoint for a rule.
-func (db *DB) CreateEndpoint(ctx context.Context, ruleID, endpointType, value string) (*Endpoint, error) {
+// Value implements driver.Valuer, storing the metadata as JSON.
+// The JSON is passed as a string: lib/pq would send []byte as bytea.
+func (m EndpointMetadata) Value() (driver.Value, error) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner for the JSONB metadata column.
+func (m *EndpointMetadata) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*m = EndpointMetadata{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported endpoint metadata type %T", src)
+	}
+	*m = EndpointMetadata{}
+	return json.Unmarshal(data, m)
+}
+
+// endpointColumns are the endpoint columns scanned by scanEndpoint, in order.
+const endpointColumns = `endpoint_id, rule_id, type, value, enabled, metadata, COALESCE(template_id, ''), created_at, updated_at`
+
+// endpointTemplateConstraint is the foreign key from endpoints.template_id to templates.
+const endpointTemplateConstraint = "endpoints_template_id_fkey"
+
+// CreateEndpoint creates a new endpoint for a rule. An empty templateID uses the default layout.
+func (db *DB) CreateEndpoint(ctx context.Context, ruleID, endpointType, value string, metadata EndpointMetadata, templateID string) (*Endpoint, error) {
 	query := `
-		INSERT INTO endpoints (rule_id, type, value, enabled, created_at, updated_at)
-		VALUES ($1, $2, $3, TRUE, NOW(), NOW())
-		RETURNING endpoint_id, rule_id, type, value, enabled, created_at, updated_at
-	`
-	var endpoint Endpoint
-	err := db.conn.QueryRowContext(ctx, query, ruleID, endpointType, value).Scan(
-		&endpoint.EndpointID,
-		&endpoint.RuleID,
-		&endpoint.Type,
-		&endpoint.Value,
-		&endpoint.Enabled,
-		&endpoint.CreatedAt,
-		&endpoint.UpdatedAt,
-	)
+		INSERT INTO endpoints (rule_id, type, value, enabled, metadata, template_id, created_at, updated_at)
+		VALUES ($1, $2, $3, TRUE, $4, NULLIF($5, ''), NOW(), NOW())
+		RETURNING ` + endpointColumns
+	endpoint, err := scanEndpoint(db.conn.QueryRowContext(ctx, query, ruleID, endpointType, value, metadata, templateID))
 	if err != nil {
 		if pqErr, ok := err.(*pq.Error); ok {
 			if pqErr.Code == "23505" { // unique_violation
 				return nil, fmt.Errorf("endpoint already exists for rule %s with type %s and value %s", ruleID, endpointType, value)
 			}
 			if pqErr.Code == "23503" { // foreign_key_violation
+				if pqErr.Constraint == endpointTemplateConstraint {
+					return nil, fmt.Errorf("template not found: %s", templateID)
+				}
 				return nil, fmt.Errorf("rule not found: %s", ruleID)
 			}
 		}
 		return nil, fmt.Errorf("failed to create endpoint: %w", err)
 	}
-	return &endpoint, nil
+	return endpoint, nil
 }
 
 // GetEndpoint retrieves an endpoint by ID.
 func (db *DB) GetEndpoint(ctx context.Context, endpointID string) (*Endpoint, error) {
-	query := `
-		SELECT endpoint_id, rule_id, type, value, enabled, created_at, updated_at
-		FROM endpoints
-		WHERE endpoint_id = $1
-	`
-	var endpoint Endpoint
-	err := db.conn.QueryRowContext(ctx, query, endpointID).Scan(
-		&endpoint.EndpointID,
-		&endpoint.RuleID,
-		&endpoint.Type,
-		&endpoint.Value,
-		&endpoint.Enabled,
-		&endpoint.CreatedAt,
-		&endpoint.UpdatedAt,
-	)
+	query := `SELECT ` + endpointColumns + ` FROM endpoints WHERE endpoint_id = $1`
+	endpoint, err := scanEndpoint(db.conn.QueryRowContext(ctx, query, endpointID))
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("endpoint not found: %s", endpointID)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get endpoint: %w", err)
 	}
-	return &endpoint, nil
+	return endpoint, nil
 }
 
 // ListEndpoints retrieves endpoints with pagination, optionally filtered by rule_id.
@@ -111,12 +127,12 @@ func (db *DB) ListEndpoints(ctx context.Context, ruleID *string, limit, offset i
 
 	// Get paginated results
 	query := fmt.Sprintf(`
-		SELECT endpoint_id, rule_id, type, value, enabled, created_at, updated_at
+		SELECT %s
 		FROM endpoints
 		%s
 		ORDER BY created_at DESC
 		LIMIT $%d OFFSET $%d
-	`, whereClause, argIndex, argIndex+1)
+	`, endpointColumns, whereClause, argIndex, argIndex+1)
 
 	args := append(countArgs, limit, offset)
 	rows, err := db.conn.QueryContext(ctx, query, args...)
@@ -127,19 +143,11 @@ func (db *DB) ListEndpoints(ctx context.Context, ruleID *string, limit, offset i
 
 	var endpoints []*Endpoint
 	for rows.Next() {
-		var endpoint Endpoint
-		if err := rows.Scan(
-			&endpoint.EndpointID,
-			&endpoint.RuleID,
-			&endpoint.Type,
-			&endpoint.Value,
-			&endpoint.Enabled,
-			&endpoint.CreatedAt,
-			&endpoint.UpdatedAt,
-		); err != nil {
+		endpoint, err := scanEndpoint(rows)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan endpoint: %w", err)
 		}
-		endpoints = append(endpoints, &endpoint)
+		endpoints = append(endpoints, endpoint)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -154,33 +162,37 @@ func (db *DB) ListEndpoints(ctx context.Context, ruleID *string, limit, offset i
 	}, nil
 }
 
-// UpdateEndpoint updates an endpoint.
-func (db *DB) UpdateEndpoint(ctx context.Context, endpointID, endpointType, value string) (*Endpoint, error) {
+// UpdateEndpoint updates an endpoint. A nil metadata keeps the endpoint's current metadata,
+// and a nil templateID its current template; an empty templateID reverts to the default layout.
+func (db *DB) UpdateEndpoint(ctx context.Context, endpointID, endpointType, value string, metadata *EndpointMetadata, templateID *string) (*Endpoint, error) {
 	query := `
 		UPDATE endpoints
 		SET type = $2,
 		    value = $3,
+		    metadata = COALESCE($4, metadata),
+		    template_id = CASE WHEN $5::text IS NULL THEN template_id ELSE NULLIF($5, '') END,
 		    updated_at = NOW()
 		WHERE endpoint_id = $1
-		RETURNING endpoint_id, rule_id, type, value, enabled, created_at, updated_at
-	`
-	var endpoint Endpoint
-	err := db.conn.QueryRowContext(ctx, query, endpointID, endpointType, value).Scan(
-		&endpoint.EndpointID,
-		&endpoint.RuleID,
-		&endpoint.Type,
-		&endpoint.Value,
-		&endpoint.Enabled,
-		&endpoint.CreatedAt,
-		&endpoint.UpdatedAt,
-	)
+		RETURNING ` + endpointColumns
+	var metadataArg interface{}
+	if metadata != nil {
+		metadataArg = *metadata
+	}
+	var templateArg sql.NullString
+	if templateID != nil {
+		templateArg = sql.NullString{String: *templateID, Valid: true}
+	}
+	endpoint, err := scanEndpoint(db.conn.QueryRowContext(ctx, query, endpointID, endpointType, value, metadataArg, templateArg))
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("endpoint not found: %s", endpointID)
 	}
 	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" && pqErr.Constraint == endpointTemplateConstraint {
+			return nil, fmt.Errorf("template not found: %s", templateArg.String)
+		}
 		return nil, fmt.Errorf("failed to update endpoint: %w", err)
 	}
-	return &endpoint, nil
+	return endpoint, nil
 }
 
 // ToggleEndpointEnabled toggles the enabled status of an endpoint.
@@ -190,25 +202,15 @@ func (db *DB) ToggleEndpointEnabled(ctx context.Context, endpointID string, enab
 		SET enabled = $2,
 		    updated_at = NOW()
 		WHERE endpoint_id = $1
-		RETURNING endpoint_id, rule_id, type, value, enabled, created_at, updated_at
-	`
-	var endpoint Endpoint
-	err := db.conn.QueryRowContext(ctx, query, endpointID, enabled).Scan(
-		&endpoint.EndpointID,
-		&endpoint.RuleID,
-		&endpoint.Type,
-		&endpoint.Value,
-		&endpoint.Enabled,
-		&endpoint.CreatedAt,
-		&endpoint.UpdatedAt,
-	)
+		RETURNING ` + endpointColumns
+	endpoint, err := scanEndpoint(db.conn.QueryRowContext(ctx, query, endpointID, enabled))
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("endpoint not found: %s", endpointID)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to toggle endpoint enabled: %w", err)
 	}
-	return &endpoint, nil
+	return endpoint, nil
 }
 
 // DeleteEndpoint deletes an endpoint by ID.
@@ -227,3 +229,24 @@ func (db *DB) DeleteEndpoint(ctx context.Context, endpointID string) error {
 	}
 	return nil
 }
+
+// scanEndpoint scans an endpoint from a sql.Row or sql.Rows selecting endpointColumns.
+func scanEndpoint(scanner interface {
+	Scan(dest ...interface{}) error
+}) (*Endpoint, error) {
+	var endpoint Endpoint
+	if err := scanner.Scan(
+		&endpoint.EndpointID,
+		&endpoint.RuleID,
+		&endpoint.Type,
+		&endpoint.Value,
+		&endpoint.Enabled,
+		&endpoint.Metadata,
+		&endpoint.TemplateID,
+		&endpoint.CreatedAt,
+		&endpoint.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &endpoint, nil
+}
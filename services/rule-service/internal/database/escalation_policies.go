@@ -0,0 +1,222 @@
+// Package database provides database operations for clients, rules, and endpoints.
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+const escalationPolicyColumns = `p.policy_id, p.client_id, p.name, p.steps,
+	ARRAY(SELECT r.rule_id FROM rules r WHERE r.escalation_policy_id = p.policy_id ORDER BY r.rule_id),
+	p.created_at, p.updated_at`
+
+// CreateEscalationPolicy creates an escalation policy for a client.
+func (db *DB) CreateEscalationPolicy(ctx context.Context, clientID, name string, steps EscalationSteps) (*EscalationPolicy, error) {
+	query := `
+		WITH p AS (
+			INSERT INTO escalation_policies (client_id, name, steps, created_at, updated_at)
+			VALUES ($1, $2, $3, NOW(), NOW())
+			RETURNING *
+		)
+		SELECT ` + escalationPolicyColumns + ` FROM p`
+	policy, err := scanEscalationPolicy(db.conn.QueryRowContext(ctx, query, clientID, name, steps))
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			if pqErr.Code == "23505" { // unique_violation
+				return nil, fmt.Errorf("escalation policy already exists for client %s with name %s", clientID, name)
+			}
+			if pqErr.Code == "23503" { // foreign_key_violation
+				return nil, fmt.Errorf("client not found: %s", clientID)
+			}
+		}
+		return nil, fmt.Errorf("failed to create escalation policy: %w", err)
+	}
+	return policy, nil
+}
+
+// GetEscalationPolicy retrieves an escalation policy by ID.
+func (db *DB) GetEscalationPolicy(ctx context.Context, policyID string) (*EscalationPolicy, error) {
+	query := `SELECT ` + escalationPolicyColumns + ` FROM escalation_policies p WHERE p.policy_id = $1`
+	policy, err := scanEscalationPolicy(db.conn.QueryRowContext(ctx, query, policyID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("escalation policy not found: %s", policyID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get escalation policy: %w", err)
+	}
+	return policy, nil
+}
+
+// ListEscalationPolicies retrieves escalation policies, optionally filtered by client_id, by name.
+// Policies are few per client, so results are not paginated.
+func (db *DB) ListEscalationPolicies(ctx context.Context, clientID *string) ([]*EscalationPolicy, error) {
+	query := `SELECT ` + escalationPolicyColumns + ` FROM escalation_policies p`
+	var args []interface{}
+	if clientID != nil {
+		query += ` WHERE p.client_id = $1`
+		args = append(args, *clientID)
+	}
+	query += ` ORDER BY p.client_id, p.name`
+
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list escalation policies: %w", err)
+	}
+	defer rows.Close()
+
+	policies := make([]*EscalationPolicy, 0)
+	for rows.Next() {
+		policy, err := scanEscalationPolicy(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan escalation policy: %w", err)
+		}
+		policies = append(policies, policy)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// UpdateEscalationPolicy changes an escalation policy. Nil fields keep their value.
+// Notifications already escalating continue with the new steps from the step they reached.
+func (db *DB) UpdateEscalationPolicy(ctx context.Context, policyID string, name *string, steps *EscalationSteps) (*EscalationPolicy, error) {
+	var stepsArg interface{}
+	if steps != nil {
+		stepsArg = *steps
+	}
+	query := `
+		WITH p AS (
+			UPDATE escalation_policies
+			SET name = COALESCE($2, name),
+			    steps = COALESCE($3::jsonb, steps),
+			    updated_at = NOW()
+			WHERE policy_id = $1
+			RETURNING *
+		)
+		SELECT ` + escalationPolicyColumns + ` FROM p`
+	policy, err := scanEscalationPolicy(db.conn.QueryRowContext(ctx, query, policyID, name, stepsArg))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("escalation policy not found: %s", policyID)
+	}
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" { // unique_violation
+			return nil, fmt.Errorf("escalation policy already exists with name %s", *name)
+		}
+		return nil, fmt.Errorf("failed to update escalation policy: %w", err)
+	}
+	return policy, nil
+}
+
+// DeleteEscalationPolicy deletes an escalation policy by ID. Its rules no longer escalate.
+func (db *DB) DeleteEscalationPolicy(ctx context.Context, policyID string) error {
+	result, err := db.conn.ExecContext(ctx, `DELETE FROM escalation_policies WHERE policy_id = $1`, policyID)
+	if err != nil {
+		return fmt.Errorf("failed to delete escalation policy: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("escalation policy not found: %s", policyID)
+	}
+	return nil
+}
+
+// SetRuleEscalationPolicy links a rule to an escalation policy; an empty policyID unlinks it.
+// It does not change the rule's version, since evaluation does not depend on escalation.
+func (db *DB) SetRuleEscalationPolicy(ctx context.Context, ruleID, policyID string) error {
+	result, err := db.conn.ExecContext(ctx,
+		`UPDATE rules SET escalation_policy_id = NULLIF($2, '') WHERE rule_id = $1`,
+		ruleID, policyID,
+	)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" { // foreign_key_violation
+			return fmt.Errorf("escalation policy not found: %s", policyID)
+		}
+		return fmt.Errorf("failed to set rule escalation policy: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("rule not found: %s", ruleID)
+	}
+	return nil
+}
+
+// GetRuleEscalationPolicyID returns the ID of the escalation policy of a rule, "" if it has none.
+func (db *DB) GetRuleEscalationPolicyID(ctx context.Context, ruleID string) (string, error) {
+	var policyID sql.NullString
+	err := db.conn.QueryRowContext(ctx, `SELECT escalation_policy_id FROM rules WHERE rule_id = $1`, ruleID).Scan(&policyID)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("rule not found: %s", ruleID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get rule escalation policy: %w", err)
+	}
+	return policyID.String, nil
+}
+
+// scanEscalationPolicy scans a policy from a sql.Row or sql.Rows selecting escalationPolicyColumns.
+func scanEscalationPolicy(scanner interface {
+	Scan(dest ...interface{}) error
+}) (*EscalationPolicy, error) {
+	var policy EscalationPolicy
+	if err := scanner.Scan(
+		&policy.PolicyID,
+		&policy.ClientID,
+		&policy.Name,
+		&policy.Steps,
+		pq.Array(&policy.RuleIDs),
+		&policy.CreatedAt,
+		&policy.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if policy.RuleIDs == nil {
+		policy.RuleIDs = []string{}
+	}
+	return &policy, nil
+}
+
+// Value implements driver.Valuer for the JSONB steps column.
+func (s EscalationSteps) Value() (driver.Value, error) {
+	if s == nil {
+		s = EscalationSteps{}
+	}
+	data, err := json.Marshal([]EscalationStep(s))
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner for the JSONB steps column.
+func (s *EscalationSteps) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*s = EscalationSteps{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported escalation steps type %T", src)
+	}
+	steps := EscalationSteps{}
+	if err := json.Unmarshal(data, (*[]EscalationStep)(&steps)); err != nil {
+		return err
+	}
+	*s = steps
+	return nil
+}
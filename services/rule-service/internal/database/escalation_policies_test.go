@@ -0,0 +1,111 @@
+// Package database provides tests for escalation policy operations.
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var escalationPolicyRowColumns = []string{"policy_id", "client_id", "name", "steps", "rule_ids", "created_at", "updated_at"}
+
+// TestDB_CreateEscalationPolicy tests that steps are stored as JSON and read back.
+func TestDB_CreateEscalationPolicy(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	d := &DB{conn: db}
+	now := time.Now()
+	stepsJSON := `[{"delay_seconds":900,"endpoint_ids":["ep-1"]},{"delay_seconds":1800,"endpoint_ids":["ep-2"]}]`
+
+	mock.ExpectQuery("INSERT INTO escalation_policies").
+		WithArgs("client-1", "on-call", stepsJSON).
+		WillReturnRows(sqlmock.NewRows(escalationPolicyRowColumns).
+			AddRow("policy-1", "client-1", "on-call", []byte(stepsJSON), "{}", now, now))
+
+	policy, err := d.CreateEscalationPolicy(context.Background(), "client-1", "on-call", EscalationSteps{
+		{DelaySeconds: 900, EndpointIDs: []string{"ep-1"}},
+		{DelaySeconds: 1800, EndpointIDs: []string{"ep-2"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateEscalationPolicy() error = %v", err)
+	}
+	if len(policy.Steps) != 2 || policy.Steps[1].DelaySeconds != 1800 || policy.Steps[1].EndpointIDs[0] != "ep-2" {
+		t.Errorf("steps = %+v, want the two stored steps", policy.Steps)
+	}
+	if policy.RuleIDs == nil || len(policy.RuleIDs) != 0 {
+		t.Errorf("RuleIDs = %v, want empty", policy.RuleIDs)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Mock expectations were not met: %v", err)
+	}
+}
+
+// TestDB_GetEscalationPolicy tests reading a policy and the rules using it.
+func TestDB_GetEscalationPolicy(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	d := &DB{conn: db}
+	now := time.Now()
+
+	mock.ExpectQuery("FROM escalation_policies p WHERE p.policy_id").
+		WithArgs("policy-1").
+		WillReturnRows(sqlmock.NewRows(escalationPolicyRowColumns).
+			AddRow("policy-1", "client-1", "on-call", []byte(`[{"delay_seconds":900,"endpoint_ids":["ep-1"]}]`), "{rule-1,rule-2}", now, now))
+	mock.ExpectQuery("FROM escalation_policies p WHERE p.policy_id").
+		WithArgs("missing").
+		WillReturnRows(sqlmock.NewRows(escalationPolicyRowColumns))
+
+	policy, err := d.GetEscalationPolicy(context.Background(), "policy-1")
+	if err != nil {
+		t.Fatalf("GetEscalationPolicy() error = %v", err)
+	}
+	if len(policy.RuleIDs) != 2 || policy.RuleIDs[1] != "rule-2" {
+		t.Errorf("RuleIDs = %v, want [rule-1 rule-2]", policy.RuleIDs)
+	}
+
+	if _, err := d.GetEscalationPolicy(context.Background(), "missing"); err == nil || err.Error() != "escalation policy not found: missing" {
+		t.Errorf("GetEscalationPolicy(missing) error = %v, want not found", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Mock expectations were not met: %v", err)
+	}
+}
+
+// TestDB_SetRuleEscalationPolicy tests linking and unlinking a rule.
+func TestDB_SetRuleEscalationPolicy(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	d := &DB{conn: db}
+	ctx := context.Background()
+
+	mock.ExpectExec("UPDATE rules SET escalation_policy_id").
+		WithArgs("rule-1", "policy-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE rules SET escalation_policy_id").
+		WithArgs("missing", "").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err := d.SetRuleEscalationPolicy(ctx, "rule-1", "policy-1"); err != nil {
+		t.Errorf("SetRuleEscalationPolicy() error = %v", err)
+	}
+	if err := d.SetRuleEscalationPolicy(ctx, "missing", ""); err == nil || err.Error() != "rule not found: missing" {
+		t.Errorf("SetRuleEscalationPolicy(missing) error = %v, want rule not found", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Mock expectations were not met: %v", err)
+	}
+}
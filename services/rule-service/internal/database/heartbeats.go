@@ -0,0 +1,173 @@
+// Package database provides database operations for clients, rules, and endpoints.
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+const heartbeatColumns = `heartbeat_id, client_id, interval_seconds, severity, source, name, last_ping_at, alerted_at, created_at, updated_at`
+
+// PingHeartbeat records a ping for a heartbeat, creating it on first ping.
+// Each ping resets the missed state so the heartbeat can alert again after its next miss.
+// Returns an error if the heartbeat exists but belongs to a different client.
+func (db *DB) PingHeartbeat(ctx context.Context, heartbeatID, clientID string, intervalSeconds int, severity, source, name string) (*Heartbeat, error) {
+	query := `
+		INSERT INTO heartbeats (heartbeat_id, client_id, interval_seconds, severity, source, name, last_ping_at, alerted_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW(), NULL, NOW(), NOW())
+		ON CONFLICT (heartbeat_id) DO UPDATE
+		SET interval_seconds = EXCLUDED.interval_seconds,
+		    severity = EXCLUDED.severity,
+		    source = EXCLUDED.source,
+		    name = EXCLUDED.name,
+		    last_ping_at = NOW(),
+		    alerted_at = NULL,
+		    updated_at = NOW()
+		WHERE heartbeats.client_id = EXCLUDED.client_id
+		RETURNING ` + heartbeatColumns
+
+	row := db.conn.QueryRowContext(ctx, query, heartbeatID, clientID, intervalSeconds, severity, source, name)
+	hb, err := scanHeartbeat(row)
+	if err == sql.ErrNoRows {
+		// Conflict on heartbeat_id but the WHERE clause rejected the update: owned by another client
+		return nil, fmt.Errorf("heartbeat already exists for another client: %s", heartbeatID)
+	}
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" { // foreign_key_violation
+			return nil, fmt.Errorf("client not found: %s", clientID)
+		}
+		return nil, fmt.Errorf("failed to ping heartbeat: %w", err)
+	}
+	return hb, nil
+}
+
+// GetHeartbeat retrieves a heartbeat by ID.
+func (db *DB) GetHeartbeat(ctx context.Context, heartbeatID string) (*Heartbeat, error) {
+	query := `SELECT ` + heartbeatColumns + ` FROM heartbeats WHERE heartbeat_id = $1`
+	hb, err := scanHeartbeat(db.conn.QueryRowContext(ctx, query, heartbeatID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("heartbeat not found: %s", heartbeatID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get heartbeat: %w", err)
+	}
+	return hb, nil
+}
+
+// ListHeartbeats retrieves heartbeats, optionally filtered by client_id.
+// Heartbeats are few per client, so results are not paginated.
+func (db *DB) ListHeartbeats(ctx context.Context, clientID *string) ([]*Heartbeat, error) {
+	query := `SELECT ` + heartbeatColumns + ` FROM heartbeats`
+	var args []interface{}
+	if clientID != nil {
+		query += ` WHERE client_id = $1`
+		args = append(args, *clientID)
+	}
+	query += ` ORDER BY created_at DESC`
+
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list heartbeats: %w", err)
+	}
+	defer rows.Close()
+
+	heartbeats := make([]*Heartbeat, 0)
+	for rows.Next() {
+		hb, err := scanHeartbeat(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan heartbeat: %w", err)
+		}
+		heartbeats = append(heartbeats, hb)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return heartbeats, nil
+}
+
+// DeleteHeartbeat deletes a heartbeat by ID.
+func (db *DB) DeleteHeartbeat(ctx context.Context, heartbeatID string) error {
+	result, err := db.conn.ExecContext(ctx, `DELETE FROM heartbeats WHERE heartbeat_id = $1`, heartbeatID)
+	if err != nil {
+		return fmt.Errorf("failed to delete heartbeat: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("heartbeat not found: %s", heartbeatID)
+	}
+	return nil
+}
+
+// ClaimMissedHeartbeats atomically marks every overdue heartbeat as alerted and returns them.
+// A heartbeat is overdue when no ping arrived within interval_seconds of the last one and it
+// has not already alerted since that ping. The single UPDATE makes the claim safe across
+// multiple rule-service instances: each missed ping is returned to exactly one caller.
+func (db *DB) ClaimMissedHeartbeats(ctx context.Context) ([]*Heartbeat, error) {
+	query := `
+		UPDATE heartbeats
+		SET alerted_at = NOW()
+		WHERE alerted_at IS NULL
+		  AND last_ping_at + interval_seconds * INTERVAL '1 second' < NOW()
+		RETURNING ` + heartbeatColumns
+
+	rows, err := db.conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim missed heartbeats: %w", err)
+	}
+	defer rows.Close()
+
+	missed := make([]*Heartbeat, 0)
+	for rows.Next() {
+		hb, err := scanHeartbeat(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan heartbeat: %w", err)
+		}
+		missed = append(missed, hb)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return missed, nil
+}
+
+// ReleaseHeartbeatClaim clears the alerted state of a heartbeat so the next scan retries it.
+// Used when publishing the synthetic alert fails after the claim.
+func (db *DB) ReleaseHeartbeatClaim(ctx context.Context, heartbeatID string) error {
+	_, err := db.conn.ExecContext(ctx, `UPDATE heartbeats SET alerted_at = NULL WHERE heartbeat_id = $1`, heartbeatID)
+	if err != nil {
+		return fmt.Errorf("failed to release heartbeat claim: %w", err)
+	}
+	return nil
+}
+
+// scanHeartbeat scans a heartbeat from a sql.Row or sql.Rows into a Heartbeat struct.
+func scanHeartbeat(scanner interface {
+	Scan(dest ...interface{}) error
+}) (*Heartbeat, error) {
+	var hb Heartbeat
+	var alertedAt sql.NullTime
+	if err := scanner.Scan(
+		&hb.HeartbeatID,
+		&hb.ClientID,
+		&hb.IntervalSeconds,
+		&hb.Severity,
+		&hb.Source,
+		&hb.Name,
+		&hb.LastPingAt,
+		&alertedAt,
+		&hb.CreatedAt,
+		&hb.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if alertedAt.Valid {
+		hb.AlertedAt = &alertedAt.Time
+	}
+	return &hb, nil
+}
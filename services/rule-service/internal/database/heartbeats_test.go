@@ -0,0 +1,120 @@
+// Package database provides tests for heartbeat database operations.
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+)
+
+var heartbeatRowColumns = []string{
+	"heartbeat_id", "client_id", "interval_seconds", "severity", "source", "name",
+	"last_ping_at", "alerted_at", "created_at", "updated_at",
+}
+
+// TestDB_PingHeartbeat tests the PingHeartbeat method.
+func TestDB_PingHeartbeat(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	d := &DB{conn: db}
+	ctx := context.Background()
+	now := time.Now()
+
+	t.Run("successful ping", func(t *testing.T) {
+		mock.ExpectQuery("INSERT INTO heartbeats").
+			WithArgs("hb-1", "client-1", 60, "HIGH", "heartbeat", "hb-1").
+			WillReturnRows(sqlmock.NewRows(heartbeatRowColumns).
+				AddRow("hb-1", "client-1", 60, "HIGH", "heartbeat", "hb-1", now, nil, now, now))
+
+		hb, err := d.PingHeartbeat(ctx, "hb-1", "client-1", 60, "HIGH", "heartbeat", "hb-1")
+		if err != nil {
+			t.Fatalf("PingHeartbeat() error = %v", err)
+		}
+		if hb.HeartbeatID != "hb-1" || hb.AlertedAt != nil {
+			t.Errorf("PingHeartbeat() = %+v", hb)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Mock expectations were not met: %v", err)
+		}
+	})
+
+	t.Run("owned by another client", func(t *testing.T) {
+		mock.ExpectQuery("INSERT INTO heartbeats").
+			WithArgs("hb-1", "client-2", 60, "HIGH", "heartbeat", "hb-1").
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := d.PingHeartbeat(ctx, "hb-1", "client-2", 60, "HIGH", "heartbeat", "hb-1")
+		if err == nil || !contains(err.Error(), "already exists") {
+			t.Errorf("PingHeartbeat() error = %v, want 'already exists'", err)
+		}
+	})
+
+	t.Run("client not found", func(t *testing.T) {
+		mock.ExpectQuery("INSERT INTO heartbeats").
+			WithArgs("hb-1", "missing", 60, "HIGH", "heartbeat", "hb-1").
+			WillReturnError(&pq.Error{Code: "23503"})
+
+		_, err := d.PingHeartbeat(ctx, "hb-1", "missing", 60, "HIGH", "heartbeat", "hb-1")
+		if err == nil || !contains(err.Error(), "client not found") {
+			t.Errorf("PingHeartbeat() error = %v, want 'client not found'", err)
+		}
+	})
+}
+
+// TestDB_ClaimMissedHeartbeats tests the ClaimMissedHeartbeats method.
+func TestDB_ClaimMissedHeartbeats(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	d := &DB{conn: db}
+	now := time.Now()
+
+	mock.ExpectQuery("UPDATE heartbeats\\s+SET alerted_at = NOW\\(\\)").
+		WillReturnRows(sqlmock.NewRows(heartbeatRowColumns).
+			AddRow("hb-1", "client-1", 60, "HIGH", "heartbeat", "hb-1", now.Add(-time.Hour), now, now, now))
+
+	missed, err := d.ClaimMissedHeartbeats(context.Background())
+	if err != nil {
+		t.Fatalf("ClaimMissedHeartbeats() error = %v", err)
+	}
+	if len(missed) != 1 || missed[0].AlertedAt == nil {
+		t.Errorf("ClaimMissedHeartbeats() = %+v, want one alerted heartbeat", missed)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Mock expectations were not met: %v", err)
+	}
+}
+
+// TestDB_DeleteHeartbeat tests the DeleteHeartbeat method.
+func TestDB_DeleteHeartbeat(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	d := &DB{conn: db}
+
+	mock.ExpectExec("DELETE FROM heartbeats").
+		WithArgs("hb-999").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = d.DeleteHeartbeat(context.Background(), "hb-999")
+	if err == nil || !contains(err.Error(), "heartbeat not found") {
+		t.Errorf("DeleteHeartbeat() error = %v, want 'heartbeat not found'", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Mock expectations were not met: %v", err)
+	}
+}
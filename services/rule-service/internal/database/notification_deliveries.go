@@ -0,0 +1,40 @@
+// Package database provides database operations for clients, rules, and endpoints.
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// ListNotificationDeliveries retrieves the endpoint deliveries of a notification in the order they were recorded.
+// Returns an empty slice if the notification has no deliveries yet.
+func (db *DB) ListNotificationDeliveries(ctx context.Context, notificationID string) ([]*NotificationDelivery, error) {
+	query := `
+		SELECT delivery_id, notification_id, endpoint_id, channel, status, error, latency_ms, attempts, created_at
+		FROM notification_deliveries
+		WHERE notification_id = $1
+		ORDER BY delivery_id ASC
+	`
+	rows, err := db.conn.QueryContext(ctx, query, notificationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notification deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]*NotificationDelivery, 0)
+	for rows.Next() {
+		var d NotificationDelivery
+		var endpointID, errText sql.NullString
+		if err := rows.Scan(&d.DeliveryID, &d.NotificationID, &endpointID, &d.Channel, &d.Status, &errText, &d.LatencyMs, &d.Attempts, &d.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan notification delivery: %w", err)
+		}
+		d.EndpointID = endpointID.String
+		d.Error = errText.String
+		deliveries = append(deliveries, &d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
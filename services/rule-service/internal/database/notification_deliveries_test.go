@@ -0,0 +1,45 @@
+// Package database provides tests for notification delivery database operations.
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// TestDB_ListNotificationDeliveries tests the ListNotificationDeliveries method.
+func TestDB_ListNotificationDeliveries(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	d := &DB{conn: db}
+	now := time.Now()
+
+	mock.ExpectQuery("FROM notification_deliveries").
+		WithArgs("notif-1").
+		WillReturnRows(sqlmock.NewRows([]string{"delivery_id", "notification_id", "endpoint_id", "channel", "status", "error", "latency_ms", "attempts", "created_at"}).
+			AddRow(int64(1), "notif-1", "ep-1", "email", "SENT", nil, 120, 1, now).
+			AddRow(int64(2), "notif-1", nil, "slack", "FAILED", "channel_not_found", 40, 3, now))
+
+	deliveries, err := d.ListNotificationDeliveries(context.Background(), "notif-1")
+	if err != nil {
+		t.Fatalf("ListNotificationDeliveries() error = %v", err)
+	}
+	if len(deliveries) != 2 {
+		t.Fatalf("ListNotificationDeliveries() returned %d deliveries, want 2", len(deliveries))
+	}
+	if deliveries[0].EndpointID != "ep-1" || deliveries[0].Status != "SENT" || deliveries[0].Error != "" || deliveries[0].LatencyMs != 120 {
+		t.Errorf("deliveries[0] = %+v", deliveries[0])
+	}
+	if deliveries[1].EndpointID != "" || deliveries[1].Error != "channel_not_found" || deliveries[1].Attempts != 3 {
+		t.Errorf("deliveries[1] = %+v", deliveries[1])
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Mock expectations were not met: %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+// Package database provides database operations for clients, rules, and endpoints.
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// ListNotificationEvents retrieves the journal of a notification in the order events were recorded.
+// Returns an empty slice if the notification has no events.
+func (db *DB) ListNotificationEvents(ctx context.Context, notificationID string) ([]*NotificationEvent, error) {
+	query := `
+		SELECT event_id, notification_id, service, event_type, endpoint_type, error, actor, note, created_at
+		FROM notification_events
+		WHERE notification_id = $1
+		ORDER BY event_id ASC
+	`
+	rows, err := db.conn.QueryContext(ctx, query, notificationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notification events: %w", err)
+	}
+	defer rows.Close()
+
+	events := make([]*NotificationEvent, 0)
+	for rows.Next() {
+		var e NotificationEvent
+		var endpointType, errText, actor, note sql.NullString
+		if err := rows.Scan(&e.EventID, &e.NotificationID, &e.Service, &e.EventType, &endpointType, &errText, &actor, &note, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan notification event: %w", err)
+		}
+		e.EndpointType = endpointType.String
+		e.Error = errText.String
+		e.Actor = actor.String
+		e.Note = note.String
+		events = append(events, &e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
@@ -0,0 +1,49 @@
+// Package database provides tests for notification journal database operations.
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// TestDB_ListNotificationEvents tests the ListNotificationEvents method.
+func TestDB_ListNotificationEvents(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	d := &DB{conn: db}
+	now := time.Now()
+
+	mock.ExpectQuery("FROM notification_events").
+		WithArgs("notif-1").
+		WillReturnRows(sqlmock.NewRows([]string{"event_id", "notification_id", "service", "event_type", "endpoint_type", "error", "actor", "note", "created_at"}).
+			AddRow(int64(1), "notif-1", "aggregator", "created", nil, nil, nil, nil, now).
+			AddRow(int64(2), "notif-1", "sender", "send_attempt", "webhook", "timeout", nil, nil, now).
+			AddRow(int64(3), "notif-1", "rule-service", "acknowledged", nil, nil, "alice", "looking into it", now))
+
+	events, err := d.ListNotificationEvents(context.Background(), "notif-1")
+	if err != nil {
+		t.Fatalf("ListNotificationEvents() error = %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("ListNotificationEvents() returned %d events, want 3", len(events))
+	}
+	if events[0].EndpointType != "" || events[0].Error != "" {
+		t.Errorf("events[0] = %+v, want no endpoint or error", events[0])
+	}
+	if events[1].EndpointType != "webhook" || events[1].Error != "timeout" {
+		t.Errorf("events[1] = %+v, want webhook/timeout", events[1])
+	}
+	if events[0].Actor != "" || events[2].Actor != "alice" || events[2].Note != "looking into it" {
+		t.Errorf("actors = %q/%q, want only events[2] by alice with a note", events[0].Actor, events[2].Actor)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Mock expectations were not met: %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+// Package database provides database operations for clients, rules, and endpoints.
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// NotificationExportFilter selects the notifications of one client created in [From, To).
+// Limit 0 means no limit.
+type NotificationExportFilter struct {
+	ClientID string
+	From     time.Time
+	To       time.Time
+	Statuses []string // matches any; empty means all statuses
+	Limit    int
+	Offset   int
+}
+
+// ExportNotifications streams the notifications matching filter to fn, oldest first, without
+// loading them into memory. Iteration stops at the first error returned by fn.
+func (db *DB) ExportNotifications(ctx context.Context, filter NotificationExportFilter, fn func(*Notification) error) error {
+	query := `
+		SELECT notification_id, client_id, alert_id, severity, source, name, context, rule_ids, status, created_at, updated_at
+		FROM notifications
+		WHERE client_id = $1 AND created_at >= $2 AND created_at < $3
+	`
+	args := []interface{}{filter.ClientID, filter.From, filter.To}
+	if len(filter.Statuses) > 0 {
+		args = append(args, pq.Array(filter.Statuses))
+		query += fmt.Sprintf(" AND status = ANY($%d)", len(args))
+	}
+	query += " ORDER BY created_at, notification_id"
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if filter.Offset > 0 {
+		args = append(args, filter.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to export notifications: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var notif Notification
+		var contextJSON sql.NullString
+		if err := rows.Scan(
+			&notif.NotificationID,
+			&notif.ClientID,
+			&notif.AlertID,
+			&notif.Severity,
+			&notif.Source,
+			&notif.Name,
+			&contextJSON,
+			pq.Array(&notif.RuleIDs),
+			&notif.Status,
+			&notif.CreatedAt,
+			&notif.UpdatedAt,
+		); err != nil {
+			return fmt.Errorf("failed to scan notification: %w", err)
+		}
+
+		notif.Context = unmarshalNotificationContext(contextJSON, "notification_id", notif.NotificationID)
+		if err := fn(&notif); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to export notifications: %w", err)
+	}
+	return nil
+}
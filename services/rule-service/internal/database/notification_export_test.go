@@ -0,0 +1,80 @@
+// Package database provides tests for notification export database operations.
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+)
+
+// TestDB_ExportNotifications tests that ExportNotifications streams rows to the callback.
+func TestDB_ExportNotifications(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	d := &DB{conn: db}
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	columns := []string{"notification_id", "client_id", "alert_id", "severity", "source", "name", "context", "rule_ids", "status", "created_at", "updated_at"}
+
+	mock.ExpectQuery(`WHERE client_id = \$1 AND created_at >= \$2 AND created_at < \$3 AND status = ANY\(\$4\) ORDER BY created_at, notification_id LIMIT \$5 OFFSET \$6`).
+		WithArgs("client-1", from, to, pq.Array([]string{"SENT"}), 2, 10).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("notif-1", "client-1", "alert-1", "HIGH", "api", "timeout", `{"k":"v"}`, pq.Array([]string{"rule-1"}), "SENT", from, from).
+			AddRow("notif-2", "client-1", "alert-2", "LOW", "db", "slow", nil, pq.Array([]string{"rule-2"}), "SENT", from, from))
+
+	var ids []string
+	err = d.ExportNotifications(context.Background(), NotificationExportFilter{
+		ClientID: "client-1", From: from, To: to, Statuses: []string{"SENT"}, Limit: 2, Offset: 10,
+	}, func(n *Notification) error {
+		ids = append(ids, n.NotificationID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ExportNotifications() error = %v", err)
+	}
+	if len(ids) != 2 || ids[0] != "notif-1" {
+		t.Errorf("ExportNotifications() streamed %v, want notif-1, notif-2", ids)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Mock expectations were not met: %v", err)
+	}
+}
+
+// TestDB_ExportNotifications_CallbackError tests that a callback error stops the export.
+func TestDB_ExportNotifications_CallbackError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	d := &DB{conn: db}
+	now := time.Now()
+	columns := []string{"notification_id", "client_id", "alert_id", "severity", "source", "name", "context", "rule_ids", "status", "created_at", "updated_at"}
+
+	mock.ExpectQuery(`ORDER BY created_at, notification_id$`).
+		WithArgs("client-1", now.Add(-time.Hour), now).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("notif-1", "client-1", "alert-1", "HIGH", "api", "timeout", nil, pq.Array([]string{}), "SENT", now, now).
+			AddRow("notif-2", "client-1", "alert-2", "HIGH", "api", "timeout", nil, pq.Array([]string{}), "SENT", now, now))
+
+	writeErr := errors.New("client went away")
+	calls := 0
+	err = d.ExportNotifications(context.Background(), NotificationExportFilter{
+		ClientID: "client-1", From: now.Add(-time.Hour), To: now,
+	}, func(n *Notification) error {
+		calls++
+		return writeErr
+	})
+	if !errors.Is(err, writeErr) || calls != 1 {
+		t.Errorf("ExportNotifications() error = %v after %d calls, want the callback error after 1", err, calls)
+	}
+}
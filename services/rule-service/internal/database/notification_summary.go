@@ -0,0 +1,66 @@
+// Package database provides database operations for clients, rules, and endpoints.
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// NotificationSummary counts the notifications created since a time, by status and by severity.
+type NotificationSummary struct {
+	ClientID   string           `json:"client_id,omitempty"`
+	Since      time.Time        `json:"since"`
+	Total      int64            `json:"total"`
+	ByStatus   map[string]int64 `json:"by_status"`
+	BySeverity map[string]int64 `json:"by_severity"`
+}
+
+// GetNotificationSummary counts the notifications of a client (all clients if clientID is nil)
+// created since the start of the hour of since, from the notification_counts table kept by
+// trigger, so no notification is scanned. Notifications without a severity count under "".
+func (db *DB) GetNotificationSummary(ctx context.Context, clientID *string, since time.Time) (*NotificationSummary, error) {
+	since = since.UTC().Truncate(time.Hour)
+	query := `
+		SELECT status, severity, SUM(count)
+		FROM notification_counts
+		WHERE bucket >= $1`
+	args := []interface{}{since}
+	if clientID != nil {
+		query += ` AND client_id = $2`
+		args = append(args, *clientID)
+	}
+	query += `
+		GROUP BY status, severity
+		HAVING SUM(count) > 0`
+
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification summary: %w", err)
+	}
+	defer rows.Close()
+
+	summary := &NotificationSummary{
+		Since:      since,
+		ByStatus:   make(map[string]int64),
+		BySeverity: make(map[string]int64),
+	}
+	if clientID != nil {
+		summary.ClientID = *clientID
+	}
+	for rows.Next() {
+		var status, severity string
+		var count int64
+		if err := rows.Scan(&status, &severity, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan notification summary: %w", err)
+		}
+		summary.Total += count
+		summary.ByStatus[status] += count
+		summary.BySeverity[severity] += count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get notification summary: %w", err)
+	}
+
+	return summary, nil
+}
@@ -0,0 +1,74 @@
+// Package database provides tests for notification summary database operations.
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// TestDB_GetNotificationSummary tests the GetNotificationSummary method.
+func TestDB_GetNotificationSummary(t *testing.T) {
+	t.Run("client counts by status and severity", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("Failed to create mock: %v", err)
+		}
+		defer db.Close()
+		d := &DB{conn: db}
+
+		since := time.Date(2026, 3, 1, 10, 45, 0, 0, time.UTC)
+		clientID := "client-1"
+		mock.ExpectQuery(`FROM notification_counts\s+WHERE bucket >= \$1 AND client_id = \$2\s+GROUP BY status, severity`).
+			WithArgs(time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC), clientID).
+			WillReturnRows(sqlmock.NewRows([]string{"status", "severity", "sum"}).
+				AddRow("SENT", "HIGH", int64(5)).
+				AddRow("SENT", "LOW", int64(2)).
+				AddRow("FAILED", "HIGH", int64(1)))
+
+		summary, err := d.GetNotificationSummary(context.Background(), &clientID, since)
+		if err != nil {
+			t.Fatalf("GetNotificationSummary() error = %v", err)
+		}
+		if summary.Total != 8 || summary.ClientID != clientID {
+			t.Errorf("GetNotificationSummary() total = %d client = %q, want 8 for client-1", summary.Total, summary.ClientID)
+		}
+		if summary.ByStatus["SENT"] != 7 || summary.ByStatus["FAILED"] != 1 {
+			t.Errorf("ByStatus = %v, want SENT 7 and FAILED 1", summary.ByStatus)
+		}
+		if summary.BySeverity["HIGH"] != 6 || summary.BySeverity["LOW"] != 2 {
+			t.Errorf("BySeverity = %v, want HIGH 6 and LOW 2", summary.BySeverity)
+		}
+		if !summary.Since.Equal(time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)) {
+			t.Errorf("Since = %v, want the start of the hour", summary.Since)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Mock expectations were not met: %v", err)
+		}
+	})
+
+	t.Run("all clients, no notifications", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("Failed to create mock: %v", err)
+		}
+		defer db.Close()
+		d := &DB{conn: db}
+
+		mock.ExpectQuery(`FROM notification_counts\s+WHERE bucket >= \$1\s+GROUP BY`).
+			WillReturnRows(sqlmock.NewRows([]string{"status", "severity", "sum"}))
+
+		summary, err := d.GetNotificationSummary(context.Background(), nil, time.Now())
+		if err != nil {
+			t.Fatalf("GetNotificationSummary() error = %v", err)
+		}
+		if summary.Total != 0 || summary.ByStatus == nil || summary.BySeverity == nil {
+			t.Errorf("GetNotificationSummary() = %+v, want zero total and empty maps", summary)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Mock expectations were not met: %v", err)
+		}
+	})
+}
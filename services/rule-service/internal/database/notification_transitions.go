@@ -0,0 +1,76 @@
+// Package database provides database operations for clients, rules, and endpoints.
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+	"github.com/lib/pq"
+)
+
+// ErrInvalidTransition is returned when a notification cannot move to the requested status from
+// its current one, e.g. acknowledging a resolved notification.
+var ErrInvalidTransition = errors.New("invalid notification status transition")
+
+// Journal event types of the manual transitions made through rule-service.
+const (
+	EventAcknowledged = "acknowledged"
+	EventResolved     = "resolved"
+)
+
+// AcknowledgeNotification marks a notification ACKNOWLEDGED and records actor and note in its journal.
+// Acknowledged notifications get no more reminders; the acknowledgement time is stamped by a trigger.
+func (db *DB) AcknowledgeNotification(ctx context.Context, notificationID, actor, note string) (*Notification, error) {
+	return db.transitionNotification(ctx, notificationID, shared.NotificationAcknowledged, EventAcknowledged, actor, note)
+}
+
+// ResolveNotification marks a notification RESOLVED and records actor and note in its journal.
+// RESOLVED is terminal; the resolution time is stamped by a trigger.
+func (db *DB) ResolveNotification(ctx context.Context, notificationID, actor, note string) (*Notification, error) {
+	return db.transitionNotification(ctx, notificationID, shared.NotificationResolved, EventResolved, actor, note)
+}
+
+// transitionNotification moves a notification to status if its current status allows it, and
+// journals the transition in the same transaction. Returns ErrInvalidTransition otherwise.
+func (db *DB) transitionNotification(ctx context.Context, notificationID string, status shared.NotificationStatus, eventType, actor, note string) (*Notification, error) {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE notifications
+		SET status = $2, updated_at = NOW()
+		WHERE notification_id = $1 AND status = ANY($3)
+	`, notificationID, status.String(), pq.Array(status.Predecessors()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update notification status: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		var current string
+		err := tx.QueryRowContext(ctx, `SELECT status FROM notifications WHERE notification_id = $1`, notificationID).Scan(&current)
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("notification not found: %s", notificationID)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get notification status: %w", err)
+		}
+		return nil, fmt.Errorf("%w: %s to %s", ErrInvalidTransition, current, status)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO notification_events (notification_id, service, event_type, actor, note)
+		VALUES ($1, 'rule-service', $2, $3, NULLIF($4, ''))
+	`, notificationID, eventType, actor, note); err != nil {
+		return nil, fmt.Errorf("failed to record notification event: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return db.GetNotification(ctx, notificationID)
+}
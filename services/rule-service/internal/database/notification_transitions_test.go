@@ -0,0 +1,95 @@
+// Package database provides tests for manual notification transitions.
+package database
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// TestDB_AcknowledgeNotification tests that an acknowledgement updates the status and is journaled.
+func TestDB_AcknowledgeNotification(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	d := &DB{conn: db}
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE notifications").
+		WithArgs("notif-1", "ACKNOWLEDGED", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO notification_events").
+		WithArgs("notif-1", EventAcknowledged, "alice", "looking into it").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery("SELECT notification_id").
+		WithArgs("notif-1").
+		WillReturnRows(sqlmock.NewRows([]string{"notification_id", "client_id", "alert_id", "severity", "source", "name", "context", "rule_ids", "status", "created_at", "updated_at"}).
+			AddRow("notif-1", "client-1", "alert-1", "HIGH", "payments", "timeout", nil, "{rule-1}", "ACKNOWLEDGED", now, now))
+
+	notification, err := d.AcknowledgeNotification(context.Background(), "notif-1", "alice", "looking into it")
+	if err != nil {
+		t.Fatalf("AcknowledgeNotification() error = %v", err)
+	}
+	if notification.Status != "ACKNOWLEDGED" {
+		t.Errorf("AcknowledgeNotification() status = %q, want ACKNOWLEDGED", notification.Status)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Mock expectations were not met: %v", err)
+	}
+}
+
+// TestDB_ResolveNotification_Rejected tests the errors returned when nothing was updated.
+func TestDB_ResolveNotification_Rejected(t *testing.T) {
+	tests := []struct {
+		name      string
+		current   string // empty if the notification does not exist
+		wantErr   error
+		wantInMsg string
+	}{
+		{name: "already resolved", current: "RESOLVED", wantErr: ErrInvalidTransition, wantInMsg: "RESOLVED to RESOLVED"},
+		{name: "missing", wantInMsg: "not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("Failed to create mock: %v", err)
+			}
+			defer db.Close()
+
+			d := &DB{conn: db}
+
+			mock.ExpectBegin()
+			mock.ExpectExec("UPDATE notifications").
+				WithArgs("notif-1", "RESOLVED", sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			rows := sqlmock.NewRows([]string{"status"})
+			if tt.current != "" {
+				rows.AddRow(tt.current)
+			}
+			mock.ExpectQuery("SELECT status FROM notifications").WithArgs("notif-1").WillReturnRows(rows)
+			mock.ExpectRollback()
+
+			_, err = d.ResolveNotification(context.Background(), "notif-1", "alice", "")
+			if err == nil || !strings.Contains(err.Error(), tt.wantInMsg) {
+				t.Fatalf("ResolveNotification() error = %v, want it to contain %q", err, tt.wantInMsg)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("ResolveNotification() error = %v, want %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("Mock expectations were not met: %v", err)
+			}
+		})
+	}
+}
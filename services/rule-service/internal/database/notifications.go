@@ -51,9 +51,10 @@ type NotificationListResult struct {
 	Offset        int             `json:"offset"`
 }
 
-// ListNotifications retrieves notifications with pagination, optionally filtered by client_id or status.
+// ListNotifications retrieves notifications with pagination, optionally filtered by client_id and
+// by statuses (a notification matches if it has any of them; empty means all statuses).
 // Default limit is 50, max limit is 200.
-func (db *DB) ListNotifications(ctx context.Context, clientID *string, status *string, limit, offset int) (*NotificationListResult, error) {
+func (db *DB) ListNotifications(ctx context.Context, clientID *string, statuses []string, limit, offset int) (*NotificationListResult, error) {
 	// Apply default and max limits
 	if limit <= 0 {
 		limit = 50
@@ -75,9 +76,9 @@ func (db *DB) ListNotifications(ctx context.Context, clientID *string, status *s
 		args = append(args, *clientID)
 		argIndex++
 	}
-	if status != nil {
-		whereClauses = append(whereClauses, fmt.Sprintf("status = $%d", argIndex))
-		args = append(args, *status)
+	if len(statuses) > 0 {
+		whereClauses = append(whereClauses, fmt.Sprintf("status = ANY($%d)", argIndex))
+		args = append(args, pq.Array(statuses))
 		argIndex++
 	}
 
@@ -0,0 +1,54 @@
+// Package database provides database operations for clients, rules, and endpoints.
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// GetRuleImpact counts, per UTC day since the given time, the alerts a rule with the given
+// criteria would have matched ("*" matches any value), and how many of them the client was not
+// already notified about. The notifications table is the alert history: every alert that matched
+// at least one rule is recorded there, so alerts that matched no rule at all are not counted.
+// Days without matching alerts are omitted.
+func (db *DB) GetRuleImpact(ctx context.Context, clientID, severity, source, name string, since time.Time) ([]*RuleImpactDay, error) {
+	query := `
+		WITH matched AS (
+			SELECT alert_id, MIN(created_at) AS first_seen
+			FROM notifications
+			WHERE created_at >= $1
+			  AND ($2 = '*' OR severity = $2)
+			  AND ($3 = '*' OR source = $3)
+			  AND ($4 = '*' OR name = $4)
+			GROUP BY alert_id
+		)
+		SELECT date_trunc('day', m.first_seen) AS day,
+		       COUNT(*) AS notifications,
+		       COUNT(*) FILTER (WHERE NOT EXISTS (
+		           SELECT 1 FROM notifications n WHERE n.client_id = $5 AND n.alert_id = m.alert_id
+		       )) AS new_notifications
+		FROM matched m
+		GROUP BY day
+		ORDER BY day ASC
+	`
+	rows, err := db.conn.QueryContext(ctx, query, since, severity, source, name, clientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rule impact: %w", err)
+	}
+	defer rows.Close()
+
+	days := make([]*RuleImpactDay, 0)
+	for rows.Next() {
+		var d RuleImpactDay
+		if err := rows.Scan(&d.Day, &d.Notifications, &d.NewNotifications); err != nil {
+			return nil, fmt.Errorf("failed to scan rule impact: %w", err)
+		}
+		d.Day = d.Day.UTC()
+		days = append(days, &d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return days, nil
+}
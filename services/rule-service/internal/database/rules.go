@@ -4,7 +4,10 @@ package database
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/lib/pq"
@@ -12,13 +15,14 @@ import (
 
 // CreateRule creates a new rule in the database.
 // Returns the created rule with generated rule_id and version.
-func (db *DB) CreateRule(ctx context.Context, clientID, severity, source, name string) (*Rule, error) {
+func (db *DB) CreateRule(ctx context.Context, clientID, severity, source, name, description string, exclusions RuleExclusions, conditions RuleConditions) (*Rule, error) {
 	query := `
-		INSERT INTO rules (client_id, severity, source, name, enabled, version, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, TRUE, 1, NOW(), NOW())
-		RETURNING rule_id, client_id, severity, source, name, enabled, version, created_at, updated_at
+		INSERT INTO rules (client_id, severity, source, name, description, exclude_sources, exclude_names, context_conditions, enabled, version, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, TRUE, 1, NOW(), NOW())
+		RETURNING rule_id, client_id, severity, source, name, description, exclude_sources, exclude_names, context_conditions, enabled, version, created_at, updated_at
 	`
-	row := db.conn.QueryRowContext(ctx, query, clientID, severity, source, name)
+	row := db.conn.QueryRowContext(ctx, query, clientID, severity, source, name, description,
+		pq.Array(nonNilStrings(exclusions.Sources)), pq.Array(nonNilStrings(exclusions.Names)), conditions)
 	rule, err := scanRule(row)
 	if err != nil {
 		if pqErr, ok := err.(*pq.Error); ok {
@@ -43,7 +47,7 @@ func (db *DB) CreateRule(ctx context.Context, clientID, severity, source, name s
 // GetRule retrieves a rule by ID.
 func (db *DB) GetRule(ctx context.Context, ruleID string) (*Rule, error) {
 	query := `
-		SELECT rule_id, client_id, severity, source, name, enabled, version, created_at, updated_at
+		SELECT rule_id, client_id, severity, source, name, description, exclude_sources, exclude_names, context_conditions, enabled, version, created_at, updated_at
 		FROM rules
 		WHERE rule_id = $1
 	`
@@ -58,9 +62,10 @@ func (db *DB) GetRule(ctx context.Context, ruleID string) (*Rule, error) {
 	return rule, nil
 }
 
-// ListRules retrieves rules with pagination, optionally filtered by client_id.
+// ListRules retrieves rules with pagination, optionally filtered by client, enabled state,
+// severity, source, name substring, and last update time.
 // Default limit is 50, max limit is 200.
-func (db *DB) ListRules(ctx context.Context, clientID *string, limit, offset int) (*RuleListResult, error) {
+func (db *DB) ListRules(ctx context.Context, filter RuleFilter, limit, offset int) (*RuleListResult, error) {
 	// Apply default and max limits
 	if limit <= 0 {
 		limit = 50
@@ -72,20 +77,17 @@ func (db *DB) ListRules(ctx context.Context, clientID *string, limit, offset int
 		offset = 0
 	}
 
-	// Build WHERE clause
-	whereClause := ""
-	var countArgs []interface{}
-	argIndex := 1
+	whereClauses, countArgs := ruleFilterClauses(filter, 1)
+	argIndex := len(countArgs) + 1
 
-	if clientID != nil {
-		whereClause = fmt.Sprintf("WHERE client_id = $%d", argIndex)
-		countArgs = append(countArgs, *clientID)
-		argIndex++
+	whereClause := ""
+	if len(whereClauses) > 0 {
+		whereClause = "WHERE " + strings.Join(whereClauses, " AND ")
 	}
 
 	// Get total count - use cached count for exact result with fast response
 	var total int64
-	if clientID == nil {
+	if len(whereClauses) == 0 {
 		// Unfiltered: use counts cache for exact count (updated by triggers)
 		cacheQuery := `SELECT row_count FROM table_counts WHERE table_name = 'rules'`
 		if err := db.conn.QueryRowContext(ctx, cacheQuery).Scan(&total); err != nil {
@@ -103,7 +105,7 @@ func (db *DB) ListRules(ctx context.Context, clientID *string, limit, offset int
 
 	// Get paginated results
 	query := fmt.Sprintf(`
-		SELECT rule_id, client_id, severity, source, name, enabled, version, created_at, updated_at
+		SELECT rule_id, client_id, severity, source, name, description, exclude_sources, exclude_names, context_conditions, enabled, version, created_at, updated_at
 		FROM rules
 		%s
 		ORDER BY created_at DESC
@@ -138,20 +140,66 @@ func (db *DB) ListRules(ctx context.Context, clientID *string, limit, offset int
 	}, nil
 }
 
-// UpdateRule updates a rule with optimistic locking.
+// ruleFilterClauses returns the WHERE conditions of a rule filter and their arguments,
+// numbering placeholders from argIndex.
+func ruleFilterClauses(filter RuleFilter, argIndex int) ([]string, []interface{}) {
+	var clauses []string
+	var args []interface{}
+	add := func(format string, arg interface{}) {
+		clauses = append(clauses, fmt.Sprintf(format, argIndex))
+		args = append(args, arg)
+		argIndex++
+	}
+
+	if filter.ClientID != nil {
+		add("client_id = $%d", *filter.ClientID)
+	}
+	if filter.Enabled != nil {
+		add("enabled = $%d", *filter.Enabled)
+	}
+	if filter.Severity != nil {
+		add("severity = $%d", *filter.Severity)
+	}
+	if filter.Source != nil {
+		add("source = $%d", *filter.Source)
+	}
+	if filter.NameContains != nil {
+		// Served by the idx_rules_name_trgm trigram index
+		add("name ILIKE $%d", "%"+escapeLikePattern(*filter.NameContains)+"%")
+	}
+	if filter.UpdatedSince != nil {
+		add("updated_at >= $%d", *filter.UpdatedSince)
+	}
+	return clauses, args
+}
+
+// UpdateRule updates a rule with optimistic locking. A nil description, nil exclusions, or nil
+// conditions keep the current ones; exclusions of a field that is no longer a wildcard are cleared.
 // Returns the updated rule or an error if version mismatch.
-func (db *DB) UpdateRule(ctx context.Context, ruleID string, severity, source, name string, expectedVersion int) (*Rule, error) {
+func (db *DB) UpdateRule(ctx context.Context, ruleID string, severity, source, name string, description *string, exclusions *RuleExclusions, conditions *RuleConditions, expectedVersion int) (*Rule, error) {
+	var excludeSources, excludeNames, contextConditions interface{}
+	if exclusions != nil {
+		excludeSources = pq.Array(nonNilStrings(exclusions.Sources))
+		excludeNames = pq.Array(nonNilStrings(exclusions.Names))
+	}
+	if conditions != nil {
+		contextConditions = *conditions
+	}
 	query := `
 		UPDATE rules
 		SET severity = $2,
 		    source = $3,
 		    name = $4,
+		    description = COALESCE($6, description),
+		    exclude_sources = CASE WHEN $3 = '*' THEN COALESCE($7, exclude_sources) ELSE '{}' END,
+		    exclude_names = CASE WHEN $4 = '*' THEN COALESCE($8, exclude_names) ELSE '{}' END,
+		    context_conditions = COALESCE($9::jsonb, context_conditions),
 		    version = version + 1,
 		    updated_at = NOW()
 		WHERE rule_id = $1 AND version = $5
-		RETURNING rule_id, client_id, severity, source, name, enabled, version, created_at, updated_at
+		RETURNING rule_id, client_id, severity, source, name, description, exclude_sources, exclude_names, context_conditions, enabled, version, created_at, updated_at
 	`
-	row := db.conn.QueryRowContext(ctx, query, ruleID, severity, source, name, expectedVersion)
+	row := db.conn.QueryRowContext(ctx, query, ruleID, severity, source, name, expectedVersion, description, excludeSources, excludeNames, contextConditions)
 	rule, err := scanRule(row)
 	if err == sql.ErrNoRows {
 		// Check if rule exists but version mismatch
@@ -174,7 +222,7 @@ func (db *DB) ToggleRuleEnabled(ctx context.Context, ruleID string, enabled bool
 		    version = version + 1,
 		    updated_at = NOW()
 		WHERE rule_id = $1 AND version = $3
-		RETURNING rule_id, client_id, severity, source, name, enabled, version, created_at, updated_at
+		RETURNING rule_id, client_id, severity, source, name, description, exclude_sources, exclude_names, context_conditions, enabled, version, created_at, updated_at
 	`
 	row := db.conn.QueryRowContext(ctx, query, ruleID, enabled, expectedVersion)
 	rule, err := scanRule(row)
@@ -210,7 +258,7 @@ func (db *DB) DeleteRule(ctx context.Context, ruleID string) error {
 // GetRulesUpdatedSince retrieves rules updated after a given timestamp.
 func (db *DB) GetRulesUpdatedSince(ctx context.Context, since time.Time) ([]*Rule, error) {
 	query := `
-		SELECT rule_id, client_id, severity, source, name, enabled, version, created_at, updated_at
+		SELECT rule_id, client_id, severity, source, name, description, exclude_sources, exclude_names, context_conditions, enabled, version, created_at, updated_at
 		FROM rules
 		WHERE updated_at > $1
 		ORDER BY updated_at ASC
@@ -231,3 +279,78 @@ func (db *DB) GetRulesUpdatedSince(ctx context.Context, since time.Time) ([]*Rul
 	}
 	return rules, rows.Err()
 }
+
+// ListClientRules retrieves all rules of a client, oldest first.
+func (db *DB) ListClientRules(ctx context.Context, clientID string) ([]*Rule, error) {
+	query := `
+		SELECT rule_id, client_id, severity, source, name, description, exclude_sources, exclude_names, context_conditions, enabled, version, created_at, updated_at
+		FROM rules
+		WHERE client_id = $1
+		ORDER BY created_at ASC, rule_id ASC
+	`
+	rows, err := db.conn.QueryContext(ctx, query, clientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list client rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []*Rule
+	for rows.Next() {
+		rule, err := scanRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+// Value implements driver.Valuer, storing the conditions as a JSON array (never null).
+// The JSON is passed as a string: lib/pq would send []byte as bytea.
+func (c RuleConditions) Value() (driver.Value, error) {
+	if c == nil {
+		c = RuleConditions{}
+	}
+	data, err := json.Marshal([]ContextCondition(c))
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner for the JSONB context_conditions column.
+func (c *RuleConditions) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*c = RuleConditions{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported rule conditions type %T", src)
+	}
+	conditions := RuleConditions{}
+	if err := json.Unmarshal(data, (*[]ContextCondition)(&conditions)); err != nil {
+		return err
+	}
+	*c = conditions
+	return nil
+}
+
+// nonNilStrings returns s, or an empty slice if s is nil, so it is stored as an empty
+// array rather than NULL.
+func nonNilStrings(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+// escapeLikePattern escapes LIKE wildcards so user input matches literally
+// (backslash is Postgres' default LIKE escape character).
+func escapeLikePattern(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
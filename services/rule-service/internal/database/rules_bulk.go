@@ -0,0 +1,112 @@
+// Package database provides database operations for clients, rules, and endpoints.
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// MaxBulkToggleRules bounds the rules a single bulk toggle may select.
+const MaxBulkToggleRules = 1000
+
+// BulkSetRulesEnabled enables or disables the selected rules in one transaction. The selected
+// rules are locked in rule_id order, so concurrent bulk toggles and single-rule writes serialize
+// instead of deadlocking, and every requested rule ID must exist and every given version must
+// match, or nothing is changed. Rules already in the target state keep their version.
+func (db *DB) BulkSetRulesEnabled(ctx context.Context, sel RuleSelection, enabled bool) (*BulkToggleResult, error) {
+	whereClauses, args := ruleFilterClauses(sel.Filter, 1)
+	if len(sel.RuleIDs) > 0 {
+		whereClauses = append(whereClauses, fmt.Sprintf("rule_id = ANY($%d)", len(args)+1))
+		args = append(args, pq.Array(sel.RuleIDs))
+	}
+	if len(whereClauses) == 0 {
+		return nil, fmt.Errorf("bulk toggle requires rule IDs or a filter")
+	}
+
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin bulk toggle transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(`
+		SELECT rule_id, version, enabled
+		FROM rules
+		WHERE %s
+		ORDER BY rule_id
+		FOR UPDATE
+	`, strings.Join(whereClauses, " AND ")), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock rules: %w", err)
+	}
+	versions := make(map[string]int)
+	var toChange, unchanged []string
+	for rows.Next() {
+		var ruleID string
+		var version int
+		var current bool
+		if err := rows.Scan(&ruleID, &version, &current); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan rule: %w", err)
+		}
+		versions[ruleID] = version
+		if current == enabled {
+			unchanged = append(unchanged, ruleID)
+		} else {
+			toChange = append(toChange, ruleID)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to lock rules: %w", err)
+	}
+
+	if len(versions) > MaxBulkToggleRules {
+		return nil, fmt.Errorf("bulk toggle selects %d rules, at most %d are allowed", len(versions), MaxBulkToggleRules)
+	}
+	for _, ruleID := range sel.RuleIDs {
+		if _, ok := versions[ruleID]; !ok {
+			return nil, fmt.Errorf("rule not found: %s", ruleID)
+		}
+	}
+	for ruleID, expected := range sel.Versions {
+		if actual, ok := versions[ruleID]; ok && actual != expected {
+			return nil, fmt.Errorf("rule version mismatch: rule %s expected version %d, current version %d", ruleID, expected, actual)
+		}
+	}
+
+	result := &BulkToggleResult{Updated: []*Rule{}, Unchanged: nonNilStrings(unchanged)}
+	if len(toChange) > 0 {
+		rows, err := tx.QueryContext(ctx, `
+			UPDATE rules
+			SET enabled = $1,
+			    version = version + 1,
+			    updated_at = NOW()
+			WHERE rule_id = ANY($2)
+			RETURNING rule_id, client_id, severity, source, name, description, exclude_sources, exclude_names, context_conditions, enabled, version, created_at, updated_at
+		`, enabled, pq.Array(toChange))
+		if err != nil {
+			return nil, fmt.Errorf("failed to toggle rules: %w", err)
+		}
+		for rows.Next() {
+			rule, err := scanRule(rows)
+			if err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan rule: %w", err)
+			}
+			result.Updated = append(result.Updated, rule)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to toggle rules: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit bulk toggle transaction: %w", err)
+	}
+	return result, nil
+}
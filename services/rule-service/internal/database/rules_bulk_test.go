@@ -0,0 +1,115 @@
+// Package database provides tests for bulk rule operations.
+package database
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+)
+
+// TestDB_BulkSetRulesEnabled tests BulkSetRulesEnabled.
+func TestDB_BulkSetRulesEnabled(t *testing.T) {
+	clientID := "client-1"
+
+	t.Run("toggles only rules not in the target state", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("Failed to create mock: %v", err)
+		}
+		defer db.Close()
+		d := &DB{conn: db}
+
+		now := time.Now()
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT rule_id, version, enabled\s+FROM rules\s+WHERE client_id = \$1\s+ORDER BY rule_id\s+FOR UPDATE`).
+			WithArgs(clientID).
+			WillReturnRows(sqlmock.NewRows([]string{"rule_id", "version", "enabled"}).
+				AddRow("rule-1", 2, true).
+				AddRow("rule-2", 1, false))
+		mock.ExpectQuery("UPDATE rules").
+			WithArgs(false, pq.Array([]string{"rule-1"})).
+			WillReturnRows(sqlmock.NewRows([]string{"rule_id", "client_id", "severity", "source", "name", "description", "exclude_sources", "exclude_names", "context_conditions", "enabled", "version", "created_at", "updated_at"}).
+				AddRow("rule-1", clientID, "HIGH", "api", "*", "", "{}", "{}", "[]", false, 3, now, now))
+		mock.ExpectCommit()
+
+		result, err := d.BulkSetRulesEnabled(context.Background(), RuleSelection{
+			Filter:   RuleFilter{ClientID: &clientID},
+			Versions: map[string]int{"rule-1": 2},
+		}, false)
+		if err != nil {
+			t.Fatalf("BulkSetRulesEnabled() error = %v", err)
+		}
+		if len(result.Updated) != 1 || result.Updated[0].Version != 3 {
+			t.Errorf("BulkSetRulesEnabled() updated = %+v, want rule-1 at version 3", result.Updated)
+		}
+		if len(result.Unchanged) != 1 || result.Unchanged[0] != "rule-2" {
+			t.Errorf("BulkSetRulesEnabled() unchanged = %v, want [rule-2]", result.Unchanged)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Mock expectations were not met: %v", err)
+		}
+	})
+
+	t.Run("version mismatch rolls back", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("Failed to create mock: %v", err)
+		}
+		defer db.Close()
+		d := &DB{conn: db}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT rule_id, version, enabled").
+			WithArgs(pq.Array([]string{"rule-1"})).
+			WillReturnRows(sqlmock.NewRows([]string{"rule_id", "version", "enabled"}).AddRow("rule-1", 3, true))
+		mock.ExpectRollback()
+
+		_, err = d.BulkSetRulesEnabled(context.Background(), RuleSelection{
+			RuleIDs:  []string{"rule-1"},
+			Versions: map[string]int{"rule-1": 2},
+		}, false)
+		if err == nil || !strings.Contains(err.Error(), "version mismatch") {
+			t.Errorf("BulkSetRulesEnabled() error = %v, want version mismatch", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Mock expectations were not met: %v", err)
+		}
+	})
+
+	t.Run("unknown rule rolls back", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("Failed to create mock: %v", err)
+		}
+		defer db.Close()
+		d := &DB{conn: db}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT rule_id, version, enabled").
+			WithArgs(clientID, pq.Array([]string{"rule-1", "rule-9"})).
+			WillReturnRows(sqlmock.NewRows([]string{"rule_id", "version", "enabled"}).AddRow("rule-1", 1, true))
+		mock.ExpectRollback()
+
+		_, err = d.BulkSetRulesEnabled(context.Background(), RuleSelection{
+			RuleIDs: []string{"rule-1", "rule-9"},
+			Filter:  RuleFilter{ClientID: &clientID},
+		}, false)
+		if err == nil || !strings.Contains(err.Error(), "rule not found: rule-9") {
+			t.Errorf("BulkSetRulesEnabled() error = %v, want rule not found", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Mock expectations were not met: %v", err)
+		}
+	})
+
+	t.Run("empty selection", func(t *testing.T) {
+		d := &DB{}
+		if _, err := d.BulkSetRulesEnabled(context.Background(), RuleSelection{}, false); err == nil {
+			t.Error("BulkSetRulesEnabled() expected error for empty selection")
+		}
+	})
+}
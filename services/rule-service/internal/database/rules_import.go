@@ -0,0 +1,65 @@
+// Package database provides database operations for clients, rules, and endpoints.
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// MaxImportRules bounds the rules a single import document may hold.
+const MaxImportRules = 1000
+
+// ImportRules creates rules and their endpoints for an existing client in one transaction.
+// Either every rule is created or none is. The client row is locked for the transaction, so
+// concurrent imports for the same client run one after the other.
+func (db *DB) ImportRules(ctx context.Context, clientID string, rules []BootstrapRule) ([]*BootstrappedRule, error) {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin import transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var locked string
+	err = tx.QueryRowContext(ctx, `SELECT client_id FROM clients WHERE client_id = $1 FOR UPDATE`, clientID).Scan(&locked)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("client not found: %s", clientID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock client: %w", err)
+	}
+
+	created, err := insertRuleSet(ctx, tx, clientID, rules)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit import transaction: %w", err)
+	}
+	return created, nil
+}
+
+// ListClientEndpoints retrieves the endpoints of every rule of a client, oldest first.
+func (db *DB) ListClientEndpoints(ctx context.Context, clientID string) ([]*Endpoint, error) {
+	rows, err := db.conn.QueryContext(ctx, `
+		SELECT `+endpointColumns+`
+		FROM endpoints
+		WHERE rule_id IN (SELECT rule_id FROM rules WHERE client_id = $1)
+		ORDER BY created_at ASC, endpoint_id ASC
+	`, clientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list client endpoints: %w", err)
+	}
+	defer rows.Close()
+
+	var endpoints []*Endpoint
+	for rows.Next() {
+		endpoint, err := scanEndpoint(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan endpoint: %w", err)
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	return endpoints, rows.Err()
+}
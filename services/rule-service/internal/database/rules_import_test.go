@@ -0,0 +1,134 @@
+// Package database provides tests for rule import and export.
+package database
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+)
+
+// TestDB_ImportRules tests ImportRules.
+func TestDB_ImportRules(t *testing.T) {
+	rules := []BootstrapRule{{
+		Severity:  "HIGH",
+		Source:    "api",
+		Name:      "timeout",
+		Disabled:  true,
+		Endpoints: []BootstrapEndpoint{{Type: "email", Value: "oncall@team-a.example", Disabled: true}},
+	}}
+
+	t.Run("creates rules and endpoints for the locked client", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("Failed to create mock: %v", err)
+		}
+		defer db.Close()
+		d := &DB{conn: db}
+
+		now := time.Now()
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT client_id FROM clients WHERE client_id = \$1 FOR UPDATE`).
+			WithArgs("team-a").
+			WillReturnRows(sqlmock.NewRows([]string{"client_id"}).AddRow("team-a"))
+		mock.ExpectQuery("INSERT INTO rules").
+			WithArgs("team-a", "HIGH", "api", "timeout", "", pq.Array([]string{}), pq.Array([]string{}), "[]", false).
+			WillReturnRows(sqlmock.NewRows([]string{"rule_id", "client_id", "severity", "source", "name", "description", "exclude_sources", "exclude_names", "context_conditions", "enabled", "version", "created_at", "updated_at"}).
+				AddRow("rule-1", "team-a", "HIGH", "api", "timeout", "", "{}", "{}", "[]", false, 1, now, now))
+		mock.ExpectQuery("INSERT INTO endpoints").
+			WithArgs("rule-1", "email", "oncall@team-a.example", false).
+			WillReturnRows(sqlmock.NewRows([]string{"endpoint_id", "rule_id", "type", "value", "enabled", "metadata", "template_id", "created_at", "updated_at"}).
+				AddRow("endpoint-1", "rule-1", "email", "oncall@team-a.example", false, []byte("{}"), "", now, now))
+		mock.ExpectCommit()
+
+		created, err := d.ImportRules(context.Background(), "team-a", rules)
+		if err != nil {
+			t.Fatalf("ImportRules() error = %v", err)
+		}
+		if len(created) != 1 || created[0].Enabled || len(created[0].Endpoints) != 1 || created[0].Endpoints[0].Enabled {
+			t.Errorf("ImportRules() = %+v, want one disabled rule with one disabled endpoint", created)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Mock expectations were not met: %v", err)
+		}
+	})
+
+	t.Run("unknown client", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("Failed to create mock: %v", err)
+		}
+		defer db.Close()
+		d := &DB{conn: db}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT client_id FROM clients").
+			WithArgs("team-a").
+			WillReturnRows(sqlmock.NewRows([]string{"client_id"}))
+		mock.ExpectRollback()
+
+		_, err = d.ImportRules(context.Background(), "team-a", rules)
+		if err == nil || !strings.Contains(err.Error(), "client not found") {
+			t.Errorf("ImportRules() error = %v, want client not found", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Mock expectations were not met: %v", err)
+		}
+	})
+
+	t.Run("existing rule rolls back", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("Failed to create mock: %v", err)
+		}
+		defer db.Close()
+		d := &DB{conn: db}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT client_id FROM clients").
+			WithArgs("team-a").
+			WillReturnRows(sqlmock.NewRows([]string{"client_id"}).AddRow("team-a"))
+		mock.ExpectQuery("INSERT INTO rules").
+			WillReturnError(&pq.Error{Code: "23505"})
+		mock.ExpectRollback()
+
+		_, err = d.ImportRules(context.Background(), "team-a", rules)
+		if err == nil || !strings.Contains(err.Error(), "rule already exists") {
+			t.Errorf("ImportRules() error = %v, want rule already exists", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Mock expectations were not met: %v", err)
+		}
+	})
+}
+
+// TestDB_ListClientEndpoints tests ListClientEndpoints.
+func TestDB_ListClientEndpoints(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+	d := &DB{conn: db}
+
+	now := time.Now()
+	mock.ExpectQuery(`WHERE rule_id IN \(SELECT rule_id FROM rules WHERE client_id = \$1\)`).
+		WithArgs("team-a").
+		WillReturnRows(sqlmock.NewRows([]string{"endpoint_id", "rule_id", "type", "value", "enabled", "metadata", "template_id", "created_at", "updated_at"}).
+			AddRow("endpoint-1", "rule-1", "email", "oncall@team-a.example", true, []byte("{}"), "", now, now).
+			AddRow("endpoint-2", "rule-2", "slack", "https://hooks.slack.com/x", true, []byte("{}"), "", now, now))
+
+	endpoints, err := d.ListClientEndpoints(context.Background(), "team-a")
+	if err != nil {
+		t.Fatalf("ListClientEndpoints() error = %v", err)
+	}
+	if len(endpoints) != 2 || endpoints[1].RuleID != "rule-2" {
+		t.Errorf("ListClientEndpoints() = %+v, want the endpoints of rule-1 and rule-2", endpoints)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Mock expectations were not met: %v", err)
+	}
+}
@@ -0,0 +1,135 @@
+// Package database provides database operations for clients, rules, and endpoints.
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+const templateColumns = `template_id, client_id, name, subject, body, created_at, updated_at`
+
+// CreateTemplate creates a notification template for a client.
+func (db *DB) CreateTemplate(ctx context.Context, clientID, name, subject, body string) (*Template, error) {
+	query := `
+		INSERT INTO templates (client_id, name, subject, body, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, NOW(), NOW())
+		RETURNING ` + templateColumns
+	tmpl, err := scanTemplate(db.conn.QueryRowContext(ctx, query, clientID, name, subject, body))
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			if pqErr.Code == "23505" { // unique_violation
+				return nil, fmt.Errorf("template already exists for client %s with name %s", clientID, name)
+			}
+			if pqErr.Code == "23503" { // foreign_key_violation
+				return nil, fmt.Errorf("client not found: %s", clientID)
+			}
+		}
+		return nil, fmt.Errorf("failed to create template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// GetTemplate retrieves a template by ID.
+func (db *DB) GetTemplate(ctx context.Context, templateID string) (*Template, error) {
+	query := `SELECT ` + templateColumns + ` FROM templates WHERE template_id = $1`
+	tmpl, err := scanTemplate(db.conn.QueryRowContext(ctx, query, templateID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("template not found: %s", templateID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// ListTemplates retrieves templates, optionally filtered by client_id, by name.
+// Templates are few per client, so results are not paginated.
+func (db *DB) ListTemplates(ctx context.Context, clientID *string) ([]*Template, error) {
+	query := `SELECT ` + templateColumns + ` FROM templates`
+	var args []interface{}
+	if clientID != nil {
+		query += ` WHERE client_id = $1`
+		args = append(args, *clientID)
+	}
+	query += ` ORDER BY client_id, name`
+
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+	defer rows.Close()
+
+	templates := make([]*Template, 0)
+	for rows.Next() {
+		tmpl, err := scanTemplate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan template: %w", err)
+		}
+		templates = append(templates, tmpl)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// UpdateTemplate changes a template. Nil fields keep their value.
+func (db *DB) UpdateTemplate(ctx context.Context, templateID string, name, subject, body *string) (*Template, error) {
+	query := `
+		UPDATE templates
+		SET name = COALESCE($2, name),
+		    subject = COALESCE($3, subject),
+		    body = COALESCE($4, body),
+		    updated_at = NOW()
+		WHERE template_id = $1
+		RETURNING ` + templateColumns
+	tmpl, err := scanTemplate(db.conn.QueryRowContext(ctx, query, templateID, name, subject, body))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("template not found: %s", templateID)
+	}
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" { // unique_violation
+			return nil, fmt.Errorf("template already exists with name %s", *name)
+		}
+		return nil, fmt.Errorf("failed to update template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// DeleteTemplate deletes a template by ID. Its endpoints revert to the default layout.
+func (db *DB) DeleteTemplate(ctx context.Context, templateID string) error {
+	result, err := db.conn.ExecContext(ctx, `DELETE FROM templates WHERE template_id = $1`, templateID)
+	if err != nil {
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("template not found: %s", templateID)
+	}
+	return nil
+}
+
+// scanTemplate scans a template from a sql.Row or sql.Rows selecting templateColumns.
+func scanTemplate(scanner interface {
+	Scan(dest ...interface{}) error
+}) (*Template, error) {
+	var tmpl Template
+	if err := scanner.Scan(
+		&tmpl.TemplateID,
+		&tmpl.ClientID,
+		&tmpl.Name,
+		&tmpl.Subject,
+		&tmpl.Body,
+		&tmpl.CreatedAt,
+		&tmpl.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &tmpl, nil
+}
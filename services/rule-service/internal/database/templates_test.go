@@ -0,0 +1,149 @@
+// Package database provides tests for notification template database operations.
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+)
+
+var templateRowColumns = []string{
+	"template_id", "client_id", "name", "subject", "body", "created_at", "updated_at",
+}
+
+// TestDB_CreateTemplate tests the CreateTemplate method.
+func TestDB_CreateTemplate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	d := &DB{conn: db}
+	ctx := context.Background()
+	now := time.Now()
+
+	t.Run("successful create", func(t *testing.T) {
+		mock.ExpectQuery("INSERT INTO templates").
+			WithArgs("client-1", "short", "{{.Name}}", "{{.Severity}}").
+			WillReturnRows(sqlmock.NewRows(templateRowColumns).
+				AddRow("template-1", "client-1", "short", "{{.Name}}", "{{.Severity}}", now, now))
+
+		tmpl, err := d.CreateTemplate(ctx, "client-1", "short", "{{.Name}}", "{{.Severity}}")
+		if err != nil {
+			t.Fatalf("CreateTemplate() error = %v", err)
+		}
+		if tmpl.TemplateID != "template-1" || tmpl.Body != "{{.Severity}}" {
+			t.Errorf("CreateTemplate() = %+v", tmpl)
+		}
+	})
+
+	t.Run("duplicate name", func(t *testing.T) {
+		mock.ExpectQuery("INSERT INTO templates").
+			WithArgs("client-1", "short", "", "{{.Severity}}").
+			WillReturnError(&pq.Error{Code: "23505"})
+
+		_, err := d.CreateTemplate(ctx, "client-1", "short", "", "{{.Severity}}")
+		if err == nil || !contains(err.Error(), "already exists") {
+			t.Errorf("CreateTemplate() error = %v, want 'already exists'", err)
+		}
+	})
+
+	t.Run("client not found", func(t *testing.T) {
+		mock.ExpectQuery("INSERT INTO templates").
+			WithArgs("missing", "short", "", "{{.Severity}}").
+			WillReturnError(&pq.Error{Code: "23503"})
+
+		_, err := d.CreateTemplate(ctx, "missing", "short", "", "{{.Severity}}")
+		if err == nil || !contains(err.Error(), "client not found") {
+			t.Errorf("CreateTemplate() error = %v, want 'client not found'", err)
+		}
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Mock expectations were not met: %v", err)
+	}
+}
+
+// TestDB_UpdateTemplate tests the UpdateTemplate method.
+func TestDB_UpdateTemplate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	d := &DB{conn: db}
+	now := time.Now()
+	body := "{{.Name}} fired"
+
+	mock.ExpectQuery("UPDATE templates").
+		WithArgs("template-1", nil, nil, body).
+		WillReturnRows(sqlmock.NewRows(templateRowColumns).
+			AddRow("template-1", "client-1", "short", "", body, now, now))
+	mock.ExpectQuery("UPDATE templates").
+		WithArgs("template-999", nil, nil, body).
+		WillReturnError(sql.ErrNoRows)
+
+	tmpl, err := d.UpdateTemplate(context.Background(), "template-1", nil, nil, &body)
+	if err != nil || tmpl.Body != body {
+		t.Errorf("UpdateTemplate() = %+v, %v", tmpl, err)
+	}
+	_, err = d.UpdateTemplate(context.Background(), "template-999", nil, nil, &body)
+	if err == nil || !contains(err.Error(), "template not found") {
+		t.Errorf("UpdateTemplate() error = %v, want 'template not found'", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Mock expectations were not met: %v", err)
+	}
+}
+
+// TestDB_DeleteTemplate tests the DeleteTemplate method.
+func TestDB_DeleteTemplate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	d := &DB{conn: db}
+
+	mock.ExpectExec("DELETE FROM templates").
+		WithArgs("template-999").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = d.DeleteTemplate(context.Background(), "template-999")
+	if err == nil || !contains(err.Error(), "template not found") {
+		t.Errorf("DeleteTemplate() error = %v, want 'template not found'", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Mock expectations were not met: %v", err)
+	}
+}
+
+// TestDB_CreateEndpoint_TemplateNotFound tests that a missing template is told apart from a missing rule.
+func TestDB_CreateEndpoint_TemplateNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	d := &DB{conn: db}
+
+	mock.ExpectQuery("INSERT INTO endpoints").
+		WithArgs("rule-1", "email", "test@example.com", "{}", "template-999").
+		WillReturnError(&pq.Error{Code: "23503", Constraint: endpointTemplateConstraint})
+
+	_, err = d.CreateEndpoint(context.Background(), "rule-1", "email", "test@example.com", EndpointMetadata{}, "template-999")
+	if err == nil || !contains(err.Error(), "template not found") {
+		t.Errorf("CreateEndpoint() error = %v, want 'template not found'", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Mock expectations were not met: %v", err)
+	}
+}
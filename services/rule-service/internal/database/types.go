@@ -2,6 +2,7 @@
 package database
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -9,34 +10,135 @@ import (
 type Client struct {
 	ClientID  string    `json:"client_id"`
 	Name      string    `json:"name"`
+	Timezone  string    `json:"timezone"` // IANA timezone notification timestamps are rendered in, "" for UTC
+	Locale    string    `json:"locale"`   // locale of notification timestamps, "" for the default layout
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
 // Rule represents a rule record in the database.
 type Rule struct {
-	RuleID    string    `json:"rule_id"`
-	ClientID  string    `json:"client_id"`
-	Severity  string    `json:"severity"`
-	Source    string    `json:"source"`
-	Name      string    `json:"name"`
-	Enabled   bool      `json:"enabled"`
-	Version   int       `json:"version"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	RuleID         string         `json:"rule_id"`
+	ClientID       string         `json:"client_id"`
+	Severity       string         `json:"severity"`
+	Source         string         `json:"source"`
+	Name           string         `json:"name"`
+	Description    string         `json:"description"`
+	ExcludeSources []string       `json:"exclude_sources"`
+	ExcludeNames   []string       `json:"exclude_names"`
+	Conditions     RuleConditions `json:"conditions"`
+	Enabled        bool           `json:"enabled"`
+	Version        int            `json:"version"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
 }
 
+// RuleExclusions are the alert sources and names a wildcard rule does not match:
+// a rule with source "*" skips alerts whose source is in Sources, and likewise for names.
+type RuleExclusions struct {
+	Sources []string
+	Names   []string
+}
+
+// Context condition operators.
+const (
+	ConditionOpEqual    = "=="
+	ConditionOpNotEqual = "!="
+)
+
+// ContextCondition matches one key of an alert's context. With ==, the key must be present with
+// Value; with !=, the key must be absent or have another value.
+type ContextCondition struct {
+	Key   string `json:"key"`
+	Op    string `json:"op"`
+	Value string `json:"value"`
+}
+
+// RuleConditions are the context conditions of a rule, all of which must hold for it to match.
+// They are stored as JSON in rules.context_conditions.
+type RuleConditions []ContextCondition
+
 // Endpoint represents an endpoint record in the database.
 type Endpoint struct {
-	EndpointID string    `json:"endpoint_id"`
-	RuleID     string    `json:"rule_id"`
-	Type       string    `json:"type"` // email, webhook, slack
-	Value      string    `json:"value"` // email address, URL, etc.
-	Enabled    bool      `json:"enabled"`
+	EndpointID string           `json:"endpoint_id"`
+	RuleID     string           `json:"rule_id"`
+	Type       string           `json:"type"`  // email, webhook, slack
+	Value      string           `json:"value"` // email address, URL, etc.
+	Enabled    bool             `json:"enabled"`
+	Metadata   EndpointMetadata `json:"metadata"`
+	TemplateID string           `json:"template_id,omitempty"` // notification template, empty for the default layout
+	CreatedAt  time.Time        `json:"created_at"`
+	UpdatedAt  time.Time        `json:"updated_at"`
+}
+
+// EndpointMetadata holds optional per-endpoint settings, stored as JSON in endpoints.metadata.
+type EndpointMetadata struct {
+	// Headers are static HTTP headers sent with every webhook request.
+	Headers []EndpointHeader `json:"headers,omitempty"`
+	// OAuth2 authenticates webhook requests with a client-credentials access token.
+	OAuth2 *EndpointOAuth2 `json:"oauth2,omitempty"`
+}
+
+// EndpointHeader is a static webhook header. Secret values are stored encrypted.
+type EndpointHeader struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Secret bool   `json:"secret,omitempty"`
+}
+
+// EndpointOAuth2 is an OAuth2 client-credentials configuration. The client secret is stored encrypted.
+type EndpointOAuth2 struct {
+	TokenURL     string   `json:"token_url"`
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret"`
+	Scopes       []string `json:"scopes,omitempty"`
+}
+
+// Template is a client's notification template: a Go text/template subject and body the
+// sender renders in place of the default email and Slack layout.
+type Template struct {
+	TemplateID string    `json:"template_id"`
+	ClientID   string    `json:"client_id"`
+	Name       string    `json:"name"`
+	Subject    string    `json:"subject"` // email subject; empty keeps the default subject
+	Body       string    `json:"body"`
 	CreatedAt  time.Time `json:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at"`
 }
 
+// APIKey is an issued API key. The key itself is never stored, only its SHA-256 digest and
+// its first characters (KeyPrefix) to recognize it. A key without a ClientID is an admin key.
+type APIKey struct {
+	KeyID     string     `json:"key_id"`
+	ClientID  string     `json:"client_id,omitempty"`
+	Name      string     `json:"name"`
+	KeyPrefix string     `json:"key_prefix"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// EscalationPolicy is a client's escalation path for notifications nobody acknowledges.
+// Rules opt in through rules.escalation_policy_id; RuleIDs lists the rules using the policy.
+type EscalationPolicy struct {
+	PolicyID  string          `json:"policy_id"`
+	ClientID  string          `json:"client_id"`
+	Name      string          `json:"name"`
+	Steps     EscalationSteps `json:"steps"`
+	RuleIDs   []string        `json:"rule_ids"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// EscalationStep sends a still unacknowledged notification to EndpointIDs, DelaySeconds after
+// the previous step (or, for the first step, after the notification was delivered).
+type EscalationStep struct {
+	DelaySeconds int      `json:"delay_seconds"`
+	EndpointIDs  []string `json:"endpoint_ids"`
+}
+
+// EscalationSteps are the ordered steps of a policy, stored as JSON in escalation_policies.steps.
+type EscalationSteps []EscalationStep
+
 // Notification represents a notification record in the database.
 type Notification struct {
 	NotificationID string            `json:"notification_id"`
@@ -52,6 +154,50 @@ type Notification struct {
 	UpdatedAt      time.Time         `json:"updated_at"`
 }
 
+// NotificationEvent is one entry in a notification's journal: a state transition
+// recorded by the aggregator (created, enqueued), sender (send attempts, outcome, ack),
+// or rule-service (acknowledged, resolved, with the actor and note).
+type NotificationEvent struct {
+	EventID        int64     `json:"event_id"`
+	NotificationID string    `json:"notification_id"`
+	Service        string    `json:"service"`
+	EventType      string    `json:"event_type"`
+	EndpointType   string    `json:"endpoint_type,omitempty"`
+	Error          string    `json:"error,omitempty"`
+	Actor          string    `json:"actor,omitempty"`
+	Note           string    `json:"note,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// NotificationDelivery represents one endpoint delivery of a notification, written by the sender.
+// EndpointID is empty for deliveries to the owning team's Slack channel, which is not an endpoint.
+type NotificationDelivery struct {
+	DeliveryID     int64     `json:"delivery_id"`
+	NotificationID string    `json:"notification_id"`
+	EndpointID     string    `json:"endpoint_id,omitempty"`
+	Channel        string    `json:"channel"`
+	Status         string    `json:"status"`
+	Error          string    `json:"error,omitempty"`
+	LatencyMs      int       `json:"latency_ms"`
+	Attempts       int       `json:"attempts"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Heartbeat represents a heartbeat monitor (dead-man's switch) record in the database.
+// If no ping arrives within IntervalSeconds of LastPingAt, a synthetic alert is injected.
+type Heartbeat struct {
+	HeartbeatID     string     `json:"heartbeat_id"`
+	ClientID        string     `json:"client_id"`
+	IntervalSeconds int        `json:"interval_seconds"`
+	Severity        string     `json:"severity"`
+	Source          string     `json:"source"`
+	Name            string     `json:"name"`
+	LastPingAt      time.Time  `json:"last_ping_at"`
+	AlertedAt       *time.Time `json:"alerted_at,omitempty"` // set when a missed ping was alerted; cleared by the next ping
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
 // ClientListResult contains paginated client results.
 type ClientListResult struct {
 	Clients []*Client `json:"clients"`
@@ -60,6 +206,68 @@ type ClientListResult struct {
 	Offset  int       `json:"offset"`
 }
 
+// RuleFilter narrows ListRules results. Zero-value fields are not filtered on.
+type RuleFilter struct {
+	ClientID     *string
+	Enabled      *bool
+	Severity     *string
+	Source       *string
+	NameContains *string // case-insensitive substring match on name
+	UpdatedSince *time.Time
+}
+
+// RuleSelection selects the rules of a bulk operation: the rules in RuleIDs, if any, that also
+// match Filter. Versions optionally gives the expected version of selected rules.
+type RuleSelection struct {
+	RuleIDs  []string
+	Versions map[string]int // rule_id -> expected version; rules not listed are not checked
+	Filter   RuleFilter
+}
+
+// BulkToggleResult is the outcome of a bulk enable or disable.
+type BulkToggleResult struct {
+	Updated   []*Rule  `json:"updated"`   // rules whose enabled state changed, with their new version
+	Unchanged []string `json:"unchanged"` // IDs of selected rules already in the target state
+}
+
+// RuleImpactDay counts the historical alerts a rule would have matched on one day (UTC).
+type RuleImpactDay struct {
+	Day              time.Time `json:"day"`
+	Notifications    int       `json:"notifications"`     // matching alerts, one notification each
+	NewNotifications int       `json:"new_notifications"` // of those, alerts the client was not already notified about
+}
+
+// BootstrapRule is a rule to create when bootstrapping a client or importing rules, with its endpoints.
+type BootstrapRule struct {
+	Severity    string
+	Source      string
+	Name        string
+	Description string
+	Exclusions  RuleExclusions
+	Conditions  RuleConditions
+	Disabled    bool // create the rule disabled, e.g. when importing an exported disabled rule
+	Endpoints   []BootstrapEndpoint
+}
+
+// BootstrapEndpoint is an endpoint to create for a BootstrapRule.
+type BootstrapEndpoint struct {
+	Type     string
+	Value    string
+	Disabled bool
+}
+
+// BootstrappedRule is a rule created by BootstrapClient or ImportRules, with its endpoints.
+type BootstrappedRule struct {
+	*Rule
+	Endpoints []*Endpoint `json:"endpoints"`
+}
+
+// BootstrapResult contains the client, rules, and endpoints created by BootstrapClient.
+type BootstrapResult struct {
+	Client *Client             `json:"client"`
+	Rules  []*BootstrappedRule `json:"rules"`
+}
+
 // RuleListResult contains paginated rule results.
 type RuleListResult struct {
 	Rules  []*Rule `json:"rules"`
@@ -75,3 +283,71 @@ type EndpointListResult struct {
 	Limit     int         `json:"limit"`
 	Offset    int         `json:"offset"`
 }
+
+// EndpointChange represents a pending endpoint_outbox row, recorded by a trigger on endpoints.
+type EndpointChange struct {
+	EventID      int64     `json:"event_id"`
+	EndpointID   string    `json:"endpoint_id"`
+	RuleID       string    `json:"rule_id"`
+	ClientID     string    `json:"client_id"` // empty when the rule was already gone
+	Action       string    `json:"action"`
+	EndpointType string    `json:"endpoint_type"`
+	Enabled      bool      `json:"enabled"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// ClientWebhook is a meta-webhook: a URL that receives platform events about a client's resources.
+// Secret is the encrypted signing secret and is never returned by the API.
+type ClientWebhook struct {
+	WebhookID  string    `json:"webhook_id"`
+	ClientID   string    `json:"client_id"`
+	URL        string    `json:"url"`
+	Secret     string    `json:"-"`
+	EventTypes []string  `json:"event_types"` // empty subscribes to every event type
+	Enabled    bool      `json:"enabled"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// WebhookDelivery is a platform event queued for a meta-webhook, recorded by triggers on
+// rules and endpoints or by RecordPlatformEvent.
+type WebhookDelivery struct {
+	DeliveryID    int64           `json:"delivery_id"`
+	WebhookID     string          `json:"webhook_id"`
+	EventType     string          `json:"event_type"`
+	Payload       json.RawMessage `json:"payload"`
+	Attempts      int             `json:"attempts"`
+	NextAttemptAt time.Time       `json:"next_attempt_at"`
+	DeliveredAt   *time.Time      `json:"delivered_at,omitempty"`
+	FailedAt      *time.Time      `json:"failed_at,omitempty"` // set when the dispatcher gave up
+	LastError     string          `json:"last_error,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// PendingWebhookDelivery is a claimed delivery with the webhook it is sent to.
+type PendingWebhookDelivery struct {
+	WebhookDelivery
+	URL            string
+	Secret         string // encrypted
+	WebhookEnabled bool
+}
+
+// UnmatchedSignature counts the alerts of one (severity, source, name) that matched no rule
+// during a UTC day.
+type UnmatchedSignature struct {
+	ClientID      string    `json:"client_id,omitempty"` // the alerts' client_hint; empty for unhinted alerts
+	Severity      string    `json:"severity"`
+	Source        string    `json:"source"`
+	Name          string    `json:"name"`
+	Count         int64     `json:"count"`
+	FirstSeenAt   time.Time `json:"first_seen_at"`
+	LastSeenAt    time.Time `json:"last_seen_at"`
+	SampleAlertID string    `json:"sample_alert_id,omitempty"`
+}
+
+// UnmatchedSummary is a day's unmatched alerts for a client, or for the whole platform.
+type UnmatchedSummary struct {
+	TotalAlerts int64                 `json:"total_alerts"`
+	Signatures  int64                 `json:"signatures"` // distinct (severity, source, name)
+	Top         []*UnmatchedSignature `json:"top"`        // most frequent signatures first
+}
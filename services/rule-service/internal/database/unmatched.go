@@ -0,0 +1,140 @@
+// Package database provides database operations for clients, rules, and endpoints.
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RecordUnmatchedSignatures adds the counts of sigs to the day's unmatched alert signatures in
+// one transaction, widening each signature's first/last seen times.
+func (db *DB) RecordUnmatchedSignatures(ctx context.Context, day time.Time, sigs []*UnmatchedSignature) error {
+	if len(sigs) == 0 {
+		return nil
+	}
+
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin unmatched signatures transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO unmatched_alert_signatures (day, client_id, severity, source, name, alert_count, first_seen_at, last_seen_at, sample_alert_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (day, client_id, severity, source, name) DO UPDATE
+		SET alert_count = unmatched_alert_signatures.alert_count + EXCLUDED.alert_count,
+		    first_seen_at = LEAST(unmatched_alert_signatures.first_seen_at, EXCLUDED.first_seen_at),
+		    last_seen_at = GREATEST(unmatched_alert_signatures.last_seen_at, EXCLUDED.last_seen_at)`
+	for _, sig := range sigs {
+		if _, err := tx.ExecContext(ctx, query, day.Format(time.DateOnly), sig.ClientID, sig.Severity, sig.Source, sig.Name,
+			sig.Count, sig.FirstSeenAt.UTC(), sig.LastSeenAt.UTC(), sig.SampleAlertID); err != nil {
+			return fmt.Errorf("failed to record unmatched signature: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit unmatched signatures: %w", err)
+	}
+	return nil
+}
+
+// ListUnmatchedClients returns the clients whose hinted alerts matched none of their rules
+// during the day.
+func (db *DB) ListUnmatchedClients(ctx context.Context, day time.Time) ([]string, error) {
+	rows, err := db.conn.QueryContext(ctx, `
+		SELECT DISTINCT client_id FROM unmatched_alert_signatures
+		WHERE day = $1 AND client_id <> ''
+		ORDER BY client_id
+	`, day.Format(time.DateOnly))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unmatched clients: %w", err)
+	}
+	defer rows.Close()
+
+	var clients []string
+	for rows.Next() {
+		var clientID string
+		if err := rows.Scan(&clientID); err != nil {
+			return nil, fmt.Errorf("failed to scan unmatched client: %w", err)
+		}
+		clients = append(clients, clientID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return clients, nil
+}
+
+// SummarizeUnmatched returns the day's unmatched alerts hinted for clientID, or of the whole
+// platform (hinted or not) when clientID is empty, with the limit most frequent signatures.
+func (db *DB) SummarizeUnmatched(ctx context.Context, day time.Time, clientID string, limit int) (*UnmatchedSummary, error) {
+	rows, err := db.conn.QueryContext(ctx, `
+		SELECT severity, source, name, SUM(alert_count) AS alert_count,
+		       MIN(first_seen_at), MAX(last_seen_at), MIN(sample_alert_id),
+		       SUM(SUM(alert_count)) OVER () AS total_alerts, COUNT(*) OVER () AS signatures
+		FROM unmatched_alert_signatures
+		WHERE day = $1 AND ($2 = '' OR client_id = $2)
+		GROUP BY severity, source, name
+		ORDER BY alert_count DESC, severity, source, name
+		LIMIT $3
+	`, day.Format(time.DateOnly), clientID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize unmatched alerts: %w", err)
+	}
+	defer rows.Close()
+
+	summary := &UnmatchedSummary{Top: make([]*UnmatchedSignature, 0)}
+	for rows.Next() {
+		sig := &UnmatchedSignature{ClientID: clientID}
+		if err := rows.Scan(&sig.Severity, &sig.Source, &sig.Name, &sig.Count,
+			&sig.FirstSeenAt, &sig.LastSeenAt, &sig.SampleAlertID,
+			&summary.TotalAlerts, &summary.Signatures); err != nil {
+			return nil, fmt.Errorf("failed to scan unmatched signature: %w", err)
+		}
+		summary.Top = append(summary.Top, sig)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// ClaimUnmatchedReport marks the day as reported and returns true, or returns false if another
+// caller already claimed it. The single INSERT makes the claim safe across multiple
+// rule-service instances: each day is reported by exactly one of them.
+func (db *DB) ClaimUnmatchedReport(ctx context.Context, day time.Time) (bool, error) {
+	result, err := db.conn.ExecContext(ctx,
+		`INSERT INTO unmatched_reports (day, sent_at) VALUES ($1, NOW()) ON CONFLICT (day) DO NOTHING`,
+		day.Format(time.DateOnly))
+	if err != nil {
+		return false, fmt.Errorf("failed to claim unmatched report: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected == 1, nil
+}
+
+// ReleaseUnmatchedReport removes the day's claim so the report is retried.
+func (db *DB) ReleaseUnmatchedReport(ctx context.Context, day time.Time) error {
+	if _, err := db.conn.ExecContext(ctx, `DELETE FROM unmatched_reports WHERE day = $1`, day.Format(time.DateOnly)); err != nil {
+		return fmt.Errorf("failed to release unmatched report: %w", err)
+	}
+	return nil
+}
+
+// PruneUnmatchedSignatures deletes the signatures and report claims of days before the given
+// day and returns how many signatures were deleted.
+func (db *DB) PruneUnmatchedSignatures(ctx context.Context, before time.Time) (int64, error) {
+	day := before.Format(time.DateOnly)
+	result, err := db.conn.ExecContext(ctx, `DELETE FROM unmatched_alert_signatures WHERE day < $1`, day)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune unmatched signatures: %w", err)
+	}
+	if _, err := db.conn.ExecContext(ctx, `DELETE FROM unmatched_reports WHERE day < $1`, day); err != nil {
+		return 0, fmt.Errorf("failed to prune unmatched reports: %w", err)
+	}
+	return result.RowsAffected()
+}
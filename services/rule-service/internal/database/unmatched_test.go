@@ -0,0 +1,123 @@
+// Package database provides tests for unmatched alert database operations.
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// TestDB_RecordUnmatchedSignatures tests the RecordUnmatchedSignatures method.
+func TestDB_RecordUnmatchedSignatures(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	d := &DB{conn: db}
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	seen := day.Add(time.Hour)
+	sigs := []*UnmatchedSignature{
+		{ClientID: "client-1", Severity: "HIGH", Source: "api", Name: "timeout", Count: 3, FirstSeenAt: seen, LastSeenAt: seen, SampleAlertID: "a1"},
+		{Severity: "LOW", Source: "db", Name: "slow", Count: 1, FirstSeenAt: seen, LastSeenAt: seen, SampleAlertID: "a2"},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO unmatched_alert_signatures").
+		WithArgs("2024-05-01", "client-1", "HIGH", "api", "timeout", int64(3), seen, seen, "a1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO unmatched_alert_signatures").
+		WithArgs("2024-05-01", "", "LOW", "db", "slow", int64(1), seen, seen, "a2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := d.RecordUnmatchedSignatures(context.Background(), day, sigs); err != nil {
+		t.Fatalf("RecordUnmatchedSignatures() error = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Mock expectations were not met: %v", err)
+	}
+}
+
+// TestDB_SummarizeUnmatched tests the SummarizeUnmatched method.
+func TestDB_SummarizeUnmatched(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	d := &DB{conn: db}
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	seen := day.Add(time.Hour)
+	columns := []string{"severity", "source", "name", "alert_count", "first_seen_at", "last_seen_at", "sample_alert_id", "total_alerts", "signatures"}
+
+	t.Run("top signatures", func(t *testing.T) {
+		mock.ExpectQuery("FROM unmatched_alert_signatures").
+			WithArgs("2024-05-01", "client-1", 2).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow("HIGH", "api", "timeout", int64(7), seen, seen, "a1", int64(10), int64(3)).
+				AddRow("LOW", "db", "slow", int64(2), seen, seen, "a2", int64(10), int64(3)))
+
+		summary, err := d.SummarizeUnmatched(context.Background(), day, "client-1", 2)
+		if err != nil {
+			t.Fatalf("SummarizeUnmatched() error = %v", err)
+		}
+		if summary.TotalAlerts != 10 || summary.Signatures != 3 || len(summary.Top) != 2 {
+			t.Fatalf("SummarizeUnmatched() = %+v, want 10 alerts in 3 signatures", summary)
+		}
+		if top := summary.Top[0]; top.Count != 7 || top.ClientID != "client-1" || top.Source != "api" {
+			t.Errorf("Top[0] = %+v", top)
+		}
+	})
+
+	t.Run("no unmatched alerts", func(t *testing.T) {
+		mock.ExpectQuery("FROM unmatched_alert_signatures").
+			WithArgs("2024-05-01", "", 10).
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		summary, err := d.SummarizeUnmatched(context.Background(), day, "", 10)
+		if err != nil {
+			t.Fatalf("SummarizeUnmatched() error = %v", err)
+		}
+		if summary.TotalAlerts != 0 || len(summary.Top) != 0 {
+			t.Errorf("SummarizeUnmatched() = %+v, want empty", summary)
+		}
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Mock expectations were not met: %v", err)
+	}
+}
+
+// TestDB_ClaimUnmatchedReport tests the ClaimUnmatchedReport method.
+func TestDB_ClaimUnmatchedReport(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	d := &DB{conn: db}
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectExec("INSERT INTO unmatched_reports").
+		WithArgs("2024-05-01").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO unmatched_reports").
+		WithArgs("2024-05-01").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if claimed, err := d.ClaimUnmatchedReport(context.Background(), day); err != nil || !claimed {
+		t.Errorf("first ClaimUnmatchedReport() = %v, %v, want claimed", claimed, err)
+	}
+	if claimed, err := d.ClaimUnmatchedReport(context.Background(), day); err != nil || claimed {
+		t.Errorf("second ClaimUnmatchedReport() = %v, %v, want already claimed", claimed, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Mock expectations were not met: %v", err)
+	}
+}
@@ -0,0 +1,290 @@
+// Package database provides database operations for clients, rules, and endpoints.
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const webhookColumns = `webhook_id, client_id, url, secret, event_types, enabled, created_at, updated_at`
+
+const webhookDeliveryColumns = `delivery_id, webhook_id, event_type, payload, attempts, next_attempt_at, delivered_at, failed_at, COALESCE(last_error, ''), created_at`
+
+// CreateWebhook registers a meta-webhook for a client. secret is stored as given (encrypted by the caller).
+func (db *DB) CreateWebhook(ctx context.Context, clientID, url, secret string, eventTypes []string) (*ClientWebhook, error) {
+	query := `
+		INSERT INTO client_webhooks (client_id, url, secret, event_types, enabled, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, TRUE, NOW(), NOW())
+		RETURNING ` + webhookColumns
+
+	wh, err := scanWebhook(db.conn.QueryRowContext(ctx, query, clientID, url, secret, pq.Array(nonNilStrings(eventTypes))))
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" { // foreign_key_violation
+			return nil, fmt.Errorf("client not found: %s", clientID)
+		}
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+	return wh, nil
+}
+
+// GetWebhook retrieves a meta-webhook by ID.
+func (db *DB) GetWebhook(ctx context.Context, webhookID string) (*ClientWebhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM client_webhooks WHERE webhook_id = $1`
+	wh, err := scanWebhook(db.conn.QueryRowContext(ctx, query, webhookID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("webhook not found: %s", webhookID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+	return wh, nil
+}
+
+// ListWebhooks retrieves meta-webhooks, optionally filtered by client_id.
+// Webhooks are few per client, so results are not paginated.
+func (db *DB) ListWebhooks(ctx context.Context, clientID *string) ([]*ClientWebhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM client_webhooks`
+	var args []interface{}
+	if clientID != nil {
+		query += ` WHERE client_id = $1`
+		args = append(args, *clientID)
+	}
+	query += ` ORDER BY created_at DESC`
+
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	webhooks := make([]*ClientWebhook, 0)
+	for rows.Next() {
+		wh, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook: %w", err)
+		}
+		webhooks = append(webhooks, wh)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// UpdateWebhook changes the URL, event types or enabled state of a meta-webhook.
+// Nil arguments keep the current value.
+func (db *DB) UpdateWebhook(ctx context.Context, webhookID string, url *string, eventTypes *[]string, enabled *bool) (*ClientWebhook, error) {
+	var types interface{}
+	if eventTypes != nil {
+		types = pq.Array(nonNilStrings(*eventTypes))
+	}
+	query := `
+		UPDATE client_webhooks
+		SET url = COALESCE($2, url),
+		    event_types = COALESCE($3, event_types),
+		    enabled = COALESCE($4, enabled),
+		    updated_at = NOW()
+		WHERE webhook_id = $1
+		RETURNING ` + webhookColumns
+
+	wh, err := scanWebhook(db.conn.QueryRowContext(ctx, query, webhookID, url, types, enabled))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("webhook not found: %s", webhookID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update webhook: %w", err)
+	}
+	return wh, nil
+}
+
+// DeleteWebhook deletes a meta-webhook and its deliveries.
+func (db *DB) DeleteWebhook(ctx context.Context, webhookID string) error {
+	result, err := db.conn.ExecContext(ctx, `DELETE FROM client_webhooks WHERE webhook_id = $1`, webhookID)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("webhook not found: %s", webhookID)
+	}
+	return nil
+}
+
+// ListWebhookDeliveries retrieves the latest deliveries of a meta-webhook, newest first.
+func (db *DB) ListWebhookDeliveries(ctx context.Context, webhookID string, limit int) ([]*WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + `
+		FROM webhook_deliveries
+		WHERE webhook_id = $1
+		ORDER BY delivery_id DESC
+		LIMIT $2`
+
+	rows, err := db.conn.QueryContext(ctx, query, webhookID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]*WebhookDelivery, 0)
+	for rows.Next() {
+		var d WebhookDelivery
+		if err := scanWebhookDelivery(rows, &d); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, &d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// RecordPlatformEvent queues an event for every enabled meta-webhook of the client subscribed
+// to eventType. Used for events that do not come from a table trigger.
+func (db *DB) RecordPlatformEvent(ctx context.Context, clientID, eventType string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal platform event: %w", err)
+	}
+	if _, err := db.conn.ExecContext(ctx, `SELECT record_platform_event($1, $2, $3::jsonb)`, clientID, eventType, string(payload)); err != nil {
+		return fmt.Errorf("failed to record platform event: %w", err)
+	}
+	return nil
+}
+
+// ClaimWebhookDeliveries claims up to limit due deliveries, oldest first, counting the attempt
+// and pushing next_attempt_at out by lease so no other rule-service instance claims them while
+// they are being sent. SKIP LOCKED lets instances claim disjoint batches concurrently.
+func (db *DB) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*PendingWebhookDelivery, error) {
+	query := `
+		UPDATE webhook_deliveries d
+		SET attempts = d.attempts + 1,
+		    next_attempt_at = NOW() + $2 * INTERVAL '1 millisecond'
+		FROM client_webhooks w
+		WHERE w.webhook_id = d.webhook_id
+		  AND d.delivery_id IN (
+		      SELECT delivery_id FROM webhook_deliveries
+		      WHERE delivered_at IS NULL AND failed_at IS NULL AND next_attempt_at <= NOW()
+		      ORDER BY delivery_id
+		      LIMIT $1
+		      FOR UPDATE SKIP LOCKED
+		  )
+		RETURNING d.delivery_id, d.webhook_id, d.event_type, d.payload, d.attempts, d.next_attempt_at,
+		          d.delivered_at, d.failed_at, COALESCE(d.last_error, ''), d.created_at, w.url, w.secret, w.enabled`
+
+	rows, err := db.conn.QueryContext(ctx, query, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	claimed := make([]*PendingWebhookDelivery, 0)
+	for rows.Next() {
+		var p PendingWebhookDelivery
+		if err := scanWebhookDelivery(rows, &p.WebhookDelivery, &p.URL, &p.Secret, &p.WebhookEnabled); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		claimed = append(claimed, &p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return claimed, nil
+}
+
+// MarkWebhookDelivered records that a delivery was accepted by the webhook.
+func (db *DB) MarkWebhookDelivered(ctx context.Context, deliveryID int64) error {
+	_, err := db.conn.ExecContext(ctx, `UPDATE webhook_deliveries SET delivered_at = NOW(), last_error = NULL WHERE delivery_id = $1`, deliveryID)
+	if err != nil {
+		return fmt.Errorf("failed to mark webhook delivered: %w", err)
+	}
+	return nil
+}
+
+// RetryWebhookDelivery records a failed attempt and schedules the next one at nextAttempt.
+func (db *DB) RetryWebhookDelivery(ctx context.Context, deliveryID int64, nextAttempt time.Time, lastError string) error {
+	_, err := db.conn.ExecContext(ctx, `UPDATE webhook_deliveries SET next_attempt_at = $2, last_error = $3 WHERE delivery_id = $1`, deliveryID, nextAttempt, lastError)
+	if err != nil {
+		return fmt.Errorf("failed to schedule webhook retry: %w", err)
+	}
+	return nil
+}
+
+// FailWebhookDelivery records that a delivery was given up on.
+func (db *DB) FailWebhookDelivery(ctx context.Context, deliveryID int64, lastError string) error {
+	_, err := db.conn.ExecContext(ctx, `UPDATE webhook_deliveries SET failed_at = NOW(), last_error = $2 WHERE delivery_id = $1`, deliveryID, lastError)
+	if err != nil {
+		return fmt.Errorf("failed to mark webhook delivery failed: %w", err)
+	}
+	return nil
+}
+
+// PruneWebhookDeliveries deletes deliveries delivered or given up on before the given time.
+// Returns the number of rows deleted.
+func (db *DB) PruneWebhookDeliveries(ctx context.Context, before time.Time) (int64, error) {
+	result, err := db.conn.ExecContext(ctx, `DELETE FROM webhook_deliveries WHERE COALESCE(delivered_at, failed_at) < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune webhook deliveries: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// scanWebhook scans a meta-webhook from a sql.Row or sql.Rows into a ClientWebhook struct.
+func scanWebhook(scanner interface {
+	Scan(dest ...interface{}) error
+}) (*ClientWebhook, error) {
+	var wh ClientWebhook
+	var eventTypes pq.StringArray
+	if err := scanner.Scan(
+		&wh.WebhookID,
+		&wh.ClientID,
+		&wh.URL,
+		&wh.Secret,
+		&eventTypes,
+		&wh.Enabled,
+		&wh.CreatedAt,
+		&wh.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	wh.EventTypes = nonNilStrings(eventTypes)
+	return &wh, nil
+}
+
+// scanWebhookDelivery scans webhookDeliveryColumns, followed by any extra columns, into d and extra.
+func scanWebhookDelivery(scanner interface {
+	Scan(dest ...interface{}) error
+}, d *WebhookDelivery, extra ...interface{}) error {
+	var payload []byte
+	var deliveredAt, failedAt sql.NullTime
+	dest := []interface{}{
+		&d.DeliveryID,
+		&d.WebhookID,
+		&d.EventType,
+		&payload,
+		&d.Attempts,
+		&d.NextAttemptAt,
+		&deliveredAt,
+		&failedAt,
+		&d.LastError,
+		&d.CreatedAt,
+	}
+	if err := scanner.Scan(append(dest, extra...)...); err != nil {
+		return err
+	}
+	d.Payload = json.RawMessage(payload)
+	if deliveredAt.Valid {
+		d.DeliveredAt = &deliveredAt.Time
+	}
+	if failedAt.Valid {
+		d.FailedAt = &failedAt.Time
+	}
+	return nil
+}
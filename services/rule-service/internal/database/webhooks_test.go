@@ -0,0 +1,171 @@
+// Package database provides tests for meta-webhook database operations.
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+)
+
+var webhookRowColumns = []string{
+	"webhook_id", "client_id", "url", "secret", "event_types", "enabled", "created_at", "updated_at",
+}
+
+var webhookDeliveryRowColumns = []string{
+	"delivery_id", "webhook_id", "event_type", "payload", "attempts", "next_attempt_at",
+	"delivered_at", "failed_at", "last_error", "created_at",
+}
+
+// TestDB_CreateWebhook tests the CreateWebhook method.
+func TestDB_CreateWebhook(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	d := &DB{conn: db}
+	ctx := context.Background()
+	now := time.Now()
+
+	t.Run("successful create", func(t *testing.T) {
+		mock.ExpectQuery("INSERT INTO client_webhooks").
+			WithArgs("client-1", "https://example.com", "sealed", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows(webhookRowColumns).
+				AddRow("wh-1", "client-1", "https://example.com", "sealed", "{rule.created}", true, now, now))
+
+		wh, err := d.CreateWebhook(ctx, "client-1", "https://example.com", "sealed", []string{"rule.created"})
+		if err != nil {
+			t.Fatalf("CreateWebhook() error = %v", err)
+		}
+		if wh.WebhookID != "wh-1" || len(wh.EventTypes) != 1 || wh.EventTypes[0] != "rule.created" || !wh.Enabled {
+			t.Errorf("CreateWebhook() = %+v", wh)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Mock expectations were not met: %v", err)
+		}
+	})
+
+	t.Run("client not found", func(t *testing.T) {
+		mock.ExpectQuery("INSERT INTO client_webhooks").
+			WithArgs("missing", "https://example.com", "sealed", sqlmock.AnyArg()).
+			WillReturnError(&pq.Error{Code: "23503"})
+
+		_, err := d.CreateWebhook(ctx, "missing", "https://example.com", "sealed", nil)
+		if err == nil || !contains(err.Error(), "client not found") {
+			t.Errorf("CreateWebhook() error = %v, want 'client not found'", err)
+		}
+	})
+}
+
+// TestDB_UpdateWebhook tests the UpdateWebhook method.
+func TestDB_UpdateWebhook(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	d := &DB{conn: db}
+	ctx := context.Background()
+
+	mock.ExpectQuery("UPDATE client_webhooks").
+		WithArgs("wh-9", nil, nil, false).
+		WillReturnError(sql.ErrNoRows)
+
+	disabled := false
+	_, err = d.UpdateWebhook(ctx, "wh-9", nil, nil, &disabled)
+	if err == nil || !contains(err.Error(), "webhook not found") {
+		t.Errorf("UpdateWebhook() error = %v, want 'webhook not found'", err)
+	}
+}
+
+// TestDB_DeleteWebhook tests the DeleteWebhook method.
+func TestDB_DeleteWebhook(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	d := &DB{conn: db}
+	ctx := context.Background()
+
+	mock.ExpectExec("DELETE FROM client_webhooks").
+		WithArgs("wh-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := d.DeleteWebhook(ctx, "wh-1"); err != nil {
+		t.Errorf("DeleteWebhook() error = %v", err)
+	}
+
+	mock.ExpectExec("DELETE FROM client_webhooks").
+		WithArgs("wh-9").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	if err := d.DeleteWebhook(ctx, "wh-9"); err == nil || !contains(err.Error(), "webhook not found") {
+		t.Errorf("DeleteWebhook() error = %v, want 'webhook not found'", err)
+	}
+}
+
+// TestDB_RecordPlatformEvent tests that event data is passed to record_platform_event as JSON.
+func TestDB_RecordPlatformEvent(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	d := &DB{conn: db}
+
+	mock.ExpectExec("SELECT record_platform_event").
+		WithArgs("client-1", "notification.dead_lettered", `{"notification_id":"notif-1"}`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = d.RecordPlatformEvent(context.Background(), "client-1", "notification.dead_lettered", map[string]string{"notification_id": "notif-1"})
+	if err != nil {
+		t.Fatalf("RecordPlatformEvent() error = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Mock expectations were not met: %v", err)
+	}
+}
+
+// TestDB_ClaimWebhookDeliveries tests the ClaimWebhookDeliveries method.
+func TestDB_ClaimWebhookDeliveries(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	defer db.Close()
+
+	d := &DB{conn: db}
+	now := time.Now()
+	columns := append(append([]string{}, webhookDeliveryRowColumns...), "url", "secret", "enabled")
+
+	mock.ExpectQuery("UPDATE webhook_deliveries d").
+		WithArgs(50, int64(60000)).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(int64(3), "wh-1", "rule.created", []byte(`{"event_type":"rule.created"}`), 2, now, nil, nil, "timeout", now,
+				"https://example.com", "sealed", true))
+
+	claimed, err := d.ClaimWebhookDeliveries(context.Background(), 50, time.Minute)
+	if err != nil {
+		t.Fatalf("ClaimWebhookDeliveries() error = %v", err)
+	}
+	if len(claimed) != 1 {
+		t.Fatalf("ClaimWebhookDeliveries() returned %d deliveries, want 1", len(claimed))
+	}
+	p := claimed[0]
+	if p.DeliveryID != 3 || p.Attempts != 2 || p.URL != "https://example.com" || p.Secret != "sealed" || !p.WebhookEnabled {
+		t.Errorf("ClaimWebhookDeliveries() = %+v", p)
+	}
+	if p.DeliveredAt != nil || p.FailedAt != nil || p.LastError != "timeout" || string(p.Payload) != `{"event_type":"rule.created"}` {
+		t.Errorf("ClaimWebhookDeliveries() delivery = %+v", p.WebhookDelivery)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Mock expectations were not met: %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+// Package events defines the event structures for rule-service.
+package events
+
+import (
+	protocommon "github.com/afikmenashe/alerting-platform/pkg/proto/common"
+)
+
+// AlertNew represents an alert published to the alerts.new topic.
+// rule-service only produces synthetic alerts (e.g. missed heartbeats).
+type AlertNew struct {
+	AlertID       string            `json:"alert_id"`
+	SchemaVersion int               `json:"schema_version"`
+	EventTS       int64             `json:"event_ts"` // Unix timestamp
+	Severity      string            `json:"severity"`
+	Source        string            `json:"source"`
+	Name          string            `json:"name"`
+	Context       map[string]string `json:"context,omitempty"`
+	ClientHint    string            `json:"client_hint,omitempty"` // Restricts matching to this client's rules
+}
+
+// UnmatchedAlert is an alert that matched no rule, read from the evaluator's alerts.unmatched
+// topic to count unmatched alert signatures.
+type UnmatchedAlert struct {
+	AlertID     string            `json:"alert_id"`
+	EventTS     int64             `json:"event_ts"`
+	Severity    string            `json:"severity"`
+	Source      string            `json:"source"`
+	Name        string            `json:"name"`
+	Context     map[string]string `json:"context,omitempty"`
+	ClientHint  string            `json:"client_hint,omitempty"`
+	RuleCount   int               `json:"rule_count"`   // Rules loaded when the alert was evaluated
+	EvaluatedAt int64             `json:"evaluated_at"` // Unix milliseconds
+}
+
+// ToProtoSeverity converts a severity string to the protobuf Severity enum.
+func ToProtoSeverity(severity string) protocommon.Severity {
+	switch severity {
+	case "LOW":
+		return protocommon.Severity_LOW
+	case "MEDIUM":
+		return protocommon.Severity_MEDIUM
+	case "HIGH":
+		return protocommon.Severity_HIGH
+	case "CRITICAL":
+		return protocommon.Severity_CRITICAL
+	default:
+		return protocommon.Severity_UNSPECIFIED
+	}
+}
@@ -3,16 +3,40 @@ package events
 
 import (
 	protocommon "github.com/afikmenashe/alerting-platform/pkg/proto/common"
+	protoendpoints "github.com/afikmenashe/alerting-platform/pkg/proto/endpoints"
 )
 
 // RuleChanged represents a rule change event published to rule.changed topic.
 type RuleChanged struct {
 	RuleID        string `json:"rule_id"`
 	ClientID      string `json:"client_id"`
-	Action        string `json:"action"` // CREATED, UPDATED, DELETED, DISABLED
+	Action        string `json:"action"` // CREATED, UPDATED, DELETED, DISABLED, REBUILD
 	Version       int    `json:"version"`
 	UpdatedAt     int64  `json:"updated_at"` // Unix timestamp
 	SchemaVersion int    `json:"schema_version"`
+	// Rule carries the rule's matching fields after the change; nil for DELETED.
+	Rule *RulePayload `json:"rule,omitempty"`
+	// PublishedAtMs is when the event was published (Unix milliseconds), used to measure rule propagation.
+	PublishedAtMs int64 `json:"published_at_ms,omitempty"`
+}
+
+// RulePayload is the subset of rule fields consumers need to apply a change without a DB lookup.
+type RulePayload struct {
+	Severity       string   `json:"severity"`
+	Source         string   `json:"source"`
+	Name           string   `json:"name"`
+	Enabled        bool     `json:"enabled"`
+	ExcludeSources []string `json:"exclude_sources,omitempty"`
+	ExcludeNames   []string `json:"exclude_names,omitempty"`
+	// Conditions on the alert context, all of which must hold for the rule to match.
+	Conditions []ContextCondition `json:"conditions,omitempty"`
+}
+
+// ContextCondition matches one key of an alert's context with == or !=.
+type ContextCondition struct {
+	Key   string `json:"key"`
+	Op    string `json:"op"`
+	Value string `json:"value"`
 }
 
 // Valid actions for RuleChanged
@@ -21,6 +45,9 @@ const (
 	ActionUpdated  = "UPDATED"
 	ActionDeleted  = "DELETED"
 	ActionDisabled = "DISABLED"
+	// ActionRebuild announces that many rules of ClientID changed at once (a bulk import).
+	// It has no rule_id or payload: consumers rebuild or reload all rules instead.
+	ActionRebuild = "REBUILD"
 )
 
 // ToProtoAction converts a string action to the protobuf RuleAction enum.
@@ -35,7 +62,46 @@ func ToProtoAction(action string) protocommon.RuleAction {
 		return protocommon.RuleAction_RULE_ACTION_DELETED
 	case ActionDisabled:
 		return protocommon.RuleAction_RULE_ACTION_DISABLED
+	case ActionRebuild:
+		return protocommon.RuleAction_RULE_ACTION_REBUILD
 	default:
 		return protocommon.RuleAction_RULE_ACTION_UNSPECIFIED
 	}
 }
+
+// EndpointChanged represents an endpoint change event published to endpoint.changed topic.
+// The endpoint's value and metadata are not included: they hold addresses and secrets.
+type EndpointChanged struct {
+	EndpointID    string `json:"endpoint_id"`
+	RuleID        string `json:"rule_id"`
+	ClientID      string `json:"client_id"` // empty when the endpoint was deleted along with its rule
+	Action        string `json:"action"`    // CREATED, UPDATED, DELETED, ENABLED, DISABLED
+	Type          string `json:"type"`
+	Enabled       bool   `json:"enabled"`
+	ChangedAt     int64  `json:"changed_at"` // Unix timestamp
+	SchemaVersion int    `json:"schema_version"`
+	PublishedAtMs int64  `json:"published_at_ms,omitempty"`
+}
+
+// Valid actions for EndpointChanged, in addition to CREATED, UPDATED and DELETED
+const (
+	ActionEnabled = "ENABLED"
+)
+
+// ToProtoEndpointAction converts a string action to the protobuf EndpointAction enum.
+func ToProtoEndpointAction(action string) protoendpoints.EndpointAction {
+	switch action {
+	case ActionCreated:
+		return protoendpoints.EndpointAction_ENDPOINT_ACTION_CREATED
+	case ActionUpdated:
+		return protoendpoints.EndpointAction_ENDPOINT_ACTION_UPDATED
+	case ActionDeleted:
+		return protoendpoints.EndpointAction_ENDPOINT_ACTION_DELETED
+	case ActionEnabled:
+		return protoendpoints.EndpointAction_ENDPOINT_ACTION_ENABLED
+	case ActionDisabled:
+		return protoendpoints.EndpointAction_ENDPOINT_ACTION_DISABLED
+	default:
+		return protoendpoints.EndpointAction_ENDPOINT_ACTION_UNSPECIFIED
+	}
+}
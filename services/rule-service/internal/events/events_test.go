@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	protocommon "github.com/afikmenashe/alerting-platform/pkg/proto/common"
+	protoendpoints "github.com/afikmenashe/alerting-platform/pkg/proto/endpoints"
 )
 
 func TestToProtoAction(t *testing.T) {
@@ -16,6 +17,7 @@ func TestToProtoAction(t *testing.T) {
 		{ActionUpdated, protocommon.RuleAction_RULE_ACTION_UPDATED},
 		{ActionDeleted, protocommon.RuleAction_RULE_ACTION_DELETED},
 		{ActionDisabled, protocommon.RuleAction_RULE_ACTION_DISABLED},
+		{ActionRebuild, protocommon.RuleAction_RULE_ACTION_REBUILD},
 		{"UNKNOWN", protocommon.RuleAction_RULE_ACTION_UNSPECIFIED},
 		{"", protocommon.RuleAction_RULE_ACTION_UNSPECIFIED},
 	}
@@ -29,3 +31,49 @@ func TestToProtoAction(t *testing.T) {
 		})
 	}
 }
+
+func TestToProtoEndpointAction(t *testing.T) {
+	tests := []struct {
+		action   string
+		expected protoendpoints.EndpointAction
+	}{
+		{ActionCreated, protoendpoints.EndpointAction_ENDPOINT_ACTION_CREATED},
+		{ActionUpdated, protoendpoints.EndpointAction_ENDPOINT_ACTION_UPDATED},
+		{ActionDeleted, protoendpoints.EndpointAction_ENDPOINT_ACTION_DELETED},
+		{ActionEnabled, protoendpoints.EndpointAction_ENDPOINT_ACTION_ENABLED},
+		{ActionDisabled, protoendpoints.EndpointAction_ENDPOINT_ACTION_DISABLED},
+		{"UNKNOWN", protoendpoints.EndpointAction_ENDPOINT_ACTION_UNSPECIFIED},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			got := ToProtoEndpointAction(tt.action)
+			if got != tt.expected {
+				t.Errorf("ToProtoEndpointAction(%q) = %v, want %v", tt.action, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestToProtoSeverity(t *testing.T) {
+	tests := []struct {
+		severity string
+		expected protocommon.Severity
+	}{
+		{"LOW", protocommon.Severity_LOW},
+		{"MEDIUM", protocommon.Severity_MEDIUM},
+		{"HIGH", protocommon.Severity_HIGH},
+		{"CRITICAL", protocommon.Severity_CRITICAL},
+		{"*", protocommon.Severity_UNSPECIFIED},
+		{"", protocommon.Severity_UNSPECIFIED},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.severity, func(t *testing.T) {
+			got := ToProtoSeverity(tt.severity)
+			if got != tt.expected {
+				t.Errorf("ToProtoSeverity(%q) = %v, want %v", tt.severity, got, tt.expected)
+			}
+		})
+	}
+}
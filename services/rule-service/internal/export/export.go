@@ -0,0 +1,213 @@
+// Package export encodes notifications as CSV or NDJSON for compliance exports, and runs
+// large exports asynchronously to files.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"rule-service/internal/database"
+)
+
+// Format is an export file format.
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+)
+
+// ParseFormat parses an export format. Empty defaults to CSV.
+func ParseFormat(s string) (Format, error) {
+	switch Format(strings.ToLower(strings.TrimSpace(s))) {
+	case "", FormatCSV:
+		return FormatCSV, nil
+	case FormatNDJSON:
+		return FormatNDJSON, nil
+	default:
+		return "", fmt.Errorf("format must be one of: csv, ndjson")
+	}
+}
+
+// ContentType returns the MIME type of the format.
+func (f Format) ContentType() string {
+	if f == FormatNDJSON {
+		return "application/x-ndjson"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Extension returns the file extension of the format, without the dot.
+func (f Format) Extension() string {
+	return string(f)
+}
+
+// Columns are the exportable notification columns, in default order.
+var Columns = []string{
+	"notification_id",
+	"client_id",
+	"alert_id",
+	"severity",
+	"source",
+	"name",
+	"status",
+	"rule_ids",
+	"context",
+	"created_at",
+	"updated_at",
+}
+
+// ParseColumns parses a comma-separated column list, preserving order.
+// Empty selects all Columns. Unknown or repeated columns are rejected.
+func ParseColumns(s string) ([]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return Columns, nil
+	}
+
+	var columns []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !isColumn(name) {
+			return nil, fmt.Errorf("unknown column %q, must be one of: %s", name, strings.Join(Columns, ", "))
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("column %q is repeated", name)
+		}
+		seen[name] = true
+		columns = append(columns, name)
+	}
+	if len(columns) == 0 {
+		return Columns, nil
+	}
+	return columns, nil
+}
+
+func isColumn(name string) bool {
+	for _, c := range Columns {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Encoder writes notifications in an export format.
+type Encoder interface {
+	// Encode writes one notification.
+	Encode(n *database.Notification) error
+	// Flush writes any buffered data to the underlying writer.
+	Flush() error
+}
+
+// NewEncoder returns an encoder writing the given columns to w. A CSV encoder writes
+// its header row immediately.
+func NewEncoder(w io.Writer, format Format, columns []string) (Encoder, error) {
+	if format == FormatNDJSON {
+		return &ndjsonEncoder{enc: json.NewEncoder(w), columns: columns}, nil
+	}
+	enc := &csvEncoder{w: csv.NewWriter(w), columns: columns, record: make([]string, len(columns))}
+	if err := enc.w.Write(columns); err != nil {
+		return nil, fmt.Errorf("failed to write csv header: %w", err)
+	}
+	return enc, nil
+}
+
+type csvEncoder struct {
+	w       *csv.Writer
+	columns []string
+	record  []string
+}
+
+func (e *csvEncoder) Encode(n *database.Notification) error {
+	for i, column := range e.columns {
+		e.record[i] = csvValue(n, column)
+	}
+	return e.w.Write(e.record)
+}
+
+func (e *csvEncoder) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type ndjsonEncoder struct {
+	enc     *json.Encoder
+	columns []string
+}
+
+func (e *ndjsonEncoder) Encode(n *database.Notification) error {
+	row := make(map[string]interface{}, len(e.columns))
+	for _, column := range e.columns {
+		row[column] = jsonValue(n, column)
+	}
+	return e.enc.Encode(row)
+}
+
+func (e *ndjsonEncoder) Flush() error {
+	return nil
+}
+
+// jsonValue returns the value of column for NDJSON output.
+func jsonValue(n *database.Notification, column string) interface{} {
+	switch column {
+	case "rule_ids":
+		if n.RuleIDs == nil {
+			return []string{}
+		}
+		return n.RuleIDs
+	case "context":
+		return n.Context
+	case "created_at":
+		return n.CreatedAt.UTC().Format(time.RFC3339Nano)
+	case "updated_at":
+		return n.UpdatedAt.UTC().Format(time.RFC3339Nano)
+	default:
+		return csvValue(n, column)
+	}
+}
+
+// csvValue returns the value of column as a single CSV field. Rule IDs are joined
+// with ";" and the context is encoded as a JSON object.
+func csvValue(n *database.Notification, column string) string {
+	switch column {
+	case "notification_id":
+		return n.NotificationID
+	case "client_id":
+		return n.ClientID
+	case "alert_id":
+		return n.AlertID
+	case "severity":
+		return n.Severity
+	case "source":
+		return n.Source
+	case "name":
+		return n.Name
+	case "status":
+		return n.Status
+	case "rule_ids":
+		return strings.Join(n.RuleIDs, ";")
+	case "context":
+		if len(n.Context) == 0 {
+			return "{}"
+		}
+		b, err := json.Marshal(n.Context)
+		if err != nil {
+			return "{}"
+		}
+		return string(b)
+	case "created_at":
+		return n.CreatedAt.UTC().Format(time.RFC3339Nano)
+	case "updated_at":
+		return n.UpdatedAt.UTC().Format(time.RFC3339Nano)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,175 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"rule-service/internal/database"
+)
+
+func testNotification() *database.Notification {
+	created := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	return &database.Notification{
+		NotificationID: "notif-1",
+		ClientID:       "client-1",
+		AlertID:        "alert-1",
+		Severity:       "HIGH",
+		Source:         "api",
+		Name:           "timeout, upstream",
+		Context:        map[string]string{"region": "eu"},
+		RuleIDs:        []string{"rule-1", "rule-2"},
+		Status:         "SENT",
+		CreatedAt:      created,
+		UpdatedAt:      created.Add(time.Minute),
+	}
+}
+
+func TestParseColumns(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []string
+		wantErr bool
+	}{
+		{in: "", want: Columns},
+		{in: "status, notification_id", want: []string{"status", "notification_id"}},
+		{in: "status,bogus", wantErr: true},
+		{in: "status,status", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseColumns(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseColumns(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("ParseColumns(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncoder_CSV(t *testing.T) {
+	var buf bytes.Buffer
+	enc, err := NewEncoder(&buf, FormatCSV, []string{"notification_id", "name", "rule_ids", "context", "created_at"})
+	if err != nil {
+		t.Fatalf("NewEncoder() error = %v", err)
+	}
+	if err := enc.Encode(testNotification()); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if err := enc.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	want := "notification_id,name,rule_ids,context,created_at\n" +
+		`notif-1,"timeout, upstream",rule-1;rule-2,"{""region"":""eu""}",2026-03-01T12:00:00Z` + "\n"
+	if buf.String() != want {
+		t.Errorf("CSV output = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestEncoder_NDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	enc, _ := NewEncoder(&buf, FormatNDJSON, []string{"notification_id", "rule_ids", "context"})
+	enc.Encode(testNotification())
+	enc.Encode(testNotification())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("NDJSON output has %d lines, want 2", len(lines))
+	}
+	var row map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &row); err != nil {
+		t.Fatalf("failed to decode NDJSON line: %v", err)
+	}
+	if len(row) != 3 || row["notification_id"] != "notif-1" {
+		t.Errorf("NDJSON row = %v, want only the selected columns", row)
+	}
+	if ids, ok := row["rule_ids"].([]interface{}); !ok || len(ids) != 2 {
+		t.Errorf("rule_ids = %v, want a JSON array", row["rule_ids"])
+	}
+}
+
+// fakeExporter streams a fixed number of notifications.
+type fakeExporter struct {
+	rows int
+	err  error
+}
+
+func (f *fakeExporter) ExportNotifications(ctx context.Context, filter database.NotificationExportFilter, fn func(*database.Notification) error) error {
+	for i := 0; i < f.rows; i++ {
+		if err := fn(testNotification()); err != nil {
+			return err
+		}
+	}
+	return f.err
+}
+
+func waitForJob(t *testing.T, m *Manager, id string) Job {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := m.Get(id)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		if job.Status != JobStatusRunning {
+			return job
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("export job did not finish")
+	return Job{}
+}
+
+func TestManager_Job(t *testing.T) {
+	m, err := NewManager(&fakeExporter{rows: 3}, t.TempDir(), time.Minute)
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+
+	started, err := m.Start(Request{Filter: database.NotificationExportFilter{ClientID: "client-1"}, Format: FormatCSV, Columns: []string{"notification_id"}})
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	job := waitForJob(t, m, started.JobID)
+	if job.Status != JobStatusCompleted || job.Rows != 3 || job.ExpiresAt == nil {
+		t.Fatalf("job = %+v, want completed with 3 rows", job)
+	}
+
+	f, _, err := m.Open(job.JobID)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	content, _ := io.ReadAll(f)
+	f.Close()
+	if want := "notification_id\nnotif-1\nnotif-1\nnotif-1\n"; string(content) != want {
+		t.Errorf("export file = %q, want %q", content, want)
+	}
+
+	// Expired jobs are removed with their files
+	m.prune(job.ExpiresAt.Add(time.Second))
+	if _, err := m.Get(job.JobID); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("Get() after expiry error = %v, want ErrJobNotFound", err)
+	}
+}
+
+func TestManager_FailedJob(t *testing.T) {
+	m, _ := NewManager(&fakeExporter{rows: 1, err: errors.New("connection reset")}, t.TempDir(), time.Minute)
+
+	started, _ := m.Start(Request{Format: FormatNDJSON, Columns: Columns})
+	job := waitForJob(t, m, started.JobID)
+	if job.Status != JobStatusFailed || !strings.Contains(job.Error, "connection reset") {
+		t.Fatalf("job = %+v, want failed with the export error", job)
+	}
+	if _, _, err := m.Open(job.JobID); !errors.Is(err, ErrJobNotReady) {
+		t.Errorf("Open() error = %v, want ErrJobNotReady", err)
+	}
+}
@@ -0,0 +1,259 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"rule-service/internal/database"
+
+	"github.com/afikmenashe/alerting-platform/pkg/ids"
+)
+
+// Export job defaults.
+const (
+	// DefaultJobTTL is how long a finished export job and its file are kept.
+	DefaultJobTTL = time.Hour
+	// MaxRunningJobs bounds concurrent export jobs, each of which holds a database connection.
+	MaxRunningJobs = 2
+	// pruneInterval is how often expired jobs are removed.
+	pruneInterval = time.Minute
+)
+
+var (
+	// ErrJobNotFound is returned for unknown or expired jobs.
+	ErrJobNotFound = errors.New("export job not found")
+	// ErrJobNotReady is returned when downloading a job that has not completed.
+	ErrJobNotReady = errors.New("export job is not completed")
+	// ErrTooManyJobs is returned when MaxRunningJobs are already running.
+	ErrTooManyJobs = errors.New("too many export jobs running")
+)
+
+// Exporter streams notifications from the database.
+type Exporter interface {
+	ExportNotifications(ctx context.Context, filter database.NotificationExportFilter, fn func(*database.Notification) error) error
+}
+
+// JobStatus is the status of an export job.
+type JobStatus string
+
+const (
+	JobStatusRunning   JobStatus = "running"
+	JobStatusCompleted JobStatus = "completed"
+	JobStatusFailed    JobStatus = "failed"
+)
+
+// Request describes an export.
+type Request struct {
+	Filter  database.NotificationExportFilter
+	Format  Format
+	Columns []string
+}
+
+// Job is an asynchronous export to a file.
+type Job struct {
+	JobID       string     `json:"job_id"`
+	Status      JobStatus  `json:"status"`
+	ClientID    string     `json:"client_id"`
+	From        time.Time  `json:"from"`
+	To          time.Time  `json:"to"`
+	Statuses    []string   `json:"statuses,omitempty"`
+	Format      Format     `json:"format"`
+	Columns     []string   `json:"columns"`
+	Rows        int64      `json:"rows"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+
+	path string
+}
+
+// Manager runs export jobs, writing each to a file in its directory. Jobs are kept in
+// memory, so a job is only visible on the instance that started it.
+// It is safe for concurrent use.
+type Manager struct {
+	exporter Exporter
+	dir      string
+	ttl      time.Duration
+
+	mu      sync.Mutex
+	jobs    map[string]*Job
+	running int
+	ctx     context.Context
+}
+
+// NewManager creates a manager writing export files to dir, which is created if needed.
+// A non-positive ttl uses DefaultJobTTL.
+func NewManager(exporter Exporter, dir string, ttl time.Duration) (*Manager, error) {
+	if ttl <= 0 {
+		ttl = DefaultJobTTL
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create export directory: %w", err)
+	}
+	return &Manager{
+		exporter: exporter,
+		dir:      dir,
+		ttl:      ttl,
+		jobs:     make(map[string]*Job),
+		ctx:      context.Background(),
+	}, nil
+}
+
+// Run removes expired jobs and their files until ctx is cancelled. Jobs started
+// afterwards are cancelled with ctx.
+func (m *Manager) Run(ctx context.Context) {
+	m.mu.Lock()
+	m.ctx = ctx
+	m.mu.Unlock()
+
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.prune(time.Now())
+		}
+	}
+}
+
+// Start starts an export job in the background.
+func (m *Manager) Start(req Request) (Job, error) {
+	id := ids.New()
+
+	m.mu.Lock()
+	if m.running >= MaxRunningJobs {
+		m.mu.Unlock()
+		return Job{}, ErrTooManyJobs
+	}
+	job := &Job{
+		JobID:     id,
+		Status:    JobStatusRunning,
+		ClientID:  req.Filter.ClientID,
+		From:      req.Filter.From,
+		To:        req.Filter.To,
+		Statuses:  req.Filter.Statuses,
+		Format:    req.Format,
+		Columns:   req.Columns,
+		CreatedAt: time.Now().UTC(),
+		path:      filepath.Join(m.dir, id+"."+req.Format.Extension()),
+	}
+	m.jobs[id] = job
+	m.running++
+	ctx := m.ctx
+	snapshot := *job
+	m.mu.Unlock()
+
+	go m.run(ctx, job, req)
+	return snapshot, nil
+}
+
+// Get returns a copy of the job.
+func (m *Manager) Get(id string) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[id]
+	if !ok {
+		return Job{}, ErrJobNotFound
+	}
+	return *job, nil
+}
+
+// Open opens the file of a completed job. The caller closes it.
+func (m *Manager) Open(id string) (*os.File, Job, error) {
+	job, err := m.Get(id)
+	if err != nil {
+		return nil, Job{}, err
+	}
+	if job.Status != JobStatusCompleted {
+		return nil, job, ErrJobNotReady
+	}
+	f, err := os.Open(job.path)
+	if err != nil {
+		return nil, job, fmt.Errorf("failed to open export file: %w", err)
+	}
+	return f, job, nil
+}
+
+// run writes the export file and records the outcome. A failed export's partial file is removed.
+func (m *Manager) run(ctx context.Context, job *Job, req Request) {
+	rows, err := m.write(ctx, job.path, req)
+	if err != nil {
+		os.Remove(job.path)
+		slog.Error("Export job failed", "job_id", job.JobID, "client_id", job.ClientID, "rows", rows, "error", err)
+	} else {
+		slog.Info("Export job completed", "job_id", job.JobID, "client_id", job.ClientID, "rows", rows)
+	}
+
+	now := time.Now().UTC()
+	expiresAt := now.Add(m.ttl)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.running--
+	job.Rows = rows
+	job.CompletedAt = &now
+	job.ExpiresAt = &expiresAt
+	if err != nil {
+		job.Status = JobStatusFailed
+		job.Error = err.Error()
+	} else {
+		job.Status = JobStatusCompleted
+	}
+}
+
+// write streams the export to path and returns the number of rows written.
+func (m *Manager) write(ctx context.Context, path string, req Request) (int64, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create export file: %w", err)
+	}
+	defer f.Close()
+
+	enc, err := NewEncoder(f, req.Format, req.Columns)
+	if err != nil {
+		return 0, err
+	}
+
+	var rows int64
+	err = m.exporter.ExportNotifications(ctx, req.Filter, func(n *database.Notification) error {
+		if err := enc.Encode(n); err != nil {
+			return fmt.Errorf("failed to write export row: %w", err)
+		}
+		rows++
+		return nil
+	})
+	if err != nil {
+		return rows, err
+	}
+	if err := enc.Flush(); err != nil {
+		return rows, fmt.Errorf("failed to write export file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return rows, fmt.Errorf("failed to write export file: %w", err)
+	}
+	return rows, nil
+}
+
+// prune removes finished jobs that expired before now, with their files.
+func (m *Manager) prune(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, job := range m.jobs {
+		if job.ExpiresAt == nil || job.ExpiresAt.After(now) {
+			continue
+		}
+		if err := os.Remove(job.path); err != nil && !os.IsNotExist(err) {
+			slog.Warn("Failed to remove expired export file", "job_id", id, "error", err)
+		}
+		delete(m.jobs, id)
+	}
+}
@@ -0,0 +1,147 @@
+// Package handlers provides HTTP handlers for the rule-service API.
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"rule-service/internal/database"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// maxAPIKeyNameLength bounds the label of an API key.
+const maxAPIKeyNameLength = 255
+
+// CreateAPIKeyRequest issues an API key. An empty client_id issues an admin key,
+// which is not scoped to a client.
+type CreateAPIKeyRequest struct {
+	ClientID string `json:"client_id,omitempty"`
+	Name     string `json:"name"`
+}
+
+func (req *CreateAPIKeyRequest) validate(v *shared.Validator) {
+	if v.Required("name", req.Name) {
+		v.MaxLength("name", req.Name, maxAPIKeyNameLength)
+	}
+}
+
+// CreateAPIKeyResponse is the issued API key with the key itself, which is only ever returned here.
+type CreateAPIKeyResponse struct {
+	*database.APIKey
+	Key string `json:"key"`
+}
+
+// requireAPIKeys writes a 501 response and returns false if API keys are not configured.
+func (h *Handlers) requireAPIKeys(w http.ResponseWriter) bool {
+	if h.apiKeys == nil {
+		http.Error(w, "API keys are not configured", http.StatusNotImplemented)
+		return false
+	}
+	return true
+}
+
+// CreateAPIKey issues an API key and returns it. Client-scoped callers can only issue keys for
+// their own client; admin keys need an admin caller.
+// POST /api/v1/api-keys
+func (h *Handlers) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost) || !h.requireAPIKeys(w) {
+		return
+	}
+
+	var req CreateAPIKeyRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if !validateRequest(w, &req) {
+		return
+	}
+	if req.ClientID == "" && !h.requireAdminToken(w, r) {
+		return
+	}
+	if req.ClientID != "" && !authorizeClient(w, r, req.ClientID) {
+		return
+	}
+
+	key, err := shared.GenerateAPIKey()
+	if err != nil {
+		slog.Error("Failed to generate API key", "error", err)
+		http.Error(w, "Failed to create API key", http.StatusInternalServerError)
+		return
+	}
+
+	apiKey, err := h.apiKeys.CreateAPIKey(r.Context(), req.ClientID, req.Name, shared.HashAPIKey(key), shared.APIKeyDisplayPrefix(key))
+	if err != nil && strings.Contains(err.Error(), "client not found") {
+		http.Error(w, "Client not found", http.StatusNotFound)
+		return
+	}
+	if handleDBError(w, err, "API key", req.ClientID) {
+		return
+	}
+
+	slog.Info("Issued API key", "key_id", apiKey.KeyID, "client_id", apiKey.ClientID, "name", apiKey.Name)
+	h.metrics.IncrementCustom("api_keys_created")
+	writeJSON(w, http.StatusCreated, CreateAPIKeyResponse{APIKey: apiKey, Key: key})
+}
+
+// ListAPIKeys lists API keys, newest first, optionally filtered by client_id. An empty client_id
+// filter value lists admin keys. Client-scoped callers only see their own client's keys.
+// GET /api/v1/api-keys[?client_id=]
+func (h *Handlers) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet) || !h.requireAPIKeys(w) {
+		return
+	}
+
+	var clientID *string
+	if q := r.URL.Query(); q.Has("client_id") {
+		v := q.Get("client_id")
+		clientID = &v
+	}
+	clientID, ok := scopeClientFilter(w, r, clientID)
+	if !ok {
+		return
+	}
+
+	keys, err := h.apiKeys.ListAPIKeys(r.Context(), clientID)
+	if err != nil {
+		slog.Error("Failed to list API keys", "error", err)
+		http.Error(w, "Failed to list API keys", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, keys)
+}
+
+// RevokeAPIKey revokes an API key. Revoking a revoked key succeeds and keeps its revocation time.
+// Authenticators cache valid keys, so a revoked key may keep working for up to their cache TTL.
+// POST /api/v1/api-keys/revoke?key_id=
+func (h *Handlers) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost) || !h.requireAPIKeys(w) {
+		return
+	}
+
+	keyID, ok := requireQueryParam(w, r, "key_id")
+	if !ok {
+		return
+	}
+	if _, scoped := scopedClient(r); scoped {
+		apiKey, err := h.apiKeys.GetAPIKey(r.Context(), keyID)
+		if handleDBError(w, err, "API key", keyID) {
+			return
+		}
+		if !authorizeOwner(w, r, "API key", apiKey.ClientID) {
+			return
+		}
+	}
+
+	apiKey, err := h.apiKeys.RevokeAPIKey(r.Context(), keyID)
+	if handleDBError(w, err, "API key", keyID) {
+		return
+	}
+
+	slog.Info("Revoked API key", "key_id", apiKey.KeyID, "client_id", apiKey.ClientID)
+	h.metrics.IncrementCustom("api_keys_revoked")
+	writeJSON(w, http.StatusOK, apiKey)
+}
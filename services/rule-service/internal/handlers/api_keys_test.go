@@ -0,0 +1,307 @@
+// Package handlers provides tests for the API key endpoints and client scoping.
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"rule-service/internal/database"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// fakeAPIKeyStore is an in-memory APIKeyStore.
+type fakeAPIKeyStore struct {
+	keys   map[string]*database.APIKey
+	hashes map[string]string // key_id -> key_hash
+}
+
+func newFakeAPIKeyStore() *fakeAPIKeyStore {
+	return &fakeAPIKeyStore{keys: make(map[string]*database.APIKey), hashes: make(map[string]string)}
+}
+
+func (f *fakeAPIKeyStore) CreateAPIKey(ctx context.Context, clientID, name, keyHash, keyPrefix string) (*database.APIKey, error) {
+	if clientID == "missing" {
+		return nil, fmt.Errorf("client not found: %s", clientID)
+	}
+	k := &database.APIKey{
+		KeyID:     fmt.Sprintf("key-%d", len(f.keys)+1),
+		ClientID:  clientID,
+		Name:      name,
+		KeyPrefix: keyPrefix,
+		CreatedAt: time.Now(),
+	}
+	f.keys[k.KeyID] = k
+	f.hashes[k.KeyID] = keyHash
+	return k, nil
+}
+
+func (f *fakeAPIKeyStore) GetAPIKey(ctx context.Context, keyID string) (*database.APIKey, error) {
+	k, ok := f.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("api key not found: %s", keyID)
+	}
+	return k, nil
+}
+
+func (f *fakeAPIKeyStore) ListAPIKeys(ctx context.Context, clientID *string) ([]*database.APIKey, error) {
+	out := []*database.APIKey{}
+	for _, k := range f.keys {
+		if clientID == nil || k.ClientID == *clientID {
+			out = append(out, k)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeAPIKeyStore) RevokeAPIKey(ctx context.Context, keyID string) (*database.APIKey, error) {
+	k, ok := f.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("api key not found: %s", keyID)
+	}
+	if k.RevokedAt == nil {
+		now := time.Now()
+		k.RevokedAt = &now
+	}
+	return k, nil
+}
+
+// asClient returns r authenticated with an API key scoped to clientID, or with an admin key
+// if clientID is empty.
+func asClient(r *http.Request, clientID string) *http.Request {
+	p := shared.APIPrincipal{KeyID: "caller", Name: "test", ClientID: clientID}
+	return r.WithContext(shared.WithAPIPrincipal(r.Context(), p))
+}
+
+func clientPtr(clientID string) *string {
+	return &clientID
+}
+
+func newAPIKeyHandlers(store APIKeyStore) *Handlers {
+	h := NewHandlersWithDeps(&mockRepository{}, &mockPublisher{}, nil)
+	WithAPIKeys(store)(h)
+	return h
+}
+
+// TestHandlers_CreateAPIKey tests that a key is returned once and only its hash is stored.
+func TestHandlers_CreateAPIKey(t *testing.T) {
+	store := newFakeAPIKeyStore()
+	h := newAPIKeyHandlers(store)
+
+	w := httptest.NewRecorder()
+	h.CreateAPIKey(w, httptest.NewRequest(http.MethodPost, "/api/v1/api-keys", strings.NewReader(`{"client_id":"client-1","name":" ci "}`)))
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("CreateAPIKey() status = %v, want %v: %s", w.Code, http.StatusCreated, w.Body.String())
+	}
+	var resp CreateAPIKeyResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !strings.HasPrefix(resp.Key, shared.APIKeyPrefix) {
+		t.Errorf("key = %q, want prefix %q", resp.Key, shared.APIKeyPrefix)
+	}
+	if resp.Name != "ci" || resp.ClientID != "client-1" {
+		t.Errorf("key = %+v, want name ci for client-1", resp.APIKey)
+	}
+	if got := store.hashes[resp.KeyID]; got != shared.HashAPIKey(resp.Key) {
+		t.Errorf("stored hash = %q, want hash of the returned key", got)
+	}
+	if resp.KeyPrefix != shared.APIKeyDisplayPrefix(resp.Key) {
+		t.Errorf("key_prefix = %q, want %q", resp.KeyPrefix, shared.APIKeyDisplayPrefix(resp.Key))
+	}
+}
+
+// TestHandlers_CreateAPIKey_Rejected tests the validation and authorization of key issuance.
+func TestHandlers_CreateAPIKey_Rejected(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		caller     *string // client of the caller's key; nil for no API key
+		adminToken string
+		wantStatus int
+	}{
+		{name: "missing name", body: `{"client_id":"client-1"}`, wantStatus: http.StatusBadRequest},
+		{name: "unknown client", body: `{"client_id":"missing","name":"ci"}`, wantStatus: http.StatusNotFound},
+		{name: "other client", body: `{"client_id":"client-2","name":"ci"}`, caller: clientPtr("client-1"), wantStatus: http.StatusForbidden},
+		{name: "admin key by client key", body: `{"name":"ops"}`, caller: clientPtr("client-1"), wantStatus: http.StatusForbidden},
+		{name: "admin key without admin token", body: `{"name":"ops"}`, adminToken: "secret", wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeAPIKeyStore()
+			h := newAPIKeyHandlers(store)
+			h.adminToken = tt.adminToken
+
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/api-keys", strings.NewReader(tt.body))
+			if tt.caller != nil {
+				r = asClient(r, *tt.caller)
+			}
+			w := httptest.NewRecorder()
+			h.CreateAPIKey(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("CreateAPIKey() status = %v, want %v: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if len(store.keys) != 0 {
+				t.Errorf("store has %d keys, want none", len(store.keys))
+			}
+		})
+	}
+}
+
+// TestHandlers_CreateAPIKey_AdminKey tests that an admin caller can issue an admin key.
+func TestHandlers_CreateAPIKey_AdminKey(t *testing.T) {
+	h := newAPIKeyHandlers(newFakeAPIKeyStore())
+
+	w := httptest.NewRecorder()
+	h.CreateAPIKey(w, asClient(httptest.NewRequest(http.MethodPost, "/api/v1/api-keys", strings.NewReader(`{"name":"ops"}`)), ""))
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("CreateAPIKey() status = %v, want %v: %s", w.Code, http.StatusCreated, w.Body.String())
+	}
+}
+
+// TestHandlers_APIKeys_NotConfigured tests that the endpoints return 501 without a store.
+func TestHandlers_APIKeys_NotConfigured(t *testing.T) {
+	h := NewHandlersWithDeps(&mockRepository{}, &mockPublisher{}, nil)
+
+	w := httptest.NewRecorder()
+	h.ListAPIKeys(w, httptest.NewRequest(http.MethodGet, "/api/v1/api-keys", nil))
+
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("ListAPIKeys() status = %v, want %v", w.Code, http.StatusNotImplemented)
+	}
+}
+
+// TestHandlers_ListAPIKeys_Scoped tests that a client key only lists its own client's keys.
+func TestHandlers_ListAPIKeys_Scoped(t *testing.T) {
+	store := newFakeAPIKeyStore()
+	store.CreateAPIKey(context.Background(), "client-1", "a", "h1", "ak_1")
+	store.CreateAPIKey(context.Background(), "client-2", "b", "h2", "ak_2")
+	store.CreateAPIKey(context.Background(), "", "ops", "h3", "ak_3")
+	h := newAPIKeyHandlers(store)
+
+	w := httptest.NewRecorder()
+	h.ListAPIKeys(w, asClient(httptest.NewRequest(http.MethodGet, "/api/v1/api-keys", nil), "client-1"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("ListAPIKeys() status = %v, want %v", w.Code, http.StatusOK)
+	}
+	var keys []database.APIKey
+	if err := json.Unmarshal(w.Body.Bytes(), &keys); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(keys) != 1 || keys[0].ClientID != "client-1" {
+		t.Errorf("ListAPIKeys() = %+v, want only the client-1 key", keys)
+	}
+
+	w = httptest.NewRecorder()
+	h.ListAPIKeys(w, asClient(httptest.NewRequest(http.MethodGet, "/api/v1/api-keys?client_id=client-2", nil), "client-1"))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("ListAPIKeys(other client) status = %v, want %v", w.Code, http.StatusForbidden)
+	}
+}
+
+// TestHandlers_RevokeAPIKey tests revocation, which client keys can only do for their own client.
+func TestHandlers_RevokeAPIKey(t *testing.T) {
+	store := newFakeAPIKeyStore()
+	own, _ := store.CreateAPIKey(context.Background(), "client-1", "a", "h1", "ak_1")
+	other, _ := store.CreateAPIKey(context.Background(), "client-2", "b", "h2", "ak_2")
+	h := newAPIKeyHandlers(store)
+
+	w := httptest.NewRecorder()
+	h.RevokeAPIKey(w, asClient(httptest.NewRequest(http.MethodPost, "/api/v1/api-keys/revoke?key_id="+other.KeyID, nil), "client-1"))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("RevokeAPIKey(other client) status = %v, want %v", w.Code, http.StatusNotFound)
+	}
+	if other.RevokedAt != nil {
+		t.Error("other client's key was revoked")
+	}
+
+	w = httptest.NewRecorder()
+	h.RevokeAPIKey(w, asClient(httptest.NewRequest(http.MethodPost, "/api/v1/api-keys/revoke?key_id="+own.KeyID, nil), "client-1"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("RevokeAPIKey() status = %v, want %v: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	if own.RevokedAt == nil {
+		t.Error("key was not revoked")
+	}
+
+	w = httptest.NewRecorder()
+	h.RevokeAPIKey(w, httptest.NewRequest(http.MethodPost, "/api/v1/api-keys/revoke?key_id=nope", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("RevokeAPIKey(unknown) status = %v, want %v", w.Code, http.StatusNotFound)
+	}
+}
+
+// TestHandlers_ClientScope tests that client keys cannot reach other clients' resources.
+func TestHandlers_ClientScope(t *testing.T) {
+	// The mock repository's rules and notifications belong to client-1
+	tests := []struct {
+		name       string
+		handler    func(h *Handlers) http.HandlerFunc
+		method     string
+		target     string
+		body       string
+		caller     string
+		wantStatus int
+	}{
+		{"get own rule", func(h *Handlers) http.HandlerFunc { return h.GetRule }, http.MethodGet, "/api/v1/rules?rule_id=rule-1", "", "client-1", http.StatusOK},
+		{"get other rule", func(h *Handlers) http.HandlerFunc { return h.GetRule }, http.MethodGet, "/api/v1/rules?rule_id=rule-1", "", "client-2", http.StatusNotFound},
+		{"get rule as admin", func(h *Handlers) http.HandlerFunc { return h.GetRule }, http.MethodGet, "/api/v1/rules?rule_id=rule-1", "", "", http.StatusOK},
+		{"create rule for other client", func(h *Handlers) http.HandlerFunc { return h.CreateRule }, http.MethodPost, "/api/v1/rules", `{"client_id":"client-1","severity":"HIGH","source":"s","name":"n"}`, "client-2", http.StatusForbidden},
+		{"list other client's rules", func(h *Handlers) http.HandlerFunc { return h.ListRules }, http.MethodGet, "/api/v1/rules?client_id=client-1", "", "client-2", http.StatusForbidden},
+		{"toggle other rule", func(h *Handlers) http.HandlerFunc { return h.ToggleRuleEnabled }, http.MethodPost, "/api/v1/rules/toggle?rule_id=rule-1", `{"enabled":false,"version":1}`, "client-2", http.StatusNotFound},
+		{"delete other rule", func(h *Handlers) http.HandlerFunc { return h.DeleteRule }, http.MethodDelete, "/api/v1/rules/delete?rule_id=rule-1", "", "client-2", http.StatusNotFound},
+		{"get other endpoint", func(h *Handlers) http.HandlerFunc { return h.GetEndpoint }, http.MethodGet, "/api/v1/endpoints?endpoint_id=ep-1", "", "client-2", http.StatusNotFound},
+		{"delete other endpoint", func(h *Handlers) http.HandlerFunc { return h.DeleteEndpoint }, http.MethodDelete, "/api/v1/endpoints/delete?endpoint_id=ep-1", "", "client-2", http.StatusNotFound},
+		{"list endpoints without rule", func(h *Handlers) http.HandlerFunc { return h.ListEndpoints }, http.MethodGet, "/api/v1/endpoints", "", "client-1", http.StatusBadRequest},
+		{"list own rule's endpoints", func(h *Handlers) http.HandlerFunc { return h.ListEndpoints }, http.MethodGet, "/api/v1/endpoints?rule_id=rule-1", "", "client-1", http.StatusOK},
+		{"get other notification", func(h *Handlers) http.HandlerFunc { return h.GetNotification }, http.MethodGet, "/api/v1/notifications?notification_id=n-1", "", "client-2", http.StatusNotFound},
+		{"ack other notification", func(h *Handlers) http.HandlerFunc { return h.AcknowledgeNotification }, http.MethodPost, "/api/v1/notifications/ack", `{"notification_id":"n-1","actor":"alice"}`, "client-2", http.StatusNotFound},
+		{"list other client's notifications", func(h *Handlers) http.HandlerFunc { return h.ListNotifications }, http.MethodGet, "/api/v1/notifications?client_id=client-1", "", "client-2", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandlersWithDeps(&mockRepository{}, &mockPublisher{}, nil)
+			w := httptest.NewRecorder()
+			tt.handler(h)(w, asClient(httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body)), tt.caller))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %v, want %v: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+}
+
+// TestHandlers_ListScoped tests that list filters default to the client of a client key.
+func TestHandlers_ListScoped(t *testing.T) {
+	var ruleClient, notificationClient *string
+	repo := &mockRepository{
+		ListRulesFn: func(ctx context.Context, filter database.RuleFilter, limit, offset int) (*database.RuleListResult, error) {
+			ruleClient = filter.ClientID
+			return &database.RuleListResult{}, nil
+		},
+		ListNotificationsFn: func(ctx context.Context, clientID *string, statuses []string, limit, offset int) (*database.NotificationListResult, error) {
+			notificationClient = clientID
+			return &database.NotificationListResult{}, nil
+		},
+	}
+	h := NewHandlersWithDeps(repo, &mockPublisher{}, nil)
+
+	h.ListRules(httptest.NewRecorder(), asClient(httptest.NewRequest(http.MethodGet, "/api/v1/rules", nil), "client-1"))
+	h.ListNotifications(httptest.NewRecorder(), asClient(httptest.NewRequest(http.MethodGet, "/api/v1/notifications", nil), "client-1"))
+
+	if ruleClient == nil || *ruleClient != "client-1" {
+		t.Errorf("ListRules() client filter = %v, want client-1", ruleClient)
+	}
+	if notificationClient == nil || *notificationClient != "client-1" {
+		t.Errorf("ListNotifications() client filter = %v, want client-1", notificationClient)
+	}
+}
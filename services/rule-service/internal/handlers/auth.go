@@ -0,0 +1,106 @@
+// Package handlers provides HTTP handlers for the rule-service API.
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// scopedClient returns the client the caller's API key is scoped to. scoped is false for
+// admin keys and when API keys are not enforced, in which case every client is accessible.
+func scopedClient(r *http.Request) (clientID string, scoped bool) {
+	p, ok := shared.APIPrincipalFromContext(r.Context())
+	if !ok || p.Admin() {
+		return "", false
+	}
+	return p.ClientID, true
+}
+
+// authorizeClient checks that the caller may act for clientID, which the request names
+// explicitly. Writes 403 and returns false otherwise.
+func authorizeClient(w http.ResponseWriter, r *http.Request, clientID string) bool {
+	if scoped, ok := scopedClient(r); ok && scoped != clientID {
+		http.Error(w, "API key is not authorized for client "+clientID, http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// scopeClientFilter returns the client_id filter of a list request: the requested one, or for
+// a client-scoped key its own client. Writes 403 and returns false if the key asks for another client.
+func scopeClientFilter(w http.ResponseWriter, r *http.Request, requested *string) (*string, bool) {
+	scoped, ok := scopedClient(r)
+	if !ok {
+		return requested, true
+	}
+	if requested != nil && *requested != scoped {
+		http.Error(w, "API key is not authorized for client "+*requested, http.StatusForbidden)
+		return nil, false
+	}
+	return &scoped, true
+}
+
+// authorizeOwner checks that the caller may access a resource owned by clientID that the request
+// names by ID. A resource of another client is reported as not found, so keys cannot probe IDs.
+func authorizeOwner(w http.ResponseWriter, r *http.Request, resource, clientID string) bool {
+	if scoped, ok := scopedClient(r); ok && scoped != clientID {
+		http.Error(w, strings.ToUpper(resource[:1])+resource[1:]+" not found", http.StatusNotFound)
+		return false
+	}
+	return true
+}
+
+// authorizeRule checks that the caller may access ruleID, reporting a denial as resource not found.
+// It only reads the rule for client-scoped keys.
+func (h *Handlers) authorizeRule(w http.ResponseWriter, r *http.Request, resource, ruleID string) bool {
+	if _, ok := scopedClient(r); !ok {
+		return true
+	}
+	rule, err := h.db.GetRule(r.Context(), ruleID)
+	if handleDBError(w, err, resource, ruleID) {
+		return false
+	}
+	return authorizeOwner(w, r, resource, rule.ClientID)
+}
+
+// authorizeEndpoint checks that the caller may access endpointID through the rule it belongs to.
+// It only reads the endpoint for client-scoped keys.
+func (h *Handlers) authorizeEndpoint(w http.ResponseWriter, r *http.Request, endpointID string) bool {
+	if _, ok := scopedClient(r); !ok {
+		return true
+	}
+	endpoint, err := h.db.GetEndpoint(r.Context(), endpointID)
+	if handleDBError(w, err, "endpoint", endpointID) {
+		return false
+	}
+	return h.authorizeRule(w, r, "endpoint", endpoint.RuleID)
+}
+
+// authorizeNotification checks that the caller may access notificationID.
+// It only reads the notification for client-scoped keys.
+func (h *Handlers) authorizeNotification(w http.ResponseWriter, r *http.Request, notificationID string) bool {
+	if _, ok := scopedClient(r); !ok {
+		return true
+	}
+	notification, err := h.db.GetNotification(r.Context(), notificationID)
+	if err != nil {
+		http.Error(w, "Notification not found", http.StatusNotFound)
+		return false
+	}
+	return authorizeOwner(w, r, "notification", notification.ClientID)
+}
+
+// authorizeExportJob checks that the caller may access jobID.
+func (h *Handlers) authorizeExportJob(w http.ResponseWriter, r *http.Request, jobID string) bool {
+	if _, ok := scopedClient(r); !ok {
+		return true
+	}
+	job, err := h.exportJobs.Get(jobID)
+	if err != nil {
+		http.Error(w, "Export job not found", http.StatusNotFound)
+		return false
+	}
+	return authorizeOwner(w, r, "export job", job.ClientID)
+}
@@ -6,17 +6,37 @@ import (
 	"rule-service/internal/producer"
 
 	"github.com/afikmenashe/alerting-platform/pkg/metrics"
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
 )
 
 const (
 	SchemaVersion = 1
 )
 
+// defaultNormalization is shared.DefaultNormalization, which rule-updater applies unless
+// configured otherwise.
+var defaultNormalization = shared.Normalization{Trim: true, FoldCase: true}
+
 // Handlers wraps dependencies for HTTP handlers.
 type Handlers struct {
 	db       Repository
 	producer RulePublisher
 	metrics  MetricsRecorder
+	secrets  *shared.SecretBox // encrypts secret endpoint headers; nil rejects them
+
+	emergencyStop EmergencyStopStore // nil disables the emergency stop endpoints
+	ruleSnapshots RuleSnapshotStore  // nil disables the rule snapshot endpoints
+	adminToken    string             // required by admin endpoints when set
+
+	exportJobs ExportJobManager // nil disables asynchronous exports
+	webhooks   WebhookStore     // nil disables the meta-webhook endpoints
+	apiKeys    APIKeyStore      // nil disables the API key endpoints
+
+	// normalization is how rule-updater normalizes rule and alert fields, applied when a
+	// sample alert is tested against a client's rules.
+	normalization shared.Normalization
+
+	health *shared.HealthChecker // checks dependencies for /healthz and /readyz; nil reports them healthy
 }
 
 // Option is a functional option for configuring Handlers.
@@ -31,18 +51,72 @@ func WithMetrics(m MetricsRecorder) Option {
 	}
 }
 
+// WithSecretBox sets the box used to encrypt secret endpoint header values.
+func WithSecretBox(b *shared.SecretBox) Option {
+	return func(h *Handlers) {
+		h.secrets = b
+	}
+}
+
+// WithEmergencyStop enables the emergency stop admin endpoints, backed by store.
+// If adminToken is non-empty, the endpoints require it as a bearer token.
+func WithEmergencyStop(store EmergencyStopStore, adminToken string) Option {
+	return func(h *Handlers) {
+		h.emergencyStop = store
+		h.adminToken = adminToken
+	}
+}
+
+// WithRuleSnapshots enables the rule snapshot history admin endpoints, backed by store.
+// If adminToken is non-empty, the endpoints require it as a bearer token.
+func WithRuleSnapshots(store RuleSnapshotStore, adminToken string) Option {
+	return func(h *Handlers) {
+		h.ruleSnapshots = store
+		h.adminToken = adminToken
+	}
+}
+
+// WithExportJobs enables asynchronous notification exports.
+func WithExportJobs(m ExportJobManager) Option {
+	return func(h *Handlers) {
+		h.exportJobs = m
+	}
+}
+
+// WithWebhooks enables the meta-webhook endpoints, backed by store.
+func WithWebhooks(store WebhookStore) Option {
+	return func(h *Handlers) {
+		h.webhooks = store
+	}
+}
+
+// WithNormalization sets the normalization rule tests match with. It must match rule-updater's
+// -normalize, so tests match like the evaluator; the default is shared.DefaultNormalization.
+func WithNormalization(n shared.Normalization) Option {
+	return func(h *Handlers) {
+		h.normalization = n
+	}
+}
+
+// WithAPIKeys enables the API key endpoints, backed by store.
+func WithAPIKeys(store APIKeyStore) Option {
+	return func(h *Handlers) {
+		h.apiKeys = store
+	}
+}
+
 // NewHandlers creates a new handlers instance.
 // If metricsCollector is nil, a no-op implementation is used.
-func NewHandlers(db *database.DB, prod *producer.Producer, metricsCollector *metrics.Collector, opts ...Option) *Handlers {
+func NewHandlers(db *database.DB, prod *producer.Producer, metricsCollector metrics.Collector, opts ...Option) *Handlers {
 	h := &Handlers{
-		db:       db,
-		producer: prod,
-		metrics:  NoOpMetrics{}, // Default to no-op, never nil
+		db:            db,
+		producer:      prod,
+		metrics:       NoOpMetrics{}, // Default to no-op, never nil
+		normalization: defaultNormalization,
 	}
 
-	// If a metrics collector was provided, wrap it
 	if metricsCollector != nil {
-		h.metrics = &metricsAdapter{collector: metricsCollector}
+		h.metrics = metricsCollector
 	}
 
 	// Apply any additional options
@@ -61,21 +135,16 @@ func NewHandlersWithDeps(db Repository, prod RulePublisher, m MetricsRecorder) *
 		metrics = NoOpMetrics{}
 	}
 	return &Handlers{
-		db:       db,
-		producer: prod,
-		metrics:  metrics,
+		db:            db,
+		producer:      prod,
+		metrics:       metrics,
+		normalization: defaultNormalization,
 	}
 }
 
-// GetMetricsCollector returns a metrics.Collector for middleware use.
-// Returns nil if the underlying metrics is not a collector.
-// This method exists for backward compatibility with the router middleware.
-func (h *Handlers) GetMetricsCollector() *metrics.Collector {
-	// Check if the metrics adapter wraps a real collector
-	if adapter, ok := h.metrics.(*metricsAdapter); ok {
-		if collector, ok := adapter.collector.(*metrics.Collector); ok {
-			return collector
-		}
-	}
-	return nil
+// GetMetricsCollector returns the metrics.Collector for middleware use.
+// Returns nil if the handlers record metrics with something else (e.g. NoOpMetrics).
+func (h *Handlers) GetMetricsCollector() metrics.Collector {
+	collector, _ := h.metrics.(metrics.Collector)
+	return collector
 }
@@ -4,12 +4,44 @@ package handlers
 import (
 	"log/slog"
 	"net/http"
+	"strings"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
 )
 
 // CreateClientRequest represents a request to create a client.
 type CreateClientRequest struct {
 	ClientID string `json:"client_id"`
 	Name     string `json:"name"`
+	Timezone string `json:"timezone,omitempty"` // IANA timezone, e.g. "Europe/Berlin"; default UTC
+	Locale   string `json:"locale,omitempty"`   // e.g. "en-GB"; default ISO-like layout
+}
+
+func (req *CreateClientRequest) validate(v *shared.Validator) {
+	v.Required("client_id", req.ClientID)
+	v.Required("name", req.Name)
+	validateClientSettings(v, req.Timezone, req.Locale)
+}
+
+// UpdateClientSettingsRequest changes how a client's notification timestamps are rendered.
+// Omitted fields keep their value; an empty string resets to the default.
+type UpdateClientSettingsRequest struct {
+	Timezone *string `json:"timezone,omitempty"`
+	Locale   *string `json:"locale,omitempty"`
+}
+
+func (req *UpdateClientSettingsRequest) validate(v *shared.Validator) {
+	if !v.Check(req.Timezone != nil || req.Locale != nil, "", "timezone or locale is required") {
+		return
+	}
+	var timezone, locale string
+	if req.Timezone != nil {
+		timezone = *req.Timezone
+	}
+	if req.Locale != nil {
+		locale = *req.Locale
+	}
+	validateClientSettings(v, timezone, locale)
 }
 
 // CreateClient creates a new client.
@@ -23,17 +55,14 @@ func (h *Handlers) CreateClient(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.ClientID == "" {
-		http.Error(w, "client_id is required", http.StatusBadRequest)
-		return
-	}
-	if req.Name == "" {
-		http.Error(w, "name is required", http.StatusBadRequest)
+	req.Timezone = strings.TrimSpace(req.Timezone)
+	req.Locale = strings.TrimSpace(req.Locale)
+	if !validateRequest(w, &req) {
 		return
 	}
 
 	ctx := r.Context()
-	if err := h.db.CreateClient(ctx, req.ClientID, req.Name); err != nil {
+	if err := h.db.CreateClient(ctx, req.ClientID, req.Name, req.Timezone, req.Locale); err != nil {
 		if handleDBError(w, err, "client", req.ClientID) {
 			return
 		}
@@ -75,6 +104,57 @@ func (h *Handlers) GetClient(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, client)
 }
 
+// UpdateClientSettings changes the timezone and locale a client's notification timestamps
+// are rendered in.
+// PUT /api/v1/clients/settings?client_id=
+func (h *Handlers) UpdateClientSettings(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPut) {
+		return
+	}
+
+	clientID, ok := requireQueryParam(w, r, "client_id")
+	if !ok {
+		return
+	}
+
+	var req UpdateClientSettingsRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Timezone != nil {
+		timezone := strings.TrimSpace(*req.Timezone)
+		req.Timezone = &timezone
+	}
+	if req.Locale != nil {
+		locale := strings.TrimSpace(*req.Locale)
+		req.Locale = &locale
+	}
+	if !validateRequest(w, &req) {
+		return
+	}
+
+	client, err := h.db.UpdateClientSettings(r.Context(), clientID, req.Timezone, req.Locale)
+	if err != nil {
+		if handleDBError(w, err, "client", clientID) {
+			return
+		}
+		http.Error(w, "Failed to update client settings: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, client)
+}
+
+// validateClientSettings validates a client timezone and locale. Empty values are valid.
+func validateClientSettings(v *shared.Validator, timezone, locale string) {
+	if err := shared.ValidateClientTimezone(timezone); err != nil {
+		v.Add("timezone", err.Error())
+	}
+	if err := shared.ValidateClientLocale(locale); err != nil {
+		v.Add("locale", err.Error())
+	}
+}
+
 // ListClients retrieves clients with pagination.
 // Query params: limit (default 50, max 200), offset (default 0)
 func (h *Handlers) ListClients(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,165 @@
+// Package handlers provides HTTP handlers for the rule-service API.
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"rule-service/internal/database"
+	"rule-service/internal/events"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// Default rule set created when a bootstrap document has no rules: every CRITICAL alert is emailed.
+const (
+	defaultBootstrapSeverity = "CRITICAL"
+	defaultBootstrapSource   = "*"
+	defaultBootstrapName     = "*"
+)
+
+// BootstrapClientRequest is the onboarding document for a new client.
+// Without rules, the default rule set is created and routed to email.
+type BootstrapClientRequest struct {
+	ClientID string                 `json:"client_id"`
+	Name     string                 `json:"name"`
+	Email    string                 `json:"email,omitempty"` // endpoint of the default rule set
+	Rules    []BootstrapRuleRequest `json:"rules,omitempty"`
+}
+
+// BootstrapRuleRequest is a rule in a bootstrap document.
+type BootstrapRuleRequest struct {
+	Severity       string                     `json:"severity"`
+	Source         string                     `json:"source"`
+	Name           string                     `json:"name"`
+	Description    string                     `json:"description,omitempty"`
+	ExcludeSources []string                   `json:"exclude_sources,omitempty"`
+	ExcludeNames   []string                   `json:"exclude_names,omitempty"`
+	Conditions     database.RuleConditions    `json:"conditions,omitempty"`
+	Endpoints      []BootstrapEndpointRequest `json:"endpoints"`
+}
+
+// BootstrapEndpointRequest is an endpoint of a bootstrap rule.
+type BootstrapEndpointRequest struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// BootstrapClient creates a client, its rules, and their endpoints in one transaction,
+// then publishes a rule.changed event for every created rule.
+// POST /api/v1/clients/bootstrap
+func (h *Handlers) BootstrapClient(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	var req BootstrapClientRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if !validateRequest(w, &req) {
+		return
+	}
+
+	ctx := r.Context()
+	result, err := h.db.BootstrapClient(ctx, req.ClientID, req.Name, bootstrapRules(&req))
+	if err != nil {
+		if handleDBError(w, err, "client", req.ClientID) {
+			return
+		}
+		http.Error(w, "Failed to bootstrap client: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	for _, rule := range result.Rules {
+		h.publishRuleChangedEvent(ctx, rule.Rule, events.ActionCreated)
+	}
+
+	writeJSON(w, http.StatusCreated, result)
+}
+
+// validate rejects duplicates up front, so the only conflict left for the database is an
+// existing client.
+func (req *BootstrapClientRequest) validate(v *shared.Validator) {
+	v.Required("client_id", req.ClientID)
+	v.Required("name", req.Name)
+	if len(req.Rules) == 0 {
+		v.Check(req.Email != "", "email", "email is required when no rules are given")
+		return
+	}
+	v.Check(req.Email == "", "email", "email only applies to the default rule set; add it as an endpoint of a rule instead")
+
+	seenRules := make(map[[4]string]bool, len(req.Rules))
+	for i, r := range req.Rules {
+		field := fmt.Sprintf("rules[%d]", i)
+		prefix := field + "."
+		validateRuleFields(v, prefix, r.Severity, r.Source, r.Name)
+		key := ruleCriteriaKey(r.Severity, r.Source, r.Name, r.Conditions)
+		if seenRules[key] {
+			v.Addf(field, "%s: duplicate rule (severity=%s, source=%s, name=%s)", field, r.Severity, r.Source, r.Name)
+		}
+		seenRules[key] = true
+		validateRuleDescription(v, prefix, r.Description)
+		validateRuleExclusions(v, prefix, r.Source, r.Name, r.exclusions())
+		validateRuleConditions(v, prefix, r.Conditions)
+
+		seenEndpoints := make(map[BootstrapEndpointRequest]bool, len(r.Endpoints))
+		for j, e := range r.Endpoints {
+			endpoint := fmt.Sprintf("%sendpoints[%d]", prefix, j)
+			if validateRuleSetEndpoint(v, endpoint, e.Type, e.Value) && seenEndpoints[e] {
+				v.Add(endpoint, endpoint+": duplicate endpoint")
+			}
+			seenEndpoints[e] = true
+		}
+	}
+}
+
+// ruleCriteriaKey identifies a rule of a client by its criteria, like the database's unique
+// constraint: rules differing only in their context conditions may coexist.
+func ruleCriteriaKey(severity, source, name string, conditions database.RuleConditions) [4]string {
+	var encoded []byte
+	if len(conditions) > 0 {
+		encoded, _ = json.Marshal(conditions)
+	}
+	return [4]string{severity, source, name, string(encoded)}
+}
+
+// validateRuleSetEndpoint validates an endpoint of a rule in a bootstrap or import document.
+// field names the endpoint (rules[0].endpoints[1]). Reports whether it is valid.
+func validateRuleSetEndpoint(v *shared.Validator, field, endpointType, value string) bool {
+	ok := v.Required(field+".type", endpointType)
+	ok = v.Required(field+".value", value) && ok
+	if endpointType != "" {
+		ok = v.OneOf(field+".type", endpointType, endpointTypes...) && ok
+	}
+	return ok
+}
+
+// exclusions returns the exclusion lists of the rule.
+func (r *BootstrapRuleRequest) exclusions() database.RuleExclusions {
+	return database.RuleExclusions{Sources: r.ExcludeSources, Names: r.ExcludeNames}
+}
+
+// bootstrapRules returns the rules to create for a validated bootstrap document.
+func bootstrapRules(req *BootstrapClientRequest) []database.BootstrapRule {
+	if len(req.Rules) == 0 {
+		return []database.BootstrapRule{{
+			Severity:  defaultBootstrapSeverity,
+			Source:    defaultBootstrapSource,
+			Name:      defaultBootstrapName,
+			Endpoints: []database.BootstrapEndpoint{{Type: "email", Value: req.Email}},
+		}}
+	}
+
+	rules := make([]database.BootstrapRule, 0, len(req.Rules))
+	for _, r := range req.Rules {
+		rule := database.BootstrapRule{Severity: r.Severity, Source: r.Source, Name: r.Name, Description: r.Description, Exclusions: r.exclusions(), Conditions: r.Conditions}
+		for _, e := range r.Endpoints {
+			rule.Endpoints = append(rule.Endpoints, database.BootstrapEndpoint{Type: e.Type, Value: e.Value})
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
@@ -0,0 +1,129 @@
+// Package handlers provides tests for the client bootstrap handler.
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"rule-service/internal/database"
+	"rule-service/internal/events"
+)
+
+// TestHandlers_BootstrapClient_DefaultRuleSet tests that a document without rules gets the default rule set.
+func TestHandlers_BootstrapClient_DefaultRuleSet(t *testing.T) {
+	var gotRules []database.BootstrapRule
+	mockDB := &mockRepository{}
+	mockDB.BootstrapClientFn = func(ctx context.Context, clientID, name string, rules []database.BootstrapRule) (*database.BootstrapResult, error) {
+		gotRules = rules
+		return (&mockRepository{}).BootstrapClient(ctx, clientID, name, rules)
+	}
+	publisher := &mockPublisher{}
+
+	h := NewHandlersWithDeps(mockDB, publisher, nil)
+	body := `{"client_id":"team-a","name":"Team A","email":"oncall@team-a.example"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/clients/bootstrap", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+
+	h.BootstrapClient(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("BootstrapClient() status = %v, want %v: %s", w.Code, http.StatusCreated, w.Body.String())
+	}
+	if len(gotRules) != 1 {
+		t.Fatalf("BootstrapClient() created %d rules, want 1", len(gotRules))
+	}
+	rule := gotRules[0]
+	if rule.Severity != "CRITICAL" || rule.Source != "*" || rule.Name != "*" {
+		t.Errorf("BootstrapClient() default rule = %s/%s/%s, want CRITICAL/*/*", rule.Severity, rule.Source, rule.Name)
+	}
+	if len(rule.Endpoints) != 1 || rule.Endpoints[0] != (database.BootstrapEndpoint{Type: "email", Value: "oncall@team-a.example"}) {
+		t.Errorf("BootstrapClient() default endpoints = %+v, want the email endpoint", rule.Endpoints)
+	}
+	if len(publisher.Published) != 1 || publisher.Published[0].Action != events.ActionCreated || publisher.Published[0].ClientID != "team-a" {
+		t.Errorf("BootstrapClient() published %+v, want one CREATED event for team-a", publisher.Published)
+	}
+}
+
+// TestHandlers_BootstrapClient tests validation and error handling of the BootstrapClient handler.
+func TestHandlers_BootstrapClient(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		setupMock      func(*mockRepository)
+		expectedStatus int
+		expectedEvents int
+	}{
+		{
+			name:           "custom rules",
+			body:           `{"client_id":"team-a","name":"Team A","rules":[{"severity":"HIGH","source":"api","name":"*","endpoints":[{"type":"slack","value":"https://hooks.slack.example/x"}]},{"severity":"CRITICAL","source":"*","name":"*","endpoints":[]}]}`,
+			setupMock:      func(m *mockRepository) {},
+			expectedStatus: http.StatusCreated,
+			expectedEvents: 2,
+		},
+		{
+			name:           "missing email for default rule set",
+			body:           `{"client_id":"team-a","name":"Team A"}`,
+			setupMock:      func(m *mockRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "email with custom rules",
+			body:           `{"client_id":"team-a","name":"Team A","email":"a@team-a.example","rules":[{"severity":"HIGH","source":"api","name":"*"}]}`,
+			setupMock:      func(m *mockRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "duplicate rule",
+			body:           `{"client_id":"team-a","name":"Team A","rules":[{"severity":"HIGH","source":"api","name":"*"},{"severity":"HIGH","source":"api","name":"*"}]}`,
+			setupMock:      func(m *mockRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid endpoint type",
+			body:           `{"client_id":"team-a","name":"Team A","rules":[{"severity":"HIGH","source":"api","name":"*","endpoints":[{"type":"pager","value":"x"}]}]}`,
+			setupMock:      func(m *mockRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "all wildcard rule",
+			body:           `{"client_id":"team-a","name":"Team A","rules":[{"severity":"*","source":"*","name":"*"}]}`,
+			setupMock:      func(m *mockRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "client already exists",
+			body: `{"client_id":"team-a","name":"Team A","email":"a@team-a.example"}`,
+			setupMock: func(m *mockRepository) {
+				m.BootstrapClientFn = func(ctx context.Context, clientID, name string, rules []database.BootstrapRule) (*database.BootstrapResult, error) {
+					return nil, fmt.Errorf("client already exists: %s", clientID)
+				}
+			},
+			expectedStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := &mockRepository{}
+			tt.setupMock(mockDB)
+			publisher := &mockPublisher{}
+
+			h := NewHandlersWithDeps(mockDB, publisher, nil)
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/clients/bootstrap", bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+
+			h.BootstrapClient(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("BootstrapClient() status = %v, want %v: %s", w.Code, tt.expectedStatus, w.Body.String())
+			}
+			if len(publisher.Published) != tt.expectedEvents {
+				t.Errorf("BootstrapClient() published %d events, want %d", len(publisher.Published), tt.expectedEvents)
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+// Package handlers provides HTTP handlers for the rule-service API.
+package handlers
+
+import (
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// maxEmergencyStopReasonLength bounds the emergency stop reason.
+const maxEmergencyStopReasonLength = 500
+
+// EmergencyStopRequest activates the emergency stop.
+type EmergencyStopRequest struct {
+	Reason   string `json:"reason"`
+	Actor    string `json:"actor,omitempty"`
+	StopJobs bool   `json:"stop_jobs,omitempty"` // also halt alert-producer generation jobs
+}
+
+func (req *EmergencyStopRequest) validate(v *shared.Validator) {
+	if v.Required("reason", req.Reason) {
+		v.MaxLength("reason", req.Reason, maxEmergencyStopReasonLength)
+	}
+}
+
+// ResumeRequest clears the emergency stop.
+type ResumeRequest struct {
+	Actor string `json:"actor,omitempty"`
+}
+
+// ReadinessResponse is returned by /readyz.
+type ReadinessResponse struct {
+	Status        string                             `json:"status"` // ready, unavailable, emergency_stop, or unknown
+	Dependencies  map[string]shared.DependencyStatus `json:"dependencies,omitempty"`
+	EmergencyStop *shared.EmergencyStop              `json:"emergency_stop,omitempty"`
+	Error         string                             `json:"error,omitempty"`
+}
+
+// GetEmergencyStop returns the current emergency stop.
+// GET /api/v1/admin/emergency-stop
+func (h *Handlers) GetEmergencyStop(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet) || !h.requireAdmin(w, r) {
+		return
+	}
+
+	stop, err := h.emergencyStop.GetEmergencyStop(r.Context())
+	if err != nil {
+		slog.Error("Failed to read emergency stop", "error", err)
+		http.Error(w, "Failed to read emergency stop", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, stop)
+}
+
+// ActivateEmergencyStop halts all outbound sending platform-wide until ResumeEmergencyStop is called.
+// POST /api/v1/admin/emergency-stop
+func (h *Handlers) ActivateEmergencyStop(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost) || !h.requireAdmin(w, r) {
+		return
+	}
+
+	var req EmergencyStopRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if !validateRequest(w, &req) {
+		return
+	}
+
+	current, err := h.emergencyStop.GetEmergencyStop(r.Context())
+	if err != nil {
+		slog.Error("Failed to read emergency stop", "error", err)
+		http.Error(w, "Failed to read emergency stop", http.StatusInternalServerError)
+		return
+	}
+	if current.Active {
+		http.Error(w, "Emergency stop is already active: "+current.Reason, http.StatusConflict)
+		return
+	}
+
+	stop := shared.EmergencyStop{Active: true, Reason: req.Reason, Actor: req.Actor, StopJobs: req.StopJobs}
+	if err := h.emergencyStop.SetEmergencyStop(r.Context(), stop); err != nil {
+		slog.Error("Failed to activate emergency stop", "error", err)
+		http.Error(w, "Failed to activate emergency stop", http.StatusInternalServerError)
+		return
+	}
+
+	slog.Warn("Emergency stop activated",
+		"reason", req.Reason,
+		"actor", req.Actor,
+		"stop_jobs", req.StopJobs,
+		"remote_addr", r.RemoteAddr,
+	)
+	h.metrics.IncrementCustom("emergency_stops")
+
+	stop, err = h.emergencyStop.GetEmergencyStop(r.Context())
+	if err != nil {
+		slog.Error("Failed to read emergency stop", "error", err)
+		http.Error(w, "Failed to read emergency stop", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, stop)
+}
+
+// ResumeEmergencyStop clears the emergency stop so sending resumes.
+// POST /api/v1/admin/emergency-stop/resume
+func (h *Handlers) ResumeEmergencyStop(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost) || !h.requireAdmin(w, r) {
+		return
+	}
+
+	var req ResumeRequest
+	if r.ContentLength != 0 && !decodeJSON(w, r, &req) {
+		return
+	}
+
+	current, err := h.emergencyStop.GetEmergencyStop(r.Context())
+	if err != nil {
+		slog.Error("Failed to read emergency stop", "error", err)
+		http.Error(w, "Failed to read emergency stop", http.StatusInternalServerError)
+		return
+	}
+	if !current.Active {
+		http.Error(w, "Emergency stop is not active", http.StatusConflict)
+		return
+	}
+
+	if err := h.emergencyStop.ClearEmergencyStop(r.Context()); err != nil {
+		slog.Error("Failed to clear emergency stop", "error", err)
+		http.Error(w, "Failed to clear emergency stop", http.StatusInternalServerError)
+		return
+	}
+
+	slog.Warn("Emergency stop cleared",
+		"actor", req.Actor,
+		"stopped_reason", current.Reason,
+		"remote_addr", r.RemoteAddr,
+	)
+	h.metrics.IncrementCustom("emergency_resumes")
+	writeJSON(w, http.StatusOK, shared.EmergencyStop{})
+}
+
+// Readiness reports whether the service can serve requests and the platform is delivering
+// notifications. It returns 503 with the status of each dependency while Postgres, Redis or
+// the Kafka producer is down, and 503 with the stop reason while the emergency stop is active,
+// so the stop is visible to probes and dashboards. Routing health checks should keep using /health.
+// GET /readyz
+func (h *Handlers) Readiness(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet) {
+		return
+	}
+	report := h.checkHealth(r)
+	resp := ReadinessResponse{Status: "ready", Dependencies: report.Dependencies}
+	if !report.Healthy() {
+		resp.Status = "unavailable"
+		writeJSON(w, http.StatusServiceUnavailable, resp)
+		return
+	}
+	if h.emergencyStop == nil {
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	stop, err := h.emergencyStop.GetEmergencyStop(r.Context())
+	if err != nil {
+		resp.Status = "unknown"
+		resp.Error = err.Error()
+		writeJSON(w, http.StatusServiceUnavailable, resp)
+		return
+	}
+	if stop.Active {
+		resp.Status = "emergency_stop"
+		resp.EmergencyStop = &stop
+		writeJSON(w, http.StatusServiceUnavailable, resp)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// requireAdmin checks that the emergency stop is configured and, if an admin token is set,
+// that the request carries it as a bearer token. Writes an error response otherwise.
+func (h *Handlers) requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if h.emergencyStop == nil {
+		http.Error(w, "Emergency stop is not configured", http.StatusNotImplemented)
+		return false
+	}
+	return h.requireAdminToken(w, r)
+}
+
+// requireAdminToken checks that the request carries the admin token as a bearer token, if
+// one is set. With API key authentication, any admin key is accepted instead and client-scoped
+// keys are refused. Writes an error response otherwise.
+func (h *Handlers) requireAdminToken(w http.ResponseWriter, r *http.Request) bool {
+	if p, ok := shared.APIPrincipalFromContext(r.Context()); ok {
+		if !p.Admin() {
+			http.Error(w, "Admin API key required", http.StatusForbidden)
+			return false
+		}
+		return true
+	}
+	if h.adminToken == "" {
+		return true
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.adminToken)) != 1 {
+		http.Error(w, "Admin token required", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
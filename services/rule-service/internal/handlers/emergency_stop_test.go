@@ -0,0 +1,123 @@
+// Package handlers provides tests for the emergency stop admin endpoints.
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// fakeEmergencyStopStore is an in-memory EmergencyStopStore.
+type fakeEmergencyStopStore struct {
+	stop shared.EmergencyStop
+}
+
+func (f *fakeEmergencyStopStore) GetEmergencyStop(ctx context.Context) (shared.EmergencyStop, error) {
+	return f.stop, nil
+}
+
+func (f *fakeEmergencyStopStore) SetEmergencyStop(ctx context.Context, stop shared.EmergencyStop) error {
+	now := time.Now()
+	stop.StoppedAt = &now
+	f.stop = stop
+	return nil
+}
+
+func (f *fakeEmergencyStopStore) ClearEmergencyStop(ctx context.Context) error {
+	f.stop = shared.EmergencyStop{}
+	return nil
+}
+
+func newEmergencyStopHandlers(store EmergencyStopStore, adminToken string) *Handlers {
+	h := NewHandlersWithDeps(&mockRepository{}, &mockPublisher{}, nil)
+	WithEmergencyStop(store, adminToken)(h)
+	return h
+}
+
+// TestHandlers_EmergencyStop tests activating, reading and resuming the emergency stop.
+func TestHandlers_EmergencyStop(t *testing.T) {
+	store := &fakeEmergencyStopStore{}
+	h := newEmergencyStopHandlers(store, "")
+
+	w := httptest.NewRecorder()
+	h.ActivateEmergencyStop(w, httptest.NewRequest(http.MethodPost, "/api/v1/admin/emergency-stop",
+		bytes.NewBufferString(`{"reason":"sender is looping","actor":"oncall","stop_jobs":true}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("ActivateEmergencyStop() status = %v, want %v: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	if !store.stop.Active || store.stop.Reason != "sender is looping" || !store.stop.StopJobs {
+		t.Errorf("stored stop = %+v, want active with reason and stop_jobs", store.stop)
+	}
+
+	// A second stop does not overwrite the first reason
+	w = httptest.NewRecorder()
+	h.ActivateEmergencyStop(w, httptest.NewRequest(http.MethodPost, "/api/v1/admin/emergency-stop",
+		bytes.NewBufferString(`{"reason":"other"}`)))
+	if w.Code != http.StatusConflict {
+		t.Errorf("second ActivateEmergencyStop() status = %v, want %v", w.Code, http.StatusConflict)
+	}
+
+	w = httptest.NewRecorder()
+	h.Readiness(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var ready ReadinessResponse
+	json.NewDecoder(w.Body).Decode(&ready)
+	if w.Code != http.StatusServiceUnavailable || ready.Status != "emergency_stop" || ready.EmergencyStop == nil || ready.EmergencyStop.Reason != "sender is looping" {
+		t.Errorf("Readiness() = %v %+v, want 503 with the stop reason", w.Code, ready)
+	}
+
+	w = httptest.NewRecorder()
+	h.ResumeEmergencyStop(w, httptest.NewRequest(http.MethodPost, "/api/v1/admin/emergency-stop/resume", nil))
+	if w.Code != http.StatusOK || store.stop.Active {
+		t.Fatalf("ResumeEmergencyStop() status = %v, stop = %+v, want 200 and cleared", w.Code, store.stop)
+	}
+
+	w = httptest.NewRecorder()
+	h.ResumeEmergencyStop(w, httptest.NewRequest(http.MethodPost, "/api/v1/admin/emergency-stop/resume", nil))
+	if w.Code != http.StatusConflict {
+		t.Errorf("ResumeEmergencyStop() when not stopped status = %v, want %v", w.Code, http.StatusConflict)
+	}
+
+	w = httptest.NewRecorder()
+	h.Readiness(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Readiness() after resume status = %v, want %v", w.Code, http.StatusOK)
+	}
+}
+
+// TestHandlers_EmergencyStop_Validation tests request validation and admin authentication.
+func TestHandlers_EmergencyStop_Validation(t *testing.T) {
+	tests := []struct {
+		name       string
+		handlers   *Handlers
+		body       string
+		token      string
+		wantStatus int
+	}{
+		{"missing reason", newEmergencyStopHandlers(&fakeEmergencyStopStore{}, ""), `{"reason":"  "}`, "", http.StatusBadRequest},
+		{"missing admin token", newEmergencyStopHandlers(&fakeEmergencyStopStore{}, "s3cret"), `{"reason":"x"}`, "", http.StatusUnauthorized},
+		{"wrong admin token", newEmergencyStopHandlers(&fakeEmergencyStopStore{}, "s3cret"), `{"reason":"x"}`, "nope", http.StatusUnauthorized},
+		{"valid admin token", newEmergencyStopHandlers(&fakeEmergencyStopStore{}, "s3cret"), `{"reason":"x"}`, "s3cret", http.StatusOK},
+		{"not configured", NewHandlersWithDeps(&mockRepository{}, &mockPublisher{}, nil), `{"reason":"x"}`, "", http.StatusNotImplemented},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/emergency-stop", bytes.NewBufferString(tt.body))
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := httptest.NewRecorder()
+			tt.handlers.ActivateEmergencyStop(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("ActivateEmergencyStop() status = %v, want %v: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+}
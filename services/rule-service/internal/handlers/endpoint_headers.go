@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"rule-service/internal/database"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// maskedSecret replaces secret header values in API responses.
+// Sending it back on update keeps the stored secret.
+const maskedSecret = "********"
+
+// maxEndpointHeaders bounds the number of custom headers per endpoint.
+const maxEndpointHeaders = 20
+
+// reservedHeaders are set by the webhook sender and cannot be overridden.
+var reservedHeaders = map[string]struct{}{
+	"Content-Type":      {},
+	"Content-Length":    {},
+	"Host":              {},
+	"Connection":        {},
+	"Transfer-Encoding": {},
+}
+
+// validateHeaders checks custom headers requested for an endpoint of the given type.
+func validateHeaders(v *shared.Validator, endpointType string, headers []database.EndpointHeader) {
+	if len(headers) == 0 {
+		return
+	}
+	if !v.Check(endpointType == "webhook", "headers", "headers are only supported for webhook endpoints") {
+		return
+	}
+	if !v.Check(len(headers) <= maxEndpointHeaders, "headers", fmt.Sprintf("at most %d headers are allowed", maxEndpointHeaders)) {
+		return
+	}
+
+	seen := make(map[string]bool, len(headers))
+	for i, header := range headers {
+		field := fmt.Sprintf("headers[%d]", i)
+		if !isValidHeaderName(header.Name) {
+			v.Addf(field+".name", "header name %q is invalid", header.Name)
+			continue
+		}
+		name := http.CanonicalHeaderKey(header.Name)
+		if _, ok := reservedHeaders[name]; ok {
+			v.Addf(field+".name", "header %s cannot be overridden", name)
+		} else if seen[name] {
+			v.Addf(field+".name", "duplicate header %s", name)
+		}
+		seen[name] = true
+		if header.Value == "" {
+			v.Addf(field+".value", "header %s value is required", name)
+		} else if strings.ContainsAny(header.Value, "\r\n") {
+			v.Addf(field+".value", "header %s value cannot contain line breaks", name)
+		}
+	}
+}
+
+// isValidHeaderName reports whether name is a valid HTTP header field name (an RFC 7230 token).
+func isValidHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case strings.ContainsRune("!#$%&'*+-.^_`|~", c):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// sealHeaders returns the headers to store: names are canonicalized and secret values encrypted.
+// A secret sent back as maskedSecret keeps the encrypted value of the same header in existing.
+func (h *Handlers) sealHeaders(headers, existing []database.EndpointHeader) ([]database.EndpointHeader, error) {
+	sealed := make([]database.EndpointHeader, 0, len(headers))
+	for _, header := range headers {
+		header.Name = http.CanonicalHeaderKey(header.Name)
+		if header.Secret {
+			value, err := h.sealSecret(header, existing)
+			if err != nil {
+				return nil, err
+			}
+			header.Value = value
+		}
+		sealed = append(sealed, header)
+	}
+	return sealed, nil
+}
+
+// sealSecret returns the encrypted value of a secret header.
+func (h *Handlers) sealSecret(header database.EndpointHeader, existing []database.EndpointHeader) (string, error) {
+	if header.Value == maskedSecret {
+		for _, e := range existing {
+			if e.Secret && e.Name == header.Name {
+				return e.Value, nil
+			}
+		}
+		return "", fmt.Errorf("header %s has no stored secret to keep", header.Name)
+	}
+	if h.secrets == nil {
+		return "", fmt.Errorf("secret headers require ENDPOINT_SECRET_KEY to be configured")
+	}
+	value, err := h.secrets.Encrypt(header.Value)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt header %s: %w", header.Name, err)
+	}
+	return value, nil
+}
+
+// maskEndpoint returns a copy of endpoint with secret header values and the OAuth2
+// client secret masked, for API responses.
+func maskEndpoint(endpoint *database.Endpoint) *database.Endpoint {
+	if endpoint == nil || (len(endpoint.Metadata.Headers) == 0 && endpoint.Metadata.OAuth2 == nil) {
+		return endpoint
+	}
+	masked := *endpoint
+	masked.Metadata.Headers = make([]database.EndpointHeader, len(endpoint.Metadata.Headers))
+	for i, header := range endpoint.Metadata.Headers {
+		if header.Secret {
+			header.Value = maskedSecret
+		}
+		masked.Metadata.Headers[i] = header
+	}
+	if endpoint.Metadata.OAuth2 != nil {
+		oauth2 := *endpoint.Metadata.OAuth2
+		oauth2.ClientSecret = maskedSecret
+		masked.Metadata.OAuth2 = &oauth2
+	}
+	return &masked
+}
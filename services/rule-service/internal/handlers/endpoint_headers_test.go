@@ -0,0 +1,148 @@
+// Package handlers provides tests for webhook endpoint custom headers.
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"rule-service/internal/database"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+func newSecretHandlers(t *testing.T, mockDB *mockRepository) (*Handlers, *shared.SecretBox) {
+	t.Helper()
+	box, err := shared.NewSecretBox("test-key")
+	if err != nil {
+		t.Fatalf("NewSecretBox() error = %v", err)
+	}
+	h := NewHandlersWithDeps(mockDB, &mockPublisher{}, nil)
+	WithSecretBox(box)(h)
+	return h, box
+}
+
+// TestHandlers_CreateEndpoint_Headers tests that secret headers are stored encrypted and masked in responses.
+func TestHandlers_CreateEndpoint_Headers(t *testing.T) {
+	var stored database.EndpointMetadata
+	mockDB := &mockRepository{}
+	mockDB.CreateEndpointFn = func(ctx context.Context, ruleID, endpointType, value string, metadata database.EndpointMetadata, templateID string) (*database.Endpoint, error) {
+		stored = metadata
+		return &database.Endpoint{EndpointID: "endpoint-1", RuleID: ruleID, Type: endpointType, Value: value, Enabled: true, Metadata: metadata}, nil
+	}
+	h, box := newSecretHandlers(t, mockDB)
+
+	body := `{"rule_id":"rule-1","type":"webhook","value":"https://hooks.acme.io/alerts","headers":[
+		{"name":"authorization","value":"Bearer s3cret","secret":true},
+		{"name":"X-Team","value":"payments"}]}`
+	w := httptest.NewRecorder()
+	h.CreateEndpoint(w, httptest.NewRequest(http.MethodPost, "/api/v1/endpoints", bytes.NewBufferString(body)))
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("CreateEndpoint() status = %v, want %v: %s", w.Code, http.StatusCreated, w.Body.String())
+	}
+	if len(stored.Headers) != 2 || stored.Headers[0].Name != "Authorization" {
+		t.Fatalf("stored headers = %+v, want canonical Authorization and X-Team", stored.Headers)
+	}
+	if plain, err := box.Decrypt(stored.Headers[0].Value); err != nil || plain != "Bearer s3cret" {
+		t.Errorf("stored secret decrypts to %q (err %v), want the original value", plain, err)
+	}
+	if stored.Headers[1].Value != "payments" {
+		t.Errorf("stored X-Team = %q, want it in plain text", stored.Headers[1].Value)
+	}
+
+	var resp database.Endpoint
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Metadata.Headers[0].Value != maskedSecret || resp.Metadata.Headers[1].Value != "payments" {
+		t.Errorf("response headers = %+v, want only the secret masked", resp.Metadata.Headers)
+	}
+}
+
+// TestHandlers_CreateEndpoint_InvalidHeaders tests header validation.
+func TestHandlers_CreateEndpoint_InvalidHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		secrets bool
+	}{
+		{"headers on email endpoint", `{"rule_id":"rule-1","type":"email","value":"a@b.io","headers":[{"name":"X-A","value":"1"}]}`, true},
+		{"invalid name", `{"rule_id":"rule-1","type":"webhook","value":"https://h.io","headers":[{"name":"X A","value":"1"}]}`, true},
+		{"reserved name", `{"rule_id":"rule-1","type":"webhook","value":"https://h.io","headers":[{"name":"content-type","value":"text/plain"}]}`, true},
+		{"duplicate name", `{"rule_id":"rule-1","type":"webhook","value":"https://h.io","headers":[{"name":"X-A","value":"1"},{"name":"x-a","value":"2"}]}`, true},
+		{"empty value", `{"rule_id":"rule-1","type":"webhook","value":"https://h.io","headers":[{"name":"X-A","value":""}]}`, true},
+		{"line break in value", `{"rule_id":"rule-1","type":"webhook","value":"https://h.io","headers":[{"name":"X-A","value":"1\r\nX-B: 2"}]}`, true},
+		{"masked secret on create", `{"rule_id":"rule-1","type":"webhook","value":"https://h.io","headers":[{"name":"X-A","value":"********","secret":true}]}`, true},
+		{"secret without key", `{"rule_id":"rule-1","type":"webhook","value":"https://h.io","headers":[{"name":"X-A","value":"1","secret":true}]}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := &mockRepository{}
+			h := NewHandlersWithDeps(mockDB, &mockPublisher{}, nil)
+			if tt.secrets {
+				h, _ = newSecretHandlers(t, mockDB)
+			}
+			w := httptest.NewRecorder()
+			h.CreateEndpoint(w, httptest.NewRequest(http.MethodPost, "/api/v1/endpoints", bytes.NewBufferString(tt.body)))
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("CreateEndpoint() status = %v, want %v", w.Code, http.StatusBadRequest)
+			}
+		})
+	}
+}
+
+// TestHandlers_UpdateEndpoint_Headers tests that a masked secret keeps the stored value
+// and that omitting headers leaves them unchanged.
+func TestHandlers_UpdateEndpoint_Headers(t *testing.T) {
+	existing := []database.EndpointHeader{{Name: "Authorization", Value: "sealed-token", Secret: true}}
+
+	t.Run("masked secret keeps stored value", func(t *testing.T) {
+		var got *database.EndpointMetadata
+		mockDB := &mockRepository{}
+		mockDB.GetEndpointFn = func(ctx context.Context, endpointID string) (*database.Endpoint, error) {
+			return &database.Endpoint{EndpointID: endpointID, Type: "webhook", Metadata: database.EndpointMetadata{Headers: existing}}, nil
+		}
+		mockDB.UpdateEndpointFn = func(ctx context.Context, endpointID, endpointType, value string, metadata *database.EndpointMetadata, templateID *string) (*database.Endpoint, error) {
+			got = metadata
+			return &database.Endpoint{EndpointID: endpointID, Type: endpointType, Value: value, Metadata: *metadata}, nil
+		}
+		h, _ := newSecretHandlers(t, mockDB)
+
+		body := `{"type":"webhook","value":"https://h.io","headers":[{"name":"Authorization","value":"********","secret":true},{"name":"X-Team","value":"payments"}]}`
+		w := httptest.NewRecorder()
+		h.UpdateEndpoint(w, httptest.NewRequest(http.MethodPut, "/api/v1/endpoints?endpoint_id=endpoint-1", bytes.NewBufferString(body)))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("UpdateEndpoint() status = %v, want %v: %s", w.Code, http.StatusOK, w.Body.String())
+		}
+		if got == nil || len(got.Headers) != 2 || got.Headers[0].Value != "sealed-token" {
+			t.Errorf("updated metadata = %+v, want the stored secret kept", got)
+		}
+	})
+
+	t.Run("omitted headers are unchanged", func(t *testing.T) {
+		called := false
+		mockDB := &mockRepository{}
+		mockDB.UpdateEndpointFn = func(ctx context.Context, endpointID, endpointType, value string, metadata *database.EndpointMetadata, templateID *string) (*database.Endpoint, error) {
+			called = true
+			if metadata != nil {
+				t.Errorf("metadata = %+v, want nil", metadata)
+			}
+			return &database.Endpoint{EndpointID: endpointID, Type: endpointType, Value: value}, nil
+		}
+		h, _ := newSecretHandlers(t, mockDB)
+
+		w := httptest.NewRecorder()
+		h.UpdateEndpoint(w, httptest.NewRequest(http.MethodPut, "/api/v1/endpoints?endpoint_id=endpoint-1", bytes.NewBufferString(`{"type":"webhook","value":"https://h.io"}`)))
+
+		if w.Code != http.StatusOK || !called {
+			t.Errorf("UpdateEndpoint() status = %v, called = %v, want 200 and an update", w.Code, called)
+		}
+	})
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"rule-service/internal/database"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// validateOAuth2 checks an OAuth2 client-credentials configuration requested for an endpoint.
+// A nil config, or one without a token URL (which removes OAuth2 on update), is valid.
+func validateOAuth2(v *shared.Validator, endpointType string, o *database.EndpointOAuth2) {
+	if o == nil || o.TokenURL == "" {
+		return
+	}
+	if !v.Check(endpointType == "webhook", "oauth2", "oauth2 is only supported for webhook endpoints") {
+		return
+	}
+	if u, err := url.Parse(o.TokenURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.Add("oauth2.token_url", "oauth2 token_url must be an HTTP or HTTPS URL")
+	}
+	v.Check(o.ClientID != "", "oauth2.client_id", "oauth2 client_id is required")
+	v.Check(o.ClientSecret != "", "oauth2.client_secret", "oauth2 client_secret is required")
+	for i, scope := range o.Scopes {
+		if scope == "" || strings.ContainsAny(scope, " \t\r\n") {
+			v.Addf(fmt.Sprintf("oauth2.scopes[%d]", i), "oauth2 scope %q is invalid", scope)
+		}
+	}
+}
+
+// sealOAuth2 returns the OAuth2 config to store, with the client secret encrypted.
+// A client secret sent back as maskedSecret keeps the secret in existing.
+func (h *Handlers) sealOAuth2(o, existing *database.EndpointOAuth2) (*database.EndpointOAuth2, error) {
+	if o == nil || o.TokenURL == "" {
+		return nil, nil
+	}
+	sealed := *o
+	if o.ClientSecret == maskedSecret {
+		if existing == nil {
+			return nil, fmt.Errorf("oauth2 has no stored client_secret to keep")
+		}
+		sealed.ClientSecret = existing.ClientSecret
+		return &sealed, nil
+	}
+	if h.secrets == nil {
+		return nil, fmt.Errorf("oauth2 requires ENDPOINT_SECRET_KEY to be configured")
+	}
+	secret, err := h.secrets.Encrypt(o.ClientSecret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt oauth2 client_secret: %w", err)
+	}
+	sealed.ClientSecret = secret
+	return &sealed, nil
+}
+
+// sealMetadata builds the metadata to store from the requested headers and OAuth2 config.
+// Fields left out of the request (nil) keep their value in current.
+func (h *Handlers) sealMetadata(headers []database.EndpointHeader, oauth2 *database.EndpointOAuth2, current database.EndpointMetadata) (database.EndpointMetadata, error) {
+	metadata := current
+	if headers != nil {
+		sealed, err := h.sealHeaders(headers, current.Headers)
+		if err != nil {
+			return metadata, err
+		}
+		metadata.Headers = sealed
+	}
+	if oauth2 != nil {
+		sealed, err := h.sealOAuth2(oauth2, current.OAuth2)
+		if err != nil {
+			return metadata, err
+		}
+		metadata.OAuth2 = sealed
+	}
+	return metadata, nil
+}
@@ -0,0 +1,145 @@
+// Package handlers provides tests for webhook endpoint OAuth2 configuration.
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"rule-service/internal/database"
+)
+
+// TestHandlers_CreateEndpoint_OAuth2 tests that the client secret is stored encrypted and masked in responses.
+func TestHandlers_CreateEndpoint_OAuth2(t *testing.T) {
+	var stored database.EndpointMetadata
+	mockDB := &mockRepository{}
+	mockDB.CreateEndpointFn = func(ctx context.Context, ruleID, endpointType, value string, metadata database.EndpointMetadata, templateID string) (*database.Endpoint, error) {
+		stored = metadata
+		return &database.Endpoint{EndpointID: "endpoint-1", RuleID: ruleID, Type: endpointType, Value: value, Metadata: metadata}, nil
+	}
+	h, box := newSecretHandlers(t, mockDB)
+
+	body := `{"rule_id":"rule-1","type":"webhook","value":"https://api.acme.io/alerts",
+		"oauth2":{"token_url":"https://auth.acme.io/token","client_id":"alerting","client_secret":"s3cret","scopes":["alerts.write"]}}`
+	w := httptest.NewRecorder()
+	h.CreateEndpoint(w, httptest.NewRequest(http.MethodPost, "/api/v1/endpoints", bytes.NewBufferString(body)))
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("CreateEndpoint() status = %v, want %v: %s", w.Code, http.StatusCreated, w.Body.String())
+	}
+	if stored.OAuth2 == nil || stored.OAuth2.ClientID != "alerting" {
+		t.Fatalf("stored oauth2 = %+v, want the requested config", stored.OAuth2)
+	}
+	if plain, err := box.Decrypt(stored.OAuth2.ClientSecret); err != nil || plain != "s3cret" {
+		t.Errorf("stored client_secret decrypts to %q (err %v), want the original value", plain, err)
+	}
+
+	var resp database.Endpoint
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Metadata.OAuth2 == nil || resp.Metadata.OAuth2.ClientSecret != maskedSecret {
+		t.Errorf("response oauth2 = %+v, want the client secret masked", resp.Metadata.OAuth2)
+	}
+}
+
+// TestHandlers_CreateEndpoint_InvalidOAuth2 tests OAuth2 validation.
+func TestHandlers_CreateEndpoint_InvalidOAuth2(t *testing.T) {
+	tests := []struct {
+		name   string
+		oauth2 string
+	}{
+		{"relative token url", `{"token_url":"/token","client_id":"a","client_secret":"b"}`},
+		{"missing client id", `{"token_url":"https://auth.io/token","client_secret":"b"}`},
+		{"missing client secret", `{"token_url":"https://auth.io/token","client_id":"a"}`},
+		{"scope with space", `{"token_url":"https://auth.io/token","client_id":"a","client_secret":"b","scopes":["a b"]}`},
+		{"masked secret on create", `{"token_url":"https://auth.io/token","client_id":"a","client_secret":"********"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := newSecretHandlers(t, &mockRepository{})
+			body := `{"rule_id":"rule-1","type":"webhook","value":"https://h.io","oauth2":` + tt.oauth2 + `}`
+			w := httptest.NewRecorder()
+			h.CreateEndpoint(w, httptest.NewRequest(http.MethodPost, "/api/v1/endpoints", bytes.NewBufferString(body)))
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("CreateEndpoint() status = %v, want %v", w.Code, http.StatusBadRequest)
+			}
+		})
+	}
+
+	t.Run("email endpoint", func(t *testing.T) {
+		h, _ := newSecretHandlers(t, &mockRepository{})
+		body := `{"rule_id":"rule-1","type":"email","value":"a@b.io","oauth2":{"token_url":"https://auth.io/token","client_id":"a","client_secret":"b"}}`
+		w := httptest.NewRecorder()
+		h.CreateEndpoint(w, httptest.NewRequest(http.MethodPost, "/api/v1/endpoints", bytes.NewBufferString(body)))
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("CreateEndpoint() status = %v, want %v", w.Code, http.StatusBadRequest)
+		}
+	})
+}
+
+// TestHandlers_UpdateEndpoint_OAuth2 tests merging OAuth2 updates with the stored metadata.
+func TestHandlers_UpdateEndpoint_OAuth2(t *testing.T) {
+	current := database.EndpointMetadata{
+		Headers: []database.EndpointHeader{{Name: "X-Team", Value: "payments"}},
+		OAuth2:  &database.EndpointOAuth2{TokenURL: "https://auth.io/token", ClientID: "alerting", ClientSecret: "sealed-secret"},
+	}
+
+	tests := []struct {
+		name   string
+		oauth2 string
+		check  func(t *testing.T, m *database.EndpointMetadata)
+	}{
+		{
+			name:   "masked secret keeps stored secret",
+			oauth2: `{"token_url":"https://auth.io/v2/token","client_id":"alerting","client_secret":"********"}`,
+			check: func(t *testing.T, m *database.EndpointMetadata) {
+				if m.OAuth2 == nil || m.OAuth2.ClientSecret != "sealed-secret" || m.OAuth2.TokenURL != "https://auth.io/v2/token" {
+					t.Errorf("oauth2 = %+v, want new token URL with the stored secret", m.OAuth2)
+				}
+			},
+		},
+		{
+			name:   "empty object removes oauth2",
+			oauth2: `{}`,
+			check: func(t *testing.T, m *database.EndpointMetadata) {
+				if m.OAuth2 != nil {
+					t.Errorf("oauth2 = %+v, want nil", m.OAuth2)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *database.EndpointMetadata
+			mockDB := &mockRepository{}
+			mockDB.GetEndpointFn = func(ctx context.Context, endpointID string) (*database.Endpoint, error) {
+				return &database.Endpoint{EndpointID: endpointID, Type: "webhook", Metadata: current}, nil
+			}
+			mockDB.UpdateEndpointFn = func(ctx context.Context, endpointID, endpointType, value string, metadata *database.EndpointMetadata, templateID *string) (*database.Endpoint, error) {
+				got = metadata
+				return &database.Endpoint{EndpointID: endpointID, Type: endpointType, Value: value, Metadata: *metadata}, nil
+			}
+			h, _ := newSecretHandlers(t, mockDB)
+
+			body := `{"type":"webhook","value":"https://h.io","oauth2":` + tt.oauth2 + `}`
+			w := httptest.NewRecorder()
+			h.UpdateEndpoint(w, httptest.NewRequest(http.MethodPut, "/api/v1/endpoints?endpoint_id=endpoint-1", bytes.NewBufferString(body)))
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("UpdateEndpoint() status = %v, want %v: %s", w.Code, http.StatusOK, w.Body.String())
+			}
+			if got == nil || len(got.Headers) != 1 {
+				t.Fatalf("metadata = %+v, want the stored headers kept", got)
+			}
+			tt.check(t, got)
+		})
+	}
+}
@@ -4,19 +4,55 @@ package handlers
 import (
 	"log/slog"
 	"net/http"
+
+	"rule-service/internal/database"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
 )
 
 // CreateEndpointRequest represents a request to create an endpoint.
 type CreateEndpointRequest struct {
-	RuleID string `json:"rule_id"`
-	Type   string `json:"type"`   // email, webhook, slack
-	Value  string `json:"value"`  // email address, URL, etc.
+	RuleID     string                    `json:"rule_id"`
+	Type       string                    `json:"type"`                  // email, webhook, slack
+	Value      string                    `json:"value"`                 // email address, URL, etc.
+	Headers    []database.EndpointHeader `json:"headers,omitempty"`     // webhook only
+	OAuth2     *database.EndpointOAuth2  `json:"oauth2,omitempty"`      // webhook only
+	TemplateID string                    `json:"template_id,omitempty"` // email and slack only
+}
+
+func (req *CreateEndpointRequest) validate(v *shared.Validator) {
+	v.Required("rule_id", req.RuleID)
+	validateEndpoint(v, req.Type, req.Value, req.Headers, req.OAuth2, req.TemplateID)
 }
 
 // UpdateEndpointRequest represents a request to update an endpoint.
+// Omitting headers, oauth2 or template_id keeps the current value; an empty list, object or
+// string removes it.
 type UpdateEndpointRequest struct {
-	Type  string `json:"type"`  // email, webhook, slack
-	Value string `json:"value"` // email address, URL, etc.
+	Type       string                    `json:"type"`                  // email, webhook, slack
+	Value      string                    `json:"value"`                 // email address, URL, etc.
+	Headers    []database.EndpointHeader `json:"headers,omitempty"`     // webhook only
+	OAuth2     *database.EndpointOAuth2  `json:"oauth2,omitempty"`      // webhook only
+	TemplateID *string                   `json:"template_id,omitempty"` // email and slack only
+}
+
+func (req *UpdateEndpointRequest) validate(v *shared.Validator) {
+	var templateID string
+	if req.TemplateID != nil {
+		templateID = *req.TemplateID
+	}
+	validateEndpoint(v, req.Type, req.Value, req.Headers, req.OAuth2, templateID)
+}
+
+// validateEndpoint validates the fields endpoints are created and updated with.
+func validateEndpoint(v *shared.Validator, endpointType, value string, headers []database.EndpointHeader, oauth2 *database.EndpointOAuth2, templateID string) {
+	if v.Required("type", endpointType) {
+		v.OneOf("type", endpointType, endpointTypes...)
+	}
+	v.Required("value", value)
+	validateHeaders(v, endpointType, headers)
+	validateOAuth2(v, endpointType, oauth2)
+	v.Check(templateID == "" || endpointType != "webhook", "template_id", "template_id is only supported for email and slack endpoints")
 }
 
 // ToggleEndpointEnabledRequest represents a request to toggle endpoint enabled status.
@@ -35,27 +71,24 @@ func (h *Handlers) CreateEndpoint(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.RuleID == "" {
-		http.Error(w, "rule_id is required", http.StatusBadRequest)
+	if !validateRequest(w, &req) {
 		return
 	}
-	if req.Type == "" {
-		http.Error(w, "type is required", http.StatusBadRequest)
+	if !h.authorizeRule(w, r, "rule", req.RuleID) {
 		return
 	}
-	if req.Value == "" {
-		http.Error(w, "value is required", http.StatusBadRequest)
+
+	metadata, err := h.sealMetadata(req.Headers, req.OAuth2, database.EndpointMetadata{})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-
-	// Validate endpoint type enum
-	if !isValidEndpointType(req.Type) {
-		http.Error(w, "type must be one of: email, webhook, slack", http.StatusBadRequest)
+	if !h.checkEndpointTemplate(w, r, req.RuleID, req.TemplateID) {
 		return
 	}
 
 	ctx := r.Context()
-	endpoint, err := h.db.CreateEndpoint(ctx, req.RuleID, req.Type, req.Value)
+	endpoint, err := h.db.CreateEndpoint(ctx, req.RuleID, req.Type, req.Value, metadata, req.TemplateID)
 	if err != nil {
 		if handleDBError(w, err, "endpoint", req.RuleID) {
 			return
@@ -64,7 +97,7 @@ func (h *Handlers) CreateEndpoint(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeJSON(w, http.StatusCreated, endpoint)
+	writeJSON(w, http.StatusCreated, maskEndpoint(endpoint))
 }
 
 // GetEndpoint retrieves an endpoint by ID.
@@ -87,12 +120,16 @@ func (h *Handlers) GetEndpoint(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to get endpoint: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if !h.authorizeRule(w, r, "endpoint", endpoint.RuleID) {
+		return
+	}
 
-	writeJSON(w, http.StatusOK, endpoint)
+	writeJSON(w, http.StatusOK, maskEndpoint(endpoint))
 }
 
 // ListEndpoints retrieves endpoints with pagination, optionally filtered by rule_id.
-// Query params: rule_id (optional), limit (default 50, max 200), offset (default 0)
+// Query params: rule_id (optional, required for client-scoped API keys), limit (default 50, max 200),
+// offset (default 0)
 func (h *Handlers) ListEndpoints(w http.ResponseWriter, r *http.Request) {
 	if !requireMethod(w, r, http.MethodGet) {
 		return
@@ -102,6 +139,12 @@ func (h *Handlers) ListEndpoints(w http.ResponseWriter, r *http.Request) {
 	var ruleIDPtr *string
 	if ruleID != "" {
 		ruleIDPtr = &ruleID
+		if !h.authorizeRule(w, r, "rule", ruleID) {
+			return
+		}
+	} else if _, scoped := scopedClient(r); scoped {
+		http.Error(w, "rule_id is required for client-scoped API keys", http.StatusBadRequest)
+		return
 	}
 
 	p := parsePagination(r)
@@ -113,6 +156,9 @@ func (h *Handlers) ListEndpoints(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	for i, endpoint := range result.Endpoints {
+		result.Endpoints[i] = maskEndpoint(endpoint)
+	}
 	writeJSON(w, http.StatusOK, result)
 }
 
@@ -126,29 +172,52 @@ func (h *Handlers) UpdateEndpoint(w http.ResponseWriter, r *http.Request) {
 	if !ok {
 		return
 	}
+	if !h.authorizeEndpoint(w, r, endpointID) {
+		return
+	}
 
 	var req UpdateEndpointRequest
 	if !decodeJSON(w, r, &req) {
 		return
 	}
 
-	if req.Type == "" {
-		http.Error(w, "type is required", http.StatusBadRequest)
+	if !validateRequest(w, &req) {
 		return
 	}
-	if req.Value == "" {
-		http.Error(w, "value is required", http.StatusBadRequest)
-		return
+
+	ctx := r.Context()
+	templateID := req.TemplateID
+	if req.Type == "webhook" && (templateID == nil || *templateID == "") {
+		// Webhook payloads are not templated; drop any template the endpoint had
+		none := ""
+		templateID = &none
 	}
 
-	// Validate endpoint type enum
-	if !isValidEndpointType(req.Type) {
-		http.Error(w, "type must be one of: email, webhook, slack", http.StatusBadRequest)
-		return
+	var metadata *database.EndpointMetadata
+	if req.Headers != nil || req.OAuth2 != nil || (templateID != nil && *templateID != "") {
+		current, err := h.db.GetEndpoint(ctx, endpointID)
+		if err != nil {
+			if handleDBError(w, err, "endpoint", endpointID) {
+				return
+			}
+			http.Error(w, "Failed to get endpoint: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if req.Headers != nil || req.OAuth2 != nil {
+			// Merge with the stored metadata: omitted fields and masked secrets keep their values
+			sealed, err := h.sealMetadata(req.Headers, req.OAuth2, current.Metadata)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			metadata = &sealed
+		}
+		if templateID != nil && !h.checkEndpointTemplate(w, r, current.RuleID, *templateID) {
+			return
+		}
 	}
 
-	ctx := r.Context()
-	endpoint, err := h.db.UpdateEndpoint(ctx, endpointID, req.Type, req.Value)
+	endpoint, err := h.db.UpdateEndpoint(ctx, endpointID, req.Type, req.Value, metadata, templateID)
 	if err != nil {
 		if handleDBError(w, err, "endpoint", endpointID) {
 			return
@@ -157,7 +226,7 @@ func (h *Handlers) UpdateEndpoint(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeJSON(w, http.StatusOK, endpoint)
+	writeJSON(w, http.StatusOK, maskEndpoint(endpoint))
 }
 
 // ToggleEndpointEnabled toggles the enabled status of an endpoint.
@@ -170,6 +239,9 @@ func (h *Handlers) ToggleEndpointEnabled(w http.ResponseWriter, r *http.Request)
 	if !ok {
 		return
 	}
+	if !h.authorizeEndpoint(w, r, endpointID) {
+		return
+	}
 
 	var req ToggleEndpointEnabledRequest
 	if !decodeJSON(w, r, &req) {
@@ -186,7 +258,7 @@ func (h *Handlers) ToggleEndpointEnabled(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	writeJSON(w, http.StatusOK, endpoint)
+	writeJSON(w, http.StatusOK, maskEndpoint(endpoint))
 }
 
 // DeleteEndpoint deletes an endpoint.
@@ -199,6 +271,9 @@ func (h *Handlers) DeleteEndpoint(w http.ResponseWriter, r *http.Request) {
 	if !ok {
 		return
 	}
+	if !h.authorizeEndpoint(w, r, endpointID) {
+		return
+	}
 
 	ctx := r.Context()
 	if err := h.db.DeleteEndpoint(ctx, endpointID); err != nil {
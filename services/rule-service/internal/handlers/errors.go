@@ -2,9 +2,14 @@
 package handlers
 
 import (
+	"fmt"
 	"log/slog"
 	"net/http"
 	"strings"
+
+	"rule-service/internal/database"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
 )
 
 // handleDBError handles database errors and writes appropriate HTTP responses.
@@ -40,38 +45,81 @@ func handleDBError(w http.ResponseWriter, err error, resource string, resourceID
 	return true
 }
 
-// validateRuleFields validates rule fields (severity, source, name) are not empty.
-// Returns true if valid, false otherwise (and writes error response).
-func validateRuleFields(w http.ResponseWriter, severity, source, name string) bool {
-	if severity == "" {
-		http.Error(w, "severity is required", http.StatusBadRequest)
-		return false
+// validateRuleFields validates the rule fields: severity, source, and name are required,
+// severity must be a known severity or a wildcard, and they cannot all be wildcards.
+// prefix is prepended to the field names, for rules nested in a request (rules[0].).
+func validateRuleFields(v *shared.Validator, prefix, severity, source, name string) {
+	ok := v.Required(prefix+"severity", severity)
+	ok = v.Required(prefix+"source", source) && ok
+	ok = v.Required(prefix+"name", name) && ok
+	if severity != "" {
+		ok = v.OneOf(prefix+"severity", severity, ruleSeverities...) && ok
 	}
-	if source == "" {
-		http.Error(w, "source is required", http.StatusBadRequest)
-		return false
-	}
-	if name == "" {
-		http.Error(w, "name is required", http.StatusBadRequest)
-		return false
+	if ok && isAllWildcards(severity, source, name) {
+		v.Add(strings.TrimSuffix(prefix, "."), "cannot create rule with all fields as wildcards (*)")
 	}
-	return true
 }
 
-// validateRuleValues validates rule values (severity enum and wildcard rules).
-// Returns true if valid, false otherwise (and writes error response).
-func validateRuleValues(w http.ResponseWriter, severity, source, name string) bool {
-	// Validate severity enum (allow "*" as wildcard)
-	if !isValidSeverity(severity) {
-		http.Error(w, "severity must be one of: LOW, MEDIUM, HIGH, CRITICAL, or * (wildcard)", http.StatusBadRequest)
-		return false
-	}
+// maxRuleDescriptionLength bounds rule descriptions, which are copied into every notification.
+const maxRuleDescriptionLength = 500
 
-	// Validate that not all fields are wildcards
-	if isAllWildcards(severity, source, name) {
-		http.Error(w, "cannot create rule with all fields as wildcards (*)", http.StatusBadRequest)
-		return false
+// validateRuleDescription validates an optional rule description.
+func validateRuleDescription(v *shared.Validator, prefix, description string) {
+	v.MaxLength(prefix+"description", description, maxRuleDescriptionLength)
+}
+
+// maxRuleExclusions bounds each exclusion list, which the evaluator checks on every match.
+const maxRuleExclusions = 100
+
+// validateRuleExclusions validates the exclusion lists of a rule.
+// A field can only have exclusions if it is a wildcard, and exclusions are literal values.
+func validateRuleExclusions(v *shared.Validator, prefix, source, name string, exclusions database.RuleExclusions) {
+	validateExclusionList(v, prefix+"exclude_sources", "source", source, exclusions.Sources)
+	validateExclusionList(v, prefix+"exclude_names", "name", name, exclusions.Names)
+}
+
+// validateExclusionList validates one exclusion list against the field it excludes values of.
+func validateExclusionList(v *shared.Validator, list, field, value string, exclusions []string) {
+	if len(exclusions) == 0 {
+		return
+	}
+	if value != "*" {
+		v.Addf(list, "%s requires %s to be a wildcard (*)", list, field)
+		return
+	}
+	if len(exclusions) > maxRuleExclusions {
+		v.Addf(list, "%s must have at most %d entries", list, maxRuleExclusions)
+		return
+	}
+	for i, e := range exclusions {
+		if strings.TrimSpace(e) == "" || e == "*" {
+			v.Addf(fmt.Sprintf("%s[%d]", list, i), "%s entries must be non-empty values other than *", list)
+		}
 	}
+}
 
-	return true
+// Bounds of a rule's context conditions, which the evaluator checks on every match.
+// Keys and values are bounded like the alert context they are compared with.
+const (
+	maxRuleConditions       = 20
+	maxConditionKeyLength   = 128
+	maxConditionValueLength = 1024
+)
+
+// validateRuleConditions validates the context conditions of a rule.
+func validateRuleConditions(v *shared.Validator, prefix string, conditions database.RuleConditions) {
+	if len(conditions) > maxRuleConditions {
+		v.Addf(prefix+"conditions", "%sconditions must have at most %d entries", prefix, maxRuleConditions)
+		return
+	}
+	for i, c := range conditions {
+		field := fmt.Sprintf("%sconditions[%d]", prefix, i)
+		if strings.TrimSpace(c.Key) == "" {
+			v.Add(field+".key", field+".key is required")
+		} else {
+			v.MaxLength(field+".key", c.Key, maxConditionKeyLength)
+		}
+		v.OneOf(field+".op", c.Op, database.ConditionOpEqual, database.ConditionOpNotEqual)
+		v.MaxLength(field+".value", c.Value, maxConditionValueLength)
+	}
 }
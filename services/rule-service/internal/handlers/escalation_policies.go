@@ -0,0 +1,330 @@
+// Package handlers provides HTTP handlers for the rule-service API.
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"rule-service/internal/database"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// Bounds of escalation policies, which the sender scans every few seconds.
+const (
+	maxEscalationPolicyNameLength = 255
+	maxEscalationSteps            = 10
+	maxEscalationStepEndpoints    = 20
+	minEscalationDelay            = time.Minute
+	maxEscalationDelay            = 7 * 24 * time.Hour
+)
+
+// CreateEscalationPolicyRequest creates an escalation policy.
+type CreateEscalationPolicyRequest struct {
+	ClientID string                   `json:"client_id"`
+	Name     string                   `json:"name"`
+	Steps    database.EscalationSteps `json:"steps"`
+}
+
+// UpdateEscalationPolicyRequest changes an escalation policy. Omitted fields keep their value.
+type UpdateEscalationPolicyRequest struct {
+	Name  *string                   `json:"name,omitempty"`
+	Steps *database.EscalationSteps `json:"steps,omitempty"`
+}
+
+// RuleEscalationPolicy links a rule to an escalation policy; an empty EscalationPolicyID means none.
+type RuleEscalationPolicy struct {
+	RuleID             string `json:"rule_id"`
+	EscalationPolicyID string `json:"escalation_policy_id"`
+}
+
+func (req *CreateEscalationPolicyRequest) validate(v *shared.Validator) {
+	v.Required("client_id", req.ClientID)
+	validateEscalationPolicyName(v, req.Name)
+	validateEscalationSteps(v, req.Steps)
+}
+
+func (req *UpdateEscalationPolicyRequest) validate(v *shared.Validator) {
+	if req.Name != nil {
+		validateEscalationPolicyName(v, *req.Name)
+	}
+	if req.Steps != nil {
+		validateEscalationSteps(v, *req.Steps)
+	}
+}
+
+// validateEscalationPolicyName validates a policy name.
+func validateEscalationPolicyName(v *shared.Validator, name string) {
+	if v.Required("name", name) {
+		v.MaxLength("name", name, maxEscalationPolicyNameLength)
+	}
+}
+
+// validateEscalationSteps validates the steps of a policy.
+func validateEscalationSteps(v *shared.Validator, steps database.EscalationSteps) {
+	if !v.Check(len(steps) > 0, "steps", "steps must have at least one step") {
+		return
+	}
+	if !v.Check(len(steps) <= maxEscalationSteps, "steps", fmt.Sprintf("steps must have at most %d entries", maxEscalationSteps)) {
+		return
+	}
+	for i, step := range steps {
+		field := fmt.Sprintf("steps[%d]", i)
+		delay := time.Duration(step.DelaySeconds) * time.Second
+		if delay < minEscalationDelay || delay > maxEscalationDelay {
+			v.Addf(field+".delay_seconds", "%s.delay_seconds must be between %d and %d", field, int(minEscalationDelay.Seconds()), int(maxEscalationDelay.Seconds()))
+		}
+		if len(step.EndpointIDs) == 0 {
+			v.Add(field+".endpoint_ids", field+".endpoint_ids is required")
+		} else if len(step.EndpointIDs) > maxEscalationStepEndpoints {
+			v.Addf(field+".endpoint_ids", "%s.endpoint_ids must have at most %d entries", field, maxEscalationStepEndpoints)
+		}
+	}
+}
+
+// checkEscalationSteps checks that every endpoint of the validated steps belongs to a rule of
+// the client. Writes an error response and returns false otherwise.
+func (h *Handlers) checkEscalationSteps(w http.ResponseWriter, r *http.Request, clientID string, steps database.EscalationSteps) bool {
+	ctx := r.Context()
+	v := shared.NewValidator()
+	ruleClients := make(map[string]string) // rule ID -> client ID
+	for i, step := range steps {
+		field := fmt.Sprintf("steps[%d].endpoint_ids", i)
+		for _, endpointID := range step.EndpointIDs {
+			endpoint, err := h.db.GetEndpoint(ctx, endpointID)
+			if err != nil {
+				slog.Warn("Escalation endpoint lookup failed", "endpoint_id", endpointID, "error", err)
+				v.Addf(field, "%s: endpoint not found: %s", field, endpointID)
+				continue
+			}
+			owner, ok := ruleClients[endpoint.RuleID]
+			if !ok {
+				rule, err := h.db.GetRule(ctx, endpoint.RuleID)
+				if err != nil {
+					handleDBError(w, err, "rule", endpoint.RuleID)
+					return false
+				}
+				owner = rule.ClientID
+				ruleClients[endpoint.RuleID] = owner
+			}
+			if owner != clientID {
+				v.Addf(field, "%s: endpoint %s belongs to another client", field, endpointID)
+			}
+		}
+	}
+	if err := v.Err(); err != nil {
+		shared.WriteValidationError(w, err)
+		return false
+	}
+	return true
+}
+
+// CreateEscalationPolicy creates an escalation policy for a client.
+// POST /api/v1/escalation-policies
+func (h *Handlers) CreateEscalationPolicy(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	var req CreateEscalationPolicyRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if !validateRequest(w, &req) || !h.checkEscalationSteps(w, r, req.ClientID, req.Steps) {
+		return
+	}
+
+	policy, err := h.db.CreateEscalationPolicy(r.Context(), req.ClientID, req.Name, req.Steps)
+	if err != nil {
+		if handleDBError(w, err, "escalation policy", req.Name) {
+			return
+		}
+		http.Error(w, "Failed to create escalation policy: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.metrics.IncrementCustom("escalation_policies_created")
+	writeJSON(w, http.StatusCreated, policy)
+}
+
+// GetEscalationPolicy retrieves an escalation policy by ID.
+func (h *Handlers) GetEscalationPolicy(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	policyID, ok := requireQueryParam(w, r, "policy_id")
+	if !ok {
+		return
+	}
+
+	policy, err := h.db.GetEscalationPolicy(r.Context(), policyID)
+	if err != nil {
+		if handleDBError(w, err, "escalation policy", policyID) {
+			return
+		}
+		http.Error(w, "Failed to get escalation policy: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, policy)
+}
+
+// ListEscalationPolicies lists escalation policies, optionally filtered by client_id.
+func (h *Handlers) ListEscalationPolicies(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	var clientID *string
+	if c := r.URL.Query().Get("client_id"); c != "" {
+		clientID = &c
+	}
+
+	policies, err := h.db.ListEscalationPolicies(r.Context(), clientID)
+	if err != nil {
+		slog.Error("Failed to list escalation policies", "error", err)
+		http.Error(w, "Failed to list escalation policies", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, policies)
+}
+
+// UpdateEscalationPolicy changes an escalation policy. Notifications already escalating
+// continue with the new steps from the step they reached.
+// PUT /api/v1/escalation-policies/update?policy_id=
+func (h *Handlers) UpdateEscalationPolicy(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPut) {
+		return
+	}
+
+	policyID, ok := requireQueryParam(w, r, "policy_id")
+	if !ok {
+		return
+	}
+
+	var req UpdateEscalationPolicyRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if !validateRequest(w, &req) {
+		return
+	}
+
+	ctx := r.Context()
+	if req.Steps != nil {
+		current, err := h.db.GetEscalationPolicy(ctx, policyID)
+		if err != nil {
+			if handleDBError(w, err, "escalation policy", policyID) {
+				return
+			}
+			http.Error(w, "Failed to get escalation policy: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !h.checkEscalationSteps(w, r, current.ClientID, *req.Steps) {
+			return
+		}
+	}
+
+	policy, err := h.db.UpdateEscalationPolicy(ctx, policyID, req.Name, req.Steps)
+	if err != nil {
+		if handleDBError(w, err, "escalation policy", policyID) {
+			return
+		}
+		http.Error(w, "Failed to update escalation policy: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, policy)
+}
+
+// DeleteEscalationPolicy deletes an escalation policy. Its rules no longer escalate.
+func (h *Handlers) DeleteEscalationPolicy(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodDelete) {
+		return
+	}
+
+	policyID, ok := requireQueryParam(w, r, "policy_id")
+	if !ok {
+		return
+	}
+
+	if err := h.db.DeleteEscalationPolicy(r.Context(), policyID); err != nil {
+		if handleDBError(w, err, "escalation policy", policyID) {
+			return
+		}
+		http.Error(w, "Failed to delete escalation policy: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetRuleEscalationPolicy returns the escalation policy a rule uses.
+// GET /api/v1/rules/escalation-policy?rule_id=
+func (h *Handlers) GetRuleEscalationPolicy(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	ruleID, ok := requireQueryParam(w, r, "rule_id")
+	if !ok {
+		return
+	}
+
+	policyID, err := h.db.GetRuleEscalationPolicyID(r.Context(), ruleID)
+	if handleDBError(w, err, "rule", ruleID) {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, RuleEscalationPolicy{RuleID: ruleID, EscalationPolicyID: policyID})
+}
+
+// SetRuleEscalationPolicy links a rule to an escalation policy of the same client, or unlinks
+// it with an empty escalation_policy_id. Notifications of the rule that are already open
+// escalate with the new policy.
+// PUT /api/v1/rules/escalation-policy?rule_id=
+func (h *Handlers) SetRuleEscalationPolicy(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPut) {
+		return
+	}
+
+	ruleID, ok := requireQueryParam(w, r, "rule_id")
+	if !ok {
+		return
+	}
+
+	var req RuleEscalationPolicy
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	ctx := r.Context()
+	if req.EscalationPolicyID != "" {
+		rule, err := h.db.GetRule(ctx, ruleID)
+		if handleDBError(w, err, "rule", ruleID) {
+			return
+		}
+		policy, err := h.db.GetEscalationPolicy(ctx, req.EscalationPolicyID)
+		if err != nil {
+			slog.Warn("Escalation policy lookup failed", "policy_id", req.EscalationPolicyID, "error", err)
+			http.Error(w, "escalation policy not found: "+req.EscalationPolicyID, http.StatusBadRequest)
+			return
+		}
+		if policy.ClientID != rule.ClientID {
+			http.Error(w, "escalation policy belongs to another client", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := h.db.SetRuleEscalationPolicy(ctx, ruleID, req.EscalationPolicyID); handleDBError(w, err, "rule", ruleID) {
+		return
+	}
+
+	slog.Info("Rule escalation policy set", "rule_id", ruleID, "escalation_policy_id", req.EscalationPolicyID)
+	writeJSON(w, http.StatusOK, RuleEscalationPolicy{RuleID: ruleID, EscalationPolicyID: req.EscalationPolicyID})
+}
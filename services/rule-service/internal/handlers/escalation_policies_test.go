@@ -0,0 +1,212 @@
+// Package handlers provides tests for escalation policy HTTP handlers.
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"rule-service/internal/database"
+)
+
+// TestHandlers_CreateEscalationPolicy tests the CreateEscalationPolicy handler.
+func TestHandlers_CreateEscalationPolicy(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		setupMock      func(*mockRepository)
+		expectedStatus int
+	}{
+		{
+			name:           "successful create",
+			body:           `{"client_id":"client-1","name":"on-call","steps":[{"delay_seconds":900,"endpoint_ids":["ep-1"]},{"delay_seconds":1800,"endpoint_ids":["ep-2","ep-3"]}]}`,
+			setupMock:      func(m *mockRepository) {},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "missing client_id",
+			body:           `{"name":"on-call","steps":[{"delay_seconds":900,"endpoint_ids":["ep-1"]}]}`,
+			setupMock:      func(m *mockRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "no steps",
+			body:           `{"client_id":"client-1","name":"on-call","steps":[]}`,
+			setupMock:      func(m *mockRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "delay too short",
+			body:           `{"client_id":"client-1","name":"on-call","steps":[{"delay_seconds":10,"endpoint_ids":["ep-1"]}]}`,
+			setupMock:      func(m *mockRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "step without endpoints",
+			body:           `{"client_id":"client-1","name":"on-call","steps":[{"delay_seconds":900}]}`,
+			setupMock:      func(m *mockRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "unknown endpoint",
+			body: `{"client_id":"client-1","name":"on-call","steps":[{"delay_seconds":900,"endpoint_ids":["missing"]}]}`,
+			setupMock: func(m *mockRepository) {
+				m.GetEndpointFn = func(ctx context.Context, endpointID string) (*database.Endpoint, error) {
+					return nil, fmt.Errorf("endpoint not found: %s", endpointID)
+				}
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "endpoint of another client",
+			body: `{"client_id":"client-1","name":"on-call","steps":[{"delay_seconds":900,"endpoint_ids":["ep-1"]}]}`,
+			setupMock: func(m *mockRepository) {
+				m.GetRuleFn = func(ctx context.Context, ruleID string) (*database.Rule, error) {
+					return &database.Rule{RuleID: ruleID, ClientID: "client-2"}, nil
+				}
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "duplicate name",
+			body: `{"client_id":"client-1","name":"on-call","steps":[{"delay_seconds":900,"endpoint_ids":["ep-1"]}]}`,
+			setupMock: func(m *mockRepository) {
+				m.CreateEscalationPolicyFn = func(ctx context.Context, clientID, name string, steps database.EscalationSteps) (*database.EscalationPolicy, error) {
+					return nil, fmt.Errorf("escalation policy already exists for client %s with name %s", clientID, name)
+				}
+			},
+			expectedStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := &mockRepository{}
+			tt.setupMock(mockDB)
+
+			h := NewHandlersWithDeps(mockDB, &mockPublisher{}, nil)
+			w := httptest.NewRecorder()
+
+			h.CreateEscalationPolicy(w, httptest.NewRequest(http.MethodPost, "/api/v1/escalation-policies", bytes.NewBufferString(tt.body)))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("CreateEscalationPolicy() status = %v, want %v, body = %s", w.Code, tt.expectedStatus, w.Body.String())
+			}
+		})
+	}
+}
+
+// TestHandlers_UpdateEscalationPolicy tests that new steps are checked against the policy's client.
+func TestHandlers_UpdateEscalationPolicy(t *testing.T) {
+	t.Run("rename keeps steps", func(t *testing.T) {
+		var gotSteps *database.EscalationSteps
+		mockDB := &mockRepository{
+			UpdateEscalationPolicyFn: func(ctx context.Context, policyID string, name *string, steps *database.EscalationSteps) (*database.EscalationPolicy, error) {
+				gotSteps = steps
+				return &database.EscalationPolicy{PolicyID: policyID, Name: *name}, nil
+			},
+		}
+
+		h := NewHandlersWithDeps(mockDB, &mockPublisher{}, nil)
+		w := httptest.NewRecorder()
+		h.UpdateEscalationPolicy(w, httptest.NewRequest(http.MethodPut, "/api/v1/escalation-policies/update?policy_id=policy-1", bytes.NewBufferString(`{"name":"night"}`)))
+
+		if w.Code != http.StatusOK || gotSteps != nil {
+			t.Errorf("UpdateEscalationPolicy() status = %v, steps = %v, want 200 and unchanged steps", w.Code, gotSteps)
+		}
+	})
+
+	t.Run("steps with endpoint of another client", func(t *testing.T) {
+		mockDB := &mockRepository{
+			GetEscalationPolicyFn: func(ctx context.Context, policyID string) (*database.EscalationPolicy, error) {
+				return &database.EscalationPolicy{PolicyID: policyID, ClientID: "client-2"}, nil
+			},
+		}
+
+		h := NewHandlersWithDeps(mockDB, &mockPublisher{}, nil)
+		w := httptest.NewRecorder()
+		h.UpdateEscalationPolicy(w, httptest.NewRequest(http.MethodPut, "/api/v1/escalation-policies/update?policy_id=policy-1", bytes.NewBufferString(`{"steps":[{"delay_seconds":900,"endpoint_ids":["ep-1"]}]}`)))
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("UpdateEscalationPolicy() status = %v, want %v", w.Code, http.StatusBadRequest)
+		}
+	})
+}
+
+// TestHandlers_DeleteEscalationPolicy tests the DeleteEscalationPolicy handler.
+func TestHandlers_DeleteEscalationPolicy(t *testing.T) {
+	mockDB := &mockRepository{
+		DeleteEscalationPolicyFn: func(ctx context.Context, policyID string) error {
+			return fmt.Errorf("escalation policy not found: %s", policyID)
+		},
+	}
+
+	h := NewHandlersWithDeps(mockDB, &mockPublisher{}, nil)
+	w := httptest.NewRecorder()
+	h.DeleteEscalationPolicy(w, httptest.NewRequest(http.MethodDelete, "/api/v1/escalation-policies/delete?policy_id=missing", nil))
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("DeleteEscalationPolicy() status = %v, want %v", w.Code, http.StatusNotFound)
+	}
+}
+
+// TestHandlers_RuleEscalationPolicy tests linking rules to escalation policies.
+func TestHandlers_RuleEscalationPolicy(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		policyClient   string
+		expectedStatus int
+		wantLinked     string
+	}{
+		{"link", `{"escalation_policy_id":"policy-1"}`, "client-1", http.StatusOK, "policy-1"},
+		{"unlink", `{"escalation_policy_id":""}`, "client-1", http.StatusOK, ""},
+		{"policy of another client", `{"escalation_policy_id":"policy-1"}`, "client-2", http.StatusBadRequest, "unchanged"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			linked := "unchanged"
+			mockDB := &mockRepository{
+				GetEscalationPolicyFn: func(ctx context.Context, policyID string) (*database.EscalationPolicy, error) {
+					return &database.EscalationPolicy{PolicyID: policyID, ClientID: tt.policyClient}, nil
+				},
+				SetRuleEscalationPolicyFn: func(ctx context.Context, ruleID, policyID string) error {
+					linked = policyID
+					return nil
+				},
+			}
+
+			h := NewHandlersWithDeps(mockDB, &mockPublisher{}, nil)
+			w := httptest.NewRecorder()
+			h.SetRuleEscalationPolicy(w, httptest.NewRequest(http.MethodPut, "/api/v1/rules/escalation-policy?rule_id=rule-1", bytes.NewBufferString(tt.body)))
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("SetRuleEscalationPolicy() status = %v, want %v, body = %s", w.Code, tt.expectedStatus, w.Body.String())
+			}
+			if linked != tt.wantLinked {
+				t.Errorf("linked policy = %q, want %q", linked, tt.wantLinked)
+			}
+		})
+	}
+
+	t.Run("get", func(t *testing.T) {
+		mockDB := &mockRepository{
+			GetRuleEscalationPolicyIDFn: func(ctx context.Context, ruleID string) (string, error) {
+				return "policy-1", nil
+			},
+		}
+
+		h := NewHandlersWithDeps(mockDB, &mockPublisher{}, nil)
+		w := httptest.NewRecorder()
+		h.GetRuleEscalationPolicy(w, httptest.NewRequest(http.MethodGet, "/api/v1/rules/escalation-policy?rule_id=rule-1", nil))
+
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"escalation_policy_id":"policy-1"`) {
+			t.Errorf("GetRuleEscalationPolicy() status = %v, body = %s, want policy-1", w.Code, w.Body.String())
+		}
+	})
+}
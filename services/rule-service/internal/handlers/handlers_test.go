@@ -5,13 +5,17 @@ package handlers
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"rule-service/internal/database"
+	"rule-service/internal/events"
 )
 
 // TestHandlers_CreateClient tests the CreateClient handler.
@@ -28,7 +32,7 @@ func TestHandlers_CreateClient(t *testing.T) {
 			method: http.MethodPost,
 			body:   `{"client_id":"client-1","name":"Test Client"}`,
 			setupMock: func(m *mockRepository) {
-				m.CreateClientFn = func(ctx context.Context, clientID, name string) error {
+				m.CreateClientFn = func(ctx context.Context, clientID, name, timezone, locale string) error {
 					return nil
 				}
 				m.GetClientFn = func(ctx context.Context, clientID string) (*database.Client, error) {
@@ -65,12 +69,40 @@ func TestHandlers_CreateClient(t *testing.T) {
 			setupMock:      func(m *mockRepository) {},
 			expectedStatus: http.StatusBadRequest,
 		},
+		{
+			name:   "with timezone and locale",
+			method: http.MethodPost,
+			body:   `{"client_id":"client-1","name":"Test Client","timezone":"Europe/Berlin","locale":"de-DE"}`,
+			setupMock: func(m *mockRepository) {
+				m.CreateClientFn = func(ctx context.Context, clientID, name, timezone, locale string) error {
+					if timezone != "Europe/Berlin" || locale != "de-DE" {
+						return fmt.Errorf("unexpected settings %q %q", timezone, locale)
+					}
+					return nil
+				}
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "unknown timezone",
+			method:         http.MethodPost,
+			body:           `{"client_id":"client-1","name":"Test Client","timezone":"Mars/Olympus"}`,
+			setupMock:      func(m *mockRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unsupported locale",
+			method:         http.MethodPost,
+			body:           `{"client_id":"client-1","name":"Test Client","locale":"xx-XX"}`,
+			setupMock:      func(m *mockRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
 		{
 			name:   "duplicate client",
 			method: http.MethodPost,
 			body:   `{"client_id":"client-1","name":"Test Client"}`,
 			setupMock: func(m *mockRepository) {
-				m.CreateClientFn = func(ctx context.Context, clientID, name string) error {
+				m.CreateClientFn = func(ctx context.Context, clientID, name, timezone, locale string) error {
 					return fmt.Errorf("client already exists: %s", clientID)
 				}
 			},
@@ -96,6 +128,110 @@ func TestHandlers_CreateClient(t *testing.T) {
 	}
 }
 
+// TestHandlers_UpdateClientSettings tests the UpdateClientSettings handler.
+func TestHandlers_UpdateClientSettings(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		url            string
+		body           string
+		setupMock      func(*mockRepository)
+		expectedStatus int
+		wantTimezone   string
+		wantLocale     string
+	}{
+		{
+			name:           "set both",
+			method:         http.MethodPut,
+			url:            "/api/v1/clients/settings?client_id=client-1",
+			body:           `{"timezone":" America/New_York ","locale":"en-US"}`,
+			setupMock:      func(m *mockRepository) {},
+			expectedStatus: http.StatusOK,
+			wantTimezone:   "America/New_York",
+			wantLocale:     "en-US",
+		},
+		{
+			name:           "reset timezone",
+			method:         http.MethodPut,
+			url:            "/api/v1/clients/settings?client_id=client-1",
+			body:           `{"timezone":""}`,
+			setupMock:      func(m *mockRepository) {},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "nothing to change",
+			method:         http.MethodPut,
+			url:            "/api/v1/clients/settings?client_id=client-1",
+			body:           `{}`,
+			setupMock:      func(m *mockRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unknown timezone",
+			method:         http.MethodPut,
+			url:            "/api/v1/clients/settings?client_id=client-1",
+			body:           `{"timezone":"Local"}`,
+			setupMock:      func(m *mockRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "missing client_id",
+			method:         http.MethodPut,
+			url:            "/api/v1/clients/settings",
+			body:           `{"locale":"en-GB"}`,
+			setupMock:      func(m *mockRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "client not found",
+			method: http.MethodPut,
+			url:    "/api/v1/clients/settings?client_id=client-999",
+			body:   `{"locale":"en-GB"}`,
+			setupMock: func(m *mockRepository) {
+				m.UpdateClientSettingsFn = func(ctx context.Context, clientID string, timezone, locale *string) (*database.Client, error) {
+					return nil, fmt.Errorf("client not found: %s", clientID)
+				}
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "wrong method",
+			method:         http.MethodPost,
+			url:            "/api/v1/clients/settings?client_id=client-1",
+			body:           `{"locale":"en-GB"}`,
+			setupMock:      func(m *mockRepository) {},
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := &mockRepository{}
+			tt.setupMock(mockDB)
+
+			h := NewHandlersWithDeps(mockDB, &mockPublisher{}, nil)
+			req := httptest.NewRequest(tt.method, tt.url, bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+
+			h.UpdateClientSettings(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("UpdateClientSettings() status = %v, want %v, body = %s", w.Code, tt.expectedStatus, w.Body.String())
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			var client database.Client
+			if err := json.Unmarshal(w.Body.Bytes(), &client); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if client.Timezone != tt.wantTimezone || client.Locale != tt.wantLocale {
+				t.Errorf("UpdateClientSettings() = %q %q, want %q %q", client.Timezone, client.Locale, tt.wantTimezone, tt.wantLocale)
+			}
+		})
+	}
+}
+
 // TestHandlers_GetClient tests the GetClient handler.
 func TestHandlers_GetClient(t *testing.T) {
 	tests := []struct {
@@ -215,7 +351,7 @@ func TestHandlers_CreateRule(t *testing.T) {
 			method: http.MethodPost,
 			body:   `{"client_id":"client-1","severity":"HIGH","source":"source-1","name":"alert-1"}`,
 			setupMock: func(m *mockRepository) {
-				m.CreateRuleFn = func(ctx context.Context, clientID, severity, source, name string) (*database.Rule, error) {
+				m.CreateRuleFn = func(ctx context.Context, clientID, severity, source, name, description string, exclusions database.RuleExclusions, conditions database.RuleConditions) (*database.Rule, error) {
 					return &database.Rule{
 						RuleID: "rule-1", ClientID: clientID, Severity: severity, Source: source, Name: name,
 						Enabled: true, Version: 1, CreatedAt: time.Now(), UpdatedAt: time.Now(),
@@ -266,12 +402,93 @@ func TestHandlers_CreateRule(t *testing.T) {
 			setupMock:      func(m *mockRepository) {},
 			expectedStatus: http.StatusBadRequest,
 		},
+		{
+			name:   "with description",
+			method: http.MethodPost,
+			body:   `{"client_id":"client-1","severity":"HIGH","source":"checkout","name":"*","description":"Checkout errors page the payments on-call"}`,
+			setupMock: func(m *mockRepository) {
+				m.CreateRuleFn = func(ctx context.Context, clientID, severity, source, name, description string, exclusions database.RuleExclusions, conditions database.RuleConditions) (*database.Rule, error) {
+					if description != "Checkout errors page the payments on-call" {
+						return nil, fmt.Errorf("unexpected description %q", description)
+					}
+					return &database.Rule{RuleID: "rule-1", ClientID: clientID, Description: description}, nil
+				}
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "description too long",
+			method:         http.MethodPost,
+			body:           `{"client_id":"client-1","severity":"HIGH","source":"source-1","name":"alert-1","description":"` + strings.Repeat("x", maxRuleDescriptionLength+1) + `"}`,
+			setupMock:      func(m *mockRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "with exclusions",
+			method: http.MethodPost,
+			body:   `{"client_id":"client-1","severity":"HIGH","source":"*","name":"timeout","exclude_sources":["staging","canary"]}`,
+			setupMock: func(m *mockRepository) {
+				m.CreateRuleFn = func(ctx context.Context, clientID, severity, source, name, description string, exclusions database.RuleExclusions, conditions database.RuleConditions) (*database.Rule, error) {
+					if !reflect.DeepEqual(exclusions.Sources, []string{"staging", "canary"}) || len(exclusions.Names) != 0 {
+						return nil, fmt.Errorf("unexpected exclusions %+v", exclusions)
+					}
+					return &database.Rule{RuleID: "rule-1", ClientID: clientID, ExcludeSources: exclusions.Sources}, nil
+				}
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "exclusions on a non-wildcard field",
+			method:         http.MethodPost,
+			body:           `{"client_id":"client-1","severity":"HIGH","source":"api","name":"timeout","exclude_sources":["staging"]}`,
+			setupMock:      func(m *mockRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "wildcard exclusion",
+			method:         http.MethodPost,
+			body:           `{"client_id":"client-1","severity":"HIGH","source":"api","name":"*","exclude_names":["*"]}`,
+			setupMock:      func(m *mockRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "with conditions",
+			method: http.MethodPost,
+			body:   `{"client_id":"client-1","severity":"HIGH","source":"api","name":"*","conditions":[{"key":"region","op":"==","value":"eu-west-1"},{"key":"env","op":"!=","value":"dev"}]}`,
+			setupMock: func(m *mockRepository) {
+				m.CreateRuleFn = func(ctx context.Context, clientID, severity, source, name, description string, exclusions database.RuleExclusions, conditions database.RuleConditions) (*database.Rule, error) {
+					want := database.RuleConditions{
+						{Key: "region", Op: database.ConditionOpEqual, Value: "eu-west-1"},
+						{Key: "env", Op: database.ConditionOpNotEqual, Value: "dev"},
+					}
+					if !reflect.DeepEqual(conditions, want) {
+						return nil, fmt.Errorf("unexpected conditions %+v", conditions)
+					}
+					return &database.Rule{RuleID: "rule-1", ClientID: clientID, Conditions: conditions}, nil
+				}
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "condition with unknown op",
+			method:         http.MethodPost,
+			body:           `{"client_id":"client-1","severity":"HIGH","source":"api","name":"*","conditions":[{"key":"region","op":"~=","value":"eu"}]}`,
+			setupMock:      func(m *mockRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "condition without key",
+			method:         http.MethodPost,
+			body:           `{"client_id":"client-1","severity":"HIGH","source":"api","name":"*","conditions":[{"key":" ","op":"==","value":"eu"}]}`,
+			setupMock:      func(m *mockRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
 		{
 			name:   "client not found",
 			method: http.MethodPost,
 			body:   `{"client_id":"client-999","severity":"HIGH","source":"source-1","name":"alert-1"}`,
 			setupMock: func(m *mockRepository) {
-				m.CreateRuleFn = func(ctx context.Context, clientID, severity, source, name string) (*database.Rule, error) {
+				m.CreateRuleFn = func(ctx context.Context, clientID, severity, source, name, description string, exclusions database.RuleExclusions, conditions database.RuleConditions) (*database.Rule, error) {
 					return nil, fmt.Errorf("client not found: %s", clientID)
 				}
 			},
@@ -321,7 +538,7 @@ func TestHandlers_GetRule(t *testing.T) {
 func TestHandlers_ListRules(t *testing.T) {
 	t.Run("list all", func(t *testing.T) {
 		mockDB := &mockRepository{}
-		mockDB.ListRulesFn = func(ctx context.Context, clientID *string, limit, offset int) (*database.RuleListResult, error) {
+		mockDB.ListRulesFn = func(ctx context.Context, filter database.RuleFilter, limit, offset int) (*database.RuleListResult, error) {
 			return &database.RuleListResult{Rules: []*database.Rule{{RuleID: "rule-1"}}, Total: 1, Limit: limit, Offset: offset}, nil
 		}
 
@@ -338,11 +555,11 @@ func TestHandlers_ListRules(t *testing.T) {
 
 	t.Run("list by client", func(t *testing.T) {
 		mockDB := &mockRepository{}
-		mockDB.ListRulesFn = func(ctx context.Context, clientID *string, limit, offset int) (*database.RuleListResult, error) {
-			if clientID == nil || *clientID != "client-1" {
+		mockDB.ListRulesFn = func(ctx context.Context, filter database.RuleFilter, limit, offset int) (*database.RuleListResult, error) {
+			if filter.ClientID == nil || *filter.ClientID != "client-1" {
 				t.Error("Expected client_id filter")
 			}
-			return &database.RuleListResult{Rules: []*database.Rule{{RuleID: "rule-1", ClientID: *clientID}}, Total: 1, Limit: limit, Offset: offset}, nil
+			return &database.RuleListResult{Rules: []*database.Rule{{RuleID: "rule-1", ClientID: *filter.ClientID}}, Total: 1, Limit: limit, Offset: offset}, nil
 		}
 
 		h := NewHandlersWithDeps(mockDB, &mockPublisher{}, nil)
@@ -355,6 +572,66 @@ func TestHandlers_ListRules(t *testing.T) {
 			t.Errorf("ListRules() status = %v, want %v", w.Code, http.StatusOK)
 		}
 	})
+
+	t.Run("all filters", func(t *testing.T) {
+		var got database.RuleFilter
+		mockDB := &mockRepository{}
+		mockDB.ListRulesFn = func(ctx context.Context, filter database.RuleFilter, limit, offset int) (*database.RuleListResult, error) {
+			got = filter
+			return &database.RuleListResult{Rules: []*database.Rule{}, Limit: limit, Offset: offset}, nil
+		}
+
+		h := NewHandlersWithDeps(mockDB, &mockPublisher{}, nil)
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/rules?enabled=false&severity=HIGH&source=api&name=disk&updated_since=2026-01-02T03:04:05Z", nil)
+		w := httptest.NewRecorder()
+
+		h.ListRules(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("ListRules() status = %v, want %v", w.Code, http.StatusOK)
+		}
+		if got.Enabled == nil || *got.Enabled {
+			t.Errorf("Enabled filter = %v, want false", got.Enabled)
+		}
+		if got.Severity == nil || *got.Severity != "HIGH" {
+			t.Errorf("Severity filter = %v, want HIGH", got.Severity)
+		}
+		if got.Source == nil || *got.Source != "api" {
+			t.Errorf("Source filter = %v, want api", got.Source)
+		}
+		if got.NameContains == nil || *got.NameContains != "disk" {
+			t.Errorf("NameContains filter = %v, want disk", got.NameContains)
+		}
+		want := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+		if got.UpdatedSince == nil || !got.UpdatedSince.Equal(want) {
+			t.Errorf("UpdatedSince filter = %v, want %v", got.UpdatedSince, want)
+		}
+		if got.ClientID != nil {
+			t.Errorf("ClientID filter = %v, want nil", *got.ClientID)
+		}
+	})
+
+	invalid := []struct {
+		name  string
+		query string
+	}{
+		{"invalid enabled", "enabled=maybe"},
+		{"invalid severity", "severity=URGENT"},
+		{"invalid updated_since", "updated_since=yesterday"},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandlersWithDeps(&mockRepository{}, &mockPublisher{}, nil)
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/rules?"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			h.ListRules(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("ListRules() status = %v, want %v", w.Code, http.StatusBadRequest)
+			}
+		})
+	}
 }
 
 // TestHandlers_UpdateRule tests the UpdateRule handler.
@@ -373,7 +650,7 @@ func TestHandlers_UpdateRule(t *testing.T) {
 			query:  "?rule_id=rule-1",
 			body:   `{"severity":"CRITICAL","source":"source-2","name":"alert-2","version":1}`,
 			setupMock: func(m *mockRepository) {
-				m.UpdateRuleFn = func(ctx context.Context, ruleID string, severity, source, name string, expectedVersion int) (*database.Rule, error) {
+				m.UpdateRuleFn = func(ctx context.Context, ruleID string, severity, source, name string, description *string, exclusions *database.RuleExclusions, conditions *database.RuleConditions, expectedVersion int) (*database.Rule, error) {
 					return &database.Rule{RuleID: ruleID, Severity: severity, Source: source, Name: name, Version: 2, UpdatedAt: time.Now()}, nil
 				}
 			},
@@ -395,13 +672,74 @@ func TestHandlers_UpdateRule(t *testing.T) {
 			setupMock:      func(m *mockRepository) {},
 			expectedStatus: http.StatusBadRequest,
 		},
+		{
+			name:   "omitted exclusions are kept",
+			method: http.MethodPut,
+			query:  "?rule_id=rule-1",
+			body:   `{"severity":"CRITICAL","source":"*","name":"alert-2","version":1}`,
+			setupMock: func(m *mockRepository) {
+				m.UpdateRuleFn = func(ctx context.Context, ruleID string, severity, source, name string, description *string, exclusions *database.RuleExclusions, conditions *database.RuleConditions, expectedVersion int) (*database.Rule, error) {
+					if exclusions != nil {
+						return nil, fmt.Errorf("unexpected exclusions %+v", exclusions)
+					}
+					return &database.Rule{RuleID: ruleID, Version: 2}, nil
+				}
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "exclusions are replaced",
+			method: http.MethodPut,
+			query:  "?rule_id=rule-1",
+			body:   `{"severity":"CRITICAL","source":"*","name":"alert-2","exclude_sources":["staging"],"version":1}`,
+			setupMock: func(m *mockRepository) {
+				m.UpdateRuleFn = func(ctx context.Context, ruleID string, severity, source, name string, description *string, exclusions *database.RuleExclusions, conditions *database.RuleConditions, expectedVersion int) (*database.Rule, error) {
+					if exclusions == nil || !reflect.DeepEqual(exclusions.Sources, []string{"staging"}) || len(exclusions.Names) != 0 {
+						return nil, fmt.Errorf("unexpected exclusions %+v", exclusions)
+					}
+					return &database.Rule{RuleID: ruleID, Version: 2}, nil
+				}
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "exclusions on a non-wildcard field",
+			method:         http.MethodPut,
+			query:          "?rule_id=rule-1",
+			body:           `{"severity":"CRITICAL","source":"*","name":"alert-2","exclude_names":["other"],"version":1}`,
+			setupMock:      func(m *mockRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "empty conditions are removed",
+			method: http.MethodPut,
+			query:  "?rule_id=rule-1",
+			body:   `{"severity":"CRITICAL","source":"api","name":"alert-2","conditions":[],"version":1}`,
+			setupMock: func(m *mockRepository) {
+				m.UpdateRuleFn = func(ctx context.Context, ruleID string, severity, source, name string, description *string, exclusions *database.RuleExclusions, conditions *database.RuleConditions, expectedVersion int) (*database.Rule, error) {
+					if conditions == nil || len(*conditions) != 0 {
+						return nil, fmt.Errorf("unexpected conditions %+v", conditions)
+					}
+					return &database.Rule{RuleID: ruleID, Version: 2}, nil
+				}
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "too many conditions",
+			method:         http.MethodPut,
+			query:          "?rule_id=rule-1",
+			body:           `{"severity":"CRITICAL","source":"api","name":"alert-2","conditions":[` + strings.Repeat(`{"key":"k","op":"==","value":"v"},`, maxRuleConditions) + `{"key":"k","op":"==","value":"v"}],"version":1}`,
+			setupMock:      func(m *mockRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
 		{
 			name:   "version mismatch",
 			method: http.MethodPut,
 			query:  "?rule_id=rule-1",
 			body:   `{"severity":"CRITICAL","source":"source-2","name":"alert-2","version":1}`,
 			setupMock: func(m *mockRepository) {
-				m.UpdateRuleFn = func(ctx context.Context, ruleID string, severity, source, name string, expectedVersion int) (*database.Rule, error) {
+				m.UpdateRuleFn = func(ctx context.Context, ruleID string, severity, source, name string, description *string, exclusions *database.RuleExclusions, conditions *database.RuleConditions, expectedVersion int) (*database.Rule, error) {
 					return nil, fmt.Errorf("rule version mismatch: expected version %d", expectedVersion)
 				}
 			},
@@ -484,7 +822,7 @@ func TestHandlers_CreateEndpoint(t *testing.T) {
 			method: http.MethodPost,
 			body:   `{"rule_id":"rule-1","type":"email","value":"test@example.com"}`,
 			setupMock: func(m *mockRepository) {
-				m.CreateEndpointFn = func(ctx context.Context, ruleID, endpointType, value string) (*database.Endpoint, error) {
+				m.CreateEndpointFn = func(ctx context.Context, ruleID, endpointType, value string, metadata database.EndpointMetadata, templateID string) (*database.Endpoint, error) {
 					return &database.Endpoint{EndpointID: "endpoint-1", RuleID: ruleID, Type: endpointType, Value: value, Enabled: true}, nil
 				}
 			},
@@ -502,7 +840,7 @@ func TestHandlers_CreateEndpoint(t *testing.T) {
 			method: http.MethodPost,
 			body:   `{"rule_id":"rule-999","type":"email","value":"test@example.com"}`,
 			setupMock: func(m *mockRepository) {
-				m.CreateEndpointFn = func(ctx context.Context, ruleID, endpointType, value string) (*database.Endpoint, error) {
+				m.CreateEndpointFn = func(ctx context.Context, ruleID, endpointType, value string, metadata database.EndpointMetadata, templateID string) (*database.Endpoint, error) {
 					return nil, fmt.Errorf("rule not found: %s", ruleID)
 				}
 			},
@@ -572,7 +910,7 @@ func TestHandlers_ListEndpoints(t *testing.T) {
 func TestHandlers_UpdateEndpoint(t *testing.T) {
 	t.Run("successful update", func(t *testing.T) {
 		mockDB := &mockRepository{}
-		mockDB.UpdateEndpointFn = func(ctx context.Context, endpointID, endpointType, value string) (*database.Endpoint, error) {
+		mockDB.UpdateEndpointFn = func(ctx context.Context, endpointID, endpointType, value string, metadata *database.EndpointMetadata, templateID *string) (*database.Endpoint, error) {
 			return &database.Endpoint{EndpointID: endpointID, Type: endpointType, Value: value}, nil
 		}
 
@@ -648,11 +986,122 @@ func TestHandlers_GetNotification(t *testing.T) {
 	})
 }
 
+// TestHandlers_ListNotificationEvents tests the ListNotificationEvents handler.
+func TestHandlers_ListNotificationEvents(t *testing.T) {
+	t.Run("successful list", func(t *testing.T) {
+		mockDB := &mockRepository{}
+		mockDB.ListNotificationEventsFn = func(ctx context.Context, notificationID string) ([]*database.NotificationEvent, error) {
+			return []*database.NotificationEvent{
+				{EventID: 1, NotificationID: notificationID, Service: "aggregator", EventType: "created"},
+				{EventID: 2, NotificationID: notificationID, Service: "sender", EventType: "send_attempt", EndpointType: "webhook", Error: "timeout"},
+			}, nil
+		}
+
+		h := NewHandlersWithDeps(mockDB, &mockPublisher{}, nil)
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/notifications/events?notification_id=notif-1", nil)
+		w := httptest.NewRecorder()
+
+		h.ListNotificationEvents(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("ListNotificationEvents() status = %v, want %v", w.Code, http.StatusOK)
+		}
+		var events []database.NotificationEvent
+		if err := json.NewDecoder(w.Body).Decode(&events); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if len(events) != 2 || events[1].EndpointType != "webhook" || events[1].Error != "timeout" {
+			t.Errorf("ListNotificationEvents() = %+v", events)
+		}
+	})
+
+	t.Run("missing notification_id", func(t *testing.T) {
+		h := NewHandlersWithDeps(&mockRepository{}, &mockPublisher{}, nil)
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/notifications/events", nil)
+		w := httptest.NewRecorder()
+
+		h.ListNotificationEvents(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("ListNotificationEvents() status = %v, want %v", w.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		mockDB := &mockRepository{}
+		mockDB.ListNotificationEventsFn = func(ctx context.Context, notificationID string) ([]*database.NotificationEvent, error) {
+			return nil, fmt.Errorf("connection refused")
+		}
+
+		h := NewHandlersWithDeps(mockDB, &mockPublisher{}, nil)
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/notifications/events?notification_id=notif-1", nil)
+		w := httptest.NewRecorder()
+
+		h.ListNotificationEvents(w, req)
+
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("ListNotificationEvents() status = %v, want %v", w.Code, http.StatusInternalServerError)
+		}
+	})
+}
+
+// TestHandlers_ListNotificationDeliveries tests the ListNotificationDeliveries handler.
+func TestHandlers_ListNotificationDeliveries(t *testing.T) {
+	t.Run("successful list", func(t *testing.T) {
+		mockDB := &mockRepository{}
+		mockDB.ListNotificationDeliveriesFn = func(ctx context.Context, notificationID string) ([]*database.NotificationDelivery, error) {
+			if notificationID != "notif-1" {
+				t.Errorf("notificationID = %q, want notif-1", notificationID)
+			}
+			return []*database.NotificationDelivery{
+				{DeliveryID: 1, NotificationID: notificationID, EndpointID: "ep-1", Channel: "email", Status: "SENT", Attempts: 1},
+				{DeliveryID: 2, NotificationID: notificationID, EndpointID: "ep-2", Channel: "webhook", Status: "FAILED", Error: "status 503", Attempts: 3},
+			}, nil
+		}
+
+		h := NewHandlersWithDeps(mockDB, &mockPublisher{}, nil)
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/notifications/notif-1/deliveries", nil)
+		req.SetPathValue("id", "notif-1")
+		w := httptest.NewRecorder()
+
+		h.ListNotificationDeliveries(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("ListNotificationDeliveries() status = %v, want %v", w.Code, http.StatusOK)
+		}
+		var deliveries []database.NotificationDelivery
+		if err := json.NewDecoder(w.Body).Decode(&deliveries); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if len(deliveries) != 2 || deliveries[1].Status != "FAILED" || deliveries[1].Error != "status 503" {
+			t.Errorf("ListNotificationDeliveries() = %+v", deliveries)
+		}
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		mockDB := &mockRepository{}
+		mockDB.ListNotificationDeliveriesFn = func(ctx context.Context, notificationID string) ([]*database.NotificationDelivery, error) {
+			return nil, fmt.Errorf("connection refused")
+		}
+
+		h := NewHandlersWithDeps(mockDB, &mockPublisher{}, nil)
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/notifications/notif-1/deliveries", nil)
+		req.SetPathValue("id", "notif-1")
+		w := httptest.NewRecorder()
+
+		h.ListNotificationDeliveries(w, req)
+
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("ListNotificationDeliveries() status = %v, want %v", w.Code, http.StatusInternalServerError)
+		}
+	})
+}
+
 // TestHandlers_ListNotifications tests the ListNotifications handler.
 func TestHandlers_ListNotifications(t *testing.T) {
 	t.Run("list all with pagination", func(t *testing.T) {
 		mockDB := &mockRepository{}
-		mockDB.ListNotificationsFn = func(ctx context.Context, clientID *string, status *string, limit, offset int) (*database.NotificationListResult, error) {
+		mockDB.ListNotificationsFn = func(ctx context.Context, clientID *string, statuses []string, limit, offset int) (*database.NotificationListResult, error) {
 			return &database.NotificationListResult{
 				Notifications: []*database.Notification{{NotificationID: "notif-1", Status: "RECEIVED"}},
 				Total:         1,
@@ -671,14 +1120,196 @@ func TestHandlers_ListNotifications(t *testing.T) {
 			t.Errorf("ListNotifications() status = %v, want %v", w.Code, http.StatusOK)
 		}
 	})
+
+	t.Run("filter by statuses", func(t *testing.T) {
+		var got []string
+		mockDB := &mockRepository{}
+		mockDB.ListNotificationsFn = func(ctx context.Context, clientID *string, statuses []string, limit, offset int) (*database.NotificationListResult, error) {
+			got = statuses
+			return &database.NotificationListResult{Limit: limit, Offset: offset}, nil
+		}
+
+		h := NewHandlersWithDeps(mockDB, &mockPublisher{}, nil)
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/notifications?status=partially_sent,FAILED&status=acknowledged,RESOLVED", nil)
+		w := httptest.NewRecorder()
+
+		h.ListNotifications(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("ListNotifications() status = %v, want %v", w.Code, http.StatusOK)
+		}
+		if strings.Join(got, ",") != "PARTIALLY_SENT,FAILED,ACKNOWLEDGED,RESOLVED" {
+			t.Errorf("statuses = %v, want [PARTIALLY_SENT FAILED ACKNOWLEDGED RESOLVED]", got)
+		}
+	})
+
+	t.Run("unknown status", func(t *testing.T) {
+		h := NewHandlersWithDeps(&mockRepository{}, &mockPublisher{}, nil)
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/notifications?status=DELIVERED", nil)
+		w := httptest.NewRecorder()
+
+		h.ListNotifications(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("ListNotifications() status = %v, want %v", w.Code, http.StatusBadRequest)
+		}
+	})
+}
+
+// TestHandlers_GetNotificationSummary tests the GetNotificationSummary handler.
+func TestHandlers_GetNotificationSummary(t *testing.T) {
+	t.Run("client window", func(t *testing.T) {
+		var gotClientID *string
+		var gotSince time.Time
+		mockDB := &mockRepository{}
+		mockDB.GetNotificationSummaryFn = func(ctx context.Context, clientID *string, since time.Time) (*database.NotificationSummary, error) {
+			gotClientID, gotSince = clientID, since
+			return &database.NotificationSummary{Total: 3, ByStatus: map[string]int64{"SENT": 3}, BySeverity: map[string]int64{"HIGH": 3}}, nil
+		}
+
+		h := NewHandlersWithDeps(mockDB, &mockPublisher{}, nil)
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/notifications/summary?client_id=client-1&window=2h", nil)
+		w := httptest.NewRecorder()
+
+		h.GetNotificationSummary(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("GetNotificationSummary() status = %v, want %v", w.Code, http.StatusOK)
+		}
+		if gotClientID == nil || *gotClientID != "client-1" {
+			t.Errorf("client_id = %v, want client-1", gotClientID)
+		}
+		if ago := time.Since(gotSince); ago < 2*time.Hour || ago > 2*time.Hour+time.Minute {
+			t.Errorf("since = %v ago, want 2h", ago)
+		}
+		if !strings.Contains(w.Body.String(), `"by_status":{"SENT":3}`) {
+			t.Errorf("GetNotificationSummary() body = %s, want by_status counts", w.Body.String())
+		}
+	})
+
+	t.Run("default window for all clients", func(t *testing.T) {
+		var gotClientID *string
+		var gotSince time.Time
+		mockDB := &mockRepository{}
+		mockDB.GetNotificationSummaryFn = func(ctx context.Context, clientID *string, since time.Time) (*database.NotificationSummary, error) {
+			gotClientID, gotSince = clientID, since
+			return &database.NotificationSummary{}, nil
+		}
+
+		h := NewHandlersWithDeps(mockDB, &mockPublisher{}, nil)
+		w := httptest.NewRecorder()
+		h.GetNotificationSummary(w, httptest.NewRequest(http.MethodGet, "/api/v1/notifications/summary", nil))
+
+		if w.Code != http.StatusOK || gotClientID != nil {
+			t.Fatalf("GetNotificationSummary() status = %v client_id = %v, want 200 for all clients", w.Code, gotClientID)
+		}
+		if ago := time.Since(gotSince); ago < 24*time.Hour || ago > 24*time.Hour+time.Minute {
+			t.Errorf("since = %v ago, want 24h", ago)
+		}
+	})
+
+	t.Run("client key is scoped to its client", func(t *testing.T) {
+		var gotClientID string
+		mockDB := &mockRepository{}
+		mockDB.GetNotificationSummaryFn = func(ctx context.Context, clientID *string, since time.Time) (*database.NotificationSummary, error) {
+			gotClientID = *clientID
+			return &database.NotificationSummary{}, nil
+		}
+
+		h := NewHandlersWithDeps(mockDB, &mockPublisher{}, nil)
+		w := httptest.NewRecorder()
+		h.GetNotificationSummary(w, asClient(httptest.NewRequest(http.MethodGet, "/api/v1/notifications/summary", nil), "client-1"))
+
+		if w.Code != http.StatusOK || gotClientID != "client-1" {
+			t.Errorf("GetNotificationSummary() status = %v client_id = %q, want 200 and client-1", w.Code, gotClientID)
+		}
+	})
+
+	for _, window := range []string{"soon", "30m", "721h"} {
+		t.Run("invalid window "+window, func(t *testing.T) {
+			h := NewHandlersWithDeps(&mockRepository{}, &mockPublisher{}, nil)
+			w := httptest.NewRecorder()
+			h.GetNotificationSummary(w, httptest.NewRequest(http.MethodGet, "/api/v1/notifications/summary?window="+window, nil))
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("GetNotificationSummary() status = %v, want %v", w.Code, http.StatusBadRequest)
+			}
+		})
+	}
+}
+
+// TestHandlers_NotificationTransitions tests the AcknowledgeNotification and ResolveNotification handlers.
+func TestHandlers_NotificationTransitions(t *testing.T) {
+	t.Run("acknowledge with actor and note", func(t *testing.T) {
+		var gotID, gotActor, gotNote string
+		mockDB := &mockRepository{
+			AcknowledgeNotificationFn: func(ctx context.Context, notificationID, actor, note string) (*database.Notification, error) {
+				gotID, gotActor, gotNote = notificationID, actor, note
+				return &database.Notification{NotificationID: notificationID, Status: "ACKNOWLEDGED"}, nil
+			},
+		}
+
+		h := NewHandlersWithDeps(mockDB, &mockPublisher{}, nil)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/notifications/ack", bytes.NewBufferString(`{"notification_id":"notif-1","actor":" alice ","note":"looking into it"}`))
+		w := httptest.NewRecorder()
+
+		h.AcknowledgeNotification(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("AcknowledgeNotification() status = %v, want %v, body = %s", w.Code, http.StatusOK, w.Body.String())
+		}
+		if gotID != "notif-1" || gotActor != "alice" || gotNote != "looking into it" {
+			t.Errorf("AcknowledgeNotification(%q, %q, %q), want notif-1 by alice with the note", gotID, gotActor, gotNote)
+		}
+		if !strings.Contains(w.Body.String(), `"status":"ACKNOWLEDGED"`) {
+			t.Errorf("AcknowledgeNotification() body = %s, want the acknowledged notification", w.Body.String())
+		}
+	})
+
+	tests := []struct {
+		name           string
+		body           string
+		resolveErr     error
+		expectedStatus int
+	}{
+		{"resolved", `{"notification_id":"notif-1","actor":"alice"}`, nil, http.StatusOK},
+		{"missing notification_id", `{"actor":"alice"}`, nil, http.StatusBadRequest},
+		{"missing actor", `{"notification_id":"notif-1","actor":"  "}`, nil, http.StatusBadRequest},
+		{"note too long", `{"notification_id":"notif-1","actor":"alice","note":"` + strings.Repeat("x", maxNotificationNoteLength+1) + `"}`, nil, http.StatusBadRequest},
+		{"already resolved", `{"notification_id":"notif-1","actor":"alice"}`, fmt.Errorf("%w: RESOLVED to RESOLVED", database.ErrInvalidTransition), http.StatusConflict},
+		{"not found", `{"notification_id":"missing","actor":"alice"}`, fmt.Errorf("notification not found: missing"), http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := &mockRepository{
+				ResolveNotificationFn: func(ctx context.Context, notificationID, actor, note string) (*database.Notification, error) {
+					if tt.resolveErr != nil {
+						return nil, tt.resolveErr
+					}
+					return &database.Notification{NotificationID: notificationID, Status: "RESOLVED"}, nil
+				},
+			}
+
+			h := NewHandlersWithDeps(mockDB, &mockPublisher{}, nil)
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/notifications/resolve", bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+
+			h.ResolveNotification(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("ResolveNotification() status = %v, want %v, body = %s", w.Code, tt.expectedStatus, w.Body.String())
+			}
+		})
+	}
 }
 
 // TestRuleEventPublishing verifies that rule CRUD operations publish events correctly.
 func TestRuleEventPublishing(t *testing.T) {
 	t.Run("create publishes CREATED event", func(t *testing.T) {
 		mockDB := &mockRepository{}
-		mockDB.CreateRuleFn = func(ctx context.Context, clientID, severity, source, name string) (*database.Rule, error) {
-			return &database.Rule{RuleID: "rule-1", ClientID: clientID, Severity: severity, Version: 1, UpdatedAt: time.Now()}, nil
+		mockDB.CreateRuleFn = func(ctx context.Context, clientID, severity, source, name, description string, exclusions database.RuleExclusions, conditions database.RuleConditions) (*database.Rule, error) {
+			return &database.Rule{RuleID: "rule-1", ClientID: clientID, Severity: severity, Source: source, Name: name, Enabled: true, Version: 1, UpdatedAt: time.Now()}, nil
 		}
 		mockPub := &mockPublisher{}
 		mockMetrics := &mockMetrics{}
@@ -695,6 +1326,10 @@ func TestRuleEventPublishing(t *testing.T) {
 		if mockPub.Published[0].Action != "CREATED" {
 			t.Errorf("Expected CREATED action, got %s", mockPub.Published[0].Action)
 		}
+		wantRule := events.RulePayload{Severity: "HIGH", Source: "src", Name: "alert", Enabled: true}
+		if got := mockPub.Published[0].Rule; got == nil || !reflect.DeepEqual(*got, wantRule) {
+			t.Errorf("Expected rule payload %+v, got %+v", wantRule, got)
+		}
 		if mockMetrics.PublishedCount != 1 {
 			t.Errorf("Expected 1 published metric, got %d", mockMetrics.PublishedCount)
 		}
@@ -702,7 +1337,7 @@ func TestRuleEventPublishing(t *testing.T) {
 
 	t.Run("update publishes UPDATED event", func(t *testing.T) {
 		mockDB := &mockRepository{}
-		mockDB.UpdateRuleFn = func(ctx context.Context, ruleID string, severity, source, name string, expectedVersion int) (*database.Rule, error) {
+		mockDB.UpdateRuleFn = func(ctx context.Context, ruleID string, severity, source, name string, description *string, exclusions *database.RuleExclusions, conditions *database.RuleConditions, expectedVersion int) (*database.Rule, error) {
 			return &database.Rule{RuleID: ruleID, Severity: severity, Version: 2, UpdatedAt: time.Now()}, nil
 		}
 		mockPub := &mockPublisher{}
@@ -743,6 +1378,9 @@ func TestRuleEventPublishing(t *testing.T) {
 		if mockPub.Published[0].Action != "DELETED" {
 			t.Errorf("Expected DELETED action, got %s", mockPub.Published[0].Action)
 		}
+		if mockPub.Published[0].Rule != nil {
+			t.Errorf("Expected no rule payload on DELETED, got %+v", mockPub.Published[0].Rule)
+		}
 	})
 
 	t.Run("toggle disabled publishes DISABLED event", func(t *testing.T) {
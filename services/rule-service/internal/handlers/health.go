@@ -0,0 +1,39 @@
+// Package handlers provides HTTP handlers for the rule-service API.
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// WithHealthChecker makes /healthz and /readyz check the service's dependencies with checker.
+func WithHealthChecker(checker *shared.HealthChecker) Option {
+	return func(h *Handlers) {
+		h.health = checker
+	}
+}
+
+// checkHealth returns the dependencies' health, or an empty healthy report if no checker is set.
+func (h *Handlers) checkHealth(r *http.Request) shared.HealthReport {
+	if h.health == nil {
+		return shared.HealthReport{Status: shared.HealthStatusUp}
+	}
+	return h.health.Check(r.Context())
+}
+
+// Healthz reports whether Postgres, Redis and the Kafka producer are reachable, with the
+// status and latency of each. It returns 503 if any of them is down. Reports are cached for
+// a few seconds, so frequent probes do not load the dependencies.
+// GET /healthz
+func (h *Handlers) Healthz(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet) {
+		return
+	}
+	report := h.checkHealth(r)
+	status := http.StatusOK
+	if !report.Healthy() {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, report)
+}
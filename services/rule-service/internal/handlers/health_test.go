@@ -0,0 +1,89 @@
+// Package handlers provides tests for the health and readiness endpoints.
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// newHealthChecker returns a checker of postgres, redis and the Kafka producer, with the
+// producer failing with kafkaErr.
+func newHealthChecker(kafkaErr error) *shared.HealthChecker {
+	return shared.NewHealthChecker(
+		shared.DependencyCheck{Name: "postgres", Check: func(ctx context.Context) error { return nil }},
+		shared.DependencyCheck{Name: "redis", Check: func(ctx context.Context) error { return nil }},
+		shared.DependencyCheck{Name: "kafka_producer", Check: func(ctx context.Context) error { return kafkaErr }},
+	)
+}
+
+// TestHandlers_Healthz tests that /healthz reports each dependency.
+func TestHandlers_Healthz(t *testing.T) {
+	tests := []struct {
+		name       string
+		checker    *shared.HealthChecker
+		wantStatus int
+		want       string
+		wantDeps   int
+	}{
+		{"not configured", nil, http.StatusOK, shared.HealthStatusUp, 0},
+		{"all up", newHealthChecker(nil), http.StatusOK, shared.HealthStatusUp, 3},
+		{"kafka down", newHealthChecker(errors.New("no brokers")), http.StatusServiceUnavailable, shared.HealthStatusDown, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandlersWithDeps(&mockRepository{}, &mockPublisher{}, nil)
+			if tt.checker != nil {
+				WithHealthChecker(tt.checker)(h)
+			}
+			w := httptest.NewRecorder()
+			h.Healthz(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+			var report shared.HealthReport
+			if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if w.Code != tt.wantStatus || report.Status != tt.want || len(report.Dependencies) != tt.wantDeps {
+				t.Errorf("Healthz() = %v %+v, want %v %s with %d dependencies", w.Code, report, tt.wantStatus, tt.want, tt.wantDeps)
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	NewHandlersWithDeps(&mockRepository{}, &mockPublisher{}, nil).Healthz(w, httptest.NewRequest(http.MethodPost, "/healthz", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Healthz() POST status = %v, want %v", w.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+// TestHandlers_Readiness_Dependencies tests that /readyz fails while a dependency is down.
+func TestHandlers_Readiness_Dependencies(t *testing.T) {
+	h := newEmergencyStopHandlers(&fakeEmergencyStopStore{}, "")
+	WithHealthChecker(newHealthChecker(errors.New("no brokers")))(h)
+	w := httptest.NewRecorder()
+	h.Readiness(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	var ready ReadinessResponse
+	json.NewDecoder(w.Body).Decode(&ready)
+	if w.Code != http.StatusServiceUnavailable || ready.Status != "unavailable" {
+		t.Errorf("Readiness() = %v %+v, want 503 unavailable", w.Code, ready)
+	}
+	if s := ready.Dependencies["kafka_producer"]; s.Status != shared.HealthStatusDown || s.Error != "no brokers" {
+		t.Errorf("kafka_producer = %+v, want down with its error", s)
+	}
+
+	WithHealthChecker(newHealthChecker(nil))(h)
+	w = httptest.NewRecorder()
+	h.Readiness(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	ready = ReadinessResponse{}
+	json.NewDecoder(w.Body).Decode(&ready)
+	if w.Code != http.StatusOK || ready.Status != "ready" || len(ready.Dependencies) != 3 {
+		t.Errorf("Readiness() = %v %+v, want 200 ready with the dependencies", w.Code, ready)
+	}
+}
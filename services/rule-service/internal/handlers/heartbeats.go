@@ -0,0 +1,147 @@
+// Package handlers provides HTTP handlers for the rule-service API.
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// Heartbeat defaults and bounds.
+const (
+	defaultHeartbeatSeverity = "HIGH"
+	defaultHeartbeatSource   = "heartbeat"
+	maxHeartbeatInterval     = 7 * 24 * 60 * 60 // one week, in seconds
+)
+
+// PingHeartbeatRequest represents a heartbeat ping.
+// The first ping registers the heartbeat; later pings may change its settings.
+type PingHeartbeatRequest struct {
+	ClientID        string `json:"client_id"`
+	IntervalSeconds int    `json:"interval_seconds"`
+	Severity        string `json:"severity,omitempty"` // default HIGH
+	Source          string `json:"source,omitempty"`   // default "heartbeat"
+	Name            string `json:"name,omitempty"`     // default heartbeat ID
+}
+
+func (req *PingHeartbeatRequest) validate(v *shared.Validator) {
+	v.Required("client_id", req.ClientID)
+	v.Between("interval_seconds", req.IntervalSeconds, 1, maxHeartbeatInterval)
+	if req.Severity != "" {
+		v.OneOf("severity", req.Severity, alertSeverities...)
+	}
+}
+
+// PingHeartbeat records a heartbeat ping.
+// POST /api/v1/heartbeats/{id}/ping
+func (h *Handlers) PingHeartbeat(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	heartbeatID := r.PathValue("id")
+	if heartbeatID == "" {
+		http.Error(w, "heartbeat id is required", http.StatusBadRequest)
+		return
+	}
+
+	var req PingHeartbeatRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if !validateRequest(w, &req) {
+		return
+	}
+	if !authorizeClient(w, r, req.ClientID) {
+		return
+	}
+	if req.Severity == "" {
+		req.Severity = defaultHeartbeatSeverity
+	}
+	if req.Source == "" {
+		req.Source = defaultHeartbeatSource
+	}
+	if req.Name == "" {
+		req.Name = heartbeatID
+	}
+
+	hb, err := h.db.PingHeartbeat(r.Context(), heartbeatID, req.ClientID, req.IntervalSeconds, req.Severity, req.Source, req.Name)
+	if err != nil {
+		if handleDBError(w, err, "heartbeat", heartbeatID) {
+			return
+		}
+		http.Error(w, "Failed to ping heartbeat: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.metrics.IncrementCustom("heartbeat_pings")
+	writeJSON(w, http.StatusOK, hb)
+}
+
+// GetHeartbeat retrieves a heartbeat by ID.
+func (h *Handlers) GetHeartbeat(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	heartbeatID, ok := requireQueryParam(w, r, "heartbeat_id")
+	if !ok {
+		return
+	}
+
+	hb, err := h.db.GetHeartbeat(r.Context(), heartbeatID)
+	if err != nil {
+		if handleDBError(w, err, "heartbeat", heartbeatID) {
+			return
+		}
+		http.Error(w, "Failed to get heartbeat: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, hb)
+}
+
+// ListHeartbeats lists heartbeats, optionally filtered by client_id.
+func (h *Handlers) ListHeartbeats(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	var clientID *string
+	if c := r.URL.Query().Get("client_id"); c != "" {
+		clientID = &c
+	}
+
+	heartbeats, err := h.db.ListHeartbeats(r.Context(), clientID)
+	if err != nil {
+		slog.Error("Failed to list heartbeats", "error", err)
+		http.Error(w, "Failed to list heartbeats", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, heartbeats)
+}
+
+// DeleteHeartbeat deletes a heartbeat.
+func (h *Handlers) DeleteHeartbeat(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodDelete) {
+		return
+	}
+
+	heartbeatID, ok := requireQueryParam(w, r, "heartbeat_id")
+	if !ok {
+		return
+	}
+
+	if err := h.db.DeleteHeartbeat(r.Context(), heartbeatID); err != nil {
+		if handleDBError(w, err, "heartbeat", heartbeatID) {
+			return
+		}
+		http.Error(w, "Failed to delete heartbeat: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
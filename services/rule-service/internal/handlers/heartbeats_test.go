@@ -0,0 +1,186 @@
+// Package handlers provides tests for heartbeat HTTP handlers.
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"rule-service/internal/database"
+)
+
+// TestHandlers_PingHeartbeat tests the PingHeartbeat handler.
+func TestHandlers_PingHeartbeat(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		body           string
+		setupMock      func(*mockRepository)
+		expectedStatus int
+	}{
+		{
+			name:           "successful ping",
+			method:         http.MethodPost,
+			body:           `{"client_id":"client-1","interval_seconds":60}`,
+			setupMock:      func(m *mockRepository) {},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "wrong method",
+			method:         http.MethodGet,
+			body:           `{"client_id":"client-1","interval_seconds":60}`,
+			setupMock:      func(m *mockRepository) {},
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "invalid JSON",
+			method:         http.MethodPost,
+			body:           `invalid json`,
+			setupMock:      func(m *mockRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "missing client_id",
+			method:         http.MethodPost,
+			body:           `{"interval_seconds":60}`,
+			setupMock:      func(m *mockRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "missing interval",
+			method:         http.MethodPost,
+			body:           `{"client_id":"client-1"}`,
+			setupMock:      func(m *mockRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "interval too long",
+			method:         http.MethodPost,
+			body:           `{"client_id":"client-1","interval_seconds":700000}`,
+			setupMock:      func(m *mockRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "wildcard severity rejected",
+			method:         http.MethodPost,
+			body:           `{"client_id":"client-1","interval_seconds":60,"severity":"*"}`,
+			setupMock:      func(m *mockRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "owned by another client",
+			method: http.MethodPost,
+			body:   `{"client_id":"client-2","interval_seconds":60}`,
+			setupMock: func(m *mockRepository) {
+				m.PingHeartbeatFn = func(ctx context.Context, heartbeatID, clientID string, intervalSeconds int, severity, source, name string) (*database.Heartbeat, error) {
+					return nil, fmt.Errorf("heartbeat already exists for another client: %s", heartbeatID)
+				}
+			},
+			expectedStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := &mockRepository{}
+			tt.setupMock(mockDB)
+
+			h := NewHandlersWithDeps(mockDB, &mockPublisher{}, nil)
+			req := httptest.NewRequest(tt.method, "/api/v1/heartbeats/nightly-backup/ping", bytes.NewBufferString(tt.body))
+			req.SetPathValue("id", "nightly-backup")
+			w := httptest.NewRecorder()
+
+			h.PingHeartbeat(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("PingHeartbeat() status = %v, want %v, body = %s", w.Code, tt.expectedStatus, w.Body.String())
+			}
+		})
+	}
+}
+
+// TestHandlers_PingHeartbeat_Defaults tests that omitted fields get defaults.
+func TestHandlers_PingHeartbeat_Defaults(t *testing.T) {
+	var gotSeverity, gotSource, gotName string
+	mockDB := &mockRepository{
+		PingHeartbeatFn: func(ctx context.Context, heartbeatID, clientID string, intervalSeconds int, severity, source, name string) (*database.Heartbeat, error) {
+			gotSeverity, gotSource, gotName = severity, source, name
+			return &database.Heartbeat{HeartbeatID: heartbeatID}, nil
+		},
+	}
+
+	h := NewHandlersWithDeps(mockDB, &mockPublisher{}, nil)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/heartbeats/nightly-backup/ping", bytes.NewBufferString(`{"client_id":"client-1","interval_seconds":3600}`))
+	req.SetPathValue("id", "nightly-backup")
+	w := httptest.NewRecorder()
+
+	h.PingHeartbeat(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("PingHeartbeat() status = %v, want %v", w.Code, http.StatusOK)
+	}
+	if gotSeverity != "HIGH" || gotSource != "heartbeat" || gotName != "nightly-backup" {
+		t.Errorf("PingHeartbeat() defaults = (%s, %s, %s), want (HIGH, heartbeat, nightly-backup)", gotSeverity, gotSource, gotName)
+	}
+}
+
+// TestHandlers_GetHeartbeat tests the GetHeartbeat handler.
+func TestHandlers_GetHeartbeat(t *testing.T) {
+	t.Run("not found", func(t *testing.T) {
+		mockDB := &mockRepository{
+			GetHeartbeatFn: func(ctx context.Context, heartbeatID string) (*database.Heartbeat, error) {
+				return nil, fmt.Errorf("heartbeat not found: %s", heartbeatID)
+			},
+		}
+
+		h := NewHandlersWithDeps(mockDB, &mockPublisher{}, nil)
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/heartbeats?heartbeat_id=missing", nil)
+		w := httptest.NewRecorder()
+
+		h.GetHeartbeat(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("GetHeartbeat() status = %v, want %v", w.Code, http.StatusNotFound)
+		}
+	})
+}
+
+// TestHandlers_ListHeartbeats tests the ListHeartbeats handler.
+func TestHandlers_ListHeartbeats(t *testing.T) {
+	var gotClientID *string
+	mockDB := &mockRepository{
+		ListHeartbeatsFn: func(ctx context.Context, clientID *string) ([]*database.Heartbeat, error) {
+			gotClientID = clientID
+			return []*database.Heartbeat{}, nil
+		},
+	}
+
+	h := NewHandlersWithDeps(mockDB, &mockPublisher{}, nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/heartbeats?client_id=client-1", nil)
+	w := httptest.NewRecorder()
+
+	h.ListHeartbeats(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("ListHeartbeats() status = %v, want %v", w.Code, http.StatusOK)
+	}
+	if gotClientID == nil || *gotClientID != "client-1" {
+		t.Errorf("ListHeartbeats() client filter = %v, want client-1", gotClientID)
+	}
+}
+
+// TestHandlers_DeleteHeartbeat tests the DeleteHeartbeat handler.
+func TestHandlers_DeleteHeartbeat(t *testing.T) {
+	h := NewHandlersWithDeps(&mockRepository{}, &mockPublisher{}, nil)
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/heartbeats/delete?heartbeat_id=nightly-backup", nil)
+	w := httptest.NewRecorder()
+
+	h.DeleteHeartbeat(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Errorf("DeleteHeartbeat() status = %v, want %v", w.Code, http.StatusNoContent)
+	}
+}
@@ -3,10 +3,14 @@ package handlers
 
 import (
 	"context"
+	"os"
 	"time"
 
 	"rule-service/internal/database"
 	"rule-service/internal/events"
+	"rule-service/internal/export"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
 )
 
 // RulePublisher defines the interface for publishing rule change events to Kafka.
@@ -16,38 +20,120 @@ type RulePublisher interface {
 	// Returns an error if serialization or publishing fails.
 	Publish(ctx context.Context, changed *events.RuleChanged) error
 
+	// PublishBatch sends several rule changed events in a single Kafka write.
+	PublishBatch(ctx context.Context, changes []*events.RuleChanged) error
+
 	// Close gracefully closes the publisher and releases resources.
 	Close() error
 }
 
+// EmergencyStopStore reads and writes the platform-wide emergency stop.
+type EmergencyStopStore interface {
+	GetEmergencyStop(ctx context.Context) (shared.EmergencyStop, error)
+	SetEmergencyStop(ctx context.Context, stop shared.EmergencyStop) error
+	ClearEmergencyStop(ctx context.Context) error
+}
+
+// RuleSnapshotStore reads the rule snapshot versions rule-updater keeps, reads and writes
+// the evaluator's pin and requests evaluator reloads.
+type RuleSnapshotStore interface {
+	ListRuleSnapshotVersions(ctx context.Context) ([]shared.RuleSnapshotVersion, error)
+	GetRuleSnapshot(ctx context.Context, version int64) ([]byte, error)
+	GetCurrentRuleSnapshotVersion(ctx context.Context) (int64, error)
+	GetRuleSnapshotPin(ctx context.Context) (*shared.RuleSnapshotPin, error)
+	SetRuleSnapshotPin(ctx context.Context, pin shared.RuleSnapshotPin) error
+	ClearRuleSnapshotPin(ctx context.Context) error
+	RequestRuleSnapshotReload(ctx context.Context) (int64, error)
+}
+
+// ExportJobManager runs asynchronous notification exports.
+type ExportJobManager interface {
+	Start(req export.Request) (export.Job, error)
+	Get(jobID string) (export.Job, error)
+	Open(jobID string) (*os.File, export.Job, error)
+}
+
+// WebhookStore manages client meta-webhooks and lists their deliveries.
+type WebhookStore interface {
+	CreateWebhook(ctx context.Context, clientID, url, secret string, eventTypes []string) (*database.ClientWebhook, error)
+	GetWebhook(ctx context.Context, webhookID string) (*database.ClientWebhook, error)
+	ListWebhooks(ctx context.Context, clientID *string) ([]*database.ClientWebhook, error)
+	UpdateWebhook(ctx context.Context, webhookID string, url *string, eventTypes *[]string, enabled *bool) (*database.ClientWebhook, error)
+	DeleteWebhook(ctx context.Context, webhookID string) error
+	ListWebhookDeliveries(ctx context.Context, webhookID string, limit int) ([]*database.WebhookDelivery, error)
+}
+
+// APIKeyStore issues, lists and revokes API keys.
+type APIKeyStore interface {
+	CreateAPIKey(ctx context.Context, clientID, name, keyHash, keyPrefix string) (*database.APIKey, error)
+	GetAPIKey(ctx context.Context, keyID string) (*database.APIKey, error)
+	ListAPIKeys(ctx context.Context, clientID *string) ([]*database.APIKey, error)
+	RevokeAPIKey(ctx context.Context, keyID string) (*database.APIKey, error)
+}
+
 // Repository defines the interface for database operations.
 // This allows handlers to be tested without a real database.
 type Repository interface {
 	// Client operations
-	CreateClient(ctx context.Context, clientID, name string) error
+	CreateClient(ctx context.Context, clientID, name, timezone, locale string) error
 	GetClient(ctx context.Context, clientID string) (*database.Client, error)
+	UpdateClientSettings(ctx context.Context, clientID string, timezone, locale *string) (*database.Client, error)
 	ListClients(ctx context.Context, limit, offset int) (*database.ClientListResult, error)
+	BootstrapClient(ctx context.Context, clientID, name string, rules []database.BootstrapRule) (*database.BootstrapResult, error)
 
 	// Rule operations
-	CreateRule(ctx context.Context, clientID, severity, source, name string) (*database.Rule, error)
+	CreateRule(ctx context.Context, clientID, severity, source, name, description string, exclusions database.RuleExclusions, conditions database.RuleConditions) (*database.Rule, error)
 	GetRule(ctx context.Context, ruleID string) (*database.Rule, error)
-	ListRules(ctx context.Context, clientID *string, limit, offset int) (*database.RuleListResult, error)
-	UpdateRule(ctx context.Context, ruleID string, severity, source, name string, expectedVersion int) (*database.Rule, error)
+	ListRules(ctx context.Context, filter database.RuleFilter, limit, offset int) (*database.RuleListResult, error)
+	UpdateRule(ctx context.Context, ruleID string, severity, source, name string, description *string, exclusions *database.RuleExclusions, conditions *database.RuleConditions, expectedVersion int) (*database.Rule, error)
 	ToggleRuleEnabled(ctx context.Context, ruleID string, enabled bool, expectedVersion int) (*database.Rule, error)
+	BulkSetRulesEnabled(ctx context.Context, sel database.RuleSelection, enabled bool) (*database.BulkToggleResult, error)
 	DeleteRule(ctx context.Context, ruleID string) error
 	GetRulesUpdatedSince(ctx context.Context, since time.Time) ([]*database.Rule, error)
+	ListClientRules(ctx context.Context, clientID string) ([]*database.Rule, error)
+	ImportRules(ctx context.Context, clientID string, rules []database.BootstrapRule) ([]*database.BootstrappedRule, error)
+	GetRuleImpact(ctx context.Context, clientID, severity, source, name string, since time.Time) ([]*database.RuleImpactDay, error)
 
 	// Endpoint operations
-	CreateEndpoint(ctx context.Context, ruleID, endpointType, value string) (*database.Endpoint, error)
+	CreateEndpoint(ctx context.Context, ruleID, endpointType, value string, metadata database.EndpointMetadata, templateID string) (*database.Endpoint, error)
 	GetEndpoint(ctx context.Context, endpointID string) (*database.Endpoint, error)
 	ListEndpoints(ctx context.Context, ruleID *string, limit, offset int) (*database.EndpointListResult, error)
-	UpdateEndpoint(ctx context.Context, endpointID, endpointType, value string) (*database.Endpoint, error)
+	ListClientEndpoints(ctx context.Context, clientID string) ([]*database.Endpoint, error)
+	UpdateEndpoint(ctx context.Context, endpointID, endpointType, value string, metadata *database.EndpointMetadata, templateID *string) (*database.Endpoint, error)
 	ToggleEndpointEnabled(ctx context.Context, endpointID string, enabled bool) (*database.Endpoint, error)
 	DeleteEndpoint(ctx context.Context, endpointID string) error
 
+	// Template operations
+	CreateTemplate(ctx context.Context, clientID, name, subject, body string) (*database.Template, error)
+	GetTemplate(ctx context.Context, templateID string) (*database.Template, error)
+	ListTemplates(ctx context.Context, clientID *string) ([]*database.Template, error)
+	UpdateTemplate(ctx context.Context, templateID string, name, subject, body *string) (*database.Template, error)
+	DeleteTemplate(ctx context.Context, templateID string) error
+
+	// Escalation policy operations
+	CreateEscalationPolicy(ctx context.Context, clientID, name string, steps database.EscalationSteps) (*database.EscalationPolicy, error)
+	GetEscalationPolicy(ctx context.Context, policyID string) (*database.EscalationPolicy, error)
+	ListEscalationPolicies(ctx context.Context, clientID *string) ([]*database.EscalationPolicy, error)
+	UpdateEscalationPolicy(ctx context.Context, policyID string, name *string, steps *database.EscalationSteps) (*database.EscalationPolicy, error)
+	DeleteEscalationPolicy(ctx context.Context, policyID string) error
+	GetRuleEscalationPolicyID(ctx context.Context, ruleID string) (string, error)
+	SetRuleEscalationPolicy(ctx context.Context, ruleID, policyID string) error
+
 	// Notification operations
 	GetNotification(ctx context.Context, notificationID string) (*database.Notification, error)
-	ListNotifications(ctx context.Context, clientID *string, status *string, limit, offset int) (*database.NotificationListResult, error)
+	ListNotifications(ctx context.Context, clientID *string, statuses []string, limit, offset int) (*database.NotificationListResult, error)
+	GetNotificationSummary(ctx context.Context, clientID *string, since time.Time) (*database.NotificationSummary, error)
+	ListNotificationEvents(ctx context.Context, notificationID string) ([]*database.NotificationEvent, error)
+	ListNotificationDeliveries(ctx context.Context, notificationID string) ([]*database.NotificationDelivery, error)
+	AcknowledgeNotification(ctx context.Context, notificationID, actor, note string) (*database.Notification, error)
+	ResolveNotification(ctx context.Context, notificationID, actor, note string) (*database.Notification, error)
+	ExportNotifications(ctx context.Context, filter database.NotificationExportFilter, fn func(*database.Notification) error) error
+
+	// Heartbeat operations
+	PingHeartbeat(ctx context.Context, heartbeatID, clientID string, intervalSeconds int, severity, source, name string) (*database.Heartbeat, error)
+	GetHeartbeat(ctx context.Context, heartbeatID string) (*database.Heartbeat, error)
+	ListHeartbeats(ctx context.Context, clientID *string) ([]*database.Heartbeat, error)
+	DeleteHeartbeat(ctx context.Context, heartbeatID string) error
 
 	// Lifecycle
 	Close() error
@@ -75,23 +161,3 @@ func (NoOpMetrics) RecordProcessed(_ time.Duration)   {}
 func (NoOpMetrics) RecordPublished()                  {}
 func (NoOpMetrics) RecordError()                      {}
 func (NoOpMetrics) IncrementCustom(_ string)          {}
-
-// metricsAdapter wraps the pkg/metrics.Collector to implement MetricsRecorder.
-type metricsAdapter struct {
-	collector metricsCollectorInterface
-}
-
-// metricsCollectorInterface defines the subset of metrics.Collector methods we use.
-type metricsCollectorInterface interface {
-	RecordReceived()
-	RecordProcessed(latency time.Duration)
-	RecordPublished()
-	RecordError()
-	IncrementCustom(name string)
-}
-
-func (a *metricsAdapter) RecordReceived()                 { a.collector.RecordReceived() }
-func (a *metricsAdapter) RecordProcessed(d time.Duration) { a.collector.RecordProcessed(d) }
-func (a *metricsAdapter) RecordPublished()                { a.collector.RecordPublished() }
-func (a *metricsAdapter) RecordError()                    { a.collector.RecordError() }
-func (a *metricsAdapter) IncrementCustom(name string)     { a.collector.IncrementCustom(name) }
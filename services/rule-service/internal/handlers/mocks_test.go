@@ -3,6 +3,7 @@ package handlers
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"rule-service/internal/database"
@@ -12,33 +13,76 @@ import (
 // mockRepository implements Repository interface for testing.
 type mockRepository struct {
 	// Callbacks for each method (set these to control behavior)
-	CreateClientFn        func(ctx context.Context, clientID, name string) error
+	CreateClientFn        func(ctx context.Context, clientID, name, timezone, locale string) error
 	GetClientFn           func(ctx context.Context, clientID string) (*database.Client, error)
+	UpdateClientSettingsFn func(ctx context.Context, clientID string, timezone, locale *string) (*database.Client, error)
 	ListClientsFn         func(ctx context.Context, limit, offset int) (*database.ClientListResult, error)
-	CreateRuleFn          func(ctx context.Context, clientID, severity, source, name string) (*database.Rule, error)
+	BootstrapClientFn     func(ctx context.Context, clientID, name string, rules []database.BootstrapRule) (*database.BootstrapResult, error)
+	CreateRuleFn          func(ctx context.Context, clientID, severity, source, name, description string, exclusions database.RuleExclusions, conditions database.RuleConditions) (*database.Rule, error)
 	GetRuleFn             func(ctx context.Context, ruleID string) (*database.Rule, error)
-	ListRulesFn           func(ctx context.Context, clientID *string, limit, offset int) (*database.RuleListResult, error)
-	UpdateRuleFn          func(ctx context.Context, ruleID string, severity, source, name string, expectedVersion int) (*database.Rule, error)
+	ListRulesFn           func(ctx context.Context, filter database.RuleFilter, limit, offset int) (*database.RuleListResult, error)
+	UpdateRuleFn          func(ctx context.Context, ruleID string, severity, source, name string, description *string, exclusions *database.RuleExclusions, conditions *database.RuleConditions, expectedVersion int) (*database.Rule, error)
 	ToggleRuleEnabledFn   func(ctx context.Context, ruleID string, enabled bool, expectedVersion int) (*database.Rule, error)
+	BulkSetRulesEnabledFn func(ctx context.Context, sel database.RuleSelection, enabled bool) (*database.BulkToggleResult, error)
 	DeleteRuleFn          func(ctx context.Context, ruleID string) error
 	GetRulesUpdatedSinceFn func(ctx context.Context, since time.Time) ([]*database.Rule, error)
-	CreateEndpointFn      func(ctx context.Context, ruleID, endpointType, value string) (*database.Endpoint, error)
+	ListClientRulesFn     func(ctx context.Context, clientID string) ([]*database.Rule, error)
+	ImportRulesFn         func(ctx context.Context, clientID string, rules []database.BootstrapRule) ([]*database.BootstrappedRule, error)
+	GetRuleImpactFn       func(ctx context.Context, clientID, severity, source, name string, since time.Time) ([]*database.RuleImpactDay, error)
+	CreateEndpointFn      func(ctx context.Context, ruleID, endpointType, value string, metadata database.EndpointMetadata, templateID string) (*database.Endpoint, error)
 	GetEndpointFn         func(ctx context.Context, endpointID string) (*database.Endpoint, error)
 	ListEndpointsFn       func(ctx context.Context, ruleID *string, limit, offset int) (*database.EndpointListResult, error)
-	UpdateEndpointFn      func(ctx context.Context, endpointID, endpointType, value string) (*database.Endpoint, error)
+	ListClientEndpointsFn func(ctx context.Context, clientID string) ([]*database.Endpoint, error)
+	UpdateEndpointFn      func(ctx context.Context, endpointID, endpointType, value string, metadata *database.EndpointMetadata, templateID *string) (*database.Endpoint, error)
 	ToggleEndpointEnabledFn func(ctx context.Context, endpointID string, enabled bool) (*database.Endpoint, error)
 	DeleteEndpointFn      func(ctx context.Context, endpointID string) error
+	CreateTemplateFn      func(ctx context.Context, clientID, name, subject, body string) (*database.Template, error)
+	GetTemplateFn         func(ctx context.Context, templateID string) (*database.Template, error)
+	ListTemplatesFn       func(ctx context.Context, clientID *string) ([]*database.Template, error)
+	UpdateTemplateFn      func(ctx context.Context, templateID string, name, subject, body *string) (*database.Template, error)
+	DeleteTemplateFn      func(ctx context.Context, templateID string) error
+	CreateEscalationPolicyFn func(ctx context.Context, clientID, name string, steps database.EscalationSteps) (*database.EscalationPolicy, error)
+	GetEscalationPolicyFn func(ctx context.Context, policyID string) (*database.EscalationPolicy, error)
+	ListEscalationPoliciesFn func(ctx context.Context, clientID *string) ([]*database.EscalationPolicy, error)
+	UpdateEscalationPolicyFn func(ctx context.Context, policyID string, name *string, steps *database.EscalationSteps) (*database.EscalationPolicy, error)
+	DeleteEscalationPolicyFn func(ctx context.Context, policyID string) error
+	GetRuleEscalationPolicyIDFn func(ctx context.Context, ruleID string) (string, error)
+	SetRuleEscalationPolicyFn func(ctx context.Context, ruleID, policyID string) error
 	GetNotificationFn     func(ctx context.Context, notificationID string) (*database.Notification, error)
-	ListNotificationsFn   func(ctx context.Context, clientID *string, status *string, limit, offset int) (*database.NotificationListResult, error)
+	ListNotificationsFn   func(ctx context.Context, clientID *string, statuses []string, limit, offset int) (*database.NotificationListResult, error)
+	GetNotificationSummaryFn func(ctx context.Context, clientID *string, since time.Time) (*database.NotificationSummary, error)
+	ListNotificationEventsFn func(ctx context.Context, notificationID string) ([]*database.NotificationEvent, error)
+	ListNotificationDeliveriesFn func(ctx context.Context, notificationID string) ([]*database.NotificationDelivery, error)
+	AcknowledgeNotificationFn func(ctx context.Context, notificationID, actor, note string) (*database.Notification, error)
+	ResolveNotificationFn func(ctx context.Context, notificationID, actor, note string) (*database.Notification, error)
+	ExportNotificationsFn func(ctx context.Context, filter database.NotificationExportFilter, fn func(*database.Notification) error) error
+	PingHeartbeatFn       func(ctx context.Context, heartbeatID, clientID string, intervalSeconds int, severity, source, name string) (*database.Heartbeat, error)
+	GetHeartbeatFn        func(ctx context.Context, heartbeatID string) (*database.Heartbeat, error)
+	ListHeartbeatsFn      func(ctx context.Context, clientID *string) ([]*database.Heartbeat, error)
+	DeleteHeartbeatFn     func(ctx context.Context, heartbeatID string) error
 }
 
-func (m *mockRepository) CreateClient(ctx context.Context, clientID, name string) error {
+func (m *mockRepository) CreateClient(ctx context.Context, clientID, name, timezone, locale string) error {
 	if m.CreateClientFn != nil {
-		return m.CreateClientFn(ctx, clientID, name)
+		return m.CreateClientFn(ctx, clientID, name, timezone, locale)
 	}
 	return nil
 }
 
+func (m *mockRepository) UpdateClientSettings(ctx context.Context, clientID string, timezone, locale *string) (*database.Client, error) {
+	if m.UpdateClientSettingsFn != nil {
+		return m.UpdateClientSettingsFn(ctx, clientID, timezone, locale)
+	}
+	client := &database.Client{ClientID: clientID, Name: "Test"}
+	if timezone != nil {
+		client.Timezone = *timezone
+	}
+	if locale != nil {
+		client.Locale = *locale
+	}
+	return client, nil
+}
+
 func (m *mockRepository) GetClient(ctx context.Context, clientID string) (*database.Client, error) {
 	if m.GetClientFn != nil {
 		return m.GetClientFn(ctx, clientID)
@@ -53,9 +97,21 @@ func (m *mockRepository) ListClients(ctx context.Context, limit, offset int) (*d
 	return &database.ClientListResult{Clients: []*database.Client{}, Total: 0, Limit: limit, Offset: offset}, nil
 }
 
-func (m *mockRepository) CreateRule(ctx context.Context, clientID, severity, source, name string) (*database.Rule, error) {
+func (m *mockRepository) BootstrapClient(ctx context.Context, clientID, name string, rules []database.BootstrapRule) (*database.BootstrapResult, error) {
+	if m.BootstrapClientFn != nil {
+		return m.BootstrapClientFn(ctx, clientID, name, rules)
+	}
+	result := &database.BootstrapResult{Client: &database.Client{ClientID: clientID, Name: name}}
+	for i, r := range rules {
+		rule := &database.Rule{RuleID: fmt.Sprintf("rule-%d", i+1), ClientID: clientID, Severity: r.Severity, Source: r.Source, Name: r.Name, Enabled: true, Version: 1}
+		result.Rules = append(result.Rules, &database.BootstrappedRule{Rule: rule})
+	}
+	return result, nil
+}
+
+func (m *mockRepository) CreateRule(ctx context.Context, clientID, severity, source, name, description string, exclusions database.RuleExclusions, conditions database.RuleConditions) (*database.Rule, error) {
 	if m.CreateRuleFn != nil {
-		return m.CreateRuleFn(ctx, clientID, severity, source, name)
+		return m.CreateRuleFn(ctx, clientID, severity, source, name, description, exclusions, conditions)
 	}
 	return &database.Rule{RuleID: "rule-1", ClientID: clientID, Severity: severity, Source: source, Name: name, Enabled: true, Version: 1}, nil
 }
@@ -67,16 +123,16 @@ func (m *mockRepository) GetRule(ctx context.Context, ruleID string) (*database.
 	return &database.Rule{RuleID: ruleID, ClientID: "client-1", Severity: "HIGH", Source: "source-1", Name: "alert-1", Enabled: true, Version: 1}, nil
 }
 
-func (m *mockRepository) ListRules(ctx context.Context, clientID *string, limit, offset int) (*database.RuleListResult, error) {
+func (m *mockRepository) ListRules(ctx context.Context, filter database.RuleFilter, limit, offset int) (*database.RuleListResult, error) {
 	if m.ListRulesFn != nil {
-		return m.ListRulesFn(ctx, clientID, limit, offset)
+		return m.ListRulesFn(ctx, filter, limit, offset)
 	}
 	return &database.RuleListResult{Rules: []*database.Rule{}, Total: 0, Limit: limit, Offset: offset}, nil
 }
 
-func (m *mockRepository) UpdateRule(ctx context.Context, ruleID string, severity, source, name string, expectedVersion int) (*database.Rule, error) {
+func (m *mockRepository) UpdateRule(ctx context.Context, ruleID string, severity, source, name string, description *string, exclusions *database.RuleExclusions, conditions *database.RuleConditions, expectedVersion int) (*database.Rule, error) {
 	if m.UpdateRuleFn != nil {
-		return m.UpdateRuleFn(ctx, ruleID, severity, source, name, expectedVersion)
+		return m.UpdateRuleFn(ctx, ruleID, severity, source, name, description, exclusions, conditions, expectedVersion)
 	}
 	return &database.Rule{RuleID: ruleID, Severity: severity, Source: source, Name: name, Version: expectedVersion + 1}, nil
 }
@@ -88,6 +144,13 @@ func (m *mockRepository) ToggleRuleEnabled(ctx context.Context, ruleID string, e
 	return &database.Rule{RuleID: ruleID, Enabled: enabled, Version: expectedVersion + 1}, nil
 }
 
+func (m *mockRepository) BulkSetRulesEnabled(ctx context.Context, sel database.RuleSelection, enabled bool) (*database.BulkToggleResult, error) {
+	if m.BulkSetRulesEnabledFn != nil {
+		return m.BulkSetRulesEnabledFn(ctx, sel, enabled)
+	}
+	return &database.BulkToggleResult{Updated: []*database.Rule{}, Unchanged: []string{}}, nil
+}
+
 func (m *mockRepository) DeleteRule(ctx context.Context, ruleID string) error {
 	if m.DeleteRuleFn != nil {
 		return m.DeleteRuleFn(ctx, ruleID)
@@ -102,11 +165,37 @@ func (m *mockRepository) GetRulesUpdatedSince(ctx context.Context, since time.Ti
 	return []*database.Rule{}, nil
 }
 
-func (m *mockRepository) CreateEndpoint(ctx context.Context, ruleID, endpointType, value string) (*database.Endpoint, error) {
+func (m *mockRepository) ListClientRules(ctx context.Context, clientID string) ([]*database.Rule, error) {
+	if m.ListClientRulesFn != nil {
+		return m.ListClientRulesFn(ctx, clientID)
+	}
+	return []*database.Rule{}, nil
+}
+
+func (m *mockRepository) ImportRules(ctx context.Context, clientID string, rules []database.BootstrapRule) ([]*database.BootstrappedRule, error) {
+	if m.ImportRulesFn != nil {
+		return m.ImportRulesFn(ctx, clientID, rules)
+	}
+	created := make([]*database.BootstrappedRule, 0, len(rules))
+	for i, r := range rules {
+		rule := &database.Rule{RuleID: fmt.Sprintf("imported-%d", i+1), ClientID: clientID, Severity: r.Severity, Source: r.Source, Name: r.Name, Enabled: !r.Disabled, Version: 1}
+		created = append(created, &database.BootstrappedRule{Rule: rule})
+	}
+	return created, nil
+}
+
+func (m *mockRepository) GetRuleImpact(ctx context.Context, clientID, severity, source, name string, since time.Time) ([]*database.RuleImpactDay, error) {
+	if m.GetRuleImpactFn != nil {
+		return m.GetRuleImpactFn(ctx, clientID, severity, source, name, since)
+	}
+	return []*database.RuleImpactDay{}, nil
+}
+
+func (m *mockRepository) CreateEndpoint(ctx context.Context, ruleID, endpointType, value string, metadata database.EndpointMetadata, templateID string) (*database.Endpoint, error) {
 	if m.CreateEndpointFn != nil {
-		return m.CreateEndpointFn(ctx, ruleID, endpointType, value)
+		return m.CreateEndpointFn(ctx, ruleID, endpointType, value, metadata, templateID)
 	}
-	return &database.Endpoint{EndpointID: "endpoint-1", RuleID: ruleID, Type: endpointType, Value: value, Enabled: true}, nil
+	return &database.Endpoint{EndpointID: "endpoint-1", RuleID: ruleID, Type: endpointType, Value: value, Enabled: true, Metadata: metadata, TemplateID: templateID}, nil
 }
 
 func (m *mockRepository) GetEndpoint(ctx context.Context, endpointID string) (*database.Endpoint, error) {
@@ -123,9 +212,16 @@ func (m *mockRepository) ListEndpoints(ctx context.Context, ruleID *string, limi
 	return &database.EndpointListResult{Endpoints: []*database.Endpoint{}, Total: 0, Limit: limit, Offset: offset}, nil
 }
 
-func (m *mockRepository) UpdateEndpoint(ctx context.Context, endpointID, endpointType, value string) (*database.Endpoint, error) {
+func (m *mockRepository) ListClientEndpoints(ctx context.Context, clientID string) ([]*database.Endpoint, error) {
+	if m.ListClientEndpointsFn != nil {
+		return m.ListClientEndpointsFn(ctx, clientID)
+	}
+	return []*database.Endpoint{}, nil
+}
+
+func (m *mockRepository) UpdateEndpoint(ctx context.Context, endpointID, endpointType, value string, metadata *database.EndpointMetadata, templateID *string) (*database.Endpoint, error) {
 	if m.UpdateEndpointFn != nil {
-		return m.UpdateEndpointFn(ctx, endpointID, endpointType, value)
+		return m.UpdateEndpointFn(ctx, endpointID, endpointType, value, metadata, templateID)
 	}
 	return &database.Endpoint{EndpointID: endpointID, Type: endpointType, Value: value}, nil
 }
@@ -144,6 +240,90 @@ func (m *mockRepository) DeleteEndpoint(ctx context.Context, endpointID string)
 	return nil
 }
 
+func (m *mockRepository) CreateTemplate(ctx context.Context, clientID, name, subject, body string) (*database.Template, error) {
+	if m.CreateTemplateFn != nil {
+		return m.CreateTemplateFn(ctx, clientID, name, subject, body)
+	}
+	return &database.Template{TemplateID: "template-1", ClientID: clientID, Name: name, Subject: subject, Body: body}, nil
+}
+
+func (m *mockRepository) GetTemplate(ctx context.Context, templateID string) (*database.Template, error) {
+	if m.GetTemplateFn != nil {
+		return m.GetTemplateFn(ctx, templateID)
+	}
+	return &database.Template{TemplateID: templateID, ClientID: "client-1", Name: "short", Body: "{{.Name}}"}, nil
+}
+
+func (m *mockRepository) ListTemplates(ctx context.Context, clientID *string) ([]*database.Template, error) {
+	if m.ListTemplatesFn != nil {
+		return m.ListTemplatesFn(ctx, clientID)
+	}
+	return []*database.Template{}, nil
+}
+
+func (m *mockRepository) UpdateTemplate(ctx context.Context, templateID string, name, subject, body *string) (*database.Template, error) {
+	if m.UpdateTemplateFn != nil {
+		return m.UpdateTemplateFn(ctx, templateID, name, subject, body)
+	}
+	return &database.Template{TemplateID: templateID, ClientID: "client-1"}, nil
+}
+
+func (m *mockRepository) DeleteTemplate(ctx context.Context, templateID string) error {
+	if m.DeleteTemplateFn != nil {
+		return m.DeleteTemplateFn(ctx, templateID)
+	}
+	return nil
+}
+
+func (m *mockRepository) CreateEscalationPolicy(ctx context.Context, clientID, name string, steps database.EscalationSteps) (*database.EscalationPolicy, error) {
+	if m.CreateEscalationPolicyFn != nil {
+		return m.CreateEscalationPolicyFn(ctx, clientID, name, steps)
+	}
+	return &database.EscalationPolicy{PolicyID: "policy-1", ClientID: clientID, Name: name, Steps: steps, RuleIDs: []string{}}, nil
+}
+
+func (m *mockRepository) GetEscalationPolicy(ctx context.Context, policyID string) (*database.EscalationPolicy, error) {
+	if m.GetEscalationPolicyFn != nil {
+		return m.GetEscalationPolicyFn(ctx, policyID)
+	}
+	return &database.EscalationPolicy{PolicyID: policyID, ClientID: "client-1", RuleIDs: []string{}}, nil
+}
+
+func (m *mockRepository) ListEscalationPolicies(ctx context.Context, clientID *string) ([]*database.EscalationPolicy, error) {
+	if m.ListEscalationPoliciesFn != nil {
+		return m.ListEscalationPoliciesFn(ctx, clientID)
+	}
+	return []*database.EscalationPolicy{}, nil
+}
+
+func (m *mockRepository) UpdateEscalationPolicy(ctx context.Context, policyID string, name *string, steps *database.EscalationSteps) (*database.EscalationPolicy, error) {
+	if m.UpdateEscalationPolicyFn != nil {
+		return m.UpdateEscalationPolicyFn(ctx, policyID, name, steps)
+	}
+	return &database.EscalationPolicy{PolicyID: policyID, ClientID: "client-1", RuleIDs: []string{}}, nil
+}
+
+func (m *mockRepository) DeleteEscalationPolicy(ctx context.Context, policyID string) error {
+	if m.DeleteEscalationPolicyFn != nil {
+		return m.DeleteEscalationPolicyFn(ctx, policyID)
+	}
+	return nil
+}
+
+func (m *mockRepository) GetRuleEscalationPolicyID(ctx context.Context, ruleID string) (string, error) {
+	if m.GetRuleEscalationPolicyIDFn != nil {
+		return m.GetRuleEscalationPolicyIDFn(ctx, ruleID)
+	}
+	return "", nil
+}
+
+func (m *mockRepository) SetRuleEscalationPolicy(ctx context.Context, ruleID, policyID string) error {
+	if m.SetRuleEscalationPolicyFn != nil {
+		return m.SetRuleEscalationPolicyFn(ctx, ruleID, policyID)
+	}
+	return nil
+}
+
 func (m *mockRepository) GetNotification(ctx context.Context, notificationID string) (*database.Notification, error) {
 	if m.GetNotificationFn != nil {
 		return m.GetNotificationFn(ctx, notificationID)
@@ -151,13 +331,83 @@ func (m *mockRepository) GetNotification(ctx context.Context, notificationID str
 	return &database.Notification{NotificationID: notificationID, ClientID: "client-1", Status: "RECEIVED"}, nil
 }
 
-func (m *mockRepository) ListNotifications(ctx context.Context, clientID *string, status *string, limit, offset int) (*database.NotificationListResult, error) {
+func (m *mockRepository) ListNotifications(ctx context.Context, clientID *string, statuses []string, limit, offset int) (*database.NotificationListResult, error) {
 	if m.ListNotificationsFn != nil {
-		return m.ListNotificationsFn(ctx, clientID, status, limit, offset)
+		return m.ListNotificationsFn(ctx, clientID, statuses, limit, offset)
 	}
 	return &database.NotificationListResult{Notifications: []*database.Notification{}, Total: 0, Limit: limit, Offset: offset}, nil
 }
 
+func (m *mockRepository) GetNotificationSummary(ctx context.Context, clientID *string, since time.Time) (*database.NotificationSummary, error) {
+	if m.GetNotificationSummaryFn != nil {
+		return m.GetNotificationSummaryFn(ctx, clientID, since)
+	}
+	return &database.NotificationSummary{Since: since, ByStatus: map[string]int64{}, BySeverity: map[string]int64{}}, nil
+}
+
+func (m *mockRepository) ListNotificationEvents(ctx context.Context, notificationID string) ([]*database.NotificationEvent, error) {
+	if m.ListNotificationEventsFn != nil {
+		return m.ListNotificationEventsFn(ctx, notificationID)
+	}
+	return []*database.NotificationEvent{}, nil
+}
+
+func (m *mockRepository) ListNotificationDeliveries(ctx context.Context, notificationID string) ([]*database.NotificationDelivery, error) {
+	if m.ListNotificationDeliveriesFn != nil {
+		return m.ListNotificationDeliveriesFn(ctx, notificationID)
+	}
+	return []*database.NotificationDelivery{}, nil
+}
+
+func (m *mockRepository) AcknowledgeNotification(ctx context.Context, notificationID, actor, note string) (*database.Notification, error) {
+	if m.AcknowledgeNotificationFn != nil {
+		return m.AcknowledgeNotificationFn(ctx, notificationID, actor, note)
+	}
+	return &database.Notification{NotificationID: notificationID, ClientID: "client-1", Status: "ACKNOWLEDGED"}, nil
+}
+
+func (m *mockRepository) ResolveNotification(ctx context.Context, notificationID, actor, note string) (*database.Notification, error) {
+	if m.ResolveNotificationFn != nil {
+		return m.ResolveNotificationFn(ctx, notificationID, actor, note)
+	}
+	return &database.Notification{NotificationID: notificationID, ClientID: "client-1", Status: "RESOLVED"}, nil
+}
+
+func (m *mockRepository) ExportNotifications(ctx context.Context, filter database.NotificationExportFilter, fn func(*database.Notification) error) error {
+	if m.ExportNotificationsFn != nil {
+		return m.ExportNotificationsFn(ctx, filter, fn)
+	}
+	return nil
+}
+
+func (m *mockRepository) PingHeartbeat(ctx context.Context, heartbeatID, clientID string, intervalSeconds int, severity, source, name string) (*database.Heartbeat, error) {
+	if m.PingHeartbeatFn != nil {
+		return m.PingHeartbeatFn(ctx, heartbeatID, clientID, intervalSeconds, severity, source, name)
+	}
+	return &database.Heartbeat{HeartbeatID: heartbeatID, ClientID: clientID, IntervalSeconds: intervalSeconds, Severity: severity, Source: source, Name: name}, nil
+}
+
+func (m *mockRepository) GetHeartbeat(ctx context.Context, heartbeatID string) (*database.Heartbeat, error) {
+	if m.GetHeartbeatFn != nil {
+		return m.GetHeartbeatFn(ctx, heartbeatID)
+	}
+	return &database.Heartbeat{HeartbeatID: heartbeatID, ClientID: "client-1", IntervalSeconds: 60}, nil
+}
+
+func (m *mockRepository) ListHeartbeats(ctx context.Context, clientID *string) ([]*database.Heartbeat, error) {
+	if m.ListHeartbeatsFn != nil {
+		return m.ListHeartbeatsFn(ctx, clientID)
+	}
+	return []*database.Heartbeat{}, nil
+}
+
+func (m *mockRepository) DeleteHeartbeat(ctx context.Context, heartbeatID string) error {
+	if m.DeleteHeartbeatFn != nil {
+		return m.DeleteHeartbeatFn(ctx, heartbeatID)
+	}
+	return nil
+}
+
 func (m *mockRepository) Close() error {
 	return nil
 }
@@ -166,6 +416,7 @@ func (m *mockRepository) Close() error {
 type mockPublisher struct {
 	PublishFn  func(ctx context.Context, changed *events.RuleChanged) error
 	Published  []*events.RuleChanged // Records all published events
+	Batches    int                   // Number of PublishBatch calls
 }
 
 func (m *mockPublisher) Publish(ctx context.Context, changed *events.RuleChanged) error {
@@ -176,6 +427,12 @@ func (m *mockPublisher) Publish(ctx context.Context, changed *events.RuleChanged
 	return nil
 }
 
+func (m *mockPublisher) PublishBatch(ctx context.Context, changes []*events.RuleChanged) error {
+	m.Batches++
+	m.Published = append(m.Published, changes...)
+	return nil
+}
+
 func (m *mockPublisher) Close() error {
 	return nil
 }
@@ -2,10 +2,46 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"log/slog"
 	"net/http"
+	"strings"
+	"time"
+
+	"rule-service/internal/database"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// Bounds of the audit fields of an acknowledgement or resolution.
+const (
+	maxNotificationActorLength = 255
+	maxNotificationNoteLength  = 1000
+)
+
+// Notification summary window bounds. Counts are kept per hour, so the window is rounded
+// out to whole hours.
+const (
+	defaultSummaryWindow = 24 * time.Hour
+	maxSummaryWindow     = 30 * 24 * time.Hour
 )
 
+// NotificationTransitionRequest acknowledges or resolves a notification.
+type NotificationTransitionRequest struct {
+	NotificationID string `json:"notification_id"`
+	Actor          string `json:"actor"`
+	Note           string `json:"note,omitempty"`
+}
+
+func (req *NotificationTransitionRequest) validate(v *shared.Validator) {
+	v.Required("notification_id", req.NotificationID)
+	if v.Required("actor", req.Actor) {
+		v.MaxLength("actor", req.Actor, maxNotificationActorLength)
+	}
+	v.MaxLength("note", req.Note, maxNotificationNoteLength)
+}
+
 // GetNotification retrieves a notification by ID.
 func (h *Handlers) GetNotification(w http.ResponseWriter, r *http.Request) {
 	if !requireMethod(w, r, http.MethodGet) {
@@ -24,33 +60,142 @@ func (h *Handlers) GetNotification(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Notification not found", http.StatusNotFound)
 		return
 	}
+	if !authorizeOwner(w, r, "notification", notification.ClientID) {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, notification)
+}
+
+// ListNotificationEvents returns the journal of a notification, oldest first.
+// GET /api/v1/notifications/events?notification_id=<id>
+func (h *Handlers) ListNotificationEvents(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	notificationID, ok := requireQueryParam(w, r, "notification_id")
+	if !ok {
+		return
+	}
+	if !h.authorizeNotification(w, r, notificationID) {
+		return
+	}
+
+	events, err := h.db.ListNotificationEvents(r.Context(), notificationID)
+	if err != nil {
+		slog.Error("Failed to list notification events", "error", err, "notification_id", notificationID)
+		http.Error(w, "Failed to list notification events", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, events)
+}
+
+// AcknowledgeNotification marks a notification ACKNOWLEDGED, which stops its reminders.
+// POST /api/v1/notifications/ack
+func (h *Handlers) AcknowledgeNotification(w http.ResponseWriter, r *http.Request) {
+	h.transitionNotification(w, r, h.db.AcknowledgeNotification, "notifications_acknowledged")
+}
+
+// ResolveNotification marks a notification RESOLVED; it may be acknowledged first or not.
+// POST /api/v1/notifications/resolve
+func (h *Handlers) ResolveNotification(w http.ResponseWriter, r *http.Request) {
+	h.transitionNotification(w, r, h.db.ResolveNotification, "notifications_resolved")
+}
+
+// transitionNotification validates a transition request and applies it with transition, which
+// also journals the actor and note. Transitions the current status does not allow return 409.
+func (h *Handlers) transitionNotification(w http.ResponseWriter, r *http.Request, transition func(ctx context.Context, notificationID, actor, note string) (*database.Notification, error), counter string) {
+	if !requireMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	var req NotificationTransitionRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	req.Actor = strings.TrimSpace(req.Actor)
+	req.Note = strings.TrimSpace(req.Note)
+	if !validateRequest(w, &req) {
+		return
+	}
+	if !h.authorizeNotification(w, r, req.NotificationID) {
+		return
+	}
 
+	notification, err := transition(r.Context(), req.NotificationID, req.Actor, req.Note)
+	if errors.Is(err, database.ErrInvalidTransition) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if handleDBError(w, err, "notification", req.NotificationID) {
+		return
+	}
+
+	slog.Info("Notification status changed",
+		"notification_id", notification.NotificationID,
+		"status", notification.Status,
+		"actor", req.Actor,
+	)
+	h.metrics.IncrementCustom(counter)
 	writeJSON(w, http.StatusOK, notification)
 }
 
+// ListNotificationDeliveries returns the endpoint deliveries of a notification, oldest first,
+// showing which channels succeeded or failed.
+// GET /api/v1/notifications/{id}/deliveries
+func (h *Handlers) ListNotificationDeliveries(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	notificationID := r.PathValue("id")
+	if notificationID == "" {
+		http.Error(w, "notification id is required", http.StatusBadRequest)
+		return
+	}
+	if !h.authorizeNotification(w, r, notificationID) {
+		return
+	}
+
+	deliveries, err := h.db.ListNotificationDeliveries(r.Context(), notificationID)
+	if err != nil {
+		slog.Error("Failed to list notification deliveries", "error", err, "notification_id", notificationID)
+		http.Error(w, "Failed to list notification deliveries", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, deliveries)
+}
+
 // ListNotifications retrieves notifications with pagination, optionally filtered by client_id or status.
-// Query params: client_id, status, limit (default 50, max 200), offset (default 0)
+// Query params: client_id, status (comma-separated or repeated; matches any), limit (default 50, max 200), offset (default 0)
 func (h *Handlers) ListNotifications(w http.ResponseWriter, r *http.Request) {
 	if !requireMethod(w, r, http.MethodGet) {
 		return
 	}
 
 	clientID := r.URL.Query().Get("client_id")
-	status := r.URL.Query().Get("status")
 
 	var clientIDPtr *string
 	if clientID != "" {
 		clientIDPtr = &clientID
 	}
+	clientIDPtr, ok := scopeClientFilter(w, r, clientIDPtr)
+	if !ok {
+		return
+	}
 
-	var statusPtr *string
-	if status != "" {
-		statusPtr = &status
+	statuses, err := parseStatusFilter(r.URL.Query()["status"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
 	p := parsePagination(r)
 	ctx := r.Context()
-	result, err := h.db.ListNotifications(ctx, clientIDPtr, statusPtr, p.Limit, p.Offset)
+	result, err := h.db.ListNotifications(ctx, clientIDPtr, statuses, p.Limit, p.Offset)
 	if err != nil {
 		slog.Error("Failed to list notifications", "error", err)
 		http.Error(w, "Failed to list notifications", http.StatusInternalServerError)
@@ -59,3 +204,61 @@ func (h *Handlers) ListNotifications(w http.ResponseWriter, r *http.Request) {
 
 	writeJSON(w, http.StatusOK, result)
 }
+
+// GetNotificationSummary counts the notifications created in a recent window by status and by
+// severity, from per-hour counters rather than the notifications themselves, for the UI header
+// badges. The window starts at the beginning of the hour it reaches back to.
+// Query params: client_id (optional for admin keys), window (Go duration, default 24h, max 720h)
+// GET /api/v1/notifications/summary
+func (h *Handlers) GetNotificationSummary(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	var clientIDPtr *string
+	if clientID := r.URL.Query().Get("client_id"); clientID != "" {
+		clientIDPtr = &clientID
+	}
+	clientIDPtr, ok := scopeClientFilter(w, r, clientIDPtr)
+	if !ok {
+		return
+	}
+
+	window := defaultSummaryWindow
+	if value := r.URL.Query().Get("window"); value != "" {
+		var err error
+		window, err = time.ParseDuration(value)
+		if err != nil || window < time.Hour || window > maxSummaryWindow {
+			http.Error(w, "window must be a duration between 1h and 720h", http.StatusBadRequest)
+			return
+		}
+	}
+
+	summary, err := h.db.GetNotificationSummary(r.Context(), clientIDPtr, time.Now().Add(-window))
+	if err != nil {
+		slog.Error("Failed to get notification summary", "error", err)
+		http.Error(w, "Failed to get notification summary", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, summary)
+}
+
+// parseStatusFilter parses status query values into canonical notification statuses.
+// Each value may hold several comma-separated statuses; unknown statuses are rejected.
+func parseStatusFilter(values []string) ([]string, error) {
+	var statuses []string
+	for _, value := range values {
+		for _, name := range strings.Split(value, ",") {
+			if strings.TrimSpace(name) == "" {
+				continue
+			}
+			status, err := shared.ParseNotificationStatus(name)
+			if err != nil {
+				return nil, err
+			}
+			statuses = append(statuses, status.String())
+		}
+	}
+	return statuses, nil
+}
@@ -0,0 +1,306 @@
+// Package handlers provides HTTP handlers for the rule-service API.
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"rule-service/internal/database"
+	"rule-service/internal/export"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+const (
+	// defaultExportLimit and maxExportLimit bound one page of a synchronous export.
+	// Larger exports page with offset or use an export job.
+	defaultExportLimit = 10000
+	maxExportLimit     = 100000
+	// exportFlushRows is how many rows are written between flushes of a streamed export.
+	exportFlushRows = 500
+	// nextOffsetTrailer is set on a synchronous export when more rows follow the page.
+	nextOffsetTrailer = "X-Next-Offset"
+)
+
+// ExportJobRequest starts an asynchronous notification export.
+type ExportJobRequest struct {
+	ClientID string   `json:"client_id"`
+	From     string   `json:"from"`
+	To       string   `json:"to,omitempty"`
+	Status   []string `json:"status,omitempty"`
+	Format   string   `json:"format,omitempty"`
+	Columns  []string `json:"columns,omitempty"`
+}
+
+// ExportNotifications streams a client's notifications in a time range as CSV or NDJSON.
+// The response is written as rows are read from the database, so memory use does not grow
+// with the export size. If more rows follow the page, the X-Next-Offset trailer holds the
+// offset of the next page.
+// GET /api/v1/notifications/export?client_id=<id>&from=<rfc3339>[&to=<rfc3339>][&format=csv|ndjson]
+// [&columns=a,b][&status=SENT,...][&limit=][&offset=]
+func (h *Handlers) ExportNotifications(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	q := r.URL.Query()
+	v := shared.NewValidator()
+	req := buildExportRequest(v, q.Get("client_id"), q.Get("from"), q.Get("to"), q["status"], q.Get("format"), q.Get("columns"))
+	if err := v.Err(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !authorizeClient(w, r, req.Filter.ClientID) {
+		return
+	}
+	limit, offset, err := parseExportPage(q.Get("limit"), q.Get("offset"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// Fetch one extra row to learn whether another page follows
+	req.Filter.Limit = limit + 1
+	req.Filter.Offset = offset
+
+	// Exports can outlast the server's write timeout
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		slog.Warn("Failed to clear write deadline for export", "error", err)
+	}
+
+	w.Header().Set("Content-Type", req.Format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", exportFilename(req)))
+	w.Header().Set("Trailer", nextOffsetTrailer)
+
+	out := &countingWriter{w: w}
+	enc, err := export.NewEncoder(out, req.Format, req.Columns)
+	if err != nil {
+		slog.Error("Failed to start notification export", "error", err)
+		return
+	}
+
+	rows := 0
+	err = h.db.ExportNotifications(r.Context(), req.Filter, func(n *database.Notification) error {
+		if rows == limit {
+			w.Header().Set(nextOffsetTrailer, strconv.Itoa(offset+limit))
+			return nil
+		}
+		if err := enc.Encode(n); err != nil {
+			return err
+		}
+		rows++
+		if rows%exportFlushRows == 0 {
+			if err := enc.Flush(); err != nil {
+				return err
+			}
+			rc.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		slog.Error("Failed to export notifications", "error", err, "client_id", req.Filter.ClientID, "rows", rows)
+		if out.n == 0 {
+			// Nothing has reached the client yet, so the error can still be reported
+			w.Header().Del("Content-Disposition")
+			w.Header().Del("Trailer")
+			http.Error(w, "Failed to export notifications", http.StatusInternalServerError)
+			return
+		}
+		// Abort the chunked response so the client sees a truncated transfer, not a short file
+		panic(http.ErrAbortHandler)
+	}
+	if err := enc.Flush(); err != nil {
+		slog.Error("Failed to write notification export", "error", err, "client_id", req.Filter.ClientID)
+		return
+	}
+
+	slog.Info("Exported notifications", "client_id", req.Filter.ClientID, "format", req.Format, "rows", rows, "offset", offset)
+	h.metrics.IncrementCustom("notification_exports")
+}
+
+// StartExportJob starts an asynchronous export for ranges too large to stream in one
+// request. Poll GetExportJob, then fetch the file from DownloadExportJob.
+// POST /api/v1/notifications/export/jobs
+func (h *Handlers) StartExportJob(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost) || !h.requireExportJobs(w) {
+		return
+	}
+
+	var body ExportJobRequest
+	if !decodeJSON(w, r, &body) {
+		return
+	}
+	v := shared.NewValidator()
+	req := buildExportRequest(v, body.ClientID, body.From, body.To, body.Status, body.Format, strings.Join(body.Columns, ","))
+	if err := v.Err(); err != nil {
+		shared.WriteValidationError(w, err)
+		return
+	}
+	if !authorizeClient(w, r, req.Filter.ClientID) {
+		return
+	}
+
+	job, err := h.exportJobs.Start(req)
+	if errors.Is(err, export.ErrTooManyJobs) {
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to start export job", "error", err, "client_id", req.Filter.ClientID)
+		http.Error(w, "Failed to start export job", http.StatusInternalServerError)
+		return
+	}
+
+	slog.Info("Started export job", "job_id", job.JobID, "client_id", job.ClientID, "format", job.Format)
+	h.metrics.IncrementCustom("notification_export_jobs")
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// GetExportJob returns the status of an export job.
+// GET /api/v1/notifications/export/jobs?job_id=<id>
+func (h *Handlers) GetExportJob(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet) || !h.requireExportJobs(w) {
+		return
+	}
+
+	jobID, ok := requireQueryParam(w, r, "job_id")
+	if !ok {
+		return
+	}
+
+	job, err := h.exportJobs.Get(jobID)
+	if err != nil {
+		http.Error(w, "Export job not found", http.StatusNotFound)
+		return
+	}
+	if !authorizeOwner(w, r, "export job", job.ClientID) {
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+// DownloadExportJob serves the file of a completed export job.
+// GET /api/v1/notifications/export/jobs/download?job_id=<id>
+func (h *Handlers) DownloadExportJob(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet) || !h.requireExportJobs(w) {
+		return
+	}
+
+	jobID, ok := requireQueryParam(w, r, "job_id")
+	if !ok {
+		return
+	}
+	if !h.authorizeExportJob(w, r, jobID) {
+		return
+	}
+
+	f, job, err := h.exportJobs.Open(jobID)
+	switch {
+	case errors.Is(err, export.ErrJobNotFound):
+		http.Error(w, "Export job not found", http.StatusNotFound)
+		return
+	case errors.Is(err, export.ErrJobNotReady):
+		http.Error(w, "Export job is "+string(job.Status), http.StatusConflict)
+		return
+	case err != nil:
+		slog.Error("Failed to open export file", "error", err, "job_id", jobID)
+		http.Error(w, "Failed to open export file", http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		slog.Warn("Failed to clear write deadline for export download", "error", err)
+	}
+
+	req := export.Request{Filter: database.NotificationExportFilter{ClientID: job.ClientID, From: job.From}, Format: job.Format}
+	w.Header().Set("Content-Type", job.Format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", exportFilename(req)))
+	http.ServeContent(w, r, "", *job.CompletedAt, f)
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// requireExportJobs checks that export jobs are configured. Writes an error response otherwise.
+func (h *Handlers) requireExportJobs(w http.ResponseWriter) bool {
+	if h.exportJobs == nil {
+		http.Error(w, "Export jobs are not configured", http.StatusNotImplemented)
+		return false
+	}
+	return true
+}
+
+// buildExportRequest validates export parameters, recording invalid ones in v. client_id and
+// from are required; to defaults to now and must be after from.
+func buildExportRequest(v *shared.Validator, clientID, from, to string, status []string, format, columns string) export.Request {
+	var req export.Request
+	v.Required("client_id", clientID)
+	var fromTime time.Time
+	fromOK := v.Required("from", from)
+	if fromOK {
+		var err error
+		fromTime, err = time.Parse(time.RFC3339, from)
+		fromOK = v.Check(err == nil, "from", "from must be an RFC 3339 timestamp")
+	}
+	toTime := time.Now().UTC()
+	toOK := true
+	if to != "" {
+		var err error
+		toTime, err = time.Parse(time.RFC3339, to)
+		toOK = v.Check(err == nil, "to", "to must be an RFC 3339 timestamp")
+	}
+	if fromOK && toOK {
+		v.Check(toTime.After(fromTime), "to", "to must be after from")
+	}
+
+	statuses, err := parseStatusFilter(status)
+	if err != nil {
+		v.Add("status", err.Error())
+	}
+	if req.Format, err = export.ParseFormat(format); err != nil {
+		v.Add("format", err.Error())
+	}
+	if req.Columns, err = export.ParseColumns(columns); err != nil {
+		v.Add("columns", err.Error())
+	}
+	req.Filter = database.NotificationExportFilter{ClientID: clientID, From: fromTime, To: toTime, Statuses: statuses}
+	return req
+}
+
+// parseExportPage parses the limit and offset of a synchronous export page.
+func parseExportPage(limitStr, offsetStr string) (limit, offset int, err error) {
+	limit = defaultExportLimit
+	if limitStr != "" {
+		if limit, err = strconv.Atoi(limitStr); err != nil || limit <= 0 || limit > maxExportLimit {
+			return 0, 0, fmt.Errorf("limit must be between 1 and %d", maxExportLimit)
+		}
+	}
+	if offsetStr != "" {
+		if offset, err = strconv.Atoi(offsetStr); err != nil || offset < 0 {
+			return 0, 0, errors.New("offset must be a non-negative integer")
+		}
+	}
+	return limit, offset, nil
+}
+
+// exportFilename names an export file after its client and start date.
+func exportFilename(req export.Request) string {
+	return fmt.Sprintf("notifications-%s-%s.%s", req.Filter.ClientID, req.Filter.From.UTC().Format("20060102"), req.Format.Extension())
+}
@@ -0,0 +1,183 @@
+// Package handlers provides tests for the notification export endpoints.
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"rule-service/internal/database"
+	"rule-service/internal/export"
+)
+
+// exportRepository streams n notifications and records the filter it was called with.
+func exportRepository(n int, filter *database.NotificationExportFilter) *mockRepository {
+	return &mockRepository{
+		ExportNotificationsFn: func(ctx context.Context, f database.NotificationExportFilter, fn func(*database.Notification) error) error {
+			*filter = f
+			for i := 0; i < n && (f.Limit == 0 || i < f.Limit); i++ {
+				if err := fn(&database.Notification{NotificationID: "notif-" + string(rune('a'+i)), ClientID: f.ClientID, Status: "SENT"}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// TestHandlers_ExportNotifications tests the streamed export.
+func TestHandlers_ExportNotifications(t *testing.T) {
+	var filter database.NotificationExportFilter
+	h := NewHandlersWithDeps(exportRepository(3, &filter), &mockPublisher{}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/notifications/export?client_id=client-1&from=2026-03-01T00:00:00Z&to=2026-04-01T00:00:00Z&columns=notification_id,status&status=SENT&limit=2", nil)
+	w := httptest.NewRecorder()
+	h.ExportNotifications(w, req)
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("ExportNotifications() status = %v, want %v: %s", resp.StatusCode, http.StatusOK, body)
+	}
+	if want := "notification_id,status\nnotif-a,SENT\nnotif-b,SENT\n"; string(body) != want {
+		t.Errorf("ExportNotifications() body = %q, want %q", body, want)
+	}
+	if got := resp.Trailer.Get("X-Next-Offset"); got != "2" {
+		t.Errorf("X-Next-Offset trailer = %q, want %q", got, "2")
+	}
+	if !strings.Contains(resp.Header.Get("Content-Disposition"), "notifications-client-1-20260301.csv") {
+		t.Errorf("Content-Disposition = %q", resp.Header.Get("Content-Disposition"))
+	}
+	if filter.ClientID != "client-1" || filter.Limit != 3 || len(filter.Statuses) != 1 || !filter.To.Equal(time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("filter = %+v, want client-1, limit+1, SENT, to April", filter)
+	}
+}
+
+// TestHandlers_ExportNotifications_LastPage tests that the last page has no next offset.
+func TestHandlers_ExportNotifications_LastPage(t *testing.T) {
+	var filter database.NotificationExportFilter
+	h := NewHandlersWithDeps(exportRepository(2, &filter), &mockPublisher{}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/notifications/export?client_id=client-1&from=2026-03-01T00:00:00Z&format=ndjson&limit=5&offset=10", nil)
+	w := httptest.NewRecorder()
+	h.ExportNotifications(w, req)
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+	if lines := strings.Split(strings.TrimSpace(string(body)), "\n"); len(lines) != 2 {
+		t.Errorf("ExportNotifications() returned %d NDJSON lines, want 2", len(lines))
+	}
+	if resp.Header.Get("Content-Type") != "application/x-ndjson" {
+		t.Errorf("Content-Type = %q", resp.Header.Get("Content-Type"))
+	}
+	if got := resp.Trailer.Get("X-Next-Offset"); got != "" {
+		t.Errorf("X-Next-Offset trailer = %q, want none on the last page", got)
+	}
+	if filter.Offset != 10 {
+		t.Errorf("filter.Offset = %d, want 10", filter.Offset)
+	}
+}
+
+// TestHandlers_ExportNotifications_Validation tests export parameter validation.
+func TestHandlers_ExportNotifications_Validation(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		repo       *mockRepository
+		wantStatus int
+	}{
+		{"missing client_id", "from=2026-03-01T00:00:00Z", &mockRepository{}, http.StatusBadRequest},
+		{"missing from", "client_id=c", &mockRepository{}, http.StatusBadRequest},
+		{"invalid from", "client_id=c&from=yesterday", &mockRepository{}, http.StatusBadRequest},
+		{"to before from", "client_id=c&from=2026-03-01T00:00:00Z&to=2026-02-01T00:00:00Z", &mockRepository{}, http.StatusBadRequest},
+		{"unknown format", "client_id=c&from=2026-03-01T00:00:00Z&format=xml", &mockRepository{}, http.StatusBadRequest},
+		{"unknown column", "client_id=c&from=2026-03-01T00:00:00Z&columns=password", &mockRepository{}, http.StatusBadRequest},
+		{"unknown status", "client_id=c&from=2026-03-01T00:00:00Z&status=BOGUS", &mockRepository{}, http.StatusBadRequest},
+		{"limit too large", "client_id=c&from=2026-03-01T00:00:00Z&limit=1000000", &mockRepository{}, http.StatusBadRequest},
+		{"database error", "client_id=c&from=2026-03-01T00:00:00Z", &mockRepository{
+			ExportNotificationsFn: func(ctx context.Context, f database.NotificationExportFilter, fn func(*database.Notification) error) error {
+				return errors.New("connection refused")
+			},
+		}, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandlersWithDeps(tt.repo, &mockPublisher{}, nil)
+			w := httptest.NewRecorder()
+			h.ExportNotifications(w, httptest.NewRequest(http.MethodGet, "/api/v1/notifications/export?"+tt.query, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("ExportNotifications() status = %v, want %v: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+}
+
+// TestHandlers_ExportJob tests starting, polling and downloading an export job.
+func TestHandlers_ExportJob(t *testing.T) {
+	var filter database.NotificationExportFilter
+	repo := exportRepository(2, &filter)
+	jobs, err := export.NewManager(repo, t.TempDir(), time.Minute)
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	h := NewHandlersWithDeps(repo, &mockPublisher{}, nil)
+	WithExportJobs(jobs)(h)
+
+	w := httptest.NewRecorder()
+	h.StartExportJob(w, httptest.NewRequest(http.MethodPost, "/api/v1/notifications/export/jobs",
+		bytes.NewBufferString(`{"client_id":"client-1","from":"2026-01-01T00:00:00Z","format":"ndjson","columns":["notification_id"]}`)))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("StartExportJob() status = %v, want %v: %s", w.Code, http.StatusAccepted, w.Body.String())
+	}
+	var job export.Job
+	json.NewDecoder(w.Body).Decode(&job)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for job.Status == export.JobStatusRunning && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+		w = httptest.NewRecorder()
+		h.GetExportJob(w, httptest.NewRequest(http.MethodGet, "/api/v1/notifications/export/jobs?job_id="+job.JobID, nil))
+		json.NewDecoder(w.Body).Decode(&job)
+	}
+	if job.Status != export.JobStatusCompleted || job.Rows != 2 {
+		t.Fatalf("job = %+v, want completed with 2 rows", job)
+	}
+	if filter.Limit != 0 {
+		t.Errorf("job filter.Limit = %d, want unlimited", filter.Limit)
+	}
+
+	w = httptest.NewRecorder()
+	h.DownloadExportJob(w, httptest.NewRequest(http.MethodGet, "/api/v1/notifications/export/jobs/download?job_id="+job.JobID, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("DownloadExportJob() status = %v, want %v", w.Code, http.StatusOK)
+	}
+	if want := "{\"notification_id\":\"notif-a\"}\n{\"notification_id\":\"notif-b\"}\n"; w.Body.String() != want {
+		t.Errorf("DownloadExportJob() body = %q, want %q", w.Body.String(), want)
+	}
+
+	w = httptest.NewRecorder()
+	h.GetExportJob(w, httptest.NewRequest(http.MethodGet, "/api/v1/notifications/export/jobs?job_id=unknown", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("GetExportJob() unknown status = %v, want %v", w.Code, http.StatusNotFound)
+	}
+}
+
+// TestHandlers_ExportJob_NotConfigured tests that export jobs require a job manager.
+func TestHandlers_ExportJob_NotConfigured(t *testing.T) {
+	h := NewHandlersWithDeps(&mockRepository{}, &mockPublisher{}, nil)
+	w := httptest.NewRecorder()
+	h.StartExportJob(w, httptest.NewRequest(http.MethodPost, "/api/v1/notifications/export/jobs",
+		bytes.NewBufferString(`{"client_id":"client-1","from":"2026-01-01T00:00:00Z"}`)))
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("StartExportJob() status = %v, want %v", w.Code, http.StatusNotImplemented)
+	}
+}
@@ -0,0 +1,415 @@
+// Package handlers provides HTTP handlers for the rule-service API.
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// maxRuleSnapshotPinReasonLength bounds the rule snapshot pin reason.
+const maxRuleSnapshotPinReasonLength = 500
+
+// RuleSnapshotsResponse lists the rule snapshot versions rule-updater keeps.
+type RuleSnapshotsResponse struct {
+	CurrentVersion int64                        `json:"current_version"`
+	Pin            *shared.RuleSnapshotPin      `json:"pin,omitempty"`
+	Versions       []shared.RuleSnapshotVersion `json:"versions"` // newest first
+}
+
+// RuleSnapshotPinRequest pins the evaluator to a kept snapshot version.
+type RuleSnapshotPinRequest struct {
+	Version int64  `json:"version"`
+	Reason  string `json:"reason"`
+	Actor   string `json:"actor,omitempty"`
+}
+
+func (req *RuleSnapshotPinRequest) validate(v *shared.Validator) {
+	v.Check(req.Version > 0, "version", "version must be positive")
+	if v.Required("reason", req.Reason) {
+		v.MaxLength("reason", req.Reason, maxRuleSnapshotPinReasonLength)
+	}
+}
+
+// RuleSnapshotReloadResponse reports a forced evaluator reload request.
+type RuleSnapshotReloadResponse struct {
+	ReloadGeneration int64                   `json:"reload_generation"`
+	Pin              *shared.RuleSnapshotPin `json:"pin,omitempty"` // what the evaluator reloads, if pinned
+}
+
+// SnapshotRule is a rule as the evaluator matches it in a snapshot: fields are normalized.
+type SnapshotRule struct {
+	RuleID         string              `json:"rule_id"`
+	ClientID       string              `json:"client_id"`
+	Severity       string              `json:"severity"`
+	Source         string              `json:"source"`
+	Name           string              `json:"name"`
+	ExcludeSources []string            `json:"exclude_sources,omitempty"`
+	ExcludeNames   []string            `json:"exclude_names,omitempty"`
+	Conditions     []SnapshotCondition `json:"conditions,omitempty"`
+}
+
+// SnapshotCondition is a context condition of a SnapshotRule.
+type SnapshotCondition struct {
+	Key   string `json:"key"`
+	Op    string `json:"op"`
+	Value string `json:"value"`
+}
+
+// SnapshotRuleChange is a rule whose matching changed between two snapshot versions.
+type SnapshotRuleChange struct {
+	RuleID string        `json:"rule_id"`
+	Before *SnapshotRule `json:"before"`
+	After  *SnapshotRule `json:"after"`
+}
+
+// RuleSnapshotDiff lists the rules added, removed and changed between two snapshot versions,
+// by rule_id.
+type RuleSnapshotDiff struct {
+	From              int64                `json:"from"`
+	To                int64                `json:"to"`
+	FromNormalization string               `json:"from_normalization,omitempty"`
+	ToNormalization   string               `json:"to_normalization,omitempty"`
+	Added             []*SnapshotRule      `json:"added"`
+	Removed           []*SnapshotRule      `json:"removed"`
+	Changed           []SnapshotRuleChange `json:"changed"`
+	Unchanged         int                  `json:"unchanged"`
+}
+
+// ruleSnapshot is the part of a snapshot written by rule-updater that a diff reads.
+type ruleSnapshot struct {
+	Normalization string           `json:"normalization"`
+	BySeverity    map[string][]int `json:"by_severity"`
+	BySource      map[string][]int `json:"by_source"`
+	ByName        map[string][]int `json:"by_name"`
+	Rules         map[int]struct {
+		RuleID         string              `json:"rule_id"`
+		ClientID       string              `json:"client_id"`
+		ExcludeSources []string            `json:"exclude_sources"`
+		ExcludeNames   []string            `json:"exclude_names"`
+		Conditions     []SnapshotCondition `json:"conditions"`
+	} `json:"rules"`
+}
+
+// ListRuleSnapshots returns the kept rule snapshot versions, the current one and the pin.
+// GET /api/v1/admin/rule-snapshots
+func (h *Handlers) ListRuleSnapshots(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet) || !h.requireRuleSnapshots(w, r) {
+		return
+	}
+
+	current, err := h.ruleSnapshots.GetCurrentRuleSnapshotVersion(r.Context())
+	if err != nil {
+		slog.Error("Failed to read rule snapshot version", "error", err)
+		http.Error(w, "Failed to read rule snapshot version", http.StatusInternalServerError)
+		return
+	}
+	pin, err := h.ruleSnapshots.GetRuleSnapshotPin(r.Context())
+	if err != nil {
+		slog.Error("Failed to read rule snapshot pin", "error", err)
+		http.Error(w, "Failed to read rule snapshot pin", http.StatusInternalServerError)
+		return
+	}
+	versions, err := h.ruleSnapshots.ListRuleSnapshotVersions(r.Context())
+	if err != nil {
+		slog.Error("Failed to list rule snapshot versions", "error", err)
+		http.Error(w, "Failed to list rule snapshot versions", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, RuleSnapshotsResponse{CurrentVersion: current, Pin: pin, Versions: versions})
+}
+
+// DiffRuleSnapshots compares two kept snapshot versions. to defaults to the newest kept one.
+// GET /api/v1/admin/rule-snapshots/diff?from={version}&to={version}
+func (h *Handlers) DiffRuleSnapshots(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet) || !h.requireRuleSnapshots(w, r) {
+		return
+	}
+
+	fromStr, ok := requireQueryParam(w, r, "from")
+	if !ok {
+		return
+	}
+	from, err := parseSnapshotVersion(fromStr)
+	if err != nil {
+		http.Error(w, "from: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	var to int64
+	if toStr := r.URL.Query().Get("to"); toStr != "" {
+		if to, err = parseSnapshotVersion(toStr); err != nil {
+			http.Error(w, "to: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else {
+		versions, err := h.ruleSnapshots.ListRuleSnapshotVersions(r.Context())
+		if err != nil {
+			slog.Error("Failed to list rule snapshot versions", "error", err)
+			http.Error(w, "Failed to list rule snapshot versions", http.StatusInternalServerError)
+			return
+		}
+		if len(versions) == 0 {
+			http.Error(w, "No rule snapshot versions are kept", http.StatusNotFound)
+			return
+		}
+		to = versions[0].Version
+	}
+
+	before, ok := h.loadRuleSnapshot(w, r, from)
+	if !ok {
+		return
+	}
+	after, ok := h.loadRuleSnapshot(w, r, to)
+	if !ok {
+		return
+	}
+
+	diff := diffRuleSnapshots(before, after)
+	diff.From, diff.To = from, to
+	writeJSON(w, http.StatusOK, diff)
+}
+
+// PinRuleSnapshot pins the evaluator to a kept snapshot version until UnpinRuleSnapshot is
+// called; rule changes are not applied meanwhile.
+// POST /api/v1/admin/rule-snapshots/pin
+func (h *Handlers) PinRuleSnapshot(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost) || !h.requireRuleSnapshots(w, r) {
+		return
+	}
+
+	var req RuleSnapshotPinRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if !validateRequest(w, &req) {
+		return
+	}
+
+	// Only kept versions can be pinned; loading it also checks it is a valid snapshot
+	if _, ok := h.loadRuleSnapshot(w, r, req.Version); !ok {
+		return
+	}
+
+	pin := shared.RuleSnapshotPin{Version: req.Version, Reason: req.Reason, Actor: req.Actor}
+	if err := h.ruleSnapshots.SetRuleSnapshotPin(r.Context(), pin); err != nil {
+		slog.Error("Failed to pin rule snapshot", "error", err)
+		http.Error(w, "Failed to pin rule snapshot", http.StatusInternalServerError)
+		return
+	}
+
+	slog.Warn("Rule snapshot pinned",
+		"version", req.Version,
+		"reason", req.Reason,
+		"actor", req.Actor,
+		"remote_addr", r.RemoteAddr,
+	)
+	h.metrics.IncrementCustom("rule_snapshot_pins")
+
+	stored, err := h.ruleSnapshots.GetRuleSnapshotPin(r.Context())
+	if err != nil {
+		slog.Error("Failed to read rule snapshot pin", "error", err)
+		http.Error(w, "Failed to read rule snapshot pin", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, stored)
+}
+
+// UnpinRuleSnapshot clears the pin, so the evaluator goes back to the current snapshot.
+// POST /api/v1/admin/rule-snapshots/unpin
+func (h *Handlers) UnpinRuleSnapshot(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost) || !h.requireRuleSnapshots(w, r) {
+		return
+	}
+
+	var req ResumeRequest
+	if r.ContentLength != 0 && !decodeJSON(w, r, &req) {
+		return
+	}
+
+	current, err := h.ruleSnapshots.GetRuleSnapshotPin(r.Context())
+	if err != nil {
+		slog.Error("Failed to read rule snapshot pin", "error", err)
+		http.Error(w, "Failed to read rule snapshot pin", http.StatusInternalServerError)
+		return
+	}
+	if current == nil {
+		http.Error(w, "Rule snapshot is not pinned", http.StatusConflict)
+		return
+	}
+
+	if err := h.ruleSnapshots.ClearRuleSnapshotPin(r.Context()); err != nil {
+		slog.Error("Failed to unpin rule snapshot", "error", err)
+		http.Error(w, "Failed to unpin rule snapshot", http.StatusInternalServerError)
+		return
+	}
+
+	slog.Warn("Rule snapshot unpinned",
+		"actor", req.Actor,
+		"pinned_version", current.Version,
+		"remote_addr", r.RemoteAddr,
+	)
+	h.metrics.IncrementCustom("rule_snapshot_unpins")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ReloadRuleSnapshots makes every evaluator rebuild its indexes from the snapshot it matches
+// against (the pinned version, if pinned), even if the version did not change.
+// POST /api/v1/admin/rule-snapshots/reload
+func (h *Handlers) ReloadRuleSnapshots(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost) || !h.requireRuleSnapshots(w, r) {
+		return
+	}
+
+	var req ResumeRequest
+	if r.ContentLength != 0 && !decodeJSON(w, r, &req) {
+		return
+	}
+
+	generation, err := h.ruleSnapshots.RequestRuleSnapshotReload(r.Context())
+	if err != nil {
+		slog.Error("Failed to request rule snapshot reload", "error", err)
+		http.Error(w, "Failed to request rule snapshot reload", http.StatusInternalServerError)
+		return
+	}
+	pin, err := h.ruleSnapshots.GetRuleSnapshotPin(r.Context())
+	if err != nil {
+		slog.Error("Failed to read rule snapshot pin", "error", err)
+		http.Error(w, "Failed to read rule snapshot pin", http.StatusInternalServerError)
+		return
+	}
+
+	slog.Warn("Rule snapshot reload requested",
+		"reload_generation", generation,
+		"actor", req.Actor,
+		"remote_addr", r.RemoteAddr,
+	)
+	h.metrics.IncrementCustom("rule_snapshot_reloads")
+	writeJSON(w, http.StatusAccepted, RuleSnapshotReloadResponse{ReloadGeneration: generation, Pin: pin})
+}
+
+// requireRuleSnapshots checks that the rule snapshot endpoints are configured and the request
+// carries the admin token, if one is set. Writes an error response otherwise.
+func (h *Handlers) requireRuleSnapshots(w http.ResponseWriter, r *http.Request) bool {
+	if h.ruleSnapshots == nil {
+		http.Error(w, "Rule snapshot history is not configured", http.StatusNotImplemented)
+		return false
+	}
+	return h.requireAdminToken(w, r)
+}
+
+// loadRuleSnapshot loads and decodes a kept snapshot version. Writes an error response and
+// returns false if it is not kept or cannot be decoded.
+func (h *Handlers) loadRuleSnapshot(w http.ResponseWriter, r *http.Request, version int64) (*ruleSnapshot, bool) {
+	data, err := h.ruleSnapshots.GetRuleSnapshot(r.Context(), version)
+	if errors.Is(err, shared.ErrRuleSnapshotNotFound) {
+		http.Error(w, fmt.Sprintf("Rule snapshot version %d is not kept", version), http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		slog.Error("Failed to read rule snapshot", "version", version, "error", err)
+		http.Error(w, "Failed to read rule snapshot", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	var snap ruleSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		slog.Error("Failed to decode rule snapshot", "version", version, "error", err)
+		http.Error(w, "Failed to decode rule snapshot", http.StatusInternalServerError)
+		return nil, false
+	}
+	return &snap, true
+}
+
+// parseSnapshotVersion parses a positive snapshot version.
+func parseSnapshotVersion(s string) (int64, error) {
+	version, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, fmt.Errorf("invalid snapshot version %q", s)
+	}
+	return version, nil
+}
+
+// rulesByID rebuilds the snapshot's rules from its inverted indexes, keyed by rule_id.
+// Index entries left by removed rules are ignored.
+func (snap *ruleSnapshot) rulesByID() map[string]*SnapshotRule {
+	rules := make(map[string]*SnapshotRule, len(snap.Rules))
+	byInt := make(map[int]*SnapshotRule, len(snap.Rules))
+	for ruleInt, info := range snap.Rules {
+		rule := &SnapshotRule{
+			RuleID:         info.RuleID,
+			ClientID:       info.ClientID,
+			ExcludeSources: info.ExcludeSources,
+			ExcludeNames:   info.ExcludeNames,
+			Conditions:     info.Conditions,
+		}
+		rules[info.RuleID] = rule
+		byInt[ruleInt] = rule
+	}
+	for severity, ruleInts := range snap.BySeverity {
+		for _, ruleInt := range ruleInts {
+			if rule, ok := byInt[ruleInt]; ok {
+				rule.Severity = severity
+			}
+		}
+	}
+	for source, ruleInts := range snap.BySource {
+		for _, ruleInt := range ruleInts {
+			if rule, ok := byInt[ruleInt]; ok {
+				rule.Source = source
+			}
+		}
+	}
+	for name, ruleInts := range snap.ByName {
+		for _, ruleInt := range ruleInts {
+			if rule, ok := byInt[ruleInt]; ok {
+				rule.Name = name
+			}
+		}
+	}
+	return rules
+}
+
+// diffRuleSnapshots compares two snapshots by rule_id, ignoring how rules are numbered, so a
+// compaction alone shows no changes. Lists are sorted by rule_id.
+func diffRuleSnapshots(before, after *ruleSnapshot) *RuleSnapshotDiff {
+	diff := &RuleSnapshotDiff{
+		Added:   []*SnapshotRule{},
+		Removed: []*SnapshotRule{},
+		Changed: []SnapshotRuleChange{},
+	}
+	if before.Normalization != after.Normalization {
+		diff.FromNormalization, diff.ToNormalization = before.Normalization, after.Normalization
+	}
+
+	beforeRules, afterRules := before.rulesByID(), after.rulesByID()
+	for ruleID, rule := range afterRules {
+		old, ok := beforeRules[ruleID]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, rule)
+		case reflect.DeepEqual(old, rule):
+			diff.Unchanged++
+		default:
+			diff.Changed = append(diff.Changed, SnapshotRuleChange{RuleID: ruleID, Before: old, After: rule})
+		}
+	}
+	for ruleID, rule := range beforeRules {
+		if _, ok := afterRules[ruleID]; !ok {
+			diff.Removed = append(diff.Removed, rule)
+		}
+	}
+
+	sort.Slice(diff.Added, func(i, j int) bool { return diff.Added[i].RuleID < diff.Added[j].RuleID })
+	sort.Slice(diff.Removed, func(i, j int) bool { return diff.Removed[i].RuleID < diff.Removed[j].RuleID })
+	sort.Slice(diff.Changed, func(i, j int) bool { return diff.Changed[i].RuleID < diff.Changed[j].RuleID })
+	return diff
+}
@@ -0,0 +1,259 @@
+// Package handlers provides tests for the rule snapshot history admin endpoints.
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// fakeRuleSnapshotStore is an in-memory RuleSnapshotStore.
+type fakeRuleSnapshotStore struct {
+	snapshots map[int64]string
+	current   int64
+	pin       *shared.RuleSnapshotPin
+	reloads   int64
+}
+
+func (f *fakeRuleSnapshotStore) ListRuleSnapshotVersions(ctx context.Context) ([]shared.RuleSnapshotVersion, error) {
+	var versions []shared.RuleSnapshotVersion
+	for v := f.current; v > 0; v-- {
+		if data, ok := f.snapshots[v]; ok {
+			versions = append(versions, shared.RuleSnapshotVersion{Version: v, Bytes: int64(len(data))})
+		}
+	}
+	return versions, nil
+}
+
+func (f *fakeRuleSnapshotStore) GetRuleSnapshot(ctx context.Context, version int64) ([]byte, error) {
+	data, ok := f.snapshots[version]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", shared.ErrRuleSnapshotNotFound, version)
+	}
+	return []byte(data), nil
+}
+
+func (f *fakeRuleSnapshotStore) GetCurrentRuleSnapshotVersion(ctx context.Context) (int64, error) {
+	return f.current, nil
+}
+
+func (f *fakeRuleSnapshotStore) GetRuleSnapshotPin(ctx context.Context) (*shared.RuleSnapshotPin, error) {
+	return f.pin, nil
+}
+
+func (f *fakeRuleSnapshotStore) SetRuleSnapshotPin(ctx context.Context, pin shared.RuleSnapshotPin) error {
+	now := time.Now()
+	pin.PinnedAt = &now
+	f.pin = &pin
+	return nil
+}
+
+func (f *fakeRuleSnapshotStore) ClearRuleSnapshotPin(ctx context.Context) error {
+	f.pin = nil
+	return nil
+}
+
+func (f *fakeRuleSnapshotStore) RequestRuleSnapshotReload(ctx context.Context) (int64, error) {
+	f.reloads++
+	return f.reloads, nil
+}
+
+// newFakeRuleSnapshotStore keeps versions 1 to 3: version 2 adds rule-b and changes rule-a's
+// source, version 3 compacts version 2 and removes rule-c.
+func newFakeRuleSnapshotStore() *fakeRuleSnapshotStore {
+	return &fakeRuleSnapshotStore{
+		current: 3,
+		snapshots: map[int64]string{
+			1: `{"version":1,"by_severity":{"high":[1,2]},"by_source":{"api":[1],"db":[2]},"by_name":{"cpu":[1,2]},
+				"rules":{"1":{"rule_id":"rule-a","client_id":"client-1"},"2":{"rule_id":"rule-c","client_id":"client-2"}}}`,
+			2: `{"version":2,"by_severity":{"high":[2,3,1]},"by_source":{"web":[1],"db":[2,3]},"by_name":{"cpu":[1,2,3]},
+				"rules":{"1":{"rule_id":"rule-a","client_id":"client-1"},"2":{"rule_id":"rule-c","client_id":"client-2"},
+				"3":{"rule_id":"rule-b","client_id":"client-1","exclude_names":["disk"]}}}`,
+			3: `{"version":3,"by_severity":{"high":[1,2]},"by_source":{"web":[1],"db":[2]},"by_name":{"cpu":[1,2]},
+				"rules":{"1":{"rule_id":"rule-a","client_id":"client-1"},"2":{"rule_id":"rule-b","client_id":"client-1","exclude_names":["disk"]}}}`,
+		},
+	}
+}
+
+func newRuleSnapshotHandlers(store RuleSnapshotStore, adminToken string) *Handlers {
+	h := NewHandlersWithDeps(&mockRepository{}, &mockPublisher{}, nil)
+	WithRuleSnapshots(store, adminToken)(h)
+	return h
+}
+
+// TestHandlers_ListRuleSnapshots tests listing the kept versions.
+func TestHandlers_ListRuleSnapshots(t *testing.T) {
+	h := newRuleSnapshotHandlers(newFakeRuleSnapshotStore(), "")
+
+	w := httptest.NewRecorder()
+	h.ListRuleSnapshots(w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/rule-snapshots", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("ListRuleSnapshots() status = %v, want %v: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var resp RuleSnapshotsResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.CurrentVersion != 3 || resp.Pin != nil || len(resp.Versions) != 3 || resp.Versions[0].Version != 3 {
+		t.Errorf("ListRuleSnapshots() = %+v, want versions 3, 2, 1 and no pin", resp)
+	}
+}
+
+// TestHandlers_DiffRuleSnapshots tests diffing kept versions by rule_id.
+func TestHandlers_DiffRuleSnapshots(t *testing.T) {
+	h := newRuleSnapshotHandlers(newFakeRuleSnapshotStore(), "")
+
+	w := httptest.NewRecorder()
+	h.DiffRuleSnapshots(w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/rule-snapshots/diff?from=1&to=2", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("DiffRuleSnapshots() status = %v, want %v: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var diff RuleSnapshotDiff
+	json.NewDecoder(w.Body).Decode(&diff)
+	if len(diff.Added) != 1 || diff.Added[0].RuleID != "rule-b" || diff.Added[0].ExcludeNames[0] != "disk" {
+		t.Errorf("added = %+v, want rule-b", diff.Added)
+	}
+	if len(diff.Changed) != 1 || diff.Changed[0].Before.Source != "api" || diff.Changed[0].After.Source != "web" {
+		t.Errorf("changed = %+v, want rule-a's source api -> web", diff.Changed)
+	}
+	if len(diff.Removed) != 0 || diff.Unchanged != 1 {
+		t.Errorf("removed = %+v, unchanged = %d, want none removed and rule-c unchanged", diff.Removed, diff.Unchanged)
+	}
+
+	// to defaults to the newest version; renumbering alone is no change
+	w = httptest.NewRecorder()
+	h.DiffRuleSnapshots(w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/rule-snapshots/diff?from=2", nil))
+	diff = RuleSnapshotDiff{}
+	json.NewDecoder(w.Body).Decode(&diff)
+	if diff.To != 3 || len(diff.Removed) != 1 || diff.Removed[0].RuleID != "rule-c" || len(diff.Added) != 0 || len(diff.Changed) != 0 || diff.Unchanged != 2 {
+		t.Errorf("DiffRuleSnapshots(from=2) = %+v, want only rule-c removed in version 3", diff)
+	}
+}
+
+// TestHandlers_DiffRuleSnapshots_Errors tests invalid and missing versions.
+func TestHandlers_DiffRuleSnapshots_Errors(t *testing.T) {
+	h := newRuleSnapshotHandlers(newFakeRuleSnapshotStore(), "")
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"", http.StatusBadRequest},
+		{"?from=abc", http.StatusBadRequest},
+		{"?from=1&to=0", http.StatusBadRequest},
+		{"?from=7", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		h.DiffRuleSnapshots(w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/rule-snapshots/diff"+tt.query, nil))
+		if w.Code != tt.want {
+			t.Errorf("DiffRuleSnapshots(%q) status = %v, want %v", tt.query, w.Code, tt.want)
+		}
+	}
+}
+
+// TestHandlers_PinRuleSnapshot tests pinning and unpinning the evaluator.
+func TestHandlers_PinRuleSnapshot(t *testing.T) {
+	store := newFakeRuleSnapshotStore()
+	h := newRuleSnapshotHandlers(store, "")
+
+	w := httptest.NewRecorder()
+	h.PinRuleSnapshot(w, httptest.NewRequest(http.MethodPost, "/api/v1/admin/rule-snapshots/pin",
+		bytes.NewBufferString(`{"version":9,"reason":"bad rollout"}`)))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("PinRuleSnapshot(9) status = %v, want %v (version not kept)", w.Code, http.StatusNotFound)
+	}
+
+	w = httptest.NewRecorder()
+	h.PinRuleSnapshot(w, httptest.NewRequest(http.MethodPost, "/api/v1/admin/rule-snapshots/pin",
+		bytes.NewBufferString(`{"version":2}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("PinRuleSnapshot() without reason status = %v, want %v", w.Code, http.StatusBadRequest)
+	}
+
+	w = httptest.NewRecorder()
+	h.PinRuleSnapshot(w, httptest.NewRequest(http.MethodPost, "/api/v1/admin/rule-snapshots/pin",
+		bytes.NewBufferString(`{"version":2,"reason":"rule-c removed by mistake","actor":"oncall"}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("PinRuleSnapshot() status = %v, want %v: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	if store.pin == nil || store.pin.Version != 2 || store.pin.Actor != "oncall" {
+		t.Errorf("stored pin = %+v, want version 2 by oncall", store.pin)
+	}
+
+	w = httptest.NewRecorder()
+	h.UnpinRuleSnapshot(w, httptest.NewRequest(http.MethodPost, "/api/v1/admin/rule-snapshots/unpin", nil))
+	if w.Code != http.StatusNoContent || store.pin != nil {
+		t.Fatalf("UnpinRuleSnapshot() status = %v, pin = %+v, want 204 and cleared", w.Code, store.pin)
+	}
+
+	w = httptest.NewRecorder()
+	h.UnpinRuleSnapshot(w, httptest.NewRequest(http.MethodPost, "/api/v1/admin/rule-snapshots/unpin", nil))
+	if w.Code != http.StatusConflict {
+		t.Errorf("second UnpinRuleSnapshot() status = %v, want %v", w.Code, http.StatusConflict)
+	}
+}
+
+// TestHandlers_ReloadRuleSnapshots tests requesting a forced evaluator reload.
+func TestHandlers_ReloadRuleSnapshots(t *testing.T) {
+	store := newFakeRuleSnapshotStore()
+	store.pin = &shared.RuleSnapshotPin{Version: 2, Reason: "bad import"}
+	h := newRuleSnapshotHandlers(store, "")
+
+	w := httptest.NewRecorder()
+	h.ReloadRuleSnapshots(w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/rule-snapshots/reload", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("ReloadRuleSnapshots() GET status = %v, want %v", w.Code, http.StatusMethodNotAllowed)
+	}
+
+	w = httptest.NewRecorder()
+	h.ReloadRuleSnapshots(w, httptest.NewRequest(http.MethodPost, "/api/v1/admin/rule-snapshots/reload",
+		bytes.NewBufferString(`{"actor":"oncall"}`)))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("ReloadRuleSnapshots() status = %v, want %v: %s", w.Code, http.StatusAccepted, w.Body.String())
+	}
+	var resp RuleSnapshotReloadResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.ReloadGeneration != 1 || resp.Pin == nil || resp.Pin.Version != 2 {
+		t.Errorf("response = %+v, want generation 1 reloading pinned version 2", resp)
+	}
+
+	w = httptest.NewRecorder()
+	h.ReloadRuleSnapshots(w, httptest.NewRequest(http.MethodPost, "/api/v1/admin/rule-snapshots/reload", nil))
+	if w.Code != http.StatusAccepted || store.reloads != 2 {
+		t.Errorf("second ReloadRuleSnapshots() status = %v, reloads = %d, want 202 and 2", w.Code, store.reloads)
+	}
+}
+
+// TestHandlers_RuleSnapshots_Admin tests the admin token and the unconfigured store.
+func TestHandlers_RuleSnapshots_Admin(t *testing.T) {
+	h := newRuleSnapshotHandlers(newFakeRuleSnapshotStore(), "secret")
+
+	w := httptest.NewRecorder()
+	h.ListRuleSnapshots(w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/rule-snapshots", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("ListRuleSnapshots() without token status = %v, want %v", w.Code, http.StatusUnauthorized)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/rule-snapshots", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	w = httptest.NewRecorder()
+	h.ListRuleSnapshots(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("ListRuleSnapshots() with token status = %v, want %v", w.Code, http.StatusOK)
+	}
+
+	h = NewHandlersWithDeps(&mockRepository{}, &mockPublisher{}, nil)
+	w = httptest.NewRecorder()
+	h.ListRuleSnapshots(w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/rule-snapshots", nil))
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("ListRuleSnapshots() unconfigured status = %v, want %v", w.Code, http.StatusNotImplemented)
+	}
+}
@@ -4,23 +4,79 @@ package handlers
 import (
 	"net/http"
 
+	"rule-service/internal/database"
 	"rule-service/internal/events"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
 )
 
 // CreateRuleRequest represents a request to create a rule.
 type CreateRuleRequest struct {
-	ClientID string `json:"client_id"`
-	Severity string `json:"severity"`
-	Source   string `json:"source"`
-	Name     string `json:"name"`
+	ClientID       string   `json:"client_id"`
+	Severity       string   `json:"severity"`
+	Source         string   `json:"source"`
+	Name           string   `json:"name"`
+	Description    string   `json:"description,omitempty"`
+	ExcludeSources []string `json:"exclude_sources,omitempty"` // Only with source "*"
+	ExcludeNames   []string `json:"exclude_names,omitempty"`   // Only with name "*"
+
+	Conditions database.RuleConditions `json:"conditions,omitempty"` // Alert context conditions, all of which must hold
+}
+
+func (req *CreateRuleRequest) validate(v *shared.Validator) {
+	v.Required("client_id", req.ClientID)
+	validateRuleFields(v, "", req.Severity, req.Source, req.Name)
+	validateRuleDescription(v, "", req.Description)
+	validateRuleExclusions(v, "", req.Source, req.Name, req.exclusions())
+	validateRuleConditions(v, "", req.Conditions)
+}
+
+// exclusions returns the exclusion lists of the rule.
+func (req *CreateRuleRequest) exclusions() database.RuleExclusions {
+	return database.RuleExclusions{Sources: req.ExcludeSources, Names: req.ExcludeNames}
 }
 
 // UpdateRuleRequest represents a request to update a rule.
 type UpdateRuleRequest struct {
-	Severity string `json:"severity"`
-	Source   string `json:"source"`
-	Name     string `json:"name"`
-	Version  int    `json:"version"` // Optimistic locking version
+	Severity    string  `json:"severity"`
+	Source      string  `json:"source"`
+	Name        string  `json:"name"`
+	Description *string `json:"description,omitempty"` // Omitted keeps the current description
+	// Omitting both exclusion lists keeps the current ones; giving either replaces both.
+	// Exclusions of a field that is no longer a wildcard are always dropped.
+	ExcludeSources *[]string `json:"exclude_sources,omitempty"`
+	ExcludeNames   *[]string `json:"exclude_names,omitempty"`
+	// Omitted keeps the current context conditions; an empty list removes them.
+	Conditions *database.RuleConditions `json:"conditions,omitempty"`
+	Version    int                      `json:"version"` // Optimistic locking version
+}
+
+func (req *UpdateRuleRequest) validate(v *shared.Validator) {
+	validateRuleFields(v, "", req.Severity, req.Source, req.Name)
+	if req.Description != nil {
+		validateRuleDescription(v, "", *req.Description)
+	}
+	if exclusions := req.exclusions(); exclusions != nil {
+		validateRuleExclusions(v, "", req.Source, req.Name, *exclusions)
+	}
+	if req.Conditions != nil {
+		validateRuleConditions(v, "", *req.Conditions)
+	}
+}
+
+// exclusions returns the exclusion lists to store, or nil to keep the current ones.
+func (req *UpdateRuleRequest) exclusions() *database.RuleExclusions {
+	if req.ExcludeSources == nil && req.ExcludeNames == nil {
+		return nil
+	}
+	var exclusions database.RuleExclusions
+	if req.ExcludeSources != nil {
+		exclusions.Sources = *req.ExcludeSources
+	}
+	if req.ExcludeNames != nil {
+		exclusions.Names = *req.ExcludeNames
+	}
+	return &exclusions
 }
 
 // ToggleRuleEnabledRequest represents a request to toggle rule enabled status.
@@ -40,21 +96,15 @@ func (h *Handlers) CreateRule(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.ClientID == "" {
-		http.Error(w, "client_id is required", http.StatusBadRequest)
-		return
-	}
-
-	if !validateRuleFields(w, req.Severity, req.Source, req.Name) {
+	if !validateRequest(w, &req) {
 		return
 	}
-
-	if !validateRuleValues(w, req.Severity, req.Source, req.Name) {
+	if !authorizeClient(w, r, req.ClientID) {
 		return
 	}
 
 	ctx := r.Context()
-	rule, err := h.db.CreateRule(ctx, req.ClientID, req.Severity, req.Source, req.Name)
+	rule, err := h.db.CreateRule(ctx, req.ClientID, req.Severity, req.Source, req.Name, req.Description, req.exclusions(), req.Conditions)
 	if err != nil {
 		if handleDBError(w, err, "rule", req.ClientID) {
 			return
@@ -88,26 +138,32 @@ func (h *Handlers) GetRule(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to get rule: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if !authorizeOwner(w, r, "rule", rule.ClientID) {
+		return
+	}
 
 	writeJSON(w, http.StatusOK, rule)
 }
 
-// ListRules retrieves rules with pagination, optionally filtered by client_id.
-// Query params: client_id, limit (default 50, max 200), offset (default 0)
+// ListRules retrieves rules with pagination and optional filters.
+// Query params: client_id, enabled (true/false), severity, source, name (substring),
+// updated_since (RFC 3339), limit (default 50, max 200), offset (default 0)
 func (h *Handlers) ListRules(w http.ResponseWriter, r *http.Request) {
 	if !requireMethod(w, r, http.MethodGet) {
 		return
 	}
 
-	clientID := r.URL.Query().Get("client_id")
-	var clientIDPtr *string
-	if clientID != "" {
-		clientIDPtr = &clientID
+	filter, ok := parseRuleFilter(w, r)
+	if !ok {
+		return
+	}
+	if filter.ClientID, ok = scopeClientFilter(w, r, filter.ClientID); !ok {
+		return
 	}
 
 	p := parsePagination(r)
 	ctx := r.Context()
-	result, err := h.db.ListRules(ctx, clientIDPtr, p.Limit, p.Offset)
+	result, err := h.db.ListRules(ctx, filter, p.Limit, p.Offset)
 	if err != nil {
 		if handleDBError(w, err, "rule", "") {
 			return
@@ -129,22 +185,21 @@ func (h *Handlers) UpdateRule(w http.ResponseWriter, r *http.Request) {
 	if !ok {
 		return
 	}
-
-	var req UpdateRuleRequest
-	if !decodeJSON(w, r, &req) {
+	if !h.authorizeRule(w, r, "rule", ruleID) {
 		return
 	}
 
-	if !validateRuleFields(w, req.Severity, req.Source, req.Name) {
+	var req UpdateRuleRequest
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
-	if !validateRuleValues(w, req.Severity, req.Source, req.Name) {
+	if !validateRequest(w, &req) {
 		return
 	}
 
 	ctx := r.Context()
-	rule, err := h.db.UpdateRule(ctx, ruleID, req.Severity, req.Source, req.Name, req.Version)
+	rule, err := h.db.UpdateRule(ctx, ruleID, req.Severity, req.Source, req.Name, req.Description, req.exclusions(), req.Conditions, req.Version)
 	if err != nil {
 		if handleDBError(w, err, "rule", ruleID) {
 			return
@@ -168,6 +223,9 @@ func (h *Handlers) ToggleRuleEnabled(w http.ResponseWriter, r *http.Request) {
 	if !ok {
 		return
 	}
+	if !h.authorizeRule(w, r, "rule", ruleID) {
+		return
+	}
 
 	var req ToggleRuleEnabledRequest
 	if !decodeJSON(w, r, &req) {
@@ -216,6 +274,9 @@ func (h *Handlers) DeleteRule(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to get rule for deletion: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if !authorizeOwner(w, r, "rule", rule.ClientID) {
+		return
+	}
 
 	// Delete the rule
 	if err := h.db.DeleteRule(ctx, ruleID); err != nil {
@@ -0,0 +1,127 @@
+// Package handlers provides HTTP handlers for the rule-service API.
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"rule-service/internal/database"
+	"rule-service/internal/events"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// BulkToggleRulesRequest enables or disables a set of rules atomically. The rules are the ones
+// in rule_ids, if given, that also match filter; at least rule_ids or filter.client_id is required.
+type BulkToggleRulesRequest struct {
+	RuleIDs  []string        `json:"rule_ids,omitempty"`
+	Filter   *BulkRuleFilter `json:"filter,omitempty"`
+	Versions map[string]int  `json:"versions,omitempty"` // optional expected version per rule_id
+	Enabled  *bool           `json:"enabled"`
+}
+
+// BulkRuleFilter selects rules by their criteria. Empty fields are not filtered on.
+type BulkRuleFilter struct {
+	ClientID string `json:"client_id,omitempty"`
+	Severity string `json:"severity,omitempty"`
+	Source   string `json:"source,omitempty"`
+	Name     string `json:"name,omitempty"` // case-insensitive substring
+}
+
+// BulkToggleRules enables or disables the selected rules in one transaction and publishes
+// their rule.changed events in a single Kafka write. Nothing is changed if a rule ID is unknown
+// or a given version does not match.
+// POST /api/v1/rules/bulk-toggle
+func (h *Handlers) BulkToggleRules(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	var req BulkToggleRulesRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if !validateRequest(w, &req) {
+		return
+	}
+	sel := bulkRuleSelection(&req)
+	var ok bool
+	if sel.Filter.ClientID, ok = scopeClientFilter(w, r, sel.Filter.ClientID); !ok {
+		return
+	}
+
+	ctx := r.Context()
+	result, err := h.db.BulkSetRulesEnabled(ctx, sel, *req.Enabled)
+	if err != nil {
+		if handleDBError(w, err, "rule", "") {
+			return
+		}
+		http.Error(w, "Failed to toggle rules: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Same actions as ToggleRuleEnabled: re-enabling is treated as update
+	action := events.ActionDisabled
+	if *req.Enabled {
+		action = events.ActionUpdated
+	}
+	h.publishRuleChangedEvents(ctx, result.Updated, action)
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (req *BulkToggleRulesRequest) validate(v *shared.Validator) {
+	v.Check(req.Enabled != nil, "enabled", "enabled is required")
+	if !v.Check(len(req.RuleIDs) <= database.MaxBulkToggleRules, "rule_ids", fmt.Sprintf("rule_ids must have at most %d entries", database.MaxBulkToggleRules)) {
+		return
+	}
+
+	seen := make(map[string]bool, len(req.RuleIDs))
+	for i, ruleID := range req.RuleIDs {
+		field := fmt.Sprintf("rule_ids[%d]", i)
+		if ruleID == "" {
+			v.Add(field, field+": rule ID is required")
+		} else if seen[ruleID] {
+			v.Addf(field, "%s: duplicate rule ID %s", field, ruleID)
+		}
+		seen[ruleID] = true
+	}
+	if len(req.RuleIDs) > 0 {
+		for ruleID := range req.Versions {
+			if !seen[ruleID] {
+				v.Addf("versions", "versions: rule %s is not in rule_ids", ruleID)
+			}
+		}
+	}
+
+	var clientID string
+	if f := req.Filter; f != nil {
+		clientID = f.ClientID
+		if f.Severity != "" {
+			v.OneOf("filter.severity", f.Severity, ruleSeverities...)
+		}
+	}
+	// Without rule IDs the filter must be scoped to a client, so a typo cannot toggle every rule
+	v.Check(len(req.RuleIDs) > 0 || clientID != "", "filter.client_id", "rule_ids or filter.client_id is required")
+}
+
+// bulkRuleSelection returns the rules selected by a validated bulk toggle request.
+func bulkRuleSelection(req *BulkToggleRulesRequest) database.RuleSelection {
+	sel := database.RuleSelection{RuleIDs: req.RuleIDs, Versions: req.Versions}
+	if f := req.Filter; f != nil {
+		if f.ClientID != "" {
+			sel.Filter.ClientID = &f.ClientID
+		}
+		if f.Severity != "" {
+			sel.Filter.Severity = &f.Severity
+		}
+		if f.Source != "" {
+			sel.Filter.Source = &f.Source
+		}
+		if f.Name != "" {
+			sel.Filter.NameContains = &f.Name
+		}
+	}
+	return sel
+}
@@ -0,0 +1,138 @@
+// Package handlers provides tests for the bulk rule toggle handler.
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"rule-service/internal/database"
+	"rule-service/internal/events"
+)
+
+// TestHandlers_BulkToggleRules tests the BulkToggleRules handler.
+func TestHandlers_BulkToggleRules(t *testing.T) {
+	t.Run("disables a client's rules with one batch", func(t *testing.T) {
+		var gotSel database.RuleSelection
+		var gotEnabled bool
+		mockDB := &mockRepository{
+			BulkSetRulesEnabledFn: func(ctx context.Context, sel database.RuleSelection, enabled bool) (*database.BulkToggleResult, error) {
+				gotSel, gotEnabled = sel, enabled
+				return &database.BulkToggleResult{
+					Updated: []*database.Rule{
+						{RuleID: "rule-1", ClientID: "client-1", Version: 3, UpdatedAt: time.Now()},
+						{RuleID: "rule-2", ClientID: "client-1", Version: 2, UpdatedAt: time.Now()},
+					},
+					Unchanged: []string{"rule-3"},
+				}, nil
+			},
+		}
+		pub := &mockPublisher{}
+
+		h := NewHandlersWithDeps(mockDB, pub, nil)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/rules/bulk-toggle", bytes.NewBufferString(`{"filter":{"client_id":"client-1"},"enabled":false}`))
+		w := httptest.NewRecorder()
+
+		h.BulkToggleRules(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("BulkToggleRules() status = %v, want %v: %s", w.Code, http.StatusOK, w.Body.String())
+		}
+		if gotSel.Filter.ClientID == nil || *gotSel.Filter.ClientID != "client-1" || gotEnabled {
+			t.Errorf("BulkSetRulesEnabled() selection = %+v enabled = %v, want client-1 disabled", gotSel, gotEnabled)
+		}
+		if pub.Batches != 1 || len(pub.Published) != 2 {
+			t.Fatalf("published %d events in %d batches, want 2 in 1", len(pub.Published), pub.Batches)
+		}
+		for _, changed := range pub.Published {
+			if changed.Action != events.ActionDisabled {
+				t.Errorf("event action = %s, want %s", changed.Action, events.ActionDisabled)
+			}
+		}
+	})
+
+	t.Run("nothing changed publishes nothing", func(t *testing.T) {
+		pub := &mockPublisher{}
+		h := NewHandlersWithDeps(&mockRepository{}, pub, nil)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/rules/bulk-toggle", bytes.NewBufferString(`{"rule_ids":["rule-1"],"enabled":true}`))
+		w := httptest.NewRecorder()
+
+		h.BulkToggleRules(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("BulkToggleRules() status = %v, want %v", w.Code, http.StatusOK)
+		}
+		if pub.Batches != 0 {
+			t.Errorf("published %d batches, want 0", pub.Batches)
+		}
+	})
+
+	t.Run("version mismatch", func(t *testing.T) {
+		mockDB := &mockRepository{
+			BulkSetRulesEnabledFn: func(ctx context.Context, sel database.RuleSelection, enabled bool) (*database.BulkToggleResult, error) {
+				return nil, fmt.Errorf("rule version mismatch: rule rule-1 expected version 1, current version 2")
+			},
+		}
+		pub := &mockPublisher{}
+		h := NewHandlersWithDeps(mockDB, pub, nil)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/rules/bulk-toggle", bytes.NewBufferString(`{"rule_ids":["rule-1"],"versions":{"rule-1":1},"enabled":false}`))
+		w := httptest.NewRecorder()
+
+		h.BulkToggleRules(w, req)
+
+		if w.Code != http.StatusConflict {
+			t.Errorf("BulkToggleRules() status = %v, want %v", w.Code, http.StatusConflict)
+		}
+		if len(pub.Published) != 0 {
+			t.Errorf("published %d events, want 0", len(pub.Published))
+		}
+	})
+
+	t.Run("client key is scoped to its client", func(t *testing.T) {
+		var gotClientID string
+		mockDB := &mockRepository{
+			BulkSetRulesEnabledFn: func(ctx context.Context, sel database.RuleSelection, enabled bool) (*database.BulkToggleResult, error) {
+				gotClientID = *sel.Filter.ClientID
+				return &database.BulkToggleResult{}, nil
+			},
+		}
+		h := NewHandlersWithDeps(mockDB, &mockPublisher{}, nil)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/rules/bulk-toggle", bytes.NewBufferString(`{"rule_ids":["rule-1"],"enabled":false}`))
+		w := httptest.NewRecorder()
+
+		h.BulkToggleRules(w, asClient(req, "client-1"))
+
+		if w.Code != http.StatusOK || gotClientID != "client-1" {
+			t.Errorf("BulkToggleRules() status = %v client_id = %q, want 200 and client-1", w.Code, gotClientID)
+		}
+	})
+
+	invalid := []struct {
+		name string
+		body string
+	}{
+		{"missing enabled", `{"rule_ids":["rule-1"]}`},
+		{"no selection", `{"enabled":false}`},
+		{"filter without client", `{"filter":{"source":"api"},"enabled":false}`},
+		{"duplicate rule ID", `{"rule_ids":["rule-1","rule-1"],"enabled":false}`},
+		{"version of unselected rule", `{"rule_ids":["rule-1"],"versions":{"rule-2":1},"enabled":false}`},
+		{"invalid severity", `{"filter":{"client_id":"client-1","severity":"URGENT"},"enabled":false}`},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandlersWithDeps(&mockRepository{}, &mockPublisher{}, nil)
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/rules/bulk-toggle", bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+
+			h.BulkToggleRules(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("BulkToggleRules() status = %v, want %v", w.Code, http.StatusBadRequest)
+			}
+		})
+	}
+}
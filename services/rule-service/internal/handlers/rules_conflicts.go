@@ -0,0 +1,40 @@
+// Package handlers provides HTTP handlers for the rule-service API.
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+
+	"rule-service/internal/conflicts"
+)
+
+// GetRuleConflicts reports duplicate and shadowed rules of a client.
+// GET /api/v1/rules/conflicts?client_id=<id>
+func (h *Handlers) GetRuleConflicts(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	clientID, ok := requireQueryParam(w, r, "client_id")
+	if !ok || !authorizeClient(w, r, clientID) {
+		return
+	}
+
+	ctx := r.Context()
+	if _, err := h.db.GetClient(ctx, clientID); err != nil {
+		if handleDBError(w, err, "client", clientID) {
+			return
+		}
+		http.Error(w, "Failed to get client: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rules, err := h.db.ListClientRules(ctx, clientID)
+	if err != nil {
+		slog.Error("Failed to list client rules", "error", err, "client_id", clientID)
+		http.Error(w, "Failed to list client rules", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, conflicts.Analyze(clientID, rules))
+}
@@ -0,0 +1,83 @@
+// Package handlers provides tests for the rule conflicts handler.
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"rule-service/internal/conflicts"
+	"rule-service/internal/database"
+)
+
+// TestHandlers_GetRuleConflicts tests the GetRuleConflicts handler.
+func TestHandlers_GetRuleConflicts(t *testing.T) {
+	t.Run("reports shadowed rules", func(t *testing.T) {
+		var gotClientID string
+		mockDB := &mockRepository{
+			ListClientRulesFn: func(ctx context.Context, clientID string) ([]*database.Rule, error) {
+				gotClientID = clientID
+				return []*database.Rule{
+					{RuleID: "rule-1", ClientID: clientID, Severity: "*", Source: "api", Name: "*", Enabled: true},
+					{RuleID: "rule-2", ClientID: clientID, Severity: "HIGH", Source: "api", Name: "timeout", Enabled: true},
+				}, nil
+			},
+		}
+
+		h := NewHandlersWithDeps(mockDB, &mockPublisher{}, nil)
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/rules/conflicts?client_id=client-1", nil)
+		w := httptest.NewRecorder()
+
+		h.GetRuleConflicts(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("GetRuleConflicts() status = %v, want %v", w.Code, http.StatusOK)
+		}
+		if gotClientID != "client-1" {
+			t.Errorf("ListClientRules() client_id = %q, want client-1", gotClientID)
+		}
+		var report conflicts.Report
+		if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if report.RulesAnalyzed != 2 || len(report.Conflicts) != 1 {
+			t.Fatalf("GetRuleConflicts() report = %+v, want 2 rules and 1 conflict", report)
+		}
+		if c := report.Conflicts[0]; c.RuleID != "rule-2" || c.CoveredByRuleID != "rule-1" || c.Type != conflicts.TypeShadowed {
+			t.Errorf("GetRuleConflicts() conflict = %+v, want rule-2 shadowed by rule-1", c)
+		}
+	})
+
+	t.Run("missing client_id", func(t *testing.T) {
+		h := NewHandlersWithDeps(&mockRepository{}, &mockPublisher{}, nil)
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/rules/conflicts", nil)
+		w := httptest.NewRecorder()
+
+		h.GetRuleConflicts(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("GetRuleConflicts() status = %v, want %v", w.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("client not found", func(t *testing.T) {
+		mockDB := &mockRepository{
+			GetClientFn: func(ctx context.Context, clientID string) (*database.Client, error) {
+				return nil, fmt.Errorf("client not found: %s", clientID)
+			},
+		}
+
+		h := NewHandlersWithDeps(mockDB, &mockPublisher{}, nil)
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/rules/conflicts?client_id=missing", nil)
+		w := httptest.NewRecorder()
+
+		h.GetRuleConflicts(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("GetRuleConflicts() status = %v, want %v", w.Code, http.StatusNotFound)
+		}
+	})
+}
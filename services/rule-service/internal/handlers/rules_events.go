@@ -14,15 +14,7 @@ import (
 // It logs errors but does not fail the operation if publishing fails.
 // The updatedAt parameter allows customizing the timestamp (useful for deletions).
 func (h *Handlers) publishRuleEvent(ctx context.Context, rule *database.Rule, action string, updatedAt int64) {
-	changed := &events.RuleChanged{
-		RuleID:        rule.RuleID,
-		ClientID:      rule.ClientID,
-		Action:        action,
-		Version:       rule.Version,
-		UpdatedAt:     updatedAt,
-		SchemaVersion: SchemaVersion,
-	}
-
+	changed := newRuleChangedEvent(rule, action, updatedAt)
 	if err := h.producer.Publish(ctx, changed); err != nil {
 		slog.Error("Failed to publish rule.changed event",
 			"error", err,
@@ -37,6 +29,84 @@ func (h *Handlers) publishRuleEvent(ctx context.Context, rule *database.Rule, ac
 	h.metrics.IncrementCustom("kafka_rule_" + action)
 }
 
+// publishRuleChangedEvents publishes a rule.changed event for each of rules in a single Kafka
+// write, using each rule's UpdatedAt timestamp. Like publishRuleEvent, failures are only logged.
+func (h *Handlers) publishRuleChangedEvents(ctx context.Context, rules []*database.Rule, action string) {
+	if len(rules) == 0 {
+		return
+	}
+	changes := make([]*events.RuleChanged, 0, len(rules))
+	for _, rule := range rules {
+		changes = append(changes, newRuleChangedEvent(rule, action, rule.UpdatedAt.Unix()))
+	}
+	if err := h.producer.PublishBatch(ctx, changes); err != nil {
+		slog.Error("Failed to publish rule.changed event batch",
+			"error", err,
+			"count", len(changes),
+			"action", action,
+		)
+		return
+	}
+
+	for range changes {
+		h.metrics.RecordPublished()
+		h.metrics.IncrementCustom("kafka_rule_" + action)
+	}
+}
+
+// publishRuleRebuildEvent publishes a single rule.changed REBUILD event for a client whose
+// rules changed in bulk, instead of an event per rule: rule-updater rebuilds the snapshot and
+// evaluators reload it. Like publishRuleEvent, failures are only logged.
+func (h *Handlers) publishRuleRebuildEvent(ctx context.Context, clientID string) {
+	now := time.Now()
+	changed := &events.RuleChanged{
+		ClientID:      clientID,
+		Action:        events.ActionRebuild,
+		UpdatedAt:     now.Unix(),
+		SchemaVersion: SchemaVersion,
+		PublishedAtMs: now.UnixMilli(),
+	}
+	if err := h.producer.Publish(ctx, changed); err != nil {
+		slog.Error("Failed to publish rule.changed rebuild event",
+			"error", err,
+			"client_id", clientID,
+		)
+		return
+	}
+
+	h.metrics.RecordPublished()
+	h.metrics.IncrementCustom("kafka_rule_" + events.ActionRebuild)
+}
+
+// newRuleChangedEvent builds the rule.changed event of a rule change.
+func newRuleChangedEvent(rule *database.Rule, action string, updatedAt int64) *events.RuleChanged {
+	changed := &events.RuleChanged{
+		RuleID:        rule.RuleID,
+		ClientID:      rule.ClientID,
+		Action:        action,
+		Version:       rule.Version,
+		UpdatedAt:     updatedAt,
+		SchemaVersion: SchemaVersion,
+		PublishedAtMs: time.Now().UnixMilli(),
+	}
+	// Embed the rule so consumers can apply the change directly; deletions carry no payload
+	if action != events.ActionDeleted {
+		changed.Rule = &events.RulePayload{
+			Severity: rule.Severity,
+			Source:   rule.Source,
+			Name:     rule.Name,
+			Enabled:  rule.Enabled,
+
+			ExcludeSources: rule.ExcludeSources,
+			ExcludeNames:   rule.ExcludeNames,
+		}
+		for _, c := range rule.Conditions {
+			changed.Rule.Conditions = append(changed.Rule.Conditions, events.ContextCondition{Key: c.Key, Op: c.Op, Value: c.Value})
+		}
+	}
+	return changed
+}
+
 // publishRuleChangedEvent publishes a rule.changed event after a successful DB operation.
 // Uses the rule's UpdatedAt timestamp.
 func (h *Handlers) publishRuleChangedEvent(ctx context.Context, rule *database.Rule, action string) {
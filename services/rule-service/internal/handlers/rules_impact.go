@@ -0,0 +1,153 @@
+// Package handlers provides HTTP handlers for the rule-service API.
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"rule-service/internal/database"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// Impact analysis window bounds, in days.
+const (
+	defaultImpactDays = 7
+	maxImpactDays     = 90
+)
+
+// RuleImpactRequest describes a proposed rule to analyze.
+// When RuleID is set, the proposal is a change to that rule and its current criteria are analyzed too.
+type RuleImpactRequest struct {
+	ClientID string `json:"client_id,omitempty"` // defaults to the client of rule_id
+	RuleID   string `json:"rule_id,omitempty"`
+	Severity string `json:"severity"`
+	Source   string `json:"source"`
+	Name     string `json:"name"`
+	Days     int    `json:"days,omitempty"` // default 7
+}
+
+func (req *RuleImpactRequest) validate(v *shared.Validator) {
+	v.Check(req.ClientID != "" || req.RuleID != "", "client_id", "client_id or rule_id is required")
+	v.Between("days", req.Days, 1, maxImpactDays)
+	validateRuleFields(v, "", req.Severity, req.Source, req.Name)
+}
+
+// RuleImpact is the historical notification volume of one set of rule criteria.
+type RuleImpact struct {
+	Severity         string                    `json:"severity"`
+	Source           string                    `json:"source"`
+	Name             string                    `json:"name"`
+	Notifications    int                       `json:"notifications"`
+	NewNotifications int                       `json:"new_notifications"`
+	ByDay            []*database.RuleImpactDay `json:"by_day"`
+}
+
+// RuleImpactResponse compares the proposed criteria with the current ones, if any.
+type RuleImpactResponse struct {
+	ClientID string      `json:"client_id"`
+	Days     int         `json:"days"`
+	Since    time.Time   `json:"since"`
+	Proposed *RuleImpact `json:"proposed"`
+	Current  *RuleImpact `json:"current,omitempty"`
+}
+
+// GetRuleImpact estimates how many notifications a proposed rule would have generated
+// over the last N days, based on the alerts recorded in the notifications history.
+// POST /api/v1/rules/impact
+func (h *Handlers) GetRuleImpact(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	var req RuleImpactRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if req.Days == 0 {
+		req.Days = defaultImpactDays
+	}
+	if !validateRequest(w, &req) {
+		return
+	}
+
+	ctx := r.Context()
+	var current *database.Rule
+	if req.RuleID != "" {
+		rule, err := h.db.GetRule(ctx, req.RuleID)
+		if err != nil {
+			if handleDBError(w, err, "rule", req.RuleID) {
+				return
+			}
+			http.Error(w, "Failed to get rule: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if req.ClientID == "" {
+			req.ClientID = rule.ClientID
+		}
+		if req.ClientID != rule.ClientID {
+			http.Error(w, "rule_id does not belong to client_id", http.StatusBadRequest)
+			return
+		}
+		current = rule
+	} else {
+		if _, err := h.db.GetClient(ctx, req.ClientID); err != nil {
+			if handleDBError(w, err, "client", req.ClientID) {
+				return
+			}
+			http.Error(w, "Failed to get client: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// The window covers whole UTC days, ending with today
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	since := today.AddDate(0, 0, -(req.Days - 1))
+
+	resp := &RuleImpactResponse{ClientID: req.ClientID, Days: req.Days, Since: since}
+	var err error
+	if resp.Proposed, err = h.ruleImpact(ctx, req.ClientID, req.Severity, req.Source, req.Name, since, req.Days); err == nil && current != nil {
+		resp.Current, err = h.ruleImpact(ctx, req.ClientID, current.Severity, current.Source, current.Name, since, req.Days)
+	}
+	if err != nil {
+		slog.Error("Failed to get rule impact", "error", err, "client_id", req.ClientID)
+		http.Error(w, "Failed to get rule impact", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// ruleImpact loads the daily counts for one set of criteria and fills in days without alerts.
+func (h *Handlers) ruleImpact(ctx context.Context, clientID, severity, source, name string, since time.Time, days int) (*RuleImpact, error) {
+	counts, err := h.db.GetRuleImpact(ctx, clientID, severity, source, name, since)
+	if err != nil {
+		return nil, err
+	}
+
+	byDay := make(map[string]*database.RuleImpactDay, len(counts))
+	for _, c := range counts {
+		byDay[c.Day.Format(time.DateOnly)] = c
+	}
+
+	impact := &RuleImpact{
+		Severity: severity,
+		Source:   source,
+		Name:     name,
+		ByDay:    make([]*database.RuleImpactDay, 0, days),
+	}
+	for i := 0; i < days; i++ {
+		day := since.AddDate(0, 0, i)
+		count, ok := byDay[day.Format(time.DateOnly)]
+		if !ok {
+			count = &database.RuleImpactDay{Day: day}
+		}
+		impact.Notifications += count.Notifications
+		impact.NewNotifications += count.NewNotifications
+		impact.ByDay = append(impact.ByDay, count)
+	}
+	return impact, nil
+}
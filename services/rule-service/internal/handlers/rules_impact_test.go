@@ -0,0 +1,105 @@
+// Package handlers provides tests for the rule impact handler.
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"rule-service/internal/database"
+)
+
+// TestHandlers_GetRuleImpact tests the GetRuleImpact handler.
+func TestHandlers_GetRuleImpact(t *testing.T) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	t.Run("fills days and compares with the current rule", func(t *testing.T) {
+		var gotSince time.Time
+		mockDB := &mockRepository{
+			GetRuleFn: func(ctx context.Context, ruleID string) (*database.Rule, error) {
+				return &database.Rule{RuleID: ruleID, ClientID: "client-1", Severity: "HIGH", Source: "api", Name: "timeout"}, nil
+			},
+			GetRuleImpactFn: func(ctx context.Context, clientID, severity, source, name string, since time.Time) ([]*database.RuleImpactDay, error) {
+				gotSince = since
+				if severity == "*" {
+					return []*database.RuleImpactDay{
+						{Day: today.AddDate(0, 0, -2), Notifications: 4, NewNotifications: 3},
+						{Day: today, Notifications: 1, NewNotifications: 0},
+					}, nil
+				}
+				return []*database.RuleImpactDay{{Day: today, Notifications: 1}}, nil
+			},
+		}
+
+		h := NewHandlersWithDeps(mockDB, &mockPublisher{}, nil)
+		body := `{"rule_id":"rule-1","severity":"*","source":"api","name":"timeout","days":3}`
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/rules/impact", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+
+		h.GetRuleImpact(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("GetRuleImpact() status = %v, want %v: %s", w.Code, http.StatusOK, w.Body.String())
+		}
+		if want := today.AddDate(0, 0, -2); !gotSince.Equal(want) {
+			t.Errorf("GetRuleImpact() since = %v, want %v", gotSince, want)
+		}
+
+		var resp RuleImpactResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if resp.ClientID != "client-1" || resp.Days != 3 {
+			t.Errorf("GetRuleImpact() client_id = %q, days = %d; want client-1, 3", resp.ClientID, resp.Days)
+		}
+		if resp.Proposed == nil || resp.Proposed.Notifications != 5 || resp.Proposed.NewNotifications != 3 {
+			t.Fatalf("GetRuleImpact() proposed = %+v, want 5 notifications, 3 new", resp.Proposed)
+		}
+		wantByDay := []int{4, 0, 1}
+		if len(resp.Proposed.ByDay) != len(wantByDay) {
+			t.Fatalf("GetRuleImpact() proposed by_day has %d days, want %d", len(resp.Proposed.ByDay), len(wantByDay))
+		}
+		for i, want := range wantByDay {
+			if got := resp.Proposed.ByDay[i].Notifications; got != want {
+				t.Errorf("GetRuleImpact() by_day[%d] = %d, want %d", i, got, want)
+			}
+		}
+		if resp.Current == nil || resp.Current.Severity != "HIGH" || resp.Current.Notifications != 1 {
+			t.Errorf("GetRuleImpact() current = %+v, want HIGH with 1 notification", resp.Current)
+		}
+	})
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{"missing client and rule", `{"severity":"HIGH","source":"api","name":"timeout"}`, http.StatusBadRequest},
+		{"days out of range", `{"client_id":"client-1","severity":"HIGH","source":"api","name":"timeout","days":91}`, http.StatusBadRequest},
+		{"all wildcards", `{"client_id":"client-1","severity":"*","source":"*","name":"*"}`, http.StatusBadRequest},
+		{"rule of another client", `{"client_id":"client-2","rule_id":"rule-1","severity":"HIGH","source":"api","name":"timeout"}`, http.StatusBadRequest},
+		{"new rule", `{"client_id":"client-1","severity":"HIGH","source":"api","name":"timeout"}`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := &mockRepository{
+				GetRuleFn: func(ctx context.Context, ruleID string) (*database.Rule, error) {
+					return &database.Rule{RuleID: ruleID, ClientID: "client-1"}, nil
+				},
+			}
+			h := NewHandlersWithDeps(mockDB, &mockPublisher{}, nil)
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/rules/impact", bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+
+			h.GetRuleImpact(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("GetRuleImpact() status = %v, want %v", w.Code, tt.expectedStatus)
+			}
+		})
+	}
+}
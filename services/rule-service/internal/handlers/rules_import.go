@@ -0,0 +1,365 @@
+// Package handlers provides HTTP handlers for the rule-service API.
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"rule-service/internal/database"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+	"gopkg.in/yaml.v3"
+)
+
+// Rule document formats, chosen with ?format= on export and the Content-Type on import.
+const (
+	ruleDocumentJSON = "json"
+	ruleDocumentYAML = "yaml"
+)
+
+// Statuses of the rows of a rule import.
+const (
+	ImportRowCreated = "created" // created, or would be created by a dry run
+	ImportRowSkipped = "skipped" // the client already has a rule with the same criteria
+	ImportRowInvalid = "invalid"
+)
+
+// RuleDocument is a client's rules with their endpoints, as written by ExportRules and read by
+// ImportRules, in JSON or YAML.
+type RuleDocument struct {
+	ClientID string         `json:"client_id" yaml:"client_id"`
+	Rules    []DocumentRule `json:"rules" yaml:"rules"`
+}
+
+// DocumentRule is a rule of a RuleDocument.
+type DocumentRule struct {
+	Severity       string                  `json:"severity" yaml:"severity"`
+	Source         string                  `json:"source" yaml:"source"`
+	Name           string                  `json:"name" yaml:"name"`
+	Description    string                  `json:"description,omitempty" yaml:"description,omitempty"`
+	ExcludeSources []string                `json:"exclude_sources,omitempty" yaml:"exclude_sources,omitempty"`
+	ExcludeNames   []string                `json:"exclude_names,omitempty" yaml:"exclude_names,omitempty"`
+	Conditions     database.RuleConditions `json:"conditions,omitempty" yaml:"conditions,omitempty"`
+	Enabled        *bool                   `json:"enabled,omitempty" yaml:"enabled,omitempty"` // default true
+	Endpoints      []DocumentEndpoint      `json:"endpoints,omitempty" yaml:"endpoints,omitempty"`
+}
+
+// DocumentEndpoint is an endpoint of a DocumentRule. Headers, OAuth2 settings and templates
+// are not part of the document: they hold secrets or refer to the client's templates.
+type DocumentEndpoint struct {
+	Type    string `json:"type" yaml:"type"`
+	Value   string `json:"value" yaml:"value"`
+	Enabled *bool  `json:"enabled,omitempty" yaml:"enabled,omitempty"` // default true
+}
+
+// RuleImportResult reports what an import did, or would do for a dry run, row by row.
+type RuleImportResult struct {
+	ClientID string          `json:"client_id"`
+	DryRun   bool            `json:"dry_run"`
+	Created  int             `json:"created"`
+	Skipped  int             `json:"skipped"`
+	Invalid  int             `json:"invalid"`
+	Rows     []RuleImportRow `json:"rows"`
+}
+
+// RuleImportRow is the outcome of one rule of an import document.
+type RuleImportRow struct {
+	Index  int                 `json:"index"` // position in the document's rules
+	Status string              `json:"status"`
+	RuleID string              `json:"rule_id,omitempty"` // the created rule, or the existing one for skipped rows
+	Errors []shared.FieldError `json:"errors,omitempty"`
+}
+
+// ExportRules writes a client's rules and their endpoints as a rule document, which ImportRules
+// accepts, so a rule set can be reviewed, versioned, or copied to another client.
+// GET /api/v1/rules/export?client_id=<id>[&format=json|yaml]
+func (h *Handlers) ExportRules(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	clientID, ok := requireQueryParam(w, r, "client_id")
+	if !ok || !authorizeClient(w, r, clientID) {
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = ruleDocumentJSON
+	}
+	v := shared.NewValidator()
+	if !v.OneOf("format", format, ruleDocumentJSON, ruleDocumentYAML) {
+		shared.WriteValidationError(w, v.Err())
+		return
+	}
+
+	ctx := r.Context()
+	if _, err := h.db.GetClient(ctx, clientID); err != nil {
+		if handleDBError(w, err, "client", clientID) {
+			return
+		}
+		http.Error(w, "Failed to get client: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rules, err := h.db.ListClientRules(ctx, clientID)
+	if err != nil {
+		slog.Error("Failed to list client rules", "error", err, "client_id", clientID)
+		http.Error(w, "Failed to list client rules", http.StatusInternalServerError)
+		return
+	}
+	endpoints, err := h.db.ListClientEndpoints(ctx, clientID)
+	if err != nil {
+		slog.Error("Failed to list client endpoints", "error", err, "client_id", clientID)
+		http.Error(w, "Failed to list client endpoints", http.StatusInternalServerError)
+		return
+	}
+
+	doc := newRuleDocument(clientID, rules, endpoints)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("rules-%s.%s", clientID, format)))
+	if format == ruleDocumentJSON {
+		writeJSON(w, http.StatusOK, doc)
+		return
+	}
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		w.Header().Del("Content-Disposition")
+		http.Error(w, "Failed to encode rules: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/yaml")
+	w.WriteHeader(http.StatusOK)
+	w.Write(out.Bytes())
+}
+
+// ImportRules creates the rules of a rule document, with their endpoints, for the document's
+// client in one transaction, then publishes a single rule.changed REBUILD event rather than an
+// event per rule. Rules the client already has (same criteria) are skipped and their endpoints
+// left alone, so importing an export again changes nothing. If any row is invalid nothing is
+// created and the response lists every row's errors; with dry_run=true nothing is created
+// either way. The document is YAML if the Content-Type says so, JSON otherwise.
+// POST /api/v1/rules/import[?dry_run=true]
+func (h *Handlers) ImportRules(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	dryRun := false
+	if raw := r.URL.Query().Get("dry_run"); raw != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(raw); err != nil {
+			v := shared.NewValidator()
+			v.Add("dry_run", "dry_run must be true or false")
+			shared.WriteValidationError(w, v.Err())
+			return
+		}
+	}
+
+	var doc RuleDocument
+	if !decodeRuleDocument(w, r, &doc) {
+		return
+	}
+	if !validateRequest(w, &doc) || !authorizeClient(w, r, doc.ClientID) {
+		return
+	}
+
+	ctx := r.Context()
+	if _, err := h.db.GetClient(ctx, doc.ClientID); err != nil {
+		if handleDBError(w, err, "client", doc.ClientID) {
+			return
+		}
+		http.Error(w, "Failed to get client: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	existing, err := h.db.ListClientRules(ctx, doc.ClientID)
+	if err != nil {
+		slog.Error("Failed to list client rules", "error", err, "client_id", doc.ClientID)
+		http.Error(w, "Failed to list client rules", http.StatusInternalServerError)
+		return
+	}
+
+	result, toCreate, rows := planRuleImport(&doc, existing)
+	result.DryRun = dryRun
+	if result.Invalid > 0 {
+		writeJSON(w, http.StatusBadRequest, result)
+		return
+	}
+	if dryRun || len(toCreate) == 0 {
+		writeJSON(w, http.StatusOK, result)
+		return
+	}
+
+	created, err := h.db.ImportRules(ctx, doc.ClientID, toCreate)
+	if err != nil {
+		// A rule created since the rules were listed conflicts with a row
+		if strings.Contains(err.Error(), "already exists") {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if handleDBError(w, err, "client", doc.ClientID) {
+			return
+		}
+		http.Error(w, "Failed to import rules: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for i, rule := range created {
+		result.Rows[rows[i]].RuleID = rule.RuleID
+	}
+
+	h.publishRuleRebuildEvent(ctx, doc.ClientID)
+	slog.Info("Imported rules", "client_id", doc.ClientID, "created", result.Created, "skipped", result.Skipped)
+
+	writeJSON(w, http.StatusCreated, result)
+}
+
+// validate checks the document as a whole; its rules are checked row by row by planRuleImport.
+func (doc *RuleDocument) validate(v *shared.Validator) {
+	v.Required("client_id", doc.ClientID)
+	v.Check(len(doc.Rules) > 0, "rules", "rules must have at least one rule")
+	v.Check(len(doc.Rules) <= database.MaxImportRules, "rules", fmt.Sprintf("rules must have at most %d entries", database.MaxImportRules))
+}
+
+// validate records the invalid fields of the rule at rules[index].
+func (rule *DocumentRule) validate(v *shared.Validator, index int) {
+	prefix := fmt.Sprintf("rules[%d].", index)
+	validateRuleFields(v, prefix, rule.Severity, rule.Source, rule.Name)
+	validateRuleDescription(v, prefix, rule.Description)
+	validateRuleExclusions(v, prefix, rule.Source, rule.Name, database.RuleExclusions{Sources: rule.ExcludeSources, Names: rule.ExcludeNames})
+	validateRuleConditions(v, prefix, rule.Conditions)
+
+	seen := make(map[[2]string]bool, len(rule.Endpoints))
+	for j, e := range rule.Endpoints {
+		endpoint := fmt.Sprintf("%sendpoints[%d]", prefix, j)
+		key := [2]string{e.Type, e.Value}
+		if validateRuleSetEndpoint(v, endpoint, e.Type, e.Value) && seen[key] {
+			v.Add(endpoint, endpoint+": duplicate endpoint")
+		}
+		seen[key] = true
+	}
+}
+
+// planRuleImport checks every row of a validated document against itself and the client's
+// existing rules. It returns the report, the rules to create, and for each of those the index
+// of its row.
+func planRuleImport(doc *RuleDocument, existing []*database.Rule) (*RuleImportResult, []database.BootstrapRule, []int) {
+	existingIDs := make(map[[4]string]string, len(existing))
+	for _, rule := range existing {
+		existingIDs[ruleCriteriaKey(rule.Severity, rule.Source, rule.Name, rule.Conditions)] = rule.RuleID
+	}
+
+	result := &RuleImportResult{ClientID: doc.ClientID, Rows: make([]RuleImportRow, len(doc.Rules))}
+	var toCreate []database.BootstrapRule
+	var rows []int
+	firstRow := make(map[[4]string]int, len(doc.Rules))
+	for i := range doc.Rules {
+		rule := &doc.Rules[i]
+		row := RuleImportRow{Index: i}
+
+		v := shared.NewValidator()
+		rule.validate(v, i)
+		key := ruleCriteriaKey(rule.Severity, rule.Source, rule.Name, rule.Conditions)
+		if first, dup := firstRow[key]; dup {
+			v.Addf(fmt.Sprintf("rules[%d]", i), "rules[%d]: duplicate of rules[%d] (severity=%s, source=%s, name=%s)", i, first, rule.Severity, rule.Source, rule.Name)
+		} else {
+			firstRow[key] = i
+		}
+
+		switch ruleID, exists := existingIDs[key]; {
+		case !v.Valid():
+			row.Status, row.Errors = ImportRowInvalid, v.Errors()
+			result.Invalid++
+		case exists:
+			row.Status, row.RuleID = ImportRowSkipped, ruleID
+			result.Skipped++
+		default:
+			row.Status = ImportRowCreated
+			result.Created++
+			toCreate = append(toCreate, rule.toBootstrapRule())
+			rows = append(rows, i)
+		}
+		result.Rows[i] = row
+	}
+	return result, toCreate, rows
+}
+
+// toBootstrapRule returns the rule to create for a valid document rule.
+func (rule *DocumentRule) toBootstrapRule() database.BootstrapRule {
+	created := database.BootstrapRule{
+		Severity:    rule.Severity,
+		Source:      rule.Source,
+		Name:        rule.Name,
+		Description: rule.Description,
+		Exclusions:  database.RuleExclusions{Sources: rule.ExcludeSources, Names: rule.ExcludeNames},
+		Conditions:  rule.Conditions,
+		Disabled:    rule.Enabled != nil && !*rule.Enabled,
+	}
+	for _, e := range rule.Endpoints {
+		created.Endpoints = append(created.Endpoints, database.BootstrapEndpoint{Type: e.Type, Value: e.Value, Disabled: e.Enabled != nil && !*e.Enabled})
+	}
+	return created
+}
+
+// newRuleDocument builds the document of a client's rules and their endpoints.
+func newRuleDocument(clientID string, rules []*database.Rule, endpoints []*database.Endpoint) *RuleDocument {
+	byRule := make(map[string][]DocumentEndpoint)
+	for _, e := range endpoints {
+		enabled := e.Enabled
+		byRule[e.RuleID] = append(byRule[e.RuleID], DocumentEndpoint{Type: e.Type, Value: e.Value, Enabled: &enabled})
+	}
+
+	doc := &RuleDocument{ClientID: clientID, Rules: make([]DocumentRule, 0, len(rules))}
+	for _, rule := range rules {
+		enabled := rule.Enabled
+		doc.Rules = append(doc.Rules, DocumentRule{
+			Severity:       rule.Severity,
+			Source:         rule.Source,
+			Name:           rule.Name,
+			Description:    rule.Description,
+			ExcludeSources: rule.ExcludeSources,
+			ExcludeNames:   rule.ExcludeNames,
+			Conditions:     rule.Conditions,
+			Enabled:        &enabled,
+			Endpoints:      byRule[rule.RuleID],
+		})
+	}
+	return doc
+}
+
+// decodeRuleDocument decodes the request body as a YAML rule document if its Content-Type is
+// YAML, and as JSON otherwise. Returns false after writing an error response if it is invalid.
+func decodeRuleDocument(w http.ResponseWriter, r *http.Request, doc *RuleDocument) bool {
+	if !isYAMLContentType(r.Header.Get("Content-Type")) {
+		return decodeJSON(w, r, doc)
+	}
+	if err := yaml.NewDecoder(r.Body).Decode(doc); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			return false
+		}
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// isYAMLContentType reports whether a Content-Type header names a YAML media type.
+func isYAMLContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch mediaType {
+	case "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,301 @@
+// Package handlers provides tests for the rule import and export handlers.
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"rule-service/internal/database"
+	"rule-service/internal/events"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TestHandlers_ExportRules tests that a client's rules are exported with their endpoints as JSON and YAML.
+func TestHandlers_ExportRules(t *testing.T) {
+	mockDB := &mockRepository{}
+	mockDB.ListClientRulesFn = func(ctx context.Context, clientID string) ([]*database.Rule, error) {
+		return []*database.Rule{
+			{RuleID: "rule-1", ClientID: clientID, Severity: "HIGH", Source: "api", Name: "timeout", Enabled: true,
+				Conditions: database.RuleConditions{{Key: "region", Op: "==", Value: "eu"}}},
+			{RuleID: "rule-2", ClientID: clientID, Severity: "*", Source: "db", Name: "*", ExcludeNames: []string{"noisy"}},
+		}, nil
+	}
+	mockDB.ListClientEndpointsFn = func(ctx context.Context, clientID string) ([]*database.Endpoint, error) {
+		return []*database.Endpoint{{EndpointID: "endpoint-1", RuleID: "rule-1", Type: "email", Value: "oncall@team-a.example", Enabled: true}}, nil
+	}
+	h := NewHandlersWithDeps(mockDB, &mockPublisher{}, nil)
+
+	w := httptest.NewRecorder()
+	h.ExportRules(w, httptest.NewRequest(http.MethodGet, "/api/v1/rules/export?client_id=team-a", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("ExportRules() status = %v, want %v: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	if got := w.Header().Get("Content-Disposition"); got != `attachment; filename="rules-team-a.json"` {
+		t.Errorf("Content-Disposition = %q, want the JSON filename", got)
+	}
+	var doc RuleDocument
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("failed to decode export: %v", err)
+	}
+	if doc.ClientID != "team-a" || len(doc.Rules) != 2 {
+		t.Fatalf("ExportRules() = %+v, want the 2 rules of team-a", doc)
+	}
+	if r := doc.Rules[0]; len(r.Endpoints) != 1 || r.Endpoints[0].Value != "oncall@team-a.example" || len(r.Conditions) != 1 || !*r.Enabled {
+		t.Errorf("rules[0] = %+v, want the enabled rule with its condition and email endpoint", r)
+	}
+	if r := doc.Rules[1]; len(r.Endpoints) != 0 || *r.Enabled || r.ExcludeNames[0] != "noisy" {
+		t.Errorf("rules[1] = %+v, want the disabled rule without endpoints", r)
+	}
+
+	w = httptest.NewRecorder()
+	h.ExportRules(w, httptest.NewRequest(http.MethodGet, "/api/v1/rules/export?client_id=team-a&format=yaml", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/yaml" {
+		t.Fatalf("ExportRules(yaml) status = %v, Content-Type = %q, want 200 YAML", w.Code, w.Header().Get("Content-Type"))
+	}
+	var fromYAML RuleDocument
+	if err := yaml.Unmarshal(w.Body.Bytes(), &fromYAML); err != nil {
+		t.Fatalf("failed to decode YAML export: %v\n%s", err, w.Body.String())
+	}
+	if fromYAML.Rules[0].Conditions[0] != doc.Rules[0].Conditions[0] || fromYAML.Rules[1].Severity != "*" {
+		t.Errorf("YAML export = %+v, want the same rules as the JSON export", fromYAML)
+	}
+}
+
+// TestHandlers_ExportRules_Errors tests the error responses of the ExportRules handler.
+func TestHandlers_ExportRules_Errors(t *testing.T) {
+	tests := []struct {
+		name           string
+		url            string
+		setupMock      func(*mockRepository)
+		expectedStatus int
+	}{
+		{name: "missing client_id", url: "/api/v1/rules/export", setupMock: func(m *mockRepository) {}, expectedStatus: http.StatusBadRequest},
+		{name: "unknown format", url: "/api/v1/rules/export?client_id=team-a&format=csv", setupMock: func(m *mockRepository) {}, expectedStatus: http.StatusBadRequest},
+		{
+			name: "unknown client",
+			url:  "/api/v1/rules/export?client_id=team-z",
+			setupMock: func(m *mockRepository) {
+				m.GetClientFn = func(ctx context.Context, clientID string) (*database.Client, error) {
+					return nil, fmt.Errorf("client not found: %s", clientID)
+				}
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := &mockRepository{}
+			tt.setupMock(mockDB)
+			h := NewHandlersWithDeps(mockDB, &mockPublisher{}, nil)
+			w := httptest.NewRecorder()
+
+			h.ExportRules(w, httptest.NewRequest(http.MethodGet, tt.url, nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("ExportRules() status = %v, want %v: %s", w.Code, tt.expectedStatus, w.Body.String())
+			}
+		})
+	}
+}
+
+// TestHandlers_ImportRules tests that an import creates new rows, skips existing rules and
+// publishes a single rebuild event.
+func TestHandlers_ImportRules(t *testing.T) {
+	var gotRules []database.BootstrapRule
+	mockDB := &mockRepository{}
+	mockDB.ListClientRulesFn = func(ctx context.Context, clientID string) ([]*database.Rule, error) {
+		return []*database.Rule{{RuleID: "existing-1", ClientID: clientID, Severity: "HIGH", Source: "api", Name: "timeout"}}, nil
+	}
+	mockDB.ImportRulesFn = func(ctx context.Context, clientID string, rules []database.BootstrapRule) ([]*database.BootstrappedRule, error) {
+		gotRules = rules
+		return (&mockRepository{}).ImportRules(ctx, clientID, rules)
+	}
+	publisher := &mockPublisher{}
+	h := NewHandlersWithDeps(mockDB, publisher, nil)
+
+	body := `
+client_id: team-a
+rules:
+  - severity: HIGH
+    source: api
+    name: timeout
+  - severity: "*"
+    source: db
+    name: "*"
+    enabled: false
+    conditions:
+      - {key: region, op: "==", value: eu}
+    endpoints:
+      - type: email
+        value: oncall@team-a.example
+        enabled: false
+`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/rules/import", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/yaml")
+	w := httptest.NewRecorder()
+
+	h.ImportRules(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("ImportRules() status = %v, want %v: %s", w.Code, http.StatusCreated, w.Body.String())
+	}
+	var result RuleImportResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("failed to decode result: %v", err)
+	}
+	if result.Created != 1 || result.Skipped != 1 || result.Invalid != 0 {
+		t.Errorf("ImportRules() = %+v, want 1 created and 1 skipped", result)
+	}
+	if row := result.Rows[0]; row.Status != ImportRowSkipped || row.RuleID != "existing-1" {
+		t.Errorf("rows[0] = %+v, want skipped as existing-1", row)
+	}
+	if row := result.Rows[1]; row.Status != ImportRowCreated || row.RuleID != "imported-1" {
+		t.Errorf("rows[1] = %+v, want created as imported-1", row)
+	}
+	if len(gotRules) != 1 || !gotRules[0].Disabled || len(gotRules[0].Conditions) != 1 ||
+		len(gotRules[0].Endpoints) != 1 || !gotRules[0].Endpoints[0].Disabled {
+		t.Errorf("ImportRules() created %+v, want the disabled db rule with its condition and disabled endpoint", gotRules)
+	}
+	if len(publisher.Published) != 1 || publisher.Published[0].Action != events.ActionRebuild ||
+		publisher.Published[0].ClientID != "team-a" || publisher.Published[0].RuleID != "" {
+		t.Errorf("ImportRules() published %+v, want one REBUILD event for team-a", publisher.Published)
+	}
+}
+
+// TestHandlers_ImportRules_Rows tests dry runs and the per-row error report of the ImportRules handler.
+func TestHandlers_ImportRules_Rows(t *testing.T) {
+	tests := []struct {
+		name           string
+		url            string
+		body           string
+		expectedStatus int
+		expectedRows   []string // status of each row
+		expectedFields []string // fields of the invalid rows' errors, in order
+	}{
+		{
+			name:           "dry run creates nothing",
+			url:            "/api/v1/rules/import?dry_run=true",
+			body:           `{"client_id":"team-a","rules":[{"severity":"HIGH","source":"api","name":"*"}]}`,
+			expectedStatus: http.StatusOK,
+			expectedRows:   []string{ImportRowCreated},
+		},
+		{
+			name:           "invalid rows are reported by index",
+			url:            "/api/v1/rules/import",
+			body:           `{"client_id":"team-a","rules":[{"severity":"HIGH","source":"api","name":"*"},{"severity":"URGENT","source":"api","name":"x","endpoints":[{"type":"fax","value":"1"}]},{"severity":"HIGH","source":"api","name":"*"}]}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedRows:   []string{ImportRowCreated, ImportRowInvalid, ImportRowInvalid},
+			expectedFields: []string{"rules[1].severity", "rules[1].endpoints[0].type", "rules[2]"},
+		},
+		{
+			name:           "dry run reports invalid rows too",
+			url:            "/api/v1/rules/import?dry_run=1",
+			body:           `{"client_id":"team-a","rules":[{"severity":"HIGH","source":"","name":"*","endpoints":[{"type":"email","value":"a@b.example"},{"type":"email","value":"a@b.example"}]}]}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedRows:   []string{ImportRowInvalid},
+			expectedFields: []string{"rules[0].source", "rules[0].endpoints[1]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := &mockRepository{}
+			mockDB.ImportRulesFn = func(ctx context.Context, clientID string, rules []database.BootstrapRule) ([]*database.BootstrappedRule, error) {
+				if tt.expectedStatus != http.StatusCreated {
+					t.Error("ImportRules() wrote rules")
+				}
+				return nil, nil
+			}
+			publisher := &mockPublisher{}
+			h := NewHandlersWithDeps(mockDB, publisher, nil)
+			w := httptest.NewRecorder()
+
+			h.ImportRules(w, httptest.NewRequest(http.MethodPost, tt.url, bytes.NewBufferString(tt.body)))
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("ImportRules() status = %v, want %v: %s", w.Code, tt.expectedStatus, w.Body.String())
+			}
+			var result RuleImportResult
+			if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+				t.Fatalf("failed to decode result: %v", err)
+			}
+			var statuses, fields []string
+			for _, row := range result.Rows {
+				statuses = append(statuses, row.Status)
+				for _, fe := range row.Errors {
+					fields = append(fields, fe.Field)
+				}
+			}
+			if fmt.Sprint(statuses) != fmt.Sprint(tt.expectedRows) || fmt.Sprint(fields) != fmt.Sprint(tt.expectedFields) {
+				t.Errorf("ImportRules() rows = %v with errors on %v, want %v with errors on %v", statuses, fields, tt.expectedRows, tt.expectedFields)
+			}
+			if len(publisher.Published) != 0 {
+				t.Errorf("ImportRules() published %d events, want none", len(publisher.Published))
+			}
+		})
+	}
+}
+
+// TestHandlers_ImportRules_Errors tests document-level errors of the ImportRules handler.
+func TestHandlers_ImportRules_Errors(t *testing.T) {
+	tests := []struct {
+		name           string
+		url            string
+		body           string
+		setupMock      func(*mockRepository)
+		expectedStatus int
+	}{
+		{name: "invalid dry_run", url: "/api/v1/rules/import?dry_run=maybe", body: `{}`, setupMock: func(m *mockRepository) {}, expectedStatus: http.StatusBadRequest},
+		{name: "invalid JSON", url: "/api/v1/rules/import", body: `{`, setupMock: func(m *mockRepository) {}, expectedStatus: http.StatusBadRequest},
+		{name: "no rules", url: "/api/v1/rules/import", body: `{"client_id":"team-a","rules":[]}`, setupMock: func(m *mockRepository) {}, expectedStatus: http.StatusBadRequest},
+		{
+			name: "unknown client",
+			url:  "/api/v1/rules/import",
+			body: `{"client_id":"team-z","rules":[{"severity":"HIGH","source":"api","name":"*"}]}`,
+			setupMock: func(m *mockRepository) {
+				m.GetClientFn = func(ctx context.Context, clientID string) (*database.Client, error) {
+					return nil, fmt.Errorf("client not found: %s", clientID)
+				}
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "rule created concurrently",
+			url:  "/api/v1/rules/import",
+			body: `{"client_id":"team-a","rules":[{"severity":"HIGH","source":"api","name":"*"}]}`,
+			setupMock: func(m *mockRepository) {
+				m.ImportRulesFn = func(ctx context.Context, clientID string, rules []database.BootstrapRule) ([]*database.BootstrappedRule, error) {
+					return nil, fmt.Errorf("rule already exists for client %s with criteria (severity=HIGH, source=api, name=*)", clientID)
+				}
+			},
+			expectedStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := &mockRepository{}
+			tt.setupMock(mockDB)
+			publisher := &mockPublisher{}
+			h := NewHandlersWithDeps(mockDB, publisher, nil)
+			w := httptest.NewRecorder()
+
+			h.ImportRules(w, httptest.NewRequest(http.MethodPost, tt.url, bytes.NewBufferString(tt.body)))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("ImportRules() status = %v, want %v: %s", w.Code, tt.expectedStatus, w.Body.String())
+			}
+			if len(publisher.Published) != 0 {
+				t.Errorf("ImportRules() published %d events, want none", len(publisher.Published))
+			}
+		})
+	}
+}
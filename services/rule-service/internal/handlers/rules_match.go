@@ -0,0 +1,156 @@
+// Package handlers provides HTTP handlers for the rule-service API.
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+
+	"rule-service/internal/database"
+	"rule-service/internal/rulematch"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// maxTestContextEntries bounds the context of a sample alert, like the evaluator bounds the
+// context of alerts by default.
+const maxTestContextEntries = 64
+
+// RuleTestRequest is a sample alert to test against a client's rules.
+type RuleTestRequest struct {
+	ClientID string            `json:"client_id"`
+	Severity string            `json:"severity"`
+	Source   string            `json:"source"`
+	Name     string            `json:"name"`
+	Context  map[string]string `json:"context,omitempty"`
+}
+
+func (req *RuleTestRequest) validate(v *shared.Validator) {
+	v.Required("client_id", req.ClientID)
+	if v.Required("severity", req.Severity) {
+		v.OneOf("severity", req.Severity, alertSeverities...)
+	}
+	v.Required("source", req.Source)
+	v.Required("name", req.Name)
+	if len(req.Context) > maxTestContextEntries {
+		v.Addf("context", "context must have at most %d entries", maxTestContextEntries)
+		return
+	}
+	for key, value := range req.Context {
+		if len(key) > maxConditionKeyLength {
+			v.Addf("context", "context keys must be at most %d characters", maxConditionKeyLength)
+			return
+		}
+		v.MaxLength("context."+key, value, maxConditionValueLength)
+	}
+}
+
+// RuleTestMatch is a rule that matches the sample alert, with its endpoints.
+type RuleTestMatch struct {
+	RuleID    string               `json:"rule_id"`
+	Severity  string               `json:"severity"`
+	Source    string               `json:"source"`
+	Name      string               `json:"name"`
+	Enabled   bool                 `json:"enabled"`
+	Endpoints []*database.Endpoint `json:"endpoints"`
+}
+
+// RuleTestResponse lists the rules a sample alert matches and the endpoints it would notify.
+type RuleTestResponse struct {
+	ClientID      string `json:"client_id"`
+	Normalization string `json:"normalization"`
+	RulesTested   int    `json:"rules_tested"`
+	// Matches holds every matching rule, enabled or not, in rule creation order.
+	Matches []RuleTestMatch `json:"matches"`
+	// Notified holds the enabled endpoints of the enabled matching rules, once per type and
+	// value as the sender delivers them: if rules share an endpoint value, the first rule's
+	// endpoint is listed.
+	Notified []*database.Endpoint `json:"notified"`
+}
+
+// TestRule matches a sample alert against a client's rules, enabled or not, the way the
+// evaluator matches alerts, and reports which rules match and which endpoints would be
+// notified. Nothing is published.
+// POST /api/v1/rules/test
+func (h *Handlers) TestRule(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	var req RuleTestRequest
+	if !decodeJSON(w, r, &req) || !validateRequest(w, &req) || !authorizeClient(w, r, req.ClientID) {
+		return
+	}
+
+	ctx := r.Context()
+	if _, err := h.db.GetClient(ctx, req.ClientID); err != nil {
+		if handleDBError(w, err, "client", req.ClientID) {
+			return
+		}
+		http.Error(w, "Failed to get client: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rules, err := h.db.ListClientRules(ctx, req.ClientID)
+	if err != nil {
+		slog.Error("Failed to list client rules", "error", err, "client_id", req.ClientID)
+		http.Error(w, "Failed to list client rules", http.StatusInternalServerError)
+		return
+	}
+	endpoints, err := h.db.ListClientEndpoints(ctx, req.ClientID)
+	if err != nil {
+		slog.Error("Failed to list client endpoints", "error", err, "client_id", req.ClientID)
+		http.Error(w, "Failed to list client endpoints", http.StatusInternalServerError)
+		return
+	}
+
+	matched := rulematch.NewIndex(rules, h.normalization).Match(rulematch.Alert{
+		Severity: req.Severity,
+		Source:   req.Source,
+		Name:     req.Name,
+		Context:  req.Context,
+	})
+	writeJSON(w, http.StatusOK, newRuleTestResponse(req.ClientID, h.normalization, len(rules), matched, endpoints))
+}
+
+// newRuleTestResponse reports the matched rules with their endpoints, and the endpoints the
+// sender would notify.
+func newRuleTestResponse(clientID string, normalization shared.Normalization, rulesTested int, matched []*database.Rule, endpoints []*database.Endpoint) *RuleTestResponse {
+	byRule := make(map[string][]*database.Endpoint)
+	for _, ep := range endpoints {
+		byRule[ep.RuleID] = append(byRule[ep.RuleID], ep)
+	}
+
+	resp := &RuleTestResponse{
+		ClientID:      clientID,
+		Normalization: normalization.String(),
+		RulesTested:   rulesTested,
+		Matches:       make([]RuleTestMatch, 0, len(matched)),
+		Notified:      make([]*database.Endpoint, 0),
+	}
+	notified := make(map[[2]string]bool)
+	for _, rule := range matched {
+		ruleEndpoints := byRule[rule.RuleID]
+		if ruleEndpoints == nil {
+			ruleEndpoints = []*database.Endpoint{}
+		}
+		resp.Matches = append(resp.Matches, RuleTestMatch{
+			RuleID:    rule.RuleID,
+			Severity:  rule.Severity,
+			Source:    rule.Source,
+			Name:      rule.Name,
+			Enabled:   rule.Enabled,
+			Endpoints: ruleEndpoints,
+		})
+		if !rule.Enabled {
+			continue
+		}
+		for _, ep := range ruleEndpoints {
+			key := [2]string{ep.Type, ep.Value}
+			if ep.Enabled && !notified[key] {
+				notified[key] = true
+				resp.Notified = append(resp.Notified, ep)
+			}
+		}
+	}
+	return resp
+}
@@ -0,0 +1,102 @@
+// Package handlers provides tests for the rule test handler.
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"rule-service/internal/database"
+)
+
+// TestHandlers_TestRule tests the TestRule handler.
+func TestHandlers_TestRule(t *testing.T) {
+	mockDB := &mockRepository{
+		ListClientRulesFn: func(ctx context.Context, clientID string) ([]*database.Rule, error) {
+			return []*database.Rule{
+				{RuleID: "rule-1", ClientID: clientID, Severity: "HIGH", Source: "api", Name: "timeout", Enabled: true},
+				{RuleID: "rule-2", ClientID: clientID, Severity: "*", Source: "API", Name: "*", Enabled: true, Conditions: database.RuleConditions{
+					{Key: "env", Op: database.ConditionOpEqual, Value: "prod"},
+				}},
+				{RuleID: "rule-3", ClientID: clientID, Severity: "HIGH", Source: "*", Name: "timeout", Enabled: false},
+				{RuleID: "rule-4", ClientID: clientID, Severity: "LOW", Source: "api", Name: "timeout", Enabled: true},
+			}, nil
+		},
+		ListClientEndpointsFn: func(ctx context.Context, clientID string) ([]*database.Endpoint, error) {
+			return []*database.Endpoint{
+				{EndpointID: "ep-1", RuleID: "rule-1", Type: "email", Value: "oncall@team-a.example", Enabled: true},
+				{EndpointID: "ep-2", RuleID: "rule-2", Type: "email", Value: "oncall@team-a.example", Enabled: true},
+				{EndpointID: "ep-3", RuleID: "rule-2", Type: "slack", Value: "https://hooks.slack.com/x", Enabled: false},
+				{EndpointID: "ep-4", RuleID: "rule-3", Type: "webhook", Value: "https://example.com/hook", Enabled: true},
+			}, nil
+		},
+	}
+	h := NewHandlersWithDeps(mockDB, &mockPublisher{}, nil)
+
+	t.Run("reports matching rules and notified endpoints", func(t *testing.T) {
+		body := `{"client_id": "team-a", "severity": "HIGH", "source": " api ", "name": "timeout", "context": {"env": "Prod"}}`
+		w := httptest.NewRecorder()
+		h.TestRule(w, httptest.NewRequest(http.MethodPost, "/api/v1/rules/test", strings.NewReader(body)))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("TestRule() status = %v, want %v: %s", w.Code, http.StatusOK, w.Body.String())
+		}
+		var resp RuleTestResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if resp.RulesTested != 4 || resp.Normalization != "trim,fold" {
+			t.Errorf("TestRule() rules_tested = %d, normalization = %q, want 4 and trim,fold", resp.RulesTested, resp.Normalization)
+		}
+		var matched []string
+		for _, m := range resp.Matches {
+			matched = append(matched, fmt.Sprintf("%s:%v:%d", m.RuleID, m.Enabled, len(m.Endpoints)))
+		}
+		if got, want := strings.Join(matched, ","), "rule-1:true:1,rule-2:true:2,rule-3:false:1"; got != want {
+			t.Errorf("TestRule() matches = %s, want %s", got, want)
+		}
+		// The shared email is notified once, through rule-1; disabled endpoints and rules are not notified
+		if len(resp.Notified) != 1 || resp.Notified[0].EndpointID != "ep-1" {
+			t.Errorf("TestRule() notified = %+v, want only ep-1", resp.Notified)
+		}
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		body := `{"client_id": "team-a", "severity": "CRITICAL", "source": "db", "name": "crash"}`
+		w := httptest.NewRecorder()
+		h.TestRule(w, httptest.NewRequest(http.MethodPost, "/api/v1/rules/test", strings.NewReader(body)))
+
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"matches":[]`) || !strings.Contains(w.Body.String(), `"notified":[]`) {
+			t.Errorf("TestRule() = %d %s, want 200 with no matches", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("invalid alert", func(t *testing.T) {
+		body := `{"client_id": "team-a", "severity": "*", "source": "", "name": "crash"}`
+		w := httptest.NewRecorder()
+		h.TestRule(w, httptest.NewRequest(http.MethodPost, "/api/v1/rules/test", strings.NewReader(body)))
+
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"severity"`) || !strings.Contains(w.Body.String(), `"source"`) {
+			t.Errorf("TestRule() = %d %s, want 400 for severity and source", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("client not found", func(t *testing.T) {
+		h := NewHandlersWithDeps(&mockRepository{
+			GetClientFn: func(ctx context.Context, clientID string) (*database.Client, error) {
+				return nil, fmt.Errorf("client not found: %s", clientID)
+			},
+		}, &mockPublisher{}, nil)
+		body := `{"client_id": "missing", "severity": "HIGH", "source": "api", "name": "timeout"}`
+		w := httptest.NewRecorder()
+		h.TestRule(w, httptest.NewRequest(http.MethodPost, "/api/v1/rules/test", strings.NewReader(body)))
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("TestRule() status = %v, want %v", w.Code, http.StatusNotFound)
+		}
+	})
+}
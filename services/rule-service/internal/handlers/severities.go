@@ -0,0 +1,23 @@
+// Package handlers provides HTTP handlers for the rule-service API.
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// SeveritiesResponse lists how severities are presented, most urgent first.
+type SeveritiesResponse struct {
+	Severities []shared.SeverityStyle `json:"severities"`
+}
+
+// ListSeverities returns the color, emoji and label of each severity, as the sender uses them
+// in notifications, so the UI presents severities the same way.
+// GET /api/v1/severities
+func (h *Handlers) ListSeverities(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet) {
+		return
+	}
+	writeJSON(w, http.StatusOK, SeveritiesResponse{Severities: shared.SeverityStyles()})
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+func TestHandlers_ListSeverities(t *testing.T) {
+	h := NewHandlersWithDeps(&mockRepository{}, &mockPublisher{}, nil)
+	w := httptest.NewRecorder()
+	h.ListSeverities(w, httptest.NewRequest(http.MethodGet, "/api/v1/severities", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	var resp SeveritiesResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(resp.Severities) != len(shared.DefaultSeverityStyles) || resp.Severities[0] != shared.SeverityStyleFor("CRITICAL") {
+		t.Errorf("severities = %+v, want the severity styles, CRITICAL first", resp.Severities)
+	}
+
+	w = httptest.NewRecorder()
+	h.ListSeverities(w, httptest.NewRequest(http.MethodPost, "/api/v1/severities", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d, want 405", w.Code)
+	}
+}
@@ -0,0 +1,241 @@
+// Package handlers provides HTTP handlers for the rule-service API.
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// maxTemplateNameLength bounds template names (templates.name).
+const maxTemplateNameLength = 255
+
+// CreateTemplateRequest creates a notification template. Subject and body are Go text/template
+// templates executed with the alert's fields and context (see shared.NotificationTemplateData).
+type CreateTemplateRequest struct {
+	ClientID string `json:"client_id"`
+	Name     string `json:"name"`
+	Subject  string `json:"subject,omitempty"` // email only; empty keeps the default subject
+	Body     string `json:"body"`
+}
+
+// UpdateTemplateRequest changes a notification template. Omitted fields keep their value.
+type UpdateTemplateRequest struct {
+	Name    *string `json:"name,omitempty"`
+	Subject *string `json:"subject,omitempty"`
+	Body    *string `json:"body,omitempty"`
+}
+
+func (req *CreateTemplateRequest) validate(v *shared.Validator) {
+	v.Required("client_id", req.ClientID)
+	validateTemplateName(v, req.Name)
+	validateTemplate(v, req.Subject, req.Body)
+}
+
+func (req *UpdateTemplateRequest) validate(v *shared.Validator) {
+	if req.Name != nil {
+		validateTemplateName(v, *req.Name)
+	}
+}
+
+// validateTemplateName validates a template name.
+func validateTemplateName(v *shared.Validator, name string) {
+	if v.Required("name", name) {
+		v.MaxLength("name", name, maxTemplateNameLength)
+	}
+}
+
+// validateTemplate validates a template subject and body together (see
+// shared.ValidateNotificationTemplate), so the error has no single field.
+func validateTemplate(v *shared.Validator, subject, body string) {
+	if err := shared.ValidateNotificationTemplate(subject, body); err != nil {
+		v.Add("", err.Error())
+	}
+}
+
+// CreateTemplate creates a notification template for a client.
+// POST /api/v1/templates
+func (h *Handlers) CreateTemplate(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	var req CreateTemplateRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if !validateRequest(w, &req) {
+		return
+	}
+
+	tmpl, err := h.db.CreateTemplate(r.Context(), req.ClientID, req.Name, req.Subject, req.Body)
+	if err != nil {
+		if handleDBError(w, err, "template", req.Name) {
+			return
+		}
+		http.Error(w, "Failed to create template: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.metrics.IncrementCustom("templates_created")
+	writeJSON(w, http.StatusCreated, tmpl)
+}
+
+// GetTemplate retrieves a template by ID.
+func (h *Handlers) GetTemplate(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	templateID, ok := requireQueryParam(w, r, "template_id")
+	if !ok {
+		return
+	}
+
+	tmpl, err := h.db.GetTemplate(r.Context(), templateID)
+	if err != nil {
+		if handleDBError(w, err, "template", templateID) {
+			return
+		}
+		http.Error(w, "Failed to get template: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tmpl)
+}
+
+// ListTemplates lists templates, optionally filtered by client_id.
+func (h *Handlers) ListTemplates(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	var clientID *string
+	if c := r.URL.Query().Get("client_id"); c != "" {
+		clientID = &c
+	}
+
+	templates, err := h.db.ListTemplates(r.Context(), clientID)
+	if err != nil {
+		slog.Error("Failed to list templates", "error", err)
+		http.Error(w, "Failed to list templates", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, templates)
+}
+
+// UpdateTemplate changes a template. The endpoints using it render with the new version
+// from their next notification on.
+// PUT /api/v1/templates/update?template_id=
+func (h *Handlers) UpdateTemplate(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPut) {
+		return
+	}
+
+	templateID, ok := requireQueryParam(w, r, "template_id")
+	if !ok {
+		return
+	}
+
+	var req UpdateTemplateRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if !validateRequest(w, &req) {
+		return
+	}
+
+	ctx := r.Context()
+	if req.Subject != nil || req.Body != nil {
+		// Validate the template as it will be stored
+		current, err := h.db.GetTemplate(ctx, templateID)
+		if err != nil {
+			if handleDBError(w, err, "template", templateID) {
+				return
+			}
+			http.Error(w, "Failed to get template: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		subject, body := current.Subject, current.Body
+		if req.Subject != nil {
+			subject = *req.Subject
+		}
+		if req.Body != nil {
+			body = *req.Body
+		}
+		v := shared.NewValidator()
+		validateTemplate(v, subject, body)
+		if err := v.Err(); err != nil {
+			shared.WriteValidationError(w, err)
+			return
+		}
+	}
+
+	tmpl, err := h.db.UpdateTemplate(ctx, templateID, req.Name, req.Subject, req.Body)
+	if err != nil {
+		if handleDBError(w, err, "template", templateID) {
+			return
+		}
+		http.Error(w, "Failed to update template: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tmpl)
+}
+
+// DeleteTemplate deletes a template. Its endpoints revert to the default layout.
+func (h *Handlers) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodDelete) {
+		return
+	}
+
+	templateID, ok := requireQueryParam(w, r, "template_id")
+	if !ok {
+		return
+	}
+
+	if err := h.db.DeleteTemplate(r.Context(), templateID); err != nil {
+		if handleDBError(w, err, "template", templateID) {
+			return
+		}
+		http.Error(w, "Failed to delete template: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// checkEndpointTemplate checks that the template of an endpoint of a rule belongs to the rule's
+// client; validateEndpoint checks that the endpoint type supports templates. An empty templateID
+// is the default layout. It writes an error response and returns false if the check fails.
+func (h *Handlers) checkEndpointTemplate(w http.ResponseWriter, r *http.Request, ruleID, templateID string) bool {
+	if templateID == "" {
+		return true
+	}
+
+	ctx := r.Context()
+	tmpl, err := h.db.GetTemplate(ctx, templateID)
+	if err != nil {
+		if handleDBError(w, err, "template", templateID) {
+			return false
+		}
+		http.Error(w, "Failed to get template: "+err.Error(), http.StatusInternalServerError)
+		return false
+	}
+	rule, err := h.db.GetRule(ctx, ruleID)
+	if err != nil {
+		if handleDBError(w, err, "rule", ruleID) {
+			return false
+		}
+		http.Error(w, "Failed to get rule: "+err.Error(), http.StatusInternalServerError)
+		return false
+	}
+	if tmpl.ClientID != rule.ClientID {
+		http.Error(w, "template belongs to another client", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,242 @@
+// Package handlers provides tests for notification template HTTP handlers.
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"rule-service/internal/database"
+
+	"github.com/afikmenashe/alerting-platform/pkg/metrics"
+)
+
+// TestHandlers_CreateTemplate tests the CreateTemplate handler.
+func TestHandlers_CreateTemplate(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		setupMock      func(*mockRepository)
+		expectedStatus int
+	}{
+		{
+			name:           "successful create",
+			body:           `{"client_id":"client-1","name":"short","subject":"[{{.Severity}}] {{.Name}}","body":"{{.Name}} from {{.Source}}: {{index .Context \"region\"}}"}`,
+			setupMock:      func(m *mockRepository) {},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "missing client_id",
+			body:           `{"name":"short","body":"{{.Name}}"}`,
+			setupMock:      func(m *mockRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "missing name",
+			body:           `{"client_id":"client-1","body":"{{.Name}}"}`,
+			setupMock:      func(m *mockRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "missing body",
+			body:           `{"client_id":"client-1","name":"short"}`,
+			setupMock:      func(m *mockRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "syntax error",
+			body:           `{"client_id":"client-1","name":"short","body":"{{.Name"}`,
+			setupMock:      func(m *mockRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unknown field",
+			body:           `{"client_id":"client-1","name":"short","body":"{{.Title}}"}`,
+			setupMock:      func(m *mockRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "multi-line subject",
+			body:           `{"client_id":"client-1","name":"short","subject":"a\nb","body":"{{.Name}}"}`,
+			setupMock:      func(m *mockRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "duplicate name",
+			body: `{"client_id":"client-1","name":"short","body":"{{.Name}}"}`,
+			setupMock: func(m *mockRepository) {
+				m.CreateTemplateFn = func(ctx context.Context, clientID, name, subject, body string) (*database.Template, error) {
+					return nil, fmt.Errorf("template already exists for client %s with name %s", clientID, name)
+				}
+			},
+			expectedStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := &mockRepository{}
+			tt.setupMock(mockDB)
+
+			h := NewHandlersWithDeps(mockDB, &mockPublisher{}, nil)
+			w := httptest.NewRecorder()
+
+			h.CreateTemplate(w, httptest.NewRequest(http.MethodPost, "/api/v1/templates", bytes.NewBufferString(tt.body)))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("CreateTemplate() status = %v, want %v, body = %s", w.Code, tt.expectedStatus, w.Body.String())
+			}
+		})
+	}
+}
+
+// TestHandlers_CreateTemplate_RecordsMetric tests that created templates are counted.
+func TestHandlers_CreateTemplate_RecordsMetric(t *testing.T) {
+	collector := metrics.NewMemoryCollector("rule-service")
+	h := NewHandlersWithDeps(&mockRepository{}, &mockPublisher{}, collector)
+
+	h.CreateTemplate(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/v1/templates", bytes.NewBufferString(`{"client_id":"client-1","name":"short","body":"{{.Name}}"}`)))
+	h.CreateTemplate(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/v1/templates", bytes.NewBufferString(`{"client_id":"client-1","name":"short"}`)))
+
+	if got := collector.Custom("templates_created"); got != 1 {
+		t.Errorf("templates_created = %d, want 1", got)
+	}
+	if h.GetMetricsCollector() != collector {
+		t.Error("GetMetricsCollector() should return the collector for the middleware")
+	}
+}
+
+// TestHandlers_UpdateTemplate tests that an update is validated against the stored template.
+func TestHandlers_UpdateTemplate(t *testing.T) {
+	t.Run("valid body", func(t *testing.T) {
+		var gotBody *string
+		mockDB := &mockRepository{
+			UpdateTemplateFn: func(ctx context.Context, templateID string, name, subject, body *string) (*database.Template, error) {
+				gotBody = body
+				return &database.Template{TemplateID: templateID, Body: *body}, nil
+			},
+		}
+
+		h := NewHandlersWithDeps(mockDB, &mockPublisher{}, nil)
+		w := httptest.NewRecorder()
+		h.UpdateTemplate(w, httptest.NewRequest(http.MethodPut, "/api/v1/templates/update?template_id=template-1", bytes.NewBufferString(`{"body":"{{upper .Severity}}"}`)))
+
+		if w.Code != http.StatusOK || gotBody == nil || *gotBody != "{{upper .Severity}}" {
+			t.Errorf("UpdateTemplate() status = %v, body = %v, want 200 and the new body", w.Code, gotBody)
+		}
+	})
+
+	t.Run("invalid subject", func(t *testing.T) {
+		h := NewHandlersWithDeps(&mockRepository{}, &mockPublisher{}, nil)
+		w := httptest.NewRecorder()
+		h.UpdateTemplate(w, httptest.NewRequest(http.MethodPut, "/api/v1/templates/update?template_id=template-1", bytes.NewBufferString(`{"subject":"{{.Missing}}"}`)))
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("UpdateTemplate() status = %v, want %v", w.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockDB := &mockRepository{
+			GetTemplateFn: func(ctx context.Context, templateID string) (*database.Template, error) {
+				return nil, fmt.Errorf("template not found: %s", templateID)
+			},
+		}
+
+		h := NewHandlersWithDeps(mockDB, &mockPublisher{}, nil)
+		w := httptest.NewRecorder()
+		h.UpdateTemplate(w, httptest.NewRequest(http.MethodPut, "/api/v1/templates/update?template_id=missing", bytes.NewBufferString(`{"body":"{{.Name}}"}`)))
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("UpdateTemplate() status = %v, want %v", w.Code, http.StatusNotFound)
+		}
+	})
+}
+
+// TestHandlers_DeleteTemplate tests the DeleteTemplate handler.
+func TestHandlers_DeleteTemplate(t *testing.T) {
+	h := NewHandlersWithDeps(&mockRepository{}, &mockPublisher{}, nil)
+	w := httptest.NewRecorder()
+
+	h.DeleteTemplate(w, httptest.NewRequest(http.MethodDelete, "/api/v1/templates/delete?template_id=template-1", nil))
+
+	if w.Code != http.StatusNoContent {
+		t.Errorf("DeleteTemplate() status = %v, want %v", w.Code, http.StatusNoContent)
+	}
+}
+
+// TestHandlers_EndpointTemplate tests assigning templates to endpoints.
+func TestHandlers_EndpointTemplate(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		templateClient string
+		expectedStatus int
+	}{
+		{
+			name:           "email endpoint",
+			body:           `{"rule_id":"rule-1","type":"email","value":"oncall@example.com","template_id":"template-1"}`,
+			templateClient: "client-1",
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "webhook endpoint",
+			body:           `{"rule_id":"rule-1","type":"webhook","value":"https://hooks.example.com","template_id":"template-1"}`,
+			templateClient: "client-1",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "template of another client",
+			body:           `{"rule_id":"rule-1","type":"slack","value":"https://hooks.slack.com/x","template_id":"template-1"}`,
+			templateClient: "client-2",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stored string
+			mockDB := &mockRepository{
+				GetTemplateFn: func(ctx context.Context, templateID string) (*database.Template, error) {
+					return &database.Template{TemplateID: templateID, ClientID: tt.templateClient}, nil
+				},
+				CreateEndpointFn: func(ctx context.Context, ruleID, endpointType, value string, metadata database.EndpointMetadata, templateID string) (*database.Endpoint, error) {
+					stored = templateID
+					return &database.Endpoint{EndpointID: "endpoint-1", RuleID: ruleID, Type: endpointType, Value: value, TemplateID: templateID}, nil
+				},
+			}
+
+			h := NewHandlersWithDeps(mockDB, &mockPublisher{}, nil)
+			w := httptest.NewRecorder()
+			h.CreateEndpoint(w, httptest.NewRequest(http.MethodPost, "/api/v1/endpoints", bytes.NewBufferString(tt.body)))
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("CreateEndpoint() status = %v, want %v, body = %s", w.Code, tt.expectedStatus, w.Body.String())
+			}
+			if tt.expectedStatus == http.StatusCreated && stored != "template-1" {
+				t.Errorf("CreateEndpoint() stored template = %q, want template-1", stored)
+			}
+		})
+	}
+
+	t.Run("update clears template", func(t *testing.T) {
+		var stored *string
+		mockDB := &mockRepository{
+			UpdateEndpointFn: func(ctx context.Context, endpointID, endpointType, value string, metadata *database.EndpointMetadata, templateID *string) (*database.Endpoint, error) {
+				stored = templateID
+				return &database.Endpoint{EndpointID: endpointID, Type: endpointType, Value: value}, nil
+			},
+		}
+
+		h := NewHandlersWithDeps(mockDB, &mockPublisher{}, nil)
+		w := httptest.NewRecorder()
+		h.UpdateEndpoint(w, httptest.NewRequest(http.MethodPut, "/api/v1/endpoints/update?endpoint_id=endpoint-1", bytes.NewBufferString(`{"type":"email","value":"oncall@example.com","template_id":""}`)))
+
+		if w.Code != http.StatusOK || stored == nil || *stored != "" {
+			t.Errorf("UpdateEndpoint() status = %v, template = %v, want 200 and an empty template", w.Code, stored)
+		}
+	})
+}
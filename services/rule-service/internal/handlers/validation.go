@@ -2,38 +2,38 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"slices"
 	"strconv"
+	"time"
+
+	"rule-service/internal/database"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
 )
 
 // Keep validation logic centralized to avoid divergence across endpoints.
 
-var validSeverities = map[string]struct{}{
-	"LOW":      {},
-	"MEDIUM":   {},
-	"HIGH":     {},
-	"CRITICAL": {},
-	"*":        {}, // wildcard
-}
+// alertSeverities are the severities of alerts.
+var alertSeverities = []string{"LOW", "MEDIUM", "HIGH", "CRITICAL"}
+
+// ruleSeverities are the severities a rule can match: an alert severity or the wildcard.
+var ruleSeverities = append(slices.Clone(alertSeverities), "*")
 
 func isValidSeverity(severity string) bool {
-	_, ok := validSeverities[severity]
-	return ok
+	return slices.Contains(ruleSeverities, severity)
 }
 
 func isAllWildcards(severity, source, name string) bool {
 	return severity == "*" && source == "*" && name == "*"
 }
 
-var validEndpointTypes = map[string]struct{}{
-	"email":   {},
-	"webhook": {},
-	"slack":   {},
-}
+// endpointTypes are the supported notification endpoint types.
+var endpointTypes = []string{"email", "webhook", "slack"}
 
 func isValidEndpointType(t string) bool {
-	_, ok := validEndpointTypes[t]
-	return ok
+	return slices.Contains(endpointTypes, t)
 }
 
 // HTTP helper functions to reduce duplication across handlers.
@@ -52,12 +52,34 @@ func requireMethod(w http.ResponseWriter, r *http.Request, method string) bool {
 // Returns true on success, false on error (and writes error response).
 func decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
 	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			return false
+		}
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return false
 	}
 	return true
 }
 
+// requestValidator is implemented by request bodies, which record their invalid fields in v.
+type requestValidator interface {
+	validate(v *shared.Validator)
+}
+
+// validateRequest validates a decoded request body.
+// Returns true if valid, false otherwise (and writes a 400 response listing every invalid field).
+func validateRequest(w http.ResponseWriter, req requestValidator) bool {
+	v := shared.NewValidator()
+	req.validate(v)
+	if err := v.Err(); err != nil {
+		shared.WriteValidationError(w, err)
+		return false
+	}
+	return true
+}
+
 // writeJSON writes the value as JSON with appropriate headers.
 func writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
 	w.Header().Set("Content-Type", "application/json")
@@ -104,3 +126,44 @@ func parsePagination(r *http.Request) Pagination {
 
 	return p
 }
+
+// parseRuleFilter parses the ListRules query filters.
+// Writes a 400 response and returns false if a filter value is invalid.
+func parseRuleFilter(w http.ResponseWriter, r *http.Request) (database.RuleFilter, bool) {
+	q := r.URL.Query()
+	var filter database.RuleFilter
+
+	if v := q.Get("client_id"); v != "" {
+		filter.ClientID = &v
+	}
+	if v := q.Get("enabled"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			http.Error(w, "enabled must be true or false", http.StatusBadRequest)
+			return filter, false
+		}
+		filter.Enabled = &enabled
+	}
+	if v := q.Get("severity"); v != "" {
+		if !isValidSeverity(v) {
+			http.Error(w, "severity must be one of: LOW, MEDIUM, HIGH, CRITICAL, *", http.StatusBadRequest)
+			return filter, false
+		}
+		filter.Severity = &v
+	}
+	if v := q.Get("source"); v != "" {
+		filter.Source = &v
+	}
+	if v := q.Get("name"); v != "" {
+		filter.NameContains = &v
+	}
+	if v := q.Get("updated_since"); v != "" {
+		since, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			http.Error(w, "updated_since must be an RFC 3339 timestamp", http.StatusBadRequest)
+			return filter, false
+		}
+		filter.UpdatedSince = &since
+	}
+	return filter, true
+}
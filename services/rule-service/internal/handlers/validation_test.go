@@ -2,9 +2,14 @@
 package handlers
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
 )
 
 func TestParsePagination(t *testing.T) {
@@ -124,3 +129,42 @@ func TestIsAllWildcards(t *testing.T) {
 		t.Error("isAllWildcards(*, source, *) = true, want false")
 	}
 }
+
+func TestDecodeJSON_BodyTooLarge(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/rules", strings.NewReader(`{"name": "`+strings.Repeat("x", 64)+`"}`))
+	w := httptest.NewRecorder()
+	req.Body = http.MaxBytesReader(w, req.Body, 16)
+
+	var v map[string]string
+	if decodeJSON(w, req, &v) {
+		t.Fatal("decodeJSON() = true, want false")
+	}
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+	}
+}
+
+func TestValidateRequest_FieldErrors(t *testing.T) {
+	h := NewHandlersWithDeps(&mockRepository{}, &mockPublisher{}, nil)
+	body := `{"client_id":"client-1","severity":"SEVERE","name":"*","exclude_names":["*"],"conditions":[{"key":"","op":"==","value":"eu"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/rules", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	h.CreateRule(w, req)
+
+	if w.Code != http.StatusBadRequest || w.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("status = %d, Content-Type = %q, want 400 JSON", w.Code, w.Header().Get("Content-Type"))
+	}
+	var resp shared.ValidationError
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	var fields []string
+	for _, fe := range resp.Errors {
+		fields = append(fields, fe.Field)
+	}
+	want := []string{"source", "severity", "exclude_names[0]", "conditions[0].key"}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("fields = %v, want %v (errors %+v)", fields, want, resp.Errors)
+	}
+}
@@ -0,0 +1,299 @@
+// Package handlers provides HTTP handlers for the rule-service API.
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"rule-service/internal/database"
+	"rule-service/internal/metawebhook"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// Meta-webhook bounds.
+const (
+	maxWebhooksPerClient     = 10
+	maxWebhookURLLength      = 2048
+	defaultWebhookDeliveries = 50
+	maxWebhookDeliveries     = 200
+	webhookSecretBytes       = 32
+)
+
+// CreateWebhookRequest registers a meta-webhook.
+type CreateWebhookRequest struct {
+	ClientID   string   `json:"client_id"`
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types,omitempty"` // empty subscribes to every event type
+}
+
+// UpdateWebhookRequest changes a meta-webhook. Omitted fields keep their value.
+type UpdateWebhookRequest struct {
+	URL        *string   `json:"url,omitempty"`
+	EventTypes *[]string `json:"event_types,omitempty"`
+	Enabled    *bool     `json:"enabled,omitempty"`
+}
+
+func (req *CreateWebhookRequest) validate(v *shared.Validator) {
+	v.Required("client_id", req.ClientID)
+	validateWebhookURL(v, req.URL)
+	validateEventTypes(v, req.EventTypes)
+}
+
+func (req *UpdateWebhookRequest) validate(v *shared.Validator) {
+	if req.URL != nil {
+		validateWebhookURL(v, *req.URL)
+	}
+	if req.EventTypes != nil {
+		validateEventTypes(v, *req.EventTypes)
+	}
+}
+
+// CreateWebhookResponse is the created meta-webhook with its signing secret,
+// which is only ever returned here.
+type CreateWebhookResponse struct {
+	*database.ClientWebhook
+	Secret string `json:"secret"`
+}
+
+// requireWebhooks writes a 501 response and returns false if meta-webhooks are not configured.
+func (h *Handlers) requireWebhooks(w http.ResponseWriter) bool {
+	if h.webhooks == nil {
+		http.Error(w, "Meta-webhooks are not configured", http.StatusNotImplemented)
+		return false
+	}
+	return true
+}
+
+// CreateWebhook registers a meta-webhook for a client and returns its signing secret.
+// POST /api/v1/webhooks
+func (h *Handlers) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost) || !h.requireWebhooks(w) {
+		return
+	}
+
+	var req CreateWebhookRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if !validateRequest(w, &req) {
+		return
+	}
+	eventTypes := dedupeEventTypes(req.EventTypes)
+	if h.secrets == nil {
+		http.Error(w, "meta-webhooks require ENDPOINT_SECRET_KEY to be configured", http.StatusBadRequest)
+		return
+	}
+
+	existing, err := h.webhooks.ListWebhooks(r.Context(), &req.ClientID)
+	if err != nil {
+		slog.Error("Failed to list webhooks", "error", err)
+		http.Error(w, "Failed to create webhook", http.StatusInternalServerError)
+		return
+	}
+	if len(existing) >= maxWebhooksPerClient {
+		http.Error(w, fmt.Sprintf("a client can have at most %d webhooks", maxWebhooksPerClient), http.StatusBadRequest)
+		return
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		slog.Error("Failed to generate webhook secret", "error", err)
+		http.Error(w, "Failed to create webhook", http.StatusInternalServerError)
+		return
+	}
+	sealed, err := h.secrets.Encrypt(secret)
+	if err != nil {
+		slog.Error("Failed to encrypt webhook secret", "error", err)
+		http.Error(w, "Failed to create webhook", http.StatusInternalServerError)
+		return
+	}
+
+	wh, err := h.webhooks.CreateWebhook(r.Context(), req.ClientID, req.URL, sealed, eventTypes)
+	if err != nil {
+		if handleDBError(w, err, "webhook", req.ClientID) {
+			return
+		}
+	}
+
+	h.metrics.IncrementCustom("webhooks_created")
+	writeJSON(w, http.StatusCreated, CreateWebhookResponse{ClientWebhook: wh, Secret: secret})
+}
+
+// GetWebhook retrieves a meta-webhook by ID.
+// GET /api/v1/webhooks?webhook_id=
+func (h *Handlers) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet) || !h.requireWebhooks(w) {
+		return
+	}
+
+	webhookID, ok := requireQueryParam(w, r, "webhook_id")
+	if !ok {
+		return
+	}
+
+	wh, err := h.webhooks.GetWebhook(r.Context(), webhookID)
+	if err != nil {
+		if handleDBError(w, err, "webhook", webhookID) {
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, wh)
+}
+
+// ListWebhooks lists meta-webhooks, optionally filtered by client_id.
+// GET /api/v1/webhooks?client_id=
+func (h *Handlers) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet) || !h.requireWebhooks(w) {
+		return
+	}
+
+	var clientID *string
+	if c := r.URL.Query().Get("client_id"); c != "" {
+		clientID = &c
+	}
+
+	webhooks, err := h.webhooks.ListWebhooks(r.Context(), clientID)
+	if err != nil {
+		slog.Error("Failed to list webhooks", "error", err)
+		http.Error(w, "Failed to list webhooks", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, webhooks)
+}
+
+// UpdateWebhook changes the URL, event types or enabled state of a meta-webhook.
+// PUT /api/v1/webhooks/update?webhook_id=
+func (h *Handlers) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPut) || !h.requireWebhooks(w) {
+		return
+	}
+
+	webhookID, ok := requireQueryParam(w, r, "webhook_id")
+	if !ok {
+		return
+	}
+
+	var req UpdateWebhookRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if !validateRequest(w, &req) {
+		return
+	}
+	if req.EventTypes != nil {
+		eventTypes := dedupeEventTypes(*req.EventTypes)
+		req.EventTypes = &eventTypes
+	}
+
+	wh, err := h.webhooks.UpdateWebhook(r.Context(), webhookID, req.URL, req.EventTypes, req.Enabled)
+	if err != nil {
+		if handleDBError(w, err, "webhook", webhookID) {
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, wh)
+}
+
+// DeleteWebhook deletes a meta-webhook and its deliveries.
+// DELETE /api/v1/webhooks/delete?webhook_id=
+func (h *Handlers) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodDelete) || !h.requireWebhooks(w) {
+		return
+	}
+
+	webhookID, ok := requireQueryParam(w, r, "webhook_id")
+	if !ok {
+		return
+	}
+
+	if err := h.webhooks.DeleteWebhook(r.Context(), webhookID); err != nil {
+		if handleDBError(w, err, "webhook", webhookID) {
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListWebhookDeliveries lists the latest deliveries of a meta-webhook, newest first.
+// GET /api/v1/webhooks/deliveries?webhook_id=&limit=
+func (h *Handlers) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet) || !h.requireWebhooks(w) {
+		return
+	}
+
+	webhookID, ok := requireQueryParam(w, r, "webhook_id")
+	if !ok {
+		return
+	}
+	limit := defaultWebhookDeliveries
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxWebhookDeliveries {
+			http.Error(w, fmt.Sprintf("limit must be between 1 and %d", maxWebhookDeliveries), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	if _, err := h.webhooks.GetWebhook(r.Context(), webhookID); err != nil {
+		if handleDBError(w, err, "webhook", webhookID) {
+			return
+		}
+	}
+	deliveries, err := h.webhooks.ListWebhookDeliveries(r.Context(), webhookID, limit)
+	if err != nil {
+		slog.Error("Failed to list webhook deliveries", "error", err)
+		http.Error(w, "Failed to list webhook deliveries", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, deliveries)
+}
+
+// validateWebhookURL validates a meta-webhook URL: an absolute http or https URL.
+func validateWebhookURL(v *shared.Validator, raw string) {
+	if !v.Required("url", raw) || !v.MaxLength("url", raw, maxWebhookURLLength) {
+		return
+	}
+	u, err := url.Parse(raw)
+	v.Check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", "url", "url must be an absolute http or https URL")
+}
+
+// validateEventTypes validates meta-webhook event types.
+func validateEventTypes(v *shared.Validator, types []string) {
+	for i, t := range types {
+		v.Check(metawebhook.IsEventType(t), fmt.Sprintf("event_types[%d]", i), fmt.Sprintf("unknown event type %q", t))
+	}
+}
+
+// dedupeEventTypes removes duplicate event types.
+func dedupeEventTypes(types []string) []string {
+	seen := make(map[string]bool, len(types))
+	deduped := make([]string, 0, len(types))
+	for _, t := range types {
+		if !seen[t] {
+			seen[t] = true
+			deduped = append(deduped, t)
+		}
+	}
+	return deduped
+}
+
+// newWebhookSecret returns a random hex signing secret.
+func newWebhookSecret() (string, error) {
+	b := make([]byte, webhookSecretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,299 @@
+// Package handlers provides tests for the meta-webhook endpoints.
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"rule-service/internal/database"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// fakeWebhookStore is an in-memory WebhookStore.
+type fakeWebhookStore struct {
+	webhooks   map[string]*database.ClientWebhook
+	deliveries []*database.WebhookDelivery
+	limit      int // limit passed to ListWebhookDeliveries
+}
+
+func newFakeWebhookStore() *fakeWebhookStore {
+	return &fakeWebhookStore{webhooks: make(map[string]*database.ClientWebhook)}
+}
+
+func (f *fakeWebhookStore) CreateWebhook(ctx context.Context, clientID, url, secret string, eventTypes []string) (*database.ClientWebhook, error) {
+	if clientID == "missing" {
+		return nil, fmt.Errorf("client not found: %s", clientID)
+	}
+	wh := &database.ClientWebhook{
+		WebhookID:  fmt.Sprintf("wh-%d", len(f.webhooks)+1),
+		ClientID:   clientID,
+		URL:        url,
+		Secret:     secret,
+		EventTypes: eventTypes,
+		Enabled:    true,
+	}
+	f.webhooks[wh.WebhookID] = wh
+	return wh, nil
+}
+
+func (f *fakeWebhookStore) GetWebhook(ctx context.Context, webhookID string) (*database.ClientWebhook, error) {
+	wh, ok := f.webhooks[webhookID]
+	if !ok {
+		return nil, fmt.Errorf("webhook not found: %s", webhookID)
+	}
+	return wh, nil
+}
+
+func (f *fakeWebhookStore) ListWebhooks(ctx context.Context, clientID *string) ([]*database.ClientWebhook, error) {
+	var out []*database.ClientWebhook
+	for _, wh := range f.webhooks {
+		if clientID == nil || wh.ClientID == *clientID {
+			out = append(out, wh)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeWebhookStore) UpdateWebhook(ctx context.Context, webhookID string, url *string, eventTypes *[]string, enabled *bool) (*database.ClientWebhook, error) {
+	wh, ok := f.webhooks[webhookID]
+	if !ok {
+		return nil, fmt.Errorf("webhook not found: %s", webhookID)
+	}
+	if url != nil {
+		wh.URL = *url
+	}
+	if eventTypes != nil {
+		wh.EventTypes = *eventTypes
+	}
+	if enabled != nil {
+		wh.Enabled = *enabled
+	}
+	return wh, nil
+}
+
+func (f *fakeWebhookStore) DeleteWebhook(ctx context.Context, webhookID string) error {
+	if _, ok := f.webhooks[webhookID]; !ok {
+		return fmt.Errorf("webhook not found: %s", webhookID)
+	}
+	delete(f.webhooks, webhookID)
+	return nil
+}
+
+func (f *fakeWebhookStore) ListWebhookDeliveries(ctx context.Context, webhookID string, limit int) ([]*database.WebhookDelivery, error) {
+	f.limit = limit
+	return f.deliveries, nil
+}
+
+// newWebhookHandlers returns handlers backed by store, with secrets enabled.
+func newWebhookHandlers(t *testing.T, store WebhookStore) (*Handlers, *shared.SecretBox) {
+	t.Helper()
+	box, err := shared.NewSecretBox("test-key")
+	if err != nil {
+		t.Fatalf("NewSecretBox() error = %v", err)
+	}
+	h := NewHandlersWithDeps(&mockRepository{}, &mockPublisher{}, nil)
+	WithSecretBox(box)(h)
+	WithWebhooks(store)(h)
+	return h, box
+}
+
+// TestHandlers_CreateWebhook tests that a webhook is created with an encrypted secret,
+// returned in plain text once.
+func TestHandlers_CreateWebhook(t *testing.T) {
+	store := newFakeWebhookStore()
+	h, box := newWebhookHandlers(t, store)
+
+	body := `{"client_id":"client-1","url":"https://hooks.example.com/platform","event_types":["rule.created","rule.created","endpoint.disabled"]}`
+	w := httptest.NewRecorder()
+	h.CreateWebhook(w, httptest.NewRequest(http.MethodPost, "/api/v1/webhooks", strings.NewReader(body)))
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("CreateWebhook() status = %v, want %v: %s", w.Code, http.StatusCreated, w.Body.String())
+	}
+	var resp map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	secret, _ := resp["secret"].(string)
+	if len(secret) != 2*webhookSecretBytes {
+		t.Fatalf("secret = %q, want %d hex characters", secret, 2*webhookSecretBytes)
+	}
+
+	stored := store.webhooks["wh-1"]
+	if stored.Secret == secret {
+		t.Error("secret stored in plain text")
+	}
+	if opened, err := box.Decrypt(stored.Secret); err != nil || opened != secret {
+		t.Errorf("Decrypt(stored secret) = %q, %v, want the returned secret", opened, err)
+	}
+	if got := strings.Join(stored.EventTypes, ","); got != "rule.created,endpoint.disabled" {
+		t.Errorf("event types = %q, want duplicates removed", got)
+	}
+}
+
+// TestHandlers_CreateWebhook_Validation tests rejected webhook registrations.
+func TestHandlers_CreateWebhook_Validation(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"missing client", `{"url":"https://example.com"}`, http.StatusBadRequest, "client_id is required"},
+		{"missing url", `{"client_id":"client-1"}`, http.StatusBadRequest, "url is required"},
+		{"relative url", `{"client_id":"client-1","url":"/hooks"}`, http.StatusBadRequest, "absolute http or https"},
+		{"unsupported scheme", `{"client_id":"client-1","url":"ftp://example.com"}`, http.StatusBadRequest, "absolute http or https"},
+		{"unknown event type", `{"client_id":"client-1","url":"https://example.com","event_types":["rule.renamed"]}`, http.StatusBadRequest, "unknown event type"},
+		{"unknown client", `{"client_id":"missing","url":"https://example.com"}`, http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := newWebhookHandlers(t, newFakeWebhookStore())
+			w := httptest.NewRecorder()
+			h.CreateWebhook(w, httptest.NewRequest(http.MethodPost, "/api/v1/webhooks", strings.NewReader(tt.body)))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("CreateWebhook() status = %v, want %v: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("CreateWebhook() body = %q, want it to contain %q", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+// TestHandlers_CreateWebhook_Limit tests the per-client webhook limit.
+func TestHandlers_CreateWebhook_Limit(t *testing.T) {
+	store := newFakeWebhookStore()
+	for i := 0; i < maxWebhooksPerClient; i++ {
+		store.CreateWebhook(context.Background(), "client-1", "https://example.com", "sealed", nil)
+	}
+	h, _ := newWebhookHandlers(t, store)
+
+	w := httptest.NewRecorder()
+	h.CreateWebhook(w, httptest.NewRequest(http.MethodPost, "/api/v1/webhooks", strings.NewReader(`{"client_id":"client-1","url":"https://example.com"}`)))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("CreateWebhook() status = %v, want %v", w.Code, http.StatusBadRequest)
+	}
+}
+
+// TestHandlers_CreateWebhook_NoSecretKey tests that webhooks need the secret key.
+func TestHandlers_CreateWebhook_NoSecretKey(t *testing.T) {
+	h := NewHandlersWithDeps(&mockRepository{}, &mockPublisher{}, nil)
+	WithWebhooks(newFakeWebhookStore())(h)
+
+	w := httptest.NewRecorder()
+	h.CreateWebhook(w, httptest.NewRequest(http.MethodPost, "/api/v1/webhooks", strings.NewReader(`{"client_id":"client-1","url":"https://example.com"}`)))
+
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "ENDPOINT_SECRET_KEY") {
+		t.Errorf("CreateWebhook() = %v %q, want 400 mentioning ENDPOINT_SECRET_KEY", w.Code, w.Body.String())
+	}
+}
+
+// TestHandlers_Webhooks_NotConfigured tests that the endpoints report 501 without a store.
+func TestHandlers_Webhooks_NotConfigured(t *testing.T) {
+	h := NewHandlersWithDeps(&mockRepository{}, &mockPublisher{}, nil)
+
+	w := httptest.NewRecorder()
+	h.ListWebhooks(w, httptest.NewRequest(http.MethodGet, "/api/v1/webhooks", nil))
+
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("ListWebhooks() status = %v, want %v", w.Code, http.StatusNotImplemented)
+	}
+}
+
+// TestHandlers_UpdateWebhook tests disabling a webhook and replacing its event types.
+func TestHandlers_UpdateWebhook(t *testing.T) {
+	store := newFakeWebhookStore()
+	store.CreateWebhook(context.Background(), "client-1", "https://example.com", "sealed", nil)
+	h, _ := newWebhookHandlers(t, store)
+
+	w := httptest.NewRecorder()
+	h.UpdateWebhook(w, httptest.NewRequest(http.MethodPut, "/api/v1/webhooks/update?webhook_id=wh-1", strings.NewReader(`{"enabled":false,"event_types":["rule.deleted"]}`)))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("UpdateWebhook() status = %v, want %v: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	wh := store.webhooks["wh-1"]
+	if wh.Enabled || len(wh.EventTypes) != 1 || wh.URL != "https://example.com" {
+		t.Errorf("webhook = %+v, want disabled with one event type and the same URL", wh)
+	}
+	if strings.Contains(w.Body.String(), "sealed") {
+		t.Error("UpdateWebhook() response exposes the stored secret")
+	}
+
+	w = httptest.NewRecorder()
+	h.UpdateWebhook(w, httptest.NewRequest(http.MethodPut, "/api/v1/webhooks/update?webhook_id=wh-1", strings.NewReader(`{"url":"mailto:ops@example.com"}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("UpdateWebhook() with invalid url status = %v, want %v", w.Code, http.StatusBadRequest)
+	}
+
+	w = httptest.NewRecorder()
+	h.UpdateWebhook(w, httptest.NewRequest(http.MethodPut, "/api/v1/webhooks/update?webhook_id=wh-9", strings.NewReader(`{"enabled":true}`)))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("UpdateWebhook() of unknown webhook status = %v, want %v", w.Code, http.StatusNotFound)
+	}
+}
+
+// TestHandlers_DeleteWebhook tests deleting a webhook.
+func TestHandlers_DeleteWebhook(t *testing.T) {
+	store := newFakeWebhookStore()
+	store.CreateWebhook(context.Background(), "client-1", "https://example.com", "sealed", nil)
+	h, _ := newWebhookHandlers(t, store)
+
+	w := httptest.NewRecorder()
+	h.DeleteWebhook(w, httptest.NewRequest(http.MethodDelete, "/api/v1/webhooks/delete?webhook_id=wh-1", nil))
+	if w.Code != http.StatusNoContent {
+		t.Errorf("DeleteWebhook() status = %v, want %v", w.Code, http.StatusNoContent)
+	}
+
+	w = httptest.NewRecorder()
+	h.DeleteWebhook(w, httptest.NewRequest(http.MethodDelete, "/api/v1/webhooks/delete?webhook_id=wh-1", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("DeleteWebhook() twice status = %v, want %v", w.Code, http.StatusNotFound)
+	}
+}
+
+// TestHandlers_ListWebhookDeliveries tests the delivery log limit handling.
+func TestHandlers_ListWebhookDeliveries(t *testing.T) {
+	store := newFakeWebhookStore()
+	store.CreateWebhook(context.Background(), "client-1", "https://example.com", "sealed", nil)
+	store.deliveries = []*database.WebhookDelivery{{DeliveryID: 1, WebhookID: "wh-1", EventType: "rule.created"}}
+	h, _ := newWebhookHandlers(t, store)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantLimit  int
+	}{
+		{"default limit", "webhook_id=wh-1", http.StatusOK, defaultWebhookDeliveries},
+		{"explicit limit", "webhook_id=wh-1&limit=5", http.StatusOK, 5},
+		{"limit too large", "webhook_id=wh-1&limit=1000", http.StatusBadRequest, 0},
+		{"unknown webhook", "webhook_id=wh-9", http.StatusNotFound, 0},
+		{"missing webhook_id", "", http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store.limit = 0
+			w := httptest.NewRecorder()
+			h.ListWebhookDeliveries(w, httptest.NewRequest(http.MethodGet, "/api/v1/webhooks/deliveries?"+tt.query, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("ListWebhookDeliveries() status = %v, want %v", w.Code, tt.wantStatus)
+			}
+			if store.limit != tt.wantLimit {
+				t.Errorf("limit = %d, want %d", store.limit, tt.wantLimit)
+			}
+		})
+	}
+}
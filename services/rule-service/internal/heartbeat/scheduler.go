@@ -0,0 +1,150 @@
+// Package heartbeat implements the dead-man's-switch scheduler.
+// It periodically finds heartbeats whose pings stopped arriving and injects a synthetic
+// alert into alerts.new, so the absence of events can trigger notifications like any other alert.
+package heartbeat
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"rule-service/internal/database"
+	"rule-service/internal/events"
+
+	"github.com/afikmenashe/alerting-platform/pkg/ids"
+)
+
+// DefaultCheckInterval is how often the scheduler scans for missed heartbeats.
+const DefaultCheckInterval = 15 * time.Second
+
+// alertSchemaVersion is the alerts.new schema version of injected alerts.
+const alertSchemaVersion = 1
+
+// Store provides the heartbeat persistence needed by the scheduler.
+type Store interface {
+	// ClaimMissedHeartbeats atomically marks overdue heartbeats as alerted and returns them.
+	ClaimMissedHeartbeats(ctx context.Context) ([]*database.Heartbeat, error)
+	// ReleaseHeartbeatClaim clears the alerted state so the heartbeat is retried on the next scan.
+	ReleaseHeartbeatClaim(ctx context.Context, heartbeatID string) error
+}
+
+// AlertPublisher publishes synthetic alerts to alerts.new.
+type AlertPublisher interface {
+	PublishAlert(ctx context.Context, alert *events.AlertNew) error
+}
+
+// MetricsRecorder is the subset of metrics used by the scheduler.
+type MetricsRecorder interface {
+	IncrementCustom(name string)
+}
+
+// noOpMetrics is used when no metrics recorder is provided.
+type noOpMetrics struct{}
+
+func (noOpMetrics) IncrementCustom(string) {}
+
+// Scheduler detects missed heartbeats and injects synthetic alerts.
+type Scheduler struct {
+	store     Store
+	publisher AlertPublisher
+	metrics   MetricsRecorder
+	interval  time.Duration
+	now       func() time.Time
+}
+
+// NewScheduler creates a scheduler that scans every interval.
+// A non-positive interval uses DefaultCheckInterval; a nil metrics recorder is replaced with a no-op.
+func NewScheduler(store Store, publisher AlertPublisher, m MetricsRecorder, interval time.Duration) *Scheduler {
+	if interval <= 0 {
+		interval = DefaultCheckInterval
+	}
+	if m == nil {
+		m = noOpMetrics{}
+	}
+	return &Scheduler{
+		store:     store,
+		publisher: publisher,
+		metrics:   m,
+		interval:  interval,
+		now:       time.Now,
+	}
+}
+
+// Run scans for missed heartbeats until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	slog.Info("Starting heartbeat scheduler", "check_interval", s.interval)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Heartbeat scheduler stopped")
+			return
+		case <-ticker.C:
+			s.CheckOnce(ctx)
+		}
+	}
+}
+
+// CheckOnce performs a single scan and returns the number of synthetic alerts published.
+func (s *Scheduler) CheckOnce(ctx context.Context) int {
+	missed, err := s.store.ClaimMissedHeartbeats(ctx)
+	if err != nil {
+		slog.Error("Failed to check for missed heartbeats", "error", err)
+		return 0
+	}
+
+	published := 0
+	for _, hb := range missed {
+		alert := s.buildAlert(hb)
+		if err := s.publisher.PublishAlert(ctx, alert); err != nil {
+			slog.Error("Failed to publish missed heartbeat alert",
+				"heartbeat_id", hb.HeartbeatID,
+				"client_id", hb.ClientID,
+				"error", err,
+			)
+			s.metrics.IncrementCustom("heartbeat_alert_errors")
+			// Release the claim so the next scan retries this heartbeat
+			if err := s.store.ReleaseHeartbeatClaim(ctx, hb.HeartbeatID); err != nil {
+				slog.Error("Failed to release heartbeat claim", "heartbeat_id", hb.HeartbeatID, "error", err)
+			}
+			continue
+		}
+
+		published++
+		s.metrics.IncrementCustom("heartbeats_missed")
+		slog.Warn("Heartbeat missed, injected synthetic alert",
+			"heartbeat_id", hb.HeartbeatID,
+			"client_id", hb.ClientID,
+			"alert_id", alert.AlertID,
+			"last_ping_at", hb.LastPingAt,
+		)
+	}
+	return published
+}
+
+// buildAlert creates the synthetic alert for a missed heartbeat.
+// The context carries enough detail for the notification to explain what went silent.
+// The alert is hinted for the heartbeat's client so it never matches other clients' rules.
+func (s *Scheduler) buildAlert(hb *database.Heartbeat) *events.AlertNew {
+	now := s.now()
+	return &events.AlertNew{
+		AlertID:       ids.NewAt(now),
+		SchemaVersion: alertSchemaVersion,
+		EventTS:       now.Unix(),
+		Severity:      hb.Severity,
+		Source:        hb.Source,
+		Name:          hb.Name,
+		Context: map[string]string{
+			"synthetic":        "heartbeat",
+			"heartbeat_id":     hb.HeartbeatID,
+			"client_id":        hb.ClientID,
+			"interval_seconds": strconv.Itoa(hb.IntervalSeconds),
+			"last_ping_at":     hb.LastPingAt.UTC().Format(time.RFC3339),
+		},
+		ClientHint: hb.ClientID,
+	}
+}
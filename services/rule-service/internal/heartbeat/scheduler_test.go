@@ -0,0 +1,133 @@
+package heartbeat
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"rule-service/internal/database"
+	"rule-service/internal/events"
+
+	"github.com/afikmenashe/alerting-platform/pkg/ids"
+)
+
+// fakeStore is a test fake for Store.
+type fakeStore struct {
+	missed   []*database.Heartbeat
+	claimErr error
+	released []string
+}
+
+func (f *fakeStore) ClaimMissedHeartbeats(ctx context.Context) ([]*database.Heartbeat, error) {
+	return f.missed, f.claimErr
+}
+
+func (f *fakeStore) ReleaseHeartbeatClaim(ctx context.Context, heartbeatID string) error {
+	f.released = append(f.released, heartbeatID)
+	return nil
+}
+
+// fakePublisher is a test fake for AlertPublisher.
+type fakePublisher struct {
+	published []*events.AlertNew
+	err       error
+}
+
+func (f *fakePublisher) PublishAlert(ctx context.Context, alert *events.AlertNew) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.published = append(f.published, alert)
+	return nil
+}
+
+// fakeMetrics is a test fake for MetricsRecorder.
+type fakeMetrics struct {
+	counts map[string]int
+}
+
+func (f *fakeMetrics) IncrementCustom(name string) {
+	if f.counts == nil {
+		f.counts = make(map[string]int)
+	}
+	f.counts[name]++
+}
+
+func missedHeartbeat() *database.Heartbeat {
+	return &database.Heartbeat{
+		HeartbeatID:     "nightly-backup",
+		ClientID:        "client-1",
+		IntervalSeconds: 3600,
+		Severity:        "HIGH",
+		Source:          "heartbeat",
+		Name:            "nightly-backup",
+		LastPingAt:      time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestScheduler_CheckOnce_PublishesAlert(t *testing.T) {
+	store := &fakeStore{missed: []*database.Heartbeat{missedHeartbeat()}}
+	publisher := &fakePublisher{}
+	metrics := &fakeMetrics{}
+
+	s := NewScheduler(store, publisher, metrics, time.Second)
+	now := time.Date(2024, 1, 1, 2, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	if got := s.CheckOnce(context.Background()); got != 1 {
+		t.Fatalf("CheckOnce() = %d, want 1", got)
+	}
+	if len(publisher.published) != 1 {
+		t.Fatalf("published %d alerts, want 1", len(publisher.published))
+	}
+
+	alert := publisher.published[0]
+	if alert.Source != "heartbeat" || alert.Name != "nightly-backup" || alert.Severity != "HIGH" {
+		t.Errorf("alert = %+v, want heartbeat/nightly-backup/HIGH", alert)
+	}
+	if alert.EventTS != now.Unix() {
+		t.Errorf("alert EventTS = %d, want %d", alert.EventTS, now.Unix())
+	}
+	if created, err := ids.Time(alert.AlertID); err != nil || !created.Equal(now) {
+		t.Errorf("alert ID %q created at %v (error %v), want %v", alert.AlertID, created, err, now)
+	}
+	if alert.Context["heartbeat_id"] != "nightly-backup" || alert.Context["last_ping_at"] != "2024-01-01T00:00:00Z" {
+		t.Errorf("alert context = %v", alert.Context)
+	}
+	if metrics.counts["heartbeats_missed"] != 1 {
+		t.Errorf("heartbeats_missed = %d, want 1", metrics.counts["heartbeats_missed"])
+	}
+}
+
+func TestScheduler_CheckOnce_PublishFailureReleasesClaim(t *testing.T) {
+	store := &fakeStore{missed: []*database.Heartbeat{missedHeartbeat()}}
+	publisher := &fakePublisher{err: errors.New("kafka down")}
+	metrics := &fakeMetrics{}
+
+	s := NewScheduler(store, publisher, metrics, time.Second)
+
+	if got := s.CheckOnce(context.Background()); got != 0 {
+		t.Errorf("CheckOnce() = %d, want 0", got)
+	}
+	if len(store.released) != 1 || store.released[0] != "nightly-backup" {
+		t.Errorf("released = %v, want [nightly-backup]", store.released)
+	}
+	if metrics.counts["heartbeat_alert_errors"] != 1 {
+		t.Errorf("heartbeat_alert_errors = %d, want 1", metrics.counts["heartbeat_alert_errors"])
+	}
+}
+
+func TestScheduler_CheckOnce_ClaimError(t *testing.T) {
+	store := &fakeStore{claimErr: errors.New("db down")}
+	publisher := &fakePublisher{}
+
+	s := NewScheduler(store, publisher, nil, 0)
+
+	if got := s.CheckOnce(context.Background()); got != 0 {
+		t.Errorf("CheckOnce() = %d, want 0", got)
+	}
+	if s.interval != DefaultCheckInterval {
+		t.Errorf("interval = %v, want %v", s.interval, DefaultCheckInterval)
+	}
+}
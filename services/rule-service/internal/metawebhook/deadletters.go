@@ -0,0 +1,149 @@
+package metawebhook
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	kafkautil "github.com/afikmenashe/alerting-platform/pkg/kafka"
+	protonotifications "github.com/afikmenashe/alerting-platform/pkg/proto/notifications"
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/protobuf/proto"
+)
+
+// DefaultDeadLetterGroupID is the consumer group of the dead-letter watcher.
+const DefaultDeadLetterGroupID = "rule-service-dead-letter-events"
+
+// retryDelay is how long the watcher waits after a failure before reading again.
+const retryDelay = time.Second
+
+// MessageReader reads and commits messages of the dead-lettered notifications topic.
+type MessageReader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// EventRecorder queues a platform event for the client's meta-webhooks.
+type EventRecorder interface {
+	RecordPlatformEvent(ctx context.Context, clientID, eventType string, data interface{}) error
+}
+
+// DeadLetterEvent is the data of a notification.dead_lettered event.
+type DeadLetterEvent struct {
+	NotificationID string `json:"notification_id"`
+	AlertID        string `json:"alert_id"`
+	Error          string `json:"error"`
+	RetryCount     string `json:"retry_count,omitempty"`
+	FailedAt       string `json:"failed_at,omitempty"`
+}
+
+// DeadLetterWatcher turns notifications the sender dead-lettered into notification.dead_lettered
+// events for the owning client.
+type DeadLetterWatcher struct {
+	reader   MessageReader
+	recorder EventRecorder
+	metrics  MetricsRecorder
+}
+
+// NewDeadLetterReader creates a Kafka reader of the sender's dead-letter topic.
+func NewDeadLetterReader(brokers, topic, groupID string) (*kafka.Reader, error) {
+	if err := kafkautil.ValidateConsumerParams(brokers, topic, groupID); err != nil {
+		return nil, err
+	}
+	brokerList := kafkautil.ParseBrokers(brokers)
+	slog.Info("Initializing dead-letter Kafka consumer",
+		"brokers", brokerList,
+		"topic", topic,
+		"group_id", groupID,
+	)
+	return kafka.NewReader(kafkautil.NewReaderConfig(brokerList, topic, groupID)), nil
+}
+
+// NewDeadLetterWatcher creates a watcher reading from reader and recording events with recorder.
+// A nil metrics recorder is replaced with a no-op.
+func NewDeadLetterWatcher(reader MessageReader, recorder EventRecorder, m MetricsRecorder) *DeadLetterWatcher {
+	if m == nil {
+		m = noOpMetrics{}
+	}
+	return &DeadLetterWatcher{reader: reader, recorder: recorder, metrics: m}
+}
+
+// Run records dead-lettered notifications until ctx is cancelled.
+func (w *DeadLetterWatcher) Run(ctx context.Context) {
+	slog.Info("Starting dead-letter watcher")
+	for {
+		if err := w.HandleNext(ctx); err != nil {
+			if ctx.Err() != nil {
+				slog.Info("Dead-letter watcher stopped")
+				return
+			}
+			slog.Error("Failed to handle dead-lettered notification", "error", err)
+			if !sleep(ctx, retryDelay) {
+				return
+			}
+		}
+	}
+}
+
+// HandleNext reads one dead-lettered notification, records its event and commits it.
+// Messages that are not notifications are committed without an event. Recording is retried
+// until it succeeds, since committing a later message would skip this one.
+func (w *DeadLetterWatcher) HandleNext(ctx context.Context) error {
+	msg, err := w.reader.FetchMessage(ctx)
+	if err != nil {
+		return err
+	}
+
+	var pb protonotifications.NotificationReady
+	if err := proto.Unmarshal(msg.Value, &pb); err != nil || pb.ClientId == "" {
+		slog.Warn("Skipping dead-lettered message without a client",
+			"partition", msg.Partition,
+			"offset", msg.Offset,
+		)
+		return w.reader.CommitMessages(ctx, msg)
+	}
+
+	event := DeadLetterEvent{
+		NotificationID: pb.NotificationId,
+		AlertID:        pb.AlertId,
+		Error:          header(msg, kafkautil.HeaderDLQError),
+		RetryCount:     header(msg, kafkautil.HeaderDLQRetryCount),
+		FailedAt:       header(msg, kafkautil.HeaderDLQFailedAt),
+	}
+	for {
+		err := w.recorder.RecordPlatformEvent(ctx, pb.ClientId, EventNotificationDeadLetter, event)
+		if err == nil {
+			break
+		}
+		slog.Error("Failed to record dead-letter event, retrying",
+			"notification_id", pb.NotificationId,
+			"error", err,
+		)
+		if !sleep(ctx, retryDelay) {
+			return fmt.Errorf("failed to record dead-letter event: %w", err)
+		}
+	}
+	w.metrics.IncrementCustom("dead_letter_events_recorded")
+	return w.reader.CommitMessages(ctx, msg)
+}
+
+// sleep waits for d and returns false if ctx was cancelled first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+// header returns the value of the first header named key, or "".
+func header(msg kafka.Message, key string) string {
+	for _, h := range msg.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
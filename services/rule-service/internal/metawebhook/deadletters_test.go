@@ -0,0 +1,149 @@
+package metawebhook
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	kafkautil "github.com/afikmenashe/alerting-platform/pkg/kafka"
+	protonotifications "github.com/afikmenashe/alerting-platform/pkg/proto/notifications"
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/protobuf/proto"
+)
+
+// fakeReader is a test fake for MessageReader serving its messages in order.
+type fakeReader struct {
+	messages  []kafka.Message
+	committed []kafka.Message
+}
+
+func (f *fakeReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	if len(f.messages) == 0 {
+		return kafka.Message{}, errors.New("no more messages")
+	}
+	msg := f.messages[0]
+	f.messages = f.messages[1:]
+	return msg, nil
+}
+
+func (f *fakeReader) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	f.committed = append(f.committed, msgs...)
+	return nil
+}
+
+// recordedEvent is one RecordPlatformEvent call.
+type recordedEvent struct {
+	clientID  string
+	eventType string
+	data      interface{}
+}
+
+// fakeRecorder is a test fake for EventRecorder that fails its first failures calls.
+type fakeRecorder struct {
+	failures int
+	events   []recordedEvent
+}
+
+func (f *fakeRecorder) RecordPlatformEvent(ctx context.Context, clientID, eventType string, data interface{}) error {
+	if f.failures > 0 {
+		f.failures--
+		return errors.New("db down")
+	}
+	f.events = append(f.events, recordedEvent{clientID: clientID, eventType: eventType, data: data})
+	return nil
+}
+
+// deadLetteredNotification returns a dead-lettered notifications.ready message.
+func deadLetteredNotification(t *testing.T, clientID string) kafka.Message {
+	t.Helper()
+	value, err := proto.Marshal(&protonotifications.NotificationReady{
+		NotificationId: "notif-1",
+		ClientId:       clientID,
+		AlertId:        "alert-1",
+	})
+	if err != nil {
+		t.Fatalf("proto.Marshal() error = %v", err)
+	}
+	return kafka.Message{
+		Value: value,
+		Headers: []kafka.Header{
+			{Key: kafkautil.HeaderDLQError, Value: []byte("smtp: connection refused")},
+			{Key: kafkautil.HeaderDLQRetryCount, Value: []byte("3")},
+		},
+	}
+}
+
+// TestDeadLetterWatcher_HandleNext tests that a dead-lettered notification becomes an event.
+func TestDeadLetterWatcher_HandleNext(t *testing.T) {
+	reader := &fakeReader{messages: []kafka.Message{deadLetteredNotification(t, "client-1")}}
+	recorder := &fakeRecorder{}
+	m := &fakeMetrics{}
+
+	if err := NewDeadLetterWatcher(reader, recorder, m).HandleNext(context.Background()); err != nil {
+		t.Fatalf("HandleNext() error = %v", err)
+	}
+	if len(recorder.events) != 1 {
+		t.Fatalf("recorded %d events, want 1", len(recorder.events))
+	}
+	event := recorder.events[0]
+	if event.clientID != "client-1" || event.eventType != EventNotificationDeadLetter {
+		t.Errorf("event = %s %s, want client-1 %s", event.clientID, event.eventType, EventNotificationDeadLetter)
+	}
+	want := DeadLetterEvent{NotificationID: "notif-1", AlertID: "alert-1", Error: "smtp: connection refused", RetryCount: "3"}
+	if event.data != want {
+		t.Errorf("event data = %+v, want %+v", event.data, want)
+	}
+	if len(reader.committed) != 1 {
+		t.Errorf("committed %d messages, want 1", len(reader.committed))
+	}
+	if m.counts["dead_letter_events_recorded"] != 1 {
+		t.Errorf("dead_letter_events_recorded = %d, want 1", m.counts["dead_letter_events_recorded"])
+	}
+}
+
+// TestDeadLetterWatcher_HandleNext_SkipsUnknownMessages tests that messages without a client are committed.
+func TestDeadLetterWatcher_HandleNext_SkipsUnknownMessages(t *testing.T) {
+	reader := &fakeReader{messages: []kafka.Message{{Value: []byte("not a notification")}, deadLetteredNotification(t, "")}}
+	recorder := &fakeRecorder{}
+	w := NewDeadLetterWatcher(reader, recorder, nil)
+
+	for i := 0; i < 2; i++ {
+		if err := w.HandleNext(context.Background()); err != nil {
+			t.Fatalf("HandleNext() error = %v", err)
+		}
+	}
+	if len(recorder.events) != 0 {
+		t.Errorf("recorded %d events, want 0", len(recorder.events))
+	}
+	if len(reader.committed) != 2 {
+		t.Errorf("committed %d messages, want 2", len(reader.committed))
+	}
+}
+
+// TestDeadLetterWatcher_HandleNext_RetriesRecording tests that recording is retried before committing.
+func TestDeadLetterWatcher_HandleNext_RetriesRecording(t *testing.T) {
+	reader := &fakeReader{messages: []kafka.Message{deadLetteredNotification(t, "client-1")}}
+	recorder := &fakeRecorder{failures: 1}
+
+	if err := NewDeadLetterWatcher(reader, recorder, nil).HandleNext(context.Background()); err != nil {
+		t.Fatalf("HandleNext() error = %v", err)
+	}
+	if len(recorder.events) != 1 || len(reader.committed) != 1 {
+		t.Errorf("recorded %d events and committed %d messages, want 1 and 1", len(recorder.events), len(reader.committed))
+	}
+}
+
+// TestDeadLetterWatcher_HandleNext_Cancelled tests that a message is not committed if recording never succeeds.
+func TestDeadLetterWatcher_HandleNext_Cancelled(t *testing.T) {
+	reader := &fakeReader{messages: []kafka.Message{deadLetteredNotification(t, "client-1")}}
+	recorder := &fakeRecorder{failures: 1}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := NewDeadLetterWatcher(reader, recorder, nil).HandleNext(ctx); err == nil {
+		t.Fatal("HandleNext() error = nil, want the recording error")
+	}
+	if len(reader.committed) != 0 {
+		t.Errorf("committed %d messages, want 0", len(reader.committed))
+	}
+}
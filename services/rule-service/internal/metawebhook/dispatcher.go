@@ -0,0 +1,253 @@
+package metawebhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"rule-service/internal/database"
+)
+
+const (
+	// DefaultInterval is how often the dispatcher polls for due deliveries.
+	DefaultInterval = 2 * time.Second
+	// DefaultBatchSize is the maximum number of deliveries claimed per poll.
+	DefaultBatchSize = 50
+	// DefaultMaxAttempts is how many times a delivery is tried before it is given up on.
+	DefaultMaxAttempts = 8
+	// DefaultRetention is how long delivered and failed deliveries are kept.
+	DefaultRetention = 7 * 24 * time.Hour
+	// requestTimeout bounds one delivery request.
+	requestTimeout = 10 * time.Second
+	// claimLease keeps a claimed delivery from being claimed again while it is being sent.
+	claimLease = time.Minute
+	// baseBackoff and maxBackoff bound the delay between attempts of a delivery.
+	baseBackoff = 30 * time.Second
+	maxBackoff  = time.Hour
+	// pruneInterval is how often old deliveries are deleted.
+	pruneInterval = time.Hour
+	// maxErrorLength bounds the error recorded on a delivery.
+	maxErrorLength = 500
+)
+
+// Store provides the delivery persistence needed by the dispatcher.
+type Store interface {
+	ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*database.PendingWebhookDelivery, error)
+	MarkWebhookDelivered(ctx context.Context, deliveryID int64) error
+	RetryWebhookDelivery(ctx context.Context, deliveryID int64, nextAttempt time.Time, lastError string) error
+	FailWebhookDelivery(ctx context.Context, deliveryID int64, lastError string) error
+	PruneWebhookDeliveries(ctx context.Context, before time.Time) (int64, error)
+}
+
+// SecretOpener decrypts stored webhook signing secrets.
+type SecretOpener interface {
+	Decrypt(encoded string) (string, error)
+}
+
+// MetricsRecorder is the subset of metrics used by the dispatcher.
+type MetricsRecorder interface {
+	IncrementCustom(name string)
+}
+
+// noOpMetrics is used when no metrics recorder is provided.
+type noOpMetrics struct{}
+
+func (noOpMetrics) IncrementCustom(string) {}
+
+// Dispatcher sends queued platform events to meta-webhooks.
+type Dispatcher struct {
+	store       Store
+	secrets     SecretOpener
+	metrics     MetricsRecorder
+	client      *http.Client
+	interval    time.Duration
+	batchSize   int
+	maxAttempts int
+	retention   time.Duration
+	now         func() time.Time
+	lastPrune   time.Time
+}
+
+// NewDispatcher creates a dispatcher that polls for due deliveries every interval.
+// A non-positive interval uses DefaultInterval; a nil metrics recorder is replaced with a no-op.
+func NewDispatcher(store Store, secrets SecretOpener, m MetricsRecorder, interval time.Duration) *Dispatcher {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	if m == nil {
+		m = noOpMetrics{}
+	}
+	return &Dispatcher{
+		store:       store,
+		secrets:     secrets,
+		metrics:     m,
+		client:      &http.Client{Timeout: requestTimeout},
+		interval:    interval,
+		batchSize:   DefaultBatchSize,
+		maxAttempts: DefaultMaxAttempts,
+		retention:   DefaultRetention,
+		now:         time.Now,
+	}
+}
+
+// Run dispatches due deliveries until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	slog.Info("Starting meta-webhook dispatcher", "interval", d.interval, "max_attempts", d.maxAttempts)
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Meta-webhook dispatcher stopped")
+			return
+		case <-ticker.C:
+			d.DispatchOnce(ctx)
+			d.pruneIfDue(ctx)
+		}
+	}
+}
+
+// DispatchOnce sends one batch of due deliveries and returns how many were delivered.
+func (d *Dispatcher) DispatchOnce(ctx context.Context) int {
+	claimed, err := d.store.ClaimWebhookDeliveries(ctx, d.batchSize, claimLease)
+	if err != nil {
+		slog.Error("Failed to claim webhook deliveries", "error", err)
+		d.metrics.IncrementCustom("webhook_dispatch_errors")
+		return 0
+	}
+
+	delivered := 0
+	for _, p := range claimed {
+		if d.dispatch(ctx, p) {
+			delivered++
+		}
+	}
+	return delivered
+}
+
+// dispatch sends one delivery and records the outcome. Returns true if it was delivered.
+func (d *Dispatcher) dispatch(ctx context.Context, p *database.PendingWebhookDelivery) bool {
+	if !p.WebhookEnabled {
+		d.fail(ctx, p, "webhook disabled")
+		return false
+	}
+
+	err := d.send(ctx, p)
+	if err == nil {
+		if err := d.store.MarkWebhookDelivered(ctx, p.DeliveryID); err != nil {
+			// The lease expires and the event is sent again: receivers dedupe on the delivery ID
+			slog.Error("Failed to mark webhook delivered", "delivery_id", p.DeliveryID, "error", err)
+		}
+		d.metrics.IncrementCustom("webhook_deliveries_sent")
+		return true
+	}
+
+	lastError := truncate(err.Error(), maxErrorLength)
+	if p.Attempts >= d.maxAttempts {
+		d.fail(ctx, p, lastError)
+		return false
+	}
+	next := d.now().Add(backoff(p.Attempts))
+	if err := d.store.RetryWebhookDelivery(ctx, p.DeliveryID, next, lastError); err != nil {
+		slog.Error("Failed to schedule webhook retry", "delivery_id", p.DeliveryID, "error", err)
+	}
+	slog.Warn("Webhook delivery failed, will retry",
+		"delivery_id", p.DeliveryID,
+		"webhook_id", p.WebhookID,
+		"attempt", p.Attempts,
+		"next_attempt_at", next,
+		"error", lastError,
+	)
+	d.metrics.IncrementCustom("webhook_delivery_retries")
+	return false
+}
+
+// fail gives up on a delivery.
+func (d *Dispatcher) fail(ctx context.Context, p *database.PendingWebhookDelivery, reason string) {
+	if err := d.store.FailWebhookDelivery(ctx, p.DeliveryID, reason); err != nil {
+		slog.Error("Failed to mark webhook delivery failed", "delivery_id", p.DeliveryID, "error", err)
+	}
+	slog.Warn("Webhook delivery given up",
+		"delivery_id", p.DeliveryID,
+		"webhook_id", p.WebhookID,
+		"event_type", p.EventType,
+		"attempts", p.Attempts,
+		"error", reason,
+	)
+	d.metrics.IncrementCustom("webhook_deliveries_failed")
+}
+
+// send POSTs the event payload to the webhook. Any 2xx response is a success.
+func (d *Dispatcher) send(ctx context.Context, p *database.PendingWebhookDelivery) error {
+	secret, err := d.secrets.Decrypt(p.Secret)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt webhook secret: %w", err)
+	}
+
+	timestamp := d.now().Unix()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URL, bytes.NewReader(p.Payload))
+	if err != nil {
+		return fmt.Errorf("invalid webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, p.EventType)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(p.DeliveryID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(secret, timestamp, p.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// pruneIfDue deletes old finished deliveries at most once per pruneInterval.
+func (d *Dispatcher) pruneIfDue(ctx context.Context) {
+	now := d.now()
+	if now.Sub(d.lastPrune) < pruneInterval {
+		return
+	}
+	d.lastPrune = now
+	n, err := d.store.PruneWebhookDeliveries(ctx, now.Add(-d.retention))
+	if err != nil {
+		slog.Error("Failed to prune webhook deliveries", "error", err)
+		return
+	}
+	if n > 0 {
+		slog.Info("Pruned webhook deliveries", "deleted", n, "retention", d.retention)
+	}
+}
+
+// backoff returns the delay after the given failed attempt: baseBackoff doubled per attempt, capped at maxBackoff.
+func backoff(attempt int) time.Duration {
+	delay := baseBackoff
+	for i := 1; i < attempt && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+	return delay
+}
+
+// truncate shortens s to at most n bytes.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
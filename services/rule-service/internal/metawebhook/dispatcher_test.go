@@ -0,0 +1,265 @@
+package metawebhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"rule-service/internal/database"
+)
+
+// fakeStore is a test fake for Store that hands out its pending deliveries once.
+type fakeStore struct {
+	pending   []*database.PendingWebhookDelivery
+	claimErr  error
+	delivered []int64
+	retried   map[int64]time.Time
+	failed    map[int64]string
+	pruned    []time.Time
+}
+
+func newFakeStore(pending ...*database.PendingWebhookDelivery) *fakeStore {
+	return &fakeStore{pending: pending, retried: make(map[int64]time.Time), failed: make(map[int64]string)}
+}
+
+func (f *fakeStore) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*database.PendingWebhookDelivery, error) {
+	if f.claimErr != nil {
+		return nil, f.claimErr
+	}
+	claimed := f.pending
+	f.pending = nil
+	return claimed, nil
+}
+
+func (f *fakeStore) MarkWebhookDelivered(ctx context.Context, deliveryID int64) error {
+	f.delivered = append(f.delivered, deliveryID)
+	return nil
+}
+
+func (f *fakeStore) RetryWebhookDelivery(ctx context.Context, deliveryID int64, nextAttempt time.Time, lastError string) error {
+	f.retried[deliveryID] = nextAttempt
+	return nil
+}
+
+func (f *fakeStore) FailWebhookDelivery(ctx context.Context, deliveryID int64, lastError string) error {
+	f.failed[deliveryID] = lastError
+	return nil
+}
+
+func (f *fakeStore) PruneWebhookDeliveries(ctx context.Context, before time.Time) (int64, error) {
+	f.pruned = append(f.pruned, before)
+	return 0, nil
+}
+
+// fakeOpener is a test fake for SecretOpener that strips a "sealed:" prefix.
+type fakeOpener struct{}
+
+func (fakeOpener) Decrypt(encoded string) (string, error) {
+	if !strings.HasPrefix(encoded, "sealed:") {
+		return "", errors.New("not sealed")
+	}
+	return strings.TrimPrefix(encoded, "sealed:"), nil
+}
+
+// fakeMetrics is a test fake for MetricsRecorder.
+type fakeMetrics struct {
+	counts map[string]int
+}
+
+func (f *fakeMetrics) IncrementCustom(name string) {
+	if f.counts == nil {
+		f.counts = make(map[string]int)
+	}
+	f.counts[name]++
+}
+
+// pendingDelivery returns a claimed delivery to url on its given attempt.
+func pendingDelivery(id int64, url string, attempts int) *database.PendingWebhookDelivery {
+	return &database.PendingWebhookDelivery{
+		WebhookDelivery: database.WebhookDelivery{
+			DeliveryID: id,
+			WebhookID:  "wh-1",
+			EventType:  EventRuleCreated,
+			Payload:    json.RawMessage(`{"event_type":"rule.created","client_id":"client-1","data":{"rule_id":"rule-1"}}`),
+			Attempts:   attempts,
+		},
+		URL:            url,
+		Secret:         "sealed:s3cret",
+		WebhookEnabled: true,
+	}
+}
+
+// TestDispatcher_DispatchOnce_Delivers tests that a delivery is POSTed with a valid signature.
+func TestDispatcher_DispatchOnce_Delivers(t *testing.T) {
+	var got *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	store := newFakeStore(pendingDelivery(7, server.URL, 1))
+	m := &fakeMetrics{}
+	d := NewDispatcher(store, fakeOpener{}, m, 0)
+
+	if n := d.DispatchOnce(context.Background()); n != 1 {
+		t.Fatalf("DispatchOnce() = %d, want 1", n)
+	}
+	if len(store.delivered) != 1 || store.delivered[0] != 7 {
+		t.Errorf("delivered = %v, want [7]", store.delivered)
+	}
+	if got.Header.Get(HeaderEvent) != EventRuleCreated || got.Header.Get(HeaderDelivery) != "7" {
+		t.Errorf("headers = %v", got.Header)
+	}
+	timestamp, err := strconv.ParseInt(got.Header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		t.Fatalf("invalid timestamp header: %v", err)
+	}
+	if want := Sign("s3cret", timestamp, body); got.Header.Get(HeaderSignature) != want {
+		t.Errorf("signature = %q, want %q", got.Header.Get(HeaderSignature), want)
+	}
+	if m.counts["webhook_deliveries_sent"] != 1 {
+		t.Errorf("webhook_deliveries_sent = %d, want 1", m.counts["webhook_deliveries_sent"])
+	}
+}
+
+// TestDispatcher_DispatchOnce_Retries tests that a failed attempt is retried with backoff.
+func TestDispatcher_DispatchOnce_Retries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	store := newFakeStore(pendingDelivery(1, server.URL, 2))
+	d := NewDispatcher(store, fakeOpener{}, nil, 0)
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return now }
+
+	if n := d.DispatchOnce(context.Background()); n != 0 {
+		t.Errorf("DispatchOnce() = %d, want 0", n)
+	}
+	if next, ok := store.retried[1]; !ok || !next.Equal(now.Add(time.Minute)) {
+		t.Errorf("retry scheduled at %v (%v), want %v", next, ok, now.Add(time.Minute))
+	}
+	if len(store.failed) != 0 {
+		t.Errorf("failed = %v, want none", store.failed)
+	}
+}
+
+// TestDispatcher_DispatchOnce_GivesUp tests that the last attempt fails the delivery.
+func TestDispatcher_DispatchOnce_GivesUp(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	store := newFakeStore(pendingDelivery(1, server.URL, DefaultMaxAttempts))
+	m := &fakeMetrics{}
+	NewDispatcher(store, fakeOpener{}, m, 0).DispatchOnce(context.Background())
+
+	if !strings.Contains(store.failed[1], "status 500") {
+		t.Errorf("failed[1] = %q, want the response status", store.failed[1])
+	}
+	if len(store.retried) != 0 {
+		t.Errorf("retried = %v, want none", store.retried)
+	}
+	if m.counts["webhook_deliveries_failed"] != 1 {
+		t.Errorf("webhook_deliveries_failed = %d, want 1", m.counts["webhook_deliveries_failed"])
+	}
+}
+
+// TestDispatcher_DispatchOnce_DisabledWebhook tests that deliveries of a disabled webhook are dropped.
+func TestDispatcher_DispatchOnce_DisabledWebhook(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	p := pendingDelivery(1, server.URL, 1)
+	p.WebhookEnabled = false
+	store := newFakeStore(p)
+	NewDispatcher(store, fakeOpener{}, nil, 0).DispatchOnce(context.Background())
+
+	if called {
+		t.Error("disabled webhook was called")
+	}
+	if store.failed[1] != "webhook disabled" {
+		t.Errorf("failed[1] = %q, want %q", store.failed[1], "webhook disabled")
+	}
+}
+
+// TestDispatcher_DispatchOnce_ClaimError tests that claim errors are counted.
+func TestDispatcher_DispatchOnce_ClaimError(t *testing.T) {
+	store := newFakeStore()
+	store.claimErr = errors.New("db down")
+	m := &fakeMetrics{}
+
+	if n := NewDispatcher(store, fakeOpener{}, m, 0).DispatchOnce(context.Background()); n != 0 {
+		t.Errorf("DispatchOnce() = %d, want 0", n)
+	}
+	if m.counts["webhook_dispatch_errors"] != 1 {
+		t.Errorf("webhook_dispatch_errors = %d, want 1", m.counts["webhook_dispatch_errors"])
+	}
+}
+
+// TestDispatcher_PruneIfDue tests that finished deliveries are pruned at most hourly.
+func TestDispatcher_PruneIfDue(t *testing.T) {
+	store := newFakeStore()
+	d := NewDispatcher(store, fakeOpener{}, nil, 0)
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return now }
+
+	d.pruneIfDue(context.Background())
+	d.pruneIfDue(context.Background())
+	if len(store.pruned) != 1 || !store.pruned[0].Equal(now.Add(-DefaultRetention)) {
+		t.Fatalf("pruned = %v, want once before %v", store.pruned, now.Add(-DefaultRetention))
+	}
+
+	now = now.Add(pruneInterval)
+	d.pruneIfDue(context.Background())
+	if len(store.pruned) != 2 {
+		t.Errorf("pruned %d times, want 2 after pruneInterval", len(store.pruned))
+	}
+}
+
+// TestBackoff tests the retry delay schedule.
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{7, 32 * time.Minute},
+		{8, time.Hour},
+		{20, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := backoff(tt.attempt); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+// TestSign tests that the signature covers the timestamp and body.
+func TestSign(t *testing.T) {
+	body := []byte(`{"a":1}`)
+	sig := Sign("secret", 100, body)
+	if !strings.HasPrefix(sig, "sha256=") || len(sig) != len("sha256=")+64 {
+		t.Fatalf("Sign() = %q, want sha256=<64 hex>", sig)
+	}
+	if Sign("secret", 101, body) == sig || Sign("other", 100, body) == sig || Sign("secret", 100, []byte(`{"a":2}`)) == sig {
+		t.Error("Sign() does not depend on the timestamp, secret and body")
+	}
+}
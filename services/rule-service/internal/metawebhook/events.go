@@ -0,0 +1,71 @@
+// Package metawebhook delivers platform lifecycle events to the meta-webhooks clients register,
+// so they can follow changes to their own resources in their own tooling.
+//
+// Events are queued in webhook_deliveries by triggers on rules and endpoints (in the same
+// transaction as the change), by the dead-letter watcher and by the daily unmatched alert
+// report (package unmatched); the dispatcher POSTs them with an HMAC signature and retries
+// failed deliveries with exponential backoff.
+package metawebhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+// Platform event types. A webhook subscribed to no types receives all of them.
+const (
+	EventRuleCreated            = "rule.created"
+	EventRuleUpdated            = "rule.updated"
+	EventRuleEnabled            = "rule.enabled"
+	EventRuleDisabled           = "rule.disabled"
+	EventRuleDeleted            = "rule.deleted"
+	EventEndpointEnabled        = "endpoint.enabled"
+	EventEndpointDisabled       = "endpoint.disabled"
+	EventNotificationDeadLetter = "notification.dead_lettered"
+	EventUnmatchedReport        = "unmatched.report"
+)
+
+// EventTypes lists every platform event type.
+var EventTypes = []string{
+	EventRuleCreated,
+	EventRuleUpdated,
+	EventRuleEnabled,
+	EventRuleDisabled,
+	EventRuleDeleted,
+	EventEndpointEnabled,
+	EventEndpointDisabled,
+	EventNotificationDeadLetter,
+	EventUnmatchedReport,
+}
+
+// IsEventType reports whether t is a platform event type.
+func IsEventType(t string) bool {
+	for _, known := range EventTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Headers sent with every delivery.
+const (
+	HeaderEvent     = "X-Platform-Event"
+	HeaderDelivery  = "X-Platform-Delivery"
+	HeaderTimestamp = "X-Platform-Timestamp"
+	HeaderSignature = "X-Platform-Signature"
+)
+
+// Sign returns the signature header value of a delivery: the hex HMAC-SHA256 of
+// "<timestamp>.<body>" with the webhook's secret, prefixed with "sha256=". Receivers
+// recompute it to check the delivery came from the platform, and reject old timestamps
+// to prevent replays.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,63 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+)
+
+// swaggerUIVersion is the swagger-ui-dist release the UI page loads.
+const swaggerUIVersion = "5.17.14"
+
+// swaggerUIPage renders the spec at SpecURL with Swagger UI. Its scripts and styles are
+// loaded from the unpkg CDN, so the page needs internet access from the browser.
+var swaggerUIPage = template.Must(template.New("swagger-ui").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>{{.Title}}</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@{{.Version}}/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@{{.Version}}/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.ui = SwaggerUIBundle({
+      url: {{.SpecURL}},
+      dom_id: "#swagger-ui",
+      persistAuthorization: true,
+    });
+  </script>
+</body>
+</html>
+`))
+
+// SpecHandler serves doc as JSON. The document is encoded once, when the handler is created.
+func SpecHandler(doc *Document) http.Handler {
+	body, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		panic(fmt.Sprintf("openapi: encode document: %v", err))
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Write(body)
+	})
+}
+
+// UIHandler serves a Swagger UI page for the document at specURL.
+func UIHandler(title, specURL string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		swaggerUIPage.Execute(w, struct{ Title, SpecURL, Version string }{title, specURL, swaggerUIVersion})
+	})
+}
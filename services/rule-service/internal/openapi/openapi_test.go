@@ -0,0 +1,206 @@
+// Package openapi provides tests for the OpenAPI document and its handlers.
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// collectRefs returns every $ref in the JSON encoding of v.
+func collectRefs(t *testing.T, v any) []string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var generic any
+	if err := json.Unmarshal(data, &generic); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	var refs []string
+	var walk func(any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			for k, child := range v {
+				if ref, ok := child.(string); ok && k == "$ref" {
+					refs = append(refs, ref)
+				}
+				walk(child)
+			}
+		case []any:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(generic)
+	return refs
+}
+
+// TestBuild tests that the document is complete and internally consistent.
+func TestBuild(t *testing.T) {
+	doc := Build()
+
+	if doc.OpenAPI != Version {
+		t.Errorf("OpenAPI = %q, want %q", doc.OpenAPI, Version)
+	}
+	for _, path := range []string{"/api/v1/clients", "/api/v1/rules", "/api/v1/endpoints", "/api/v1/notifications", "/api/v1/notifications/{id}/deliveries"} {
+		if doc.Paths[path] == nil {
+			t.Errorf("Paths[%q] missing", path)
+		}
+	}
+
+	for _, ref := range collectRefs(t, doc) {
+		switch {
+		case strings.HasPrefix(ref, "#/components/schemas/"):
+			if doc.Components.Schemas[strings.TrimPrefix(ref, "#/components/schemas/")] == nil {
+				t.Errorf("unresolved schema reference %q", ref)
+			}
+		case strings.HasPrefix(ref, "#/components/responses/"):
+			if doc.Components.Responses[strings.TrimPrefix(ref, "#/components/responses/")] == nil {
+				t.Errorf("unresolved response reference %q", ref)
+			}
+		default:
+			t.Errorf("unexpected reference %q", ref)
+		}
+	}
+
+	ids := make(map[string]string)
+	for path, item := range doc.Paths {
+		for _, op := range []*Operation{item.Get, item.Put, item.Post, item.Delete} {
+			if op == nil {
+				continue
+			}
+			if op.OperationID == "" || op.Summary == "" {
+				t.Errorf("%s: operation without operationId or summary", path)
+			}
+			if other, ok := ids[op.OperationID]; ok {
+				t.Errorf("operationId %q used by %s and %s", op.OperationID, other, path)
+			}
+			ids[op.OperationID] = path
+			if op.Responses["401"] == nil || op.Responses["429"] == nil {
+				t.Errorf("%s %s: missing authentication or rate limit responses", path, op.OperationID)
+			}
+		}
+	}
+}
+
+// TestBuild_Schemas tests that schemas follow the JSON encoding of the API types.
+func TestBuild_Schemas(t *testing.T) {
+	schemas := Build().Components.Schemas
+
+	rule := schemas["Rule"]
+	if rule == nil {
+		t.Fatal("Rule schema missing")
+	}
+	if got := rule.Properties["created_at"]; got == nil || got.Format != "date-time" {
+		t.Errorf("Rule.created_at = %+v, want date-time string", got)
+	}
+	if got := rule.Properties["conditions"]; got == nil || got.Type != "array" || got.Items.Ref != "#/components/schemas/ContextCondition" {
+		t.Errorf("Rule.conditions = %+v, want array of ContextCondition", got)
+	}
+
+	create := schemas["CreateRuleRequest"]
+	if create == nil {
+		t.Fatal("CreateRuleRequest schema missing")
+	}
+	if !slices.Contains(create.Required, "client_id") {
+		t.Errorf("CreateRuleRequest.required = %v, want client_id", create.Required)
+	}
+	if slices.Contains(create.Required, "description") {
+		t.Errorf("CreateRuleRequest.required = %v, want description optional", create.Required)
+	}
+
+	if got := schemas["Notification"].Properties["context"]; got == nil || got.AdditionalProperties == nil || got.AdditionalProperties.Type != "string" {
+		t.Errorf("Notification.context = %+v, want map of strings", got)
+	}
+}
+
+// TestSchemas tests the schemas derived from Go types.
+func TestSchemas(t *testing.T) {
+	type inner struct {
+		ID string `json:"id"`
+	}
+	type outer struct {
+		inner
+		At       time.Time  `json:"at"`
+		Until    *time.Time `json:"until"`
+		Count    int64      `json:"count,omitempty"`
+		Ignored  string     `json:"-"`
+		Untagged bool
+		private  string
+	}
+
+	s := newSchemas()
+	ref := s.of(&outer{})
+	if ref.Ref != "#/components/schemas/outer" {
+		t.Fatalf("Ref = %q, want #/components/schemas/outer", ref.Ref)
+	}
+	obj := s.components["outer"]
+
+	want := []string{"id", "at", "until", "count", "Untagged"}
+	for _, name := range want {
+		if obj.Properties[name] == nil {
+			t.Errorf("property %q missing", name)
+		}
+	}
+	if len(obj.Properties) != len(want) {
+		t.Errorf("properties = %d, want %d", len(obj.Properties), len(want))
+	}
+	if !obj.Properties["until"].Nullable {
+		t.Error("until should be nullable")
+	}
+	if got := obj.Properties["count"]; got.Type != "integer" || got.Format != "int64" {
+		t.Errorf("count = %+v, want int64 integer", got)
+	}
+	if slices.Contains(obj.Required, "count") || !slices.Contains(obj.Required, "id") {
+		t.Errorf("required = %v, want id but not count", obj.Required)
+	}
+}
+
+// TestSpecHandler tests that the document is served as JSON.
+func TestSpecHandler(t *testing.T) {
+	handler := SpecHandler(Build())
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/openapi.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+	var doc Document
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if doc.OpenAPI != Version || doc.Paths["/api/v1/rules"] == nil {
+		t.Errorf("served document = %q with %d paths", doc.OpenAPI, len(doc.Paths))
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/openapi.json", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+// TestUIHandler tests that the Swagger UI page loads the document.
+func TestUIHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	UIHandler("rule-service API", "/api/v1/openapi.json").ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/docs", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, `"/api/v1/openapi.json"`) || !strings.Contains(body, "swagger-ui-bundle.js") {
+		t.Errorf("page does not load the document with Swagger UI:\n%s", body)
+	}
+}
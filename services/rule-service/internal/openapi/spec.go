@@ -0,0 +1,446 @@
+package openapi
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"rule-service/internal/conflicts"
+	"rule-service/internal/database"
+	"rule-service/internal/export"
+	"rule-service/internal/handlers"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// Shared error responses. Errors are plain text, as written by http.Error, except that invalid
+// request bodies are answered with a JSON list of field errors.
+var errorResponses = map[int]string{
+	http.StatusBadRequest:      "Invalid request",
+	http.StatusUnauthorized:    "Missing or invalid API key",
+	http.StatusForbidden:       "The API key may not access this client or route",
+	http.StatusNotFound:        "Not found",
+	http.StatusConflict:        "Conflict with the current state, e.g. an existing ID or a stale version",
+	http.StatusTooManyRequests: "Rate limited; retry after Retry-After seconds",
+	http.StatusNotImplemented:  "Not configured on this deployment",
+}
+
+// Build returns the OpenAPI document of the client, rule, endpoint and notification routes.
+func Build() *Document {
+	b := &builder{
+		doc: &Document{
+			OpenAPI: Version,
+			Info: Info{
+				Title: "rule-service API",
+				Description: "Manages clients, their alert rules and notification endpoints, and the notifications sent for them. " +
+					"When API key authentication is enabled, every route needs an API key; client keys only reach their own client's data.",
+				Version: "v1",
+			},
+			Tags: []Tag{
+				{Name: "clients", Description: "Clients and their settings"},
+				{Name: "rules", Description: "Alert rules of a client"},
+				{Name: "endpoints", Description: "Notification endpoints of a rule"},
+				{Name: "notifications", Description: "Notifications, their journal, deliveries and exports"},
+			},
+			Paths: make(map[string]*PathItem),
+			Components: Components{
+				Responses: make(map[string]*Response),
+				SecuritySchemes: map[string]*SecurityScheme{
+					"bearerAuth": {Type: "http", Scheme: "bearer", Description: "API key issued by POST /api/v1/api-keys, or the admin token"},
+					"apiKey":     {Type: "apiKey", In: "header", Name: "X-API-Key", Description: "API key issued by POST /api/v1/api-keys, or the admin token"},
+				},
+			},
+			Security: []map[string][]string{{"bearerAuth": {}}, {"apiKey": {}}},
+		},
+		schemas: newSchemas(),
+	}
+	for code, description := range errorResponses {
+		b.doc.Components.Responses[errorName(code)] = &Response{
+			Description: description,
+			Content:     map[string]*MediaType{"text/plain": {Schema: &Schema{Type: "string"}}},
+		}
+	}
+	b.doc.Components.Responses[errorName(http.StatusBadRequest)].Content["application/json"] = &MediaType{Schema: b.schemas.of(shared.ValidationError{})}
+
+	b.clients()
+	b.rules()
+	b.endpoints()
+	b.notifications()
+
+	b.doc.Components.Schemas = b.schemas.components
+	return b.doc
+}
+
+// builder adds operations to a document.
+type builder struct {
+	doc     *Document
+	schemas *schemas
+}
+
+// add adds op as method on path. Every operation may also be refused by authentication
+// and rate limiting, so those responses are added here.
+func (b *builder) add(method, path string, op *Operation) {
+	item, ok := b.doc.Paths[path]
+	if !ok {
+		item = &PathItem{}
+		b.doc.Paths[path] = item
+	}
+	slot := item.operation(method)
+	if *slot != nil {
+		panic("openapi: duplicate operation " + method + " " + path)
+	}
+	for _, code := range []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests} {
+		if _, ok := op.Responses[strconv.Itoa(code)]; !ok {
+			op.Responses[strconv.Itoa(code)] = errorRef(code)
+		}
+	}
+	*slot = op
+}
+
+// body returns a required JSON request body of the type of v.
+func (b *builder) body(v any) *RequestBody {
+	return &RequestBody{
+		Required: true,
+		Content:  map[string]*MediaType{"application/json": {Schema: b.schemas.of(v)}},
+	}
+}
+
+// json returns a JSON response of the type of v, or one of the types of vs.
+func (b *builder) json(description string, v any, vs ...any) *Response {
+	schema := b.schemas.of(v)
+	if len(vs) > 0 {
+		schema = &Schema{OneOf: []*Schema{schema}}
+		for _, v := range vs {
+			schema.OneOf = append(schema.OneOf, b.schemas.of(v))
+		}
+	}
+	return &Response{
+		Description: description,
+		Content:     map[string]*MediaType{"application/json": {Schema: schema}},
+	}
+}
+
+// responses returns the responses of an operation: status ok with response okResp, and the
+// shared error responses of codes.
+func responses(ok int, okResp *Response, codes ...int) map[string]*Response {
+	out := map[string]*Response{strconv.Itoa(ok): okResp}
+	for _, code := range codes {
+		out[strconv.Itoa(code)] = errorRef(code)
+	}
+	return out
+}
+
+// noContent is the response of a successful deletion.
+var noContent = &Response{Description: "Deleted"}
+
+// errorRef refers to the shared error response of code.
+func errorRef(code int) *Response {
+	if _, ok := errorResponses[code]; !ok {
+		panic("openapi: no shared response for status " + strconv.Itoa(code))
+	}
+	return &Response{Ref: "#/components/responses/" + errorName(code)}
+}
+
+// errorName is the component name of the shared error response of code, e.g. NotFound.
+func errorName(code int) string {
+	return strings.ReplaceAll(http.StatusText(code), " ", "")
+}
+
+// query returns an optional string query parameter.
+func query(name, description string) *Parameter {
+	return &Parameter{Name: name, In: "query", Description: description, Schema: &Schema{Type: "string"}}
+}
+
+// requiredQuery returns a required string query parameter.
+func requiredQuery(name, description string) *Parameter {
+	p := query(name, description)
+	p.Required = true
+	return p
+}
+
+// typedQuery returns an optional query parameter of a JSON schema type and format.
+func typedQuery(name, typ, format, description string) *Parameter {
+	return &Parameter{Name: name, In: "query", Description: description, Schema: &Schema{Type: typ, Format: format}}
+}
+
+// pathParam returns a path parameter, which is always required.
+func pathParam(name, description string) *Parameter {
+	return &Parameter{Name: name, In: "path", Description: description, Required: true, Schema: &Schema{Type: "string"}}
+}
+
+// pagination returns the limit and offset parameters of list routes.
+func pagination() []*Parameter {
+	return []*Parameter{
+		typedQuery("limit", "integer", "int32", "Page size (default 50, max 200)"),
+		typedQuery("offset", "integer", "int32", "Number of results to skip (default 0)"),
+	}
+}
+
+func (b *builder) clients() {
+	tags := []string{"clients"}
+	b.add(http.MethodPost, "/api/v1/clients", &Operation{
+		Tags: tags, OperationID: "createClient", Summary: "Create a client",
+		RequestBody: b.body(handlers.CreateClientRequest{}),
+		Responses:   responses(http.StatusCreated, b.json("The created client", database.Client{}), http.StatusBadRequest, http.StatusConflict),
+	})
+	b.add(http.MethodGet, "/api/v1/clients", &Operation{
+		Tags: tags, OperationID: "getClients", Summary: "Get a client, or list clients",
+		Description: "With client_id, returns that client; otherwise a page of clients.",
+		Parameters:  append([]*Parameter{query("client_id", "Client to return")}, pagination()...),
+		Responses:   responses(http.StatusOK, b.json("The client, or a page of clients", database.Client{}, database.ClientListResult{}), http.StatusNotFound),
+	})
+	b.add(http.MethodPut, "/api/v1/clients/settings", &Operation{
+		Tags: tags, OperationID: "updateClientSettings", Summary: "Change how a client's notification timestamps are rendered",
+		Description: "Omitted fields keep their value; an empty string resets to the default.",
+		Parameters:  []*Parameter{requiredQuery("client_id", "Client to update")},
+		RequestBody: b.body(handlers.UpdateClientSettingsRequest{}),
+		Responses:   responses(http.StatusOK, b.json("The updated client", database.Client{}), http.StatusBadRequest, http.StatusNotFound),
+	})
+	b.add(http.MethodPost, "/api/v1/clients/bootstrap", &Operation{
+		Tags: tags, OperationID: "bootstrapClient", Summary: "Create a client with its rules and endpoints in one transaction",
+		Description: "Without rules, a default rule set emailing every CRITICAL alert to email is created.",
+		RequestBody: b.body(handlers.BootstrapClientRequest{}),
+		Responses:   responses(http.StatusCreated, b.json("The created client, rules and endpoints", database.BootstrapResult{}), http.StatusBadRequest, http.StatusConflict),
+	})
+}
+
+func (b *builder) rules() {
+	tags := []string{"rules"}
+	ruleID := requiredQuery("rule_id", "Rule ID")
+	b.add(http.MethodPost, "/api/v1/rules", &Operation{
+		Tags: tags, OperationID: "createRule", Summary: "Create a rule",
+		Description: "Publishes a rule.changed event.",
+		RequestBody: b.body(handlers.CreateRuleRequest{}),
+		Responses:   responses(http.StatusCreated, b.json("The created rule", database.Rule{}), http.StatusBadRequest, http.StatusNotFound, http.StatusConflict),
+	})
+	b.add(http.MethodGet, "/api/v1/rules", &Operation{
+		Tags: tags, OperationID: "getRules", Summary: "Get a rule, or list rules",
+		Description: "With rule_id, returns that rule; otherwise a page of rules matching the filters.",
+		Parameters: append([]*Parameter{
+			query("rule_id", "Rule to return"),
+			query("client_id", "Only rules of this client"),
+			typedQuery("enabled", "boolean", "", "Only enabled or disabled rules"),
+			query("severity", "Only rules with this severity"),
+			query("source", "Only rules with this source"),
+			query("name", "Only rules whose name contains this substring"),
+			typedQuery("updated_since", "string", "date-time", "Only rules updated at or after this time"),
+		}, pagination()...),
+		Responses: responses(http.StatusOK, b.json("The rule, or a page of rules", database.Rule{}, database.RuleListResult{}), http.StatusBadRequest, http.StatusNotFound),
+	})
+	b.add(http.MethodPut, "/api/v1/rules/update", &Operation{
+		Tags: tags, OperationID: "updateRule", Summary: "Update a rule",
+		Description: "version must be the current version of the rule. Publishes a rule.changed event.",
+		Parameters:  []*Parameter{ruleID},
+		RequestBody: b.body(handlers.UpdateRuleRequest{}),
+		Responses:   responses(http.StatusOK, b.json("The updated rule", database.Rule{}), http.StatusBadRequest, http.StatusNotFound, http.StatusConflict),
+	})
+	b.add(http.MethodPost, "/api/v1/rules/toggle", &Operation{
+		Tags: tags, OperationID: "toggleRule", Summary: "Enable or disable a rule",
+		Description: "version must be the current version of the rule. Publishes a rule.changed event.",
+		Parameters:  []*Parameter{ruleID},
+		RequestBody: b.body(handlers.ToggleRuleEnabledRequest{}),
+		Responses:   responses(http.StatusOK, b.json("The updated rule", database.Rule{}), http.StatusBadRequest, http.StatusNotFound, http.StatusConflict),
+	})
+	b.add(http.MethodPost, "/api/v1/rules/bulk-toggle", &Operation{
+		Tags: tags, OperationID: "bulkToggleRules", Summary: "Enable or disable many rules atomically",
+		Description: "Selects the rules in rule_ids that match filter; rule_ids or filter.client_id is required. Nothing changes if a rule is unknown or a given version does not match. Publishes one rule.changed event per changed rule in a single Kafka write.",
+		RequestBody: b.body(handlers.BulkToggleRulesRequest{}),
+		Responses:   responses(http.StatusOK, b.json("The changed and unchanged rules", database.BulkToggleResult{}), http.StatusBadRequest, http.StatusNotFound, http.StatusConflict),
+	})
+	b.add(http.MethodDelete, "/api/v1/rules/delete", &Operation{
+		Tags: tags, OperationID: "deleteRule", Summary: "Delete a rule and its endpoints",
+		Description: "Publishes a rule.changed event.",
+		Parameters:  []*Parameter{ruleID},
+		Responses:   responses(http.StatusNoContent, noContent, http.StatusBadRequest, http.StatusNotFound),
+	})
+	b.add(http.MethodGet, "/api/v1/rules/conflicts", &Operation{
+		Tags: tags, OperationID: "getRuleConflicts", Summary: "Report duplicate and shadowed rules of a client",
+		Parameters: []*Parameter{requiredQuery("client_id", "Client whose rules are analyzed")},
+		Responses:  responses(http.StatusOK, b.json("The conflict report", conflicts.Report{}), http.StatusBadRequest, http.StatusNotFound),
+	})
+	format := query("format", "json (default) or yaml")
+	format.Schema.Enum = []string{"json", "yaml"}
+	b.add(http.MethodGet, "/api/v1/rules/export", &Operation{
+		Tags: tags, OperationID: "exportRules", Summary: "Export a client's rules and their endpoints",
+		Description: "The document can be imported with importRules. Endpoint headers, OAuth2 settings and templates are not exported.",
+		Parameters:  []*Parameter{requiredQuery("client_id", "Client whose rules are exported"), format},
+		Responses: responses(http.StatusOK, &Response{
+			Description: "The rule document",
+			Content: map[string]*MediaType{
+				"application/json": {Schema: b.schemas.of(handlers.RuleDocument{})},
+				"application/yaml": {Schema: b.schemas.of(handlers.RuleDocument{})},
+			},
+		}, http.StatusBadRequest, http.StatusNotFound),
+	})
+	imported := responses(http.StatusCreated, b.json("The created and skipped rows", handlers.RuleImportResult{}), http.StatusNotFound, http.StatusConflict)
+	imported[strconv.Itoa(http.StatusOK)] = b.json("The dry-run report, or nothing to create", handlers.RuleImportResult{})
+	imported[strconv.Itoa(http.StatusBadRequest)] = b.json("The document or some of its rows are invalid; nothing was created", shared.ValidationError{}, handlers.RuleImportResult{})
+	b.add(http.MethodPost, "/api/v1/rules/import", &Operation{
+		Tags: tags, OperationID: "importRules", Summary: "Import a rule document for its client",
+		Description: "Creates the document's rules and endpoints in one transaction and publishes a single rule.changed REBUILD event. Rules the client already has are skipped. Send YAML with Content-Type application/yaml.",
+		Parameters:  []*Parameter{typedQuery("dry_run", "boolean", "", "Validate and report without creating anything")},
+		RequestBody: &RequestBody{
+			Required: true,
+			Content: map[string]*MediaType{
+				"application/json": {Schema: b.schemas.of(handlers.RuleDocument{})},
+				"application/yaml": {Schema: b.schemas.of(handlers.RuleDocument{})},
+			},
+		},
+		Responses: imported,
+	})
+	b.add(http.MethodPost, "/api/v1/rules/impact", &Operation{
+		Tags: tags, OperationID: "getRuleImpact", Summary: "Estimate the notifications a proposed rule would have generated",
+		Description: "With rule_id, the proposal is a change to that rule and its current criteria are analyzed too.",
+		RequestBody: b.body(handlers.RuleImpactRequest{}),
+		Responses:   responses(http.StatusOK, b.json("The historical notification volume", handlers.RuleImpactResponse{}), http.StatusBadRequest, http.StatusNotFound),
+	})
+	b.add(http.MethodPost, "/api/v1/rules/test", &Operation{
+		Tags: tags, OperationID: "testRule", Summary: "Test a sample alert against a client's rules",
+		Description: "Matches the alert like the evaluator does, including disabled rules, and reports the matching rules and the endpoints that would be notified. Nothing is published.",
+		RequestBody: b.body(handlers.RuleTestRequest{}),
+		Responses:   responses(http.StatusOK, b.json("The matching rules and notified endpoints", handlers.RuleTestResponse{}), http.StatusBadRequest, http.StatusNotFound),
+	})
+	b.add(http.MethodGet, "/api/v1/rules/escalation-policy", &Operation{
+		Tags: tags, OperationID: "getRuleEscalationPolicy", Summary: "Get the escalation policy of a rule",
+		Parameters: []*Parameter{ruleID},
+		Responses:  responses(http.StatusOK, b.json("The rule's escalation policy; empty if none", handlers.RuleEscalationPolicy{}), http.StatusBadRequest, http.StatusNotFound),
+	})
+	b.add(http.MethodPut, "/api/v1/rules/escalation-policy", &Operation{
+		Tags: tags, OperationID: "setRuleEscalationPolicy", Summary: "Link a rule to an escalation policy of its client",
+		Description: "An empty escalation_policy_id unlinks the rule. Open notifications of the rule escalate with the new policy.",
+		Parameters:  []*Parameter{ruleID},
+		RequestBody: b.body(handlers.RuleEscalationPolicy{}),
+		Responses:   responses(http.StatusOK, b.json("The rule's escalation policy", handlers.RuleEscalationPolicy{}), http.StatusBadRequest, http.StatusNotFound),
+	})
+}
+
+func (b *builder) endpoints() {
+	tags := []string{"endpoints"}
+	endpointID := requiredQuery("endpoint_id", "Endpoint ID")
+	secrets := "Secret header values and OAuth2 client secrets are masked."
+	b.add(http.MethodPost, "/api/v1/endpoints", &Operation{
+		Tags: tags, OperationID: "createEndpoint", Summary: "Create an endpoint for a rule",
+		Description: "type is email, webhook or slack. headers and oauth2 are for webhooks only; template_id for email and slack only.",
+		RequestBody: b.body(handlers.CreateEndpointRequest{}),
+		Responses:   responses(http.StatusCreated, b.json("The created endpoint. "+secrets, database.Endpoint{}), http.StatusBadRequest, http.StatusNotFound, http.StatusConflict),
+	})
+	b.add(http.MethodGet, "/api/v1/endpoints", &Operation{
+		Tags: tags, OperationID: "getEndpoints", Summary: "Get an endpoint, or list endpoints",
+		Description: "With endpoint_id, returns that endpoint; otherwise a page of endpoints. Client API keys must filter by rule_id.",
+		Parameters: append([]*Parameter{
+			query("endpoint_id", "Endpoint to return"),
+			query("rule_id", "Only endpoints of this rule"),
+		}, pagination()...),
+		Responses: responses(http.StatusOK, b.json("The endpoint, or a page of endpoints. "+secrets, database.Endpoint{}, database.EndpointListResult{}), http.StatusBadRequest, http.StatusNotFound),
+	})
+	b.add(http.MethodPut, "/api/v1/endpoints/update", &Operation{
+		Tags: tags, OperationID: "updateEndpoint", Summary: "Update an endpoint",
+		Description: "Omitting headers, oauth2 or template_id keeps the current value; an empty list, object or string removes it.",
+		Parameters:  []*Parameter{endpointID},
+		RequestBody: b.body(handlers.UpdateEndpointRequest{}),
+		Responses:   responses(http.StatusOK, b.json("The updated endpoint. "+secrets, database.Endpoint{}), http.StatusBadRequest, http.StatusNotFound, http.StatusConflict),
+	})
+	b.add(http.MethodPost, "/api/v1/endpoints/toggle", &Operation{
+		Tags: tags, OperationID: "toggleEndpoint", Summary: "Enable or disable an endpoint",
+		Parameters:  []*Parameter{endpointID},
+		RequestBody: b.body(handlers.ToggleEndpointEnabledRequest{}),
+		Responses:   responses(http.StatusOK, b.json("The updated endpoint. "+secrets, database.Endpoint{}), http.StatusBadRequest, http.StatusNotFound),
+	})
+	b.add(http.MethodDelete, "/api/v1/endpoints/delete", &Operation{
+		Tags: tags, OperationID: "deleteEndpoint", Summary: "Delete an endpoint",
+		Parameters: []*Parameter{endpointID},
+		Responses:  responses(http.StatusNoContent, noContent, http.StatusBadRequest, http.StatusNotFound),
+	})
+}
+
+func (b *builder) notifications() {
+	tags := []string{"notifications"}
+	notificationID := requiredQuery("notification_id", "Notification ID")
+	status := query("status", "Only notifications with any of these statuses, comma-separated or repeated")
+	b.add(http.MethodGet, "/api/v1/notifications", &Operation{
+		Tags: tags, OperationID: "getNotifications", Summary: "Get a notification, or list notifications",
+		Description: "With notification_id, returns that notification; otherwise a page of notifications, newest first.",
+		Parameters: append([]*Parameter{
+			query("notification_id", "Notification to return"),
+			query("client_id", "Only notifications of this client"),
+			status,
+		}, pagination()...),
+		Responses: responses(http.StatusOK, b.json("The notification, or a page of notifications", database.Notification{}, database.NotificationListResult{}), http.StatusBadRequest, http.StatusNotFound),
+	})
+	b.add(http.MethodGet, "/api/v1/severities", &Operation{
+		Tags: tags, OperationID: "listSeverities", Summary: "List the color, emoji and label of each severity",
+		Description: "The styles the sender uses in email and Slack notifications, most urgent first.",
+		Responses:   responses(http.StatusOK, b.json("The severity styles", handlers.SeveritiesResponse{})),
+	})
+	b.add(http.MethodGet, "/api/v1/notifications/summary", &Operation{
+		Tags: tags, OperationID: "getNotificationSummary", Summary: "Count recent notifications by status and severity",
+		Description: "Served from per-hour counters, so the window starts at the beginning of the hour it reaches back to.",
+		Parameters: []*Parameter{
+			query("client_id", "Only notifications of this client"),
+			query("window", "How far back to count, as a duration (default 24h, max 720h)"),
+		},
+		Responses: responses(http.StatusOK, b.json("The notification counts", database.NotificationSummary{}), http.StatusBadRequest),
+	})
+	b.add(http.MethodGet, "/api/v1/notifications/events", &Operation{
+		Tags: tags, OperationID: "listNotificationEvents", Summary: "List the journal of a notification, oldest first",
+		Parameters: []*Parameter{notificationID},
+		Responses:  responses(http.StatusOK, b.json("The notification's events", []*database.NotificationEvent{}), http.StatusBadRequest, http.StatusNotFound),
+	})
+	transition := b.body(handlers.NotificationTransitionRequest{})
+	b.add(http.MethodPost, "/api/v1/notifications/ack", &Operation{
+		Tags: tags, OperationID: "acknowledgeNotification", Summary: "Acknowledge a notification, which stops its reminders",
+		RequestBody: transition,
+		Responses:   responses(http.StatusOK, b.json("The acknowledged notification", database.Notification{}), http.StatusBadRequest, http.StatusNotFound, http.StatusConflict),
+	})
+	b.add(http.MethodPost, "/api/v1/notifications/resolve", &Operation{
+		Tags: tags, OperationID: "resolveNotification", Summary: "Resolve a notification",
+		RequestBody: transition,
+		Responses:   responses(http.StatusOK, b.json("The resolved notification", database.Notification{}), http.StatusBadRequest, http.StatusNotFound, http.StatusConflict),
+	})
+	b.add(http.MethodGet, "/api/v1/notifications/{id}/deliveries", &Operation{
+		Tags: tags, OperationID: "listNotificationDeliveries", Summary: "List the endpoint deliveries of a notification, oldest first",
+		Parameters: []*Parameter{pathParam("id", "Notification ID")},
+		Responses:  responses(http.StatusOK, b.json("The notification's deliveries", []*database.NotificationDelivery{}), http.StatusBadRequest, http.StatusNotFound),
+	})
+
+	exported := &Response{
+		Description: "The notifications as CSV or NDJSON. If more rows follow the page, the X-Next-Offset trailer holds the offset of the next page.",
+		Content: map[string]*MediaType{
+			export.FormatCSV.ContentType():    {Schema: &Schema{Type: "string"}},
+			export.FormatNDJSON.ContentType(): {Schema: &Schema{Type: "string"}},
+		},
+	}
+	format := query("format", "csv (default) or ndjson")
+	format.Schema.Enum = []string{string(export.FormatCSV), string(export.FormatNDJSON)}
+	b.add(http.MethodGet, "/api/v1/notifications/export", &Operation{
+		Tags: tags, OperationID: "exportNotifications", Summary: "Stream a client's notifications in a time range",
+		Parameters: []*Parameter{
+			requiredQuery("client_id", "Client whose notifications are exported"),
+			{Name: "from", In: "query", Description: "Start of the range", Required: true, Schema: &Schema{Type: "string", Format: "date-time"}},
+			typedQuery("to", "string", "date-time", "End of the range (default now)"),
+			format,
+			query("columns", "Comma-separated columns, in order (default all)"),
+			status,
+			typedQuery("limit", "integer", "int32", "Rows per page"),
+			typedQuery("offset", "integer", "int32", "Rows to skip"),
+		},
+		Responses: responses(http.StatusOK, exported, http.StatusBadRequest),
+	})
+	jobID := requiredQuery("job_id", "Export job ID")
+	b.add(http.MethodPost, "/api/v1/notifications/export/jobs", &Operation{
+		Tags: tags, OperationID: "startExportJob", Summary: "Start an asynchronous export for large ranges",
+		RequestBody: b.body(handlers.ExportJobRequest{}),
+		Responses:   responses(http.StatusAccepted, b.json("The started job", export.Job{}), http.StatusBadRequest, http.StatusNotImplemented),
+	})
+	b.add(http.MethodGet, "/api/v1/notifications/export/jobs", &Operation{
+		Tags: tags, OperationID: "getExportJob", Summary: "Get the status of an export job",
+		Parameters: []*Parameter{jobID},
+		Responses:  responses(http.StatusOK, b.json("The job", export.Job{}), http.StatusBadRequest, http.StatusNotFound, http.StatusNotImplemented),
+	})
+	b.add(http.MethodGet, "/api/v1/notifications/export/jobs/download", &Operation{
+		Tags: tags, OperationID: "downloadExportJob", Summary: "Download the file of a completed export job",
+		Parameters: []*Parameter{jobID},
+		Responses: responses(http.StatusOK, &Response{Description: "The export file", Content: exported.Content},
+			http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusNotImplemented),
+	})
+}
@@ -0,0 +1,159 @@
+// Package outbox relays endpoint changes from the endpoint_outbox table to the endpoint.changed topic.
+// A trigger records every endpoint write in the outbox within the same transaction, so no change
+// is lost if Kafka is down or rule-service stops between the write and the publish: the relay
+// publishes pending rows in order and marks them published once Kafka has acknowledged them.
+package outbox
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"rule-service/internal/database"
+	"rule-service/internal/events"
+)
+
+const (
+	// DefaultInterval is how often the relay polls the outbox.
+	DefaultInterval = time.Second
+	// DefaultBatchSize is the maximum number of changes published per outbox transaction.
+	DefaultBatchSize = 100
+	// DefaultRetention is how long published changes are kept in the outbox.
+	DefaultRetention = 7 * 24 * time.Hour
+	// pruneInterval is how often published changes older than the retention are deleted.
+	pruneInterval = time.Hour
+)
+
+// eventSchemaVersion is the endpoint.changed schema version of published events.
+const eventSchemaVersion = 1
+
+// Store provides the outbox persistence needed by the relay.
+type Store interface {
+	// PublishEndpointChanges hands up to limit pending changes to publish, in order,
+	// and marks the published ones.
+	PublishEndpointChanges(ctx context.Context, limit int, publish func(*database.EndpointChange) error) (int, error)
+	// PruneEndpointOutbox deletes changes published before the given time.
+	PruneEndpointOutbox(ctx context.Context, before time.Time) (int64, error)
+}
+
+// EndpointPublisher publishes endpoint change events to endpoint.changed.
+type EndpointPublisher interface {
+	PublishEndpointChanged(ctx context.Context, changed *events.EndpointChanged) error
+}
+
+// MetricsRecorder is the subset of metrics used by the relay.
+type MetricsRecorder interface {
+	IncrementCustom(name string)
+}
+
+// noOpMetrics is used when no metrics recorder is provided.
+type noOpMetrics struct{}
+
+func (noOpMetrics) IncrementCustom(string) {}
+
+// Relay publishes pending outbox rows to Kafka.
+type Relay struct {
+	store     Store
+	publisher EndpointPublisher
+	metrics   MetricsRecorder
+	interval  time.Duration
+	batchSize int
+	retention time.Duration
+	now       func() time.Time
+	lastPrune time.Time
+}
+
+// NewRelay creates a relay that polls the outbox every interval.
+// A non-positive interval uses DefaultInterval; a nil metrics recorder is replaced with a no-op.
+func NewRelay(store Store, publisher EndpointPublisher, m MetricsRecorder, interval time.Duration) *Relay {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	if m == nil {
+		m = noOpMetrics{}
+	}
+	return &Relay{
+		store:     store,
+		publisher: publisher,
+		metrics:   m,
+		interval:  interval,
+		batchSize: DefaultBatchSize,
+		retention: DefaultRetention,
+		now:       time.Now,
+	}
+}
+
+// Run relays endpoint changes until ctx is cancelled.
+func (r *Relay) Run(ctx context.Context) {
+	slog.Info("Starting endpoint outbox relay", "interval", r.interval)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Endpoint outbox relay stopped")
+			return
+		case <-ticker.C:
+			r.RelayOnce(ctx)
+			r.pruneIfDue(ctx)
+		}
+	}
+}
+
+// RelayOnce publishes pending changes until the outbox is drained or publishing fails,
+// and returns the number of events published.
+func (r *Relay) RelayOnce(ctx context.Context) int {
+	total := 0
+	for {
+		n, err := r.store.PublishEndpointChanges(ctx, r.batchSize, func(c *database.EndpointChange) error {
+			return r.publisher.PublishEndpointChanged(ctx, r.buildEvent(c))
+		})
+		total += n
+		for i := 0; i < n; i++ {
+			r.metrics.IncrementCustom("endpoint_events_published")
+		}
+		if err != nil {
+			// The failed change stays pending and is retried, in order, on the next poll
+			slog.Error("Failed to relay endpoint changes", "published", n, "error", err)
+			r.metrics.IncrementCustom("endpoint_outbox_errors")
+			return total
+		}
+		if n < r.batchSize {
+			return total
+		}
+	}
+}
+
+// pruneIfDue deletes old published changes at most once per pruneInterval.
+func (r *Relay) pruneIfDue(ctx context.Context) {
+	now := r.now()
+	if now.Sub(r.lastPrune) < pruneInterval {
+		return
+	}
+	r.lastPrune = now
+	n, err := r.store.PruneEndpointOutbox(ctx, now.Add(-r.retention))
+	if err != nil {
+		slog.Error("Failed to prune endpoint outbox", "error", err)
+		return
+	}
+	if n > 0 {
+		slog.Info("Pruned endpoint outbox", "deleted", n, "retention", r.retention)
+	}
+}
+
+// buildEvent converts an outbox row to the endpoint.changed event.
+func (r *Relay) buildEvent(c *database.EndpointChange) *events.EndpointChanged {
+	return &events.EndpointChanged{
+		EndpointID:    c.EndpointID,
+		RuleID:        c.RuleID,
+		ClientID:      c.ClientID,
+		Action:        c.Action,
+		Type:          c.EndpointType,
+		Enabled:       c.Enabled,
+		ChangedAt:     c.CreatedAt.Unix(),
+		SchemaVersion: eventSchemaVersion,
+		PublishedAtMs: r.now().UnixMilli(),
+	}
+}
@@ -0,0 +1,141 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"rule-service/internal/database"
+	"rule-service/internal/events"
+)
+
+// fakeStore is a test fake for Store that serves pending changes in order.
+type fakeStore struct {
+	pending []*database.EndpointChange
+	pruned  []time.Time
+}
+
+func (f *fakeStore) PublishEndpointChanges(ctx context.Context, limit int, publish func(*database.EndpointChange) error) (int, error) {
+	n := 0
+	for n < limit && n < len(f.pending) {
+		if err := publish(f.pending[n]); err != nil {
+			f.pending = f.pending[n:]
+			return n, err
+		}
+		n++
+	}
+	f.pending = f.pending[n:]
+	return n, nil
+}
+
+func (f *fakeStore) PruneEndpointOutbox(ctx context.Context, before time.Time) (int64, error) {
+	f.pruned = append(f.pruned, before)
+	return 0, nil
+}
+
+// fakePublisher is a test fake for EndpointPublisher.
+type fakePublisher struct {
+	published []*events.EndpointChanged
+	failOn    string // endpoint_id whose publish fails
+}
+
+func (f *fakePublisher) PublishEndpointChanged(ctx context.Context, changed *events.EndpointChanged) error {
+	if changed.EndpointID == f.failOn {
+		return errors.New("kafka unavailable")
+	}
+	f.published = append(f.published, changed)
+	return nil
+}
+
+// fakeMetrics is a test fake for MetricsRecorder.
+type fakeMetrics struct {
+	counts map[string]int
+}
+
+func (f *fakeMetrics) IncrementCustom(name string) {
+	if f.counts == nil {
+		f.counts = make(map[string]int)
+	}
+	f.counts[name]++
+}
+
+func changes(ids ...string) []*database.EndpointChange {
+	var result []*database.EndpointChange
+	for i, id := range ids {
+		result = append(result, &database.EndpointChange{
+			EventID:      int64(i + 1),
+			EndpointID:   id,
+			RuleID:       "rule-1",
+			ClientID:     "client-1",
+			Action:       events.ActionUpdated,
+			EndpointType: "webhook",
+			Enabled:      true,
+			CreatedAt:    time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC),
+		})
+	}
+	return result
+}
+
+func TestRelay_RelayOnce_DrainsInBatches(t *testing.T) {
+	store := &fakeStore{pending: changes("ep-1", "ep-2", "ep-3", "ep-4", "ep-5")}
+	pub := &fakePublisher{}
+	m := &fakeMetrics{}
+	relay := NewRelay(store, pub, m, 0)
+	relay.batchSize = 2
+
+	if n := relay.RelayOnce(context.Background()); n != 5 {
+		t.Fatalf("RelayOnce() = %d, want 5", n)
+	}
+	if len(store.pending) != 0 || m.counts["endpoint_events_published"] != 5 {
+		t.Errorf("pending = %d, published metric = %d, want the outbox drained", len(store.pending), m.counts["endpoint_events_published"])
+	}
+
+	got := pub.published[0]
+	if got.EndpointID != "ep-1" || got.RuleID != "rule-1" || got.Action != events.ActionUpdated ||
+		got.Type != "webhook" || got.ChangedAt != 1773144000 || got.SchemaVersion != eventSchemaVersion {
+		t.Errorf("published event = %+v", got)
+	}
+}
+
+func TestRelay_RelayOnce_StopsOnFailure(t *testing.T) {
+	store := &fakeStore{pending: changes("ep-1", "ep-2", "ep-3")}
+	pub := &fakePublisher{failOn: "ep-2"}
+	m := &fakeMetrics{}
+	relay := NewRelay(store, pub, m, time.Second)
+
+	if n := relay.RelayOnce(context.Background()); n != 1 {
+		t.Fatalf("RelayOnce() = %d, want 1", n)
+	}
+	if len(store.pending) != 2 || store.pending[0].EndpointID != "ep-2" {
+		t.Errorf("pending = %v, want ep-2 and ep-3 left for the next poll", store.pending)
+	}
+	if m.counts["endpoint_outbox_errors"] != 1 {
+		t.Errorf("metrics = %v, want one outbox error", m.counts)
+	}
+
+	// Once Kafka recovers the remaining changes go out in order
+	pub.failOn = ""
+	if n := relay.RelayOnce(context.Background()); n != 2 || pub.published[1].EndpointID != "ep-2" {
+		t.Errorf("RelayOnce() = %d, published %v", n, pub.published)
+	}
+}
+
+func TestRelay_PruneIfDue(t *testing.T) {
+	store := &fakeStore{}
+	relay := NewRelay(store, &fakePublisher{}, nil, 0)
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	relay.now = func() time.Time { return now }
+
+	relay.pruneIfDue(context.Background())
+	relay.pruneIfDue(context.Background())
+	if len(store.pruned) != 1 || !store.pruned[0].Equal(now.Add(-DefaultRetention)) {
+		t.Fatalf("pruned = %v, want one prune of changes older than the retention", store.pruned)
+	}
+
+	now = now.Add(pruneInterval)
+	relay.pruneIfDue(context.Background())
+	if len(store.pruned) != 2 {
+		t.Errorf("pruned = %v, want a second prune after the prune interval", store.pruned)
+	}
+}
@@ -0,0 +1,107 @@
+// Package producer provides Kafka producer functionality for rule.changed topic.
+package producer
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"rule-service/internal/events"
+
+	kafkautil "github.com/afikmenashe/alerting-platform/pkg/kafka"
+	protoalerts "github.com/afikmenashe/alerting-platform/pkg/proto/alerts"
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/protobuf/proto"
+)
+
+// AlertProducer publishes synthetic alerts (e.g. missed heartbeats) to the alerts.new topic.
+// Messages use the same protobuf encoding and keying as alert-producer so the evaluator
+// treats them like any other alert.
+type AlertProducer struct {
+	writer *kafka.Writer
+	topic  string
+}
+
+// NewAlertProducer creates a new Kafka producer for the alerts.new topic.
+func NewAlertProducer(brokers string, topic string) (*AlertProducer, error) {
+	if err := kafkautil.ValidateProducerParams(brokers, topic); err != nil {
+		return nil, err
+	}
+
+	brokerList := kafkautil.ParseBrokers(brokers)
+
+	slog.Info("Initializing Kafka alert producer",
+		"brokers", brokerList,
+		"topic", topic,
+	)
+
+	writer := &kafka.Writer{
+		Addr:         kafka.TCP(brokerList...),
+		Transport:    kafkautil.Transport(),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		WriteTimeout: kafkautil.WriteTimeout,
+		RequiredAcks: kafka.RequireOne,
+		Async:        false,
+		BatchSize:    1,
+	}
+
+	return &AlertProducer{
+		writer: writer,
+		topic:  topic,
+	}, nil
+}
+
+// PublishAlert serializes an alert to protobuf and publishes it to Kafka.
+// The message is keyed by a hash of alert_id, matching alert-producer.
+func (p *AlertProducer) PublishAlert(ctx context.Context, alert *events.AlertNew) error {
+	payload, err := proto.Marshal(&protoalerts.AlertNew{
+		AlertId:       alert.AlertID,
+		SchemaVersion: int32(alert.SchemaVersion),
+		EventTs:       alert.EventTS,
+		Severity:      events.ToProtoSeverity(alert.Severity),
+		Source:        alert.Source,
+		Name:          alert.Name,
+		Context:       alert.Context,
+		ClientHint:    alert.ClientHint,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal alert: %w", err)
+	}
+
+	key := sha256.Sum256([]byte(alert.AlertID))
+	msg := kafka.Message{
+		Key:   key[:16],
+		Value: payload,
+		Headers: []kafka.Header{
+			{Key: "content-type", Value: []byte("application/x-protobuf")},
+			{Key: "schema_version", Value: []byte(fmt.Sprintf("%d", alert.SchemaVersion))},
+			{Key: "severity", Value: []byte(alert.Severity)},
+		},
+		Time: time.Unix(alert.EventTS, 0),
+	}
+
+	if err := p.writer.WriteMessages(ctx, msg); err != nil {
+		slog.Error("Failed to write alert to Kafka",
+			"alert_id", alert.AlertID,
+			"topic", p.topic,
+			"error", err,
+		)
+		return fmt.Errorf("failed to write message to Kafka: %w", err)
+	}
+
+	slog.Info("Published synthetic alert",
+		"alert_id", alert.AlertID,
+		"source", alert.Source,
+		"name", alert.Name,
+	)
+	return nil
+}
+
+// Close gracefully closes the Kafka writer and releases resources.
+func (p *AlertProducer) Close() error {
+	slog.Info("Closing Kafka alert producer", "topic", p.topic)
+	return p.writer.Close()
+}
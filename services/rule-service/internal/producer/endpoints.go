@@ -0,0 +1,106 @@
+// Package producer provides Kafka producer functionality for rule.changed topic.
+package producer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	kafkautil "github.com/afikmenashe/alerting-platform/pkg/kafka"
+	protoendpoints "github.com/afikmenashe/alerting-platform/pkg/proto/endpoints"
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/protobuf/proto"
+	"rule-service/internal/events"
+)
+
+// EndpointProducer publishes endpoint change events to the endpoint.changed topic.
+// Messages are keyed by rule_id, so the changes of one rule's endpoints stay in order.
+type EndpointProducer struct {
+	writer *kafka.Writer
+	topic  string
+}
+
+// NewEndpointProducer creates a new Kafka producer for the endpoint.changed topic.
+func NewEndpointProducer(brokers string, topic string) (*EndpointProducer, error) {
+	if err := kafkautil.ValidateProducerParams(brokers, topic); err != nil {
+		return nil, err
+	}
+
+	brokerList := kafkautil.ParseBrokers(brokers)
+
+	slog.Info("Initializing Kafka endpoint producer",
+		"brokers", brokerList,
+		"topic", topic,
+	)
+
+	createTopicIfNotExists(brokerList[0], topic)
+
+	writer := &kafka.Writer{
+		Addr:         kafka.TCP(brokerList...),
+		Transport:    kafkautil.Transport(),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		WriteTimeout: kafkautil.WriteTimeout,
+		RequiredAcks: kafka.RequireOne,
+		Async:        false,
+		BatchSize:    1,
+	}
+
+	return &EndpointProducer{
+		writer: writer,
+		topic:  topic,
+	}, nil
+}
+
+// PublishEndpointChanged serializes an endpoint change to protobuf and publishes it to Kafka.
+func (p *EndpointProducer) PublishEndpointChanged(ctx context.Context, changed *events.EndpointChanged) error {
+	payload, err := proto.Marshal(&protoendpoints.EndpointChanged{
+		EndpointId:    changed.EndpointID,
+		RuleId:        changed.RuleID,
+		ClientId:      changed.ClientID,
+		Action:        events.ToProtoEndpointAction(changed.Action),
+		Type:          changed.Type,
+		Enabled:       changed.Enabled,
+		ChangedAt:     changed.ChangedAt,
+		SchemaVersion: int32(changed.SchemaVersion),
+		PublishedAtMs: changed.PublishedAtMs,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal endpoint change: %w", err)
+	}
+
+	msg := kafka.Message{
+		Key:   []byte(changed.RuleID),
+		Value: payload,
+		Headers: []kafka.Header{
+			{Key: "content-type", Value: []byte("application/x-protobuf")},
+			{Key: "schema_version", Value: []byte(fmt.Sprintf("%d", changed.SchemaVersion))},
+			{Key: "action", Value: []byte(changed.Action)},
+		},
+		Time: time.Unix(changed.ChangedAt, 0),
+	}
+
+	if err := p.writer.WriteMessages(ctx, msg); err != nil {
+		slog.Error("Failed to write endpoint change to Kafka",
+			"endpoint_id", changed.EndpointID,
+			"rule_id", changed.RuleID,
+			"topic", p.topic,
+			"error", err,
+		)
+		return fmt.Errorf("failed to write message to Kafka: %w", err)
+	}
+
+	slog.Debug("Published endpoint.changed event",
+		"endpoint_id", changed.EndpointID,
+		"rule_id", changed.RuleID,
+		"action", changed.Action,
+	)
+	return nil
+}
+
+// Close gracefully closes the Kafka writer and releases resources.
+func (p *EndpointProducer) Close() error {
+	slog.Info("Closing Kafka endpoint producer", "topic", p.topic)
+	return p.writer.Close()
+}
@@ -42,6 +42,7 @@ func NewProducer(brokers string, topic string) (*Producer, error) {
 	// Use Hash balancer to partition by rule_id
 	writer := &kafka.Writer{
 		Addr:         kafka.TCP(brokerList...),
+		Transport:    kafkautil.Transport(),
 		Topic:        topic,
 		Balancer:     &kafka.Hash{}, // Key-based partitioning (hashes the message key)
 		WriteTimeout: kafkautil.WriteTimeout,
@@ -70,6 +71,67 @@ func NewProducer(brokers string, topic string) (*Producer, error) {
 // The message is keyed by rule_id for partition distribution.
 // Returns an error if serialization or publishing fails.
 func (p *Producer) Publish(ctx context.Context, changed *events.RuleChanged) error {
+	msg, err := newMessage(changed)
+	if err != nil {
+		return err
+	}
+
+	// Write to Kafka (synchronous, waits for ack)
+	if err := p.writer.WriteMessages(ctx, msg); err != nil {
+		slog.Error("Failed to write message to Kafka",
+			"rule_id", changed.RuleID,
+			"topic", p.topic,
+			"error", err,
+		)
+		return fmt.Errorf("failed to write message to Kafka: %w", err)
+	}
+
+	slog.Info("Published rule changed event",
+		"rule_id", changed.RuleID,
+		"client_id", changed.ClientID,
+		"action", changed.Action,
+		"version", changed.Version,
+	)
+
+	return nil
+}
+
+// PublishBatch publishes several rule changed events in a single Kafka write.
+// Each message is keyed by its rule_id like Publish. Returns an error if any event
+// cannot be serialized, in which case nothing is written, or if the write fails.
+func (p *Producer) PublishBatch(ctx context.Context, changes []*events.RuleChanged) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	msgs := make([]kafka.Message, 0, len(changes))
+	for _, changed := range changes {
+		msg, err := newMessage(changed)
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, msg)
+	}
+
+	if err := p.writer.WriteMessages(ctx, msgs...); err != nil {
+		slog.Error("Failed to write message batch to Kafka",
+			"count", len(msgs),
+			"topic", p.topic,
+			"error", err,
+		)
+		return fmt.Errorf("failed to write message batch to Kafka: %w", err)
+	}
+
+	slog.Info("Published rule changed event batch",
+		"count", len(msgs),
+		"action", changes[0].Action,
+	)
+
+	return nil
+}
+
+// newMessage serializes a rule changed event to a protobuf Kafka message keyed by rule_id
+// (client_id for rebuild events).
+func newMessage(changed *events.RuleChanged) (kafka.Message, error) {
 	evt := &protorules.RuleChanged{
 		RuleId:        changed.RuleID,
 		ClientId:      changed.ClientID,
@@ -77,6 +139,21 @@ func (p *Producer) Publish(ctx context.Context, changed *events.RuleChanged) err
 		Version:       int32(changed.Version),
 		UpdatedAt:     changed.UpdatedAt,
 		SchemaVersion: int32(changed.SchemaVersion),
+		PublishedAtMs: changed.PublishedAtMs,
+	}
+	if changed.Rule != nil {
+		evt.Rule = &protorules.RulePayload{
+			Severity: changed.Rule.Severity,
+			Source:   changed.Rule.Source,
+			Name:     changed.Rule.Name,
+			Enabled:  changed.Rule.Enabled,
+
+			ExcludeSources: changed.Rule.ExcludeSources,
+			ExcludeNames:   changed.Rule.ExcludeNames,
+		}
+		for _, c := range changed.Rule.Conditions {
+			evt.Rule.Conditions = append(evt.Rule.Conditions, &protorules.ContextCondition{Key: c.Key, Op: c.Op, Value: c.Value})
+		}
 	}
 
 	payload, err := proto.Marshal(evt)
@@ -87,11 +164,14 @@ func (p *Producer) Publish(ctx context.Context, changed *events.RuleChanged) err
 			"action", changed.Action,
 			"error", err,
 		)
-		return fmt.Errorf("failed to marshal rule changed event: %w", err)
+		return kafka.Message{}, fmt.Errorf("failed to marshal rule changed event: %w", err)
 	}
 
-	// Partition key: use rule_id
+	// Partition key: use rule_id, or client_id for rebuild events, which have no rule
 	partitionKey := []byte(changed.RuleID)
+	if changed.RuleID == "" {
+		partitionKey = []byte(changed.ClientID)
+	}
 
 	// Create Kafka message with key, value, headers, and timestamp
 	msg := kafka.Message{
@@ -117,25 +197,12 @@ func (p *Producer) Publish(ctx context.Context, changed *events.RuleChanged) err
 		},
 		Time: time.Unix(changed.UpdatedAt, 0),
 	}
+	return msg, nil
+}
 
-	// Write to Kafka (synchronous, waits for ack)
-	if err := p.writer.WriteMessages(ctx, msg); err != nil {
-		slog.Error("Failed to write message to Kafka",
-			"rule_id", changed.RuleID,
-			"topic", p.topic,
-			"error", err,
-		)
-		return fmt.Errorf("failed to write message to Kafka: %w", err)
-	}
-
-	slog.Info("Published rule changed event",
-		"rule_id", changed.RuleID,
-		"client_id", changed.ClientID,
-		"action", changed.Action,
-		"version", changed.Version,
-	)
-
-	return nil
+// Ping checks that the brokers are reachable and the topic exists, for health and readiness probes.
+func (p *Producer) Ping(ctx context.Context) error {
+	return kafkautil.Ping(ctx, p.writer.Addr, p.topic)
 }
 
 // Close gracefully closes the Kafka writer and releases resources.
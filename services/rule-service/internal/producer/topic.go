@@ -4,13 +4,14 @@ package producer
 import (
 	"log/slog"
 
+	kafkautil "github.com/afikmenashe/alerting-platform/pkg/kafka"
 	"github.com/segmentio/kafka-go"
 )
 
 // createTopicIfNotExists attempts to create the topic if it doesn't exist.
 // This is a best-effort operation and failures are logged but don't prevent producer creation.
 func createTopicIfNotExists(broker, topic string) {
-	conn, err := kafka.Dial("tcp", broker)
+	conn, err := kafkautil.Dial(broker)
 	if err != nil {
 		slog.Warn("Could not connect to Kafka to check/create topic",
 			"broker", broker,
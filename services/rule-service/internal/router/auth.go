@@ -0,0 +1,63 @@
+// Package router provides HTTP routing configuration for the rule-service API.
+package router
+
+import (
+	"net/http"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// publicPaths are served without an API key: probes and the API description, which carry
+// no client data.
+var publicPaths = []string{"/health", "/healthz", "/readyz", "/api/v1/openapi.json", "/api/v1/docs"}
+
+// clientScopedPatterns are the routes a client-scoped API key may call. Their handlers limit
+// the key to its own client's rules, endpoints, notifications, heartbeats and API keys, or
+// serve no client data (severities); every other route (clients, templates, admin, ...) needs an admin key.
+var clientScopedPatterns = map[string]bool{
+	"/api/v1/rules":                              true,
+	"/api/v1/rules/update":                       true,
+	"/api/v1/rules/toggle":                       true,
+	"/api/v1/rules/bulk-toggle":                  true,
+	"/api/v1/rules/delete":                       true,
+	"/api/v1/rules/conflicts":                    true,
+	"/api/v1/rules/export":                       true,
+	"/api/v1/rules/import":                       true,
+	"/api/v1/rules/test":                         true,
+	"/api/v1/endpoints":                          true,
+	"/api/v1/endpoints/update":                   true,
+	"/api/v1/endpoints/toggle":                   true,
+	"/api/v1/endpoints/delete":                   true,
+	"/api/v1/notifications":                      true,
+	"/api/v1/notifications/summary":              true,
+	"/api/v1/notifications/events":               true,
+	"/api/v1/notifications/ack":                  true,
+	"/api/v1/notifications/resolve":              true,
+	"/api/v1/notifications/{id}/deliveries":      true,
+	"/api/v1/notifications/export":               true,
+	"/api/v1/notifications/export/jobs":          true,
+	"/api/v1/notifications/export/jobs/download": true,
+	"/api/v1/heartbeats/{id}/ping":               true,
+	"/api/v1/api-keys":                           true,
+	"/api/v1/api-keys/revoke":                    true,
+	"/api/v1/severities":                         true,
+}
+
+// clientScopeMiddleware rejects client-scoped API keys with 403 on routes outside
+// clientScopedPatterns. Admin keys and unauthenticated requests pass through.
+func clientScopeMiddleware(mux *http.ServeMux) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, ok := shared.APIPrincipalFromContext(r.Context())
+			if !ok || p.Admin() {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if _, pattern := mux.Handler(r); pattern != "" && !clientScopedPatterns[pattern] {
+				http.Error(w, "Admin API key required", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,72 @@
+// Package router provides tests for API key authentication and client scoping.
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"rule-service/internal/database"
+	"rule-service/internal/handlers"
+	"rule-service/internal/producer"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// stubAPIKeyStore authenticates fixed keys.
+type stubAPIKeyStore map[string]shared.APIPrincipal
+
+func (s stubAPIKeyStore) LookupAPIKey(ctx context.Context, keyHash string) (*shared.APIPrincipal, error) {
+	for key, p := range s {
+		if shared.HashAPIKey(key) == keyHash {
+			return &p, nil
+		}
+	}
+	return nil, nil
+}
+
+// TestRouter_APIKeyAuth tests that routes require a key and client keys only reach client routes.
+func TestRouter_APIKeyAuth(t *testing.T) {
+	store := stubAPIKeyStore{
+		"client-key": {KeyID: "k1", Name: "ci", ClientID: "client-1"},
+		"admin-key":  {KeyID: "k2", Name: "ops"},
+	}
+	h := handlers.NewHandlers(&database.DB{}, &producer.Producer{}, nil)
+	handler := NewRouter(h, WithAuthenticator(shared.NewAPIKeyAuthenticator(store, "static-admin", 0))).Handler()
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		key        string
+		wantStatus int
+	}{
+		{"health is public", http.MethodGet, "/health", "", http.StatusOK},
+		{"API description is public", http.MethodGet, "/api/v1/openapi.json", "", http.StatusOK},
+		{"Swagger UI is public", http.MethodGet, "/api/v1/docs", "", http.StatusOK},
+		{"missing key", http.MethodPut, "/api/v1/clients", "", http.StatusUnauthorized},
+		{"unknown key", http.MethodPut, "/api/v1/clients", "nope", http.StatusUnauthorized},
+		{"client key on admin route", http.MethodPut, "/api/v1/clients", "client-key", http.StatusForbidden},
+		{"client key on client route", http.MethodPut, "/api/v1/api-keys", "client-key", http.StatusMethodNotAllowed},
+		{"admin key", http.MethodPut, "/api/v1/clients", "admin-key", http.StatusMethodNotAllowed},
+		{"static admin key", http.MethodPut, "/api/v1/clients", "static-admin", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.key != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.key)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("%s %s status = %v, want %v: %s", tt.method, tt.path, w.Code, tt.wantStatus, w.Body.String())
+			}
+			if w.Code == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Error("401 response has no WWW-Authenticate header")
+			}
+		})
+	}
+}
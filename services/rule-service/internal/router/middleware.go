@@ -8,21 +8,9 @@ import (
 	"github.com/afikmenashe/alerting-platform/pkg/metrics"
 )
 
-// corsMiddleware applies CORS headers to all requests.
-func corsMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-
-		next.ServeHTTP(w, r)
-	})
-}
+// probePaths are the health and readiness endpoints, which are neither counted in the request
+// metrics nor rate limited.
+var probePaths = map[string]bool{"/health": true, "/healthz": true, "/readyz": true}
 
 // responseWriter wraps http.ResponseWriter to capture status code.
 type responseWriter struct {
@@ -35,8 +23,13 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap lets http.ResponseController reach the underlying writer (flushing, deadlines).
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // metricsMiddleware tracks HTTP request metrics.
-func metricsMiddleware(collector *metrics.Collector) func(http.Handler) http.Handler {
+func metricsMiddleware(collector metrics.Collector) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			if collector == nil {
@@ -44,8 +37,8 @@ func metricsMiddleware(collector *metrics.Collector) func(http.Handler) http.Han
 				return
 			}
 
-			// Skip health endpoint
-			if r.URL.Path == "/health" {
+			// Skip health and readiness probes
+			if probePaths[r.URL.Path] {
 				next.ServeHTTP(w, r)
 				return
 			}
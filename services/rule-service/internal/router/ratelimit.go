@@ -0,0 +1,72 @@
+// Package router provides HTTP routing configuration for the rule-service API.
+package router
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+
+	"github.com/afikmenashe/alerting-platform/pkg/metrics"
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// RateLimiter decides whether a caller may make another request.
+// Implemented by *shared.RateLimiter.
+type RateLimiter interface {
+	Allow(ctx context.Context, caller string) (shared.RateLimitDecision, error)
+}
+
+// rateLimitCaller identifies the caller of r for rate limiting. Requests with a client-scoped
+// API key are limited per client and those with an admin key per key. Without API keys,
+// client-scoped requests are limited per client_id and the rest per remote address.
+func rateLimitCaller(r *http.Request) string {
+	if p, ok := shared.APIPrincipalFromContext(r.Context()); ok {
+		if !p.Admin() {
+			return p.ClientID
+		}
+		return "key:" + p.KeyID
+	}
+	if clientID := r.URL.Query().Get("client_id"); clientID != "" {
+		return clientID
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// rateLimitMiddleware rejects callers over their tier's limit with 429 and a Retry-After header.
+// If the limiter fails (e.g. Redis is down), requests are let through.
+func rateLimitMiddleware(limiter RateLimiter, collector metrics.Collector) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if limiter == nil || probePaths[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			caller := rateLimitCaller(r)
+			decision, err := limiter.Allow(r.Context(), caller)
+			if err != nil {
+				slog.Warn("Rate limit check failed, allowing request", "error", err, "caller", caller)
+				if collector != nil {
+					collector.IncrementCustom("rate_limit_errors")
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !decision.Allowed {
+				if collector != nil {
+					collector.IncrementCustom("rate_limited_requests")
+					collector.IncrementCustom("rate_limited_" + decision.Tier)
+				}
+				w.Header().Set("Retry-After", shared.RetryAfterSeconds(decision.RetryAfter))
+				http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,94 @@
+// Package router provides tests for the rate limiting middleware.
+package router
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/afikmenashe/alerting-platform/pkg/metrics"
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// fakeLimiter is a test fake for RateLimiter returning a fixed decision and recording callers.
+type fakeLimiter struct {
+	decision shared.RateLimitDecision
+	err      error
+	callers  []string
+}
+
+func (f *fakeLimiter) Allow(ctx context.Context, caller string) (shared.RateLimitDecision, error) {
+	f.callers = append(f.callers, caller)
+	return f.decision, f.err
+}
+
+// okHandler answers every request with 200.
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+})
+
+// TestRateLimitMiddleware tests that callers over their limit get 429 with Retry-After.
+func TestRateLimitMiddleware(t *testing.T) {
+	tests := []struct {
+		name           string
+		limiter        *fakeLimiter
+		wantStatus     int
+		wantRetryAfter string
+		wantCounter    string
+	}{
+		{"allowed", &fakeLimiter{decision: shared.RateLimitDecision{Allowed: true, Tier: "default"}}, http.StatusOK, "", ""},
+		{"throttled", &fakeLimiter{decision: shared.RateLimitDecision{Tier: "default", RetryAfter: 1500 * time.Millisecond}}, http.StatusTooManyRequests, "2", "rate_limited_default"},
+		{"limiter error fails open", &fakeLimiter{err: errors.New("redis down")}, http.StatusOK, "", "rate_limit_errors"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collector := metrics.NewMemoryCollector("rule-service")
+			handler := rateLimitMiddleware(tt.limiter, collector)(okHandler)
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/rules?client_id=acme", nil)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %v, want %v", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Retry-After"); got != tt.wantRetryAfter {
+				t.Errorf("Retry-After = %q, want %q", got, tt.wantRetryAfter)
+			}
+			if len(tt.limiter.callers) != 1 || tt.limiter.callers[0] != "acme" {
+				t.Errorf("callers = %v, want [acme]", tt.limiter.callers)
+			}
+			if tt.wantCounter != "" && collector.Custom(tt.wantCounter) != 1 {
+				t.Errorf("%s = %d, want 1", tt.wantCounter, collector.Custom(tt.wantCounter))
+			}
+		})
+	}
+}
+
+// TestRateLimitMiddleware_SkipsHealth tests that health and readiness probes are never limited.
+func TestRateLimitMiddleware_SkipsHealth(t *testing.T) {
+	limiter := &fakeLimiter{}
+	handler := rateLimitMiddleware(limiter, nil)(okHandler)
+
+	for _, path := range []string{"/health", "/healthz", "/readyz"} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+		if w.Code != http.StatusOK || len(limiter.callers) != 0 {
+			t.Errorf("%s: status = %v, callers = %v, want 200 and no check", path, w.Code, limiter.callers)
+		}
+	}
+}
+
+// TestRateLimitCaller tests that requests without a client_id are limited per remote address.
+func TestRateLimitCaller(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/rules", nil)
+	req.RemoteAddr = "10.0.0.7:51234"
+	if got := rateLimitCaller(req); got != "ip:10.0.0.7" {
+		t.Errorf("rateLimitCaller() = %q, want ip:10.0.0.7", got)
+	}
+}
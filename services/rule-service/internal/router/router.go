@@ -6,28 +6,65 @@ import (
 	"net/http"
 
 	"rule-service/internal/handlers"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
 )
 
 // Router wraps the HTTP mux and provides route configuration.
 type Router struct {
 	mux      *http.ServeMux
 	handlers *handlers.Handlers
+	limiter  RateLimiter
+	auth     *shared.APIKeyAuthenticator
+	cors     *shared.CORSPolicy
+}
+
+// Option configures optional router features.
+type Option func(*Router)
+
+// WithRateLimiter limits the requests of each client with limiter.
+func WithRateLimiter(limiter RateLimiter) Option {
+	return func(r *Router) {
+		r.limiter = limiter
+	}
+}
+
+// WithAuthenticator requires an API key on every route except health checks. Keys scoped to a
+// client can only reach that client's rules, endpoints and notifications.
+func WithAuthenticator(auth *shared.APIKeyAuthenticator) Option {
+	return func(r *Router) {
+		r.auth = auth
+	}
+}
+
+// WithCORS sets which browser origins may call the API (default: any origin).
+func WithCORS(policy *shared.CORSPolicy) Option {
+	return func(r *Router) {
+		r.cors = policy
+	}
 }
 
 // NewRouter creates a new router with all routes configured.
-func NewRouter(h *handlers.Handlers) *Router {
+func NewRouter(h *handlers.Handlers, opts ...Option) *Router {
 	r := &Router{
 		mux:      http.NewServeMux(),
 		handlers: h,
 	}
+	for _, opt := range opts {
+		opt(r)
+	}
 	r.setupRoutes()
 	return r
 }
 
-// Handler returns the HTTP handler with CORS and metrics middleware applied.
+// Handler returns the HTTP handler with CORS, metrics, authentication and rate limiting
+// middleware applied.
 func (r *Router) Handler() http.Handler {
-	// Apply middleware in order: metrics -> cors -> handler
-	handler := corsMiddleware(r.mux)
+	// Apply middleware in order: metrics -> cors -> auth -> client scope -> rate limit -> handler
+	handler := rateLimitMiddleware(r.limiter, r.handlers.GetMetricsCollector())(r.mux)
+	handler = clientScopeMiddleware(r.mux)(handler)
+	handler = shared.APIKeyMiddleware(r.auth, "rule-service", publicPaths...)(handler)
+	handler = shared.CORSMiddleware(r.cors, "Retry-After")(handler)
 	handler = metricsMiddleware(r.handlers.GetMetricsCollector())(handler)
 	return handler
 }
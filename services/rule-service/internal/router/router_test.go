@@ -4,11 +4,15 @@ package router
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"rule-service/internal/database"
 	"rule-service/internal/handlers"
+	"rule-service/internal/openapi"
 	"rule-service/internal/producer"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
 )
 
 // TestNewRouter tests the NewRouter constructor.
@@ -60,6 +64,67 @@ func TestRouter_Handler(t *testing.T) {
 	}
 }
 
+// TestRouter_WithCORS tests that only the configured origins get CORS headers.
+func TestRouter_WithCORS(t *testing.T) {
+	policy, err := shared.ParseCORSPolicy("https://app.example.com", "GET, POST", "Content-Type, Authorization", true)
+	if err != nil {
+		t.Fatalf("ParseCORSPolicy() error = %v", err)
+	}
+	h := handlers.NewHandlers(&database.DB{}, &producer.Producer{}, nil)
+	handler := NewRouter(h, WithCORS(policy)).Handler()
+
+	tests := []struct {
+		name       string
+		origin     string
+		wantOrigin string
+	}{
+		{"allowed origin", "https://app.example.com", "https://app.example.com"},
+		{"other origin", "https://evil.example.com", ""},
+		{"no origin", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodOptions, "/api/v1/clients", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			if got := w.Header().Get("Vary"); got != "Origin" {
+				t.Errorf("Vary = %q, want Origin", got)
+			}
+			if tt.wantOrigin == "" {
+				return
+			}
+			if got := w.Header().Get("Access-Control-Allow-Methods"); got != "GET, POST" {
+				t.Errorf("Access-Control-Allow-Methods = %q, want %q", got, "GET, POST")
+			}
+			if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+				t.Errorf("Access-Control-Allow-Credentials = %q, want true", got)
+			}
+			if w.Header().Get("Access-Control-Max-Age") == "" {
+				t.Error("Access-Control-Max-Age not set on preflight")
+			}
+		})
+	}
+}
+
+// TestRouter_OpenAPIPaths tests that every path in the OpenAPI document is routed.
+func TestRouter_OpenAPIPaths(t *testing.T) {
+	router := NewRouter(handlers.NewHandlers(&database.DB{}, &producer.Producer{}, nil))
+
+	for path := range openapi.Build().Paths {
+		req := httptest.NewRequest(http.MethodGet, strings.ReplaceAll(path, "{id}", "x"), nil)
+		if _, pattern := router.mux.Handler(req); pattern != path {
+			t.Errorf("OpenAPI path %q is routed to %q", path, pattern)
+		}
+	}
+}
+
 // TestRouter_HealthCheck tests the health check endpoint.
 func TestRouter_HealthCheck(t *testing.T) {
 	db := &database.DB{}
@@ -98,6 +163,9 @@ func TestNewServer(t *testing.T) {
 	if server.Handler == nil {
 		t.Error("NewServer() Handler is nil")
 	}
+	if server.ReadHeaderTimeout <= 0 {
+		t.Error("NewServer() ReadHeaderTimeout is not set")
+	}
 }
 
 // TestRouter_Routes tests that routes are properly configured.
@@ -138,6 +206,7 @@ func TestRouter_Routes(t *testing.T) {
 		{"rules GET", http.MethodGet, "/api/v1/rules?rule_id=test"},
 		{"rules UPDATE", http.MethodPut, "/api/v1/rules/update?rule_id=test"},
 		{"rules TOGGLE", http.MethodPost, "/api/v1/rules/toggle?rule_id=test"},
+		{"rules BULK TOGGLE", http.MethodPost, "/api/v1/rules/bulk-toggle"},
 		{"rules DELETE", http.MethodDelete, "/api/v1/rules/delete?rule_id=test"},
 		{"endpoints POST", http.MethodPost, "/api/v1/endpoints"},
 		{"endpoints GET", http.MethodGet, "/api/v1/endpoints?endpoint_id=test"},
@@ -145,6 +214,21 @@ func TestRouter_Routes(t *testing.T) {
 		{"endpoints TOGGLE", http.MethodPost, "/api/v1/endpoints/toggle?endpoint_id=test"},
 		{"endpoints DELETE", http.MethodDelete, "/api/v1/endpoints/delete?endpoint_id=test"},
 		{"notifications GET", http.MethodGet, "/api/v1/notifications?notification_id=test"},
+		{"notification summary GET", http.MethodGet, "/api/v1/notifications/summary"},
+		{"notification events GET", http.MethodGet, "/api/v1/notifications/events?notification_id=test"},
+		{"notification deliveries GET", http.MethodGet, "/api/v1/notifications/test/deliveries"},
+		{"notification ack POST", http.MethodPost, "/api/v1/notifications/ack"},
+		{"notification resolve POST", http.MethodPost, "/api/v1/notifications/resolve"},
+		{"escalation policies POST", http.MethodPost, "/api/v1/escalation-policies"},
+		{"escalation policies GET", http.MethodGet, "/api/v1/escalation-policies?policy_id=test"},
+		{"escalation policies UPDATE", http.MethodPut, "/api/v1/escalation-policies/update?policy_id=test"},
+		{"escalation policies DELETE", http.MethodDelete, "/api/v1/escalation-policies/delete?policy_id=test"},
+		{"rule escalation policy PUT", http.MethodPut, "/api/v1/rules/escalation-policy?rule_id=test"},
+		{"webhooks POST", http.MethodPost, "/api/v1/webhooks"},
+		{"webhooks GET", http.MethodGet, "/api/v1/webhooks?webhook_id=test"},
+		{"webhooks UPDATE", http.MethodPut, "/api/v1/webhooks/update?webhook_id=test"},
+		{"webhooks DELETE", http.MethodDelete, "/api/v1/webhooks/delete?webhook_id=test"},
+		{"webhook deliveries GET", http.MethodGet, "/api/v1/webhooks/deliveries?webhook_id=test"},
 	}
 
 	for _, tt := range tests {
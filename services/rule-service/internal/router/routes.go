@@ -3,6 +3,8 @@ package router
 
 import (
 	"net/http"
+
+	"rule-service/internal/openapi"
 )
 
 // setupRoutes configures all HTTP routes for the API.
@@ -23,6 +25,22 @@ func (r *Router) setupRoutes() {
 		}
 	})
 
+	r.mux.HandleFunc("/api/v1/clients/settings", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodPut {
+			r.handlers.UpdateClientSettings(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.mux.HandleFunc("/api/v1/clients/bootstrap", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodPost {
+			r.handlers.BootstrapClient(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
 	// Rule endpoints
 	r.mux.HandleFunc("/api/v1/rules", func(w http.ResponseWriter, req *http.Request) {
 		switch req.Method {
@@ -55,6 +73,14 @@ func (r *Router) setupRoutes() {
 		}
 	})
 
+	r.mux.HandleFunc("/api/v1/rules/bulk-toggle", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodPost {
+			r.handlers.BulkToggleRules(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
 	r.mux.HandleFunc("/api/v1/rules/delete", func(w http.ResponseWriter, req *http.Request) {
 		if req.Method == http.MethodDelete {
 			r.handlers.DeleteRule(w, req)
@@ -63,6 +89,46 @@ func (r *Router) setupRoutes() {
 		}
 	})
 
+	r.mux.HandleFunc("/api/v1/rules/conflicts", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodGet {
+			r.handlers.GetRuleConflicts(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.mux.HandleFunc("/api/v1/rules/export", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodGet {
+			r.handlers.ExportRules(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.mux.HandleFunc("/api/v1/rules/import", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodPost {
+			r.handlers.ImportRules(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.mux.HandleFunc("/api/v1/rules/test", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodPost {
+			r.handlers.TestRule(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.mux.HandleFunc("/api/v1/rules/impact", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodPost {
+			r.handlers.GetRuleImpact(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
 	// Endpoint endpoints
 	r.mux.HandleFunc("/api/v1/endpoints", func(w http.ResponseWriter, req *http.Request) {
 		switch req.Method {
@@ -104,6 +170,81 @@ func (r *Router) setupRoutes() {
 		}
 	})
 
+	// Notification template endpoints
+	r.mux.HandleFunc("/api/v1/templates", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodPost:
+			r.handlers.CreateTemplate(w, req)
+		case http.MethodGet:
+			if req.URL.Query().Get("template_id") != "" {
+				r.handlers.GetTemplate(w, req)
+			} else {
+				r.handlers.ListTemplates(w, req)
+			}
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.mux.HandleFunc("/api/v1/templates/update", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodPut {
+			r.handlers.UpdateTemplate(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.mux.HandleFunc("/api/v1/templates/delete", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodDelete {
+			r.handlers.DeleteTemplate(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Escalation policy endpoints
+	r.mux.HandleFunc("/api/v1/escalation-policies", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodPost:
+			r.handlers.CreateEscalationPolicy(w, req)
+		case http.MethodGet:
+			if req.URL.Query().Get("policy_id") != "" {
+				r.handlers.GetEscalationPolicy(w, req)
+			} else {
+				r.handlers.ListEscalationPolicies(w, req)
+			}
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.mux.HandleFunc("/api/v1/escalation-policies/update", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodPut {
+			r.handlers.UpdateEscalationPolicy(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.mux.HandleFunc("/api/v1/escalation-policies/delete", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodDelete {
+			r.handlers.DeleteEscalationPolicy(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.mux.HandleFunc("/api/v1/rules/escalation-policy", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			r.handlers.GetRuleEscalationPolicy(w, req)
+		case http.MethodPut:
+			r.handlers.SetRuleEscalationPolicy(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
 	// Notification endpoints
 	r.mux.HandleFunc("/api/v1/notifications", func(w http.ResponseWriter, req *http.Request) {
 		switch req.Method {
@@ -118,6 +259,241 @@ func (r *Router) setupRoutes() {
 		}
 	})
 
+	// Notification counts by status and severity (UI header badges)
+	r.mux.HandleFunc("/api/v1/notifications/summary", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodGet {
+			r.handlers.GetNotificationSummary(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Notification journal (state transitions written by aggregator and sender)
+	r.mux.HandleFunc("/api/v1/notifications/events", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodGet {
+			r.handlers.ListNotificationEvents(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Manual transitions, journaled with the actor and note
+	r.mux.HandleFunc("/api/v1/notifications/ack", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodPost {
+			r.handlers.AcknowledgeNotification(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.mux.HandleFunc("/api/v1/notifications/resolve", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodPost {
+			r.handlers.ResolveNotification(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.mux.HandleFunc("/api/v1/notifications/{id}/deliveries", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodGet {
+			r.handlers.ListNotificationDeliveries(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Notification exports (compliance): streamed, or as an asynchronous job for large ranges
+	r.mux.HandleFunc("/api/v1/notifications/export", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodGet {
+			r.handlers.ExportNotifications(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.mux.HandleFunc("/api/v1/notifications/export/jobs", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			r.handlers.GetExportJob(w, req)
+		case http.MethodPost:
+			r.handlers.StartExportJob(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.mux.HandleFunc("/api/v1/notifications/export/jobs/download", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodGet {
+			r.handlers.DownloadExportJob(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Heartbeat endpoints (dead-man's switch)
+	r.mux.HandleFunc("/api/v1/heartbeats/{id}/ping", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodPost {
+			r.handlers.PingHeartbeat(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.mux.HandleFunc("/api/v1/heartbeats", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			if req.URL.Query().Get("heartbeat_id") != "" {
+				r.handlers.GetHeartbeat(w, req)
+			} else {
+				r.handlers.ListHeartbeats(w, req)
+			}
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.mux.HandleFunc("/api/v1/heartbeats/delete", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodDelete {
+			r.handlers.DeleteHeartbeat(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Meta-webhooks: platform lifecycle events delivered to client-registered URLs
+	r.mux.HandleFunc("/api/v1/webhooks", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodPost:
+			r.handlers.CreateWebhook(w, req)
+		case http.MethodGet:
+			if req.URL.Query().Get("webhook_id") != "" {
+				r.handlers.GetWebhook(w, req)
+			} else {
+				r.handlers.ListWebhooks(w, req)
+			}
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.mux.HandleFunc("/api/v1/webhooks/update", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodPut {
+			r.handlers.UpdateWebhook(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.mux.HandleFunc("/api/v1/webhooks/delete", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodDelete {
+			r.handlers.DeleteWebhook(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.mux.HandleFunc("/api/v1/webhooks/deliveries", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodGet {
+			r.handlers.ListWebhookDeliveries(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// API keys: issued once, stored hashed
+	r.mux.HandleFunc("/api/v1/api-keys", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodPost:
+			r.handlers.CreateAPIKey(w, req)
+		case http.MethodGet:
+			r.handlers.ListAPIKeys(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.mux.HandleFunc("/api/v1/api-keys/revoke", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodPost {
+			r.handlers.RevokeAPIKey(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Emergency stop ("big red button") admin endpoints
+	r.mux.HandleFunc("/api/v1/admin/emergency-stop", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			r.handlers.GetEmergencyStop(w, req)
+		case http.MethodPost:
+			r.handlers.ActivateEmergencyStop(w, req)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.mux.HandleFunc("/api/v1/admin/emergency-stop/resume", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodPost {
+			r.handlers.ResumeEmergencyStop(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Rule snapshot history admin endpoints
+	r.mux.HandleFunc("/api/v1/admin/rule-snapshots", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodGet {
+			r.handlers.ListRuleSnapshots(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.mux.HandleFunc("/api/v1/admin/rule-snapshots/diff", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodGet {
+			r.handlers.DiffRuleSnapshots(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.mux.HandleFunc("/api/v1/admin/rule-snapshots/pin", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodPost {
+			r.handlers.PinRuleSnapshot(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.mux.HandleFunc("/api/v1/admin/rule-snapshots/unpin", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodPost {
+			r.handlers.UnpinRuleSnapshot(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	r.mux.HandleFunc("/api/v1/admin/rule-snapshots/reload", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodPost {
+			r.handlers.ReloadRuleSnapshots(w, req)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Severity colors, emoji and labels, shared with the sender's notifications
+	r.mux.HandleFunc("/api/v1/severities", r.handlers.ListSeverities)
+
+	// OpenAPI document of the client, rule, endpoint and notification routes, and a Swagger UI page
+	r.mux.Handle("/api/v1/openapi.json", openapi.SpecHandler(openapi.Build()))
+	r.mux.Handle("/api/v1/docs", openapi.UIHandler("rule-service API", "/api/v1/openapi.json"))
+
+	// Dependency health (Postgres, Redis, Kafka producer), cached between probes
+	r.mux.HandleFunc("/healthz", r.handlers.Healthz)
+
+	// Readiness endpoint (reports unhealthy dependencies and the emergency stop)
+	r.mux.HandleFunc("/readyz", r.handlers.Readiness)
+
 	// Health check endpoint
 	r.mux.HandleFunc("/health", func(w http.ResponseWriter, req *http.Request) {
 		w.WriteHeader(http.StatusOK)
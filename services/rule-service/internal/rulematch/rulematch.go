@@ -0,0 +1,151 @@
+// Package rulematch matches a sample alert against a client's rules the way the evaluator
+// matches alerts. Rules are normalized like rule-updater normalizes them in the snapshot and
+// loaded into the evaluator's inverted severity/source/name indexes, with "*" as the wildcard
+// key; the candidates in all three indexes are then checked against the rule's exclusions and
+// context conditions with shared.ContextConditionHolds, as in the evaluator.
+package rulematch
+
+import (
+	"sort"
+
+	"rule-service/internal/database"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// wildcard is the rule field value that matches any alert value.
+const wildcard = "*"
+
+// Alert is a sample alert to match.
+type Alert struct {
+	Severity string
+	Source   string
+	Name     string
+	Context  map[string]string
+}
+
+// Index holds a client's rules in the evaluator's inverted indexes.
+type Index struct {
+	bySeverity map[string][]int // severity -> []ruleInt
+	bySource   map[string][]int // source -> []ruleInt
+	byName     map[string][]int // name -> []ruleInt
+	rules      []*database.Rule // ruleInt -> rule
+	entries    []entry          // ruleInt -> normalized exclusions and conditions
+
+	normalization shared.Normalization
+}
+
+// entry holds the normalized parts of a rule that are checked after the index lookup.
+type entry struct {
+	excludeSources []string
+	excludeNames   []string
+	conditions     database.RuleConditions
+}
+
+// NewIndex indexes rules by position, normalized with normalization. It should be the
+// normalization rule-updater writes snapshots with (its -normalize flag).
+func NewIndex(rules []*database.Rule, normalization shared.Normalization) *Index {
+	idx := &Index{
+		bySeverity:    make(map[string][]int),
+		bySource:      make(map[string][]int),
+		byName:        make(map[string][]int),
+		rules:         rules,
+		entries:       make([]entry, len(rules)),
+		normalization: normalization,
+	}
+	for ruleInt, rule := range rules {
+		severity := normalization.Apply(rule.Severity)
+		source := normalization.Apply(rule.Source)
+		name := normalization.Apply(rule.Name)
+		idx.bySeverity[severity] = append(idx.bySeverity[severity], ruleInt)
+		idx.bySource[source] = append(idx.bySource[source], ruleInt)
+		idx.byName[name] = append(idx.byName[name], ruleInt)
+		idx.entries[ruleInt] = entry{
+			excludeSources: idx.normalizeAll(rule.ExcludeSources),
+			excludeNames:   idx.normalizeAll(rule.ExcludeNames),
+			conditions:     idx.normalizeConditions(rule.Conditions),
+		}
+	}
+	return idx
+}
+
+// Match returns the rules that match alert, in index order. Disabled rules are matched too:
+// callers decide whether they count.
+func (idx *Index) Match(alert Alert) []*database.Rule {
+	severity := idx.normalization.Apply(alert.Severity)
+	source := idx.normalization.Apply(alert.Source)
+	name := idx.normalization.Apply(alert.Name)
+
+	severityRules := withWildcard(idx.bySeverity, severity)
+	sourceRules := withWildcard(idx.bySource, source)
+	nameRules := withWildcard(idx.byName, name)
+
+	matched := make([]int, 0)
+	for ruleInt := range severityRules {
+		if sourceRules[ruleInt] && nameRules[ruleInt] && idx.ruleHolds(idx.entries[ruleInt], source, name, alert.Context) {
+			matched = append(matched, ruleInt)
+		}
+	}
+	sort.Ints(matched)
+
+	rules := make([]*database.Rule, len(matched))
+	for i, ruleInt := range matched {
+		rules[i] = idx.rules[ruleInt]
+	}
+	return rules
+}
+
+// ruleHolds reports whether a candidate rule matches the normalized alert source and name and
+// the alert context: exclusions and context conditions are not indexed.
+func (idx *Index) ruleHolds(e entry, source, name string, context map[string]string) bool {
+	if contains(e.excludeSources, source) || contains(e.excludeNames, name) {
+		return false
+	}
+	for _, c := range e.conditions {
+		if !shared.ContextConditionHolds(idx.normalization, c.Key, c.Op, c.Value, context) {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeAll returns a normalized copy of values.
+func (idx *Index) normalizeAll(values []string) []string {
+	normalized := make([]string, len(values))
+	for i, v := range values {
+		normalized[i] = idx.normalization.Apply(v)
+	}
+	return normalized
+}
+
+// normalizeConditions returns a copy of conditions with normalized values. Keys are matched exactly.
+func (idx *Index) normalizeConditions(conditions database.RuleConditions) database.RuleConditions {
+	normalized := make(database.RuleConditions, len(conditions))
+	for i, c := range conditions {
+		c.Value = idx.normalization.Apply(c.Value)
+		normalized[i] = c
+	}
+	return normalized
+}
+
+// withWildcard returns the set of ruleInts stored under key or under the wildcard.
+func withWildcard(byField map[string][]int, key string) map[int]bool {
+	set := make(map[int]bool, len(byField[key])+len(byField[wildcard]))
+	for _, ruleInt := range byField[key] {
+		set[ruleInt] = true
+	}
+	for _, ruleInt := range byField[wildcard] {
+		set[ruleInt] = true
+	}
+	return set
+}
+
+// contains reports whether values contains v.
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
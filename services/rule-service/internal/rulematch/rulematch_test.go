@@ -0,0 +1,70 @@
+// Package rulematch provides tests for sample alert matching.
+package rulematch
+
+import (
+	"reflect"
+	"testing"
+
+	"rule-service/internal/database"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// TestIndex_Match tests that rules match sample alerts like the evaluator matches alerts.
+func TestIndex_Match(t *testing.T) {
+	rules := []*database.Rule{
+		{RuleID: "exact", Severity: "HIGH", Source: "api", Name: "timeout", Enabled: true},
+		{RuleID: "any-source", Severity: "HIGH", Source: "*", Name: "timeout", ExcludeSources: []string{"Canary"}, Enabled: true},
+		{RuleID: "prod-only", Severity: "*", Source: "api", Name: "*", Conditions: database.RuleConditions{
+			{Key: "env", Op: database.ConditionOpEqual, Value: "Prod"},
+		}},
+		{RuleID: "not-eu", Severity: "HIGH", Source: "*", Name: "*", Conditions: database.RuleConditions{
+			{Key: "region", Op: database.ConditionOpNotEqual, Value: "eu"},
+		}, Enabled: true},
+		{RuleID: "other", Severity: "LOW", Source: "db", Name: "error", Enabled: true},
+	}
+	fold := shared.Normalization{Trim: true, FoldCase: true}
+
+	tests := []struct {
+		name          string
+		normalization shared.Normalization
+		alert         Alert
+		want          []string
+	}{
+		{
+			name:          "exact, wildcard and condition rules",
+			normalization: fold,
+			alert:         Alert{Severity: "HIGH", Source: "api", Name: "timeout", Context: map[string]string{"env": "prod"}},
+			want:          []string{"exact", "any-source", "prod-only", "not-eu"},
+		},
+		{
+			name:          "excluded source and failed conditions",
+			normalization: fold,
+			alert:         Alert{Severity: "HIGH", Source: " canary ", Name: "timeout", Context: map[string]string{"region": "EU"}},
+			want:          []string{},
+		},
+		{
+			name:          "case matters without normalization",
+			normalization: shared.Normalization{},
+			alert:         Alert{Severity: "HIGH", Source: "API", Name: "timeout", Context: map[string]string{"env": "Prod"}},
+			want:          []string{"any-source", "not-eu"},
+		},
+		{
+			name:          "no rule",
+			normalization: fold,
+			alert:         Alert{Severity: "MEDIUM", Source: "queue", Name: "lag"},
+			want:          []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make([]string, 0)
+			for _, rule := range NewIndex(rules, tt.normalization).Match(tt.alert) {
+				got = append(got, rule.RuleID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package unmatched
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"rule-service/internal/database"
+	"rule-service/internal/events"
+	"rule-service/internal/metawebhook"
+
+	"github.com/afikmenashe/alerting-platform/pkg/ids"
+)
+
+// Reporter defaults.
+const (
+	// DefaultReportHour is the UTC hour after which the previous day is reported.
+	DefaultReportHour = 6
+	// DefaultTopSignatures is how many signatures a report lists.
+	DefaultTopSignatures = 10
+	// DefaultRetention is how long daily signatures are kept.
+	DefaultRetention = 30 * 24 * time.Hour
+	// checkInterval is how often the reporter checks whether a report is due.
+	checkInterval = time.Minute
+)
+
+// Synthetic report alert fields, which the ops client's rule matches.
+const (
+	ReportSeverity = "LOW"
+	ReportSource   = "alerting-platform"
+	ReportName     = "unmatched-alerts-report"
+)
+
+// alertSchemaVersion is the alerts.new schema version of report alerts.
+const alertSchemaVersion = 1
+
+// maxContextValue caps the length of a report alert's context values.
+const maxContextValue = 256
+
+// ReportStore provides the unmatched alert counts and report claims needed by the reporter.
+type ReportStore interface {
+	// ClaimUnmatchedReport marks the day as reported, returning false if it already was.
+	ClaimUnmatchedReport(ctx context.Context, day time.Time) (bool, error)
+	// ReleaseUnmatchedReport removes the day's claim so the report is retried.
+	ReleaseUnmatchedReport(ctx context.Context, day time.Time) error
+	ListUnmatchedClients(ctx context.Context, day time.Time) ([]string, error)
+	SummarizeUnmatched(ctx context.Context, day time.Time, clientID string, limit int) (*database.UnmatchedSummary, error)
+	PruneUnmatchedSignatures(ctx context.Context, before time.Time) (int64, error)
+}
+
+// AlertPublisher publishes synthetic alerts to alerts.new.
+type AlertPublisher interface {
+	PublishAlert(ctx context.Context, alert *events.AlertNew) error
+}
+
+// EventRecorder queues a platform event for the client's meta-webhooks.
+type EventRecorder interface {
+	RecordPlatformEvent(ctx context.Context, clientID, eventType string, data interface{}) error
+}
+
+// ReportEvent is the data of an unmatched.report event.
+type ReportEvent struct {
+	Date string `json:"date"` // the reported UTC day, YYYY-MM-DD
+	database.UnmatchedSummary
+}
+
+// ReporterOptions configure the reporter.
+type ReporterOptions struct {
+	// Hour is the UTC hour after which the previous day is reported.
+	Hour int
+	// Top is how many signatures a report lists (non-positive uses DefaultTopSignatures).
+	Top int
+	// OpsClient receives the platform-wide report as a synthetic alert hinted for it; empty
+	// disables the platform report.
+	OpsClient string
+	// Retention is how long daily signatures are kept (non-positive uses DefaultRetention).
+	Retention time.Duration
+}
+
+// Reporter sends the daily unmatched alert reports.
+type Reporter struct {
+	store     ReportStore
+	publisher AlertPublisher
+	recorder  EventRecorder
+	metrics   MetricsRecorder
+	opts      ReporterOptions
+	now       func() time.Time
+	lastDay   time.Time // the last day this instance reported or saw claimed
+}
+
+// NewReporter creates a reporter. A nil metrics recorder is replaced with a no-op.
+func NewReporter(store ReportStore, publisher AlertPublisher, recorder EventRecorder, m MetricsRecorder, opts ReporterOptions) *Reporter {
+	if opts.Top <= 0 {
+		opts.Top = DefaultTopSignatures
+	}
+	if opts.Retention <= 0 {
+		opts.Retention = DefaultRetention
+	}
+	if m == nil {
+		m = noOpMetrics{}
+	}
+	return &Reporter{
+		store:     store,
+		publisher: publisher,
+		recorder:  recorder,
+		metrics:   m,
+		opts:      opts,
+		now:       time.Now,
+	}
+}
+
+// Run sends each day's report once it is due, until ctx is cancelled.
+func (r *Reporter) Run(ctx context.Context) {
+	slog.Info("Starting unmatched alerts reporter",
+		"report_hour_utc", r.opts.Hour,
+		"top", r.opts.Top,
+		"ops_client", r.opts.OpsClient,
+	)
+
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Unmatched alerts reporter stopped")
+			return
+		case <-ticker.C:
+			r.ReportOnce(ctx)
+		}
+	}
+}
+
+// ReportOnce reports the previous UTC day if the report hour has passed and no instance has
+// reported it yet, and returns whether it did. Once reported, signatures older than the
+// retention are pruned.
+func (r *Reporter) ReportOnce(ctx context.Context) bool {
+	now := r.now().UTC()
+	if now.Hour() < r.opts.Hour {
+		return false
+	}
+	day := now.Truncate(24*time.Hour).AddDate(0, 0, -1)
+	if day.Equal(r.lastDay) {
+		return false
+	}
+
+	claimed, err := r.store.ClaimUnmatchedReport(ctx, day)
+	if err != nil {
+		slog.Error("Failed to claim unmatched alerts report", "day", day.Format(time.DateOnly), "error", err)
+		return false
+	}
+	r.lastDay = day
+	if !claimed {
+		return false
+	}
+
+	if err := r.report(ctx, day); err != nil {
+		slog.Error("Failed to send unmatched alerts report", "day", day.Format(time.DateOnly), "error", err)
+		r.metrics.IncrementCustom("unmatched_report_errors")
+		// Release the claim so the next check retries the report
+		if err := r.store.ReleaseUnmatchedReport(ctx, day); err != nil {
+			slog.Error("Failed to release unmatched alerts report claim", "day", day.Format(time.DateOnly), "error", err)
+		}
+		r.lastDay = time.Time{}
+		return false
+	}
+	r.metrics.IncrementCustom("unmatched_reports_sent")
+
+	pruned, err := r.store.PruneUnmatchedSignatures(ctx, day.Add(-r.opts.Retention))
+	if err != nil {
+		slog.Error("Failed to prune unmatched signatures", "error", err)
+	} else if pruned > 0 {
+		slog.Info("Pruned unmatched signatures", "count", pruned)
+	}
+	return true
+}
+
+// report sends the day's platform report to the ops client, then each client's own report to
+// its meta-webhooks. A failed platform report fails the whole report so it is retried; a
+// failed client report is logged and skipped, since retrying would repeat the others.
+func (r *Reporter) report(ctx context.Context, day time.Time) error {
+	clients, err := r.store.ListUnmatchedClients(ctx, day)
+	if err != nil {
+		return err
+	}
+
+	if r.opts.OpsClient != "" {
+		summary, err := r.store.SummarizeUnmatched(ctx, day, "", r.opts.Top)
+		if err != nil {
+			return err
+		}
+		if summary.TotalAlerts > 0 {
+			alert := r.buildAlert(day, summary)
+			if err := r.publisher.PublishAlert(ctx, alert); err != nil {
+				return fmt.Errorf("failed to publish report alert: %w", err)
+			}
+			slog.Info("Published unmatched alerts report",
+				"day", day.Format(time.DateOnly),
+				"ops_client", r.opts.OpsClient,
+				"alert_id", alert.AlertID,
+				"unmatched_alerts", summary.TotalAlerts,
+			)
+		}
+	}
+
+	for _, clientID := range clients {
+		summary, err := r.store.SummarizeUnmatched(ctx, day, clientID, r.opts.Top)
+		if err == nil {
+			event := ReportEvent{Date: day.Format(time.DateOnly), UnmatchedSummary: *summary}
+			err = r.recorder.RecordPlatformEvent(ctx, clientID, metawebhook.EventUnmatchedReport, event)
+		}
+		if err != nil {
+			slog.Error("Failed to record unmatched alerts report", "client_id", clientID, "error", err)
+			r.metrics.IncrementCustom("unmatched_report_errors")
+		}
+	}
+	return nil
+}
+
+// buildAlert creates the synthetic alert carrying the platform report. The context lists the
+// top signatures as top_1..top_N, "<severity> <source>/<name>: <count>", most frequent first.
+// The alert is hinted for the ops client so it never matches other clients' rules.
+func (r *Reporter) buildAlert(day time.Time, summary *database.UnmatchedSummary) *events.AlertNew {
+	now := r.now()
+	alertContext := map[string]string{
+		"synthetic":        "unmatched_report",
+		"report_date":      day.Format(time.DateOnly),
+		"unmatched_alerts": strconv.FormatInt(summary.TotalAlerts, 10),
+		"signatures":       strconv.FormatInt(summary.Signatures, 10),
+	}
+	for i, sig := range summary.Top {
+		value := fmt.Sprintf("%s %s/%s: %d", sig.Severity, sig.Source, sig.Name, sig.Count)
+		if len(value) > maxContextValue {
+			value = value[:maxContextValue]
+		}
+		alertContext["top_"+strconv.Itoa(i+1)] = value
+	}
+	return &events.AlertNew{
+		AlertID:       ids.NewAt(now),
+		SchemaVersion: alertSchemaVersion,
+		EventTS:       now.Unix(),
+		Severity:      ReportSeverity,
+		Source:        ReportSource,
+		Name:          ReportName,
+		Context:       alertContext,
+		ClientHint:    r.opts.OpsClient,
+	}
+}
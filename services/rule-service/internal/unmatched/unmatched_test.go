@@ -0,0 +1,265 @@
+package unmatched
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"rule-service/internal/database"
+	"rule-service/internal/events"
+	"rule-service/internal/metawebhook"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// fakeReader is a test fake for MessageReader serving its messages in order.
+type fakeReader struct {
+	messages  []kafka.Message
+	committed []kafka.Message
+}
+
+func (f *fakeReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	if len(f.messages) == 0 {
+		return kafka.Message{}, errors.New("no more messages")
+	}
+	msg := f.messages[0]
+	f.messages = f.messages[1:]
+	return msg, nil
+}
+
+func (f *fakeReader) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	f.committed = append(f.committed, msgs...)
+	return nil
+}
+
+// fakeStore is a test fake for SignatureStore and ReportStore.
+type fakeStore struct {
+	failures   int
+	recorded   map[string][]*database.UnmatchedSignature // by day
+	claimed    map[string]bool
+	released   []string
+	clients    []string
+	summaries  map[string]*database.UnmatchedSummary // by client; "" for the platform
+	prunedFrom time.Time
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		recorded:  make(map[string][]*database.UnmatchedSignature),
+		claimed:   make(map[string]bool),
+		summaries: make(map[string]*database.UnmatchedSummary),
+	}
+}
+
+func (f *fakeStore) RecordUnmatchedSignatures(ctx context.Context, day time.Time, sigs []*database.UnmatchedSignature) error {
+	if f.failures > 0 {
+		f.failures--
+		return errors.New("db down")
+	}
+	key := day.Format(time.DateOnly)
+	f.recorded[key] = append(f.recorded[key], sigs...)
+	return nil
+}
+
+func (f *fakeStore) ClaimUnmatchedReport(ctx context.Context, day time.Time) (bool, error) {
+	key := day.Format(time.DateOnly)
+	if f.claimed[key] {
+		return false, nil
+	}
+	f.claimed[key] = true
+	return true, nil
+}
+
+func (f *fakeStore) ReleaseUnmatchedReport(ctx context.Context, day time.Time) error {
+	key := day.Format(time.DateOnly)
+	delete(f.claimed, key)
+	f.released = append(f.released, key)
+	return nil
+}
+
+func (f *fakeStore) ListUnmatchedClients(ctx context.Context, day time.Time) ([]string, error) {
+	return f.clients, nil
+}
+
+func (f *fakeStore) SummarizeUnmatched(ctx context.Context, day time.Time, clientID string, limit int) (*database.UnmatchedSummary, error) {
+	if summary, ok := f.summaries[clientID]; ok {
+		return summary, nil
+	}
+	return &database.UnmatchedSummary{}, nil
+}
+
+func (f *fakeStore) PruneUnmatchedSignatures(ctx context.Context, before time.Time) (int64, error) {
+	f.prunedFrom = before
+	return 0, nil
+}
+
+// fakePublisher is a test fake for AlertPublisher.
+type fakePublisher struct {
+	published []*events.AlertNew
+	err       error
+}
+
+func (f *fakePublisher) PublishAlert(ctx context.Context, alert *events.AlertNew) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.published = append(f.published, alert)
+	return nil
+}
+
+// recordedEvent is one RecordPlatformEvent call.
+type recordedEvent struct {
+	clientID  string
+	eventType string
+	data      interface{}
+}
+
+// fakeRecorder is a test fake for EventRecorder.
+type fakeRecorder struct {
+	events []recordedEvent
+}
+
+func (f *fakeRecorder) RecordPlatformEvent(ctx context.Context, clientID, eventType string, data interface{}) error {
+	f.events = append(f.events, recordedEvent{clientID: clientID, eventType: eventType, data: data})
+	return nil
+}
+
+func unmatchedMessage(t *testing.T, clientHint, source string, evaluatedAt time.Time) kafka.Message {
+	t.Helper()
+	value, err := json.Marshal(events.UnmatchedAlert{
+		AlertID:     "alert-" + source,
+		Severity:    "HIGH",
+		Source:      source,
+		Name:        "timeout",
+		ClientHint:  clientHint,
+		EvaluatedAt: evaluatedAt.UnixMilli(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal alert: %v", err)
+	}
+	return kafka.Message{Value: value}
+}
+
+func TestWatcher_HandleBatch(t *testing.T) {
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	reader := &fakeReader{messages: []kafka.Message{
+		unmatchedMessage(t, "client-1", "api", day.Add(time.Hour)),
+		unmatchedMessage(t, "client-1", "api", day.Add(2*time.Hour)),
+		unmatchedMessage(t, "", "db", day.Add(3*time.Hour)),
+		unmatchedMessage(t, "", "db", day.Add(25*time.Hour)),
+		{Value: []byte("not json")},
+	}}
+	store := newFakeStore()
+	store.failures = 1
+
+	w := NewWatcher(reader, store, nil)
+	w.flushInterval = time.Second
+	counted, err := w.HandleBatch(context.Background())
+	if err != nil {
+		t.Fatalf("HandleBatch() error = %v", err)
+	}
+	if counted != 4 || len(reader.committed) != 5 {
+		t.Fatalf("HandleBatch() counted %d, committed %d, want 4 and 5", counted, len(reader.committed))
+	}
+
+	first := store.recorded["2024-05-01"]
+	if len(first) != 2 {
+		t.Fatalf("recorded %d signatures on 2024-05-01, want 2", len(first))
+	}
+	for _, sig := range first {
+		if sig.Source == "api" && (sig.ClientID != "client-1" || sig.Count != 2 ||
+			!sig.FirstSeenAt.Equal(day.Add(time.Hour)) || !sig.LastSeenAt.Equal(day.Add(2*time.Hour))) {
+			t.Errorf("api signature = %+v, want 2 alerts of client-1 seen between 01:00 and 02:00", sig)
+		}
+	}
+	if next := store.recorded["2024-05-02"]; len(next) != 1 || next[0].Count != 1 {
+		t.Errorf("recorded %+v on 2024-05-02, want the db signature once", next)
+	}
+}
+
+func TestReporter_ReportOnce(t *testing.T) {
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	newReporter := func(now time.Time) (*Reporter, *fakeStore, *fakePublisher, *fakeRecorder) {
+		store := newFakeStore()
+		store.clients = []string{"client-1"}
+		store.summaries[""] = &database.UnmatchedSummary{
+			TotalAlerts: 12,
+			Signatures:  2,
+			Top: []*database.UnmatchedSignature{
+				{Severity: "HIGH", Source: "api", Name: "timeout", Count: 10},
+				{Severity: "LOW", Source: "db", Name: "slow", Count: 2},
+			},
+		}
+		store.summaries["client-1"] = &database.UnmatchedSummary{TotalAlerts: 10, Signatures: 1}
+		publisher := &fakePublisher{}
+		recorder := &fakeRecorder{}
+		r := NewReporter(store, publisher, recorder, nil, ReporterOptions{Hour: 6, OpsClient: "ops"})
+		r.now = func() time.Time { return now }
+		return r, store, publisher, recorder
+	}
+
+	t.Run("reports the previous day once", func(t *testing.T) {
+		r, store, publisher, recorder := newReporter(day.Add(30 * time.Hour))
+		if !r.ReportOnce(context.Background()) {
+			t.Fatal("ReportOnce() = false, want true")
+		}
+		if r.ReportOnce(context.Background()) {
+			t.Error("second ReportOnce() = true, want false")
+		}
+
+		if len(publisher.published) != 1 {
+			t.Fatalf("published %d alerts, want 1", len(publisher.published))
+		}
+		alert := publisher.published[0]
+		if alert.ClientHint != "ops" || alert.Source != ReportSource || alert.Name != ReportName {
+			t.Errorf("alert = %+v, want the report for ops", alert)
+		}
+		if alert.Context["report_date"] != "2024-05-01" || alert.Context["unmatched_alerts"] != "12" ||
+			alert.Context["top_1"] != "HIGH api/timeout: 10" || alert.Context["top_2"] != "LOW db/slow: 2" {
+			t.Errorf("alert context = %v", alert.Context)
+		}
+
+		if len(recorder.events) != 1 || recorder.events[0].clientID != "client-1" || recorder.events[0].eventType != metawebhook.EventUnmatchedReport {
+			t.Fatalf("events = %+v, want an unmatched.report for client-1", recorder.events)
+		}
+		if event := recorder.events[0].data.(ReportEvent); event.Date != "2024-05-01" || event.TotalAlerts != 10 {
+			t.Errorf("event = %+v", event)
+		}
+		if want := day.Add(-DefaultRetention); !store.prunedFrom.Equal(want) {
+			t.Errorf("pruned before %s, want %s", store.prunedFrom, want)
+		}
+	})
+
+	t.Run("waits for the report hour", func(t *testing.T) {
+		r, _, publisher, _ := newReporter(day.Add(29 * time.Hour))
+		if r.ReportOnce(context.Background()) || len(publisher.published) != 0 {
+			t.Error("ReportOnce() reported before the report hour")
+		}
+	})
+
+	t.Run("already claimed by another instance", func(t *testing.T) {
+		r, store, publisher, _ := newReporter(day.Add(30 * time.Hour))
+		store.claimed["2024-05-01"] = true
+		if r.ReportOnce(context.Background()) || len(publisher.published) != 0 {
+			t.Error("ReportOnce() reported a claimed day")
+		}
+	})
+
+	t.Run("publish failure releases the claim", func(t *testing.T) {
+		r, store, publisher, recorder := newReporter(day.Add(30 * time.Hour))
+		publisher.err = errors.New("broker down")
+		if r.ReportOnce(context.Background()) {
+			t.Fatal("ReportOnce() = true, want false")
+		}
+		if len(store.released) != 1 || store.claimed["2024-05-01"] || len(recorder.events) != 0 {
+			t.Errorf("released %v, events %+v, want the claim released and no events", store.released, recorder.events)
+		}
+
+		publisher.err = nil
+		if !r.ReportOnce(context.Background()) {
+			t.Error("retried ReportOnce() = false, want true")
+		}
+	})
+}
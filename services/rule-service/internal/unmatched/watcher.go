@@ -0,0 +1,228 @@
+// Package unmatched reports the alerts that matched no rule, to help close rule coverage gaps.
+// The watcher counts the alerts the evaluator publishes to alerts.unmatched per day, client
+// hint and (severity, source, name) signature; the reporter sends a daily report of the top
+// signatures to each client's meta-webhooks and, as a synthetic alert, to an ops client.
+package unmatched
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"rule-service/internal/database"
+	"rule-service/internal/events"
+
+	kafkautil "github.com/afikmenashe/alerting-platform/pkg/kafka"
+	"github.com/segmentio/kafka-go"
+)
+
+// Watcher defaults.
+const (
+	// DefaultGroupID is the consumer group of the watcher.
+	DefaultGroupID = "rule-service-unmatched-alerts"
+	// DefaultBatchSize is how many unmatched alerts are counted in one database write.
+	DefaultBatchSize = 500
+	// DefaultFlushInterval is how long the watcher waits to fill a batch.
+	DefaultFlushInterval = 5 * time.Second
+)
+
+// retryDelay is how long the watcher waits after a failure before trying again.
+const retryDelay = time.Second
+
+// MessageReader reads and commits messages of the alerts.unmatched topic.
+type MessageReader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// SignatureStore persists unmatched alert counts.
+type SignatureStore interface {
+	RecordUnmatchedSignatures(ctx context.Context, day time.Time, sigs []*database.UnmatchedSignature) error
+}
+
+// MetricsRecorder is the subset of metrics used by the watcher and the reporter.
+type MetricsRecorder interface {
+	IncrementCustom(name string)
+	AddCustom(name string, value uint64)
+}
+
+// noOpMetrics is used when no metrics recorder is provided.
+type noOpMetrics struct{}
+
+func (noOpMetrics) IncrementCustom(string)   {}
+func (noOpMetrics) AddCustom(string, uint64) {}
+
+// signatureKey identifies a signature within a batch.
+type signatureKey struct {
+	day      string
+	clientID string
+	severity string
+	source   string
+	name     string
+}
+
+// Watcher counts unmatched alerts into daily signatures.
+type Watcher struct {
+	reader        MessageReader
+	store         SignatureStore
+	metrics       MetricsRecorder
+	batchSize     int
+	flushInterval time.Duration
+	now           func() time.Time
+}
+
+// NewReader creates a Kafka reader of the evaluator's alerts.unmatched topic.
+func NewReader(brokers, topic, groupID string) (*kafka.Reader, error) {
+	if err := kafkautil.ValidateConsumerParams(brokers, topic, groupID); err != nil {
+		return nil, err
+	}
+	brokerList := kafkautil.ParseBrokers(brokers)
+	slog.Info("Initializing unmatched alerts Kafka consumer",
+		"brokers", brokerList,
+		"topic", topic,
+		"group_id", groupID,
+	)
+	return kafka.NewReader(kafkautil.NewReaderConfig(brokerList, topic, groupID)), nil
+}
+
+// NewWatcher creates a watcher reading from reader and counting into store.
+// A nil metrics recorder is replaced with a no-op.
+func NewWatcher(reader MessageReader, store SignatureStore, m MetricsRecorder) *Watcher {
+	if m == nil {
+		m = noOpMetrics{}
+	}
+	return &Watcher{
+		reader:        reader,
+		store:         store,
+		metrics:       m,
+		batchSize:     DefaultBatchSize,
+		flushInterval: DefaultFlushInterval,
+		now:           time.Now,
+	}
+}
+
+// Run counts unmatched alerts until ctx is cancelled.
+func (w *Watcher) Run(ctx context.Context) {
+	slog.Info("Starting unmatched alerts watcher", "batch_size", w.batchSize, "flush_interval", w.flushInterval)
+	for {
+		if _, err := w.HandleBatch(ctx); err != nil {
+			if ctx.Err() != nil {
+				slog.Info("Unmatched alerts watcher stopped")
+				return
+			}
+			slog.Error("Failed to count unmatched alerts", "error", err)
+			if !sleep(ctx, retryDelay) {
+				return
+			}
+		}
+	}
+}
+
+// HandleBatch reads up to a batch of unmatched alerts, waiting for the first one and then at
+// most the flush interval for the rest, counts them and commits them. It returns how many
+// alerts were counted. Malformed messages are committed without being counted. Recording is
+// retried until it succeeds, since committing would otherwise lose the batch's counts.
+func (w *Watcher) HandleBatch(ctx context.Context) (int, error) {
+	msg, err := w.reader.FetchMessage(ctx)
+	if err != nil {
+		return 0, err
+	}
+	msgs := []kafka.Message{msg}
+
+	fillCtx, cancel := context.WithTimeout(ctx, w.flushInterval)
+	for len(msgs) < w.batchSize {
+		msg, err := w.reader.FetchMessage(fillCtx)
+		if err != nil {
+			break
+		}
+		msgs = append(msgs, msg)
+	}
+	cancel()
+
+	days, counted := w.aggregate(msgs)
+	for day, sigs := range days {
+		for {
+			err := w.store.RecordUnmatchedSignatures(ctx, day, sigs)
+			if err == nil {
+				break
+			}
+			slog.Error("Failed to record unmatched signatures, retrying", "day", day.Format(time.DateOnly), "error", err)
+			if !sleep(ctx, retryDelay) {
+				return 0, fmt.Errorf("failed to record unmatched signatures: %w", err)
+			}
+		}
+	}
+	w.metrics.AddCustom("unmatched_alerts_recorded", uint64(counted))
+	return counted, w.reader.CommitMessages(ctx, msgs...)
+}
+
+// aggregate counts msgs into signatures grouped by UTC day, and returns them with the number of
+// alerts counted. An alert's day is when it was evaluated.
+func (w *Watcher) aggregate(msgs []kafka.Message) (map[time.Time][]*database.UnmatchedSignature, int) {
+	sigs := make(map[signatureKey]*database.UnmatchedSignature)
+	dayOf := make(map[signatureKey]time.Time)
+	counted := 0
+	for _, msg := range msgs {
+		var alert events.UnmatchedAlert
+		if err := json.Unmarshal(msg.Value, &alert); err != nil || alert.Source == "" {
+			slog.Warn("Skipping malformed unmatched alert",
+				"partition", msg.Partition,
+				"offset", msg.Offset,
+			)
+			continue
+		}
+		seen := w.now().UTC()
+		if alert.EvaluatedAt > 0 {
+			seen = time.UnixMilli(alert.EvaluatedAt).UTC()
+		}
+		day := seen.Truncate(24 * time.Hour)
+		key := signatureKey{
+			day:      day.Format(time.DateOnly),
+			clientID: alert.ClientHint,
+			severity: alert.Severity,
+			source:   alert.Source,
+			name:     alert.Name,
+		}
+
+		sig, ok := sigs[key]
+		if !ok {
+			sig = &database.UnmatchedSignature{
+				ClientID:      alert.ClientHint,
+				Severity:      alert.Severity,
+				Source:        alert.Source,
+				Name:          alert.Name,
+				FirstSeenAt:   seen,
+				LastSeenAt:    seen,
+				SampleAlertID: alert.AlertID,
+			}
+			sigs[key] = sig
+			dayOf[key] = day
+		}
+		sig.Count++
+		if seen.Before(sig.FirstSeenAt) {
+			sig.FirstSeenAt = seen
+		}
+		if seen.After(sig.LastSeenAt) {
+			sig.LastSeenAt = seen
+		}
+		counted++
+	}
+
+	days := make(map[time.Time][]*database.UnmatchedSignature)
+	for key, sig := range sigs {
+		days[dayOf[key]] = append(days[dayOf[key]], sig)
+	}
+	return days, counted
+}
+
+// sleep waits for d and returns false if ctx was cancelled first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
@@ -0,0 +1,2 @@
+-- Remove the pipeline canary (cascades to its rule and endpoint)
+DELETE FROM clients WHERE client_id = 'canary';
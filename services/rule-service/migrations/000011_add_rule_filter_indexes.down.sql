@@ -0,0 +1,7 @@
+-- Drop rule filter indexes
+-- idx_rules_updated_at predates this migration (000002) and is kept.
+-- The pg_trgm extension is left installed; other objects may depend on it.
+DROP INDEX IF EXISTS idx_rules_name_trgm;
+DROP INDEX IF EXISTS idx_rules_source_created_at;
+DROP INDEX IF EXISTS idx_rules_severity_created_at;
+DROP INDEX IF EXISTS idx_rules_enabled_created_at;
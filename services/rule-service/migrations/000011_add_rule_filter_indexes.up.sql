@@ -0,0 +1,10 @@
+-- Indexes for server-side filtering of GET /api/v1/rules
+-- Each equality filter is paired with created_at so the filtered page is read in list order.
+CREATE INDEX IF NOT EXISTS idx_rules_enabled_created_at ON rules(enabled, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_rules_severity_created_at ON rules(severity, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_rules_source_created_at ON rules(source, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_rules_updated_at ON rules(updated_at);
+
+-- Trigram index for case-insensitive name substring search (name ILIKE '%...%')
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX IF NOT EXISTS idx_rules_name_trgm ON rules USING GIN (name gin_trgm_ops);
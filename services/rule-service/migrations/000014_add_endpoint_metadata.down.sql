@@ -0,0 +1,2 @@
+ALTER TABLE endpoints
+    DROP COLUMN IF EXISTS metadata;
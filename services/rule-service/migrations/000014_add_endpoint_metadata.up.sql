@@ -0,0 +1,9 @@
+-- Per-endpoint metadata, e.g. static headers sent with every webhook request:
+--   {"headers": [{"name": "Authorization", "value": "<encrypted>", "secret": true}]}
+-- Secret header values are encrypted by rule-service and decrypted by the sender.
+--
+-- Migration: 000014
+-- Service: rule-service (table owner)
+-- Used by: sender
+ALTER TABLE endpoints
+    ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}';
@@ -0,0 +1,2 @@
+ALTER TABLE rules
+    DROP COLUMN IF EXISTS description;
@@ -0,0 +1,8 @@
+-- Human-readable rule description, embedded into notifications by the aggregator
+-- so templates can say which rule fired.
+--
+-- Migration: 000016
+-- Service: rule-service (table owner)
+-- Used by: aggregator
+ALTER TABLE rules
+    ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+ALTER TABLE rules
+    DROP COLUMN IF EXISTS exclude_names,
+    DROP COLUMN IF EXISTS exclude_sources;
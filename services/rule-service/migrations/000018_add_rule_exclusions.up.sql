@@ -0,0 +1,9 @@
+-- Exclusion lists for wildcard rules: a rule with source "*" (or name "*") does not
+-- match alerts whose source (or name) is in exclude_sources (or exclude_names).
+--
+-- Migration: 000018
+-- Service: rule-service (table owner)
+-- Used by: rule-updater
+ALTER TABLE rules
+    ADD COLUMN IF NOT EXISTS exclude_sources TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS exclude_names TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS trg_endpoints_outbox ON endpoints;
+DROP FUNCTION IF EXISTS record_endpoint_change();
+DROP TABLE IF EXISTS endpoint_outbox;
@@ -0,0 +1,62 @@
+-- Outbox of endpoint changes. A trigger records every insert, update and delete of an
+-- endpoint in the same transaction, whichever code path makes it (including deletes
+-- cascading from rules and clients); rule-service publishes the pending rows to the
+-- endpoint.changed topic and marks them published.
+--
+-- Migration: 000020
+-- Service: rule-service (table owner)
+-- Used by: sender (endpoint.changed consumer)
+CREATE TABLE IF NOT EXISTS endpoint_outbox (
+    event_id BIGSERIAL PRIMARY KEY,
+    endpoint_id VARCHAR(255) NOT NULL,
+    rule_id VARCHAR(255) NOT NULL,
+    client_id VARCHAR(255),
+    action VARCHAR(20) NOT NULL,
+    endpoint_type VARCHAR(50) NOT NULL,
+    enabled BOOLEAN NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    published_at TIMESTAMP
+);
+
+CREATE OR REPLACE FUNCTION record_endpoint_change() RETURNS TRIGGER AS $$
+DECLARE
+    changed endpoints%ROWTYPE;
+    change_action VARCHAR(20);
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        changed := NEW;
+        change_action := 'CREATED';
+    ELSIF TG_OP = 'DELETE' THEN
+        changed := OLD;
+        change_action := 'DELETED';
+    ELSIF NEW.type = OLD.type AND NEW.value = OLD.value AND NEW.metadata = OLD.metadata
+        AND NEW.enabled IS DISTINCT FROM OLD.enabled THEN
+        changed := NEW;
+        change_action := CASE WHEN NEW.enabled THEN 'ENABLED' ELSE 'DISABLED' END;
+    ELSE
+        changed := NEW;
+        change_action := 'UPDATED';
+    END IF;
+
+    -- The rule is gone when the endpoint is deleted along with it
+    INSERT INTO endpoint_outbox (endpoint_id, rule_id, client_id, action, endpoint_type, enabled)
+    VALUES (
+        changed.endpoint_id,
+        changed.rule_id,
+        (SELECT client_id FROM rules WHERE rule_id = changed.rule_id),
+        change_action,
+        changed.type,
+        TG_OP <> 'DELETE' AND COALESCE(changed.enabled, FALSE)
+    );
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_endpoints_outbox ON endpoints;
+CREATE TRIGGER trg_endpoints_outbox
+    AFTER INSERT OR UPDATE OR DELETE ON endpoints
+    FOR EACH ROW EXECUTE FUNCTION record_endpoint_change();
+
+-- The relay scans pending changes in order and prunes old published ones
+CREATE INDEX IF NOT EXISTS idx_endpoint_outbox_pending ON endpoint_outbox(event_id) WHERE published_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_endpoint_outbox_published_at ON endpoint_outbox(published_at) WHERE published_at IS NOT NULL;
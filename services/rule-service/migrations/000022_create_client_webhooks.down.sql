@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS trg_endpoints_platform_events ON endpoints;
+DROP TRIGGER IF EXISTS trg_rules_platform_events ON rules;
+DROP FUNCTION IF EXISTS record_endpoint_event();
+DROP FUNCTION IF EXISTS record_rule_event();
+DROP FUNCTION IF EXISTS record_platform_event(VARCHAR, VARCHAR, JSONB);
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS client_webhooks;
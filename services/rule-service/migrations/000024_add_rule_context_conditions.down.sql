@@ -0,0 +1,7 @@
+-- Fails if rules differing only in their conditions exist; remove them first.
+ALTER TABLE rules DROP CONSTRAINT IF EXISTS rules_client_criteria_unique;
+ALTER TABLE rules
+    ADD CONSTRAINT rules_client_criteria_unique UNIQUE (client_id, severity, source, name);
+
+ALTER TABLE rules
+    DROP COLUMN IF EXISTS context_conditions;
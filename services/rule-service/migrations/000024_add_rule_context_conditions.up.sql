@@ -0,0 +1,14 @@
+-- Context conditions: a rule only matches alerts whose context satisfies all of them,
+-- e.g. [{"key": "region", "op": "==", "value": "eu-west-1"}].
+-- Rules with the same criteria but different conditions may coexist (per-region routing),
+-- so the conditions join the unique criteria constraint.
+--
+-- Migration: 000024
+-- Service: rule-service (table owner)
+-- Used by: rule-updater
+ALTER TABLE rules
+    ADD COLUMN IF NOT EXISTS context_conditions JSONB NOT NULL DEFAULT '[]';
+
+ALTER TABLE rules DROP CONSTRAINT IF EXISTS rules_client_criteria_unique;
+ALTER TABLE rules
+    ADD CONSTRAINT rules_client_criteria_unique UNIQUE (client_id, severity, source, name, context_conditions);
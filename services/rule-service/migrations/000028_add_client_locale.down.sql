@@ -0,0 +1,3 @@
+ALTER TABLE clients
+    DROP COLUMN IF EXISTS locale,
+    DROP COLUMN IF EXISTS timezone;
@@ -0,0 +1,9 @@
+-- Client timezone and locale: the sender renders notification timestamps (event time and
+-- creation time) in them. Empty means UTC and the default layout.
+--
+-- Migration: 000028
+-- Service: rule-service (table owner)
+-- Used by: sender (reads timezone and locale)
+ALTER TABLE clients
+    ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS locale VARCHAR(35) NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_endpoints_template;
+ALTER TABLE endpoints DROP COLUMN IF EXISTS template_id;
+DROP TABLE IF EXISTS templates;
@@ -0,0 +1,22 @@
+-- Notification templates: a client's Go text/template subject and body, rendered by the
+-- sender in place of the default email and Slack layout for endpoints that reference one.
+-- Deleting a template reverts its endpoints to the default layout.
+--
+-- Migration: 000030
+-- Service: rule-service (table owner)
+-- Used by: sender (reads templates and endpoints.template_id)
+CREATE TABLE IF NOT EXISTS templates (
+    template_id VARCHAR(255) PRIMARY KEY DEFAULT gen_random_uuid()::text,
+    client_id VARCHAR(255) NOT NULL REFERENCES clients(client_id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    subject TEXT NOT NULL DEFAULT '',
+    body TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(client_id, name)
+);
+
+ALTER TABLE endpoints
+    ADD COLUMN IF NOT EXISTS template_id VARCHAR(255) REFERENCES templates(template_id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_endpoints_template ON endpoints(template_id) WHERE template_id IS NOT NULL;
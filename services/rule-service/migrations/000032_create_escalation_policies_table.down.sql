@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_rules_escalation_policy;
+ALTER TABLE rules DROP COLUMN IF EXISTS escalation_policy_id;
+DROP TABLE IF EXISTS escalation_policies;
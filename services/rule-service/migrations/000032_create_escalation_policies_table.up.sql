@@ -0,0 +1,22 @@
+-- Escalation policies: ordered steps of a delay and endpoints. While a delivered notification
+-- stays unacknowledged, the sender's escalation scheduler sends it to each step's endpoints
+-- once the step's delay has passed since the previous step (or the delivery).
+-- A rule opts in through rules.escalation_policy_id; deleting a policy unlinks its rules.
+--
+-- Migration: 000032
+-- Service: rule-service (table owner)
+-- Used by: sender (reads policies and rules.escalation_policy_id)
+CREATE TABLE IF NOT EXISTS escalation_policies (
+    policy_id VARCHAR(255) PRIMARY KEY DEFAULT gen_random_uuid()::text,
+    client_id VARCHAR(255) NOT NULL REFERENCES clients(client_id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    steps JSONB NOT NULL DEFAULT '[]', -- [{"delay_seconds": 900, "endpoint_ids": ["..."]}, ...]
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(client_id, name)
+);
+
+ALTER TABLE rules
+    ADD COLUMN IF NOT EXISTS escalation_policy_id VARCHAR(255) REFERENCES escalation_policies(policy_id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_rules_escalation_policy ON rules(escalation_policy_id) WHERE escalation_policy_id IS NOT NULL;
@@ -0,0 +1,19 @@
+-- API keys: bearer keys authenticating callers of rule-service, metrics-service and the
+-- alert-producer API. Only the SHA-256 digest of a key is stored; the key itself is shown once,
+-- when it is issued. A key with a client_id is scoped to that client's resources; a key without
+-- one is an admin key. Revoked keys are kept for auditing.
+--
+-- Migration: 000035
+-- Service: rule-service (table owner)
+-- Used by: metrics-service, alert-producer (look up keys by digest)
+CREATE TABLE IF NOT EXISTS api_keys (
+    key_id VARCHAR(255) PRIMARY KEY DEFAULT gen_random_uuid()::text,
+    client_id VARCHAR(255) REFERENCES clients(client_id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    key_hash CHAR(64) NOT NULL UNIQUE, -- hex SHA-256 of the key
+    key_prefix VARCHAR(32) NOT NULL,   -- first characters of the key, to recognize it
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_client ON api_keys(client_id);
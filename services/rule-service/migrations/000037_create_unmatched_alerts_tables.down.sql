@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS unmatched_reports;
+DROP TABLE IF EXISTS unmatched_alert_signatures;
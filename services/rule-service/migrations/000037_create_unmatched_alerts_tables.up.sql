@@ -0,0 +1,25 @@
+-- Unmatched alert signatures: alerts the evaluator matched to no rule (read from its
+-- alerts.unmatched topic), counted per UTC day, client hint and (severity, source, name).
+-- client_id is the alert's client_hint, '' for unhinted alerts. A daily report of the top
+-- signatures helps clients and operators close rule coverage gaps. unmatched_reports records
+-- the days already reported, so only one rule-service instance sends each day's report.
+--
+-- Migration: 000037
+-- Service: rule-service (table owner)
+CREATE TABLE IF NOT EXISTS unmatched_alert_signatures (
+    day DATE NOT NULL,
+    client_id VARCHAR(255) NOT NULL DEFAULT '',
+    severity VARCHAR(50) NOT NULL,
+    source VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    alert_count BIGINT NOT NULL DEFAULT 0,
+    first_seen_at TIMESTAMP NOT NULL,
+    last_seen_at TIMESTAMP NOT NULL,
+    sample_alert_id VARCHAR(255) NOT NULL DEFAULT '',
+    PRIMARY KEY (day, client_id, severity, source, name)
+);
+
+CREATE TABLE IF NOT EXISTS unmatched_reports (
+    day DATE PRIMARY KEY,
+    sent_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,21 @@
+-- Generation schedules: recurring alert generation jobs of the alert-producer API. A job with
+-- config (the generate request body) is started every time cron fires in timezone.
+-- last_run_at is when the last run was due; claiming a run by advancing it with a conditional
+-- UPDATE makes each run start one job across alert-producer API instances.
+--
+-- Migration: 000038
+-- Service: rule-service (table owner)
+-- Used by: alert-producer (creates, runs, pauses and deletes schedules)
+CREATE TABLE IF NOT EXISTS generation_schedules (
+    schedule_id VARCHAR(255) PRIMARY KEY,
+    owner VARCHAR(255) NOT NULL,         -- API key name of the creator ('' when unauthenticated)
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    cron VARCHAR(255) NOT NULL,          -- five-field cron expression
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    config JSONB NOT NULL,
+    paused BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_run_at TIMESTAMP,
+    last_job_id VARCHAR(255)
+);
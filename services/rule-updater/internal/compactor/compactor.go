@@ -0,0 +1,101 @@
+// Package compactor periodically compacts the rule snapshot in Redis.
+// Per-rule changes never shrink the snapshot's dictionaries and leave gaps in the ruleInts,
+// so it slowly accumulates garbage between full rebuilds.
+package compactor
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"rule-updater/internal/snapshot"
+)
+
+// DefaultInterval is the default time between compactions.
+const DefaultInterval = time.Hour
+
+// SnapshotCompactor compacts the snapshot store.
+type SnapshotCompactor interface {
+	Compact(ctx context.Context) (*snapshot.CompactionResult, error)
+}
+
+// MetricsRecorder records compaction metrics.
+type MetricsRecorder interface {
+	IncrementCustom(name string)
+	AddCustom(name string, value uint64)
+}
+
+// noopMetrics is used when no metrics recorder is configured.
+type noopMetrics struct{}
+
+func (noopMetrics) IncrementCustom(string)   {}
+func (noopMetrics) AddCustom(string, uint64) {}
+
+// Compactor runs snapshot compactions on an interval.
+type Compactor struct {
+	store    SnapshotCompactor
+	interval time.Duration
+	metrics  MetricsRecorder
+}
+
+// New creates a compactor that compacts store every interval.
+func New(store SnapshotCompactor, interval time.Duration) *Compactor {
+	return &Compactor{
+		store:    store,
+		interval: interval,
+		metrics:  noopMetrics{},
+	}
+}
+
+// WithMetrics records compactions, conflicts, and bytes reclaimed.
+func (c *Compactor) WithMetrics(m MetricsRecorder) *Compactor {
+	if m != nil {
+		c.metrics = m
+	}
+	return c
+}
+
+// Run compacts the snapshot every interval until ctx is cancelled.
+func (c *Compactor) Run(ctx context.Context) {
+	slog.Info("Starting snapshot compactor", "interval", c.interval)
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Snapshot compactor stopped")
+			return
+		case <-ticker.C:
+			if err := c.CompactOnce(ctx); err != nil {
+				slog.Error("Failed to compact snapshot", "error", err)
+			}
+		}
+	}
+}
+
+// CompactOnce compacts the snapshot once. A rule change landing during the compaction is
+// not an error: the compaction is skipped until the next interval.
+func (c *Compactor) CompactOnce(ctx context.Context) error {
+	result, err := c.store.Compact(ctx)
+	if errors.Is(err, snapshot.ErrVersionConflict) {
+		slog.Info("Snapshot changed during compaction, skipping until next interval")
+		c.metrics.IncrementCustom("snapshot_compaction_conflicts")
+		return nil
+	}
+	if err != nil {
+		c.metrics.IncrementCustom("snapshot_compaction_errors")
+		return err
+	}
+	if result.Version == 0 {
+		return nil
+	}
+
+	c.metrics.IncrementCustom("snapshot_compactions")
+	if result.BytesBefore > result.BytesAfter {
+		c.metrics.AddCustom("snapshot_compaction_bytes_reclaimed", uint64(result.BytesBefore-result.BytesAfter))
+	}
+	return nil
+}
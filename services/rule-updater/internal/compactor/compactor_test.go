@@ -0,0 +1,84 @@
+package compactor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"rule-updater/internal/snapshot"
+)
+
+type fakeStore struct {
+	result *snapshot.CompactionResult
+	err    error
+}
+
+func (s *fakeStore) Compact(ctx context.Context) (*snapshot.CompactionResult, error) {
+	return s.result, s.err
+}
+
+type fakeMetrics struct {
+	counters map[string]uint64
+}
+
+func newFakeMetrics() *fakeMetrics {
+	return &fakeMetrics{counters: make(map[string]uint64)}
+}
+
+func (m *fakeMetrics) IncrementCustom(name string) {
+	m.counters[name]++
+}
+
+func (m *fakeMetrics) AddCustom(name string, value uint64) {
+	m.counters[name] += value
+}
+
+func TestCompactor_CompactOnce(t *testing.T) {
+	tests := []struct {
+		name    string
+		store   *fakeStore
+		wantErr bool
+		want    map[string]uint64
+	}{
+		{
+			name:  "compacted",
+			store: &fakeStore{result: &snapshot.CompactionResult{Version: 7, BytesBefore: 1000, BytesAfter: 600}},
+			want:  map[string]uint64{"snapshot_compactions": 1, "snapshot_compaction_bytes_reclaimed": 400},
+		},
+		{
+			name:  "nothing to compact",
+			store: &fakeStore{result: &snapshot.CompactionResult{BytesBefore: 600, BytesAfter: 600}},
+			want:  map[string]uint64{},
+		},
+		{
+			name:  "version conflict is skipped",
+			store: &fakeStore{err: fmt.Errorf("%w: expected version 6", snapshot.ErrVersionConflict)},
+			want:  map[string]uint64{"snapshot_compaction_conflicts": 1},
+		},
+		{
+			name:    "redis error",
+			store:   &fakeStore{err: errors.New("connection refused")},
+			wantErr: true,
+			want:    map[string]uint64{"snapshot_compaction_errors": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newFakeMetrics()
+			err := New(tt.store, DefaultInterval).WithMetrics(m).CompactOnce(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CompactOnce() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(m.counters) != len(tt.want) {
+				t.Errorf("metrics = %v, want %v", m.counters, tt.want)
+			}
+			for name, want := range tt.want {
+				if got := m.counters[name]; got != want {
+					t.Errorf("%s = %d, want %d", name, got, want)
+				}
+			}
+		})
+	}
+}
@@ -3,6 +3,9 @@ package config
 
 import (
 	"fmt"
+	"time"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
 )
 
 // Config holds all configuration parameters for the rule-updater service.
@@ -12,6 +15,27 @@ type Config struct {
 	ConsumerGroupID   string
 	PostgresDSN       string
 	RedisAddr         string
+	// RedisSentinelMaster is the Sentinel master name; RedisAddr then lists the Sentinels
+	RedisSentinelMaster string
+	// RedisCluster connects to a Redis Cluster; RedisAddr then lists seed nodes
+	RedisCluster bool
+	// Normalize is the comma-separated normalization of rule fields (see shared.ParseNormalization)
+	Normalize string
+	// CompactInterval is the time between snapshot compactions (0 disables compaction)
+	CompactInterval time.Duration
+	// SnapshotHistory is how many snapshot versions are kept at {rules}:snapshot:v<version> (0 keeps none)
+	SnapshotHistory int
+	// SnapshotDeltas is how many per-write change entries are kept at {rules}:deltas (0 keeps none)
+	SnapshotDeltas int
+	// DLQTopic receives rule.changed events that cannot be applied (empty disables the dead-letter queue)
+	DLQTopic string
+	// DLQMaxFailures is the number of failed attempts before an event is dead-lettered
+	DLQMaxFailures int
+}
+
+// DLQEnabled reports whether unprocessable events are dead-lettered.
+func (c *Config) DLQEnabled() bool {
+	return c.DLQTopic != ""
 }
 
 // Validate checks that all required configuration fields are set and have valid values.
@@ -29,8 +53,23 @@ func (c *Config) Validate() error {
 	if c.PostgresDSN == "" {
 		return fmt.Errorf("postgres-dsn cannot be empty")
 	}
-	if c.RedisAddr == "" {
-		return fmt.Errorf("redis-addr cannot be empty")
+	if _, err := shared.ParseRedisOptions(c.RedisAddr, c.RedisSentinelMaster, c.RedisCluster); err != nil {
+		return err
+	}
+	if _, err := shared.ParseNormalization(c.Normalize); err != nil {
+		return fmt.Errorf("invalid normalize: %w", err)
+	}
+	if c.CompactInterval < 0 {
+		return fmt.Errorf("compact-interval cannot be negative")
+	}
+	if c.SnapshotHistory < 0 {
+		return fmt.Errorf("snapshot-history cannot be negative")
+	}
+	if c.SnapshotDeltas < 0 {
+		return fmt.Errorf("snapshot-deltas cannot be negative")
+	}
+	if c.DLQEnabled() && c.DLQMaxFailures <= 0 {
+		return fmt.Errorf("dlq-max-failures must be positive")
 	}
 	return nil
 }
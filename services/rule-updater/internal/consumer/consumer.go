@@ -40,6 +40,8 @@ func fromProtoRuleAction(action protocommon.RuleAction) events.Action {
 		return events.ActionDeleted
 	case protocommon.RuleAction_RULE_ACTION_DISABLED:
 		return events.ActionDisabled
+	case protocommon.RuleAction_RULE_ACTION_REBUILD:
+		return events.ActionRebuild
 	default:
 		return events.Action("")
 	}
@@ -95,6 +97,7 @@ func (c *Consumer) ReadMessage(ctx context.Context) (*events.RuleChanged, *kafka
 		Version:       int(pb.Version),
 		UpdatedAt:     pb.UpdatedAt,
 		SchemaVersion: int(pb.SchemaVersion),
+		PublishedAtMs: pb.PublishedAtMs,
 	}
 
 	return &ruleChanged, &msg, nil
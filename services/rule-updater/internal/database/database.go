@@ -4,11 +4,12 @@ package database
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 // RuleStore defines the interface for rule database operations.
@@ -29,6 +30,49 @@ type Rule struct {
 	Version   int
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	// ExcludeSources and ExcludeNames are the alert sources and names a wildcard
+	// source or name does not match.
+	ExcludeSources []string
+	ExcludeNames   []string
+	// Conditions are alert context conditions that must all hold for the rule to match.
+	Conditions []ContextCondition
+}
+
+// ContextCondition matches one key of an alert's context. Op is == or !=.
+type ContextCondition struct {
+	Key   string `json:"key"`
+	Op    string `json:"op"`
+	Value string `json:"value"`
+}
+
+// conditionsColumn scans the JSONB context_conditions column into a rule's conditions.
+type conditionsColumn struct {
+	conditions *[]ContextCondition
+}
+
+// Scan implements sql.Scanner. An empty array scans as nil conditions.
+func (c conditionsColumn) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*c.conditions = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported rule conditions type %T", src)
+	}
+	var conditions []ContextCondition
+	if err := json.Unmarshal(data, &conditions); err != nil {
+		return fmt.Errorf("invalid rule conditions: %w", err)
+	}
+	if len(conditions) == 0 {
+		conditions = nil
+	}
+	*c.conditions = conditions
+	return nil
 }
 
 // DB wraps a database connection and provides rule operations.
@@ -69,7 +113,7 @@ func (db *DB) Close() error {
 // This is used to rebuild the complete snapshot.
 func (db *DB) GetAllEnabledRules(ctx context.Context) ([]*Rule, error) {
 	query := `
-		SELECT rule_id, client_id, severity, source, name, enabled, version, created_at, updated_at
+		SELECT rule_id, client_id, severity, source, name, enabled, version, created_at, updated_at, exclude_sources, exclude_names, context_conditions
 		FROM rules
 		WHERE enabled = TRUE
 		ORDER BY created_at ASC
@@ -93,6 +137,9 @@ func (db *DB) GetAllEnabledRules(ctx context.Context) ([]*Rule, error) {
 			&rule.Version,
 			&rule.CreatedAt,
 			&rule.UpdatedAt,
+			pq.Array(&rule.ExcludeSources),
+			pq.Array(&rule.ExcludeNames),
+			conditionsColumn{&rule.Conditions},
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan rule: %w", err)
 		}
@@ -105,7 +152,7 @@ func (db *DB) GetAllEnabledRules(ctx context.Context) ([]*Rule, error) {
 // This is used to fetch rule details for incremental updates.
 func (db *DB) GetRule(ctx context.Context, ruleID string) (*Rule, error) {
 	query := `
-		SELECT rule_id, client_id, severity, source, name, enabled, version, created_at, updated_at
+		SELECT rule_id, client_id, severity, source, name, enabled, version, created_at, updated_at, exclude_sources, exclude_names, context_conditions
 		FROM rules
 		WHERE rule_id = $1
 	`
@@ -120,6 +167,9 @@ func (db *DB) GetRule(ctx context.Context, ruleID string) (*Rule, error) {
 		&rule.Version,
 		&rule.CreatedAt,
 		&rule.UpdatedAt,
+		pq.Array(&rule.ExcludeSources),
+		pq.Array(&rule.ExcludeNames),
+		conditionsColumn{&rule.Conditions},
 	)
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("rule not found: %s", ruleID)
@@ -85,10 +85,10 @@ func TestDB_GetAllEnabledRules(t *testing.T) {
 		{
 			name: "success with rules",
 			setup: func() {
-				rows := sqlmock.NewRows([]string{"rule_id", "client_id", "severity", "source", "name", "enabled", "version", "created_at", "updated_at"}).
-					AddRow("rule-1", "client-1", "HIGH", "source-1", "name-1", true, 1, time.Now(), time.Now()).
-					AddRow("rule-2", "client-2", "MEDIUM", "source-2", "name-2", true, 1, time.Now(), time.Now())
-				mock.ExpectQuery(`SELECT rule_id, client_id, severity, source, name, enabled, version, created_at, updated_at`).
+				rows := sqlmock.NewRows([]string{"rule_id", "client_id", "severity", "source", "name", "enabled", "version", "created_at", "updated_at", "exclude_sources", "exclude_names", "context_conditions"}).
+					AddRow("rule-1", "client-1", "HIGH", "source-1", "name-1", true, 1, time.Now(), time.Now(), "{}", "{}", "[]").
+					AddRow("rule-2", "client-2", "MEDIUM", "*", "name-2", true, 1, time.Now(), time.Now(), "{staging}", "{}", `[{"key":"region","op":"==","value":"eu-west-1"}]`)
+				mock.ExpectQuery(`SELECT rule_id, client_id, severity, source, name, enabled, version, created_at, updated_at, exclude_sources, exclude_names, context_conditions`).
 					WillReturnRows(rows)
 			},
 			wantErr: false,
@@ -97,8 +97,8 @@ func TestDB_GetAllEnabledRules(t *testing.T) {
 		{
 			name: "success with no rules",
 			setup: func() {
-				rows := sqlmock.NewRows([]string{"rule_id", "client_id", "severity", "source", "name", "enabled", "version", "created_at", "updated_at"})
-				mock.ExpectQuery(`SELECT rule_id, client_id, severity, source, name, enabled, version, created_at, updated_at`).
+				rows := sqlmock.NewRows([]string{"rule_id", "client_id", "severity", "source", "name", "enabled", "version", "created_at", "updated_at", "exclude_sources", "exclude_names", "context_conditions"})
+				mock.ExpectQuery(`SELECT rule_id, client_id, severity, source, name, enabled, version, created_at, updated_at, exclude_sources, exclude_names, context_conditions`).
 					WillReturnRows(rows)
 			},
 			wantErr: false,
@@ -107,7 +107,7 @@ func TestDB_GetAllEnabledRules(t *testing.T) {
 		{
 			name: "database error",
 			setup: func() {
-				mock.ExpectQuery(`SELECT rule_id, client_id, severity, source, name, enabled, version, created_at, updated_at`).
+				mock.ExpectQuery(`SELECT rule_id, client_id, severity, source, name, enabled, version, created_at, updated_at, exclude_sources, exclude_names, context_conditions`).
 					WillReturnError(sql.ErrConnDone)
 			},
 			wantErr: true,
@@ -154,9 +154,9 @@ func TestDB_GetRule(t *testing.T) {
 			name:   "success",
 			ruleID: "rule-1",
 			setup: func() {
-				rows := sqlmock.NewRows([]string{"rule_id", "client_id", "severity", "source", "name", "enabled", "version", "created_at", "updated_at"}).
-					AddRow("rule-1", "client-1", "HIGH", "source-1", "name-1", true, 1, time.Now(), time.Now())
-				mock.ExpectQuery(`SELECT rule_id, client_id, severity, source, name, enabled, version, created_at, updated_at`).
+				rows := sqlmock.NewRows([]string{"rule_id", "client_id", "severity", "source", "name", "enabled", "version", "created_at", "updated_at", "exclude_sources", "exclude_names", "context_conditions"}).
+					AddRow("rule-1", "client-1", "HIGH", "source-1", "name-1", true, 1, time.Now(), time.Now(), "{}", "{}", "[]")
+				mock.ExpectQuery(`SELECT rule_id, client_id, severity, source, name, enabled, version, created_at, updated_at, exclude_sources, exclude_names, context_conditions`).
 					WithArgs("rule-1").
 					WillReturnRows(rows)
 			},
@@ -167,7 +167,7 @@ func TestDB_GetRule(t *testing.T) {
 			name:   "rule not found",
 			ruleID: "rule-not-found",
 			setup: func() {
-				mock.ExpectQuery(`SELECT rule_id, client_id, severity, source, name, enabled, version, created_at, updated_at`).
+				mock.ExpectQuery(`SELECT rule_id, client_id, severity, source, name, enabled, version, created_at, updated_at, exclude_sources, exclude_names, context_conditions`).
 					WithArgs("rule-not-found").
 					WillReturnError(sql.ErrNoRows)
 			},
@@ -178,7 +178,7 @@ func TestDB_GetRule(t *testing.T) {
 			name:   "database error",
 			ruleID: "rule-1",
 			setup: func() {
-				mock.ExpectQuery(`SELECT rule_id, client_id, severity, source, name, enabled, version, created_at, updated_at`).
+				mock.ExpectQuery(`SELECT rule_id, client_id, severity, source, name, enabled, version, created_at, updated_at, exclude_sources, exclude_names, context_conditions`).
 					WithArgs("rule-1").
 					WillReturnError(sql.ErrConnDone)
 			},
@@ -220,7 +220,7 @@ func TestDB_GetAllEnabledRules_ScanError(t *testing.T) {
 	// Test scan error by providing wrong number of columns
 	rows := sqlmock.NewRows([]string{"rule_id", "client_id"}).
 		AddRow("rule-1", "client-1")
-	mock.ExpectQuery(`SELECT rule_id, client_id, severity, source, name, enabled, version, created_at, updated_at`).
+	mock.ExpectQuery(`SELECT rule_id, client_id, severity, source, name, enabled, version, created_at, updated_at, exclude_sources, exclude_names, context_conditions`).
 		WillReturnRows(rows)
 
 	_, err = db.GetAllEnabledRules(ctx)
@@ -232,3 +232,22 @@ func TestDB_GetAllEnabledRules_ScanError(t *testing.T) {
 		t.Errorf("Unfulfilled expectations: %v", err)
 	}
 }
+
+func TestConditionsColumn_Scan(t *testing.T) {
+	var conditions []ContextCondition
+	col := conditionsColumn{&conditions}
+
+	if err := col.Scan([]byte(`[{"key":"region","op":"==","value":"eu-west-1"}]`)); err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if len(conditions) != 1 || conditions[0] != (ContextCondition{Key: "region", Op: "==", Value: "eu-west-1"}) {
+		t.Errorf("Scan() conditions = %+v, want region == eu-west-1", conditions)
+	}
+
+	if err := col.Scan("[]"); err != nil || conditions != nil {
+		t.Errorf("Scan([]) = %+v, %v, want nil conditions", conditions, err)
+	}
+	if err := col.Scan([]byte(`{`)); err == nil {
+		t.Error("Scan() expected error for invalid JSON")
+	}
+}
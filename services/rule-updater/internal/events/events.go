@@ -12,6 +12,9 @@ const (
 	ActionUpdated  Action = "UPDATED"
 	ActionDeleted  Action = "DELETED"
 	ActionDisabled Action = "DISABLED"
+	// ActionRebuild means many rules of a client changed at once (a bulk import); the event
+	// has no rule_id and the snapshot is rebuilt from the database.
+	ActionRebuild Action = "REBUILD"
 )
 
 // IsAdditive returns true if the action adds or updates a rule (requires DB lookup).
@@ -27,7 +30,7 @@ func (a Action) IsRemoval() bool {
 // IsValid returns true if the action is a known valid action.
 func (a Action) IsValid() bool {
 	switch a {
-	case ActionCreated, ActionUpdated, ActionDeleted, ActionDisabled:
+	case ActionCreated, ActionUpdated, ActionDeleted, ActionDisabled, ActionRebuild:
 		return true
 	default:
 		return false
@@ -48,11 +51,13 @@ type RuleChanged struct {
 	Version       int    `json:"version"`
 	UpdatedAt     int64  `json:"updated_at"` // Unix timestamp
 	SchemaVersion int    `json:"schema_version"`
+	// PublishedAtMs is when rule-service published the event (Unix milliseconds); 0 if unknown.
+	PublishedAtMs int64 `json:"published_at_ms,omitempty"`
 }
 
 // Validate checks that the event has valid required fields.
 func (e *RuleChanged) Validate() error {
-	if e.RuleID == "" {
+	if e.RuleID == "" && e.Action != ActionRebuild {
 		return fmt.Errorf("rule_id is required")
 	}
 	if !e.Action.IsValid() {
@@ -55,6 +55,7 @@ func TestAction_IsValid(t *testing.T) {
 		{ActionUpdated, true},
 		{ActionDeleted, true},
 		{ActionDisabled, true},
+		{ActionRebuild, true},
 		{Action("UNKNOWN"), false},
 		{Action(""), false},
 		{Action("created"), false}, // case sensitive
@@ -125,6 +126,14 @@ func TestRuleChanged_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "rebuild event without rule_id",
+			event: RuleChanged{
+				ClientID: "client-1",
+				Action:   ActionRebuild,
+			},
+			wantErr: false,
+		},
 		{
 			name: "invalid action",
 			event: RuleChanged{
@@ -17,6 +17,7 @@ type fakeConsumer struct {
 	kafkaMessages []*kafka.Message
 	readIndex    int
 	readErr      error
+	decodeErr    error // returned with a raw message, as for an undecodable message
 	commitErr    error
 	commitCalls  int
 }
@@ -47,6 +48,9 @@ func (f *fakeConsumer) ReadMessage(ctx context.Context) (*events.RuleChanged, *k
 	if f.readErr != nil {
 		return nil, nil, f.readErr
 	}
+	if f.decodeErr != nil {
+		return nil, &kafka.Message{Topic: "rule.changed", Offset: 42}, f.decodeErr
+	}
 	if f.readIndex >= len(f.messages) {
 		// Block until context is cancelled (simulates waiting for message)
 		<-ctx.Done()
@@ -170,3 +174,40 @@ func (f *fakeMetrics) RecordError() {
 func (f *fakeMetrics) IncrementCustom(name string) {
 	f.customCalls[name]++
 }
+
+// fakePropagation is a test fake for PropagationRecorder.
+type fakePropagation struct {
+	stages  []string
+	ruleIDs []string
+	latency time.Duration
+}
+
+func (f *fakePropagation) RecordRulePropagation(ctx context.Context, stage, ruleID string, publishedAt, appliedAt time.Time) error {
+	f.stages = append(f.stages, stage)
+	f.ruleIDs = append(f.ruleIDs, ruleID)
+	f.latency = appliedAt.Sub(publishedAt)
+	return nil
+}
+
+// fakeDLQ is a test fake for DeadLetterQueue that dead-letters on the maxFailures-th Fail.
+type fakeDLQ struct {
+	maxFailures int
+	failures    int
+	rejected    []error
+	deadLetters []kafka.Message
+}
+
+func (f *fakeDLQ) Reject(ctx context.Context, msg *kafka.Message, reason error) (bool, error) {
+	f.rejected = append(f.rejected, reason)
+	f.deadLetters = append(f.deadLetters, *msg)
+	return true, nil
+}
+
+func (f *fakeDLQ) Fail(ctx context.Context, msg *kafka.Message, reason error) (bool, error) {
+	f.failures++
+	if f.failures < f.maxFailures {
+		return false, nil
+	}
+	f.deadLetters = append(f.deadLetters, *msg)
+	return true, nil
+}
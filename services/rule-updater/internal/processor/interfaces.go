@@ -18,6 +18,16 @@ type MessageConsumer interface {
 	Close() error
 }
 
+// DeadLetterQueue moves messages that cannot be processed to a dead-letter topic.
+// It is implemented by pkg/kafka's DeadLetterQueue.
+type DeadLetterQueue interface {
+	// Reject dead-letters a message that will never succeed. Returns true if it was published.
+	Reject(ctx context.Context, msg *kafka.Message, reason error) (bool, error)
+	// Fail records a failed attempt and dead-letters the message once it has failed too often.
+	// Returns true if it was published.
+	Fail(ctx context.Context, msg *kafka.Message, reason error) (bool, error)
+}
+
 // RuleStore provides access to rules in the database.
 type RuleStore interface {
 	GetRule(ctx context.Context, ruleID string) (*database.Rule, error)
@@ -29,6 +39,19 @@ type SnapshotWriter interface {
 	RemoveRuleDirect(ctx context.Context, ruleID string) error
 }
 
+// SnapshotRebuilder rebuilds the whole snapshot from the database.
+type SnapshotRebuilder interface {
+	Rebuild(ctx context.Context) error
+}
+
+// RebuilderFunc adapts a function to a SnapshotRebuilder.
+type RebuilderFunc func(ctx context.Context) error
+
+// Rebuild calls f(ctx).
+func (f RebuilderFunc) Rebuild(ctx context.Context) error {
+	return f(ctx)
+}
+
 // MetricsRecorder records processing metrics.
 type MetricsRecorder interface {
 	RecordReceived()
@@ -38,6 +61,18 @@ type MetricsRecorder interface {
 	IncrementCustom(name string)
 }
 
+// PropagationRecorder records when a rule change reached a pipeline stage.
+type PropagationRecorder interface {
+	RecordRulePropagation(ctx context.Context, stage, ruleID string, publishedAt, appliedAt time.Time) error
+}
+
+// noopPropagation is used when no propagation recorder is configured.
+type noopPropagation struct{}
+
+func (noopPropagation) RecordRulePropagation(context.Context, string, string, time.Time, time.Time) error {
+	return nil
+}
+
 // noopMetrics is a no-op implementation of MetricsRecorder.
 // This avoids scattered nil checks throughout the code.
 type noopMetrics struct{}
@@ -7,14 +7,14 @@ import (
 	"github.com/afikmenashe/alerting-platform/pkg/metrics"
 )
 
-// metricsAdapter adapts *metrics.Collector to MetricsRecorder interface.
+// metricsAdapter adapts metrics.Collector to MetricsRecorder interface.
 type metricsAdapter struct {
-	collector *metrics.Collector
+	collector metrics.Collector
 }
 
 // NewMetricsAdapter wraps a metrics.Collector as a MetricsRecorder.
 // If collector is nil, returns a no-op implementation.
-func NewMetricsAdapter(collector *metrics.Collector) MetricsRecorder {
+func NewMetricsAdapter(collector metrics.Collector) MetricsRecorder {
 	if collector == nil {
 		return NoopMetrics()
 	}
@@ -4,6 +4,7 @@ package processor
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"time"
@@ -19,12 +20,18 @@ import (
 
 // Processor orchestrates rule change processing and snapshot updates.
 type Processor struct {
-	consumer MessageConsumer
-	db       RuleStore
-	writer   SnapshotWriter
-	metrics  MetricsRecorder
+	consumer    MessageConsumer
+	db          RuleStore
+	writer      SnapshotWriter
+	rebuilder   SnapshotRebuilder
+	metrics     MetricsRecorder
+	propagation PropagationRecorder
+	dlq         DeadLetterQueue
 }
 
+// errInvalidRuleChange marks rule change events that can never be applied.
+var errInvalidRuleChange = errors.New("invalid rule change event")
+
 // Option configures a Processor.
 type Option func(*Processor)
 
@@ -37,20 +44,53 @@ func WithMetrics(m MetricsRecorder) Option {
 	}
 }
 
-// WithMetricsCollector sets a metrics.Collector as the metrics recorder.
-func WithMetricsCollector(c *metrics.Collector) Option {
+// WithMetricsCollector sets a metrics.Collector as the metrics and rule propagation recorder.
+func WithMetricsCollector(c metrics.Collector) Option {
 	return func(p *Processor) {
 		p.metrics = NewMetricsAdapter(c)
+		if c != nil {
+			p.propagation = c
+		}
+	}
+}
+
+// WithPropagationRecorder sets the recorder for rule propagation latency.
+func WithPropagationRecorder(r PropagationRecorder) Option {
+	return func(p *Processor) {
+		if r != nil {
+			p.propagation = r
+		}
+	}
+}
+
+// WithDeadLetterQueue dead-letters events that fail to decode or are invalid, and events that
+// fail to apply too many times, then commits them. Without it such events are left uncommitted.
+func WithDeadLetterQueue(q DeadLetterQueue) Option {
+	return func(p *Processor) {
+		if q != nil {
+			p.dlq = q
+		}
+	}
+}
+
+// WithRebuilder rebuilds the snapshot from the database on REBUILD events, which bulk imports
+// publish instead of one event per rule. Without it REBUILD events fail to apply.
+func WithRebuilder(r SnapshotRebuilder) Option {
+	return func(p *Processor) {
+		if r != nil {
+			p.rebuilder = r
+		}
 	}
 }
 
 // New creates a new rule change processor with functional options.
 func New(consumer MessageConsumer, db RuleStore, writer SnapshotWriter, opts ...Option) *Processor {
 	p := &Processor{
-		consumer: consumer,
-		db:       db,
-		writer:   writer,
-		metrics:  NoopMetrics(),
+		consumer:    consumer,
+		db:          db,
+		writer:      writer,
+		metrics:     NoopMetrics(),
+		propagation: noopPropagation{},
 	}
 	for _, opt := range opts {
 		opt(p)
@@ -66,7 +106,7 @@ func NewProcessor(consumer *consumer.Consumer, db *database.DB, writer *snapshot
 
 // NewProcessorWithMetrics creates a processor with shared metrics collector.
 // Deprecated: Use New() with WithMetricsCollector option instead.
-func NewProcessorWithMetrics(consumer *consumer.Consumer, db *database.DB, writer *snapshot.Writer, m *metrics.Collector) *Processor {
+func NewProcessorWithMetrics(consumer *consumer.Consumer, db *database.DB, writer *snapshot.Writer, m metrics.Collector) *Processor {
 	return New(consumer, db, writer, WithMetricsCollector(m))
 }
 
@@ -100,6 +140,11 @@ func (p *Processor) processOneMessage(ctx context.Context) error {
 			return ctx.Err()
 		}
 		slog.Error("Failed to read rule.changed event", "error", err)
+		// A message that was read but could not be decoded never will be
+		if msg != nil && p.dlq != nil {
+			dead, dlqErr := p.dlq.Reject(ctx, msg, err)
+			p.handleDeadLetter(ctx, msg, dead, dlqErr)
+		}
 		return err
 	}
 
@@ -120,12 +165,23 @@ func (p *Processor) processOneMessage(ctx context.Context) error {
 			"error", err,
 		)
 		p.metrics.RecordError()
+		if p.dlq != nil {
+			var dead bool
+			var dlqErr error
+			if errors.Is(err, errInvalidRuleChange) {
+				dead, dlqErr = p.dlq.Reject(ctx, msg, err)
+			} else {
+				dead, dlqErr = p.dlq.Fail(ctx, msg, err)
+			}
+			p.handleDeadLetter(ctx, msg, dead, dlqErr)
+		}
 		return err
 	}
 
 	p.metrics.RecordProcessed(time.Since(startTime))
 	p.metrics.RecordPublished()
 	p.metrics.IncrementCustom("rules_" + ruleChanged.Action.String())
+	p.recordPropagation(ctx, ruleChanged)
 
 	slog.Info("Rule change applied directly to Redis",
 		"rule_id", ruleChanged.RuleID,
@@ -140,6 +196,34 @@ func (p *Processor) processOneMessage(ctx context.Context) error {
 	return nil
 }
 
+// handleDeadLetter commits a message once it is in the dead-letter topic.
+func (p *Processor) handleDeadLetter(ctx context.Context, msg *kafka.Message, dead bool, err error) {
+	if err != nil {
+		slog.Error("Failed to dead-letter message", "offset", msg.Offset, "error", err)
+		p.metrics.RecordError()
+		return
+	}
+	if !dead {
+		return
+	}
+	p.metrics.IncrementCustom("messages_dead_lettered")
+	if err := p.consumer.CommitMessage(ctx, msg); err != nil {
+		slog.Error("Failed to commit dead-lettered message", "offset", msg.Offset, "error", err)
+	}
+}
+
+// recordPropagation records how long the change took to reach the snapshot since rule-service published it.
+// Events from producers that predate the publish timestamp, and rebuild events, which have no rule, are skipped.
+func (p *Processor) recordPropagation(ctx context.Context, ruleChanged *events.RuleChanged) {
+	if ruleChanged.PublishedAtMs <= 0 || ruleChanged.RuleID == "" {
+		return
+	}
+	publishedAt := time.UnixMilli(ruleChanged.PublishedAtMs)
+	if err := p.propagation.RecordRulePropagation(ctx, metrics.PropagationStageSnapshot, ruleChanged.RuleID, publishedAt, time.Now()); err != nil {
+		slog.Debug("Failed to record rule propagation", "rule_id", ruleChanged.RuleID, "error", err)
+	}
+}
+
 // commitMessage commits the Kafka message offset after successful processing.
 func (p *Processor) commitMessage(ctx context.Context, msg *kafka.Message, ruleChanged *events.RuleChanged) error {
 	if err := p.consumer.CommitMessage(ctx, msg); err != nil {
@@ -157,7 +241,7 @@ func (p *Processor) commitMessage(ctx context.Context, msg *kafka.Message, ruleC
 // This avoids loading the entire snapshot into Go memory.
 func (p *Processor) applyRuleChange(ctx context.Context, ruleChanged *events.RuleChanged) error {
 	if err := ruleChanged.Validate(); err != nil {
-		return fmt.Errorf("invalid rule change event: %w", err)
+		return fmt.Errorf("%w: %w", errInvalidRuleChange, err)
 	}
 
 	if ruleChanged.Action.IsAdditive() {
@@ -168,7 +252,11 @@ func (p *Processor) applyRuleChange(ctx context.Context, ruleChanged *events.Rul
 		return p.applyRemovalChange(ctx, ruleChanged)
 	}
 
-	return fmt.Errorf("unknown action: %s", ruleChanged.Action)
+	if ruleChanged.Action == events.ActionRebuild {
+		return p.applyRebuild(ctx, ruleChanged)
+	}
+
+	return fmt.Errorf("%w: unknown action: %s", errInvalidRuleChange, ruleChanged.Action)
 }
 
 // applyAdditiveChange handles CREATED and UPDATED actions.
@@ -217,3 +305,21 @@ func (p *Processor) applyRemovalChange(ctx context.Context, ruleChanged *events.
 
 	return nil
 }
+
+// applyRebuild handles REBUILD actions by rebuilding the snapshot from the database.
+func (p *Processor) applyRebuild(ctx context.Context, ruleChanged *events.RuleChanged) error {
+	if p.rebuilder == nil {
+		return fmt.Errorf("snapshot rebuilder is not configured")
+	}
+
+	if err := p.rebuilder.Rebuild(ctx); err != nil {
+		return fmt.Errorf("failed to rebuild snapshot: %w", err)
+	}
+
+	slog.Info("Snapshot rebuilt after rule.changed event",
+		"client_id", ruleChanged.ClientID,
+		"action", ruleChanged.Action,
+	)
+
+	return nil
+}
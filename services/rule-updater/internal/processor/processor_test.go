@@ -181,6 +181,39 @@ func TestApplyRuleChange_Disabled(t *testing.T) {
 	}
 }
 
+func TestApplyRuleChange_Rebuild(t *testing.T) {
+	rebuilds := 0
+	p := New(nil, nil, nil, WithRebuilder(RebuilderFunc(func(ctx context.Context) error {
+		rebuilds++
+		return nil
+	})))
+
+	ruleChanged := &events.RuleChanged{
+		ClientID: "client-1",
+		Action:   events.ActionRebuild,
+	}
+
+	if err := p.applyRuleChange(context.Background(), ruleChanged); err != nil {
+		t.Errorf("applyRuleChange() error = %v, want nil", err)
+	}
+	if rebuilds != 1 {
+		t.Errorf("expected 1 rebuild, got %d", rebuilds)
+	}
+}
+
+func TestApplyRuleChange_RebuildWithoutRebuilder(t *testing.T) {
+	p := New(nil, nil, nil)
+
+	ruleChanged := &events.RuleChanged{
+		ClientID: "client-1",
+		Action:   events.ActionRebuild,
+	}
+
+	if err := p.applyRuleChange(context.Background(), ruleChanged); err == nil {
+		t.Error("applyRuleChange() without a rebuilder expected error, got nil")
+	}
+}
+
 func TestApplyRuleChange_UnknownAction(t *testing.T) {
 	p := New(nil, nil, nil)
 
@@ -308,6 +341,43 @@ func TestProcessOneMessage_Success(t *testing.T) {
 	}
 }
 
+func TestProcessOneMessage_RecordsPropagation(t *testing.T) {
+	consumer := newFakeConsumer()
+	writer := newFakeSnapshotWriter()
+	propagation := &fakePropagation{}
+
+	publishedAt := time.Now().Add(-2 * time.Second)
+	consumer.AddMessage(&events.RuleChanged{
+		RuleID:        "rule-9",
+		ClientID:      "client-1",
+		Action:        events.ActionDeleted,
+		Version:       2,
+		PublishedAtMs: publishedAt.UnixMilli(),
+	})
+	// Events without a publish timestamp are not recorded
+	consumer.AddMessage(&events.RuleChanged{
+		RuleID:   "rule-10",
+		ClientID: "client-1",
+		Action:   events.ActionDeleted,
+		Version:  2,
+	})
+
+	p := New(consumer, newFakeRuleStore(), writer, WithPropagationRecorder(propagation))
+
+	for i := 0; i < 2; i++ {
+		if err := p.processOneMessage(context.Background()); err != nil {
+			t.Fatalf("processOneMessage() error = %v, want nil", err)
+		}
+	}
+
+	if len(propagation.stages) != 1 || propagation.stages[0] != "snapshot" || propagation.ruleIDs[0] != "rule-9" {
+		t.Fatalf("recorded propagation stages %v for rules %v, want snapshot for rule-9", propagation.stages, propagation.ruleIDs)
+	}
+	if propagation.latency < 2*time.Second {
+		t.Errorf("recorded propagation latency = %v, want at least 2s", propagation.latency)
+	}
+}
+
 func TestProcessOneMessage_ApplyError(t *testing.T) {
 	consumer := newFakeConsumer()
 	writer := newFakeSnapshotWriter()
@@ -351,6 +421,66 @@ func TestProcessOneMessage_ReadError(t *testing.T) {
 	}
 }
 
+func TestProcessOneMessage_DeadLettersUndecodableMessage(t *testing.T) {
+	consumer := newFakeConsumer()
+	consumer.decodeErr = errors.New("failed to unmarshal protobuf rule.changed event")
+	dlq := &fakeDLQ{maxFailures: 3}
+	metrics := newFakeMetrics()
+
+	p := New(consumer, nil, nil, WithMetrics(metrics), WithDeadLetterQueue(dlq))
+	if err := p.processOneMessage(context.Background()); err == nil {
+		t.Fatal("processOneMessage() expected error, got nil")
+	}
+
+	if len(dlq.rejected) != 1 {
+		t.Fatalf("rejected = %d, want 1", len(dlq.rejected))
+	}
+	if consumer.commitCalls != 1 {
+		t.Errorf("expected dead-lettered message to be committed, got %d commit calls", consumer.commitCalls)
+	}
+}
+
+func TestProcessOneMessage_DeadLettersInvalidEventAtOnce(t *testing.T) {
+	consumer := newFakeConsumer()
+	consumer.AddMessage(&events.RuleChanged{RuleID: "rule-1", ClientID: "client-1", Action: events.Action("UNKNOWN"), Version: 1})
+	dlq := &fakeDLQ{maxFailures: 3}
+
+	p := New(consumer, nil, newFakeSnapshotWriter(), WithDeadLetterQueue(dlq))
+	p.processOneMessage(context.Background())
+
+	if len(dlq.rejected) != 1 || dlq.failures != 0 {
+		t.Fatalf("rejected = %d, failures = %d; want the invalid event rejected without retries", len(dlq.rejected), dlq.failures)
+	}
+	if consumer.commitCalls != 1 {
+		t.Errorf("expected 1 commit call, got %d", consumer.commitCalls)
+	}
+}
+
+func TestProcessOneMessage_DeadLettersAfterMaxFailures(t *testing.T) {
+	consumer := newFakeConsumer()
+	for i := 0; i < 3; i++ {
+		consumer.AddMessage(&events.RuleChanged{RuleID: "rule-1", ClientID: "client-1", Action: events.ActionCreated, Version: 1})
+	}
+	dlq := &fakeDLQ{maxFailures: 3}
+
+	// No DB configured - every CREATED event fails to apply
+	p := New(consumer, nil, newFakeSnapshotWriter(), WithDeadLetterQueue(dlq))
+	for i := 0; i < 2; i++ {
+		p.processOneMessage(context.Background())
+		if consumer.commitCalls != 0 {
+			t.Fatalf("failure %d: message committed before reaching max failures", i+1)
+		}
+	}
+	p.processOneMessage(context.Background())
+
+	if len(dlq.deadLetters) != 1 {
+		t.Fatalf("deadLetters = %d, want 1", len(dlq.deadLetters))
+	}
+	if consumer.commitCalls != 1 {
+		t.Errorf("expected 1 commit call after dead-lettering, got %d", consumer.commitCalls)
+	}
+}
+
 func TestNoopMetrics(t *testing.T) {
 	m := NoopMetrics()
 
@@ -0,0 +1,287 @@
+// Package seed generates synthetic clients, rules and endpoints from a static config and
+// writes them to Postgres, to reproduce performance issues at a realistic scale.
+// Generation is deterministic: the same config always produces the same data.
+package seed
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+)
+
+// severities are the rule severities accepted by rule-service.
+var severities = []string{"LOW", "MEDIUM", "HIGH", "CRITICAL"}
+
+// endpointTypes are the endpoint types accepted by rule-service.
+var endpointTypes = map[string]bool{"email": true, "webhook": true, "slack": true}
+
+// wildcard matches any value of a rule field.
+const wildcard = "*"
+
+// maxDrawAttempts bounds the draws for a rule that is not a duplicate of one of its client's rules.
+const maxDrawAttempts = 100
+
+// Config describes the data to seed.
+//
+// Sources and Names are the number of distinct alert sources and names rules are drawn
+// from. The wildcard ratios are the fraction of rules with * for that field (a rule is
+// never wildcard on all three). EndpointMix weighs the endpoint types of each rule's
+// EndpointsPerRule endpoints.
+type Config struct {
+	ClientPrefix          string             `json:"client_prefix"`
+	Clients               int                `json:"clients"`
+	RulesPerClient        int                `json:"rules_per_client"`
+	Sources               int                `json:"sources"`
+	Names                 int                `json:"names"`
+	WildcardSeverityRatio float64            `json:"wildcard_severity_ratio,omitempty"`
+	WildcardSourceRatio   float64            `json:"wildcard_source_ratio,omitempty"`
+	WildcardNameRatio     float64            `json:"wildcard_name_ratio,omitempty"`
+	EndpointsPerRule      int                `json:"endpoints_per_rule"`
+	EndpointMix           map[string]float64 `json:"endpoint_mix"`
+	Seed                  int64              `json:"seed"`
+}
+
+// DefaultConfig returns the config used when no config file is given: a small data set
+// that seeds in seconds.
+func DefaultConfig() Config {
+	return Config{
+		ClientPrefix:        "seed-",
+		Clients:             100,
+		RulesPerClient:      50,
+		Sources:             20,
+		Names:               20,
+		WildcardSourceRatio: 0.1,
+		WildcardNameRatio:   0.1,
+		EndpointsPerRule:    2,
+		EndpointMix:         map[string]float64{"email": 0.5, "webhook": 0.3, "slack": 0.2},
+		Seed:                1,
+	}
+}
+
+// LoadConfig reads a config from a JSON file of the form:
+//
+//	{"clients": 1500, "rules_per_client": 300, "sources": 50, "names": 50, "wildcard_source_ratio": 0.1, "endpoints_per_rule": 2, "endpoint_mix": {"email": 1, "webhook": 1}}
+//
+// Fields missing from the file keep their DefaultConfig values.
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to read seed config file: %w", err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.EndpointMix = nil
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("failed to parse seed config file: %w", err)
+	}
+	if cfg.EndpointMix == nil {
+		cfg.EndpointMix = DefaultConfig().EndpointMix
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid seed config file: %w", err)
+	}
+	return cfg, nil
+}
+
+// Validate checks that the config describes data rule-service would accept.
+func (c Config) Validate() error {
+	if c.ClientPrefix == "" {
+		return fmt.Errorf("client_prefix cannot be empty")
+	}
+	if c.Clients <= 0 {
+		return fmt.Errorf("clients must be positive")
+	}
+	if c.RulesPerClient <= 0 {
+		return fmt.Errorf("rules_per_client must be positive")
+	}
+	if c.Sources <= 0 || c.Names <= 0 {
+		return fmt.Errorf("sources and names must be positive")
+	}
+	for field, ratio := range map[string]float64{
+		"wildcard_severity_ratio": c.WildcardSeverityRatio,
+		"wildcard_source_ratio":   c.WildcardSourceRatio,
+		"wildcard_name_ratio":     c.WildcardNameRatio,
+	} {
+		if ratio < 0 || ratio > 1 {
+			return fmt.Errorf("%s must be in [0, 1]", field)
+		}
+	}
+	if c.EndpointsPerRule < 0 {
+		return fmt.Errorf("endpoints_per_rule cannot be negative")
+	}
+	if c.EndpointsPerRule > 0 {
+		var total float64
+		for endpointType, weight := range c.EndpointMix {
+			if !endpointTypes[endpointType] {
+				return fmt.Errorf("endpoint_mix: unknown endpoint type %q", endpointType)
+			}
+			if weight < 0 {
+				return fmt.Errorf("endpoint_mix: weight of %q cannot be negative", endpointType)
+			}
+			total += weight
+		}
+		if total == 0 {
+			return fmt.Errorf("endpoint_mix must give at least one endpoint type a weight")
+		}
+	}
+	if combinations := c.combinations(); c.RulesPerClient > combinations {
+		return fmt.Errorf("rules_per_client (%d) exceeds the %d distinct rules the severities, sources, names and wildcards allow", c.RulesPerClient, combinations)
+	}
+	return nil
+}
+
+// combinations returns the number of distinct (severity, source, name) rules a client can have.
+func (c Config) combinations() int {
+	withWildcard := func(n int, ratio float64) int {
+		if ratio > 0 {
+			n++
+		}
+		return n
+	}
+	total := withWildcard(len(severities), c.WildcardSeverityRatio) *
+		withWildcard(c.Sources, c.WildcardSourceRatio) *
+		withWildcard(c.Names, c.WildcardNameRatio)
+	if c.WildcardSeverityRatio > 0 && c.WildcardSourceRatio > 0 && c.WildcardNameRatio > 0 {
+		total-- // all wildcards
+	}
+	return total
+}
+
+// Client is a generated client with its rules.
+type Client struct {
+	ClientID string
+	Name     string
+	Rules    []Rule
+}
+
+// Rule is a generated rule with its endpoints.
+type Rule struct {
+	RuleID    string
+	Severity  string
+	Source    string
+	Name      string
+	Endpoints []Endpoint
+}
+
+// Endpoint is a generated endpoint.
+type Endpoint struct {
+	Type  string
+	Value string
+}
+
+// Generate produces the clients, rules and endpoints described by cfg.
+// Rule IDs are UUIDs drawn from cfg.Seed, so reseeding recreates the same rules.
+func Generate(cfg Config) ([]Client, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	mix := newEndpointMix(cfg.EndpointMix)
+	clients := make([]Client, 0, cfg.Clients)
+	for i := 1; i <= cfg.Clients; i++ {
+		client := Client{
+			ClientID: fmt.Sprintf("%s%05d", cfg.ClientPrefix, i),
+			Name:     fmt.Sprintf("Seed Client %d", i),
+			Rules:    make([]Rule, 0, cfg.RulesPerClient),
+		}
+
+		seen := make(map[[3]string]bool, cfg.RulesPerClient)
+		for j := 1; j <= cfg.RulesPerClient; j++ {
+			rule, err := drawRule(rng, cfg, seen)
+			if err != nil {
+				return nil, fmt.Errorf("client %s: %w", client.ClientID, err)
+			}
+			rule.RuleID = newUUID(rng)
+			for k := 1; k <= cfg.EndpointsPerRule; k++ {
+				rule.Endpoints = append(rule.Endpoints, newEndpoint(mix.pick(rng), client.ClientID, j, k))
+			}
+			client.Rules = append(client.Rules, rule)
+		}
+		clients = append(clients, client)
+	}
+	return clients, nil
+}
+
+// drawRule draws a rule that is not in seen and adds it.
+func drawRule(rng *rand.Rand, cfg Config, seen map[[3]string]bool) (Rule, error) {
+	for attempt := 0; attempt < maxDrawAttempts; attempt++ {
+		rule := Rule{
+			Severity: severities[rng.Intn(len(severities))],
+			Source:   fmt.Sprintf("source-%03d", rng.Intn(cfg.Sources)+1),
+			Name:     fmt.Sprintf("name-%03d", rng.Intn(cfg.Names)+1),
+		}
+		if rng.Float64() < cfg.WildcardSeverityRatio {
+			rule.Severity = wildcard
+		}
+		if rng.Float64() < cfg.WildcardSourceRatio {
+			rule.Source = wildcard
+		}
+		if rng.Float64() < cfg.WildcardNameRatio {
+			rule.Name = wildcard
+		}
+
+		key := [3]string{rule.Severity, rule.Source, rule.Name}
+		if key == [3]string{wildcard, wildcard, wildcard} || seen[key] {
+			continue
+		}
+		seen[key] = true
+		return rule, nil
+	}
+	return Rule{}, fmt.Errorf("no distinct rule after %d draws; lower rules_per_client or add sources and names", maxDrawAttempts)
+}
+
+// newEndpoint returns the kth endpoint of a client's jth rule. Values are unique per rule.
+func newEndpoint(endpointType, clientID string, j, k int) Endpoint {
+	switch endpointType {
+	case "email":
+		return Endpoint{Type: endpointType, Value: fmt.Sprintf("%s-rule%d-%d@example.com", clientID, j, k)}
+	case "webhook":
+		return Endpoint{Type: endpointType, Value: fmt.Sprintf("https://webhook.example.com/%s/rule%d/%d", clientID, j, k)}
+	default:
+		return Endpoint{Type: endpointType, Value: fmt.Sprintf("#alerts-%s-rule%d-%d", clientID, j, k)}
+	}
+}
+
+// newUUID returns a version 4 UUID drawn from rng.
+func newUUID(rng *rand.Rand) string {
+	var b [16]byte
+	rng.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// endpointMix picks endpoint types by weight.
+type endpointMix struct {
+	types   []string
+	weights []float64
+	total   float64
+}
+
+func newEndpointMix(weights map[string]float64) endpointMix {
+	var mix endpointMix
+	for endpointType := range weights {
+		mix.types = append(mix.types, endpointType)
+	}
+	// Sorted so that the same config always picks the same types
+	sort.Strings(mix.types)
+	for _, endpointType := range mix.types {
+		mix.weights = append(mix.weights, weights[endpointType])
+		mix.total += weights[endpointType]
+	}
+	return mix
+}
+
+func (m endpointMix) pick(rng *rand.Rand) string {
+	r := rng.Float64() * m.total
+	for i, weight := range m.weights {
+		if r < weight {
+			return m.types[i]
+		}
+		r -= weight
+	}
+	return m.types[len(m.types)-1]
+}
@@ -0,0 +1,247 @@
+package seed
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr string
+	}{
+		{name: "default", modify: func(*Config) {}},
+		{name: "no endpoints needs no mix", modify: func(c *Config) { c.EndpointsPerRule = 0; c.EndpointMix = nil }},
+		{name: "empty prefix", modify: func(c *Config) { c.ClientPrefix = "" }, wantErr: "client_prefix"},
+		{name: "no clients", modify: func(c *Config) { c.Clients = 0 }, wantErr: "clients must be positive"},
+		{name: "no rules", modify: func(c *Config) { c.RulesPerClient = 0 }, wantErr: "rules_per_client"},
+		{name: "no sources", modify: func(c *Config) { c.Sources = 0 }, wantErr: "sources and names"},
+		{name: "ratio above 1", modify: func(c *Config) { c.WildcardNameRatio = 1.5 }, wantErr: "wildcard_name_ratio"},
+		{name: "unknown endpoint type", modify: func(c *Config) { c.EndpointMix = map[string]float64{"pager": 1} }, wantErr: "unknown endpoint type"},
+		{name: "zero mix", modify: func(c *Config) { c.EndpointMix = map[string]float64{"email": 0} }, wantErr: "at least one endpoint type"},
+		{
+			// 4 severities x 2 sources x 2 names
+			name: "more rules than combinations",
+			modify: func(c *Config) {
+				c.Sources, c.Names, c.WildcardSourceRatio, c.WildcardNameRatio, c.RulesPerClient = 2, 2, 0, 0, 17
+			},
+			wantErr: "exceeds the 16 distinct rules",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			tt.modify(&cfg)
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_Combinations(t *testing.T) {
+	cfg := Config{Sources: 2, Names: 3, WildcardSeverityRatio: 0.1, WildcardSourceRatio: 0.1, WildcardNameRatio: 0.1}
+	// (4+1) x (2+1) x (3+1) minus the all-wildcard rule
+	if got := cfg.combinations(); got != 59 {
+		t.Errorf("combinations() = %d, want 59", got)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "seed.json")
+	if err := os.WriteFile(path, []byte(`{"clients": 3, "rules_per_client": 5, "endpoint_mix": {"slack": 1}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.Clients != 3 || cfg.RulesPerClient != 5 {
+		t.Errorf("LoadConfig() clients = %d, rules_per_client = %d, want 3 and 5", cfg.Clients, cfg.RulesPerClient)
+	}
+	if cfg.ClientPrefix != "seed-" || cfg.Sources != DefaultConfig().Sources {
+		t.Errorf("LoadConfig() did not keep defaults for missing fields: %+v", cfg)
+	}
+	if !reflect.DeepEqual(cfg.EndpointMix, map[string]float64{"slack": 1}) {
+		t.Errorf("LoadConfig() endpoint_mix = %v, want only slack", cfg.EndpointMix)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"clients": -1}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("LoadConfig() with invalid config should return error")
+	}
+	if _, err := LoadConfig(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("LoadConfig() with missing file should return error")
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Clients = 20
+	cfg.RulesPerClient = 40
+	cfg.Sources = 5
+	cfg.Names = 5
+	cfg.WildcardSeverityRatio = 0.2
+	cfg.WildcardSourceRatio = 0.3
+	cfg.WildcardNameRatio = 0.3
+	cfg.EndpointsPerRule = 3
+
+	clients, err := Generate(cfg)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if len(clients) != cfg.Clients {
+		t.Fatalf("Generate() returned %d clients, want %d", len(clients), cfg.Clients)
+	}
+
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	ruleIDs := make(map[string]bool)
+	var rules, wildcardSources int
+	for _, client := range clients {
+		if !strings.HasPrefix(client.ClientID, cfg.ClientPrefix) {
+			t.Errorf("client ID %q lacks prefix %q", client.ClientID, cfg.ClientPrefix)
+		}
+		if len(client.Rules) != cfg.RulesPerClient {
+			t.Errorf("client %s has %d rules, want %d", client.ClientID, len(client.Rules), cfg.RulesPerClient)
+		}
+		seen := make(map[[3]string]bool)
+		for _, rule := range client.Rules {
+			rules++
+			key := [3]string{rule.Severity, rule.Source, rule.Name}
+			if seen[key] {
+				t.Errorf("client %s has duplicate rule %v", client.ClientID, key)
+			}
+			seen[key] = true
+			if key == [3]string{"*", "*", "*"} {
+				t.Errorf("client %s has an all-wildcard rule", client.ClientID)
+			}
+			if rule.Source == "*" {
+				wildcardSources++
+			}
+			if !uuid.MatchString(rule.RuleID) || ruleIDs[rule.RuleID] {
+				t.Errorf("rule ID %q is not a fresh UUID", rule.RuleID)
+			}
+			ruleIDs[rule.RuleID] = true
+			if len(rule.Endpoints) != cfg.EndpointsPerRule {
+				t.Errorf("rule %s has %d endpoints, want %d", rule.RuleID, len(rule.Endpoints), cfg.EndpointsPerRule)
+			}
+		}
+	}
+	// Duplicate draws are redrawn, so the ratio is approximate
+	if ratio := float64(wildcardSources) / float64(rules); ratio < 0.2 || ratio > 0.4 {
+		t.Errorf("wildcard source ratio = %.2f, want about 0.3", ratio)
+	}
+
+	again, err := Generate(cfg)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if !reflect.DeepEqual(clients, again) {
+		t.Error("Generate() is not deterministic for the same config")
+	}
+}
+
+func TestGenerate_EndpointMix(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Clients = 2
+	cfg.RulesPerClient = 10
+	cfg.EndpointMix = map[string]float64{"webhook": 1, "email": 0}
+
+	clients, err := Generate(cfg)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	for _, client := range clients {
+		for _, rule := range client.Rules {
+			values := make(map[string]bool)
+			for _, endpoint := range rule.Endpoints {
+				if endpoint.Type != "webhook" {
+					t.Errorf("endpoint type = %q, want only webhook", endpoint.Type)
+				}
+				if values[endpoint.Value] {
+					t.Errorf("rule %s has duplicate endpoint %q", rule.RuleID, endpoint.Value)
+				}
+				values[endpoint.Value] = true
+			}
+		}
+	}
+}
+
+func TestClean(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock DB: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec(`DELETE FROM clients WHERE starts_with\(client_id, \$1\)`).
+		WithArgs("seed-").
+		WillReturnResult(sqlmock.NewResult(0, 7))
+
+	deleted, err := Clean(context.Background(), db, "seed-")
+	if err != nil {
+		t.Fatalf("Clean() error = %v", err)
+	}
+	if deleted != 7 {
+		t.Errorf("Clean() = %d, want 7", deleted)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestInsert(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock DB: %v", err)
+	}
+	defer db.Close()
+
+	// 1 client, batchRows+1 rules (two batches), 1 endpoint on the last rule
+	client := Client{ClientID: "seed-00001", Name: "Seed Client 1"}
+	for i := 0; i <= batchRows; i++ {
+		client.Rules = append(client.Rules, Rule{RuleID: "rule", Severity: "HIGH", Source: "api", Name: "timeout"})
+	}
+	client.Rules[batchRows].Endpoints = []Endpoint{{Type: "email", Value: "a@example.com"}}
+
+	mock.ExpectExec(`INSERT INTO clients \(client_id, name, created_at, updated_at\) VALUES \(\$1, \$2, NOW\(\), NOW\(\)\)$`).
+		WithArgs("seed-00001", "Seed Client 1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO rules \(rule_id, client_id, severity, source, name, enabled, version, created_at, updated_at\) VALUES`).
+		WillReturnResult(sqlmock.NewResult(0, batchRows))
+	mock.ExpectExec(`INSERT INTO rules .* VALUES \(\$1, \$2, \$3, \$4, \$5, TRUE, 1, NOW\(\), NOW\(\)\)$`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO endpoints \(rule_id, type, value, enabled, created_at, updated_at\) VALUES \(\$1, \$2, \$3, TRUE, NOW\(\), NOW\(\)\)$`).
+		WithArgs("rule", "email", "a@example.com").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	counts, err := Insert(context.Background(), db, []Client{client})
+	if err != nil {
+		t.Fatalf("Insert() error = %v", err)
+	}
+	if want := (Counts{Clients: 1, Rules: batchRows + 1, Endpoints: 1}); counts != want {
+		t.Errorf("Insert() = %+v, want %+v", counts, want)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
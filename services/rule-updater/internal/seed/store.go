@@ -0,0 +1,129 @@
+package seed
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// batchRows is the number of rows per multi-row INSERT, well below Postgres' 65535 parameter limit.
+const batchRows = 1000
+
+// Counts is the number of rows seeded.
+type Counts struct {
+	Clients   int
+	Rules     int
+	Endpoints int
+}
+
+// Clean deletes the clients whose ID starts with prefix, and with them their rules and endpoints.
+// Returns the number of clients deleted.
+func Clean(ctx context.Context, db *sql.DB, prefix string) (int64, error) {
+	result, err := db.ExecContext(ctx, `DELETE FROM clients WHERE starts_with(client_id, $1)`, prefix)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete seeded clients: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return deleted, nil
+}
+
+// Insert writes clients, then their rules, then the rules' endpoints, in batches.
+// Rules are inserted enabled, with no description, exclusions or conditions.
+func Insert(ctx context.Context, db *sql.DB, clients []Client) (Counts, error) {
+	var counts Counts
+
+	clientRows := newBatcher(db, "clients", "client_id, name", "created_at, updated_at", "NOW(), NOW()")
+	for _, client := range clients {
+		if err := clientRows.add(ctx, client.ClientID, client.Name); err != nil {
+			return counts, err
+		}
+	}
+	if err := clientRows.flush(ctx); err != nil {
+		return counts, err
+	}
+	counts.Clients = clientRows.inserted
+	slog.Info("Seeded clients", "count", counts.Clients)
+
+	ruleRows := newBatcher(db, "rules", "rule_id, client_id, severity, source, name", "enabled, version, created_at, updated_at", "TRUE, 1, NOW(), NOW()")
+	for _, client := range clients {
+		for _, rule := range client.Rules {
+			if err := ruleRows.add(ctx, rule.RuleID, client.ClientID, rule.Severity, rule.Source, rule.Name); err != nil {
+				return counts, err
+			}
+		}
+	}
+	if err := ruleRows.flush(ctx); err != nil {
+		return counts, err
+	}
+	counts.Rules = ruleRows.inserted
+	slog.Info("Seeded rules", "count", counts.Rules)
+
+	endpointRows := newBatcher(db, "endpoints", "rule_id, type, value", "enabled, created_at, updated_at", "TRUE, NOW(), NOW()")
+	for _, client := range clients {
+		for _, rule := range client.Rules {
+			for _, endpoint := range rule.Endpoints {
+				if err := endpointRows.add(ctx, rule.RuleID, endpoint.Type, endpoint.Value); err != nil {
+					return counts, err
+				}
+			}
+		}
+	}
+	if err := endpointRows.flush(ctx); err != nil {
+		return counts, err
+	}
+	counts.Endpoints = endpointRows.inserted
+	slog.Info("Seeded endpoints", "count", counts.Endpoints)
+
+	return counts, nil
+}
+
+// batcher buffers rows of one table and inserts them batchRows at a time.
+// Every row gets the same constants for the fixed columns.
+type batcher struct {
+	db       *sql.DB
+	table    string
+	columns  string
+	fixed    string // columns set to constants
+	values   string // the constants
+	args     []any
+	rows     []string
+	inserted int
+}
+
+func newBatcher(db *sql.DB, table, columns, fixed, values string) *batcher {
+	return &batcher{db: db, table: table, columns: columns, fixed: fixed, values: values}
+}
+
+// add buffers a row, inserting the buffered rows once there are batchRows of them.
+func (b *batcher) add(ctx context.Context, row ...any) error {
+	placeholders := make([]string, len(row))
+	for i := range row {
+		placeholders[i] = fmt.Sprintf("$%d", len(b.args)+i+1)
+	}
+	b.rows = append(b.rows, fmt.Sprintf("(%s, %s)", strings.Join(placeholders, ", "), b.values))
+	b.args = append(b.args, row...)
+	if len(b.rows) >= batchRows {
+		return b.flush(ctx)
+	}
+	return nil
+}
+
+// flush inserts the buffered rows.
+func (b *batcher) flush(ctx context.Context) error {
+	if len(b.rows) == 0 {
+		return nil
+	}
+	query := fmt.Sprintf("INSERT INTO %s (%s, %s) VALUES %s", b.table, b.columns, b.fixed, strings.Join(b.rows, ", "))
+	if _, err := b.db.ExecContext(ctx, query, b.args...); err != nil {
+		return fmt.Errorf("failed to insert %s: %w", b.table, err)
+	}
+	b.inserted += len(b.rows)
+	b.rows = b.rows[:0]
+	b.args = b.args[:0]
+	return nil
+}
@@ -3,11 +3,21 @@ package snapshot
 
 import (
 	"rule-updater/internal/database"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
 )
 
-// BuildSnapshot builds a snapshot from a list of enabled rules.
+// BuildSnapshot builds a snapshot from a list of enabled rules, matching fields exactly.
 // It creates dictionaries for compression and inverted indexes for fast matching.
 func BuildSnapshot(rules []*database.Rule) *Snapshot {
+	return BuildNormalizedSnapshot(rules, shared.Normalization{})
+}
+
+// BuildNormalizedSnapshot builds a snapshot from a list of enabled rules whose severity,
+// source and name are normalized with n. The normalization is recorded in the snapshot.
+func BuildNormalizedSnapshot(rules []*database.Rule, n shared.Normalization) *Snapshot {
+	rules = normalizeRules(rules, n)
+
 	// Build dictionaries (string -> int mapping for compression)
 	severityDict := make(map[string]int)
 	sourceDict := make(map[string]int)
@@ -49,10 +59,7 @@ func BuildSnapshot(rules []*database.Rule) *Snapshot {
 		byName[rule.Name] = append(byName[rule.Name], ruleInt)
 
 		// Store rule info
-		rulesMap[ruleInt] = RuleInfo{
-			RuleID:   rule.RuleID,
-			ClientID: rule.ClientID,
-		}
+		rulesMap[ruleInt] = newRuleInfo(rule)
 
 		ruleInt++
 	}
@@ -66,5 +73,62 @@ func BuildSnapshot(rules []*database.Rule) *Snapshot {
 		BySource:      bySource,
 		ByName:        byName,
 		Rules:         rulesMap,
+		Normalization: n.String(),
+	}
+}
+
+// newRuleInfo returns the rule info stored for rule.
+func newRuleInfo(rule *database.Rule) RuleInfo {
+	return RuleInfo{
+		RuleID:         rule.RuleID,
+		ClientID:       rule.ClientID,
+		ExcludeSources: rule.ExcludeSources,
+		ExcludeNames:   rule.ExcludeNames,
+		Conditions:     rule.Conditions,
+	}
+}
+
+// normalizeRules returns copies of rules with normalized severity, source, name, exclusions
+// and condition values.
+func normalizeRules(rules []*database.Rule, n shared.Normalization) []*database.Rule {
+	if n == (shared.Normalization{}) {
+		return rules
+	}
+	normalized := make([]*database.Rule, len(rules))
+	for i, rule := range rules {
+		normalized[i] = normalizeRule(rule, n)
+	}
+	return normalized
+}
+
+// normalizeRule returns a copy of rule with normalized severity, source, name, exclusions
+// and condition values. Condition keys are matched exactly and left as they are.
+func normalizeRule(rule *database.Rule, n shared.Normalization) *database.Rule {
+	r := *rule
+	r.Severity = n.Apply(r.Severity)
+	r.Source = n.Apply(r.Source)
+	r.Name = n.Apply(r.Name)
+	r.ExcludeSources = normalizeValues(r.ExcludeSources, n)
+	r.ExcludeNames = normalizeValues(r.ExcludeNames, n)
+	if len(r.Conditions) > 0 {
+		conditions := make([]database.ContextCondition, len(r.Conditions))
+		for i, c := range r.Conditions {
+			c.Value = n.Apply(c.Value)
+			conditions[i] = c
+		}
+		r.Conditions = conditions
+	}
+	return &r
+}
+
+// normalizeValues returns a normalized copy of values.
+func normalizeValues(values []string, n shared.Normalization) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	normalized := make([]string, len(values))
+	for i, v := range values {
+		normalized[i] = n.Apply(v)
 	}
+	return normalized
 }
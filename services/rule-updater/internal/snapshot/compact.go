@@ -0,0 +1,183 @@
+// Package snapshot handles building and writing rule snapshots to Redis.
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+	"github.com/redis/go-redis/v9"
+)
+
+// CompactionStats counts the garbage a compaction removed from a snapshot.
+type CompactionStats struct {
+	DictEntriesRemoved int // dictionary values no rule uses anymore
+	IndexRefsRemoved   int // index entries pointing at rules that no longer exist
+	RulesRenumbered    int // rules whose ruleInt changed to close gaps
+}
+
+// Empty reports whether the compaction found nothing to remove.
+func (s CompactionStats) Empty() bool {
+	return s == CompactionStats{}
+}
+
+// CompactionResult describes a compaction written by Writer.Compact.
+type CompactionResult struct {
+	CompactionStats
+	Version     int64 // version of the compacted snapshot; 0 if nothing was written
+	BytesBefore int
+	BytesAfter  int
+}
+
+// Compact returns a copy of the snapshot without garbage: dictionaries are rebuilt from the
+// values still indexed, index entries of removed rules are dropped, and ruleInts are
+// renumbered 1..n in their current order. Matching is unchanged.
+func (snap *Snapshot) Compact() (*Snapshot, CompactionStats) {
+	var stats CompactionStats
+
+	// Renumber rules in ruleInt order, so the compacted snapshot is deterministic
+	oldInts := make([]int, 0, len(snap.Rules))
+	for ruleInt := range snap.Rules {
+		oldInts = append(oldInts, ruleInt)
+	}
+	sort.Ints(oldInts)
+
+	renumbered := make(map[int]int, len(oldInts))
+	rules := make(map[int]RuleInfo, len(oldInts))
+	for i, oldInt := range oldInts {
+		newInt := i + 1
+		if newInt != oldInt {
+			stats.RulesRenumbered++
+		}
+		renumbered[oldInt] = newInt
+		rules[newInt] = snap.Rules[oldInt]
+	}
+
+	compacted := &Snapshot{
+		SchemaVersion: snap.SchemaVersion,
+		Version:       snap.Version,
+		BySeverity:    compactIndex(snap.BySeverity, renumbered, &stats),
+		BySource:      compactIndex(snap.BySource, renumbered, &stats),
+		ByName:        compactIndex(snap.ByName, renumbered, &stats),
+		Rules:         rules,
+		Normalization: snap.Normalization,
+	}
+	compacted.SeverityDict = compactDict(snap.SeverityDict, compacted.BySeverity, &stats)
+	compacted.SourceDict = compactDict(snap.SourceDict, compacted.BySource, &stats)
+	compacted.NameDict = compactDict(snap.NameDict, compacted.ByName, &stats)
+
+	return compacted, stats
+}
+
+// compactIndex rewrites an inverted index with renumbered ruleInts, dropping entries of
+// rules that no longer exist and keys left without rules.
+func compactIndex(index map[string][]int, renumbered map[int]int, stats *CompactionStats) map[string][]int {
+	compacted := make(map[string][]int, len(index))
+	for key, ruleInts := range index {
+		list := make([]int, 0, len(ruleInts))
+		for _, ruleInt := range ruleInts {
+			newInt, ok := renumbered[ruleInt]
+			if !ok {
+				stats.IndexRefsRemoved++
+				continue
+			}
+			list = append(list, newInt)
+		}
+		if len(list) > 0 {
+			sort.Ints(list)
+			compacted[key] = list
+		}
+	}
+	return compacted
+}
+
+// compactDict rebuilds a dictionary with only the values used by index, numbered 1..n in
+// sorted order.
+func compactDict(dict map[string]int, index map[string][]int, stats *CompactionStats) map[string]int {
+	keys := make([]string, 0, len(index))
+	for key := range index {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	compacted := make(map[string]int, len(keys))
+	for i, key := range keys {
+		compacted[key] = i + 1
+	}
+	for key := range dict {
+		if _, ok := compacted[key]; !ok {
+			stats.DictEntriesRemoved++
+		}
+	}
+	return compacted
+}
+
+// Compact rewrites the snapshot in Redis without garbage (see Snapshot.Compact). The write
+// is fenced like WriteSnapshot and bumps the version, so the evaluator reloads the compacted
+// snapshot; nothing is written if there is no garbage.
+// Returns ErrVersionConflict if a rule change landed while compacting.
+func (w *Writer) Compact(ctx context.Context) (*CompactionResult, error) {
+	// Load the version first: anything written after this point fences out our write
+	version, err := w.GetVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := w.client.Get(ctx, SnapshotKey).Bytes()
+	if err == redis.Nil {
+		return &CompactionResult{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get snapshot from Redis: %w", err)
+	}
+
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal snapshot: %w", err)
+	}
+
+	compacted, stats := snap.Compact()
+	result := &CompactionResult{CompactionStats: stats, BytesBefore: len(data), BytesAfter: len(data)}
+	if stats.Empty() {
+		return result, nil
+	}
+
+	compacted.Version = version + 1
+	compactedData, err := json.Marshal(compacted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+
+	newVersion, err := w.writeSnapshotScript.Run(ctx, w.client, w.keys(),
+		compactedData,
+		version,
+		w.historySize,
+		shared.RuleDeltaCompact,
+		w.deltaSize,
+	).Int64()
+	if err != nil {
+		return nil, fmt.Errorf("failed to write compacted snapshot to Redis: %w", err)
+	}
+	if newVersion < 0 {
+		return nil, fmt.Errorf("%w: expected version %d", ErrVersionConflict, version)
+	}
+
+	result.Version = newVersion
+	result.BytesAfter = len(compactedData)
+
+	slog.Info("Snapshot compacted",
+		"version", newVersion,
+		"rules_count", len(compacted.Rules),
+		"dict_entries_removed", stats.DictEntriesRemoved,
+		"index_refs_removed", stats.IndexRefsRemoved,
+		"rules_renumbered", stats.RulesRenumbered,
+		"bytes_before", result.BytesBefore,
+		"bytes_after", result.BytesAfter,
+	)
+	w.announcer.AnnounceRuleSnapshotChanged(ctx, newVersion)
+
+	return result, nil
+}
@@ -6,6 +6,49 @@ import "github.com/redis/go-redis/v9"
 // Lua scripts for direct Redis updates
 
 const (
+	// recordHistoryLua defines set_snapshot, which every script writes the snapshot with: it
+	// stores the snapshot JSON and, at <key>:checksum, its SHA-1 for readers to verify (see
+	// shared.RuleSnapshotChecksum). It also defines record_history, which every script calls
+	// after writing the snapshot: it keeps a copy of the snapshot at <snapshot key>:v<version>,
+	// listed in the history sorted set (KEYS[3]), and drops the oldest copies beyond keep
+	// (0 keeps none). record_delta records the change the script made as a JSON entry in the
+	// deltas sorted set (KEYS[4], see shared.RuleSnapshotDeltasKey), scored by the version
+	// written, and drops the oldest entries beyond keep (0 records none).
+	recordHistoryLua = `
+		local function set_snapshot(key, snapshot_json)
+			redis.call('SET', key, snapshot_json)
+			redis.call('SET', key .. ':checksum', redis.sha1hex(snapshot_json))
+		end
+
+		local function record_history(snapshot_key, history_key, version, snapshot_json, keep)
+			if not keep or keep <= 0 then
+				return
+			end
+			set_snapshot(snapshot_key .. ':v' .. version, snapshot_json)
+			redis.call('ZADD', history_key, version, version)
+			local excess = redis.call('ZCARD', history_key) - keep
+			if excess > 0 then
+				local expired = redis.call('ZRANGE', history_key, 0, excess - 1)
+				for _, old_version in ipairs(expired) do
+					redis.call('DEL', snapshot_key .. ':v' .. old_version, snapshot_key .. ':v' .. old_version .. ':checksum')
+				end
+				redis.call('ZREMRANGEBYRANK', history_key, 0, excess - 1)
+			end
+		end
+
+		local function record_delta(delta_key, version, delta, keep)
+			if not keep or keep <= 0 then
+				return
+			end
+			delta.version = version
+			redis.call('ZADD', delta_key, version, cjson.encode(delta))
+			local excess = redis.call('ZCARD', delta_key) - keep
+			if excess > 0 then
+				redis.call('ZREMRANGEBYRANK', delta_key, 0, excess - 1)
+			end
+		end
+	`
+
 	// addRuleScript adds or updates a rule in the snapshot JSON directly in Redis
 	addRuleScript = `
 		local snapshot_key = KEYS[1]
@@ -15,15 +58,27 @@ const (
 		local severity = ARGV[3]
 		local source = ARGV[4]
 		local name = ARGV[5]
+		local normalization = ARGV[6]
+		local exclude_sources = ARGV[7]
+		local exclude_names = ARGV[8]
+		local conditions = ARGV[9]
+		local history_size = tonumber(ARGV[10])
+		local delta_size = tonumber(ARGV[11])
 		
 		-- Load current snapshot
 		local snapshot_json = redis.call('GET', snapshot_key)
+		local created = false
 		if not snapshot_json then
 			-- Create empty snapshot
-			snapshot_json = '{"schema_version":1,"severity_dict":{},"source_dict":{},"name_dict":{},"by_severity":{},"by_source":{},"by_name":{},"rules":{}}'
+			snapshot_json = '{"schema_version":1,"version":0,"severity_dict":{},"source_dict":{},"name_dict":{},"by_severity":{},"by_source":{},"by_name":{},"rules":{}}'
+			created = true
 		end
 		
 		local snapshot = cjson.decode(snapshot_json)
+		if created and normalization then
+			-- Fields passed in are normalized; record how, for the evaluator
+			snapshot.normalization = normalization
+		end
 		
 		-- Find existing ruleInt for this rule_id
 		local existing_rule_int = nil
@@ -120,16 +175,45 @@ const (
 		end
 		table.insert(snapshot.by_name[name], rule_int)
 		
-		-- Add to rules map
-		snapshot.rules[tostring(rule_int)] = {
+		-- Add to rules map, with exclusion lists and context conditions (JSON arrays) if any
+		local rule_info = {
 			rule_id = rule_id,
 			client_id = client_id
 		}
+		if exclude_sources and exclude_sources ~= '' then
+			rule_info.exclude_sources = cjson.decode(exclude_sources)
+		end
+		if exclude_names and exclude_names ~= '' then
+			rule_info.exclude_names = cjson.decode(exclude_names)
+		end
+		if conditions and conditions ~= '' then
+			rule_info.conditions = cjson.decode(conditions)
+		end
+		snapshot.rules[tostring(rule_int)] = rule_info
 		
-		-- Write back and increment version
-		local updated_json = cjson.encode(snapshot)
-		redis.call('SET', snapshot_key, updated_json)
-		return redis.call('INCR', version_key)
+		-- Increment version and embed it in the snapshot
+		local next_version = redis.call('INCR', version_key)
+		local snapshot_version = tonumber(snapshot.version) or 0
+		if snapshot_version >= next_version then
+			next_version = snapshot_version + 1
+			redis.call('SET', version_key, next_version)
+		end
+		snapshot.version = next_version
+		local encoded = cjson.encode(snapshot)
+		set_snapshot(snapshot_key, encoded)
+		record_history(snapshot_key, KEYS[3], next_version, encoded, history_size)
+		record_delta(KEYS[4], next_version, {
+			op = 'put',
+			rule_id = rule_id,
+			client_id = client_id,
+			severity = severity,
+			source = source,
+			name = name,
+			exclude_sources = rule_info.exclude_sources,
+			exclude_names = rule_info.exclude_names,
+			conditions = rule_info.conditions
+		}, delta_size)
+		return next_version
 	`
 
 	// removeRuleScript removes a rule from the snapshot JSON directly in Redis
@@ -137,6 +221,8 @@ const (
 		local snapshot_key = KEYS[1]
 		local version_key = KEYS[2]
 		local rule_id = ARGV[1]
+		local history_size = tonumber(ARGV[2])
+		local delta_size = tonumber(ARGV[3])
 		
 		-- Load current snapshot
 		local snapshot_json = redis.call('GET', snapshot_key)
@@ -194,14 +280,50 @@ const (
 		-- Remove from rules map
 		snapshot.rules[tostring(rule_int)] = nil
 		
-		-- Write back and increment version
-		local updated_json = cjson.encode(snapshot)
-		redis.call('SET', snapshot_key, updated_json)
-		return redis.call('INCR', version_key)
+		-- Increment version and embed it in the snapshot
+		local next_version = redis.call('INCR', version_key)
+		local snapshot_version = tonumber(snapshot.version) or 0
+		if snapshot_version >= next_version then
+			next_version = snapshot_version + 1
+			redis.call('SET', version_key, next_version)
+		end
+		snapshot.version = next_version
+		local encoded = cjson.encode(snapshot)
+		set_snapshot(snapshot_key, encoded)
+		record_history(snapshot_key, KEYS[3], next_version, encoded, history_size)
+		record_delta(KEYS[4], next_version, {op = 'remove', rule_id = rule_id}, delta_size)
+		return next_version
+	`
+
+	// writeSnapshotScript replaces the whole snapshot only if {rules}:version still equals the
+	// version the writer loaded (compare-and-set), so a slow full rebuild cannot overwrite a
+	// newer snapshot. The delta entry has the op passed in (snapshot or compact).
+	// Returns the new version, or -1 on conflict.
+	writeSnapshotScript = `
+		local snapshot_key = KEYS[1]
+		local version_key = KEYS[2]
+		local snapshot_json = ARGV[1]
+		local expected_version = tonumber(ARGV[2])
+		local history_size = tonumber(ARGV[3])
+		local delta_op = ARGV[4]
+		local delta_size = tonumber(ARGV[5])
+
+		local current_version = tonumber(redis.call('GET', version_key)) or 0
+		if current_version ~= expected_version then
+			return -1
+		end
+
+		set_snapshot(snapshot_key, snapshot_json)
+		redis.call('SET', version_key, expected_version + 1)
+		record_history(snapshot_key, KEYS[3], expected_version + 1, snapshot_json, history_size)
+		record_delta(KEYS[4], expected_version + 1, {op = delta_op}, delta_size)
+		return expected_version + 1
 	`
 )
 
 // newLuaScripts initializes the Lua scripts for the Writer.
-func newLuaScripts() (*redis.Script, *redis.Script) {
-	return redis.NewScript(addRuleScript), redis.NewScript(removeRuleScript)
+func newLuaScripts() (add, remove, write *redis.Script) {
+	return redis.NewScript(recordHistoryLua + addRuleScript),
+		redis.NewScript(recordHistoryLua + removeRuleScript),
+		redis.NewScript(recordHistoryLua + writeSnapshotScript)
 }
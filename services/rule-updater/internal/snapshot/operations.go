@@ -81,10 +81,7 @@ func (snap *Snapshot) AddRule(rule *database.Rule) error {
 	snap.addToIndexes(rule.Severity, rule.Source, rule.Name, ruleInt)
 
 	// Store rule info
-	snap.Rules[ruleInt] = RuleInfo{
-		RuleID:   rule.RuleID,
-		ClientID: rule.ClientID,
-	}
+	snap.Rules[ruleInt] = newRuleInfo(rule)
 
 	return nil
 }
@@ -0,0 +1,69 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"rule-updater/internal/database"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// maxRebuildAttempts bounds retries when a concurrent rule change fences out a full rebuild.
+const maxRebuildAttempts = 3
+
+// RuleSource provides the rules a full rebuild is built from.
+type RuleSource interface {
+	GetAllEnabledRules(ctx context.Context) ([]*database.Rule, error)
+}
+
+// Rebuild queries all enabled rules from the database, builds a snapshot,
+// and writes it to Redis with an incremented version.
+// The write is fenced on the version read before querying, so if a rule change lands
+// in the meantime the rebuild starts over instead of overwriting the newer snapshot.
+func Rebuild(ctx context.Context, rules RuleSource, writer *Writer, normalization shared.Normalization) error {
+	var err error
+	for attempt := 1; attempt <= maxRebuildAttempts; attempt++ {
+		err = rebuildOnce(ctx, rules, writer, normalization)
+		if !errors.Is(err, ErrVersionConflict) {
+			return err
+		}
+		slog.Warn("Snapshot changed during rebuild, retrying", "attempt", attempt)
+	}
+	return err
+}
+
+// rebuildOnce performs a single fenced rebuild.
+func rebuildOnce(ctx context.Context, source RuleSource, writer *Writer, normalization shared.Normalization) error {
+	// Load the version first: anything written after this point fences out our write
+	version, err := writer.GetVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Query all enabled rules
+	rules, err := source.GetAllEnabledRules(ctx)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Found enabled rules", "count", len(rules))
+
+	// Build snapshot from rules
+	snap := BuildNormalizedSnapshot(rules, normalization)
+
+	// Write snapshot to Redis (this also increments the version)
+	if err := writer.WriteSnapshot(ctx, snap, version); err != nil {
+		return err
+	}
+
+	slog.Info("Snapshot rebuilt successfully",
+		"rules_count", len(rules),
+		"severity_dict_size", len(snap.SeverityDict),
+		"source_dict_size", len(snap.SourceDict),
+		"name_dict_size", len(snap.NameDict),
+	)
+
+	return nil
+}
@@ -1,11 +1,17 @@
 // Package snapshot handles building and writing rule snapshots to Redis.
 package snapshot
 
+import (
+	"rule-updater/internal/database"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
 const (
 	// SnapshotKey is the Redis key where the rule snapshot is stored.
-	SnapshotKey = "rules:snapshot"
+	SnapshotKey = shared.RuleSnapshotKey
 	// VersionKey is the Redis key where the rule version is stored.
-	VersionKey = "rules:version"
+	VersionKey = shared.RuleVersionKey
 	// SchemaVersion is the current schema version for the snapshot format.
 	SchemaVersion = 1
 )
@@ -14,6 +20,8 @@ const (
 // This matches the structure expected by the evaluator.
 type Snapshot struct {
 	SchemaVersion int                    `json:"schema_version"`
+	// Version is the {rules}:version this snapshot was written at, so readers can reject older content.
+	Version       int64                  `json:"version"`
 	SeverityDict  map[string]int         `json:"severity_dict"`
 	SourceDict    map[string]int         `json:"source_dict"`
 	NameDict      map[string]int         `json:"name_dict"`
@@ -21,12 +29,20 @@ type Snapshot struct {
 	BySource      map[string][]int        `json:"by_source"`   // source -> []ruleInt
 	ByName        map[string][]int        `json:"by_name"`     // name -> []ruleInt
 	Rules         map[int]RuleInfo        `json:"rules"`       // ruleInt -> {rule_id, client_id}
+	// Normalization is how rule fields were normalized (see shared.ParseNormalization); the
+	// evaluator normalizes alerts the same way. Empty for snapshots that predate normalization.
+	Normalization string `json:"normalization,omitempty"`
 }
 
-// RuleInfo contains the rule ID and client ID for a given ruleInt.
+// RuleInfo contains the rule ID and client ID for a given ruleInt, the alert sources
+// and names the rule's wildcards do not match, and the alert context conditions the rule
+// requires (values normalized like the indexes).
 type RuleInfo struct {
-	RuleID   string `json:"rule_id"`
-	ClientID string `json:"client_id"`
+	RuleID         string                      `json:"rule_id"`
+	ClientID       string                      `json:"client_id"`
+	ExcludeSources []string                    `json:"exclude_sources,omitempty"`
+	ExcludeNames   []string                    `json:"exclude_names,omitempty"`
+	Conditions     []database.ContextCondition `json:"conditions,omitempty"`
 }
 
 // newEmptySnapshot creates a new empty snapshot with initialized maps.
@@ -3,9 +3,12 @@ package snapshot
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"testing"
+	"time"
 
 	"rule-updater/internal/database"
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -168,6 +171,40 @@ func TestBuildSnapshot(t *testing.T) {
 	}
 }
 
+func TestBuildNormalizedSnapshot(t *testing.T) {
+	rules := []*database.Rule{
+		{RuleID: "rule-1", ClientID: "client-1", Severity: "HIGH", Source: " API ", Name: "Timeout", Enabled: true,
+			Conditions: []database.ContextCondition{{Key: "Region", Op: "==", Value: " EU-West-1 "}}},
+		{RuleID: "rule-2", ClientID: "client-2", Severity: "HIGH", Source: "api", Name: "*", Enabled: true, ExcludeNames: []string{" Heartbeat "}},
+	}
+
+	snap := BuildNormalizedSnapshot(rules, shared.Normalization{Trim: true, FoldCase: true})
+	if snap.Normalization != "trim,fold" {
+		t.Errorf("Normalization = %q, want trim,fold", snap.Normalization)
+	}
+	source := shared.Normalization{Trim: true, FoldCase: true}.Apply("api")
+	if got := snap.BySource[source]; len(got) != 2 {
+		t.Errorf("BySource[%q] = %v, want both rules", source, got)
+	}
+	if _, ok := snap.ByName["*"]; !ok {
+		t.Error("wildcard name was normalized away")
+	}
+	if got := snap.Rules[2].ExcludeNames; len(got) != 1 || got[0] != "heartbeat" {
+		t.Errorf("Rules[2].ExcludeNames = %v, want [heartbeat]", got)
+	}
+	// Condition values are normalized, keys are matched exactly
+	if got := snap.Rules[1].Conditions; len(got) != 1 || got[0] != (database.ContextCondition{Key: "Region", Op: "==", Value: "eu-west-1"}) {
+		t.Errorf("Rules[1].Conditions = %+v, want Region == eu-west-1", got)
+	}
+	if rules[0].Source != " API " || rules[1].ExcludeNames[0] != " Heartbeat " || rules[0].Conditions[0].Value != " EU-West-1 " {
+		t.Error("BuildNormalizedSnapshot() modified the input rules")
+	}
+
+	if exact := BuildSnapshot(rules); exact.Normalization != shared.NormalizeExact || len(exact.BySource) != 2 {
+		t.Errorf("BuildSnapshot() = %q with sources %v, want exact matching", exact.Normalization, exact.BySource)
+	}
+}
+
 func TestSnapshot_findRuleInt(t *testing.T) {
 	snap := &Snapshot{
 		Rules: map[int]RuleInfo{
@@ -597,7 +634,7 @@ func TestWriter_WriteSnapshot_Integration(t *testing.T) {
 		},
 	}
 
-	if err := writer.WriteSnapshot(ctx, snap); err != nil {
+	if err := writer.WriteSnapshot(ctx, snap, 0); err != nil {
 		t.Fatalf("WriteSnapshot() error = %v, want nil", err)
 	}
 
@@ -632,6 +669,104 @@ func TestWriter_WriteSnapshot_Integration(t *testing.T) {
 	client.Del(ctx, SnapshotKey, VersionKey)
 }
 
+func TestWriter_WriteSnapshot_VersionFencing_Integration(t *testing.T) {
+	client := redis.NewClient(&redis.Options{
+		Addr: "localhost:6379",
+	})
+	defer client.Close()
+
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("Skipping integration test: Redis not available: %v", err)
+	}
+
+	writer := NewWriter(client)
+	client.Del(ctx, SnapshotKey, VersionKey)
+	defer client.Del(ctx, SnapshotKey, VersionKey)
+
+	// A rebuild that loaded version 0 starts building...
+	stale := newEmptySnapshot()
+
+	// ...while a direct rule change moves the version forward
+	rule := &database.Rule{RuleID: "rule-1", ClientID: "client-1", Severity: "HIGH", Source: "service-a", Name: "disk-full", Enabled: true}
+	if err := writer.AddRuleDirect(ctx, rule); err != nil {
+		t.Fatalf("AddRuleDirect() error = %v, want nil", err)
+	}
+
+	err := writer.WriteSnapshot(ctx, stale, 0)
+	if !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("WriteSnapshot() with stale version error = %v, want ErrVersionConflict", err)
+	}
+
+	snap, err := writer.LoadSnapshot(ctx)
+	if err != nil {
+		t.Fatalf("LoadSnapshot() error = %v, want nil", err)
+	}
+	if len(snap.Rules) != 1 {
+		t.Errorf("stale write overwrote newer snapshot: Rules count = %v, want 1", len(snap.Rules))
+	}
+	if snap.Version != 1 {
+		t.Errorf("AddRuleDirect() embedded Version = %v, want 1", snap.Version)
+	}
+
+	// Writing with the current version succeeds and embeds the next version
+	if err := writer.WriteSnapshot(ctx, stale, 1); err != nil {
+		t.Fatalf("WriteSnapshot() with current version error = %v, want nil", err)
+	}
+	snap, err = writer.LoadSnapshot(ctx)
+	if err != nil {
+		t.Fatalf("LoadSnapshot() error = %v, want nil", err)
+	}
+	if snap.Version != 2 {
+		t.Errorf("WriteSnapshot() embedded Version = %v, want 2", snap.Version)
+	}
+}
+
+func TestWriter_AnnouncesWrites_Integration(t *testing.T) {
+	client := redis.NewClient(&redis.Options{
+		Addr: "localhost:6379",
+	})
+	defer client.Close()
+
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("Skipping integration test: Redis not available: %v", err)
+	}
+
+	writer := NewWriter(client)
+	client.Del(ctx, SnapshotKey, VersionKey)
+	defer client.Del(ctx, SnapshotKey, VersionKey)
+
+	sub := shared.NewRuleSnapshotStore(client).SubscribeRuleSnapshotChanged(ctx)
+	defer sub.Close()
+	if _, err := sub.Receive(ctx); err != nil { // subscription confirmation
+		t.Fatalf("Receive() error = %v", err)
+	}
+
+	if err := writer.WriteSnapshot(ctx, newEmptySnapshot(), 0); err != nil {
+		t.Fatalf("WriteSnapshot() error = %v, want nil", err)
+	}
+	rule := &database.Rule{RuleID: "rule-1", ClientID: "client-1", Severity: "HIGH", Source: "service-a", Name: "disk-full", Enabled: true}
+	if err := writer.AddRuleDirect(ctx, rule); err != nil {
+		t.Fatalf("AddRuleDirect() error = %v, want nil", err)
+	}
+	if err := writer.RemoveRuleDirect(ctx, "rule-1"); err != nil {
+		t.Fatalf("RemoveRuleDirect() error = %v, want nil", err)
+	}
+
+	receiveCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	for _, want := range []string{"1", "2", "3"} {
+		msg, err := sub.ReceiveMessage(receiveCtx)
+		if err != nil {
+			t.Fatalf("ReceiveMessage() error = %v", err)
+		}
+		if msg.Channel != shared.RuleSnapshotChangedChannel || msg.Payload != want {
+			t.Errorf("announcement = %s %q, want %s %q", msg.Channel, msg.Payload, shared.RuleSnapshotChangedChannel, want)
+		}
+	}
+}
+
 func TestWriter_GetVersion_Integration(t *testing.T) {
 	client := redis.NewClient(&redis.Options{
 		Addr: "localhost:6379",
@@ -671,6 +806,49 @@ func TestWriter_GetVersion_Integration(t *testing.T) {
 	client.Del(ctx, VersionKey)
 }
 
+func TestWriter_MigrateVersion_Integration(t *testing.T) {
+	client := redis.NewClient(&redis.Options{
+		Addr: "localhost:6379",
+	})
+	defer client.Close()
+
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("Skipping integration test: Redis not available: %v", err)
+	}
+
+	writer := NewWriter(client)
+
+	// Clean up before test
+	client.Del(ctx, VersionKey, shared.LegacyRuleVersionKey)
+
+	// No legacy version: nothing to migrate
+	if migrated, err := writer.MigrateVersion(ctx); err != nil || migrated != 0 {
+		t.Fatalf("MigrateVersion() = %v, %v, want 0, nil", migrated, err)
+	}
+
+	// Legacy version is carried over
+	client.Set(ctx, shared.LegacyRuleVersionKey, 17, 0)
+	if migrated, err := writer.MigrateVersion(ctx); err != nil || migrated != 17 {
+		t.Fatalf("MigrateVersion() = %v, %v, want 17, nil", migrated, err)
+	}
+	if version, _ := writer.GetVersion(ctx); version != 17 {
+		t.Errorf("GetVersion() after migration = %v, want 17", version)
+	}
+
+	// An existing version is never overwritten
+	client.Set(ctx, VersionKey, 20, 0)
+	if migrated, err := writer.MigrateVersion(ctx); err != nil || migrated != 0 {
+		t.Fatalf("MigrateVersion() = %v, %v, want 0, nil", migrated, err)
+	}
+	if version, _ := writer.GetVersion(ctx); version != 20 {
+		t.Errorf("GetVersion() = %v, want 20", version)
+	}
+
+	// Clean up
+	client.Del(ctx, VersionKey, shared.LegacyRuleVersionKey)
+}
+
 func TestWriter_LoadSnapshot_Integration(t *testing.T) {
 	client := redis.NewClient(&redis.Options{
 		Addr: "localhost:6379",
@@ -716,7 +894,7 @@ func TestWriter_LoadSnapshot_Integration(t *testing.T) {
 		},
 	}
 
-	if err := writer.WriteSnapshot(ctx, testSnap); err != nil {
+	if err := writer.WriteSnapshot(ctx, testSnap, 0); err != nil {
 		t.Fatalf("WriteSnapshot() error = %v, want nil", err)
 	}
 
@@ -758,6 +936,9 @@ func TestWriter_AddRuleDirect_Integration(t *testing.T) {
 		Source:   "service-a",
 		Name:     "disk-full",
 		Enabled:  true,
+		Conditions: []database.ContextCondition{
+			{Key: "region", Op: "==", Value: "eu-west-1"},
+		},
 	}
 
 	if err := writer.AddRuleDirect(ctx, rule); err != nil {
@@ -774,6 +955,9 @@ func TestWriter_AddRuleDirect_Integration(t *testing.T) {
 	for _, ruleInfo := range snap.Rules {
 		if ruleInfo.RuleID == rule.RuleID {
 			found = true
+			if len(ruleInfo.Conditions) != 1 || ruleInfo.Conditions[0] != rule.Conditions[0] {
+				t.Errorf("AddRuleDirect() conditions = %+v, want %+v", ruleInfo.Conditions, rule.Conditions)
+			}
 			break
 		}
 	}
@@ -959,3 +1143,219 @@ func TestRuleInfo_JSONRoundTrip(t *testing.T) {
 		t.Errorf("ClientID = %v, want %v", unmarshaled.ClientID, ruleInfo.ClientID)
 	}
 }
+
+func TestSnapshot_Compact(t *testing.T) {
+	snap := &Snapshot{
+		SchemaVersion: SchemaVersion,
+		Version:       9,
+		// "db" and "slow" are left over from removed rules
+		SeverityDict: map[string]int{"HIGH": 1, "LOW": 2},
+		SourceDict:   map[string]int{"api": 1, "db": 2},
+		NameDict:     map[string]int{"timeout": 1, "slow": 2, "*": 3},
+		BySeverity:   map[string][]int{"HIGH": {3, 7}, "LOW": {9}},
+		BySource:     map[string][]int{"api": {3, 7}},
+		ByName:       map[string][]int{"timeout": {7}, "*": {3}},
+		Rules: map[int]RuleInfo{
+			3: {RuleID: "rule-a", ClientID: "client-1"},
+			7: {RuleID: "rule-b", ClientID: "client-2", ExcludeSources: []string{"staging"}},
+		},
+		Normalization: "trim,fold",
+	}
+
+	compacted, stats := snap.Compact()
+
+	want := CompactionStats{DictEntriesRemoved: 3, IndexRefsRemoved: 1, RulesRenumbered: 2}
+	if stats != want {
+		t.Errorf("Compact() stats = %+v, want %+v", stats, want)
+	}
+	if len(compacted.Rules) != 2 || compacted.Rules[1].RuleID != "rule-a" || compacted.Rules[2].RuleID != "rule-b" {
+		t.Errorf("Compact() rules = %v, want rule-a as 1 and rule-b as 2", compacted.Rules)
+	}
+	if got := compacted.Rules[2].ExcludeSources; len(got) != 1 || got[0] != "staging" {
+		t.Errorf("Compact() dropped exclusions: %v", got)
+	}
+	if got := compacted.BySource["api"]; len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Errorf("BySource[api] = %v, want [1 2]", got)
+	}
+	if _, ok := compacted.BySeverity["LOW"]; ok {
+		t.Error("index key of a removed rule was kept")
+	}
+	if len(compacted.SeverityDict) != 1 || len(compacted.SourceDict) != 1 || len(compacted.NameDict) != 2 {
+		t.Errorf("Compact() dictionaries = %v %v %v", compacted.SeverityDict, compacted.SourceDict, compacted.NameDict)
+	}
+	if compacted.Normalization != snap.Normalization || compacted.Version != snap.Version {
+		t.Error("Compact() lost the snapshot's normalization or version")
+	}
+	if len(snap.Rules) != 2 || snap.Rules[3].RuleID != "rule-a" {
+		t.Error("Compact() modified the original snapshot")
+	}
+
+	if _, stats := compacted.Compact(); !stats.Empty() {
+		t.Errorf("compacting a compacted snapshot = %+v, want nothing", stats)
+	}
+}
+
+func TestWriter_Compact_Integration(t *testing.T) {
+	client := redis.NewClient(&redis.Options{
+		Addr: "localhost:6379",
+	})
+	defer client.Close()
+
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("Skipping integration test: Redis not available: %v", err)
+	}
+
+	writer := NewWriter(client)
+	client.Del(ctx, SnapshotKey, VersionKey)
+
+	for _, id := range []string{"rule-1", "rule-2"} {
+		rule := &database.Rule{RuleID: id, ClientID: "client-1", Severity: "HIGH", Source: id, Name: "timeout", Enabled: true}
+		if err := writer.AddRuleDirect(ctx, rule); err != nil {
+			t.Fatalf("AddRuleDirect() error = %v", err)
+		}
+	}
+	if err := writer.RemoveRuleDirect(ctx, "rule-1"); err != nil {
+		t.Fatalf("RemoveRuleDirect() error = %v", err)
+	}
+
+	result, err := writer.Compact(ctx)
+	if err != nil {
+		t.Fatalf("Compact() error = %v", err)
+	}
+	if result.Version != 4 || result.BytesAfter >= result.BytesBefore {
+		t.Errorf("Compact() = %+v, want version 4 and a smaller snapshot", result)
+	}
+
+	snap, err := writer.LoadSnapshot(ctx)
+	if err != nil {
+		t.Fatalf("LoadSnapshot() error = %v", err)
+	}
+	if len(snap.SourceDict) != 1 || snap.Rules[1].RuleID != "rule-2" {
+		t.Errorf("compacted snapshot = %+v, want only rule-2 as ruleInt 1", snap)
+	}
+
+	// Nothing left to compact: no write, no version bump
+	if result, err := writer.Compact(ctx); err != nil || result.Version != 0 {
+		t.Errorf("second Compact() = %+v, %v, want no write", result, err)
+	}
+}
+
+func TestWriter_History_Integration(t *testing.T) {
+	client := redis.NewClient(&redis.Options{
+		Addr: "localhost:6379",
+	})
+	defer client.Close()
+
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("Skipping integration test: Redis not available: %v", err)
+	}
+
+	writer := NewWriter(client).WithHistory(2)
+	client.Del(ctx, SnapshotKey, VersionKey, shared.RuleSnapshotHistoryKey,
+		shared.RuleSnapshotKeyAt(1), shared.RuleSnapshotKeyAt(2), shared.RuleSnapshotKeyAt(3))
+
+	for _, id := range []string{"rule-1", "rule-2"} {
+		rule := &database.Rule{RuleID: id, ClientID: "client-1", Severity: "HIGH", Source: id, Name: "timeout", Enabled: true}
+		if err := writer.AddRuleDirect(ctx, rule); err != nil {
+			t.Fatalf("AddRuleDirect() error = %v", err)
+		}
+	}
+	if err := writer.RemoveRuleDirect(ctx, "rule-1"); err != nil {
+		t.Fatalf("RemoveRuleDirect() error = %v", err)
+	}
+
+	store := shared.NewRuleSnapshotStore(client)
+	versions, err := store.ListRuleSnapshotVersions(ctx)
+	if err != nil {
+		t.Fatalf("ListRuleSnapshotVersions() error = %v", err)
+	}
+	if len(versions) != 2 || versions[0].Version != 3 || versions[1].Version != 2 {
+		t.Fatalf("kept versions = %+v, want 3 and 2", versions)
+	}
+	if _, err := store.GetRuleSnapshot(ctx, 1); !errors.Is(err, shared.ErrRuleSnapshotNotFound) {
+		t.Errorf("GetRuleSnapshot(1) error = %v, want ErrRuleSnapshotNotFound", err)
+	}
+
+	// The kept copy is the snapshot as written
+	current, err := client.Get(ctx, SnapshotKey).Bytes()
+	if err != nil {
+		t.Fatalf("Get(%s) error = %v", SnapshotKey, err)
+	}
+	kept, err := store.GetRuleSnapshot(ctx, 3)
+	if err != nil {
+		t.Fatalf("GetRuleSnapshot(3) error = %v", err)
+	}
+	if string(kept) != string(current) {
+		t.Errorf("kept version 3 = %s, want the current snapshot %s", kept, current)
+	}
+}
+
+func TestWriter_Deltas_Integration(t *testing.T) {
+	client := redis.NewClient(&redis.Options{
+		Addr: "localhost:6379",
+	})
+	defer client.Close()
+
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("Skipping integration test: Redis not available: %v", err)
+	}
+
+	writer := NewWriter(client).WithDeltas(3)
+	client.Del(ctx, SnapshotKey, VersionKey, shared.RuleSnapshotDeltasKey)
+	defer client.Del(ctx, SnapshotKey, VersionKey, shared.RuleSnapshotDeltasKey)
+
+	if err := writer.WriteSnapshot(ctx, BuildSnapshot(nil), 0); err != nil {
+		t.Fatalf("WriteSnapshot() error = %v", err)
+	}
+	rule := &database.Rule{
+		RuleID: "rule-1", ClientID: "client-1", Severity: "HIGH", Source: "service-a", Name: "disk-full", Enabled: true,
+		Conditions: []database.ContextCondition{{Key: "region", Op: "==", Value: "eu-west-1"}},
+	}
+	if err := writer.AddRuleDirect(ctx, rule); err != nil {
+		t.Fatalf("AddRuleDirect() error = %v", err)
+	}
+	if err := writer.RemoveRuleDirect(ctx, "rule-1"); err != nil {
+		t.Fatalf("RemoveRuleDirect() error = %v", err)
+	}
+	if _, err := writer.Compact(ctx); err != nil {
+		t.Fatalf("Compact() error = %v", err)
+	}
+
+	type delta struct {
+		Version    int64                       `json:"version"`
+		Op         string                      `json:"op"`
+		RuleID     string                      `json:"rule_id"`
+		Severity   string                      `json:"severity"`
+		Conditions []database.ContextCondition `json:"conditions"`
+	}
+	entries, err := client.ZRange(ctx, shared.RuleSnapshotDeltasKey, 0, -1).Result()
+	if err != nil {
+		t.Fatalf("ZRange(%s) error = %v", shared.RuleSnapshotDeltasKey, err)
+	}
+	var deltas []delta
+	for _, entry := range entries {
+		var d delta
+		if err := json.Unmarshal([]byte(entry), &d); err != nil {
+			t.Fatalf("delta %s: %v", entry, err)
+		}
+		deltas = append(deltas, d)
+	}
+
+	// The snapshot write (version 1) is dropped beyond the last 3 entries
+	if len(deltas) != 3 {
+		t.Fatalf("deltas = %+v, want 3", deltas)
+	}
+	if d := deltas[0]; d.Version != 2 || d.Op != shared.RuleDeltaPut || d.RuleID != "rule-1" || d.Severity != "HIGH" ||
+		len(d.Conditions) != 1 || d.Conditions[0] != rule.Conditions[0] {
+		t.Errorf("deltas[0] = %+v, want put of rule-1 at version 2", d)
+	}
+	if d := deltas[1]; d.Version != 3 || d.Op != shared.RuleDeltaRemove || d.RuleID != "rule-1" {
+		t.Errorf("deltas[1] = %+v, want remove of rule-1 at version 3", d)
+	}
+	if d := deltas[2]; d.Version != 4 || d.Op != shared.RuleDeltaCompact {
+		t.Errorf("deltas[2] = %+v, want compact at version 4", d)
+	}
+}
@@ -4,18 +4,24 @@ package snapshot
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 
 	"rule-updater/internal/database"
 
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
 	"github.com/redis/go-redis/v9"
 )
 
+// ErrVersionConflict is returned by WriteSnapshot when {rules}:version changed after the
+// writer loaded it, meaning a newer snapshot was written in the meantime.
+var ErrVersionConflict = errors.New("snapshot version conflict")
+
 // SnapshotWriter defines the interface for snapshot write operations.
 // This interface is implemented by Writer and can be used for testing.
 type SnapshotWriter interface {
-	WriteSnapshot(ctx context.Context, snapshot *Snapshot) error
+	WriteSnapshot(ctx context.Context, snapshot *Snapshot, expectedVersion int64) error
 	AddRuleDirect(ctx context.Context, rule *database.Rule) error
 	RemoveRuleDirect(ctx context.Context, ruleID string) error
 	GetVersion(ctx context.Context) (int64, error)
@@ -24,53 +30,100 @@ type SnapshotWriter interface {
 
 // Writer handles building and writing snapshots to Redis.
 type Writer struct {
-	client           *redis.Client
-	addRuleScript    *redis.Script
-	removeRuleScript *redis.Script
+	client              redis.UniversalClient
+	addRuleScript       *redis.Script
+	removeRuleScript    *redis.Script
+	writeSnapshotScript *redis.Script
+	// normalization is applied to rules added with AddRuleDirect.
+	normalization shared.Normalization
+	// historySize is how many snapshot versions are kept (see WithHistory).
+	historySize int
+	// deltaSize is how many change entries are kept (see WithDeltas).
+	deltaSize int
+	// announcer publishes every write on shared.RuleSnapshotChangedChannel.
+	announcer *shared.RuleSnapshotStore
 }
 
 // NewWriter creates a new snapshot writer with the given Redis client.
-func NewWriter(client *redis.Client) *Writer {
-	addScript, removeScript := newLuaScripts()
+func NewWriter(client redis.UniversalClient) *Writer {
+	addScript, removeScript, writeScript := newLuaScripts()
 	return &Writer{
-		client:           client,
-		addRuleScript:    addScript,
-		removeRuleScript: removeScript,
+		client:              client,
+		addRuleScript:       addScript,
+		removeRuleScript:    removeScript,
+		writeSnapshotScript: writeScript,
+		historySize:         shared.DefaultRuleSnapshotHistory,
+		deltaSize:           shared.DefaultRuleSnapshotDeltas,
+		announcer:           shared.NewRuleSnapshotStore(client),
 	}
 }
 
-// WriteSnapshot writes a snapshot to Redis and increments the version.
-// This is an atomic operation: both snapshot and version are updated together.
-func (w *Writer) WriteSnapshot(ctx context.Context, snapshot *Snapshot) error {
+// WithHistory sets how many snapshot versions are kept: every write also copies the
+// snapshot to {rules}:snapshot:v<version> and drops the copies beyond the last size versions.
+// 0 keeps none. Defaults to shared.DefaultRuleSnapshotHistory.
+func (w *Writer) WithHistory(size int) *Writer {
+	w.historySize = size
+	return w
+}
+
+// WithDeltas sets how many change entries are kept: every write also records the rule it put
+// or removed, or that the whole snapshot was rewritten, in shared.RuleSnapshotDeltasKey, so the
+// evaluator can apply the changes instead of reloading the snapshot. Entries beyond the last
+// size versions are dropped; an evaluator further behind reloads the snapshot. 0 records none.
+// Defaults to shared.DefaultRuleSnapshotDeltas.
+func (w *Writer) WithDeltas(size int) *Writer {
+	w.deltaSize = size
+	return w
+}
+
+// keys returns the keys every script writes: the snapshot, the version, the history and the deltas.
+func (w *Writer) keys() []string {
+	return []string{SnapshotKey, VersionKey, shared.RuleSnapshotHistoryKey, shared.RuleSnapshotDeltasKey}
+}
+
+// WithNormalization normalizes the fields of rules added with AddRuleDirect. It must match
+// the normalization of the snapshots passed to WriteSnapshot (see BuildNormalizedSnapshot).
+func (w *Writer) WithNormalization(n shared.Normalization) *Writer {
+	w.normalization = n
+	return w
+}
+
+// WriteSnapshot writes a snapshot to Redis and increments the version, fenced on expectedVersion:
+// the write only happens if {rules}:version still equals the version the caller loaded before
+// building the snapshot. The new version is embedded in the snapshot payload and announced on
+// shared.RuleSnapshotChangedChannel, like every write.
+// Returns ErrVersionConflict if another writer got there first.
+func (w *Writer) WriteSnapshot(ctx context.Context, snapshot *Snapshot, expectedVersion int64) error {
+	snapshot.Version = expectedVersion + 1
+
 	// Serialize snapshot to JSON
 	data, err := json.Marshal(snapshot)
 	if err != nil {
 		return fmt.Errorf("failed to marshal snapshot: %w", err)
 	}
 
-	// Use Redis pipeline to atomically update both snapshot and version
-	pipe := w.client.Pipeline()
-	pipe.Set(ctx, SnapshotKey, data, 0) // No expiration
-	pipe.Incr(ctx, VersionKey)          // Increment version
-
-	_, err = pipe.Exec(ctx)
+	// Compare-and-set both snapshot and version atomically
+	version, err := w.writeSnapshotScript.Run(ctx, w.client, w.keys(),
+		data,
+		expectedVersion,
+		w.historySize,
+		shared.RuleDeltaSnapshot,
+		w.deltaSize,
+	).Int64()
 	if err != nil {
 		return fmt.Errorf("failed to write snapshot to Redis: %w", err)
 	}
-
-	// Get the new version for logging
-	version, err := w.client.Get(ctx, VersionKey).Int64()
-	if err != nil {
-		// This shouldn't happen, but log it if it does
-		slog.Warn("Failed to get version after write", "error", err)
-	} else {
-		slog.Info("Snapshot written to Redis",
-			"schema_version", snapshot.SchemaVersion,
-			"rules_count", len(snapshot.Rules),
-			"version", version,
-		)
+	if version < 0 {
+		return fmt.Errorf("%w: expected version %d", ErrVersionConflict, expectedVersion)
 	}
 
+	slog.Info("Snapshot written to Redis",
+		"schema_version", snapshot.SchemaVersion,
+		"rules_count", len(snapshot.Rules),
+		"version", version,
+	)
+	w.announcer.AnnounceRuleSnapshotChanged(ctx, version)
+
 	return nil
 }
 
@@ -88,6 +141,32 @@ func (w *Writer) GetVersion(ctx context.Context) (int64, error) {
 	return version, nil
 }
 
+// MigrateVersion carries the version over from the key used before the snapshot keys were
+// hash-tagged (shared.LegacyRuleVersionKey), so numbering continues where it stopped and the
+// evaluator, which rejects older versions, accepts the next snapshot. Does nothing once the
+// current version key exists. Returns the carried-over version, or 0.
+func (w *Writer) MigrateVersion(ctx context.Context) (int64, error) {
+	legacy, err := w.client.Get(ctx, shared.LegacyRuleVersionKey).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get legacy version from Redis: %w", err)
+	}
+	set, err := w.client.SetNX(ctx, VersionKey, legacy, 0).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to migrate version in Redis: %w", err)
+	}
+	if !set {
+		return 0, nil
+	}
+	slog.Info("Rule version carried over from legacy key",
+		"legacy_key", shared.LegacyRuleVersionKey,
+		"version", legacy,
+	)
+	return legacy, nil
+}
+
 // LoadSnapshot loads the current snapshot from Redis.
 // Returns an empty snapshot if no snapshot exists yet.
 func (w *Writer) LoadSnapshot(ctx context.Context) (*Snapshot, error) {
@@ -116,14 +195,34 @@ func (w *Writer) AddRuleDirect(ctx context.Context, rule *database.Rule) error {
 		return nil
 	}
 
+	rule = normalizeRule(rule, w.normalization)
+	excludeSources, err := encodeExclusions(rule.ExcludeSources)
+	if err != nil {
+		return err
+	}
+	excludeNames, err := encodeExclusions(rule.ExcludeNames)
+	if err != nil {
+		return err
+	}
+	conditions, err := encodeConditions(rule.Conditions)
+	if err != nil {
+		return err
+	}
+
 	// Execute Lua script to add rule directly in Redis
 	// The script handles finding/assigning ruleInt internally
-	version, err := w.addRuleScript.Run(ctx, w.client, []string{SnapshotKey, VersionKey},
+	version, err := w.addRuleScript.Run(ctx, w.client, w.keys(),
 		rule.RuleID,
 		rule.ClientID,
 		rule.Severity,
 		rule.Source,
 		rule.Name,
+		w.normalization.String(),
+		excludeSources,
+		excludeNames,
+		conditions,
+		w.historySize,
+		w.deltaSize,
 	).Int64()
 
 	if err != nil {
@@ -134,16 +233,45 @@ func (w *Writer) AddRuleDirect(ctx context.Context, rule *database.Rule) error {
 		"rule_id", rule.RuleID,
 		"version", version,
 	)
+	w.announcer.AnnounceRuleSnapshotChanged(ctx, version)
 
 	return nil
 }
 
+// encodeExclusions encodes an exclusion list as a JSON array for the add rule script,
+// or as an empty string if there are no exclusions.
+func encodeExclusions(values []string) (string, error) {
+	if len(values) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal rule exclusions: %w", err)
+	}
+	return string(data), nil
+}
+
+// encodeConditions encodes context conditions as a JSON array for the add rule script,
+// or as an empty string if there are none.
+func encodeConditions(conditions []database.ContextCondition) (string, error) {
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(conditions)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal rule conditions: %w", err)
+	}
+	return string(data), nil
+}
+
 // RemoveRuleDirect removes a rule directly from Redis using a Lua script.
 // This avoids loading the entire snapshot into Go memory.
 func (w *Writer) RemoveRuleDirect(ctx context.Context, ruleID string) error {
 	// Execute Lua script to remove rule directly in Redis
-	version, err := w.removeRuleScript.Run(ctx, w.client, []string{SnapshotKey, VersionKey},
+	version, err := w.removeRuleScript.Run(ctx, w.client, w.keys(),
 		ruleID,
+		w.historySize,
+		w.deltaSize,
 	).Int64()
 
 	if err != nil {
@@ -162,6 +290,7 @@ func (w *Writer) RemoveRuleDirect(ctx context.Context, ruleID string) error {
 		"rule_id", ruleID,
 		"version", version,
 	)
+	w.announcer.AnnounceRuleSnapshotChanged(ctx, version)
 
 	return nil
 }
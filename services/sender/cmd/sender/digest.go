@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"sender/internal/database"
+	"sender/internal/events"
+)
+
+// loadGrouped loads the notifications a digest lists into notification.Grouped, so the payload
+// builders can list them. Does nothing for a notification that is not a digest.
+func loadGrouped(ctx context.Context, deps *processorDeps, ready *events.NotificationReady, notification *database.Notification) error {
+	if len(ready.GroupedNotificationIDs) == 0 {
+		return nil
+	}
+	grouped, err := deps.db.GetNotificationsByIDs(ctx, ready.GroupedNotificationIDs)
+	if err != nil {
+		return fmt.Errorf("failed to fetch grouped notifications: %w", err)
+	}
+	notification.Grouped = grouped
+	return nil
+}
+
+// updateGrouped moves a digest's grouped notifications along with the digest. Grouped
+// notifications that left the digest's path (e.g. were acknowledged meanwhile) are left as
+// they are. Best effort: a failure is logged and counted, the digest's own status stands.
+func updateGrouped(ctx context.Context, deps *processorDeps, notification *database.Notification, status database.NotificationStatus) {
+	if len(notification.Grouped) == 0 {
+		return
+	}
+	ids := make([]string, len(notification.Grouped))
+	for i, grouped := range notification.Grouped {
+		ids[i] = grouped.NotificationID
+	}
+	updated, err := deps.db.UpdateNotificationsStatus(ctx, ids, status)
+	if err != nil {
+		logAndRecordError(deps.metrics, "Failed to update grouped notification statuses",
+			"notification_id", notification.NotificationID, "status", status, "error", err)
+		return
+	}
+	slog.Debug("Updated grouped notification statuses",
+		"notification_id", notification.NotificationID,
+		"status", status,
+		"updated", updated,
+		"grouped", len(ids),
+	)
+}
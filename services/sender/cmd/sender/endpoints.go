@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	"sender/internal/endpointcache"
+	"sender/internal/endpointconsumer"
+	"sender/internal/ruleconsumer"
+)
+
+// invalidateOnRuleChanges drops a rule's cached endpoints whenever a rule.changed event is
+// read for it, until ctx is cancelled.
+func invalidateOnRuleChanges(ctx context.Context, rules *ruleconsumer.Consumer, cache *endpointcache.Cache) {
+	invalidateEndpoints(ctx, "rule.changed", cache, func(ctx context.Context) (string, error) {
+		changed, err := rules.ReadMessage(ctx)
+		if err != nil {
+			return "", err
+		}
+		slog.Debug("Rule changed, invalidating cached endpoints",
+			"rule_id", changed.RuleID,
+			"client_id", changed.ClientID,
+			"version", changed.Version,
+		)
+		return changed.RuleID, nil
+	})
+}
+
+// invalidateOnEndpointChanges drops a rule's cached endpoints whenever an endpoint.changed
+// event is read for one of its endpoints, until ctx is cancelled.
+func invalidateOnEndpointChanges(ctx context.Context, endpoints *endpointconsumer.Consumer, cache *endpointcache.Cache) {
+	invalidateEndpoints(ctx, "endpoint.changed", cache, func(ctx context.Context) (string, error) {
+		changed, err := endpoints.ReadMessage(ctx)
+		if err != nil {
+			return "", err
+		}
+		slog.Debug("Endpoint changed, invalidating cached endpoints",
+			"rule_id", changed.RuleID,
+			"endpoint_id", changed.EndpointID,
+			"action", changed.Action,
+		)
+		return changed.RuleID, nil
+	})
+}
+
+// invalidateEndpoints invalidates the rule returned by each call to next until ctx is cancelled.
+func invalidateEndpoints(ctx context.Context, topic string, cache *endpointcache.Cache, next func(context.Context) (string, error)) {
+	slog.Info("Starting endpoint cache invalidation", "topic", topic)
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Endpoint cache invalidation stopped", "topic", topic)
+			return
+		default:
+			ruleID, err := next(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				slog.Error("Failed to read endpoint cache invalidation event", "topic", topic, "error", err)
+				continue
+			}
+			cache.Invalidate(ruleID)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	"sender/internal/database"
+	"sender/internal/metrics"
+)
+
+// journalWriter is the subset of the database used for the notification journal.
+type journalWriter interface {
+	RecordNotificationEvent(ctx context.Context, notificationID, eventType, endpointType string, eventErr error) error
+	RecordNotificationDelivery(ctx context.Context, delivery database.Delivery) error
+}
+
+// deliveryJournal records sender state transitions in the notification_events journal, and the
+// outcome of each endpoint delivery in notification_deliveries.
+// Writes are best effort: failures are logged and counted but never fail processing.
+// A nil *deliveryJournal records nothing.
+type deliveryJournal struct {
+	writer  journalWriter
+	metrics metrics.Recorder
+}
+
+// ObserveDelivery records one send attempt (after retries) to an endpoint.
+func (j *deliveryJournal) ObserveDelivery(ctx context.Context, notification *database.Notification, endpointType string, err error) {
+	j.record(ctx, notification.NotificationID, database.EventSendAttempt, endpointType, err)
+}
+
+// ObserveSimulation records a simulated (dry-run) send to an endpoint.
+func (j *deliveryJournal) ObserveSimulation(ctx context.Context, notification *database.Notification, endpointType string) {
+	j.record(ctx, notification.NotificationID, database.EventSendSimulated, endpointType, nil)
+}
+
+// ObserveDeliveryDetail records the outcome of one endpoint delivery.
+func (j *deliveryJournal) ObserveDeliveryDetail(ctx context.Context, delivery database.Delivery) {
+	if j == nil || j.writer == nil {
+		return
+	}
+	if err := j.writer.RecordNotificationDelivery(ctx, delivery); err != nil {
+		slog.Warn("Failed to record notification delivery",
+			"notification_id", delivery.NotificationID,
+			"channel", delivery.Channel,
+			"error", err,
+		)
+		j.metrics.RecordJournalError()
+	}
+}
+
+// record appends a single event to the notification's journal.
+func (j *deliveryJournal) record(ctx context.Context, notificationID, eventType, endpointType string, eventErr error) {
+	if j == nil || j.writer == nil {
+		return
+	}
+	if err := j.writer.RecordNotificationEvent(ctx, notificationID, eventType, endpointType, eventErr); err != nil {
+		slog.Warn("Failed to record notification event",
+			"notification_id", notificationID,
+			"event_type", eventType,
+			"error", err,
+		)
+		j.metrics.RecordJournalError()
+	}
+}
@@ -2,7 +2,10 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,9 +16,17 @@ import (
 	"sender/internal/events"
 	"sender/internal/metrics"
 	"sender/internal/sender"
+	"sender/internal/shard"
+	"sender/internal/statestore"
+	"sender/internal/workqueue"
+
+	kafkautil "github.com/afikmenashe/alerting-platform/pkg/kafka"
+	pkgmetrics "github.com/afikmenashe/alerting-platform/pkg/metrics"
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
 )
 
-const workerCount = 10
+// defaultWorkers is the default number of notifications delivered concurrently.
+const defaultWorkers = 10
 
 // work represents a unit of work for the worker pool.
 type work struct {
@@ -23,32 +34,88 @@ type work struct {
 	msg   *kafka.Message
 }
 
+// endpointLoader returns the endpoints of a notification's rules: the endpoint cache, or the
+// database when the cache is disabled.
+type endpointLoader interface {
+	GetEndpointsByRuleIDs(ctx context.Context, ruleIDs []string) (map[string][]database.Endpoint, error)
+}
+
 // processorDeps holds all dependencies needed for notification processing.
 // This makes testing and dependency injection cleaner.
 type processorDeps struct {
-	consumer *consumer.Consumer
-	db       *database.DB
-	sender   *sender.Sender
-	metrics  metrics.Recorder
+	consumer  *consumer.Consumer
+	db        statestore.Store
+	endpoints endpointLoader
+	sender    *sender.Sender
+	metrics   metrics.Recorder
+	tracer    *deliveryTracer
+	journal   *deliveryJournal
+	stop      *shared.EmergencyStopWatcher // nil disables the emergency stop
+	shard     *shard.Assignment            // nil owns every client
+	dlq       deadLetterQueue              // nil disables the dead-letter queue
+	attempts  int                          // attempts at a message before committing past it
 }
 
-// processNotifications reads notification ready events from Kafka and processes them concurrently.
+// deadLetterQueue moves messages that cannot be processed to a dead-letter topic.
+// It is implemented by pkg/kafka's DeadLetterQueue.
+type deadLetterQueue interface {
+	Reject(ctx context.Context, msg *kafka.Message, reason error) (bool, error)
+	Fail(ctx context.Context, msg *kafka.Message, reason error) (bool, error)
+}
+
+// processNotifications reads notification ready events from Kafka and processes them with
+// workers concurrent workers, so a slow SMTP or webhook call only holds up its own worker.
+// Read events wait in a priority queue of queueSize, so workers take the most urgent first.
+// Workers finish out of order; the consumer only commits an offset once every earlier message
+// of its partition is handled.
 // Rate limiting for email providers is handled at the email sender level.
-func processNotifications(ctx context.Context, kafkaConsumer *consumer.Consumer, db *database.DB, notifSender *sender.Sender, m metrics.Recorder) error {
-	slog.Info("Starting notification processing loop", "workers", workerCount)
+// While the emergency stop is active, nothing is read or sent; held messages are sent after resume.
+// A sharded sender (non-nil assignment) commits past notifications of clients it does not own.
+// With a dead-letter queue, undecodable events and events that keep failing before delivery are
+// dead-lettered and committed. A message that fails is retried up to attempts times (default
+// kafkautil.DefaultMaxFailures) and then committed, so it cannot stall its partition. A positive
+// throttleInterval flushes THROTTLED notifications that
+// often (see flushThrottled).
+func processNotifications(ctx context.Context, kafkaConsumer *consumer.Consumer, db statestore.Store, endpoints endpointLoader, notifSender *sender.Sender, m metrics.Recorder, tracer *deliveryTracer, journal *deliveryJournal, stop *shared.EmergencyStopWatcher, workers, queueSize int, assignment *shard.Assignment, dlq deadLetterQueue, attempts int, throttleInterval time.Duration) error {
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+	if queueSize <= 0 {
+		queueSize = workqueue.DefaultCapacity
+	}
+	if attempts <= 0 {
+		attempts = kafkautil.DefaultMaxFailures
+	}
+	slog.Info("Starting notification processing loop", "workers", workers, "queue_size", queueSize, "shard", assignment.String())
 
 	deps := &processorDeps{
-		consumer: kafkaConsumer,
-		db:       db,
-		sender:   notifSender,
-		metrics:  m,
+		consumer:  kafkaConsumer,
+		db:        db,
+		endpoints: endpoints,
+		sender:    notifSender,
+		metrics:   m,
+		tracer:    tracer,
+		journal:   journal,
+		stop:      stop,
+		shard:     assignment,
+		dlq:       dlq,
+		attempts:  attempts,
 	}
 
-	jobs := make(chan work, workerCount*2)
+	jobs := workqueue.New[work](queueSize)
 	var wg sync.WaitGroup
 
+	// Deliver throttled notifications again once their endpoints have tokens back
+	if throttleInterval > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			flushThrottled(ctx, deps, throttleInterval)
+		}()
+	}
+
 	// Start worker goroutines
-	for i := 0; i < workerCount; i++ {
+	for i := 0; i < workers; i++ {
 		wg.Add(1)
 		go runWorker(ctx, deps, jobs, &wg)
 	}
@@ -56,43 +123,135 @@ func processNotifications(ctx context.Context, kafkaConsumer *consumer.Consumer,
 	// Read messages and dispatch to workers
 	dispatchMessages(ctx, deps, jobs)
 
-	close(jobs)
+	jobs.Close()
 	wg.Wait()
 	slog.Info("Notification processing loop stopped")
 	return nil
 }
 
-// runWorker processes jobs from the channel until it's closed.
-func runWorker(ctx context.Context, deps *processorDeps, jobs <-chan work, wg *sync.WaitGroup) {
+// retryBackoff is the wait before a failed message is processed again.
+const retryBackoff = time.Second
+
+// runWorker processes jobs from the queue, most urgent first, until it's closed and drained.
+func runWorker(ctx context.Context, deps *processorDeps, jobs *workqueue.Queue[work], wg *sync.WaitGroup) {
 	defer wg.Done()
-	for job := range jobs {
-		processOne(ctx, deps, job.ready, job.msg)
+	for {
+		job, ok := jobs.Pop()
+		if !ok {
+			return
+		}
+		processWithRetries(ctx, deps, job.ready, job.msg)
 	}
 }
 
-// dispatchMessages reads messages from Kafka and dispatches them to workers.
-func dispatchMessages(ctx context.Context, deps *processorDeps, jobs chan<- work) {
+// processWithRetries processes a message until it is committed, up to deps.attempts times.
+// Fetched messages are not redelivered until the sender restarts, and an uncommitted message
+// holds back the commits of every later message of its partition, so once the attempts are
+// used up the message is committed anyway. Its notification keeps its status (RECEIVED or
+// SENDING) in the database.
+func processWithRetries(ctx context.Context, deps *processorDeps, ready *events.NotificationReady, msg *kafka.Message) {
+	for attempt := 1; ; attempt++ {
+		if processOne(ctx, deps, ready, msg) || ctx.Err() != nil {
+			return
+		}
+		if attempt >= deps.attempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retryBackoff):
+		}
+	}
+
+	logAndRecordError(deps.metrics, "Giving up on notification, committing past it",
+		"notification_id", ready.NotificationID,
+		"attempts", deps.attempts,
+		"offset", msg.Offset,
+	)
+	commitOffset(ctx, deps.consumer, msg)
+}
+
+// dispatchMessages reads messages from Kafka and queues them for the workers by priority.
+func dispatchMessages(ctx context.Context, deps *processorDeps, jobs *workqueue.Queue[work]) {
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		default:
+			if !waitForResume(ctx, deps, "") {
+				return
+			}
 			ready, msg, err := deps.consumer.ReadMessage(ctx)
 			if err != nil {
 				if ctx.Err() != nil {
 					return
 				}
 				slog.Error("Failed to read notification ready event", "error", err)
+				// A message that was read but could not be decoded never will be
+				if msg != nil && deps.dlq != nil {
+					dead, dlqErr := deps.dlq.Reject(ctx, msg, err)
+					handleDeadLetter(ctx, deps, msg, dead, dlqErr)
+				} else if msg != nil {
+					// Skip it, or it would hold back the offsets of the messages after it
+					commitOffset(ctx, deps.consumer, msg)
+				}
 				continue
 			}
 			deps.metrics.RecordReceived()
-			jobs <- work{ready: ready, msg: msg}
+			if !ownedByShard(ctx, deps, ready, msg) {
+				continue
+			}
+			if !jobs.Push(ctx, work{ready: ready, msg: msg}, ready.Priority) {
+				return
+			}
 		}
 	}
 }
 
-// processOne handles a single notification: fetch, send, update status, commit.
-func processOne(ctx context.Context, deps *processorDeps, ready *events.NotificationReady, msg *kafka.Message) {
+// ownedByShard reports whether this sender delivers the notification. Notifications of
+// clients owned by other shards are committed here so they aren't read again.
+func ownedByShard(ctx context.Context, deps *processorDeps, ready *events.NotificationReady, msg *kafka.Message) bool {
+	if deps.shard == nil {
+		return true
+	}
+	owned := deps.shard.Owns(ready.ClientID)
+	deps.metrics.RecordShard(shard.Slice(shard.Bucket(ready.ClientID)), owned)
+	if !owned {
+		commitOffset(ctx, deps.consumer, msg)
+	}
+	return owned
+}
+
+// waitForResume blocks while the emergency stop is active. notificationID is the held
+// notification, if any. Returns false if ctx was cancelled while waiting.
+func waitForResume(ctx context.Context, deps *processorDeps, notificationID string) bool {
+	if deps.stop == nil {
+		return true
+	}
+	stop := deps.stop.Current()
+	if !stop.Active {
+		return true
+	}
+
+	if notificationID != "" {
+		deps.metrics.RecordEmergencyStopHold()
+		slog.Warn("Emergency stop active, holding notification",
+			"notification_id", notificationID,
+			"reason", stop.Reason,
+		)
+	}
+	return deps.stop.Wait(ctx) == nil
+}
+
+// processOne handles a single notification: fetch, send, update status, commit. Returns false
+// if the message was left uncommitted, after a failure or because ctx was cancelled.
+func processOne(ctx context.Context, deps *processorDeps, ready *events.NotificationReady, msg *kafka.Message) bool {
+	// Hold the notification (without committing) while the emergency stop is active
+	if !waitForResume(ctx, deps, ready.NotificationID) {
+		return false
+	}
+
 	startTime := time.Now()
 
 	// Fetch notification from database
@@ -100,31 +259,62 @@ func processOne(ctx context.Context, deps *processorDeps, ready *events.Notifica
 	if err != nil {
 		logAndRecordError(deps.metrics, "Failed to fetch notification",
 			"notification_id", ready.NotificationID, "error", err)
-		return
+		return failAttempt(ctx, deps, msg, fmt.Errorf("failed to fetch notification: %w", err))
 	}
 
 	// Skip if already processed (idempotency check)
 	if isAlreadyProcessed(notification.Status) {
 		handleAlreadyProcessed(ctx, deps, ready, msg)
-		return
+		return true
+	}
+
+	// A digest lists the notifications it groups
+	if err := loadGrouped(ctx, deps, ready, notification); err != nil {
+		logAndRecordError(deps.metrics, "Failed to fetch grouped notifications",
+			"notification_id", ready.NotificationID, "error", err)
+		return failAttempt(ctx, deps, msg, err)
 	}
 
 	// Fetch endpoints for the notification's rules
-	endpoints, err := deps.db.GetEndpointsByRuleIDs(ctx, notification.RuleIDs)
+	endpoints, err := deps.endpoints.GetEndpointsByRuleIDs(ctx, notification.RuleIDs)
 	if err != nil {
 		logAndRecordError(deps.metrics, "Failed to fetch endpoints",
 			"notification_id", ready.NotificationID, "error", err)
-		return
+		return failAttempt(ctx, deps, msg, fmt.Errorf("failed to fetch endpoints: %w", err))
 	}
 
+	// Claim the notification; this fails if it left RECEIVED/SENDING since it was fetched
+	if err := deps.db.UpdateNotificationStatus(ctx, ready.NotificationID, database.StatusSending); err != nil {
+		if errors.Is(err, database.ErrInvalidTransition) {
+			handleAlreadyProcessed(ctx, deps, ready, msg)
+			return true
+		}
+		logAndRecordError(deps.metrics, "Failed to mark notification as sending",
+			"notification_id", ready.NotificationID, "error", err)
+		return failAttempt(ctx, deps, msg, fmt.Errorf("failed to mark notification as sending: %w", err))
+	}
+	return deliverClaimed(ctx, deps, ready, notification, endpoints, msg, startTime)
+}
+
+// deliverClaimed sends a notification that was moved to SENDING, records the outcome and
+// commits msg. msg is nil for a throttled notification being flushed. Returns false if the
+// outcome could not be recorded, leaving msg uncommitted.
+func deliverClaimed(ctx context.Context, deps *processorDeps, ready *events.NotificationReady, notification *database.Notification, endpoints map[string][]database.Endpoint, msg *kafka.Message, startTime time.Time) bool {
+	updateGrouped(ctx, deps, notification, database.StatusSending)
+
 	// Attempt to send the notification
-	if err := deps.sender.SendNotification(ctx, notification, endpoints); err != nil {
-		handleSendFailure(ctx, deps, ready, notification, msg, startTime, err)
-		return
+	result, err := deps.sender.Deliver(ctx, notification, endpoints)
+	if err != nil {
+		return handleSendFailure(ctx, deps, ready, notification, msg, startTime, err)
+	}
+
+	// Endpoints over their rate limit are sent to later, by the throttle flusher
+	if result.Throttled > 0 {
+		return handleThrottled(ctx, deps, ready, notification, msg, result)
 	}
 
-	// Update status to SENT and commit
-	handleSendSuccess(ctx, deps, ready, notification, msg, startTime)
+	// Update status to SENT, PARTIALLY_SENT or SIMULATED and commit
+	return handleSendSuccess(ctx, deps, ready, notification, msg, startTime, result)
 }
 
 // isAlreadyProcessed checks if a notification has already been processed.
@@ -143,23 +333,26 @@ func handleAlreadyProcessed(ctx context.Context, deps *processorDeps, ready *eve
 }
 
 // handleSendFailure handles the case where sending a notification failed.
-func handleSendFailure(ctx context.Context, deps *processorDeps, ready *events.NotificationReady, notification *database.Notification, msg *kafka.Message, startTime time.Time, sendErr error) {
+func handleSendFailure(ctx context.Context, deps *processorDeps, ready *events.NotificationReady, notification *database.Notification, msg *kafka.Message, startTime time.Time, sendErr error) bool {
 	slog.Error("Failed to send notification",
 		"notification_id", ready.NotificationID,
 		"error", sendErr,
 	)
 
 	// Mark as FAILED (dead letter queue pattern - notification can be retried later)
-	if err := deps.db.UpdateNotificationStatus(ctx, ready.NotificationID, database.StatusFailed.String()); err != nil {
+	if err := deps.db.UpdateNotificationStatus(ctx, ready.NotificationID, database.StatusFailed); err != nil {
 		logAndRecordError(deps.metrics, "Failed to mark notification as failed",
 			"notification_id", ready.NotificationID, "error", err)
-		// Don't commit - will retry on redelivery
-		return
+		// Not committed: retried
+		return false
 	}
+	updateGrouped(ctx, deps, notification, database.StatusFailed)
 
 	deps.metrics.RecordProcessed(time.Since(startTime))
 	deps.metrics.RecordError()
 	deps.metrics.RecordFailed()
+	deps.tracer.recordOutcome(ctx, notification, database.StatusFailed, sendErr)
+	deps.journal.record(ctx, ready.NotificationID, database.EventFailed, "", sendErr)
 
 	slog.Warn("Notification marked as FAILED (DLQ)",
 		"notification_id", ready.NotificationID,
@@ -169,36 +362,150 @@ func handleSendFailure(ctx context.Context, deps *processorDeps, ready *events.N
 	)
 
 	// Commit offset - we've handled this notification (by marking it failed)
-	commitOffset(ctx, deps.consumer, msg)
+	if commitOffset(ctx, deps.consumer, msg) {
+		deps.journal.record(ctx, ready.NotificationID, database.EventAcked, "", nil)
+	}
+	return true
 }
 
-// handleSendSuccess handles the case where sending a notification succeeded.
-func handleSendSuccess(ctx context.Context, deps *processorDeps, ready *events.NotificationReady, notification *database.Notification, msg *kafka.Message, startTime time.Time) {
-	if err := deps.db.UpdateNotificationStatus(ctx, ready.NotificationID, database.StatusSent.String()); err != nil {
+// handleSendSuccess handles the case where sending a notification succeeded for at least one endpoint.
+// The notification is marked PARTIALLY_SENT if some endpoints failed, and SIMULATED in dry-run mode.
+func handleSendSuccess(ctx context.Context, deps *processorDeps, ready *events.NotificationReady, notification *database.Notification, msg *kafka.Message, startTime time.Time, result sender.DeliveryResult) bool {
+	status, event := database.StatusSent, database.EventSent
+	switch {
+	case deps.sender.DryRun():
+		status, event = database.StatusSimulated, database.EventSimulated
+	case result.Partial():
+		status, event = database.StatusPartiallySent, database.EventPartiallySent
+	}
+
+	if err := deps.db.UpdateNotificationStatus(ctx, ready.NotificationID, status); err != nil {
 		logAndRecordError(deps.metrics, "Failed to update notification status",
 			"notification_id", ready.NotificationID, "error", err)
-		return
+		// Not committed: the retry skips the endpoints already delivered to
+		return false
 	}
+	updateGrouped(ctx, deps, notification, status)
+	deps.sender.ForgetDeliveries(ready.NotificationID)
 
 	deps.metrics.RecordProcessed(time.Since(startTime))
 	deps.metrics.RecordPublished()
-	deps.metrics.RecordSent()
+	switch status {
+	case database.StatusSimulated:
+		deps.metrics.RecordSimulated()
+	case database.StatusPartiallySent:
+		deps.metrics.RecordPartiallySent()
+	default:
+		deps.metrics.RecordSent()
+	}
+	recordCanary(ctx, deps.metrics, notification)
+	if ready.ReadyAtMs > 0 && status != database.StatusSimulated {
+		deps.metrics.RecordDeliveryLatency(ctx, time.UnixMilli(ready.ReadyAtMs))
+	}
+	deps.tracer.recordOutcome(ctx, notification, status, nil)
+	var partialErr error
+	if status == database.StatusPartiallySent {
+		partialErr = fmt.Errorf("%d of %d endpoints failed: %s", result.Failed, result.Failed+result.Delivered, strings.Join(result.Errors, "; "))
+	}
+	deps.journal.record(ctx, ready.NotificationID, event, "", partialErr)
 
 	slog.Info("Successfully sent notification",
 		"notification_id", ready.NotificationID,
+		"status", status,
 		"alert_id", ready.AlertID,
 		"client_id", ready.ClientID,
 		"rule_ids", notification.RuleIDs,
 	)
 
-	commitOffset(ctx, deps.consumer, msg)
+	if commitOffset(ctx, deps.consumer, msg) {
+		deps.journal.record(ctx, ready.NotificationID, database.EventAcked, "", nil)
+	}
+	return true
+}
+
+// handleThrottled handles a delivery that held back endpoints over their rate limit. The
+// notification is marked THROTTLED until every endpoint has a token back, and the offset is
+// committed; the throttle flusher delivers it again, skipping the endpoints already sent to.
+func handleThrottled(ctx context.Context, deps *processorDeps, ready *events.NotificationReady, notification *database.Notification, msg *kafka.Message, result sender.DeliveryResult) bool {
+	if err := deps.db.ThrottleNotification(ctx, ready.NotificationID, result.RetryAfter); err != nil {
+		logAndRecordError(deps.metrics, "Failed to mark notification as throttled",
+			"notification_id", ready.NotificationID, "error", err)
+		// Not committed: the retry skips the endpoints already delivered to
+		return false
+	}
+	updateGrouped(ctx, deps, notification, database.StatusThrottled)
+
+	deps.metrics.RecordThrottled()
+	deps.tracer.recordOutcome(ctx, notification, database.StatusThrottled, nil)
+	throttleErr := fmt.Errorf("%d endpoints over their rate limit, retrying in %s", result.Throttled, result.RetryAfter)
+	deps.journal.record(ctx, ready.NotificationID, database.EventThrottled, "", throttleErr)
+
+	slog.Info("Notification throttled",
+		"notification_id", ready.NotificationID,
+		"alert_id", ready.AlertID,
+		"client_id", ready.ClientID,
+		"delivered", result.Delivered,
+		"throttled", result.Throttled,
+		"retry_after", result.RetryAfter,
+	)
+
+	if commitOffset(ctx, deps.consumer, msg) {
+		deps.journal.record(ctx, ready.NotificationID, database.EventAcked, "", nil)
+	}
+	return true
+}
+
+// recordCanary records end-to-end latency when the notification is a pipeline canary.
+func recordCanary(ctx context.Context, m metrics.Recorder, notification *database.Notification) {
+	if !pkgmetrics.IsCanary(notification.Context) {
+		return
+	}
+	sentAt, ok := pkgmetrics.CanarySentAt(notification.Context)
+	if !ok {
+		slog.Warn("Canary notification missing sent time", "notification_id", notification.NotificationID)
+		return
+	}
+	m.RecordCanaryDelivered(ctx, notification.AlertID, sentAt)
 }
 
 // commitOffset commits the Kafka offset for the given message.
-func commitOffset(ctx context.Context, c *consumer.Consumer, msg *kafka.Message) {
+// Returns true if the commit succeeded, and false for a nil message (a flushed notification).
+func commitOffset(ctx context.Context, c *consumer.Consumer, msg *kafka.Message) bool {
+	if msg == nil {
+		return false
+	}
 	if err := c.CommitMessage(ctx, msg); err != nil {
 		slog.Error("Failed to commit offset", "error", err)
+		return false
+	}
+	return true
+}
+
+// failAttempt records a failed attempt to process a message before anything was sent, and
+// dead-letters it once it has failed too often. Failures after sending are not dead-lettered:
+// retries are deduplicated by the notification's status. Returns true if the message was
+// dead-lettered and committed.
+func failAttempt(ctx context.Context, deps *processorDeps, msg *kafka.Message, reason error) bool {
+	if deps.dlq == nil {
+		return false
 	}
+	dead, err := deps.dlq.Fail(ctx, msg, reason)
+	return handleDeadLetter(ctx, deps, msg, dead, err)
+}
+
+// handleDeadLetter commits a message once it is in the dead-letter topic, and returns whether
+// it did.
+func handleDeadLetter(ctx context.Context, deps *processorDeps, msg *kafka.Message, dead bool, err error) bool {
+	if err != nil {
+		logAndRecordError(deps.metrics, "Failed to dead-letter message", "offset", msg.Offset, "error", err)
+		return false
+	}
+	if !dead {
+		return false
+	}
+	deps.metrics.RecordDeadLettered()
+	commitOffset(ctx, deps.consumer, msg)
+	return true
 }
 
 // logAndRecordError logs an error and records it in metrics.
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"sender/internal/database"
+	"sender/internal/events"
+)
+
+const (
+	// defaultThrottleCheckInterval is how often throttled notifications are checked for.
+	defaultThrottleCheckInterval = 5 * time.Second
+	// throttleBatchSize is the most throttled notifications flushed per check.
+	throttleBatchSize = 100
+)
+
+// flushThrottled delivers THROTTLED notifications again once their endpoints have tokens
+// back, every interval until ctx is cancelled.
+func flushThrottled(ctx context.Context, deps *processorDeps, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultThrottleCheckInterval
+	}
+	slog.Info("Starting throttled notification flusher", "check_interval", interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Throttled notification flusher stopped")
+			return
+		case <-ticker.C:
+			flushThrottledOnce(ctx, deps)
+		}
+	}
+}
+
+// flushThrottledOnce delivers the throttled notifications that are due and returns how many
+// it claimed. Nothing is claimed while the emergency stop is active.
+func flushThrottledOnce(ctx context.Context, deps *processorDeps) int {
+	if deps.stop != nil && deps.stop.Current().Active {
+		slog.Debug("Emergency stop active, skipping throttled notification flush")
+		return 0
+	}
+
+	due, err := deps.db.GetDueThrottledNotifications(ctx, throttleBatchSize)
+	if err != nil {
+		logAndRecordError(deps.metrics, "Failed to check for throttled notifications", "error", err)
+		return 0
+	}
+
+	flushed := 0
+	for _, notification := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		if flushOne(ctx, deps, notification) {
+			flushed++
+		}
+	}
+	if flushed > 0 {
+		slog.Info("Flushed throttled notifications", "count", flushed)
+	}
+	return flushed
+}
+
+// flushOne claims a throttled notification and delivers it again. Endpoints it was already
+// sent to are skipped by the delivery ledger. Returns false if it was not claimed.
+func flushOne(ctx context.Context, deps *processorDeps, notification *database.Notification) bool {
+	if deps.shard != nil && !deps.shard.Owns(notification.ClientID) {
+		return false
+	}
+	startTime := time.Now()
+
+	ready := &events.NotificationReady{
+		NotificationID: notification.NotificationID,
+		ClientID:       notification.ClientID,
+		AlertID:        notification.AlertID,
+	}
+	// A throttled digest lists the notifications it groups
+	grouped, err := deps.db.GetDigestNotificationIDs(ctx, notification.NotificationID)
+	if err != nil {
+		logAndRecordError(deps.metrics, "Failed to fetch digest notifications",
+			"notification_id", notification.NotificationID, "error", err)
+		return false
+	}
+	ready.GroupedNotificationIDs = grouped
+	if err := loadGrouped(ctx, deps, ready, notification); err != nil {
+		logAndRecordError(deps.metrics, "Failed to fetch grouped notifications",
+			"notification_id", notification.NotificationID, "error", err)
+		return false
+	}
+
+	endpoints, err := deps.endpoints.GetEndpointsByRuleIDs(ctx, notification.RuleIDs)
+	if err != nil {
+		logAndRecordError(deps.metrics, "Failed to fetch endpoints",
+			"notification_id", notification.NotificationID, "error", err)
+		return false
+	}
+
+	// Claim the notification; another instance may have flushed it, or it was acknowledged
+	claimed, err := deps.db.ClaimThrottledNotification(ctx, notification.NotificationID)
+	if err != nil {
+		logAndRecordError(deps.metrics, "Failed to claim throttled notification",
+			"notification_id", notification.NotificationID, "error", err)
+		return false
+	}
+	if !claimed {
+		return false
+	}
+	deps.journal.record(ctx, notification.NotificationID, database.EventThrottleFlushed, "", nil)
+
+	deliverClaimed(ctx, deps, ready, notification, endpoints, nil, startTime)
+	return true
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+
+	"sender/internal/database"
+
+	pkgmetrics "github.com/afikmenashe/alerting-platform/pkg/metrics"
+)
+
+// traceRecorder is the subset of the metrics collector used for alert tracing.
+type traceRecorder interface {
+	RecordTrace(ctx context.Context, alertID string, event pkgmetrics.TraceEvent)
+}
+
+// deliveryTracer records sender events in per-alert traces for the debug trace endpoint.
+// A nil *deliveryTracer records nothing.
+type deliveryTracer struct {
+	recorder traceRecorder
+}
+
+// ObserveDelivery records the outcome of a single endpoint delivery.
+func (t *deliveryTracer) ObserveDelivery(ctx context.Context, notification *database.Notification, endpointType string, err error) {
+	event := "delivered"
+	if err != nil {
+		event = "delivery_failed"
+	}
+	t.record(ctx, notification, event, map[string]string{"endpoint_type": endpointType}, err)
+}
+
+// ObserveSimulation records a simulated (dry-run) endpoint delivery.
+func (t *deliveryTracer) ObserveSimulation(ctx context.Context, notification *database.Notification, endpointType string) {
+	t.record(ctx, notification, "simulated", map[string]string{"endpoint_type": endpointType}, nil)
+}
+
+// recordOutcome records the final status of a notification.
+func (t *deliveryTracer) recordOutcome(ctx context.Context, notification *database.Notification, status database.NotificationStatus, err error) {
+	t.record(ctx, notification, "notification_"+string(status), nil, err)
+}
+
+func (t *deliveryTracer) record(ctx context.Context, notification *database.Notification, event string, details map[string]string, err error) {
+	if t == nil || t.recorder == nil {
+		return
+	}
+	te := pkgmetrics.TraceEvent{
+		Stage:          pkgmetrics.StageSender,
+		Event:          event,
+		ClientID:       notification.ClientID,
+		RuleIDs:        notification.RuleIDs,
+		NotificationID: notification.NotificationID,
+		Details:        details,
+	}
+	if err != nil {
+		te.Error = err.Error()
+	}
+	t.recorder.RecordTrace(ctx, notification.AlertID, te)
+}
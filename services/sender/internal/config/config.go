@@ -3,8 +3,16 @@ package config
 
 import (
 	"fmt"
+	"time"
+
+	"sender/internal/shard"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
 )
 
+// DefaultConsumerGroupID is the notifications.ready consumer group of unsharded senders.
+const DefaultConsumerGroupID = "sender-group"
+
 // Config holds all configuration parameters for the sender service.
 type Config struct {
 	KafkaBrokers            string
@@ -12,6 +20,153 @@ type Config struct {
 	ConsumerGroupID         string
 	PostgresDSN             string
 	RedisAddr               string
+
+	// NotificationsGroupedTopic carries the aggregator's digests, read in the same consumer
+	// group as NotificationsReadyTopic (empty = digests are not delivered).
+	NotificationsGroupedTopic string
+
+	// SlackBotToken enables Slack channel delivery via the Web API (optional).
+	SlackBotToken string
+	// OwnerRouting routes notifications to the owning team's Slack channel, resolved from the
+	// service catalog (CatalogURL) and/or the static mapping (OwnershipFile).
+	OwnerRouting      bool
+	CatalogURL        string
+	CatalogToken      string
+	OwnershipFile     string
+	OwnershipCacheTTL time.Duration
+	// EndpointSecretKey decrypts secret webhook header values (must match rule-service's key).
+	EndpointSecretKey string
+	// DryRun simulates all sends instead of notifying anyone (for staging environments).
+	DryRun bool
+
+	// Per-channel timeouts for a single send attempt.
+	EmailTimeout   time.Duration
+	SlackTimeout   time.Duration
+	WebhookTimeout time.Duration
+	// SendDeadline bounds the total time spent sending one notification, retries included.
+	SendDeadline time.Duration
+	// Per-channel maximum number of retries of a transient send failure (0 disables retries).
+	EmailMaxRetries   int
+	SlackMaxRetries   int
+	WebhookMaxRetries int
+
+	// Shared HTTP connection pool for Slack and webhook delivery.
+	HTTPMaxIdleConnsPerHost int
+	HTTPMaxConnsPerHost     int
+	HTTPIdleConnTimeout     time.Duration
+
+	// Workers is how many notifications are delivered concurrently (0 uses the default).
+	Workers int
+	// WorkQueueSize is how many notifications are read ahead of the workers and delivered
+	// highest priority first (0 uses the default).
+	WorkQueueSize int
+
+	// Sharding by client (disabled when ShardRange and ShardClients are empty). A shard owns
+	// a client hash bucket range and/or explicit clients, minus ShardExcludeClients, and reads
+	// notifications.ready with its own consumer group, skipping clients it does not own.
+	ShardRange          string // lo-hi, within 0-1023
+	ShardClients        string // Comma-separated
+	ShardExcludeClients string // Comma-separated
+
+	// Dead-letter queue for notification events that cannot be processed (disabled when DLQTopic is empty).
+	DLQTopic       string
+	DLQMaxFailures int // Failed attempts before an event is dead-lettered
+
+	// DNS cache for Slack and webhook hosts.
+	DNSCacheTTL    time.Duration
+	DNSNegativeTTL time.Duration
+
+	// Endpoint cache (disabled when EndpointCacheTTL is 0), invalidated by rule.changed and
+	// endpoint.changed events. Each instance has its own cache, so both group IDs must be unique
+	// per instance.
+	EndpointCacheTTL       time.Duration
+	RuleChangedTopic       string
+	RuleChangedGroupID     string
+	EndpointChangedTopic   string
+	EndpointChangedGroupID string
+
+	// Reminders for delivered notifications that are not acknowledged (disabled when ReminderInterval is 0).
+	ReminderInterval      time.Duration
+	ReminderMax           int
+	ReminderSeverities    string // Comma-separated
+	ReminderCheckInterval time.Duration
+
+	// Escalation of unacknowledged notifications through their rules' escalation policies
+	// (disabled when EscalationCheckInterval is 0).
+	EscalationCheckInterval time.Duration
+
+	// Where notification statuses are written: "postgres" (default), or "redis", kept for
+	// StateTTL and written back to Postgres every StateSyncInterval.
+	StateStore        string
+	StateTTL          time.Duration
+	StateSyncInterval time.Duration
+
+	// Per-endpoint rate limits (disabled when EndpointRateLimits is empty), as comma-separated
+	// type:rate:burst entries, e.g. "email:0.2:5,slack:1:10". Sends over the limit are marked
+	// THROTTLED and flushed every ThrottleCheckInterval.
+	EndpointRateLimits    string
+	ThrottleCheckInterval time.Duration
+
+	// Related alerts (disabled when RelatedAlerts is 0): email and Slack notifications list up
+	// to RelatedAlerts earlier alerts with the same fingerprint, in at most
+	// RelatedAlertsMaxBytes of text.
+	RelatedAlerts         int
+	RelatedAlertsMaxBytes int
+
+	// SeverityStyles overrides the color, emoji and label of severities in email and Slack
+	// notifications: a JSON object keyed by severity (see shared.ConfigureSeverityStyles).
+	SeverityStyles string
+}
+
+// MaxRelatedAlerts caps RelatedAlerts.
+const MaxRelatedAlerts = 50
+
+// rateLimitedTypes are the endpoint types EndpointRateLimits can limit.
+var rateLimitedTypes = map[string]bool{"email": true, "slack": true, "webhook": true}
+
+// EndpointRateLimitingEnabled reports whether sends are rate limited per endpoint.
+func (c *Config) EndpointRateLimitingEnabled() bool {
+	return c.EndpointRateLimits != ""
+}
+
+// EndpointRateLimitPolicy parses EndpointRateLimits into a policy with one tier per
+// endpoint type.
+func (c *Config) EndpointRateLimitPolicy() (*shared.RateLimitPolicy, error) {
+	policy, err := shared.ParseRateLimitPolicy(c.EndpointRateLimits, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, tier := range policy.Tiers() {
+		if !rateLimitedTypes[tier] {
+			return nil, fmt.Errorf("invalid endpoint rate limit type %s: must be email, slack or webhook", tier)
+		}
+	}
+	return policy, nil
+}
+
+// RelatedAlertsEnabled reports whether notifications list earlier alerts with the same fingerprint.
+func (c *Config) RelatedAlertsEnabled() bool {
+	return c.RelatedAlerts > 0
+}
+
+// RemindersEnabled reports whether the reminder scheduler is configured.
+func (c *Config) RemindersEnabled() bool {
+	return c.ReminderInterval > 0
+}
+
+// EscalationsEnabled reports whether the escalation scheduler is configured.
+func (c *Config) EscalationsEnabled() bool {
+	return c.EscalationCheckInterval > 0
+}
+
+// DLQEnabled reports whether unprocessable notification events are dead-lettered.
+func (c *Config) DLQEnabled() bool {
+	return c.DLQTopic != ""
+}
+
+// EndpointCacheEnabled reports whether endpoints are cached.
+func (c *Config) EndpointCacheEnabled() bool {
+	return c.EndpointCacheTTL > 0
 }
 
 // Validate checks that all required configuration fields are set and have valid values.
@@ -32,5 +187,135 @@ func (c *Config) Validate() error {
 	if c.RedisAddr == "" {
 		return fmt.Errorf("redis-addr cannot be empty")
 	}
+	if c.OwnerRouting && c.SlackBotToken == "" {
+		return fmt.Errorf("slack-bot-token cannot be empty when owner-routing is enabled")
+	}
+	if c.OwnerRouting && c.CatalogURL == "" && c.OwnershipFile == "" {
+		return fmt.Errorf("catalog-url or ownership-file is required when owner-routing is enabled")
+	}
+	if c.OwnershipCacheTTL < 0 {
+		return fmt.Errorf("ownership-cache-ttl cannot be negative")
+	}
+	if c.EmailTimeout <= 0 {
+		return fmt.Errorf("email-timeout must be positive")
+	}
+	if c.SlackTimeout <= 0 {
+		return fmt.Errorf("slack-timeout must be positive")
+	}
+	if c.WebhookTimeout <= 0 {
+		return fmt.Errorf("webhook-timeout must be positive")
+	}
+	if c.SendDeadline <= 0 {
+		return fmt.Errorf("send-deadline must be positive")
+	}
+	if c.EmailMaxRetries < 0 {
+		return fmt.Errorf("email-max-retries cannot be negative")
+	}
+	if c.SlackMaxRetries < 0 {
+		return fmt.Errorf("slack-max-retries cannot be negative")
+	}
+	if c.WebhookMaxRetries < 0 {
+		return fmt.Errorf("webhook-max-retries cannot be negative")
+	}
+	if c.Workers < 0 {
+		return fmt.Errorf("workers cannot be negative")
+	}
+	if c.WorkQueueSize < 0 {
+		return fmt.Errorf("work-queue-size cannot be negative")
+	}
+	sharding, err := shard.Parse(c.ShardRange, c.ShardClients, c.ShardExcludeClients)
+	if err != nil {
+		return err
+	}
+	if sharding != nil && c.ConsumerGroupID == DefaultConsumerGroupID {
+		return fmt.Errorf("consumer-group-id must be unique per shard when sharding is enabled")
+	}
+	if c.DLQEnabled() && c.DLQMaxFailures <= 0 {
+		return fmt.Errorf("dlq-max-failures must be positive")
+	}
+	if c.HTTPMaxIdleConnsPerHost <= 0 {
+		return fmt.Errorf("http-max-idle-conns-per-host must be positive")
+	}
+	if c.HTTPMaxConnsPerHost <= 0 {
+		return fmt.Errorf("http-max-conns-per-host must be positive")
+	}
+	if c.HTTPMaxIdleConnsPerHost > c.HTTPMaxConnsPerHost {
+		return fmt.Errorf("http-max-idle-conns-per-host cannot exceed http-max-conns-per-host")
+	}
+	if c.HTTPIdleConnTimeout <= 0 {
+		return fmt.Errorf("http-idle-conn-timeout must be positive")
+	}
+	if c.DNSCacheTTL <= 0 {
+		return fmt.Errorf("dns-cache-ttl must be positive")
+	}
+	if c.DNSNegativeTTL <= 0 {
+		return fmt.Errorf("dns-negative-ttl must be positive")
+	}
+	if c.EndpointCacheTTL < 0 {
+		return fmt.Errorf("endpoint-cache-ttl cannot be negative")
+	}
+	if c.EndpointCacheEnabled() {
+		if c.RuleChangedTopic == "" {
+			return fmt.Errorf("rule-changed-topic cannot be empty when the endpoint cache is enabled")
+		}
+		if c.RuleChangedGroupID == "" {
+			return fmt.Errorf("rule-changed-group-id cannot be empty when the endpoint cache is enabled")
+		}
+		if c.EndpointChangedTopic == "" {
+			return fmt.Errorf("endpoint-changed-topic cannot be empty when the endpoint cache is enabled")
+		}
+		if c.EndpointChangedGroupID == "" {
+			return fmt.Errorf("endpoint-changed-group-id cannot be empty when the endpoint cache is enabled")
+		}
+	}
+	if c.ReminderInterval < 0 {
+		return fmt.Errorf("reminder-interval cannot be negative")
+	}
+	if c.RemindersEnabled() {
+		if c.ReminderMax <= 0 {
+			return fmt.Errorf("reminder-max must be positive")
+		}
+		if c.ReminderSeverities == "" {
+			return fmt.Errorf("reminder-severities cannot be empty when reminders are enabled")
+		}
+		if c.ReminderCheckInterval <= 0 {
+			return fmt.Errorf("reminder-check-interval must be positive")
+		}
+	}
+	if c.EscalationCheckInterval < 0 {
+		return fmt.Errorf("escalation-check-interval cannot be negative")
+	}
+	switch c.StateStore {
+	case "", "postgres":
+	case "redis":
+		if c.StateTTL <= 0 {
+			return fmt.Errorf("state-ttl must be positive")
+		}
+		if c.StateSyncInterval <= 0 {
+			return fmt.Errorf("state-sync-interval must be positive")
+		}
+		if c.StateSyncInterval >= c.StateTTL {
+			return fmt.Errorf("state-sync-interval must be shorter than state-ttl")
+		}
+	default:
+		return fmt.Errorf("state-store must be postgres or redis, got %q", c.StateStore)
+	}
+	if c.EndpointRateLimitingEnabled() {
+		if _, err := c.EndpointRateLimitPolicy(); err != nil {
+			return fmt.Errorf("invalid endpoint-rate-limits: %w", err)
+		}
+		if c.ThrottleCheckInterval <= 0 {
+			return fmt.Errorf("throttle-check-interval must be positive")
+		}
+	}
+	if c.RelatedAlerts < 0 {
+		return fmt.Errorf("related-alerts cannot be negative")
+	}
+	if c.RelatedAlerts > MaxRelatedAlerts {
+		return fmt.Errorf("related-alerts cannot exceed %d", MaxRelatedAlerts)
+	}
+	if c.RelatedAlertsEnabled() && c.RelatedAlertsMaxBytes <= 0 {
+		return fmt.Errorf("related-alerts-max-bytes must be positive")
+	}
 	return nil
 }
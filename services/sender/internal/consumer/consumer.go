@@ -1,4 +1,4 @@
-// Package consumer provides Kafka consumer functionality for notifications.ready topic.
+// Package consumer provides Kafka consumer functionality for notifications.ready and notifications.grouped topics.
 package consumer
 
 import (
@@ -13,15 +13,30 @@ import (
 	"google.golang.org/protobuf/proto"
 )
 
+// messageReader fetches and commits messages; it is implemented by *kafka.Reader.
+type messageReader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
 // Consumer wraps a Kafka reader and provides a simple interface for consuming notification ready events.
 type Consumer struct {
-	reader *kafka.Reader
-	topic  string
+	reader       messageReader
+	topic        string
+	groupedTopic string // empty if digests are not read
+	offsets      *offsetTracker
 }
 
 // NewConsumer creates a new Kafka consumer with the specified brokers, topic, and group ID.
 // The consumer is configured for at-least-once delivery semantics.
 func NewConsumer(brokers string, topic string, groupID string) (*Consumer, error) {
+	return NewConsumerWithGroupedTopic(brokers, topic, "", groupID)
+}
+
+// NewConsumerWithGroupedTopic creates a consumer that also reads digest events from
+// groupedTopic, in the same consumer group. An empty groupedTopic reads topic only.
+func NewConsumerWithGroupedTopic(brokers, topic, groupedTopic, groupID string) (*Consumer, error) {
 	if err := kafkautil.ValidateConsumerParams(brokers, topic, groupID); err != nil {
 		return nil, err
 	}
@@ -32,30 +47,49 @@ func NewConsumer(brokers string, topic string, groupID string) (*Consumer, error
 	slog.Info("Initializing Kafka consumer",
 		"brokers", brokerList,
 		"topic", topic,
+		"grouped_topic", groupedTopic,
 		"group_id", groupID,
 	)
 
 	// Configure Kafka reader for at-least-once delivery
 	// StartOffset only applies when no committed offset exists for the consumer group
 	// Using FirstOffset ensures we read all messages when starting fresh
-	reader := kafka.NewReader(kafkautil.NewReaderConfig(brokerList, topic, groupID))
+	readerConfig := kafkautil.NewReaderConfig(brokerList, topic, groupID)
+	if groupedTopic != "" {
+		readerConfig.Topic = ""
+		readerConfig.GroupTopics = []string{topic, groupedTopic}
+	}
+	reader := kafka.NewReader(readerConfig)
 
 	// Log config from centralized source
 	kafkautil.LogReaderConfig()
 
 	return &Consumer{
-		reader: reader,
-		topic:  topic,
+		reader:       reader,
+		topic:        topic,
+		groupedTopic: groupedTopic,
+		offsets:      newOffsetTracker(),
 	}, nil
 }
 
 // ReadMessage reads the next message from Kafka and deserializes it as a NotificationReady.
-// Returns an error if reading or deserialization fails.
+// Returns an error if reading or deserialization fails. Every message returned, even with a
+// deserialization error, is tracked as in flight until it is passed to CommitMessage. Messages
+// are fetched without committing them: offsets are only committed by CommitMessage.
 func (c *Consumer) ReadMessage(ctx context.Context) (*events.NotificationReady, *kafka.Message, error) {
-	msg, err := c.reader.ReadMessage(ctx)
+	msg, err := c.reader.FetchMessage(ctx)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to read message from Kafka: %w", err)
 	}
+	c.offsets.track(msg)
+
+	if c.groupedTopic != "" && msg.Topic == c.groupedTopic {
+		ready, err := decodeGrouped(msg.Value)
+		if err != nil {
+			return nil, &msg, err
+		}
+		return ready, &msg, nil
+	}
 
 	var pb pbnotifications.NotificationReady
 	if err := proto.Unmarshal(msg.Value, &pb); err != nil {
@@ -67,15 +101,46 @@ func (c *Consumer) ReadMessage(ctx context.Context) (*events.NotificationReady,
 		ClientID:       pb.ClientId,
 		AlertID:        pb.AlertId,
 		SchemaVersion:  int(pb.SchemaVersion),
+		Priority:       int(pb.Priority),
+		EvaluatedAtMs:  pb.EvaluatedAtMs,
+		AggregatedAtMs: pb.AggregatedAtMs,
+		ReadyAtMs:      pb.ReadyAtMs,
 	}
 
 	return ready, &msg, nil
 }
 
-// CommitMessage commits the offset for the given message.
+// decodeGrouped deserializes a notifications.grouped digest event as a NotificationReady
+// for the digest notification.
+func decodeGrouped(value []byte) (*events.NotificationReady, error) {
+	var pb pbnotifications.NotificationGrouped
+	if err := proto.Unmarshal(value, &pb); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal notification grouped protobuf: %w", err)
+	}
+	if len(pb.NotificationIds) == 0 {
+		return nil, fmt.Errorf("notification grouped event %s lists no notifications", pb.NotificationId)
+	}
+
+	return &events.NotificationReady{
+		NotificationID:         pb.NotificationId,
+		ClientID:               pb.ClientId,
+		SchemaVersion:          int(pb.SchemaVersion),
+		Priority:               int(pb.Priority),
+		GroupedNotificationIDs: pb.NotificationIds,
+	}, nil
+}
+
+// CommitMessage marks the given message as processed and commits the offset of its partition up
+// to the last processed message with no unprocessed message before it. Messages are processed
+// concurrently, so the offset of msg may only be committed by a later call, once every earlier
+// message of its partition is processed too.
 // This should be called after successfully processing a message.
 func (c *Consumer) CommitMessage(ctx context.Context, msg *kafka.Message) error {
-	return c.reader.CommitMessages(ctx, *msg)
+	commit, ok := c.offsets.done(*msg)
+	if !ok {
+		return nil
+	}
+	return c.reader.CommitMessages(ctx, commit)
 }
 
 // Close gracefully closes the Kafka reader and releases resources.
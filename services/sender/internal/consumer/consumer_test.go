@@ -3,11 +3,15 @@ package consumer
 import (
 	"context"
 	"encoding/json"
+	"reflect"
 	"testing"
 	"time"
 
 	"sender/internal/events"
+
+	pbnotifications "github.com/afikmenashe/alerting-platform/pkg/proto/notifications"
 	"github.com/segmentio/kafka-go"
+	"google.golang.org/protobuf/proto"
 )
 
 func TestNewConsumer(t *testing.T) {
@@ -170,3 +174,94 @@ func TestConsumer_CommitMessage(t *testing.T) {
 		t.Logf("CommitMessage() error (expected in test environment): %v", err)
 	}
 }
+
+func TestDecodeGrouped(t *testing.T) {
+	value, err := proto.Marshal(&pbnotifications.NotificationGrouped{
+		NotificationId:  "digest-1",
+		ClientId:        "client-456",
+		GroupId:         "client-456:1700000000",
+		NotificationIds: []string{"notif-1", "notif-2"},
+		SchemaVersion:   1,
+		Priority:        4,
+	})
+	if err != nil {
+		t.Fatalf("proto.Marshal() error = %v", err)
+	}
+
+	ready, err := decodeGrouped(value)
+	if err != nil {
+		t.Fatalf("decodeGrouped() error = %v", err)
+	}
+	want := &events.NotificationReady{
+		NotificationID:         "digest-1",
+		ClientID:               "client-456",
+		SchemaVersion:          1,
+		Priority:               4,
+		GroupedNotificationIDs: []string{"notif-1", "notif-2"},
+	}
+	if !reflect.DeepEqual(ready, want) {
+		t.Errorf("decodeGrouped() = %+v, want %+v", ready, want)
+	}
+
+	empty, _ := proto.Marshal(&pbnotifications.NotificationGrouped{NotificationId: "digest-2"})
+	if _, err := decodeGrouped(empty); err == nil {
+		t.Error("decodeGrouped() of a digest without notifications should return error")
+	}
+	if _, err := decodeGrouped([]byte{0xff}); err == nil {
+		t.Error("decodeGrouped() of invalid protobuf should return error")
+	}
+}
+
+// fakeReader is a messageReader serving its messages in order and recording commits.
+type fakeReader struct {
+	messages  []kafka.Message
+	committed []kafka.Message
+}
+
+func (f *fakeReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	if len(f.messages) == 0 {
+		return kafka.Message{}, context.Canceled
+	}
+	msg := f.messages[0]
+	f.messages = f.messages[1:]
+	return msg, nil
+}
+
+func (f *fakeReader) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	f.committed = append(f.committed, msgs...)
+	return nil
+}
+
+func (f *fakeReader) Close() error { return nil }
+
+func TestConsumer_CommitsOnlyDoneMessages(t *testing.T) {
+	value, err := proto.Marshal(&pbnotifications.NotificationReady{NotificationId: "notif-1", ClientId: "client-1"})
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	reader := &fakeReader{messages: []kafka.Message{
+		{Topic: "notifications.ready", Offset: 1, Value: value},
+		{Topic: "notifications.ready", Offset: 2, Value: value},
+	}}
+	c := &Consumer{reader: reader, topic: "notifications.ready", offsets: newOffsetTracker()}
+	ctx := context.Background()
+
+	_, first, err := c.ReadMessage(ctx)
+	if err != nil {
+		t.Fatalf("ReadMessage() error = %v", err)
+	}
+	_, second, err := c.ReadMessage(ctx)
+	if err != nil {
+		t.Fatalf("ReadMessage() error = %v", err)
+	}
+	if len(reader.committed) != 0 {
+		t.Fatalf("committed %v before any message was done, want nothing", reader.committed)
+	}
+
+	if err := c.CommitMessage(ctx, second); err != nil || len(reader.committed) != 0 {
+		t.Fatalf("CommitMessage(2) committed %v (err %v) with 1 in flight, want nothing", reader.committed, err)
+	}
+	if err := c.CommitMessage(ctx, first); err != nil || len(reader.committed) != 1 || reader.committed[0].Offset != 2 {
+		t.Errorf("CommitMessage(1) committed %v (err %v), want offset 2", reader.committed, err)
+	}
+}
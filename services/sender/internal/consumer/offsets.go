@@ -0,0 +1,74 @@
+package consumer
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// topicPartition identifies a partition of one of the consumed topics.
+type topicPartition struct {
+	topic     string
+	partition int
+}
+
+// inFlight is a message that was read and is not yet part of a committed offset.
+type inFlight struct {
+	offset int64
+	done   bool
+}
+
+// offsetTracker tracks the messages handed to the workers per partition, so that an offset is
+// only committed once every earlier message of its partition is done. Workers finish out of
+// order; committing a later offset first would mark an earlier, still in-flight message as
+// consumed and lose it if the sender stopped before finishing it.
+type offsetTracker struct {
+	mu         sync.Mutex
+	partitions map[topicPartition][]inFlight // in offset order
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{partitions: make(map[topicPartition][]inFlight)}
+}
+
+// track records a message as read. Messages of a partition are read in offset order; reading an
+// offset at or below one already tracked means the partition was reassigned and is read again
+// from its committed offset, so what was in flight before is forgotten.
+func (t *offsetTracker) track(msg kafka.Message) {
+	key := topicPartition{topic: msg.Topic, partition: msg.Partition}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	pending := t.partitions[key]
+	if n := len(pending); n > 0 && msg.Offset <= pending[n-1].offset {
+		pending = nil
+	}
+	t.partitions[key] = append(pending, inFlight{offset: msg.Offset})
+}
+
+// done marks a message as handled and returns the message whose offset to commit: the last of
+// the partition's done messages with no in-flight message before them. ok is false if nothing
+// can be committed yet, or if msg is not tracked (e.g. read before a reassignment).
+func (t *offsetTracker) done(msg kafka.Message) (commit kafka.Message, ok bool) {
+	key := topicPartition{topic: msg.Topic, partition: msg.Partition}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	pending := t.partitions[key]
+	i := sort.Search(len(pending), func(i int) bool { return pending[i].offset >= msg.Offset })
+	if i == len(pending) || pending[i].offset != msg.Offset {
+		return kafka.Message{}, false
+	}
+	pending[i].done = true
+
+	n := 0
+	for n < len(pending) && pending[n].done {
+		n++
+	}
+	if n == 0 {
+		return kafka.Message{}, false
+	}
+	t.partitions[key] = pending[n:]
+	return kafka.Message{Topic: msg.Topic, Partition: msg.Partition, Offset: pending[n-1].offset}, true
+}
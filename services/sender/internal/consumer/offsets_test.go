@@ -0,0 +1,79 @@
+package consumer
+
+import (
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func TestOffsetTracker_CommitsContiguousOffsets(t *testing.T) {
+	tracker := newOffsetTracker()
+	msgs := make([]kafka.Message, 4)
+	for i := range msgs {
+		msgs[i] = kafka.Message{Topic: "notifications.ready", Partition: 0, Offset: int64(10 + i)}
+		tracker.track(msgs[i])
+	}
+
+	// Later messages finish first: nothing is committed past the in-flight offset 10
+	if _, ok := tracker.done(msgs[2]); ok {
+		t.Fatal("done(12) committed with 10 in flight")
+	}
+	if _, ok := tracker.done(msgs[1]); ok {
+		t.Fatal("done(11) committed with 10 in flight")
+	}
+
+	commit, ok := tracker.done(msgs[0])
+	if !ok || commit.Offset != 12 || commit.Topic != "notifications.ready" || commit.Partition != 0 {
+		t.Fatalf("done(10) = %+v, %v, want offset 12", commit, ok)
+	}
+
+	commit, ok = tracker.done(msgs[3])
+	if !ok || commit.Offset != 13 {
+		t.Fatalf("done(13) = %+v, %v, want offset 13", commit, ok)
+	}
+	if pending := tracker.partitions[topicPartition{topic: "notifications.ready"}]; len(pending) != 0 {
+		t.Errorf("pending = %v, want none", pending)
+	}
+}
+
+func TestOffsetTracker_PartitionsAreIndependent(t *testing.T) {
+	tracker := newOffsetTracker()
+	p0 := kafka.Message{Topic: "notifications.ready", Partition: 0, Offset: 5}
+	p1 := kafka.Message{Topic: "notifications.ready", Partition: 1, Offset: 7}
+	grouped := kafka.Message{Topic: "notifications.grouped", Partition: 0, Offset: 3}
+	tracker.track(p0)
+	tracker.track(p1)
+	tracker.track(grouped)
+
+	if commit, ok := tracker.done(p1); !ok || commit.Partition != 1 || commit.Offset != 7 {
+		t.Errorf("done(p1) = %+v, %v, want partition 1 offset 7", commit, ok)
+	}
+	if commit, ok := tracker.done(grouped); !ok || commit.Topic != "notifications.grouped" || commit.Offset != 3 {
+		t.Errorf("done(grouped) = %+v, %v, want notifications.grouped offset 3", commit, ok)
+	}
+}
+
+func TestOffsetTracker_Reassignment(t *testing.T) {
+	tracker := newOffsetTracker()
+	old := kafka.Message{Topic: "notifications.ready", Partition: 0, Offset: 20}
+	tracker.track(old)
+	tracker.track(kafka.Message{Topic: "notifications.ready", Partition: 0, Offset: 21})
+
+	// The partition is read again from its committed offset after a rebalance
+	reread := kafka.Message{Topic: "notifications.ready", Partition: 0, Offset: 18}
+	tracker.track(reread)
+
+	if _, ok := tracker.done(old); ok {
+		t.Error("done() of a message read before the reassignment committed")
+	}
+	if commit, ok := tracker.done(reread); !ok || commit.Offset != 18 {
+		t.Errorf("done(18) = %+v, %v, want offset 18", commit, ok)
+	}
+}
+
+func TestOffsetTracker_UntrackedMessage(t *testing.T) {
+	tracker := newOffsetTracker()
+	if _, ok := tracker.done(kafka.Message{Topic: "notifications.ready", Offset: 1}); ok {
+		t.Error("done() of an untracked message committed")
+	}
+}
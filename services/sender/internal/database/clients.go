@@ -0,0 +1,30 @@
+// Package database provides database operations for notifications and endpoints tables.
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// ClientProfile holds the client settings notifications are rendered with.
+type ClientProfile struct {
+	Timezone string // IANA timezone, "" for UTC
+	Locale   string // timestamp locale, "" for the default layout
+}
+
+// GetClientProfile returns the timezone and locale of a client. A client that does not exist
+// (anymore) gets the empty profile.
+func (db *DB) GetClientProfile(ctx context.Context, clientID string) (*ClientProfile, error) {
+	var profile ClientProfile
+	err := db.conn.QueryRowContext(ctx, `
+		SELECT timezone, locale FROM clients WHERE client_id = $1
+	`, clientID).Scan(&profile.Timezone, &profile.Locale)
+	if err == sql.ErrNoRows {
+		return &profile, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client profile: %w", err)
+	}
+	return &profile, nil
+}
@@ -242,25 +242,31 @@ func TestDB_UpdateNotificationStatus(t *testing.T) {
 	tests := []struct {
 		name           string
 		notificationID string
-		status         string
+		status         NotificationStatus
 		wantErr        bool
 	}{
 		{
-			name:           "update to SENT",
+			name:           "claim for sending",
 			notificationID: "test-notif-update",
-			status:         "SENT",
+			status:         StatusSending,
 			wantErr:        false,
 		},
 		{
-			name:           "update to FAILED",
+			name:           "update to PARTIALLY_SENT",
 			notificationID: "test-notif-update",
-			status:         "FAILED",
+			status:         StatusPartiallySent,
 			wantErr:        false,
 		},
+		{
+			name:           "invalid transition to FAILED",
+			notificationID: "test-notif-update",
+			status:         StatusFailed,
+			wantErr:        true,
+		},
 		{
 			name:           "non-existent notification",
 			notificationID: "non-existent-id-99999",
-			status:         "SENT",
+			status:         StatusSent,
 			wantErr:        true,
 		},
 	}
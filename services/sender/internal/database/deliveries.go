@@ -0,0 +1,47 @@
+// Package database provides database operations for notifications and endpoints tables.
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Delivery statuses recorded in notification_deliveries.
+const (
+	DeliverySent      = "SENT"
+	DeliveryFailed    = "FAILED"
+	DeliverySimulated = "SIMULATED"
+)
+
+// Delivery is the outcome of delivering a notification to one endpoint, after retries.
+type Delivery struct {
+	NotificationID string
+	EndpointID     string // Empty for the owning team's Slack channel, which is not an endpoint
+	Channel        string // Endpoint type
+	Status         string
+	Err            error
+	Latency        time.Duration // Time spent on all attempts
+	Attempts       int           // Send attempts made; 0 if the endpoint was never tried
+}
+
+// RecordNotificationDelivery appends an endpoint delivery to notification_deliveries.
+func (db *DB) RecordNotificationDelivery(ctx context.Context, d Delivery) error {
+	var endpointID, errText sql.NullString
+	if d.EndpointID != "" {
+		endpointID = sql.NullString{String: d.EndpointID, Valid: true}
+	}
+	if d.Err != nil {
+		errText = sql.NullString{String: d.Err.Error(), Valid: true}
+	}
+
+	_, err := db.conn.ExecContext(ctx, `
+		INSERT INTO notification_deliveries (notification_id, endpoint_id, channel, status, error, latency_ms, attempts)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, d.NotificationID, endpointID, d.Channel, d.Status, errText, d.Latency.Milliseconds(), d.Attempts)
+	if err != nil {
+		return fmt.Errorf("failed to record notification delivery: %w", err)
+	}
+	return nil
+}
@@ -3,6 +3,8 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -16,10 +18,36 @@ type Endpoint struct {
 	Type       string
 	Value      string
 	Enabled    bool
+	Headers    []EndpointHeader // custom webhook headers from the endpoint metadata
+	OAuth2     *EndpointOAuth2  // OAuth2 client-credentials config from the endpoint metadata
+	TemplateID string           // notification template for email and Slack; empty for the default layout
 	CreatedAt  time.Time
 	UpdatedAt  time.Time
 }
 
+// EndpointHeader is a static header sent with every webhook request.
+// Secret values are encrypted by rule-service and must be decrypted before sending.
+type EndpointHeader struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Secret bool   `json:"secret,omitempty"`
+}
+
+// EndpointOAuth2 is an OAuth2 client-credentials configuration for a webhook.
+// The client secret is encrypted by rule-service and must be decrypted before use.
+type EndpointOAuth2 struct {
+	TokenURL     string   `json:"token_url"`
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret"`
+	Scopes       []string `json:"scopes,omitempty"`
+}
+
+// endpointMetadata mirrors the endpoints.metadata JSON written by rule-service.
+type endpointMetadata struct {
+	Headers []EndpointHeader `json:"headers"`
+	OAuth2  *EndpointOAuth2  `json:"oauth2"`
+}
+
 // GetEmailEndpointsByRuleIDs retrieves all enabled email endpoints for the given rule IDs.
 // Returns a map of rule_id -> []email addresses.
 // Note: ruleIDs may be UUIDs (from actual rules) or test strings (like "rule-001").
@@ -67,7 +95,7 @@ func (db *DB) GetEndpointsByRuleIDs(ctx context.Context, ruleIDs []string) (map[
 	// Cast rule_id::text to compare with TEXT[] elements
 	// This handles both UUID rule_ids and test string rule_ids
 	query := `
-		SELECT rule_id::text, type, value, endpoint_id, enabled, created_at, updated_at
+		SELECT rule_id::text, type, value, endpoint_id, enabled, metadata, COALESCE(template_id, ''), created_at, updated_at
 		FROM endpoints
 		WHERE rule_id::text = ANY($1) AND enabled = TRUE
 		ORDER BY rule_id, created_at ASC
@@ -79,12 +107,68 @@ func (db *DB) GetEndpointsByRuleIDs(ctx context.Context, ruleIDs []string) (map[
 	}
 	defer rows.Close()
 
+	return scanEndpoints(rows)
+}
+
+// GetEndpointsByIDs retrieves the enabled endpoints with the given IDs.
+// Returns a map of rule_id -> []Endpoint; disabled and deleted endpoints are left out.
+func (db *DB) GetEndpointsByIDs(ctx context.Context, endpointIDs []string) (map[string][]Endpoint, error) {
+	if len(endpointIDs) == 0 {
+		return make(map[string][]Endpoint), nil
+	}
+
+	query := `
+		SELECT rule_id::text, type, value, endpoint_id, enabled, metadata, COALESCE(template_id, ''), created_at, updated_at
+		FROM endpoints
+		WHERE endpoint_id::text = ANY($1) AND enabled = TRUE
+		ORDER BY rule_id, created_at ASC
+	`
+
+	rows, err := db.conn.QueryContext(ctx, query, pq.Array(endpointIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query endpoints: %w", err)
+	}
+	defer rows.Close()
+
+	return scanEndpoints(rows)
+}
+
+// GetEnabledEndpoints retrieves all enabled endpoints, for preloading the endpoint cache.
+// Returns a map of rule_id -> []Endpoint.
+func (db *DB) GetEnabledEndpoints(ctx context.Context) (map[string][]Endpoint, error) {
+	query := `
+		SELECT rule_id::text, type, value, endpoint_id, enabled, metadata, COALESCE(template_id, ''), created_at, updated_at
+		FROM endpoints
+		WHERE enabled = TRUE
+		ORDER BY rule_id, created_at ASC
+	`
+
+	rows, err := db.conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query endpoints: %w", err)
+	}
+	defer rows.Close()
+
+	return scanEndpoints(rows)
+}
+
+// scanEndpoints reads endpoint rows into a map of rule_id -> []Endpoint.
+func scanEndpoints(rows *sql.Rows) (map[string][]Endpoint, error) {
 	result := make(map[string][]Endpoint)
 	for rows.Next() {
 		var ep Endpoint
-		if err := rows.Scan(&ep.RuleID, &ep.Type, &ep.Value, &ep.EndpointID, &ep.Enabled, &ep.CreatedAt, &ep.UpdatedAt); err != nil {
+		var metadata []byte
+		if err := rows.Scan(&ep.RuleID, &ep.Type, &ep.Value, &ep.EndpointID, &ep.Enabled, &metadata, &ep.TemplateID, &ep.CreatedAt, &ep.UpdatedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan endpoint: %w", err)
 		}
+		if len(metadata) > 0 {
+			var m endpointMetadata
+			if err := json.Unmarshal(metadata, &m); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal metadata of endpoint %s: %w", ep.EndpointID, err)
+			}
+			ep.Headers = m.Headers
+			ep.OAuth2 = m.OAuth2
+		}
 		result[ep.RuleID] = append(result[ep.RuleID], ep)
 	}
 
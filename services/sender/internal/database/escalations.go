@@ -0,0 +1,117 @@
+// Package database provides database operations for notifications and endpoints tables.
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// DueEscalation is an unacknowledged notification claimed for the next step of its rule's escalation policy.
+type DueEscalation struct {
+	Notification *Notification
+	PolicyID     string
+	// Step is this step's position (1 for the first step); Steps is the number of steps in the policy.
+	Step  int
+	Steps int
+	// EndpointIDs are the endpoints the step delivers to.
+	EndpointIDs []string
+}
+
+// escalationStep is one step of an escalation policy as stored in escalation_policies.steps.
+type escalationStep struct {
+	DelaySeconds int      `json:"delay_seconds"`
+	EndpointIDs  []string `json:"endpoint_ids"`
+}
+
+// ClaimDueEscalations atomically claims up to limit notifications that are due their next
+// escalation step and returns them. A notification escalates through the policy of its lowest
+// rule ID that has one. It is due when it is SENT, PARTIALLY_SENT or FAILED (not acknowledged
+// or resolved), it has steps left, and the next step's delay has passed since the previous step
+// (or since it was last delivered). Notifications delivered in a digest (group_id set) do not
+// escalate. Claiming increments escalation_step and sets last_escalated_at, so concurrent
+// senders never run the same step twice.
+func (db *DB) ClaimDueEscalations(ctx context.Context, limit int) ([]*DueEscalation, error) {
+	query := `
+		UPDATE notifications n
+		SET escalation_step = n.escalation_step + 1,
+			last_escalated_at = NOW()
+		FROM (
+			SELECT c.notification_id, p.policy_id, p.steps -> c.escalation_step AS step, jsonb_array_length(p.steps) AS steps
+			FROM notifications c
+			CROSS JOIN LATERAL (
+				SELECT ep.policy_id, ep.steps
+				FROM rules r
+				JOIN escalation_policies ep ON ep.policy_id = r.escalation_policy_id
+				WHERE r.rule_id::text = ANY(c.rule_ids)
+				ORDER BY r.rule_id
+				LIMIT 1
+			) p
+			WHERE c.status = ANY($1)
+				AND c.group_id IS NULL
+				AND c.escalation_step < jsonb_array_length(p.steps)
+				AND COALESCE(c.last_escalated_at, c.last_notified_at, c.created_at)
+					<= NOW() - make_interval(secs => ((p.steps -> c.escalation_step) ->> 'delay_seconds')::float8)
+			ORDER BY c.created_at
+			LIMIT $2
+			FOR UPDATE OF c SKIP LOCKED
+		) due
+		WHERE n.notification_id = due.notification_id
+		RETURNING n.notification_id, n.client_id, n.alert_id, n.severity, n.source, n.name,
+			n.context, n.rule_ids, n.status, n.created_at, n.updated_at, n.event_ts,
+			due.policy_id, n.escalation_step, due.steps, due.step
+	`
+
+	rows, err := db.conn.QueryContext(ctx, query,
+		pq.Array([]string{StatusSent.String(), StatusPartiallySent.String(), StatusFailed.String()}),
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim due escalations: %w", err)
+	}
+	defer rows.Close()
+
+	var due []*DueEscalation
+	for rows.Next() {
+		var notif Notification
+		var contextJSON sql.NullString
+		var eventTS sql.NullTime
+		var stepJSON []byte
+		escalation := &DueEscalation{Notification: &notif}
+		if err := rows.Scan(
+			&notif.NotificationID,
+			&notif.ClientID,
+			&notif.AlertID,
+			&notif.Severity,
+			&notif.Source,
+			&notif.Name,
+			&contextJSON,
+			pq.Array(&notif.RuleIDs),
+			&notif.Status,
+			&notif.CreatedAt,
+			&notif.UpdatedAt,
+			&eventTS,
+			&escalation.PolicyID,
+			&escalation.Step,
+			&escalation.Steps,
+			&stepJSON,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan due escalation: %w", err)
+		}
+		var step escalationStep
+		if err := json.Unmarshal(stepJSON, &step); err != nil {
+			return nil, fmt.Errorf("failed to decode escalation step %d of policy %s: %w", escalation.Step, escalation.PolicyID, err)
+		}
+		notif.Context = unmarshalContext(contextJSON, notif.NotificationID)
+		notif.EventTime = eventTS.Time
+		escalation.EndpointIDs = step.EndpointIDs
+		due = append(due, escalation)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate due escalations: %w", err)
+	}
+	return due, nil
+}
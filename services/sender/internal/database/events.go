@@ -0,0 +1,54 @@
+// Package database provides database operations for notifications and endpoints tables.
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// journalService identifies the sender as the writer of notification events.
+const journalService = "sender"
+
+// Notification journal event types written by the sender.
+const (
+	EventSendAttempt   = "send_attempt"
+	EventSent          = "sent"
+	EventPartiallySent = "partially_sent"
+	EventFailed        = "failed"
+	EventAcked         = "acked"
+	// Dry-run events, written instead of send_attempt and sent when sends are simulated
+	EventSendSimulated = "send_simulated"
+	EventSimulated     = "simulated"
+	// Reminder events, written by the reminder scheduler
+	EventReminderSent   = "reminder_sent"
+	EventReminderFailed = "reminder_failed"
+	// Escalation events, written by the escalation scheduler
+	EventEscalationSent   = "escalation_sent"
+	EventEscalationFailed = "escalation_failed"
+	// Rate limiting events: some endpoints were over their limit, and the throttled
+	// notification was claimed again to deliver to them
+	EventThrottled       = "throttled"
+	EventThrottleFlushed = "throttle_flushed"
+)
+
+// RecordNotificationEvent appends an event to the notification's journal (notification_events).
+// endpointType is empty for events not tied to an endpoint; eventErr, if non-nil, is stored as the failure reason.
+func (db *DB) RecordNotificationEvent(ctx context.Context, notificationID, eventType, endpointType string, eventErr error) error {
+	var endpoint, errText sql.NullString
+	if endpointType != "" {
+		endpoint = sql.NullString{String: endpointType, Valid: true}
+	}
+	if eventErr != nil {
+		errText = sql.NullString{String: eventErr.Error(), Valid: true}
+	}
+
+	_, err := db.conn.ExecContext(ctx, `
+		INSERT INTO notification_events (notification_id, service, event_type, endpoint_type, error)
+		VALUES ($1, $2, $3, $4, $5)
+	`, notificationID, journalService, eventType, endpoint, errText)
+	if err != nil {
+		return fmt.Errorf("failed to record notification event: %w", err)
+	}
+	return nil
+}
@@ -5,32 +5,35 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"time"
 
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
 	"github.com/lib/pq"
 )
 
-// NotificationStatus represents the status of a notification.
-type NotificationStatus string
+// NotificationStatus is the status of a notification; the lifecycle is defined in the shared package.
+type NotificationStatus = shared.NotificationStatus
 
-// Notification status constants.
+// Notification statuses written by the sender.
 const (
-	StatusPending NotificationStatus = "PENDING"
-	StatusSent    NotificationStatus = "SENT"
-	StatusFailed  NotificationStatus = "FAILED"
+	StatusReceived      = shared.NotificationReceived
+	StatusSending       = shared.NotificationSending
+	StatusSent          = shared.NotificationSent
+	StatusPartiallySent = shared.NotificationPartiallySent
+	StatusFailed        = shared.NotificationFailed
+	// StatusSimulated marks a notification handled in dry-run mode: every send was simulated.
+	StatusSimulated = shared.NotificationSimulated
+	// StatusThrottled marks a notification some of whose endpoints were rate limited; it is
+	// delivered to them once throttled_until passes.
+	StatusThrottled = shared.NotificationThrottled
 )
 
-// String returns the string representation of the status.
-func (s NotificationStatus) String() string {
-	return string(s)
-}
-
-// IsTerminal returns true if the status is a terminal state (SENT or FAILED).
-func (s NotificationStatus) IsTerminal() bool {
-	return s == StatusSent || s == StatusFailed
-}
+// ErrInvalidTransition is returned when a status update is not allowed from the notification's
+// current status, e.g. marking an acknowledged notification as SENT.
+var ErrInvalidTransition = errors.New("invalid notification status transition")
 
 // Notification represents a notification record in the database.
 type Notification struct {
@@ -45,17 +48,36 @@ type Notification struct {
 	Status         string
 	CreatedAt      time.Time
 	UpdatedAt      time.Time
+	// EventTime is when the alert happened; zero if unknown (older notifications and digests).
+	EventTime time.Time
+
+	// Timezone and Locale are the client's settings timestamps are rendered with. They are not
+	// read with the notification; the sender sets them before building payloads.
+	Timezone string
+	Locale   string
+
+	// Grouped holds the notifications listed by a digest (notifications.grouped); empty otherwise.
+	Grouped []*Notification
+
+	// Related holds earlier alerts with the same fingerprint, newest first, listed as context.
+	// Like Timezone, it is not read with the notification; the sender sets it.
+	Related []*RelatedAlert
+
+	// Template renders the message for the endpoint being sent to; nil for the default layout.
+	// The sender sets it on a per-endpoint copy of the notification.
+	Template *Template
 }
 
 // GetNotification retrieves a notification by ID.
 func (db *DB) GetNotification(ctx context.Context, notificationID string) (*Notification, error) {
 	query := `
-		SELECT notification_id, client_id, alert_id, severity, source, name, context, rule_ids, status, created_at, updated_at
+		SELECT notification_id, client_id, alert_id, severity, source, name, context, rule_ids, status, created_at, updated_at, event_ts
 		FROM notifications
 		WHERE notification_id = $1
 	`
 	var notif Notification
 	var contextJSON sql.NullString
+	var eventTS sql.NullTime
 	err := db.conn.QueryRowContext(ctx, query, notificationID).Scan(
 		&notif.NotificationID,
 		&notif.ClientID,
@@ -68,6 +90,7 @@ func (db *DB) GetNotification(ctx context.Context, notificationID string) (*Noti
 		&notif.Status,
 		&notif.CreatedAt,
 		&notif.UpdatedAt,
+		&eventTS,
 	)
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("notification not found: %s", notificationID)
@@ -76,28 +99,35 @@ func (db *DB) GetNotification(ctx context.Context, notificationID string) (*Noti
 		return nil, fmt.Errorf("failed to get notification: %w", err)
 	}
 
-	// Deserialize context JSON
+	notif.Context = unmarshalContext(contextJSON, notificationID)
+	notif.EventTime = eventTS.Time
+
+	return &notif, nil
+}
+
+// unmarshalContext deserializes a notification's context JSON.
+// A NULL, empty, or malformed context yields an empty map.
+func unmarshalContext(contextJSON sql.NullString, notificationID string) map[string]string {
+	context := make(map[string]string)
 	if contextJSON.Valid && contextJSON.String != "" {
-		if err := json.Unmarshal([]byte(contextJSON.String), &notif.Context); err != nil {
+		if err := json.Unmarshal([]byte(contextJSON.String), &context); err != nil {
 			slog.Warn("Failed to unmarshal context JSON", "error", err, "notification_id", notificationID)
-			notif.Context = make(map[string]string)
+			return make(map[string]string)
 		}
-	} else {
-		notif.Context = make(map[string]string)
 	}
-
-	return &notif, nil
+	return context
 }
 
-// UpdateNotificationStatus updates the status of a notification.
-// This is idempotent: if status is already SENT, it's a no-op.
-func (db *DB) UpdateNotificationStatus(ctx context.Context, notificationID string, status string) error {
+// UpdateNotificationStatus moves a notification to status. The update only applies if the
+// notification's current status may transition to status; otherwise it returns an error
+// wrapping ErrInvalidTransition and the notification is left unchanged.
+func (db *DB) UpdateNotificationStatus(ctx context.Context, notificationID string, status NotificationStatus) error {
 	query := `
 		UPDATE notifications
 		SET status = $2, updated_at = NOW()
-		WHERE notification_id = $1
+		WHERE notification_id = $1 AND status = ANY($3)
 	`
-	result, err := db.conn.ExecContext(ctx, query, notificationID, status)
+	result, err := db.conn.ExecContext(ctx, query, notificationID, status.String(), pq.Array(status.Predecessors()))
 	if err != nil {
 		return fmt.Errorf("failed to update notification status: %w", err)
 	}
@@ -108,7 +138,15 @@ func (db *DB) UpdateNotificationStatus(ctx context.Context, notificationID strin
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("notification not found: %s", notificationID)
+		var current string
+		err := db.conn.QueryRowContext(ctx, `SELECT status FROM notifications WHERE notification_id = $1`, notificationID).Scan(&current)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("notification not found: %s", notificationID)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get notification status: %w", err)
+		}
+		return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, current, status)
 	}
 
 	slog.Debug("Updated notification status",
@@ -118,3 +156,96 @@ func (db *DB) UpdateNotificationStatus(ctx context.Context, notificationID strin
 
 	return nil
 }
+
+// GetNotificationsByIDs retrieves the notifications with the given IDs, in the order given.
+// IDs that do not exist are skipped.
+func (db *DB) GetNotificationsByIDs(ctx context.Context, notificationIDs []string) ([]*Notification, error) {
+	query := `
+		SELECT notification_id, client_id, alert_id, severity, source, name, context, rule_ids, status, created_at, updated_at, event_ts
+		FROM notifications
+		WHERE notification_id = ANY($1)
+		ORDER BY array_position($1, notification_id)
+	`
+	rows, err := db.conn.QueryContext(ctx, query, pq.Array(notificationIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notifications: %w", err)
+	}
+	defer rows.Close()
+
+	return scanNotifications(rows)
+}
+
+// scanNotifications scans the notification rows returned by a query selecting the columns of
+// GetNotification, in that order.
+func scanNotifications(rows *sql.Rows) ([]*Notification, error) {
+	var notifications []*Notification
+	for rows.Next() {
+		var notif Notification
+		var contextJSON sql.NullString
+		var eventTS sql.NullTime
+		if err := rows.Scan(
+			&notif.NotificationID,
+			&notif.ClientID,
+			&notif.AlertID,
+			&notif.Severity,
+			&notif.Source,
+			&notif.Name,
+			&contextJSON,
+			pq.Array(&notif.RuleIDs),
+			&notif.Status,
+			&notif.CreatedAt,
+			&notif.UpdatedAt,
+			&eventTS,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan notification: %w", err)
+		}
+		notif.Context = unmarshalContext(contextJSON, notif.NotificationID)
+		notif.EventTime = eventTS.Time
+		notifications = append(notifications, &notif)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate notifications: %w", err)
+	}
+	return notifications, nil
+}
+
+// UpdateNotificationsStatus moves each of the notifications that may transition to status,
+// and leaves the others unchanged. Returns the number of notifications moved.
+func (db *DB) UpdateNotificationsStatus(ctx context.Context, notificationIDs []string, status NotificationStatus) (int64, error) {
+	query := `
+		UPDATE notifications
+		SET status = $2, updated_at = NOW()
+		WHERE notification_id = ANY($1) AND status = ANY($3)
+	`
+	result, err := db.conn.ExecContext(ctx, query, pq.Array(notificationIDs), status.String(), pq.Array(status.Predecessors()))
+	if err != nil {
+		return 0, fmt.Errorf("failed to update notification statuses: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected, nil
+}
+
+// SyncNotificationStatus writes back a status the sender kept outside Postgres (see the
+// statestore package). The write only applies if the notification is still in base, the status
+// the sender moved it from; it returns false if another service moved it meanwhile, e.g.
+// acknowledged it. throttledUntil is zero unless status is THROTTLED.
+func (db *DB) SyncNotificationStatus(ctx context.Context, notificationID string, base, status NotificationStatus, throttledUntil time.Time) (bool, error) {
+	query := `
+		UPDATE notifications
+		SET status = $3, throttled_until = $4, updated_at = NOW()
+		WHERE notification_id = $1 AND status = $2
+	`
+	until := sql.NullTime{Time: throttledUntil, Valid: !throttledUntil.IsZero()}
+	result, err := db.conn.ExecContext(ctx, query, notificationID, base.String(), status.String(), until)
+	if err != nil {
+		return false, fmt.Errorf("failed to sync notification status: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected == 1, nil
+}
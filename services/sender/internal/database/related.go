@@ -0,0 +1,52 @@
+// Package database provides database operations for notifications and endpoints tables.
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RelatedAlert is an earlier notification of the same client with the same fingerprint
+// (severity, source and name) as a notification being sent.
+type RelatedAlert struct {
+	NotificationID string
+	AlertID        string
+	Status         string
+	FiredAt        time.Time // the alert's event time, or the notification's creation if unknown
+	RepeatCount    int       // repeats suppressed while the notification was open
+}
+
+// GetRelatedAlerts returns up to limit notifications of the notification's client with the
+// same fingerprint that were created before it, newest first.
+func (db *DB) GetRelatedAlerts(ctx context.Context, notificationID string, limit int) ([]*RelatedAlert, error) {
+	query := `
+		SELECT r.notification_id, r.alert_id, r.status, COALESCE(r.event_ts, r.created_at), r.repeat_count
+		FROM notifications n
+		JOIN notifications r
+			ON r.client_id = n.client_id
+			AND r.fingerprint = n.fingerprint
+			AND r.created_at < n.created_at
+		WHERE n.notification_id = $1
+		ORDER BY r.created_at DESC
+		LIMIT $2
+	`
+	rows, err := db.conn.QueryContext(ctx, query, notificationID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get related alerts: %w", err)
+	}
+	defer rows.Close()
+
+	var related []*RelatedAlert
+	for rows.Next() {
+		var alert RelatedAlert
+		if err := rows.Scan(&alert.NotificationID, &alert.AlertID, &alert.Status, &alert.FiredAt, &alert.RepeatCount); err != nil {
+			return nil, fmt.Errorf("failed to scan related alert: %w", err)
+		}
+		related = append(related, &alert)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate related alerts: %w", err)
+	}
+	return related, nil
+}
@@ -0,0 +1,92 @@
+// Package database provides database operations for notifications and endpoints tables.
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// DueReminder is a delivered, unacknowledged notification claimed for a reminder.
+type DueReminder struct {
+	Notification *Notification
+	// Number is this reminder's position (1 for the first reminder).
+	Number int
+}
+
+// ClaimDueReminders atomically claims up to limit notifications that are due a reminder and
+// returns them. A notification is due when it is SENT or PARTIALLY_SENT (delivered and not
+// acknowledged or resolved), its severity is in severities, it has had fewer than maxReminders
+// reminders, and interval has passed since it was last delivered. Notifications delivered in a
+// digest (group_id set) are reminded through the digest only. Claiming increments
+// reminder_count and sets last_reminded_at, so concurrent senders never remind the same
+// notification twice.
+func (db *DB) ClaimDueReminders(ctx context.Context, severities []string, interval time.Duration, maxReminders, limit int) ([]*DueReminder, error) {
+	query := `
+		UPDATE notifications n
+		SET reminder_count = n.reminder_count + 1,
+			last_reminded_at = NOW()
+		FROM (
+			SELECT notification_id
+			FROM notifications
+			WHERE status = ANY($1)
+				AND severity = ANY($2)
+				AND reminder_count < $3
+				AND group_id IS NULL
+				AND COALESCE(last_reminded_at, last_notified_at, created_at) <= NOW() - make_interval(secs => $4::float8)
+			ORDER BY created_at
+			LIMIT $5
+			FOR UPDATE SKIP LOCKED
+		) due
+		WHERE n.notification_id = due.notification_id
+		RETURNING n.notification_id, n.client_id, n.alert_id, n.severity, n.source, n.name,
+			n.context, n.rule_ids, n.status, n.created_at, n.updated_at, n.event_ts, n.reminder_count
+	`
+
+	rows, err := db.conn.QueryContext(ctx, query,
+		pq.Array([]string{StatusSent.String(), StatusPartiallySent.String()}),
+		pq.Array(severities),
+		maxReminders,
+		interval.Seconds(),
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim due reminders: %w", err)
+	}
+	defer rows.Close()
+
+	var due []*DueReminder
+	for rows.Next() {
+		var notif Notification
+		var contextJSON sql.NullString
+		var eventTS sql.NullTime
+		reminder := &DueReminder{Notification: &notif}
+		if err := rows.Scan(
+			&notif.NotificationID,
+			&notif.ClientID,
+			&notif.AlertID,
+			&notif.Severity,
+			&notif.Source,
+			&notif.Name,
+			&contextJSON,
+			pq.Array(&notif.RuleIDs),
+			&notif.Status,
+			&notif.CreatedAt,
+			&notif.UpdatedAt,
+			&eventTS,
+			&reminder.Number,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan due reminder: %w", err)
+		}
+		notif.Context = unmarshalContext(contextJSON, notif.NotificationID)
+		notif.EventTime = eventTS.Time
+		due = append(due, reminder)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate due reminders: %w", err)
+	}
+	return due, nil
+}
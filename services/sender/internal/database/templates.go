@@ -0,0 +1,31 @@
+// Package database provides database operations for notifications and endpoints tables.
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Template is a notification template rendering an endpoint's email or Slack message.
+// Subject and Body are Go text/template templates (see shared.NotificationTemplateData).
+type Template struct {
+	TemplateID string
+	Subject    string // email subject; empty keeps the default subject
+	Body       string
+}
+
+// GetTemplate retrieves a notification template by ID.
+func (db *DB) GetTemplate(ctx context.Context, templateID string) (*Template, error) {
+	var tmpl Template
+	err := db.conn.QueryRowContext(ctx, `
+		SELECT template_id, subject, body FROM templates WHERE template_id = $1
+	`, templateID).Scan(&tmpl.TemplateID, &tmpl.Subject, &tmpl.Body)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("template not found: %s", templateID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template: %w", err)
+	}
+	return &tmpl, nil
+}
@@ -0,0 +1,105 @@
+// Package database provides database operations for notifications and endpoints tables.
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ThrottleNotification moves a notification being sent to THROTTLED, to be delivered again
+// once retryAfter has passed. It returns an error wrapping ErrInvalidTransition if the
+// notification is no longer SENDING (e.g. it was acknowledged meanwhile).
+func (db *DB) ThrottleNotification(ctx context.Context, notificationID string, retryAfter time.Duration) error {
+	query := `
+		UPDATE notifications
+		SET status = $2, throttled_until = NOW() + make_interval(secs => $3::float8), updated_at = NOW()
+		WHERE notification_id = $1 AND status = ANY($4)
+	`
+	result, err := db.conn.ExecContext(ctx, query, notificationID, StatusThrottled.String(), retryAfter.Seconds(), pq.Array(StatusThrottled.Predecessors()))
+	if err != nil {
+		return fmt.Errorf("failed to throttle notification: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: %s is no longer %s", ErrInvalidTransition, notificationID, StatusSending)
+	}
+	return nil
+}
+
+// GetDueThrottledNotifications returns up to limit THROTTLED notifications whose
+// throttled_until has passed, longest due first. Notifications held in a digest are throttled
+// with it and flushed through it, so they are not returned.
+func (db *DB) GetDueThrottledNotifications(ctx context.Context, limit int) ([]*Notification, error) {
+	query := `
+		SELECT notification_id, client_id, alert_id, severity, source, name, context, rule_ids, status, created_at, updated_at, event_ts
+		FROM notifications
+		WHERE status = $1
+			AND throttled_until <= NOW()
+			AND group_id IS NULL
+		ORDER BY throttled_until
+		LIMIT $2
+	`
+	rows, err := db.conn.QueryContext(ctx, query, StatusThrottled.String(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due throttled notifications: %w", err)
+	}
+	defer rows.Close()
+
+	return scanNotifications(rows)
+}
+
+// ClaimThrottledNotification moves a THROTTLED notification back to SENDING to deliver it.
+// It returns false if the notification is no longer THROTTLED, e.g. because another sender
+// instance claimed it first.
+func (db *DB) ClaimThrottledNotification(ctx context.Context, notificationID string) (bool, error) {
+	query := `
+		UPDATE notifications
+		SET status = $2, throttled_until = NULL, updated_at = NOW()
+		WHERE notification_id = $1 AND status = $3
+	`
+	result, err := db.conn.ExecContext(ctx, query, notificationID, StatusSending.String(), StatusThrottled.String())
+	if err != nil {
+		return false, fmt.Errorf("failed to claim throttled notification: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected == 1, nil
+}
+
+// GetDigestNotificationIDs returns the IDs of the notifications listed by a digest, oldest
+// first. It returns none if the notification is not a digest.
+func (db *DB) GetDigestNotificationIDs(ctx context.Context, digestID string) ([]string, error) {
+	query := `
+		SELECT n.notification_id
+		FROM notifications n
+		JOIN notification_groups g ON g.group_id = n.group_id
+		WHERE g.digest_notification_id = $1
+		ORDER BY n.created_at, n.notification_id
+	`
+	rows, err := db.conn.QueryContext(ctx, query, digestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get digest notifications: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan digest notification: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate digest notifications: %w", err)
+	}
+	return ids, nil
+}
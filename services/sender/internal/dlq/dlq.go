@@ -0,0 +1,127 @@
+// Package dlq inspects the sender's dead-letter topic and re-drives its entries.
+// Entries are the notification events the sender dead-lettered, with the failure metadata
+// headers added by the shared dead-letter queue (see pkg/kafka).
+package dlq
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	kafkautil "github.com/afikmenashe/alerting-platform/pkg/kafka"
+	pbnotifications "github.com/afikmenashe/alerting-platform/pkg/proto/notifications"
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/protobuf/proto"
+)
+
+// Entry is a dead-lettered notification event and why it was dead-lettered.
+type Entry struct {
+	Partition int   `json:"partition"`
+	Offset    int64 `json:"offset"`
+
+	// The decoded event; empty if it could not be decoded (see DecodeError)
+	NotificationID         string   `json:"notification_id,omitempty"`
+	ClientID               string   `json:"client_id,omitempty"`
+	AlertID                string   `json:"alert_id,omitempty"`
+	GroupedNotificationIDs []string `json:"grouped_notification_ids,omitempty"` // set for digests only
+	DecodeError            string   `json:"decode_error,omitempty"`
+
+	// Failure metadata from the dead-letter headers
+	Error           string    `json:"error"`
+	RetryCount      int       `json:"retry_count"`
+	SourceTopic     string    `json:"source_topic"`
+	SourcePartition int       `json:"source_partition"`
+	SourceOffset    int64     `json:"source_offset"`
+	FailedAt        time.Time `json:"failed_at"`
+
+	Message kafka.Message `json:"-"`
+}
+
+// NewEntry decodes a message of the dead-letter topic. Events dead-lettered from groupedTopic
+// are decoded as digests, all others as notifications.ready events.
+func NewEntry(msg kafka.Message, groupedTopic string) *Entry {
+	entry := &Entry{
+		Partition:   msg.Partition,
+		Offset:      msg.Offset,
+		Error:       header(msg, kafkautil.HeaderDLQError),
+		SourceTopic: header(msg, kafkautil.HeaderDLQSourceTopic),
+		Message:     msg,
+	}
+	entry.RetryCount, _ = strconv.Atoi(header(msg, kafkautil.HeaderDLQRetryCount))
+	entry.SourcePartition, _ = strconv.Atoi(header(msg, kafkautil.HeaderDLQSourcePartition))
+	entry.SourceOffset, _ = strconv.ParseInt(header(msg, kafkautil.HeaderDLQSourceOffset), 10, 64)
+	entry.FailedAt, _ = time.Parse(time.RFC3339Nano, header(msg, kafkautil.HeaderDLQFailedAt))
+
+	if groupedTopic != "" && entry.SourceTopic == groupedTopic {
+		var pb pbnotifications.NotificationGrouped
+		if err := proto.Unmarshal(msg.Value, &pb); err != nil {
+			entry.DecodeError = fmt.Sprintf("failed to unmarshal notification grouped protobuf: %v", err)
+			return entry
+		}
+		entry.NotificationID, entry.ClientID, entry.GroupedNotificationIDs = pb.NotificationId, pb.ClientId, pb.NotificationIds
+	} else {
+		var pb pbnotifications.NotificationReady
+		if err := proto.Unmarshal(msg.Value, &pb); err != nil {
+			entry.DecodeError = fmt.Sprintf("failed to unmarshal notification ready protobuf: %v", err)
+			return entry
+		}
+		entry.NotificationID, entry.ClientID, entry.AlertID = pb.NotificationId, pb.ClientId, pb.AlertId
+	}
+	if entry.NotificationID == "" {
+		entry.DecodeError = "event has no notification_id"
+	}
+	return entry
+}
+
+// ID identifies the entry in the dead-letter topic as partition:offset.
+func (e *Entry) ID() string {
+	return fmt.Sprintf("%d:%d", e.Partition, e.Offset)
+}
+
+// ParseID parses an entry ID of the form partition:offset.
+func ParseID(id string) (partition int, offset int64, err error) {
+	p, o, ok := strings.Cut(id, ":")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid entry %q: expected partition:offset", id)
+	}
+	if partition, err = strconv.Atoi(p); err != nil || partition < 0 {
+		return 0, 0, fmt.Errorf("invalid partition in entry %q", id)
+	}
+	if offset, err = strconv.ParseInt(o, 10, 64); err != nil || offset < 0 {
+		return 0, 0, fmt.Errorf("invalid offset in entry %q", id)
+	}
+	return partition, offset, nil
+}
+
+// Select returns the entries with the given IDs, in the order of ids.
+// Returns an error naming the first ID that is not in entries.
+func Select(entries []*Entry, ids []string) ([]*Entry, error) {
+	byID := make(map[string]*Entry, len(entries))
+	for _, e := range entries {
+		byID[e.ID()] = e
+	}
+	selected := make([]*Entry, 0, len(ids))
+	for _, id := range ids {
+		partition, offset, err := ParseID(id)
+		if err != nil {
+			return nil, err
+		}
+		entry, ok := byID[fmt.Sprintf("%d:%d", partition, offset)]
+		if !ok {
+			return nil, fmt.Errorf("entry %s not found in the dead-letter topic", id)
+		}
+		selected = append(selected, entry)
+	}
+	return selected, nil
+}
+
+// header returns the value of the first header named key, or "".
+func header(msg kafka.Message, key string) string {
+	for _, h := range msg.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,182 @@
+package dlq
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"sender/internal/database"
+
+	kafkautil "github.com/afikmenashe/alerting-platform/pkg/kafka"
+	pbnotifications "github.com/afikmenashe/alerting-platform/pkg/proto/notifications"
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/protobuf/proto"
+)
+
+// deadLettered builds a dead-letter topic message for event, as the sender's queue writes it.
+func deadLettered(t *testing.T, event proto.Message, sourceTopic string, offset int64) kafka.Message {
+	t.Helper()
+	value, err := proto.Marshal(event)
+	if err != nil {
+		t.Fatalf("proto.Marshal() error = %v", err)
+	}
+	source := &kafka.Message{
+		Topic:     sourceTopic,
+		Partition: 2,
+		Offset:    40 + offset,
+		Key:       []byte("client-1"),
+		Value:     value,
+		Headers:   []kafka.Header{{Key: "trace-id", Value: []byte("t-1")}},
+	}
+	msg := kafkautil.DeadLetterMessage(source, errors.New("endpoint lookup failed"), 3, time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC))
+	msg.Partition, msg.Offset = 0, offset
+	return msg
+}
+
+func readyEvent(id string) *pbnotifications.NotificationReady {
+	return &pbnotifications.NotificationReady{NotificationId: id, ClientId: "client-1", AlertId: "alert-" + id}
+}
+
+func TestNewEntry(t *testing.T) {
+	entry := NewEntry(deadLettered(t, readyEvent("n-1"), "notifications.ready", 7), "notifications.grouped")
+	if entry.ID() != "0:7" || entry.NotificationID != "n-1" || entry.ClientID != "client-1" || entry.AlertID != "alert-n-1" {
+		t.Errorf("NewEntry() = %+v, want notification n-1 at 0:7", entry)
+	}
+	if entry.Error != "endpoint lookup failed" || entry.RetryCount != 3 || entry.SourceTopic != "notifications.ready" ||
+		entry.SourcePartition != 2 || entry.SourceOffset != 47 || !entry.FailedAt.Equal(time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("NewEntry() failure metadata = %+v", entry)
+	}
+
+	digest := &pbnotifications.NotificationGrouped{NotificationId: "d-1", ClientId: "client-1", NotificationIds: []string{"n-1", "n-2"}}
+	entry = NewEntry(deadLettered(t, digest, "notifications.grouped", 8), "notifications.grouped")
+	if entry.NotificationID != "d-1" || len(entry.GroupedNotificationIDs) != 2 || entry.DecodeError != "" {
+		t.Errorf("NewEntry(digest) = %+v, want digest d-1 of 2 notifications", entry)
+	}
+
+	msg := deadLettered(t, readyEvent("n-1"), "notifications.ready", 9)
+	msg.Value = []byte{0xff, 0xff}
+	if entry := NewEntry(msg, ""); entry.DecodeError == "" {
+		t.Errorf("NewEntry(garbage) = %+v, want a decode error", entry)
+	}
+}
+
+func TestParseIDAndSelect(t *testing.T) {
+	for _, id := range []string{"", "3", "a:1", "1:b", "-1:2"} {
+		if _, _, err := ParseID(id); err == nil {
+			t.Errorf("ParseID(%q) expected error", id)
+		}
+	}
+
+	entries := []*Entry{{Partition: 0, Offset: 1}, {Partition: 1, Offset: 1}, {Partition: 1, Offset: 2}}
+	selected, err := Select(entries, []string{"1:2", "0:1"})
+	if err != nil || len(selected) != 2 || selected[0] != entries[2] || selected[1] != entries[0] {
+		t.Errorf("Select() = %v, %v, want entries 1:2 and 0:1", selected, err)
+	}
+	if _, err := Select(entries, []string{"2:1"}); err == nil {
+		t.Error("Select() expected error for a missing entry")
+	}
+}
+
+// fakeStore returns notifications with fixed statuses.
+type fakeStore map[string]database.NotificationStatus
+
+func (s fakeStore) GetNotificationsByIDs(ctx context.Context, ids []string) ([]*database.Notification, error) {
+	var found []*database.Notification
+	for _, id := range ids {
+		if status, ok := s[id]; ok {
+			found = append(found, &database.Notification{NotificationID: id, Status: status.String()})
+		}
+	}
+	return found, nil
+}
+
+// fakeWriter records written messages.
+type fakeWriter struct {
+	written []kafka.Message
+	err     error
+}
+
+func (w *fakeWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	if w.err != nil {
+		return w.err
+	}
+	w.written = append(w.written, msgs...)
+	return nil
+}
+
+func TestRedriver_Redrive(t *testing.T) {
+	garbage := deadLettered(t, readyEvent("n-x"), "notifications.ready", 6)
+	garbage.Value = []byte{0xff, 0xff}
+	noSource := deadLettered(t, readyEvent("n-5"), "notifications.ready", 7)
+	noSource.Headers = nil
+	entries := []*Entry{
+		NewEntry(deadLettered(t, readyEvent("n-1"), "notifications.ready", 1), ""),
+		NewEntry(deadLettered(t, readyEvent("n-2"), "notifications.ready", 2), ""), // already SENT
+		NewEntry(deadLettered(t, readyEvent("n-3"), "notifications.ready", 3), ""), // deleted
+		NewEntry(deadLettered(t, readyEvent("n-1"), "notifications.ready", 4), ""), // dead-lettered twice
+		NewEntry(deadLettered(t, readyEvent("n-4"), "notifications.ready", 5), ""), // FAILED
+		NewEntry(garbage, ""),
+		NewEntry(noSource, ""),
+	}
+	store := fakeStore{
+		"n-1": database.StatusReceived,
+		"n-2": database.StatusSent,
+		"n-4": database.StatusFailed,
+		"n-5": database.StatusSending,
+	}
+	writer := &fakeWriter{}
+
+	results, err := NewRedriver(store, writer, "fallback.topic").Redrive(context.Background(), entries, false)
+	if err != nil {
+		t.Fatalf("Redrive() error = %v", err)
+	}
+	want := []struct {
+		redriven bool
+		reason   string
+	}{
+		{true, ""},
+		{false, "already SENT"},
+		{false, "notification not found"},
+		{false, "duplicate of entry 0:1"},
+		{false, "already FAILED"},
+		{false, ""}, // cannot be decoded
+		{true, ""},
+	}
+	for i, w := range want {
+		r := results[i]
+		if r.Redriven != w.redriven || (w.reason != "" && r.SkipReason != w.reason) || (!w.redriven && r.SkipReason == "") {
+			t.Errorf("result %d = %+v, want redriven %v (%s)", i, r, w.redriven, w.reason)
+		}
+	}
+
+	if len(writer.written) != 2 {
+		t.Fatalf("written = %d messages, want 2", len(writer.written))
+	}
+	first := writer.written[0]
+	if first.Topic != "notifications.ready" || string(first.Key) != "client-1" || string(first.Value) != string(entries[0].Message.Value) {
+		t.Errorf("redriven message = %+v, want the original event on notifications.ready", first)
+	}
+	if len(first.Headers) != 1 || first.Headers[0].Key != "trace-id" {
+		t.Errorf("redriven headers = %v, want only the original trace-id header", first.Headers)
+	}
+	if writer.written[1].Topic != "fallback.topic" {
+		t.Errorf("entry without source topic redriven to %q, want fallback.topic", writer.written[1].Topic)
+	}
+}
+
+func TestRedriver_DryRunAndWriteFailure(t *testing.T) {
+	entries := []*Entry{NewEntry(deadLettered(t, readyEvent("n-1"), "notifications.ready", 1), "")}
+	store := fakeStore{"n-1": database.StatusReceived}
+
+	writer := &fakeWriter{}
+	results, err := NewRedriver(store, writer, "").Redrive(context.Background(), entries, true)
+	if err != nil || len(results) != 1 || !results[0].Redriven || len(writer.written) != 0 {
+		t.Errorf("Redrive(dry run) = %+v, %v, wrote %d, want reported but not published", results, err, len(writer.written))
+	}
+
+	writer.err = errors.New("broker down")
+	if _, err := NewRedriver(store, writer, "").Redrive(context.Background(), entries, false); err == nil {
+		t.Error("Redrive() expected error when publishing fails")
+	}
+}
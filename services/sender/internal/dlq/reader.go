@@ -0,0 +1,117 @@
+package dlq
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	kafkautil "github.com/afikmenashe/alerting-platform/pkg/kafka"
+	"github.com/segmentio/kafka-go"
+)
+
+// ReadTopic reads every message currently in topic, by partition and offset, without a
+// consumer group: nothing is committed, so reading never affects other readers of the topic.
+func ReadTopic(ctx context.Context, brokers, topic string) ([]kafka.Message, error) {
+	brokerList := kafkautil.ParseBrokers(brokers)
+	if len(brokerList) == 0 {
+		return nil, fmt.Errorf("brokers cannot be empty")
+	}
+
+	bounds, err := partitionBounds(ctx, brokerList, topic)
+	if err != nil {
+		return nil, err
+	}
+
+	partitions := make([]int, 0, len(bounds))
+	for p := range bounds {
+		partitions = append(partitions, p)
+	}
+	sort.Ints(partitions)
+
+	var messages []kafka.Message
+	for _, p := range partitions {
+		b := bounds[p]
+		if b.first >= b.last {
+			continue
+		}
+		read, err := readPartition(ctx, brokerList, topic, p, b)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, read...)
+	}
+	return messages, nil
+}
+
+// offsetBounds is the first offset of a partition and the offset after its last message.
+type offsetBounds struct {
+	first, last int64
+}
+
+// partitionBounds returns the offsets each partition of topic holds.
+func partitionBounds(ctx context.Context, brokerList []string, topic string) (map[int]offsetBounds, error) {
+	client := &kafka.Client{
+		Addr:      kafka.TCP(brokerList...),
+		Timeout:   kafkautil.ReadTimeout,
+		Transport: kafkautil.Transport(),
+	}
+	meta, err := client.Metadata(ctx, &kafka.MetadataRequest{Topics: []string{topic}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata: %w", err)
+	}
+	var requests []kafka.OffsetRequest
+	for _, t := range meta.Topics {
+		if t.Name != topic {
+			continue
+		}
+		if t.Error != nil {
+			return nil, fmt.Errorf("failed to read metadata of topic %s: %w", topic, t.Error)
+		}
+		for _, p := range t.Partitions {
+			requests = append(requests, kafka.FirstOffsetOf(p.ID), kafka.LastOffsetOf(p.ID))
+		}
+	}
+	if len(requests) == 0 {
+		return nil, fmt.Errorf("topic %s has no partitions", topic)
+	}
+
+	resp, err := client.ListOffsets(ctx, &kafka.ListOffsetsRequest{
+		Topics: map[string][]kafka.OffsetRequest{topic: requests},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list offsets: %w", err)
+	}
+	bounds := make(map[int]offsetBounds)
+	for _, p := range resp.Topics[topic] {
+		if p.Error != nil {
+			return nil, fmt.Errorf("failed to list offsets of %s/%d: %w", topic, p.Partition, p.Error)
+		}
+		bounds[p.Partition] = offsetBounds{first: p.FirstOffset, last: p.LastOffset}
+	}
+	return bounds, nil
+}
+
+// readPartition reads the messages of a partition between its bounds.
+func readPartition(ctx context.Context, brokerList []string, topic string, partition int, b offsetBounds) ([]kafka.Message, error) {
+	readerConfig := kafkautil.NewReaderConfig(brokerList, topic, "")
+	readerConfig.Partition = partition
+	reader := kafka.NewReader(readerConfig)
+	defer reader.Close()
+	if err := reader.SetOffset(b.first); err != nil {
+		return nil, fmt.Errorf("failed to seek %s/%d: %w", topic, partition, err)
+	}
+
+	var messages []kafka.Message
+	for {
+		readCtx, cancel := context.WithTimeout(ctx, kafkautil.ReadTimeout)
+		msg, err := reader.ReadMessage(readCtx)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s/%d: %w", topic, partition, err)
+		}
+		messages = append(messages, msg)
+		if msg.Offset >= b.last-1 {
+			return messages, nil
+		}
+	}
+}
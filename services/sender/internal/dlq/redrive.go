@@ -0,0 +1,138 @@
+package dlq
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"sender/internal/database"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// dlqHeaderPrefix is the prefix of the failure metadata headers, dropped when re-driving.
+const dlqHeaderPrefix = "dlq-"
+
+// NotificationStore looks up the notifications of entries to re-drive.
+type NotificationStore interface {
+	GetNotificationsByIDs(ctx context.Context, notificationIDs []string) ([]*database.Notification, error)
+}
+
+// MessageWriter republishes re-driven events. Each message names its topic.
+type MessageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// Result is the outcome of re-driving one entry.
+type Result struct {
+	Entry          string `json:"entry"`
+	NotificationID string `json:"notification_id,omitempty"`
+	Topic          string `json:"topic,omitempty"`
+	Redriven       bool   `json:"redriven"`
+	SkipReason     string `json:"skip_reason,omitempty"`
+}
+
+// Redriver republishes dead-lettered events to the topic they were dead-lettered from.
+//
+// An entry is skipped if its event cannot be decoded, its notification no longer exists, or
+// delivery of the notification is already over (SENT or another terminal status): the sender
+// would skip the event anyway, and a SENT notification must never be delivered twice.
+type Redriver struct {
+	store        NotificationStore
+	writer       MessageWriter
+	defaultTopic string
+}
+
+// NewRedriver creates a redriver. Entries without a source topic header are republished to
+// defaultTopic.
+func NewRedriver(store NotificationStore, writer MessageWriter, defaultTopic string) *Redriver {
+	return &Redriver{store: store, writer: writer, defaultTopic: defaultTopic}
+}
+
+// Redrive republishes entries, in order, and reports the outcome of each. With dryRun, the
+// entries are checked but nothing is published. Returns the results so far on a publish error.
+func (r *Redriver) Redrive(ctx context.Context, entries []*Entry, dryRun bool) ([]Result, error) {
+	statuses, err := r.statuses(ctx, entries)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, 0, len(entries))
+	redriven := make(map[string]string) // notification ID -> entry ID
+	for _, entry := range entries {
+		result := Result{Entry: entry.ID(), NotificationID: entry.NotificationID, Topic: r.topic(entry)}
+		status, found := statuses[entry.NotificationID]
+		switch {
+		case entry.DecodeError != "":
+			result.SkipReason = "cannot be decoded: " + entry.DecodeError
+		case redriven[entry.NotificationID] != "":
+			result.SkipReason = "duplicate of entry " + redriven[entry.NotificationID]
+		case !found:
+			result.SkipReason = "notification not found"
+		case status.IsTerminal():
+			result.SkipReason = "already " + status.String()
+		}
+		if result.SkipReason != "" {
+			result.Topic = ""
+			results = append(results, result)
+			continue
+		}
+
+		if !dryRun {
+			if err := r.writer.WriteMessages(ctx, redriveMessage(entry, result.Topic)); err != nil {
+				return results, fmt.Errorf("failed to republish entry %s to %s: %w", result.Entry, result.Topic, err)
+			}
+		}
+		result.Redriven = true
+		redriven[entry.NotificationID] = result.Entry
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// statuses returns the current status of the entries' notifications.
+func (r *Redriver) statuses(ctx context.Context, entries []*Entry) (map[string]database.NotificationStatus, error) {
+	var ids []string
+	for _, entry := range entries {
+		if entry.DecodeError == "" {
+			ids = append(ids, entry.NotificationID)
+		}
+	}
+	statuses := make(map[string]database.NotificationStatus, len(ids))
+	if len(ids) == 0 {
+		return statuses, nil
+	}
+	notifications, err := r.store.GetNotificationsByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up notification statuses: %w", err)
+	}
+	for _, n := range notifications {
+		statuses[n.NotificationID] = database.NotificationStatus(n.Status)
+	}
+	return statuses, nil
+}
+
+// topic returns the topic to republish an entry to.
+func (r *Redriver) topic(entry *Entry) string {
+	if entry.SourceTopic != "" {
+		return entry.SourceTopic
+	}
+	return r.defaultTopic
+}
+
+// redriveMessage rebuilds the original event of an entry: same key, value and headers,
+// without the dead-letter metadata.
+func redriveMessage(entry *Entry, topic string) kafka.Message {
+	headers := make([]kafka.Header, 0, len(entry.Message.Headers))
+	for _, h := range entry.Message.Headers {
+		if !strings.HasPrefix(h.Key, dlqHeaderPrefix) {
+			headers = append(headers, h)
+		}
+	}
+	return kafka.Message{
+		Topic:   topic,
+		Key:     entry.Message.Key,
+		Value:   entry.Message.Value,
+		Headers: headers,
+	}
+}
@@ -0,0 +1,166 @@
+// Package endpointcache provides an in-memory cache of rule endpoints for the sender.
+// Every notification needs the endpoints of its rules, and the same rules fire over and over,
+// so caching them by rule_id removes a Postgres query from most sends. Entries expire after a
+// TTL and are dropped as soon as a rule.changed or endpoint.changed event is seen for their rule.
+package endpointcache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"sender/internal/database"
+)
+
+// DefaultTTL is how long a rule's endpoints are cached. Changes normally invalidate entries
+// within seconds; the TTL bounds staleness if an invalidation event is missed.
+const DefaultTTL = time.Minute
+
+// Loader loads endpoints from Postgres.
+type Loader interface {
+	// GetEndpointsByRuleIDs returns the enabled endpoints of the given rules.
+	GetEndpointsByRuleIDs(ctx context.Context, ruleIDs []string) (map[string][]database.Endpoint, error)
+	// GetEnabledEndpoints returns all enabled endpoints.
+	GetEnabledEndpoints(ctx context.Context) (map[string][]database.Endpoint, error)
+}
+
+// Recorder records cache lookups.
+type Recorder interface {
+	RecordEndpointCacheLookup(hit bool)
+}
+
+// entry is the cached endpoints of one rule; rules without endpoints are cached too.
+type entry struct {
+	endpoints []database.Endpoint
+	expires   time.Time
+}
+
+// Cache caches enabled endpoints by rule_id.
+type Cache struct {
+	loader   Loader
+	ttl      time.Duration
+	recorder Recorder
+	now      func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]entry
+	// generation is bumped by every invalidation, so a load that raced with one is not cached
+	generation uint64
+}
+
+// New creates an endpoint cache backed by loader.
+// A non-positive TTL uses the default; a nil recorder records nothing.
+func New(loader Loader, ttl time.Duration, rec Recorder) *Cache {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &Cache{
+		loader:   loader,
+		ttl:      ttl,
+		recorder: rec,
+		now:      time.Now,
+		entries:  make(map[string]entry),
+	}
+}
+
+// GetEndpointsByRuleIDs returns the enabled endpoints of the given rules, loading the rules
+// missing from the cache in a single query. Like the database, it returns a map of
+// rule_id -> []Endpoint without keys for rules that have no endpoints.
+// The returned slices are shared with the cache and must not be modified.
+func (c *Cache) GetEndpointsByRuleIDs(ctx context.Context, ruleIDs []string) (map[string][]database.Endpoint, error) {
+	now := c.now()
+	result := make(map[string][]database.Endpoint)
+	var missing []string
+	hits := 0
+
+	c.mu.Lock()
+	generation := c.generation
+	for _, ruleID := range ruleIDs {
+		if ruleID == "" {
+			continue
+		}
+		e, ok := c.entries[ruleID]
+		if !ok || !now.Before(e.expires) {
+			missing = append(missing, ruleID)
+			continue
+		}
+		hits++
+		if len(e.endpoints) > 0 {
+			result[ruleID] = e.endpoints
+		}
+	}
+	c.mu.Unlock()
+
+	for range hits {
+		c.record(true)
+	}
+	for range missing {
+		c.record(false)
+	}
+	if len(missing) == 0 {
+		return result, nil
+	}
+
+	loaded, err := c.loader.GetEndpointsByRuleIDs(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	for ruleID, endpoints := range loaded {
+		result[ruleID] = endpoints
+	}
+
+	c.mu.Lock()
+	if c.generation == generation {
+		expires := now.Add(c.ttl)
+		for _, ruleID := range missing {
+			c.entries[ruleID] = entry{endpoints: loaded[ruleID], expires: expires}
+		}
+	}
+	c.mu.Unlock()
+	return result, nil
+}
+
+// Preload fills the cache with the endpoints of every rule, so the first notifications after
+// startup do not each query Postgres. Rules not preloaded are loaded on first use.
+func (c *Cache) Preload(ctx context.Context) (int, error) {
+	c.mu.Lock()
+	generation := c.generation
+	c.mu.Unlock()
+
+	loaded, err := c.loader.GetEnabledEndpoints(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation != generation {
+		return 0, nil
+	}
+	expires := c.now().Add(c.ttl)
+	for ruleID, endpoints := range loaded {
+		c.entries[ruleID] = entry{endpoints: endpoints, expires: expires}
+	}
+	return len(loaded), nil
+}
+
+// Invalidate drops the cached endpoints of a rule.
+func (c *Cache) Invalidate(ruleID string) {
+	c.mu.Lock()
+	delete(c.entries, ruleID)
+	c.generation++
+	c.mu.Unlock()
+}
+
+// Len returns the number of cached rules, expired ones included.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+func (c *Cache) record(hit bool) {
+	if c.recorder != nil {
+		c.recorder.RecordEndpointCacheLookup(hit)
+	}
+}
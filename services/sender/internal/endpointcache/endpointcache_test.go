@@ -0,0 +1,192 @@
+package endpointcache
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"sender/internal/database"
+)
+
+// fakeLoader serves endpoints from a map and records the rules queried.
+type fakeLoader struct {
+	endpoints map[string][]database.Endpoint
+	err       error
+	queries   [][]string
+	// onLoad runs during a load, to simulate concurrent invalidations
+	onLoad func()
+}
+
+func (f *fakeLoader) GetEndpointsByRuleIDs(ctx context.Context, ruleIDs []string) (map[string][]database.Endpoint, error) {
+	f.queries = append(f.queries, append([]string(nil), ruleIDs...))
+	if f.onLoad != nil {
+		f.onLoad()
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	result := make(map[string][]database.Endpoint)
+	for _, ruleID := range ruleIDs {
+		if eps, ok := f.endpoints[ruleID]; ok {
+			result[ruleID] = eps
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeLoader) GetEnabledEndpoints(ctx context.Context) (map[string][]database.Endpoint, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.endpoints, nil
+}
+
+// countingRecorder counts cache hits and misses.
+type countingRecorder struct {
+	hits, misses int
+}
+
+func (c *countingRecorder) RecordEndpointCacheLookup(hit bool) {
+	if hit {
+		c.hits++
+	} else {
+		c.misses++
+	}
+}
+
+func newTestCache(loader *fakeLoader, rec Recorder) (*Cache, *time.Time) {
+	now := time.Now()
+	c := New(loader, time.Minute, rec)
+	c.now = func() time.Time { return now }
+	return c, &now
+}
+
+func webhook(ruleID, value string) database.Endpoint {
+	return database.Endpoint{RuleID: ruleID, Type: "webhook", Value: value, Enabled: true}
+}
+
+func TestCache_LoadsMissesOnceUntilTTL(t *testing.T) {
+	loader := &fakeLoader{endpoints: map[string][]database.Endpoint{
+		"rule-1": {webhook("rule-1", "https://hooks.internal/1")},
+		"rule-2": {webhook("rule-2", "https://hooks.internal/2")},
+	}}
+	rec := &countingRecorder{}
+	c, now := newTestCache(loader, rec)
+	ctx := context.Background()
+
+	got, err := c.GetEndpointsByRuleIDs(ctx, []string{"rule-1", "rule-3"})
+	if err != nil {
+		t.Fatalf("GetEndpointsByRuleIDs() error = %v", err)
+	}
+	if len(got) != 1 || len(got["rule-1"]) != 1 {
+		t.Fatalf("GetEndpointsByRuleIDs() = %v, want only rule-1's endpoint", got)
+	}
+
+	// rule-1 and rule-3 (no endpoints) are cached; only rule-2 is loaded
+	got, err = c.GetEndpointsByRuleIDs(ctx, []string{"rule-1", "rule-2", "rule-3", ""})
+	if err != nil {
+		t.Fatalf("GetEndpointsByRuleIDs() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Errorf("GetEndpointsByRuleIDs() = %v, want rule-1 and rule-2", got)
+	}
+	want := [][]string{{"rule-1", "rule-3"}, {"rule-2"}}
+	if !reflect.DeepEqual(loader.queries, want) {
+		t.Errorf("queries = %v, want %v", loader.queries, want)
+	}
+	if rec.hits != 2 || rec.misses != 3 {
+		t.Errorf("hits/misses = %d/%d, want 2/3", rec.hits, rec.misses)
+	}
+
+	// Expired entries are reloaded
+	*now = now.Add(time.Minute)
+	if _, err := c.GetEndpointsByRuleIDs(ctx, []string{"rule-1"}); err != nil {
+		t.Fatalf("GetEndpointsByRuleIDs() error = %v", err)
+	}
+	if len(loader.queries) != 3 {
+		t.Errorf("queries = %v, want rule-1 reloaded after the TTL", loader.queries)
+	}
+}
+
+func TestCache_Invalidate(t *testing.T) {
+	loader := &fakeLoader{endpoints: map[string][]database.Endpoint{
+		"rule-1": {webhook("rule-1", "https://hooks.internal/old")},
+	}}
+	c, _ := newTestCache(loader, nil)
+	ctx := context.Background()
+
+	if _, err := c.GetEndpointsByRuleIDs(ctx, []string{"rule-1"}); err != nil {
+		t.Fatalf("GetEndpointsByRuleIDs() error = %v", err)
+	}
+	loader.endpoints["rule-1"] = []database.Endpoint{webhook("rule-1", "https://hooks.internal/new")}
+	c.Invalidate("rule-1")
+
+	got, err := c.GetEndpointsByRuleIDs(ctx, []string{"rule-1"})
+	if err != nil {
+		t.Fatalf("GetEndpointsByRuleIDs() error = %v", err)
+	}
+	if got["rule-1"][0].Value != "https://hooks.internal/new" {
+		t.Errorf("endpoint = %q, want the value after the change", got["rule-1"][0].Value)
+	}
+}
+
+func TestCache_LoadRacingInvalidationIsNotCached(t *testing.T) {
+	loader := &fakeLoader{endpoints: map[string][]database.Endpoint{
+		"rule-1": {webhook("rule-1", "https://hooks.internal/1")},
+	}}
+	c, _ := newTestCache(loader, nil)
+	loader.onLoad = func() { c.Invalidate("rule-1") }
+
+	if _, err := c.GetEndpointsByRuleIDs(context.Background(), []string{"rule-1"}); err != nil {
+		t.Fatalf("GetEndpointsByRuleIDs() error = %v", err)
+	}
+	if c.Len() != 0 {
+		t.Errorf("Len() = %d, want the racing load not cached", c.Len())
+	}
+}
+
+func TestCache_LoadError(t *testing.T) {
+	loader := &fakeLoader{err: errors.New("connection refused")}
+	c, _ := newTestCache(loader, nil)
+
+	if _, err := c.GetEndpointsByRuleIDs(context.Background(), []string{"rule-1"}); err == nil {
+		t.Fatal("GetEndpointsByRuleIDs() error = nil, want the load error")
+	}
+	if c.Len() != 0 {
+		t.Errorf("Len() = %d, want nothing cached after a failed load", c.Len())
+	}
+}
+
+func TestCache_Preload(t *testing.T) {
+	loader := &fakeLoader{endpoints: map[string][]database.Endpoint{
+		"rule-1": {webhook("rule-1", "https://hooks.internal/1")},
+		"rule-2": {webhook("rule-2", "https://hooks.internal/2")},
+	}}
+	rec := &countingRecorder{}
+	c, _ := newTestCache(loader, rec)
+	ctx := context.Background()
+
+	n, err := c.Preload(ctx)
+	if err != nil || n != 2 {
+		t.Fatalf("Preload() = %d, %v, want 2 rules", n, err)
+	}
+
+	got, err := c.GetEndpointsByRuleIDs(ctx, []string{"rule-1", "rule-2"})
+	if err != nil {
+		t.Fatalf("GetEndpointsByRuleIDs() error = %v", err)
+	}
+	ruleIDs := make([]string, 0, len(got))
+	for ruleID := range got {
+		ruleIDs = append(ruleIDs, ruleID)
+	}
+	sort.Strings(ruleIDs)
+	if !reflect.DeepEqual(ruleIDs, []string{"rule-1", "rule-2"}) || len(loader.queries) != 0 {
+		t.Errorf("GetEndpointsByRuleIDs() = %v after %v queries, want both rules from the cache", got, loader.queries)
+	}
+	if rec.hits != 2 || rec.misses != 0 {
+		t.Errorf("hits/misses = %d/%d, want 2/0", rec.hits, rec.misses)
+	}
+}
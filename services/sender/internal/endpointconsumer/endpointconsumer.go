@@ -0,0 +1,75 @@
+// Package endpointconsumer provides Kafka consumer functionality for endpoint.changed topic.
+package endpointconsumer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	kafkautil "github.com/afikmenashe/alerting-platform/pkg/kafka"
+	protoendpoints "github.com/afikmenashe/alerting-platform/pkg/proto/endpoints"
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/protobuf/proto"
+	"sender/internal/events"
+)
+
+// Consumer wraps a Kafka reader for consuming endpoint.changed events.
+type Consumer struct {
+	reader *kafka.Reader
+	topic  string
+}
+
+// NewConsumer creates a new Kafka consumer for endpoint.changed topic.
+// Every sender instance keeps its own endpoint cache, so each needs its own group ID.
+func NewConsumer(brokers string, topic string, groupID string) (*Consumer, error) {
+	if err := kafkautil.ValidateConsumerParams(brokers, topic, groupID); err != nil {
+		return nil, err
+	}
+
+	// Parse comma-separated broker list
+	brokerList := kafkautil.ParseBrokers(brokers)
+
+	slog.Info("Initializing endpoint.changed Kafka consumer",
+		"brokers", brokerList,
+		"topic", topic,
+		"group_id", groupID,
+	)
+
+	reader := kafka.NewReader(kafkautil.NewReaderConfig(brokerList, topic, groupID))
+
+	return &Consumer{
+		reader: reader,
+		topic:  topic,
+	}, nil
+}
+
+// ReadMessage reads the next endpoint.changed message from Kafka.
+func (c *Consumer) ReadMessage(ctx context.Context) (*events.EndpointChanged, error) {
+	msg, err := c.reader.ReadMessage(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read message from Kafka: %w", err)
+	}
+
+	var pb protoendpoints.EndpointChanged
+	if err := proto.Unmarshal(msg.Value, &pb); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal protobuf endpoint changed event: %w", err)
+	}
+
+	return &events.EndpointChanged{
+		EndpointID: pb.EndpointId,
+		RuleID:     pb.RuleId,
+		Action:     strings.TrimPrefix(pb.Action.String(), "ENDPOINT_ACTION_"),
+	}, nil
+}
+
+// Close gracefully closes the Kafka reader.
+func (c *Consumer) Close() error {
+	slog.Info("Closing endpoint.changed consumer", "topic", c.topic)
+	if err := c.reader.Close(); err != nil {
+		slog.Error("Error closing endpoint.changed consumer", "error", err)
+		return err
+	}
+	slog.Info("Endpoint.changed consumer closed successfully")
+	return nil
+}
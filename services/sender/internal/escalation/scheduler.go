@@ -0,0 +1,174 @@
+// Package escalation implements the escalation scheduler.
+// It delivers notifications that nobody has acknowledged to the next step of their rule's
+// escalation policy, once that step's delay has passed.
+package escalation
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"sender/internal/database"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// DefaultCheckInterval is how often the scheduler scans for due escalation steps.
+const DefaultCheckInterval = 30 * time.Second
+
+// claimBatchSize bounds how many escalations are claimed per scan.
+const claimBatchSize = 100
+
+// ContextKeyEscalation is added to an escalated notification's context as "<step>/<steps>" so receivers can tell it apart.
+const ContextKeyEscalation = "escalation"
+
+// Store provides the notification persistence needed by the scheduler.
+type Store interface {
+	// ClaimDueEscalations atomically claims unacknowledged notifications due their next escalation step.
+	ClaimDueEscalations(ctx context.Context, limit int) ([]*database.DueEscalation, error)
+	// GetEndpointsByIDs returns the enabled endpoints of an escalation step.
+	GetEndpointsByIDs(ctx context.Context, endpointIDs []string) (map[string][]database.Endpoint, error)
+	// RecordNotificationEvent appends an event to the notification journal.
+	RecordNotificationEvent(ctx context.Context, notificationID, eventType, endpointType string, eventErr error) error
+}
+
+// NotificationSender delivers a notification to its endpoints.
+type NotificationSender interface {
+	SendNotification(ctx context.Context, notification *database.Notification, endpoints map[string][]database.Endpoint) error
+}
+
+// MetricsRecorder is the subset of metrics used by the scheduler.
+type MetricsRecorder interface {
+	RecordEscalation(sent bool)
+	RecordJournalError()
+}
+
+// StopSignal reports the platform-wide emergency stop.
+type StopSignal interface {
+	Current() shared.EmergencyStop
+}
+
+// Scheduler escalates unacknowledged notifications through their escalation policies.
+type Scheduler struct {
+	store    Store
+	sender   NotificationSender
+	metrics  MetricsRecorder
+	interval time.Duration
+	stop     StopSignal
+}
+
+// NewScheduler creates a scheduler that scans every interval.
+// A non-positive interval uses DefaultCheckInterval.
+func NewScheduler(store Store, sender NotificationSender, m MetricsRecorder, interval time.Duration) *Scheduler {
+	if interval <= 0 {
+		interval = DefaultCheckInterval
+	}
+	return &Scheduler{
+		store:    store,
+		sender:   sender,
+		metrics:  m,
+		interval: interval,
+	}
+}
+
+// WithStopSignal pauses escalations while the emergency stop is active.
+// Passing nil disables the check.
+func (s *Scheduler) WithStopSignal(stop StopSignal) *Scheduler {
+	s.stop = stop
+	return s
+}
+
+// Run scans for due escalation steps until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	slog.Info("Starting escalation scheduler", "check_interval", s.interval)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Escalation scheduler stopped")
+			return
+		case <-ticker.C:
+			s.CheckOnce(ctx)
+		}
+	}
+}
+
+// CheckOnce performs a single scan and returns the number of escalation steps sent.
+// A claimed step that fails to send is not retried: the sender already retried it, and the
+// next step follows after its own delay.
+// Nothing is claimed while the emergency stop is active, so due steps are sent after resume.
+func (s *Scheduler) CheckOnce(ctx context.Context) int {
+	if s.stop != nil && s.stop.Current().Active {
+		slog.Debug("Emergency stop active, skipping escalation scan")
+		return 0
+	}
+
+	due, err := s.store.ClaimDueEscalations(ctx, claimBatchSize)
+	if err != nil {
+		slog.Error("Failed to check for due escalations", "error", err)
+		return 0
+	}
+
+	sent := 0
+	for _, escalation := range due {
+		if err := s.escalate(ctx, escalation); err != nil {
+			slog.Error("Failed to send escalation",
+				"notification_id", escalation.Notification.NotificationID,
+				"client_id", escalation.Notification.ClientID,
+				"policy_id", escalation.PolicyID,
+				"step", escalation.Step,
+				"error", err,
+			)
+			s.metrics.RecordEscalation(false)
+			s.journal(ctx, escalation.Notification.NotificationID, database.EventEscalationFailed, err)
+			continue
+		}
+
+		sent++
+		s.metrics.RecordEscalation(true)
+		s.journal(ctx, escalation.Notification.NotificationID, database.EventEscalationSent, nil)
+		slog.Info("Escalated unacknowledged notification",
+			"notification_id", escalation.Notification.NotificationID,
+			"alert_id", escalation.Notification.AlertID,
+			"client_id", escalation.Notification.ClientID,
+			"policy_id", escalation.PolicyID,
+			"step", escalation.Step,
+			"steps", escalation.Steps,
+		)
+	}
+	return sent
+}
+
+// escalate sends one notification to its step's endpoints, marked with the step.
+func (s *Scheduler) escalate(ctx context.Context, escalation *database.DueEscalation) error {
+	notification := escalation.Notification
+	endpoints, err := s.store.GetEndpointsByIDs(ctx, escalation.EndpointIDs)
+	if err != nil {
+		return fmt.Errorf("failed to fetch endpoints: %w", err)
+	}
+	if len(endpoints) == 0 {
+		return fmt.Errorf("no enabled endpoints in step %d of policy %s", escalation.Step, escalation.PolicyID)
+	}
+
+	if notification.Context == nil {
+		notification.Context = make(map[string]string)
+	}
+	notification.Context[ContextKeyEscalation] = fmt.Sprintf("%d/%d", escalation.Step, escalation.Steps)
+
+	return s.sender.SendNotification(ctx, notification, endpoints)
+}
+
+// journal records an escalation outcome in the notification journal. Failures are logged and counted.
+func (s *Scheduler) journal(ctx context.Context, notificationID, eventType string, eventErr error) {
+	if err := s.store.RecordNotificationEvent(ctx, notificationID, eventType, "", eventErr); err != nil {
+		slog.Warn("Failed to record notification event",
+			"notification_id", notificationID,
+			"event_type", eventType,
+			"error", err,
+		)
+		s.metrics.RecordJournalError()
+	}
+}
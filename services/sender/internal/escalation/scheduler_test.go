@@ -0,0 +1,186 @@
+package escalation
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"sender/internal/database"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// fakeStore returns fixed due escalations and the endpoints in endpoints, and records journal events.
+type fakeStore struct {
+	due          []*database.DueEscalation
+	claimErr     error
+	endpoints    map[string]database.Endpoint
+	endpointsErr error
+
+	claimed     bool
+	endpointIDs [][]string
+	journal     map[string][]string
+}
+
+func (f *fakeStore) ClaimDueEscalations(ctx context.Context, limit int) ([]*database.DueEscalation, error) {
+	f.claimed = true
+	return f.due, f.claimErr
+}
+
+func (f *fakeStore) GetEndpointsByIDs(ctx context.Context, endpointIDs []string) (map[string][]database.Endpoint, error) {
+	f.endpointIDs = append(f.endpointIDs, endpointIDs)
+	if f.endpointsErr != nil {
+		return nil, f.endpointsErr
+	}
+	result := make(map[string][]database.Endpoint)
+	for _, id := range endpointIDs {
+		if ep, ok := f.endpoints[id]; ok {
+			result[ep.RuleID] = append(result[ep.RuleID], ep)
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeStore) RecordNotificationEvent(ctx context.Context, notificationID, eventType, endpointType string, eventErr error) error {
+	if f.journal == nil {
+		f.journal = make(map[string][]string)
+	}
+	f.journal[notificationID] = append(f.journal[notificationID], eventType)
+	return nil
+}
+
+// fakeSender records sent notifications with their endpoints and fails for the listed notification IDs.
+type fakeSender struct {
+	sent      []*database.Notification
+	endpoints []map[string][]database.Endpoint
+	fail      map[string]bool
+}
+
+func (f *fakeSender) SendNotification(ctx context.Context, notification *database.Notification, endpoints map[string][]database.Endpoint) error {
+	if f.fail[notification.NotificationID] {
+		return errors.New("smtp down")
+	}
+	f.sent = append(f.sent, notification)
+	f.endpoints = append(f.endpoints, endpoints)
+	return nil
+}
+
+// fakeMetrics counts escalation outcomes.
+type fakeMetrics struct {
+	sent, failed, journalErrors int
+}
+
+func (f *fakeMetrics) RecordEscalation(sent bool) {
+	if sent {
+		f.sent++
+	} else {
+		f.failed++
+	}
+}
+
+func (f *fakeMetrics) RecordJournalError() { f.journalErrors++ }
+
+func dueEscalation(id string, step int, endpointIDs ...string) *database.DueEscalation {
+	return &database.DueEscalation{
+		Notification: &database.Notification{NotificationID: id, ClientID: "client-1", Severity: "CRITICAL", RuleIDs: []string{"rule-1"}},
+		PolicyID:     "policy-1",
+		Step:         step,
+		Steps:        2,
+		EndpointIDs:  endpointIDs,
+	}
+}
+
+var stepEndpoints = map[string]database.Endpoint{
+	"ep-lead":    {EndpointID: "ep-lead", RuleID: "rule-2", Type: "email", Value: "lead@example.com", Enabled: true},
+	"ep-manager": {EndpointID: "ep-manager", RuleID: "rule-3", Type: "slack", Value: "https://hooks.slack.com/x", Enabled: true},
+}
+
+func TestScheduler_CheckOnce(t *testing.T) {
+	store := &fakeStore{
+		due:       []*database.DueEscalation{dueEscalation("notif-1", 2, "ep-lead", "ep-manager"), dueEscalation("notif-2", 1, "ep-lead")},
+		endpoints: stepEndpoints,
+	}
+	sender := &fakeSender{fail: map[string]bool{"notif-2": true}}
+	m := &fakeMetrics{}
+
+	sent := NewScheduler(store, sender, m, 0).CheckOnce(context.Background())
+
+	if sent != 1 {
+		t.Errorf("CheckOnce() = %d, want 1", sent)
+	}
+	if len(sender.sent) != 1 || sender.sent[0].Context[ContextKeyEscalation] != "2/2" {
+		t.Fatalf("sent = %+v, want notif-1 marked as escalation step 2/2", sender.sent)
+	}
+	want := map[string][]database.Endpoint{"rule-2": {stepEndpoints["ep-lead"]}, "rule-3": {stepEndpoints["ep-manager"]}}
+	if !reflect.DeepEqual(sender.endpoints[0], want) {
+		t.Errorf("sent to %v, want the step's endpoints %v", sender.endpoints[0], want)
+	}
+	if m.sent != 1 || m.failed != 1 {
+		t.Errorf("metrics sent=%d failed=%d, want 1 and 1", m.sent, m.failed)
+	}
+	if got := store.journal["notif-1"]; !reflect.DeepEqual(got, []string{database.EventEscalationSent}) {
+		t.Errorf("notif-1 journal = %v, want escalation_sent", got)
+	}
+	if got := store.journal["notif-2"]; !reflect.DeepEqual(got, []string{database.EventEscalationFailed}) {
+		t.Errorf("notif-2 journal = %v, want escalation_failed", got)
+	}
+}
+
+// fakeStopSignal reports a fixed emergency stop.
+type fakeStopSignal struct {
+	stop shared.EmergencyStop
+}
+
+func (f *fakeStopSignal) Current() shared.EmergencyStop { return f.stop }
+
+func TestScheduler_CheckOnce_EmergencyStop(t *testing.T) {
+	store := &fakeStore{due: []*database.DueEscalation{dueEscalation("notif-1", 1, "ep-lead")}, endpoints: stepEndpoints}
+	stop := &fakeStopSignal{stop: shared.EmergencyStop{Active: true, Reason: "incident"}}
+	s := NewScheduler(store, &fakeSender{}, &fakeMetrics{}, 0).WithStopSignal(stop)
+
+	if sent := s.CheckOnce(context.Background()); sent != 0 || store.claimed {
+		t.Errorf("CheckOnce() during emergency stop = %d (claimed: %v), want 0 and nothing claimed", sent, store.claimed)
+	}
+
+	stop.stop = shared.EmergencyStop{}
+	if sent := s.CheckOnce(context.Background()); sent != 1 {
+		t.Errorf("CheckOnce() after resume = %d, want 1", sent)
+	}
+}
+
+func TestScheduler_CheckOnce_Errors(t *testing.T) {
+	t.Run("claim failure sends nothing", func(t *testing.T) {
+		sender := &fakeSender{}
+		s := NewScheduler(&fakeStore{claimErr: errors.New("db down")}, sender, &fakeMetrics{}, 0)
+		if sent := s.CheckOnce(context.Background()); sent != 0 || len(sender.sent) != 0 {
+			t.Errorf("CheckOnce() = %d with %d sends, want nothing sent", sent, len(sender.sent))
+		}
+	})
+
+	t.Run("endpoint lookup failure counts as failed escalation", func(t *testing.T) {
+		store := &fakeStore{due: []*database.DueEscalation{dueEscalation("notif-1", 1, "ep-lead")}, endpointsErr: errors.New("db down")}
+		m := &fakeMetrics{}
+		if sent := NewScheduler(store, &fakeSender{}, m, 0).CheckOnce(context.Background()); sent != 0 || m.failed != 1 {
+			t.Errorf("CheckOnce() = %d with %d failures, want 0 and 1", sent, m.failed)
+		}
+	})
+
+	t.Run("step without enabled endpoints counts as failed escalation", func(t *testing.T) {
+		store := &fakeStore{due: []*database.DueEscalation{dueEscalation("notif-1", 1, "ep-deleted")}, endpoints: stepEndpoints}
+		sender := &fakeSender{}
+		m := &fakeMetrics{}
+		if sent := NewScheduler(store, sender, m, 0).CheckOnce(context.Background()); sent != 0 || len(sender.sent) != 0 || m.failed != 1 {
+			t.Errorf("CheckOnce() = %d with %d sends and %d failures, want nothing sent and 1 failure", sent, len(sender.sent), m.failed)
+		}
+		if got := store.journal["notif-1"]; !reflect.DeepEqual(got, []string{database.EventEscalationFailed}) {
+			t.Errorf("journal = %v, want escalation_failed", got)
+		}
+	})
+}
+
+func TestNewScheduler_DefaultInterval(t *testing.T) {
+	if s := NewScheduler(nil, nil, nil, 0); s.interval != DefaultCheckInterval {
+		t.Errorf("interval = %v, want %v", s.interval, DefaultCheckInterval)
+	}
+}
@@ -1,11 +1,35 @@
-// Package events defines the event structures for notifications.ready topic.
+// Package events defines the event structures for notifications.ready, notifications.grouped, rule.changed and endpoint.changed topics.
 package events
 
 // NotificationReady represents a notification ready event from the notifications.ready topic.
 // This event is emitted by the aggregator when a new notification is created.
+// Digest events from the notifications.grouped topic are read into it too, with the
+// digest's grouped notifications in GroupedNotificationIDs.
 type NotificationReady struct {
-	NotificationID string `json:"notification_id"`
-	ClientID       string `json:"client_id"`
-	AlertID        string `json:"alert_id"`
-	SchemaVersion  int    `json:"schema_version"`
+	NotificationID         string   `json:"notification_id"`
+	ClientID               string   `json:"client_id"`
+	AlertID                string   `json:"alert_id"`
+	SchemaVersion          int      `json:"schema_version"`
+	Priority               int      `json:"priority"`                           // derived from severity; 0 for events published before priorities
+	GroupedNotificationIDs []string `json:"grouped_notification_ids,omitempty"` // set for digests only
+	// Times (Unix ms) the notification passed the earlier stages; 0 if unknown, and for digests
+	EvaluatedAtMs  int64 `json:"evaluated_at_ms,omitempty"`
+	AggregatedAtMs int64 `json:"aggregated_at_ms,omitempty"`
+	ReadyAtMs      int64 `json:"ready_at_ms,omitempty"`
+}
+
+// RuleChanged is the part of a rule.changed event the sender needs to invalidate its
+// endpoint cache. The rule payload is not decoded.
+type RuleChanged struct {
+	RuleID   string `json:"rule_id"`
+	ClientID string `json:"client_id"`
+	Version  int    `json:"version"`
+}
+
+// EndpointChanged is the part of an endpoint.changed event the sender needs to invalidate its
+// endpoint cache. Events carry no endpoint value, so the endpoints are reloaded from Postgres.
+type EndpointChanged struct {
+	EndpointID string `json:"endpoint_id"`
+	RuleID     string `json:"rule_id"`
+	Action     string `json:"action"`
 }
@@ -1,6 +1,9 @@
 package metrics
 
 import (
+	"context"
+	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/afikmenashe/alerting-platform/pkg/metrics"
@@ -8,11 +11,11 @@ import (
 
 // CollectorAdapter adapts pkg/metrics.Collector to the Recorder interface.
 type CollectorAdapter struct {
-	collector *metrics.Collector
+	collector metrics.Collector
 }
 
 // NewCollectorAdapter wraps a metrics.Collector to implement Recorder.
-func NewCollectorAdapter(collector *metrics.Collector) *CollectorAdapter {
+func NewCollectorAdapter(collector metrics.Collector) *CollectorAdapter {
 	return &CollectorAdapter{collector: collector}
 }
 
@@ -44,5 +47,145 @@ func (a *CollectorAdapter) RecordSent() {
 	a.collector.IncrementCustom("notifications_sent")
 }
 
+func (a *CollectorAdapter) RecordPartiallySent() {
+	a.collector.IncrementCustom("notifications_partially_sent")
+}
+
+func (a *CollectorAdapter) RecordSimulated() {
+	a.collector.IncrementCustom("notifications_simulated")
+}
+
+func (a *CollectorAdapter) RecordEmergencyStopHold() {
+	a.collector.IncrementCustom("emergency_stop_holds")
+}
+
+func (a *CollectorAdapter) RecordCanaryDelivered(ctx context.Context, alertID string, sentAt time.Time) {
+	a.collector.IncrementCustom("canaries_delivered")
+	if err := a.collector.RecordCanaryDelivered(ctx, alertID, sentAt, time.Now()); err != nil {
+		slog.Warn("Failed to record canary delivery", "alert_id", alertID, "error", err)
+	}
+}
+
+func (a *CollectorAdapter) RecordDeliveryLatency(ctx context.Context, readyAt time.Time) {
+	if err := a.collector.RecordStageLatency(ctx, metrics.LatencyStageDelivery, readyAt, time.Now()); err != nil {
+		slog.Debug("Failed to record delivery latency", "error", err)
+	}
+}
+
+func (a *CollectorAdapter) RecordJournalError() {
+	a.collector.IncrementCustom("journal_errors")
+}
+
+// RecordChannelTimeout counts timed-out attempts in total and per endpoint type.
+func (a *CollectorAdapter) RecordChannelTimeout(endpointType string) {
+	a.collector.IncrementCustom("send_timeouts")
+	a.collector.IncrementCustom("send_timeouts_" + endpointType)
+}
+
+func (a *CollectorAdapter) RecordSendDeadlineExceeded() {
+	a.collector.IncrementCustom("send_deadline_exceeded")
+}
+
+// RecordSendFailure counts failed attempts in total, per failure class, and per endpoint type and class.
+func (a *CollectorAdapter) RecordSendFailure(endpointType, class string) {
+	a.collector.IncrementCustom("send_failures")
+	a.collector.IncrementCustom("send_failures_" + class)
+	a.collector.IncrementCustom("send_failures_" + endpointType + "_" + class)
+}
+
+// RecordConnection counts new and reused HTTP connections; reuse rate is reused / (reused + new).
+func (a *CollectorAdapter) RecordConnection(reused bool) {
+	if reused {
+		a.collector.IncrementCustom("http_conns_reused")
+	} else {
+		a.collector.IncrementCustom("http_conns_new")
+	}
+}
+
+// RecordDNSLookup counts DNS cache hits and misses, and failed lookups.
+func (a *CollectorAdapter) RecordDNSLookup(cacheHit, failed bool) {
+	if cacheHit {
+		a.collector.IncrementCustom("dns_cache_hits")
+	} else {
+		a.collector.IncrementCustom("dns_cache_misses")
+	}
+	if failed {
+		a.collector.IncrementCustom("dns_failures")
+	}
+}
+
+// RecordEndpointCacheLookup counts endpoint cache hits and misses; hit rate is hits / (hits + misses).
+func (a *CollectorAdapter) RecordEndpointCacheLookup(hit bool) {
+	if hit {
+		a.collector.IncrementCustom("endpoint_cache_hits")
+	} else {
+		a.collector.IncrementCustom("endpoint_cache_misses")
+	}
+}
+
+// RecordReminder counts reminders sent and reminders that failed to send.
+func (a *CollectorAdapter) RecordReminder(sent bool) {
+	if sent {
+		a.collector.IncrementCustom("reminders_sent")
+	} else {
+		a.collector.IncrementCustom("reminders_failed")
+	}
+}
+
+// RecordEscalation counts escalation steps sent and steps that failed to send.
+func (a *CollectorAdapter) RecordEscalation(sent bool) {
+	if sent {
+		a.collector.IncrementCustom("escalations_sent")
+	} else {
+		a.collector.IncrementCustom("escalations_failed")
+	}
+}
+
+// RecordOAuthTokenFetch counts OAuth2 token requests and failed ones.
+func (a *CollectorAdapter) RecordOAuthTokenFetch(failed bool) {
+	a.collector.IncrementCustom("oauth_token_fetches")
+	if failed {
+		a.collector.IncrementCustom("oauth_token_failures")
+	}
+}
+
+// RecordShard counts owned and skipped notifications, and all notifications per client hash
+// slice; slice counts show how to split bucket ranges evenly across shards.
+func (a *CollectorAdapter) RecordShard(slice int, owned bool) {
+	if owned {
+		a.collector.IncrementCustom("shard_owned")
+	} else {
+		a.collector.IncrementCustom("shard_skipped")
+	}
+	a.collector.IncrementCustom(fmt.Sprintf("shard_slice_%02d", slice))
+}
+
+func (a *CollectorAdapter) RecordDeadLettered() {
+	a.collector.IncrementCustom("messages_dead_lettered")
+}
+
 // Ensure CollectorAdapter implements Recorder
 var _ Recorder = (*CollectorAdapter)(nil)
+
+func (a *CollectorAdapter) RecordThrottled() {
+	a.collector.IncrementCustom("notifications_throttled")
+}
+
+// RecordEndpointThrottled counts rate-limited endpoint sends in total and per endpoint type.
+func (a *CollectorAdapter) RecordEndpointThrottled(endpointType string) {
+	a.collector.IncrementCustom("endpoint_sends_throttled")
+	a.collector.IncrementCustom("endpoint_sends_throttled_" + endpointType)
+}
+
+func (a *CollectorAdapter) RecordRateLimitError() {
+	a.collector.IncrementCustom("rate_limit_errors")
+}
+
+// RecordStateSync counts notification statuses written back to Postgres and stale ones.
+func (a *CollectorAdapter) RecordStateSync(stale bool) {
+	if stale {
+		a.collector.IncrementCustom("notification_states_stale")
+	} else {
+		a.collector.IncrementCustom("notification_states_synced")
+	}
+}
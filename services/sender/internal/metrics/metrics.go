@@ -2,7 +2,10 @@
 // It uses the null object pattern to avoid nil checks throughout the codebase.
 package metrics
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 // Recorder defines the interface for recording sender metrics.
 // Implementations can record to various backends (Redis, Prometheus, etc.)
@@ -27,6 +30,74 @@ type Recorder interface {
 
 	// RecordSent increments the count of successfully sent notifications.
 	RecordSent()
+
+	// RecordPartiallySent increments the count of notifications sent to some, but not all, endpoints.
+	RecordPartiallySent()
+
+	// RecordSimulated increments the count of notifications whose sends were all simulated (dry-run mode).
+	RecordSimulated()
+
+	// RecordEmergencyStopHold increments the count of notifications held back by the emergency stop.
+	RecordEmergencyStopHold()
+
+	// RecordCanaryDelivered records a pipeline canary reaching the sender.
+	// sentAt is when the canary alert was emitted.
+	RecordCanaryDelivered(ctx context.Context, alertID string, sentAt time.Time)
+
+	// RecordDeliveryLatency records the time from a notification's ready event being published
+	// (readyAt) until it was delivered, as the delivery stage of the pipeline latency.
+	RecordDeliveryLatency(ctx context.Context, readyAt time.Time)
+
+	// RecordJournalError increments the count of failed notification journal writes.
+	RecordJournalError()
+
+	// RecordChannelTimeout increments the count of send attempts that hit their channel timeout.
+	RecordChannelTimeout(endpointType string)
+
+	// RecordSendDeadlineExceeded increments the count of notifications that ran out of send deadline.
+	RecordSendDeadlineExceeded()
+
+	// RecordSendFailure records a failed send attempt by endpoint type and failure class (timeout, 4xx, 5xx, auth, ...).
+	RecordSendFailure(endpointType, class string)
+
+	// RecordConnection records whether an outgoing HTTP request reused a pooled connection.
+	RecordConnection(reused bool)
+
+	// RecordDNSLookup records a Slack/webhook host lookup, whether it was served from cache and whether it failed.
+	RecordDNSLookup(cacheHit, failed bool)
+
+	// RecordEndpointCacheLookup records a rule's endpoints lookup and whether it was served from cache.
+	RecordEndpointCacheLookup(hit bool)
+
+	// RecordReminder records a reminder for an unacknowledged notification and whether it was sent.
+	RecordReminder(sent bool)
+
+	// RecordEscalation records an escalation step for an unacknowledged notification and whether it was sent.
+	RecordEscalation(sent bool)
+
+	// RecordOAuthTokenFetch records an OAuth2 access token request for a webhook and whether it failed.
+	RecordOAuthTokenFetch(failed bool)
+
+	// RecordShard records a notification read by a sharded sender, the client hash slice it
+	// falls into and whether this shard owns it.
+	RecordShard(slice int, owned bool)
+
+	// RecordDeadLettered increments the count of messages moved to the dead-letter topic.
+	RecordDeadLettered()
+
+	// RecordThrottled increments the count of notifications throttled because some of their
+	// endpoints were over their rate limit.
+	RecordThrottled()
+
+	// RecordEndpointThrottled records a send to an endpoint of the given type held back by its rate limit.
+	RecordEndpointThrottled(endpointType string)
+
+	// RecordRateLimitError increments the count of rate limit checks that failed (the send went ahead).
+	RecordRateLimitError()
+
+	// RecordStateSync records a notification status kept in Redis written back to Postgres, and
+	// whether it was stale (another service had moved the notification meanwhile).
+	RecordStateSync(stale bool)
 }
 
 // NoOp is a no-op implementation of Recorder that discards all metrics.
@@ -38,13 +109,34 @@ func NewNoOp() *NoOp {
 	return &NoOp{}
 }
 
-func (n *NoOp) RecordReceived()                   {}
-func (n *NoOp) RecordProcessed(_ time.Duration)   {}
-func (n *NoOp) RecordPublished()                  {}
-func (n *NoOp) RecordError()                      {}
-func (n *NoOp) RecordSkipped()                    {}
-func (n *NoOp) RecordFailed()                     {}
-func (n *NoOp) RecordSent()                       {}
+func (n *NoOp) RecordReceived()                                                {}
+func (n *NoOp) RecordProcessed(_ time.Duration)                                {}
+func (n *NoOp) RecordPublished()                                               {}
+func (n *NoOp) RecordError()                                                   {}
+func (n *NoOp) RecordSkipped()                                                 {}
+func (n *NoOp) RecordFailed()                                                  {}
+func (n *NoOp) RecordSent()                                                    {}
+func (n *NoOp) RecordPartiallySent()                                           {}
+func (n *NoOp) RecordSimulated()                                               {}
+func (n *NoOp) RecordEmergencyStopHold()                                       {}
+func (n *NoOp) RecordCanaryDelivered(_ context.Context, _ string, _ time.Time) {}
+func (n *NoOp) RecordDeliveryLatency(_ context.Context, _ time.Time)           {}
+func (n *NoOp) RecordJournalError()                                            {}
+func (n *NoOp) RecordChannelTimeout(_ string)                                  {}
+func (n *NoOp) RecordSendDeadlineExceeded()                                    {}
+func (n *NoOp) RecordSendFailure(_, _ string)                                  {}
+func (n *NoOp) RecordConnection(_ bool)                                        {}
+func (n *NoOp) RecordDNSLookup(_, _ bool)                                      {}
+func (n *NoOp) RecordEndpointCacheLookup(_ bool)                               {}
+func (n *NoOp) RecordReminder(_ bool)                                          {}
+func (n *NoOp) RecordEscalation(_ bool)                                        {}
+func (n *NoOp) RecordOAuthTokenFetch(_ bool)                                   {}
+func (n *NoOp) RecordShard(_ int, _ bool)                                      {}
+func (n *NoOp) RecordDeadLettered()                                            {}
+func (n *NoOp) RecordThrottled()                                               {}
+func (n *NoOp) RecordEndpointThrottled(_ string)                               {}
+func (n *NoOp) RecordRateLimitError()                                          {}
+func (n *NoOp) RecordStateSync(_ bool)                                         {}
 
 // Ensure NoOp implements Recorder
 var _ Recorder = (*NoOp)(nil)
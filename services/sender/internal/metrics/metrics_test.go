@@ -1,8 +1,11 @@
 package metrics
 
 import (
+	"context"
 	"testing"
 	"time"
+
+	"github.com/afikmenashe/alerting-platform/pkg/metrics"
 )
 
 func TestNoOp_ImplementsRecorder(t *testing.T) {
@@ -20,6 +23,27 @@ func TestNoOp_AllMethodsWork(t *testing.T) {
 	noop.RecordSkipped()
 	noop.RecordFailed()
 	noop.RecordSent()
+	noop.RecordPartiallySent()
+	noop.RecordSimulated()
+	noop.RecordEmergencyStopHold()
+	noop.RecordCanaryDelivered(context.Background(), "alert-1", time.Now())
+	noop.RecordDeliveryLatency(context.Background(), time.Now())
+	noop.RecordJournalError()
+	noop.RecordChannelTimeout("webhook")
+	noop.RecordSendDeadlineExceeded()
+	noop.RecordSendFailure("webhook", "5xx")
+	noop.RecordConnection(true)
+	noop.RecordDNSLookup(true, false)
+	noop.RecordEndpointCacheLookup(true)
+	noop.RecordReminder(true)
+	noop.RecordEscalation(true)
+	noop.RecordOAuthTokenFetch(false)
+	noop.RecordShard(3, true)
+	noop.RecordDeadLettered()
+	noop.RecordThrottled()
+	noop.RecordEndpointThrottled("slack")
+	noop.RecordRateLimitError()
+	noop.RecordStateSync(false)
 }
 
 func TestNewNoOp(t *testing.T) {
@@ -28,3 +52,40 @@ func TestNewNoOp(t *testing.T) {
 		t.Error("NewNoOp() returned nil")
 	}
 }
+
+func TestCollectorAdapter_RecordsCounters(t *testing.T) {
+	collector := metrics.NewMemoryCollector("sender")
+	a := NewCollectorAdapter(collector)
+
+	a.RecordSent()
+	a.RecordSendFailure("webhook", "timeout")
+	a.RecordConnection(true)
+	a.RecordCanaryDelivered(context.Background(), "canary-1", time.Now().Add(-time.Second))
+
+	for _, name := range []string{"notifications_sent", "send_failures", "send_failures_timeout", "send_failures_webhook_timeout", "http_conns_reused", "canaries_delivered"} {
+		if got := collector.Custom(name); got != 1 {
+			t.Errorf("%s = %d, want 1", name, got)
+		}
+	}
+	status, err := collector.GetCanaryStatus(context.Background(), time.Minute)
+	if err != nil || status.Status != metrics.CanaryHealthy || status.LastAlertID != "canary-1" {
+		t.Errorf("GetCanaryStatus() = %+v, %v, want canary-1 delivered", status, err)
+	}
+}
+
+func TestCollectorAdapter_RecordDeliveryLatency(t *testing.T) {
+	collector := metrics.NewMemoryCollector("sender")
+	a := NewCollectorAdapter(collector)
+
+	a.RecordDeliveryLatency(context.Background(), time.Now().Add(-2*time.Second))
+	a.RecordDeliveryLatency(context.Background(), time.Time{}) // ready time unknown
+
+	latency, err := collector.GetPipelineLatency(context.Background())
+	if err != nil {
+		t.Fatalf("GetPipelineLatency() error = %v", err)
+	}
+	delivery := latency.Stages[metrics.LatencyStageDelivery]
+	if delivery == nil || delivery.Count != 1 || delivery.LastLatencyMs < 2000 {
+		t.Errorf("delivery latency = %+v, want one of at least 2000ms", delivery)
+	}
+}
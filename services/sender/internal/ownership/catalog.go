@@ -0,0 +1,103 @@
+package ownership
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SlackChannelAnnotation is the catalog entity annotation holding the team's Slack channel.
+const SlackChannelAnnotation = "slack.com/channel"
+
+// catalogTimeout bounds a single catalog request so a slow catalog cannot stall the pipeline.
+const catalogTimeout = 3 * time.Second
+
+// CatalogClient looks up component ownership in a Backstage-compatible service catalog.
+// The alert source is treated as the component name in the configured namespace.
+type CatalogClient struct {
+	baseURL    string
+	token      string
+	namespace  string
+	httpClient *http.Client
+}
+
+// NewCatalogClient creates a catalog client for baseURL (e.g. https://backstage.example.com).
+// token is sent as a bearer token when non-empty.
+func NewCatalogClient(baseURL, token string) *CatalogClient {
+	return &CatalogClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		token:      token,
+		namespace:  "default",
+		httpClient: &http.Client{Timeout: catalogTimeout},
+	}
+}
+
+// catalogEntity is the subset of a Backstage entity used for ownership.
+type catalogEntity struct {
+	Metadata struct {
+		Name        string            `json:"name"`
+		Annotations map[string]string `json:"annotations"`
+	} `json:"metadata"`
+	Spec struct {
+		Owner string `json:"owner"`
+	} `json:"spec"`
+}
+
+// Lookup fetches the component entity named source and returns its owner.
+// Returns (nil, nil) if the catalog has no such component.
+func (c *CatalogClient) Lookup(ctx context.Context, source string) (*Owner, error) {
+	endpoint := fmt.Sprintf("%s/api/catalog/entities/by-name/component/%s/%s",
+		c.baseURL, url.PathEscape(c.namespace), url.PathEscape(source))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create catalog request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("catalog request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("catalog returned status %d", resp.StatusCode)
+	}
+
+	var entity catalogEntity
+	if err := json.NewDecoder(resp.Body).Decode(&entity); err != nil {
+		return nil, fmt.Errorf("failed to decode catalog entity: %w", err)
+	}
+	if entity.Spec.Owner == "" {
+		return nil, nil
+	}
+
+	return &Owner{
+		Owner:        entity.Spec.Owner,
+		Team:         teamFromOwnerRef(entity.Spec.Owner),
+		SlackChannel: entity.Metadata.Annotations[SlackChannelAnnotation],
+	}, nil
+}
+
+// teamFromOwnerRef extracts the entity name from a Backstage reference
+// such as "group:default/payments-team" -> "payments-team".
+func teamFromOwnerRef(ref string) string {
+	if i := strings.Index(ref, ":"); i >= 0 {
+		ref = ref[i+1:]
+	}
+	if i := strings.LastIndex(ref, "/"); i >= 0 {
+		ref = ref[i+1:]
+	}
+	return ref
+}
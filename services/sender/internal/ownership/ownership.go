@@ -0,0 +1,145 @@
+// Package ownership resolves the owning team's Slack channel of an alert source for owner
+// routing. Lookups go to a Backstage-compatible service catalog first, are cached in memory,
+// and fall back to a static mapping file when the catalog has no answer. The catalog and
+// mapping file use the same format as the aggregator's ownership enrichment.
+//
+// The channel is resolved by the sender itself rather than read from the notification
+// context, which producers can write to.
+package ownership
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// DefaultCacheTTL is how long a resolved (or missing) ownership entry is cached.
+const DefaultCacheTTL = 5 * time.Minute
+
+// DefaultFailureTTL is how long the answer for a source is cached after a failed catalog
+// lookup, so an unavailable catalog is not queried (and waited for) on every notification.
+const DefaultFailureTTL = 30 * time.Second
+
+// Owner describes who owns an alert source.
+type Owner struct {
+	Owner        string `json:"owner"`
+	Team         string `json:"team"`
+	SlackChannel string `json:"slack_channel"`
+}
+
+// Lookup resolves the owner of a source.
+// Implementations return (nil, nil) when the source is unknown.
+type Lookup interface {
+	Lookup(ctx context.Context, source string) (*Owner, error)
+}
+
+// cacheEntry holds a cached lookup result. A nil owner caches a miss.
+type cacheEntry struct {
+	owner     *Owner
+	expiresAt time.Time
+}
+
+// Resolver resolves the Slack channel of alert sources. It is safe for concurrent use.
+type Resolver struct {
+	catalog    Lookup
+	fallback   Lookup
+	ttl        time.Duration
+	failureTTL time.Duration
+	now        func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+}
+
+// NewResolver creates a resolver that queries catalog first and fallback second.
+// Either lookup may be nil. A non-positive ttl uses DefaultCacheTTL.
+func NewResolver(catalog, fallback Lookup, ttl time.Duration) *Resolver {
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	return &Resolver{
+		catalog:    catalog,
+		fallback:   fallback,
+		ttl:        ttl,
+		failureTTL: min(DefaultFailureTTL, ttl),
+		now:        time.Now,
+		cache:      make(map[string]cacheEntry),
+	}
+}
+
+// SlackChannel returns the Slack channel of source's owner, or "" if it is unknown.
+func (r *Resolver) SlackChannel(ctx context.Context, source string) string {
+	if source == "" {
+		return ""
+	}
+	owner := r.resolve(ctx, source)
+	if owner == nil {
+		return ""
+	}
+	return owner.SlackChannel
+}
+
+// resolve returns the owner for source using the cache, the catalog, and the fallback in that order.
+func (r *Resolver) resolve(ctx context.Context, source string) *Owner {
+	if owner, ok := r.cached(source); ok {
+		return owner
+	}
+
+	var owner *Owner
+	if r.catalog != nil {
+		found, err := r.catalog.Lookup(ctx, source)
+		if err != nil {
+			// Cache the fallback's answer briefly so the catalog is retried soon, but not per notification
+			slog.Warn("Service catalog lookup failed, using fallback mapping",
+				"source", source,
+				"error", err,
+			)
+			owner = r.lookupFallback(ctx, source)
+			r.store(source, owner, r.failureTTL)
+			return owner
+		}
+		owner = found
+	}
+
+	if owner == nil {
+		owner = r.lookupFallback(ctx, source)
+	}
+
+	r.store(source, owner, r.ttl)
+	return owner
+}
+
+// lookupFallback queries the static fallback mapping, if configured.
+func (r *Resolver) lookupFallback(ctx context.Context, source string) *Owner {
+	if r.fallback == nil {
+		return nil
+	}
+	owner, err := r.fallback.Lookup(ctx, source)
+	if err != nil {
+		slog.Warn("Fallback ownership lookup failed", "source", source, "error", err)
+		return nil
+	}
+	return owner
+}
+
+func (r *Resolver) cached(source string) (*Owner, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.cache[source]
+	if !ok {
+		return nil, false
+	}
+	if r.now().After(entry.expiresAt) {
+		delete(r.cache, source)
+		return nil, false
+	}
+	return entry.owner, true
+}
+
+func (r *Resolver) store(source string, owner *Owner, ttl time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cache[source] = cacheEntry{owner: owner, expiresAt: r.now().Add(ttl)}
+}
@@ -0,0 +1,196 @@
+package ownership
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeLookup is a test fake for Lookup that counts calls.
+type fakeLookup struct {
+	owner *Owner
+	err   error
+	calls int
+}
+
+func (f *fakeLookup) Lookup(ctx context.Context, source string) (*Owner, error) {
+	f.calls++
+	return f.owner, f.err
+}
+
+func TestResolver_SlackChannel(t *testing.T) {
+	payments := &Owner{Owner: "group:default/payments", Team: "payments", SlackChannel: "#payments-alerts"}
+
+	tests := []struct {
+		name     string
+		catalog  *fakeLookup
+		fallback *fakeLookup
+		source   string
+		want     string
+	}{
+		{
+			name:     "catalog hit",
+			catalog:  &fakeLookup{owner: payments},
+			fallback: &fakeLookup{},
+			source:   "payments",
+			want:     "#payments-alerts",
+		},
+		{
+			name:     "catalog miss uses fallback",
+			catalog:  &fakeLookup{},
+			fallback: &fakeLookup{owner: &Owner{SlackChannel: "#core"}},
+			source:   "payments",
+			want:     "#core",
+		},
+		{
+			name:     "catalog error uses fallback",
+			catalog:  &fakeLookup{err: errors.New("boom")},
+			fallback: &fakeLookup{owner: &Owner{SlackChannel: "#core"}},
+			source:   "payments",
+			want:     "#core",
+		},
+		{
+			name:     "unknown source",
+			catalog:  &fakeLookup{},
+			fallback: &fakeLookup{},
+			source:   "payments",
+		},
+		{
+			name:     "no source",
+			catalog:  &fakeLookup{owner: payments},
+			fallback: &fakeLookup{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewResolver(tt.catalog, tt.fallback, time.Minute)
+			if got := r.SlackChannel(context.Background(), tt.source); got != tt.want {
+				t.Errorf("SlackChannel() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolver_Caching(t *testing.T) {
+	catalog := &fakeLookup{owner: &Owner{SlackChannel: "#payments"}}
+	r := NewResolver(catalog, nil, time.Minute)
+	now := time.Now()
+	r.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		r.SlackChannel(context.Background(), "payments")
+	}
+	if catalog.calls != 1 {
+		t.Errorf("catalog calls = %d, want 1 (cached)", catalog.calls)
+	}
+
+	// Expire the entry
+	now = now.Add(2 * time.Minute)
+	r.SlackChannel(context.Background(), "payments")
+	if catalog.calls != 2 {
+		t.Errorf("catalog calls after expiry = %d, want 2", catalog.calls)
+	}
+}
+
+func TestResolver_CatalogErrorCachedBriefly(t *testing.T) {
+	catalog := &fakeLookup{err: errors.New("unavailable")}
+	r := NewResolver(catalog, nil, time.Minute)
+	now := time.Now()
+	r.now = func() time.Time { return now }
+
+	r.SlackChannel(context.Background(), "payments")
+	r.SlackChannel(context.Background(), "payments")
+	if catalog.calls != 1 {
+		t.Errorf("catalog calls = %d, want 1 (failures are cached)", catalog.calls)
+	}
+
+	now = now.Add(DefaultFailureTTL + time.Second)
+	r.SlackChannel(context.Background(), "payments")
+	if catalog.calls != 2 {
+		t.Errorf("catalog calls after the failure TTL = %d, want 2", catalog.calls)
+	}
+}
+
+func TestCatalogClient_Lookup(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/catalog/entities/by-name/component/default/payments":
+			w.Write([]byte(`{"metadata":{"name":"payments","annotations":{"slack.com/channel":"#payments-alerts"}},"spec":{"owner":"group:default/payments-team"}}`))
+		case "/api/catalog/entities/by-name/component/default/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewCatalogClient(server.URL+"/", "secret")
+
+	owner, err := client.Lookup(context.Background(), "payments")
+	if err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+	want := Owner{Owner: "group:default/payments-team", Team: "payments-team", SlackChannel: "#payments-alerts"}
+	if owner == nil || *owner != want {
+		t.Errorf("Lookup() = %+v, want %+v", owner, want)
+	}
+
+	owner, err = client.Lookup(context.Background(), "unknown")
+	if err != nil || owner != nil {
+		t.Errorf("Lookup(unknown) = %+v, %v; want nil, nil", owner, err)
+	}
+
+	if _, err := client.Lookup(context.Background(), "broken"); err == nil {
+		t.Error("Lookup(broken) expected error")
+	}
+}
+
+func TestLoadStaticMapping(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "owners.json")
+	content := `{"payments": {"owner": "group:default/payments", "team": "payments", "slack_channel": "#payments"}}`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	mapping, err := LoadStaticMapping(path)
+	if err != nil {
+		t.Fatalf("LoadStaticMapping() error = %v", err)
+	}
+
+	owner, _ := mapping.Lookup(context.Background(), "payments")
+	if owner == nil || owner.SlackChannel != "#payments" {
+		t.Errorf("Lookup(payments) = %+v", owner)
+	}
+	owner, _ = mapping.Lookup(context.Background(), "unknown")
+	if owner != nil {
+		t.Errorf("Lookup(unknown) = %+v, want nil", owner)
+	}
+
+	if _, err := LoadStaticMapping(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("LoadStaticMapping(missing) expected error")
+	}
+}
+
+func TestTeamFromOwnerRef(t *testing.T) {
+	tests := map[string]string{
+		"group:default/payments": "payments",
+		"default/payments":       "payments",
+		"payments":               "payments",
+		"user:jane":              "jane",
+	}
+	for ref, want := range tests {
+		if got := teamFromOwnerRef(ref); got != want {
+			t.Errorf("teamFromOwnerRef(%q) = %q, want %q", ref, got, want)
+		}
+	}
+}
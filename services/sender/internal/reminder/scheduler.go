@@ -0,0 +1,202 @@
+// Package reminder implements the reminder scheduler.
+// It periodically re-sends delivered notifications that nobody has acknowledged,
+// every Interval and up to MaxReminders times, for the severities in the policy.
+package reminder
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"sender/internal/database"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// Reminder defaults.
+const (
+	// DefaultCheckInterval is how often the scheduler scans for due reminders.
+	DefaultCheckInterval = 30 * time.Second
+	// DefaultMaxReminders is how many reminders a notification gets at most.
+	DefaultMaxReminders = 3
+	// DefaultSeverities are the severities that get reminders.
+	DefaultSeverities = "CRITICAL"
+)
+
+// claimBatchSize bounds how many reminders are claimed per scan.
+const claimBatchSize = 100
+
+// ContextKeyReminder is added to a reminder's context as "<n>/<max>" so receivers can tell it apart.
+const ContextKeyReminder = "reminder"
+
+// Policy decides which notifications get reminders and how often.
+type Policy struct {
+	Severities   []string
+	Interval     time.Duration // time after delivery, and between reminders
+	MaxReminders int
+}
+
+// Store provides the notification persistence needed by the scheduler.
+type Store interface {
+	// ClaimDueReminders atomically claims delivered, unacknowledged notifications due a reminder.
+	ClaimDueReminders(ctx context.Context, severities []string, interval time.Duration, maxReminders, limit int) ([]*database.DueReminder, error)
+	// GetEndpointsByRuleIDs returns the endpoints of the notification's rules.
+	GetEndpointsByRuleIDs(ctx context.Context, ruleIDs []string) (map[string][]database.Endpoint, error)
+	// RecordNotificationEvent appends an event to the notification journal.
+	RecordNotificationEvent(ctx context.Context, notificationID, eventType, endpointType string, eventErr error) error
+}
+
+// NotificationSender delivers a notification to its endpoints.
+type NotificationSender interface {
+	SendNotification(ctx context.Context, notification *database.Notification, endpoints map[string][]database.Endpoint) error
+}
+
+// MetricsRecorder is the subset of metrics used by the scheduler.
+type MetricsRecorder interface {
+	RecordReminder(sent bool)
+	RecordJournalError()
+}
+
+// StopSignal reports the platform-wide emergency stop.
+type StopSignal interface {
+	Current() shared.EmergencyStop
+}
+
+// Scheduler re-sends unacknowledged notifications according to a Policy.
+type Scheduler struct {
+	store    Store
+	sender   NotificationSender
+	metrics  MetricsRecorder
+	policy   Policy
+	interval time.Duration
+	stop     StopSignal
+}
+
+// NewScheduler creates a scheduler that scans every interval.
+// A non-positive interval uses DefaultCheckInterval.
+func NewScheduler(store Store, sender NotificationSender, m MetricsRecorder, policy Policy, interval time.Duration) *Scheduler {
+	if interval <= 0 {
+		interval = DefaultCheckInterval
+	}
+	return &Scheduler{
+		store:    store,
+		sender:   sender,
+		metrics:  m,
+		policy:   policy,
+		interval: interval,
+	}
+}
+
+// WithStopSignal pauses reminders while the emergency stop is active.
+// Passing nil disables the check.
+func (s *Scheduler) WithStopSignal(stop StopSignal) *Scheduler {
+	s.stop = stop
+	return s
+}
+
+// Run scans for due reminders until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	slog.Info("Starting reminder scheduler",
+		"check_interval", s.interval,
+		"severities", s.policy.Severities,
+		"reminder_interval", s.policy.Interval,
+		"max_reminders", s.policy.MaxReminders,
+	)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Reminder scheduler stopped")
+			return
+		case <-ticker.C:
+			s.CheckOnce(ctx)
+		}
+	}
+}
+
+// CheckOnce performs a single scan and returns the number of reminders sent.
+// A claimed reminder that fails to send still counts against MaxReminders:
+// the sender already retried it, and the next reminder follows after Interval.
+// Nothing is claimed while the emergency stop is active, so due reminders are sent after resume.
+func (s *Scheduler) CheckOnce(ctx context.Context) int {
+	if s.stop != nil && s.stop.Current().Active {
+		slog.Debug("Emergency stop active, skipping reminder scan")
+		return 0
+	}
+
+	due, err := s.store.ClaimDueReminders(ctx, s.policy.Severities, s.policy.Interval, s.policy.MaxReminders, claimBatchSize)
+	if err != nil {
+		slog.Error("Failed to check for due reminders", "error", err)
+		return 0
+	}
+
+	sent := 0
+	for _, reminder := range due {
+		if err := s.remind(ctx, reminder); err != nil {
+			slog.Error("Failed to send reminder",
+				"notification_id", reminder.Notification.NotificationID,
+				"client_id", reminder.Notification.ClientID,
+				"reminder", reminder.Number,
+				"error", err,
+			)
+			s.metrics.RecordReminder(false)
+			s.journal(ctx, reminder.Notification.NotificationID, database.EventReminderFailed, err)
+			continue
+		}
+
+		sent++
+		s.metrics.RecordReminder(true)
+		s.journal(ctx, reminder.Notification.NotificationID, database.EventReminderSent, nil)
+		slog.Info("Sent reminder for unacknowledged notification",
+			"notification_id", reminder.Notification.NotificationID,
+			"alert_id", reminder.Notification.AlertID,
+			"client_id", reminder.Notification.ClientID,
+			"reminder", reminder.Number,
+			"max_reminders", s.policy.MaxReminders,
+		)
+	}
+	return sent
+}
+
+// remind re-sends one notification to its rules' endpoints, marked as a reminder.
+func (s *Scheduler) remind(ctx context.Context, reminder *database.DueReminder) error {
+	notification := reminder.Notification
+	endpoints, err := s.store.GetEndpointsByRuleIDs(ctx, notification.RuleIDs)
+	if err != nil {
+		return fmt.Errorf("failed to fetch endpoints: %w", err)
+	}
+
+	if notification.Context == nil {
+		notification.Context = make(map[string]string)
+	}
+	notification.Context[ContextKeyReminder] = fmt.Sprintf("%d/%d", reminder.Number, s.policy.MaxReminders)
+
+	return s.sender.SendNotification(ctx, notification, endpoints)
+}
+
+// journal records a reminder outcome in the notification journal. Failures are logged and counted.
+func (s *Scheduler) journal(ctx context.Context, notificationID, eventType string, eventErr error) {
+	if err := s.store.RecordNotificationEvent(ctx, notificationID, eventType, "", eventErr); err != nil {
+		slog.Warn("Failed to record notification event",
+			"notification_id", notificationID,
+			"event_type", eventType,
+			"error", err,
+		)
+		s.metrics.RecordJournalError()
+	}
+}
+
+// ParseSeverities parses a comma-separated severity list, trimming and upper-casing each entry.
+func ParseSeverities(s string) []string {
+	var severities []string
+	for _, part := range strings.Split(s, ",") {
+		if sev := strings.ToUpper(strings.TrimSpace(part)); sev != "" {
+			severities = append(severities, sev)
+		}
+	}
+	return severities
+}
@@ -0,0 +1,168 @@
+package reminder
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"sender/internal/database"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// fakeStore returns fixed due reminders and records journal events.
+type fakeStore struct {
+	due          []*database.DueReminder
+	claimErr     error
+	endpointsErr error
+
+	claimSeverities []string
+	claimInterval   time.Duration
+	claimMax        int
+	journal         map[string][]string
+}
+
+func (f *fakeStore) ClaimDueReminders(ctx context.Context, severities []string, interval time.Duration, maxReminders, limit int) ([]*database.DueReminder, error) {
+	f.claimSeverities = severities
+	f.claimInterval = interval
+	f.claimMax = maxReminders
+	return f.due, f.claimErr
+}
+
+func (f *fakeStore) GetEndpointsByRuleIDs(ctx context.Context, ruleIDs []string) (map[string][]database.Endpoint, error) {
+	if f.endpointsErr != nil {
+		return nil, f.endpointsErr
+	}
+	return map[string][]database.Endpoint{"email": {{Type: "email", Value: "oncall@example.com"}}}, nil
+}
+
+func (f *fakeStore) RecordNotificationEvent(ctx context.Context, notificationID, eventType, endpointType string, eventErr error) error {
+	if f.journal == nil {
+		f.journal = make(map[string][]string)
+	}
+	f.journal[notificationID] = append(f.journal[notificationID], eventType)
+	return nil
+}
+
+// fakeSender records sent notifications and fails for the listed notification IDs.
+type fakeSender struct {
+	sent []*database.Notification
+	fail map[string]bool
+}
+
+func (f *fakeSender) SendNotification(ctx context.Context, notification *database.Notification, endpoints map[string][]database.Endpoint) error {
+	if f.fail[notification.NotificationID] {
+		return errors.New("smtp down")
+	}
+	f.sent = append(f.sent, notification)
+	return nil
+}
+
+// fakeMetrics counts reminder outcomes.
+type fakeMetrics struct {
+	sent, failed, journalErrors int
+}
+
+func (f *fakeMetrics) RecordReminder(sent bool) {
+	if sent {
+		f.sent++
+	} else {
+		f.failed++
+	}
+}
+
+func (f *fakeMetrics) RecordJournalError() { f.journalErrors++ }
+
+func dueReminder(id string, number int) *database.DueReminder {
+	return &database.DueReminder{
+		Notification: &database.Notification{NotificationID: id, ClientID: "client-1", Severity: "CRITICAL", RuleIDs: []string{"rule-1"}},
+		Number:       number,
+	}
+}
+
+func TestScheduler_CheckOnce(t *testing.T) {
+	store := &fakeStore{due: []*database.DueReminder{dueReminder("notif-1", 1), dueReminder("notif-2", 3)}}
+	sender := &fakeSender{fail: map[string]bool{"notif-2": true}}
+	m := &fakeMetrics{}
+	policy := Policy{Severities: []string{"CRITICAL"}, Interval: 15 * time.Minute, MaxReminders: 3}
+
+	sent := NewScheduler(store, sender, m, policy, time.Minute).CheckOnce(context.Background())
+
+	if sent != 1 {
+		t.Errorf("CheckOnce() = %d, want 1", sent)
+	}
+	if !reflect.DeepEqual(store.claimSeverities, []string{"CRITICAL"}) || store.claimInterval != 15*time.Minute || store.claimMax != 3 {
+		t.Errorf("claimed with %v/%v/%d, want the policy", store.claimSeverities, store.claimInterval, store.claimMax)
+	}
+	if len(sender.sent) != 1 || sender.sent[0].Context[ContextKeyReminder] != "1/3" {
+		t.Fatalf("sent = %+v, want notif-1 marked as reminder 1/3", sender.sent)
+	}
+	if m.sent != 1 || m.failed != 1 {
+		t.Errorf("metrics sent=%d failed=%d, want 1 and 1", m.sent, m.failed)
+	}
+	if got := store.journal["notif-1"]; !reflect.DeepEqual(got, []string{database.EventReminderSent}) {
+		t.Errorf("notif-1 journal = %v, want reminder_sent", got)
+	}
+	if got := store.journal["notif-2"]; !reflect.DeepEqual(got, []string{database.EventReminderFailed}) {
+		t.Errorf("notif-2 journal = %v, want reminder_failed", got)
+	}
+}
+
+// fakeStopSignal reports a fixed emergency stop.
+type fakeStopSignal struct {
+	stop shared.EmergencyStop
+}
+
+func (f *fakeStopSignal) Current() shared.EmergencyStop { return f.stop }
+
+func TestScheduler_CheckOnce_EmergencyStop(t *testing.T) {
+	store := &fakeStore{due: []*database.DueReminder{dueReminder("notif-1", 1)}}
+	sender := &fakeSender{}
+	stop := &fakeStopSignal{stop: shared.EmergencyStop{Active: true, Reason: "incident"}}
+	s := NewScheduler(store, sender, &fakeMetrics{}, Policy{MaxReminders: 1}, 0).WithStopSignal(stop)
+
+	if sent := s.CheckOnce(context.Background()); sent != 0 || store.claimMax != 0 {
+		t.Errorf("CheckOnce() during emergency stop = %d (claimed: %v), want 0 and nothing claimed", sent, store.claimMax != 0)
+	}
+
+	stop.stop = shared.EmergencyStop{}
+	if sent := s.CheckOnce(context.Background()); sent != 1 {
+		t.Errorf("CheckOnce() after resume = %d, want 1", sent)
+	}
+}
+
+func TestScheduler_CheckOnce_Errors(t *testing.T) {
+	t.Run("claim failure sends nothing", func(t *testing.T) {
+		sender := &fakeSender{}
+		s := NewScheduler(&fakeStore{claimErr: errors.New("db down")}, sender, &fakeMetrics{}, Policy{MaxReminders: 1}, 0)
+		if sent := s.CheckOnce(context.Background()); sent != 0 || len(sender.sent) != 0 {
+			t.Errorf("CheckOnce() = %d with %d sends, want nothing sent", sent, len(sender.sent))
+		}
+	})
+
+	t.Run("endpoint lookup failure counts as failed reminder", func(t *testing.T) {
+		store := &fakeStore{due: []*database.DueReminder{dueReminder("notif-1", 1)}, endpointsErr: errors.New("db down")}
+		m := &fakeMetrics{}
+		if sent := NewScheduler(store, &fakeSender{}, m, Policy{MaxReminders: 1}, 0).CheckOnce(context.Background()); sent != 0 {
+			t.Errorf("CheckOnce() = %d, want 0", sent)
+		}
+		if m.failed != 1 {
+			t.Errorf("failed reminders = %d, want 1", m.failed)
+		}
+	})
+}
+
+func TestNewScheduler_DefaultInterval(t *testing.T) {
+	if s := NewScheduler(nil, nil, nil, Policy{}, 0); s.interval != DefaultCheckInterval {
+		t.Errorf("interval = %v, want %v", s.interval, DefaultCheckInterval)
+	}
+}
+
+func TestParseSeverities(t *testing.T) {
+	got := ParseSeverities(" critical, HIGH ,,")
+	if !reflect.DeepEqual(got, []string{"CRITICAL", "HIGH"}) {
+		t.Errorf("ParseSeverities() = %v, want [CRITICAL HIGH]", got)
+	}
+}
@@ -0,0 +1,74 @@
+// Package ruleconsumer provides Kafka consumer functionality for rule.changed topic.
+package ruleconsumer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	kafkautil "github.com/afikmenashe/alerting-platform/pkg/kafka"
+	protorules "github.com/afikmenashe/alerting-platform/pkg/proto/rules"
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/protobuf/proto"
+	"sender/internal/events"
+)
+
+// Consumer wraps a Kafka reader for consuming rule.changed events.
+type Consumer struct {
+	reader *kafka.Reader
+	topic  string
+}
+
+// NewConsumer creates a new Kafka consumer for rule.changed topic.
+// Every sender instance keeps its own endpoint cache, so each needs its own group ID.
+func NewConsumer(brokers string, topic string, groupID string) (*Consumer, error) {
+	if err := kafkautil.ValidateConsumerParams(brokers, topic, groupID); err != nil {
+		return nil, err
+	}
+
+	// Parse comma-separated broker list
+	brokerList := kafkautil.ParseBrokers(brokers)
+
+	slog.Info("Initializing rule.changed Kafka consumer",
+		"brokers", brokerList,
+		"topic", topic,
+		"group_id", groupID,
+	)
+
+	reader := kafka.NewReader(kafkautil.NewReaderConfig(brokerList, topic, groupID))
+
+	return &Consumer{
+		reader: reader,
+		topic:  topic,
+	}, nil
+}
+
+// ReadMessage reads the next rule.changed message from Kafka.
+func (c *Consumer) ReadMessage(ctx context.Context) (*events.RuleChanged, error) {
+	msg, err := c.reader.ReadMessage(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read message from Kafka: %w", err)
+	}
+
+	var pb protorules.RuleChanged
+	if err := proto.Unmarshal(msg.Value, &pb); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal protobuf rule changed event: %w", err)
+	}
+
+	return &events.RuleChanged{
+		RuleID:   pb.RuleId,
+		ClientID: pb.ClientId,
+		Version:  int(pb.Version),
+	}, nil
+}
+
+// Close gracefully closes the Kafka reader.
+func (c *Consumer) Close() error {
+	slog.Info("Closing rule.changed consumer", "topic", c.topic)
+	if err := c.reader.Close(); err != nil {
+		slog.Error("Error closing rule.changed consumer", "error", err)
+		return err
+	}
+	slog.Info("Rule.changed consumer closed successfully")
+	return nil
+}
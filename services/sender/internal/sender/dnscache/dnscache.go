@@ -0,0 +1,140 @@
+// Package dnscache provides an in-process DNS cache for outgoing Slack and webhook requests.
+// Notifications to the same host are frequent, so caching lookups removes a resolver round trip
+// from most sends and keeps delivery working through short resolver outages.
+package dnscache
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// Cache defaults.
+const (
+	// DefaultTTL is how long a successful lookup is cached. The system resolver does not expose
+	// record TTLs, so this acts as an upper bound and is kept short enough to follow typical
+	// DNS changes (load balancer failover, IP rotation).
+	DefaultTTL = 30 * time.Second
+	// DefaultNegativeTTL is how long a failed lookup is cached, so a broken host does not
+	// hit the resolver on every retry.
+	DefaultNegativeTTL = 5 * time.Second
+)
+
+// Recorder records cache lookups.
+type Recorder interface {
+	RecordDNSLookup(cacheHit, failed bool)
+}
+
+// entry is a cached lookup result: either addresses or the lookup error.
+type entry struct {
+	addrs   []string
+	err     error
+	expires time.Time
+}
+
+// Resolver caches host lookups with separate TTLs for successes and failures.
+type Resolver struct {
+	lookup      func(ctx context.Context, host string) ([]string, error)
+	ttl         time.Duration
+	negativeTTL time.Duration
+	recorder    Recorder
+	now         func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]entry
+}
+
+// New creates a caching resolver backed by the system resolver.
+// Non-positive TTLs use the defaults; a nil recorder records nothing.
+func New(ttl, negativeTTL time.Duration, rec Recorder) *Resolver {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	if negativeTTL <= 0 {
+		negativeTTL = DefaultNegativeTTL
+	}
+	return &Resolver{
+		lookup:      net.DefaultResolver.LookupHost,
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		recorder:    rec,
+		now:         time.Now,
+		entries:     make(map[string]entry),
+	}
+}
+
+// LookupHost returns the addresses for host, from the cache when fresh.
+// Failed lookups are cached for the negative TTL, except when the caller's context
+// was cancelled, which says nothing about the host.
+func (r *Resolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	now := r.now()
+
+	r.mu.Lock()
+	e, ok := r.entries[host]
+	r.mu.Unlock()
+	if ok && now.Before(e.expires) {
+		r.record(true, e.err != nil)
+		return e.addrs, e.err
+	}
+
+	addrs, err := r.lookup(ctx, host)
+	r.record(false, err != nil)
+
+	switch {
+	case err == nil:
+		r.store(host, entry{addrs: addrs, expires: now.Add(r.ttl)})
+	case ctx.Err() == nil && !errors.Is(err, context.Canceled):
+		r.store(host, entry{err: err, expires: now.Add(r.negativeTTL)})
+	}
+	return addrs, err
+}
+
+// DialContext returns a dial function for http.Transport that resolves hosts through the
+// cache and tries each address in turn.
+func (r *Resolver) DialContext(dialer *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		if net.ParseIP(host) != nil {
+			return dialer.DialContext(ctx, network, addr)
+		}
+
+		addrs, err := r.LookupHost(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+
+		var lastErr error
+		for _, ip := range addrs {
+			conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip, port))
+			if err == nil {
+				return conn, nil
+			}
+			lastErr = err
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		if lastErr == nil {
+			lastErr = &net.DNSError{Err: "no addresses found", Name: host, IsNotFound: true}
+		}
+		return nil, lastErr
+	}
+}
+
+// store caches a lookup result.
+func (r *Resolver) store(host string, e entry) {
+	r.mu.Lock()
+	r.entries[host] = e
+	r.mu.Unlock()
+}
+
+func (r *Resolver) record(cacheHit, failed bool) {
+	if r.recorder != nil {
+		r.recorder.RecordDNSLookup(cacheHit, failed)
+	}
+}
@@ -0,0 +1,143 @@
+package dnscache
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// fakeLookup counts lookups and returns a fixed result.
+type fakeLookup struct {
+	calls int
+	addrs []string
+	err   error
+}
+
+func (f *fakeLookup) LookupHost(ctx context.Context, host string) ([]string, error) {
+	f.calls++
+	return f.addrs, f.err
+}
+
+// countingRecorder counts cache hits and failures.
+type countingRecorder struct {
+	hits, misses, failures int
+}
+
+func (c *countingRecorder) RecordDNSLookup(cacheHit, failed bool) {
+	if cacheHit {
+		c.hits++
+	} else {
+		c.misses++
+	}
+	if failed {
+		c.failures++
+	}
+}
+
+func newTestResolver(lookup *fakeLookup, rec Recorder) (*Resolver, *time.Time) {
+	now := time.Now()
+	r := New(30*time.Second, 5*time.Second, rec)
+	r.lookup = lookup.LookupHost
+	r.now = func() time.Time { return now }
+	return r, &now
+}
+
+func TestResolver_CachesUntilTTL(t *testing.T) {
+	lookup := &fakeLookup{addrs: []string{"10.0.0.1"}}
+	rec := &countingRecorder{}
+	r, now := newTestResolver(lookup, rec)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		addrs, err := r.LookupHost(ctx, "hooks.internal")
+		if err != nil || len(addrs) != 1 || addrs[0] != "10.0.0.1" {
+			t.Fatalf("LookupHost() = %v, %v", addrs, err)
+		}
+	}
+	if lookup.calls != 1 {
+		t.Errorf("lookups = %d, want 1", lookup.calls)
+	}
+	if rec.hits != 2 || rec.misses != 1 {
+		t.Errorf("hits=%d misses=%d, want 2/1", rec.hits, rec.misses)
+	}
+
+	*now = now.Add(31 * time.Second)
+	if _, err := r.LookupHost(ctx, "hooks.internal"); err != nil {
+		t.Fatalf("LookupHost() error = %v", err)
+	}
+	if lookup.calls != 2 {
+		t.Errorf("lookups after TTL = %d, want 2", lookup.calls)
+	}
+}
+
+func TestResolver_NegativeCaching(t *testing.T) {
+	dnsErr := &net.DNSError{Err: "no such host", Name: "gone.internal", IsNotFound: true}
+	lookup := &fakeLookup{err: dnsErr}
+	rec := &countingRecorder{}
+	r, now := newTestResolver(lookup, rec)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := r.LookupHost(ctx, "gone.internal"); !errors.Is(err, dnsErr) {
+			t.Fatalf("LookupHost() error = %v, want %v", err, dnsErr)
+		}
+	}
+	if lookup.calls != 1 {
+		t.Errorf("lookups = %d, want 1 (negative cached)", lookup.calls)
+	}
+	if rec.failures != 2 {
+		t.Errorf("failures = %d, want 2", rec.failures)
+	}
+
+	*now = now.Add(6 * time.Second)
+	r.LookupHost(ctx, "gone.internal")
+	if lookup.calls != 2 {
+		t.Errorf("lookups after negative TTL = %d, want 2", lookup.calls)
+	}
+}
+
+func TestResolver_DoesNotCacheCancelledLookups(t *testing.T) {
+	lookup := &fakeLookup{err: context.Canceled}
+	r, _ := newTestResolver(lookup, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r.LookupHost(ctx, "hooks.internal")
+	r.LookupHost(context.Background(), "hooks.internal")
+
+	if lookup.calls != 2 {
+		t.Errorf("lookups = %d, want 2", lookup.calls)
+	}
+}
+
+func TestResolver_DialContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	_, port, _ := net.SplitHostPort(u.Host)
+
+	// hooks.internal resolves (via the fake) to the test server's loopback address
+	lookup := &fakeLookup{addrs: []string{"127.0.0.1"}}
+	r, _ := newTestResolver(lookup, nil)
+	client := &http.Client{Transport: &http.Transport{DialContext: r.DialContext(&net.Dialer{})}}
+
+	resp, err := client.Get("http://hooks.internal:" + port + "/")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+	if lookup.calls != 1 {
+		t.Errorf("lookups = %d, want 1", lookup.calls)
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 	"sender/internal/database"
 	"sender/internal/sender/email/provider"
+	"sender/internal/sender/failure"
 	"sender/internal/sender/payload"
 )
 
@@ -98,12 +99,12 @@ func isTestEmail(email string) bool {
 // Send sends an email notification using the configured provider.
 func (s *Sender) Send(ctx context.Context, endpointValue string, notification *database.Notification) error {
 	if endpointValue == "" {
-		return fmt.Errorf("email recipient is required")
+		return failure.New(failure.Invalid, fmt.Errorf("email recipient is required"))
 	}
 
 	recipients := parseRecipients(endpointValue)
 	if len(recipients) == 0 {
-		return fmt.Errorf("no valid email recipients provided")
+		return failure.New(failure.Invalid, fmt.Errorf("no valid email recipients provided"))
 	}
 
 	// Filter out test emails and validate format
@@ -112,7 +113,7 @@ func (s *Sender) Send(ctx context.Context, endpointValue string, notification *d
 
 	for _, recipient := range recipients {
 		if !strings.Contains(recipient, "@") {
-			return fmt.Errorf("invalid email address format: %q (missing @ symbol)", recipient)
+			return failure.New(failure.Invalid, fmt.Errorf("invalid email address format: %q (missing @ symbol)", recipient))
 		}
 
 		if isTestEmail(recipient) {
@@ -10,6 +10,8 @@ import (
 	"strconv"
 	"sync"
 	"time"
+
+	"sender/internal/sender/failure"
 )
 
 // EmailRequest represents an email to be sent.
@@ -157,7 +159,7 @@ func (r *Registry) GetPrimary() (Provider, error) {
 		}
 	}
 
-	return nil, fmt.Errorf("no configured email provider available")
+	return nil, failure.New(failure.Invalid, fmt.Errorf("no configured email provider available"))
 }
 
 // Send sends an email using the best available provider with rate limiting.
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"log/slog"
 
+	"sender/internal/sender/failure"
+
 	"github.com/resend/resend-go/v2"
 )
 
@@ -47,11 +49,11 @@ func (p *ResendProvider) IsConfigured() bool {
 // Send sends an email via Resend API.
 func (p *ResendProvider) Send(ctx context.Context, req *EmailRequest) error {
 	if p.client == nil {
-		return fmt.Errorf("Resend client not initialized")
+		return failure.New(failure.Invalid, fmt.Errorf("Resend client not initialized"))
 	}
 
 	if len(req.To) == 0 {
-		return fmt.Errorf("no recipients specified")
+		return failure.New(failure.Invalid, fmt.Errorf("no recipients specified"))
 	}
 
 	// Build Resend request
@@ -68,14 +70,14 @@ func (p *ResendProvider) Send(ctx context.Context, req *EmailRequest) error {
 		params.Text = req.Body
 	}
 
-	result, err := p.client.Emails.Send(params)
+	result, err := p.client.Emails.SendWithContext(ctx, params)
 	if err != nil {
 		slog.Error("Resend send failed",
 			"error", err,
 			"to", req.To,
 			"subject", req.Subject,
 		)
-		return fmt.Errorf("Resend send failed: %w", err)
+		return failure.Wrap(fmt.Errorf("Resend send failed: %w", err))
 	}
 
 	slog.Info("Email sent via Resend",
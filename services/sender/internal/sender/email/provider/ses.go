@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"log/slog"
 
+	"sender/internal/sender/failure"
+
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/sesv2"
 	"github.com/aws/aws-sdk-go-v2/service/sesv2/types"
@@ -50,11 +52,11 @@ func (p *SESProvider) IsConfigured() bool {
 // Send sends an email via AWS SES.
 func (p *SESProvider) Send(ctx context.Context, req *EmailRequest) error {
 	if p.client == nil {
-		return fmt.Errorf("SES client not initialized")
+		return failure.New(failure.Invalid, fmt.Errorf("SES client not initialized"))
 	}
 
 	if len(req.To) == 0 {
-		return fmt.Errorf("no recipients specified")
+		return failure.New(failure.Invalid, fmt.Errorf("no recipients specified"))
 	}
 
 	// Build the email body
@@ -86,7 +88,7 @@ func (p *SESProvider) Send(ctx context.Context, req *EmailRequest) error {
 			"to", req.To,
 			"subject", req.Subject,
 		)
-		return fmt.Errorf("SES send failed: %w", err)
+		return failure.Wrap(fmt.Errorf("SES send failed: %w", err))
 	}
 
 	slog.Info("Email sent via SES",
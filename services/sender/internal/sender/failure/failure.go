@@ -0,0 +1,149 @@
+// Package failure classifies send failures, so metrics can tell our bugs (invalid endpoints,
+// 4xx) from receiver outages (5xx, timeouts, DNS) and credential problems (auth).
+// Channels attach a Class to the errors they return; Classify reads it back.
+package failure
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Class is the category of a send failure, as used in metric names.
+type Class string
+
+// Failure classes.
+const (
+	Timeout     Class = "timeout"      // the receiver did not answer in time
+	ClientError Class = "4xx"          // the receiver rejected the request (other than auth and rate limits)
+	ServerError Class = "5xx"          // the receiver failed
+	Auth        Class = "auth"         // credentials missing, invalid or revoked
+	RateLimited Class = "rate_limited" // the receiver throttled us
+	DNS         Class = "dns"          // the receiver's host could not be resolved
+	TLS         Class = "tls"          // the TLS handshake or certificate verification failed
+	Invalid     Class = "invalid"      // the endpoint or its configuration is invalid; nothing was sent
+	Other       Class = "other"        // anything else
+)
+
+// Classes lists every failure class.
+var Classes = []Class{Timeout, ClientError, ServerError, Auth, RateLimited, DNS, TLS, Invalid, Other}
+
+// Error is an error with a failure class. Its message is the wrapped error's, so wrapping
+// doesn't change what is logged or how it is retried.
+type Error struct {
+	Class Class
+	Err   error
+}
+
+func (e *Error) Error() string { return e.Err.Error() }
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// New attaches class to err. It returns nil if err is nil.
+func New(class Class, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Class: class, Err: err}
+}
+
+// Wrap attaches the class Classify finds for err, typically a transport error.
+func Wrap(err error) error {
+	if err == nil {
+		return nil
+	}
+	return New(Classify(err), err)
+}
+
+// HTTPStatus attaches the class of an HTTP error status to err.
+func HTTPStatus(status int, err error) error {
+	return New(StatusClass(status), err)
+}
+
+// StatusClass returns the class of an HTTP error status.
+func StatusClass(status int) Class {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return Auth
+	case status == http.StatusTooManyRequests:
+		return RateLimited
+	case status == http.StatusRequestTimeout:
+		return Timeout
+	case status >= 400 && status < 500:
+		return ClientError
+	case status >= 500 && status < 600:
+		return ServerError
+	default:
+		return Other
+	}
+}
+
+// Classify returns the class of a send failure, or "" if err is nil. A class attached with
+// New wins; otherwise the class is inferred from the error's type and, for clients that
+// flatten errors to strings, its message.
+func Classify(err error) Class {
+	if err == nil {
+		return ""
+	}
+
+	var classified *Error
+	if errors.As(err, &classified) {
+		return classified.Class
+	}
+
+	// AWS SDK (SES) response errors carry the HTTP status
+	var status interface{ HTTPStatusCode() int }
+	if errors.As(err, &status) && status.HTTPStatusCode() >= 400 {
+		return StatusClass(status.HTTPStatusCode())
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return DNS
+	}
+	if isTLSError(err) {
+		return TLS
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return Timeout
+	}
+
+	return classifyMessage(strings.ToLower(err.Error()))
+}
+
+// isTLSError reports whether err is a TLS handshake or certificate error.
+func isTLSError(err error) bool {
+	var (
+		recordErr   tls.RecordHeaderError
+		alertErr    tls.AlertError
+		verifyErr   *tls.CertificateVerificationError
+		authority   x509.UnknownAuthorityError
+		hostname    x509.HostnameError
+		certInvalid x509.CertificateInvalidError
+	)
+	return errors.As(err, &recordErr) || errors.As(err, &alertErr) || errors.As(err, &verifyErr) ||
+		errors.As(err, &authority) || errors.As(err, &hostname) || errors.As(err, &certInvalid)
+}
+
+// classifyMessage infers a class from a lower-cased error message.
+func classifyMessage(msg string) Class {
+	switch {
+	case strings.Contains(msg, "no such host"):
+		return DNS
+	case strings.Contains(msg, "tls:") || strings.Contains(msg, "x509:"):
+		return TLS
+	case strings.Contains(msg, "rate limit") || strings.Contains(msg, "too many requests") || strings.Contains(msg, "throttl"):
+		return RateLimited
+	case strings.Contains(msg, "timeout") || strings.Contains(msg, "deadline exceeded"):
+		return Timeout
+	case strings.Contains(msg, "unauthorized") || strings.Contains(msg, "forbidden") || strings.Contains(msg, "api key"):
+		return Auth
+	default:
+		return Other
+	}
+}
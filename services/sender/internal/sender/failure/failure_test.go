@@ -0,0 +1,65 @@
+package failure
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"testing"
+)
+
+// responseError mimics an AWS SDK response error.
+type responseError struct{ status int }
+
+func (e *responseError) Error() string       { return fmt.Sprintf("http %d", e.status) }
+func (e *responseError) HTTPStatusCode() int { return e.status }
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Class
+	}{
+		{"nil", nil, ""},
+		{"attached class", New(Invalid, errors.New("webhook URL is required")), Invalid},
+		{"attached class wins over message", fmt.Errorf("send timeout: %w", New(Auth, errors.New("401"))), Auth},
+		{"unauthorized", HTTPStatus(401, errors.New("status 401")), Auth},
+		{"forbidden", HTTPStatus(403, errors.New("status 403")), Auth},
+		{"too many requests", HTTPStatus(429, errors.New("status 429")), RateLimited},
+		{"request timeout", HTTPStatus(408, errors.New("status 408")), Timeout},
+		{"not found", HTTPStatus(404, errors.New("status 404")), ClientError},
+		{"bad gateway", HTTPStatus(502, errors.New("status 502")), ServerError},
+		{"sdk response error", fmt.Errorf("SES send failed: %w", &responseError{status: 400}), ClientError},
+		{"sdk throttling", fmt.Errorf("SES send failed: %w", &responseError{status: 429}), RateLimited},
+		{"dns", &url.Error{Op: "Post", URL: "https://hooks.internal", Err: &net.DNSError{Err: "no such host", Name: "hooks.internal", IsNotFound: true}}, DNS},
+		{"tls", &url.Error{Op: "Post", URL: "https://hooks.internal", Err: x509.UnknownAuthorityError{}}, TLS},
+		{"deadline", &url.Error{Op: "Post", URL: "https://hooks.internal", Err: context.DeadlineExceeded}, Timeout},
+		{"flattened rate limit", errors.New("[ERROR]: Too many requests. You can only make 2 requests per second."), RateLimited},
+		{"flattened api key", errors.New("[ERROR]: API key is invalid"), Auth},
+		{"unknown", errors.New("connection refused"), Other},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(tt.err); got != tt.want {
+				t.Errorf("Classify(%v) = %q, want %q", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestError_KeepsMessageAndChain(t *testing.T) {
+	sentinel := errors.New("webhook returned status 401")
+	err := New(Auth, sentinel)
+	if err.Error() != sentinel.Error() {
+		t.Errorf("Error() = %q, want %q", err.Error(), sentinel.Error())
+	}
+	if !errors.Is(err, sentinel) {
+		t.Error("errors.Is() = false, want the wrapped error to be found")
+	}
+	if New(Auth, nil) != nil || Wrap(nil) != nil {
+		t.Error("New/Wrap(nil) should return nil")
+	}
+}
@@ -0,0 +1,101 @@
+// Package httpclient provides the shared, pooled HTTP client used by the Slack and webhook senders.
+// Sharing one transport keeps idle connections alive across notifications instead of opening
+// a new connection (and ephemeral port) per send.
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"time"
+)
+
+// Pool defaults.
+const (
+	// DefaultTimeout is the overall client timeout; per-attempt channel timeouts are normally shorter.
+	DefaultTimeout             = 30 * time.Second
+	DefaultMaxIdleConns        = 200
+	DefaultMaxIdleConnsPerHost = 20
+	DefaultMaxConnsPerHost     = 50
+	DefaultIdleConnTimeout     = 90 * time.Second
+)
+
+// maxDrainBytes caps how much of an unread response body is discarded to make its connection reusable.
+const maxDrainBytes = 64 << 10
+
+// Options configures the connection pool.
+type Options struct {
+	// MaxIdleConns caps idle keep-alive connections across all hosts.
+	MaxIdleConns int
+	// MaxIdleConnsPerHost caps idle keep-alive connections kept per host.
+	MaxIdleConnsPerHost int
+	// MaxConnsPerHost caps total (active + idle) connections per host; requests beyond it wait.
+	MaxConnsPerHost int
+	// IdleConnTimeout closes idle connections after this long.
+	IdleConnTimeout time.Duration
+	// DialContext, if set, replaces the transport's dialer (e.g. to resolve through a DNS cache).
+	DialContext func(ctx context.Context, network, addr string) (net.Conn, error)
+}
+
+// DefaultOptions returns the default pool settings.
+func DefaultOptions() Options {
+	return Options{
+		MaxIdleConns:        DefaultMaxIdleConns,
+		MaxIdleConnsPerHost: DefaultMaxIdleConnsPerHost,
+		MaxConnsPerHost:     DefaultMaxConnsPerHost,
+		IdleConnTimeout:     DefaultIdleConnTimeout,
+	}
+}
+
+// ConnRecorder records whether each request reused a pooled connection.
+type ConnRecorder interface {
+	RecordConnection(reused bool)
+}
+
+// New creates an HTTP client with a tuned keep-alive pool. HTTP/2 is negotiated via ALPN
+// for TLS hosts that support it. If rec is non-nil, every request reports whether it reused
+// a connection, which gives the pool's reuse rate.
+func New(opts Options, rec ConnRecorder) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ForceAttemptHTTP2 = true
+	transport.MaxIdleConns = opts.MaxIdleConns
+	transport.MaxIdleConnsPerHost = opts.MaxIdleConnsPerHost
+	transport.MaxConnsPerHost = opts.MaxConnsPerHost
+	transport.IdleConnTimeout = opts.IdleConnTimeout
+	if opts.DialContext != nil {
+		transport.DialContext = opts.DialContext
+	}
+
+	var rt http.RoundTripper = transport
+	if rec != nil {
+		rt = &tracingTransport{base: transport, rec: rec}
+	}
+	return &http.Client{
+		Timeout:   DefaultTimeout,
+		Transport: rt,
+	}
+}
+
+// DrainAndClose discards the rest of a response body (up to a limit) and closes it,
+// so the underlying connection can return to the pool.
+func DrainAndClose(body io.ReadCloser) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(body, maxDrainBytes))
+	_ = body.Close()
+}
+
+// tracingTransport reports connection reuse for every request.
+type tracingTransport struct {
+	base http.RoundTripper
+	rec  ConnRecorder
+}
+
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.rec.RecordConnection(info.Reused)
+		},
+	}
+	return t.base.RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+}
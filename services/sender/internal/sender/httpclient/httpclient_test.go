@@ -0,0 +1,67 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// countingRecorder counts new and reused connections.
+type countingRecorder struct {
+	mu     sync.Mutex
+	reused int
+	fresh  int
+}
+
+func (c *countingRecorder) RecordConnection(reused bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if reused {
+		c.reused++
+	} else {
+		c.fresh++
+	}
+}
+
+func TestNew_AppliesPoolOptions(t *testing.T) {
+	opts := Options{MaxIdleConns: 7, MaxIdleConnsPerHost: 3, MaxConnsPerHost: 5, IdleConnTimeout: DefaultIdleConnTimeout}
+	client := New(opts, nil)
+
+	if client.Timeout != DefaultTimeout {
+		t.Errorf("Timeout = %v, want %v", client.Timeout, DefaultTimeout)
+	}
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("Transport = %T, want *http.Transport", client.Transport)
+	}
+	if transport.MaxIdleConns != 7 || transport.MaxIdleConnsPerHost != 3 || transport.MaxConnsPerHost != 5 {
+		t.Errorf("pool = %d/%d/%d, want 7/3/5", transport.MaxIdleConns, transport.MaxIdleConnsPerHost, transport.MaxConnsPerHost)
+	}
+	if !transport.ForceAttemptHTTP2 {
+		t.Error("ForceAttemptHTTP2 = false, want true")
+	}
+}
+
+func TestNew_RecordsConnectionReuse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	rec := &countingRecorder{}
+	client := New(DefaultOptions(), rec)
+
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		// Unread bodies would prevent reuse; DrainAndClose makes the connection reusable
+		DrainAndClose(resp.Body)
+	}
+
+	if rec.fresh != 1 || rec.reused != 2 {
+		t.Errorf("connections new=%d reused=%d, want new=1 reused=2", rec.fresh, rec.reused)
+	}
+}
@@ -0,0 +1,125 @@
+// Package ledger tracks the endpoints each notification was already delivered to.
+// A notification can be delivered again before it reaches a final status: Kafka redelivers it
+// when the sender crashes mid-send or fails to record the outcome. The ledger lets the sender
+// skip the endpoints that already received it, so a flaky webhook does not cause duplicate emails.
+package ledger
+
+import (
+	"sync"
+	"time"
+)
+
+// Ledger defaults.
+const (
+	// DefaultTTL is how long a notification's deliveries are remembered after its last delivery.
+	// Redelivery happens within seconds to minutes, so this comfortably covers it.
+	DefaultTTL = time.Hour
+	// DefaultMaxNotifications bounds the number of notifications remembered. When full, the
+	// notification expiring first is forgotten.
+	DefaultMaxNotifications = 10000
+)
+
+// entry is the set of endpoints one notification was delivered to.
+type entry struct {
+	delivered map[string]bool
+	expires   time.Time
+}
+
+// Ledger is an in-process record of delivered endpoints per notification. It is safe for
+// concurrent use. It is not shared between sender instances: a notification redelivered to
+// another instance is sent to all its endpoints.
+type Ledger struct {
+	ttl time.Duration
+	max int
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+// New creates a ledger. Non-positive values use DefaultTTL and DefaultMaxNotifications.
+func New(ttl time.Duration, maxNotifications int) *Ledger {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	if maxNotifications <= 0 {
+		maxNotifications = DefaultMaxNotifications
+	}
+	return &Ledger{
+		ttl:     ttl,
+		max:     maxNotifications,
+		now:     time.Now,
+		entries: make(map[string]*entry),
+	}
+}
+
+// Delivered reports whether the notification was already delivered to endpoint.
+func (l *Ledger) Delivered(notificationID, endpoint string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e, ok := l.entries[notificationID]
+	if !ok {
+		return false
+	}
+	if !l.now().Before(e.expires) {
+		delete(l.entries, notificationID)
+		return false
+	}
+	return e.delivered[endpoint]
+}
+
+// MarkDelivered records that the notification was delivered to endpoint.
+func (l *Ledger) MarkDelivered(notificationID, endpoint string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	e, ok := l.entries[notificationID]
+	if !ok {
+		l.makeRoom(now)
+		e = &entry{delivered: make(map[string]bool)}
+		l.entries[notificationID] = e
+	}
+	e.delivered[endpoint] = true
+	e.expires = now.Add(l.ttl)
+}
+
+// Forget drops the notification's deliveries, once it reached a final status and will not be
+// sent again.
+func (l *Ledger) Forget(notificationID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.entries, notificationID)
+}
+
+// Len returns the number of notifications remembered.
+func (l *Ledger) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.entries)
+}
+
+// makeRoom drops expired entries when the ledger is full, then the entry expiring first if it
+// is still full. Must be called with mu held.
+func (l *Ledger) makeRoom(now time.Time) {
+	if len(l.entries) < l.max {
+		return
+	}
+	for id, e := range l.entries {
+		if !now.Before(e.expires) {
+			delete(l.entries, id)
+		}
+	}
+	if len(l.entries) < l.max {
+		return
+	}
+	var oldestID string
+	var oldest time.Time
+	for id, e := range l.entries {
+		if oldestID == "" || e.expires.Before(oldest) {
+			oldestID, oldest = id, e.expires
+		}
+	}
+	delete(l.entries, oldestID)
+}
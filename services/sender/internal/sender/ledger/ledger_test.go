@@ -0,0 +1,77 @@
+package ledger
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestLedger_MarkDelivered(t *testing.T) {
+	l := New(0, 0)
+
+	if l.Delivered("notif-1", "email:a@example.com") {
+		t.Fatal("Delivered() = true before any delivery")
+	}
+	l.MarkDelivered("notif-1", "email:a@example.com")
+
+	if !l.Delivered("notif-1", "email:a@example.com") {
+		t.Error("Delivered() = false after MarkDelivered")
+	}
+	if l.Delivered("notif-1", "webhook:https://example.com") {
+		t.Error("Delivered() = true for another endpoint")
+	}
+	if l.Delivered("notif-2", "email:a@example.com") {
+		t.Error("Delivered() = true for another notification")
+	}
+}
+
+func TestLedger_Expiry(t *testing.T) {
+	l := New(time.Minute, 0)
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+
+	l.MarkDelivered("notif-1", "email:a@example.com")
+	now = now.Add(59 * time.Second)
+	if !l.Delivered("notif-1", "email:a@example.com") {
+		t.Error("Delivered() = false before the TTL")
+	}
+
+	now = now.Add(time.Second)
+	if l.Delivered("notif-1", "email:a@example.com") {
+		t.Error("Delivered() = true after the TTL")
+	}
+	if l.Len() != 0 {
+		t.Errorf("Len() = %d, want the expired entry dropped", l.Len())
+	}
+}
+
+func TestLedger_Forget(t *testing.T) {
+	l := New(0, 0)
+	l.MarkDelivered("notif-1", "email:a@example.com")
+	l.Forget("notif-1")
+
+	if l.Delivered("notif-1", "email:a@example.com") {
+		t.Error("Delivered() = true after Forget")
+	}
+}
+
+func TestLedger_MaxNotifications(t *testing.T) {
+	l := New(time.Hour, 3)
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+
+	for i := 1; i <= 4; i++ {
+		l.MarkDelivered(fmt.Sprintf("notif-%d", i), "email:a@example.com")
+		now = now.Add(time.Second)
+	}
+
+	if l.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", l.Len())
+	}
+	if l.Delivered("notif-1", "email:a@example.com") {
+		t.Error("oldest notification was not evicted")
+	}
+	if !l.Delivered("notif-4", "email:a@example.com") {
+		t.Error("newest notification was evicted")
+	}
+}
@@ -0,0 +1,28 @@
+// Package null provides a sender that discards notifications.
+// It backs the pipeline canary's endpoint so canaries exercise the full send path
+// without reaching a real recipient.
+package null
+
+import (
+	"context"
+
+	"sender/internal/database"
+)
+
+// Sender implements a no-op notification sender.
+type Sender struct{}
+
+// NewSender creates a new null sender.
+func NewSender() *Sender {
+	return &Sender{}
+}
+
+// Type returns the endpoint type this sender handles.
+func (s *Sender) Type() string {
+	return "null"
+}
+
+// Send discards the notification and always succeeds.
+func (s *Sender) Send(ctx context.Context, endpointValue string, notification *database.Notification) error {
+	return nil
+}
@@ -0,0 +1,20 @@
+package null
+
+import (
+	"context"
+	"testing"
+
+	"sender/internal/database"
+)
+
+func TestSender_Type(t *testing.T) {
+	if got := NewSender().Type(); got != "null" {
+		t.Errorf("Type() = %q, want %q", got, "null")
+	}
+}
+
+func TestSender_Send(t *testing.T) {
+	if err := NewSender().Send(context.Background(), "canary", &database.Notification{NotificationID: "n-1"}); err != nil {
+		t.Errorf("Send() error = %v, want nil", err)
+	}
+}
@@ -0,0 +1,181 @@
+// Package oauth2 fetches and caches OAuth2 client-credentials access tokens for webhook endpoints.
+// Tokens are cached per (token URL, client, scopes) and refreshed shortly before they expire,
+// so webhooks can call secured APIs without long-lived tokens embedded in their URLs.
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"sender/internal/sender/failure"
+	"sender/internal/sender/httpclient"
+)
+
+// Token cache defaults.
+const (
+	// DefaultExpiryDelta is how long before expiry a cached token is refreshed, so it does not
+	// expire while a request is in flight.
+	DefaultExpiryDelta = 30 * time.Second
+	// DefaultTokenLifetime is used when the token response has no expires_in.
+	DefaultTokenLifetime = 5 * time.Minute
+)
+
+// Credentials identify an OAuth2 client. ClientSecret is the decrypted secret.
+type Credentials struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+}
+
+// key identifies the cached token for these credentials.
+func (c Credentials) key() string {
+	return strings.Join([]string{c.TokenURL, c.ClientID, c.ClientSecret, strings.Join(c.Scopes, " ")}, "\x00")
+}
+
+// Recorder records token fetches.
+type Recorder interface {
+	RecordOAuthTokenFetch(failed bool)
+}
+
+// noOpRecorder is used when no recorder is configured.
+type noOpRecorder struct{}
+
+func (noOpRecorder) RecordOAuthTokenFetch(bool) {}
+
+// entry is a cached token. Its mutex serializes fetches, so concurrent sends to the same
+// endpoint share one token request.
+type entry struct {
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// Cache fetches client-credentials tokens and caches them until shortly before they expire.
+type Cache struct {
+	client   *http.Client
+	recorder Recorder
+	now      func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+// NewCache creates a token cache that requests tokens with client.
+// A nil client uses http.DefaultClient; a nil recorder records nothing.
+func NewCache(client *http.Client, rec Recorder) *Cache {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if rec == nil {
+		rec = noOpRecorder{}
+	}
+	return &Cache{
+		client:   client,
+		recorder: rec,
+		now:      time.Now,
+		entries:  make(map[string]*entry),
+	}
+}
+
+// Token returns a valid access token for creds, fetching a new one if none is cached
+// or the cached one is about to expire.
+func (c *Cache) Token(ctx context.Context, creds Credentials) (string, error) {
+	e := c.entry(creds)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.token != "" && c.now().Add(DefaultExpiryDelta).Before(e.expires) {
+		return e.token, nil
+	}
+
+	token, lifetime, err := c.fetch(ctx, creds)
+	c.recorder.RecordOAuthTokenFetch(err != nil)
+	if err != nil {
+		return "", err
+	}
+	e.token = token
+	e.expires = c.now().Add(lifetime)
+	return token, nil
+}
+
+// Invalidate drops the cached token for creds, e.g. after the webhook rejected it.
+func (c *Cache) Invalidate(creds Credentials) {
+	e := c.entry(creds)
+	e.mu.Lock()
+	e.token = ""
+	e.mu.Unlock()
+}
+
+// entry returns the cache entry for creds, creating it if needed.
+func (c *Cache) entry(creds Credentials) *entry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := creds.key()
+	e, ok := c.entries[key]
+	if !ok {
+		e = &entry{}
+		c.entries[key] = e
+	}
+	return e
+}
+
+// tokenResponse is the token endpoint's JSON response (RFC 6749 section 5.1).
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// fetch requests a new token with the client credentials grant, authenticating with HTTP Basic.
+func (c *Cache) fetch(ctx context.Context, creds Credentials) (string, time.Duration, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(creds.Scopes) > 0 {
+		form.Set("scope", strings.Join(creds.Scopes, " "))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, creds.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, failure.New(failure.Invalid, fmt.Errorf("failed to create oauth2 token request: %w", err))
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(creds.ClientID), url.QueryEscape(creds.ClientSecret))
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", 0, failure.Wrap(fmt.Errorf("failed to fetch oauth2 token: %w", err))
+	}
+	defer httpclient.DrainAndClose(resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		err := fmt.Errorf("oauth2 token endpoint returned status %d", resp.StatusCode)
+		if resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			// The token endpoint rejected the client credentials (invalid_client, invalid_scope, ...)
+			return "", 0, failure.New(failure.Auth, err)
+		}
+		return "", 0, failure.HTTPStatus(resp.StatusCode, err)
+	}
+
+	var token tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", 0, fmt.Errorf("failed to decode oauth2 token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", 0, fmt.Errorf("oauth2 token response has no access_token")
+	}
+	if token.TokenType != "" && !strings.EqualFold(token.TokenType, "bearer") {
+		return "", 0, fmt.Errorf("unsupported oauth2 token type %q", token.TokenType)
+	}
+
+	lifetime := DefaultTokenLifetime
+	if token.ExpiresIn > 0 {
+		lifetime = time.Duration(token.ExpiresIn) * time.Second
+	}
+	return token.AccessToken, lifetime, nil
+}
@@ -0,0 +1,134 @@
+package oauth2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// tokenServer issues numbered tokens and records the last request.
+type tokenServer struct {
+	*httptest.Server
+	requests  atomic.Int32
+	status    int
+	expiresIn int
+	lastForm  string
+	lastUser  string
+	lastPass  string
+}
+
+func newTokenServer(t *testing.T) *tokenServer {
+	ts := &tokenServer{status: http.StatusOK, expiresIn: 3600}
+	ts.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := ts.requests.Add(1)
+		r.ParseForm()
+		ts.lastForm = r.PostForm.Encode()
+		ts.lastUser, ts.lastPass, _ = r.BasicAuth()
+		if ts.status != http.StatusOK {
+			w.WriteHeader(ts.status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer","expires_in":%d}`, n, ts.expiresIn)
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+type fakeRecorder struct {
+	fetches, failures int
+}
+
+func (f *fakeRecorder) RecordOAuthTokenFetch(failed bool) {
+	f.fetches++
+	if failed {
+		f.failures++
+	}
+}
+
+func TestCache_Token(t *testing.T) {
+	ts := newTokenServer(t)
+	rec := &fakeRecorder{}
+	cache := NewCache(ts.Client(), rec)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	creds := Credentials{TokenURL: ts.URL, ClientID: "alerting", ClientSecret: "s3cret", Scopes: []string{"alerts.write", "alerts.read"}}
+
+	token, err := cache.Token(context.Background(), creds)
+	if err != nil || token != "token-1" {
+		t.Fatalf("Token() = %q, %v, want token-1", token, err)
+	}
+	if ts.lastForm != "grant_type=client_credentials&scope=alerts.write+alerts.read" {
+		t.Errorf("token request form = %q, want client_credentials grant with scopes", ts.lastForm)
+	}
+	if ts.lastUser != "alerting" || ts.lastPass != "s3cret" {
+		t.Errorf("basic auth = %q/%q, want the client credentials", ts.lastUser, ts.lastPass)
+	}
+
+	if token, _ := cache.Token(context.Background(), creds); token != "token-1" {
+		t.Errorf("second Token() = %q, want the cached token-1", token)
+	}
+
+	// Refreshed once within DefaultExpiryDelta of expiry
+	now = now.Add(time.Hour - DefaultExpiryDelta)
+	if token, _ := cache.Token(context.Background(), creds); token != "token-2" {
+		t.Errorf("Token() near expiry = %q, want a refreshed token-2", token)
+	}
+
+	cache.Invalidate(creds)
+	if token, _ := cache.Token(context.Background(), creds); token != "token-3" {
+		t.Errorf("Token() after Invalidate = %q, want token-3", token)
+	}
+
+	if rec.fetches != 3 || rec.failures != 0 {
+		t.Errorf("recorded %d fetches, %d failures, want 3 and 0", rec.fetches, rec.failures)
+	}
+}
+
+func TestCache_Token_Errors(t *testing.T) {
+	t.Run("error status", func(t *testing.T) {
+		ts := newTokenServer(t)
+		ts.status = http.StatusServiceUnavailable
+		rec := &fakeRecorder{}
+		cache := NewCache(ts.Client(), rec)
+
+		_, err := cache.Token(context.Background(), Credentials{TokenURL: ts.URL, ClientID: "a", ClientSecret: "b"})
+		if err == nil {
+			t.Fatal("Token() error = nil, want error")
+		}
+		if rec.failures != 1 {
+			t.Errorf("failures = %d, want 1", rec.failures)
+		}
+	})
+
+	t.Run("unsupported token type", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"access_token":"x","token_type":"mac"}`)
+		}))
+		defer server.Close()
+
+		_, err := NewCache(server.Client(), nil).Token(context.Background(), Credentials{TokenURL: server.URL, ClientID: "a", ClientSecret: "b"})
+		if err == nil {
+			t.Error("Token() error = nil, want unsupported token type")
+		}
+	})
+}
+
+func TestCache_Token_DefaultLifetime(t *testing.T) {
+	ts := newTokenServer(t)
+	ts.expiresIn = 0
+	cache := NewCache(ts.Client(), nil)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	creds := Credentials{TokenURL: ts.URL, ClientID: "a", ClientSecret: "b"}
+
+	cache.Token(context.Background(), creds)
+	now = now.Add(DefaultTokenLifetime)
+	if token, _ := cache.Token(context.Background(), creds); token != "token-2" {
+		t.Errorf("Token() after DefaultTokenLifetime = %q, want token-2", token)
+	}
+}
@@ -3,10 +3,13 @@ package payload
 
 import (
 	"fmt"
+	"log/slog"
 	"strings"
 	"time"
 
 	"sender/internal/database"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
 )
 
 // EmailPayload represents email message content.
@@ -17,8 +20,17 @@ type EmailPayload struct {
 }
 
 // BuildEmailPayload builds email subject, body, and HTML from a notification.
+// A digest lists its grouped alerts after the context, followed by related alerts, if any.
+// With a template, the rendered body is sent as plain text only; the subject is rendered if
+// the template has one.
 func BuildEmailPayload(notification *database.Notification) EmailPayload {
-	subject := fmt.Sprintf("Alert: %s - %s", notification.Severity, notification.Name)
+	subject := fmt.Sprintf("%s: %s - %s", titlePrefix(notification), notification.Severity, notification.Name)
+	if tmplSubject, body, ok := renderTemplate(notification); ok {
+		if tmplSubject != "" {
+			subject = tmplSubject
+		}
+		return EmailPayload{Subject: subject, Body: body}
+	}
 	body := buildEmailBody(notification)
 	html := buildEmailHTML(notification)
 	return EmailPayload{
@@ -28,8 +40,65 @@ func BuildEmailPayload(notification *database.Notification) EmailPayload {
 	}
 }
 
+// timestamps returns the format of the notification's timestamps: the client's timezone and
+// locale, UTC and the default layout if unset.
+func timestamps(notification *database.Notification) shared.TimestampFormat {
+	return shared.NewTimestampFormat(notification.Timezone, notification.Locale)
+}
+
+// templateData returns the data a notification template is executed with.
+func templateData(notification *database.Notification, format shared.TimestampFormat) shared.NotificationTemplateData {
+	data := shared.NotificationTemplateData{
+		NotificationID: notification.NotificationID,
+		ClientID:       notification.ClientID,
+		AlertID:        notification.AlertID,
+		Severity:       notification.Severity,
+		Source:         notification.Source,
+		Name:           notification.Name,
+		Context:        notification.Context,
+		RuleIDs:        notification.RuleIDs,
+		Created:        format.Format(notification.CreatedAt),
+	}
+	if !notification.EventTime.IsZero() {
+		data.EventTime = format.Format(notification.EventTime)
+	}
+	for _, alert := range notification.Grouped {
+		data.Alerts = append(data.Alerts, templateData(alert, format))
+	}
+	return data
+}
+
+// renderTemplate renders the notification's template subject and body. It returns false if
+// the notification has no template or rendering fails, in which case the default layout is
+// sent: a broken template must not stop the alert.
+func renderTemplate(notification *database.Notification) (subject, body string, ok bool) {
+	tmpl := notification.Template
+	if tmpl == nil {
+		return "", "", false
+	}
+	data := templateData(notification, timestamps(notification))
+	var err error
+	if tmpl.Subject != "" {
+		subject, err = shared.RenderNotificationTemplate("subject", tmpl.Subject, data)
+		subject = strings.TrimSpace(subject)
+	}
+	if err == nil {
+		body, err = shared.RenderNotificationTemplate("body", tmpl.Body, data)
+	}
+	if err != nil {
+		slog.Warn("Failed to render notification template, using the default layout",
+			"notification_id", notification.NotificationID,
+			"template_id", tmpl.TemplateID,
+			"error", err,
+		)
+		return "", "", false
+	}
+	return subject, body, true
+}
+
 // buildEmailBody builds the plain text email body from the notification.
 func buildEmailBody(notification *database.Notification) string {
+	format := timestamps(notification)
 	var sb strings.Builder
 	sb.WriteString("Alert Notification\n")
 	sb.WriteString("==================\n\n")
@@ -40,6 +109,10 @@ func buildEmailBody(notification *database.Notification) string {
 	sb.WriteString(fmt.Sprintf("Client ID: %s\n", notification.ClientID))
 	sb.WriteString(fmt.Sprintf("Notification ID: %s\n", notification.NotificationID))
 	sb.WriteString(fmt.Sprintf("Matched Rule IDs: %s\n", strings.Join(notification.RuleIDs, ", ")))
+	if !notification.EventTime.IsZero() {
+		sb.WriteString(fmt.Sprintf("Event Time: %s\n", format.Format(notification.EventTime)))
+	}
+	sb.WriteString(fmt.Sprintf("Created: %s\n", format.Format(notification.CreatedAt)))
 
 	if len(notification.Context) > 0 {
 		sb.WriteString("\nContext:\n")
@@ -48,12 +121,73 @@ func buildEmailBody(notification *database.Notification) string {
 		}
 	}
 
+	if len(notification.Grouped) > 0 {
+		sb.WriteString(fmt.Sprintf("\nGrouped Alerts (%d):\n", len(notification.Grouped)))
+		for _, alert := range notification.Grouped {
+			sb.WriteString(fmt.Sprintf("  - %s\n", groupedAlertLine(alert, format)))
+		}
+	}
+
+	if len(notification.Related) > 0 {
+		sb.WriteString(fmt.Sprintf("\nPreviously Fired (%d):\n", len(notification.Related)))
+		for _, alert := range notification.Related {
+			sb.WriteString(fmt.Sprintf("  - %s\n", relatedAlertLine(alert, format)))
+		}
+	}
+
 	return sb.String()
 }
 
+// titlePrefix returns the title of a notification's messages: "Alert", or "Alert digest".
+func titlePrefix(notification *database.Notification) string {
+	if len(notification.Grouped) > 0 {
+		return "Alert digest"
+	}
+	return "Alert"
+}
+
+// groupedAlertLine describes one alert of a digest on a single line, with the time it
+// happened (or was created, if unknown).
+func groupedAlertLine(alert *database.Notification, format shared.TimestampFormat) string {
+	return fmt.Sprintf("[%s] %s from %s (alert %s, %s)", alert.Severity, alert.Name, alert.Source, alert.AlertID, format.Format(alertTime(alert)))
+}
+
+// relatedAlertLine describes an earlier alert with the same fingerprint on a single line.
+func relatedAlertLine(alert *database.RelatedAlert, format shared.TimestampFormat) string {
+	line := fmt.Sprintf("%s at %s (%s", alert.AlertID, format.Format(alert.FiredAt), alert.Status)
+	if alert.RepeatCount > 0 {
+		line += fmt.Sprintf(", repeated %d times", alert.RepeatCount)
+	}
+	return line + ")"
+}
+
+// LimitRelatedAlerts drops the oldest related alerts of the notification until their lines
+// take at most maxBytes, so a long alert history cannot blow up the message.
+func LimitRelatedAlerts(notification *database.Notification, maxBytes int) {
+	format := timestamps(notification)
+	size := 0
+	for i, alert := range notification.Related {
+		size += len(relatedAlertLine(alert, format))
+		if size > maxBytes {
+			notification.Related = notification.Related[:i]
+			return
+		}
+	}
+}
+
+// alertTime returns when the alert of a notification happened, or when the notification was
+// created if that is unknown.
+func alertTime(notification *database.Notification) time.Time {
+	if !notification.EventTime.IsZero() {
+		return notification.EventTime
+	}
+	return notification.CreatedAt
+}
+
 // buildEmailHTML builds the HTML email body from the notification.
 func buildEmailHTML(notification *database.Notification) string {
-	severityColor := getSeverityColorHex(notification.Severity)
+	style := shared.SeverityStyleFor(notification.Severity)
+	format := timestamps(notification)
 
 	var sb strings.Builder
 	sb.WriteString(`<!DOCTYPE html>
@@ -73,9 +207,9 @@ func buildEmailHTML(notification *database.Notification) string {
 </head>
 <body>
   <div class="container">
-    <div class="header" style="background: ` + severityColor + `;">
-      <h2 style="margin: 0;">Alert: ` + notification.Name + `</h2>
-      <p style="margin: 5px 0 0 0; opacity: 0.9;">Severity: ` + notification.Severity + `</p>
+    <div class="header" style="background: ` + style.Color + `;">
+      <h2 style="margin: 0;">` + style.Emoji + ` ` + titlePrefix(notification) + `: ` + notification.Name + `</h2>
+      <p style="margin: 5px 0 0 0; opacity: 0.9;">Severity: ` + style.Label + `</p>
     </div>
     <div class="content">
       <div class="field">
@@ -99,6 +233,19 @@ func buildEmailHTML(notification *database.Notification) string {
         <div class="value">` + strings.Join(notification.RuleIDs, ", ") + `</div>
       </div>`)
 
+	if !notification.EventTime.IsZero() {
+		sb.WriteString(`
+      <div class="field">
+        <div class="label">Event Time</div>
+        <div class="value">` + format.Format(notification.EventTime) + `</div>
+      </div>`)
+	}
+	sb.WriteString(`
+      <div class="field">
+        <div class="label">Created</div>
+        <div class="value">` + format.Format(notification.CreatedAt) + `</div>
+      </div>`)
+
 	if len(notification.Context) > 0 {
 		sb.WriteString(`
       <div class="context">
@@ -114,6 +261,35 @@ func buildEmailHTML(notification *database.Notification) string {
       </div>`)
 	}
 
+	if len(notification.Grouped) > 0 {
+		sb.WriteString(`
+      <div class="context">
+        <div class="label" style="margin-bottom: 10px;">Grouped Alerts (` + fmt.Sprint(len(notification.Grouped)) + `)</div>`)
+		for _, alert := range notification.Grouped {
+			sb.WriteString(`
+        <div class="field">
+          <div class="value"><span style="color: ` + shared.SeverityStyleFor(alert.Severity).Color + `; font-weight: 600;">` + alert.Severity + `</span> ` + alert.Name + ` from ` + alert.Source + `</div>
+          <div class="label">` + alert.AlertID + ` · ` + format.Format(alertTime(alert)) + `</div>
+        </div>`)
+		}
+		sb.WriteString(`
+      </div>`)
+	}
+
+	if len(notification.Related) > 0 {
+		sb.WriteString(`
+      <div class="context">
+        <div class="label" style="margin-bottom: 10px;">Previously Fired (` + fmt.Sprint(len(notification.Related)) + `)</div>`)
+		for _, alert := range notification.Related {
+			sb.WriteString(`
+        <div class="field">
+          <div class="value">` + relatedAlertLine(alert, format) + `</div>
+        </div>`)
+		}
+		sb.WriteString(`
+      </div>`)
+	}
+
 	sb.WriteString(`
     </div>
     <div class="footer">
@@ -126,22 +302,6 @@ func buildEmailHTML(notification *database.Notification) string {
 	return sb.String()
 }
 
-// getSeverityColorHex returns the hex color for a given severity.
-func getSeverityColorHex(severity string) string {
-	switch strings.ToUpper(severity) {
-	case "CRITICAL":
-		return "#dc2626" // red
-	case "HIGH":
-		return "#ea580c" // orange
-	case "MEDIUM":
-		return "#ca8a04" // yellow
-	case "LOW":
-		return "#16a34a" // green
-	default:
-		return "#6b7280" // gray
-	}
-}
-
 // SlackPayload represents a Slack webhook payload.
 type SlackPayload struct {
 	Text        string       `json:"text,omitempty"`
@@ -165,9 +325,20 @@ type Field struct {
 }
 
 // BuildSlackPayload builds a Slack webhook payload from the notification.
+// A digest lists its grouped alerts in the attachment text, followed by related alerts, if any.
+// With a template, the attachment text is the rendered body and the fields are left out.
 func BuildSlackPayload(notification *database.Notification) SlackPayload {
-	// Determine color based on severity
-	color := getSeverityColor(notification.Severity)
+	style := shared.SeverityStyleFor(notification.Severity)
+	format := timestamps(notification)
+	title := fmt.Sprintf("%s: %s - %s", titlePrefix(notification), notification.Severity, notification.Name)
+
+	if _, body, ok := renderTemplate(notification); ok {
+		return SlackPayload{
+			Attachments: []Attachment{
+				{Color: style.Color, Title: title, Text: body},
+			},
+		}
+	}
 
 	// Build fields
 	fields := []Field{
@@ -179,6 +350,11 @@ func BuildSlackPayload(notification *database.Notification) SlackPayload {
 		{Title: "Notification ID", Value: notification.NotificationID, Short: true},
 	}
 
+	if !notification.EventTime.IsZero() {
+		fields = append(fields, Field{Title: "Event Time", Value: format.Format(notification.EventTime), Short: true})
+	}
+	fields = append(fields, Field{Title: "Created", Value: format.Format(notification.CreatedAt), Short: true})
+
 	if len(notification.RuleIDs) > 0 {
 		fields = append(fields, Field{
 			Title: "Matched Rule IDs",
@@ -189,19 +365,31 @@ func BuildSlackPayload(notification *database.Notification) SlackPayload {
 
 	// Build attachment text
 	var text strings.Builder
-	text.WriteString(fmt.Sprintf("*Alert: %s*\n", notification.Name))
+	text.WriteString(fmt.Sprintf("%s *%s: %s*\n", style.Emoji, titlePrefix(notification), notification.Name))
 	if len(notification.Context) > 0 {
 		text.WriteString("\n*Context:*\n")
 		for k, v := range notification.Context {
 			text.WriteString(fmt.Sprintf("• %s: %s\n", k, v))
 		}
 	}
+	if len(notification.Grouped) > 0 {
+		text.WriteString(fmt.Sprintf("\n*Grouped alerts (%d):*\n", len(notification.Grouped)))
+		for _, alert := range notification.Grouped {
+			text.WriteString(fmt.Sprintf("• %s\n", groupedAlertLine(alert, format)))
+		}
+	}
+	if len(notification.Related) > 0 {
+		text.WriteString(fmt.Sprintf("\n*Previously fired (%d):*\n", len(notification.Related)))
+		for _, alert := range notification.Related {
+			text.WriteString(fmt.Sprintf("• %s\n", relatedAlertLine(alert, format)))
+		}
+	}
 
 	return SlackPayload{
 		Attachments: []Attachment{
 			{
-				Color:  color,
-				Title:  fmt.Sprintf("Alert: %s - %s", notification.Severity, notification.Name),
+				Color:  style.Color,
+				Title:  title,
 				Text:   text.String(),
 				Fields: fields,
 			},
@@ -209,20 +397,6 @@ func BuildSlackPayload(notification *database.Notification) SlackPayload {
 	}
 }
 
-// getSeverityColor returns the Slack color for a given severity.
-func getSeverityColor(severity string) string {
-	switch strings.ToUpper(severity) {
-	case "CRITICAL":
-		return "danger" // red
-	case "HIGH", "MEDIUM":
-		return "warning" // yellow
-	case "LOW":
-		return "good" // green
-	default:
-		return "good" // default to green
-	}
-}
-
 // WebhookPayload represents a webhook payload.
 type WebhookPayload struct {
 	NotificationID string            `json:"notification_id"`
@@ -234,10 +408,44 @@ type WebhookPayload struct {
 	Context        map[string]string `json:"context,omitempty"`
 	RuleIDs        []string          `json:"rule_ids"`
 	Timestamp      string            `json:"timestamp"`
+	EventTS        string            `json:"event_ts,omitempty"` // RFC 3339 in the client's timezone, if known
+	CreatedAt      string            `json:"created_at"`         // RFC 3339 in the client's timezone
+	Timezone       string            `json:"timezone"`           // the client's IANA timezone, "UTC" if unset
+	Alerts         []WebhookAlert    `json:"alerts,omitempty"` // the grouped alerts of a digest
+}
+
+// WebhookAlert is one grouped alert of a digest webhook payload.
+type WebhookAlert struct {
+	NotificationID string            `json:"notification_id"`
+	AlertID        string            `json:"alert_id"`
+	Severity       string            `json:"severity"`
+	Source         string            `json:"source"`
+	Name           string            `json:"name"`
+	Context        map[string]string `json:"context,omitempty"`
+	RuleIDs        []string          `json:"rule_ids"`
+	EventTS        string            `json:"event_ts,omitempty"`
+	CreatedAt      string            `json:"created_at"`
 }
 
 // BuildWebhookPayload builds a webhook payload from the notification.
+// A digest lists its grouped alerts in Alerts. Timestamp is the send time in UTC; the
+// notification's own timestamps carry the client's UTC offset.
 func BuildWebhookPayload(notification *database.Notification) WebhookPayload {
+	format := timestamps(notification)
+	var alerts []WebhookAlert
+	for _, alert := range notification.Grouped {
+		alerts = append(alerts, WebhookAlert{
+			NotificationID: alert.NotificationID,
+			AlertID:        alert.AlertID,
+			Severity:       alert.Severity,
+			Source:         alert.Source,
+			Name:           alert.Name,
+			Context:        alert.Context,
+			RuleIDs:        alert.RuleIDs,
+			EventTS:        webhookTime(format, alert.EventTime),
+			CreatedAt:      format.RFC3339(alert.CreatedAt),
+		})
+	}
 	return WebhookPayload{
 		NotificationID: notification.NotificationID,
 		ClientID:       notification.ClientID,
@@ -248,5 +456,17 @@ func BuildWebhookPayload(notification *database.Notification) WebhookPayload {
 		Context:        notification.Context,
 		RuleIDs:        notification.RuleIDs,
 		Timestamp:      time.Now().UTC().Format(time.RFC3339),
+		EventTS:        webhookTime(format, notification.EventTime),
+		CreatedAt:      format.RFC3339(notification.CreatedAt),
+		Timezone:       format.Location.String(),
+		Alerts:         alerts,
+	}
+}
+
+// webhookTime renders t for a webhook payload, "" if it is unknown.
+func webhookTime(format shared.TimestampFormat, t time.Time) string {
+	if t.IsZero() {
+		return ""
 	}
+	return format.RFC3339(t)
 }
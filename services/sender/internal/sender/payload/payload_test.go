@@ -163,7 +163,7 @@ func TestBuildSlackPayload_NoRuleIDs(t *testing.T) {
 	}
 }
 
-func TestGetSeverityColor(t *testing.T) {
+func TestBuildSlackPayload_SeverityColor(t *testing.T) {
 	tests := []struct {
 		name     string
 		severity string
@@ -172,37 +172,37 @@ func TestGetSeverityColor(t *testing.T) {
 		{
 			name:     "CRITICAL",
 			severity: "CRITICAL",
-			want:     "danger",
+			want:     "#dc2626",
 		},
 		{
 			name:     "critical lowercase",
 			severity: "critical",
-			want:     "danger",
+			want:     "#dc2626",
 		},
 		{
 			name:     "HIGH",
 			severity: "HIGH",
-			want:     "warning",
+			want:     "#ea580c",
 		},
 		{
 			name:     "MEDIUM",
 			severity: "MEDIUM",
-			want:     "warning",
+			want:     "#ca8a04",
 		},
 		{
 			name:     "LOW",
 			severity: "LOW",
-			want:     "good",
+			want:     "#16a34a",
 		},
 		{
 			name:     "unknown severity",
 			severity: "UNKNOWN",
-			want:     "good",
+			want:     "#6b7280",
 		},
 		{
 			name:     "empty severity",
 			severity: "",
-			want:     "good",
+			want:     "#6b7280",
 		},
 	}
 
@@ -218,7 +218,7 @@ func TestGetSeverityColor(t *testing.T) {
 			}
 			got := payload.Attachments[0].Color
 			if got != tt.want {
-				t.Errorf("getSeverityColor(%s) = %v, want %v", tt.severity, got, tt.want)
+				t.Errorf("BuildSlackPayload(%s) color = %v, want %v", tt.severity, got, tt.want)
 			}
 		})
 	}
@@ -313,3 +313,201 @@ func TestBuildWebhookPayload_NoContext(t *testing.T) {
 		t.Errorf("BuildWebhookPayload() Context should be empty, got %v", payload.Context)
 	}
 }
+
+// digestNotification returns a digest listing two grouped alerts.
+func digestNotification() *database.Notification {
+	created := time.Date(2026, 10, 15, 12, 31, 0, 0, time.UTC)
+	return &database.Notification{
+		NotificationID: "digest-1",
+		ClientID:       "client-456",
+		AlertID:        "digest:client-456:1792067400",
+		Severity:       "CRITICAL",
+		Source:         "digest",
+		Name:           "2 alerts",
+		Context:        map[string]string{"alert_count": "2"},
+		RuleIDs:        []string{"rule-001", "rule-002"},
+		Grouped: []*database.Notification{
+			{NotificationID: "notif-1", AlertID: "alert-1", Severity: "CRITICAL", Source: "api", Name: "Disk full", RuleIDs: []string{"rule-001"}, CreatedAt: created},
+			{NotificationID: "notif-2", AlertID: "alert-2", Severity: "LOW", Source: "db", Name: "Slow query", RuleIDs: []string{"rule-002"}, CreatedAt: created},
+		},
+	}
+}
+
+func TestBuildEmailPayload_Digest(t *testing.T) {
+	payload := BuildEmailPayload(digestNotification())
+
+	if !strings.HasPrefix(payload.Subject, "Alert digest: CRITICAL") {
+		t.Errorf("BuildEmailPayload() subject = %q, want an alert digest subject", payload.Subject)
+	}
+	for _, want := range []string{"Grouped Alerts (2)", "[CRITICAL] Disk full from api (alert alert-1, 2026-10-15 12:31:00 UTC)", "Slow query"} {
+		if !strings.Contains(payload.Body, want) {
+			t.Errorf("BuildEmailPayload() body should contain %q", want)
+		}
+	}
+	if !strings.Contains(payload.HTML, "Grouped Alerts (2)") || !strings.Contains(payload.HTML, "Slow query") {
+		t.Error("BuildEmailPayload() HTML should list the grouped alerts")
+	}
+}
+
+func TestBuildSlackPayload_Digest(t *testing.T) {
+	payload := BuildSlackPayload(digestNotification())
+
+	attachment := payload.Attachments[0]
+	if !strings.HasPrefix(attachment.Title, "Alert digest:") {
+		t.Errorf("BuildSlackPayload() title = %q, want an alert digest title", attachment.Title)
+	}
+	if !strings.Contains(attachment.Text, "Grouped alerts (2)") || !strings.Contains(attachment.Text, "Disk full") {
+		t.Errorf("BuildSlackPayload() text should list the grouped alerts, got %q", attachment.Text)
+	}
+}
+
+func TestBuildWebhookPayload_Digest(t *testing.T) {
+	payload := BuildWebhookPayload(digestNotification())
+
+	if len(payload.Alerts) != 2 {
+		t.Fatalf("BuildWebhookPayload() Alerts = %d, want 2", len(payload.Alerts))
+	}
+	alert := payload.Alerts[0]
+	if alert.NotificationID != "notif-1" || alert.AlertID != "alert-1" || alert.Severity != "CRITICAL" || alert.CreatedAt != "2026-10-15T12:31:00Z" {
+		t.Errorf("BuildWebhookPayload() Alerts[0] = %+v", alert)
+	}
+
+	// A notification that is not a digest has no alerts
+	if plain := BuildWebhookPayload(&database.Notification{}); plain.Alerts != nil {
+		t.Errorf("BuildWebhookPayload() Alerts = %v, want nil", plain.Alerts)
+	}
+}
+
+func TestPayloads_ClientTimezoneAndLocale(t *testing.T) {
+	created := time.Date(2026, 10, 15, 12, 31, 0, 0, time.UTC)
+	notification := &database.Notification{
+		NotificationID: "notif-123",
+		Severity:       "HIGH",
+		Name:           "Test Alert",
+		CreatedAt:      created,
+		EventTime:      created.Add(-time.Minute),
+		Timezone:       "Europe/Berlin",
+		Locale:         "de-DE",
+	}
+
+	email := BuildEmailPayload(notification)
+	for _, want := range []string{"Event Time: 15.10.2026 14:30:00 CEST", "Created: 15.10.2026 14:31:00 CEST"} {
+		if !strings.Contains(email.Body, want) {
+			t.Errorf("BuildEmailPayload() body should contain %q, got %q", want, email.Body)
+		}
+		if !strings.Contains(email.HTML, strings.SplitN(want, ": ", 2)[1]) {
+			t.Errorf("BuildEmailPayload() HTML should contain %q", want)
+		}
+	}
+
+	fields := map[string]string{}
+	for _, field := range BuildSlackPayload(notification).Attachments[0].Fields {
+		fields[field.Title] = field.Value
+	}
+	if fields["Event Time"] != "15.10.2026 14:30:00 CEST" || fields["Created"] != "15.10.2026 14:31:00 CEST" {
+		t.Errorf("BuildSlackPayload() fields = %v, want local event and created times", fields)
+	}
+
+	webhook := BuildWebhookPayload(notification)
+	if webhook.EventTS != "2026-10-15T14:30:00+02:00" || webhook.CreatedAt != "2026-10-15T14:31:00+02:00" || webhook.Timezone != "Europe/Berlin" {
+		t.Errorf("BuildWebhookPayload() = event_ts %q, created_at %q, timezone %q", webhook.EventTS, webhook.CreatedAt, webhook.Timezone)
+	}
+
+	// Without a profile or event time, timestamps are UTC and event_ts is omitted
+	notification.Timezone, notification.Locale, notification.EventTime = "", "", time.Time{}
+	if body := BuildEmailPayload(notification).Body; strings.Contains(body, "Event Time") || !strings.Contains(body, "Created: 2026-10-15 12:31:00 UTC") {
+		t.Errorf("BuildEmailPayload() body = %q, want only the UTC creation time", body)
+	}
+	if webhook := BuildWebhookPayload(notification); webhook.EventTS != "" || webhook.CreatedAt != "2026-10-15T12:31:00Z" || webhook.Timezone != "UTC" {
+		t.Errorf("BuildWebhookPayload() = event_ts %q, created_at %q, timezone %q", webhook.EventTS, webhook.CreatedAt, webhook.Timezone)
+	}
+}
+
+func TestPayloads_RelatedAlerts(t *testing.T) {
+	fired := time.Date(2026, 10, 15, 12, 31, 0, 0, time.UTC)
+	notification := &database.Notification{
+		NotificationID: "notif-123",
+		AlertID:        "alert-3",
+		Severity:       "HIGH",
+		Name:           "Test Alert",
+		CreatedAt:      fired.Add(time.Hour),
+		Related: []*database.RelatedAlert{
+			{NotificationID: "notif-2", AlertID: "alert-2", Status: "RESOLVED", FiredAt: fired, RepeatCount: 3},
+			{NotificationID: "notif-1", AlertID: "alert-1", Status: "ACKNOWLEDGED", FiredAt: fired.Add(-time.Hour)},
+		},
+	}
+	want := []string{
+		"alert-2 at 2026-10-15 12:31:00 UTC (RESOLVED, repeated 3 times)",
+		"alert-1 at 2026-10-15 11:31:00 UTC (ACKNOWLEDGED)",
+	}
+
+	email := BuildEmailPayload(notification)
+	if !strings.Contains(email.Body, "Previously Fired (2):") || !strings.Contains(email.HTML, "Previously Fired (2)") {
+		t.Errorf("BuildEmailPayload() should list the related alerts, got %q", email.Body)
+	}
+	text := BuildSlackPayload(notification).Attachments[0].Text
+	for _, line := range want {
+		if !strings.Contains(email.Body, line) || !strings.Contains(email.HTML, line) {
+			t.Errorf("BuildEmailPayload() should contain %q", line)
+		}
+		if !strings.Contains(text, line) {
+			t.Errorf("BuildSlackPayload() text should contain %q, got %q", line, text)
+		}
+	}
+
+	// The cap keeps the newest alerts that fit
+	LimitRelatedAlerts(notification, len(want[0])+10)
+	if len(notification.Related) != 1 || notification.Related[0].AlertID != "alert-2" {
+		t.Errorf("LimitRelatedAlerts() kept %d alerts, want only alert-2", len(notification.Related))
+	}
+	LimitRelatedAlerts(notification, 10)
+	if len(notification.Related) != 0 {
+		t.Errorf("LimitRelatedAlerts() kept %d alerts, want none", len(notification.Related))
+	}
+	if body := BuildEmailPayload(notification).Body; strings.Contains(body, "Previously Fired") {
+		t.Errorf("BuildEmailPayload() body = %q, want no related alerts section", body)
+	}
+}
+
+func TestPayloads_Template(t *testing.T) {
+	created := time.Date(2026, 10, 15, 12, 31, 0, 0, time.UTC)
+	notification := &database.Notification{
+		NotificationID: "notif-123",
+		AlertID:        "alert-789",
+		Severity:       "HIGH",
+		Source:         "api",
+		Name:           "Test Alert",
+		Context:        map[string]string{"host": "web-1"},
+		CreatedAt:      created,
+		Template: &database.Template{
+			TemplateID: "template-1",
+			Subject:    "[{{.Severity}}] {{.Name}}",
+			Body:       "{{upper .Source}} {{.Name}} on {{index .Context \"host\"}} at {{.Created}}{{index .Context \"missing\"}}",
+		},
+	}
+	wantBody := "API Test Alert on web-1 at 2026-10-15 12:31:00 UTC"
+
+	email := BuildEmailPayload(notification)
+	if email.Subject != "[HIGH] Test Alert" || email.Body != wantBody || email.HTML != "" {
+		t.Errorf("BuildEmailPayload() = %+v, want the rendered template", email)
+	}
+	attachment := BuildSlackPayload(notification).Attachments[0]
+	if attachment.Text != wantBody || attachment.Color != "#ea580c" || len(attachment.Fields) != 0 {
+		t.Errorf("BuildSlackPayload() attachment = %+v, want the rendered body", attachment)
+	}
+
+	// Without a subject the default subject is kept
+	notification.Template.Subject = ""
+	if subject := BuildEmailPayload(notification).Subject; subject != "Alert: HIGH - Test Alert" {
+		t.Errorf("BuildEmailPayload() subject = %q, want the default subject", subject)
+	}
+
+	// A template failing to render falls back to the default layout
+	notification.Template.Body = "{{.Nope}}"
+	if email := BuildEmailPayload(notification); !strings.Contains(email.Body, "Alert Notification") || email.HTML == "" {
+		t.Errorf("BuildEmailPayload() body = %q, want the default layout", email.Body)
+	}
+	if text := BuildSlackPayload(notification).Attachments[0].Text; !strings.Contains(text, "*Alert: Test Alert*") {
+		t.Errorf("BuildSlackPayload() text = %q, want the default layout", text)
+	}
+}
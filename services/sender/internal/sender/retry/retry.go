@@ -3,9 +3,11 @@ package retry
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"math"
 	"math/rand"
+	"net"
 	"strings"
 	"time"
 )
@@ -36,6 +38,12 @@ func IsRetryable(err error) bool {
 		return false
 	}
 
+	// DNS failures (resolver outage, propagation delay, negative-cached lookups) are treated
+	// as transient and retried with backoff rather than failing the endpoint outright
+	if IsDNSError(err) {
+		return true
+	}
+
 	errStr := strings.ToLower(err.Error())
 
 	// Non-retryable errors (permanent failures)
@@ -79,6 +87,16 @@ func IsRetryable(err error) bool {
 	return false
 }
 
+// IsDNSError reports whether err is a DNS resolution failure.
+func IsDNSError(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	// Some clients flatten errors to strings; match the resolver's wording
+	return err != nil && strings.Contains(err.Error(), "no such host")
+}
+
 // WithRetry executes a function with retry logic and exponential backoff.
 // It only retries on transient errors determined by IsRetryable.
 func WithRetry(ctx context.Context, cfg Config, operation string, fn func() error) error {
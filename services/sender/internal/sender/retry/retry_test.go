@@ -3,6 +3,8 @@ package retry
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net"
 	"testing"
 	"time"
 )
@@ -48,6 +50,16 @@ func TestIsRetryable(t *testing.T) {
 			err:      errors.New("validation error: invalid email"),
 			expected: false,
 		},
+		{
+			name:     "DNS not found",
+			err:      fmt.Errorf("failed to send webhook notification: %w", &net.DNSError{Err: "no such host", Name: "hooks.internal", IsNotFound: true}),
+			expected: true,
+		},
+		{
+			name:     "DNS failure flattened to string",
+			err:      errors.New(`Post "https://hooks.internal/x": dial tcp: lookup hooks.internal: no such host`),
+			expected: true,
+		},
 		{
 			name:     "generic error",
 			err:      errors.New("some random error"),
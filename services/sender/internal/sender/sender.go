@@ -4,63 +4,411 @@ package sender
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"strings"
+	"time"
 
 	"sender/internal/database"
 	"sender/internal/sender/email"
+	"sender/internal/sender/failure"
+	"sender/internal/sender/ledger"
+	"sender/internal/sender/null"
+	"sender/internal/sender/oauth2"
+	"sender/internal/sender/payload"
 	"sender/internal/sender/retry"
 	"sender/internal/sender/slack"
 	"sender/internal/sender/strategy"
 	"sender/internal/sender/webhook"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
+)
+
+// Default send timeouts.
+const (
+	// DefaultChannelTimeout bounds a single send attempt to one endpoint.
+	DefaultChannelTimeout = 10 * time.Second
+	// DefaultSendDeadline bounds the total time spent sending one notification, retries included.
+	DefaultSendDeadline = 60 * time.Second
 )
 
+// DefaultRelatedAlertsMaxBytes caps the related alert lines of one notification (see WithRelatedAlerts).
+const DefaultRelatedAlertsMaxBytes = 1024
+
+// Options configures optional sender behavior.
+type Options struct {
+	// SlackBotToken enables posting to Slack channels by name.
+	SlackBotToken string
+	// Owners resolves the owning team's Slack channel of an alert source. Non-nil enables
+	// owner routing for rules that have no Slack endpoint of their own.
+	Owners OwnerChannels
+	// ChannelTimeouts bounds each send attempt per endpoint type (e.g. "webhook").
+	// Types without a positive entry use DefaultChannelTimeout.
+	ChannelTimeouts map[string]time.Duration
+	// SendDeadline bounds the total time spent sending one notification.
+	// Zero uses DefaultSendDeadline.
+	SendDeadline time.Duration
+	// ChannelRetries is the maximum number of retries of a transient failure per endpoint
+	// type (e.g. "webhook"). Types without a non-negative entry use retry.DefaultConfig().
+	ChannelRetries map[string]int
+	// HTTPClient is shared by the Slack and webhook senders (see the httpclient package).
+	// If nil, each uses its own default client.
+	HTTPClient *http.Client
+	// Secrets decrypts secret webhook header values and OAuth2 client secrets.
+	// If nil, endpoints with secret headers or OAuth2 fail to send.
+	Secrets *shared.SecretBox
+	// TokenRecorder records OAuth2 token fetches. Optional.
+	TokenRecorder oauth2.Recorder
+	// DryRun simulates every send instead of contacting email, Slack or webhook endpoints.
+	// Simulated sends are logged and reported to SimulationObserver observers.
+	DryRun bool
+}
+
+// TimeoutRecorder counts sends cut short by a timeout.
+type TimeoutRecorder interface {
+	// RecordChannelTimeout records a send attempt that exceeded its channel timeout.
+	RecordChannelTimeout(endpointType string)
+	// RecordSendDeadlineExceeded records a notification that ran out of send deadline.
+	RecordSendDeadlineExceeded()
+}
+
+// noOpTimeouts is used when no timeout recorder is configured.
+type noOpTimeouts struct{}
+
+func (noOpTimeouts) RecordChannelTimeout(string) {}
+func (noOpTimeouts) RecordSendDeadlineExceeded() {}
+
+// FailureRecorder counts failed send attempts by failure class (see the failure package).
+type FailureRecorder interface {
+	// RecordSendFailure records a failed send attempt to an endpoint type.
+	RecordSendFailure(endpointType, class string)
+}
+
+// noOpFailures is used when no failure recorder is configured.
+type noOpFailures struct{}
+
+func (noOpFailures) RecordSendFailure(string, string) {}
+
+// EndpointLimiter rate limits sends per endpoint, with one limit per endpoint type.
+// It is implemented by shared.RateLimiter, with a tier named after each limited type.
+type EndpointLimiter interface {
+	// AllowIn takes a token from caller's bucket in the named tier.
+	AllowIn(ctx context.Context, tier, caller string) (shared.RateLimitDecision, error)
+}
+
+// ThrottleRecorder counts endpoint sends held back by their rate limit.
+type ThrottleRecorder interface {
+	// RecordEndpointThrottled records a send to an endpoint type held back by its rate limit.
+	RecordEndpointThrottled(endpointType string)
+	// RecordRateLimitError records a rate limit check that failed; the send went ahead.
+	RecordRateLimitError()
+}
+
+// noOpThrottles is used when no throttle recorder is configured.
+type noOpThrottles struct{}
+
+func (noOpThrottles) RecordEndpointThrottled(string) {}
+func (noOpThrottles) RecordRateLimitError()          {}
+
+// ClientProfiles looks up the timezone and locale a client's notifications are rendered with.
+// It is implemented by database.DB.
+type ClientProfiles interface {
+	GetClientProfile(ctx context.Context, clientID string) (*database.ClientProfile, error)
+}
+
+// OwnerChannels resolves the Slack channel of the team owning an alert source, or "" if it is
+// unknown. It is implemented by ownership.Resolver.
+type OwnerChannels interface {
+	SlackChannel(ctx context.Context, source string) string
+}
+
+// RelatedAlerts looks up the earlier alerts with the same fingerprint as a notification.
+// It is implemented by database.DB.
+type RelatedAlerts interface {
+	GetRelatedAlerts(ctx context.Context, notificationID string, limit int) ([]*database.RelatedAlert, error)
+}
+
+// Templates looks up the notification templates endpoints render their messages with.
+// It is implemented by database.DB.
+type Templates interface {
+	GetTemplate(ctx context.Context, templateID string) (*database.Template, error)
+}
+
+// DeliveryObserver is notified of the outcome of every endpoint delivery (after retries).
+type DeliveryObserver interface {
+	ObserveDelivery(ctx context.Context, notification *database.Notification, endpointType string, err error)
+}
+
+// SimulationObserver is optionally implemented by a DeliveryObserver to tell simulated
+// (dry-run) deliveries apart. Observers without it see simulated deliveries as successful.
+type SimulationObserver interface {
+	ObserveSimulation(ctx context.Context, notification *database.Notification, endpointType string)
+}
+
+// DeliveryDetailObserver is optionally implemented by a DeliveryObserver to receive the details
+// of every endpoint delivery, simulated ones included: the endpoint, status, attempts and time taken.
+// Endpoints skipped because an earlier delivery of the notification reached them are not reported.
+type DeliveryDetailObserver interface {
+	ObserveDeliveryDetail(ctx context.Context, delivery database.Delivery)
+}
+
 // Sender coordinates notification sending across multiple channels.
 type Sender struct {
-	registry *strategy.Registry
+	registry  *strategy.Registry
+	owners    OwnerChannels
+	dryRun    bool
+	observers []DeliveryObserver
+
+	channelTimeouts map[string]time.Duration
+	sendDeadline    time.Duration
+	channelRetries  map[string]int
+	delivered       *ledger.Ledger
+	timeouts        TimeoutRecorder
+	failures        FailureRecorder
+	limiter         EndpointLimiter
+	throttles       ThrottleRecorder
+	profiles        ClientProfiles
+	related         RelatedAlerts
+	relatedLimit    int
+	relatedMaxBytes int
+	templates       Templates
+	secrets         *shared.SecretBox
+	tokens          *oauth2.Cache
 }
 
 // NewSender creates a new sender coordinator with all strategies registered.
 func NewSender() *Sender {
+	return NewSenderWithOptions(Options{})
+}
+
+// NewSenderWithOptions creates a new sender coordinator with all strategies registered
+// and the given options applied.
+func NewSenderWithOptions(opts Options) *Sender {
 	registry := strategy.NewRegistry()
 
 	// Register all sender strategies
 	registry.Register(email.NewSender())
-	registry.Register(slack.NewSender())
-	registry.Register(webhook.NewSender())
+	if opts.HTTPClient != nil {
+		registry.Register(slack.NewSenderWithClient(opts.SlackBotToken, opts.HTTPClient))
+		registry.Register(webhook.NewSenderWithClient(opts.HTTPClient))
+	} else {
+		registry.Register(slack.NewSenderWithBotToken(opts.SlackBotToken))
+		registry.Register(webhook.NewSender())
+	}
+	registry.Register(null.NewSender())
 
-	return &Sender{
-		registry: registry,
+	s := &Sender{
+		registry:  registry,
+		owners:    opts.Owners,
+		dryRun:    opts.DryRun,
+		timeouts:  noOpTimeouts{},
+		failures:  noOpFailures{},
+		throttles: noOpThrottles{},
+		secrets:   opts.Secrets,
+		tokens:    oauth2.NewCache(opts.HTTPClient, opts.TokenRecorder),
+		delivered: ledger.New(0, 0),
 	}
+	s.SetTimeouts(opts.ChannelTimeouts, opts.SendDeadline)
+	s.SetRetryLimits(opts.ChannelRetries)
+	return s
 }
 
 // NewSenderWithRegistry creates a new sender coordinator with a custom registry.
 // This is useful for testing or custom sender configurations.
 func NewSenderWithRegistry(registry *strategy.Registry) *Sender {
 	return &Sender{
-		registry: registry,
+		registry:     registry,
+		sendDeadline: DefaultSendDeadline,
+		timeouts:     noOpTimeouts{},
+		failures:     noOpFailures{},
+		throttles:    noOpThrottles{},
+		tokens:       oauth2.NewCache(nil, nil),
+		delivered:    ledger.New(0, 0),
 	}
 }
 
+// EnableOwnerRouting turns on automatic routing to the owning team's Slack channel, as
+// resolved by owners.
+func (s *Sender) EnableOwnerRouting(owners OwnerChannels) {
+	s.owners = owners
+}
+
+// EnableDryRun turns on dry-run mode: sends are simulated instead of delivered.
+func (s *Sender) EnableDryRun() {
+	s.dryRun = true
+}
+
+// DryRun reports whether sends are simulated.
+func (s *Sender) DryRun() bool {
+	return s.dryRun
+}
+
+// SetTimeouts sets the per-channel attempt timeouts and the per-notification send deadline.
+// Missing or non-positive values fall back to DefaultChannelTimeout and DefaultSendDeadline.
+func (s *Sender) SetTimeouts(channelTimeouts map[string]time.Duration, sendDeadline time.Duration) {
+	if sendDeadline <= 0 {
+		sendDeadline = DefaultSendDeadline
+	}
+	s.channelTimeouts = channelTimeouts
+	s.sendDeadline = sendDeadline
+}
+
+// SetRetryLimits sets the maximum number of retries per endpoint type.
+// Missing or negative values fall back to retry.DefaultConfig().
+func (s *Sender) SetRetryLimits(channelRetries map[string]int) {
+	s.channelRetries = channelRetries
+}
+
+// WithTimeoutRecorder sets the recorder for channel timeouts and exceeded send deadlines.
+func (s *Sender) WithTimeoutRecorder(r TimeoutRecorder) *Sender {
+	if r == nil {
+		r = noOpTimeouts{}
+	}
+	s.timeouts = r
+	return s
+}
+
+// WithFailureRecorder sets the recorder for failed send attempts.
+func (s *Sender) WithFailureRecorder(r FailureRecorder) *Sender {
+	if r == nil {
+		r = noOpFailures{}
+	}
+	s.failures = r
+	return s
+}
+
+// WithEndpointLimiter rate limits deliveries per endpoint (see Deliver). Reminders are not
+// limited. Passing nil disables rate limiting.
+func (s *Sender) WithEndpointLimiter(l EndpointLimiter) *Sender {
+	s.limiter = l
+	return s
+}
+
+// WithThrottleRecorder sets the recorder for rate-limited sends and failed rate limit checks.
+func (s *Sender) WithThrottleRecorder(r ThrottleRecorder) *Sender {
+	if r == nil {
+		r = noOpThrottles{}
+	}
+	s.throttles = r
+	return s
+}
+
+// WithClientProfiles renders notification timestamps in each client's timezone and locale.
+// Without it, or if the lookup fails, timestamps are rendered in UTC.
+func (s *Sender) WithClientProfiles(p ClientProfiles) *Sender {
+	s.profiles = p
+	return s
+}
+
+// WithRelatedAlerts lists up to limit earlier alerts with the same fingerprint in email and
+// Slack notifications, dropping the oldest until their lines take at most maxBytes. Digests
+// do not list related alerts. Passing nil or a non-positive limit disables it.
+func (s *Sender) WithRelatedAlerts(r RelatedAlerts, limit, maxBytes int) *Sender {
+	s.related = r
+	s.relatedLimit = limit
+	s.relatedMaxBytes = maxBytes
+	return s
+}
+
+// WithTemplates renders the messages of email and Slack endpoints with a template_id using
+// their template. Templates are read at send time, so edits apply to the next notification.
+// Without it, or if the lookup or rendering fails, the default layout is sent.
+func (s *Sender) WithTemplates(t Templates) *Sender {
+	s.templates = t
+	return s
+}
+
+// WithDeliveryObserver adds an observer for per-endpoint delivery outcomes.
+// Observers are called in the order they were added.
+func (s *Sender) WithDeliveryObserver(o DeliveryObserver) *Sender {
+	s.observers = append(s.observers, o)
+	return s
+}
+
+// DeliveryResult summarizes the endpoint deliveries of one notification.
+type DeliveryResult struct {
+	// Delivered counts endpoints sent to successfully (or simulated in dry-run mode).
+	Delivered int
+	// Failed counts endpoints whose send failed after retries.
+	Failed int
+	// AlreadyDelivered counts the delivered endpoints that were skipped because an earlier
+	// delivery of the same notification reached them. They are included in Delivered.
+	AlreadyDelivered int
+	// Throttled counts endpoints not sent to because they were over their rate limit.
+	Throttled int
+	// RetryAfter is how long until every throttled endpoint gets a token back.
+	RetryAfter time.Duration
+	// Errors describes each failed endpoint.
+	Errors []string
+}
+
+// Partial reports whether some, but not all, endpoints failed.
+func (r DeliveryResult) Partial() bool {
+	return r.Failed > 0 && r.Delivered > 0
+}
+
 // SendNotification sends notifications to all relevant endpoints for the given notification.
 // It supports email, Slack, and webhook endpoints using the strategy pattern.
+// It fails only if no endpoint was delivered to; use Deliver to detect partial failures.
+// Every endpoint is sent to, even if an earlier delivery of the notification reached it
+// (reminders re-send on purpose).
 func (s *Sender) SendNotification(ctx context.Context, notification *database.Notification, endpoints map[string][]database.Endpoint) error {
-	if len(endpoints) == 0 {
+	_, err := s.deliver(ctx, notification, endpoints, false)
+	return err
+}
+
+// Deliver sends the notification to all relevant endpoints like SendNotification and also
+// returns how many endpoints were delivered to and how many failed.
+// Each endpoint is retried on its own, and endpoints an earlier delivery of the notification
+// reached are skipped (see ForgetDeliveries), so a redelivered notification only goes to the
+// endpoints that did not get it. Endpoints over their rate limit (see WithEndpointLimiter) are
+// not sent to and are counted in Throttled; a delivery with throttled endpoints never fails,
+// it is delivered again later.
+func (s *Sender) Deliver(ctx context.Context, notification *database.Notification, endpoints map[string][]database.Endpoint) (DeliveryResult, error) {
+	return s.deliver(ctx, notification, endpoints, true)
+}
+
+// deliver sends the notification to its endpoints. With skipDelivered, endpoints an earlier
+// delivery of the notification reached are skipped.
+func (s *Sender) deliver(ctx context.Context, notification *database.Notification, endpoints map[string][]database.Endpoint, skipDelivered bool) (DeliveryResult, error) {
+	var result DeliveryResult
+	ownerChannel := s.ownerChannel(ctx, notification)
+	s.applyClientProfile(ctx, notification)
+	s.applyRelatedAlerts(ctx, notification)
+
+	if len(endpoints) == 0 && ownerChannel == "" {
 		slog.Warn("No endpoints found for notification",
 			"notification_id", notification.NotificationID,
 			"rule_ids", notification.RuleIDs,
 		)
-		return fmt.Errorf("no endpoints found for notification %s", notification.NotificationID)
+		return result, fmt.Errorf("no endpoints found for notification %s", notification.NotificationID)
 	}
 
 	// Group endpoints by type and value
 	endpointsByType := s.groupEndpoints(endpoints, notification.RuleIDs)
+	configs := endpointConfigs(endpoints, notification.RuleIDs)
+	endpointIDs := endpointIDs(endpoints, notification.RuleIDs)
+	templateIDs := endpointTemplates(endpoints, notification.RuleIDs)
+	templates := make(map[string]*database.Template)
+
+	// Route to the owning team's channel for rules without their own Slack endpoint
+	if ownerChannel != "" && needsOwnerRoute(endpoints, notification.RuleIDs) {
+		endpointsByType["slack"] = appendUnique(endpointsByType["slack"], ownerChannel)
+	}
+
+	// Bound the whole notification so one hanging endpoint can't stall the worker
+	ctx, cancel := context.WithTimeout(ctx, s.sendDeadline)
+	defer cancel()
 
 	// Send to all endpoint types
 	var errors []string
 	totalEndpoints := 0
 	successfulSends := 0
+	alreadyDelivered := 0
+	throttled := 0
+	var retryAfter time.Duration
 
 	for endpointType, endpointValues := range endpointsByType {
 		sender, ok := s.registry.Get(endpointType)
@@ -74,25 +422,98 @@ func (s *Sender) SendNotification(ctx context.Context, notification *database.No
 
 		totalEndpoints += len(endpointValues)
 		for _, endpointValue := range endpointValues {
+			key := endpointKey{endpointType, endpointValue}
+			delivery := database.Delivery{
+				NotificationID: notification.NotificationID,
+				EndpointID:     endpointIDs[key],
+				Channel:        endpointType,
+			}
+
+			if ctx.Err() != nil {
+				errors = append(errors, fmt.Sprintf("%s (%s): send deadline exceeded", endpointType, endpointValue))
+				delivery.Status, delivery.Err = database.DeliveryFailed, fmt.Errorf("send deadline exceeded")
+				s.observeDetail(ctx, delivery)
+				continue
+			}
+
+			if s.dryRun {
+				s.simulate(ctx, notification, endpointType, endpointValue)
+				delivery.Status = database.DeliverySimulated
+				s.observeDetail(ctx, delivery)
+				successfulSends++
+				continue
+			}
+
+			if skipDelivered && s.delivered.Delivered(notification.NotificationID, key.String()) {
+				slog.Info("Endpoint already delivered to, skipping",
+					"notification_id", notification.NotificationID,
+					"type", endpointType,
+					"endpoint", endpointValue,
+				)
+				alreadyDelivered++
+				successfulSends++
+				continue
+			}
+
+			if skipDelivered {
+				if wait, limited := s.rateLimited(ctx, notification.NotificationID, key); limited {
+					throttled++
+					if wait > retryAfter {
+						retryAfter = wait
+					}
+					continue
+				}
+			}
+
+			auth, err := s.resolveAuth(configs[key])
+			if err != nil {
+				s.failures.RecordSendFailure(endpointType, string(failure.Invalid))
+				errors = append(errors, fmt.Sprintf("%s (%s): %s", endpointType, endpointValue, err.Error()))
+				delivery.Status, delivery.Err = database.DeliveryFailed, err
+				s.observeDetail(ctx, delivery)
+				continue
+			}
+
 			// Use retry with exponential backoff for transient failures
-			retryCfg := retry.DefaultConfig()
+			retryCfg := s.retryConfig(endpointType)
 			operation := fmt.Sprintf("send_%s_%s", endpointType, notification.NotificationID)
+			message := s.withTemplate(ctx, notification, templateIDs[key], templates)
 
-			err := retry.WithRetry(ctx, retryCfg, operation, func() error {
-				return sender.Send(ctx, endpointValue, notification)
+			start := time.Now()
+			err = retry.WithRetry(ctx, retryCfg, operation, func() error {
+				delivery.Attempts++
+				return s.sendAttempt(ctx, sender, endpointType, endpointValue, auth, message)
 			})
+			delivery.Latency = time.Since(start)
+			for _, o := range s.observers {
+				o.ObserveDelivery(ctx, notification, endpointType, err)
+			}
 
 			if err != nil {
 				errors = append(errors, fmt.Sprintf("%s (%s): %s", endpointType, endpointValue, err.Error()))
+				delivery.Status, delivery.Err = database.DeliveryFailed, err
 			} else {
+				s.delivered.MarkDelivered(notification.NotificationID, key.String())
 				successfulSends++
+				delivery.Status = database.DeliverySent
 			}
+			s.observeDetail(ctx, delivery)
 		}
 	}
 
-	// If all sends failed, return error
-	if len(errors) > 0 && successfulSends == 0 {
-		return fmt.Errorf("all sends failed: %s", strings.Join(errors, "; "))
+	if ctx.Err() == context.DeadlineExceeded {
+		s.timeouts.RecordSendDeadlineExceeded()
+		slog.Warn("Send deadline exceeded",
+			"notification_id", notification.NotificationID,
+			"deadline", s.sendDeadline,
+		)
+	}
+
+	result = DeliveryResult{Delivered: successfulSends, Failed: len(errors), AlreadyDelivered: alreadyDelivered, Throttled: throttled, RetryAfter: retryAfter, Errors: errors}
+
+	// If all sends failed, return error; throttled endpoints are still to be sent to
+	if len(errors) > 0 && successfulSends == 0 && throttled == 0 {
+		return result, fmt.Errorf("all sends failed: %s", strings.Join(errors, "; "))
 	}
 
 	// If some sends failed, log warning but don't fail
@@ -105,7 +526,197 @@ func (s *Sender) SendNotification(ctx context.Context, notification *database.No
 		)
 	}
 
-	return nil
+	return result, nil
+}
+
+// rateLimited takes a token from the endpoint's rate limit bucket and reports whether the
+// endpoint is over its limit, and if so how long until it gets a token back. A failed check
+// lets the send through.
+func (s *Sender) rateLimited(ctx context.Context, notificationID string, key endpointKey) (time.Duration, bool) {
+	if s.limiter == nil {
+		return 0, false
+	}
+	decision, err := s.limiter.AllowIn(ctx, key.Type, key.String())
+	if err != nil {
+		slog.Warn("Failed to check endpoint rate limit, sending anyway",
+			"notification_id", notificationID,
+			"type", key.Type,
+			"error", err,
+		)
+		s.throttles.RecordRateLimitError()
+		return 0, false
+	}
+	if decision.Allowed {
+		return 0, false
+	}
+	s.throttles.RecordEndpointThrottled(key.Type)
+	slog.Info("Endpoint over its rate limit, throttling",
+		"notification_id", notificationID,
+		"type", key.Type,
+		"endpoint", key.Value,
+		"retry_after", decision.RetryAfter,
+	)
+	return decision.RetryAfter, true
+}
+
+// applyClientProfile sets the client's timezone and locale on the notification, unless they
+// are already set. A failed lookup is logged and the notification is rendered in UTC.
+func (s *Sender) applyClientProfile(ctx context.Context, notification *database.Notification) {
+	if s.profiles == nil || notification.Timezone != "" || notification.Locale != "" {
+		return
+	}
+	profile, err := s.profiles.GetClientProfile(ctx, notification.ClientID)
+	if err != nil {
+		slog.Warn("Failed to get client profile, rendering timestamps in UTC",
+			"notification_id", notification.NotificationID,
+			"client_id", notification.ClientID,
+			"error", err,
+		)
+		return
+	}
+	notification.Timezone = profile.Timezone
+	notification.Locale = profile.Locale
+}
+
+// applyRelatedAlerts sets the notification's related alerts, unless they are already set or
+// the notification is a digest. A failed lookup is logged and the notification is sent without them.
+func (s *Sender) applyRelatedAlerts(ctx context.Context, notification *database.Notification) {
+	if s.related == nil || s.relatedLimit <= 0 || notification.Related != nil || len(notification.Grouped) > 0 {
+		return
+	}
+	related, err := s.related.GetRelatedAlerts(ctx, notification.NotificationID, s.relatedLimit)
+	if err != nil {
+		slog.Warn("Failed to get related alerts, sending without them",
+			"notification_id", notification.NotificationID,
+			"error", err,
+		)
+		return
+	}
+	notification.Related = related
+	payload.LimitRelatedAlerts(notification, s.relatedMaxBytes)
+}
+
+// withTemplate returns the notification to send to an endpoint using templateID: a copy with
+// the template set, or the notification itself for the default layout. Templates are looked
+// up once per delivery, in cache. A failed lookup is logged and the default layout is sent.
+func (s *Sender) withTemplate(ctx context.Context, notification *database.Notification, templateID string, cache map[string]*database.Template) *database.Notification {
+	if s.templates == nil || templateID == "" {
+		return notification
+	}
+	tmpl, ok := cache[templateID]
+	if !ok {
+		var err error
+		tmpl, err = s.templates.GetTemplate(ctx, templateID)
+		if err != nil {
+			slog.Warn("Failed to get notification template, using the default layout",
+				"notification_id", notification.NotificationID,
+				"template_id", templateID,
+				"error", err,
+			)
+		}
+		cache[templateID] = tmpl
+	}
+	if tmpl == nil {
+		return notification
+	}
+	templated := *notification
+	templated.Template = tmpl
+	return &templated
+}
+
+// ForgetDeliveries drops the record of the endpoints the notification was delivered to.
+// Call it once the notification reached a final status and will not be delivered again.
+func (s *Sender) ForgetDeliveries(notificationID string) {
+	s.delivered.Forget(notificationID)
+}
+
+// observeDetail reports an endpoint delivery to the observers implementing DeliveryDetailObserver.
+func (s *Sender) observeDetail(ctx context.Context, delivery database.Delivery) {
+	for _, o := range s.observers {
+		if do, ok := o.(DeliveryDetailObserver); ok {
+			do.ObserveDeliveryDetail(ctx, delivery)
+		}
+	}
+}
+
+// simulate stands in for a real send in dry-run mode: it logs the delivery that would have
+// been made and reports it to the observers.
+func (s *Sender) simulate(ctx context.Context, notification *database.Notification, endpointType, endpointValue string) {
+	slog.Info("Simulated send",
+		"notification_id", notification.NotificationID,
+		"type", endpointType,
+		"endpoint", endpointValue,
+	)
+	for _, o := range s.observers {
+		if so, ok := o.(SimulationObserver); ok {
+			so.ObserveSimulation(ctx, notification, endpointType)
+		} else {
+			o.ObserveDelivery(ctx, notification, endpointType, nil)
+		}
+	}
+}
+
+// sendAttempt performs one send bounded by the endpoint type's channel timeout.
+// A timed-out attempt returns an error mentioning "timeout", so it is retried while the
+// send deadline allows. Every failed attempt is recorded with its failure class.
+func (s *Sender) sendAttempt(ctx context.Context, sender strategy.NotificationSender, endpointType, endpointValue string, auth endpointAuth, notification *database.Notification) error {
+	timeout := s.channelTimeout(endpointType)
+	attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := s.send(attemptCtx, sender, endpointValue, auth, notification)
+	if auth.oauth2 != nil && errors.Is(err, webhook.ErrUnauthorized) {
+		// The cached token may have been revoked; retry once with a fresh one
+		s.tokens.Invalidate(*auth.oauth2)
+		err = s.send(attemptCtx, sender, endpointValue, auth, notification)
+	}
+	if err != nil && ctx.Err() == nil && attemptCtx.Err() == context.DeadlineExceeded {
+		s.timeouts.RecordChannelTimeout(endpointType)
+		err = failure.New(failure.Timeout, fmt.Errorf("%s send timeout after %s: %w", endpointType, timeout, err))
+	}
+	if err != nil {
+		s.failures.RecordSendFailure(endpointType, string(failure.Classify(err)))
+	}
+	return err
+}
+
+// send delivers to one endpoint, adding its custom headers and OAuth2 access token if it has any.
+func (s *Sender) send(ctx context.Context, sender strategy.NotificationSender, endpointValue string, auth endpointAuth, notification *database.Notification) error {
+	hs, ok := sender.(strategy.HeaderSender)
+	if !ok || (len(auth.headers) == 0 && auth.oauth2 == nil) {
+		return sender.Send(ctx, endpointValue, notification)
+	}
+
+	headers := auth.headers
+	if auth.oauth2 != nil {
+		token, err := s.tokens.Token(ctx, *auth.oauth2)
+		if err != nil {
+			return err
+		}
+		headers = make(map[string]string, len(auth.headers)+1)
+		for name, value := range auth.headers {
+			headers[name] = value
+		}
+		headers["Authorization"] = "Bearer " + token
+	}
+	return hs.SendWithHeaders(ctx, endpointValue, headers, notification)
+}
+
+// channelTimeout returns the attempt timeout for an endpoint type.
+func (s *Sender) channelTimeout(endpointType string) time.Duration {
+	if timeout := s.channelTimeouts[endpointType]; timeout > 0 {
+		return timeout
+	}
+	return DefaultChannelTimeout
+}
+
+// retryConfig returns the retry configuration for an endpoint type.
+func (s *Sender) retryConfig(endpointType string) retry.Config {
+	cfg := retry.DefaultConfig()
+	if maxRetries, ok := s.channelRetries[endpointType]; ok && maxRetries >= 0 {
+		cfg.MaxRetries = maxRetries
+	}
+	return cfg
 }
 
 // groupEndpoints groups endpoints by type and collects unique values.
@@ -139,3 +750,152 @@ func (s *Sender) groupEndpoints(endpoints map[string][]database.Endpoint, ruleID
 
 	return result
 }
+
+// endpointKey identifies a grouped endpoint by type and value.
+type endpointKey struct {
+	Type  string
+	Value string
+}
+
+// String returns the key as "type:value".
+func (k endpointKey) String() string {
+	return k.Type + ":" + k.Value
+}
+
+// endpointAuth is the decrypted authentication of one endpoint.
+type endpointAuth struct {
+	headers map[string]string
+	oauth2  *oauth2.Credentials
+}
+
+// endpointConfigs collects the enabled endpoints of the given rules that have custom headers
+// or OAuth2. If rules share an endpoint value with different settings, the first rule's are used.
+func endpointConfigs(endpoints map[string][]database.Endpoint, ruleIDs []string) map[endpointKey]database.Endpoint {
+	configs := make(map[endpointKey]database.Endpoint)
+	for _, ruleID := range ruleIDs {
+		for _, ep := range endpoints[ruleID] {
+			key := endpointKey{ep.Type, ep.Value}
+			if _, seen := configs[key]; ep.Enabled && (len(ep.Headers) > 0 || ep.OAuth2 != nil) && !seen {
+				configs[key] = ep
+			}
+		}
+	}
+	return configs
+}
+
+// endpointIDs maps each enabled endpoint of the given rules to its endpoint ID.
+// If rules share an endpoint value, the first rule's endpoint is used.
+func endpointIDs(endpoints map[string][]database.Endpoint, ruleIDs []string) map[endpointKey]string {
+	ids := make(map[endpointKey]string)
+	for _, ruleID := range ruleIDs {
+		for _, ep := range endpoints[ruleID] {
+			key := endpointKey{ep.Type, ep.Value}
+			if _, seen := ids[key]; ep.Enabled && !seen {
+				ids[key] = ep.EndpointID
+			}
+		}
+	}
+	return ids
+}
+
+// endpointTemplates maps each enabled endpoint of the given rules that has a template to its
+// template ID. If rules share an endpoint value, the first rule's endpoint is used.
+func endpointTemplates(endpoints map[string][]database.Endpoint, ruleIDs []string) map[endpointKey]string {
+	templates := make(map[endpointKey]string)
+	seen := make(map[endpointKey]bool)
+	for _, ruleID := range ruleIDs {
+		for _, ep := range endpoints[ruleID] {
+			key := endpointKey{ep.Type, ep.Value}
+			if !ep.Enabled || seen[key] {
+				continue
+			}
+			seen[key] = true
+			if ep.TemplateID != "" {
+				templates[key] = ep.TemplateID
+			}
+		}
+	}
+	return templates
+}
+
+// resolveAuth decrypts an endpoint's secret header values and OAuth2 client secret.
+func (s *Sender) resolveAuth(ep database.Endpoint) (endpointAuth, error) {
+	var auth endpointAuth
+	if len(ep.Headers) > 0 {
+		auth.headers = make(map[string]string, len(ep.Headers))
+	}
+	for _, header := range ep.Headers {
+		value := header.Value
+		if header.Secret {
+			var err error
+			if value, err = s.decrypt(header.Value); err != nil {
+				return auth, fmt.Errorf("secret header %s: %w", header.Name, err)
+			}
+		}
+		auth.headers[header.Name] = value
+	}
+
+	if ep.OAuth2 != nil {
+		secret, err := s.decrypt(ep.OAuth2.ClientSecret)
+		if err != nil {
+			return auth, fmt.Errorf("oauth2 client secret: %w", err)
+		}
+		auth.oauth2 = &oauth2.Credentials{
+			TokenURL:     ep.OAuth2.TokenURL,
+			ClientID:     ep.OAuth2.ClientID,
+			ClientSecret: secret,
+			Scopes:       ep.OAuth2.Scopes,
+		}
+	}
+	return auth, nil
+}
+
+// decrypt decrypts a secret stored by rule-service.
+func (s *Sender) decrypt(value string) (string, error) {
+	if s.secrets == nil {
+		return "", fmt.Errorf("ENDPOINT_SECRET_KEY is not configured")
+	}
+	return s.secrets.Decrypt(value)
+}
+
+// ownerChannel returns the Slack channel of the team owning the notification's source, or ""
+// if owner routing is disabled or no channel is known. The channel is resolved at send time:
+// a slack_channel in the notification context is ignored, since producers can set it.
+func (s *Sender) ownerChannel(ctx context.Context, notification *database.Notification) string {
+	if s.owners == nil {
+		return ""
+	}
+	channel := s.owners.SlackChannel(ctx, notification.Source)
+	if !slack.IsChannel(channel) {
+		return ""
+	}
+	return channel
+}
+
+// needsOwnerRoute reports whether any matched rule lacks an enabled Slack endpoint.
+// A rule with its own Slack endpoint overrides owner routing for that rule.
+func needsOwnerRoute(endpoints map[string][]database.Endpoint, ruleIDs []string) bool {
+	for _, ruleID := range ruleIDs {
+		hasSlack := false
+		for _, ep := range endpoints[ruleID] {
+			if ep.Enabled && ep.Type == "slack" {
+				hasSlack = true
+				break
+			}
+		}
+		if !hasSlack {
+			return true
+		}
+	}
+	return false
+}
+
+// appendUnique appends value to values if it is not already present.
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
@@ -3,11 +3,19 @@ package sender
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"sender/internal/database"
+	"sender/internal/sender/failure"
 	"sender/internal/sender/strategy"
+	"sender/internal/sender/webhook"
+
+	"github.com/afikmenashe/alerting-platform/pkg/shared"
 )
 
 // mockNotificationSender is a mock implementation of NotificationSender for testing
@@ -210,6 +218,32 @@ func TestSender_SendNotification_PartialFailure(t *testing.T) {
 	}
 }
 
+func TestSender_Deliver_PartialFailure(t *testing.T) {
+	registry := strategy.NewRegistry()
+	registry.Register(&mockNotificationSender{senderType: "email"})
+	registry.Register(&mockNotificationSender{senderType: "slack", sendErr: fmt.Errorf("slack error")})
+	s := NewSenderWithRegistry(registry)
+
+	notification := &database.Notification{NotificationID: "notif-123", RuleIDs: []string{"rule-001"}}
+	endpoints := map[string][]database.Endpoint{
+		"rule-001": {
+			{EndpointID: "ep-001", RuleID: "rule-001", Type: "email", Value: "test@example.com", Enabled: true},
+			{EndpointID: "ep-002", RuleID: "rule-001", Type: "slack", Value: "https://hooks.slack.com/test", Enabled: true},
+		},
+	}
+
+	result, err := s.Deliver(context.Background(), notification, endpoints)
+	if err != nil {
+		t.Fatalf("Deliver() error = %v, want nil", err)
+	}
+	if result.Delivered != 1 || result.Failed != 1 || !result.Partial() {
+		t.Errorf("Deliver() result = %+v, want 1 delivered and 1 failed", result)
+	}
+	if len(result.Errors) != 1 || !contains(result.Errors[0], "slack error") {
+		t.Errorf("Deliver() errors = %v, want the slack failure", result.Errors)
+	}
+}
+
 func TestSender_SendNotification_AllFailures(t *testing.T) {
 	registry := strategy.NewRegistry()
 
@@ -341,7 +375,902 @@ func TestSender_groupEndpoints_NonExistentRuleID(t *testing.T) {
 	}
 }
 
+// fakeOwners is a test fake for OwnerChannels mapping sources to channels.
+type fakeOwners map[string]string
+
+func (f fakeOwners) SlackChannel(ctx context.Context, source string) string {
+	return f[source]
+}
+
+func TestSender_SendNotification_OwnerRouting(t *testing.T) {
+	owners := fakeOwners{"payments-api": "#payments-alerts"}
+
+	tests := []struct {
+		name      string
+		owners    OwnerChannels
+		source    string
+		context   map[string]string
+		endpoints map[string][]database.Endpoint
+		wantSlack string
+		wantErr   bool
+	}{
+		{
+			name:      "routes to owner channel when rule has no endpoints",
+			owners:    owners,
+			source:    "payments-api",
+			endpoints: map[string][]database.Endpoint{},
+			wantSlack: "#payments-alerts",
+		},
+		{
+			name:   "rule slack endpoint overrides owner channel",
+			owners: owners,
+			source: "payments-api",
+			endpoints: map[string][]database.Endpoint{
+				"rule-001": {{EndpointID: "ep-001", RuleID: "rule-001", Type: "slack", Value: "https://hooks.slack.com/test", Enabled: true}},
+			},
+			wantSlack: "https://hooks.slack.com/test",
+		},
+		{
+			name:      "disabled owner routing",
+			source:    "payments-api",
+			endpoints: map[string][]database.Endpoint{},
+			wantErr:   true,
+		},
+		{
+			name:      "unknown source",
+			owners:    owners,
+			source:    "checkout",
+			endpoints: map[string][]database.Endpoint{},
+			wantErr:   true,
+		},
+		{
+			name:      "context channel set by the producer is ignored",
+			owners:    owners,
+			source:    "checkout",
+			context:   map[string]string{"slack_channel": "#attacker"},
+			endpoints: map[string][]database.Endpoint{},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := strategy.NewRegistry()
+			slackSender := &mockNotificationSender{senderType: "slack"}
+			registry.Register(slackSender)
+
+			s := NewSenderWithRegistry(registry)
+			if tt.owners != nil {
+				s.EnableOwnerRouting(tt.owners)
+			}
+
+			notification := &database.Notification{
+				NotificationID: "notif-123",
+				RuleIDs:        []string{"rule-001"},
+				Source:         tt.source,
+				Context:        tt.context,
+			}
+
+			err := s.SendNotification(context.Background(), notification, tt.endpoints)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SendNotification() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantSlack != "" && slackSender.endpointValue != tt.wantSlack {
+				t.Errorf("slack endpoint = %q, want %q", slackSender.endpointValue, tt.wantSlack)
+			}
+			if tt.wantErr && slackSender.endpointValue != "" {
+				t.Errorf("sent to slack endpoint %q, want no send", slackSender.endpointValue)
+			}
+		})
+	}
+}
+
 // Helper function to check if string contains substring
 func contains(s, substr string) bool {
 	return strings.Contains(s, substr)
 }
+
+// recordingObserver records delivery outcomes by endpoint type.
+type recordingObserver struct {
+	outcomes map[string]error
+}
+
+func (o *recordingObserver) ObserveDelivery(ctx context.Context, notification *database.Notification, endpointType string, err error) {
+	if o.outcomes == nil {
+		o.outcomes = make(map[string]error)
+	}
+	o.outcomes[endpointType] = err
+}
+
+func TestSender_SendNotification_ObservesDeliveries(t *testing.T) {
+	registry := strategy.NewRegistry()
+	registry.Register(&mockNotificationSender{senderType: "email"})
+	registry.Register(&mockNotificationSender{senderType: "webhook", sendErr: fmt.Errorf("webhook error")})
+
+	observer := &recordingObserver{}
+	second := &recordingObserver{}
+	s := NewSenderWithRegistry(registry).WithDeliveryObserver(observer).WithDeliveryObserver(second)
+
+	notification := &database.Notification{NotificationID: "notif-123", RuleIDs: []string{"rule-001"}}
+	endpoints := map[string][]database.Endpoint{
+		"rule-001": {
+			{EndpointID: "ep-001", RuleID: "rule-001", Type: "email", Value: "test@example.com", Enabled: true},
+			{EndpointID: "ep-002", RuleID: "rule-001", Type: "webhook", Value: "https://hooks.example.com/test", Enabled: true},
+		},
+	}
+
+	if err := s.SendNotification(context.Background(), notification, endpoints); err != nil {
+		t.Fatalf("SendNotification() error = %v", err)
+	}
+	if len(observer.outcomes) != 2 {
+		t.Fatalf("observed %d deliveries, want 2", len(observer.outcomes))
+	}
+	if observer.outcomes["email"] != nil {
+		t.Errorf("email outcome = %v, want nil", observer.outcomes["email"])
+	}
+	if observer.outcomes["webhook"] == nil {
+		t.Error("webhook outcome = nil, want error")
+	}
+	if len(second.outcomes) != 2 {
+		t.Errorf("second observer saw %d deliveries, want 2", len(second.outcomes))
+	}
+}
+
+// hangingSender blocks until its context is done, like an unresponsive endpoint.
+type hangingSender struct {
+	senderType string
+}
+
+func (h *hangingSender) Send(ctx context.Context, endpointValue string, notification *database.Notification) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (h *hangingSender) Type() string {
+	return h.senderType
+}
+
+// recordingTimeouts counts timeout hits.
+type recordingTimeouts struct {
+	mu               sync.Mutex
+	channelTimeouts  map[string]int
+	deadlineExceeded int
+}
+
+func (r *recordingTimeouts) RecordChannelTimeout(endpointType string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.channelTimeouts == nil {
+		r.channelTimeouts = make(map[string]int)
+	}
+	r.channelTimeouts[endpointType]++
+}
+
+func (r *recordingTimeouts) RecordSendDeadlineExceeded() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deadlineExceeded++
+}
+
+func TestSender_SendNotification_ChannelTimeout(t *testing.T) {
+	registry := strategy.NewRegistry()
+	registry.Register(&hangingSender{senderType: "webhook"})
+	emailSender := &mockNotificationSender{senderType: "email"}
+	registry.Register(emailSender)
+
+	timeouts := &recordingTimeouts{}
+	failures := &recordingFailures{}
+	s := NewSenderWithRegistry(registry).WithTimeoutRecorder(timeouts).WithFailureRecorder(failures)
+	s.SetTimeouts(map[string]time.Duration{"webhook": 20 * time.Millisecond}, 5*time.Second)
+
+	notification := &database.Notification{NotificationID: "notif-123", RuleIDs: []string{"rule-001"}}
+	endpoints := map[string][]database.Endpoint{
+		"rule-001": {
+			{EndpointID: "ep-001", RuleID: "rule-001", Type: "webhook", Value: "https://hooks.internal/hang", Enabled: true},
+			{EndpointID: "ep-002", RuleID: "rule-001", Type: "email", Value: "test@company.com", Enabled: true},
+		},
+	}
+
+	// The email endpoint succeeds, so the hanging webhook must not fail the notification
+	if err := s.SendNotification(context.Background(), notification, endpoints); err != nil {
+		t.Fatalf("SendNotification() error = %v", err)
+	}
+	if !emailSender.sendCalled {
+		t.Error("email sender was not called")
+	}
+	// Timed-out attempts are retried: initial attempt plus DefaultConfig().MaxRetries
+	if got := timeouts.channelTimeouts["webhook"]; got != 4 {
+		t.Errorf("webhook channel timeouts = %d, want 4", got)
+	}
+	if timeouts.deadlineExceeded != 0 {
+		t.Errorf("deadline exceeded = %d, want 0", timeouts.deadlineExceeded)
+	}
+	if got := failures.counts["webhook/timeout"]; got != 4 {
+		t.Errorf("webhook timeout failures = %d, want 4", got)
+	}
+}
+
+// recordingFailures counts failed attempts by "type/class".
+type recordingFailures struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (r *recordingFailures) RecordSendFailure(endpointType, class string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.counts == nil {
+		r.counts = make(map[string]int)
+	}
+	r.counts[endpointType+"/"+class]++
+}
+
+func TestSender_SendNotification_RecordsFailureClasses(t *testing.T) {
+	registry := strategy.NewRegistry()
+	registry.Register(&mockNotificationSender{
+		senderType: "webhook",
+		sendErr:    failure.HTTPStatus(http.StatusNotFound, fmt.Errorf("webhook returned status 404")),
+	})
+	registry.Register(&mockNotificationSender{senderType: "email", sendErr: fmt.Errorf("smtp: unexpected EOF")})
+
+	failures := &recordingFailures{}
+	s := NewSenderWithRegistry(registry).WithFailureRecorder(failures)
+
+	notification := &database.Notification{NotificationID: "notif-123", RuleIDs: []string{"rule-001"}}
+	endpoints := map[string][]database.Endpoint{
+		"rule-001": {
+			{EndpointID: "ep-001", RuleID: "rule-001", Type: "webhook", Value: "https://hooks.internal/gone", Enabled: true},
+			{EndpointID: "ep-002", RuleID: "rule-001", Type: "email", Value: "test@company.com", Enabled: true},
+		},
+	}
+
+	if err := s.SendNotification(context.Background(), notification, endpoints); err == nil {
+		t.Fatal("SendNotification() error = nil, want all sends failed")
+	}
+	// Neither error is retryable, so each endpoint is attempted once
+	want := map[string]int{"webhook/4xx": 1, "email/other": 1}
+	if len(failures.counts) != len(want) {
+		t.Errorf("failures = %v, want %v", failures.counts, want)
+	}
+	for key, n := range want {
+		if failures.counts[key] != n {
+			t.Errorf("failures[%s] = %d, want %d", key, failures.counts[key], n)
+		}
+	}
+}
+
+func TestSender_SendNotification_SendDeadline(t *testing.T) {
+	registry := strategy.NewRegistry()
+	registry.Register(&hangingSender{senderType: "webhook"})
+
+	timeouts := &recordingTimeouts{}
+	s := NewSenderWithRegistry(registry).WithTimeoutRecorder(timeouts)
+	s.SetTimeouts(map[string]time.Duration{"webhook": time.Minute}, 50*time.Millisecond)
+
+	notification := &database.Notification{NotificationID: "notif-123", RuleIDs: []string{"rule-001"}}
+	endpoints := map[string][]database.Endpoint{
+		"rule-001": {
+			{EndpointID: "ep-001", RuleID: "rule-001", Type: "webhook", Value: "https://hooks.internal/a", Enabled: true},
+			{EndpointID: "ep-002", RuleID: "rule-001", Type: "webhook", Value: "https://hooks.internal/b", Enabled: true},
+		},
+	}
+
+	start := time.Now()
+	err := s.SendNotification(context.Background(), notification, endpoints)
+	if err == nil {
+		t.Fatal("SendNotification() error = nil, want error")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("SendNotification() took %v, want it bounded by the send deadline", elapsed)
+	}
+	if !strings.Contains(err.Error(), "send deadline exceeded") {
+		t.Errorf("SendNotification() error = %v, want send deadline exceeded", err)
+	}
+	if timeouts.deadlineExceeded != 1 {
+		t.Errorf("deadline exceeded = %d, want 1", timeouts.deadlineExceeded)
+	}
+	if len(timeouts.channelTimeouts) != 0 {
+		t.Errorf("channel timeouts = %v, want none", timeouts.channelTimeouts)
+	}
+}
+
+// headerSender records the headers it was sent with.
+type headerSender struct {
+	mockNotificationSender
+	headers map[string]string
+}
+
+func (h *headerSender) SendWithHeaders(ctx context.Context, endpointValue string, headers map[string]string, notification *database.Notification) error {
+	h.headers = headers
+	return h.Send(ctx, endpointValue, notification)
+}
+
+func TestSender_SendNotification_EndpointHeaders(t *testing.T) {
+	box, err := shared.NewSecretBox("test-key")
+	if err != nil {
+		t.Fatalf("NewSecretBox() error = %v", err)
+	}
+	sealed, err := box.Encrypt("Bearer s3cret")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+
+	notification := &database.Notification{NotificationID: "notif-123", RuleIDs: []string{"rule-001"}}
+	endpoints := map[string][]database.Endpoint{
+		"rule-001": {{
+			EndpointID: "ep-001", RuleID: "rule-001", Type: "webhook", Value: "https://hooks.internal/a", Enabled: true,
+			Headers: []database.EndpointHeader{
+				{Name: "Authorization", Value: sealed, Secret: true},
+				{Name: "X-Team", Value: "payments"},
+			},
+		}},
+	}
+
+	t.Run("secret headers are decrypted", func(t *testing.T) {
+		webhook := &headerSender{mockNotificationSender: mockNotificationSender{senderType: "webhook"}}
+		registry := strategy.NewRegistry()
+		registry.Register(webhook)
+		s := NewSenderWithRegistry(registry)
+		s.secrets = box
+
+		if err := s.SendNotification(context.Background(), notification, endpoints); err != nil {
+			t.Fatalf("SendNotification() error = %v", err)
+		}
+		if webhook.headers["Authorization"] != "Bearer s3cret" || webhook.headers["X-Team"] != "payments" {
+			t.Errorf("headers = %v, want decrypted Authorization and X-Team", webhook.headers)
+		}
+	})
+
+	t.Run("secret headers without key fail", func(t *testing.T) {
+		webhook := &headerSender{mockNotificationSender: mockNotificationSender{senderType: "webhook"}}
+		registry := strategy.NewRegistry()
+		registry.Register(webhook)
+
+		err := NewSenderWithRegistry(registry).SendNotification(context.Background(), notification, endpoints)
+		if err == nil || !strings.Contains(err.Error(), "ENDPOINT_SECRET_KEY") {
+			t.Errorf("SendNotification() error = %v, want missing key error", err)
+		}
+		if webhook.sendCalled {
+			t.Error("webhook was called without its secret header")
+		}
+	})
+}
+
+// rejectingSender rejects the first token it sees with 401 and records the Authorization headers.
+type rejectingSender struct {
+	headerSender
+	authorizations []string
+}
+
+func (r *rejectingSender) SendWithHeaders(ctx context.Context, endpointValue string, headers map[string]string, notification *database.Notification) error {
+	r.authorizations = append(r.authorizations, headers["Authorization"])
+	if len(r.authorizations) == 1 {
+		return webhook.ErrUnauthorized
+	}
+	return r.headerSender.SendWithHeaders(ctx, endpointValue, headers, notification)
+}
+
+func TestSender_SendNotification_OAuth2(t *testing.T) {
+	tokens := 0
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokens++
+		fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer","expires_in":3600}`, tokens)
+	}))
+	defer tokenServer.Close()
+
+	box, err := shared.NewSecretBox("test-key")
+	if err != nil {
+		t.Fatalf("NewSecretBox() error = %v", err)
+	}
+	sealed, err := box.Encrypt("client-secret")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+
+	webhookSender := &rejectingSender{headerSender: headerSender{mockNotificationSender: mockNotificationSender{senderType: "webhook"}}}
+	registry := strategy.NewRegistry()
+	registry.Register(webhookSender)
+	s := NewSenderWithRegistry(registry)
+	s.secrets = box
+
+	notification := &database.Notification{NotificationID: "notif-123", RuleIDs: []string{"rule-001"}}
+	endpoints := map[string][]database.Endpoint{
+		"rule-001": {{
+			EndpointID: "ep-001", RuleID: "rule-001", Type: "webhook", Value: "https://api.internal/alerts", Enabled: true,
+			Headers: []database.EndpointHeader{{Name: "X-Team", Value: "payments"}},
+			OAuth2:  &database.EndpointOAuth2{TokenURL: tokenServer.URL, ClientID: "alerting", ClientSecret: sealed},
+		}},
+	}
+
+	if err := s.SendNotification(context.Background(), notification, endpoints); err != nil {
+		t.Fatalf("SendNotification() error = %v", err)
+	}
+	want := []string{"Bearer token-1", "Bearer token-2"}
+	if strings.Join(webhookSender.authorizations, ",") != strings.Join(want, ",") {
+		t.Errorf("Authorization headers = %v, want %v (retry with a fresh token after 401)", webhookSender.authorizations, want)
+	}
+	if webhookSender.headers["X-Team"] != "payments" {
+		t.Errorf("headers = %v, want custom headers alongside the token", webhookSender.headers)
+	}
+}
+
+// simulationObserver records simulated deliveries by endpoint type.
+type simulationObserver struct {
+	recordingObserver
+	simulated []string
+}
+
+func (o *simulationObserver) ObserveSimulation(ctx context.Context, notification *database.Notification, endpointType string) {
+	o.simulated = append(o.simulated, endpointType)
+}
+
+func TestSender_SendNotification_DryRun(t *testing.T) {
+	emailSender := &mockNotificationSender{senderType: "email"}
+	webhookSender := &mockNotificationSender{senderType: "webhook", sendErr: fmt.Errorf("webhook error")}
+	registry := strategy.NewRegistry()
+	registry.Register(emailSender)
+	registry.Register(webhookSender)
+
+	simulations := &simulationObserver{}
+	plain := &recordingObserver{}
+	s := NewSenderWithRegistry(registry).WithDeliveryObserver(simulations).WithDeliveryObserver(plain)
+	s.EnableDryRun()
+
+	notification := &database.Notification{NotificationID: "notif-123", RuleIDs: []string{"rule-001"}}
+	endpoints := map[string][]database.Endpoint{
+		"rule-001": {
+			{EndpointID: "ep-001", RuleID: "rule-001", Type: "email", Value: "test@example.com", Enabled: true},
+			{EndpointID: "ep-002", RuleID: "rule-001", Type: "webhook", Value: "https://hooks.example.com/test", Enabled: true},
+		},
+	}
+
+	if err := s.SendNotification(context.Background(), notification, endpoints); err != nil {
+		t.Fatalf("SendNotification() error = %v, want nil (sends are simulated)", err)
+	}
+	if emailSender.sendCalled || webhookSender.sendCalled {
+		t.Error("channel senders were called in dry-run mode")
+	}
+	if len(simulations.simulated) != 2 || len(simulations.outcomes) != 0 {
+		t.Errorf("simulation observer saw %v simulated and %v delivered, want 2 simulated only", simulations.simulated, simulations.outcomes)
+	}
+	if len(plain.outcomes) != 2 || plain.outcomes["webhook"] != nil {
+		t.Errorf("plain observer outcomes = %v, want 2 successful deliveries", plain.outcomes)
+	}
+}
+
+// countingSender counts send attempts and fails each with err.
+type countingSender struct {
+	senderType string
+	err        error
+	mu         sync.Mutex
+	attempts   int
+}
+
+func (c *countingSender) Send(ctx context.Context, endpointValue string, notification *database.Notification) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.attempts++
+	return c.err
+}
+
+func (c *countingSender) Type() string {
+	return c.senderType
+}
+
+func TestSender_SendNotification_ChannelRetries(t *testing.T) {
+	webhookSender := &countingSender{senderType: "webhook", err: fmt.Errorf("webhook returned status 503")}
+	emailSender := &countingSender{senderType: "email", err: fmt.Errorf("smtp: connection refused")}
+	registry := strategy.NewRegistry()
+	registry.Register(webhookSender)
+	registry.Register(emailSender)
+	s := NewSenderWithRegistry(registry)
+	s.SetRetryLimits(map[string]int{"webhook": 1, "email": 0})
+
+	notification := &database.Notification{NotificationID: "notif-123", RuleIDs: []string{"rule-001"}}
+	endpoints := map[string][]database.Endpoint{
+		"rule-001": {
+			{EndpointID: "ep-001", RuleID: "rule-001", Type: "webhook", Value: "https://hooks.internal/flaky", Enabled: true},
+			{EndpointID: "ep-002", RuleID: "rule-001", Type: "email", Value: "test@company.com", Enabled: true},
+		},
+	}
+
+	if err := s.SendNotification(context.Background(), notification, endpoints); err == nil {
+		t.Fatal("SendNotification() error = nil, want all sends failed")
+	}
+	if webhookSender.attempts != 2 {
+		t.Errorf("webhook attempts = %d, want 2 (1 retry)", webhookSender.attempts)
+	}
+	if emailSender.attempts != 1 {
+		t.Errorf("email attempts = %d, want 1 (retries disabled)", emailSender.attempts)
+	}
+}
+
+func TestSender_Deliver_SkipsAlreadyDeliveredEndpoints(t *testing.T) {
+	emailSender := &countingSender{senderType: "email"}
+	webhookSender := &countingSender{senderType: "webhook", err: fmt.Errorf("webhook returned status 400")}
+	registry := strategy.NewRegistry()
+	registry.Register(emailSender)
+	registry.Register(webhookSender)
+	s := NewSenderWithRegistry(registry)
+
+	notification := &database.Notification{NotificationID: "notif-123", RuleIDs: []string{"rule-001"}}
+	endpoints := map[string][]database.Endpoint{
+		"rule-001": {
+			{EndpointID: "ep-001", RuleID: "rule-001", Type: "email", Value: "test@company.com", Enabled: true},
+			{EndpointID: "ep-002", RuleID: "rule-001", Type: "webhook", Value: "https://hooks.internal/flaky", Enabled: true},
+		},
+	}
+
+	if _, err := s.Deliver(context.Background(), notification, endpoints); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+
+	// Redelivery of the same notification only retries the webhook
+	webhookSender.err = nil
+	result, err := s.Deliver(context.Background(), notification, endpoints)
+	if err != nil {
+		t.Fatalf("Deliver() redelivery error = %v", err)
+	}
+	if emailSender.attempts != 1 {
+		t.Errorf("email attempts = %d, want 1 (no duplicate email)", emailSender.attempts)
+	}
+	if webhookSender.attempts != 2 {
+		t.Errorf("webhook attempts = %d, want 2", webhookSender.attempts)
+	}
+	if result.Delivered != 2 || result.AlreadyDelivered != 1 || result.Failed != 0 {
+		t.Errorf("Deliver() redelivery result = %+v, want 2 delivered, 1 of them earlier", result)
+	}
+
+	// Once forgotten, the notification is sent to every endpoint again
+	s.ForgetDeliveries(notification.NotificationID)
+	if _, err := s.Deliver(context.Background(), notification, endpoints); err != nil {
+		t.Fatalf("Deliver() after ForgetDeliveries error = %v", err)
+	}
+	if emailSender.attempts != 2 {
+		t.Errorf("email attempts after ForgetDeliveries = %d, want 2", emailSender.attempts)
+	}
+}
+
+func TestSender_SendNotification_IgnoresEarlierDeliveries(t *testing.T) {
+	emailSender := &countingSender{senderType: "email"}
+	registry := strategy.NewRegistry()
+	registry.Register(emailSender)
+	s := NewSenderWithRegistry(registry)
+
+	notification := &database.Notification{NotificationID: "notif-123", RuleIDs: []string{"rule-001"}}
+	endpoints := map[string][]database.Endpoint{
+		"rule-001": {{EndpointID: "ep-001", RuleID: "rule-001", Type: "email", Value: "test@company.com", Enabled: true}},
+	}
+
+	if _, err := s.Deliver(context.Background(), notification, endpoints); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+	// Reminders re-send the notification on purpose
+	if err := s.SendNotification(context.Background(), notification, endpoints); err != nil {
+		t.Fatalf("SendNotification() error = %v", err)
+	}
+	if emailSender.attempts != 2 {
+		t.Errorf("email attempts = %d, want 2", emailSender.attempts)
+	}
+}
+
+// detailObserver records delivery details.
+type detailObserver struct {
+	recordingObserver
+	details []database.Delivery
+}
+
+func (o *detailObserver) ObserveDeliveryDetail(ctx context.Context, delivery database.Delivery) {
+	o.details = append(o.details, delivery)
+}
+
+func TestSender_Deliver_ObservesDeliveryDetails(t *testing.T) {
+	webhookSender := &countingSender{senderType: "webhook", err: fmt.Errorf("webhook returned status 503")}
+	registry := strategy.NewRegistry()
+	registry.Register(&countingSender{senderType: "email"})
+	registry.Register(webhookSender)
+	s := NewSenderWithRegistry(registry)
+	s.SetRetryLimits(map[string]int{"webhook": 1})
+	observer := &detailObserver{}
+	s.WithDeliveryObserver(observer)
+
+	notification := &database.Notification{NotificationID: "notif-123", RuleIDs: []string{"rule-001", "rule-002"}}
+	endpoints := map[string][]database.Endpoint{
+		"rule-001": {
+			{EndpointID: "ep-001", RuleID: "rule-001", Type: "email", Value: "test@company.com", Enabled: true},
+			{EndpointID: "ep-002", RuleID: "rule-001", Type: "webhook", Value: "https://hooks.internal/flaky", Enabled: true},
+		},
+		"rule-002": {
+			{EndpointID: "ep-003", RuleID: "rule-002", Type: "email", Value: "test@company.com", Enabled: true},
+		},
+	}
+
+	if _, err := s.Deliver(context.Background(), notification, endpoints); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+
+	byChannel := make(map[string]database.Delivery)
+	for _, d := range observer.details {
+		byChannel[d.Channel] = d
+	}
+	if len(observer.details) != 2 {
+		t.Fatalf("observed %d deliveries, want 2 (one per unique endpoint)", len(observer.details))
+	}
+	email := byChannel["email"]
+	if email.EndpointID != "ep-001" || email.Status != database.DeliverySent || email.Attempts != 1 || email.Err != nil {
+		t.Errorf("email delivery = %+v, want ep-001 SENT after 1 attempt", email)
+	}
+	webhook := byChannel["webhook"]
+	if webhook.EndpointID != "ep-002" || webhook.Status != database.DeliveryFailed || webhook.Attempts != 2 || webhook.Err == nil {
+		t.Errorf("webhook delivery = %+v, want ep-002 FAILED after 2 attempts", webhook)
+	}
+	if webhook.NotificationID != "notif-123" || webhook.Latency <= 0 {
+		t.Errorf("webhook delivery = %+v, want the notification ID and a latency", webhook)
+	}
+}
+
+// fakeLimiter allows each caller burst sends, or fails every check with err.
+type fakeLimiter struct {
+	burst int
+	err   error
+	mu    sync.Mutex
+	taken map[string]int
+}
+
+func (f *fakeLimiter) AllowIn(ctx context.Context, tier, caller string) (shared.RateLimitDecision, error) {
+	if f.err != nil {
+		return shared.RateLimitDecision{}, f.err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.taken == nil {
+		f.taken = make(map[string]int)
+	}
+	if f.taken[caller] >= f.burst {
+		return shared.RateLimitDecision{Allowed: false, RetryAfter: 5 * time.Second}, nil
+	}
+	f.taken[caller]++
+	return shared.RateLimitDecision{Allowed: true}, nil
+}
+
+// recordingThrottles records throttled sends per endpoint type and rate limit errors.
+type recordingThrottles struct {
+	throttled map[string]int
+	errors    int
+}
+
+func (r *recordingThrottles) RecordEndpointThrottled(endpointType string) {
+	if r.throttled == nil {
+		r.throttled = make(map[string]int)
+	}
+	r.throttled[endpointType]++
+}
+
+func (r *recordingThrottles) RecordRateLimitError() {
+	r.errors++
+}
+
+func TestSender_Deliver_ThrottlesEndpointsOverTheirLimit(t *testing.T) {
+	emailSender := &countingSender{senderType: "email"}
+	registry := strategy.NewRegistry()
+	registry.Register(emailSender)
+	throttles := &recordingThrottles{}
+	s := NewSenderWithRegistry(registry).
+		WithEndpointLimiter(&fakeLimiter{burst: 1}).
+		WithThrottleRecorder(throttles)
+
+	endpoints := map[string][]database.Endpoint{
+		"rule-001": {{EndpointID: "ep-001", RuleID: "rule-001", Type: "email", Value: "oncall@company.com", Enabled: true}},
+	}
+
+	first := &database.Notification{NotificationID: "notif-1", RuleIDs: []string{"rule-001"}}
+	if _, err := s.Deliver(context.Background(), first, endpoints); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+
+	second := &database.Notification{NotificationID: "notif-2", RuleIDs: []string{"rule-001"}}
+	result, err := s.Deliver(context.Background(), second, endpoints)
+	if err != nil {
+		t.Fatalf("Deliver() error = %v, want nil (throttled endpoints are sent later)", err)
+	}
+	if result.Throttled != 1 || result.Delivered != 0 || result.RetryAfter != 5*time.Second {
+		t.Errorf("Deliver() result = %+v, want 1 throttled retrying after 5s", result)
+	}
+	if emailSender.attempts != 1 {
+		t.Errorf("email attempts = %d, want 1", emailSender.attempts)
+	}
+	if throttles.throttled["email"] != 1 {
+		t.Errorf("throttled sends = %v, want 1 email", throttles.throttled)
+	}
+
+	// Reminders are not rate limited
+	if err := s.SendNotification(context.Background(), second, endpoints); err != nil {
+		t.Fatalf("SendNotification() error = %v", err)
+	}
+	if emailSender.attempts != 2 {
+		t.Errorf("email attempts after reminder = %d, want 2", emailSender.attempts)
+	}
+}
+
+func TestSender_Deliver_SendsWhenRateLimitCheckFails(t *testing.T) {
+	emailSender := &countingSender{senderType: "email"}
+	registry := strategy.NewRegistry()
+	registry.Register(emailSender)
+	throttles := &recordingThrottles{}
+	s := NewSenderWithRegistry(registry).
+		WithEndpointLimiter(&fakeLimiter{err: fmt.Errorf("redis: connection refused")}).
+		WithThrottleRecorder(throttles)
+
+	notification := &database.Notification{NotificationID: "notif-1", RuleIDs: []string{"rule-001"}}
+	endpoints := map[string][]database.Endpoint{
+		"rule-001": {{EndpointID: "ep-001", RuleID: "rule-001", Type: "email", Value: "oncall@company.com", Enabled: true}},
+	}
+
+	result, err := s.Deliver(context.Background(), notification, endpoints)
+	if err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+	if result.Delivered != 1 || result.Throttled != 0 {
+		t.Errorf("Deliver() result = %+v, want 1 delivered", result)
+	}
+	if throttles.errors != 1 {
+		t.Errorf("rate limit errors = %d, want 1", throttles.errors)
+	}
+}
+
+// fakeProfiles returns profile for every client, or fails every lookup with err.
+type fakeProfiles struct {
+	profile database.ClientProfile
+	err     error
+	lookups int
+}
+
+func (f *fakeProfiles) GetClientProfile(ctx context.Context, clientID string) (*database.ClientProfile, error) {
+	f.lookups++
+	if f.err != nil {
+		return nil, f.err
+	}
+	profile := f.profile
+	return &profile, nil
+}
+
+func TestSender_ApplyClientProfile(t *testing.T) {
+	profiles := &fakeProfiles{profile: database.ClientProfile{Timezone: "Asia/Tokyo", Locale: "ja-JP"}}
+	s := NewSender().WithClientProfiles(profiles)
+
+	notification := &database.Notification{NotificationID: "notif-123", ClientID: "client-1"}
+	s.applyClientProfile(context.Background(), notification)
+	if notification.Timezone != "Asia/Tokyo" || notification.Locale != "ja-JP" {
+		t.Errorf("profile = %q %q, want Asia/Tokyo ja-JP", notification.Timezone, notification.Locale)
+	}
+
+	// Redelivering the same notification does not look the profile up again
+	s.applyClientProfile(context.Background(), notification)
+	if profiles.lookups != 1 {
+		t.Errorf("lookups = %d, want 1", profiles.lookups)
+	}
+
+	// A failed lookup leaves the notification in UTC
+	s = NewSender().WithClientProfiles(&fakeProfiles{err: fmt.Errorf("connection refused")})
+	notification = &database.Notification{NotificationID: "notif-456", ClientID: "client-1"}
+	s.applyClientProfile(context.Background(), notification)
+	if notification.Timezone != "" || notification.Locale != "" {
+		t.Errorf("profile = %q %q, want empty after a failed lookup", notification.Timezone, notification.Locale)
+	}
+}
+
+type fakeRelatedAlerts struct {
+	related []*database.RelatedAlert
+	err     error
+	limit   int
+	lookups int
+}
+
+func (f *fakeRelatedAlerts) GetRelatedAlerts(ctx context.Context, notificationID string, limit int) ([]*database.RelatedAlert, error) {
+	f.lookups++
+	f.limit = limit
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.related, nil
+}
+
+func TestSender_ApplyRelatedAlerts(t *testing.T) {
+	fired := time.Date(2026, 10, 15, 12, 31, 0, 0, time.UTC)
+	related := &fakeRelatedAlerts{related: []*database.RelatedAlert{
+		{AlertID: "alert-2", Status: "RESOLVED", FiredAt: fired},
+		{AlertID: "alert-1", Status: "RESOLVED", FiredAt: fired.Add(-time.Hour)},
+	}}
+	s := NewSender().WithRelatedAlerts(related, 5, 50)
+
+	notification := &database.Notification{NotificationID: "notif-123", ClientID: "client-1"}
+	s.applyRelatedAlerts(context.Background(), notification)
+	if related.limit != 5 {
+		t.Errorf("limit = %d, want 5", related.limit)
+	}
+	// Only the newest alert fits in 50 bytes
+	if len(notification.Related) != 1 || notification.Related[0].AlertID != "alert-2" {
+		t.Errorf("related = %v, want only alert-2", notification.Related)
+	}
+
+	// Redelivering the same notification does not look the alerts up again
+	s.applyRelatedAlerts(context.Background(), notification)
+	if related.lookups != 1 {
+		t.Errorf("lookups = %d, want 1", related.lookups)
+	}
+
+	// Digests do not list related alerts
+	digest := &database.Notification{NotificationID: "digest-1", Grouped: []*database.Notification{notification}}
+	s.applyRelatedAlerts(context.Background(), digest)
+	if related.lookups != 1 || digest.Related != nil {
+		t.Errorf("digest related = %v after %d lookups, want none", digest.Related, related.lookups)
+	}
+
+	// A failed lookup sends the notification without related alerts
+	s = NewSender().WithRelatedAlerts(&fakeRelatedAlerts{err: fmt.Errorf("connection refused")}, 5, 1024)
+	notification = &database.Notification{NotificationID: "notif-456", ClientID: "client-1"}
+	s.applyRelatedAlerts(context.Background(), notification)
+	if notification.Related != nil {
+		t.Errorf("related = %v, want none after a failed lookup", notification.Related)
+	}
+}
+
+type fakeTemplates struct {
+	templates map[string]*database.Template
+	lookups   int
+}
+
+func (f *fakeTemplates) GetTemplate(ctx context.Context, templateID string) (*database.Template, error) {
+	f.lookups++
+	tmpl, ok := f.templates[templateID]
+	if !ok {
+		return nil, fmt.Errorf("template not found: %s", templateID)
+	}
+	return tmpl, nil
+}
+
+func TestSender_Deliver_EndpointTemplates(t *testing.T) {
+	registry := strategy.NewRegistry()
+	emailSender := &mockNotificationSender{senderType: "email"}
+	slackSender := &mockNotificationSender{senderType: "slack"}
+	webhookSender := &mockNotificationSender{senderType: "webhook"}
+	registry.Register(emailSender)
+	registry.Register(slackSender)
+	registry.Register(webhookSender)
+
+	short := &database.Template{TemplateID: "template-1", Body: "{{.Name}}"}
+	templates := &fakeTemplates{templates: map[string]*database.Template{"template-1": short}}
+	s := NewSenderWithRegistry(registry).WithTemplates(templates)
+
+	notification := &database.Notification{NotificationID: "notif-123", RuleIDs: []string{"rule-001"}}
+	endpoints := map[string][]database.Endpoint{
+		"rule-001": {
+			{EndpointID: "ep-001", Type: "email", Value: "test@example.com", Enabled: true, TemplateID: "template-1"},
+			{EndpointID: "ep-002", Type: "slack", Value: "https://hooks.slack.com/test", Enabled: true, TemplateID: "template-1"},
+			{EndpointID: "ep-003", Type: "webhook", Value: "https://webhook.example.com", Enabled: true},
+		},
+	}
+
+	if _, err := s.Deliver(context.Background(), notification, endpoints); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+	if emailSender.notification.Template != short || slackSender.notification.Template != short {
+		t.Error("Deliver() should send the endpoint's template to email and Slack")
+	}
+	if webhookSender.notification.Template != nil || notification.Template != nil {
+		t.Error("Deliver() should not set a template on the notification or endpoints without one")
+	}
+	if templates.lookups != 1 {
+		t.Errorf("lookups = %d, want 1 per delivery", templates.lookups)
+	}
+
+	// A missing template sends the default layout
+	endpoints["rule-001"][0].TemplateID = "template-999"
+	notification = &database.Notification{NotificationID: "notif-456", RuleIDs: []string{"rule-001"}}
+	if _, err := s.Deliver(context.Background(), notification, endpoints); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+	if emailSender.notification.Template != nil {
+		t.Error("Deliver() should send the default layout when the template lookup fails")
+	}
+}
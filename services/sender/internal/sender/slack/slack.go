@@ -1,4 +1,5 @@
-// Package slack provides Slack notification sending via Incoming Webhooks.
+// Package slack provides Slack notification sending via Incoming Webhooks,
+// and optionally to channels by name via the chat.postMessage Web API.
 package slack
 
 import (
@@ -8,9 +9,12 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strings"
 	"time"
 
 	"sender/internal/database"
+	"sender/internal/sender/failure"
+	"sender/internal/sender/httpclient"
 	"sender/internal/sender/payload"
 	"sender/internal/sender/validation"
 )
@@ -24,17 +28,42 @@ func maskURL(url string) string {
 	return url
 }
 
+// PostMessageURL is the Slack Web API endpoint used for channel delivery.
+const PostMessageURL = "https://slack.com/api/chat.postMessage"
+
+// IsChannel reports whether value is a Slack channel name (e.g. "#payments-alerts").
+func IsChannel(value string) bool {
+	return len(value) > 1 && strings.HasPrefix(value, "#")
+}
+
 // Sender implements Slack notification sending via Incoming Webhooks.
+// When a bot token is configured it can also post to channels by name.
 type Sender struct {
 	httpClient *http.Client
+	botToken   string
+	apiURL     string
 }
 
-// NewSender creates a new Slack sender.
+// NewSender creates a new Slack sender that only supports Incoming Webhook URLs.
 func NewSender() *Sender {
+	return NewSenderWithBotToken("")
+}
+
+// NewSenderWithBotToken creates a Slack sender that, in addition to webhook URLs,
+// accepts channel names and posts to them using the given bot token.
+func NewSenderWithBotToken(botToken string) *Sender {
+	return NewSenderWithClient(botToken, &http.Client{
+		Timeout: 30 * time.Second,
+	})
+}
+
+// NewSenderWithClient creates a Slack sender that uses the given HTTP client,
+// typically the shared pooled client from the httpclient package.
+func NewSenderWithClient(botToken string, client *http.Client) *Sender {
 	return &Sender{
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		httpClient: client,
+		botToken:   botToken,
+		apiURL:     PostMessageURL,
 	}
 }
 
@@ -44,15 +73,19 @@ func (s *Sender) Type() string {
 }
 
 // Send sends a notification to Slack via Incoming Webhook.
-// The endpointValue should be a Slack webhook URL.
+// The endpointValue should be a Slack webhook URL, or a channel name if a bot token is configured.
 func (s *Sender) Send(ctx context.Context, endpointValue string, notification *database.Notification) error {
 	if endpointValue == "" {
-		return fmt.Errorf("slack webhook URL is required")
+		return failure.New(failure.Invalid, fmt.Errorf("slack webhook URL is required"))
+	}
+
+	if s.botToken != "" && IsChannel(endpointValue) {
+		return s.sendToChannel(ctx, endpointValue, notification)
 	}
 
 	// Validate that it's a URL (starts with http:// or https://)
 	if !validation.IsValidURL(endpointValue) {
-		return fmt.Errorf("invalid Slack webhook URL: %q (must be a valid HTTP/HTTPS URL, not a channel name). Slack webhook URLs typically start with https://hooks.slack.com/services/", endpointValue)
+		return failure.New(failure.Invalid, fmt.Errorf("invalid Slack webhook URL: %q (must be a valid HTTP/HTTPS URL, not a channel name). Slack webhook URLs typically start with https://hooks.slack.com/services/", endpointValue))
 	}
 
 	// Build Slack message payload
@@ -67,7 +100,7 @@ func (s *Sender) Send(ctx context.Context, endpointValue string, notification *d
 	// Create HTTP request
 	req, err := http.NewRequestWithContext(ctx, "POST", endpointValue, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return fmt.Errorf("failed to create HTTP request: %w", err)
+		return failure.New(failure.Invalid, fmt.Errorf("failed to create HTTP request: %w", err))
 	}
 	req.Header.Set("Content-Type", "application/json")
 
@@ -79,16 +112,16 @@ func (s *Sender) Send(ctx context.Context, endpointValue string, notification *d
 			"webhook_url", maskURL(endpointValue),
 			"notification_id", notification.NotificationID,
 		)
-		return fmt.Errorf("failed to send Slack notification to %s: %w", maskURL(endpointValue), err)
+		return failure.Wrap(fmt.Errorf("failed to send Slack notification to %s: %w", maskURL(endpointValue), err))
 	}
-	defer resp.Body.Close()
+	defer httpclient.DrainAndClose(resp.Body)
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		slog.Error("Slack webhook returned error status",
 			"status_code", resp.StatusCode,
 			"notification_id", notification.NotificationID,
 		)
-		return fmt.Errorf("slack webhook returned status %d", resp.StatusCode)
+		return failure.HTTPStatus(resp.StatusCode, fmt.Errorf("slack webhook returned status %d", resp.StatusCode))
 	}
 
 	slog.Info("Successfully sent Slack notification",
@@ -99,3 +132,81 @@ func (s *Sender) Send(ctx context.Context, endpointValue string, notification *d
 
 	return nil
 }
+
+// channelPayload is a chat.postMessage request body.
+type channelPayload struct {
+	Channel string `json:"channel"`
+	payload.SlackPayload
+}
+
+// postMessageResponse is the subset of the chat.postMessage response we inspect.
+// The Web API returns HTTP 200 with ok=false on application-level errors.
+type postMessageResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error"`
+}
+
+// sendToChannel posts a notification to a channel using the Slack Web API.
+func (s *Sender) sendToChannel(ctx context.Context, channel string, notification *database.Notification) error {
+	jsonData, err := json.Marshal(channelPayload{
+		Channel:      channel,
+		SlackPayload: payload.BuildSlackPayload(notification),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal Slack payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.apiURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return failure.New(failure.Invalid, fmt.Errorf("failed to create HTTP request: %w", err))
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+s.botToken)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		slog.Error("Failed to send Slack channel notification",
+			"error", err,
+			"channel", channel,
+			"notification_id", notification.NotificationID,
+		)
+		return failure.Wrap(fmt.Errorf("failed to send Slack notification to %s: %w", channel, err))
+	}
+	defer httpclient.DrainAndClose(resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return failure.HTTPStatus(resp.StatusCode, fmt.Errorf("slack API returned status %d", resp.StatusCode))
+	}
+
+	var result postMessageResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to decode Slack API response: %w", err)
+	}
+	if !result.OK {
+		return failure.New(apiErrorClass(result.Error), fmt.Errorf("slack API error for channel %s: %s", channel, result.Error))
+	}
+
+	slog.Info("Successfully sent Slack channel notification",
+		"notification_id", notification.NotificationID,
+		"channel", channel,
+		"client_id", notification.ClientID,
+	)
+
+	return nil
+}
+
+// apiErrorClass classifies a chat.postMessage error code.
+// See https://api.slack.com/methods/chat.postMessage#errors.
+func apiErrorClass(code string) failure.Class {
+	switch code {
+	case "ratelimited", "rate_limited":
+		return failure.RateLimited
+	case "not_authed", "invalid_auth", "account_inactive", "token_revoked", "token_expired", "missing_scope", "no_permission":
+		return failure.Auth
+	case "internal_error", "fatal_error", "service_unavailable", "request_timeout":
+		return failure.ServerError
+	default:
+		// channel_not_found, not_in_channel, is_archived, invalid_blocks, ...
+		return failure.ClientError
+	}
+}
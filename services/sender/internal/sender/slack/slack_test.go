@@ -2,11 +2,15 @@ package slack
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
 
 	"sender/internal/database"
+	"sender/internal/sender/failure"
 	"sender/internal/sender/validation"
 )
 
@@ -206,3 +210,64 @@ func TestSender_Send_HTTPError(t *testing.T) {
 		t.Logf("Send() error (expected): %v", err)
 	}
 }
+
+func TestSender_Send_Channel(t *testing.T) {
+	tests := []struct {
+		name      string
+		response  string
+		wantErr   bool
+		wantClass failure.Class
+	}{
+		{name: "ok", response: `{"ok":true}`},
+		{name: "api error", response: `{"ok":false,"error":"channel_not_found"}`, wantErr: true, wantClass: failure.ClientError},
+		{name: "revoked token", response: `{"ok":false,"error":"token_revoked"}`, wantErr: true, wantClass: failure.Auth},
+		{name: "rate limited", response: `{"ok":false,"error":"ratelimited"}`, wantErr: true, wantClass: failure.RateLimited},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotBody map[string]any
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "Bearer xoxb-test" {
+					t.Errorf("Authorization header = %q", r.Header.Get("Authorization"))
+				}
+				json.NewDecoder(r.Body).Decode(&gotBody)
+				w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			sender := NewSenderWithBotToken("xoxb-test")
+			sender.apiURL = server.URL
+
+			err := sender.Send(context.Background(), "#payments-alerts", &database.Notification{
+				NotificationID: "notif-123",
+				Severity:       "HIGH",
+				Name:           "Test Alert",
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Send() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := failure.Classify(err); got != tt.wantClass {
+				t.Errorf("failure class = %q, want %q", got, tt.wantClass)
+			}
+			if gotBody["channel"] != "#payments-alerts" {
+				t.Errorf("request channel = %v, want #payments-alerts", gotBody["channel"])
+			}
+		})
+	}
+}
+
+func TestIsChannel(t *testing.T) {
+	tests := map[string]bool{
+		"#general":                    true,
+		"#":                           false,
+		"general":                     false,
+		"https://hooks.slack.com/xxx": false,
+		"":                            false,
+	}
+	for value, want := range tests {
+		if got := IsChannel(value); got != want {
+			t.Errorf("IsChannel(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
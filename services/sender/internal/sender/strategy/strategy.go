@@ -20,6 +20,12 @@ type NotificationSender interface {
 	Type() string
 }
 
+// HeaderSender is implemented by senders that can attach an endpoint's custom
+// headers to the request (webhook). Headers are already decrypted.
+type HeaderSender interface {
+	SendWithHeaders(ctx context.Context, endpointValue string, headers map[string]string, notification *database.Notification) error
+}
+
 // Registry manages notification sender strategies.
 type Registry struct {
 	senders map[string]NotificationSender
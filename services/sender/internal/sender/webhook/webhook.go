@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -13,10 +14,16 @@ import (
 	"time"
 
 	"sender/internal/database"
+	"sender/internal/sender/failure"
+	"sender/internal/sender/httpclient"
 	"sender/internal/sender/payload"
 	"sender/internal/sender/validation"
 )
 
+// ErrUnauthorized is returned when the webhook rejects the request with 401,
+// e.g. because its OAuth2 access token was revoked.
+var ErrUnauthorized = errors.New("webhook returned status 401")
+
 // Sender implements webhook notification sending via HTTP POST.
 type Sender struct {
 	httpClient *http.Client
@@ -24,10 +31,16 @@ type Sender struct {
 
 // NewSender creates a new webhook sender.
 func NewSender() *Sender {
+	return NewSenderWithClient(&http.Client{
+		Timeout: 30 * time.Second,
+	})
+}
+
+// NewSenderWithClient creates a webhook sender that uses the given HTTP client,
+// typically the shared pooled client from the httpclient package.
+func NewSenderWithClient(client *http.Client) *Sender {
 	return &Sender{
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		httpClient: client,
 	}
 }
 
@@ -68,13 +81,19 @@ func isDummyWebhookURL(endpointValue string) bool {
 // Send sends a notification to a webhook endpoint via HTTP POST.
 // The endpointValue should be a webhook URL.
 func (s *Sender) Send(ctx context.Context, endpointValue string, notification *database.Notification) error {
+	return s.SendWithHeaders(ctx, endpointValue, nil, notification)
+}
+
+// SendWithHeaders sends a notification to a webhook endpoint via HTTP POST,
+// adding the endpoint's custom headers to the request.
+func (s *Sender) SendWithHeaders(ctx context.Context, endpointValue string, headers map[string]string, notification *database.Notification) error {
 	if endpointValue == "" {
-		return fmt.Errorf("webhook URL is required")
+		return failure.New(failure.Invalid, fmt.Errorf("webhook URL is required"))
 	}
 
 	// Validate that it's a URL (starts with http:// or https://)
 	if !validation.IsValidURL(endpointValue) {
-		return fmt.Errorf("invalid webhook URL: %q (must be a valid HTTP/HTTPS URL)", endpointValue)
+		return failure.New(failure.Invalid, fmt.Errorf("invalid webhook URL: %q (must be a valid HTTP/HTTPS URL)", endpointValue))
 	}
 
 	if isDummyWebhookURL(endpointValue) {
@@ -97,7 +116,10 @@ func (s *Sender) Send(ctx context.Context, endpointValue string, notification *d
 	// Create HTTP request
 	req, err := http.NewRequestWithContext(ctx, "POST", endpointValue, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return fmt.Errorf("failed to create HTTP request: %w", err)
+		return failure.New(failure.Invalid, fmt.Errorf("failed to create HTTP request: %w", err))
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
 	}
 	req.Header.Set("Content-Type", "application/json")
 
@@ -109,9 +131,10 @@ func (s *Sender) Send(ctx context.Context, endpointValue string, notification *d
 			"webhook_url", endpointValue,
 			"notification_id", notification.NotificationID,
 		)
-		return fmt.Errorf("failed to send webhook notification: %w", err)
+		return failure.Wrap(fmt.Errorf("failed to send webhook notification: %w", err))
 	}
-	defer resp.Body.Close()
+	// Drain before close so the keep-alive connection returns to the pool
+	defer httpclient.DrainAndClose(resp.Body)
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		slog.Error("Webhook returned error status",
@@ -119,7 +142,10 @@ func (s *Sender) Send(ctx context.Context, endpointValue string, notification *d
 			"webhook_url", endpointValue,
 			"notification_id", notification.NotificationID,
 		)
-		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+		if resp.StatusCode == http.StatusUnauthorized {
+			return failure.New(failure.Auth, ErrUnauthorized)
+		}
+		return failure.HTTPStatus(resp.StatusCode, fmt.Errorf("webhook returned status %d", resp.StatusCode))
 	}
 
 	slog.Info("Successfully sent webhook notification",
@@ -2,11 +2,15 @@ package webhook
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
 
 	"sender/internal/database"
+	"sender/internal/sender/failure"
 	"sender/internal/sender/validation"
 )
 
@@ -26,6 +30,15 @@ func TestNewSender(t *testing.T) {
 	}
 }
 
+func TestNewSenderWithClient(t *testing.T) {
+	client := &http.Client{Timeout: 5 * time.Second}
+	sender := NewSenderWithClient(client)
+
+	if sender.httpClient != client {
+		t.Error("NewSenderWithClient() should use the given client")
+	}
+}
+
 func TestSender_Type(t *testing.T) {
 	sender := NewSender()
 
@@ -175,3 +188,60 @@ func TestSender_Send_HTTPError(t *testing.T) {
 		t.Logf("Send() error (expected): %v", err)
 	}
 }
+
+func TestSender_Send_FailureClass(t *testing.T) {
+	tests := []struct {
+		status int
+		want   failure.Class
+	}{
+		{http.StatusBadRequest, failure.ClientError},
+		{http.StatusUnauthorized, failure.Auth},
+		{http.StatusTooManyRequests, failure.RateLimited},
+		{http.StatusServiceUnavailable, failure.ServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			err := NewSenderWithClient(server.Client()).Send(context.Background(), server.URL, &database.Notification{NotificationID: "notif-123"})
+			if got := failure.Classify(err); got != tt.want {
+				t.Errorf("failure class = %q (error %v), want %q", got, err, tt.want)
+			}
+		})
+	}
+
+	// The sentinel survives classification, so revoked OAuth2 tokens are still detected
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+	if err := NewSenderWithClient(server.Client()).Send(context.Background(), server.URL, &database.Notification{}); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Send() error = %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestSender_SendWithHeaders(t *testing.T) {
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sender := NewSenderWithClient(server.Client())
+	headers := map[string]string{"Authorization": "Bearer s3cret", "Content-Type": "text/plain"}
+	if err := sender.SendWithHeaders(context.Background(), server.URL, headers, &database.Notification{NotificationID: "notif-123"}); err != nil {
+		t.Fatalf("SendWithHeaders() error = %v", err)
+	}
+
+	if got.Get("Authorization") != "Bearer s3cret" {
+		t.Errorf("Authorization = %q, want the custom header", got.Get("Authorization"))
+	}
+	if got.Get("Content-Type") != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json to win over custom headers", got.Get("Content-Type"))
+	}
+}
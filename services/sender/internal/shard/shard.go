@@ -0,0 +1,149 @@
+// Package shard assigns clients to sender instances so large tenants can get dedicated capacity.
+//
+// Every client hashes to one of Buckets buckets. A sharded instance owns a bucket range
+// and/or an explicit list of clients, minus any excluded clients (those served by a
+// dedicated shard). Each shard reads the whole notifications topic with its own consumer
+// group and skips the notifications it does not own, so together the shards must cover
+// every client exactly once.
+package shard
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Buckets is the number of client hash buckets; ranges are within [0, Buckets-1].
+const Buckets = 1024
+
+// Slices is the number of equal bucket slices traffic is counted by, to show shard balance.
+const Slices = 16
+
+// Bucket returns the hash bucket of a client.
+func Bucket(clientID string) int {
+	h := fnv.New32a()
+	h.Write([]byte(clientID))
+	return int(h.Sum32() % Buckets)
+}
+
+// Slice returns the balance slice a bucket falls into.
+func Slice(bucket int) int {
+	return bucket * Slices / Buckets
+}
+
+// Assignment is the set of clients a sender instance owns.
+// A nil Assignment (sharding disabled) owns every client.
+type Assignment struct {
+	lo, hi   int
+	hasRange bool
+	clients  map[string]bool
+	exclude  map[string]bool
+}
+
+// Parse builds an assignment from a bucket range ("lo-hi", inclusive), a comma-separated
+// client list and a comma-separated list of excluded clients.
+// Returns nil if range and clients are both empty, meaning sharding is disabled.
+func Parse(bucketRange, clients, exclude string) (*Assignment, error) {
+	a := &Assignment{
+		clients: parseList(clients),
+		exclude: parseList(exclude),
+	}
+	if strings.TrimSpace(bucketRange) != "" {
+		lo, hi, err := parseRange(bucketRange)
+		if err != nil {
+			return nil, err
+		}
+		a.lo, a.hi, a.hasRange = lo, hi, true
+	}
+	if !a.hasRange && len(a.clients) == 0 {
+		if len(a.exclude) > 0 {
+			return nil, fmt.Errorf("shard-exclude-clients requires shard-range or shard-clients")
+		}
+		return nil, nil
+	}
+	for id := range a.clients {
+		if a.exclude[id] {
+			return nil, fmt.Errorf("client %q is both a shard client and excluded", id)
+		}
+	}
+	return a, nil
+}
+
+// parseRange parses an inclusive "lo-hi" bucket range.
+func parseRange(s string) (int, int, error) {
+	loStr, hiStr, ok := strings.Cut(strings.TrimSpace(s), "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid shard range %q (expected lo-hi)", s)
+	}
+	lo, err := strconv.Atoi(strings.TrimSpace(loStr))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid shard range %q: %w", s, err)
+	}
+	hi, err := strconv.Atoi(strings.TrimSpace(hiStr))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid shard range %q: %w", s, err)
+	}
+	if lo < 0 || hi >= Buckets || lo > hi {
+		return 0, 0, fmt.Errorf("invalid shard range %q (must be within 0-%d with lo <= hi)", s, Buckets-1)
+	}
+	return lo, hi, nil
+}
+
+// parseList parses a comma-separated list into a set, ignoring blanks.
+func parseList(s string) map[string]bool {
+	set := make(map[string]bool)
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			set[item] = true
+		}
+	}
+	return set
+}
+
+// Owns reports whether this instance delivers the client's notifications.
+func (a *Assignment) Owns(clientID string) bool {
+	if a == nil {
+		return true
+	}
+	if a.exclude[clientID] {
+		return false
+	}
+	if a.clients[clientID] {
+		return true
+	}
+	if !a.hasRange {
+		return false
+	}
+	b := Bucket(clientID)
+	return b >= a.lo && b <= a.hi
+}
+
+// String describes the assignment for logging.
+func (a *Assignment) String() string {
+	if a == nil {
+		return "all"
+	}
+	var parts []string
+	if a.hasRange {
+		parts = append(parts, fmt.Sprintf("buckets %d-%d", a.lo, a.hi))
+	}
+	if len(a.clients) > 0 {
+		parts = append(parts, "clients "+joinSet(a.clients))
+	}
+	if len(a.exclude) > 0 {
+		parts = append(parts, "excluding "+joinSet(a.exclude))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// joinSet returns the set's members sorted and comma-separated.
+func joinSet(set map[string]bool) string {
+	items := make([]string, 0, len(set))
+	for item := range set {
+		items = append(items, item)
+	}
+	sort.Strings(items)
+	return strings.Join(items, ",")
+}
@@ -0,0 +1,136 @@
+package shard
+
+import (
+	"fmt"
+	"testing"
+)
+
+// clientInBucketRange finds a client ID that hashes into [lo, hi].
+func clientInBucketRange(t *testing.T, lo, hi int) string {
+	t.Helper()
+	for i := 0; i < 100000; i++ {
+		id := fmt.Sprintf("client-%d", i)
+		if b := Bucket(id); b >= lo && b <= hi {
+			return id
+		}
+	}
+	t.Fatalf("no client found in buckets %d-%d", lo, hi)
+	return ""
+}
+
+func TestBucket_StableAndInRange(t *testing.T) {
+	for i := 0; i < 1000; i++ {
+		id := fmt.Sprintf("client-%d", i)
+		b := Bucket(id)
+		if b < 0 || b >= Buckets {
+			t.Fatalf("Bucket(%q) = %d, out of range", id, b)
+		}
+		if Bucket(id) != b {
+			t.Fatalf("Bucket(%q) is not stable", id)
+		}
+	}
+}
+
+func TestSlice(t *testing.T) {
+	if got := Slice(0); got != 0 {
+		t.Errorf("Slice(0) = %d, want 0", got)
+	}
+	if got := Slice(Buckets - 1); got != Slices-1 {
+		t.Errorf("Slice(%d) = %d, want %d", Buckets-1, got, Slices-1)
+	}
+}
+
+func TestParse_Disabled(t *testing.T) {
+	a, err := Parse("", " , ", "")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if a != nil {
+		t.Fatalf("Parse() = %v, want nil", a)
+	}
+	if !a.Owns("anyone") {
+		t.Error("nil assignment should own every client")
+	}
+	if a.String() != "all" {
+		t.Errorf("String() = %q, want all", a.String())
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name                    string
+		bucketRange, clients, x string
+	}{
+		{"missing dash", "100", "", ""},
+		{"not a number", "a-10", "", ""},
+		{"negative", "-1-10", "", ""},
+		{"past last bucket", "0-1024", "", ""},
+		{"reversed", "10-5", "", ""},
+		{"exclude only", "", "", "big"},
+		{"client also excluded", "", "big", "big"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.bucketRange, tt.clients, tt.x); err == nil {
+				t.Errorf("Parse(%q, %q, %q) expected error", tt.bucketRange, tt.clients, tt.x)
+			}
+		})
+	}
+}
+
+func TestAssignment_Owns(t *testing.T) {
+	low := clientInBucketRange(t, 0, 511)
+	high := clientInBucketRange(t, 512, Buckets-1)
+
+	a, err := Parse("0-511", "", "")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !a.Owns(low) {
+		t.Errorf("range 0-511 should own %q (bucket %d)", low, Bucket(low))
+	}
+	if a.Owns(high) {
+		t.Errorf("range 0-511 should not own %q (bucket %d)", high, Bucket(high))
+	}
+
+	// Dedicated shard for one large tenant
+	dedicated, err := Parse("", "big", "")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !dedicated.Owns("big") || dedicated.Owns(low) {
+		t.Error("client shard should own only its clients")
+	}
+
+	// General shard that leaves the large tenant to its dedicated shard
+	general, err := Parse("0-1023", "", "big")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if general.Owns("big") {
+		t.Error("excluded client should not be owned")
+	}
+	if !general.Owns(low) || !general.Owns(high) {
+		t.Error("full range should own every other client")
+	}
+
+	// Range plus explicit clients
+	mixed, err := Parse("0-511", high, "")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !mixed.Owns(low) || !mixed.Owns(high) {
+		t.Error("assignment should own its range and its clients")
+	}
+}
+
+func TestAssignment_String(t *testing.T) {
+	a, err := Parse(" 0 - 511 ", "b,a", "c")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	want := "buckets 0-511, clients a,b, excluding c"
+	if got := a.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,393 @@
+package statestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"sender/internal/database"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis keys.
+const (
+	stateKeyPrefix = "sender:state:"          // hash per notification
+	dirtyKey       = "sender:state:dirty"     // set of notifications not yet written back to Postgres
+	throttledKey   = "sender:state:throttled" // sorted set of THROTTLED notifications by throttled_until (ms)
+)
+
+// transitionScript moves a notification to a new status if its current status allows it.
+// The state remembers the Postgres status it was based on (base): while Postgres still has that
+// status, the Redis status is current; once another service moved the notification in Postgres
+// (e.g. acknowledged it, or reset it for re-notification), Postgres wins.
+//
+// KEYS: state hash, dirty set, throttled sorted set.
+// ARGV: notification ID, new status, Postgres status, now (ms), throttled_until (ms, 0 = none),
+// TTL (s), allowed predecessor statuses...
+// Returns {1, current} when moved, {0, current} when the transition is not allowed.
+var transitionScript = redis.NewScript(`
+local base = redis.call('HGET', KEYS[1], 'base')
+local current = ARGV[3]
+if base == ARGV[3] then
+	current = redis.call('HGET', KEYS[1], 'status')
+else
+	base = ARGV[3]
+end
+local allowed = false
+for i = 7, #ARGV do
+	if ARGV[i] == current then
+		allowed = true
+		break
+	end
+end
+if not allowed then
+	return {0, current}
+end
+redis.call('HSET', KEYS[1], 'base', base, 'status', ARGV[2], 'updated_at', ARGV[4], 'throttled_until', ARGV[5])
+redis.call('HINCRBY', KEYS[1], 'rev', 1)
+redis.call('EXPIRE', KEYS[1], ARGV[6])
+redis.call('SADD', KEYS[2], ARGV[1])
+if ARGV[5] ~= '0' then
+	redis.call('ZADD', KEYS[3], ARGV[5], ARGV[1])
+else
+	redis.call('ZREM', KEYS[3], ARGV[1])
+end
+return {1, current}
+`)
+
+// markSyncedScript drops a state written back to Postgres, unless it changed meanwhile. A state
+// that changed is rebased on the status now in Postgres and stays dirty.
+//
+// KEYS: state hash, dirty set. ARGV: notification ID, synced rev, status now in Postgres (empty if unchanged).
+var markSyncedScript = redis.NewScript(`
+local rev = redis.call('HGET', KEYS[1], 'rev')
+if not rev or rev == ARGV[2] then
+	redis.call('DEL', KEYS[1])
+	redis.call('SREM', KEYS[2], ARGV[1])
+	return 1
+end
+if ARGV[3] ~= '' then
+	redis.call('HSET', KEYS[1], 'base', ARGV[3])
+end
+return 0
+`)
+
+// Reader reads notifications from Postgres. It is implemented by *database.DB.
+type Reader interface {
+	GetNotification(ctx context.Context, notificationID string) (*database.Notification, error)
+	GetNotificationsByIDs(ctx context.Context, notificationIDs []string) ([]*database.Notification, error)
+	GetDigestNotificationIDs(ctx context.Context, digestID string) ([]string, error)
+}
+
+// State is a notification's delivery state kept in Redis.
+type State struct {
+	NotificationID string
+	// Base is the Postgres status the state was based on; Status is the current one.
+	Base           database.NotificationStatus
+	Status         database.NotificationStatus
+	ThrottledUntil time.Time // zero unless THROTTLED
+	UpdatedAt      time.Time
+	// Rev increments with every change.
+	Rev int64
+}
+
+// Redis is a Store that keeps notification statuses in Redis and reads notifications from
+// Postgres. Statuses are written back to Postgres by a Syncer.
+type Redis struct {
+	client *redis.Client
+	reader Reader
+	ttl    time.Duration
+}
+
+var _ Store = (*Redis)(nil)
+
+// NewRedis creates a Redis store reading notifications through reader.
+// States are kept for ttl after their last change; a non-positive ttl uses DefaultTTL.
+func NewRedis(client *redis.Client, reader Reader, ttl time.Duration) *Redis {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &Redis{client: client, reader: reader, ttl: ttl}
+}
+
+// GetNotification retrieves a notification, with its status from Redis while Postgres still has
+// the status the Redis state was based on.
+func (r *Redis) GetNotification(ctx context.Context, notificationID string) (*database.Notification, error) {
+	notification, err := r.reader.GetNotification(ctx, notificationID)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.overlay(ctx, []*database.Notification{notification}); err != nil {
+		return nil, err
+	}
+	return notification, nil
+}
+
+// GetNotificationsByIDs retrieves the notifications with the given IDs, in the order given,
+// with their statuses from Redis as in GetNotification. IDs that do not exist are skipped.
+func (r *Redis) GetNotificationsByIDs(ctx context.Context, notificationIDs []string) ([]*database.Notification, error) {
+	notifications, err := r.reader.GetNotificationsByIDs(ctx, notificationIDs)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.overlay(ctx, notifications); err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+// GetDigestNotificationIDs returns the IDs of the notifications listed by a digest, from Postgres.
+func (r *Redis) GetDigestNotificationIDs(ctx context.Context, digestID string) ([]string, error) {
+	return r.reader.GetDigestNotificationIDs(ctx, digestID)
+}
+
+// UpdateNotificationStatus moves a notification to status. It returns an error wrapping
+// database.ErrInvalidTransition if the notification's current status may not move to status.
+func (r *Redis) UpdateNotificationStatus(ctx context.Context, notificationID string, status database.NotificationStatus) error {
+	notification, err := r.reader.GetNotification(ctx, notificationID)
+	if err != nil {
+		return err
+	}
+	moved, current, err := r.transition(ctx, notification, status, time.Time{}, status.Predecessors())
+	if err != nil {
+		return err
+	}
+	if !moved {
+		return fmt.Errorf("%w: %s to %s", database.ErrInvalidTransition, current, status)
+	}
+	return nil
+}
+
+// UpdateNotificationsStatus moves each of the notifications that may transition to status,
+// and leaves the others unchanged. Returns the number of notifications moved.
+func (r *Redis) UpdateNotificationsStatus(ctx context.Context, notificationIDs []string, status database.NotificationStatus) (int64, error) {
+	notifications, err := r.reader.GetNotificationsByIDs(ctx, notificationIDs)
+	if err != nil {
+		return 0, err
+	}
+	var updated int64
+	for _, notification := range notifications {
+		moved, _, err := r.transition(ctx, notification, status, time.Time{}, status.Predecessors())
+		if err != nil {
+			return updated, err
+		}
+		if moved {
+			updated++
+		}
+	}
+	return updated, nil
+}
+
+// ThrottleNotification moves a notification being sent to THROTTLED, to be delivered again
+// once retryAfter has passed. It returns an error wrapping database.ErrInvalidTransition if the
+// notification is no longer SENDING.
+func (r *Redis) ThrottleNotification(ctx context.Context, notificationID string, retryAfter time.Duration) error {
+	notification, err := r.reader.GetNotification(ctx, notificationID)
+	if err != nil {
+		return err
+	}
+	moved, _, err := r.transition(ctx, notification, database.StatusThrottled, time.Now().Add(retryAfter), database.StatusThrottled.Predecessors())
+	if err != nil {
+		return err
+	}
+	if !moved {
+		return fmt.Errorf("%w: %s is no longer %s", database.ErrInvalidTransition, notificationID, database.StatusSending)
+	}
+	return nil
+}
+
+// GetDueThrottledNotifications returns up to limit THROTTLED notifications whose
+// throttled_until has passed, longest due first.
+func (r *Redis) GetDueThrottledNotifications(ctx context.Context, limit int) ([]*database.Notification, error) {
+	ids, err := r.client.ZRangeByScore(ctx, throttledKey, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(time.Now().UnixMilli(), 10),
+		Count: int64(limit),
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due throttled notifications: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	notifications, err := r.GetNotificationsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	due := make([]*database.Notification, 0, len(notifications))
+	throttled := make(map[string]bool, len(notifications))
+	for _, notification := range notifications {
+		if notification.Status == database.StatusThrottled.String() {
+			due = append(due, notification)
+			throttled[notification.NotificationID] = true
+		}
+	}
+	// Drop entries of notifications that no longer exist or left THROTTLED outside this store
+	// (e.g. were acknowledged)
+	var stale []any
+	for _, id := range ids {
+		if !throttled[id] {
+			stale = append(stale, id)
+		}
+	}
+	if len(stale) > 0 {
+		if err := r.client.ZRem(ctx, throttledKey, stale...).Err(); err != nil {
+			return nil, fmt.Errorf("failed to drop stale throttled notifications: %w", err)
+		}
+	}
+	return due, nil
+}
+
+// ClaimThrottledNotification moves a THROTTLED notification back to SENDING to deliver it.
+// It returns false if the notification is no longer THROTTLED, e.g. because another sender
+// instance claimed it first.
+func (r *Redis) ClaimThrottledNotification(ctx context.Context, notificationID string) (bool, error) {
+	notification, err := r.reader.GetNotification(ctx, notificationID)
+	if err != nil {
+		return false, err
+	}
+	moved, _, err := r.transition(ctx, notification, database.StatusSending, time.Time{}, []string{database.StatusThrottled.String()})
+	if err != nil {
+		return false, fmt.Errorf("failed to claim throttled notification: %w", err)
+	}
+	return moved, nil
+}
+
+// transition runs transitionScript for a notification read from Postgres. It returns whether
+// the notification moved, and the status it moved from (or stayed in).
+func (r *Redis) transition(ctx context.Context, notification *database.Notification, status database.NotificationStatus, throttledUntil time.Time, predecessors []string) (bool, string, error) {
+	var until int64
+	if !throttledUntil.IsZero() {
+		until = throttledUntil.UnixMilli()
+	}
+	args := []any{
+		notification.NotificationID,
+		status.String(),
+		notification.Status,
+		time.Now().UnixMilli(),
+		until,
+		int64(r.ttl.Seconds()),
+	}
+	for _, p := range predecessors {
+		args = append(args, p)
+	}
+
+	keys := []string{stateKeyPrefix + notification.NotificationID, dirtyKey, throttledKey}
+	result, err := transitionScript.Run(ctx, r.client, keys, args...).Slice()
+	if err != nil {
+		return false, "", fmt.Errorf("failed to update notification status: %w", err)
+	}
+	if len(result) != 2 {
+		return false, "", fmt.Errorf("unexpected transition result: %v", result)
+	}
+	moved, _ := result[0].(int64)
+	current, _ := result[1].(string)
+	return moved == 1, current, nil
+}
+
+// overlay replaces the statuses of notifications read from Postgres with their Redis states,
+// where the state is based on the status still in Postgres.
+func (r *Redis) overlay(ctx context.Context, notifications []*database.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.SliceCmd, len(notifications))
+	for i, notification := range notifications {
+		cmds[i] = pipe.HMGet(ctx, stateKeyPrefix+notification.NotificationID, "base", "status", "updated_at")
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return fmt.Errorf("failed to read notification states: %w", err)
+	}
+
+	for i, notification := range notifications {
+		values := cmds[i].Val()
+		if len(values) != 3 {
+			continue
+		}
+		base, _ := values[0].(string)
+		status, _ := values[1].(string)
+		if status == "" || base != notification.Status {
+			continue
+		}
+		notification.Status = status
+		if ms, err := strconv.ParseInt(fmt.Sprint(values[2]), 10, 64); err == nil {
+			notification.UpdatedAt = time.UnixMilli(ms)
+		}
+	}
+	return nil
+}
+
+// PendingStates returns up to limit states not yet written back to Postgres.
+// States that expired before being written back are dropped.
+func (r *Redis) PendingStates(ctx context.Context, limit int) ([]*State, error) {
+	ids, err := r.client.SRandMemberN(ctx, dirtyKey, int64(limit)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending notification states: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, len(ids))
+	for i, id := range ids {
+		cmds[i] = pipe.HGetAll(ctx, stateKeyPrefix+id)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("failed to read notification states: %w", err)
+	}
+
+	states := make([]*State, 0, len(ids))
+	var expired []any
+	for i, id := range ids {
+		fields := cmds[i].Val()
+		if len(fields) == 0 {
+			expired = append(expired, id)
+			continue
+		}
+		states = append(states, parseState(id, fields))
+	}
+	if len(expired) > 0 {
+		if err := r.client.SRem(ctx, dirtyKey, expired...).Err(); err != nil {
+			return nil, fmt.Errorf("failed to drop expired notification states: %w", err)
+		}
+	}
+	return states, nil
+}
+
+// MarkSynced records that state was written back to Postgres (synced), or found stale because
+// another service moved the notification meanwhile. The state is dropped unless it changed since
+// it was read; a changed state stays pending.
+func (r *Redis) MarkSynced(ctx context.Context, state *State, synced bool) error {
+	var rebase string
+	if synced {
+		rebase = state.Status.String()
+	}
+	keys := []string{stateKeyPrefix + state.NotificationID, dirtyKey}
+	if err := markSyncedScript.Run(ctx, r.client, keys, state.NotificationID, state.Rev, rebase).Err(); err != nil {
+		return fmt.Errorf("failed to mark notification state synced: %w", err)
+	}
+	return nil
+}
+
+// parseState reads a state hash.
+func parseState(notificationID string, fields map[string]string) *State {
+	state := &State{
+		NotificationID: notificationID,
+		Base:           database.NotificationStatus(fields["base"]),
+		Status:         database.NotificationStatus(fields["status"]),
+	}
+	if ms, err := strconv.ParseInt(fields["throttled_until"], 10, 64); err == nil && ms > 0 {
+		state.ThrottledUntil = time.UnixMilli(ms)
+	}
+	if ms, err := strconv.ParseInt(fields["updated_at"], 10, 64); err == nil {
+		state.UpdatedAt = time.UnixMilli(ms)
+	}
+	state.Rev, _ = strconv.ParseInt(fields["rev"], 10, 64)
+	return state
+}
@@ -0,0 +1,175 @@
+package statestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"sender/internal/database"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// fakeReader serves notifications from memory, as Postgres would.
+type fakeReader struct {
+	notifications map[string]*database.Notification
+}
+
+func (f *fakeReader) GetNotification(ctx context.Context, notificationID string) (*database.Notification, error) {
+	n, ok := f.notifications[notificationID]
+	if !ok {
+		return nil, fmt.Errorf("notification not found: %s", notificationID)
+	}
+	copied := *n
+	return &copied, nil
+}
+
+func (f *fakeReader) GetNotificationsByIDs(ctx context.Context, notificationIDs []string) ([]*database.Notification, error) {
+	var result []*database.Notification
+	for _, id := range notificationIDs {
+		if n, err := f.GetNotification(ctx, id); err == nil {
+			result = append(result, n)
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeReader) GetDigestNotificationIDs(ctx context.Context, digestID string) ([]string, error) {
+	return nil, nil
+}
+
+// setupRedis connects to a local Redis, skipping the test if none is running, and removes the
+// test notifications' state when the test ends.
+func setupRedis(t *testing.T, ids ...string) *redis.Client {
+	client := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		client.Close()
+		t.Skipf("Skipping integration test: Redis not available: %v", err)
+	}
+	cleanup := func() {
+		ctx := context.Background()
+		for _, id := range ids {
+			client.Del(ctx, stateKeyPrefix+id)
+			client.SRem(ctx, dirtyKey, id)
+			client.ZRem(ctx, throttledKey, id)
+		}
+	}
+	cleanup()
+	t.Cleanup(func() {
+		cleanup()
+		client.Close()
+	})
+	return client
+}
+
+func TestRedis_Lifecycle_Integration(t *testing.T) {
+	id := "statestore-test-lifecycle"
+	client := setupRedis(t, id)
+	ctx := context.Background()
+	reader := &fakeReader{notifications: map[string]*database.Notification{
+		id: {NotificationID: id, ClientID: "client-1", Status: database.StatusReceived.String()},
+	}}
+	store := NewRedis(client, reader, time.Hour)
+
+	if err := store.UpdateNotificationStatus(ctx, id, database.StatusSending); err != nil {
+		t.Fatalf("UpdateNotificationStatus(SENDING) error = %v", err)
+	}
+	if err := store.UpdateNotificationStatus(ctx, id, database.StatusSent); err != nil {
+		t.Fatalf("UpdateNotificationStatus(SENT) error = %v", err)
+	}
+	n, err := store.GetNotification(ctx, id)
+	if err != nil || n.Status != database.StatusSent.String() {
+		t.Fatalf("GetNotification() = %v, %v, want SENT from Redis", n, err)
+	}
+	if err := store.UpdateNotificationStatus(ctx, id, database.StatusSending); !errors.Is(err, database.ErrInvalidTransition) {
+		t.Errorf("UpdateNotificationStatus(SENT -> SENDING) error = %v, want ErrInvalidTransition", err)
+	}
+
+	states, err := store.PendingStates(ctx, 1000)
+	if err != nil {
+		t.Fatalf("PendingStates() error = %v", err)
+	}
+	var state *State
+	for _, s := range states {
+		if s.NotificationID == id {
+			state = s
+		}
+	}
+	if state == nil || state.Base != database.StatusReceived || state.Status != database.StatusSent || state.Rev != 2 {
+		t.Fatalf("pending state = %+v, want RECEIVED -> SENT at rev 2", state)
+	}
+
+	// Written back: Postgres now has SENT and the state is dropped
+	reader.notifications[id].Status = database.StatusSent.String()
+	if err := store.MarkSynced(ctx, state, true); err != nil {
+		t.Fatalf("MarkSynced() error = %v", err)
+	}
+	if exists, _ := client.Exists(ctx, stateKeyPrefix+id).Result(); exists != 0 {
+		t.Error("state still in Redis after it was written back")
+	}
+	if member, _ := client.SIsMember(ctx, dirtyKey, id).Result(); member {
+		t.Error("notification still pending after it was written back")
+	}
+}
+
+func TestRedis_PostgresChangedMeanwhile_Integration(t *testing.T) {
+	id := "statestore-test-acked"
+	client := setupRedis(t, id)
+	ctx := context.Background()
+	reader := &fakeReader{notifications: map[string]*database.Notification{
+		id: {NotificationID: id, Status: database.StatusReceived.String()},
+	}}
+	store := NewRedis(client, reader, time.Hour)
+
+	if err := store.UpdateNotificationStatus(ctx, id, database.StatusSending); err != nil {
+		t.Fatalf("UpdateNotificationStatus(SENDING) error = %v", err)
+	}
+	// rule-service acknowledges the notification in Postgres while it is being sent
+	reader.notifications[id].Status = "ACKNOWLEDGED"
+
+	n, err := store.GetNotification(ctx, id)
+	if err != nil || n.Status != "ACKNOWLEDGED" {
+		t.Errorf("GetNotification() = %v, %v, want ACKNOWLEDGED from Postgres", n, err)
+	}
+	if err := store.UpdateNotificationStatus(ctx, id, database.StatusSent); !errors.Is(err, database.ErrInvalidTransition) {
+		t.Errorf("UpdateNotificationStatus(SENT) error = %v, want ErrInvalidTransition", err)
+	}
+}
+
+func TestRedis_Throttling_Integration(t *testing.T) {
+	id := "statestore-test-throttled"
+	client := setupRedis(t, id)
+	ctx := context.Background()
+	reader := &fakeReader{notifications: map[string]*database.Notification{
+		id: {NotificationID: id, Status: database.StatusSending.String()},
+	}}
+	store := NewRedis(client, reader, time.Hour)
+
+	if err := store.ThrottleNotification(ctx, id, -time.Second); err != nil {
+		t.Fatalf("ThrottleNotification() error = %v", err)
+	}
+	due, err := store.GetDueThrottledNotifications(ctx, 1000)
+	if err != nil {
+		t.Fatalf("GetDueThrottledNotifications() error = %v", err)
+	}
+	found := false
+	for _, n := range due {
+		found = found || n.NotificationID == id
+	}
+	if !found {
+		t.Fatalf("due = %v, want %s", due, id)
+	}
+
+	claimed, err := store.ClaimThrottledNotification(ctx, id)
+	if err != nil || !claimed {
+		t.Fatalf("ClaimThrottledNotification() = %v, %v, want claimed", claimed, err)
+	}
+	if claimed, _ := store.ClaimThrottledNotification(ctx, id); claimed {
+		t.Error("ClaimThrottledNotification() claimed twice")
+	}
+	if score, err := client.ZScore(ctx, throttledKey, id).Result(); !errors.Is(err, redis.Nil) {
+		t.Errorf("throttled entry = %v, %v, want removed on claim", score, err)
+	}
+}
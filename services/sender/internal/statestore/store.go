@@ -0,0 +1,40 @@
+// Package statestore persists the delivery state of notifications for the sender.
+// Postgres (*database.DB) is the default Store. Redis keeps status writes out of Postgres:
+// notifications are still read from Postgres, and a Syncer writes their statuses back to it
+// asynchronously.
+package statestore
+
+import (
+	"context"
+	"time"
+
+	"sender/internal/database"
+)
+
+// Store reads and transitions notifications. It is implemented by *database.DB and by Redis.
+type Store interface {
+	GetNotification(ctx context.Context, notificationID string) (*database.Notification, error)
+	GetNotificationsByIDs(ctx context.Context, notificationIDs []string) ([]*database.Notification, error)
+	UpdateNotificationStatus(ctx context.Context, notificationID string, status database.NotificationStatus) error
+	UpdateNotificationsStatus(ctx context.Context, notificationIDs []string, status database.NotificationStatus) (int64, error)
+	ThrottleNotification(ctx context.Context, notificationID string, retryAfter time.Duration) error
+	GetDueThrottledNotifications(ctx context.Context, limit int) ([]*database.Notification, error)
+	ClaimThrottledNotification(ctx context.Context, notificationID string) (bool, error)
+	GetDigestNotificationIDs(ctx context.Context, digestID string) ([]string, error)
+}
+
+// Store backends.
+const (
+	BackendPostgres = "postgres"
+	BackendRedis    = "redis"
+)
+
+// Defaults for the Redis backend.
+const (
+	// DefaultTTL is how long a notification's state is kept in Redis after its last change.
+	DefaultTTL = 24 * time.Hour
+	// DefaultSyncInterval is how often states are written back to Postgres.
+	DefaultSyncInterval = 5 * time.Second
+)
+
+var _ Store = (*database.DB)(nil)
@@ -0,0 +1,117 @@
+package statestore
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"sender/internal/database"
+)
+
+// syncBatchSize bounds how many states are written back per sync.
+const syncBatchSize = 500
+
+// finalSyncTimeout bounds the last sync run on shutdown.
+const finalSyncTimeout = 5 * time.Second
+
+// Source lists the states not yet written back to Postgres. It is implemented by Redis.
+type Source interface {
+	PendingStates(ctx context.Context, limit int) ([]*State, error)
+	MarkSynced(ctx context.Context, state *State, synced bool) error
+}
+
+// Writer writes statuses back to Postgres. It is implemented by *database.DB.
+type Writer interface {
+	SyncNotificationStatus(ctx context.Context, notificationID string, base, status database.NotificationStatus, throttledUntil time.Time) (bool, error)
+}
+
+// MetricsRecorder is the subset of metrics used by the syncer.
+type MetricsRecorder interface {
+	RecordStateSync(stale bool)
+	RecordError()
+}
+
+// Syncer writes the statuses kept in Redis back to Postgres.
+type Syncer struct {
+	source   Source
+	writer   Writer
+	metrics  MetricsRecorder
+	interval time.Duration
+}
+
+// NewSyncer creates a syncer that runs every interval.
+// A non-positive interval uses DefaultSyncInterval.
+func NewSyncer(source Source, writer Writer, m MetricsRecorder, interval time.Duration) *Syncer {
+	if interval <= 0 {
+		interval = DefaultSyncInterval
+	}
+	return &Syncer{source: source, writer: writer, metrics: m, interval: interval}
+}
+
+// Run syncs every interval until ctx is cancelled, then once more so statuses written just
+// before shutdown reach Postgres.
+func (s *Syncer) Run(ctx context.Context) {
+	slog.Info("Starting notification state syncer", "sync_interval", s.interval)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			finalCtx, cancel := context.WithTimeout(context.Background(), finalSyncTimeout)
+			s.SyncOnce(finalCtx)
+			cancel()
+			slog.Info("Notification state syncer stopped")
+			return
+		case <-ticker.C:
+			s.SyncOnce(ctx)
+		}
+	}
+}
+
+// SyncOnce writes back one batch of pending states and returns how many were handled.
+// A state whose notification another service moved meanwhile (e.g. acknowledged it) is stale:
+// Postgres keeps its status and the state is dropped. States that fail to write stay pending.
+func (s *Syncer) SyncOnce(ctx context.Context) int {
+	states, err := s.source.PendingStates(ctx, syncBatchSize)
+	if err != nil {
+		slog.Error("Failed to list pending notification states", "error", err)
+		s.metrics.RecordError()
+		return 0
+	}
+
+	handled := 0
+	for _, state := range states {
+		synced, err := s.writer.SyncNotificationStatus(ctx, state.NotificationID, state.Base, state.Status, state.ThrottledUntil)
+		if err != nil {
+			slog.Error("Failed to sync notification status",
+				"notification_id", state.NotificationID,
+				"status", state.Status,
+				"error", err,
+			)
+			s.metrics.RecordError()
+			continue
+		}
+		if !synced {
+			slog.Info("Notification changed in Postgres, dropping its state",
+				"notification_id", state.NotificationID,
+				"base", state.Base,
+				"status", state.Status,
+			)
+		}
+		if err := s.source.MarkSynced(ctx, state, synced); err != nil {
+			slog.Error("Failed to mark notification state synced",
+				"notification_id", state.NotificationID,
+				"error", err,
+			)
+			s.metrics.RecordError()
+			continue
+		}
+		handled++
+		s.metrics.RecordStateSync(!synced)
+	}
+	if handled > 0 {
+		slog.Debug("Synced notification states", "count", handled)
+	}
+	return handled
+}
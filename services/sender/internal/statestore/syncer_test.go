@@ -0,0 +1,137 @@
+package statestore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"sender/internal/database"
+)
+
+// fakeSource returns fixed pending states and records which were marked synced.
+type fakeSource struct {
+	states  []*State
+	listErr error
+	marked  map[string]bool // notification ID -> synced
+}
+
+func (f *fakeSource) PendingStates(ctx context.Context, limit int) ([]*State, error) {
+	return f.states, f.listErr
+}
+
+func (f *fakeSource) MarkSynced(ctx context.Context, state *State, synced bool) error {
+	if f.marked == nil {
+		f.marked = make(map[string]bool)
+	}
+	f.marked[state.NotificationID] = synced
+	return nil
+}
+
+// fakeWriter applies statuses to an in-memory table of Postgres statuses, failing for the listed IDs.
+type fakeWriter struct {
+	statuses map[string]database.NotificationStatus
+	until    map[string]time.Time
+	fail     map[string]bool
+}
+
+func (f *fakeWriter) SyncNotificationStatus(ctx context.Context, notificationID string, base, status database.NotificationStatus, throttledUntil time.Time) (bool, error) {
+	if f.fail[notificationID] {
+		return false, errors.New("db down")
+	}
+	if f.statuses[notificationID] != base {
+		return false, nil
+	}
+	f.statuses[notificationID] = status
+	if f.until == nil {
+		f.until = make(map[string]time.Time)
+	}
+	f.until[notificationID] = throttledUntil
+	return true, nil
+}
+
+// fakeMetrics counts sync outcomes.
+type fakeMetrics struct {
+	synced, stale, errors int
+}
+
+func (f *fakeMetrics) RecordStateSync(stale bool) {
+	if stale {
+		f.stale++
+	} else {
+		f.synced++
+	}
+}
+
+func (f *fakeMetrics) RecordError() { f.errors++ }
+
+func TestSyncer_SyncOnce(t *testing.T) {
+	throttledUntil := time.Now().Add(time.Minute)
+	source := &fakeSource{states: []*State{
+		{NotificationID: "sent", Base: database.StatusReceived, Status: database.StatusSent, Rev: 2},
+		{NotificationID: "throttled", Base: database.StatusReceived, Status: database.StatusThrottled, ThrottledUntil: throttledUntil, Rev: 2},
+		{NotificationID: "acked", Base: database.StatusReceived, Status: database.StatusSent, Rev: 2},
+		{NotificationID: "unreachable", Base: database.StatusReceived, Status: database.StatusSent, Rev: 2},
+	}}
+	writer := &fakeWriter{
+		statuses: map[string]database.NotificationStatus{
+			"sent":      database.StatusReceived,
+			"throttled": database.StatusReceived,
+			// Acknowledged in Postgres after the sender based its state on RECEIVED
+			"acked":       "ACKNOWLEDGED",
+			"unreachable": database.StatusReceived,
+		},
+		fail: map[string]bool{"unreachable": true},
+	}
+	m := &fakeMetrics{}
+
+	handled := NewSyncer(source, writer, m, 0).SyncOnce(context.Background())
+
+	if handled != 3 {
+		t.Errorf("SyncOnce() = %d, want 3", handled)
+	}
+	if writer.statuses["sent"] != database.StatusSent || writer.statuses["throttled"] != database.StatusThrottled {
+		t.Errorf("statuses = %v, want sent and throttled written back", writer.statuses)
+	}
+	if !writer.until["throttled"].Equal(throttledUntil) {
+		t.Errorf("throttled_until = %v, want %v", writer.until["throttled"], throttledUntil)
+	}
+	if writer.statuses["acked"] != "ACKNOWLEDGED" {
+		t.Errorf("acked status = %s, want ACKNOWLEDGED kept", writer.statuses["acked"])
+	}
+	if synced, ok := source.marked["acked"]; !ok || synced {
+		t.Errorf("acked marked = %v (%v), want marked stale", synced, ok)
+	}
+	if _, ok := source.marked["unreachable"]; ok {
+		t.Error("unreachable was marked synced, want it left pending")
+	}
+	if m.synced != 2 || m.stale != 1 || m.errors != 1 {
+		t.Errorf("metrics synced=%d stale=%d errors=%d, want 2, 1 and 1", m.synced, m.stale, m.errors)
+	}
+}
+
+func TestSyncer_SyncOnce_ListFailure(t *testing.T) {
+	m := &fakeMetrics{}
+	if handled := NewSyncer(&fakeSource{listErr: errors.New("redis down")}, &fakeWriter{}, m, 0).SyncOnce(context.Background()); handled != 0 || m.errors != 1 {
+		t.Errorf("SyncOnce() = %d with %d errors, want 0 and 1", handled, m.errors)
+	}
+}
+
+func TestSyncer_Run_SyncsOnShutdown(t *testing.T) {
+	source := &fakeSource{states: []*State{{NotificationID: "sent", Base: database.StatusSending, Status: database.StatusSent}}}
+	writer := &fakeWriter{statuses: map[string]database.NotificationStatus{"sent": database.StatusSending}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	NewSyncer(source, writer, &fakeMetrics{}, time.Hour).Run(ctx)
+
+	if writer.statuses["sent"] != database.StatusSent {
+		t.Errorf("status = %s, want SENT written back on shutdown", writer.statuses["sent"])
+	}
+}
+
+func TestNewSyncer_DefaultInterval(t *testing.T) {
+	if s := NewSyncer(nil, nil, nil, 0); s.interval != DefaultSyncInterval {
+		t.Errorf("interval = %v, want %v", s.interval, DefaultSyncInterval)
+	}
+}
@@ -0,0 +1,152 @@
+// Package workqueue provides the bounded priority queue between the sender's Kafka reader and
+// its workers. Notifications are handed out highest priority first, and in arrival order within
+// a priority, so CRITICAL notifications jump ahead of a backlog (e.g. while recovering from an
+// outage) instead of waiting behind every LOW one read before them. An item passed over for too
+// long is handed out next whatever its priority, so steady high-priority traffic cannot starve
+// lower priorities (and hold back the offset commits of their partitions forever).
+package workqueue
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+)
+
+// DefaultCapacity is the default number of notifications read ahead of the workers. The larger
+// it is, the further a CRITICAL notification can jump ahead of a backlog.
+const DefaultCapacity = 100
+
+// starvationFactor sets how long an item may wait: once capacity*starvationFactor items have
+// been handed out since it was queued, it is handed out next. Items of a full queue waiting
+// in arrival order are handed out well before that, so priorities still apply to a backlog.
+const starvationFactor = 10
+
+// Queue is a bounded, blocking priority queue. Push blocks while the queue is full, which keeps
+// the reader from running further ahead of the workers than the queue's capacity.
+type Queue[T any] struct {
+	slots chan struct{} // one token per queued item; bounds the queue
+
+	mu       sync.Mutex
+	notEmpty *sync.Cond
+	items    itemHeap[T]
+	seq      uint64
+	pops     uint64 // items handed out so far
+	maxWait  uint64 // pops an item may wait before it is handed out next
+	closed   bool
+}
+
+// New creates a queue holding at most capacity items (at least 1).
+func New[T any](capacity int) *Queue[T] {
+	if capacity < 1 {
+		capacity = 1
+	}
+	q := &Queue[T]{slots: make(chan struct{}, capacity), maxWait: uint64(capacity * starvationFactor)}
+	q.notEmpty = sync.NewCond(&q.mu)
+	return q
+}
+
+// Push adds an item with the given priority (higher is handed out first), blocking while the
+// queue is full. Returns false if ctx was cancelled first or the queue is closed.
+func (q *Queue[T]) Push(ctx context.Context, item T, priority int) bool {
+	select {
+	case q.slots <- struct{}{}:
+	case <-ctx.Done():
+		return false
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		<-q.slots
+		return false
+	}
+	heap.Push(&q.items, entry[T]{item: item, priority: priority, seq: q.seq, queuedAt: q.pops})
+	q.seq++
+	q.notEmpty.Signal()
+	return true
+}
+
+// Pop removes and returns the highest-priority item, or the oldest item if it has waited for
+// too many others, blocking while the queue is empty. After Close, the remaining items are
+// still returned; ok is false once none are left.
+func (q *Queue[T]) Pop() (item T, ok bool) {
+	q.mu.Lock()
+	for len(q.items) == 0 && !q.closed {
+		q.notEmpty.Wait()
+	}
+	if len(q.items) == 0 {
+		q.mu.Unlock()
+		return item, false
+	}
+	var e entry[T]
+	if oldest := q.items.oldest(); q.pops-q.items[oldest].queuedAt >= q.maxWait {
+		e = heap.Remove(&q.items, oldest).(entry[T])
+	} else {
+		e = heap.Pop(&q.items).(entry[T])
+	}
+	q.pops++
+	q.mu.Unlock()
+
+	<-q.slots
+	return e.item, true
+}
+
+// Close stops the queue accepting items and wakes the workers waiting in Pop.
+func (q *Queue[T]) Close() {
+	q.mu.Lock()
+	q.closed = true
+	q.notEmpty.Broadcast()
+	q.mu.Unlock()
+}
+
+// Len returns the number of queued items.
+func (q *Queue[T]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+// entry is a queued item; seq keeps items of equal priority in arrival order, and queuedAt is
+// the number of items handed out before it was queued.
+type entry[T any] struct {
+	item     T
+	priority int
+	seq      uint64
+	queuedAt uint64
+}
+
+// itemHeap implements heap.Interface, highest priority and then lowest seq first.
+type itemHeap[T any] []entry[T]
+
+func (h itemHeap[T]) Len() int { return len(h) }
+
+func (h itemHeap[T]) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h itemHeap[T]) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *itemHeap[T]) Push(x any) { *h = append(*h, x.(entry[T])) }
+
+func (h *itemHeap[T]) Pop() any {
+	old := *h
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = entry[T]{}
+	*h = old[:n-1]
+	return e
+}
+
+// oldest returns the index of the earliest queued item.
+func (h itemHeap[T]) oldest() int {
+	oldest := 0
+	for i := range h {
+		if h[i].seq < h[oldest].seq {
+			oldest = i
+		}
+	}
+	return oldest
+}
@@ -0,0 +1,99 @@
+package workqueue
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestQueue_PopsByPriorityThenArrival(t *testing.T) {
+	q := New[string](10)
+	ctx := context.Background()
+	for _, p := range []struct {
+		item     string
+		priority int
+	}{
+		{"low-1", 1}, {"high-1", 3}, {"low-2", 1}, {"critical", 4}, {"high-2", 3}, {"unknown", 0},
+	} {
+		if !q.Push(ctx, p.item, p.priority) {
+			t.Fatalf("Push(%q) = false", p.item)
+		}
+	}
+
+	var got []string
+	for q.Len() > 0 {
+		item, ok := q.Pop()
+		if !ok {
+			t.Fatal("Pop() ok = false with items queued")
+		}
+		got = append(got, item)
+	}
+	want := []string{"critical", "high-1", "high-2", "low-1", "low-2", "unknown"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("pop order = %v, want %v", got, want)
+	}
+}
+
+func TestQueue_PushBlocksWhileFull(t *testing.T) {
+	q := New[int](1)
+	q.Push(context.Background(), 1, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if q.Push(ctx, 2, 4) {
+		t.Fatal("Push() = true on a full queue, want it to block until ctx is done")
+	}
+
+	// Popping frees a slot for a blocked Push
+	done := make(chan bool)
+	go func() { done <- q.Push(context.Background(), 3, 0) }()
+	if item, _ := q.Pop(); item != 1 {
+		t.Errorf("Pop() = %d, want 1", item)
+	}
+	if !<-done {
+		t.Error("Push() = false after a slot was freed")
+	}
+}
+
+func TestQueue_CloseDrains(t *testing.T) {
+	q := New[int](4)
+	q.Push(context.Background(), 1, 0)
+
+	popped := make(chan bool)
+	go func() {
+		_, ok := q.Pop() // the queued item
+		_, ok2 := q.Pop()
+		popped <- ok && !ok2
+	}()
+	q.Close()
+	if !<-popped {
+		t.Error("Pop() after Close() should return the queued item, then ok = false")
+	}
+	if q.Push(context.Background(), 2, 0) {
+		t.Error("Push() after Close() = true, want false")
+	}
+}
+
+func TestQueue_SustainedHighPriorityDoesNotStarve(t *testing.T) {
+	const capacity = 4
+	q := New[string](capacity)
+	ctx := context.Background()
+	q.Push(ctx, "low", 1)
+	for i := 1; i < capacity; i++ {
+		q.Push(ctx, "high", 3)
+	}
+
+	// Every handed out item is replaced by another high-priority one, as under steady traffic
+	for pops := 1; pops <= capacity*starvationFactor+1; pops++ {
+		item, _ := q.Pop()
+		if item == "low" {
+			if pops <= capacity {
+				t.Fatalf("low item handed out at pop %d, before the high-priority ones", pops)
+			}
+			return
+		}
+		q.Push(ctx, "high", 3)
+	}
+	t.Fatalf("low item not handed out within %d pops", capacity*starvationFactor+1)
+}
@@ -355,9 +355,10 @@ module "metrics_service" {
   enable_service_discovery = false
 
   environment_variables = {
-    HTTP_PORT    = "8083"
-    POSTGRES_DSN = "postgres://${var.db_username}:${var.db_password}@${module.rds.endpoint}/${var.db_name}?sslmode=require"
-    REDIS_ADDR   = module.redis.endpoint
+    HTTP_PORT     = "8083"
+    POSTGRES_DSN  = "postgres://${var.db_username}:${var.db_password}@${module.rds.endpoint}/${var.db_name}?sslmode=require"
+    REDIS_ADDR    = module.redis.endpoint
+    KAFKA_BROKERS = module.kafka.kafka_endpoint
   }
 
   load_balancer_enabled = false